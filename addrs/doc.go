@@ -0,0 +1,13 @@
+// Package addrs contains types that represent "addresses", which are
+// references to specific objects within a Terraform configuration or
+// state.
+//
+// All addresses have string representations based on the syntax used to
+// write them on the command line, as for -target, and in the "moved" blocks
+// and state commands, and this package is where those strings are parsed,
+// so that every subsystem that accepts them agrees on what they mean.
+//
+// Addresses are either relative to a module, like a Resource, or absolute,
+// like an AbsResource, which adds the path of the module instance that the
+// object belongs to.
+package addrs
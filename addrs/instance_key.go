@@ -0,0 +1,64 @@
+package addrs
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// InstanceKey represents the key of an instance within an object that
+// contains multiple instances due to using "count" on a resource or
+// module call.
+//
+// The only valid InstanceKey implementations are IntKey and StringKey, or
+// NoKey for an object that isn't repeated.
+type InstanceKey interface {
+	instanceKeySigil()
+	String() string
+}
+
+// NoKey represents the absense of an InstanceKey, for the single instance
+// of a configuration object that does not use "count".
+var NoKey InstanceKey
+
+// IntKey is the InstanceKey representation of an integer index, as used
+// for the instances of objects that use "count".
+type IntKey int
+
+func (k IntKey) instanceKeySigil() {
+}
+
+func (k IntKey) String() string {
+	return fmt.Sprintf("[%d]", int(k))
+}
+
+// StringKey is the InstanceKey representation of a string key, as used in
+// the addresses of the instances of objects that are keyed by name.
+type StringKey string
+
+func (k StringKey) instanceKeySigil() {
+}
+
+func (k StringKey) String() string {
+	return fmt.Sprintf("[%s]", strconv.Quote(string(k)))
+}
+
+// instanceKeyLess returns true if the key a sorts before the key b. NoKey
+// sorts before all other keys, and integer keys before string keys.
+func instanceKeyLess(a, b InstanceKey) bool {
+	switch a := a.(type) {
+	case nil:
+		return b != nil
+	case IntKey:
+		switch b := b.(type) {
+		case IntKey:
+			return a < b
+		case StringKey:
+			return true
+		}
+	case StringKey:
+		if b, ok := b.(StringKey); ok {
+			return a < b
+		}
+	}
+	return false
+}
@@ -0,0 +1,189 @@
+package addrs
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// ModuleInstance is an address for a particular module instance within the
+// dynamic module tree. This is an extension of the static traversals in
+// module paths that includes the instance keys of the module calls that
+// use "count".
+//
+// The root module instance is represented by a zero-length ModuleInstance.
+type ModuleInstance []ModuleInstanceStep
+
+// RootModuleInstance is the module instance address representing the root
+// module.
+var RootModuleInstance ModuleInstance
+
+// ModuleInstanceStep is a single traversal step through the dynamic module
+// tree: the name of a module call, and the key of one of its instances, if
+// it has several.
+type ModuleInstanceStep struct {
+	Name        string
+	InstanceKey InstanceKey
+}
+
+// IsRoot returns true if the receiver is the address of the root module
+// instance.
+func (m ModuleInstance) IsRoot() bool {
+	return len(m) == 0
+}
+
+// Child returns the address of the child module instance of the receiver
+// that has the given call name and instance key.
+func (m ModuleInstance) Child(name string, key InstanceKey) ModuleInstance {
+	ret := make(ModuleInstance, 0, len(m)+1)
+	ret = append(ret, m...)
+	return append(ret, ModuleInstanceStep{
+		Name:        name,
+		InstanceKey: key,
+	})
+}
+
+// Parent returns the address of the module instance that contains the
+// receiver, or the root module instance if the receiver is the root.
+func (m ModuleInstance) Parent() ModuleInstance {
+	if len(m) == 0 {
+		return m
+	}
+	return m[:len(m)-1]
+}
+
+// Equal returns true if the receiver and the other address are the same
+// module instance, with the same instance keys.
+func (m ModuleInstance) Equal(other ModuleInstance) bool {
+	if len(m) != len(other) {
+		return false
+	}
+	for i := range m {
+		if m[i] != other[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Less returns true if the receiver sorts before the other address, which
+// is the order the module tree is walked in, with each module instance
+// before its children.
+func (m ModuleInstance) Less(other ModuleInstance) bool {
+	for i := range m {
+		if i >= len(other) {
+			return false
+		}
+		a, b := m[i], other[i]
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		if a.InstanceKey != b.InstanceKey {
+			return instanceKeyLess(a.InstanceKey, b.InstanceKey)
+		}
+	}
+	return len(m) < len(other)
+}
+
+// String returns a string representation of the receiver, in the form
+// "module.foo[0].module.bar", which is "" for the root module.
+func (m ModuleInstance) String() string {
+	var buf bytes.Buffer
+	for i, step := range m {
+		if i > 0 {
+			buf.WriteByte('.')
+		}
+		buf.WriteString("module.")
+		buf.WriteString(step.String())
+	}
+	return buf.String()
+}
+
+// String returns the step as written in a module path, with its instance
+// key in brackets if it has one, like "foo[0]".
+func (s ModuleInstanceStep) String() string {
+	if s.InstanceKey == NoKey {
+		return s.Name
+	}
+	return s.Name + s.InstanceKey.String()
+}
+
+// LegacyPath returns the module path in the form used by the module tree
+// and the state, which is a step of the form "foo" or "foo[0]" for each
+// module call, without the root module.
+func (m ModuleInstance) LegacyPath() []string {
+	ret := make([]string, len(m))
+	for i, step := range m {
+		ret[i] = step.String()
+	}
+	return ret
+}
+
+// ModuleInstanceFromLegacyPath is the inverse of LegacyPath, returning the
+// module instance address for the given module path.
+func ModuleInstanceFromLegacyPath(path []string) (ModuleInstance, error) {
+	ret := make(ModuleInstance, 0, len(path))
+	for _, raw := range path {
+		p := &parser{src: raw}
+		step, err := p.step()
+		if err == nil && !p.done() {
+			err = p.errorf("unexpected %q", p.src[p.pos:])
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid module path step %q: %s", raw, err)
+		}
+		ret = append(ret, ModuleInstanceStep{
+			Name:        step.Name,
+			InstanceKey: step.Key,
+		})
+	}
+	return ret, nil
+}
+
+// TargetContains implements Targetable. A module instance contains itself,
+// its descendent module instances and all of the resources within them.
+//
+// A step without an instance key contains every instance of the same
+// module call, so that "module.foo" contains "module.foo[1]".
+func (m ModuleInstance) TargetContains(other Targetable) bool {
+	switch to := other.(type) {
+	case ModuleInstance:
+		if len(to) < len(m) {
+			return false
+		}
+		for i := range m {
+			if !m[i].contains(to[i]) {
+				return false
+			}
+		}
+		return true
+	case AbsResource:
+		return m.TargetContains(to.Module)
+	case AbsResourceInstance:
+		return m.TargetContains(to.Module)
+	default:
+		return false
+	}
+}
+
+// contains returns true if the step contains the other, either because
+// they're the same or because the step has no key and so addresses every
+// instance of the call.
+func (s ModuleInstanceStep) contains(other ModuleInstanceStep) bool {
+	if s.Name != other.Name {
+		return false
+	}
+	return s.InstanceKey == NoKey || s.InstanceKey == other.InstanceKey
+}
+
+func (m ModuleInstance) targetableSigil() {
+}
+
+// UniqueKey implements UniqueKeyer.
+func (m ModuleInstance) UniqueKey() UniqueKey {
+	return moduleInstanceKey(m.String())
+}
+
+type moduleInstanceKey string
+
+func (k moduleInstanceKey) uniqueKeySigil() {
+}
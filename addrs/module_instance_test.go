@@ -0,0 +1,76 @@
+package addrs
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestModuleInstanceLegacyPath(t *testing.T) {
+	path := []string{"foo[1]", "bar"}
+	m, err := ModuleInstanceFromLegacyPath(path)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if want := RootModuleInstance.Child("foo", IntKey(1)).Child("bar", NoKey); !m.Equal(want) {
+		t.Fatalf("wrong result %s; want %s", m, want)
+	}
+	if got := m.LegacyPath(); !reflect.DeepEqual(got, path) {
+		t.Fatalf("wrong path %#v; want %#v", got, path)
+	}
+
+	for _, bad := range []string{"", "foo[", "foo[-1]", "foo.bar", "foo[0]bar"} {
+		if _, err := ModuleInstanceFromLegacyPath([]string{bad}); err == nil {
+			t.Fatalf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestModuleInstanceParent(t *testing.T) {
+	m := RootModuleInstance.Child("foo", IntKey(0)).Child("bar", NoKey)
+	if got, want := m.Parent().String(), "module.foo[0]"; got != want {
+		t.Fatalf("wrong parent %q; want %q", got, want)
+	}
+	if !RootModuleInstance.Parent().IsRoot() {
+		t.Fatal("parent of root is not root")
+	}
+}
+
+func TestModuleInstanceLess(t *testing.T) {
+	var got []ModuleInstance
+	for _, s := range []string{
+		"module.b",
+		"module.a[1]",
+		"module.a[0].module.c",
+		"",
+		`module.a["x"]`,
+		"module.a[0]",
+		"module.a",
+	} {
+		m, err := ParseModuleInstanceStr(s)
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		got = append(got, m)
+	}
+	sort.Slice(got, func(i, j int) bool {
+		return got[i].Less(got[j])
+	})
+
+	var strs []string
+	for _, m := range got {
+		strs = append(strs, m.String())
+	}
+	want := []string{
+		"",
+		"module.a",
+		"module.a[0]",
+		"module.a[0].module.c",
+		"module.a[1]",
+		`module.a["x"]`,
+		"module.b",
+	}
+	if !reflect.DeepEqual(strs, want) {
+		t.Fatalf("wrong order\ngot:  %#v\nwant: %#v", strs, want)
+	}
+}
@@ -0,0 +1,277 @@
+package addrs
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// ParseModuleInstanceStr parses the address of a module instance, such as
+// "module.foo[0].module.bar". The empty string is the root module instance.
+func ParseModuleInstanceStr(s string) (ModuleInstance, error) {
+	if s == "" {
+		return RootModuleInstance, nil
+	}
+
+	steps, err := parseSteps(s)
+	if err != nil {
+		return nil, err
+	}
+	module, rest, err := moduleInstanceSteps(s, steps)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, fmt.Errorf("invalid module instance address %q: unexpected %q", s, rest[0].Name)
+	}
+	return module, nil
+}
+
+// ParseResourceStr parses the address of a resource relative to its
+// module, such as "aws_instance.foo" or "data.aws_ami.foo".
+func ParseResourceStr(s string) (Resource, error) {
+	ri, err := ParseResourceInstanceStr(s)
+	if err != nil {
+		return Resource{}, err
+	}
+	if ri.Key != NoKey {
+		return Resource{}, fmt.Errorf(
+			"invalid resource address %q: resource instance key not allowed", s)
+	}
+	return ri.Resource, nil
+}
+
+// ParseResourceInstanceStr parses the address of a resource instance
+// relative to its module, such as "aws_instance.foo[1]". The key is NoKey
+// if the address has none.
+func ParseResourceInstanceStr(s string) (ResourceInstance, error) {
+	steps, err := parseSteps(s)
+	if err != nil {
+		return ResourceInstance{}, err
+	}
+	return resourceInstanceSteps(s, steps)
+}
+
+// ParseAbsResourceStr parses the absolute address of a resource, such as
+// "module.foo[0].aws_instance.bar".
+func ParseAbsResourceStr(s string) (AbsResource, error) {
+	ri, err := ParseAbsResourceInstanceStr(s)
+	if err != nil {
+		return AbsResource{}, err
+	}
+	if ri.Resource.Key != NoKey {
+		return AbsResource{}, fmt.Errorf(
+			"invalid resource address %q: resource instance key not allowed", s)
+	}
+	return ri.ContainingResource(), nil
+}
+
+// ParseAbsResourceInstanceStr parses the absolute address of a resource
+// instance, such as "module.foo[0].aws_instance.bar[1]".
+func ParseAbsResourceInstanceStr(s string) (AbsResourceInstance, error) {
+	steps, err := parseSteps(s)
+	if err != nil {
+		return AbsResourceInstance{}, err
+	}
+	module, rest, err := moduleInstanceSteps(s, steps)
+	if err != nil {
+		return AbsResourceInstance{}, err
+	}
+	ri, err := resourceInstanceSteps(s, rest)
+	if err != nil {
+		return AbsResourceInstance{}, err
+	}
+	return ri.Absolute(module), nil
+}
+
+// ParseTarget parses an address that can be used as a target, as with the
+// -target option, which is either a module instance, a resource or a
+// resource instance. The result is a ModuleInstance, an AbsResource or an
+// AbsResourceInstance respectively.
+func ParseTarget(s string) (Targetable, error) {
+	steps, err := parseSteps(s)
+	if err != nil {
+		return nil, err
+	}
+	module, rest, err := moduleInstanceSteps(s, steps)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) == 0 {
+		return module, nil
+	}
+
+	ri, err := resourceInstanceSteps(s, rest)
+	if err != nil {
+		return nil, err
+	}
+	if ri.Key == NoKey {
+		return ri.Resource.Absolute(module), nil
+	}
+	return ri.Absolute(module), nil
+}
+
+// moduleInstanceSteps returns the module instance that the given steps of
+// the address s start with, and the steps that follow it.
+func moduleInstanceSteps(s string, steps []parsedStep) (ModuleInstance, []parsedStep, error) {
+	var module ModuleInstance
+	for len(steps) > 0 && steps[0].Name == "module" {
+		if steps[0].Key != NoKey {
+			return nil, nil, fmt.Errorf(
+				"invalid address %q: \"module\" must be followed by a module name", s)
+		}
+		if len(steps) < 2 {
+			return nil, nil, fmt.Errorf("invalid address %q: module name required", s)
+		}
+		module = module.Child(steps[1].Name, steps[1].Key)
+		steps = steps[2:]
+	}
+	return module, steps, nil
+}
+
+// resourceInstanceSteps returns the resource instance that the given steps
+// of the address s are the address of.
+func resourceInstanceSteps(s string, steps []parsedStep) (ResourceInstance, error) {
+	mode := ManagedResourceMode
+	if len(steps) > 0 && steps[0].Name == "data" && steps[0].Key == NoKey {
+		mode = DataResourceMode
+		steps = steps[1:]
+	}
+
+	switch {
+	case len(steps) < 2:
+		return ResourceInstance{}, fmt.Errorf(
+			"invalid resource address %q: resource type and name required", s)
+	case len(steps) > 2:
+		return ResourceInstance{}, fmt.Errorf(
+			"invalid resource address %q: unexpected %q after resource name", s, steps[2].Name)
+	case steps[0].Key != NoKey:
+		return ResourceInstance{}, fmt.Errorf(
+			"invalid resource address %q: resource type must not have an instance key", s)
+	case mode == ManagedResourceMode && steps[0].Name == "module":
+		return ResourceInstance{}, fmt.Errorf(
+			"invalid resource address %q: module name required", s)
+	}
+
+	return Resource{
+		Mode: mode,
+		Type: steps[0].Name,
+		Name: steps[1].Name,
+	}.Instance(steps[1].Key), nil
+}
+
+// parsedStep is one of the dot-separated steps of an address, which is a
+// name optionally followed by an instance key.
+type parsedStep struct {
+	Name string
+	Key  InstanceKey
+}
+
+// parseSteps splits the address s into its steps.
+func parseSteps(s string) ([]parsedStep, error) {
+	p := &parser{src: s}
+	var steps []parsedStep
+	for {
+		step, err := p.step()
+		if err != nil {
+			return nil, fmt.Errorf("invalid address %q: %s", s, err)
+		}
+		steps = append(steps, step)
+
+		if p.done() {
+			return steps, nil
+		}
+		if p.src[p.pos] != '.' {
+			return nil, fmt.Errorf("invalid address %q: %s", s, p.errorf("expected \".\""))
+		}
+		p.pos++
+	}
+}
+
+// parser scans the steps of an address.
+type parser struct {
+	src string
+	pos int
+}
+
+func (p *parser) done() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%s at column %d", fmt.Sprintf(format, args...), p.pos+1)
+}
+
+// step scans a name and its instance key, if it has one.
+func (p *parser) step() (parsedStep, error) {
+	start := p.pos
+	for !p.done() && isNameChar(p.src[p.pos], p.pos == start) {
+		p.pos++
+	}
+	if p.pos == start {
+		return parsedStep{}, p.errorf("name expected")
+	}
+	step := parsedStep{Name: p.src[start:p.pos]}
+
+	if p.done() || p.src[p.pos] != '[' {
+		return step, nil
+	}
+	p.pos++
+	key, err := p.key()
+	if err != nil {
+		return parsedStep{}, err
+	}
+	if p.done() || p.src[p.pos] != ']' {
+		return parsedStep{}, p.errorf("expected \"]\"")
+	}
+	p.pos++
+	step.Key = key
+	return step, nil
+}
+
+// key scans an instance key, which is a non-negative integer or a quoted
+// string.
+func (p *parser) key() (InstanceKey, error) {
+	start := p.pos
+	if !p.done() && p.src[p.pos] == '"' {
+		p.pos++
+		for !p.done() && p.src[p.pos] != '"' {
+			if p.src[p.pos] == '\\' {
+				p.pos++
+			}
+			p.pos++
+		}
+		if p.done() {
+			return nil, p.errorf("unterminated string key")
+		}
+		p.pos++
+		str, err := strconv.Unquote(p.src[start:p.pos])
+		if err != nil {
+			return nil, p.errorf("invalid string key %s", p.src[start:p.pos])
+		}
+		return StringKey(str), nil
+	}
+
+	for !p.done() && p.src[p.pos] >= '0' && p.src[p.pos] <= '9' {
+		p.pos++
+	}
+	if p.pos == start {
+		return nil, p.errorf("instance key must be a non-negative integer or a string")
+	}
+	idx, err := strconv.Atoi(p.src[start:p.pos])
+	if err != nil {
+		return nil, p.errorf("invalid instance key %s", p.src[start:p.pos])
+	}
+	return IntKey(idx), nil
+}
+
+// isNameChar returns true if c can be in a name, matching the names that
+// the configuration allows, which can't start with a dash.
+func isNameChar(c byte, first bool) bool {
+	switch {
+	case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '_':
+		return true
+	case c == '-':
+		return !first
+	}
+	return false
+}
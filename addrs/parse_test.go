@@ -0,0 +1,178 @@
+package addrs
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTarget(t *testing.T) {
+	cases := []struct {
+		Input string
+		Want  Targetable
+		Err   bool
+	}{
+		{
+			"module.foo",
+			RootModuleInstance.Child("foo", NoKey),
+			false,
+		},
+		{
+			"module.foo[2].module.bar",
+			RootModuleInstance.Child("foo", IntKey(2)).Child("bar", NoKey),
+			false,
+		},
+		{
+			`module.foo["a.b"]`,
+			RootModuleInstance.Child("foo", StringKey("a.b")),
+			false,
+		},
+		{
+			"aws_instance.foo",
+			Resource{ManagedResourceMode, "aws_instance", "foo"}.Absolute(RootModuleInstance),
+			false,
+		},
+		{
+			"aws_instance.foo[1]",
+			Resource{ManagedResourceMode, "aws_instance", "foo"}.Instance(IntKey(1)).Absolute(RootModuleInstance),
+			false,
+		},
+		{
+			"data.aws_ami.foo",
+			Resource{DataResourceMode, "aws_ami", "foo"}.Absolute(RootModuleInstance),
+			false,
+		},
+		{
+			"module.foo[0].data.aws_ami.foo[1]",
+			Resource{DataResourceMode, "aws_ami", "foo"}.Instance(IntKey(1)).Absolute(
+				RootModuleInstance.Child("foo", IntKey(0))),
+			false,
+		},
+		{
+			"module.foo.module.bar.aws_instance.baz",
+			Resource{ManagedResourceMode, "aws_instance", "baz"}.Absolute(
+				RootModuleInstance.Child("foo", NoKey).Child("bar", NoKey)),
+			false,
+		},
+		{"", nil, true},
+		{"module", nil, true},
+		{"module[0].foo", nil, true},
+		{"aws_instance", nil, true},
+		{"data.aws_ami", nil, true},
+		{"aws_instance[0].foo", nil, true},
+		{"aws_instance.foo.bar", nil, true},
+		{"aws_instance.foo[", nil, true},
+		{"aws_instance.foo[-1]", nil, true},
+		{"aws_instance.foo[a]", nil, true},
+		{`aws_instance.foo["a]`, nil, true},
+		{"aws_instance..foo", nil, true},
+		{"aws_instance.foo[0]bar", nil, true},
+		{"aws_instance.${var.a}", nil, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Input, func(t *testing.T) {
+			got, err := ParseTarget(tc.Input)
+			if (err != nil) != tc.Err {
+				t.Fatalf("wrong error: %v", err)
+			}
+			if tc.Err {
+				return
+			}
+			if !reflect.DeepEqual(got, tc.Want) {
+				t.Fatalf("wrong result\ngot:  %#v\nwant: %#v", got, tc.Want)
+			}
+			if got.String() != tc.Input {
+				t.Fatalf("wrong string %q; want %q", got.String(), tc.Input)
+			}
+		})
+	}
+}
+
+func TestParseResourceInstanceStr(t *testing.T) {
+	cases := map[string]struct {
+		Want ResourceInstance
+		Err  bool
+	}{
+		"aws_instance.foo": {
+			Resource{ManagedResourceMode, "aws_instance", "foo"}.Instance(NoKey),
+			false,
+		},
+		`aws_instance.foo["bar"]`: {
+			Resource{ManagedResourceMode, "aws_instance", "foo"}.Instance(StringKey("bar")),
+			false,
+		},
+		"data.aws_ami.foo[3]": {
+			Resource{DataResourceMode, "aws_ami", "foo"}.Instance(IntKey(3)),
+			false,
+		},
+		"module.foo":                  {Err: true},
+		"module.foo.aws_instance.bar": {Err: true},
+	}
+
+	for input, tc := range cases {
+		t.Run(input, func(t *testing.T) {
+			got, err := ParseResourceInstanceStr(input)
+			if (err != nil) != tc.Err {
+				t.Fatalf("wrong error: %v", err)
+			}
+			if got != tc.Want {
+				t.Fatalf("wrong result\ngot:  %#v\nwant: %#v", got, tc.Want)
+			}
+		})
+	}
+}
+
+func TestParseResourceStr(t *testing.T) {
+	got, err := ParseResourceStr("aws_instance.foo")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if want := (Resource{ManagedResourceMode, "aws_instance", "foo"}); got != want {
+		t.Fatalf("wrong result\ngot:  %#v\nwant: %#v", got, want)
+	}
+
+	if _, err := ParseResourceStr("aws_instance.foo[0]"); err == nil {
+		t.Fatal("expected error for resource instance")
+	}
+}
+
+func TestParseAbsResourceInstanceStr(t *testing.T) {
+	got, err := ParseAbsResourceInstanceStr("module.foo[1].aws_instance.bar[2]")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	want := Resource{ManagedResourceMode, "aws_instance", "bar"}.Instance(IntKey(2)).Absolute(
+		RootModuleInstance.Child("foo", IntKey(1)))
+	if !got.Equal(want) {
+		t.Fatalf("wrong result\ngot:  %#v\nwant: %#v", got, want)
+	}
+
+	if _, err := ParseAbsResourceInstanceStr("module.foo"); err == nil {
+		t.Fatal("expected error for module address")
+	}
+	if _, err := ParseAbsResourceStr("module.foo.aws_instance.bar[0]"); err == nil {
+		t.Fatal("expected error for resource instance")
+	}
+}
+
+func TestParseModuleInstanceStr(t *testing.T) {
+	got, err := ParseModuleInstanceStr("")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !got.IsRoot() {
+		t.Fatalf("expected root module, got %s", got)
+	}
+
+	got, err = ParseModuleInstanceStr("module.foo[0].module.bar")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if want := RootModuleInstance.Child("foo", IntKey(0)).Child("bar", NoKey); !got.Equal(want) {
+		t.Fatalf("wrong result %s; want %s", got, want)
+	}
+
+	if _, err := ParseModuleInstanceStr("module.foo.aws_instance.bar"); err == nil {
+		t.Fatal("expected error for resource address")
+	}
+}
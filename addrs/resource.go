@@ -0,0 +1,238 @@
+package addrs
+
+import (
+	"fmt"
+)
+
+// ResourceMode defines which lifecycle applies to a given resource. Each
+// resource lifecycle has a slightly different address format.
+type ResourceMode rune
+
+const (
+	// InvalidResourceMode is the zero value of ResourceMode and is not
+	// a valid resource mode.
+	InvalidResourceMode ResourceMode = 0
+
+	// ManagedResourceMode indicates a managed resource, as defined by
+	// "resource" blocks in configuration.
+	ManagedResourceMode ResourceMode = 'M'
+
+	// DataResourceMode indicates a data resource, as defined by
+	// "data" blocks in configuration.
+	DataResourceMode ResourceMode = 'D'
+)
+
+func (m ResourceMode) String() string {
+	switch m {
+	case ManagedResourceMode:
+		return "ManagedResourceMode"
+	case DataResourceMode:
+		return "DataResourceMode"
+	default:
+		return "InvalidResourceMode"
+	}
+}
+
+// Resource is an address for a resource block within configuration, which
+// contains potentially-multiple resource instances if that configuration
+// block uses "count".
+type Resource struct {
+	Mode ResourceMode
+	Type string
+	Name string
+}
+
+func (r Resource) String() string {
+	switch r.Mode {
+	case ManagedResourceMode:
+		return fmt.Sprintf("%s.%s", r.Type, r.Name)
+	case DataResourceMode:
+		return fmt.Sprintf("data.%s.%s", r.Type, r.Name)
+	default:
+		// Should never happen, but we'll return a string here rather than
+		// crashing just in case it does.
+		return fmt.Sprintf("<invalid>.%s.%s", r.Type, r.Name)
+	}
+}
+
+// Equal returns true if the receiver and the other address are the same
+// resource.
+func (r Resource) Equal(other Resource) bool {
+	return r == other
+}
+
+// Instance produces the address for a specific instance of the receiver
+// that is idenfied by the given key.
+func (r Resource) Instance(key InstanceKey) ResourceInstance {
+	return ResourceInstance{
+		Resource: r,
+		Key:      key,
+	}
+}
+
+// Absolute returns an AbsResource from the receiver and the given module
+// instance address.
+func (r Resource) Absolute(module ModuleInstance) AbsResource {
+	return AbsResource{
+		Module:   module,
+		Resource: r,
+	}
+}
+
+// ResourceInstance is an address for a specific instance of a resource.
+// When a resource is defined in configuration with "count", the resource
+// instances are distinguished by their keys, and otherwise the single
+// instance has NoKey.
+type ResourceInstance struct {
+	Resource Resource
+	Key      InstanceKey
+}
+
+func (r ResourceInstance) String() string {
+	if r.Key == NoKey {
+		return r.Resource.String()
+	}
+	return r.Resource.String() + r.Key.String()
+}
+
+// Equal returns true if the receiver and the other address are the same
+// resource instance.
+func (r ResourceInstance) Equal(other ResourceInstance) bool {
+	return r == other
+}
+
+// Absolute returns an AbsResourceInstance from the receiver and the given
+// module instance address.
+func (r ResourceInstance) Absolute(module ModuleInstance) AbsResourceInstance {
+	return AbsResourceInstance{
+		Module:   module,
+		Resource: r,
+	}
+}
+
+// AbsResource is an absolute address for a resource under a given module
+// instance path.
+type AbsResource struct {
+	Module   ModuleInstance
+	Resource Resource
+}
+
+// Instance produces the address for a specific instance of the receiver
+// that is idenfied by the given key.
+func (r AbsResource) Instance(key InstanceKey) AbsResourceInstance {
+	return AbsResourceInstance{
+		Module:   r.Module,
+		Resource: r.Resource.Instance(key),
+	}
+}
+
+// TargetContains implements Targetable. A resource contains itself and all
+// of its instances.
+func (r AbsResource) TargetContains(other Targetable) bool {
+	switch to := other.(type) {
+	case AbsResource:
+		return r.Module.TargetContains(to.Module) && len(r.Module) == len(to.Module) &&
+			r.Resource.Equal(to.Resource)
+	case AbsResourceInstance:
+		return r.TargetContains(to.ContainingResource())
+	default:
+		return false
+	}
+}
+
+func (r AbsResource) targetableSigil() {
+}
+
+func (r AbsResource) String() string {
+	if len(r.Module) == 0 {
+		return r.Resource.String()
+	}
+	return fmt.Sprintf("%s.%s", r.Module.String(), r.Resource.String())
+}
+
+// Equal returns true if the receiver and the other address are the same
+// resource in the same module instance.
+func (r AbsResource) Equal(other AbsResource) bool {
+	return r.Module.Equal(other.Module) && r.Resource.Equal(other.Resource)
+}
+
+// UniqueKey implements UniqueKeyer.
+func (r AbsResource) UniqueKey() UniqueKey {
+	return absResourceKey(r.String())
+}
+
+type absResourceKey string
+
+func (k absResourceKey) uniqueKeySigil() {
+}
+
+// AbsResourceInstance is an absolute address for a resource instance under
+// a given module instance path.
+type AbsResourceInstance struct {
+	Module   ModuleInstance
+	Resource ResourceInstance
+}
+
+// ContainingResource returns the address of the resource that the
+// receiver is an instance of.
+func (r AbsResourceInstance) ContainingResource() AbsResource {
+	return AbsResource{
+		Module:   r.Module,
+		Resource: r.Resource.Resource,
+	}
+}
+
+// TargetContains implements Targetable. A resource instance contains only
+// itself.
+func (r AbsResourceInstance) TargetContains(other Targetable) bool {
+	switch to := other.(type) {
+	case AbsResourceInstance:
+		return r.Module.TargetContains(to.Module) && len(r.Module) == len(to.Module) &&
+			r.Resource.Equal(to.Resource)
+	default:
+		return false
+	}
+}
+
+func (r AbsResourceInstance) targetableSigil() {
+}
+
+func (r AbsResourceInstance) String() string {
+	if len(r.Module) == 0 {
+		return r.Resource.String()
+	}
+	return fmt.Sprintf("%s.%s", r.Module.String(), r.Resource.String())
+}
+
+// Equal returns true if the receiver and the other address are the same
+// resource instance in the same module instance.
+func (r AbsResourceInstance) Equal(other AbsResourceInstance) bool {
+	return r.Module.Equal(other.Module) && r.Resource.Equal(other.Resource)
+}
+
+// Less returns true if the receiver should sort before the other address,
+// ordering by module instance, then by resource and then by instance key.
+func (r AbsResourceInstance) Less(other AbsResourceInstance) bool {
+	switch {
+	case !r.Module.Equal(other.Module):
+		return r.Module.Less(other.Module)
+	case r.Resource.Resource.Mode != other.Resource.Resource.Mode:
+		return r.Resource.Resource.Mode == DataResourceMode
+	case r.Resource.Resource.Type != other.Resource.Resource.Type:
+		return r.Resource.Resource.Type < other.Resource.Resource.Type
+	case r.Resource.Resource.Name != other.Resource.Resource.Name:
+		return r.Resource.Resource.Name < other.Resource.Resource.Name
+	default:
+		return instanceKeyLess(r.Resource.Key, other.Resource.Key)
+	}
+}
+
+// UniqueKey implements UniqueKeyer.
+func (r AbsResourceInstance) UniqueKey() UniqueKey {
+	return absResourceInstanceKey(r.String())
+}
+
+type absResourceInstanceKey string
+
+func (k absResourceInstanceKey) uniqueKeySigil() {
+}
@@ -0,0 +1,19 @@
+package addrs
+
+// Targetable is an interface implemented by all address types that can be
+// used as "targets" for selecting sub-graphs of a graph, as with -target.
+type Targetable interface {
+	targetableSigil()
+
+	// TargetContains returns true if the receiver is considered to contain
+	// the given other address. Containment, for the purpose of targeting,
+	// means that if a container address is targeted then all of the
+	// addresses within it are also implicitly targeted.
+	//
+	// A targetable address always contains itself.
+	TargetContains(other Targetable) bool
+
+	// String produces a string representation of the address that could be
+	// parsed as a target address by ParseTarget.
+	String() string
+}
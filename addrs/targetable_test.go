@@ -0,0 +1,78 @@
+package addrs
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestTargetContains(t *testing.T) {
+	cases := []struct {
+		Target, Other string
+		Want          bool
+	}{
+		{"module.foo", "module.foo", true},
+		{"module.foo", "module.foo[1]", true},
+		{"module.foo", "module.foo.module.bar", true},
+		{"module.foo", "module.foo[0].aws_instance.bar", true},
+		{"module.foo", "module.foo.aws_instance.bar[2]", true},
+		{"module.foo[0]", "module.foo[0].aws_instance.bar", true},
+		{"module.foo[0]", "module.foo[1].aws_instance.bar", false},
+		{"module.foo[0]", "module.foo", false},
+		{"module.foo", "module.bar", false},
+		{"module.foo.module.bar", "module.foo", false},
+		{"module.foo", "aws_instance.bar", false},
+
+		{"aws_instance.foo", "aws_instance.foo", true},
+		{"aws_instance.foo", "aws_instance.foo[0]", true},
+		{"aws_instance.foo", "data.aws_instance.foo", false},
+		{"aws_instance.foo", "aws_instance.bar", false},
+		{"aws_instance.foo", "module.baz.aws_instance.foo", false},
+		{"aws_instance.foo", "module.baz", false},
+		{"module.baz.aws_instance.foo", "module.baz[1].aws_instance.foo", true},
+		{"module.baz[1].aws_instance.foo", "module.baz.aws_instance.foo", false},
+
+		{"aws_instance.foo[0]", "aws_instance.foo[0]", true},
+		{"aws_instance.foo[0]", "aws_instance.foo[1]", false},
+		{"aws_instance.foo[0]", "aws_instance.foo", false},
+		{`aws_instance.foo["0"]`, "aws_instance.foo[0]", false},
+		{"module.baz.aws_instance.foo[0]", "module.baz[2].aws_instance.foo[0]", true},
+	}
+
+	for _, tc := range cases {
+		t.Run(fmt.Sprintf("%s contains %s", tc.Target, tc.Other), func(t *testing.T) {
+			target, err := ParseTarget(tc.Target)
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			other, err := ParseTarget(tc.Other)
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			if got := target.TargetContains(other); got != tc.Want {
+				t.Fatalf("wrong result %t; want %t", got, tc.Want)
+			}
+		})
+	}
+}
+
+func TestUniqueKey(t *testing.T) {
+	r := Resource{ManagedResourceMode, "aws_instance", "foo"}
+	keys := map[UniqueKey]string{
+		r.Absolute(RootModuleInstance).UniqueKey():                     "resource",
+		r.Instance(NoKey).Absolute(RootModuleInstance).UniqueKey():     "instance",
+		r.Instance(IntKey(0)).Absolute(RootModuleInstance).UniqueKey(): "instance 0",
+		RootModuleInstance.Child("foo", NoKey).UniqueKey():             "module",
+	}
+	if len(keys) != 4 {
+		t.Fatalf("unique keys collide: %#v", keys)
+	}
+
+	// Equal addresses that are built separately have the same key.
+	other, err := ParseTarget("aws_instance.foo[0]")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if got := keys[other.(UniqueKeyer).UniqueKey()]; got != "instance 0" {
+		t.Fatalf("wrong lookup %q", got)
+	}
+}
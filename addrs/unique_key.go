@@ -0,0 +1,22 @@
+package addrs
+
+// UniqueKey is an interface implemented by values that serve as unique map
+// keys for particular addresses.
+//
+// All implementations of UniqueKey are comparable and can thus be used as
+// map keys. Unique keys generated from different address types are always
+// distinct, even if the addresses have the same string representation, so
+// that, for example, the resource "aws_instance.foo" and its single
+// instance can be kept in the same map.
+type UniqueKey interface {
+	uniqueKeySigil()
+}
+
+// UniqueKeyer is an interface implemented by types that can be represented
+// by a unique key.
+//
+// Two addresses of the same type that are equal have the same unique key,
+// and two that are not equal do not.
+type UniqueKeyer interface {
+	UniqueKey() UniqueKey
+}
@@ -0,0 +1,90 @@
+package backend
+
+import (
+	"strings"
+
+	"github.com/hashicorp/terraform/command/format"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+// Values for ApprovalPolicy.DestroyConfirmation.
+const (
+	// DestroyConfirmationYes approves destroys by answering "yes", like any
+	// other plan. This is the default.
+	DestroyConfirmationYes = "yes"
+
+	// DestroyConfirmationWorkspace approves plans that destroy anything only
+	// by entering the name of the workspace.
+	DestroyConfirmationWorkspace = "workspace"
+)
+
+// ApprovalPolicy decides how the plan of an apply operation is approved,
+// for backends that ask for approval interactively.
+//
+// The policy only applies when approval would otherwise be asked for, so
+// "-auto-approve" and "-force" still skip approval entirely.
+type ApprovalPolicy struct {
+	// AutoApproveAttributes are the attributes that may change without
+	// approval. A plan that only updates these attributes in-place is
+	// approved automatically. Each is an attribute name, such as "tags",
+	// which also covers all of the elements nested within it.
+	AutoApproveAttributes []string
+
+	// DestroyConfirmation is how plans that destroy anything must be
+	// approved, one of the DestroyConfirmation constants. Empty is the
+	// same as DestroyConfirmationYes.
+	DestroyConfirmation string
+}
+
+// AutoApproves returns true if the given plan can be applied without
+// asking for approval. Plans that create, replace or destroy anything are
+// never approved automatically.
+func (p *ApprovalPolicy) AutoApproves(plan *format.Plan) bool {
+	if p == nil || len(p.AutoApproveAttributes) == 0 || plan.Empty() {
+		return false
+	}
+
+	for _, r := range plan.Resources {
+		switch r.Action {
+		case terraform.DiffRefresh:
+			// Reading data sources doesn't change anything.
+			continue
+		case terraform.DiffUpdate:
+		default:
+			return false
+		}
+
+		for _, attr := range r.Attributes {
+			if !p.autoApprovesAttribute(attr.Path) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// ConfirmDestroyWithWorkspace returns true if the given plan must be
+// approved by entering the name of the workspace.
+func (p *ApprovalPolicy) ConfirmDestroyWithWorkspace(plan *format.Plan) bool {
+	if p == nil || p.DestroyConfirmation != DestroyConfirmationWorkspace {
+		return false
+	}
+	return plan.Stats().ToDestroy > 0
+}
+
+func (p *ApprovalPolicy) autoApprovesAttribute(path string) bool {
+	for _, name := range p.AutoApproveAttributes {
+		if path == name || strings.HasPrefix(path, name+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// DiffPager shows the rendered diff of a plan to the user one page at a
+// time, before they're asked to approve it.
+type DiffPager interface {
+	// Page shows the given text, returning once the user is done with it.
+	Page(text string) error
+}
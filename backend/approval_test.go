@@ -0,0 +1,117 @@
+package backend
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform/command/format"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestApprovalPolicy_AutoApproves(t *testing.T) {
+	policy := &ApprovalPolicy{
+		AutoApproveAttributes: []string{"tags"},
+	}
+
+	update := func(paths ...string) *format.InstanceDiff {
+		r := &format.InstanceDiff{Action: terraform.DiffUpdate}
+		for _, path := range paths {
+			r.Attributes = append(r.Attributes, &format.AttributeDiff{
+				Path:   path,
+				Action: terraform.DiffUpdate,
+			})
+		}
+		return r
+	}
+
+	tests := map[string]struct {
+		Policy *ApprovalPolicy
+		Plan   *format.Plan
+		Want   bool
+	}{
+		"no policy": {
+			nil,
+			&format.Plan{Resources: []*format.InstanceDiff{update("tags.Name")}},
+			false,
+		},
+		"empty plan": {
+			policy,
+			&format.Plan{},
+			false,
+		},
+		"only tags": {
+			policy,
+			&format.Plan{Resources: []*format.InstanceDiff{
+				update("tags.%", "tags.Name"),
+				update("tags"),
+			}},
+			true,
+		},
+		"tags and data source reads": {
+			policy,
+			&format.Plan{Resources: []*format.InstanceDiff{
+				update("tags.Name"),
+				{Action: terraform.DiffRefresh},
+			}},
+			true,
+		},
+		"other attributes": {
+			policy,
+			&format.Plan{Resources: []*format.InstanceDiff{
+				update("tags.Name", "ami"),
+			}},
+			false,
+		},
+		"attribute with the same prefix": {
+			policy,
+			&format.Plan{Resources: []*format.InstanceDiff{
+				update("tags_all.Name"),
+			}},
+			false,
+		},
+		"create": {
+			policy,
+			&format.Plan{Resources: []*format.InstanceDiff{
+				update("tags.Name"),
+				{Action: terraform.DiffCreate},
+			}},
+			false,
+		},
+		"replace": {
+			policy,
+			&format.Plan{Resources: []*format.InstanceDiff{
+				{Action: terraform.DiffDestroyCreate, Attributes: update("tags.Name").Attributes},
+			}},
+			false,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := test.Policy.AutoApproves(test.Plan); got != test.Want {
+				t.Fatalf("wrong result %t; want %t", got, test.Want)
+			}
+		})
+	}
+}
+
+func TestApprovalPolicy_ConfirmDestroyWithWorkspace(t *testing.T) {
+	destroyPlan := &format.Plan{Resources: []*format.InstanceDiff{
+		{Action: terraform.DiffDestroy},
+	}}
+	updatePlan := &format.Plan{Resources: []*format.InstanceDiff{
+		{Action: terraform.DiffUpdate},
+	}}
+	policy := &ApprovalPolicy{DestroyConfirmation: DestroyConfirmationWorkspace}
+
+	if !policy.ConfirmDestroyWithWorkspace(destroyPlan) {
+		t.Fatal("destroy plan doesn't need the workspace name")
+	}
+	if policy.ConfirmDestroyWithWorkspace(updatePlan) {
+		t.Fatal("update plan needs the workspace name")
+	}
+
+	policy.DestroyConfirmation = DestroyConfirmationYes
+	if policy.ConfirmDestroyWithWorkspace(destroyPlan) {
+		t.Fatal("destroy plan needs the workspace name with the default confirmation")
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"errors"
 	"time"
 
+	"github.com/hashicorp/terraform/command/views"
 	"github.com/hashicorp/terraform/config/module"
 	"github.com/hashicorp/terraform/state"
 	"github.com/hashicorp/terraform/terraform"
@@ -23,6 +24,11 @@ const DefaultStateName = "default"
 // CLI can detect it and handle it appropriately.
 var ErrNamedStatesNotSupported = errors.New("named states not supported")
 
+// Error value to return from the methods of StateVersioner when the backend
+// doesn't keep versions of its states, such as when it wraps another backend
+// that may or may not.
+var ErrStateVersionsNotSupported = errors.New("state versions not supported")
+
 // Backend is the minimal interface that must be implemented to enable Terraform.
 type Backend interface {
 	// Ask for input and configure the backend. Similar to
@@ -70,6 +76,92 @@ type Enhanced interface {
 	Operation(context.Context, *Operation) (*RunningOperation, error)
 }
 
+// WorkspaceLister is implemented by backends that can describe all their
+// workspaces without reading the state of each one, which is much faster
+// for backends with many workspaces. Use ListWorkspacesWithInfo to list the
+// workspaces of any backend.
+type WorkspaceLister interface {
+	// ListWorkspacesWithInfo returns the same workspaces as States, in the
+	// same order, along with what the backend knows about their states.
+	ListWorkspacesWithInfo() ([]*WorkspaceInfo, error)
+}
+
+// WorkspaceInfo describes a workspace and its state.
+type WorkspaceInfo struct {
+	Name string
+
+	// LastModified is when the state was last written, or the zero time if
+	// it isn't known.
+	LastModified time.Time
+
+	// Serial is the serial of the state, and Size the size in bytes of the
+	// stored state. Either is -1 if it isn't known, and both are 0 if no
+	// state has been written yet.
+	Serial int64
+	Size   int64
+}
+
+// ListWorkspacesWithInfo describes the workspaces of a backend. If the
+// backend isn't a WorkspaceLister, the state of each workspace is read to
+// find its serial, while its size and modification time remain unknown.
+func ListWorkspacesWithInfo(b Backend) ([]*WorkspaceInfo, error) {
+	if l, ok := b.(WorkspaceLister); ok {
+		return l.ListWorkspacesWithInfo()
+	}
+
+	names, err := b.States()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*WorkspaceInfo, 0, len(names))
+	for _, name := range names {
+		s, err := b.State(name)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.RefreshState(); err != nil {
+			return nil, err
+		}
+
+		info := &WorkspaceInfo{Name: name, Serial: -1, Size: -1}
+		if st := s.State(); st != nil {
+			info.Serial = st.Serial
+		} else {
+			info.Serial, info.Size = 0, 0
+		}
+		result = append(result, info)
+	}
+
+	return result, nil
+}
+
+// StateVersioner is implemented by backends that keep the earlier versions
+// of their states, such as in a versioned bucket, so that a state can be
+// rolled back after a bad write.
+type StateVersioner interface {
+	// StateVersions lists the versions of the named state, newest first.
+	StateVersions(name string) ([]*StateVersion, error)
+
+	// StateVersion reads a version of the named state, as listed by
+	// StateVersions.
+	StateVersion(name, id string) (*terraform.State, error)
+}
+
+// StateVersion describes a stored version of a state.
+type StateVersion struct {
+	// ID identifies the version to the backend, such as an S3 version ID.
+	ID string
+
+	// LastModified is when the version was written, and Size the size in
+	// bytes of the stored state.
+	LastModified time.Time
+	Size         int64
+
+	// Current is true for the version that is the state itself.
+	Current bool
+}
+
 // Local implements additional behavior on a Backend that allows local
 // operations in addition to remote operations.
 //
@@ -107,10 +199,11 @@ type Operation struct {
 	//
 	// PlanOutBackend is the backend to store with the plan. This is the
 	// backend that will be used when applying the plan.
-	PlanId         string
-	PlanRefresh    bool   // PlanRefresh will do a refresh before a plan
-	PlanOutPath    string // PlanOutPath is the path to save the plan
-	PlanOutBackend *terraform.BackendState
+	PlanId          string
+	PlanRefresh     bool   // PlanRefresh will do a refresh before a plan
+	PlanRefreshOnly bool   // PlanRefreshOnly plans no changes, only the refresh
+	PlanOutPath     string // PlanOutPath is the path to save the plan
+	PlanOutBackend  *terraform.BackendState
 
 	// Module settings specify the root module to use for operations.
 	Module *module.Tree
@@ -123,6 +216,7 @@ type Operation struct {
 	// behavior of the operation.
 	Destroy      bool
 	Targets      []string
+	Replace      []string
 	Variables    map[string]interface{}
 	AutoApprove  bool
 	DestroyForce bool
@@ -131,6 +225,29 @@ type Operation struct {
 	UIIn  terraform.UIInput
 	UIOut terraform.UIOutput
 
+	// View, if non-nil, reports the progress and results of the operation
+	// instead of the text output that backends render to the CLI.
+	View views.Operation
+
+	// ApprovalPolicy, if non-nil, decides how the plan of an apply is
+	// approved. DiffPager, if non-nil, shows the plan to be approved
+	// instead of printing it.
+	ApprovalPolicy *ApprovalPolicy
+	DiffPager      DiffPager
+
+	// NoStructuralDiff shows changes to string attributes that contain
+	// JSON or YAML as diffs of their text, rather than of the documents.
+	NoStructuralDiff bool
+
+	// ConcisePlan shows only the attributes of a plan that are changing,
+	// collapsing unchanged nested blocks to a count of their elements.
+	ConcisePlan bool
+
+	// EvalTrace, if non-nil, records the references that are resolved
+	// during the operation. Backends that don't run the operation locally
+	// leave it empty.
+	EvalTrace *terraform.EvalTrace
+
 	// If LockState is true, the Operation must Lock any
 	// state.Lockers for its duration, and Unlock when complete.
 	LockState bool
@@ -1,6 +1,7 @@
 package backend
 
 import (
+	"github.com/hashicorp/terraform/svchost/auth"
 	"github.com/hashicorp/terraform/terraform"
 	"github.com/mitchellh/cli"
 	"github.com/mitchellh/colorstring"
@@ -80,4 +81,9 @@ type CLIOpts struct {
 	// some sort of workflow automation tool that abstracts away the
 	// exact commands that are being run.
 	RunningInAutomation bool
+
+	// Credentials are the credentials for Terraform-native services given
+	// by the CLI configuration, including those stored by "terraform login".
+	// It may be nil.
+	Credentials auth.CredentialsSource
 }
@@ -13,6 +13,7 @@ import (
 
 	"github.com/hashicorp/terraform/backend"
 	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/tracing"
 	"github.com/hashicorp/terraform/state"
 	"github.com/hashicorp/terraform/terraform"
 	"github.com/mitchellh/cli"
@@ -157,6 +158,80 @@ func (b *Local) States() ([]string, error) {
 	return envs, nil
 }
 
+// ListWorkspacesWithInfo describes the workspaces from their state files.
+func (b *Local) ListWorkspacesWithInfo() ([]*backend.WorkspaceInfo, error) {
+	// If we have a backend handling state, defer to that.
+	if b.Backend != nil {
+		return backend.ListWorkspacesWithInfo(b.Backend)
+	}
+
+	names, err := b.States()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*backend.WorkspaceInfo, 0, len(names))
+	for _, name := range names {
+		statePath, _, _ := b.StatePaths(name)
+		info, err := localWorkspaceInfo(name, statePath)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, info)
+	}
+
+	return result, nil
+}
+
+func localWorkspaceInfo(name, path string) (*backend.WorkspaceInfo, error) {
+	info := &backend.WorkspaceInfo{Name: name}
+
+	fi, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return info, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	info.LastModified = fi.ModTime()
+	info.Size = fi.Size()
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	s, err := terraform.ReadState(f)
+	switch {
+	case err == terraform.ErrNoState:
+	case err != nil:
+		return nil, fmt.Errorf("Error reading state of workspace %q: %s", name, err)
+	default:
+		info.Serial = s.Serial
+	}
+
+	return info, nil
+}
+
+// StateVersions lists the versions of a state kept by the backend handling
+// state, if it keeps any.
+func (b *Local) StateVersions(name string) ([]*backend.StateVersion, error) {
+	if v, ok := b.Backend.(backend.StateVersioner); ok {
+		return v.StateVersions(name)
+	}
+	return nil, backend.ErrStateVersionsNotSupported
+}
+
+// StateVersion reads a version of a state kept by the backend handling
+// state, if it keeps any.
+func (b *Local) StateVersion(name, id string) (*terraform.State, error) {
+	if v, ok := b.Backend.(backend.StateVersioner); ok {
+		return v.StateVersion(name, id)
+	}
+	return nil, backend.ErrStateVersionsNotSupported
+}
+
 // DeleteState removes a named state.
 // The "default" state cannot be removed.
 func (b *Local) DeleteState(name string) error {
@@ -251,7 +326,16 @@ func (b *Local) Operation(ctx context.Context, op *backend.Operation) (*backend.
 	go func() {
 		defer b.opLock.Unlock()
 		defer runningCtxCancel()
+
+		opName := strings.ToLower(strings.TrimPrefix(op.Type.String(), "OperationType"))
+		span := tracing.Enter("backend "+opName,
+			tracing.String("terraform.backend", "local"),
+			tracing.String("terraform.operation", opName),
+			tracing.String("terraform.workspace", op.Workspace))
+		defer span.End()
+
 		f(ctx, op, runningOp)
+		span.SetError(runningOp.Err)
 	}()
 
 	// Return
@@ -16,6 +16,7 @@ import (
 	"github.com/hashicorp/terraform/config/module"
 	"github.com/hashicorp/terraform/state"
 	"github.com/hashicorp/terraform/terraform"
+	"github.com/mitchellh/cli"
 )
 
 func (b *Local) opApply(
@@ -24,6 +25,14 @@ func (b *Local) opApply(
 	runningOp *backend.RunningOperation) {
 	log.Printf("[INFO] backend/local: starting Apply operation")
 
+	operation := "apply"
+	if op.Destroy {
+		operation = "destroy"
+	}
+	if op.View != nil {
+		op.View.OperationStart(operation)
+	}
+
 	// If we have a nil module at this point, then set it to an empty tree
 	// to avoid any potential crashes.
 	if op.Plan == nil && op.Module == nil && !op.Destroy {
@@ -75,9 +84,18 @@ func (b *Local) opApply(
 
 	// Setup the state
 	runningOp.State = tfCtx.State()
+	if op.Plan != nil {
+		recordPlanForCrash(op.Plan)
+	}
 
 	// If we weren't given a plan, then we refresh/plan
 	if op.Plan == nil {
+		// A refresh-only apply is nothing but the refresh
+		if op.PlanRefreshOnly && !op.PlanRefresh {
+			runningOp.Err = fmt.Errorf(strings.TrimSpace(planErrRefreshOnlyNoRefresh))
+			return
+		}
+
 		// If we're refreshing before apply, perform that
 		if op.PlanRefresh {
 			log.Printf("[INFO] backend/local: apply calling Refresh")
@@ -95,28 +113,109 @@ func (b *Local) opApply(
 			runningOp.Err = errwrap.Wrapf("Error running plan: {{err}}", err)
 			return
 		}
+		recordPlanForCrash(plan)
 
 		dispPlan := format.NewPlan(plan)
+		dispPlan.NoStructuralDiff = op.NoStructuralDiff
+		dispPlan.Concise = op.ConcisePlan
 		trivialPlan := dispPlan.Empty()
+		if plan.RefreshOnly {
+			trivialPlan = len(dispPlan.Drift) == 0
+		}
 		hasUI := op.UIOut != nil && op.UIIn != nil
-		mustConfirm := hasUI && ((op.Destroy && !op.DestroyForce) || (!op.Destroy && !op.AutoApprove && !trivialPlan))
+		needsApproval := (op.Destroy && !op.DestroyForce) || (!op.Destroy && !op.AutoApprove && !trivialPlan)
+
+		// The approval policy may approve plans that only change attributes
+		// that aren't worth reviewing, such as tags.
+		policyApproved := false
+		if needsApproval && !op.Destroy && !plan.RefreshOnly && op.ApprovalPolicy.AutoApproves(dispPlan) {
+			log.Printf("[INFO] backend/local: plan approved by the approval policy")
+			needsApproval = false
+			policyApproved = true
+		}
+		mustConfirm := hasUI && needsApproval
+
+		// A view reports the plan instead of the CLI, but can't ask for
+		// approval.
+		if op.View != nil {
+			if needsApproval {
+				runningOp.Err = fmt.Errorf(strings.TrimSpace(applyErrViewApproval))
+				return
+			}
+			op.View.Drift(dispPlan)
+			op.View.PlannedChanges(dispPlan)
+			if policyApproved {
+				op.View.Log(strings.TrimSpace(applyPolicyApproved))
+			}
+			mustConfirm = false
+		} else if policyApproved && b.CLI != nil {
+			b.renderDrift(b.CLI, dispPlan)
+			b.renderPlan(b.CLI, dispPlan)
+			b.CLI.Output("\n" + strings.TrimSpace(applyPolicyApproved) + "\n")
+		}
+
 		if mustConfirm {
+			// Plans that destroy anything may need the workspace name to
+			// be entered instead of "yes".
+			answer := "yes"
+			accepted := "Only 'yes' will be accepted"
+			if op.ApprovalPolicy.ConfirmDestroyWithWorkspace(dispPlan) {
+				answer = op.Workspace
+				if answer == "" {
+					answer = backend.DefaultStateName
+				}
+				accepted = fmt.Sprintf("Only the name of the workspace, %q, will be accepted", answer)
+			}
+
 			var desc, query string
 			if op.Destroy {
 				// Default destroy message
 				desc = "Terraform will destroy all your managed infrastructure, as shown above.\n" +
-					"There is no undo. Only 'yes' will be accepted to confirm."
+					"There is no undo. " + accepted + " to confirm."
 				query = "Do you really want to destroy?"
+			} else if plan.RefreshOnly {
+				desc = "Terraform will record the changes described above in the state,\n" +
+					"without changing any real objects. " + accepted + " to approve."
+				query = "Would you like to update the Terraform state to reflect these detected changes?"
 			} else {
 				desc = "Terraform will perform the actions described above.\n" +
-					"Only 'yes' will be accepted to approve."
+					accepted + " to approve."
 				query = "Do you want to perform these actions?"
 			}
 
-			if !trivialPlan {
-				// Display the plan of what we are going to apply/destroy.
-				b.renderPlan(dispPlan)
-				b.CLI.Output("")
+			// With a pager, the plan is rendered for the pager instead of
+			// being printed, and only its summary stays on screen.
+			out := b.CLI
+			var pageBuf *bytes.Buffer
+			if op.DiffPager != nil {
+				pageBuf = new(bytes.Buffer)
+				out = &cli.BasicUi{Writer: pageBuf, ErrorWriter: pageBuf}
+			}
+
+			if plan.RefreshOnly {
+				b.renderRefreshOnly(out, dispPlan)
+				out.Output("")
+			} else {
+				b.renderDrift(out, dispPlan)
+				if !trivialPlan {
+					// Display the plan of what we are going to apply/destroy.
+					b.renderPlan(out, dispPlan)
+					b.renderRenames(out, plan.RenameSuggestions())
+					out.Output("")
+				}
+			}
+
+			if pageBuf != nil {
+				if err := op.DiffPager.Page(pageBuf.String()); err != nil {
+					// The plan must be seen to be approved, so fall back
+					// to printing it.
+					log.Printf("[WARN] backend/local: failed to page the plan: %s", err)
+					b.CLI.Output(pageBuf.String())
+				} else if !plan.RefreshOnly && !trivialPlan {
+					b.CLI.Output("")
+					b.renderPlanSummary(b.CLI, dispPlan)
+					b.CLI.Output("")
+				}
 			}
 
 			v, err := op.UIIn.Input(&terraform.InputOpts{
@@ -128,7 +227,7 @@ func (b *Local) opApply(
 				runningOp.Err = errwrap.Wrapf("Error asking for approval: {{err}}", err)
 				return
 			}
-			if v != "yes" {
+			if v != answer {
 				if op.Destroy {
 					runningOp.Err = errors.New("Destroy cancelled.")
 				} else {
@@ -158,7 +257,9 @@ func (b *Local) opApply(
 	err = nil
 	select {
 	case <-ctx.Done():
-		if b.CLI != nil {
+		if op.View != nil {
+			op.View.Log("Stopping apply operation...")
+		} else if b.CLI != nil {
 			b.CLI.Output("stopping apply operation...")
 		}
 
@@ -206,8 +307,17 @@ func (b *Local) opApply(
 		return
 	}
 
+	// A view reports the results instead of the CLI.
+	if op.View != nil {
+		op.View.ChangeSummary(operation, countHook.Added, countHook.Changed, countHook.Removed)
+		return
+	}
+
 	// If we have a UI, output the results
 	if b.CLI != nil {
+		if !op.Destroy && applyState != nil {
+			b.renderChecks(b.CLI, applyState.Checks)
+		}
 		if op.Destroy {
 			b.CLI.Output(b.Colorize().Color(fmt.Sprintf(
 				"[reset][bold][green]\n"+
@@ -281,6 +391,19 @@ If you would like to destroy everything, please run 'terraform destroy' instead
 which does not require any configuration files.
 `
 
+const applyErrViewApproval = `
+Approval is required to apply this plan!
+
+The output of this operation is a stream of events, so Terraform can't ask
+for approval. To apply without asking, use the "-auto-approve" flag, or the
+"-force" flag for destroy.
+`
+
+const applyPolicyApproved = `
+Plan approved automatically: it only changes attributes that
+the approval policy of the CLI configuration allows to change without approval.
+`
+
 const stateWriteBackedUpError = `Failed to persist state to backend.
 
 The error shown above has prevented Terraform from writing the updated state
@@ -237,6 +237,198 @@ func (s failingState) WriteState(state *terraform.State) error {
 	return errors.New("fake failure")
 }
 
+func TestLocal_applyApprovalPolicyAutoApprove(t *testing.T) {
+	b := TestLocal(t)
+	p := TestLocalProvider(t, b, "test")
+	terraform.TestStateFile(t, b.StatePath, testApplyState())
+	b.CLI = new(cli.MockUi)
+
+	p.DiffReturn = &terraform.InstanceDiff{
+		Attributes: map[string]*terraform.ResourceAttrDiff{
+			"tags.Name": &terraform.ResourceAttrDiff{Old: "foo", New: "bar"},
+		},
+	}
+	p.ApplyReturn = &terraform.InstanceState{ID: "bar"}
+
+	mod, modCleanup := module.TestTree(t, "./test-fixtures/apply")
+	defer modCleanup()
+
+	op := testOperationApply()
+	op.Module = mod
+	op.UIOut = b.CLI
+	op.UIIn = &terraform.MockUIInput{
+		InputFn: func(opts *terraform.InputOpts) (string, error) {
+			t.Errorf("asked for approval: %s", opts.Query)
+			return "", nil
+		},
+	}
+	op.ApprovalPolicy = &backend.ApprovalPolicy{
+		AutoApproveAttributes: []string{"tags"},
+	}
+
+	run, err := b.Operation(context.Background(), op)
+	if err != nil {
+		t.Fatalf("bad: %s", err)
+	}
+	<-run.Done()
+	if run.Err != nil {
+		t.Fatalf("err: %s", run.Err)
+	}
+
+	if !p.ApplyCalled {
+		t.Fatal("apply should be called")
+	}
+	output := b.CLI.(*cli.MockUi).OutputWriter.String()
+	if !strings.Contains(output, "Plan approved automatically") {
+		t.Fatalf("missing approval note in output:\n%s", output)
+	}
+}
+
+func TestLocal_applyApprovalPolicyOtherChanges(t *testing.T) {
+	b := TestLocal(t)
+	p := TestLocalProvider(t, b, "test")
+	terraform.TestStateFile(t, b.StatePath, testApplyState())
+	b.CLI = new(cli.MockUi)
+
+	p.DiffReturn = &terraform.InstanceDiff{
+		Attributes: map[string]*terraform.ResourceAttrDiff{
+			"tags.Name": &terraform.ResourceAttrDiff{Old: "foo", New: "bar"},
+			"ami":       &terraform.ResourceAttrDiff{Old: "foo", New: "bar"},
+		},
+	}
+
+	mod, modCleanup := module.TestTree(t, "./test-fixtures/apply")
+	defer modCleanup()
+
+	input := &terraform.MockUIInput{InputReturnString: "no"}
+	op := testOperationApply()
+	op.Module = mod
+	op.UIOut = b.CLI
+	op.UIIn = input
+	op.ApprovalPolicy = &backend.ApprovalPolicy{
+		AutoApproveAttributes: []string{"tags"},
+	}
+
+	run, err := b.Operation(context.Background(), op)
+	if err != nil {
+		t.Fatalf("bad: %s", err)
+	}
+	<-run.Done()
+	if run.Err == nil || run.Err.Error() != "Apply cancelled." {
+		t.Fatalf("wrong error: %v", run.Err)
+	}
+
+	if !input.InputCalled {
+		t.Fatal("approval should be asked for")
+	}
+	if p.ApplyCalled {
+		t.Fatal("apply should not be called")
+	}
+}
+
+func TestLocal_applyDestroyConfirmWorkspace(t *testing.T) {
+	mod, modCleanup := module.TestTree(t, "./test-fixtures/apply")
+	defer modCleanup()
+
+	for _, answer := range []string{"yes", "default"} {
+		t.Run(answer, func(t *testing.T) {
+			b := TestLocal(t)
+			p := TestLocalProvider(t, b, "test")
+			terraform.TestStateFile(t, b.StatePath, testApplyState())
+			b.CLI = new(cli.MockUi)
+
+			input := &terraform.MockUIInput{InputReturnString: answer}
+			op := testOperationApply()
+			op.Module = mod
+			op.Destroy = true
+			op.Workspace = backend.DefaultStateName
+			op.UIOut = b.CLI
+			op.UIIn = input
+			op.ApprovalPolicy = &backend.ApprovalPolicy{
+				DestroyConfirmation: backend.DestroyConfirmationWorkspace,
+			}
+
+			run, err := b.Operation(context.Background(), op)
+			if err != nil {
+				t.Fatalf("bad: %s", err)
+			}
+			<-run.Done()
+
+			if !input.InputCalled {
+				t.Fatal("approval should be asked for")
+			}
+			if !strings.Contains(input.InputOpts.Description, `Only the name of the workspace, "default", will be accepted`) {
+				t.Fatalf("wrong description:\n%s", input.InputOpts.Description)
+			}
+
+			if answer == "yes" {
+				if run.Err == nil || run.Err.Error() != "Destroy cancelled." {
+					t.Fatalf("wrong error: %v", run.Err)
+				}
+				if p.ApplyCalled {
+					t.Fatal("apply should not be called")
+				}
+				return
+			}
+			if run.Err != nil {
+				t.Fatalf("err: %s", run.Err)
+			}
+			checkState(t, b.StateOutPath, `<no state>`)
+		})
+	}
+}
+
+func TestLocal_applyDiffPager(t *testing.T) {
+	b := TestLocal(t)
+	p := TestLocalProvider(t, b, "test")
+	b.CLI = new(cli.MockUi)
+
+	p.ApplyReturn = &terraform.InstanceState{ID: "yes"}
+
+	mod, modCleanup := module.TestTree(t, "./test-fixtures/apply")
+	defer modCleanup()
+
+	pager := &testDiffPager{}
+	op := testOperationApply()
+	op.Module = mod
+	op.UIOut = b.CLI
+	op.UIIn = &terraform.MockUIInput{InputReturnString: "yes"}
+	op.DiffPager = pager
+
+	run, err := b.Operation(context.Background(), op)
+	if err != nil {
+		t.Fatalf("bad: %s", err)
+	}
+	<-run.Done()
+	if run.Err != nil {
+		t.Fatalf("err: %s", run.Err)
+	}
+
+	if !strings.Contains(pager.text, "test_instance.foo") {
+		t.Fatalf("the plan wasn't paged:\n%s", pager.text)
+	}
+	output := b.CLI.(*cli.MockUi).OutputWriter.String()
+	if strings.Contains(output, "test_instance.foo") {
+		t.Fatalf("the plan was printed as well as paged:\n%s", output)
+	}
+	if !strings.Contains(output, "1 to add, 0 to change, 0 to destroy") {
+		t.Fatalf("missing plan summary in output:\n%s", output)
+	}
+	if !p.ApplyCalled {
+		t.Fatal("apply should be called")
+	}
+}
+
+// testDiffPager is a backend.DiffPager that keeps the text it's given.
+type testDiffPager struct {
+	text string
+}
+
+func (p *testDiffPager) Page(text string) error {
+	p.text = text
+	return nil
+}
+
 func testOperationApply() *backend.Operation {
 	return &backend.Operation{
 		Type: backend.OperationTypeApply,
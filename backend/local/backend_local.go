@@ -44,7 +44,10 @@ func (b *Local) context(op *backend.Operation) (*terraform.Context, state.State,
 	opts.Destroy = op.Destroy
 	opts.Module = op.Module
 	opts.Targets = op.Targets
+	opts.Replace = op.Replace
+	opts.RefreshOnly = op.PlanRefreshOnly
 	opts.UIInput = op.UIIn
+	opts.EvalTrace = op.EvalTrace
 	if op.Variables != nil {
 		opts.Variables = op.Variables
 	}
@@ -10,12 +10,17 @@ import (
 
 	"github.com/hashicorp/errwrap"
 	"github.com/hashicorp/go-multierror"
+	hcl2 "github.com/hashicorp/hcl2/hcl"
 	"github.com/hashicorp/terraform/backend"
 	"github.com/hashicorp/terraform/command/clistate"
 	"github.com/hashicorp/terraform/command/format"
 	"github.com/hashicorp/terraform/config/module"
+	"github.com/hashicorp/terraform/helper/crash"
 	"github.com/hashicorp/terraform/state"
 	"github.com/hashicorp/terraform/terraform"
+	"github.com/hashicorp/terraform/tfdiags"
+	"github.com/mitchellh/cli"
+	wordwrap "github.com/mitchellh/go-wordwrap"
 )
 
 func (b *Local) opPlan(
@@ -24,7 +29,11 @@ func (b *Local) opPlan(
 	runningOp *backend.RunningOperation) {
 	log.Printf("[INFO] backend/local: starting Plan operation")
 
-	if b.CLI != nil && op.Plan != nil {
+	if op.View != nil {
+		op.View.OperationStart("plan")
+	}
+
+	if b.CLI != nil && op.View == nil && op.Plan != nil {
 		b.CLI.Output(b.Colorize().Color(
 			"[reset][bold][yellow]" +
 				"The plan command received a saved plan file as input. This command\n" +
@@ -39,6 +48,12 @@ func (b *Local) opPlan(
 		return
 	}
 
+	// A refresh-only plan is nothing but the refresh
+	if op.PlanRefreshOnly && !op.PlanRefresh {
+		runningOp.Err = fmt.Errorf(strings.TrimSpace(planErrRefreshOnlyNoRefresh))
+		return
+	}
+
 	// If we have a nil module at this point, then set it to an empty tree
 	// to avoid any potential crashes.
 	if op.Module == nil {
@@ -87,7 +102,7 @@ func (b *Local) opPlan(
 	if op.PlanRefresh {
 		log.Printf("[INFO] backend/local: plan calling Refresh")
 
-		if b.CLI != nil {
+		if b.CLI != nil && op.View == nil {
 			b.CLI.Output(b.Colorize().Color(strings.TrimSpace(planRefreshing) + "\n"))
 		}
 
@@ -96,7 +111,7 @@ func (b *Local) opPlan(
 			runningOp.Err = errwrap.Wrapf("Error refreshing state: {{err}}", err)
 			return
 		}
-		if b.CLI != nil {
+		if b.CLI != nil && op.View == nil {
 			b.CLI.Output("\n------------------------------------------------------------------------")
 		}
 	}
@@ -113,7 +128,9 @@ func (b *Local) opPlan(
 
 	select {
 	case <-ctx.Done():
-		if b.CLI != nil {
+		if op.View != nil {
+			op.View.Log("Stopping plan operation...")
+		} else if b.CLI != nil {
 			b.CLI.Output("stopping plan operation...")
 		}
 
@@ -129,8 +146,13 @@ func (b *Local) opPlan(
 		runningOp.Err = errwrap.Wrapf("Error running plan: {{err}}", planErr)
 		return
 	}
-	// Record state
+	recordPlanForCrash(plan)
+
+	// Record state. The changes of a refresh-only plan are its drift.
 	runningOp.PlanEmpty = plan.Diff.Empty()
+	if plan.RefreshOnly {
+		runningOp.PlanEmpty = plan.Drift.Empty()
+	}
 
 	// Save the plan to disk
 	if path := op.PlanOutPath; path != "" {
@@ -155,15 +177,38 @@ func (b *Local) opPlan(
 		}
 	}
 
+	// A view reports the plan instead of the CLI.
+	if op.View != nil {
+		dispPlan := format.NewPlan(plan)
+		dispPlan.NoStructuralDiff = op.NoStructuralDiff
+		dispPlan.Concise = op.ConcisePlan
+		op.View.Drift(dispPlan)
+		op.View.PlannedChanges(dispPlan)
+		return
+	}
+
 	// Perform some output tasks if we have a CLI to output to.
 	if b.CLI != nil {
 		dispPlan := format.NewPlan(plan)
-		if dispPlan.Empty() {
-			b.CLI.Output("\n" + b.Colorize().Color(strings.TrimSpace(planNoChanges)))
-			return
-		}
+		dispPlan.NoStructuralDiff = op.NoStructuralDiff
+		dispPlan.Concise = op.ConcisePlan
+		if plan.RefreshOnly {
+			if len(dispPlan.Drift) == 0 {
+				b.CLI.Output("\n" + b.Colorize().Color(strings.TrimSpace(planRefreshOnlyNoChanges)))
+				return
+			}
+			b.renderRefreshOnly(b.CLI, dispPlan)
+		} else {
+			b.renderDrift(b.CLI, dispPlan)
+			b.renderChecks(b.CLI, plan.Checks)
+			b.renderRenames(b.CLI, plan.RenameSuggestions())
+			if dispPlan.Empty() {
+				b.CLI.Output("\n" + b.Colorize().Color(strings.TrimSpace(planNoChanges)))
+				return
+			}
 
-		b.renderPlan(dispPlan)
+			b.renderPlan(b.CLI, dispPlan)
+		}
 
 		// Give the user some next-steps, unless we're running in an automation
 		// tool which is presumed to provide its own UI for further actions.
@@ -186,7 +231,7 @@ func (b *Local) opPlan(
 	}
 }
 
-func (b *Local) renderPlan(dispPlan *format.Plan) {
+func (b *Local) renderPlan(ui cli.Ui, dispPlan *format.Plan) {
 
 	headerBuf := &bytes.Buffer{}
 	fmt.Fprintf(headerBuf, "\n%s\n", strings.TrimSpace(planHeaderIntro))
@@ -207,20 +252,120 @@ func (b *Local) renderPlan(dispPlan *format.Plan) {
 		fmt.Fprintf(headerBuf, "%s read (data resources)\n", format.DiffActionSymbol(terraform.DiffRefresh))
 	}
 
-	b.CLI.Output(b.Colorize().Color(headerBuf.String()))
+	ui.Output(b.Colorize().Color(headerBuf.String()))
 
-	b.CLI.Output("Terraform will perform the following actions:\n")
+	ui.Output("Terraform will perform the following actions:\n")
 
-	b.CLI.Output(dispPlan.Format(b.Colorize()))
+	ui.Output(dispPlan.Format(b.Colorize()))
+
+	b.renderPlanSummary(ui, dispPlan)
+}
 
+// renderPlanSummary displays the number of resources that the plan adds,
+// changes and destroys.
+func (b *Local) renderPlanSummary(ui cli.Ui, dispPlan *format.Plan) {
 	stats := dispPlan.Stats()
-	b.CLI.Output(b.Colorize().Color(fmt.Sprintf(
+	ui.Output(b.Colorize().Color(fmt.Sprintf(
 		"[reset][bold]Plan:[reset] "+
 			"%d to add, %d to change, %d to destroy.",
 		stats.ToAdd, stats.ToChange, stats.ToDestroy,
 	)))
 }
 
+// renderChecks displays a warning for each check whose assertions failed or
+// couldn't be evaluated. Checks never stop an operation, so they're only
+// reported.
+func (b *Local) renderChecks(ui cli.Ui, checks []*terraform.CheckResult) {
+	for _, c := range checks {
+		var summary string
+		switch c.Status {
+		case terraform.CheckFail:
+			summary = fmt.Sprintf("Check %s failed", c.Address)
+		case terraform.CheckError:
+			summary = fmt.Sprintf("Check %s could not be evaluated", c.Address)
+		default:
+			continue
+		}
+
+		var diags tfdiags.Diagnostics
+		diags = diags.Append(&hcl2.Diagnostic{
+			Severity: hcl2.DiagWarning,
+			Summary:  summary,
+			Detail:   strings.Join(c.FailureMessages, "\n\n"),
+		})
+		ui.Warn(format.Diagnostic(diags[0], b.Colorize(), 72))
+	}
+}
+
+// renderRenames displays a warning for each resource that the plan
+// destroys and recreates under a new address, suggesting how to move it
+// instead. The commands are left unwrapped so that they can be copied.
+func (b *Local) renderRenames(ui cli.Ui, renames []*terraform.RenameSuggestion) {
+	for _, r := range renames {
+		module := "the root module"
+		if len(r.From.Path) > 0 {
+			module = "module." + strings.Join(r.From.Path, ".module.")
+		}
+
+		detail := wordwrap.WrapString(fmt.Sprintf(
+			"Terraform will destroy %s and create %s, whose configuration "+
+				"is largely the same (%d%% of its attributes match). If it's the "+
+				"same object under a new address, move it rather than "+
+				"recreating it.",
+			r.From, r.To, int(r.Similarity*100),
+		), 72)
+		if block := r.MovedBlock(); block != "" {
+			detail += fmt.Sprintf("\n\nAdd this block to the configuration of %s:\n\n%s\n\nor run", module, block)
+		} else {
+			detail += "\n\nRun"
+		}
+		detail += ":\n\n  " + r.StateMvCommand()
+
+		var diags tfdiags.Diagnostics
+		diags = diags.Append(&hcl2.Diagnostic{
+			Severity: hcl2.DiagWarning,
+			Summary:  fmt.Sprintf("%s may have been renamed to %s", r.From, r.To),
+			Detail:   detail,
+		})
+		ui.Warn(format.Diagnostic(diags[0], b.Colorize(), 0))
+	}
+}
+
+// renderDrift displays any changes detected outside of Terraform during the
+// refresh before the plan. These are only a warning: Terraform doesn't take
+// any action for them except as proposed in the plan itself.
+func (b *Local) renderDrift(ui cli.Ui, dispPlan *format.Plan) {
+	if len(dispPlan.Drift) == 0 {
+		return
+	}
+
+	ui.Output("\n" + b.Colorize().Color(strings.TrimSpace(planDriftHeader)) + "\n")
+	ui.Output(dispPlan.FormatDrift(b.Colorize()))
+	ui.Output(strings.TrimSpace(planDriftFooter))
+	ui.Output("\n------------------------------------------------------------------------")
+}
+
+// renderRefreshOnly displays the changes detected by the refresh of a
+// refresh-only plan, which are all that applying the plan records.
+func (b *Local) renderRefreshOnly(ui cli.Ui, dispPlan *format.Plan) {
+	ui.Output("\n" + b.Colorize().Color(strings.TrimSpace(planDriftHeader)) + "\n")
+	ui.Output(dispPlan.FormatDrift(b.Colorize()))
+	ui.Output(strings.TrimSpace(planRefreshOnlyFooter))
+}
+
+// recordPlanForCrash records a summary of the given plan, with sensitive
+// values redacted, for the crash bundle if Terraform crashes.
+func recordPlanForCrash(plan *terraform.Plan) {
+	diff := plan.Diff
+	if plan.RefreshOnly {
+		diff = plan.Drift
+	}
+	if diff == nil {
+		return
+	}
+	crash.SetPlanSummary(diff.Redacted().String())
+}
+
 const planErrNoConfig = `
 No configuration files found!
 
@@ -231,6 +376,13 @@ flag or create a single empty configuration file. Otherwise, please create
 a Terraform configuration file in the path being executed and try again.
 `
 
+const planErrRefreshOnlyNoRefresh = `
+A refresh-only plan can't skip refreshing!
+
+The changes of a refresh-only plan are those detected by refreshing the state,
+so it can't be combined with "-refresh=false".
+`
+
 const planHeaderIntro = `
 An execution plan has been generated and is shown below.
 Resource actions are indicated with the following symbols:
@@ -257,6 +409,32 @@ configuration and real physical resources that exist. As a result, no
 actions need to be performed.
 `
 
+const planDriftHeader = `
+[reset][bold][yellow]Note: Objects have changed outside of Terraform[reset][yellow]
+
+Terraform detected the following changes made outside of Terraform since the
+last "terraform apply":
+`
+
+const planDriftFooter = `
+These changes are already reflected in the refreshed state and are not
+actions that Terraform will take. Unless you have made equivalent changes to
+your configuration, they may be why the plan below proposes changes.
+`
+
+const planRefreshOnlyNoChanges = `
+[reset][bold][green]No changes. Your infrastructure still matches the state.[reset][green]
+
+Terraform has checked that the real remote objects still match the result of
+your most recent changes, and found no differences.
+`
+
+const planRefreshOnlyFooter = `
+This is a refresh-only plan, so Terraform will not take any actions to undo
+these changes. If they were expected, applying this plan will record the
+updated values in the Terraform state without changing any real objects.
+`
+
 const planRefreshing = `
 [reset][bold]Refreshing Terraform state in-memory prior to plan...[reset]
 The refreshed state will be used to calculate this plan, but will not be
@@ -154,6 +154,136 @@ func TestLocal_planRefreshFalse(t *testing.T) {
 	}
 }
 
+func TestLocal_planDrift(t *testing.T) {
+	b := TestLocal(t)
+	p := TestLocalProvider(t, b, "test")
+	p.RefreshFn = func(
+		info *terraform.InstanceInfo,
+		s *terraform.InstanceState) (*terraform.InstanceState, error) {
+		s = s.DeepCopy()
+		s.Attributes = map[string]string{"ami": "changed"}
+		return s, nil
+	}
+	terraform.TestStateFile(t, b.StatePath, testPlanState())
+	b.CLI = cli.NewMockUi()
+
+	mod, modCleanup := module.TestTree(t, "./test-fixtures/plan")
+	defer modCleanup()
+
+	op := testOperationPlan()
+	op.Module = mod
+	op.PlanRefresh = true
+
+	run, err := b.Operation(context.Background(), op)
+	if err != nil {
+		t.Fatalf("bad: %s", err)
+	}
+	<-run.Done()
+	if run.Err != nil {
+		t.Fatalf("err: %s", run.Err)
+	}
+
+	output := b.CLI.(*cli.MockUi).OutputWriter.String()
+	if !strings.Contains(output, "Objects have changed outside of Terraform") {
+		t.Fatalf("drift should be reported:\n%s", output)
+	}
+	if !strings.Contains(output, `ami: "" => "changed"`) {
+		t.Fatalf("drift should include the changed attribute:\n%s", output)
+	}
+}
+
+func TestLocal_planChecks(t *testing.T) {
+	b := TestLocal(t)
+	TestLocalProvider(t, b, "test")
+	b.CLI = cli.NewMockUi()
+
+	mod, modCleanup := module.TestTree(t, "./test-fixtures/plan-checks")
+	defer modCleanup()
+
+	op := testOperationPlan()
+	op.Module = mod
+
+	run, err := b.Operation(context.Background(), op)
+	if err != nil {
+		t.Fatalf("bad: %s", err)
+	}
+	<-run.Done()
+	if run.Err != nil {
+		t.Fatalf("failed checks should not fail the plan: %s", run.Err)
+	}
+
+	output := b.CLI.(*cli.MockUi).ErrorWriter.String()
+	if !strings.Contains(output, "Check check.ami failed") {
+		t.Fatalf("failed check should be reported:\n%s", output)
+	}
+	if !strings.Contains(output, "The instance has the wrong image.") {
+		t.Fatalf("failed check should include its error message:\n%s", output)
+	}
+}
+
+func TestLocal_planRename(t *testing.T) {
+	b := TestLocal(t)
+	p := TestLocalProvider(t, b, "test")
+	p.DiffFn = func(
+		info *terraform.InstanceInfo,
+		s *terraform.InstanceState,
+		c *terraform.ResourceConfig) (*terraform.InstanceDiff, error) {
+		return &terraform.InstanceDiff{
+			Attributes: map[string]*terraform.ResourceAttrDiff{
+				"ami": &terraform.ResourceAttrDiff{New: c.Config["ami"].(string)},
+				"id":  &terraform.ResourceAttrDiff{NewComputed: true, RequiresNew: true},
+			},
+		}, nil
+	}
+	terraform.TestStateFile(t, b.StatePath, &terraform.State{
+		Version: 2,
+		Modules: []*terraform.ModuleState{
+			&terraform.ModuleState{
+				Path: []string{"root"},
+				Resources: map[string]*terraform.ResourceState{
+					"test_instance.old": &terraform.ResourceState{
+						Type: "test_instance",
+						Primary: &terraform.InstanceState{
+							ID: "bar",
+							Attributes: map[string]string{
+								"id":  "bar",
+								"ami": "bar",
+							},
+						},
+					},
+				},
+			},
+		},
+	})
+	b.CLI = cli.NewMockUi()
+
+	mod, modCleanup := module.TestTree(t, "./test-fixtures/plan-rename")
+	defer modCleanup()
+
+	op := testOperationPlan()
+	op.Module = mod
+
+	run, err := b.Operation(context.Background(), op)
+	if err != nil {
+		t.Fatalf("bad: %s", err)
+	}
+	<-run.Done()
+	if run.Err != nil {
+		t.Fatalf("err: %s", run.Err)
+	}
+
+	output := b.CLI.(*cli.MockUi).ErrorWriter.String()
+	for _, want := range []string{
+		"test_instance.old may have been renamed to test_instance.new",
+		"moved {\n  from = \"test_instance.old\"\n  to   = \"test_instance.new\"\n}",
+		"terraform state mv 'test_instance.old' 'test_instance.new'",
+	} {
+		if !strings.Contains(output, want) {
+			t.Fatalf("output is missing %q:\n%s", want, output)
+		}
+	}
+}
+
 func TestLocal_planDestroy(t *testing.T) {
 	b := TestLocal(t)
 	p := TestLocalProvider(t, b, "test")
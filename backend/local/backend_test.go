@@ -18,6 +18,8 @@ func TestLocal_impl(t *testing.T) {
 	var _ backend.Enhanced = new(Local)
 	var _ backend.Local = new(Local)
 	var _ backend.CLI = new(Local)
+	var _ backend.WorkspaceLister = new(Local)
+	var _ backend.StateVersioner = new(Local)
 }
 
 func TestLocal_backend(t *testing.T) {
@@ -88,6 +90,52 @@ func TestLocal_StatePaths(t *testing.T) {
 
 }
 
+func TestLocal_listWorkspacesWithInfo(t *testing.T) {
+	defer testTmpDir(t)()
+
+	b := &Local{}
+	if _, err := b.State("foo"); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := b.State(backend.DefaultStateName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	st := terraform.NewState()
+	st.Serial = 2
+	if err := s.WriteState(st); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.PersistState(); err != nil {
+		t.Fatal(err)
+	}
+
+	infos, err := b.ListWorkspacesWithInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != 2 {
+		t.Fatalf("expected 2 workspaces, got %d", len(infos))
+	}
+
+	dflt := infos[0]
+	fi, err := os.Stat(DefaultStateFilename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dflt.Name != backend.DefaultStateName || dflt.Serial != s.State().Serial ||
+		dflt.Size != fi.Size() || !dflt.LastModified.Equal(fi.ModTime()) {
+		t.Fatalf("bad: %#v", dflt)
+	}
+
+	// A workspace without a state file yet is empty.
+	expected := &backend.WorkspaceInfo{Name: "foo"}
+	if !reflect.DeepEqual(infos[1], expected) {
+		t.Fatalf("expected %#v, got %#v", expected, infos[1])
+	}
+}
+
 func TestLocal_addAndRemoveStates(t *testing.T) {
 	defer testTmpDir(t)()
 	dflt := backend.DefaultStateName
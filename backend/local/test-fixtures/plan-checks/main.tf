@@ -0,0 +1,14 @@
+variable "ami" {
+  default = "bar"
+}
+
+resource "test_instance" "foo" {
+  ami = "${var.ami}"
+}
+
+check "ami" {
+  assert {
+    condition     = "${var.ami == "baz"}"
+    error_message = "The instance has the wrong image."
+  }
+}
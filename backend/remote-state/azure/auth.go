@@ -0,0 +1,185 @@
+package azure
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/storage"
+	"github.com/Azure/go-autorest/autorest/adal"
+	"github.com/Azure/go-autorest/autorest/azure"
+)
+
+const (
+	// The default endpoint of the Azure Instance Metadata Service, which
+	// issues tokens for the managed identities of the virtual machine.
+	defaultMSIEndpoint = "http://169.254.169.254/metadata/identity/oauth2/token"
+
+	clientAssertionTypeJWT = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+
+	// The storage client requires an account key to sign requests with, even
+	// when they're authorized with a SAS token instead.
+	sasPlaceholderKey = "c2FzLXRva2VuLWF1dGg="
+)
+
+// getServicePrincipalToken returns a token for the Azure Resource Manager,
+// used to retrieve the storage account key. It's issued for the managed
+// identity of the machine, for a federated OIDC token, or for a client
+// secret, in this order of preference.
+func getServicePrincipalToken(config BackendConfig, env azure.Environment) (*adal.ServicePrincipalToken, error) {
+	oauthConfig, err := adal.NewOAuthConfig(env.ActiveDirectoryEndpoint, config.TenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case config.UseMSI:
+		token, err := getMSIToken(config.MSIEndpoint, config.ClientID, env.ResourceManagerEndpoint)
+		if err != nil {
+			return nil, fmt.Errorf("Error retrieving a managed identity token: %s", err)
+		}
+		return adal.NewServicePrincipalTokenFromManualToken(*oauthConfig, config.ClientID, env.ResourceManagerEndpoint, *token)
+
+	case config.UseOIDC:
+		if config.ClientID == "" || config.TenantID == "" {
+			return nil, fmt.Errorf("arm_client_id and arm_tenant_id must be provided when use_oidc is set")
+		}
+
+		assertion, err := getOIDCToken(config)
+		if err != nil {
+			return nil, err
+		}
+
+		secret := &oidcSecret{Assertion: assertion}
+		return adal.NewServicePrincipalTokenWithSecret(*oauthConfig, config.ClientID, env.ResourceManagerEndpoint, secret)
+
+	default:
+		if config.ClientID == "" || config.ClientSecret == "" || config.TenantID == "" {
+			return nil, fmt.Errorf("arm_client_id, arm_client_secret and arm_tenant_id must be provided, " +
+				"unless use_msi or use_oidc is set")
+		}
+
+		return adal.NewServicePrincipalToken(*oauthConfig, config.ClientID, config.ClientSecret, env.ResourceManagerEndpoint)
+	}
+}
+
+// getMSIToken retrieves a token for resource from the Instance Metadata
+// Service at endpoint, for the managed identity with the given client ID,
+// or the only one if clientID is empty.
+func getMSIToken(endpoint, clientID, resource string) (*adal.Token, error) {
+	if endpoint == "" {
+		endpoint = defaultMSIEndpoint
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid msi_endpoint %q: %s", endpoint, err)
+	}
+	q := u.Query()
+	q.Set("api-version", "2018-02-01")
+	q.Set("resource", resource)
+	if clientID != "" {
+		q.Set("client_id", clientID)
+	}
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Metadata", "true")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, body)
+	}
+
+	token := &adal.Token{}
+	if err := json.Unmarshal(body, token); err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// getOIDCToken returns the federated token to exchange for an Azure token,
+// given directly or in a file such as a projected service account token.
+func getOIDCToken(config BackendConfig) (string, error) {
+	if config.OIDCToken != "" {
+		return config.OIDCToken, nil
+	}
+	if config.OIDCTokenFilePath == "" {
+		return "", fmt.Errorf("oidc_token or oidc_token_file_path must be provided when use_oidc is set")
+	}
+
+	raw, err := ioutil.ReadFile(config.OIDCTokenFilePath)
+	if err != nil {
+		return "", fmt.Errorf("Error reading the OIDC token: %s", err)
+	}
+	return strings.TrimSpace(string(raw)), nil
+}
+
+// oidcSecret authenticates a service principal with a federated token, as
+// for workload identity federation.
+type oidcSecret struct {
+	Assertion string
+}
+
+func (s *oidcSecret) SetAuthenticationValues(spt *adal.ServicePrincipalToken, v *url.Values) error {
+	v.Set("client_assertion_type", clientAssertionTypeJWT)
+	v.Set("client_assertion", s.Assertion)
+	return nil
+}
+
+// getSASBlobClient returns a blob client whose requests are authorized
+// with a SAS token rather than the account key.
+func getSASBlobClient(config BackendConfig, env azure.Environment) (storage.BlobStorageClient, error) {
+	var client storage.BlobStorageClient
+
+	query, err := url.ParseQuery(strings.TrimPrefix(config.SASToken, "?"))
+	if err != nil {
+		return client, fmt.Errorf("invalid sas_token: %s", err)
+	}
+	if query.Get("sig") == "" {
+		return client, fmt.Errorf("invalid sas_token: missing signature")
+	}
+
+	storageClient, err := storage.NewClient(config.StorageAccountName, sasPlaceholderKey, env.StorageEndpointSuffix,
+		storage.DefaultAPIVersion, true)
+	if err != nil {
+		return client, fmt.Errorf("Error creating storage client for storage account %q: %s", config.StorageAccountName, err)
+	}
+	storageClient.Sender = &sasSender{Sender: storageClient.Sender, query: query}
+
+	return storageClient.GetBlobService(), nil
+}
+
+// sasSender authorizes the requests of a storage client with a SAS token,
+// replacing the shared key signature made with the placeholder key.
+type sasSender struct {
+	storage.Sender
+	query url.Values
+}
+
+func (s *sasSender) Send(c *storage.Client, req *http.Request) (*http.Response, error) {
+	req.Header.Del("Authorization")
+
+	q := req.URL.Query()
+	for k, v := range s.query {
+		q[k] = v
+	}
+	req.URL.RawQuery = q.Encode()
+
+	return s.Sender.Send(c, req)
+}
@@ -0,0 +1,148 @@
+package azure
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/storage"
+	"github.com/Azure/go-autorest/autorest/azure"
+	"github.com/hashicorp/terraform/backend"
+)
+
+func TestBackendConfig_sasToken(t *testing.T) {
+	config := map[string]interface{}{
+		"storage_account_name": "tfaccount",
+		"container_name":       "tfcontainer",
+		"key":                  "state",
+		"sas_token":            "?sv=2017-04-17&ss=b&srt=co&sp=rwdl&sig=c2lnbmF0dXJl",
+		"snapshot":             true,
+	}
+
+	b := backend.TestBackendConfig(t, New(), config).(*Backend)
+
+	if !b.snapshot {
+		t.Fatal("snapshot should be enabled")
+	}
+}
+
+func TestGetBlobClient_noCredentials(t *testing.T) {
+	_, err := getBlobClient(BackendConfig{StorageAccountName: "tfaccount"})
+	if err == nil || !strings.Contains(err.Error(), "resource_group_name") {
+		t.Fatalf("expected a missing credentials error, got %v", err)
+	}
+}
+
+func TestGetSASBlobClient_invalid(t *testing.T) {
+	config := BackendConfig{
+		StorageAccountName: "tfaccount",
+		SASToken:           "sv=2017-04-17&sp=rwdl",
+	}
+	if _, err := getSASBlobClient(config, azure.PublicCloud); err == nil {
+		t.Fatal("expected an error for a token without signature")
+	}
+}
+
+func TestSASSender(t *testing.T) {
+	var got *http.Request
+	inner := senderFunc(func(c *storage.Client, req *http.Request) (*http.Response, error) {
+		got = req
+		return &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(strings.NewReader(""))}, nil
+	})
+
+	query, _ := url.ParseQuery("sp=rwdl&sig=c2lnbmF0dXJl")
+	s := &sasSender{Sender: inner, query: query}
+
+	req, _ := http.NewRequest("GET", "https://tfaccount.blob.core.windows.net/tfcontainer/state?comp=lease", nil)
+	req.Header.Set("Authorization", "SharedKey tfaccount:abc")
+	if _, err := s.Send(nil, req); err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Header.Get("Authorization") != "" {
+		t.Fatal("the shared key signature wasn't removed")
+	}
+	q := got.URL.Query()
+	if q.Get("sig") != "c2lnbmF0dXJl" || q.Get("sp") != "rwdl" || q.Get("comp") != "lease" {
+		t.Fatalf("bad query: %s", got.URL.RawQuery)
+	}
+}
+
+func TestGetMSIToken(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Metadata") != "true" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if r.URL.Query().Get("client_id") != "client" || r.URL.Query().Get("resource") != "https://management.azure.com/" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"access_token":"token","expires_in":"3599","token_type":"Bearer"}`))
+	}))
+	defer ts.Close()
+
+	token, err := getMSIToken(ts.URL, "client", "https://management.azure.com/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.AccessToken != "token" {
+		t.Fatalf("bad token: %#v", token)
+	}
+
+	if _, err := getMSIToken(ts.URL, "other", "https://management.azure.com/"); err == nil {
+		t.Fatal("expected an error for an unknown identity")
+	}
+}
+
+func TestGetOIDCToken(t *testing.T) {
+	f, err := ioutil.TempFile("", "tf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.WriteString("file-token\n")
+	f.Close()
+
+	token, err := getOIDCToken(BackendConfig{OIDCTokenFilePath: f.Name()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "file-token" {
+		t.Fatalf("bad token: %q", token)
+	}
+
+	// A token given directly takes precedence over the file.
+	token, err = getOIDCToken(BackendConfig{OIDCToken: "token", OIDCTokenFilePath: f.Name()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "token" {
+		t.Fatalf("bad token: %q", token)
+	}
+
+	if _, err := getOIDCToken(BackendConfig{}); err == nil {
+		t.Fatal("expected an error without a token")
+	}
+}
+
+func TestOIDCSecret(t *testing.T) {
+	v := url.Values{}
+	s := &oidcSecret{Assertion: "token"}
+	if err := s.SetAuthenticationValues(nil, &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Get("client_assertion") != "token" || v.Get("client_assertion_type") != clientAssertionTypeJWT {
+		t.Fatalf("bad values: %v", v)
+	}
+}
+
+type senderFunc func(*storage.Client, *http.Request) (*http.Response, error)
+
+func (f senderFunc) Send(c *storage.Client, req *http.Request) (*http.Response, error) {
+	return f(c, req)
+}
@@ -7,7 +7,6 @@ import (
 	armStorage "github.com/Azure/azure-sdk-for-go/arm/storage"
 	"github.com/Azure/azure-sdk-for-go/storage"
 	"github.com/Azure/go-autorest/autorest"
-	"github.com/Azure/go-autorest/autorest/adal"
 	"github.com/Azure/go-autorest/autorest/azure"
 	"github.com/hashicorp/terraform/backend"
 	"github.com/hashicorp/terraform/helper/schema"
@@ -49,6 +48,13 @@ func New() backend.Backend {
 				DefaultFunc: schema.EnvDefaultFunc("ARM_ACCESS_KEY", ""),
 			},
 
+			"sas_token": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "A SAS token used to access the container, instead of the access key.",
+				DefaultFunc: schema.EnvDefaultFunc("ARM_SAS_TOKEN", ""),
+			},
+
 			"resource_group_name": {
 				Type:        schema.TypeString,
 				Optional:    true,
@@ -82,6 +88,48 @@ func New() backend.Backend {
 				Description: "The Tenant ID.",
 				DefaultFunc: schema.EnvDefaultFunc("ARM_TENANT_ID", ""),
 			},
+
+			"use_msi": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Description: "Authenticate with the managed identity of the machine.",
+				DefaultFunc: schema.EnvDefaultFunc("ARM_USE_MSI", false),
+			},
+
+			"msi_endpoint": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "The endpoint issuing managed identity tokens.",
+				DefaultFunc: schema.EnvDefaultFunc("ARM_MSI_ENDPOINT", ""),
+			},
+
+			"use_oidc": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Description: "Authenticate with a federated OIDC token.",
+				DefaultFunc: schema.EnvDefaultFunc("ARM_USE_OIDC", false),
+			},
+
+			"oidc_token": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "The federated OIDC token.",
+				DefaultFunc: schema.EnvDefaultFunc("ARM_OIDC_TOKEN", ""),
+			},
+
+			"oidc_token_file_path": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "The path to a file holding the federated OIDC token.",
+				DefaultFunc: schema.MultiEnvDefaultFunc([]string{"ARM_OIDC_TOKEN_FILE_PATH", "AZURE_FEDERATED_TOKEN_FILE"}, ""),
+			},
+
+			"snapshot": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Description: "Snapshot the state blob each time it's locked.",
+				DefaultFunc: schema.EnvDefaultFunc("ARM_SNAPSHOT", false),
+			},
 		},
 	}
 
@@ -99,6 +147,7 @@ type Backend struct {
 	containerName string
 	keyName       string
 	leaseID       string
+	snapshot      bool
 }
 
 type BackendConfig struct {
@@ -107,9 +156,16 @@ type BackendConfig struct {
 	ClientID           string
 	ClientSecret       string
 	ResourceGroupName  string
+	SASToken           string
 	StorageAccountName string
 	SubscriptionID     string
 	TenantID           string
+
+	UseMSI            bool
+	MSIEndpoint       string
+	UseOIDC           bool
+	OIDCToken         string
+	OIDCTokenFilePath string
 }
 
 func (b *Backend) configure(ctx context.Context) error {
@@ -122,6 +178,7 @@ func (b *Backend) configure(ctx context.Context) error {
 
 	b.containerName = data.Get("container_name").(string)
 	b.keyName = data.Get("key").(string)
+	b.snapshot = data.Get("snapshot").(bool)
 
 	config := BackendConfig{
 		AccessKey:          data.Get("access_key").(string),
@@ -129,9 +186,16 @@ func (b *Backend) configure(ctx context.Context) error {
 		ClientSecret:       data.Get("arm_client_secret").(string),
 		Environment:        data.Get("environment").(string),
 		ResourceGroupName:  data.Get("resource_group_name").(string),
+		SASToken:           data.Get("sas_token").(string),
 		StorageAccountName: data.Get("storage_account_name").(string),
 		SubscriptionID:     data.Get("arm_subscription_id").(string),
 		TenantID:           data.Get("arm_tenant_id").(string),
+
+		UseMSI:            data.Get("use_msi").(bool),
+		MSIEndpoint:       data.Get("msi_endpoint").(string),
+		UseOIDC:           data.Get("use_oidc").(bool),
+		OIDCToken:         data.Get("oidc_token").(string),
+		OIDCTokenFilePath: data.Get("oidc_token_file_path").(string),
 	}
 
 	blobClient, err := getBlobClient(config)
@@ -151,6 +215,10 @@ func getBlobClient(config BackendConfig) (storage.BlobStorageClient, error) {
 		return client, err
 	}
 
+	if config.AccessKey == "" && config.SASToken != "" {
+		return getSASBlobClient(config, env)
+	}
+
 	accessKey, err := getAccessKey(config, env)
 	if err != nil {
 		return client, err
@@ -171,21 +239,11 @@ func getAccessKey(config BackendConfig, env azure.Environment) (string, error) {
 		return config.AccessKey, nil
 	}
 
-	rgOk := config.ResourceGroupName != ""
-	subOk := config.SubscriptionID != ""
-	clientIDOk := config.ClientID != ""
-	clientSecretOK := config.ClientSecret != ""
-	tenantIDOk := config.TenantID != ""
-	if !rgOk || !subOk || !clientIDOk || !clientSecretOK || !tenantIDOk {
-		return "", fmt.Errorf("resource_group_name and credentials must be provided when access_key is absent")
-	}
-
-	oauthConfig, err := adal.NewOAuthConfig(env.ActiveDirectoryEndpoint, config.TenantID)
-	if err != nil {
-		return "", err
+	if config.ResourceGroupName == "" || config.SubscriptionID == "" {
+		return "", fmt.Errorf("resource_group_name and arm_subscription_id must be provided when access_key and sas_token are absent")
 	}
 
-	spt, err := adal.NewServicePrincipalToken(*oauthConfig, config.ClientID, config.ClientSecret, env.ResourceManagerEndpoint)
+	spt, err := getServicePrincipalToken(config, env)
 	if err != nil {
 		return "", err
 	}
@@ -27,6 +27,10 @@ type RemoteClient struct {
 	containerName string
 	keyName       string
 	leaseID       string
+
+	// snapshot is set to snapshot the state blob each time it's locked, so
+	// that the state can be recovered from the snapshot after a bad write.
+	snapshot bool
 }
 
 func (c *RemoteClient) Get() (*remote.Payload, error) {
@@ -183,6 +187,17 @@ func (c *RemoteClient) Lock(info *state.LockInfo) (string, error) {
 		return "", err
 	}
 
+	if c.snapshot {
+		_, err := blobReference.CreateSnapshot(&storage.SnapshotOptions{LeaseID: leaseID})
+		if err != nil {
+			if unlockErr := c.Unlock(leaseID); unlockErr != nil {
+				err = multierror.Append(err, unlockErr)
+			}
+			return "", fmt.Errorf("Failed to snapshot the state: %s", err)
+		}
+		log.Printf("[DEBUG] Created a snapshot of %s", stateName)
+	}
+
 	return info.ID, nil
 }
 
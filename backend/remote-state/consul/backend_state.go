@@ -57,10 +57,13 @@ func (b *Backend) DeleteState(name string) error {
 	// Determine the path of the data
 	path := b.path(name)
 
-	// Delete it. We just delete it without any locking since
-	// the DeleteState API is documented as such.
-	_, err := b.client.KV().Delete(path, nil)
-	return err
+	// Delete it, along with any chunks. We just delete it without any
+	// locking since the DeleteState API is documented as such.
+	client := &RemoteClient{
+		Client: b.client,
+		Path:   path,
+	}
+	return client.Delete()
 }
 
 func (b *Backend) State(name string) (state.State, error) {
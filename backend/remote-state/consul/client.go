@@ -21,6 +21,7 @@ import (
 const (
 	lockSuffix     = "/.lock"
 	lockInfoSuffix = "/.lockinfo"
+	chunkSuffix    = "/tfstate."
 
 	// The Session TTL associated with this lock.
 	lockSessionTTL = "15s"
@@ -30,6 +31,10 @@ const (
 	lockDelay = 5 * time.Second
 	// interval between attempts to reacquire a lost lock
 	lockReacquireInterval = 2 * time.Second
+
+	// The largest value Consul accepts in a single key. Larger states are
+	// split into chunks of at most this size.
+	maxKVSize = 512 * 1024
 )
 
 var lostLockErr = errors.New("consul lock was lost")
@@ -51,6 +56,16 @@ type RemoteClient struct {
 	// need to make sure that the state was not modified.
 	modifyIndex uint64
 
+	// readIndex is true once the state has been read, in which case a
+	// modifyIndex of 0 means there was no state, and Put will only create
+	// one if it still doesn't exist. Without it, Put overwrites any state
+	// since the caller is purposely replacing it.
+	readIndex bool
+
+	// chunks lists the chunks of the state last read or written, if it was
+	// too large for a single key.
+	chunks *chunkedState
+
 	consulLock *consulapi.Lock
 	lockCh     <-chan struct{}
 
@@ -71,33 +86,78 @@ func (c *RemoteClient) Get() (*remote.Payload, error) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	pair, _, err := c.Client.KV().Get(c.Path, nil)
+	kv := c.Client.KV()
+
+	pair, _, err := kv.Get(c.Path, nil)
 	if err != nil {
 		return nil, err
 	}
+	c.readIndex = true
+	c.chunks = nil
 	if pair == nil {
+		c.modifyIndex = 0
 		return nil, nil
 	}
 
 	c.modifyIndex = pair.ModifyIndex
 
-	payload := pair.Value
+	value := pair.Value
+	if chunked, ok := parseChunkedState(value); ok {
+		c.chunks = chunked
+		if value, err = c.getChunks(chunked); err != nil {
+			return nil, err
+		}
+	}
+
+	payload := value
 	// If the payload starts with 0x1f, it's gzip, not json
-	if len(pair.Value) >= 1 && pair.Value[0] == '\x1f' {
-		if data, err := uncompressState(pair.Value); err == nil {
+	if len(value) >= 1 && value[0] == '\x1f' {
+		if data, err := uncompressState(value); err == nil {
 			payload = data
 		} else {
 			return nil, err
 		}
 	}
 
-	md5 := md5.Sum(pair.Value)
+	md5 := md5.Sum(value)
 	return &remote.Payload{
 		Data: payload,
 		MD5:  md5[:],
 	}, nil
 }
 
+// getChunks reads the chunks of a state and joins them.
+func (c *RemoteClient) getChunks(chunked *chunkedState) ([]byte, error) {
+	kv := c.Client.KV()
+
+	var buf bytes.Buffer
+	for _, key := range chunked.Chunks {
+		pair, _, err := kv.Get(key, nil)
+		if err != nil {
+			return nil, err
+		}
+		if pair == nil {
+			return nil, fmt.Errorf(
+				"state chunk %q is missing; the state may have been written "+
+					"by another client while it was read, so try again", key)
+		}
+		buf.Write(pair.Value)
+	}
+
+	if hash := fmt.Sprintf("%x", md5.Sum(buf.Bytes())); hash != chunked.Hash {
+		return nil, fmt.Errorf(
+			"state chunks have MD5 %s, expected %s; the state may have been "+
+				"written by another client while it was read, so try again", hash, chunked.Hash)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Put stores the state in a single key if it fits, or otherwise splits it
+// into chunks stored under the state path, keyed by the MD5 of the state,
+// and stores a chunkedState referring to them in the state key. The state
+// key is always written last, with a check-and-set, so a concurrent reader
+// sees either the old or the new state.
 func (c *RemoteClient) Put(data []byte) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -113,12 +173,58 @@ func (c *RemoteClient) Put(data []byte) error {
 
 	kv := c.Client.KV()
 
+	// Find the chunks of the state being replaced, so they can be removed
+	// once it has been.
+	oldChunks := c.chunks
+	if !c.readIndex {
+		pair, _, err := kv.Get(c.Path, nil)
+		if err != nil {
+			return err
+		}
+		if pair != nil {
+			oldChunks, _ = parseChunkedState(pair.Value)
+		}
+	}
+
+	var newChunks *chunkedState
+	if len(payload) > maxKVSize {
+		var err error
+		if newChunks, err = c.putChunks(payload); err != nil {
+			return err
+		}
+		if payload, err = json.Marshal(newChunks); err != nil {
+			return err
+		}
+	}
+
+	if err := c.putState(payload); err != nil {
+		// The new chunks aren't referenced by any state, unless they're
+		// also the chunks of the current one.
+		if newChunks != nil && (oldChunks == nil || oldChunks.Hash != newChunks.Hash) {
+			c.deleteChunks(newChunks)
+		}
+		return err
+	}
+
+	if oldChunks != nil && (newChunks == nil || oldChunks.Hash != newChunks.Hash) {
+		c.deleteChunks(oldChunks)
+	}
+
+	c.chunks = newChunks
+	return nil
+}
+
+// putState writes the state key.
+// Only to be called while holding Client.mu
+func (c *RemoteClient) putState(payload []byte) error {
+	kv := c.Client.KV()
+
 	// default to doing a CAS
 	verb := consulapi.KVCAS
 
-	// Assume a 0 index doesn't need a CAS for now, since we are either
-	// creating a new state or purposely overwriting one.
-	if c.modifyIndex == 0 {
+	// If we haven't read the state, we're purposely overwriting it, such
+	// as when migrating a state from another backend.
+	if !c.readIndex {
 		verb = consulapi.KVSet
 	}
 
@@ -140,7 +246,8 @@ func (c *RemoteClient) Put(data []byte) error {
 
 	// transaction was rolled back
 	if !ok {
-		return fmt.Errorf("consul CAS failed with transaction errors: %v", resp.Errors)
+		return fmt.Errorf("consul CAS failed with transaction errors: %v; "+
+			"the state may have been modified by another client since it was read", resp.Errors)
 	}
 
 	if len(resp.Results) != 1 {
@@ -149,16 +256,70 @@ func (c *RemoteClient) Put(data []byte) error {
 	}
 
 	c.modifyIndex = resp.Results[0].ModifyIndex
+	c.readIndex = true
 	return nil
 }
 
+// putChunks writes the chunks of a state that's too large for a single key.
+// Only to be called while holding Client.mu
+func (c *RemoteClient) putChunks(payload []byte) (*chunkedState, error) {
+	kv := c.Client.KV()
+
+	chunked := &chunkedState{Hash: fmt.Sprintf("%x", md5.Sum(payload))}
+	for i := 0; len(payload) > 0; i++ {
+		n := len(payload)
+		if n > maxKVSize {
+			n = maxKVSize
+		}
+
+		key := fmt.Sprintf("%s%s%s/%d", c.Path, chunkSuffix, chunked.Hash, i)
+		if _, err := kv.Put(&consulapi.KVPair{Key: key, Value: payload[:n]}, nil); err != nil {
+			c.deleteChunks(chunked)
+			return nil, err
+		}
+
+		chunked.Chunks = append(chunked.Chunks, key)
+		payload = payload[n:]
+	}
+
+	return chunked, nil
+}
+
+// deleteChunks removes the chunks of a state. Failing to is only logged,
+// since the chunks are no longer part of any state.
+func (c *RemoteClient) deleteChunks(chunked *chunkedState) {
+	kv := c.Client.KV()
+	for _, key := range chunked.Chunks {
+		if _, err := kv.Delete(key, nil); err != nil {
+			log.Printf("[WARN] failed to delete state chunk %s: %s", key, err)
+		}
+	}
+}
+
 func (c *RemoteClient) Delete() error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
 	kv := c.Client.KV()
-	_, err := kv.Delete(c.Path, nil)
-	return err
+
+	pair, _, err := kv.Get(c.Path, nil)
+	if err != nil {
+		return err
+	}
+
+	if _, err := kv.Delete(c.Path, nil); err != nil {
+		return err
+	}
+
+	if pair != nil {
+		if chunked, ok := parseChunkedState(pair.Value); ok {
+			c.deleteChunks(chunked)
+		}
+	}
+
+	c.modifyIndex = 0
+	c.chunks = nil
+	return nil
 }
 
 func (c *RemoteClient) putLockInfo(info *state.LockInfo) error {
@@ -439,6 +600,27 @@ func (c *RemoteClient) unlock(id string) error {
 	return errs
 }
 
+// chunkedState is stored in the state key in place of a state that's too
+// large for it, and lists the keys of the chunks holding the state.
+type chunkedState struct {
+	Hash   string   `json:"current-hash"`
+	Chunks []string `json:"chunks"`
+}
+
+// parseChunkedState returns the chunkedState stored in a state key, if the
+// key doesn't hold the state itself.
+func parseChunkedState(value []byte) (*chunkedState, bool) {
+	if len(value) == 0 || value[0] != '{' {
+		return nil, false
+	}
+
+	chunked := &chunkedState{}
+	if err := json.Unmarshal(value, chunked); err != nil || chunked.Hash == "" {
+		return nil, false
+	}
+	return chunked, true
+}
+
 func compressState(data []byte) ([]byte, error) {
 	b := new(bytes.Buffer)
 	gz := gzip.NewWriter(b)
@@ -1,7 +1,9 @@
 package consul
 
 import (
+	"bytes"
 	"context"
+	"crypto/rand"
 	"fmt"
 	"net"
 	"sync"
@@ -71,6 +73,111 @@ func TestRemoteClient_gzipUpgrade(t *testing.T) {
 	remote.TestClient(t, state.(*remote.State).Client)
 }
 
+func TestRemoteClient_largeState(t *testing.T) {
+	for _, gzip := range []bool{false, true} {
+		path := fmt.Sprintf("tf-unit/%s", time.Now().String())
+
+		b := backend.TestBackendConfig(t, New(), map[string]interface{}{
+			"address": srv.HTTPAddr,
+			"path":    path,
+			"gzip":    gzip,
+		})
+
+		s, err := b.State(backend.DefaultStateName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c := s.(*remote.State).Client.(*RemoteClient)
+
+		// Random data doesn't compress, so is chunked even with gzip.
+		data := make([]byte, 2*maxKVSize+1)
+		if _, err := rand.Read(data); err != nil {
+			t.Fatal(err)
+		}
+		// Don't let the state look compressed.
+		data[0] = 0
+		if err := c.Put(data); err != nil {
+			t.Fatal(err)
+		}
+
+		keys, _, err := c.Client.KV().Keys(path+chunkSuffix, "", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(keys) != 3 {
+			t.Fatalf("expected 3 chunks, got %q", keys)
+		}
+
+		p, err := c.Get()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(p.Data, data) {
+			t.Fatal("wrong state read")
+		}
+
+		// Replacing the state with a small one removes the chunks.
+		if err := c.Put([]byte("{}")); err != nil {
+			t.Fatal(err)
+		}
+		keys, _, err = c.Client.KV().Keys(path+chunkSuffix, "", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(keys) != 0 {
+			t.Fatalf("expected no chunks, got %q", keys)
+		}
+
+		remote.TestClient(t, c)
+	}
+}
+
+func TestRemoteClient_casNewState(t *testing.T) {
+	path := fmt.Sprintf("tf-unit/%s", time.Now().String())
+
+	newClient := func() *RemoteClient {
+		b := backend.TestBackendConfig(t, New(), map[string]interface{}{
+			"address": srv.HTTPAddr,
+			"path":    path,
+		})
+		s, err := b.State(backend.DefaultStateName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s.(*remote.State).Client.(*RemoteClient)
+	}
+
+	// Both clients see that there's no state yet.
+	a, b := newClient(), newClient()
+	for _, c := range []*RemoteClient{a, b} {
+		if p, err := c.Get(); err != nil || p != nil {
+			t.Fatalf("expected no state, got %#v, %v", p, err)
+		}
+	}
+
+	if err := a.Put([]byte(`{"a": 1}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	// The second client can't overwrite the state it didn't see.
+	if err := b.Put([]byte(`{"b": 1}`)); err == nil {
+		t.Fatal("expected the CAS to fail")
+	}
+
+	// A client that never read the state overwrites it.
+	c := newClient()
+	if err := c.Put([]byte(`{"c": 1}`)); err != nil {
+		t.Fatal(err)
+	}
+	p, err := a.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(p.Data) != `{"c": 1}` {
+		t.Fatalf("wrong state %q", p.Data)
+	}
+}
+
 func TestConsul_stateLock(t *testing.T) {
 	path := fmt.Sprintf("tf-unit/%s", time.Now().String())
 
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"path"
 	"sort"
+	"strconv"
 	"strings"
 
 	"cloud.google.com/go/storage"
@@ -67,6 +68,74 @@ func (b *gcsBackend) DeleteState(name string) error {
 	return c.Delete()
 }
 
+// StateVersions lists the generations of the named state's object. The
+// bucket must have object versioning enabled to keep more than one.
+func (b *gcsBackend) StateVersions(name string) ([]*backend.StateVersion, error) {
+	c, err := b.client(name)
+	if err != nil {
+		return nil, err
+	}
+
+	bucket := b.storageClient.Bucket(b.bucketName)
+	objs := bucket.Objects(b.storageContext, &storage.Query{
+		Prefix:   c.stateFilePath,
+		Versions: true,
+	})
+
+	var gens []*storage.ObjectAttrs
+	for {
+		attrs, err := objs.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("querying Cloud Storage failed: %v", err)
+		}
+
+		if attrs.Name == c.stateFilePath {
+			gens = append(gens, attrs)
+		}
+	}
+
+	sort.Slice(gens, func(i, j int) bool {
+		return gens[i].Generation > gens[j].Generation
+	})
+
+	versions := make([]*backend.StateVersion, len(gens))
+	for i, attrs := range gens {
+		versions[i] = &backend.StateVersion{
+			ID:           strconv.FormatInt(attrs.Generation, 10),
+			LastModified: attrs.Created,
+			Size:         attrs.Size,
+			// Noncurrent generations have been replaced or deleted.
+			Current: attrs.Deleted.IsZero(),
+		}
+	}
+
+	return versions, nil
+}
+
+// StateVersion reads a generation of the named state's object.
+func (b *gcsBackend) StateVersion(name, id string) (*terraform.State, error) {
+	gen, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%q is not a valid generation", id)
+	}
+
+	c, err := b.client(name)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := c.stateFile().Generation(gen).NewReader(b.storageContext)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to open generation %d of state file at %v: %v", gen, c.stateFileURL(), err)
+	}
+	defer r.Close()
+
+	return terraform.ReadState(r)
+}
+
 // client returns a remoteClient for the named state.
 func (b *gcsBackend) client(name string) (*remoteClient, error) {
 	if name == "" {
@@ -0,0 +1,245 @@
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/url"
+	"time"
+
+	cleanhttp "github.com/hashicorp/go-cleanhttp"
+	retryablehttp "github.com/hashicorp/go-retryablehttp"
+	"github.com/hashicorp/terraform/backend"
+	remotestate "github.com/hashicorp/terraform/backend/remote-state"
+	"github.com/hashicorp/terraform/helper/logging"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/state/remote"
+)
+
+// New creates a new backend for HTTP remote state.
+func New() backend.Backend {
+	s := &schema.Backend{
+		Schema: map[string]*schema.Schema{
+			"address": &schema.Schema{
+				Type:         schema.TypeString,
+				Required:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("TF_HTTP_ADDRESS", nil),
+				Description:  "The address of the REST endpoint",
+				ValidateFunc: validateURL,
+			},
+
+			"update_method": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("TF_HTTP_UPDATE_METHOD", "POST"),
+				Description: "HTTP method to use when updating state",
+			},
+
+			"lock_address": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("TF_HTTP_LOCK_ADDRESS", ""),
+				Description:  "The address of the lock REST endpoint",
+				ValidateFunc: validateURL,
+			},
+
+			"unlock_address": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("TF_HTTP_UNLOCK_ADDRESS", ""),
+				Description:  "The address of the unlock REST endpoint",
+				ValidateFunc: validateURL,
+			},
+
+			"lock_method": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("TF_HTTP_LOCK_METHOD", "LOCK"),
+				Description: "The HTTP method to use when locking",
+			},
+
+			"unlock_method": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("TF_HTTP_UNLOCK_METHOD", "UNLOCK"),
+				Description: "The HTTP method to use when unlocking",
+			},
+
+			"username": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("TF_HTTP_USERNAME", ""),
+				Description: "The username for HTTP basic authentication",
+			},
+
+			"password": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("TF_HTTP_PASSWORD", ""),
+				Description: "The password for HTTP basic authentication",
+			},
+
+			"skip_cert_verification": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Whether to skip TLS verification.",
+			},
+
+			"client_ca_certificate_pem": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("TF_HTTP_CLIENT_CA_CERTIFICATE_PEM", ""),
+				Description: "A PEM-encoded CA certificate chain used by the client to verify server certificates during TLS authentication.",
+			},
+
+			"client_certificate_pem": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("TF_HTTP_CLIENT_CERTIFICATE_PEM", ""),
+				Description: "A PEM-encoded certificate used by the server to verify the client during mutual TLS (mTLS) authentication; requires use of client_private_key_pem.",
+			},
+
+			"client_private_key_pem": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("TF_HTTP_CLIENT_PRIVATE_KEY_PEM", ""),
+				Description: "A PEM-encoded private key, required if client_certificate_pem is specified.",
+			},
+
+			"retry_max": &schema.Schema{
+				Type:        schema.TypeInt,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("TF_HTTP_RETRY_MAX", 2),
+				Description: "The number of HTTP request retries.",
+			},
+
+			"retry_wait_min": &schema.Schema{
+				Type:        schema.TypeInt,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("TF_HTTP_RETRY_WAIT_MIN", 1),
+				Description: "The minimum time in seconds to wait between HTTP request attempts.",
+			},
+
+			"retry_wait_max": &schema.Schema{
+				Type:        schema.TypeInt,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("TF_HTTP_RETRY_WAIT_MAX", 30),
+				Description: "The maximum time in seconds to wait between HTTP request attempts.",
+			},
+		},
+	}
+
+	return &remotestate.Backend{
+		Backend:       s,
+		ConfigureFunc: configure,
+	}
+}
+
+func validateURL(v interface{}, k string) ([]string, []error) {
+	s := v.(string)
+	if s == "" {
+		return nil, nil
+	}
+
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, []error{fmt.Errorf("%s: failed to parse URL: %s", k, err)}
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, []error{fmt.Errorf("%s: address must be HTTP or HTTPS", k)}
+	}
+	return nil, nil
+}
+
+func configure(ctx context.Context) (remote.Client, error) {
+	data := schema.FromContextBackendConfig(ctx)
+
+	// The URLs were already validated, so they parse.
+	updateURL, _ := url.Parse(data.Get("address").(string))
+
+	var lockURL, unlockURL *url.URL
+	if v, ok := data.GetOk("lock_address"); ok && v.(string) != "" {
+		lockURL, _ = url.Parse(v.(string))
+	}
+	if v, ok := data.GetOk("unlock_address"); ok && v.(string) != "" {
+		unlockURL, _ = url.Parse(v.(string))
+	}
+
+	tlsConfig := &tls.Config{}
+	if data.Get("skip_cert_verification").(bool) {
+		// ignores TLS verification
+		tlsConfig.InsecureSkipVerify = true
+	}
+	if v, ok := data.GetOk("client_ca_certificate_pem"); ok && v.(string) != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(v.(string))) {
+			return nil, fmt.Errorf("failed to parse client_ca_certificate_pem")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	certPEM := data.Get("client_certificate_pem").(string)
+	keyPEM := data.Get("client_private_key_pem").(string)
+	switch {
+	case certPEM != "" && keyPEM != "":
+		cert, err := tls.X509KeyPair([]byte(certPEM), []byte(keyPEM))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the client certificate: %s", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	case certPEM != "":
+		return nil, fmt.Errorf("client_private_key_pem must be set when client_certificate_pem is set")
+	case keyPEM != "":
+		return nil, fmt.Errorf("client_certificate_pem must be set when client_private_key_pem is set")
+	}
+
+	retryMax := data.Get("retry_max").(int)
+	retryWaitMin := data.Get("retry_wait_min").(int)
+	retryWaitMax := data.Get("retry_wait_max").(int)
+	if retryMax < 0 || retryWaitMin < 0 || retryWaitMax < 0 {
+		return nil, fmt.Errorf("retry_max, retry_wait_min and retry_wait_max must not be negative")
+	}
+	if retryWaitMax < retryWaitMin {
+		return nil, fmt.Errorf("retry_wait_max must not be less than retry_wait_min")
+	}
+
+	transport := cleanhttp.DefaultPooledTransport()
+	transport.TLSClientConfig = tlsConfig
+
+	rc := retryablehttp.NewClient()
+	rc.HTTPClient.Transport = transport
+	rc.RetryMax = retryMax
+	rc.RetryWaitMin = time.Duration(retryWaitMin) * time.Second
+	rc.RetryWaitMax = time.Duration(retryWaitMax) * time.Second
+
+	logOutput, err := logging.LogOutput()
+	if err != nil {
+		return nil, err
+	}
+	if logOutput == nil {
+		logOutput = ioutil.Discard
+	}
+	rc.Logger = log.New(logOutput, "", log.Flags())
+
+	client := &httpClient{
+		URL:          updateURL,
+		UpdateMethod: data.Get("update_method").(string),
+
+		LockURL:      lockURL,
+		LockMethod:   data.Get("lock_method").(string),
+		UnlockURL:    unlockURL,
+		UnlockMethod: data.Get("unlock_method").(string),
+
+		Username: data.Get("username").(string),
+		Password: data.Get("password").(string),
+
+		// accessible only for testing use
+		Client: rc,
+	}
+
+	return remote.NewCachedClient(client, updateURL.String()), nil
+}
@@ -0,0 +1,129 @@
+package http
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform/backend"
+	remotestate "github.com/hashicorp/terraform/backend/remote-state"
+	"github.com/hashicorp/terraform/config"
+	"github.com/hashicorp/terraform/state/remote"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestBackend_impl(t *testing.T) {
+	var _ backend.Backend = New()
+}
+
+func testHTTPClient(t *testing.T, conf map[string]interface{}) *httpClient {
+	t.Helper()
+	b := backend.TestBackendConfig(t, New(), conf)
+	s, err := b.State(backend.DefaultStateName)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	return s.(*remote.State).Client.(*httpClient)
+}
+
+func TestBackendConfig(t *testing.T) {
+	// defaults
+	client := testHTTPClient(t, map[string]interface{}{
+		"address": "http://127.0.0.1:8888/foo",
+	})
+	if client.URL.String() != "http://127.0.0.1:8888/foo" {
+		t.Fatalf("wrong address %q", client.URL)
+	}
+	if client.UpdateMethod != "POST" {
+		t.Fatalf("wrong update_method %q", client.UpdateMethod)
+	}
+	if client.LockURL != nil || client.LockMethod != "LOCK" {
+		t.Fatal("Unexpected lock_address or lock_method")
+	}
+	if client.UnlockURL != nil || client.UnlockMethod != "UNLOCK" {
+		t.Fatal("Unexpected unlock_address or unlock_method")
+	}
+	if client.Username != "" || client.Password != "" {
+		t.Fatal("Unexpected username or password")
+	}
+	if client.Client.RetryMax != 2 || client.Client.RetryWaitMin != time.Second || client.Client.RetryWaitMax != 30*time.Second {
+		t.Fatalf("wrong retry settings %d %s %s", client.Client.RetryMax, client.Client.RetryWaitMin, client.Client.RetryWaitMax)
+	}
+
+	// custom
+	client = testHTTPClient(t, map[string]interface{}{
+		"address":        "http://127.0.0.1:8888/foo",
+		"update_method":  "BLAH",
+		"lock_address":   "http://127.0.0.1:8888/bar",
+		"lock_method":    "BLIP",
+		"unlock_address": "http://127.0.0.1:8888/baz",
+		"unlock_method":  "BLOOP",
+		"username":       "user",
+		"password":       "pass",
+		"retry_max":      5,
+		"retry_wait_min": 2,
+		"retry_wait_max": 10,
+	})
+	if client.UpdateMethod != "BLAH" {
+		t.Fatalf("wrong update_method %q", client.UpdateMethod)
+	}
+	if client.LockURL.String() != "http://127.0.0.1:8888/bar" || client.LockMethod != "BLIP" {
+		t.Fatalf("wrong lock_address %q or lock_method %q", client.LockURL, client.LockMethod)
+	}
+	if client.UnlockURL.String() != "http://127.0.0.1:8888/baz" || client.UnlockMethod != "BLOOP" {
+		t.Fatalf("wrong unlock_address %q or unlock_method %q", client.UnlockURL, client.UnlockMethod)
+	}
+	if client.Username != "user" || client.Password != "pass" {
+		t.Fatalf("wrong username %q or password %q", client.Username, client.Password)
+	}
+	if client.Client.RetryMax != 5 || client.Client.RetryWaitMin != 2*time.Second || client.Client.RetryWaitMax != 10*time.Second {
+		t.Fatalf("wrong retry settings %d %s %s", client.Client.RetryMax, client.Client.RetryWaitMin, client.Client.RetryWaitMax)
+	}
+}
+
+func TestBackendConfig_invalid(t *testing.T) {
+	cases := map[string]map[string]interface{}{
+		"missing address": {},
+		"bad address scheme": {
+			"address": "ftp://127.0.0.1/foo",
+		},
+		"bad lock address scheme": {
+			"address":      "http://127.0.0.1:8888/foo",
+			"lock_address": "ftp://127.0.0.1/foo",
+		},
+		"certificate without key": {
+			"address":                "http://127.0.0.1:8888/foo",
+			"client_certificate_pem": "cert",
+		},
+		"bad CA certificate": {
+			"address":                   "http://127.0.0.1:8888/foo",
+			"client_ca_certificate_pem": "not a certificate",
+		},
+		"negative retries": {
+			"address":   "http://127.0.0.1:8888/foo",
+			"retry_max": -1,
+		},
+		"inverted retry waits": {
+			"address":        "http://127.0.0.1:8888/foo",
+			"retry_wait_min": 10,
+			"retry_wait_max": 1,
+		},
+	}
+
+	for name, raw := range cases {
+		t.Run(name, func(t *testing.T) {
+			rc, err := config.NewRawConfig(raw)
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			conf := terraform.NewResourceConfig(rc)
+
+			b := New().(*remotestate.Backend)
+			if _, errs := b.Validate(conf); len(errs) > 0 {
+				return
+			}
+			if err := b.Configure(conf); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
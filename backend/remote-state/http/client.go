@@ -1,9 +1,8 @@
-package remote
+package http
 
 import (
 	"bytes"
 	"crypto/md5"
-	"crypto/tls"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
@@ -11,104 +10,14 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/url"
-	"strconv"
 
+	retryablehttp "github.com/hashicorp/go-retryablehttp"
 	"github.com/hashicorp/terraform/state"
+	"github.com/hashicorp/terraform/state/remote"
 )
 
-func httpFactory(conf map[string]string) (Client, error) {
-	address, ok := conf["address"]
-	if !ok {
-		return nil, fmt.Errorf("missing 'address' configuration")
-	}
-
-	updateURL, err := url.Parse(address)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse address URL: %s", err)
-	}
-	if updateURL.Scheme != "http" && updateURL.Scheme != "https" {
-		return nil, fmt.Errorf("address must be HTTP or HTTPS")
-	}
-	updateMethod, ok := conf["update_method"]
-	if !ok {
-		updateMethod = "POST"
-	}
-
-	var lockURL *url.URL
-	if lockAddress, ok := conf["lock_address"]; ok {
-		var err error
-		lockURL, err = url.Parse(lockAddress)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse lockAddress URL: %s", err)
-		}
-		if lockURL.Scheme != "http" && lockURL.Scheme != "https" {
-			return nil, fmt.Errorf("lockAddress must be HTTP or HTTPS")
-		}
-	} else {
-		lockURL = nil
-	}
-	lockMethod, ok := conf["lock_method"]
-	if !ok {
-		lockMethod = "LOCK"
-	}
-
-	var unlockURL *url.URL
-	if unlockAddress, ok := conf["unlock_address"]; ok {
-		var err error
-		unlockURL, err = url.Parse(unlockAddress)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse unlockAddress URL: %s", err)
-		}
-		if unlockURL.Scheme != "http" && unlockURL.Scheme != "https" {
-			return nil, fmt.Errorf("unlockAddress must be HTTP or HTTPS")
-		}
-	} else {
-		unlockURL = nil
-	}
-	unlockMethod, ok := conf["unlock_method"]
-	if !ok {
-		unlockMethod = "UNLOCK"
-	}
-
-	client := &http.Client{}
-	if skipRaw, ok := conf["skip_cert_verification"]; ok {
-		skip, err := strconv.ParseBool(skipRaw)
-		if err != nil {
-			return nil, fmt.Errorf("skip_cert_verification must be boolean")
-		}
-		if skip {
-			// Replace the client with one that ignores TLS verification
-			client = &http.Client{
-				Transport: &http.Transport{
-					TLSClientConfig: &tls.Config{
-						InsecureSkipVerify: true,
-					},
-				},
-			}
-		}
-	}
-
-	ret := &HTTPClient{
-		URL:          updateURL,
-		UpdateMethod: updateMethod,
-
-		LockURL:      lockURL,
-		LockMethod:   lockMethod,
-		UnlockURL:    unlockURL,
-		UnlockMethod: unlockMethod,
-
-		Username: conf["username"],
-		Password: conf["password"],
-
-		// accessible only for testing use
-		Client: client,
-	}
-
-	return ret, nil
-}
-
-// HTTPClient is a remote client that stores data in Consul or HTTP REST.
-type HTTPClient struct {
+// httpClient is a remote client that stores data in any HTTP REST endpoint.
+type httpClient struct {
 	// Update & Retrieve
 	URL          *url.URL
 	UpdateMethod string
@@ -120,7 +29,7 @@ type HTTPClient struct {
 	UnlockMethod string
 
 	// HTTP
-	Client   *http.Client
+	Client   *retryablehttp.Client
 	Username string
 	Password string
 
@@ -128,18 +37,26 @@ type HTTPClient struct {
 	jsonLockInfo []byte
 }
 
-func (c *HTTPClient) httpRequest(method string, url *url.URL, data *[]byte, what string) (*http.Response, error) {
+func (c *httpClient) httpRequest(method string, url *url.URL, data *[]byte, what string) (*http.Response, error) {
+	return c.httpRequestWithHeader(method, url, data, nil, what)
+}
+
+func (c *httpClient) httpRequestWithHeader(method string, url *url.URL, data *[]byte, header http.Header, what string) (*http.Response, error) {
 	// If we have data we need a reader
-	var reader io.Reader = nil
+	var reader io.ReadSeeker
 	if data != nil {
 		reader = bytes.NewReader(*data)
 	}
 
 	// Create the request
-	req, err := http.NewRequest(method, url.String(), reader)
+	req, err := retryablehttp.NewRequest(method, url.String(), reader)
 	if err != nil {
 		return nil, fmt.Errorf("Failed to make %s HTTP request: %s", what, err)
 	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
 	// Setup basic auth
 	if c.Username != "" {
 		req.SetBasicAuth(c.Username, c.Password)
@@ -165,7 +82,7 @@ func (c *HTTPClient) httpRequest(method string, url *url.URL, data *[]byte, what
 	return resp, nil
 }
 
-func (c *HTTPClient) Lock(info *state.LockInfo) (string, error) {
+func (c *httpClient) Lock(info *state.LockInfo) (string, error) {
 	if c.LockURL == nil {
 		return "", nil
 	}
@@ -188,7 +105,6 @@ func (c *HTTPClient) Lock(info *state.LockInfo) (string, error) {
 	case http.StatusForbidden:
 		return "", fmt.Errorf("HTTP remote state endpoint invalid auth")
 	case http.StatusConflict, http.StatusLocked:
-		defer resp.Body.Close()
 		body, err := ioutil.ReadAll(resp.Body)
 		if err != nil {
 			return "", fmt.Errorf("HTTP remote state already locked, failed to read body")
@@ -198,13 +114,16 @@ func (c *HTTPClient) Lock(info *state.LockInfo) (string, error) {
 		if err != nil {
 			return "", fmt.Errorf("HTTP remote state already locked, failed to unmarshal body")
 		}
-		return "", fmt.Errorf("HTTP remote state already locked: ID=%s", existing.ID)
+		return "", &state.LockError{
+			Info: &existing,
+			Err:  fmt.Errorf("HTTP remote state already locked: ID=%s", existing.ID),
+		}
 	default:
 		return "", fmt.Errorf("Unexpected HTTP response code %d", resp.StatusCode)
 	}
 }
 
-func (c *HTTPClient) Unlock(id string) error {
+func (c *httpClient) Unlock(id string) error {
 	if c.UnlockURL == nil {
 		return nil
 	}
@@ -223,8 +142,19 @@ func (c *HTTPClient) Unlock(id string) error {
 	}
 }
 
-func (c *HTTPClient) Get() (*Payload, error) {
-	resp, err := c.httpRequest("GET", c.URL, nil, "get state")
+func (c *httpClient) Get() (*remote.Payload, error) {
+	return c.GetIfChanged("")
+}
+
+// GetIfChanged implements remote.ClientConditionalGetter, using the ETag
+// of the state as its version.
+func (c *httpClient) GetIfChanged(version string) (*remote.Payload, error) {
+	var header http.Header
+	if version != "" {
+		header = http.Header{"If-None-Match": []string{version}}
+	}
+
+	resp, err := c.httpRequestWithHeader("GET", c.URL, nil, header, "get state")
 	if err != nil {
 		return nil, err
 	}
@@ -234,6 +164,8 @@ func (c *HTTPClient) Get() (*Payload, error) {
 	switch resp.StatusCode {
 	case http.StatusOK:
 		// Handled after
+	case http.StatusNotModified:
+		return nil, remote.ErrNotModified
 	case http.StatusNoContent:
 		return nil, nil
 	case http.StatusNotFound:
@@ -255,8 +187,9 @@ func (c *HTTPClient) Get() (*Payload, error) {
 	}
 
 	// Create the payload
-	payload := &Payload{
-		Data: buf.Bytes(),
+	payload := &remote.Payload{
+		Data:    buf.Bytes(),
+		Version: resp.Header.Get("ETag"),
 	}
 
 	// If there was no data, then return nil
@@ -282,7 +215,7 @@ func (c *HTTPClient) Get() (*Payload, error) {
 	return payload, nil
 }
 
-func (c *HTTPClient) Put(data []byte) error {
+func (c *httpClient) Put(data []byte) error {
 	// Copy the target URL
 	base := *c.URL
 
@@ -292,15 +225,6 @@ func (c *HTTPClient) Put(data []byte) error {
 		base.RawQuery = query.Encode()
 	}
 
-	/*
-		// Set the force query parameter if needed
-		if force {
-			values := base.Query()
-			values.Set("force", "true")
-			base.RawQuery = values.Encode()
-		}
-	*/
-
 	var method string = "POST"
 	if c.UpdateMethod != "" {
 		method = c.UpdateMethod
@@ -313,14 +237,14 @@ func (c *HTTPClient) Put(data []byte) error {
 
 	// Handle the error codes
 	switch resp.StatusCode {
-	case http.StatusOK:
+	case http.StatusOK, http.StatusCreated, http.StatusNoContent:
 		return nil
 	default:
 		return fmt.Errorf("HTTP error: %d", resp.StatusCode)
 	}
 }
 
-func (c *HTTPClient) Delete() error {
+func (c *httpClient) Delete() error {
 	// Make the request
 	resp, err := c.httpRequest("DELETE", c.URL, nil, "delete state")
 	if err != nil {
@@ -0,0 +1,282 @@
+package http
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform/backend"
+	"github.com/hashicorp/terraform/state"
+	"github.com/hashicorp/terraform/state/remote"
+)
+
+func TestHTTPClient_impl(t *testing.T) {
+	var _ remote.Client = new(httpClient)
+	var _ remote.ClientLocker = new(httpClient)
+}
+
+func TestHTTPClient(t *testing.T) {
+	handler := new(testHTTPHandler)
+	ts := httptest.NewServer(http.HandlerFunc(handler.Handle))
+	defer ts.Close()
+
+	// Test basic get/update
+	client := testHTTPClient(t, map[string]interface{}{
+		"address": ts.URL,
+	})
+	remote.TestClient(t, client)
+
+	// Test locking and alternative update_method
+	conf := map[string]interface{}{
+		"address":        ts.URL,
+		"update_method":  "PUT",
+		"lock_address":   ts.URL,
+		"unlock_address": ts.URL,
+	}
+	a := testHTTPClient(t, conf)
+	b := testHTTPClient(t, conf)
+	remote.TestRemoteLocks(t, a, b)
+}
+
+func TestHTTPClient_lockError(t *testing.T) {
+	handler := new(testHTTPHandler)
+	ts := httptest.NewServer(http.HandlerFunc(handler.Handle))
+	defer ts.Close()
+
+	conf := map[string]interface{}{
+		"address":      ts.URL,
+		"lock_address": ts.URL,
+	}
+	a := testHTTPClient(t, conf)
+	b := testHTTPClient(t, conf)
+
+	info := state.NewLockInfo()
+	info.Operation = "test"
+	if _, err := a.Lock(info); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	_, err := b.Lock(state.NewLockInfo())
+	lockErr, ok := err.(*state.LockError)
+	if !ok {
+		t.Fatalf("expected a *state.LockError, got %#v", err)
+	}
+	if lockErr.Info.ID != info.ID {
+		t.Fatalf("wrong lock ID %q; want %q", lockErr.Info.ID, info.ID)
+	}
+}
+
+func TestHTTPClient_retry(t *testing.T) {
+	handler := new(testHTTPHandler)
+	failures := 2
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failures > 0 {
+			failures--
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		handler.Handle(w, r)
+	}))
+	defer ts.Close()
+
+	handler.Data = []byte("{}")
+
+	client := testHTTPClient(t, map[string]interface{}{
+		"address":        ts.URL,
+		"retry_wait_min": 0,
+		"retry_wait_max": 0,
+	})
+	payload, err := client.Get()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if string(payload.Data) != "{}" {
+		t.Fatalf("wrong payload %q", payload.Data)
+	}
+
+	// With too few retries the request fails.
+	failures = 2
+	client = testHTTPClient(t, map[string]interface{}{
+		"address":        ts.URL,
+		"retry_max":      1,
+		"retry_wait_min": 0,
+		"retry_wait_max": 0,
+	})
+	if _, err := client.Get(); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestHTTPClient_clientCertificate(t *testing.T) {
+	certPEM, keyPEM := testCertificate(t)
+
+	handler := new(testHTTPHandler)
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(handler.Handle))
+	ts.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	ts.StartTLS()
+	defer ts.Close()
+
+	caPEM := pem.EncodeToMemory(&pem.Block{
+		Type:  "CERTIFICATE",
+		Bytes: ts.Certificate().Raw,
+	})
+
+	client := testHTTPClient(t, map[string]interface{}{
+		"address":                   ts.URL,
+		"client_ca_certificate_pem": string(caPEM),
+		"client_certificate_pem":    string(certPEM),
+		"client_private_key_pem":    string(keyPEM),
+		"retry_max":                 0,
+	})
+	remote.TestClient(t, client)
+
+	// Without the client certificate the server refuses the connection.
+	client = testHTTPClient(t, map[string]interface{}{
+		"address":                   ts.URL,
+		"client_ca_certificate_pem": string(caPEM),
+		"retry_max":                 0,
+	})
+	if _, err := client.Get(); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+// testCertificate returns a new self-signed client certificate and its
+// private key, PEM-encoded.
+func testCertificate(t *testing.T) ([]byte, []byte) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "terraform"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	return certPEM, keyPEM
+}
+
+func TestHTTPClient_cache(t *testing.T) {
+	td, err := ioutil.TempDir("", "tf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(td)
+
+	defer os.Setenv(remote.EnvStateCacheDir, os.Getenv(remote.EnvStateCacheDir))
+	os.Setenv(remote.EnvStateCacheDir, td)
+
+	handler := new(testHTTPHandler)
+	ts := httptest.NewServer(http.HandlerFunc(handler.Handle))
+	defer ts.Close()
+
+	b := backend.TestBackendConfig(t, New(), map[string]interface{}{
+		"address": ts.URL,
+	})
+	s, err := b.State(backend.DefaultStateName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, ok := s.(*remote.State).Client.(*remote.CachedClient)
+	if !ok {
+		t.Fatalf("expected a cached client, got %T", s.(*remote.State).Client)
+	}
+	remote.TestClient(t, client)
+
+	handler.Data = []byte("{}")
+	handler.Downloads = 0
+	for i := 0; i < 3; i++ {
+		payload, err := client.Get()
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		if string(payload.Data) != "{}" {
+			t.Fatalf("wrong payload %q", payload.Data)
+		}
+	}
+	if handler.Downloads != 1 {
+		t.Fatalf("expected 1 download, got %d", handler.Downloads)
+	}
+}
+
+type testHTTPHandler struct {
+	sync.Mutex
+	Data     []byte
+	LockInfo []byte
+
+	// Downloads counts the GET requests that returned the state.
+	Downloads int
+}
+
+func (h *testHTTPHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	h.Lock()
+	defer h.Unlock()
+
+	switch r.Method {
+	case "GET":
+		etag := fmt.Sprintf(`"%x"`, md5.Sum(h.Data))
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		h.Downloads++
+		w.Header().Set("ETag", etag)
+		w.Write(h.Data)
+	case "POST", "PUT":
+		buf := new(bytes.Buffer)
+		if _, err := io.Copy(buf, r.Body); err != nil {
+			w.WriteHeader(500)
+		}
+
+		h.Data = buf.Bytes()
+	case "LOCK":
+		if h.LockInfo != nil {
+			w.WriteHeader(423)
+			w.Write(h.LockInfo)
+			return
+		}
+		buf := new(bytes.Buffer)
+		io.Copy(buf, r.Body)
+		h.LockInfo = buf.Bytes()
+	case "UNLOCK":
+		h.LockInfo = nil
+	case "DELETE":
+		h.Data = nil
+		w.WriteHeader(200)
+	default:
+		w.WriteHeader(500)
+		w.Write([]byte(fmt.Sprintf("Unknown method: %s", r.Method)))
+	}
+}
@@ -1,10 +1,12 @@
 package inmem
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"sort"
+	"strconv"
 	"sync"
 	"time"
 
@@ -38,6 +40,8 @@ func Reset() {
 	locks = lockMap{
 		m: map[string]*state.LockInfo{},
 	}
+
+	SetFaults(Faults{})
 }
 
 // New creates a new backend for Inmem remote state.
@@ -50,6 +54,21 @@ func New() backend.Backend {
 				Optional:    true,
 				Description: "initializes the state in a locked configuration",
 			},
+			"latency": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "delays every read, write and lock of a state",
+			},
+			"write_conflicts": &schema.Schema{
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Description: "the number of writes to each state that fail",
+			},
+			"lock_contention": &schema.Schema{
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Description: "the number of attempts to lock each state that fail",
+			},
 		},
 	}
 	backend := &Backend{Backend: s}
@@ -65,6 +84,31 @@ func (b *Backend) configure(ctx context.Context) error {
 	states.Lock()
 	defer states.Unlock()
 
+	data := schema.FromContextBackendConfig(ctx)
+
+	// configure the faults to inject, unless they're set with SetFaults
+	var f Faults
+	var hasFaults bool
+	if v, ok := data.GetOk("latency"); ok {
+		latency, err := time.ParseDuration(v.(string))
+		if err != nil {
+			return fmt.Errorf("invalid latency %q: %s", v, err)
+		}
+		f.Latency = latency
+		hasFaults = true
+	}
+	if v, ok := data.GetOk("write_conflicts"); ok {
+		f.WriteConflicts = v.(int)
+		hasFaults = true
+	}
+	if v, ok := data.GetOk("lock_contention"); ok {
+		f.LockContention = v.(int)
+		hasFaults = true
+	}
+	if hasFaults {
+		SetFaults(f)
+	}
+
 	defaultClient := &RemoteClient{
 		Name: backend.DefaultStateName,
 	}
@@ -74,7 +118,6 @@ func (b *Backend) configure(ctx context.Context) error {
 	}
 
 	// set the default client lock info per the test config
-	data := schema.FromContextBackendConfig(ctx)
 	if v, ok := data.GetOk("lock_id"); ok && v.(string) != "" {
 		info := state.NewLockInfo()
 		info.ID = v.(string)
@@ -150,6 +193,53 @@ func (b *Backend) State(name string) (state.State, error) {
 	return s, nil
 }
 
+// StateVersions lists every state written to the named workspace, newest
+// first. Their IDs count the writes, starting from 1.
+func (b *Backend) StateVersions(name string) ([]*backend.StateVersion, error) {
+	c, err := b.remoteClient(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var versions []*backend.StateVersion
+	for i := len(c.Versions) - 1; i >= 0; i-- {
+		versions = append(versions, &backend.StateVersion{
+			ID:           strconv.Itoa(i + 1),
+			LastModified: c.Versions[i].Written,
+			Size:         int64(len(c.Versions[i].Data)),
+			Current:      i == len(c.Versions)-1 && c.Data != nil,
+		})
+	}
+
+	return versions, nil
+}
+
+// StateVersion reads a state written to the named workspace.
+func (b *Backend) StateVersion(name, id string) (*terraform.State, error) {
+	c, err := b.remoteClient(name)
+	if err != nil {
+		return nil, err
+	}
+
+	i, err := strconv.Atoi(id)
+	if err != nil || i < 1 || i > len(c.Versions) {
+		return nil, fmt.Errorf("state %q has no version %q", name, id)
+	}
+
+	return terraform.ReadState(bytes.NewReader(c.Versions[i-1].Data))
+}
+
+func (b *Backend) remoteClient(name string) (*RemoteClient, error) {
+	states.Lock()
+	defer states.Unlock()
+
+	s := states.m[name]
+	if s == nil {
+		return nil, fmt.Errorf("state %q not found", name)
+	}
+	return s.Client.(*RemoteClient), nil
+}
+
 type stateMap struct {
 	sync.Mutex
 	m map[string]*remote.State
@@ -10,6 +10,7 @@ import (
 
 func TestBackend_impl(t *testing.T) {
 	var _ backend.Backend = new(Backend)
+	var _ backend.StateVersioner = new(Backend)
 }
 
 func TestBackendConfig(t *testing.T) {
@@ -37,6 +38,35 @@ func TestBackendConfig(t *testing.T) {
 	}
 }
 
+func TestBackendConfig_faults(t *testing.T) {
+	defer Reset()
+
+	config := map[string]interface{}{
+		"latency":         "5ms",
+		"write_conflicts": 1,
+	}
+	b := backend.TestBackendConfig(t, New(), config).(*Backend)
+
+	// the write creating the workspace conflicts
+	if _, err := b.State("workspace"); err == nil {
+		t.Fatal("expected a write conflict creating the workspace")
+	}
+
+	s, err := b.State(backend.DefaultStateName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.WriteState(terraform.NewState()); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.PersistState(); err != ErrWriteConflict {
+		t.Fatalf("expected a write conflict, got %v", err)
+	}
+	if err := s.PersistState(); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestBackend(t *testing.T) {
 	defer Reset()
 	b := backend.TestBackendConfig(t, New(), nil).(*Backend)
@@ -88,3 +118,46 @@ func TestRemoteState(t *testing.T) {
 		t.Fatal("saved state has incorrect lineage")
 	}
 }
+
+func TestBackend_stateVersions(t *testing.T) {
+	defer Reset()
+	b := backend.TestBackendConfig(t, New(), nil).(*Backend)
+
+	s, err := b.State("workspace")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The workspace was created with an empty state, so write another.
+	newState := terraform.NewState()
+	newState.Serial = 5
+	if err := s.WriteState(newState); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.PersistState(); err != nil {
+		t.Fatal(err)
+	}
+
+	versions, err := b.StateVersions("workspace")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 2 {
+		t.Fatalf("expected 2 versions, got %d", len(versions))
+	}
+	if versions[0].ID != "2" || !versions[0].Current || versions[1].ID != "1" || versions[1].Current {
+		t.Fatalf("bad versions: %#v, %#v", versions[0], versions[1])
+	}
+
+	first, err := b.StateVersion("workspace", "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Lineage == newState.Lineage {
+		t.Fatal("read the wrong version")
+	}
+
+	if _, err := b.StateVersion("workspace", "3"); err == nil {
+		t.Fatal("expected an error reading a missing version")
+	}
+}
@@ -2,6 +2,7 @@ package inmem
 
 import (
 	"crypto/md5"
+	"time"
 
 	"github.com/hashicorp/terraform/state"
 	"github.com/hashicorp/terraform/state/remote"
@@ -12,9 +13,20 @@ type RemoteClient struct {
 	Data []byte
 	MD5  []byte
 	Name string
+
+	// Versions holds every state written, oldest first.
+	Versions []*Version
+}
+
+// Version is a state written to a RemoteClient.
+type Version struct {
+	Data    []byte
+	Written time.Time
 }
 
 func (c *RemoteClient) Get() (*remote.Payload, error) {
+	faults.delay()
+
 	if c.Data == nil {
 		return nil, nil
 	}
@@ -26,10 +38,16 @@ func (c *RemoteClient) Get() (*remote.Payload, error) {
 }
 
 func (c *RemoteClient) Put(data []byte) error {
+	faults.delay()
+	if err := faults.write(c.Name); err != nil {
+		return err
+	}
+
 	md5 := md5.Sum(data)
 
 	c.Data = data
 	c.MD5 = md5[:]
+	c.Versions = append(c.Versions, &Version{Data: data, Written: time.Now().UTC()})
 	return nil
 }
 
@@ -40,8 +58,14 @@ func (c *RemoteClient) Delete() error {
 }
 
 func (c *RemoteClient) Lock(info *state.LockInfo) (string, error) {
+	faults.delay()
+	if err := faults.lock(c.Name); err != nil {
+		return "", err
+	}
+
 	return locks.lock(c.Name, info)
 }
+
 func (c *RemoteClient) Unlock(id string) error {
 	return locks.unlock(c.Name, id)
 }
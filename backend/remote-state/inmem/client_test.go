@@ -2,8 +2,10 @@ package inmem
 
 import (
 	"testing"
+	"time"
 
 	"github.com/hashicorp/terraform/backend"
+	"github.com/hashicorp/terraform/state"
 	"github.com/hashicorp/terraform/state/remote"
 )
 
@@ -33,3 +35,51 @@ func TestInmemLocks(t *testing.T) {
 
 	remote.TestRemoteLocks(t, s.(*remote.State).Client, s.(*remote.State).Client)
 }
+
+func TestRemoteClient_faults(t *testing.T) {
+	defer Reset()
+	SetFaults(Faults{
+		Latency:        10 * time.Millisecond,
+		WriteConflicts: 1,
+		LockContention: 2,
+	})
+
+	c := &RemoteClient{Name: "faults"}
+
+	start := time.Now()
+	if _, err := c.Get(); err != nil {
+		t.Fatal(err)
+	}
+	if time.Since(start) < 10*time.Millisecond {
+		t.Fatal("expected the read to be delayed")
+	}
+
+	if err := c.Put([]byte("state")); err != ErrWriteConflict {
+		t.Fatalf("expected a write conflict, got %v", err)
+	}
+	if err := c.Put([]byte("state")); err != nil {
+		t.Fatal(err)
+	}
+
+	info := state.NewLockInfo()
+	for i := 0; i < 2; i++ {
+		_, err := c.Lock(info)
+		lockErr, ok := err.(*state.LockError)
+		if !ok || lockErr.Info == nil || lockErr.Info.ID == "" {
+			t.Fatalf("expected a lock error, got %v", err)
+		}
+	}
+	id, err := c.Lock(info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Unlock(id); err != nil {
+		t.Fatal(err)
+	}
+
+	// the faults apply to each state separately
+	other := &RemoteClient{Name: "other"}
+	if err := other.Put([]byte("state")); err != ErrWriteConflict {
+		t.Fatalf("expected a write conflict, got %v", err)
+	}
+}
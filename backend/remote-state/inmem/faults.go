@@ -0,0 +1,97 @@
+package inmem
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform/state"
+)
+
+// ErrWriteConflict is returned by the writes that fail because of the
+// injected Faults.
+var ErrWriteConflict = errors.New("write conflict: state was modified concurrently")
+
+// Faults are the failures injected into every instance of the inmem backend,
+// to exercise how callers of the backend interface handle a slow or
+// contended remote store.
+type Faults struct {
+	// Latency delays every read, write and lock of a state.
+	Latency time.Duration
+
+	// WriteConflicts is the number of writes to each state that fail with
+	// ErrWriteConflict before the following writes succeed.
+	WriteConflicts int
+
+	// LockContention is the number of attempts to lock each state that fail,
+	// as if another process held the lock, before the lock can be acquired.
+	LockContention int
+}
+
+// the faults are global, like the states and locks they apply to.
+var faults faultMap
+
+// SetFaults replaces the faults injected into the backend, and restarts the
+// count of the writes and lock attempts of each state. The faults are
+// cleared by Reset.
+func SetFaults(f Faults) {
+	faults.Lock()
+	defer faults.Unlock()
+
+	faults.Faults = f
+	faults.writes = map[string]int{}
+	faults.locks = map[string]int{}
+}
+
+type faultMap struct {
+	sync.Mutex
+	Faults
+
+	// the number of writes and lock attempts for each state
+	writes map[string]int
+	locks  map[string]int
+}
+
+// delay waits for the injected latency.
+func (f *faultMap) delay() {
+	f.Lock()
+	latency := f.Latency
+	f.Unlock()
+
+	if latency > 0 {
+		time.Sleep(latency)
+	}
+}
+
+// write returns ErrWriteConflict if the write to the named state must fail.
+func (f *faultMap) write(name string) error {
+	f.Lock()
+	defer f.Unlock()
+
+	f.writes[name]++
+	if f.writes[name] <= f.WriteConflicts {
+		return ErrWriteConflict
+	}
+	return nil
+}
+
+// lock returns a LockError if the attempt to lock the named state must fail.
+func (f *faultMap) lock(name string) error {
+	f.Lock()
+	defer f.Unlock()
+
+	f.locks[name]++
+	if f.locks[name] <= f.LockContention {
+		info := state.NewLockInfo()
+		info.ID = "inmem-contention"
+		info.Operation = "contention"
+		info.Info = "injected lock contention"
+		info.Created = time.Now().UTC()
+
+		return &state.LockError{
+			Err:  errors.New("state locked"),
+			Info: info,
+		}
+	}
+	return nil
+}
@@ -0,0 +1,309 @@
+package kubernetes
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+
+	cleanhttp "github.com/hashicorp/go-cleanhttp"
+	"github.com/hashicorp/terraform/backend"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+// The files that Kubernetes mounts into pods for their service account.
+const (
+	serviceAccountTokenFile     = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+	serviceAccountCACertFile    = "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt"
+	serviceAccountNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+)
+
+// nameRegexp matches the names that are valid both as part of a Kubernetes
+// object name and as a label value.
+var nameRegexp = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+// validateName checks that a secret suffix or workspace name can be used in
+// the names and labels of the backend's objects.
+func validateName(name string) error {
+	if len(name) > 63 || !nameRegexp.MatchString(name) {
+		return fmt.Errorf("%q must be at most 63 characters of lowercase letters, digits "+
+			"and '-', and must start and end with a letter or digit", name)
+	}
+	return nil
+}
+
+// New creates a new backend for Kubernetes remote state.
+func New() backend.Backend {
+	s := &schema.Backend{
+		Schema: map[string]*schema.Schema{
+			"secret_suffix": &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Suffix used when creating the secrets. Secrets will be named in the format: `tfstate-{workspace}-{secret_suffix}`",
+				ValidateFunc: func(v interface{}, k string) ([]string, []error) {
+					if err := validateName(v.(string)); err != nil {
+						return nil, []error{fmt.Errorf("%s: %s", k, err)}
+					}
+					return nil, nil
+				},
+			},
+
+			"labels": &schema.Schema{
+				Type:        schema.TypeMap,
+				Optional:    true,
+				Description: "Map of additional labels to be applied to the secrets and leases",
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+
+			"namespace": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("KUBE_NAMESPACE", ""),
+				Description: "Namespace to store the secrets and leases in; defaults to the namespace of the context, or `default`",
+			},
+
+			"in_cluster_config": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("KUBE_IN_CLUSTER_CONFIG", false),
+				Description: "Use the service account Kubernetes provides to pods",
+			},
+
+			"config_path": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("KUBE_CONFIG_PATH", ""),
+				Description: "Path to the kubeconfig file",
+			},
+
+			"config_context": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("KUBE_CTX", ""),
+				Description: "Context of the kubeconfig file to use instead of its current context",
+			},
+
+			"host": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("KUBE_HOST", ""),
+				Description: "The address of the Kubernetes API server",
+			},
+
+			"insecure": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("KUBE_INSECURE", false),
+				Description: "Whether to skip the verification of the server's certificate",
+			},
+
+			"token": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("KUBE_TOKEN", ""),
+				Description: "Token to authenticate a service account",
+			},
+
+			"client_certificate": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("KUBE_CLIENT_CERT_DATA", ""),
+				Description: "PEM-encoded client certificate for TLS authentication",
+			},
+
+			"client_key": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("KUBE_CLIENT_KEY_DATA", ""),
+				Description: "PEM-encoded client certificate key for TLS authentication",
+			},
+
+			"cluster_ca_certificate": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("KUBE_CLUSTER_CA_CERT_DATA", ""),
+				Description: "PEM-encoded root certificates bundle for TLS authentication",
+			},
+		},
+	}
+
+	result := &Backend{Backend: s}
+	result.Backend.ConfigureFunc = result.configure
+	return result
+}
+
+type Backend struct {
+	*schema.Backend
+
+	// The fields below are set from configure
+	kubeClient   *kubeClient
+	configData   *schema.ResourceData
+	secretSuffix string
+	labels       map[string]string
+}
+
+func (b *Backend) configure(ctx context.Context) error {
+	// Grab the resource data
+	b.configData = schema.FromContextBackendConfig(ctx)
+	data := b.configData
+
+	b.secretSuffix = data.Get("secret_suffix").(string)
+	b.labels = make(map[string]string)
+	for k, v := range data.Get("labels").(map[string]interface{}) {
+		b.labels[k] = v.(string)
+	}
+
+	conn, err := connectionConfig(data)
+	if err != nil {
+		return err
+	}
+
+	kubeClient, err := conn.client()
+	if err != nil {
+		return err
+	}
+	b.kubeClient = kubeClient
+	return nil
+}
+
+// connConfig holds the settings used to connect to the Kubernetes API.
+type connConfig struct {
+	Host       string
+	Insecure   bool
+	CACert     []byte
+	ClientCert []byte
+	ClientKey  []byte
+	Token      string
+	Namespace  string
+}
+
+// connectionConfig combines the connection settings of the service account
+// or the kubeconfig file with those set in the configuration, which take
+// precedence.
+func connectionConfig(data *schema.ResourceData) (*connConfig, error) {
+	conn := &connConfig{}
+
+	if data.Get("in_cluster_config").(bool) {
+		var err error
+		if conn, err = inClusterConfig(); err != nil {
+			return nil, err
+		}
+	} else if path := data.Get("config_path").(string); path != "" {
+		var err error
+		if conn, err = loadKubeConfig(path, data.Get("config_context").(string)); err != nil {
+			return nil, err
+		}
+	}
+
+	if v := data.Get("host").(string); v != "" {
+		conn.Host = v
+	}
+	if v := data.Get("insecure").(bool); v {
+		conn.Insecure = v
+	}
+	if v := data.Get("token").(string); v != "" {
+		conn.Token = v
+	}
+	if v := data.Get("client_certificate").(string); v != "" {
+		conn.ClientCert = []byte(v)
+	}
+	if v := data.Get("client_key").(string); v != "" {
+		conn.ClientKey = []byte(v)
+	}
+	if v := data.Get("cluster_ca_certificate").(string); v != "" {
+		conn.CACert = []byte(v)
+	}
+	if v := data.Get("namespace").(string); v != "" {
+		conn.Namespace = v
+	}
+	if conn.Namespace == "" {
+		conn.Namespace = "default"
+	}
+
+	if conn.Host == "" {
+		return nil, fmt.Errorf("the address of the Kubernetes API server must be set " +
+			"with host, config_path or in_cluster_config")
+	}
+	return conn, nil
+}
+
+// inClusterConfig returns the connection settings of the service account of
+// the pod that Terraform is running in.
+func inClusterConfig() (*connConfig, error) {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return nil, fmt.Errorf("in_cluster_config is set, but KUBERNETES_SERVICE_HOST " +
+			"and KUBERNETES_SERVICE_PORT are not, so Terraform isn't running in a pod")
+	}
+
+	token, err := ioutil.ReadFile(serviceAccountTokenFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the service account token: %s", err)
+	}
+	caCert, err := ioutil.ReadFile(serviceAccountCACertFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the service account CA certificate: %s", err)
+	}
+	// The namespace is optional, so it's ignored if it can't be read.
+	namespace, _ := ioutil.ReadFile(serviceAccountNamespaceFile)
+
+	return &connConfig{
+		Host:      "https://" + net.JoinHostPort(host, port),
+		Token:     strings.TrimSpace(string(token)),
+		CACert:    caCert,
+		Namespace: strings.TrimSpace(string(namespace)),
+	}, nil
+}
+
+func (c *connConfig) client() (*kubeClient, error) {
+	host := c.Host
+	if !strings.Contains(host, "://") {
+		host = "https://" + host
+	}
+	u, err := url.Parse(host)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the host %q: %s", c.Host, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("the host %q must use the http or https scheme", c.Host)
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: c.Insecure}
+	if len(c.CACert) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(c.CACert) {
+			return nil, fmt.Errorf("failed to parse the cluster CA certificate")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	switch {
+	case len(c.ClientCert) > 0 && len(c.ClientKey) > 0:
+		cert, err := tls.X509KeyPair(c.ClientCert, c.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the client certificate: %s", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	case len(c.ClientCert) > 0:
+		return nil, fmt.Errorf("client_key must be set when client_certificate is set")
+	case len(c.ClientKey) > 0:
+		return nil, fmt.Errorf("client_certificate must be set when client_key is set")
+	}
+
+	transport := cleanhttp.DefaultPooledTransport()
+	transport.TLSClientConfig = tlsConfig
+
+	return &kubeClient{
+		Client:    &http.Client{Transport: transport},
+		Host:      u,
+		Token:     c.Token,
+		Namespace: c.Namespace,
+	}, nil
+}
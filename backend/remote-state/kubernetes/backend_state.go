@@ -0,0 +1,131 @@
+package kubernetes
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform/backend"
+	"github.com/hashicorp/terraform/state"
+	"github.com/hashicorp/terraform/state/remote"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func (b *Backend) States() ([]string, error) {
+	// The chunks of large states are excluded, since they're labeled with
+	// the workspace of their state too.
+	selector := fmt.Sprintf("%s=true,%s=%s,!%s",
+		tfstateKey, tfstateSecretSuffixLabel, b.secretSuffix, tfstateChunkLabel)
+	secrets, err := b.kubeClient.ListSecrets(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, secret := range secrets {
+		name := secret.Metadata.Labels[tfstateWorkspaceLabel]
+		if name == "" || name == backend.DefaultStateName {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// the default state always exists
+	return append([]string{backend.DefaultStateName}, names...), nil
+}
+
+func (b *Backend) DeleteState(name string) error {
+	if name == backend.DefaultStateName || name == "" {
+		return fmt.Errorf("can't delete default state")
+	}
+
+	client := b.remoteClient(name)
+
+	// We just delete it without any locking since the DeleteState API is
+	// documented as such.
+	if err := client.Delete(); err != nil {
+		return err
+	}
+	if err := b.kubeClient.DeleteLease(client.leaseName()); err != nil && !isNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+func (b *Backend) State(name string) (state.State, error) {
+	if err := validateName(name); err != nil {
+		return nil, fmt.Errorf("invalid workspace name for the Kubernetes backend: %s", err)
+	}
+
+	// Build the state client
+	stateMgr := &remote.State{Client: b.remoteClient(name)}
+
+	// the default state always exists
+	if name == backend.DefaultStateName {
+		return stateMgr, nil
+	}
+
+	// Grab a lock, we use this to write an empty state if one doesn't
+	// exist already. We have to write an empty state as a sentinel value
+	// so States() knows it exists.
+	lockInfo := state.NewLockInfo()
+	lockInfo.Operation = "init"
+	lockId, err := stateMgr.Lock(lockInfo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock state in Kubernetes: %s", err)
+	}
+
+	// Local helper function so we can call it multiple places
+	lockUnlock := func(parent error) error {
+		if err := stateMgr.Unlock(lockId); err != nil {
+			return fmt.Errorf(strings.TrimSpace(errStateUnlock), lockId, err)
+		}
+
+		return parent
+	}
+
+	// Grab the value
+	if err := stateMgr.RefreshState(); err != nil {
+		err = lockUnlock(err)
+		return nil, err
+	}
+
+	// If we have no state, we have to create an empty state
+	if v := stateMgr.State(); v == nil {
+		if err := stateMgr.WriteState(terraform.NewState()); err != nil {
+			err = lockUnlock(err)
+			return nil, err
+		}
+		if err := stateMgr.PersistState(); err != nil {
+			err = lockUnlock(err)
+			return nil, err
+		}
+	}
+
+	// Unlock, the state should now be initialized
+	if err := lockUnlock(nil); err != nil {
+		return nil, err
+	}
+
+	return stateMgr, nil
+}
+
+func (b *Backend) remoteClient(name string) *RemoteClient {
+	return &RemoteClient{
+		kubeClient:   b.kubeClient,
+		Workspace:    name,
+		SecretSuffix: b.secretSuffix,
+		Labels:       b.labels,
+	}
+}
+
+const errStateUnlock = `
+Error unlocking Kubernetes state. Lock ID: %s
+
+Error: %s
+
+You may have to force-unlock this state in order to use it again.
+The Kubernetes backend acquires a lock during initialization to ensure
+the minimum required secrets are prepared.
+`
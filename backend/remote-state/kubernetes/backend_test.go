@@ -0,0 +1,422 @@
+package kubernetes
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/hashicorp/terraform/backend"
+	"github.com/hashicorp/terraform/config"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestBackend_impl(t *testing.T) {
+	var _ backend.Backend = New()
+}
+
+func TestBackend(t *testing.T) {
+	ts := httptest.NewServer(newTestKubeServer())
+	defer ts.Close()
+
+	b1 := testBackend(t, ts.URL, nil)
+	b2 := testBackend(t, ts.URL, nil)
+	backend.TestBackend(t, b1, b2)
+}
+
+func TestBackend_labels(t *testing.T) {
+	server := newTestKubeServer()
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	b := testBackend(t, ts.URL, map[string]interface{}{
+		"labels": map[string]interface{}{
+			"team":    "infra",
+			"tfstate": "false",
+		},
+	})
+	if _, err := b.State("foo"); err != nil {
+		t.Fatal(err)
+	}
+
+	secret := server.object(t, "secrets", "tfstate-foo-test")
+	labels := secret["metadata"].(map[string]interface{})["labels"].(map[string]interface{})
+	if labels["team"] != "infra" {
+		t.Fatalf("missing configured label: %#v", labels)
+	}
+	if labels["tfstate"] != "true" || labels["tfstateWorkspace"] != "foo" {
+		t.Fatalf("wrong labels: %#v", labels)
+	}
+
+	// A backend with another suffix doesn't see the workspace.
+	other := testBackend(t, ts.URL, map[string]interface{}{
+		"secret_suffix": "other",
+	})
+	states, err := other.States()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(states) != 1 || states[0] != backend.DefaultStateName {
+		t.Fatalf("wrong states: %#v", states)
+	}
+}
+
+func TestBackend_invalidWorkspace(t *testing.T) {
+	ts := httptest.NewServer(newTestKubeServer())
+	defer ts.Close()
+
+	b := testBackend(t, ts.URL, nil)
+	for _, name := range []string{"Foo", "foo_bar", "-foo", strings.Repeat("a", 64)} {
+		if _, err := b.State(name); err == nil {
+			t.Fatalf("expected error for workspace %q", name)
+		}
+	}
+}
+
+func TestBackendConfig_kubeConfig(t *testing.T) {
+	td, err := ioutil.TempDir("", "tf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(td)
+
+	if err := ioutil.WriteFile(filepath.Join(td, "token"), []byte("file-token\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	kubeConfig := `
+apiVersion: v1
+kind: Config
+current-context: first
+clusters:
+- name: first
+  cluster:
+    server: https://first.example.com
+- name: second
+  cluster:
+    server: https://second.example.com
+    insecure-skip-tls-verify: true
+contexts:
+- name: first
+  context:
+    cluster: first
+    user: first
+- name: second
+  context:
+    cluster: second
+    user: second
+    namespace: terraform
+users:
+- name: first
+  user:
+    token: first-token
+- name: second
+  user:
+    tokenFile: token
+`
+	path := filepath.Join(td, "config")
+	if err := ioutil.WriteFile(path, []byte(kubeConfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// current context
+	b := backend.TestBackendConfig(t, New(), map[string]interface{}{
+		"secret_suffix": "test",
+		"config_path":   path,
+	}).(*Backend)
+	if b.kubeClient.Host.String() != "https://first.example.com" {
+		t.Fatalf("wrong host %q", b.kubeClient.Host)
+	}
+	if b.kubeClient.Token != "first-token" || b.kubeClient.Namespace != "default" {
+		t.Fatalf("wrong token %q or namespace %q", b.kubeClient.Token, b.kubeClient.Namespace)
+	}
+
+	// another context, with the token read relative to the kubeconfig file
+	b = backend.TestBackendConfig(t, New(), map[string]interface{}{
+		"secret_suffix":  "test",
+		"config_path":    path,
+		"config_context": "second",
+	}).(*Backend)
+	if b.kubeClient.Host.String() != "https://second.example.com" {
+		t.Fatalf("wrong host %q", b.kubeClient.Host)
+	}
+	if b.kubeClient.Token != "file-token" || b.kubeClient.Namespace != "terraform" {
+		t.Fatalf("wrong token %q or namespace %q", b.kubeClient.Token, b.kubeClient.Namespace)
+	}
+
+	// explicit settings take precedence over the kubeconfig file
+	b = backend.TestBackendConfig(t, New(), map[string]interface{}{
+		"secret_suffix":  "test",
+		"config_path":    path,
+		"config_context": "second",
+		"host":           "https://other.example.com",
+		"token":          "other-token",
+		"namespace":      "other",
+	}).(*Backend)
+	if b.kubeClient.Host.String() != "https://other.example.com" {
+		t.Fatalf("wrong host %q", b.kubeClient.Host)
+	}
+	if b.kubeClient.Token != "other-token" || b.kubeClient.Namespace != "other" {
+		t.Fatalf("wrong token %q or namespace %q", b.kubeClient.Token, b.kubeClient.Namespace)
+	}
+}
+
+func TestBackendConfig_invalid(t *testing.T) {
+	cases := map[string]map[string]interface{}{
+		"missing secret_suffix": {
+			"host": "https://127.0.0.1:6443",
+		},
+		"invalid secret_suffix": {
+			"host":          "https://127.0.0.1:6443",
+			"secret_suffix": "Test_Suffix",
+		},
+		"missing host": {
+			"secret_suffix": "test",
+		},
+		"bad host scheme": {
+			"host":          "ftp://127.0.0.1:6443",
+			"secret_suffix": "test",
+		},
+		"missing kubeconfig file": {
+			"config_path":   "/nonexistent/kubeconfig",
+			"secret_suffix": "test",
+		},
+		"certificate without key": {
+			"host":               "https://127.0.0.1:6443",
+			"secret_suffix":      "test",
+			"client_certificate": "cert",
+		},
+	}
+
+	for name, raw := range cases {
+		t.Run(name, func(t *testing.T) {
+			rc, err := config.NewRawConfig(raw)
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			conf := terraform.NewResourceConfig(rc)
+
+			b := New()
+			if _, errs := b.Validate(conf); len(errs) > 0 {
+				return
+			}
+			if err := b.Configure(conf); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
+
+// testBackend returns a backend configured to use the API server at host,
+// merging conf into the default test configuration.
+func testBackend(t *testing.T, host string, conf map[string]interface{}) *Backend {
+	t.Helper()
+
+	c := map[string]interface{}{
+		"host":          host,
+		"secret_suffix": "test",
+	}
+	for k, v := range conf {
+		c[k] = v
+	}
+	return backend.TestBackendConfig(t, New(), c).(*Backend)
+}
+
+// testKubeServer is a fake Kubernetes API server that stores objects in
+// memory. It implements the requests the backend makes for secrets and
+// leases, including label selectors and the resource version checks of
+// updates.
+type testKubeServer struct {
+	sync.Mutex
+	version int
+	// objects holds the JSON-encoded objects by kind and name.
+	objects map[string]map[string][]byte
+}
+
+func newTestKubeServer() *testKubeServer {
+	return &testKubeServer{
+		objects: map[string]map[string][]byte{
+			"secrets": {},
+			"leases":  {},
+		},
+	}
+}
+
+// object returns a stored object, decoded into a map.
+func (s *testKubeServer) object(t *testing.T, kind, name string) map[string]interface{} {
+	t.Helper()
+	s.Lock()
+	defer s.Unlock()
+
+	raw, ok := s.objects[kind][name]
+	if !ok {
+		t.Fatalf("no %s named %q", kind, name)
+	}
+	var obj map[string]interface{}
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		t.Fatal(err)
+	}
+	return obj
+}
+
+// names returns the names of the stored objects of a kind.
+func (s *testKubeServer) names(kind string) []string {
+	s.Lock()
+	defer s.Unlock()
+
+	var names []string
+	for name := range s.objects[kind] {
+		names = append(names, name)
+	}
+	return names
+}
+
+func (s *testKubeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.Lock()
+	defer s.Unlock()
+
+	path := r.URL.Path
+	for _, prefix := range []string{"/api/v1/namespaces/", "/apis/coordination.k8s.io/v1/namespaces/"} {
+		path = strings.TrimPrefix(path, prefix)
+	}
+	// namespace/kind[/name]
+	parts := strings.Split(path, "/")
+	if len(parts) < 2 || s.objects[parts[1]] == nil {
+		s.status(w, http.StatusNotFound, "NotFound", "unknown path "+r.URL.Path)
+		return
+	}
+	objects := s.objects[parts[1]]
+	name := ""
+	if len(parts) > 2 {
+		name = parts[2]
+	}
+
+	var body []byte
+	var meta struct {
+		Metadata ObjectMeta `json:"metadata"`
+	}
+	if r.Method == "POST" || r.Method == "PUT" {
+		var err error
+		if body, err = ioutil.ReadAll(r.Body); err == nil {
+			err = json.Unmarshal(body, &meta)
+		}
+		if err != nil {
+			s.status(w, http.StatusBadRequest, "BadRequest", err.Error())
+			return
+		}
+	}
+
+	switch {
+	case r.Method == "GET" && name == "":
+		items := []json.RawMessage{}
+		for _, raw := range objects {
+			var obj struct {
+				Metadata ObjectMeta `json:"metadata"`
+			}
+			json.Unmarshal(raw, &obj)
+			if matchSelector(r.URL.Query().Get("labelSelector"), obj.Metadata.Labels) {
+				items = append(items, raw)
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"items": items})
+
+	case r.Method == "POST" && name == "":
+		if _, ok := objects[meta.Metadata.Name]; ok {
+			s.status(w, http.StatusConflict, "AlreadyExists", meta.Metadata.Name+" already exists")
+			return
+		}
+		s.store(w, objects, meta.Metadata.Name, body)
+
+	case name == "":
+		s.status(w, http.StatusMethodNotAllowed, "MethodNotAllowed", r.Method)
+
+	case r.Method == "GET":
+		raw, ok := objects[name]
+		if !ok {
+			s.status(w, http.StatusNotFound, "NotFound", name+" not found")
+			return
+		}
+		w.Write(raw)
+
+	case r.Method == "PUT":
+		raw, ok := objects[name]
+		if !ok {
+			s.status(w, http.StatusNotFound, "NotFound", name+" not found")
+			return
+		}
+		var existing struct {
+			Metadata ObjectMeta `json:"metadata"`
+		}
+		json.Unmarshal(raw, &existing)
+		if v := meta.Metadata.ResourceVersion; v != "" && v != existing.Metadata.ResourceVersion {
+			s.status(w, http.StatusConflict, "Conflict", name+" has been modified")
+			return
+		}
+		s.store(w, objects, name, body)
+
+	case r.Method == "DELETE":
+		if _, ok := objects[name]; !ok {
+			s.status(w, http.StatusNotFound, "NotFound", name+" not found")
+			return
+		}
+		delete(objects, name)
+		s.status(w, http.StatusOK, "", "")
+
+	default:
+		s.status(w, http.StatusMethodNotAllowed, "MethodNotAllowed", r.Method)
+	}
+}
+
+// store saves an object with a new resource version, and responds with it.
+func (s *testKubeServer) store(w http.ResponseWriter, objects map[string][]byte, name string, body []byte) {
+	var obj map[string]interface{}
+	json.Unmarshal(body, &obj)
+
+	s.version++
+	obj["metadata"].(map[string]interface{})["resourceVersion"] = strconv.Itoa(s.version)
+
+	raw, _ := json.Marshal(obj)
+	objects[name] = raw
+	w.Write(raw)
+}
+
+func (s *testKubeServer) status(w http.ResponseWriter, code int, reason, message string) {
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"kind":    "Status",
+		"code":    code,
+		"reason":  reason,
+		"message": message,
+	})
+}
+
+// matchSelector implements the equality and does-not-exist requirements
+// of label selectors.
+func matchSelector(selector string, labels map[string]string) bool {
+	if selector == "" {
+		return true
+	}
+	for _, req := range strings.Split(selector, ",") {
+		if strings.HasPrefix(req, "!") {
+			if _, ok := labels[req[1:]]; ok {
+				return false
+			}
+			continue
+		}
+		kv := strings.SplitN(req, "=", 2)
+		if len(kv) != 2 {
+			panic(fmt.Sprintf("unsupported label selector %q", req))
+		}
+		if v, ok := labels[kv[0]]; !ok || v != kv[1] {
+			return false
+		}
+	}
+	return true
+}
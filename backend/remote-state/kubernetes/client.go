@@ -0,0 +1,348 @@
+package kubernetes
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/md5"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform/state"
+	"github.com/hashicorp/terraform/state/remote"
+)
+
+const (
+	tfstateKey                = "tfstate"
+	tfstateSecretSuffixLabel  = "tfstateSecretSuffix"
+	tfstateWorkspaceLabel     = "tfstateWorkspace"
+	tfstateChunkLabel         = "tfstateChunk"
+	managedByLabel            = "app.kubernetes.io/managed-by"
+	tfstateChunksAnnotation   = "terraform.io/chunks"
+	tfstateLockInfoAnnotation = "terraform.io/lock-info"
+
+	// microTimeFormat is the format of the timestamps of leases.
+	microTimeFormat = "2006-01-02T15:04:05.000000Z07:00"
+)
+
+// secretChunkSize is the maximum size of the state data stored in a single
+// secret. Kubernetes limits the size of objects to 1MiB, so larger states
+// are split across several secrets. It's a variable for testing.
+var secretChunkSize = 1000 * 1000
+
+// RemoteClient is a remote client that stores data in Kubernetes secrets.
+//
+// The gzipped state of a workspace is stored in the secret named
+// tfstate-{workspace}-{suffix}. If it doesn't fit in a single secret then
+// the rest is stored in chunk secrets, which the first secret lists in an
+// annotation. The names of the chunks are derived from the checksum of the
+// data, so that a new state is written next to the old one and replaces it
+// at once when the first secret is updated.
+type RemoteClient struct {
+	kubeClient   *kubeClient
+	Workspace    string
+	SecretSuffix string
+	Labels       map[string]string
+}
+
+func (c *RemoteClient) Get() (*remote.Payload, error) {
+	secret, err := c.kubeClient.GetSecret(c.secretName())
+	if err != nil {
+		if isNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	data := secret.Data[tfstateKey]
+	for _, name := range chunkNames(secret) {
+		chunk, err := c.kubeClient.GetSecret(name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the state chunk %q: %s", name, err)
+		}
+		data = append(data, chunk.Data[tfstateKey]...)
+	}
+
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	state, err := uncompress(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress the state: %s", err)
+	}
+
+	md5 := md5.Sum(state)
+	return &remote.Payload{
+		Data: state,
+		MD5:  md5[:],
+	}, nil
+}
+
+func (c *RemoteClient) Put(data []byte) error {
+	compressed, err := compress(data)
+	if err != nil {
+		return err
+	}
+
+	chunks := splitChunks(compressed, secretChunkSize)
+	sum := md5.Sum(compressed)
+	id := fmt.Sprintf("%x", sum[:4])
+
+	// Write the chunks before the first secret refers to them.
+	var names []string
+	for i, chunk := range chunks[1:] {
+		name := fmt.Sprintf("%s-%s-%d", c.secretName(), id, i+1)
+		labels := c.labels()
+		labels[tfstateChunkLabel] = strconv.Itoa(i + 1)
+
+		err := c.putSecret(&Secret{
+			Metadata: ObjectMeta{Name: name, Labels: labels},
+			Type:     "Opaque",
+			Data:     map[string][]byte{tfstateKey: chunk},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to write the state chunk %q: %s", name, err)
+		}
+		names = append(names, name)
+	}
+
+	existing, err := c.kubeClient.GetSecret(c.secretName())
+	if err != nil && !isNotFound(err) {
+		return err
+	}
+
+	secret := &Secret{
+		Metadata: ObjectMeta{Name: c.secretName(), Labels: c.labels()},
+		Type:     "Opaque",
+		Data:     map[string][]byte{tfstateKey: chunks[0]},
+	}
+	if len(names) > 0 {
+		secret.Metadata.Annotations = map[string]string{
+			tfstateChunksAnnotation: strings.Join(names, ","),
+		}
+	}
+
+	if existing == nil {
+		_, err = c.kubeClient.CreateSecret(secret)
+	} else {
+		secret.Metadata.ResourceVersion = existing.Metadata.ResourceVersion
+		_, err = c.kubeClient.UpdateSecret(secret)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Remove the chunks of the previous state that weren't reused.
+	return c.deleteChunks(chunkNames(existing), names)
+}
+
+func (c *RemoteClient) Delete() error {
+	secret, err := c.kubeClient.GetSecret(c.secretName())
+	if err != nil {
+		if isNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	if err := c.kubeClient.DeleteSecret(c.secretName()); err != nil && !isNotFound(err) {
+		return err
+	}
+	return c.deleteChunks(chunkNames(secret), nil)
+}
+
+func (c *RemoteClient) Lock(info *state.LockInfo) (string, error) {
+	now := time.Now().UTC().Format(microTimeFormat)
+	annotations := map[string]string{
+		tfstateLockInfoAnnotation: string(info.Marshal()),
+	}
+
+	lease, err := c.kubeClient.GetLease(c.leaseName())
+	switch {
+	case isNotFound(err):
+		_, err = c.kubeClient.CreateLease(&Lease{
+			Metadata: ObjectMeta{
+				Name:        c.leaseName(),
+				Labels:      c.labels(),
+				Annotations: annotations,
+			},
+			Spec: LeaseSpec{HolderIdentity: info.ID, AcquireTime: now},
+		})
+	case err != nil:
+		return "", err
+	case lease.Spec.HolderIdentity != "":
+		return "", c.lockError(lease)
+	default:
+		// The lease is kept after unlocking, so it's taken over while
+		// nobody holds it. The resource version of the lease that was
+		// read makes the update fail if another client took it since.
+		lease.Metadata.Annotations = annotations
+		lease.Spec = LeaseSpec{HolderIdentity: info.ID, AcquireTime: now}
+		_, err = c.kubeClient.UpdateLease(lease)
+	}
+
+	if isConflict(err) {
+		// Another client took the lock first.
+		lease, err := c.kubeClient.GetLease(c.leaseName())
+		if err != nil {
+			return "", &state.LockError{Err: fmt.Errorf("workspace %q is already locked", c.Workspace)}
+		}
+		return "", c.lockError(lease)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return info.ID, nil
+}
+
+func (c *RemoteClient) Unlock(id string) error {
+	lease, err := c.kubeClient.GetLease(c.leaseName())
+	if err != nil {
+		if isNotFound(err) {
+			return &state.LockError{Err: errors.New("state not locked")}
+		}
+		return err
+	}
+	if lease.Spec.HolderIdentity == "" {
+		return &state.LockError{Err: errors.New("state not locked")}
+	}
+
+	if lease.Spec.HolderIdentity != id {
+		lockErr := c.lockError(lease)
+		lockErr.Err = fmt.Errorf("lock id %q does not match existing lock", id)
+		return lockErr
+	}
+
+	delete(lease.Metadata.Annotations, tfstateLockInfoAnnotation)
+	lease.Spec = LeaseSpec{}
+	if _, err := c.kubeClient.UpdateLease(lease); err != nil {
+		if isConflict(err) {
+			return &state.LockError{Err: errors.New("the lock was modified while unlocking")}
+		}
+		return err
+	}
+	return nil
+}
+
+// lockError returns the error for a lease that is already held, with the
+// information about the lock recorded in the lease.
+func (c *RemoteClient) lockError(lease *Lease) *state.LockError {
+	lockErr := &state.LockError{
+		Err: fmt.Errorf("workspace %q is already locked", c.Workspace),
+	}
+
+	if raw, ok := lease.Metadata.Annotations[tfstateLockInfoAnnotation]; ok {
+		info := &state.LockInfo{}
+		if err := json.Unmarshal([]byte(raw), info); err == nil {
+			lockErr.Info = info
+		}
+	}
+	return lockErr
+}
+
+// putSecret creates a secret, or replaces it if it already exists.
+func (c *RemoteClient) putSecret(secret *Secret) error {
+	_, err := c.kubeClient.CreateSecret(secret)
+	if !isConflict(err) {
+		return err
+	}
+
+	existing, err := c.kubeClient.GetSecret(secret.Metadata.Name)
+	if err != nil {
+		return err
+	}
+	secret.Metadata.ResourceVersion = existing.Metadata.ResourceVersion
+	_, err = c.kubeClient.UpdateSecret(secret)
+	return err
+}
+
+// deleteChunks deletes the chunk secrets in names that aren't in keep.
+func (c *RemoteClient) deleteChunks(names, keep []string) error {
+	kept := make(map[string]bool, len(keep))
+	for _, name := range keep {
+		kept[name] = true
+	}
+
+	for _, name := range names {
+		if kept[name] {
+			continue
+		}
+		if err := c.kubeClient.DeleteSecret(name); err != nil && !isNotFound(err) {
+			return fmt.Errorf("failed to delete the state chunk %q: %s", name, err)
+		}
+	}
+	return nil
+}
+
+func (c *RemoteClient) secretName() string {
+	return fmt.Sprintf("tfstate-%s-%s", c.Workspace, c.SecretSuffix)
+}
+
+func (c *RemoteClient) leaseName() string {
+	return "lock-" + c.secretName()
+}
+
+// labels returns the labels of the objects of the workspace. They are used
+// to find the workspaces, so the configured labels can't override them.
+func (c *RemoteClient) labels() map[string]string {
+	labels := make(map[string]string, len(c.Labels)+4)
+	for k, v := range c.Labels {
+		labels[k] = v
+	}
+	labels[tfstateKey] = "true"
+	labels[tfstateSecretSuffixLabel] = c.SecretSuffix
+	labels[tfstateWorkspaceLabel] = c.Workspace
+	labels[managedByLabel] = "terraform"
+	return labels
+}
+
+// chunkNames returns the names of the chunk secrets that the given secret
+// refers to, which may be nil.
+func chunkNames(secret *Secret) []string {
+	if secret == nil {
+		return nil
+	}
+	v := secret.Metadata.Annotations[tfstateChunksAnnotation]
+	if v == "" {
+		return nil
+	}
+	return strings.Split(v, ",")
+}
+
+// splitChunks splits data into chunks of at most size bytes. It always
+// returns at least one chunk.
+func splitChunks(data []byte, size int) [][]byte {
+	chunks := [][]byte{}
+	for len(data) > size {
+		chunks = append(chunks, data[:size])
+		data = data[size:]
+	}
+	return append(chunks, data)
+}
+
+func compress(data []byte) ([]byte, error) {
+	var b bytes.Buffer
+	gz := gzip.NewWriter(&b)
+	if _, err := gz.Write(data); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+func uncompress(data []byte) ([]byte, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+	return ioutil.ReadAll(gz)
+}
@@ -0,0 +1,157 @@
+package kubernetes
+
+import (
+	"bytes"
+	"crypto/rand"
+	"net/http/httptest"
+	"sort"
+	"testing"
+
+	"github.com/hashicorp/terraform/backend"
+	"github.com/hashicorp/terraform/state"
+	"github.com/hashicorp/terraform/state/remote"
+)
+
+func TestRemoteClient_impl(t *testing.T) {
+	var _ remote.Client = new(RemoteClient)
+	var _ remote.ClientLocker = new(RemoteClient)
+}
+
+func TestRemoteClient(t *testing.T) {
+	ts := httptest.NewServer(newTestKubeServer())
+	defer ts.Close()
+
+	b := testBackend(t, ts.URL, nil)
+	s, err := b.State(backend.DefaultStateName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	remote.TestClient(t, s.(*remote.State).Client)
+}
+
+func TestRemoteLocks(t *testing.T) {
+	ts := httptest.NewServer(newTestKubeServer())
+	defer ts.Close()
+
+	s1, err := testBackend(t, ts.URL, nil).State(backend.DefaultStateName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s2, err := testBackend(t, ts.URL, nil).State(backend.DefaultStateName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	remote.TestRemoteLocks(t, s1.(*remote.State).Client, s2.(*remote.State).Client)
+}
+
+func TestRemoteClient_lockInfo(t *testing.T) {
+	ts := httptest.NewServer(newTestKubeServer())
+	defer ts.Close()
+
+	c1 := testBackend(t, ts.URL, nil).remoteClient("foo")
+	c2 := testBackend(t, ts.URL, nil).remoteClient("foo")
+
+	info := state.NewLockInfo()
+	info.Operation = "test"
+	info.Who = "clientA"
+	id, err := c1.Lock(info)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A conflicting lock reports who holds the lock.
+	_, err = c2.Lock(state.NewLockInfo())
+	lockErr, ok := err.(*state.LockError)
+	if !ok {
+		t.Fatalf("expected a *state.LockError, got %#v", err)
+	}
+	if lockErr.Info == nil || lockErr.Info.ID != id || lockErr.Info.Who != "clientA" {
+		t.Fatalf("wrong lock info %#v", lockErr.Info)
+	}
+
+	// The lock can't be released with another ID, but can be with its own
+	// from another client, as when forcing unlocking.
+	if err := c2.Unlock("wrong"); err == nil {
+		t.Fatal("expected error")
+	}
+	if err := c2.Unlock(id); err != nil {
+		t.Fatal(err)
+	}
+	if err := c2.Unlock(id); err == nil {
+		t.Fatal("expected error unlocking an unlocked state")
+	}
+	if _, err := c1.Lock(state.NewLockInfo()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRemoteClient_chunks(t *testing.T) {
+	defer func(size int) { secretChunkSize = size }(secretChunkSize)
+	secretChunkSize = 1024
+
+	server := newTestKubeServer()
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	client := testBackend(t, ts.URL, nil).remoteClient("foo")
+
+	// Random data doesn't compress, so it needs several chunks.
+	large := make([]byte, 3*secretChunkSize)
+	if _, err := rand.Read(large); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Put(large); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(server.names("secrets")); n != 4 {
+		t.Fatalf("expected 4 secrets, got %d", n)
+	}
+
+	payload, err := client.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(payload.Data, large) {
+		t.Fatal("wrong data read back from chunks")
+	}
+
+	// The chunks aren't listed as workspaces.
+	b := testBackend(t, ts.URL, nil)
+	states, err := b.States()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(states)
+	if len(states) != 2 || states[0] != "default" || states[1] != "foo" {
+		t.Fatalf("wrong states: %#v", states)
+	}
+
+	// A smaller state replaces the chunks.
+	if err := client.Put([]byte("small")); err != nil {
+		t.Fatal(err)
+	}
+	names := server.names("secrets")
+	if len(names) != 1 || names[0] != "tfstate-foo-test" {
+		t.Fatalf("wrong secrets after shrinking the state: %#v", names)
+	}
+	payload, err = client.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(payload.Data) != "small" {
+		t.Fatalf("wrong data %q", payload.Data)
+	}
+
+	// Deleting removes all the chunks.
+	if err := client.Put(large); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.DeleteState("foo"); err != nil {
+		t.Fatal(err)
+	}
+	if names := server.names("secrets"); len(names) != 0 {
+		t.Fatalf("secrets left after deleting the state: %#v", names)
+	}
+}
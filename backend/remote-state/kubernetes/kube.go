@@ -0,0 +1,241 @@
+package kubernetes
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// This file contains a minimal client for the parts of the Kubernetes API
+// that the backend needs: secrets, which hold the states, and leases, which
+// hold the locks.
+
+// ObjectMeta is the metadata common to all Kubernetes objects.
+type ObjectMeta struct {
+	Name            string            `json:"name"`
+	Namespace       string            `json:"namespace,omitempty"`
+	ResourceVersion string            `json:"resourceVersion,omitempty"`
+	Labels          map[string]string `json:"labels,omitempty"`
+	Annotations     map[string]string `json:"annotations,omitempty"`
+}
+
+// Secret is a Kubernetes secret of type Opaque. The values of Data are
+// base64-encoded by the JSON encoding of []byte, as the API expects.
+type Secret struct {
+	APIVersion string            `json:"apiVersion"`
+	Kind       string            `json:"kind"`
+	Metadata   ObjectMeta        `json:"metadata"`
+	Type       string            `json:"type,omitempty"`
+	Data       map[string][]byte `json:"data,omitempty"`
+}
+
+// SecretList is the result of listing secrets.
+type SecretList struct {
+	Items []Secret `json:"items"`
+}
+
+// Lease is a coordination.k8s.io/v1 lease.
+type Lease struct {
+	APIVersion string     `json:"apiVersion"`
+	Kind       string     `json:"kind"`
+	Metadata   ObjectMeta `json:"metadata"`
+	Spec       LeaseSpec  `json:"spec"`
+}
+
+// LeaseSpec is the specification of a lease. HolderIdentity is empty while
+// nobody holds the lease.
+type LeaseSpec struct {
+	HolderIdentity string `json:"holderIdentity,omitempty"`
+	AcquireTime    string `json:"acquireTime,omitempty"`
+}
+
+// apiError is a failed response from the Kubernetes API, decoded from the
+// Status object that the API returns with failed requests.
+type apiError struct {
+	Code    int    `json:"code"`
+	Reason  string `json:"reason"`
+	Message string `json:"message"`
+}
+
+func (e *apiError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("kubernetes API returned status %d", e.Code)
+	}
+	return e.Message
+}
+
+func isNotFound(err error) bool {
+	e, ok := err.(*apiError)
+	return ok && e.Code == http.StatusNotFound
+}
+
+// isConflict returns true for the errors returned when creating an object
+// that already exists, and when updating an object that was modified since
+// it was read.
+func isConflict(err error) bool {
+	e, ok := err.(*apiError)
+	return ok && e.Code == http.StatusConflict
+}
+
+// kubeClient talks to the Kubernetes API within a single namespace.
+type kubeClient struct {
+	Client    *http.Client
+	Host      *url.URL
+	Token     string
+	Namespace string
+}
+
+func (c *kubeClient) secretsPath(name string) string {
+	p := "/api/v1/namespaces/" + url.PathEscape(c.Namespace) + "/secrets"
+	if name != "" {
+		p += "/" + url.PathEscape(name)
+	}
+	return p
+}
+
+func (c *kubeClient) leasesPath(name string) string {
+	p := "/apis/coordination.k8s.io/v1/namespaces/" + url.PathEscape(c.Namespace) + "/leases"
+	if name != "" {
+		p += "/" + url.PathEscape(name)
+	}
+	return p
+}
+
+func (c *kubeClient) GetSecret(name string) (*Secret, error) {
+	secret := &Secret{}
+	if err := c.do("GET", c.secretsPath(name), nil, nil, secret); err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
+
+// ListSecrets returns the secrets matching the given label selector.
+func (c *kubeClient) ListSecrets(selector string) ([]Secret, error) {
+	query := url.Values{}
+	query.Set("labelSelector", selector)
+	list := &SecretList{}
+	if err := c.do("GET", c.secretsPath(""), query, nil, list); err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+func (c *kubeClient) CreateSecret(secret *Secret) (*Secret, error) {
+	secret.APIVersion = "v1"
+	secret.Kind = "Secret"
+	result := &Secret{}
+	if err := c.do("POST", c.secretsPath(""), nil, secret, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// UpdateSecret replaces a secret. If the secret has a resource version then
+// the update fails with a conflict if the secret was modified since.
+func (c *kubeClient) UpdateSecret(secret *Secret) (*Secret, error) {
+	secret.APIVersion = "v1"
+	secret.Kind = "Secret"
+	result := &Secret{}
+	if err := c.do("PUT", c.secretsPath(secret.Metadata.Name), nil, secret, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *kubeClient) DeleteSecret(name string) error {
+	return c.do("DELETE", c.secretsPath(name), nil, nil, nil)
+}
+
+func (c *kubeClient) GetLease(name string) (*Lease, error) {
+	lease := &Lease{}
+	if err := c.do("GET", c.leasesPath(name), nil, nil, lease); err != nil {
+		return nil, err
+	}
+	return lease, nil
+}
+
+func (c *kubeClient) CreateLease(lease *Lease) (*Lease, error) {
+	lease.APIVersion = "coordination.k8s.io/v1"
+	lease.Kind = "Lease"
+	result := &Lease{}
+	if err := c.do("POST", c.leasesPath(""), nil, lease, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// UpdateLease replaces a lease, failing with a conflict if the lease was
+// modified since its resource version.
+func (c *kubeClient) UpdateLease(lease *Lease) (*Lease, error) {
+	lease.APIVersion = "coordination.k8s.io/v1"
+	lease.Kind = "Lease"
+	result := &Lease{}
+	if err := c.do("PUT", c.leasesPath(lease.Metadata.Name), nil, lease, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *kubeClient) DeleteLease(name string) error {
+	return c.do("DELETE", c.leasesPath(name), nil, nil, nil)
+}
+
+// do sends a request to the API, encoding in as the JSON body if it isn't
+// nil, and decodes the response into out if it isn't nil.
+func (c *kubeClient) do(method, path string, query url.Values, in, out interface{}) error {
+	u := *c.Host
+	u.Path = strings.TrimSuffix(u.Path, "/") + path
+	u.RawQuery = query.Encode()
+
+	var body []byte
+	if in != nil {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequest(method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		apiErr := &apiError{}
+		if err := json.Unmarshal(respBody, apiErr); err != nil || apiErr.Code == 0 {
+			apiErr = &apiError{Message: strings.TrimSpace(string(respBody))}
+		}
+		apiErr.Code = resp.StatusCode
+		return apiErr
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("failed to decode the response of %s %s: %s", method, path, err)
+	}
+	return nil
+}
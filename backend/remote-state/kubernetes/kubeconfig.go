@@ -0,0 +1,151 @@
+package kubernetes
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/mitchellh/go-homedir"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// kubeConfig is the subset of the kubeconfig file format that the backend
+// understands.
+type kubeConfig struct {
+	CurrentContext string `yaml:"current-context"`
+
+	Clusters []struct {
+		Name    string `yaml:"name"`
+		Cluster struct {
+			Server                   string `yaml:"server"`
+			InsecureSkipTLSVerify    bool   `yaml:"insecure-skip-tls-verify"`
+			CertificateAuthority     string `yaml:"certificate-authority"`
+			CertificateAuthorityData string `yaml:"certificate-authority-data"`
+		} `yaml:"cluster"`
+	} `yaml:"clusters"`
+
+	Contexts []struct {
+		Name    string `yaml:"name"`
+		Context struct {
+			Cluster   string `yaml:"cluster"`
+			User      string `yaml:"user"`
+			Namespace string `yaml:"namespace"`
+		} `yaml:"context"`
+	} `yaml:"contexts"`
+
+	Users []struct {
+		Name string `yaml:"name"`
+		User struct {
+			Token                 string `yaml:"token"`
+			TokenFile             string `yaml:"tokenFile"`
+			ClientCertificate     string `yaml:"client-certificate"`
+			ClientCertificateData string `yaml:"client-certificate-data"`
+			ClientKey             string `yaml:"client-key"`
+			ClientKeyData         string `yaml:"client-key-data"`
+		} `yaml:"user"`
+	} `yaml:"users"`
+}
+
+// loadKubeConfig returns the connection settings of the given context of a
+// kubeconfig file, or of its current context if contextName is empty.
+func loadKubeConfig(path, contextName string) (*connConfig, error) {
+	path, err := homedir.Expand(path)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the kubeconfig file: %s", err)
+	}
+
+	var kc kubeConfig
+	if err := yaml.Unmarshal(raw, &kc); err != nil {
+		return nil, fmt.Errorf("failed to parse the kubeconfig file %s: %s", path, err)
+	}
+
+	if contextName == "" {
+		contextName = kc.CurrentContext
+	}
+	if contextName == "" {
+		return nil, fmt.Errorf("the kubeconfig file %s has no current context, "+
+			"so config_context must be set", path)
+	}
+
+	conn := &connConfig{}
+	// Relative paths in a kubeconfig file are relative to the file.
+	l := &kubeConfigLoader{dir: filepath.Dir(path)}
+
+	found := false
+	for _, c := range kc.Contexts {
+		if c.Name != contextName {
+			continue
+		}
+		found = true
+		conn.Namespace = c.Context.Namespace
+
+		for _, cl := range kc.Clusters {
+			if cl.Name != c.Context.Cluster {
+				continue
+			}
+			conn.Host = cl.Cluster.Server
+			conn.Insecure = cl.Cluster.InsecureSkipTLSVerify
+			conn.CACert = l.load("certificate-authority", cl.Cluster.CertificateAuthorityData, cl.Cluster.CertificateAuthority)
+		}
+
+		for _, u := range kc.Users {
+			if u.Name != c.Context.User {
+				continue
+			}
+			conn.Token = u.User.Token
+			if conn.Token == "" && u.User.TokenFile != "" {
+				conn.Token = strings.TrimSpace(string(l.load("tokenFile", "", u.User.TokenFile)))
+			}
+			conn.ClientCert = l.load("client-certificate", u.User.ClientCertificateData, u.User.ClientCertificate)
+			conn.ClientKey = l.load("client-key", u.User.ClientKeyData, u.User.ClientKey)
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("the kubeconfig file %s has no context %q", path, contextName)
+	}
+	if l.err != nil {
+		return nil, fmt.Errorf("failed to load the kubeconfig file %s: %s", path, l.err)
+	}
+
+	return conn, nil
+}
+
+// kubeConfigLoader loads the values of a kubeconfig file that can either be
+// set inline, base64-encoded, or read from another file. It records the
+// first error so that the fields can be loaded one after another.
+type kubeConfigLoader struct {
+	dir string
+	err error
+}
+
+func (l *kubeConfigLoader) load(field, data, path string) []byte {
+	if l.err != nil {
+		return nil
+	}
+
+	if data != "" {
+		v, err := base64.StdEncoding.DecodeString(data)
+		if err != nil {
+			l.err = fmt.Errorf("%s-data is not valid base64: %s", field, err)
+		}
+		return v
+	}
+
+	if path == "" {
+		return nil
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(l.dir, path)
+	}
+	v, err := ioutil.ReadFile(path)
+	if err != nil {
+		l.err = fmt.Errorf("failed to read %s: %s", field, err)
+	}
+	return v
+}
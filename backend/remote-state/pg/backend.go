@@ -0,0 +1,117 @@
+package pg
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/hashicorp/terraform/backend"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/lib/pq"
+)
+
+const (
+	statesTableName = "states"
+	locksTableName  = "locks"
+)
+
+// New creates a new backend for Postgres remote state.
+func New() backend.Backend {
+	s := &schema.Backend{
+		Schema: map[string]*schema.Schema{
+			"conn_str": &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				DefaultFunc: schema.EnvDefaultFunc("PG_CONN_STR", nil),
+				Description: "Postgres connection string; a `postgres://` URL",
+			},
+
+			"schema_name": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Name of the automatically managed Postgres schema to store state",
+				Default:     "terraform_remote_state",
+			},
+
+			"skip_schema_creation": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Description: "If set to `true`, Terraform won't try to create the Postgres schema and tables",
+				Default:     false,
+			},
+		},
+	}
+
+	result := &Backend{Backend: s}
+	result.Backend.ConfigureFunc = result.configure
+	return result
+}
+
+type Backend struct {
+	*schema.Backend
+
+	// The fields below are set from configure
+	db         *sql.DB
+	configData *schema.ResourceData
+	connStr    string
+	schemaName string
+}
+
+func (b *Backend) configure(ctx context.Context) error {
+	// Grab the resource data
+	b.configData = schema.FromContextBackendConfig(ctx)
+	data := b.configData
+
+	b.connStr = data.Get("conn_str").(string)
+	b.schemaName = data.Get("schema_name").(string)
+
+	db, err := sql.Open("postgres", b.connStr)
+	if err != nil {
+		return err
+	}
+
+	if !data.Get("skip_schema_creation").(bool) {
+		if err := b.createSchema(db); err != nil {
+			db.Close()
+			return err
+		}
+	}
+
+	b.db = db
+	return nil
+}
+
+// createSchema creates the schema and the tables that the backend stores
+// states and lock information in, if they don't exist already.
+//
+// Each workspace is a row of the states table, and the locks table holds
+// the information about the lock of each locked workspace. The locks
+// themselves are advisory locks, which Postgres releases if the session
+// holding one ends.
+func (b *Backend) createSchema(db *sql.DB) error {
+	schemaName := pq.QuoteIdentifier(b.schemaName)
+	stmts := []string{
+		fmt.Sprintf(`CREATE SCHEMA IF NOT EXISTS %s`, schemaName),
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s.%s (
+			id bigserial PRIMARY KEY,
+			name text NOT NULL UNIQUE,
+			data text
+		)`, schemaName, statesTableName),
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s.%s (
+			name text PRIMARY KEY,
+			info text NOT NULL
+		)`, schemaName, locksTableName),
+	}
+
+	for _, stmt := range stmts {
+		if _, err := db.Exec(stmt); err != nil {
+			return fmt.Errorf("failed to create the Postgres schema %q: %s", b.schemaName, err)
+		}
+	}
+	return nil
+}
+
+// table returns the quoted, schema-qualified name of the given table.
+func (b *Backend) table(name string) string {
+	return pq.QuoteIdentifier(b.schemaName) + "." + name
+}
@@ -0,0 +1,134 @@
+package pg
+
+// Create the test database: createdb terraform_backend_pg_test
+// TF_ACC=1 go test -v ./backend/remote-state/pg/...
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/hashicorp/terraform/backend"
+	"github.com/hashicorp/terraform/state/remote"
+	"github.com/lib/pq"
+)
+
+func TestBackend_impl(t *testing.T) {
+	var _ backend.Backend = new(Backend)
+}
+
+// testConnStr returns the connection string of the test database, skipping
+// the test unless Postgres tests are enabled.
+func testConnStr(t *testing.T) string {
+	t.Helper()
+	skip := os.Getenv("TF_ACC") == "" && os.Getenv("TF_PG_TEST") == ""
+	if skip {
+		t.Log("pg backend tests require setting TF_ACC or TF_PG_TEST")
+		t.Skip()
+	}
+
+	connStr := os.Getenv("DATABASE_URL")
+	if connStr == "" {
+		connStr = "postgres://localhost/terraform_backend_pg_test?sslmode=disable"
+	}
+	return connStr
+}
+
+// testSchema returns a schema name unique to the test, which is dropped
+// by the returned function.
+func testSchema(t *testing.T, connStr string) (string, func()) {
+	t.Helper()
+	schemaName := fmt.Sprintf("terraform_%s", t.Name())
+
+	return schemaName, func() {
+		db, err := sql.Open("postgres", connStr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer db.Close()
+		if _, err := db.Exec(fmt.Sprintf("DROP SCHEMA IF EXISTS %s CASCADE", pq.QuoteIdentifier(schemaName))); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestBackendConfig(t *testing.T) {
+	connStr := testConnStr(t)
+	schemaName, cleanup := testSchema(t, connStr)
+	defer cleanup()
+
+	b := backend.TestBackendConfig(t, New(), map[string]interface{}{
+		"conn_str":    connStr,
+		"schema_name": schemaName,
+	}).(*Backend)
+
+	if b.db == nil {
+		t.Fatal("Backend could not be configured")
+	}
+
+	// The schema and its tables must have been created.
+	var count int
+	err := b.db.QueryRow(
+		`SELECT count(*) FROM information_schema.tables WHERE table_schema = $1`,
+		schemaName).Scan(&count)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Fatalf("wrong number of tables %d in schema %s", count, schemaName)
+	}
+
+	s, err := b.State(backend.DefaultStateName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := s.(*remote.State).Client.(*RemoteClient)
+	if c.Name != backend.DefaultStateName || c.SchemaName != schemaName {
+		t.Fatalf("wrong client %#v", c)
+	}
+}
+
+func TestBackendConfigSkipSchemaCreation(t *testing.T) {
+	connStr := testConnStr(t)
+	schemaName, cleanup := testSchema(t, connStr)
+	defer cleanup()
+
+	b := backend.TestBackendConfig(t, New(), map[string]interface{}{
+		"conn_str":             connStr,
+		"schema_name":          schemaName,
+		"skip_schema_creation": true,
+	}).(*Backend)
+
+	// Without the schema, the backend can't list the workspaces.
+	if _, err := b.States(); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestBackendStates(t *testing.T) {
+	connStr := testConnStr(t)
+	schemaName, cleanup := testSchema(t, connStr)
+	defer cleanup()
+
+	conf := map[string]interface{}{
+		"conn_str":    connStr,
+		"schema_name": schemaName,
+	}
+	b := backend.TestBackendConfig(t, New(), conf)
+	backend.TestBackend(t, b, nil)
+}
+
+func TestBackendStateLocks(t *testing.T) {
+	connStr := testConnStr(t)
+	schemaName, cleanup := testSchema(t, connStr)
+	defer cleanup()
+
+	conf := map[string]interface{}{
+		"conn_str":    connStr,
+		"schema_name": schemaName,
+	}
+	b1 := backend.TestBackendConfig(t, New(), conf)
+	b2 := backend.TestBackendConfig(t, New(), conf)
+	backend.TestBackend(t, b1, b2)
+}
@@ -0,0 +1,222 @@
+package pg
+
+import (
+	"context"
+	"crypto/md5"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/hashicorp/terraform/state"
+	"github.com/hashicorp/terraform/state/remote"
+	"github.com/lib/pq"
+)
+
+// RemoteClient is a remote client that stores data in a Postgres database.
+type RemoteClient struct {
+	Client     *sql.DB
+	Name       string
+	SchemaName string
+
+	mu sync.Mutex
+	// conn is the session that holds the advisory lock of the workspace
+	// while it is locked, since advisory locks belong to sessions rather
+	// than to the pooled connections of Client.
+	conn *sql.Conn
+	info *state.LockInfo
+}
+
+func (c *RemoteClient) table(name string) string {
+	return pq.QuoteIdentifier(c.SchemaName) + "." + name
+}
+
+// lockKey is hashed by Postgres into the key of the workspace's advisory
+// lock. The schema is included so that backends using different schemas of
+// the same database don't contend for locks.
+func (c *RemoteClient) lockKey() string {
+	return c.SchemaName + "/" + c.Name
+}
+
+func (c *RemoteClient) Get() (*remote.Payload, error) {
+	query := fmt.Sprintf(`SELECT data FROM %s WHERE name = $1`, c.table(statesTableName))
+	var data []byte
+	err := c.Client.QueryRow(query, c.Name).Scan(&data)
+	switch {
+	case err == sql.ErrNoRows:
+		// No existing state returns empty.
+		return nil, nil
+	case err != nil:
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	md5 := md5.Sum(data)
+	return &remote.Payload{
+		Data: data,
+		MD5:  md5[:],
+	}, nil
+}
+
+func (c *RemoteClient) Put(data []byte) error {
+	query := fmt.Sprintf(`INSERT INTO %s (name, data) VALUES ($1, $2)
+		ON CONFLICT (name) DO UPDATE SET data = EXCLUDED.data`, c.table(statesTableName))
+	_, err := c.Client.Exec(query, c.Name, string(data))
+	return err
+}
+
+func (c *RemoteClient) Delete() error {
+	query := fmt.Sprintf(`DELETE FROM %s WHERE name = $1`, c.table(statesTableName))
+	_, err := c.Client.Exec(query, c.Name)
+	return err
+}
+
+func (c *RemoteClient) Lock(info *state.LockInfo) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.conn != nil {
+		return "", fmt.Errorf("state %q already locked", c.Name)
+	}
+
+	ctx := context.Background()
+	conn, err := c.tryLock(ctx)
+	if err != nil {
+		return "", err
+	}
+	if conn == nil {
+		lockErr := &state.LockError{
+			Err: fmt.Errorf("workspace %q is already locked", c.Name),
+		}
+		if existing, err := c.getLockInfo(); err == nil {
+			lockErr.Info = existing
+		}
+		return "", lockErr
+	}
+
+	// The lock information is only informational, so that other clients
+	// can report who holds the lock. It may be left behind by a session
+	// that ended without unlocking, so it's replaced here.
+	query := fmt.Sprintf(`INSERT INTO %s (name, info) VALUES ($1, $2)
+		ON CONFLICT (name) DO UPDATE SET info = EXCLUDED.info`, c.table(locksTableName))
+	if _, err := conn.ExecContext(ctx, query, c.Name, string(info.Marshal())); err != nil {
+		c.releaseLock(ctx, conn)
+		return "", fmt.Errorf("failed to record the lock information: %s", err)
+	}
+
+	c.conn = conn
+	c.info = info
+	return info.ID, nil
+}
+
+func (c *RemoteClient) Unlock(id string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ctx := context.Background()
+	if c.conn == nil {
+		return c.forceUnlock(ctx, id)
+	}
+
+	if id != c.info.ID {
+		return &state.LockError{
+			Info: c.info,
+			Err:  fmt.Errorf("lock id %q does not match existing lock", id),
+		}
+	}
+
+	query := fmt.Sprintf(`DELETE FROM %s WHERE name = $1`, c.table(locksTableName))
+	_, err := c.conn.ExecContext(ctx, query, c.Name)
+
+	c.releaseLock(ctx, c.conn)
+	c.conn = nil
+	c.info = nil
+	return err
+}
+
+// forceUnlock removes a lock that this client doesn't hold. Advisory locks
+// can only be released by the sessions holding them, and are released
+// automatically when those sessions end, so this only succeeds if the
+// session that took the lock has already ended, leaving its lock
+// information behind.
+func (c *RemoteClient) forceUnlock(ctx context.Context, id string) error {
+	existing, err := c.getLockInfo()
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return &state.LockError{Err: errors.New("state not locked")}
+	}
+	if existing.ID != id {
+		return &state.LockError{
+			Info: existing,
+			Err:  fmt.Errorf("lock id %q does not match existing lock", id),
+		}
+	}
+
+	conn, err := c.tryLock(ctx)
+	if err != nil {
+		return err
+	}
+	if conn == nil {
+		return &state.LockError{
+			Info: existing,
+			Err: errors.New("the lock is held by an active Postgres session, " +
+				"and will be released when that session ends"),
+		}
+	}
+	defer c.releaseLock(ctx, conn)
+
+	query := fmt.Sprintf(`DELETE FROM %s WHERE name = $1`, c.table(locksTableName))
+	_, err = conn.ExecContext(ctx, query, c.Name)
+	return err
+}
+
+// tryLock tries to take the advisory lock of the workspace with a
+// dedicated connection, which is returned if the lock was taken and must
+// later be passed to releaseLock. If the lock is held by another session
+// then it returns nil without an error.
+func (c *RemoteClient) tryLock(ctx context.Context) (*sql.Conn, error) {
+	conn, err := c.Client.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var locked bool
+	err = conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock(hashtext($1))`, c.lockKey()).Scan(&locked)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to lock workspace %q: %s", c.Name, err)
+	}
+	if !locked {
+		conn.Close()
+		return nil, nil
+	}
+	return conn, nil
+}
+
+func (c *RemoteClient) releaseLock(ctx context.Context, conn *sql.Conn) {
+	conn.ExecContext(ctx, `SELECT pg_advisory_unlock(hashtext($1))`, c.lockKey())
+	conn.Close()
+}
+
+func (c *RemoteClient) getLockInfo() (*state.LockInfo, error) {
+	query := fmt.Sprintf(`SELECT info FROM %s WHERE name = $1`, c.table(locksTableName))
+	var raw []byte
+	err := c.Client.QueryRow(query, c.Name).Scan(&raw)
+	switch {
+	case err == sql.ErrNoRows:
+		return nil, nil
+	case err != nil:
+		return nil, err
+	}
+
+	info := &state.LockInfo{}
+	if err := json.Unmarshal(raw, info); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
@@ -0,0 +1,115 @@
+package pg
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform/backend"
+	"github.com/hashicorp/terraform/state"
+	"github.com/hashicorp/terraform/state/remote"
+)
+
+func TestRemoteClient_impl(t *testing.T) {
+	var _ remote.Client = new(RemoteClient)
+	var _ remote.ClientLocker = new(RemoteClient)
+}
+
+func TestRemoteClient(t *testing.T) {
+	connStr := testConnStr(t)
+	schemaName, cleanup := testSchema(t, connStr)
+	defer cleanup()
+
+	b := backend.TestBackendConfig(t, New(), map[string]interface{}{
+		"conn_str":    connStr,
+		"schema_name": schemaName,
+	})
+
+	s, err := b.State(backend.DefaultStateName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	remote.TestClient(t, s.(*remote.State).Client)
+}
+
+func TestRemoteLocks(t *testing.T) {
+	connStr := testConnStr(t)
+	schemaName, cleanup := testSchema(t, connStr)
+	defer cleanup()
+
+	conf := map[string]interface{}{
+		"conn_str":    connStr,
+		"schema_name": schemaName,
+	}
+
+	b1 := backend.TestBackendConfig(t, New(), conf)
+	s1, err := b1.State(backend.DefaultStateName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b2 := backend.TestBackendConfig(t, New(), conf)
+	s2, err := b2.State(backend.DefaultStateName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	remote.TestRemoteLocks(t, s1.(*remote.State).Client, s2.(*remote.State).Client)
+}
+
+func TestRemoteClient_lockInfo(t *testing.T) {
+	connStr := testConnStr(t)
+	schemaName, cleanup := testSchema(t, connStr)
+	defer cleanup()
+
+	conf := map[string]interface{}{
+		"conn_str":    connStr,
+		"schema_name": schemaName,
+	}
+
+	b1 := backend.TestBackendConfig(t, New(), conf).(*Backend)
+	b2 := backend.TestBackendConfig(t, New(), conf).(*Backend)
+	s1, _ := b1.State(backend.DefaultStateName)
+	s2, _ := b2.State(backend.DefaultStateName)
+	c1 := s1.(*remote.State).Client.(*RemoteClient)
+	c2 := s2.(*remote.State).Client.(*RemoteClient)
+
+	info := state.NewLockInfo()
+	info.Operation = "test"
+	info.Who = "clientA"
+	id, err := c1.Lock(info)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A conflicting lock reports who holds the lock.
+	_, err = c2.Lock(state.NewLockInfo())
+	lockErr, ok := err.(*state.LockError)
+	if !ok {
+		t.Fatalf("expected a *state.LockError, got %#v", err)
+	}
+	if lockErr.Info == nil || lockErr.Info.ID != id || lockErr.Info.Who != "clientA" {
+		t.Fatalf("wrong lock info %#v", lockErr.Info)
+	}
+
+	// Another client can't release a lock held by an active session.
+	if err := c2.Unlock(id); err == nil {
+		t.Fatal("expected error")
+	}
+
+	// Once the session holding the lock has ended, only its lock
+	// information remains, which can be removed by force.
+	var pid int
+	if err := c1.conn.QueryRowContext(context.Background(), `SELECT pg_backend_pid()`).Scan(&pid); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b2.db.Exec(`SELECT pg_terminate_backend($1)`, pid); err != nil {
+		t.Fatal(err)
+	}
+	if err := c2.Unlock(id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c2.Lock(state.NewLockInfo()); err != nil {
+		t.Fatal(err)
+	}
+}
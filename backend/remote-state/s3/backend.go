@@ -2,13 +2,21 @@ package s3
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
+	"regexp"
 	"strings"
 
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/hashicorp/terraform/backend"
 	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/terraform"
 
 	terraformAWS "github.com/terraform-providers/terraform-provider-aws/aws"
 )
@@ -80,10 +88,11 @@ func New() backend.Backend {
 			},
 
 			"kms_key_id": {
-				Type:        schema.TypeString,
-				Optional:    true,
-				Description: "The ARN of a KMS Key to use for encrypting the state",
-				Default:     "",
+				Type:         schema.TypeString,
+				Optional:     true,
+				Description:  "The ARN, ID or alias of a KMS Key to use for encrypting the state",
+				Default:      "",
+				ValidateFunc: validateKMSKey,
 			},
 
 			"lock_table": {
@@ -101,6 +110,13 @@ func New() backend.Backend {
 				Default:     "",
 			},
 
+			"create_dynamodb_table": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Description: "Create the DynamoDB table for state locking if it doesn't exist",
+				Default:     false,
+			},
+
 			"profile": {
 				Type:        schema.TypeString,
 				Optional:    true,
@@ -158,38 +174,43 @@ func New() backend.Backend {
 			},
 
 			"role_arn": {
-				Type:        schema.TypeString,
-				Optional:    true,
-				Description: "The role to be assumed",
-				Default:     "",
+				Type:         schema.TypeString,
+				Optional:     true,
+				Description:  "The role to be assumed",
+				Default:      "",
+				ValidateFunc: validateRoleARN,
 			},
 
 			"session_name": {
-				Type:        schema.TypeString,
-				Optional:    true,
-				Description: "The session name to use when assuming the role.",
-				Default:     "",
+				Type:         schema.TypeString,
+				Optional:     true,
+				Description:  "The session name to use when assuming the role.",
+				Default:      "",
+				ValidateFunc: validateSessionName,
 			},
 
 			"external_id": {
-				Type:        schema.TypeString,
-				Optional:    true,
-				Description: "The external ID to use when assuming the role",
-				Default:     "",
+				Type:         schema.TypeString,
+				Optional:     true,
+				Description:  "The external ID to use when assuming the role",
+				Default:      "",
+				ValidateFunc: validateExternalID,
 			},
 
 			"assume_role_policy": {
-				Type:        schema.TypeString,
-				Optional:    true,
-				Description: "The permissions applied when assuming a role.",
-				Default:     "",
+				Type:         schema.TypeString,
+				Optional:     true,
+				Description:  "The permissions applied when assuming a role.",
+				Default:      "",
+				ValidateFunc: validatePolicy,
 			},
 
 			"workspace_key_prefix": {
-				Type:        schema.TypeString,
-				Optional:    true,
-				Description: "The prefix applied to the non-default state path inside the bucket",
-				Default:     "env:",
+				Type:         schema.TypeString,
+				Optional:     true,
+				Description:  "The prefix applied to the non-default state path inside the bucket",
+				Default:      "env:",
+				ValidateFunc: validateWorkspaceKeyPrefix,
 			},
 
 			"force_path_style": {
@@ -219,9 +240,26 @@ type Backend struct {
 	acl                  string
 	kmsKeyID             string
 	ddbTable             string
+	createDDBTable       bool
 	workspaceKeyPrefix   string
 }
 
+// Validate validates the configuration of the backend, warning about the
+// options of the role to assume that are ignored since role_arn isn't set.
+func (b *Backend) Validate(c *terraform.ResourceConfig) ([]string, []error) {
+	ws, es := b.Backend.Validate(c)
+
+	if v, _ := c.Get("role_arn"); v == nil || v == "" {
+		for _, k := range []string{"session_name", "external_id", "assume_role_policy"} {
+			if v, _ := c.Get(k); v != nil && v != "" {
+				ws = append(ws, fmt.Sprintf("%s is ignored since role_arn is not set", k))
+			}
+		}
+	}
+
+	return ws, es
+}
+
 func (b *Backend) configure(ctx context.Context) error {
 	if b.s3Client != nil {
 		return nil
@@ -242,6 +280,10 @@ func (b *Backend) configure(ctx context.Context) error {
 		// try the deprecated field
 		b.ddbTable = data.Get("lock_table").(string)
 	}
+	b.createDDBTable = data.Get("create_dynamodb_table").(bool)
+	if b.createDDBTable && b.ddbTable == "" {
+		return errors.New("create_dynamodb_table requires dynamodb_table to be set")
+	}
 
 	cfg := &terraformAWS.Config{
 		AccessKey:               data.Get("access_key").(string),
@@ -271,5 +313,138 @@ func (b *Backend) configure(ctx context.Context) error {
 	b.s3Client = client.(*terraformAWS.AWSClient).S3()
 	b.dynClient = client.(*terraformAWS.AWSClient).DynamoDB()
 
+	if b.createDDBTable && b.ddbTable != "" {
+		if err := b.createLockTable(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
+
+// createLockTable creates the DynamoDB table for state locking, unless it
+// already exists, and waits until it can be used.
+func (b *Backend) createLockTable() error {
+	_, err := b.dynClient.DescribeTable(&dynamodb.DescribeTableInput{
+		TableName: aws.String(b.ddbTable),
+	})
+	if err == nil {
+		return nil
+	}
+	if awsErr, ok := err.(awserr.Error); !ok || awsErr.Code() != dynamodb.ErrCodeResourceNotFoundException {
+		return fmt.Errorf("failed to read the DynamoDB table %q: %s", b.ddbTable, err)
+	}
+
+	log.Printf("[INFO] creating the DynamoDB table %q for state locking", b.ddbTable)
+	_, err = b.dynClient.CreateTable(&dynamodb.CreateTableInput{
+		TableName: aws.String(b.ddbTable),
+		AttributeDefinitions: []*dynamodb.AttributeDefinition{
+			{
+				AttributeName: aws.String("LockID"),
+				AttributeType: aws.String(dynamodb.ScalarAttributeTypeS),
+			},
+		},
+		KeySchema: []*dynamodb.KeySchemaElement{
+			{
+				AttributeName: aws.String("LockID"),
+				KeyType:       aws.String(dynamodb.KeyTypeHash),
+			},
+		},
+		ProvisionedThroughput: &dynamodb.ProvisionedThroughput{
+			ReadCapacityUnits:  aws.Int64(5),
+			WriteCapacityUnits: aws.Int64(5),
+		},
+	})
+	if err != nil {
+		// Another client may have created the table in the meantime.
+		if awsErr, ok := err.(awserr.Error); !ok || awsErr.Code() != dynamodb.ErrCodeResourceInUseException {
+			return fmt.Errorf("failed to create the DynamoDB table %q: %s", b.ddbTable, err)
+		}
+	}
+
+	err = b.dynClient.WaitUntilTableExists(&dynamodb.DescribeTableInput{
+		TableName: aws.String(b.ddbTable),
+	})
+	if err != nil {
+		return fmt.Errorf("failed waiting for the DynamoDB table %q to be created: %s", b.ddbTable, err)
+	}
+	return nil
+}
+
+func validateRoleARN(v interface{}, k string) ([]string, []error) {
+	value := v.(string)
+	if value == "" {
+		return nil, nil
+	}
+
+	parsed, err := arn.Parse(value)
+	if err != nil {
+		return nil, []error{fmt.Errorf("%s is not a valid ARN: %s", k, err)}
+	}
+	if parsed.Service != "iam" || !strings.HasPrefix(parsed.Resource, "role/") {
+		return nil, []error{fmt.Errorf("%s must be the ARN of an IAM role, got %q", k, value)}
+	}
+	return nil, nil
+}
+
+var sessionNameRegexp = regexp.MustCompile(`^[\w+=,.@-]{2,64}$`)
+
+func validateSessionName(v interface{}, k string) ([]string, []error) {
+	value := v.(string)
+	if value != "" && !sessionNameRegexp.MatchString(value) {
+		return nil, []error{fmt.Errorf(
+			"%s must be 2 to 64 letters, digits, or any of _+=,.@-, got %q", k, value)}
+	}
+	return nil, nil
+}
+
+var externalIDRegexp = regexp.MustCompile(`^[\w+=,.@:/-]+$`)
+
+func validateExternalID(v interface{}, k string) ([]string, []error) {
+	value := v.(string)
+	if value != "" && (len(value) < 2 || len(value) > 1224 || !externalIDRegexp.MatchString(value)) {
+		return nil, []error{fmt.Errorf(
+			"%s must be 2 to 1224 letters, digits, or any of _+=,.@:/-", k)}
+	}
+	return nil, nil
+}
+
+func validatePolicy(v interface{}, k string) ([]string, []error) {
+	value := v.(string)
+	if value == "" {
+		return nil, nil
+	}
+
+	var policy map[string]interface{}
+	if err := json.Unmarshal([]byte(value), &policy); err != nil {
+		return nil, []error{fmt.Errorf("%s must be a JSON policy document: %s", k, err)}
+	}
+	return nil, nil
+}
+
+// validateKMSKey accepts the forms of KMS key identifiers that S3 accepts:
+// key and alias ARNs, key IDs, and alias names.
+func validateKMSKey(v interface{}, k string) ([]string, []error) {
+	value := v.(string)
+	if value == "" || strings.HasPrefix(value, "alias/") || kmsKeyIDRegexp.MatchString(value) {
+		return nil, nil
+	}
+
+	parsed, err := arn.Parse(value)
+	if err == nil && parsed.Service == "kms" &&
+		(strings.HasPrefix(parsed.Resource, "key/") || strings.HasPrefix(parsed.Resource, "alias/")) {
+		return nil, nil
+	}
+	return nil, []error{fmt.Errorf(
+		"%s must be the ARN, ID, or alias of a KMS key, got %q", k, value)}
+}
+
+var kmsKeyIDRegexp = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+func validateWorkspaceKeyPrefix(v interface{}, k string) ([]string, []error) {
+	value := v.(string)
+	if strings.HasPrefix(value, "/") || strings.HasSuffix(value, "/") {
+		return nil, []error{fmt.Errorf("%s must not start or end with '/'", k)}
+	}
+	return nil, nil
+}
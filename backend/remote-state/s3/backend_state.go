@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/hashicorp/terraform/backend"
 	"github.com/hashicorp/terraform/state"
@@ -15,6 +16,114 @@ import (
 )
 
 func (b *Backend) States() ([]string, error) {
+	objs, err := b.listStateObjects()
+	if err != nil {
+		return nil, err
+	}
+
+	wss := []string{backend.DefaultStateName}
+	for ws := range objs {
+		wss = append(wss, ws)
+	}
+
+	sort.Strings(wss[1:])
+	return wss, nil
+}
+
+// ListWorkspacesWithInfo describes the workspaces from the listing of the
+// bucket. Reading the serial of a state requires fetching it, so it's left
+// unknown.
+func (b *Backend) ListWorkspacesWithInfo() ([]*backend.WorkspaceInfo, error) {
+	objs, err := b.listStateObjects()
+	if err != nil {
+		return nil, err
+	}
+
+	// The default state isn't stored under the workspace key prefix.
+	dflt := &backend.WorkspaceInfo{Name: backend.DefaultStateName}
+	head, err := b.s3Client.HeadObject(&s3.HeadObjectInput{
+		Bucket: &b.bucketName,
+		Key:    &b.keyName,
+	})
+	if err != nil {
+		if awsErr, ok := err.(awserr.Error); !ok || awsErr.Code() != "NotFound" {
+			return nil, err
+		}
+	} else {
+		dflt.Serial = -1
+		dflt.Size = aws.Int64Value(head.ContentLength)
+		dflt.LastModified = aws.TimeValue(head.LastModified)
+	}
+
+	names := make([]string, 0, len(objs))
+	for ws := range objs {
+		names = append(names, ws)
+	}
+	sort.Strings(names)
+
+	result := []*backend.WorkspaceInfo{dflt}
+	for _, ws := range names {
+		obj := objs[ws]
+		result = append(result, &backend.WorkspaceInfo{
+			Name:         ws,
+			Serial:       -1,
+			Size:         aws.Int64Value(obj.Size),
+			LastModified: aws.TimeValue(obj.LastModified),
+		})
+	}
+	return result, nil
+}
+
+// StateVersions lists the versions of the object holding the named state.
+// The bucket must have versioning enabled to keep more than one.
+func (b *Backend) StateVersions(name string) ([]*backend.StateVersion, error) {
+	key := b.path(name)
+	params := &s3.ListObjectVersionsInput{
+		Bucket: &b.bucketName,
+		Prefix: &key,
+	}
+
+	var versions []*backend.StateVersion
+	err := b.s3Client.ListObjectVersionsPages(params, func(page *s3.ListObjectVersionsOutput, last bool) bool {
+		// Versions are listed newest first.
+		for _, v := range page.Versions {
+			if aws.StringValue(v.Key) != key {
+				continue
+			}
+			versions = append(versions, &backend.StateVersion{
+				ID:           aws.StringValue(v.VersionId),
+				LastModified: aws.TimeValue(v.LastModified),
+				Size:         aws.Int64Value(v.Size),
+				Current:      aws.BoolValue(v.IsLatest),
+			})
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return versions, nil
+}
+
+// StateVersion reads a version of the object holding the named state.
+func (b *Backend) StateVersion(name, id string) (*terraform.State, error) {
+	output, err := b.s3Client.GetObject(&s3.GetObjectInput{
+		Bucket:    &b.bucketName,
+		Key:       aws.String(b.path(name)),
+		VersionId: &id,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read version %s of the state: %s", id, err)
+	}
+	defer output.Body.Close()
+
+	return terraform.ReadState(output.Body)
+}
+
+// listStateObjects returns the objects holding the states of the named
+// workspaces, by workspace name.
+func (b *Backend) listStateObjects() (map[string]*s3.Object, error) {
 	prefix := b.workspaceKeyPrefix + "/"
 
 	// List bucket root if there is no workspaceKeyPrefix
@@ -26,21 +135,20 @@ func (b *Backend) States() ([]string, error) {
 		Prefix: aws.String(prefix),
 	}
 
-	resp, err := b.s3Client.ListObjects(params)
+	objs := make(map[string]*s3.Object)
+	err := b.s3Client.ListObjectsPages(params, func(page *s3.ListObjectsOutput, last bool) bool {
+		for _, obj := range page.Contents {
+			if ws := b.keyEnv(*obj.Key); ws != "" {
+				objs[ws] = obj
+			}
+		}
+		return true
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	wss := []string{backend.DefaultStateName}
-	for _, obj := range resp.Contents {
-		ws := b.keyEnv(*obj.Key)
-		if ws != "" {
-			wss = append(wss, ws)
-		}
-	}
-
-	sort.Strings(wss[1:])
-	return wss, nil
+	return objs, nil
 }
 
 func (b *Backend) keyEnv(key string) string {
@@ -53,29 +161,24 @@ func (b *Backend) keyEnv(key string) string {
 		}
 	}
 
-	parts := strings.SplitAfter(key, b.workspaceKeyPrefix)
-
-	if len(parts) < 2 {
-		return ""
-	}
-
-	// shouldn't happen since we listed by prefix
-	if parts[0] != b.workspaceKeyPrefix {
+	// The prefix may occur again in the rest of the key, so only the start
+	// of the key is matched.
+	prefix := b.workspaceKeyPrefix + "/"
+	if !strings.HasPrefix(key, prefix) {
 		return ""
 	}
 
-	parts = strings.SplitN(parts[1], "/", 3)
-
-	if len(parts) < 3 {
+	parts := strings.SplitN(strings.TrimPrefix(key, prefix), "/", 2)
+	if len(parts) < 2 || parts[0] == "" {
 		return ""
 	}
 
 	// not our key, so don't include it in our listing
-	if parts[2] != b.keyName {
+	if parts[1] != b.keyName {
 		return ""
 	}
 
-	return parts[1]
+	return parts[0]
 }
 
 func (b *Backend) DeleteState(name string) error {
@@ -117,7 +220,10 @@ func (b *Backend) State(name string) (state.State, error) {
 		return nil, err
 	}
 
-	stateMgr := &remote.State{Client: client}
+	stateMgr := &remote.State{
+		Client: remote.NewCachedClient(client, "s3://"+b.bucketName+"/"+client.path),
+	}
+
 	// Check to see if this state already exists.
 	// If we're trying to force-unlock a state, we can't take the lock before
 	// fetching the state. If the state doesn't exist, we have to assume this
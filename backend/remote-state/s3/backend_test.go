@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -30,6 +31,8 @@ func testACC(t *testing.T) {
 
 func TestBackend_impl(t *testing.T) {
 	var _ backend.Backend = new(Backend)
+	var _ backend.WorkspaceLister = new(Backend)
+	var _ backend.StateVersioner = new(Backend)
 }
 
 func TestBackendConfig(t *testing.T) {
@@ -88,6 +91,180 @@ func TestBackendConfig_invalidKey(t *testing.T) {
 	}
 }
 
+func TestBackendConfig_validate(t *testing.T) {
+	cases := map[string]struct {
+		Config map[string]interface{}
+		Err    string
+	}{
+		"assume role": {
+			Config: map[string]interface{}{
+				"role_arn":           "arn:aws:iam::123456789012:role/terraform",
+				"session_name":       "terraform@ci",
+				"external_id":        "urn:example:1234",
+				"assume_role_policy": `{"Version":"2012-10-17","Statement":[]}`,
+			},
+		},
+		"role_arn not a role": {
+			Config: map[string]interface{}{"role_arn": "arn:aws:iam::123456789012:user/terraform"},
+			Err:    "must be the ARN of an IAM role",
+		},
+		"role_arn not an arn": {
+			Config: map[string]interface{}{"role_arn": "terraform"},
+			Err:    "not a valid ARN",
+		},
+		"session_name": {
+			Config: map[string]interface{}{"session_name": "terraform session"},
+			Err:    "session_name must be",
+		},
+		"external_id": {
+			Config: map[string]interface{}{"external_id": "x"},
+			Err:    "external_id must be",
+		},
+		"assume_role_policy": {
+			Config: map[string]interface{}{"assume_role_policy": "{"},
+			Err:    "JSON policy document",
+		},
+		"kms key arn": {
+			Config: map[string]interface{}{"kms_key_id": "arn:aws:kms:us-west-2:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab"},
+		},
+		"kms alias arn": {
+			Config: map[string]interface{}{"kms_key_id": "arn:aws:kms:us-west-2:123456789012:alias/terraform"},
+		},
+		"kms key id": {
+			Config: map[string]interface{}{"kms_key_id": "1234abcd-12ab-34cd-56ef-1234567890ab"},
+		},
+		"kms alias": {
+			Config: map[string]interface{}{"kms_key_id": "alias/terraform"},
+		},
+		"kms invalid": {
+			Config: map[string]interface{}{"kms_key_id": "arn:aws:s3:::bucket"},
+			Err:    "KMS key",
+		},
+		"workspace_key_prefix": {
+			Config: map[string]interface{}{"workspace_key_prefix": "states/workspaces"},
+		},
+		"workspace_key_prefix slash": {
+			Config: map[string]interface{}{"workspace_key_prefix": "states/"},
+			Err:    "must not start or end with '/'",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			cfg := map[string]interface{}{
+				"region": "us-west-1",
+				"bucket": "tf-test",
+				"key":    "state",
+			}
+			for k, v := range tc.Config {
+				cfg[k] = v
+			}
+			rawCfg, err := config.NewRawConfig(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, errs := New().Validate(terraform.NewResourceConfig(rawCfg))
+			if tc.Err == "" {
+				if len(errs) > 0 {
+					t.Fatalf("unexpected errors: %v", errs)
+				}
+				return
+			}
+			if len(errs) != 1 || !strings.Contains(errs[0].Error(), tc.Err) {
+				t.Fatalf("expected an error containing %q, got %v", tc.Err, errs)
+			}
+		})
+	}
+}
+
+func TestBackendConfig_validateWarnings(t *testing.T) {
+	cases := map[string]struct {
+		Config map[string]interface{}
+		Warn   string
+	}{
+		"role_arn": {
+			Config: map[string]interface{}{
+				"role_arn":     "arn:aws:iam::123456789012:role/terraform",
+				"session_name": "terraform",
+			},
+		},
+		"external_id without role_arn": {
+			Config: map[string]interface{}{"external_id": "urn:example:1234"},
+			Warn:   "external_id is ignored since role_arn is not set",
+		},
+		"session_name without role_arn": {
+			Config: map[string]interface{}{"session_name": "terraform"},
+			Warn:   "session_name is ignored since role_arn is not set",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			cfg := map[string]interface{}{
+				"region": "us-west-1",
+				"bucket": "tf-test",
+				"key":    "state",
+			}
+			for k, v := range tc.Config {
+				cfg[k] = v
+			}
+			rawCfg, err := config.NewRawConfig(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ws, errs := New().Validate(terraform.NewResourceConfig(rawCfg))
+			if len(errs) > 0 {
+				t.Fatalf("unexpected errors: %v", errs)
+			}
+			if tc.Warn == "" {
+				if len(ws) > 0 {
+					t.Fatalf("unexpected warnings: %v", ws)
+				}
+				return
+			}
+			if len(ws) != 1 || ws[0] != tc.Warn {
+				t.Fatalf("expected the warning %q, got %v", tc.Warn, ws)
+			}
+		})
+	}
+}
+
+func TestBackendConfig_configureErrors(t *testing.T) {
+	cases := map[string]struct {
+		Config map[string]interface{}
+		Err    string
+	}{
+		"create_dynamodb_table without a table": {
+			Config: map[string]interface{}{"create_dynamodb_table": true},
+			Err:    "requires dynamodb_table",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			cfg := map[string]interface{}{
+				"region": "us-west-1",
+				"bucket": "tf-test",
+				"key":    "state",
+			}
+			for k, v := range tc.Config {
+				cfg[k] = v
+			}
+			rawCfg, err := config.NewRawConfig(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = New().Configure(terraform.NewResourceConfig(rawCfg))
+			if err == nil || !strings.Contains(err.Error(), tc.Err) {
+				t.Fatalf("expected an error containing %q, got %v", tc.Err, err)
+			}
+		})
+	}
+}
+
 func TestBackend(t *testing.T) {
 	testACC(t)
 
@@ -134,6 +311,36 @@ func TestBackendLocked(t *testing.T) {
 	backend.TestBackend(t, b1, b2)
 }
 
+func TestBackendLocked_createTable(t *testing.T) {
+	testACC(t)
+
+	bucketName := fmt.Sprintf("terraform-remote-s3-test-%x", time.Now().Unix())
+	keyName := "test/state"
+
+	// The first backend creates the table, and the second one uses it.
+	b1 := backend.TestBackendConfig(t, New(), map[string]interface{}{
+		"bucket":                bucketName,
+		"key":                   keyName,
+		"encrypt":               true,
+		"dynamodb_table":        bucketName,
+		"create_dynamodb_table": true,
+	}).(*Backend)
+	defer deleteDynamoDBTable(t, b1.dynClient, bucketName)
+
+	b2 := backend.TestBackendConfig(t, New(), map[string]interface{}{
+		"bucket":                bucketName,
+		"key":                   keyName,
+		"encrypt":               true,
+		"dynamodb_table":        bucketName,
+		"create_dynamodb_table": true,
+	}).(*Backend)
+
+	createS3Bucket(t, b1.s3Client, bucketName)
+	defer deleteS3Bucket(t, b1.s3Client, bucketName)
+
+	backend.TestBackend(t, b1, b2)
+}
+
 // add some extra junk in S3 to try and confuse the env listing.
 func TestBackendExtraPaths(t *testing.T) {
 	testACC(t)
@@ -310,6 +517,24 @@ func TestKeyEnv(t *testing.T) {
 	backend.TestBackend(t, b2, nil)
 }
 
+// The workspace of a key is found without reaching S3.
+func TestKeyEnv_prefix(t *testing.T) {
+	b := &Backend{keyName: "states/tfstate", workspaceKeyPrefix: "states"}
+
+	cases := map[string]string{
+		"states/ws1/states/tfstate":   "ws1",
+		"states/ws1/other/tfstate":    "",
+		"states//states/tfstate":      "",
+		"other/states/ws1/tfstate":    "",
+		"states/ws1/states/tfstate/x": "",
+	}
+	for key, expected := range cases {
+		if err := testGetWorkspaceForKey(b, key, expected); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
 func testGetWorkspaceForKey(b *Backend, key string, expected string) error {
 	if actual := b.keyEnv(key); actual != expected {
 		return fmt.Errorf("incorrect workspace for key[%q]. Expected[%q]: Actual[%q]", key, expected, actual)
@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -25,6 +26,7 @@ import (
 const (
 	stateIDSuffix          = "-md5"
 	s3ErrCodeInternalError = "InternalError"
+	s3ErrCodeNotModified   = "NotModified"
 )
 
 type RemoteClient struct {
@@ -50,13 +52,38 @@ var (
 // test hook called when checksums don't match
 var testChecksumHook func()
 
-func (c *RemoteClient) Get() (payload *remote.Payload, err error) {
+func (c *RemoteClient) Get() (*remote.Payload, error) {
+	return c.getConsistent("")
+}
+
+// GetIfChanged implements remote.ClientConditionalGetter. The version of a
+// state is its MD5 followed by the ETag of its object, so that a cached
+// state can be checked against the digest in DynamoDB without reading the
+// object again.
+func (c *RemoteClient) GetIfChanged(version string) (*remote.Payload, error) {
+	parts := strings.SplitN(version, ":", 2)
+	if len(parts) != 2 {
+		return c.Get()
+	}
+
+	// If the digest doesn't match the cached state, it was written since,
+	// even if S3 doesn't know it yet.
+	if expected, err := c.getMD5(); err == nil && len(expected) > 0 && hex.EncodeToString(expected) != parts[0] {
+		return c.Get()
+	}
+
+	return c.getConsistent(parts[1])
+}
+
+// getConsistent reads the state, unless its object still has the given
+// ETag, retrying while it doesn't match the digest in DynamoDB.
+func (c *RemoteClient) getConsistent(etag string) (payload *remote.Payload, err error) {
 	deadline := time.Now().Add(consistencyRetryTimeout)
 
 	// If we have a checksum, and the returned payload doesn't match, we retry
 	// up until deadline.
 	for {
-		payload, err = c.get()
+		payload, err = c.get(etag)
 		if err != nil {
 			return nil, err
 		}
@@ -94,23 +121,30 @@ func (c *RemoteClient) Get() (payload *remote.Payload, err error) {
 	return payload, err
 }
 
-func (c *RemoteClient) get() (*remote.Payload, error) {
+func (c *RemoteClient) get(etag string) (*remote.Payload, error) {
 	var output *s3.GetObjectOutput
 	var err error
 
+	input := &s3.GetObjectInput{
+		Bucket: &c.bucketName,
+		Key:    &c.path,
+	}
+	if etag != "" {
+		input.IfNoneMatch = aws.String(etag)
+	}
+
 	// we immediately retry on an internal error, as those are usually transient
 	maxRetries := 2
 	for retryCount := 0; ; retryCount++ {
-		output, err = c.s3Client.GetObject(&s3.GetObjectInput{
-			Bucket: &c.bucketName,
-			Key:    &c.path,
-		})
+		output, err = c.s3Client.GetObject(input)
 
 		if err != nil {
 			if awserr, ok := err.(awserr.Error); ok {
 				switch awserr.Code() {
 				case s3.ErrCodeNoSuchKey:
 					return nil, nil
+				case s3ErrCodeNotModified:
+					return nil, remote.ErrNotModified
 				case s3ErrCodeInternalError:
 					if retryCount > maxRetries {
 						return nil, err
@@ -136,6 +170,9 @@ func (c *RemoteClient) get() (*remote.Payload, error) {
 		Data: buf.Bytes(),
 		MD5:  sum[:],
 	}
+	if output.ETag != nil {
+		payload.Version = hex.EncodeToString(sum[:]) + ":" + *output.ETag
+	}
 
 	// If there was no data, then return nil
 	if len(payload.Data) == 0 {
@@ -160,13 +197,13 @@ func (c *RemoteClient) Put(data []byte) error {
 			Key:           &c.path,
 		}
 
-		if c.serverSideEncryption {
-			if c.kmsKeyID != "" {
-				i.SSEKMSKeyId = &c.kmsKeyID
-				i.ServerSideEncryption = aws.String("aws:kms")
-			} else {
-				i.ServerSideEncryption = aws.String("AES256")
-			}
+		// A KMS key always encrypts the state, even if encrypt isn't set.
+		switch {
+		case c.kmsKeyID != "":
+			i.SSEKMSKeyId = &c.kmsKeyID
+			i.ServerSideEncryption = aws.String("aws:kms")
+		case c.serverSideEncryption:
+			i.ServerSideEncryption = aws.String("AES256")
 		}
 
 		if c.acl != "" {
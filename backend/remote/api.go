@@ -0,0 +1,378 @@
+package remote
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform/svchost"
+	"github.com/hashicorp/terraform/svchost/auth"
+)
+
+// The statuses of a run. A run waits in pending until it's at the front of
+// the queue of its workspace, and then plans. Runs that can be applied wait
+// in planned, or in policy_checked when the workspace has policies, until
+// they are confirmed or discarded. A run whose soft-mandatory policies
+// failed waits in policy_override until the failure is overridden.
+const (
+	runPending            = "pending"
+	runPlanning           = "planning"
+	runPlanned            = "planned"
+	runPolicyChecking     = "policy_checking"
+	runPolicyChecked      = "policy_checked"
+	runPolicyOverride     = "policy_override"
+	runConfirmed          = "confirmed"
+	runApplying           = "applying"
+	runApplied            = "applied"
+	runPlannedAndFinished = "planned_and_finished"
+	runDiscarded          = "discarded"
+	runCanceled           = "canceled"
+	runErrored            = "errored"
+)
+
+// The types of the events of a run.
+const (
+	eventStatus      = "status"
+	eventLog         = "log"
+	eventPolicyCheck = "policy_check"
+)
+
+// The results and enforcement levels of policy checks.
+const (
+	policyPassed        = "passed"
+	policyFailed        = "failed"
+	policyAdvisory      = "advisory"
+	policySoftMandatory = "soft-mandatory"
+	policyHardMandatory = "hard-mandatory"
+)
+
+type apiError struct {
+	Code    int    `json:"-"`
+	Message string `json:"error"`
+}
+
+func (e *apiError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("remote backend API returned status %d", e.Code)
+	}
+	return e.Message
+}
+
+func isNotFound(err error) bool {
+	e, ok := err.(*apiError)
+	return ok && e.Code == http.StatusNotFound
+}
+
+func isConflict(err error) bool {
+	e, ok := err.(*apiError)
+	return ok && e.Code == http.StatusConflict
+}
+
+type workspace struct {
+	Name   string `json:"name"`
+	Locked bool   `json:"locked"`
+}
+
+type workspaceList struct {
+	Workspaces []*workspace `json:"workspaces"`
+}
+
+type configurationVersion struct {
+	ID string `json:"id"`
+}
+
+type runCreateOptions struct {
+	Workspace            string   `json:"workspace"`
+	ConfigurationVersion string   `json:"configuration_version"`
+	Message              string   `json:"message"`
+	IsDestroy            bool     `json:"is_destroy"`
+	PlanOnly             bool     `json:"plan_only"`
+	Refresh              bool     `json:"refresh"`
+	RefreshOnly          bool     `json:"refresh_only"`
+	Targets              []string `json:"targets,omitempty"`
+	Replace              []string `json:"replace,omitempty"`
+}
+
+type run struct {
+	ID              string     `json:"id"`
+	Workspace       string     `json:"workspace"`
+	Status          string     `json:"status"`
+	PositionInQueue int        `json:"position_in_queue"`
+	HasChanges      bool       `json:"has_changes"`
+	IsDestroy       bool       `json:"is_destroy"`
+	PlanOnly        bool       `json:"plan_only"`
+	Actions         runActions `json:"actions"`
+}
+
+type runActions struct {
+	IsCancelable  bool `json:"is_cancelable"`
+	IsConfirmable bool `json:"is_confirmable"`
+	IsOverridable bool `json:"is_overridable"`
+}
+
+// finished returns true if the run won't change anymore.
+func (r *run) finished() bool {
+	switch r.Status {
+	case runApplied, runPlannedAndFinished, runDiscarded, runCanceled, runErrored:
+		return true
+	}
+	return false
+}
+
+// needsInput returns true if the run waits for the user to override its
+// policy checks, or to confirm or discard it.
+func (r *run) needsInput() bool {
+	return r.Actions.IsConfirmable ||
+		(r.Status == runPolicyOverride && r.Actions.IsOverridable)
+}
+
+// runEvent is an event of a run. The events of a run are numbered from zero
+// by their offset, so that a client that reconnects can carry on from the
+// last event it received.
+type runEvent struct {
+	Offset          int          `json:"offset"`
+	Type            string       `json:"type"`
+	Status          string       `json:"status,omitempty"`
+	PositionInQueue int          `json:"position_in_queue,omitempty"`
+	Message         string       `json:"message,omitempty"`
+	Policy          *policyCheck `json:"policy,omitempty"`
+}
+
+type policyCheck struct {
+	Name             string `json:"name"`
+	Result           string `json:"result"`
+	EnforcementLevel string `json:"enforcement_level"`
+}
+
+// apiClient talks to the API of the remote backend on behalf of an
+// organization.
+type apiClient struct {
+	Client       *http.Client
+	Address      *url.URL
+	Token        string
+	Organization string
+
+	// Credentials are used to authenticate requests when Token is empty,
+	// and may be nil.
+	Credentials auth.CredentialsSource
+}
+
+func (c *apiClient) workspacesPath(name string) string {
+	p := "/api/v1/organizations/" + url.PathEscape(c.Organization) + "/workspaces"
+	if name != "" {
+		p += "/" + url.PathEscape(name)
+	}
+	return p
+}
+
+func (c *apiClient) ListWorkspaces(ctx context.Context) ([]*workspace, error) {
+	list := &workspaceList{}
+	if err := c.do(ctx, "GET", c.workspacesPath(""), nil, list); err != nil {
+		return nil, err
+	}
+	return list.Workspaces, nil
+}
+
+func (c *apiClient) ReadWorkspace(ctx context.Context, name string) (*workspace, error) {
+	w := &workspace{}
+	if err := c.do(ctx, "GET", c.workspacesPath(name), nil, w); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (c *apiClient) CreateWorkspace(ctx context.Context, name string) (*workspace, error) {
+	w := &workspace{}
+	if err := c.do(ctx, "POST", c.workspacesPath(""), &workspace{Name: name}, w); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (c *apiClient) DeleteWorkspace(ctx context.Context, name string) error {
+	return c.do(ctx, "DELETE", c.workspacesPath(name), nil, nil)
+}
+
+// UploadConfiguration creates a configuration version of a workspace from
+// a gzipped tar archive of the configuration.
+func (c *apiClient) UploadConfiguration(ctx context.Context, name string, archive io.Reader) (*configurationVersion, error) {
+	req, err := c.newRequest(ctx, "POST", c.workspacesPath(name)+"/configuration-versions", archive)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/gzip")
+
+	cv := &configurationVersion{}
+	if err := c.send(req, cv); err != nil {
+		return nil, err
+	}
+	return cv, nil
+}
+
+func (c *apiClient) CreateRun(ctx context.Context, opts *runCreateOptions) (*run, error) {
+	r := &run{}
+	if err := c.do(ctx, "POST", "/api/v1/runs", opts, r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (c *apiClient) ReadRun(ctx context.Context, id string) (*run, error) {
+	r := &run{}
+	if err := c.do(ctx, "GET", "/api/v1/runs/"+url.PathEscape(id), nil, r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// RunAction applies, discards, cancels or overrides the policy checks of a
+// run, depending on the action.
+func (c *apiClient) RunAction(ctx context.Context, id, action string) error {
+	return c.do(ctx, "POST", "/api/v1/runs/"+url.PathEscape(id)+"/actions/"+action, nil, nil)
+}
+
+// StreamEvents calls fn for each event of the run from the given offset
+// until the server closes the stream, which it does once there are no more
+// events for now. It returns the offset of the next event.
+func (c *apiClient) StreamEvents(ctx context.Context, id string, offset int, fn func(*runEvent) error) (int, error) {
+	req, err := c.newRequest(ctx, "GET", "/api/v1/runs/"+url.PathEscape(id)+"/events", nil)
+	if err != nil {
+		return offset, err
+	}
+	query := url.Values{}
+	query.Set("offset", strconv.Itoa(offset))
+	req.URL.RawQuery = query.Encode()
+	req.Header.Set("Accept", "application/x-ndjson")
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return offset, err
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp); err != nil {
+		return offset, err
+	}
+
+	// Each line of the stream is an event.
+	r := bufio.NewReader(resp.Body)
+	for {
+		line, err := r.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			ev := &runEvent{}
+			if jsonErr := json.Unmarshal(line, ev); jsonErr != nil {
+				// The last line is cut short if the stream was
+				// interrupted, so it's read again after reconnecting.
+				if err == io.EOF {
+					return offset, nil
+				}
+				return offset, fmt.Errorf("failed to decode an event of run %s: %s", id, jsonErr)
+			}
+			if ev.Offset < offset {
+				continue
+			}
+			if err := fn(ev); err != nil {
+				return offset, err
+			}
+			offset = ev.Offset + 1
+		}
+		if err == io.EOF {
+			return offset, nil
+		}
+		if err != nil {
+			return offset, err
+		}
+	}
+}
+
+func (c *apiClient) newRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	u := *c.Address
+	u.Path = strings.TrimSuffix(u.Path, "/") + path
+
+	req, err := http.NewRequest(method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Accept", "application/json")
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	} else if c.Credentials != nil {
+		host, err := svchost.ForComparison(c.Address.Host)
+		if err != nil {
+			return nil, fmt.Errorf("invalid hostname in 'address': %s", err)
+		}
+		creds, err := c.Credentials.ForHost(host)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get credentials for %s: %s", host.ForDisplay(), err)
+		}
+		if creds != nil {
+			creds.PrepareRequest(req)
+		}
+	}
+	return req, nil
+}
+
+// do sends a request to the API, encoding in as the JSON body if it isn't
+// nil, and decodes the response into out if it isn't nil.
+func (c *apiClient) do(ctx context.Context, method, path string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		raw, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(raw)
+	}
+
+	req, err := c.newRequest(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return c.send(req, out)
+}
+
+func (c *apiClient) send(req *http.Request, out interface{}) error {
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp); err != nil {
+		return err
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode the response of %s %s: %s",
+			req.Method, req.URL.Path, err)
+	}
+	return nil
+}
+
+// checkResponse returns an *apiError if the response isn't successful.
+func checkResponse(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+		return nil
+	}
+
+	body, _ := ioutil.ReadAll(resp.Body)
+	apiErr := &apiError{}
+	if err := json.Unmarshal(body, apiErr); err != nil || apiErr.Message == "" {
+		apiErr = &apiError{Message: strings.TrimSpace(string(body))}
+	}
+	apiErr.Code = resp.StatusCode
+	return apiErr
+}
@@ -0,0 +1,349 @@
+package remote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	cleanhttp "github.com/hashicorp/go-cleanhttp"
+	"github.com/hashicorp/terraform/backend"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/state"
+	"github.com/hashicorp/terraform/state/remote"
+	"github.com/hashicorp/terraform/svchost/auth"
+	"github.com/hashicorp/terraform/terraform"
+	"github.com/mitchellh/cli"
+	"github.com/mitchellh/colorstring"
+)
+
+// Remote is an implementation of EnhancedBackend that performs plan and
+// apply operations remotely, streaming their output back to the CLI. The
+// state of each workspace is stored remotely too.
+type Remote struct {
+	// CLI and Colorize control the CLI output. If CLI is nil then no CLI
+	// output will be done. If CLIColor is nil then no coloring will be done.
+	CLI      cli.Ui
+	CLIColor *colorstring.Colorize
+
+	//---------------------------------------------------------------
+	// Internal fields, do not set
+	//---------------------------------------------------------------
+	// client is the API client, setup in Configure
+	client *apiClient
+
+	// credentials are the credentials of the CLI configuration, which are
+	// used when no token is configured. They're set by CLIInit.
+	credentials auth.CredentialsSource
+
+	// workspace is the name of the remote workspace when the backend is
+	// configured with a single workspace, and prefix the prefix of the
+	// remote workspaces otherwise.
+	workspace string
+	prefix    string
+
+	// schema is the schema for configuration, set by init
+	schema *schema.Backend
+	once   sync.Once
+
+	// opLock locks operations
+	opLock sync.Mutex
+}
+
+func (b *Remote) Input(
+	ui terraform.UIInput, c *terraform.ResourceConfig) (*terraform.ResourceConfig, error) {
+	b.once.Do(b.init)
+	return b.schema.Input(ui, c)
+}
+
+func (b *Remote) Validate(c *terraform.ResourceConfig) ([]string, []error) {
+	b.once.Do(b.init)
+	warns, errs := b.schema.Validate(c)
+
+	_, hasName := c.Get("workspace")
+	_, hasPrefix := c.Get("workspace_prefix")
+	if hasName == hasPrefix {
+		errs = append(errs, errors.New(
+			`exactly one of "workspace" and "workspace_prefix" must be set`))
+	}
+	return warns, errs
+}
+
+func (b *Remote) Configure(c *terraform.ResourceConfig) error {
+	b.once.Do(b.init)
+	return b.schema.Configure(c)
+}
+
+func (b *Remote) States() ([]string, error) {
+	if b.prefix == "" {
+		return nil, backend.ErrNamedStatesNotSupported
+	}
+
+	workspaces, err := b.client.ListWorkspaces(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, w := range workspaces {
+		if !strings.HasPrefix(w.Name, b.prefix) {
+			continue
+		}
+		name := strings.TrimPrefix(w.Name, b.prefix)
+		if name == "" || name == backend.DefaultStateName {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// the default state always exists
+	return append([]string{backend.DefaultStateName}, names...), nil
+}
+
+func (b *Remote) DeleteState(name string) error {
+	if b.prefix == "" {
+		return backend.ErrNamedStatesNotSupported
+	}
+	if name == backend.DefaultStateName || name == "" {
+		return fmt.Errorf("can't delete default state")
+	}
+
+	err := b.client.DeleteWorkspace(context.Background(), b.prefix+name)
+	if err != nil && !isNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+func (b *Remote) State(name string) (state.State, error) {
+	remoteName, err := b.remoteWorkspace(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := b.ensureWorkspace(context.Background(), remoteName); err != nil {
+		return nil, err
+	}
+
+	return &remote.State{Client: &remoteClient{client: b.client, workspace: remoteName}}, nil
+}
+
+// remoteWorkspace returns the name of the remote workspace of the given
+// workspace.
+func (b *Remote) remoteWorkspace(name string) (string, error) {
+	if b.prefix == "" {
+		if name != backend.DefaultStateName {
+			return "", backend.ErrNamedStatesNotSupported
+		}
+		return b.workspace, nil
+	}
+	if name == "" {
+		name = backend.DefaultStateName
+	}
+	return b.prefix + name, nil
+}
+
+// ensureWorkspace creates the remote workspace if it doesn't exist yet, so
+// that States() lists it and runs can be started in it.
+func (b *Remote) ensureWorkspace(ctx context.Context, name string) error {
+	_, err := b.client.ReadWorkspace(ctx, name)
+	if err == nil {
+		return nil
+	}
+	if !isNotFound(err) {
+		return fmt.Errorf("failed to read workspace %q: %s", name, err)
+	}
+	if _, err := b.client.CreateWorkspace(ctx, name); err != nil && !isConflict(err) {
+		return fmt.Errorf("failed to create workspace %q: %s", name, err)
+	}
+	return nil
+}
+
+// Operation implements backend.Enhanced. Plans and applies run remotely;
+// refreshes aren't supported.
+func (b *Remote) Operation(ctx context.Context, op *backend.Operation) (*backend.RunningOperation, error) {
+	// Determine the function to call for our operation
+	var f func(context.Context, *backend.Operation, *backend.RunningOperation)
+	switch op.Type {
+	case backend.OperationTypePlan:
+		f = b.opPlan
+	case backend.OperationTypeApply:
+		f = b.opApply
+	default:
+		return nil, fmt.Errorf(
+			"\n\nThe \"remote\" backend does not support the %q operation.\n"+
+				"Please use the remote backend web UI for running this operation.", op.Type)
+	}
+
+	if err := b.checkOperation(op); err != nil {
+		return nil, err
+	}
+
+	// Lock
+	b.opLock.Lock()
+
+	// Build our running operation
+	runningCtx, runningCtxCancel := context.WithCancel(context.Background())
+	runningOp := &backend.RunningOperation{Context: runningCtx}
+
+	// Do it
+	go func() {
+		defer b.opLock.Unlock()
+		defer runningCtxCancel()
+		f(ctx, op, runningOp)
+	}()
+
+	// Return
+	return runningOp, nil
+}
+
+// checkOperation returns an error if the operation uses options that can't
+// be honored remotely.
+func (b *Remote) checkOperation(op *backend.Operation) error {
+	if op.Plan != nil {
+		return errors.New(strings.TrimSpace(errSavedPlanNotSupported))
+	}
+	if op.PlanOutPath != "" {
+		return errors.New(strings.TrimSpace(errPlanOutNotSupported))
+	}
+	if len(op.Variables) > 0 {
+		return errors.New(strings.TrimSpace(errVariablesNotSupported))
+	}
+	if op.View != nil {
+		return errors.New(strings.TrimSpace(errViewNotSupported))
+	}
+	return nil
+}
+
+// Colorize returns the Colorize structure that can be used for colorizing
+// output. This is gauranteed to always return a non-nil value and so is useful
+// as a helper to wrap any potentially colored strings.
+func (b *Remote) Colorize() *colorstring.Colorize {
+	if b.CLIColor != nil {
+		return b.CLIColor
+	}
+
+	return &colorstring.Colorize{
+		Colors:  colorstring.DefaultColors,
+		Disable: true,
+	}
+}
+
+func (b *Remote) init() {
+	b.schema = &schema.Backend{
+		Schema: map[string]*schema.Schema{
+			"address": &schema.Schema{
+				Type:         schema.TypeString,
+				Required:     true,
+				Description:  schemaDescriptions["address"],
+				DefaultFunc:  schema.EnvDefaultFunc("TF_REMOTE_ADDRESS", nil),
+				ValidateFunc: validateAddress,
+			},
+
+			"token": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: schemaDescriptions["token"],
+				DefaultFunc: schema.EnvDefaultFunc("TF_REMOTE_TOKEN", ""),
+			},
+
+			"organization": &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: schemaDescriptions["organization"],
+			},
+
+			"workspace": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: schemaDescriptions["workspace"],
+			},
+
+			"workspace_prefix": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: schemaDescriptions["workspace_prefix"],
+			},
+		},
+
+		ConfigureFunc: b.schemaConfigure,
+	}
+}
+
+func (b *Remote) schemaConfigure(ctx context.Context) error {
+	d := schema.FromContextBackendConfig(ctx)
+
+	address, err := url.Parse(d.Get("address").(string))
+	if err != nil {
+		return fmt.Errorf("Error parsing 'address': %s", err)
+	}
+
+	b.client = &apiClient{
+		Client:       cleanhttp.DefaultPooledClient(),
+		Address:      address,
+		Token:        d.Get("token").(string),
+		Organization: d.Get("organization").(string),
+		Credentials:  b.credentials,
+	}
+	b.workspace = d.Get("workspace").(string)
+	b.prefix = d.Get("workspace_prefix").(string)
+
+	return nil
+}
+
+func validateAddress(v interface{}, k string) ([]string, []error) {
+	u, err := url.Parse(v.(string))
+	if err != nil {
+		return nil, []error{fmt.Errorf("%s: %s", k, err)}
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, []error{fmt.Errorf("%s must be an http or https URL", k)}
+	}
+	return nil, nil
+}
+
+var schemaDescriptions = map[string]string{
+	"address": "Address of the remote backend service, including the HTTP scheme.\n" +
+		"If TF_REMOTE_ADDRESS is set then this will be used by default.",
+	"token": "Token to authenticate to the remote backend service. If\n" +
+		"TF_REMOTE_TOKEN is set then this will be used by default. Otherwise\n" +
+		"the credentials for the host in the CLI configuration are used.",
+	"organization": "Name of the organization containing the workspaces.",
+	"workspace": "Name of the single remote workspace to use. Conflicts with\n" +
+		"workspace_prefix.",
+	"workspace_prefix": "Prefix of the remote workspaces to use, with the name of\n" +
+		"each Terraform workspace appended to it. Conflicts with workspace.",
+}
+
+const errSavedPlanNotSupported = `
+Applying a saved plan is not supported by the "remote" backend.
+
+Plans are made and applied in the same remote run. Run "terraform apply"
+without a plan file to plan and apply the configuration remotely.
+`
+
+const errPlanOutNotSupported = `
+Saving a generated plan is not supported by the "remote" backend.
+
+Plans only exist in the remote run that made them. Run "terraform plan"
+without the -out flag to preview the changes remotely.
+`
+
+const errViewNotSupported = `
+JSON output is not supported by the "remote" backend.
+
+The output of remote runs is streamed from the remote backend as text. Run
+the command without the -json flag to see it.
+`
+
+const errVariablesNotSupported = `
+Setting variables is not supported by the "remote" backend.
+
+Set the variables of the workspace in the remote backend instead, or use
+a *.auto.tfvars file in the configuration directory, which is uploaded
+with the rest of the configuration.
+`
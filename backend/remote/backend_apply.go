@@ -0,0 +1,61 @@
+package remote
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/terraform/backend"
+)
+
+func (b *Remote) opApply(
+	ctx context.Context,
+	op *backend.Operation,
+	runningOp *backend.RunningOperation) {
+	log.Printf("[INFO] backend/remote: starting Apply operation")
+
+	s, err := b.startRun(ctx, op, false)
+	if err != nil {
+		runningOp.Err = err
+		return
+	}
+
+	// The run may wait for its failed policies to be overridden, and then
+	// to be confirmed, before it's applied.
+	for {
+		if err := s.wait(ctx); err != nil {
+			runningOp.Err = b.stopRun(s, err)
+			return
+		}
+		if s.run.finished() {
+			break
+		}
+
+		if s.run.Status == runPolicyOverride {
+			err = b.confirmOverride(ctx, op, s.run)
+		} else {
+			err = b.confirmApply(ctx, op, s.run)
+		}
+		if err != nil {
+			runningOp.Err = err
+			return
+		}
+	}
+
+	if err := runResult(s.run); err != nil {
+		runningOp.Err = err
+		return
+	}
+
+	// Read the state written by the run, for the outputs.
+	opState, err := b.State(op.Workspace)
+	if err != nil {
+		runningOp.Err = err
+		return
+	}
+	if err := opState.RefreshState(); err != nil {
+		runningOp.Err = fmt.Errorf("Error reading the state after the run: %s", err)
+		return
+	}
+	runningOp.State = opState.State()
+}
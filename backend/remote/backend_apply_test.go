@@ -0,0 +1,288 @@
+package remote
+
+import (
+	"context"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform/backend"
+	"github.com/hashicorp/terraform/config/module"
+	"github.com/hashicorp/terraform/terraform"
+	"github.com/mitchellh/cli"
+)
+
+func TestRemote_applyBasic(t *testing.T) {
+	server := newTestServer()
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	b := testBackend(t, ts, "")
+	ui := cli.NewMockUi()
+	b.CLI = ui
+
+	mod, modCleanup := module.TestTree(t, "./test-fixtures/config")
+	defer modCleanup()
+
+	input := &terraform.MockUIInput{
+		InputReturnMap: map[string]string{"approve": "yes"},
+	}
+	op := testOperationApply()
+	op.Module = mod
+	op.UIIn = input
+
+	run, err := b.Operation(context.Background(), op)
+	if err != nil {
+		t.Fatalf("bad: %s", err)
+	}
+	<-run.Done()
+	if run.Err != nil {
+		t.Fatalf("err: %s", run.Err)
+	}
+
+	if !input.InputCalled || input.InputOpts.Id != "approve" {
+		t.Fatal("expected to be asked for approval")
+	}
+	expected := []string{"run-1:apply"}
+	if !reflect.DeepEqual(server.actions, expected) {
+		t.Fatalf("expected actions %v, got %v", expected, server.actions)
+	}
+
+	// The events of the apply are streamed after the confirmation.
+	output := ui.OutputWriter.String()
+	for _, s := range []string{"Running apply in the remote backend", "Plan: 1 to add", "Apply complete!"} {
+		if n := strings.Count(output, s); n != 1 {
+			t.Fatalf("expected %q once in the output, found it %d times:\n%s", s, n, output)
+		}
+	}
+
+	// The state written by the run is returned.
+	if run.State == nil {
+		t.Fatal("expected a state")
+	}
+	if v := run.State.RootModule().Outputs["run"]; v == nil || v.Value != "run-1" {
+		t.Fatalf("wrong outputs %#v", run.State.RootModule().Outputs)
+	}
+}
+
+func TestRemote_applyDiscarded(t *testing.T) {
+	server := newTestServer()
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	b := testBackend(t, ts, "")
+
+	mod, modCleanup := module.TestTree(t, "./test-fixtures/config")
+	defer modCleanup()
+
+	op := testOperationApply()
+	op.Module = mod
+	op.UIIn = &terraform.MockUIInput{
+		InputReturnMap: map[string]string{"approve": "no"},
+	}
+
+	run, err := b.Operation(context.Background(), op)
+	if err != nil {
+		t.Fatalf("bad: %s", err)
+	}
+	<-run.Done()
+	if run.Err == nil || run.Err.Error() != "Apply cancelled." {
+		t.Fatalf("expected the apply to be cancelled, got %v", run.Err)
+	}
+
+	expected := []string{"run-1:discard"}
+	if !reflect.DeepEqual(server.actions, expected) {
+		t.Fatalf("expected actions %v, got %v", expected, server.actions)
+	}
+}
+
+func TestRemote_applyAutoApprove(t *testing.T) {
+	server := newTestServer()
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	b := testBackend(t, ts, "")
+
+	mod, modCleanup := module.TestTree(t, "./test-fixtures/config")
+	defer modCleanup()
+
+	input := &terraform.MockUIInput{}
+	op := testOperationApply()
+	op.Module = mod
+	op.UIIn = input
+	op.AutoApprove = true
+
+	run, err := b.Operation(context.Background(), op)
+	if err != nil {
+		t.Fatalf("bad: %s", err)
+	}
+	<-run.Done()
+	if run.Err != nil {
+		t.Fatalf("err: %s", run.Err)
+	}
+
+	if input.InputCalled {
+		t.Fatal("didn't expect to be asked for approval")
+	}
+	if r := server.runs["run-1"]; r.Status != runApplied {
+		t.Fatalf("expected the run to be applied, got %q", r.Status)
+	}
+}
+
+func TestRemote_applyNoChanges(t *testing.T) {
+	server := newTestServer()
+	server.hasChanges = false
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	b := testBackend(t, ts, "")
+
+	mod, modCleanup := module.TestTree(t, "./test-fixtures/config")
+	defer modCleanup()
+
+	input := &terraform.MockUIInput{}
+	op := testOperationApply()
+	op.Module = mod
+	op.UIIn = input
+
+	run, err := b.Operation(context.Background(), op)
+	if err != nil {
+		t.Fatalf("bad: %s", err)
+	}
+	<-run.Done()
+	if run.Err != nil {
+		t.Fatalf("err: %s", run.Err)
+	}
+	if input.InputCalled {
+		t.Fatal("didn't expect to be asked for approval")
+	}
+}
+
+func TestRemote_applyPolicyOverride(t *testing.T) {
+	server := newTestServer()
+	server.policies = []string{"cost:failed:soft-mandatory"}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	b := testBackend(t, ts, "")
+	ui := cli.NewMockUi()
+	b.CLI = ui
+
+	mod, modCleanup := module.TestTree(t, "./test-fixtures/config")
+	defer modCleanup()
+
+	var asked []string
+	op := testOperationApply()
+	op.Module = mod
+	op.UIIn = &terraform.MockUIInput{
+		InputFn: func(opts *terraform.InputOpts) (string, error) {
+			asked = append(asked, opts.Id)
+			return map[string]string{"override": "override", "approve": "yes"}[opts.Id], nil
+		},
+	}
+
+	run, err := b.Operation(context.Background(), op)
+	if err != nil {
+		t.Fatalf("bad: %s", err)
+	}
+	<-run.Done()
+	if run.Err != nil {
+		t.Fatalf("err: %s", run.Err)
+	}
+
+	if expected := []string{"override", "approve"}; !reflect.DeepEqual(asked, expected) {
+		t.Fatalf("expected to be asked %v, got %v", expected, asked)
+	}
+	if expected := []string{"run-1:override", "run-1:apply"}; !reflect.DeepEqual(server.actions, expected) {
+		t.Fatalf("expected actions %v, got %v", expected, server.actions)
+	}
+
+	output := ui.OutputWriter.String()
+	for _, s := range []string{`Policy "cost" failed (soft-mandatory)`, "A soft-mandatory policy failed"} {
+		if !strings.Contains(output, s) {
+			t.Fatalf("expected %q in the output:\n%s", s, output)
+		}
+	}
+}
+
+func TestRemote_applyPolicyOverrideAutoApprove(t *testing.T) {
+	server := newTestServer()
+	server.policies = []string{"cost:failed:soft-mandatory"}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	b := testBackend(t, ts, "")
+
+	mod, modCleanup := module.TestTree(t, "./test-fixtures/config")
+	defer modCleanup()
+
+	input := &terraform.MockUIInput{}
+	op := testOperationApply()
+	op.Module = mod
+	op.UIIn = input
+	op.AutoApprove = true
+
+	run, err := b.Operation(context.Background(), op)
+	if err != nil {
+		t.Fatalf("bad: %s", err)
+	}
+	<-run.Done()
+	if run.Err == nil || !strings.Contains(run.Err.Error(), "never approved automatically") {
+		t.Fatalf("expected an error, got %v", run.Err)
+	}
+
+	if input.InputCalled {
+		t.Fatal("didn't expect to be asked for input")
+	}
+	if expected := []string{"run-1:discard"}; !reflect.DeepEqual(server.actions, expected) {
+		t.Fatalf("expected actions %v, got %v", expected, server.actions)
+	}
+}
+
+func TestRemote_applyCanceled(t *testing.T) {
+	server := newTestServer()
+	server.queue = 1000000
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	b := testBackend(t, ts, "")
+
+	mod, modCleanup := module.TestTree(t, "./test-fixtures/config")
+	defer modCleanup()
+
+	op := testOperationApply()
+	op.Module = mod
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	run, err := b.Operation(ctx, op)
+	if err != nil {
+		t.Fatalf("bad: %s", err)
+	}
+
+	// Interrupt the operation while the run waits in the queue.
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+
+	<-run.Done()
+	if run.Err == nil || !strings.Contains(run.Err.Error(), "canceled") {
+		t.Fatalf("expected the run to be canceled, got %v", run.Err)
+	}
+
+	server.Lock()
+	defer server.Unlock()
+	if expected := []string{"run-1:cancel"}; !reflect.DeepEqual(server.actions, expected) {
+		t.Fatalf("expected actions %v, got %v", expected, server.actions)
+	}
+}
+
+func testOperationApply() *backend.Operation {
+	return &backend.Operation{
+		Type:        backend.OperationTypeApply,
+		PlanRefresh: true,
+		Workspace:   backend.DefaultStateName,
+	}
+}
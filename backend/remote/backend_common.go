@@ -0,0 +1,351 @@
+package remote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/atlas-go/archive"
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/terraform/backend"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+// streamRetryInterval is how long to wait before streaming the events of a
+// run again, when the stream ended before the run needed input or finished.
+// It's a variable for testing.
+var streamRetryInterval = 2 * time.Second
+
+// startRun uploads the configuration and starts a run for the operation.
+func (b *Remote) startRun(ctx context.Context, op *backend.Operation, planOnly bool) (*runStream, error) {
+	if op.Module == nil && !op.Destroy {
+		return nil, errors.New(strings.TrimSpace(errNoConfig))
+	}
+
+	workspace, err := b.remoteWorkspace(op.Workspace)
+	if err != nil {
+		return nil, err
+	}
+	if err := b.ensureWorkspace(ctx, workspace); err != nil {
+		return nil, err
+	}
+
+	configDir := "."
+	if op.Module != nil && op.Module.Config() != nil && op.Module.Config().Dir != "" {
+		configDir = op.Module.Config().Dir
+	}
+
+	a, err := configArchive(configDir)
+	if err != nil {
+		return nil, fmt.Errorf("Error archiving the configuration for uploading: %s", err)
+	}
+	defer a.Close()
+
+	cv, err := b.client.UploadConfiguration(ctx, workspace, a)
+	if err != nil {
+		return nil, fmt.Errorf("Error uploading the configuration: %s", err)
+	}
+
+	r, err := b.client.CreateRun(ctx, &runCreateOptions{
+		Workspace:            workspace,
+		ConfigurationVersion: cv.ID,
+		Message:              "Queued manually using Terraform",
+		IsDestroy:            op.Destroy,
+		PlanOnly:             planOnly,
+		Refresh:              op.PlanRefresh,
+		RefreshOnly:          op.PlanRefreshOnly,
+		Targets:              op.Targets,
+		Replace:              op.Replace,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Error creating the run: %s", err)
+	}
+	log.Printf("[INFO] backend/remote: started run %s in workspace %q", r.ID, workspace)
+
+	kind := "apply"
+	if planOnly {
+		kind = "plan"
+	}
+	b.output(fmt.Sprintf(strings.TrimSpace(outputRunHeader), kind, b.runURL(workspace, r.ID)) + "\n")
+
+	return &runStream{b: b, run: r}, nil
+}
+
+// stopRun cancels the run if the operation was interrupted, and returns
+// the error of the operation.
+func (b *Remote) stopRun(s *runStream, err error) error {
+	if err != context.Canceled {
+		return err
+	}
+
+	b.output("[reset][bold]Canceling the remote run...[reset]")
+	if err := b.client.RunAction(context.Background(), s.run.ID, "cancel"); err != nil {
+		return fmt.Errorf("Error canceling run %s: %s", s.run.ID, err)
+	}
+	return fmt.Errorf("Run %s was canceled.", s.run.ID)
+}
+
+// confirmApply asks whether to apply the planned run, unless it's been
+// approved already, and then applies or discards it.
+func (b *Remote) confirmApply(ctx context.Context, op *backend.Operation, r *run) error {
+	if op.Destroy && !op.DestroyForce || !op.Destroy && !op.AutoApprove {
+		var desc, query string
+		if op.Destroy {
+			query = "Do you really want to destroy?"
+			desc = "Terraform will destroy all your managed infrastructure, as shown above.\n" +
+				"There is no undo. Only 'yes' will be accepted to confirm."
+		} else {
+			query = "Do you want to perform these actions?"
+			desc = "Terraform will perform the actions described above.\n" +
+				"Only 'yes' will be accepted to approve."
+		}
+
+		v, err := op.UIIn.Input(&terraform.InputOpts{
+			Id:          "approve",
+			Query:       query,
+			Description: desc,
+		})
+		if err != nil {
+			return errwrap.Wrapf("Error asking for approval: {{err}}", err)
+		}
+		if v != "yes" {
+			if err := b.client.RunAction(ctx, r.ID, "discard"); err != nil {
+				return fmt.Errorf("Error discarding run %s: %s", r.ID, err)
+			}
+			if op.Destroy {
+				return errors.New("Destroy cancelled.")
+			}
+			return errors.New("Apply cancelled.")
+		}
+	}
+
+	if err := b.client.RunAction(ctx, r.ID, "apply"); err != nil {
+		return fmt.Errorf("Error applying run %s: %s", r.ID, err)
+	}
+	return nil
+}
+
+// confirmOverride asks whether to override the soft-mandatory policies that
+// failed, and then overrides them or discards the run. Overriding policies
+// is never approved automatically.
+func (b *Remote) confirmOverride(ctx context.Context, op *backend.Operation, r *run) error {
+	if op.AutoApprove || op.DestroyForce {
+		if err := b.client.RunAction(ctx, r.ID, "discard"); err != nil {
+			return fmt.Errorf("Error discarding run %s: %s", r.ID, err)
+		}
+		return errors.New(strings.TrimSpace(errPolicyOverrideAutoApprove))
+	}
+
+	v, err := op.UIIn.Input(&terraform.InputOpts{
+		Id:    "override",
+		Query: "Do you want to override the failed policy check?",
+		Description: "The run can't be applied unless the soft-mandatory policies that failed\n" +
+			"are overridden. Only 'override' will be accepted to override.",
+	})
+	if err != nil {
+		return errwrap.Wrapf("Error asking to override the policy check: {{err}}", err)
+	}
+	if v != "override" {
+		if err := b.client.RunAction(ctx, r.ID, "discard"); err != nil {
+			return fmt.Errorf("Error discarding run %s: %s", r.ID, err)
+		}
+		return errors.New("Policy check failed, the run was discarded.")
+	}
+
+	if err := b.client.RunAction(ctx, r.ID, "override"); err != nil {
+		return fmt.Errorf("Error overriding the policy check of run %s: %s", r.ID, err)
+	}
+	return nil
+}
+
+// runResult returns the error of a finished run, if it didn't succeed.
+func runResult(r *run) error {
+	switch r.Status {
+	case runApplied, runPlannedAndFinished:
+		return nil
+	case runDiscarded:
+		return fmt.Errorf("Run %s was discarded.", r.ID)
+	case runCanceled:
+		return fmt.Errorf("Run %s was canceled.", r.ID)
+	default:
+		return fmt.Errorf("Run %s errored, see the output above for details.", r.ID)
+	}
+}
+
+// runStream streams the events of a run to the CLI. It keeps track of the
+// offset of the next event so that each event is only shown once, even when
+// the stream is resumed after the run needed input.
+type runStream struct {
+	b        *Remote
+	run      *run
+	offset   int
+	position int
+}
+
+// wait shows the events of the run until it needs input or is finished,
+// updating the run with its latest status.
+func (s *runStream) wait(ctx context.Context) error {
+	for {
+		if err := s.stream(ctx); err != nil {
+			return err
+		}
+
+		r, err := s.b.client.ReadRun(ctx, s.run.ID)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("Error reading run %s: %s", s.run.ID, err)
+		}
+		s.run = r
+
+		if r.finished() || r.needsInput() {
+			// Catch up on the events sent after the stream ended.
+			return s.stream(ctx)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(streamRetryInterval):
+		}
+	}
+}
+
+// stream shows the events the server has for now. Streams cut short by
+// network errors are resumed by the next call.
+func (s *runStream) stream(ctx context.Context) error {
+	offset, err := s.b.client.StreamEvents(ctx, s.run.ID, s.offset, s.show)
+	s.offset = offset
+	if err == nil {
+		return nil
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if _, ok := err.(*apiError); ok {
+		return fmt.Errorf("Error streaming the events of run %s: %s", s.run.ID, err)
+	}
+
+	log.Printf("[WARN] backend/remote: streaming the events of run %s failed, retrying: %s", s.run.ID, err)
+	return nil
+}
+
+func (s *runStream) show(ev *runEvent) error {
+	b := s.b
+	switch ev.Type {
+	case eventLog:
+		if b.CLI != nil {
+			b.CLI.Output(ev.Message)
+		}
+
+	case eventStatus:
+		switch ev.Status {
+		case runPending:
+			if ev.PositionInQueue > 0 && ev.PositionInQueue != s.position {
+				b.output(fmt.Sprintf(
+					"[reset][yellow]Waiting for %d run(s) to finish before this run is started...[reset]",
+					ev.PositionInQueue))
+			}
+			s.position = ev.PositionInQueue
+		case runPolicyChecking:
+			b.output("\n[reset][bold]Checking policies...[reset]")
+		case runPolicyOverride:
+			b.output("\n[reset][bold][yellow]A soft-mandatory policy failed. The run can only be " +
+				"applied if the failure is overridden.[reset]")
+		}
+
+	case eventPolicyCheck:
+		p := ev.Policy
+		if p == nil {
+			break
+		}
+		color := "green"
+		if p.Result != policyPassed {
+			color = "red"
+			if p.EnforcementLevel == policyAdvisory {
+				color = "yellow"
+			}
+		}
+		b.output(fmt.Sprintf("[reset][%s]Policy %q %s (%s)[reset]",
+			color, p.Name, p.Result, p.EnforcementLevel))
+		if ev.Message != "" && b.CLI != nil {
+			b.CLI.Output(ev.Message)
+		}
+
+	default:
+		log.Printf("[DEBUG] backend/remote: ignoring event of type %q of run %s", ev.Type, s.run.ID)
+	}
+	return nil
+}
+
+// configArchive archives the configuration in dir for uploading. The data
+// directory is left out, except for the modules that were downloaded into
+// it by "terraform init".
+func configArchive(dir string) (*archive.Archive, error) {
+	opts := &archive.ArchiveOpts{
+		Exclude: []string{".terraform"},
+		Extra:   map[string]string{},
+	}
+
+	dataDir := os.Getenv("TF_DATA_DIR")
+	if dataDir == "" {
+		dataDir = ".terraform"
+	}
+	modulesDir, err := filepath.Abs(filepath.Join(dataDir, "modules"))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(modulesDir); err == nil {
+		opts.Extra[".terraform"] = archive.ExtraEntryDir
+		opts.Extra[".terraform/modules"] = modulesDir
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	return archive.CreateArchive(dir, opts)
+}
+
+// runURL returns the address of the page of a run in the web UI.
+func (b *Remote) runURL(workspace, id string) string {
+	return fmt.Sprintf("%s/app/%s/%s/runs/%s",
+		strings.TrimSuffix(b.client.Address.String(), "/"),
+		b.client.Organization, workspace, id)
+}
+
+// output shows a colored message, if there is a CLI.
+func (b *Remote) output(msg string) {
+	if b.CLI != nil {
+		b.CLI.Output(b.Colorize().Color(msg))
+	}
+}
+
+const outputRunHeader = `
+[reset][yellow]Running %s in the remote backend. Output will stream here. Pressing Ctrl-C
+will cancel the remote run.
+
+To view this run in a browser, visit:
+%s[reset]
+`
+
+const errNoConfig = `
+No configuration files found!
+
+A plan or apply requires configuration to be present. Planning without
+a configuration would mark everything for destruction, which is normally
+not what is desired. If you would like to destroy everything, please run
+"terraform destroy" instead.
+`
+
+const errPolicyOverrideAutoApprove = `
+A soft-mandatory policy failed, and the run was discarded.
+
+Overriding failed policies is never approved automatically. Run the
+command again without -auto-approve or -force to be asked whether to
+override the policy check.
+`
@@ -0,0 +1,35 @@
+package remote
+
+import (
+	"context"
+	"log"
+
+	"github.com/hashicorp/terraform/backend"
+)
+
+func (b *Remote) opPlan(
+	ctx context.Context,
+	op *backend.Operation,
+	runningOp *backend.RunningOperation) {
+	log.Printf("[INFO] backend/remote: starting Plan operation")
+
+	s, err := b.startRun(ctx, op, true)
+	if err != nil {
+		runningOp.Err = err
+		return
+	}
+
+	if err := s.wait(ctx); err != nil {
+		runningOp.Err = b.stopRun(s, err)
+		return
+	}
+
+	// Plan-only runs finish once they're planned and their policies are
+	// checked, so they never need input.
+	if err := runResult(s.run); err != nil {
+		runningOp.Err = err
+		return
+	}
+
+	runningOp.PlanEmpty = !s.run.HasChanges
+}
@@ -0,0 +1,182 @@
+package remote
+
+import (
+	"context"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform/backend"
+	"github.com/hashicorp/terraform/config/module"
+	"github.com/mitchellh/cli"
+)
+
+func TestRemote_planBasic(t *testing.T) {
+	server := newTestServer()
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	b := testBackend(t, ts, "")
+	ui := cli.NewMockUi()
+	b.CLI = ui
+
+	mod, modCleanup := module.TestTree(t, "./test-fixtures/config")
+	defer modCleanup()
+
+	op := testOperationPlan()
+	op.Module = mod
+
+	run, err := b.Operation(context.Background(), op)
+	if err != nil {
+		t.Fatalf("bad: %s", err)
+	}
+	<-run.Done()
+	if run.Err != nil {
+		t.Fatalf("err: %s", run.Err)
+	}
+	if run.PlanEmpty {
+		t.Fatal("expected a plan with changes")
+	}
+
+	output := ui.OutputWriter.String()
+	for _, s := range []string{"Running plan in the remote backend", "/app/hashicorp/prod/runs/run-1", "Plan: 1 to add"} {
+		if !strings.Contains(output, s) {
+			t.Fatalf("expected %q in the output:\n%s", s, output)
+		}
+	}
+
+	if files := server.configs["cv-1"]; !reflect.DeepEqual(files, []string{"main.tf"}) {
+		t.Fatalf("wrong files uploaded: %v", files)
+	}
+	if r := server.runs["run-1"]; !r.PlanOnly || r.Status != runPlannedAndFinished {
+		t.Fatalf("wrong run %#v", r.run)
+	}
+}
+
+func TestRemote_planNoChanges(t *testing.T) {
+	server := newTestServer()
+	server.hasChanges = false
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	b := testBackend(t, ts, "")
+
+	mod, modCleanup := module.TestTree(t, "./test-fixtures/config")
+	defer modCleanup()
+
+	op := testOperationPlan()
+	op.Module = mod
+
+	run, err := b.Operation(context.Background(), op)
+	if err != nil {
+		t.Fatalf("bad: %s", err)
+	}
+	<-run.Done()
+	if run.Err != nil {
+		t.Fatalf("err: %s", run.Err)
+	}
+	if !run.PlanEmpty {
+		t.Fatal("expected an empty plan")
+	}
+}
+
+func TestRemote_planQueued(t *testing.T) {
+	server := newTestServer()
+	server.queue = 2
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	b := testBackend(t, ts, "")
+	ui := cli.NewMockUi()
+	b.CLI = ui
+
+	mod, modCleanup := module.TestTree(t, "./test-fixtures/config")
+	defer modCleanup()
+
+	op := testOperationPlan()
+	op.Module = mod
+
+	run, err := b.Operation(context.Background(), op)
+	if err != nil {
+		t.Fatalf("bad: %s", err)
+	}
+	<-run.Done()
+	if run.Err != nil {
+		t.Fatalf("err: %s", run.Err)
+	}
+
+	// Each position in the queue is shown once, and the events that follow
+	// are streamed after reconnecting.
+	output := ui.OutputWriter.String()
+	for _, s := range []string{"Waiting for 2 run(s)", "Waiting for 1 run(s)", "Plan: 1 to add"} {
+		if n := strings.Count(output, s); n != 1 {
+			t.Fatalf("expected %q once in the output, found it %d times:\n%s", s, n, output)
+		}
+	}
+}
+
+func TestRemote_planPolicies(t *testing.T) {
+	server := newTestServer()
+	server.policies = []string{"tags:passed:hard-mandatory", "cost:failed:advisory"}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	b := testBackend(t, ts, "")
+	ui := cli.NewMockUi()
+	b.CLI = ui
+
+	mod, modCleanup := module.TestTree(t, "./test-fixtures/config")
+	defer modCleanup()
+
+	op := testOperationPlan()
+	op.Module = mod
+
+	run, err := b.Operation(context.Background(), op)
+	if err != nil {
+		t.Fatalf("bad: %s", err)
+	}
+	<-run.Done()
+	if run.Err != nil {
+		t.Fatalf("err: %s", run.Err)
+	}
+
+	output := ui.OutputWriter.String()
+	for _, s := range []string{"Checking policies", `Policy "tags" passed (hard-mandatory)`, `Policy "cost" failed (advisory)`} {
+		if !strings.Contains(output, s) {
+			t.Fatalf("expected %q in the output:\n%s", s, output)
+		}
+	}
+}
+
+func TestRemote_planPolicyHardFailed(t *testing.T) {
+	server := newTestServer()
+	server.policies = []string{"tags:failed:hard-mandatory"}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	b := testBackend(t, ts, "")
+
+	mod, modCleanup := module.TestTree(t, "./test-fixtures/config")
+	defer modCleanup()
+
+	op := testOperationPlan()
+	op.Module = mod
+
+	run, err := b.Operation(context.Background(), op)
+	if err != nil {
+		t.Fatalf("bad: %s", err)
+	}
+	<-run.Done()
+	if run.Err == nil || !strings.Contains(run.Err.Error(), "errored") {
+		t.Fatalf("expected the run to error, got %v", run.Err)
+	}
+}
+
+func testOperationPlan() *backend.Operation {
+	return &backend.Operation{
+		Type:        backend.OperationTypePlan,
+		PlanRefresh: true,
+		Workspace:   backend.DefaultStateName,
+	}
+}
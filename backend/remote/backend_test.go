@@ -0,0 +1,254 @@
+package remote
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform/backend"
+	"github.com/hashicorp/terraform/config"
+	"github.com/hashicorp/terraform/state/remote"
+	"github.com/hashicorp/terraform/svchost"
+	"github.com/hashicorp/terraform/svchost/auth"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func init() {
+	streamRetryInterval = 10 * time.Millisecond
+}
+
+func TestRemote_impl(t *testing.T) {
+	var _ backend.Enhanced = new(Remote)
+	var _ backend.CLI = new(Remote)
+	var _ remote.Client = new(remoteClient)
+	var _ remote.ClientLocker = new(remoteClient)
+}
+
+func TestRemote_validate(t *testing.T) {
+	cases := map[string]struct {
+		Config map[string]interface{}
+		Err    string
+	}{
+		"workspace": {
+			Config: map[string]interface{}{"workspace": "prod"},
+		},
+		"prefix": {
+			Config: map[string]interface{}{"workspace_prefix": "app-"},
+		},
+		"both": {
+			Config: map[string]interface{}{"workspace": "prod", "workspace_prefix": "app-"},
+			Err:    "exactly one of",
+		},
+		"neither": {
+			Config: map[string]interface{}{},
+			Err:    "exactly one of",
+		},
+		"bad address": {
+			Config: map[string]interface{}{"workspace": "prod", "address": "ftp://example.com"},
+			Err:    "http or https",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			raw := map[string]interface{}{
+				"address":      "https://example.com",
+				"organization": "hashicorp",
+			}
+			for k, v := range tc.Config {
+				raw[k] = v
+			}
+			rc, err := config.NewRawConfig(raw)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, errs := new(Remote).Validate(terraform.NewResourceConfig(rc))
+			if tc.Err == "" {
+				if len(errs) > 0 {
+					t.Fatalf("unexpected errors: %v", errs)
+				}
+				return
+			}
+			if len(errs) != 1 || !strings.Contains(errs[0].Error(), tc.Err) {
+				t.Fatalf("expected an error containing %q, got %v", tc.Err, errs)
+			}
+		})
+	}
+}
+
+func TestRemote_backend(t *testing.T) {
+	ts := httptest.NewServer(newTestServer())
+	defer ts.Close()
+
+	b1 := testBackend(t, ts, "app-")
+	b2 := testBackend(t, ts, "app-")
+	backend.TestBackend(t, b1, b2)
+}
+
+func TestRemote_singleWorkspace(t *testing.T) {
+	server := newTestServer()
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	b := testBackend(t, ts, "")
+	if _, err := b.States(); err != backend.ErrNamedStatesNotSupported {
+		t.Fatalf("expected ErrNamedStatesNotSupported, got %v", err)
+	}
+	if _, err := b.State("foo"); err != backend.ErrNamedStatesNotSupported {
+		t.Fatalf("expected ErrNamedStatesNotSupported, got %v", err)
+	}
+
+	// The default state is stored in the configured workspace, which is
+	// created if needed.
+	if _, err := b.State(backend.DefaultStateName); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := server.workspaces["prod"]; !ok {
+		t.Fatal("workspace wasn't created")
+	}
+}
+
+func TestRemote_prefix(t *testing.T) {
+	server := newTestServer()
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	// Only the workspaces with the prefix are listed.
+	server.workspaces["app-prod"] = &testWorkspace{}
+	server.workspaces["other-prod"] = &testWorkspace{}
+
+	states, err := testBackend(t, ts, "app-").States()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"default", "prod"}
+	if !reflect.DeepEqual(states, expected) {
+		t.Fatalf("expected %v, got %v", expected, states)
+	}
+}
+
+func TestRemote_credentials(t *testing.T) {
+	ts := httptest.NewServer(newTestServer())
+	defer ts.Close()
+
+	// Without a token, the credentials of the CLI configuration for the
+	// host are used. Hostnames can't be IP addresses.
+	u, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u.Host = "localhost:" + u.Port()
+	host, err := svchost.ForComparison(u.Host)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := backend.TestBackendConfig(t, &Remote{}, map[string]interface{}{
+		"address":          u.String(),
+		"organization":     "hashicorp",
+		"workspace_prefix": "app-",
+	}).(*Remote)
+	err = b.CLIInit(&backend.CLIOpts{
+		Credentials: auth.StaticCredentialsSource(map[svchost.Hostname]map[string]interface{}{
+			host: {"token": "secret"},
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := b.States(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRemote_operationUnsupported(t *testing.T) {
+	ts := httptest.NewServer(newTestServer())
+	defer ts.Close()
+	b := testBackend(t, ts, "")
+
+	cases := map[string]struct {
+		Op  *backend.Operation
+		Err string
+	}{
+		"refresh": {
+			Op:  &backend.Operation{Type: backend.OperationTypeRefresh},
+			Err: "does not support",
+		},
+		"saved plan": {
+			Op:  &backend.Operation{Type: backend.OperationTypeApply, Plan: &terraform.Plan{}},
+			Err: "Applying a saved plan",
+		},
+		"plan out": {
+			Op:  &backend.Operation{Type: backend.OperationTypePlan, PlanOutPath: "plan.out"},
+			Err: "Saving a generated plan",
+		},
+		"variables": {
+			Op: &backend.Operation{
+				Type:      backend.OperationTypePlan,
+				Variables: map[string]interface{}{"foo": "bar"},
+			},
+			Err: "Setting variables",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := b.Operation(context.Background(), tc.Op)
+			if err == nil || !strings.Contains(err.Error(), tc.Err) {
+				t.Fatalf("expected an error containing %q, got %v", tc.Err, err)
+			}
+		})
+	}
+}
+
+func TestConfigArchive(t *testing.T) {
+	td, err := ioutil.TempDir("", "tf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(td)
+
+	// The data directory of the configuration is left out, but the modules
+	// of the data directory in use are included.
+	files := []string{
+		"config/main.tf",
+		"config/.terraform/plugins/provider",
+		"data/modules/abc/main.tf",
+		"data/plugins/provider",
+	}
+	for _, f := range files {
+		path := filepath.Join(td, f)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte("test"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	defer os.Setenv("TF_DATA_DIR", os.Getenv("TF_DATA_DIR"))
+	os.Setenv("TF_DATA_DIR", filepath.Join(td, "data"))
+
+	a, err := configArchive(filepath.Join(td, "config"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+
+	archived, err := archiveFiles(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{".terraform/modules/abc/main.tf", "main.tf"}
+	if !reflect.DeepEqual(archived, expected) {
+		t.Fatalf("expected %v, got %v", expected, archived)
+	}
+}
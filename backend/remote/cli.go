@@ -0,0 +1,18 @@
+package remote
+
+import (
+	"github.com/hashicorp/terraform/backend"
+)
+
+// backend.CLI impl.
+func (b *Remote) CLIInit(opts *backend.CLIOpts) error {
+	b.CLI = opts.CLI
+	b.CLIColor = opts.CLIColor
+
+	// This may be called after Configure, which creates the client.
+	b.credentials = opts.Credentials
+	if b.client != nil {
+		b.client.Credentials = opts.Credentials
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package remote
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/hashicorp/terraform/state"
+	"github.com/hashicorp/terraform/state/remote"
+)
+
+// remoteClient stores the state of a remote workspace. The workspace is
+// locked remotely, so that the runs of the workspace and the CLI exclude
+// each other.
+type remoteClient struct {
+	client    *apiClient
+	workspace string
+}
+
+func (c *remoteClient) Get() (*remote.Payload, error) {
+	req, err := c.client.newRequest(context.Background(), "GET", c.statePath(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.client.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNoContent || resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if err := checkResponse(resp); err != nil {
+		return nil, fmt.Errorf("failed to read the state of workspace %q: %s", c.workspace, err)
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the state of workspace %q: %s", c.workspace, err)
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	sum := md5.Sum(data)
+	return &remote.Payload{
+		Data: data,
+		MD5:  sum[:],
+	}, nil
+}
+
+func (c *remoteClient) Put(data []byte) error {
+	req, err := c.client.newRequest(context.Background(), "PUT", c.statePath(), bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	if err := c.client.send(req, nil); err != nil {
+		return fmt.Errorf("failed to write the state of workspace %q: %s", c.workspace, err)
+	}
+	return nil
+}
+
+func (c *remoteClient) Delete() error {
+	req, err := c.client.newRequest(context.Background(), "DELETE", c.statePath(), nil)
+	if err != nil {
+		return err
+	}
+
+	if err := c.client.send(req, nil); err != nil && !isNotFound(err) {
+		return fmt.Errorf("failed to delete the state of workspace %q: %s", c.workspace, err)
+	}
+	return nil
+}
+
+func (c *remoteClient) Lock(info *state.LockInfo) (string, error) {
+	if err := c.lockAction("lock", info); err != nil {
+		return "", err
+	}
+	return info.ID, nil
+}
+
+func (c *remoteClient) Unlock(id string) error {
+	return c.lockAction("unlock", &state.LockInfo{ID: id})
+}
+
+// lockAction locks or unlocks the workspace. When the workspace is locked
+// by someone else, the API responds with a conflict and the information
+// about the existing lock.
+func (c *remoteClient) lockAction(action string, info *state.LockInfo) error {
+	path := c.client.workspacesPath(c.workspace) + "/actions/" + action
+	req, err := c.client.newRequest(context.Background(), "POST", path, bytes.NewReader(info.Marshal()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusConflict {
+		lockErr := &state.LockError{}
+		body, _ := ioutil.ReadAll(resp.Body)
+		existing := &state.LockInfo{}
+		if err := json.Unmarshal(body, existing); err == nil && existing.ID != "" {
+			lockErr.Info = existing
+		}
+
+		switch {
+		case action == "lock":
+			lockErr.Err = fmt.Errorf("workspace %q is already locked", c.workspace)
+		case lockErr.Info == nil:
+			lockErr.Err = fmt.Errorf("workspace %q is not locked", c.workspace)
+		default:
+			lockErr.Err = fmt.Errorf("lock id %q does not match existing lock", info.ID)
+		}
+		return lockErr
+	}
+
+	if err := checkResponse(resp); err != nil {
+		return fmt.Errorf("failed to %s workspace %q: %s", action, c.workspace, err)
+	}
+	return nil
+}
+
+func (c *remoteClient) statePath() string {
+	return c.client.workspacesPath(c.workspace) + "/state"
+}
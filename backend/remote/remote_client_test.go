@@ -0,0 +1,82 @@
+package remote
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/terraform/backend"
+	"github.com/hashicorp/terraform/state"
+	"github.com/hashicorp/terraform/state/remote"
+)
+
+func TestRemoteClient(t *testing.T) {
+	ts := httptest.NewServer(newTestServer())
+	defer ts.Close()
+
+	s, err := testBackend(t, ts, "").State(backend.DefaultStateName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	remote.TestClient(t, s.(*remote.State).Client)
+}
+
+func TestRemoteLocks(t *testing.T) {
+	ts := httptest.NewServer(newTestServer())
+	defer ts.Close()
+
+	s1, err := testBackend(t, ts, "").State(backend.DefaultStateName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s2, err := testBackend(t, ts, "").State(backend.DefaultStateName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	remote.TestRemoteLocks(t, s1.(*remote.State).Client, s2.(*remote.State).Client)
+}
+
+func TestRemoteClient_lockInfo(t *testing.T) {
+	ts := httptest.NewServer(newTestServer())
+	defer ts.Close()
+
+	s1, err := testBackend(t, ts, "").State(backend.DefaultStateName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s2, err := testBackend(t, ts, "").State(backend.DefaultStateName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c1 := s1.(*remote.State).Client.(*remoteClient)
+	c2 := s2.(*remote.State).Client.(*remoteClient)
+
+	info := state.NewLockInfo()
+	info.Operation = "test"
+	info.Who = "clientA"
+	id, err := c1.Lock(info)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A conflicting lock reports who holds the lock.
+	_, err = c2.Lock(state.NewLockInfo())
+	lockErr, ok := err.(*state.LockError)
+	if !ok {
+		t.Fatalf("expected a *state.LockError, got %#v", err)
+	}
+	if lockErr.Info == nil || lockErr.Info.ID != id || lockErr.Info.Who != "clientA" {
+		t.Fatalf("wrong lock info %#v", lockErr.Info)
+	}
+
+	if err := c2.Unlock("wrong"); err == nil {
+		t.Fatal("expected error")
+	}
+	if err := c2.Unlock(id); err != nil {
+		t.Fatal(err)
+	}
+	if err := c2.Unlock(id); err == nil {
+		t.Fatal("expected error unlocking an unlocked state")
+	}
+}
@@ -0,0 +1,375 @@
+package remote
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/hashicorp/terraform/backend"
+	"github.com/hashicorp/terraform/state"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+// testServer is a fake remote backend API that keeps everything in memory.
+// Runs are planned as soon as they leave the queue, and applied as soon as
+// they're confirmed; the events of each step are recorded for streaming.
+type testServer struct {
+	sync.Mutex
+
+	// The settings of the runs that are created next.
+	//
+	// queue is the number of times reading a new run returns it as pending
+	// before it's planned, and hasChanges whether its plan has changes.
+	// policies are the results of its policy checks, as "name:result:level".
+	queue      int
+	hasChanges bool
+	policies   []string
+
+	workspaces map[string]*testWorkspace
+	configs    map[string][]string
+	runs       map[string]*testRun
+
+	// actions records the actions taken on runs, as "id:action".
+	actions []string
+}
+
+type testWorkspace struct {
+	state []byte
+	lock  *state.LockInfo
+}
+
+type testRun struct {
+	run
+	queue  int
+	events []*runEvent
+}
+
+func newTestServer() *testServer {
+	return &testServer{
+		hasChanges: true,
+		workspaces: map[string]*testWorkspace{},
+		configs:    map[string][]string{},
+		runs:       map[string]*testRun{},
+	}
+}
+
+// testBackend returns a backend configured to use the given server, with
+// a single workspace if prefix is empty.
+func testBackend(t *testing.T, ts *httptest.Server, prefix string) *Remote {
+	t.Helper()
+
+	c := map[string]interface{}{
+		"address":      ts.URL,
+		"token":        "secret",
+		"organization": "hashicorp",
+	}
+	if prefix != "" {
+		c["workspace_prefix"] = prefix
+	} else {
+		c["workspace"] = "prod"
+	}
+	return backend.TestBackendConfig(t, &Remote{}, c).(*Remote)
+}
+
+func (s *testServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.Lock()
+	defer s.Unlock()
+
+	if r.Header.Get("Authorization") != "Bearer secret" {
+		writeError(w, http.StatusUnauthorized, "invalid token")
+		return
+	}
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case len(parts) >= 5 && parts[0] == "api" && parts[2] == "organizations" && parts[4] == "workspaces":
+		s.serveWorkspaces(w, r, parts[5:])
+	case len(parts) >= 3 && parts[0] == "api" && parts[2] == "runs":
+		s.serveRuns(w, r, parts[3:])
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+func (s *testServer) serveWorkspaces(w http.ResponseWriter, r *http.Request, parts []string) {
+	if len(parts) == 0 {
+		switch r.Method {
+		case "GET":
+			list := &workspaceList{}
+			for name := range s.workspaces {
+				list.Workspaces = append(list.Workspaces, &workspace{Name: name})
+			}
+			writeJSON(w, list)
+		case "POST":
+			ws := &workspace{}
+			json.NewDecoder(r.Body).Decode(ws)
+			if _, ok := s.workspaces[ws.Name]; ok {
+				writeError(w, http.StatusConflict, "workspace exists")
+				return
+			}
+			s.workspaces[ws.Name] = &testWorkspace{}
+			writeJSON(w, ws)
+		}
+		return
+	}
+
+	name := parts[0]
+	ws, ok := s.workspaces[name]
+	if !ok {
+		writeError(w, http.StatusNotFound, "workspace not found")
+		return
+	}
+
+	switch strings.Join(append([]string{r.Method}, parts[1:]...), " ") {
+	case "GET":
+		writeJSON(w, &workspace{Name: name, Locked: ws.lock != nil})
+	case "DELETE":
+		delete(s.workspaces, name)
+	case "GET state":
+		if ws.state == nil {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.Write(ws.state)
+	case "PUT state":
+		ws.state, _ = ioutil.ReadAll(r.Body)
+	case "DELETE state":
+		ws.state = nil
+	case "POST actions lock":
+		info := &state.LockInfo{}
+		json.NewDecoder(r.Body).Decode(info)
+		if ws.lock != nil {
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(ws.lock)
+			return
+		}
+		ws.lock = info
+	case "POST actions unlock":
+		info := &state.LockInfo{}
+		json.NewDecoder(r.Body).Decode(info)
+		if ws.lock == nil || ws.lock.ID != info.ID {
+			w.WriteHeader(http.StatusConflict)
+			if ws.lock != nil {
+				json.NewEncoder(w).Encode(ws.lock)
+			}
+			return
+		}
+		ws.lock = nil
+	case "POST configuration-versions":
+		files, err := archiveFiles(r.Body)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		id := fmt.Sprintf("cv-%d", len(s.configs)+1)
+		s.configs[id] = files
+		writeJSON(w, &configurationVersion{ID: id})
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+func (s *testServer) serveRuns(w http.ResponseWriter, r *http.Request, parts []string) {
+	if len(parts) == 0 && r.Method == "POST" {
+		opts := &runCreateOptions{}
+		json.NewDecoder(r.Body).Decode(opts)
+		if _, ok := s.workspaces[opts.Workspace]; !ok {
+			writeError(w, http.StatusNotFound, "workspace not found")
+			return
+		}
+		if _, ok := s.configs[opts.ConfigurationVersion]; !ok {
+			writeError(w, http.StatusNotFound, "configuration version not found")
+			return
+		}
+
+		tr := &testRun{queue: s.queue}
+		tr.ID = fmt.Sprintf("run-%d", len(s.runs)+1)
+		tr.Workspace = opts.Workspace
+		tr.IsDestroy = opts.IsDestroy
+		tr.PlanOnly = opts.PlanOnly
+		tr.HasChanges = s.hasChanges
+		s.runs[tr.ID] = tr
+
+		tr.setStatus(runPending)
+		if tr.queue == 0 {
+			s.plan(tr)
+		}
+		writeJSON(w, &tr.run)
+		return
+	}
+	if len(parts) == 0 {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	tr, ok := s.runs[parts[0]]
+	if !ok {
+		writeError(w, http.StatusNotFound, "run not found")
+		return
+	}
+
+	switch strings.Join(append([]string{r.Method}, parts[1:]...), " ") {
+	case "GET":
+		if tr.queue > 0 {
+			tr.queue--
+			tr.setStatus(runPending)
+			if tr.queue == 0 {
+				s.plan(tr)
+			}
+		}
+		writeJSON(w, &tr.run)
+
+	case "GET events":
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		for _, ev := range tr.events[offset:] {
+			json.NewEncoder(w).Encode(ev)
+		}
+
+	case "POST actions apply":
+		s.actions = append(s.actions, tr.ID+":apply")
+		if !tr.Actions.IsConfirmable {
+			writeError(w, http.StatusConflict, "run is not confirmable")
+			return
+		}
+		s.apply(tr)
+
+	case "POST actions discard":
+		s.actions = append(s.actions, tr.ID+":discard")
+		tr.setStatus(runDiscarded)
+
+	case "POST actions override":
+		s.actions = append(s.actions, tr.ID+":override")
+		if !tr.Actions.IsOverridable {
+			writeError(w, http.StatusConflict, "run is not overridable")
+			return
+		}
+		tr.setStatus(runPolicyChecked)
+		tr.Actions.IsConfirmable = true
+
+	case "POST actions cancel":
+		s.actions = append(s.actions, tr.ID+":cancel")
+		tr.setStatus(runCanceled)
+
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+// plan plans a run that left the queue, and checks its policies.
+func (s *testServer) plan(tr *testRun) {
+	tr.setStatus(runPlanning)
+	tr.log("Plan: 1 to add, 0 to change, 0 to destroy.")
+	tr.setStatus(runPlanned)
+
+	softFailed := false
+	if len(s.policies) > 0 {
+		tr.setStatus(runPolicyChecking)
+		for _, p := range s.policies {
+			f := strings.Split(p, ":")
+			tr.addEvent(&runEvent{
+				Type:   eventPolicyCheck,
+				Policy: &policyCheck{Name: f[0], Result: f[1], EnforcementLevel: f[2]},
+			})
+			if f[1] == policyFailed && f[2] == policyHardMandatory {
+				tr.setStatus(runErrored)
+				return
+			}
+			softFailed = softFailed || f[1] == policyFailed && f[2] == policySoftMandatory
+		}
+	}
+
+	switch {
+	case tr.PlanOnly || !tr.HasChanges:
+		tr.setStatus(runPlannedAndFinished)
+	case softFailed:
+		tr.setStatus(runPolicyOverride)
+		tr.Actions.IsOverridable = true
+	default:
+		if len(s.policies) > 0 {
+			tr.setStatus(runPolicyChecked)
+		}
+		tr.Actions.IsConfirmable = true
+	}
+}
+
+// apply applies a confirmed run, writing a new state to its workspace.
+func (s *testServer) apply(tr *testRun) {
+	tr.setStatus(runConfirmed)
+	tr.setStatus(runApplying)
+	tr.log("Apply complete! Resources: 1 added, 0 changed, 0 destroyed.")
+
+	st := terraform.NewState()
+	st.Serial = int64(len(s.actions))
+	st.RootModule().Outputs["run"] = &terraform.OutputState{Type: "string", Value: tr.ID}
+	var buf bytes.Buffer
+	terraform.WriteState(st, &buf)
+	s.workspaces[tr.Workspace].state = buf.Bytes()
+
+	tr.setStatus(runApplied)
+}
+
+func (tr *testRun) setStatus(status string) {
+	tr.Status = status
+	tr.Actions = runActions{IsCancelable: !tr.finished()}
+	ev := &runEvent{Type: eventStatus, Status: status}
+	if status == runPending {
+		ev.PositionInQueue = tr.queue
+	}
+	tr.addEvent(ev)
+}
+
+func (tr *testRun) log(msg string) {
+	tr.addEvent(&runEvent{Type: eventLog, Message: msg})
+}
+
+func (tr *testRun) addEvent(ev *runEvent) {
+	ev.Offset = len(tr.events)
+	tr.events = append(tr.events, ev)
+}
+
+// archiveFiles returns the sorted names of the files in a tar.gz archive.
+func archiveFiles(r io.Reader) ([]string, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	tr := tar.NewReader(gz)
+
+	var files []string
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if h.Typeflag != tar.TypeDir {
+			files = append(files, h.Name)
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, code int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(&apiError{Message: msg})
+}
@@ -0,0 +1,5 @@
+resource "null_resource" "foo" {}
+
+output "run" {
+  value = "${null_resource.foo.id}"
+}
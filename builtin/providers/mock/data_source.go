@@ -0,0 +1,93 @@
+package mock
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/terraform/config/configschema"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+// DataSource customizes the behavior of a data source of a mock provider.
+type DataSource struct {
+	// Computed are the values of computed attributes that aren't set in
+	// the configuration. If "id" isn't given then a unique ID is generated.
+	Computed map[string]string
+
+	// ReadFunc, if set, is called with the flatmapped attributes set in the
+	// configuration and returns the attributes of the data source, in
+	// place of the default behavior of merging them with Computed.
+	ReadFunc func(info *terraform.InstanceInfo, attrs map[string]string) (map[string]string, error)
+}
+
+// ReadDataDiff implements terraform.ResourceProvider.
+func (p *Provider) ReadDataDiff(
+	info *terraform.InstanceInfo,
+	c *terraform.ResourceConfig) (*terraform.InstanceDiff, error) {
+	block, _, err := p.dataSource(info.Type)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &terraform.InstanceDiff{
+		Attributes: diffAttributes(block, nil, c, true),
+	}
+	diff.Attributes["id"] = &terraform.ResourceAttrDiff{
+		NewComputed: true,
+		RequiresNew: true,
+		Type:        terraform.DiffAttrOutput,
+	}
+
+	return diff, nil
+}
+
+// ReadDataApply implements terraform.ResourceProvider.
+func (p *Provider) ReadDataApply(
+	info *terraform.InstanceInfo,
+	d *terraform.InstanceDiff) (*terraform.InstanceState, error) {
+	_, ds, err := p.dataSource(info.Type)
+	if err != nil {
+		return nil, err
+	}
+
+	attrs := make(map[string]string)
+	for k, attr := range d.CopyAttributes() {
+		if !attr.NewComputed && !attr.NewRemoved {
+			attrs[k] = attr.New
+		}
+	}
+
+	if ds.ReadFunc != nil {
+		attrs, err = ds.ReadFunc(info, attrs)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		for k, v := range ds.Computed {
+			if _, ok := attrs[k]; !ok {
+				attrs[k] = v
+			}
+		}
+	}
+	if attrs["id"] == "" {
+		attrs["id"] = p.nextID(info.Type)
+	}
+
+	return &terraform.InstanceState{
+		ID:         attrs["id"],
+		Attributes: attrs,
+	}, nil
+}
+
+// dataSource returns the schema and behavior of the given data source.
+func (p *Provider) dataSource(t string) (*configschema.Block, *DataSource, error) {
+	block, ok := p.schema().DataSources[t]
+	if !ok {
+		return nil, nil, fmt.Errorf("unsupported data source %q", t)
+	}
+	ds := p.DataSourceTypes[t]
+	if ds == nil {
+		ds = &DataSource{}
+	}
+
+	return block, ds, nil
+}
@@ -0,0 +1,244 @@
+// Package mock contains an implementation of terraform.ResourceProvider
+// whose behavior is described entirely by a schema, canned responses and
+// optional callback functions, so that Terraform can plan and apply
+// configurations in automated tests without any real provider plugins.
+//
+// By default, resource types behave like a remote API that stores exactly
+// what it is given: planned values come from the configuration, computed
+// attributes are filled in from canned values when an instance is created,
+// and refreshing returns the state unchanged. Each operation can be
+// replaced with a function for tests that need something more elaborate.
+package mock
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/hashicorp/terraform/config/configschema"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+// Provider is a mock provider. The zero value is a provider with no
+// configuration arguments, resource types or data sources; Schema must
+// be set to describe them.
+//
+// The methods of Provider are safe to call concurrently, but its fields
+// must not be modified once it has been passed to Terraform.
+type Provider struct {
+	// Schema describes the provider configuration and the resource types
+	// and data sources the provider supports. Configurations are validated
+	// against it.
+	Schema *terraform.ProviderSchema
+
+	// ResourceTypes and DataSourceTypes customize the behavior of the
+	// resource types and data sources in Schema, by name. Those without
+	// an entry here use the default behavior.
+	ResourceTypes   map[string]*Resource
+	DataSourceTypes map[string]*DataSource
+
+	// Funcs are the functions exported by the provider.
+	Funcs []*Function
+
+	// ConfigureFunc, if set, is called with the provider configuration
+	// when the provider is configured.
+	ConfigureFunc func(*terraform.ResourceConfig) error
+
+	lock    sync.Mutex
+	config  *terraform.ResourceConfig
+	stopped bool
+	lastID  int
+}
+
+// Function is a function exported by a mock provider.
+type Function struct {
+	Signature terraform.ProviderFunction
+	Call      func(args []interface{}) (interface{}, error)
+}
+
+var (
+	_ terraform.ResourceProvider          = (*Provider)(nil)
+	_ terraform.ResourceProviderFunctions = (*Provider)(nil)
+)
+
+// Configured returns the configuration the provider was configured with,
+// or nil if it hasn't been configured.
+func (p *Provider) Configured() *terraform.ResourceConfig {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.config
+}
+
+// Stopped returns true if Stop has been called.
+func (p *Provider) Stopped() bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.stopped
+}
+
+// GetSchema implements terraform.ResourceProvider.
+func (p *Provider) GetSchema(req *terraform.ProviderSchemaRequest) (*terraform.ProviderSchema, error) {
+	schema := p.schema()
+	ret := &terraform.ProviderSchema{
+		Provider:      schema.Provider,
+		ResourceTypes: make(map[string]*configschema.Block),
+		DataSources:   make(map[string]*configschema.Block),
+		Capabilities:  schema.Capabilities,
+	}
+	for _, name := range req.ResourceTypes {
+		if block, ok := schema.ResourceTypes[name]; ok {
+			ret.ResourceTypes[name] = block
+		}
+		if r, ok := p.ResourceTypes[name]; ok && r.SchemaVersion > 0 {
+			if ret.ResourceTypeSchemaVersions == nil {
+				ret.ResourceTypeSchemaVersions = make(map[string]int)
+			}
+			ret.ResourceTypeSchemaVersions[name] = r.SchemaVersion
+		}
+	}
+	for _, r := range p.ResourceTypes {
+		if r != nil && r.SchemaVersion > 0 {
+			ret.Capabilities.UpgradeResourceState = true
+		}
+	}
+	for _, name := range req.DataSources {
+		if block, ok := schema.DataSources[name]; ok {
+			ret.DataSources[name] = block
+		}
+	}
+
+	return ret, nil
+}
+
+// Input implements terraform.ResourceProvider. The mock provider never
+// asks for input, so the configuration is returned unchanged.
+func (p *Provider) Input(input terraform.UIInput, c *terraform.ResourceConfig) (*terraform.ResourceConfig, error) {
+	return c, nil
+}
+
+// Validate implements terraform.ResourceProvider.
+func (p *Provider) Validate(c *terraform.ResourceConfig) ([]string, []error) {
+	block := p.schema().Provider
+	if block == nil {
+		block = &configschema.Block{}
+	}
+
+	return nil, validateBlock("", block, c.Raw)
+}
+
+// Configure implements terraform.ResourceProvider.
+func (p *Provider) Configure(c *terraform.ResourceConfig) error {
+	if p.ConfigureFunc != nil {
+		if err := p.ConfigureFunc(c); err != nil {
+			return err
+		}
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.config = c
+	return nil
+}
+
+// Resources implements terraform.ResourceProvider. Every resource type in
+// the schema is importable.
+func (p *Provider) Resources() []terraform.ResourceType {
+	schema := p.schema()
+	ret := make([]terraform.ResourceType, 0, len(schema.ResourceTypes))
+	for name := range schema.ResourceTypes {
+		ret = append(ret, terraform.ResourceType{
+			Name:            name,
+			Importable:      true,
+			SchemaAvailable: true,
+		})
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].Name < ret[j].Name
+	})
+
+	return ret
+}
+
+// DataSources implements terraform.ResourceProvider.
+func (p *Provider) DataSources() []terraform.DataSource {
+	schema := p.schema()
+	ret := make([]terraform.DataSource, 0, len(schema.DataSources))
+	for name := range schema.DataSources {
+		ret = append(ret, terraform.DataSource{
+			Name:            name,
+			SchemaAvailable: true,
+		})
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].Name < ret[j].Name
+	})
+
+	return ret
+}
+
+// Stop implements terraform.ResourceProvider.
+func (p *Provider) Stop() error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.stopped = true
+	return nil
+}
+
+// ValidateResource implements terraform.ResourceProvider.
+func (p *Provider) ValidateResource(t string, c *terraform.ResourceConfig) ([]string, []error) {
+	block, ok := p.schema().ResourceTypes[t]
+	if !ok {
+		return nil, []error{fmt.Errorf("unsupported resource type %q", t)}
+	}
+
+	return nil, validateBlock("", block, c.Raw)
+}
+
+// ValidateDataSource implements terraform.ResourceProvider.
+func (p *Provider) ValidateDataSource(t string, c *terraform.ResourceConfig) ([]string, []error) {
+	block, ok := p.schema().DataSources[t]
+	if !ok {
+		return nil, []error{fmt.Errorf("unsupported data source %q", t)}
+	}
+
+	return nil, validateBlock("", block, c.Raw)
+}
+
+// Functions implements terraform.ResourceProviderFunctions.
+func (p *Provider) Functions() ([]terraform.ProviderFunction, error) {
+	ret := make([]terraform.ProviderFunction, len(p.Funcs))
+	for i, f := range p.Funcs {
+		ret[i] = f.Signature
+	}
+
+	return ret, nil
+}
+
+// CallFunction implements terraform.ResourceProviderFunctions.
+func (p *Provider) CallFunction(name string, args []interface{}) (interface{}, error) {
+	for _, f := range p.Funcs {
+		if f.Signature.Name == name {
+			if f.Call == nil {
+				return nil, fmt.Errorf("function %q has no implementation", name)
+			}
+			return f.Call(args)
+		}
+	}
+
+	return nil, fmt.Errorf("unsupported function %q", name)
+}
+
+func (p *Provider) schema() *terraform.ProviderSchema {
+	if p.Schema == nil {
+		return &terraform.ProviderSchema{}
+	}
+	return p.Schema
+}
+
+// nextID returns a new unique ID for an object of the given type.
+func (p *Provider) nextID(t string) string {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.lastID++
+	return fmt.Sprintf("%s-%d", t, p.lastID)
+}
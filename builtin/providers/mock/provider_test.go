@@ -0,0 +1,379 @@
+package mock
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/hil/ast"
+	"github.com/hashicorp/terraform/config"
+	"github.com/hashicorp/terraform/config/configschema"
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func testProvider() *Provider {
+	return &Provider{
+		Schema: &terraform.ProviderSchema{
+			Provider: &configschema.Block{
+				Attributes: map[string]*configschema.Attribute{
+					"region": {Type: cty.String, Optional: true},
+				},
+			},
+			ResourceTypes: map[string]*configschema.Block{
+				"mock_instance": {
+					Attributes: map[string]*configschema.Attribute{
+						"id":       {Type: cty.String, Computed: true},
+						"ami":      {Type: cty.String, Required: true},
+						"name":     {Type: cty.String, Optional: true},
+						"address":  {Type: cty.String, Computed: true},
+						"password": {Type: cty.String, Optional: true, Sensitive: true},
+					},
+				},
+			},
+			DataSources: map[string]*configschema.Block{
+				"mock_image": {
+					Attributes: map[string]*configschema.Attribute{
+						"id":   {Type: cty.String, Computed: true},
+						"name": {Type: cty.String, Required: true},
+						"ami":  {Type: cty.String, Computed: true},
+					},
+				},
+			},
+		},
+		ResourceTypes: map[string]*Resource{
+			"mock_instance": {
+				ForceNew: []string{"ami"},
+				Computed: map[string]string{"address": "10.0.0.1"},
+			},
+		},
+		DataSourceTypes: map[string]*DataSource{
+			"mock_image": {
+				Computed: map[string]string{"ami": "ami-1234"},
+			},
+		},
+		Funcs: []*Function{
+			{
+				Signature: terraform.ProviderFunction{
+					Name:       "upper",
+					ParamTypes: []ast.Type{ast.TypeString},
+					ReturnType: ast.TypeString,
+				},
+				Call: func(args []interface{}) (interface{}, error) {
+					return strings.ToUpper(args[0].(string)), nil
+				},
+			},
+		},
+	}
+}
+
+func testConfig(t *testing.T, raw map[string]interface{}) *terraform.ResourceConfig {
+	rc, err := config.NewRawConfig(raw)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	return terraform.NewResourceConfig(rc)
+}
+
+func TestProvider_applyLifecycle(t *testing.T) {
+	p := testProvider()
+	resource.UnitTest(t, resource.TestCase{
+		Providers: map[string]terraform.ResourceProvider{
+			"mock": p,
+		},
+		Steps: []resource.TestStep{
+			{
+				Config: `
+provider "mock" {
+  region = "us-east-1"
+}
+
+data "mock_image" "foo" {
+  name = "base"
+}
+
+resource "mock_instance" "foo" {
+  ami  = "${data.mock_image.foo.ami}"
+  name = "${provider::mock::upper("foo")}"
+}
+`,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("mock_instance.foo", "ami", "ami-1234"),
+					resource.TestCheckResourceAttr("mock_instance.foo", "name", "FOO"),
+					resource.TestCheckResourceAttr("mock_instance.foo", "address", "10.0.0.1"),
+					resource.TestCheckResourceAttrSet("mock_instance.foo", "id"),
+					func(*terraform.State) error {
+						if got, want := p.Configured().Config["region"], "us-east-1"; got != want {
+							return fmt.Errorf("wrong region %q; want %q", got, want)
+						}
+						return nil
+					},
+				),
+			},
+			{
+				Config: `
+provider "mock" {
+  region = "us-east-1"
+}
+
+resource "mock_instance" "foo" {
+  ami = "ami-5678"
+}
+`,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("mock_instance.foo", "ami", "ami-5678"),
+					resource.TestCheckNoResourceAttr("mock_instance.foo", "name"),
+					resource.TestCheckResourceAttr("mock_instance.foo", "address", "10.0.0.1"),
+				),
+			},
+			{
+				ResourceName:  "mock_instance.foo",
+				ImportState:   true,
+				ImportStateId: "i-abc123",
+				ImportStateCheck: func(states []*terraform.InstanceState) error {
+					if len(states) != 1 || states[0].ID != "i-abc123" {
+						return fmt.Errorf("wrong imported states %#v", states)
+					}
+					return nil
+				},
+			},
+		},
+	})
+}
+
+func TestProviderValidateResource(t *testing.T) {
+	p := testProvider()
+	c := testConfig(t, map[string]interface{}{
+		"address": "10.0.0.2",
+		"bogus":   "yes",
+	})
+
+	_, errs := p.ValidateResource("mock_instance", c)
+	var got []string
+	for _, err := range errs {
+		got = append(got, err.Error())
+	}
+	want := []string{
+		"address: computed attributes cannot be set",
+		"bogus: unsupported argument",
+		"ami: required field is not set",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong errors\ngot:  %#v\nwant: %#v", got, want)
+	}
+
+	if _, errs := p.ValidateResource("mock_nope", c); len(errs) != 1 {
+		t.Fatalf("expected an error for an unsupported resource type; got %#v", errs)
+	}
+}
+
+func TestProviderDiff(t *testing.T) {
+	p := testProvider()
+	info := &terraform.InstanceInfo{Type: "mock_instance"}
+	state := &terraform.InstanceState{
+		ID: "i-abc123",
+		Attributes: map[string]string{
+			"id":      "i-abc123",
+			"ami":     "ami-1234",
+			"name":    "foo",
+			"address": "10.0.0.1",
+		},
+		Meta: map[string]interface{}{"schema_version": "0"},
+	}
+
+	// Nothing changed
+	c := testConfig(t, map[string]interface{}{
+		"ami":  "ami-1234",
+		"name": "foo",
+	})
+	diff, err := p.Diff(info, state, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff != nil {
+		t.Fatalf("expected no diff; got %#v", diff)
+	}
+
+	// Replace, removing the name and setting a sensitive password. The
+	// replacement is planned as a new instance, with a new address.
+	c = testConfig(t, map[string]interface{}{
+		"ami":      "ami-5678",
+		"password": config.UnknownVariableValue,
+	})
+	diff, err = p.Diff(info, state, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &terraform.InstanceDiff{
+		Attributes: map[string]*terraform.ResourceAttrDiff{
+			"ami": {
+				Old:         "ami-1234",
+				New:         "ami-5678",
+				RequiresNew: true,
+			},
+			"address": {
+				Old:         "10.0.0.1",
+				NewComputed: true,
+			},
+			"password": {
+				NewComputed: true,
+				Sensitive:   true,
+			},
+		},
+	}
+	if !reflect.DeepEqual(diff, want) {
+		t.Fatalf("wrong diff\ngot:  %#v\nwant: %#v", diff, want)
+	}
+}
+
+func TestFlattenConfig(t *testing.T) {
+	got := flattenConfig(map[string]interface{}{
+		"ami":  "ami-1234",
+		"tags": map[string]interface{}{"env": "prod"},
+		"disk": []map[string]interface{}{
+			{"size": "10", "labels": map[string]interface{}{"a": "b"}},
+		},
+	})
+	want := map[string]string{
+		"ami":             "ami-1234",
+		"tags.%":          "1",
+		"tags.env":        "prod",
+		"disk.#":          "1",
+		"disk.0.size":     "10",
+		"disk.0.labels.%": "1",
+		"disk.0.labels.a": "b",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong attributes\ngot:  %#v\nwant: %#v", got, want)
+	}
+}
+
+func TestProviderUpgradeState(t *testing.T) {
+	p := testProvider()
+	p.ResourceTypes["mock_instance"] = &Resource{
+		SchemaVersion: 2,
+		StateUpgraders: []StateUpgradeFunc{
+			func(attrs map[string]string) (map[string]string, error) {
+				attrs["ami"] = attrs["image"]
+				delete(attrs, "image")
+				return attrs, nil
+			},
+			func(attrs map[string]string) (map[string]string, error) {
+				attrs["name"] = strings.ToLower(attrs["name"])
+				return attrs, nil
+			},
+		},
+	}
+	info := &terraform.InstanceInfo{Type: "mock_instance"}
+
+	// A state with no recorded version is at version 0
+	state := &terraform.InstanceState{
+		ID: "i-abc123",
+		Attributes: map[string]string{
+			"id":    "i-abc123",
+			"image": "ami-1234",
+			"name":  "FOO",
+		},
+	}
+	got, err := p.Refresh(info, state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &terraform.InstanceState{
+		ID: "i-abc123",
+		Attributes: map[string]string{
+			"id":   "i-abc123",
+			"ami":  "ami-1234",
+			"name": "foo",
+		},
+		Meta: map[string]interface{}{"schema_version": "2"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong state\ngot:  %#v\nwant: %#v", got, want)
+	}
+	if _, ok := state.Attributes["image"]; !ok {
+		t.Fatal("original state was modified")
+	}
+
+	// Upgrades skip the versions the state already has
+	state.Attributes["name"] = "BAR"
+	state.Meta = map[string]interface{}{"schema_version": "1"}
+	got, err = p.Refresh(info, state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Attributes["name"] != "bar" || got.Attributes["image"] != "ami-1234" {
+		t.Fatalf("wrong attributes %#v", got.Attributes)
+	}
+
+	state.Meta = map[string]interface{}{"schema_version": "3"}
+	if _, err := p.Refresh(info, state); err == nil {
+		t.Fatal("expected an error for a state from a newer schema version")
+	}
+}
+
+func TestProviderUpgradeResourceState(t *testing.T) {
+	p := testProvider()
+	p.ResourceTypes["mock_instance"] = &Resource{
+		SchemaVersion: 1,
+		StateUpgraders: []StateUpgradeFunc{
+			func(attrs map[string]string) (map[string]string, error) {
+				attrs["ami"] = attrs["image"]
+				delete(attrs, "image")
+				return attrs, nil
+			},
+		},
+	}
+
+	schema, err := p.GetSchema(&terraform.ProviderSchemaRequest{
+		ResourceTypes: []string{"mock_instance"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !schema.Capabilities.UpgradeResourceState {
+		t.Fatal("expected the UpgradeResourceState capability")
+	}
+	if got := schema.ResourceTypeSchemaVersions["mock_instance"]; got != 1 {
+		t.Fatalf("wrong schema version %d", got)
+	}
+
+	got, err := p.UpgradeResourceState(&terraform.UpgradeResourceStateRequest{
+		Info:    &terraform.InstanceInfo{Type: "mock_instance"},
+		Version: 0,
+		State: &terraform.InstanceState{
+			ID:         "i-abc123",
+			Attributes: map[string]string{"id": "i-abc123", "image": "ami-1234"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &terraform.InstanceState{
+		ID:         "i-abc123",
+		Attributes: map[string]string{"id": "i-abc123", "ami": "ami-1234"},
+		Meta:       map[string]interface{}{"schema_version": "1"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong state\ngot:  %#v\nwant: %#v", got, want)
+	}
+}
+
+func TestProviderCallFunction(t *testing.T) {
+	p := testProvider()
+
+	got, err := p.CallFunction("upper", []interface{}{"foo"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "FOO" {
+		t.Fatalf("wrong result %#v", got)
+	}
+
+	if _, err := p.CallFunction("lower", []interface{}{"FOO"}); err == nil {
+		t.Fatal("expected an error for an unsupported function")
+	}
+}
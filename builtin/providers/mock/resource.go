@@ -0,0 +1,396 @@
+package mock
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform/config"
+	"github.com/hashicorp/terraform/config/configschema"
+	"github.com/hashicorp/terraform/flatmap"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+// Resource customizes the behavior of a resource type of a mock provider.
+type Resource struct {
+	// SchemaVersion is the current version of the resource type's schema.
+	// States recorded with an earlier version are upgraded by running the
+	// StateUpgraders for each version in between, where the upgrader at
+	// index i upgrades the attributes of a state from version i to i+1.
+	// States are upgraded before being passed to any of the functions
+	// below, although Terraform normally upgrades them first by calling
+	// UpgradeResourceState.
+	SchemaVersion  int
+	StateUpgraders []StateUpgradeFunc
+
+	// ForceNew lists the attributes that can't be updated in place, so
+	// that changing any of them plans to replace the instance.
+	ForceNew []string
+
+	// Computed are the values of computed attributes that aren't set in
+	// the configuration, which are filled in when an instance is created.
+	// If "id" isn't given then a unique ID is generated.
+	Computed map[string]string
+
+	// DiffFunc, ApplyFunc, RefreshFunc and ImportFunc, if set, replace the
+	// default behavior of the corresponding ResourceProvider methods.
+	DiffFunc    func(*terraform.InstanceInfo, *terraform.InstanceState, *terraform.ResourceConfig) (*terraform.InstanceDiff, error)
+	ApplyFunc   func(*terraform.InstanceInfo, *terraform.InstanceState, *terraform.InstanceDiff) (*terraform.InstanceState, error)
+	RefreshFunc func(*terraform.InstanceInfo, *terraform.InstanceState) (*terraform.InstanceState, error)
+	ImportFunc  func(*terraform.InstanceInfo, string) ([]*terraform.InstanceState, error)
+}
+
+// StateUpgradeFunc upgrades the flatmapped attributes of a state by one
+// schema version.
+type StateUpgradeFunc func(attrs map[string]string) (map[string]string, error)
+
+// Diff implements terraform.ResourceProvider.
+func (p *Provider) Diff(
+	info *terraform.InstanceInfo,
+	s *terraform.InstanceState,
+	c *terraform.ResourceConfig) (*terraform.InstanceDiff, error) {
+	block, r, err := p.resource(info.Type)
+	if err != nil {
+		return nil, err
+	}
+	s, err = r.upgradeState(s)
+	if err != nil {
+		return nil, err
+	}
+	if r.DiffFunc != nil {
+		return r.DiffFunc(info, s, c)
+	}
+
+	if c == nil {
+		return &terraform.InstanceDiff{Destroy: true}, nil
+	}
+
+	// A tainted instance is planned to be replaced by a new one, so its
+	// attributes aren't compared with the configuration.
+	var old map[string]string
+	tainted := s != nil && s.ID != "" && s.Tainted
+	create := s == nil || s.ID == "" || tainted
+	if !create {
+		old = s.Attributes
+	}
+
+	diff := &terraform.InstanceDiff{
+		Attributes:     diffAttributes(block, old, c, create),
+		DestroyTainted: tainted,
+	}
+	for k, attr := range diff.Attributes {
+		attr.RequiresNew = tainted || r.forceNew(k)
+	}
+
+	// If the instance must be replaced then plan as if creating it, so that
+	// the plan matches the diff made against the new instance during apply.
+	if !create && diff.RequiresNew() {
+		attrs := diffAttributes(block, nil, c, true)
+		for k, attr := range attrs {
+			attr.Old = old[k]
+			attr.RequiresNew = r.forceNew(k)
+		}
+		diff.Attributes = attrs
+	}
+	if len(diff.Attributes) == 0 && !tainted {
+		return nil, nil
+	}
+
+	return diff, nil
+}
+
+// Apply implements terraform.ResourceProvider.
+func (p *Provider) Apply(
+	info *terraform.InstanceInfo,
+	s *terraform.InstanceState,
+	d *terraform.InstanceDiff) (*terraform.InstanceState, error) {
+	_, r, err := p.resource(info.Type)
+	if err != nil {
+		return nil, err
+	}
+	s, err = r.upgradeState(s)
+	if err != nil {
+		return nil, err
+	}
+	if r.ApplyFunc != nil {
+		return r.ApplyFunc(info, s, d)
+	}
+
+	if d.GetDestroy() && !d.RequiresNew() {
+		return nil, nil
+	}
+
+	attrs := make(map[string]string)
+	create := s == nil || s.ID == "" || d.RequiresNew()
+	if !create {
+		for k, v := range s.Attributes {
+			attrs[k] = v
+		}
+	}
+	for k, attr := range d.CopyAttributes() {
+		switch {
+		case attr.NewRemoved:
+			delete(attrs, k)
+		case attr.NewComputed:
+			if v, ok := r.Computed[k]; ok {
+				attrs[k] = v
+			} else {
+				delete(attrs, k)
+			}
+		default:
+			attrs[k] = attr.New
+		}
+	}
+	if create {
+		for k, v := range r.Computed {
+			if _, ok := attrs[k]; !ok {
+				attrs[k] = v
+			}
+		}
+	}
+	if attrs["id"] == "" {
+		attrs["id"] = p.nextID(info.Type)
+	}
+
+	return &terraform.InstanceState{
+		ID:         attrs["id"],
+		Attributes: attrs,
+		Meta:       r.meta(),
+	}, nil
+}
+
+// Refresh implements terraform.ResourceProvider.
+func (p *Provider) Refresh(
+	info *terraform.InstanceInfo,
+	s *terraform.InstanceState) (*terraform.InstanceState, error) {
+	_, r, err := p.resource(info.Type)
+	if err != nil {
+		return nil, err
+	}
+	s, err = r.upgradeState(s)
+	if err != nil {
+		return nil, err
+	}
+	if r.RefreshFunc != nil {
+		return r.RefreshFunc(info, s)
+	}
+
+	return s, nil
+}
+
+// UpgradeResourceState implements terraform.ResourceProviderUpgrader.
+func (p *Provider) UpgradeResourceState(req *terraform.UpgradeResourceStateRequest) (*terraform.InstanceState, error) {
+	_, r, err := p.resource(req.Info.Type)
+	if err != nil {
+		return nil, err
+	}
+
+	s := req.State.DeepCopy()
+	if s.Meta == nil {
+		s.Meta = make(map[string]interface{})
+	}
+	s.Meta["schema_version"] = strconv.Itoa(req.Version)
+	return r.upgradeState(s)
+}
+
+// ImportState implements terraform.ResourceProvider. By default the
+// imported state has only its ID set, leaving the rest to the refresh
+// that follows every import.
+func (p *Provider) ImportState(info *terraform.InstanceInfo, id string) ([]*terraform.InstanceState, error) {
+	_, r, err := p.resource(info.Type)
+	if err != nil {
+		return nil, err
+	}
+	if r.ImportFunc != nil {
+		return r.ImportFunc(info, id)
+	}
+
+	return []*terraform.InstanceState{
+		{
+			ID:         id,
+			Attributes: map[string]string{"id": id},
+			Meta:       r.meta(),
+			Ephemeral:  terraform.EphemeralState{Type: info.Type},
+		},
+	}, nil
+}
+
+// resource returns the schema and behavior of the given resource type.
+func (p *Provider) resource(t string) (*configschema.Block, *Resource, error) {
+	block, ok := p.schema().ResourceTypes[t]
+	if !ok {
+		return nil, nil, fmt.Errorf("unsupported resource type %q", t)
+	}
+	r := p.ResourceTypes[t]
+	if r == nil {
+		r = &Resource{}
+	}
+
+	return block, r, nil
+}
+
+func (r *Resource) forceNew(k string) bool {
+	name := attributeName(k)
+	for _, fn := range r.ForceNew {
+		if fn == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Resource) meta() map[string]interface{} {
+	return map[string]interface{}{
+		"schema_version": strconv.Itoa(r.SchemaVersion),
+	}
+}
+
+// upgradeState returns a copy of the given state upgraded to the current
+// schema version, or the state itself if it is already current.
+func (r *Resource) upgradeState(s *terraform.InstanceState) (*terraform.InstanceState, error) {
+	if s == nil || s.ID == "" {
+		return s, nil
+	}
+
+	version := 0
+	if raw, ok := s.Meta["schema_version"]; ok {
+		v, err := strconv.Atoi(fmt.Sprint(raw))
+		if err != nil {
+			return nil, fmt.Errorf("invalid schema version %q in state: %s", raw, err)
+		}
+		version = v
+	}
+	switch {
+	case version == r.SchemaVersion:
+		return s, nil
+	case version > r.SchemaVersion:
+		return nil, fmt.Errorf(
+			"state has schema version %d, which is newer than the current version %d",
+			version, r.SchemaVersion)
+	}
+
+	s = s.DeepCopy()
+	for ; version < r.SchemaVersion; version++ {
+		if version >= len(r.StateUpgraders) || r.StateUpgraders[version] == nil {
+			return nil, fmt.Errorf("no upgrade from schema version %d", version)
+		}
+		attrs, err := r.StateUpgraders[version](s.Attributes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to upgrade state from schema version %d: %s", version, err)
+		}
+		s.Attributes = attrs
+	}
+	if s.Meta == nil {
+		s.Meta = make(map[string]interface{})
+	}
+	s.Meta["schema_version"] = strconv.Itoa(r.SchemaVersion)
+
+	return s, nil
+}
+
+// diffAttributes returns the attribute diffs needed to get from the given
+// old attributes to the given configuration. If create is set, computed
+// attributes that aren't set in the configuration are planned as computed.
+func diffAttributes(block *configschema.Block, old map[string]string, c *terraform.ResourceConfig, create bool) map[string]*terraform.ResourceAttrDiff {
+	attrs := make(map[string]*terraform.ResourceAttrDiff)
+	computed := func(k string) bool {
+		for _, ck := range c.ComputedKeys {
+			if k == ck || strings.HasPrefix(k, ck+".") {
+				return true
+			}
+		}
+		return false
+	}
+	sensitive := func(k string) bool {
+		attr, ok := block.Attributes[attributeName(k)]
+		return ok && attr.Sensitive
+	}
+
+	proposed := flattenConfig(c.Config)
+	for k, v := range proposed {
+		if v == config.UnknownVariableValue || computed(k) {
+			attrs[k] = &terraform.ResourceAttrDiff{
+				Old:         old[k],
+				NewComputed: true,
+				Sensitive:   sensitive(k),
+			}
+			continue
+		}
+		if o, ok := old[k]; ok && o == v {
+			continue
+		}
+		attrs[k] = &terraform.ResourceAttrDiff{
+			Old:       old[k],
+			New:       v,
+			Sensitive: sensitive(k),
+		}
+	}
+
+	for k, v := range old {
+		if _, ok := proposed[k]; ok || k == "id" || computed(k) {
+			continue
+		}
+		if attr, ok := block.Attributes[attributeName(k)]; ok && attr.Computed {
+			continue
+		}
+		attrs[k] = &terraform.ResourceAttrDiff{
+			Old:        v,
+			NewRemoved: true,
+			Sensitive:  sensitive(k),
+		}
+	}
+
+	if create {
+		for name, attr := range block.Attributes {
+			if !attr.Computed || name == "id" {
+				continue
+			}
+			if _, ok := c.Config[name]; ok {
+				continue
+			}
+			attrs[name] = &terraform.ResourceAttrDiff{
+				NewComputed: true,
+				Sensitive:   attr.Sensitive,
+			}
+		}
+	}
+
+	return attrs
+}
+
+// flattenConfig flatmaps the given configuration as it's recorded in the
+// state, where a map attribute also has its number of elements under
+// "NAME.%" so that it can be interpolated as a whole. Nested blocks, which
+// are maps within lists, don't.
+func flattenConfig(raw map[string]interface{}) map[string]string {
+	attrs := flatmap.Flatten(raw)
+	var count func(k string, v interface{})
+	count = func(k string, v interface{}) {
+		switch tv := v.(type) {
+		case map[string]interface{}:
+			attrs[k+".%"] = strconv.Itoa(len(tv))
+			for ek, ev := range tv {
+				count(k+"."+ek, ev)
+			}
+		case []interface{}:
+			for i, ev := range tv {
+				if m, ok := ev.(map[string]interface{}); ok {
+					for ek, eev := range m {
+						count(fmt.Sprintf("%s.%d.%s", k, i, ek), eev)
+					}
+				}
+			}
+		case []map[string]interface{}:
+			for i, m := range tv {
+				for ek, eev := range m {
+					count(fmt.Sprintf("%s.%d.%s", k, i, ek), eev)
+				}
+			}
+		}
+	}
+	for k, v := range raw {
+		count(k, v)
+	}
+
+	return attrs
+}
@@ -0,0 +1,85 @@
+package mock
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform/config/configschema"
+)
+
+// validateBlock checks that the given raw configuration sets only the
+// arguments and nested blocks defined in the given schema, and that it sets
+// all of the required arguments. Values aren't checked, since they may not
+// be known yet.
+func validateBlock(prefix string, block *configschema.Block, raw map[string]interface{}) []error {
+	var errs []error
+
+	keys := make([]string, 0, len(raw))
+	for k := range raw {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if attr, ok := block.Attributes[k]; ok {
+			if attr.Computed && !attr.Optional && !attr.Required {
+				errs = append(errs, fmt.Errorf("%s%s: computed attributes cannot be set", prefix, k))
+			}
+			continue
+		}
+		if nested, ok := block.BlockTypes[k]; ok {
+			for i, v := range nestedBlockValues(raw[k]) {
+				errs = append(errs, validateBlock(fmt.Sprintf("%s%s.%d.", prefix, k, i), &nested.Block, v)...)
+			}
+			continue
+		}
+
+		errs = append(errs, fmt.Errorf("%s%s: unsupported argument", prefix, k))
+	}
+
+	names := make([]string, 0, len(block.Attributes))
+	for name := range block.Attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if _, ok := raw[name]; !ok && block.Attributes[name].Required {
+			errs = append(errs, fmt.Errorf("%s%s: required field is not set", prefix, name))
+		}
+	}
+
+	return errs
+}
+
+// nestedBlockValues returns the raw configurations of the nested blocks in
+// the given raw value, ignoring any value that isn't a block, such as an
+// interpolation that produces a whole list of blocks.
+func nestedBlockValues(v interface{}) []map[string]interface{} {
+	switch tv := v.(type) {
+	case map[string]interface{}:
+		return []map[string]interface{}{tv}
+	case []map[string]interface{}:
+		return tv
+	case []interface{}:
+		var ret []map[string]interface{}
+		for _, ev := range tv {
+			if m, ok := ev.(map[string]interface{}); ok {
+				ret = append(ret, m)
+			}
+		}
+		return ret
+	default:
+		return nil
+	}
+}
+
+// attributeName returns the name of the top-level attribute that the given
+// flatmap key belongs to.
+func attributeName(k string) string {
+	if i := strings.Index(k, "."); i != -1 {
+		return k[:i]
+	}
+	return k
+}
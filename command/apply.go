@@ -12,9 +12,11 @@ import (
 
 	"github.com/hashicorp/go-getter"
 	"github.com/hashicorp/terraform/backend"
+	"github.com/hashicorp/terraform/command/views"
 	"github.com/hashicorp/terraform/config"
 	"github.com/hashicorp/terraform/config/module"
 	"github.com/hashicorp/terraform/terraform"
+	"github.com/posener/complete"
 )
 
 // ApplyCommand is a Command implementation that applies a Terraform
@@ -28,7 +30,8 @@ type ApplyCommand struct {
 }
 
 func (c *ApplyCommand) Run(args []string) int {
-	var destroyForce, refresh, autoApprove bool
+	var destroyForce, overrideProtection, refresh, refreshOnly, autoApprove, jsonOutput, page, structuralDiff, concise bool
+	var replace []string
 	args, err := c.Meta.process(args, true)
 	if err != nil {
 		return 1
@@ -42,10 +45,17 @@ func (c *ApplyCommand) Run(args []string) int {
 	cmdFlags := c.Meta.flagSet(cmdName)
 	if c.Destroy {
 		cmdFlags.BoolVar(&destroyForce, "force", false, "force")
+		cmdFlags.BoolVar(&overrideProtection, "override-protection", false, "override-protection")
 	}
 	cmdFlags.BoolVar(&refresh, "refresh", true, "refresh")
+	cmdFlags.BoolVar(&jsonOutput, "json", false, "json")
+	cmdFlags.BoolVar(&page, "page", c.DiffPager != "", "page")
+	cmdFlags.BoolVar(&structuralDiff, "structural-diff", true, "structural-diff")
+	cmdFlags.BoolVar(&concise, "concise", false, "concise")
 	if !c.Destroy {
 		cmdFlags.BoolVar(&autoApprove, "auto-approve", false, "skip interactive approval of plan before applying")
+		cmdFlags.Var((*FlagStringSlice)(&replace), "replace", "resource to replace")
+		cmdFlags.BoolVar(&refreshOnly, "refresh-only", false, "refresh-only")
 	}
 	cmdFlags.IntVar(
 		&c.Meta.parallelism, "parallelism", DefaultParallelism, "parallelism")
@@ -59,6 +69,21 @@ func (c *ApplyCommand) Run(args []string) int {
 		return 1
 	}
 
+	if refreshOnly {
+		if msg := refreshOnlyConflict(refresh, false, replace); msg != "" {
+			c.Ui.Error(msg)
+			return 1
+		}
+	}
+
+	if jsonOutput {
+		// The output is a stream of events, so there's no asking for input.
+		// Applying a new plan must be approved with -auto-approve or
+		// -force instead, which the backend checks.
+		c.Meta.input = false
+		c.Meta.jsonView = views.NewJSONView(c.Ui)
+	}
+
 	// Get the args. The "maybeInit" flag tracks whether we may need to
 	// initialize the configuration from a remote path. This is true as long
 	// as we have an argument.
@@ -114,6 +139,12 @@ func (c *ApplyCommand) Run(args []string) int {
 			"Destroy can't be called with a plan file."))
 		return 1
 	}
+	if refreshOnly && plan != nil {
+		c.Ui.Error(
+			"The -refresh-only option can't be used with a plan file. To apply a\n" +
+				"refresh-only plan, create it with \"terraform plan -refresh-only\".")
+		return 1
+	}
 	if plan != nil {
 		// Reset the config path for backend loading
 		configPath = ""
@@ -148,15 +179,38 @@ func (c *ApplyCommand) Run(args []string) int {
 		return 1
 	}
 
+	// The resources of protected workspaces can only be destroyed if the
+	// protection is overridden.
+	if c.Destroy && !overrideProtection {
+		workspace := c.Workspace()
+		meta, err := workspaceMetadata(b, workspace)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Failed to read the state of workspace %q: %s", workspace, err))
+			return 1
+		}
+		if meta != nil && meta.Protected {
+			c.Ui.Error(fmt.Sprintf(strings.TrimSpace(envProtected), workspace))
+			return 1
+		}
+	}
+
 	// Build the operation
 	opReq := c.Operation()
 	opReq.Destroy = c.Destroy
 	opReq.Module = mod
 	opReq.Plan = plan
 	opReq.PlanRefresh = refresh
+	opReq.PlanRefreshOnly = refreshOnly
+	opReq.Replace = replace
 	opReq.Type = backend.OperationTypeApply
 	opReq.AutoApprove = autoApprove
 	opReq.DestroyForce = destroyForce
+	opReq.ApprovalPolicy = c.ApprovalPolicy
+	opReq.NoStructuralDiff = !structuralDiff
+	opReq.ConcisePlan = concise
+	if page && c.Input() {
+		opReq.DiffPager = c.diffPager()
+	}
 
 	// Perform the operation
 	ctx, ctxCancel := context.WithCancel(context.Background())
@@ -175,7 +229,11 @@ func (c *ApplyCommand) Run(args []string) int {
 		ctxCancel()
 
 		// Notify the user
-		c.Ui.Output(outputInterrupt)
+		if c.jsonView != nil {
+			c.jsonView.Log("Interrupt received. Gracefully shutting down...")
+		} else {
+			c.Ui.Output(outputInterrupt)
+		}
 
 		// Still get the result, since there is still one
 		select {
@@ -197,7 +255,9 @@ func (c *ApplyCommand) Run(args []string) int {
 		return 1
 	}
 
-	if !c.Destroy {
+	if !c.Destroy && c.jsonView != nil {
+		c.jsonView.Outputs(op.State)
+	} else if !c.Destroy {
 		// Get the right module that we used. If we ran a plan, then use
 		// that module.
 		if plan != nil {
@@ -212,6 +272,31 @@ func (c *ApplyCommand) Run(args []string) int {
 	return 0
 }
 
+func (c *ApplyCommand) AutocompleteArgs() complete.Predictor {
+	if c.Destroy {
+		return complete.PredictDirs("")
+	}
+	// A saved plan can have any name, so any file may be one.
+	return complete.PredictOr(complete.PredictDirs(""), complete.PredictFiles("*"))
+}
+
+func (c *ApplyCommand) AutocompleteFlags() complete.Flags {
+	flags := c.completeFlagsOperation()
+	flags["-backup"] = complete.PredictFiles("*")
+	flags["-json"] = complete.PredictNothing
+	flags["-page"] = completePredictBoolean
+	flags["-state-out"] = complete.PredictFiles("*.tfstate")
+	if c.Destroy {
+		flags["-force"] = complete.PredictNothing
+		flags["-override-protection"] = complete.PredictNothing
+	} else {
+		flags["-auto-approve"] = complete.PredictNothing
+		flags["-refresh-only"] = complete.PredictNothing
+		flags["-replace"] = c.completePredictResourceAddress()
+	}
+	return flags
+}
+
 func (c *ApplyCommand) Help() string {
 	if c.Destroy {
 		return c.helpDestroy()
@@ -252,16 +337,38 @@ Options:
 
   -auto-approve          Skip interactive approval of plan before applying.
 
+  -concise               Show only the attributes of the plan that are
+                         changing, collapsing nested blocks that aren't
+                         changing to a count of their elements.
+
   -input=true            Ask for input for variables if not directly set.
 
+  -json                  Output a stream of JSON events, one per line, instead
+                         of the text output, for automation to consume. This
+                         requires -auto-approve unless a plan file is given.
+
   -no-color              If specified, output won't contain any color.
 
+  -page                  Show the plan with a pager before asking for
+                         approval. This is the default if the CLI
+                         configuration sets a pager.
+
   -parallelism=n         Limit the number of parallel resource operations.
                          Defaults to 10.
 
   -refresh=true          Update state prior to checking for differences. This
                          has no effect if a plan file is given to apply.
 
+  -refresh-only          Only update the state to match any changes made to
+                         remote objects outside of Terraform, without changing
+                         them. This can't be combined with -refresh=false or
+                         -replace, or used with a plan file.
+
+  -replace=resource      Replace the given resource instance even if its
+                         configuration hasn't changed. This flag can be used
+                         multiple times, and has no effect if a plan file is
+                         given to apply.
+
   -state=path            Path to read and save state (unless state-out
                          is specified). Defaults to "terraform.tfstate".
 
@@ -269,6 +376,11 @@ Options:
                          "-state". This can be used to preserve the old
                          state.
 
+  -structural-diff=true  Show changes to string attributes that contain JSON
+                         or YAML as the values that changed within the
+                         documents. If false, they're shown as diffs of their
+                         text.
+
   -target=resource       Resource to target. Operation will be limited to this
                          resource and its dependencies. This flag can be used
                          multiple times.
@@ -299,12 +411,23 @@ Options:
 
   -force                 Don't ask for input for destroy confirmation.
 
+  -json                  Output a stream of JSON events, one per line, instead
+                         of the text output, for automation to consume. This
+                         requires -force.
+
   -lock=true             Lock the state file when locking is supported.
 
   -lock-timeout=0s       Duration to retry a state lock.
 
   -no-color              If specified, output won't contain any color.
 
+  -override-protection   Destroy the resources even if the current workspace
+                         is protected.
+
+  -page                  Show the plan with a pager before asking for
+                         confirmation. This is the default if the CLI
+                         configuration sets a pager.
+
   -parallelism=n         Limit the number of concurrent operations.
                          Defaults to 10.
 
@@ -370,13 +493,13 @@ func outputsAsString(state *terraform.State, modPath []string, schema []*config.
 		sort.Strings(ks)
 
 		for _, k := range ks {
+			v := outputs[k]
 			schema, ok := schemaMap[k]
-			if ok && schema.Sensitive {
+			if v.Sensitive || (ok && schema.Sensitive) {
 				outputBuf.WriteString(fmt.Sprintf("%s = <sensitive>\n", k))
 				continue
 			}
 
-			v := outputs[k]
 			switch typedV := v.Value.(type) {
 			case string:
 				outputBuf.WriteString(fmt.Sprintf("%s = %s\n", k, typedV))
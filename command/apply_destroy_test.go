@@ -93,6 +93,73 @@ func TestApply_destroy(t *testing.T) {
 	}
 }
 
+func TestApply_destroyProtected(t *testing.T) {
+	originalState := &terraform.State{
+		Workspace: &terraform.WorkspaceMetadata{Protected: true},
+		Modules: []*terraform.ModuleState{
+			&terraform.ModuleState{
+				Path: []string{"root"},
+				Resources: map[string]*terraform.ResourceState{
+					"test_instance.foo": &terraform.ResourceState{
+						Type: "test_instance",
+						Primary: &terraform.InstanceState{
+							ID: "bar",
+						},
+					},
+				},
+			},
+		},
+	}
+
+	statePath := testStateFile(t, originalState)
+
+	p := testProvider()
+	ui := new(cli.MockUi)
+	c := &ApplyCommand{
+		Destroy: true,
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(p),
+			Ui:               ui,
+		},
+	}
+
+	args := []string{
+		"-force",
+		"-state", statePath,
+		testFixturePath("apply"),
+	}
+	if code := c.Run(args); code == 0 {
+		t.Fatalf("expected failure for a protected workspace\n\n%s", ui.OutputWriter.String())
+	}
+	if !strings.Contains(ui.ErrorWriter.String(), `Workspace "default" is protected`) {
+		t.Fatalf("bad error: %s", ui.ErrorWriter.String())
+	}
+	if p.ApplyCalled {
+		t.Fatal("apply should not be called")
+	}
+
+	ui = new(cli.MockUi)
+	c = &ApplyCommand{
+		Destroy: true,
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(p),
+			Ui:               ui,
+		},
+	}
+	args = append([]string{"-override-protection"}, args...)
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+	}
+
+	state := testStateRead(t, statePath)
+	if state.HasResources() {
+		t.Fatalf("resources should be destroyed:\n\n%s", state)
+	}
+	if state.Workspace == nil || !state.Workspace.Protected {
+		t.Fatalf("the workspace should still be protected: %#v", state.Workspace)
+	}
+}
+
 func TestApply_destroyLockedState(t *testing.T) {
 	originalState := &terraform.State{
 		Modules: []*terraform.ModuleState{
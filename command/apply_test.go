@@ -823,6 +823,118 @@ func TestApply_refresh(t *testing.T) {
 	}
 }
 
+func TestApply_refreshOnly(t *testing.T) {
+	originalState := &terraform.State{
+		Modules: []*terraform.ModuleState{
+			&terraform.ModuleState{
+				Path: []string{"root"},
+				Resources: map[string]*terraform.ResourceState{
+					"test_instance.foo": &terraform.ResourceState{
+						Type: "test_instance",
+						Primary: &terraform.InstanceState{
+							ID:         "bar",
+							Attributes: map[string]string{"ami": "bar"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	statePath := testStateFile(t, originalState)
+
+	p := testProvider()
+	p.RefreshFn = nil
+	p.RefreshReturn = &terraform.InstanceState{
+		ID:         "bar",
+		Attributes: map[string]string{"ami": "baz"},
+	}
+	ui := new(cli.MockUi)
+	c := &ApplyCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(p),
+			Ui:               ui,
+		},
+	}
+
+	args := []string{
+		"-state", statePath,
+		"-auto-approve",
+		"-refresh-only",
+		testFixturePath("apply"),
+	}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+	}
+
+	if p.DiffCalled || p.ApplyCalled {
+		t.Fatal("refresh-only apply should not change any resources")
+	}
+
+	state := testStateRead(t, statePath)
+	if got := state.RootModule().Resources["test_instance.foo"].Primary.Attributes["ami"]; got != "baz" {
+		t.Fatalf("state should be refreshed, got ami = %q", got)
+	}
+}
+
+func TestApply_refreshOnlyPlan(t *testing.T) {
+	originalState := &terraform.State{
+		Modules: []*terraform.ModuleState{
+			&terraform.ModuleState{
+				Path: []string{"root"},
+				Resources: map[string]*terraform.ResourceState{
+					"test_instance.foo": &terraform.ResourceState{
+						Type: "test_instance",
+						Primary: &terraform.InstanceState{
+							ID:         "bar",
+							Attributes: map[string]string{"ami": "baz"},
+						},
+					},
+				},
+			},
+		},
+	}
+	planPath := testPlanFile(t, &terraform.Plan{
+		Module:      testModule(t, "apply"),
+		State:       originalState,
+		RefreshOnly: true,
+	})
+	statePath := testTempFile(t)
+
+	p := testProvider()
+	ui := new(cli.MockUi)
+	c := &ApplyCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(p),
+			Ui:               ui,
+		},
+	}
+
+	args := []string{
+		"-state-out", statePath,
+		planPath,
+	}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+	}
+
+	if p.ApplyCalled {
+		t.Fatal("applying a refresh-only plan should not change any resources")
+	}
+
+	state := testStateRead(t, statePath)
+	if got := state.RootModule().Resources["test_instance.foo"].Primary.Attributes["ami"]; got != "baz" {
+		t.Fatalf("state should be that of the plan, got ami = %q", got)
+	}
+
+	// The flag itself can't be given with a plan file.
+	ui = new(cli.MockUi)
+	c.Meta.Ui = ui
+	if code := c.Run([]string{"-refresh-only", planPath}); code != 1 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.OutputWriter.String())
+	}
+}
+
 func TestApply_shutdown(t *testing.T) {
 	cancelled := false
 	stopped := make(chan struct{})
@@ -1549,3 +1661,126 @@ const testApplyStateDiffStr = `
 ID = bar
 Tainted = false
 `
+
+func TestApply_json(t *testing.T) {
+	statePath := testTempFile(t)
+
+	p := testProvider()
+	ui := new(cli.MockUi)
+	c := &ApplyCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(p),
+			Ui:               ui,
+		},
+	}
+
+	args := []string{
+		"-json",
+		"-auto-approve",
+		"-state", statePath,
+		testFixturePath("apply"),
+	}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+	}
+
+	events := testJSONEvents(t, ui.OutputWriter.String())
+	want := []string{
+		"version",
+		"operation_start",
+		"planned_change",
+		"change_summary",
+		"apply_start",
+		"apply_complete",
+		"change_summary",
+		"outputs",
+	}
+	if got := testJSONEventTypes(events); !reflect.DeepEqual(got, want) {
+		t.Fatalf("bad event types\ngot:  %#v\nwant: %#v", got, want)
+	}
+
+	hook := events[5]["hook"].(map[string]interface{})
+	resource := hook["resource"].(map[string]interface{})
+	if hook["action"] != "create" || resource["addr"] != "test_instance.foo" {
+		t.Fatalf("bad apply_complete event: %#v", events[5])
+	}
+	if events[6]["@message"] != "Apply complete! Resources: 1 added, 0 changed, 0 destroyed." {
+		t.Fatalf("bad change summary: %#v", events[6])
+	}
+}
+
+func TestApply_jsonOutputs(t *testing.T) {
+	statePath := testTempFile(t)
+
+	p := testProvider()
+	ui := new(cli.MockUi)
+	c := &ApplyCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(p),
+			Ui:               ui,
+		},
+	}
+
+	args := []string{
+		"-json",
+		"-auto-approve",
+		"-state", statePath,
+		testFixturePath("apply-sensitive-output"),
+	}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+	}
+
+	events := testJSONEvents(t, ui.OutputWriter.String())
+	last := events[len(events)-1]
+	if last["type"] != "outputs" {
+		t.Fatalf("last event should be the outputs: %#v", last)
+	}
+
+	want := map[string]interface{}{
+		"notsensitive": map[string]interface{}{
+			"sensitive": false,
+			"type":      "string",
+			"value":     "Hello world",
+		},
+		"sensitive": map[string]interface{}{
+			"sensitive": true,
+			"type":      "string",
+		},
+	}
+	if got := last["outputs"]; !reflect.DeepEqual(got, want) {
+		t.Fatalf("bad outputs\ngot:  %#v\nwant: %#v", got, want)
+	}
+}
+
+func TestApply_jsonApproval(t *testing.T) {
+	statePath := testTempFile(t)
+
+	p := testProvider()
+	ui := new(cli.MockUi)
+	c := &ApplyCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(p),
+			Ui:               ui,
+		},
+	}
+
+	args := []string{
+		"-json",
+		"-state", statePath,
+		testFixturePath("apply"),
+	}
+	if code := c.Run(args); code != 1 {
+		t.Fatalf("expected failure without -auto-approve, got %d", code)
+	}
+	if p.ApplyCalled {
+		t.Fatal("apply should not be called")
+	}
+
+	events := testJSONEvents(t, ui.OutputWriter.String())
+	last := events[len(events)-1]
+	diag, _ := last["diagnostic"].(map[string]interface{})
+	if last["type"] != "diagnostic" || diag["severity"] != "error" || !strings.Contains(diag["summary"].(string), "Approval is required") {
+		t.Fatalf("bad last event: %#v", last)
+	}
+}
@@ -1,6 +1,10 @@
 package command
 
 import (
+	"strings"
+
+	"github.com/hashicorp/terraform/config"
+	"github.com/hashicorp/terraform/terraform"
 	"github.com/posener/complete"
 )
 
@@ -24,7 +28,16 @@ func (s completePredictSequence) Predict(a complete.Args) []string {
 	// dummy entries (e.g. complete.PredictNothing) as placeholders for
 	// all but the first subcommand. For example, "workspace new" needs
 	// one placeholder for the argument "new".
-	idx := len(a.Completed)
+	//
+	// Flags are skipped, since they come before the positional arguments.
+	// Flags whose values are given as separate arguments, like "-state foo",
+	// are miscounted, but "-state=foo" is the usual form.
+	idx := 0
+	for _, arg := range a.Completed {
+		if !strings.HasPrefix(arg, "-") {
+			idx++
+		}
+	}
 	if idx >= len(s) {
 		return nil
 	}
@@ -32,6 +45,23 @@ func (s completePredictSequence) Predict(a complete.Args) []string {
 	return s[idx].Predict(a)
 }
 
+// completeFlagsOperation returns the flags that the commands that run
+// operations, such as plan and apply, have in common.
+func (m *Meta) completeFlagsOperation() complete.Flags {
+	return complete.Flags{
+		"-input":        completePredictBoolean,
+		"-lock":         completePredictBoolean,
+		"-lock-timeout": complete.PredictAnything,
+		"-no-color":     complete.PredictNothing,
+		"-parallelism":  complete.PredictAnything,
+		"-refresh":      completePredictBoolean,
+		"-state":        complete.PredictFiles("*.tfstate"),
+		"-target":       m.completePredictResourceAddress(),
+		"-var":          complete.PredictAnything,
+		"-var-file":     complete.PredictFiles("*.tfvars"),
+	}
+}
+
 func (m *Meta) completePredictWorkspaceName() complete.Predictor {
 	return complete.PredictFunc(func(a complete.Args) []string {
 		// There are lot of things that can fail in here, so if we encounter
@@ -65,3 +95,121 @@ func (m *Meta) completePredictWorkspaceName() complete.Predictor {
 		return names
 	})
 }
+
+// completePredictResourceAddress returns a predictor of the addresses of the
+// resource instances in the state of the current workspace, in the same form
+// as "terraform state list" outputs them.
+func (m *Meta) completePredictResourceAddress() complete.Predictor {
+	return complete.PredictFunc(func(a complete.Args) []string {
+		st := m.completeState()
+		if st == nil {
+			return nil
+		}
+
+		results, err := filterResources(st, &stateFilter{})
+		if err != nil {
+			return nil
+		}
+
+		var addrs []string
+		for _, result := range results {
+			if result.Deposed {
+				continue
+			}
+			addrs = append(addrs, result.ResourceAddress().String())
+		}
+		return addrs
+	})
+}
+
+// completePredictResourceName returns a predictor of the names of the
+// resource instances in the state of the current workspace, in the form that
+// "terraform taint" takes them: "TYPE.NAME" or "TYPE.NAME.INDEX", within the
+// module given by any -module flag.
+func (m *Meta) completePredictResourceName() complete.Predictor {
+	return complete.PredictFunc(func(a complete.Args) []string {
+		st := m.completeState()
+		if st == nil {
+			return nil
+		}
+
+		modPath := []string{"root"}
+		for _, arg := range a.Completed {
+			if strings.HasPrefix(arg, "-module=") {
+				modPath = append(modPath, strings.Split(strings.TrimPrefix(arg, "-module="), ".")...)
+			}
+		}
+		mod := st.ModuleByPath(modPath)
+		if mod == nil {
+			return nil
+		}
+
+		var names []string
+		for name := range mod.Resources {
+			if strings.HasPrefix(name, "data.") {
+				// Data sources can't be tainted.
+				continue
+			}
+			names = append(names, name)
+		}
+		return names
+	})
+}
+
+// completePredictConfigResourceAddress returns a predictor of the addresses
+// of the managed resources declared in the root module of the configuration
+// in the current directory, which are those that "terraform import" can
+// import objects to.
+func (m *Meta) completePredictConfigResourceAddress() complete.Predictor {
+	return complete.PredictFunc(func(a complete.Args) []string {
+		configPath, err := ModulePath(nil)
+		if err != nil {
+			return nil
+		}
+
+		cfg, err := m.Config(configPath)
+		if err != nil || cfg == nil {
+			return nil
+		}
+
+		var addrs []string
+		for _, r := range cfg.Resources {
+			if r.Mode == config.ManagedResourceMode {
+				addrs = append(addrs, r.Id())
+			}
+		}
+		return addrs
+	})
+}
+
+// completeState returns the state of the current workspace, or nil if it
+// can't be loaded for any reason.
+func (m *Meta) completeState() *terraform.State {
+	// As with the workspace names, errors can't be shown to the user here,
+	// so they just leave nothing to complete.
+	configPath, err := ModulePath(nil)
+	if err != nil {
+		return nil
+	}
+
+	cfg, err := m.Config(configPath)
+	if err != nil {
+		return nil
+	}
+
+	b, err := m.Backend(&BackendOpts{
+		Config: cfg,
+	})
+	if err != nil {
+		return nil
+	}
+
+	st, err := b.State(m.Workspace())
+	if err != nil {
+		return nil
+	}
+	if err := st.RefreshState(); err != nil {
+		return nil
+	}
+	return st.State()
+}
@@ -4,8 +4,10 @@ import (
 	"io/ioutil"
 	"os"
 	"reflect"
+	"sort"
 	"testing"
 
+	"github.com/hashicorp/terraform/terraform"
 	"github.com/mitchellh/cli"
 	"github.com/posener/complete"
 )
@@ -36,3 +38,83 @@ func TestMetaCompletePredictWorkspaceName(t *testing.T) {
 		t.Errorf("wrong result\ngot:  %#v\nwant: %#v", got, want)
 	}
 }
+
+func TestMetaCompletePredictResourceAddress(t *testing.T) {
+	td := testTempDir(t)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	state := testState()
+	state.AddModule([]string{"root", "child"}).Resources["test_instance.bar"] = &terraform.ResourceState{
+		Type: "test_instance",
+		Primary: &terraform.InstanceState{
+			ID: "baz",
+		},
+	}
+	testStateFileDefault(t, state)
+
+	meta := &Meta{Ui: new(cli.MockUi)}
+
+	got := meta.completePredictResourceAddress().Predict(complete.Args{})
+	sort.Strings(got)
+	want := []string{"module.child.test_instance.bar", "test_instance.foo"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("wrong result\ngot:  %#v\nwant: %#v", got, want)
+	}
+
+	got = meta.completePredictResourceName().Predict(complete.Args{
+		Completed: []string{"-module=child"},
+	})
+	want = []string{"test_instance.bar"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("wrong result\ngot:  %#v\nwant: %#v", got, want)
+	}
+}
+
+func TestMetaCompletePredictConfigResourceAddress(t *testing.T) {
+	td := testTempDir(t)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	err := ioutil.WriteFile("main.tf", []byte(`
+resource "test_instance" "foo" {}
+data "test_data_source" "bar" {}
+`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	meta := &Meta{Ui: new(cli.MockUi)}
+
+	got := meta.completePredictConfigResourceAddress().Predict(complete.Args{})
+	want := []string{"test_instance.foo"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("wrong result\ngot:  %#v\nwant: %#v", got, want)
+	}
+}
+
+func TestCompletePredictSequence(t *testing.T) {
+	seq := completePredictSequence{
+		complete.PredictSet("first"),
+		complete.PredictSet("second"),
+	}
+
+	tests := map[string]struct {
+		Completed []string
+		Want      []string
+	}{
+		"none":            {nil, []string{"first"}},
+		"one":             {[]string{"first"}, []string{"second"}},
+		"flags skipped":   {[]string{"-lock=false", "first"}, []string{"second"}},
+		"beyond sequence": {[]string{"first", "second"}, nil},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := seq.Predict(complete.Args{Completed: test.Completed})
+			if !reflect.DeepEqual(got, test.Want) {
+				t.Errorf("wrong result\ngot:  %#v\nwant: %#v", got, test.Want)
+			}
+		})
+	}
+}
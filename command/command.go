@@ -6,6 +6,7 @@ import (
 	"os"
 	"runtime"
 
+	"github.com/hashicorp/terraform/command/workdir"
 	"github.com/hashicorp/terraform/terraform"
 )
 
@@ -13,7 +14,7 @@ import (
 var test bool = false
 
 // DefaultDataDir is the default directory for storing local data.
-const DefaultDataDir = ".terraform"
+const DefaultDataDir = workdir.DefaultDataDir
 
 // PluginPathFile is the name of the file in the data dir which stores the list
 // of directories supplied by the user with the `-plugin-dir` flag during init.
@@ -27,6 +28,10 @@ const pluginMachineName = runtime.GOOS + "_" + runtime.GOARCH
 // exists, it is never created by terraform.
 const DefaultPluginVendorDir = "terraform.d/plugins/" + pluginMachineName
 
+// DefaultDependencyLockFile is the name of the file in the working
+// directory that records the provider versions selected by "terraform init".
+const DefaultDependencyLockFile = ".terraform.lock.hcl"
+
 // DefaultStateFilename is the default filename used for the state file.
 const DefaultStateFilename = "terraform.tfstate"
 
@@ -26,8 +26,10 @@ func (c *ConsoleCommand) Run(args []string) int {
 		return 1
 	}
 
+	var planMode bool
 	cmdFlags := c.Meta.flagSet("console")
 	cmdFlags.StringVar(&c.Meta.statePath, "state", DefaultStateFilename, "path")
+	cmdFlags.BoolVar(&planMode, "plan", false, "plan")
 	cmdFlags.Usage = func() { c.Ui.Error(c.Help()) }
 	if err := cmdFlags.Parse(args); err != nil {
 		return 1
@@ -87,9 +89,23 @@ func (c *ConsoleCommand) Run(args []string) int {
 		ErrorWriter: wrappedstreams.Stderr(),
 	}
 
+	interpolater := ctx.Interpolater()
+	defer interpolater.Close()
+
+	// With -plan, the interpolations see the values the resources will have
+	// once the plan is applied, rather than those in the state.
+	if planMode {
+		plan, err := ctx.Plan()
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error running plan: %s", err))
+			return 1
+		}
+		interpolater.State = plan.PlannedState()
+	}
+
 	// IO Loop
 	session := &repl.Session{
-		Interpolater: ctx.Interpolater(),
+		Interpolater: interpolater,
 	}
 
 	// Determine if stdin is a pipe. If so, we evaluate directly.
@@ -101,11 +117,23 @@ func (c *ConsoleCommand) Run(args []string) int {
 }
 
 func (c *ConsoleCommand) modePiped(session *repl.Session, ui cli.Ui) int {
-	var lastResult string
+	var lastResult, pending string
 	scanner := bufio.NewScanner(wrappedstreams.Stdin())
 	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if pending != "" {
+			line = pending + "\n" + line
+		}
+
+		// Keep reading the lines of an interpolation until it's complete
+		if repl.Incomplete(line) {
+			pending = line
+			continue
+		}
+		pending = ""
+
 		// Handle it. If there is an error exit immediately
-		result, err := session.Handle(strings.TrimSpace(scanner.Text()))
+		result, err := session.Handle(line)
 		if err != nil {
 			ui.Error(err.Error())
 			return 1
@@ -115,6 +143,15 @@ func (c *ConsoleCommand) modePiped(session *repl.Session, ui cli.Ui) int {
 		lastResult = result
 	}
 
+	// An incomplete interpolation at the end of the input is still handled,
+	// to report why it's invalid.
+	if pending != "" {
+		if _, err := session.Handle(pending); err != nil {
+			ui.Error(err.Error())
+			return 1
+		}
+	}
+
 	// Output the final result
 	ui.Output(lastResult)
 
@@ -135,11 +172,20 @@ Usage: terraform console [options] [DIR]
 
   This command will never modify your state.
 
+  Interpolations can span several lines while their brackets or quotes are
+  open, and the names of resources, variables and functions can be
+  completed with the tab key.
+
   DIR can be set to a directory with a Terraform state to load. By
   default, this will default to the current working directory.
 
 Options:
 
+  -plan                  Evaluate interpolations against the values the
+                         resources will have once the changes planned for the
+                         configuration are applied, rather than those in the
+                         state. Values only known after apply are unknown.
+
   -state=path            Path to read state. Defaults to "terraform.tfstate"
 
   -var 'foo=bar'         Set a variable in the Terraform configuration. This
@@ -8,6 +8,7 @@ package command
 import (
 	"fmt"
 	"io"
+	"unicode"
 
 	"github.com/hashicorp/terraform/helper/wrappedreadline"
 	"github.com/hashicorp/terraform/repl"
@@ -23,6 +24,7 @@ func (c *ConsoleCommand) modeInteractive(session *repl.Session, ui cli.Ui) int {
 		InterruptPrompt:   "^C",
 		EOFPrompt:         "exit",
 		HistorySearchFold: true,
+		AutoComplete:      &consoleCompleter{session: session},
 	}))
 	if err != nil {
 		c.Ui.Error(fmt.Sprintf(
@@ -32,19 +34,36 @@ func (c *ConsoleCommand) modeInteractive(session *repl.Session, ui cli.Ui) int {
 	}
 	defer l.Close()
 
+	var pending string
 	for {
 		// Read a line
 		line, err := l.Readline()
 		if err == readline.ErrInterrupt {
-			if len(line) == 0 {
+			if len(line) == 0 && pending == "" {
 				break
 			} else {
+				// Discard any incomplete interpolation
+				pending = ""
+				l.SetPrompt("> ")
 				continue
 			}
 		} else if err == io.EOF {
 			break
 		}
 
+		if pending != "" {
+			line = pending + "\n" + line
+		}
+
+		// Keep reading the lines of an interpolation until it's complete
+		if repl.Incomplete(line) {
+			pending = line
+			l.SetPrompt("... ")
+			continue
+		}
+		pending = ""
+		l.SetPrompt("> ")
+
 		out, err := session.Handle(line)
 		if err == repl.ErrSessionExit {
 			break
@@ -59,3 +78,30 @@ func (c *ConsoleCommand) modeInteractive(session *repl.Session, ui cli.Ui) int {
 
 	return 0
 }
+
+// consoleCompleter completes the name under the cursor with the names known
+// to the session.
+type consoleCompleter struct {
+	session *repl.Session
+}
+
+func (c *consoleCompleter) Do(line []rune, pos int) ([][]rune, int) {
+	start := pos
+	for start > 0 && isNameRune(line[start-1]) {
+		start--
+	}
+	if start == pos {
+		return nil, 0
+	}
+
+	prefix := string(line[start:pos])
+	var ret [][]rune
+	for _, name := range c.session.Completions(prefix) {
+		ret = append(ret, []rune(name[len(prefix):]))
+	}
+	return ret, len(prefix)
+}
+
+func isNameRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-' || r == '.'
+}
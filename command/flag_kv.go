@@ -3,6 +3,9 @@ package command
 import (
 	"fmt"
 	"strings"
+
+	"github.com/hashicorp/terraform/helper/variables"
+	"github.com/hashicorp/terraform/terraform"
 )
 
 // FlagStringKV is a flag.Value implementation for parsing user variables
@@ -41,3 +44,50 @@ func (v *FlagStringSlice) Set(raw string) error {
 
 	return nil
 }
+
+// FlagVar is a flag.Value implementation for the -var flag, which sets the
+// values of variables in the format of 'key=value' and records that they
+// were set on the command line.
+type FlagVar terraform.InputValues
+
+func (v *FlagVar) String() string {
+	return ""
+}
+
+func (v *FlagVar) Set(raw string) error {
+	var vs variables.Flag
+	if err := vs.Set(raw); err != nil {
+		return err
+	}
+
+	*v = FlagVar(terraform.InputValues(*v).Override(
+		terraform.InputValuesFromMap(vs, terraform.ValueFromCLIArg, "")))
+	return nil
+}
+
+// FlagVarFile is a flag.Value implementation for the -var-file flag, which
+// sets the values of variables from a variable definitions file and records
+// the file they were set in. If Auto is true, the files are those loaded
+// automatically rather than those given on the command line.
+type FlagVarFile struct {
+	Values *terraform.InputValues
+	Auto   bool
+}
+
+func (v *FlagVarFile) String() string {
+	return ""
+}
+
+func (v *FlagVarFile) Set(raw string) error {
+	var vs variables.FlagFile
+	if err := vs.Set(raw); err != nil {
+		return err
+	}
+
+	sourceType := terraform.ValueFromNamedFile
+	if v.Auto {
+		sourceType = terraform.ValueFromAutoFile
+	}
+	*v.Values = v.Values.Override(terraform.InputValuesFromMap(vs, sourceType, raw))
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/hashicorp/hcl/hcl/fmtcmd"
@@ -21,9 +22,10 @@ const (
 // files to a canonical format and style.
 type FmtCommand struct {
 	Meta
-	opts  fmtcmd.Options
-	check bool
-	input io.Reader // STDIN if nil
+	opts      fmtcmd.Options
+	check     bool
+	recursive bool
+	input     io.Reader // STDIN if nil
 }
 
 func (c *FmtCommand) Run(args []string) int {
@@ -41,6 +43,7 @@ func (c *FmtCommand) Run(args []string) int {
 	cmdFlags.BoolVar(&c.opts.Write, "write", true, "write")
 	cmdFlags.BoolVar(&c.opts.Diff, "diff", false, "diff")
 	cmdFlags.BoolVar(&c.check, "check", false, "check")
+	cmdFlags.BoolVar(&c.recursive, "recursive", false, "recursive")
 	cmdFlags.Usage = func() { c.Ui.Error(c.Help()) }
 
 	if err := cmdFlags.Parse(args); err != nil {
@@ -54,14 +57,26 @@ func (c *FmtCommand) Run(args []string) int {
 		return 1
 	}
 
-	var dirs []string
-	if len(args) == 0 {
-		dirs = []string{"."}
-	} else if args[0] == stdinArg {
+	var paths []string
+	if len(args) > 0 && args[0] == stdinArg {
 		c.opts.List = false
 		c.opts.Write = false
 	} else {
-		dirs = []string{args[0]}
+		dir := "."
+		if len(args) > 0 {
+			dir = args[0]
+		}
+
+		paths, err = c.configFiles(dir)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error running fmt: %s", err))
+			return 2
+		}
+
+		// An empty list of paths would format STDIN instead.
+		if len(paths) == 0 {
+			return 0
+		}
 	}
 
 	var output io.Writer
@@ -76,7 +91,7 @@ func (c *FmtCommand) Run(args []string) int {
 		output = &cli.UiWriter{Ui: c.Ui}
 	}
 
-	err = fmtcmd.Run(dirs, []string{fileExtension}, c.input, output, c.opts)
+	err = fmtcmd.Run(paths, []string{fileExtension}, c.input, output, c.opts)
 	if err != nil {
 		c.Ui.Error(fmt.Sprintf("Error running fmt: %s", err))
 		return 2
@@ -98,6 +113,44 @@ func (c *FmtCommand) Run(args []string) int {
 	return 0
 }
 
+// configFiles returns the configuration files to format at path. If path
+// is a directory, these are the files it contains, and also those of its
+// subdirectories with -recursive, skipping hidden directories such as
+// .terraform.
+func (c *FmtCommand) configFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	var files []string
+	err = filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			if p == path {
+				return nil
+			}
+			if !c.recursive || strings.HasPrefix(info.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !strings.HasPrefix(info.Name(), ".") && filepath.Ext(p) == "."+fileExtension {
+			files = append(files, p)
+		}
+		return nil
+	})
+
+	return files, err
+}
+
 func (c *FmtCommand) Help() string {
 	helpText := `
 Usage: terraform fmt [options] [DIR]
@@ -105,7 +158,11 @@ Usage: terraform fmt [options] [DIR]
 	Rewrites all Terraform configuration files to a canonical format.
 
 	If DIR is not specified then the current working directory will be used.
-	If DIR is "-" then content will be read from STDIN.
+	If DIR is "-" then content will be read from STDIN, and the formatted
+	content written to STDOUT.
+
+	By default, only the files of DIR are formatted, and not those of its
+	subdirectories.
 
 Options:
 
@@ -115,7 +172,9 @@ Options:
 
   -diff=false      Display diffs of formatting changes
 
-  -check=false     Check if the input is formatted. Exit status will be 0 if all input is properly formatted and non-zero otherwise.
+  -check=false     Check if the input is formatted. Exit status will be 0 if all input is properly formatted, 3 if some input isn't and 2 if it can't be parsed.
+
+  -recursive=false Also process the files in subdirectories, except hidden ones such as .terraform
 
 `
 	return strings.TrimSpace(helpText)
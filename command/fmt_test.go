@@ -233,6 +233,119 @@ func TestFmt_checkStdin(t *testing.T) {
 	}
 }
 
+func TestFmt_recursive(t *testing.T) {
+	tempDir, err := fmtFixtureWriteDir()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	// a subdirectory, and a hidden one that is never formatted
+	for _, dir := range []string{"sub", ".terraform"} {
+		if err := os.Mkdir(filepath.Join(tempDir, dir), 0755); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		err := ioutil.WriteFile(filepath.Join(tempDir, dir, fmtFixture.filename), fmtFixture.input, 0644)
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+	}
+
+	cases := []struct {
+		args     []string
+		expected []string
+	}{
+		{
+			[]string{"-write=false"},
+			[]string{fmtFixture.filename},
+		},
+		{
+			[]string{"-write=false", "-recursive"},
+			[]string{fmtFixture.filename, filepath.Join("sub", fmtFixture.filename)},
+		},
+	}
+
+	for _, tc := range cases {
+		ui := new(cli.MockUi)
+		c := &FmtCommand{
+			Meta: Meta{
+				testingOverrides: metaOverridesForProvider(testProvider()),
+				Ui:               ui,
+			},
+		}
+
+		args := append(tc.args, tempDir)
+		if code := c.Run(args); code != 0 {
+			t.Fatalf("%v: wrong exit code. errors: \n%s", tc.args, ui.ErrorWriter.String())
+		}
+
+		var expected string
+		for _, name := range tc.expected {
+			expected += filepath.Join(tempDir, name) + "\n"
+		}
+		if actual := ui.OutputWriter.String(); actual != expected {
+			t.Fatalf("%v: got: %q\nexpected: %q", tc.args, actual, expected)
+		}
+	}
+}
+
+func TestFmt_diff(t *testing.T) {
+	tempDir, err := fmtFixtureWriteDir()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	ui := new(cli.MockUi)
+	c := &FmtCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(testProvider()),
+			Ui:               ui,
+		},
+	}
+
+	args := []string{
+		"-diff",
+		"-write=false",
+		"-list=false",
+		tempDir,
+	}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("wrong exit code. errors: \n%s", ui.ErrorWriter.String())
+	}
+
+	actual := ui.OutputWriter.String()
+	for _, expected := range []string{"-  foo  =  \"bar\"", "+foo = \"bar\""} {
+		if !strings.Contains(actual, expected) {
+			t.Fatalf("expected:\n%s\n\nto include: %q", actual, expected)
+		}
+	}
+}
+
+func TestFmt_emptyDir(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "tf")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	ui := new(cli.MockUi)
+	c := &FmtCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(testProvider()),
+			Ui:               ui,
+		},
+		input: bytes.NewBufferString("must not be read"),
+	}
+
+	if code := c.Run([]string{tempDir}); code != 0 {
+		t.Fatalf("wrong exit code. errors: \n%s", ui.ErrorWriter.String())
+	}
+	if ui.OutputWriter != nil {
+		t.Fatalf("expected no output, got: %q", ui.OutputWriter.String())
+	}
+}
+
 var fmtFixture = struct {
 	filename      string
 	input, golden []byte
@@ -3,6 +3,9 @@ package format
 import (
 	"bytes"
 	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/hashicorp/terraform/tfdiags"
 	"github.com/mitchellh/colorstring"
@@ -17,6 +20,15 @@ import (
 // not all aspects of the message are guaranteed to fit within the specified
 // terminal width.
 func Diagnostic(diag tfdiags.Diagnostic, color *colorstring.Colorize, width int) string {
+	return SourceDiagnostic(diag, nil, color, width)
+}
+
+// SourceDiagnostic is like Diagnostic, but the sources map the name of each
+// source file to its content, so that the lines of configuration that the
+// diagnostic is about are shown with the offending expression marked,
+// followed by the values that the expression refers to, if the diagnostic
+// knows them.
+func SourceDiagnostic(diag tfdiags.Diagnostic, sources map[string][]byte, color *colorstring.Colorize, width int) string {
 	if diag == nil {
 		// No good reason to pass a nil diagnostic in here...
 		return ""
@@ -24,11 +36,13 @@ func Diagnostic(diag tfdiags.Diagnostic, color *colorstring.Colorize, width int)
 
 	var buf bytes.Buffer
 
+	marker := "[red]"
 	switch diag.Severity() {
 	case tfdiags.Error:
 		buf.WriteString(color.Color("\n[bold][red]Error: [reset]"))
 	case tfdiags.Warning:
 		buf.WriteString(color.Color("\n[bold][yellow]Warning: [reset]"))
+		marker = "[yellow]"
 	default:
 		// Clear out any coloring that might be applied by Terraform's UI helper,
 		// so our result is not context-sensitive.
@@ -38,21 +52,28 @@ func Diagnostic(diag tfdiags.Diagnostic, color *colorstring.Colorize, width int)
 	desc := diag.Description()
 	sourceRefs := diag.Source()
 
+	var snippet *tfdiags.JSONSnippet
+	if sourceRefs.Subject != nil {
+		if src, ok := sources[sourceRefs.Subject.Filename]; ok {
+			snippet = tfdiags.Snippet(diag, src)
+		}
+	}
+
 	// We don't wrap the summary, since we expect it to be terse, and since
 	// this is where we put the text of a native Go error it may not always
 	// be pure text that lends itself well to word-wrapping.
-	if sourceRefs.Subject != nil {
+	switch {
+	case snippet != nil:
+		fmt.Fprintf(&buf, color.Color("[bold]%s[reset]\n\n"), desc.Summary)
+		fmt.Fprintf(&buf, "  on %s line %d:\n", sourceRefs.Subject.Filename, snippet.StartLine)
+		writeSnippet(&buf, snippet, color, marker, width)
+		buf.WriteString("\n")
+	case sourceRefs.Subject != nil:
 		fmt.Fprintf(&buf, color.Color("[bold]%s[reset] at %s\n\n"), desc.Summary, sourceRefs.Subject.StartString())
-	} else {
+	default:
 		fmt.Fprintf(&buf, color.Color("[bold]%s[reset]\n\n"), desc.Summary)
 	}
 
-	// TODO: also print out the relevant snippet of config source with the
-	// relevant section highlighted, so the user doesn't need to manually
-	// correlate back to config. Before we can do this, the HCL2 parser
-	// needs to be more deeply integrated so that we can use it to obtain
-	// the parsed source code and AST.
-
 	if desc.Detail != "" {
 		detail := desc.Detail
 		if width != 0 {
@@ -63,3 +84,57 @@ func Diagnostic(diag tfdiags.Diagnostic, color *colorstring.Colorize, width int)
 
 	return buf.String()
 }
+
+// writeSnippet writes the lines of a snippet with their numbers, each
+// followed by a line of carets under the part of it that's highlighted, and
+// then the values of the snippet.
+func writeSnippet(buf *bytes.Buffer, snippet *tfdiags.JSONSnippet, color *colorstring.Colorize, marker string, width int) {
+	lines := strings.Split(snippet.Code, "\n")
+	gutter := len(strconv.Itoa(snippet.StartLine + len(lines) - 1))
+
+	// An empty range still points at the position where it starts.
+	empty := snippet.HighlightEndOffset == snippet.HighlightStartOffset
+
+	lineStart := 0
+	for i, line := range lines {
+		fmt.Fprintf(buf, color.Color("  [dim]%*d:[reset] %s\n"), gutter, snippet.StartLine+i, line)
+
+		start := snippet.HighlightStartOffset - lineStart
+		end := snippet.HighlightEndOffset - lineStart
+		lineStart += len(line) + 1
+		if start < 0 {
+			start = 0
+		}
+		if end > len(line) {
+			end = len(line)
+		}
+		if start > len(line) || end < start || (end == start && !(empty && i == 0)) {
+			continue
+		}
+
+		// Copy the tabs of the line before the highlight, so that the carets
+		// line up with it however wide the tabs are shown.
+		var indent bytes.Buffer
+		for _, r := range line[:start] {
+			if r == '\t' {
+				indent.WriteRune('\t')
+			} else {
+				indent.WriteRune(' ')
+			}
+		}
+		carets := utf8.RuneCountInString(line[start:end])
+		if carets == 0 {
+			carets = 1
+		}
+		fmt.Fprintf(buf, color.Color("  %s  %s"+marker+"%s[reset]\n"), strings.Repeat(" ", gutter), indent.String(), strings.Repeat("^", carets))
+	}
+
+	for _, v := range snippet.Values {
+		text := fmt.Sprintf("%s %s", v.Traversal, v.Statement)
+		if width > gutter+6 {
+			text = wordwrap.WrapString(text, uint(width-gutter-6))
+			text = strings.Replace(text, "\n", "\n"+strings.Repeat(" ", gutter+6), -1)
+		}
+		fmt.Fprintf(buf, color.Color("  %s    [bold]%s[reset]\n"), strings.Repeat(" ", gutter), text)
+	}
+}
@@ -0,0 +1,93 @@
+package format
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/hashicorp/hcl2/hcl"
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+func TestSourceDiagnostic(t *testing.T) {
+	src := []byte("resource \"a\" \"b\" {\n\tami = \"${var.ami}\"\n  count = 1\n}\n")
+	sources := map[string][]byte{"main.tf": src}
+
+	diagnostic := func(start, end hcl.Pos, values ...tfdiags.ExpressionValue) tfdiags.Diagnostic {
+		var diags tfdiags.Diagnostics
+		diags = diags.Append(&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid AMI",
+			Detail:   "The AMI must be an ID, which starts with \"ami-\".",
+			Subject:  &hcl.Range{Filename: "main.tf", Start: start, End: end},
+		})
+		return tfdiags.WithExpressionValues(diags[0], values...)
+	}
+
+	tests := map[string]struct {
+		Diag    tfdiags.Diagnostic
+		Sources map[string][]byte
+		Width   int
+		Want    string
+	}{
+		"no source": {
+			tfdiags.Diagnostics{}.Append(errors.New("oh no"))[0],
+			sources,
+			0,
+			"\nError: oh no\n\n",
+		},
+		"missing source": {
+			diagnostic(hcl.Pos{Line: 2, Column: 8, Byte: 26}, hcl.Pos{Line: 2, Column: 20, Byte: 38}),
+			nil,
+			0,
+			"\nError: Invalid AMI at main.tf:2,8\n\nThe AMI must be an ID, which starts with \"ami-\".\n",
+		},
+		"snippet": {
+			diagnostic(
+				hcl.Pos{Line: 2, Column: 8, Byte: 26}, hcl.Pos{Line: 2, Column: 20, Byte: 38},
+				tfdiags.ExpressionValue{Traversal: "var.ami", Statement: "is \"ubuntu\""},
+			),
+			sources,
+			30,
+			"\nError: Invalid AMI\n\n" +
+				"  on main.tf line 2:\n" +
+				"  2: \tami = \"${var.ami}\"\n" +
+				"     \t      ^^^^^^^^^^^^\n" +
+				"       var.ami is \"ubuntu\"\n" +
+				"\n" +
+				"The AMI must be an ID, which\nstarts with \"ami-\".\n",
+		},
+		"multiline snippet": {
+			diagnostic(hcl.Pos{Line: 2, Column: 8, Byte: 26}, hcl.Pos{Line: 3, Column: 8, Byte: 46}),
+			sources,
+			0,
+			"\nError: Invalid AMI\n\n" +
+				"  on main.tf line 2:\n" +
+				"  2: \tami = \"${var.ami}\"\n" +
+				"     \t      ^^^^^^^^^^^^\n" +
+				"  3:   count = 1\n" +
+				"     ^^^^^^^\n" +
+				"\n" +
+				"The AMI must be an ID, which starts with \"ami-\".\n",
+		},
+		"empty range": {
+			diagnostic(hcl.Pos{Line: 3, Column: 3, Byte: 41}, hcl.Pos{Line: 3, Column: 3, Byte: 41}),
+			sources,
+			0,
+			"\nError: Invalid AMI\n\n" +
+				"  on main.tf line 3:\n" +
+				"  3:   count = 1\n" +
+				"       ^\n" +
+				"\n" +
+				"The AMI must be an ID, which starts with \"ami-\".\n",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := SourceDiagnostic(test.Diag, test.Sources, disabledColorize, test.Width)
+			if got != test.Want {
+				t.Errorf("wrong result\ngot:\n%s\nwant:\n%s", got, test.Want)
+			}
+		})
+	}
+}
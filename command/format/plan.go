@@ -19,6 +19,22 @@ import (
 // there only to clean up the state).
 type Plan struct {
 	Resources []*InstanceDiff
+
+	// Drift describes changes to resources that were detected outside of
+	// Terraform when refreshing before the plan was created. These are
+	// informational only and don't contribute to the plan's actions.
+	Drift []*InstanceDiff
+
+	// NoStructuralDiff disables showing the changes to string attributes
+	// that contain JSON or YAML as diffs of the decoded documents, so that
+	// they're shown as diffs of their text instead.
+	NoStructuralDiff bool
+
+	// Concise omits the attributes of changed resources whose values aren't
+	// changing, and collapses nested blocks and maps that aren't changing to
+	// a count of their elements, so that only the changes are shown in
+	// detail.
+	Concise bool
 }
 
 // InstanceDiff is a representation of an instance diff optimized
@@ -34,6 +50,11 @@ type InstanceDiff struct {
 
 	Tainted bool
 	Deposed bool
+	Replace bool
+
+	// Modifications describes the attributes whose planned values the
+	// provider changed from the values set in the configuration.
+	Modifications []*terraform.PlanModification
 }
 
 // AttributeDiff is a representation of an attribute diff optimized
@@ -61,12 +82,25 @@ type PlanStats struct {
 // NewPlan produces a display-oriented Plan from a terraform.Plan.
 func NewPlan(plan *terraform.Plan) *Plan {
 	ret := &Plan{}
-	if plan == nil || plan.Diff == nil || plan.Diff.Empty() {
+	if plan == nil {
 		// Nothing to do!
 		return ret
 	}
 
-	for _, m := range plan.Diff.Modules {
+	ret.Resources = newInstanceDiffs(plan.Diff)
+	ret.Drift = newInstanceDiffs(plan.Drift)
+	return ret
+}
+
+// newInstanceDiffs produces display-oriented instance diffs for all of the
+// non-empty instance diffs in the given diff, sorted by address.
+func newInstanceDiffs(diff *terraform.Diff) []*InstanceDiff {
+	var ret []*InstanceDiff
+	if diff == nil || diff.Empty() {
+		return ret
+	}
+
+	for _, m := range diff.Modules {
 		var modulePath []string
 		if !m.IsRoot() {
 			// trim off the leading "root" path segment, since it's implied
@@ -99,6 +133,9 @@ func NewPlan(plan *terraform.Plan) *Plan {
 				Action:  r.ChangeType(),
 				Tainted: r.DestroyTainted,
 				Deposed: r.DestroyDeposed,
+				Replace: r.ActionReason == terraform.DiffActionReasonReplaceByRequest,
+
+				Modifications: r.PlanModifications,
 			}
 
 			if dataSource && did.Action == terraform.DiffCreate {
@@ -107,7 +144,7 @@ func NewPlan(plan *terraform.Plan) *Plan {
 				did.Action = terraform.DiffRefresh
 			}
 
-			ret.Resources = append(ret.Resources, did)
+			ret = append(ret, did)
 
 			if did.Action == terraform.DiffDestroy {
 				// Don't show any outputs for destroy actions
@@ -156,9 +193,9 @@ func NewPlan(plan *terraform.Plan) *Plan {
 	}
 
 	// Sort the instance diffs by their addresses for display.
-	sort.Slice(ret.Resources, func(i, j int) bool {
-		iAddr := ret.Resources[i].Addr
-		jAddr := ret.Resources[j].Addr
+	sort.Slice(ret, func(i, j int) bool {
+		iAddr := ret[i].Addr
+		jAddr := ret[j].Addr
 		return iAddr.Less(jAddr)
 	})
 
@@ -174,6 +211,56 @@ func (p *Plan) Format(color *colorstring.Colorize) string {
 		return "This plan does nothing."
 	}
 
+	return p.formatInstanceDiffs(p.Resources, color)
+}
+
+// FormatDrift produces and returns a text representation of the changes
+// detected outside of Terraform that are recorded in the receiving plan,
+// intended for display in a terminal.
+//
+// If color is not nil, it is used to colorize the output.
+func (p *Plan) FormatDrift(color *colorstring.Colorize) string {
+	if len(p.Drift) == 0 {
+		return "No changes were detected outside of Terraform."
+	}
+
+	return p.formatInstanceDiffs(p.Drift, color)
+}
+
+// FormatModifications produces and returns a text representation of the
+// attributes whose planned values the provider changed from the values set
+// in the configuration, intended for display in a terminal.
+//
+// If color is not nil, it is used to colorize the output.
+func (p *Plan) FormatModifications(color *colorstring.Colorize) string {
+	if color == nil {
+		color = &colorstring.Colorize{
+			Colors: colorstring.DefaultColors,
+			Reset:  false,
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	for _, r := range p.Resources {
+		if len(r.Modifications) == 0 {
+			continue
+		}
+
+		buf.WriteString(color.Color(fmt.Sprintf("[bold]%s[reset]\n", r.Addr)))
+		for _, m := range r.Modifications {
+			buf.WriteString(fmt.Sprintf("      %s\n", m))
+		}
+		buf.WriteString("\n")
+	}
+
+	if buf.Len() == 0 {
+		return "No planned values were modified by providers."
+	}
+
+	return strings.TrimSpace(buf.String())
+}
+
+func (p *Plan) formatInstanceDiffs(diffs []*InstanceDiff, color *colorstring.Colorize) string {
 	if color == nil {
 		color = &colorstring.Colorize{
 			Colors: colorstring.DefaultColors,
@@ -184,8 +271,10 @@ func (p *Plan) Format(color *colorstring.Colorize) string {
 	// Find the longest path length of all the paths that are changing,
 	// so we can align them all.
 	keyLen := 0
-	for _, r := range p.Resources {
-		for _, attr := range r.Attributes {
+	attrs := make([]*displayAttributes, len(diffs))
+	for i, r := range diffs {
+		attrs[i] = p.displayAttributes(r)
+		for _, attr := range attrs[i].Attributes {
 			key := attr.Path
 
 			if len(key) > keyLen {
@@ -195,8 +284,8 @@ func (p *Plan) Format(color *colorstring.Colorize) string {
 	}
 
 	buf := new(bytes.Buffer)
-	for _, r := range p.Resources {
-		formatPlanInstanceDiff(buf, r, keyLen, color)
+	for i, r := range diffs {
+		p.formatPlanInstanceDiff(buf, r, attrs[i], keyLen, color)
 	}
 
 	return strings.TrimSpace(buf.String())
@@ -231,6 +320,9 @@ func (p *Plan) ActionCounts() map[terraform.DiffChangeType]int {
 }
 
 // Empty returns true if there is at least one resource diff in the receiving plan.
+//
+// Drift is not considered, since it doesn't describe any action that
+// applying the plan would take.
 func (p *Plan) Empty() bool {
 	return len(p.Resources) == 0
 }
@@ -254,9 +346,10 @@ func DiffActionSymbol(action terraform.DiffChangeType) string {
 	}
 }
 
-// formatPlanInstanceDiff writes the text representation of the given instance diff
-// to the given buffer, using the given colorizer.
-func formatPlanInstanceDiff(buf *bytes.Buffer, r *InstanceDiff, keyLen int, colorizer *colorstring.Colorize) {
+// formatPlanInstanceDiff writes the text representation of the given instance diff,
+// showing the given attributes of it, to the given buffer, using the given
+// colorizer.
+func (p *Plan) formatPlanInstanceDiff(buf *bytes.Buffer, r *InstanceDiff, attrs *displayAttributes, keyLen int, colorizer *colorstring.Colorize) {
 	addrStr := r.Addr.String()
 
 	// Determine the color for the text (green for adding, yellow
@@ -285,6 +378,9 @@ func formatPlanInstanceDiff(buf *bytes.Buffer, r *InstanceDiff, keyLen int, colo
 	if r.Deposed {
 		extraStr = extraStr + " (deposed)"
 	}
+	if r.Replace {
+		extraStr = extraStr + " (replace requested)"
+	}
 	if r.Action == terraform.DiffDestroyCreate {
 		extraStr = extraStr + colorizer.Color(" [red][bold](new resource required)")
 	}
@@ -296,7 +392,16 @@ func formatPlanInstanceDiff(buf *bytes.Buffer, r *InstanceDiff, keyLen int, colo
 		)),
 	)
 
-	for _, attr := range r.Attributes {
+	for _, attr := range attrs.Attributes {
+		if summary, ok := attrs.Collapsed[attr]; ok {
+			buf.WriteString(fmt.Sprintf(
+				"      %s:%s %s\n",
+				attr.Path,
+				strings.Repeat(" ", keyLen-len(attr.Path)),
+				summary,
+			))
+			continue
+		}
 
 		v := attr.NewValue
 		var dispV string
@@ -317,6 +422,27 @@ func formatPlanInstanceDiff(buf *bytes.Buffer, r *InstanceDiff, keyLen int, colo
 			updateMsg = colorizer.Color(" [yellow](attribute changed)")
 		}
 
+		// Long strings and documents that changed are shown as a diff of
+		// their content, rather than as both of their values.
+		if oldValues && !attr.Sensitive && !attr.NewComputed && attr.Action == terraform.DiffUpdate {
+			if diff, ok := formatStringDiff(attr.OldValue, v, !p.NoStructuralDiff, 8, colorizer); ok {
+				// The changed lines of a multi-line diff follow its summary.
+				summary, lines := diff, ""
+				if i := strings.Index(diff, "\n"); i >= 0 {
+					summary, lines = diff[:i], diff[i:]
+				}
+				buf.WriteString(fmt.Sprintf(
+					"      %s:%s %s%s%s\n",
+					attr.Path,
+					strings.Repeat(" ", keyLen-len(attr.Path)),
+					summary,
+					updateMsg,
+					lines,
+				))
+				continue
+			}
+		}
+
 		if oldValues {
 			u := attr.OldValue
 			var dispU string
@@ -344,6 +470,10 @@ func formatPlanInstanceDiff(buf *bytes.Buffer, r *InstanceDiff, keyLen int, colo
 		}
 	}
 
+	if attrs.Hidden > 0 {
+		buf.WriteString(fmt.Sprintf("      (%s hidden)\n", pluralUnchanged(attrs.Hidden, "attribute")))
+	}
+
 	// Write the reset color so we don't bleed color into later text
 	buf.WriteString(colorizer.Color("[reset]\n"))
 }
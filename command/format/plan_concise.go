@@ -0,0 +1,116 @@
+package format
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform/terraform"
+)
+
+// displayAttributes are the attributes of an instance diff that are shown
+// when formatting a plan.
+type displayAttributes struct {
+	Attributes []*AttributeDiff
+
+	// Collapsed maps the entries of Attributes that stand for whole nested
+	// blocks or maps that aren't changing to the summaries shown for them.
+	Collapsed map[*AttributeDiff]string
+
+	// Hidden is how many attributes that aren't changing were left out,
+	// not counting those of collapsed blocks.
+	Hidden int
+}
+
+// displayAttributes returns the attributes of the given instance diff to
+// show. Unless the plan is concise, or the instance has no prior values to
+// compare with, these are all of the attributes of the diff.
+//
+// Otherwise, attributes whose values aren't changing are left out, unless
+// they force the instance to be replaced. A nested block or map whose
+// attributes are all unchanged, such as every "ingress.*" attribute, is
+// collapsed to a single entry summarizing how many elements it has.
+func (p *Plan) displayAttributes(r *InstanceDiff) *displayAttributes {
+	ret := &displayAttributes{}
+	if !p.Concise || r.Action == terraform.DiffCreate || r.Action == terraform.DiffRefresh {
+		ret.Attributes = r.Attributes
+		return ret
+	}
+
+	// A block is changing if any of its attributes are.
+	changing := make(map[string]bool)
+	for _, attr := range r.Attributes {
+		if block := attributeBlock(attr.Path); block != "" && !attributeUnchanged(attr) {
+			changing[block] = true
+		}
+	}
+
+	for _, attr := range r.Attributes {
+		block := attributeBlock(attr.Path)
+		switch {
+		case block != "" && !changing[block]:
+			// The attributes are sorted by path, so those of a block are
+			// together and the first of them stands for all of them.
+			if len(ret.Attributes) > 0 && ret.Attributes[len(ret.Attributes)-1].Path == block {
+				continue
+			}
+			placeholder := &AttributeDiff{Path: block, Action: terraform.DiffUpdate}
+			if ret.Collapsed == nil {
+				ret.Collapsed = make(map[*AttributeDiff]string)
+			}
+			ret.Collapsed[placeholder] = fmt.Sprintf("(%s)", pluralUnchanged(blockElements(r.Attributes, block), "element"))
+			ret.Attributes = append(ret.Attributes, placeholder)
+		case attributeUnchanged(attr):
+			ret.Hidden++
+		default:
+			ret.Attributes = append(ret.Attributes, attr)
+		}
+	}
+
+	return ret
+}
+
+// attributeUnchanged returns true if the value of the given attribute isn't
+// changing and so isn't worth showing in a concise plan.
+func attributeUnchanged(attr *AttributeDiff) bool {
+	return attr.Action == terraform.DiffUpdate && !attr.NewComputed && !attr.ForcesNew && attr.OldValue == attr.NewValue
+}
+
+// attributeBlock returns the name of the top-level nested block or map that
+// the attribute with the given path belongs to, such as "ingress" for
+// "ingress.1234.from_port", or "" if it's a top-level attribute.
+func attributeBlock(path string) string {
+	if i := strings.Index(path, "."); i >= 0 {
+		return path[:i]
+	}
+	return ""
+}
+
+// blockElements returns how many elements the given block has, as recorded
+// by its count attribute if the diff has it, or otherwise as the number of
+// distinct keys of its attributes.
+func blockElements(attrs []*AttributeDiff, block string) int {
+	keys := make(map[string]struct{})
+	for _, attr := range attrs {
+		if attributeBlock(attr.Path) != block {
+			continue
+		}
+
+		key := strings.SplitN(attr.Path[len(block)+1:], ".", 2)[0]
+		if key == "#" || key == "%" {
+			if n, err := strconv.Atoi(attr.OldValue); err == nil {
+				return n
+			}
+			continue
+		}
+		keys[key] = struct{}{}
+	}
+	return len(keys)
+}
+
+func pluralUnchanged(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("1 unchanged %s", noun)
+	}
+	return fmt.Sprintf("%d unchanged %ss", n, noun)
+}
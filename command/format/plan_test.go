@@ -473,6 +473,104 @@ func TestPlan_destroyDeposed(t *testing.T) {
 	}
 }
 
+// Test that drift is formatted separately from the planned actions
+func TestPlan_drift(t *testing.T) {
+	plan := &terraform.Plan{
+		Drift: &terraform.Diff{
+			Modules: []*terraform.ModuleDiff{
+				&terraform.ModuleDiff{
+					Path: []string{"root"},
+					Resources: map[string]*terraform.InstanceDiff{
+						"aws_instance.foo": &terraform.InstanceDiff{
+							Attributes: map[string]*terraform.ResourceAttrDiff{
+								"ami": &terraform.ResourceAttrDiff{
+									Old: "bar",
+									New: "baz",
+								},
+							},
+						},
+						"aws_instance.gone": &terraform.InstanceDiff{
+							Destroy: true,
+						},
+					},
+				},
+			},
+		},
+	}
+	dispPlan := NewPlan(plan)
+	if !dispPlan.Empty() {
+		t.Fatal("plan with only drift should be empty")
+	}
+
+	actual := dispPlan.FormatDrift(disabledColorize)
+	expected := strings.TrimSpace(`
+~ aws_instance.foo
+      ami: "bar" => "baz"
+
+  - aws_instance.gone
+	`)
+	if actual != expected {
+		t.Fatalf("expected:\n\n%s\n\ngot:\n\n%s", expected, actual)
+	}
+}
+
+// Test that provider plan modifications are listed per resource
+func TestPlan_modifications(t *testing.T) {
+	plan := &terraform.Plan{
+		Diff: &terraform.Diff{
+			Modules: []*terraform.ModuleDiff{
+				&terraform.ModuleDiff{
+					Path: []string{"root"},
+					Resources: map[string]*terraform.InstanceDiff{
+						"aws_instance.foo": &terraform.InstanceDiff{
+							Attributes: map[string]*terraform.ResourceAttrDiff{
+								"ami": &terraform.ResourceAttrDiff{
+									Old: "bar",
+									New: "BAZ",
+								},
+								"password": &terraform.ResourceAttrDiff{
+									NewComputed: true,
+									Sensitive:   true,
+								},
+							},
+							PlanModifications: []*terraform.PlanModification{
+								{Path: "ami", Proposed: "baz", Planned: "BAZ"},
+								{Path: "password", Proposed: "secret", PlannedComputed: true, Sensitive: true},
+							},
+						},
+						"aws_instance.bar": &terraform.InstanceDiff{
+							Attributes: map[string]*terraform.ResourceAttrDiff{
+								"ami": &terraform.ResourceAttrDiff{
+									Old: "bar",
+									New: "baz",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	dispPlan := NewPlan(plan)
+
+	actual := dispPlan.FormatModifications(disabledColorize)
+	expected := strings.TrimSpace(`
+aws_instance.foo
+      ami: "baz" => "BAZ"
+      password: <sensitive> => <computed>
+	`)
+	if actual != expected {
+		t.Fatalf("expected:\n\n%s\n\ngot:\n\n%s", expected, actual)
+	}
+
+	dispPlan = NewPlan(&terraform.Plan{})
+	actual = dispPlan.FormatModifications(disabledColorize)
+	expected = "No planned values were modified by providers."
+	if actual != expected {
+		t.Fatalf("expected:\n\n%s\n\ngot:\n\n%s", expected, actual)
+	}
+}
+
 // Test that computed fields with an interpolation string get displayed
 func TestPlan_displayInterpolations(t *testing.T) {
 	plan := &terraform.Plan{
@@ -544,6 +642,184 @@ func TestPlan_forcesNewResource(t *testing.T) {
 	}
 }
 
+func TestPlan_longStringDiff(t *testing.T) {
+	plan := &terraform.Plan{
+		Diff: &terraform.Diff{
+			Modules: []*terraform.ModuleDiff{
+				&terraform.ModuleDiff{
+					Path: []string{"root"},
+					Resources: map[string]*terraform.InstanceDiff{
+						"test_resource.foo": &terraform.InstanceDiff{
+							Destroy: true,
+							Attributes: map[string]*terraform.ResourceAttrDiff{
+								"user_data": &terraform.ResourceAttrDiff{
+									Old:         "#!/bin/sh\napt-get install nginx\nservice nginx start",
+									New:         "#!/bin/sh\napt-get install apache2\nservice nginx start",
+									RequiresNew: true,
+								},
+								"password": &terraform.ResourceAttrDiff{
+									Old:       "#!/bin/sh\nhunter1",
+									New:       "#!/bin/sh\nhunter2",
+									Sensitive: true,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	dispPlan := NewPlan(plan)
+	actual := dispPlan.Format(disabledColorize)
+
+	expected := strings.TrimSpace(`
+-/+ test_resource.foo (new resource required)
+      password:  <sensitive> => <sensitive> (attribute changed)
+      user_data: (1 line removed, 1 line added) (forces new resource)
+          #!/bin/sh
+        - apt-get install nginx
+        + apt-get install apache2
+          service nginx start
+	`)
+	if actual != expected {
+		t.Fatalf("expected:\n\n%s\n\ngot:\n\n%s", expected, actual)
+	}
+}
+
+func TestPlan_structuralDiff(t *testing.T) {
+	plan := &terraform.Plan{
+		Diff: &terraform.Diff{
+			Modules: []*terraform.ModuleDiff{
+				&terraform.ModuleDiff{
+					Path: []string{"root"},
+					Resources: map[string]*terraform.InstanceDiff{
+						"test_resource.foo": &terraform.InstanceDiff{
+							Attributes: map[string]*terraform.ResourceAttrDiff{
+								"policy": &terraform.ResourceAttrDiff{
+									Old: `{"Statement":[{"Action":"s3:GetObject","Effect":"Allow"},{"Action":"s3:ListBucket","Effect":"Allow"}]}`,
+									New: `{"Statement":[{"Action":"s3:GetObject","Effect":"Allow"},{"Action":"s3:ListBucket","Effect":"Deny"}]}`,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	dispPlan := NewPlan(plan)
+	actual := dispPlan.Format(disabledColorize)
+
+	expected := strings.TrimSpace(`
+~ test_resource.foo
+      policy: (JSON document changed)
+        ~ Statement[1].Effect: "Allow" => "Deny"
+	`)
+	if actual != expected {
+		t.Fatalf("expected:\n\n%s\n\ngot:\n\n%s", expected, actual)
+	}
+
+	dispPlan.NoStructuralDiff = true
+	actual = dispPlan.Format(disabledColorize)
+
+	expected = strings.TrimSpace(`
+~ test_resource.foo
+      policy: (1 line removed, 1 line added)
+          ...
+              },
+              {
+                "Action": "s3:ListBucket",
+        -       "Effect": "Allow"
+        +       "Effect": "Deny"
+              }
+            ]
+          }
+	`)
+	if actual != expected {
+		t.Fatalf("expected:\n\n%s\n\ngot:\n\n%s", expected, actual)
+	}
+}
+
+func TestPlan_concise(t *testing.T) {
+	plan := &terraform.Plan{
+		Diff: &terraform.Diff{
+			Modules: []*terraform.ModuleDiff{
+				&terraform.ModuleDiff{
+					Path: []string{"root"},
+					Resources: map[string]*terraform.InstanceDiff{
+						"test_resource.foo": &terraform.InstanceDiff{
+							Destroy: true,
+							Attributes: map[string]*terraform.ResourceAttrDiff{
+								"ami": &terraform.ResourceAttrDiff{
+									Old:         "ami-123",
+									New:         "ami-456",
+									RequiresNew: true,
+								},
+								"zone": &terraform.ResourceAttrDiff{
+									Old:         "us-east-1a",
+									New:         "us-east-1a",
+									RequiresNew: true,
+								},
+								"instance_type": &terraform.ResourceAttrDiff{
+									Old: "t2.micro",
+									New: "t2.micro",
+								},
+								"monitoring": &terraform.ResourceAttrDiff{
+									Old: "false",
+									New: "false",
+								},
+								"private_ip": &terraform.ResourceAttrDiff{
+									Old:         "10.0.0.1",
+									NewComputed: true,
+								},
+								"ingress.#": &terraform.ResourceAttrDiff{
+									Old: "2",
+									New: "2",
+								},
+								"ingress.1234.from_port": &terraform.ResourceAttrDiff{
+									Old: "80",
+									New: "80",
+								},
+								"ingress.5678.from_port": &terraform.ResourceAttrDiff{
+									Old: "443",
+									New: "443",
+								},
+								"tags.%": &terraform.ResourceAttrDiff{
+									Old: "2",
+									New: "2",
+								},
+								"tags.Name": &terraform.ResourceAttrDiff{
+									Old: "web",
+									New: "web-1",
+								},
+								"tags.Owner": &terraform.ResourceAttrDiff{
+									Old: "ops",
+									New: "ops",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	dispPlan := NewPlan(plan)
+	dispPlan.Concise = true
+	actual := dispPlan.Format(disabledColorize)
+
+	expected := strings.TrimSpace(`
+-/+ test_resource.foo (new resource required)
+      ami:        "ami-123" => "ami-456" (forces new resource)
+      ingress:    (2 unchanged elements)
+      private_ip: "10.0.0.1" => <computed>
+      tags.Name:  "web" => "web-1"
+      zone:       "us-east-1a" => "us-east-1a" (forces new resource)
+      (4 unchanged attributes hidden)
+	`)
+	if actual != expected {
+		t.Fatalf("expected:\n\n%s\n\ngot:\n\n%s", expected, actual)
+	}
+}
+
 // Test that a root level data source gets a special plan output on create
 func TestPlan_rootDataSource(t *testing.T) {
 	plan := &terraform.Plan{
@@ -61,6 +61,10 @@ func State(opts *StateOpts) string {
 		// Output each output k/v pair
 		for _, k := range ks {
 			v := m.Outputs[k]
+			if v.Sensitive {
+				buf.WriteString(fmt.Sprintf("%s = <sensitive>\n", k))
+				continue
+			}
 			switch output := v.Value.(type) {
 			case string:
 				buf.WriteString(fmt.Sprintf("%s = %s", k, output))
@@ -134,6 +138,9 @@ func formatStateModuleExpand(
 			// Output each attribute
 			for _, ak := range attrKeys {
 				av := is.Attributes[ak]
+				if is.IsSensitive(ak) {
+					av = "<sensitive>"
+				}
 				buf.WriteString(fmt.Sprintf("  %s = %s\n", ak, av))
 			}
 		}
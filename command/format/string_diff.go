@@ -0,0 +1,329 @@
+package format
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/mitchellh/colorstring"
+)
+
+// LongStringLength is the length beyond which a changed string attribute is
+// shown as a diff of its content rather than as its before and after values.
+// Strings of more than one line are always shown as diffs.
+const LongStringLength = 80
+
+// diffContextLines is how many unchanged lines are shown around the changed
+// lines of a multi-line string diff.
+const diffContextLines = 3
+
+// maxDiffEdits is the most insertions and deletions that diffTokens looks
+// for before giving up and replacing the whole of the before tokens, which
+// keeps the cost of diffing strings that have little in common bounded.
+const maxDiffEdits = 1000
+
+// isLongString returns true if the given value of a string attribute is
+// long enough to be shown as a diff when it changes.
+func isLongString(s string) bool {
+	return len(s) > LongStringLength || strings.Contains(s, "\n")
+}
+
+// formatStringDiff returns a diff of the before and after values of a
+// string attribute, or false if they're better shown as they are.
+//
+// If structural is true, values that are both JSON or both YAML documents
+// are shown as a diff of the documents, whatever their length; see
+// formatStructuralDiff. Otherwise, long values that are both JSON are
+// pretty-printed first, so that a change to
+// a minified policy is shown as the lines of it that changed. Values of
+// more than one line are shown as the changed lines with some context, as
+// the lines that follow a summary of the change; others are shown inline
+// as a quoted string with the removed words in [-...-] and the added words
+// in {+...+}.
+func formatStringDiff(before, after string, structural bool, indent int, color *colorstring.Colorize) (string, bool) {
+	if before == "" || after == "" || before == after {
+		return "", false
+	}
+
+	if structural {
+		if diff, ok := formatStructuralDiff(before, after, indent, color); ok {
+			return diff, true
+		}
+	}
+
+	if !(isLongString(before) || isLongString(after)) {
+		return "", false
+	}
+
+	if beforeJSON, ok := prettyJSON(before); ok {
+		if afterJSON, ok := prettyJSON(after); ok {
+			if beforeJSON == afterJSON {
+				return "(only the JSON formatting changed)", true
+			}
+			before, after = beforeJSON, afterJSON
+		}
+	}
+
+	if !strings.Contains(before, "\n") && !strings.Contains(after, "\n") {
+		return formatInlineDiff(before, after, color), true
+	}
+	return formatLineDiff(before, after, indent, color), true
+}
+
+// formatInlineDiff returns a word-level diff of two single-line strings.
+func formatInlineDiff(before, after string, color *colorstring.Colorize) string {
+	// Runs of tokens with the same change are shown together.
+	var runs []diffOp
+	for _, op := range diffTokens(diffWordRe.FindAllString(before, -1), diffWordRe.FindAllString(after, -1)) {
+		if len(runs) > 0 && runs[len(runs)-1].Kind == op.Kind {
+			runs[len(runs)-1].Text += op.Text
+			continue
+		}
+		runs = append(runs, op)
+	}
+
+	buf := new(bytes.Buffer)
+	buf.WriteString(`"`)
+	for _, op := range runs {
+		text := quoteContent(op.Text)
+		switch op.Kind {
+		case diffDelete:
+			buf.WriteString(color.Color("[red]") + "[-" + text + "-]" + color.Color("[reset]"))
+		case diffInsert:
+			buf.WriteString(color.Color("[green]") + "{+" + text + "+}" + color.Color("[reset]"))
+		default:
+			buf.WriteString(text)
+		}
+	}
+	buf.WriteString(`"`)
+	return buf.String()
+}
+
+// formatLineDiff returns a summary of the changes to a multi-line string,
+// followed by the changed lines with some context, each indented by the
+// given number of spaces and marked with "-" if it was removed or "+" if
+// it was added. Unchanged lines that are left out are replaced by "...".
+func formatLineDiff(before, after string, indent int, color *colorstring.Colorize) string {
+	ops := diffTokens(strings.Split(before, "\n"), strings.Split(after, "\n"))
+
+	// Each unchanged line is shown if it's near enough to a changed one.
+	show := make([]bool, len(ops))
+	removed, added := 0, 0
+	for i, op := range ops {
+		if op.Kind == diffEqual {
+			continue
+		}
+		if op.Kind == diffDelete {
+			removed++
+		} else {
+			added++
+		}
+		for j := i - diffContextLines; j <= i+diffContextLines; j++ {
+			if j >= 0 && j < len(ops) {
+				show[j] = true
+			}
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, "(%s removed, %s added)", pluralLines(removed), pluralLines(added))
+
+	prefix := strings.Repeat(" ", indent)
+	skipped := false
+	for i, op := range ops {
+		if !show[i] {
+			skipped = true
+			continue
+		}
+		if skipped {
+			fmt.Fprintf(buf, "\n%s  ...", prefix)
+			skipped = false
+		}
+
+		switch op.Kind {
+		case diffDelete:
+			fmt.Fprintf(buf, "\n%s%s- %s%s", prefix, color.Color("[red]"), op.Text, color.Color("[reset]"))
+		case diffInsert:
+			fmt.Fprintf(buf, "\n%s%s+ %s%s", prefix, color.Color("[green]"), op.Text, color.Color("[reset]"))
+		default:
+			fmt.Fprintf(buf, "\n%s  %s", prefix, op.Text)
+		}
+	}
+	if skipped {
+		fmt.Fprintf(buf, "\n%s  ...", prefix)
+	}
+
+	return buf.String()
+}
+
+func pluralLines(n int) string {
+	if n == 1 {
+		return "1 line"
+	}
+	return fmt.Sprintf("%d lines", n)
+}
+
+// diffWordRe splits a string into words, runs of whitespace and single
+// other characters, which are what an inline diff adds and removes.
+var diffWordRe = regexp.MustCompile(`[\pL\pN_]+|\s+|.`)
+
+// quoteContent returns s as it would appear within a quoted string.
+func quoteContent(s string) string {
+	q := strconv.Quote(s)
+	return q[1 : len(q)-1]
+}
+
+// prettyJSON returns the given string indented, with the keys of its
+// objects sorted, if it's a JSON object or array.
+func prettyJSON(s string) (string, bool) {
+	trimmed := strings.TrimSpace(s)
+	if !strings.HasPrefix(trimmed, "{") && !strings.HasPrefix(trimmed, "[") {
+		return "", false
+	}
+
+	dec := json.NewDecoder(strings.NewReader(trimmed))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil || dec.More() {
+		return "", false
+	}
+
+	buf := new(bytes.Buffer)
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return "", false
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), true
+}
+
+type diffKind int
+
+const (
+	diffEqual diffKind = iota
+	diffDelete
+	diffInsert
+)
+
+// diffOp is a token that a diff keeps, deletes or inserts.
+type diffOp struct {
+	Kind diffKind
+	Text string
+}
+
+// diffTokens returns the shortest edit script that turns the tokens a into
+// the tokens b, using the algorithm from Myers' "An O(ND) Difference
+// Algorithm and Its Variations".
+func diffTokens(a, b []string) []diffOp {
+	// The common prefix and suffix are kept as they are, which is much
+	// cheaper than finding them with the main algorithm.
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+
+	var result []diffOp
+	for _, t := range a[:pre] {
+		result = append(result, diffOp{diffEqual, t})
+	}
+	result = append(result, diffMiddle(a[pre:len(a)-suf], b[pre:len(b)-suf])...)
+	for _, t := range a[len(a)-suf:] {
+		result = append(result, diffOp{diffEqual, t})
+	}
+	return result
+}
+
+func diffMiddle(a, b []string) []diffOp {
+	n, m := len(a), len(b)
+	limit := n + m
+	if limit > maxDiffEdits {
+		limit = maxDiffEdits
+	}
+
+	// v[offset+k] is the furthest x reached on diagonal k. trace[d] is the
+	// part of v for diagonals -d-1 to d+1 before step d, which is all that
+	// the backtrack needs.
+	offset := limit + 1
+	v := make([]int, 2*limit+3)
+	var trace [][]int
+	for d := 0; d <= limit; d++ {
+		trace = append(trace, append([]int(nil), v[offset-d-1:offset+d+2]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return diffBacktrack(a, b, trace)
+			}
+		}
+	}
+
+	// Too many edits to find the shortest script cheaply.
+	var result []diffOp
+	for _, t := range a {
+		result = append(result, diffOp{diffDelete, t})
+	}
+	for _, t := range b {
+		result = append(result, diffOp{diffInsert, t})
+	}
+	return result
+}
+
+func diffBacktrack(a, b []string, trace [][]int) []diffOp {
+	var result []diffOp
+	x, y := len(a), len(b)
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		at := func(k int) int { return v[k+d+1] }
+
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := 0
+		if d > 0 {
+			prevX = at(prevK)
+		}
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			x--
+			y--
+			result = append(result, diffOp{diffEqual, a[x]})
+		}
+		if d > 0 {
+			if x == prevX {
+				result = append(result, diffOp{diffInsert, b[prevY]})
+			} else {
+				result = append(result, diffOp{diffDelete, a[prevX]})
+			}
+		}
+		x, y = prevX, prevY
+	}
+
+	// The script was built from the end.
+	for i, j := 0, len(result)-1; i < j; i, j = i+1, j-1 {
+		result[i], result[j] = result[j], result[i]
+	}
+	return result
+}
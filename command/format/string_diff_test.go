@@ -0,0 +1,125 @@
+package format
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiffTokens(t *testing.T) {
+	cases := []struct {
+		A, B     string
+		Expected string
+	}{
+		{"", "", ""},
+		{"abc", "abc", "=a =b =c"},
+		{"abc", "", "-a -b -c"},
+		{"", "abc", "+a +b +c"},
+		{"abcabba", "cbabac", "-a -b =c +b =a =b -b =a +c"},
+		{"axc", "ayc", "=a -x +y =c"},
+		{"abcd", "acd", "=a -b =c =d"},
+	}
+
+	for _, tc := range cases {
+		var actual []string
+		for _, op := range diffTokens(strings.Split(tc.A, ""), strings.Split(tc.B, "")) {
+			actual = append(actual, [...]string{"=", "-", "+"}[op.Kind]+op.Text)
+		}
+		if got := strings.Join(actual, " "); got != tc.Expected {
+			t.Errorf("%q -> %q: got %q, want %q", tc.A, tc.B, got, tc.Expected)
+		}
+	}
+}
+
+func TestDiffTokens_applies(t *testing.T) {
+	// Whatever script is found, it must turn one into the other.
+	a := strings.Split("the quick brown fox jumps over the lazy dog", " ")
+	b := strings.Split("a quick red fox leaps over the dog and the cat", " ")
+
+	var gotA, gotB []string
+	for _, op := range diffTokens(a, b) {
+		if op.Kind != diffInsert {
+			gotA = append(gotA, op.Text)
+		}
+		if op.Kind != diffDelete {
+			gotB = append(gotB, op.Text)
+		}
+	}
+	if !reflect.DeepEqual(gotA, a) || !reflect.DeepEqual(gotB, b) {
+		t.Fatalf("script doesn't apply\ngot a: %q\ngot b: %q", gotA, gotB)
+	}
+}
+
+func TestFormatStringDiff(t *testing.T) {
+	long := strings.Repeat("x", LongStringLength)
+
+	cases := map[string]struct {
+		Before, After string
+		Expected      string
+	}{
+		"short": {
+			"foo", "bar",
+			"",
+		},
+		"empty": {
+			"", long + " foo",
+			"",
+		},
+		"inline": {
+			long + " foo bar", long + " foo baz\t",
+			`"` + long + ` foo [-bar-]{+baz\t+}"`,
+		},
+		"lines": {
+			"#!/bin/sh\n1\n2\n3\n4\n5\napt-get install nginx\n6\n7\n8\n9\n10",
+			"#!/bin/sh\n1\n2\n3\n4\n5\napt-get install apache2\n6\n7\n8\n9\n10\n11",
+			strings.Join([]string{
+				"(1 line removed, 2 lines added)",
+				"          ...",
+				"          3",
+				"          4",
+				"          5",
+				"        - apt-get install nginx",
+				"        + apt-get install apache2",
+				"          6",
+				"          7",
+				"          8",
+				"          9",
+				"          10",
+				"        + 11",
+			}, "\n"),
+		},
+		"json": {
+			`{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":"s3:GetObject","Resource":"*"}]}`,
+			`{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":"s3:*","Resource":"*"}]}`,
+			strings.Join([]string{
+				"(1 line removed, 1 line added)",
+				"          {",
+				"            \"Statement\": [",
+				"              {",
+				"        -       \"Action\": \"s3:GetObject\",",
+				"        +       \"Action\": \"s3:*\",",
+				"                \"Effect\": \"Allow\",",
+				"                \"Resource\": \"*\"",
+				"              }",
+				"          ...",
+			}, "\n"),
+		},
+		"json reformatted": {
+			`{"b": 1, "a": [1, 2.50], "c": "` + long + `"}`,
+			"{\n  \"a\": [1, 2.50],\n  \"b\": 1,\n  \"c\": \"" + long + "\"\n}",
+			"(only the JSON formatting changed)",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			actual, ok := formatStringDiff(tc.Before, tc.After, false, 8, disabledColorize)
+			if ok != (tc.Expected != "") {
+				t.Fatalf("wrong ok %t: %q", ok, actual)
+			}
+			if actual != tc.Expected {
+				t.Fatalf("wrong diff\ngot:\n%s\nwant:\n%s", actual, tc.Expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,258 @@
+package format
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mitchellh/colorstring"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// formatStructuralDiff returns a diff of the documents that the before and
+// after values of a string attribute decode to, or false if they aren't
+// both JSON or both YAML objects or arrays.
+//
+// Each change is shown on its own line as the path of the value within
+// the document, marked with "~" if the value changed, "+" if it was added
+// or "-" if it was removed, so that a change to one statement of a policy
+// is shown as just that change.
+func formatStructuralDiff(before, after string, indent int, color *colorstring.Colorize) (string, bool) {
+	format := "JSON"
+	beforeDoc, ok1 := decodeJSONDocument(before)
+	afterDoc, ok2 := decodeJSONDocument(after)
+	if !ok1 || !ok2 {
+		// Single lines are too likely to be YAML by accident, such as a
+		// description that has a colon in it, so YAML must have more.
+		if !strings.Contains(before, "\n") && !strings.Contains(after, "\n") {
+			return "", false
+		}
+		format = "YAML"
+		beforeDoc, ok1 = decodeYAMLDocument(before)
+		afterDoc, ok2 = decodeYAMLDocument(after)
+		if !ok1 || !ok2 {
+			return "", false
+		}
+	}
+
+	var changes []*documentChange
+	diffDocuments("", beforeDoc, afterDoc, &changes)
+	if len(changes) == 0 {
+		return fmt.Sprintf("(only the %s formatting changed)", format), true
+	}
+
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, "(%s document changed)", format)
+	prefix := strings.Repeat(" ", indent)
+	for _, c := range changes {
+		path := c.Path
+		if path == "" {
+			path = "(document)"
+		}
+		switch {
+		case c.Removed:
+			fmt.Fprintf(buf, "\n%s%s- %s: %s%s", prefix, color.Color("[red]"), path, documentValue(c.Before), color.Color("[reset]"))
+		case c.Added:
+			fmt.Fprintf(buf, "\n%s%s+ %s: %s%s", prefix, color.Color("[green]"), path, documentValue(c.After), color.Color("[reset]"))
+		default:
+			fmt.Fprintf(buf, "\n%s%s~ %s: %s => %s%s", prefix, color.Color("[yellow]"), path, documentValue(c.Before), documentValue(c.After), color.Color("[reset]"))
+		}
+	}
+	return buf.String(), true
+}
+
+// documentChange is a value of a document that was changed, added or
+// removed.
+type documentChange struct {
+	// Path is where the value is in the document, such as
+	// "Statement[0].Action", or "" for the whole document.
+	Path string
+
+	Before, After  interface{}
+	Added, Removed bool
+}
+
+// diffDocuments appends the changes between the given decoded values at
+// the given path to changes. Objects are compared key by key, and arrays
+// element by element after matching up the elements that are the same, so
+// that an element inserted into an array doesn't change those after it.
+func diffDocuments(path string, before, after interface{}, changes *[]*documentChange) {
+	if reflect.DeepEqual(before, after) {
+		return
+	}
+
+	switch b := before.(type) {
+	case map[string]interface{}:
+		a, ok := after.(map[string]interface{})
+		if !ok {
+			break
+		}
+
+		keys := make([]string, 0, len(b)+len(a))
+		for k := range b {
+			keys = append(keys, k)
+		}
+		for k := range a {
+			if _, ok := b[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			bv, inBefore := b[k]
+			av, inAfter := a[k]
+			keyPath := documentKeyPath(path, k)
+			switch {
+			case !inAfter:
+				*changes = append(*changes, &documentChange{Path: keyPath, Before: bv, Removed: true})
+			case !inBefore:
+				*changes = append(*changes, &documentChange{Path: keyPath, After: av, Added: true})
+			default:
+				diffDocuments(keyPath, bv, av, changes)
+			}
+		}
+		return
+
+	case []interface{}:
+		a, ok := after.([]interface{})
+		if !ok {
+			break
+		}
+		diffDocumentArrays(path, b, a, changes)
+		return
+	}
+
+	*changes = append(*changes, &documentChange{Path: path, Before: before, After: after})
+}
+
+// diffDocumentArrays appends the changes between two arrays. The elements
+// that are the same in both are matched with diffTokens, and then each
+// element that was removed and replaced at the same place is compared
+// with its replacement, so that a change within an element is shown as
+// that change.
+func diffDocumentArrays(path string, before, after []interface{}, changes *[]*documentChange) {
+	tokens := func(vs []interface{}) []string {
+		result := make([]string, len(vs))
+		for i, v := range vs {
+			result[i] = documentValue(v)
+		}
+		return result
+	}
+
+	ops := diffTokens(tokens(before), tokens(after))
+	i, j := 0, 0
+	for k := 0; k < len(ops); {
+		if ops[k].Kind == diffEqual {
+			i, j, k = i+1, j+1, k+1
+			continue
+		}
+
+		// A run of removals followed by a run of insertions replaces the
+		// removed elements with the inserted ones, pairwise.
+		var removed, inserted int
+		for k < len(ops) && ops[k].Kind == diffDelete {
+			removed, k = removed+1, k+1
+		}
+		for k < len(ops) && ops[k].Kind == diffInsert {
+			inserted, k = inserted+1, k+1
+		}
+		for n := 0; n < removed || n < inserted; n++ {
+			switch {
+			case n < removed && n < inserted:
+				diffDocuments(fmt.Sprintf("%s[%d]", path, j+n), before[i+n], after[j+n], changes)
+			case n < removed:
+				*changes = append(*changes, &documentChange{
+					Path: fmt.Sprintf("%s[%d]", path, i+n), Before: before[i+n], Removed: true,
+				})
+			default:
+				*changes = append(*changes, &documentChange{
+					Path: fmt.Sprintf("%s[%d]", path, j+n), After: after[j+n], Added: true,
+				})
+			}
+		}
+		i, j = i+removed, j+inserted
+	}
+}
+
+// documentKeyRe matches the keys that can be shown in a path without being
+// quoted.
+var documentKeyRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+func documentKeyPath(path, key string) string {
+	if !documentKeyRe.MatchString(key) {
+		return fmt.Sprintf("%s[%s]", path, strconv.Quote(key))
+	}
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// documentValue returns a decoded value as compact JSON.
+func documentValue(v interface{}) string {
+	buf := new(bytes.Buffer)
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// decodeJSONDocument decodes a string that's a JSON object or array.
+func decodeJSONDocument(s string) (interface{}, bool) {
+	trimmed := strings.TrimSpace(s)
+	if !strings.HasPrefix(trimmed, "{") && !strings.HasPrefix(trimmed, "[") {
+		return nil, false
+	}
+
+	dec := json.NewDecoder(strings.NewReader(trimmed))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil || dec.More() {
+		return nil, false
+	}
+	return v, true
+}
+
+// decodeYAMLDocument decodes a string that's a YAML mapping or sequence,
+// with the keys of its mappings converted to strings so that it can be
+// compared with decoded JSON.
+func decodeYAMLDocument(s string) (interface{}, bool) {
+	var v interface{}
+	if err := yaml.Unmarshal([]byte(s), &v); err != nil {
+		return nil, false
+	}
+	v = normalizeYAML(v)
+	switch v.(type) {
+	case map[string]interface{}, []interface{}:
+		return v, true
+	default:
+		return nil, false
+	}
+}
+
+func normalizeYAML(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			result[fmt.Sprintf("%v", k)] = normalizeYAML(e)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, e := range v {
+			result[i] = normalizeYAML(e)
+		}
+		return result
+	default:
+		return v
+	}
+}
@@ -0,0 +1,109 @@
+package format
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatStructuralDiff(t *testing.T) {
+	cases := map[string]struct {
+		Before, After string
+		Expected      string
+	}{
+		"not documents": {
+			"foo", "bar",
+			"",
+		},
+		"json and text": {
+			`{"a": 1}`, "some\ntext",
+			"",
+		},
+		"single line yaml": {
+			"Note: foo", "Note: bar",
+			"",
+		},
+		"json reformatted": {
+			`{"b": 1, "a": [1, 2]}`,
+			"{\n  \"a\": [1, 2],\n  \"b\": 1\n}",
+			"(only the JSON formatting changed)",
+		},
+		"json": {
+			`{"Version":"2012-10-17","Statement":[{"Sid":"Read","Effect":"Allow","Action":"s3:GetObject","Resource":"*"},{"Sid":"List","Effect":"Allow","Action":"s3:ListBucket","Resource":"*"}]}`,
+			`{"Version":"2012-10-17","Statement":[{"Sid":"Read","Effect":"Allow","Action":"s3:*","Resource":"*","Condition":{"IpAddress":{"aws:SourceIp":"10.0.0.0/8"}}},{"Sid":"List","Effect":"Allow","Action":"s3:ListBucket","Resource":"*"}]}`,
+			strings.Join([]string{
+				"(JSON document changed)",
+				`        ~ Statement[0].Action: "s3:GetObject" => "s3:*"`,
+				`        + Statement[0].Condition: {"IpAddress":{"aws:SourceIp":"10.0.0.0/8"}}`,
+			}, "\n"),
+		},
+		"json array insert": {
+			`[{"name":"a"},{"name":"b"}]`,
+			`[{"name":"z"},{"name":"a"},{"name":"b"}]`,
+			strings.Join([]string{
+				"(JSON document changed)",
+				`        + [0]: {"name":"z"}`,
+			}, "\n"),
+		},
+		"json array remove": {
+			`{"ports":[80,443,8080]}`,
+			`{"ports":[80,8080]}`,
+			strings.Join([]string{
+				"(JSON document changed)",
+				`        - ports[1]: 443`,
+			}, "\n"),
+		},
+		"json type change": {
+			`{"a":{"b":1}}`,
+			`{"a":[1]}`,
+			strings.Join([]string{
+				"(JSON document changed)",
+				`        ~ a: {"b":1} => [1]`,
+			}, "\n"),
+		},
+		"yaml": {
+			"apiVersion: v1\nkind: ConfigMap\ndata:\n  level: info\n  old: true\n",
+			"apiVersion: v1\nkind: ConfigMap\ndata:\n  level: debug\n",
+			strings.Join([]string{
+				"(YAML document changed)",
+				`        ~ data.level: "info" => "debug"`,
+				`        - data.old: true`,
+			}, "\n"),
+		},
+		"yaml reformatted": {
+			"a: 1\nb: [x, y]\n",
+			"b:\n  - x\n  - y\na: 1\n",
+			"(only the YAML formatting changed)",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			actual, ok := formatStructuralDiff(tc.Before, tc.After, 8, disabledColorize)
+			if ok != (tc.Expected != "") {
+				t.Fatalf("wrong ok %t: %q", ok, actual)
+			}
+			if actual != tc.Expected {
+				t.Fatalf("wrong diff\ngot:\n%s\nwant:\n%s", actual, tc.Expected)
+			}
+		})
+	}
+}
+
+func TestFormatStringDiff_structural(t *testing.T) {
+	before := `{"a":1,"b":2}`
+	after := `{"a":1,"b":3}`
+
+	actual, ok := formatStringDiff(before, after, true, 8, disabledColorize)
+	if !ok {
+		t.Fatal("expected a diff of a short JSON document")
+	}
+	expected := "(JSON document changed)\n        ~ b: 2 => 3"
+	if actual != expected {
+		t.Fatalf("wrong diff\ngot:\n%s\nwant:\n%s", actual, expected)
+	}
+
+	// Short documents aren't diffed at all without the structural diff.
+	if actual, ok := formatStringDiff(before, after, false, 8, disabledColorize); ok {
+		t.Fatalf("unexpected diff: %q", actual)
+	}
+}
@@ -8,6 +8,8 @@ import (
 	"github.com/hashicorp/terraform/tfdiags"
 
 	"github.com/hashicorp/terraform/backend"
+	"github.com/hashicorp/terraform/command/jsongraph"
+	"github.com/hashicorp/terraform/command/jsonplan"
 	"github.com/hashicorp/terraform/config"
 	"github.com/hashicorp/terraform/config/module"
 	"github.com/hashicorp/terraform/dag"
@@ -25,6 +27,8 @@ func (c *GraphCommand) Run(args []string) int {
 	var verbose bool
 	var drawCycles bool
 	var graphTypeStr string
+	var moduleFilter, typeFilter string
+	var jsonOutput bool
 
 	args, err := c.Meta.process(args, false)
 	if err != nil {
@@ -36,11 +40,23 @@ func (c *GraphCommand) Run(args []string) int {
 	cmdFlags.BoolVar(&verbose, "verbose", false, "verbose")
 	cmdFlags.BoolVar(&drawCycles, "draw-cycles", false, "draw-cycles")
 	cmdFlags.StringVar(&graphTypeStr, "type", "", "type")
+	cmdFlags.StringVar(&moduleFilter, "module", "", "module")
+	cmdFlags.StringVar(&typeFilter, "resource-type", "", "resource-type")
+	cmdFlags.BoolVar(&jsonOutput, "json", false, "json")
 	cmdFlags.Usage = func() { c.Ui.Error(c.Help()) }
 	if err := cmdFlags.Parse(args); err != nil {
 		return 1
 	}
 
+	var modulePath []string
+	if moduleFilter != "" {
+		modulePath, err = parseModuleAddress(moduleFilter)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Invalid -module: %s", err))
+			return 1
+		}
+	}
+
 	configPath, err := ModulePath(cmdFlags.Args())
 	if err != nil {
 		c.Ui.Error(err.Error())
@@ -139,11 +155,42 @@ func (c *GraphCommand) Run(args []string) int {
 		return 1
 	}
 
-	graphStr, err := terraform.GraphDot(g, &dag.DotOpts{
+	dotOpts := &dag.DotOpts{
 		DrawCycles: drawCycles,
 		MaxDepth:   moduleDepth,
 		Verbose:    verbose,
-	})
+	}
+
+	// The resources of a plan are labeled with their planned actions.
+	var actions map[string][]string
+	if plan != nil {
+		actions = plannedActions(plan)
+		dotOpts.DecorateNode = func(v dag.Vertex, node *dag.DotNode) {
+			decoratePlannedAction(v, node, actions)
+		}
+	}
+
+	// The JSON output only has the vertices that would be drawn, and with
+	// filters the graph is reduced to the matching vertices, keeping the
+	// dependencies between them.
+	if jsonOutput || modulePath != nil || typeFilter != "" {
+		g = filterGraph(g, func(v dag.Vertex) bool {
+			dn, ok := v.(dag.GraphNodeDotter)
+			if !ok || dn.DotNode(dag.VertexName(v), dotOpts) == nil {
+				return false
+			}
+			return graphVertexMatches(v, modulePath, typeFilter)
+		})
+	}
+
+	var graphStr string
+	if jsonOutput {
+		var out []byte
+		out, err = jsongraph.Marshal(g, graphTypeName(graphType), actions)
+		graphStr = string(out)
+	} else {
+		graphStr, err = terraform.GraphDot(g, dotOpts)
+	}
 	if err != nil {
 		c.Ui.Error(fmt.Sprintf("Error converting graph: %s", err))
 		return 1
@@ -162,6 +209,143 @@ func (c *GraphCommand) Run(args []string) int {
 	return 0
 }
 
+// plannedActions returns the actions planned for the resource instances of
+// a plan, keyed by address, in the form of the JSON representation of plans.
+func plannedActions(plan *terraform.Plan) map[string][]string {
+	ret := make(map[string][]string)
+	for _, rc := range jsonplan.NewPlan(plan).ResourceChanges {
+		if rc.Deposed {
+			continue
+		}
+		ret[rc.Address] = rc.Change.Actions
+	}
+	return ret
+}
+
+// decoratePlannedAction colors the dot node of a resource with a planned
+// action, and prefixes its label with the symbol used for the action in the
+// output of plan.
+func decoratePlannedAction(v dag.Vertex, node *dag.DotNode, actions map[string][]string) {
+	r, ok := v.(terraform.GraphNodeResource)
+	if !ok || r.ResourceAddr() == nil {
+		return
+	}
+	action := strings.Join(actions[jsongraph.ResourceAddress(r.ResourceAddr())], "/")
+
+	var symbol, color string
+	switch action {
+	case "create":
+		symbol, color = "+", "green"
+	case "read":
+		symbol, color = "<=", "cyan"
+	case "update":
+		symbol, color = "~", "orange"
+	case "delete":
+		symbol, color = "-", "red"
+	case "delete/create":
+		symbol, color = "-/+", "purple"
+	default:
+		return
+	}
+
+	label := node.Attrs["label"]
+	if label == "" {
+		label = node.Name
+	}
+	node.Attrs["label"] = symbol + " " + label
+	node.Attrs["color"] = color
+	node.Attrs["fontcolor"] = color
+}
+
+// graphVertexMatches returns true if a vertex belongs to the module with the
+// given path or one of its descendents, and is a resource of the given type.
+// An empty filter matches any vertex.
+func graphVertexMatches(v dag.Vertex, modulePath []string, resourceType string) bool {
+	if modulePath != nil {
+		path := jsongraph.ModulePath(v)
+		if len(path) < len(modulePath) {
+			return false
+		}
+		for i, name := range modulePath {
+			if path[i] != name {
+				return false
+			}
+		}
+	}
+
+	if resourceType != "" {
+		r, ok := v.(terraform.GraphNodeResource)
+		if !ok || r.ResourceAddr() == nil || r.ResourceAddr().Type != resourceType {
+			return false
+		}
+	}
+
+	return true
+}
+
+// filterGraph returns a graph of the vertices of g for which keep returns
+// true. Two of them are connected if g has a path between them through
+// vertices that aren't kept, so the dependencies between the kept vertices
+// are retained.
+func filterGraph(g *terraform.Graph, keep func(dag.Vertex) bool) *terraform.Graph {
+	ret := &terraform.Graph{Path: g.Path}
+	for _, v := range g.Vertices() {
+		if keep(v) {
+			ret.Add(v)
+		}
+	}
+
+	for _, v := range ret.Vertices() {
+		seen := make(map[dag.Vertex]struct{})
+		stack := g.DownEdges(v).List()
+		for len(stack) > 0 {
+			dep := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if _, ok := seen[dep]; ok {
+				continue
+			}
+			seen[dep] = struct{}{}
+
+			if ret.HasVertex(dep) {
+				ret.Connect(dag.BasicEdge(v, dep))
+				continue
+			}
+			stack = append(stack, g.DownEdges(dep).List()...)
+		}
+	}
+
+	ret.TransitiveReduction()
+	return ret
+}
+
+// parseModuleAddress returns the path of the module with the given address,
+// such as "module.foo.module.bar".
+func parseModuleAddress(addr string) ([]string, error) {
+	parts := strings.Split(addr, ".")
+	if len(parts)%2 != 0 {
+		return nil, fmt.Errorf("%q is not a module address, such as module.foo", addr)
+	}
+
+	path := []string{terraform.RootModuleName}
+	for i := 0; i < len(parts); i += 2 {
+		if parts[i] != "module" || parts[i+1] == "" {
+			return nil, fmt.Errorf("%q is not a module address, such as module.foo", addr)
+		}
+		path = append(path, parts[i+1])
+	}
+	return path, nil
+}
+
+// graphTypeName returns the name of a graph type, as given to -type.
+func graphTypeName(t terraform.GraphType) string {
+	for name, v := range terraform.GraphTypeMap {
+		if v == t {
+			return name
+		}
+	}
+	return t.String()
+}
+
 func (c *GraphCommand) Help() string {
 	helpText := `
 Usage: terraform graph [options] [DIR]
@@ -179,15 +363,31 @@ Usage: terraform graph [options] [DIR]
   configuration is given, and "apply" if a plan file is passed as an
   argument.
 
+  When a plan file is given, the resources with planned changes are
+  colored and labeled with the symbol of their action, as in the output of
+  "terraform plan".
+
+  The -module and -resource-type flags reduce the graph to the matching
+  nodes, keeping the dependencies between them.
+
 Options:
 
-  -draw-cycles   Highlight any cycles in the graph with colored edges.
-                 This helps when diagnosing cycle errors.
+  -draw-cycles          Highlight any cycles in the graph with colored edges.
+                        This helps when diagnosing cycle errors.
+
+  -json                 Output the graph as JSON instead of DOT, with the
+                        nodes and edges that would be drawn.
+
+  -module=module.foo    Only show the nodes of the given module and of the
+                        modules within it.
+
+  -no-color             If specified, output won't contain any color.
 
-  -no-color      If specified, output won't contain any color.
+  -resource-type=TYPE   Only show the resources of the given type, such as
+                        aws_instance.
 
-  -type=plan     Type of graph to output. Can be: plan, plan-destroy, apply,
-                 validate, input, refresh.
+  -type=plan            Type of graph to output. Can be: plan, plan-destroy,
+                        apply, validate, input, refresh.
 
 
 `
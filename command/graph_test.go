@@ -1,10 +1,14 @@
 package command
 
 import (
+	"encoding/json"
 	"os"
+	"reflect"
 	"strings"
 	"testing"
 
+	"github.com/davecgh/go-spew/spew"
+	"github.com/hashicorp/terraform/command/jsongraph"
 	"github.com/hashicorp/terraform/terraform"
 	"github.com/mitchellh/cli"
 )
@@ -144,3 +148,171 @@ func TestGraph_plan(t *testing.T) {
 		t.Fatalf("doesn't look like digraph: %s", output)
 	}
 }
+
+func testGraphFilterPlan(t *testing.T) string {
+	return testPlanFile(t, &terraform.Plan{
+		Diff: &terraform.Diff{
+			Modules: []*terraform.ModuleDiff{
+				&terraform.ModuleDiff{
+					Path: []string{"root"},
+					Resources: map[string]*terraform.InstanceDiff{
+						"test_instance.foo": &terraform.InstanceDiff{
+							Attributes: map[string]*terraform.ResourceAttrDiff{
+								"id":  &terraform.ResourceAttrDiff{NewComputed: true, RequiresNew: true},
+								"ami": &terraform.ResourceAttrDiff{NewComputed: true},
+							},
+						},
+					},
+				},
+				&terraform.ModuleDiff{
+					Path: []string{"root", "child"},
+					Resources: map[string]*terraform.InstanceDiff{
+						"test_instance.bar": &terraform.InstanceDiff{
+							Attributes: map[string]*terraform.ResourceAttrDiff{
+								"id":  &terraform.ResourceAttrDiff{NewComputed: true, RequiresNew: true},
+								"ami": &terraform.ResourceAttrDiff{New: "bar"},
+							},
+						},
+					},
+				},
+			},
+		},
+
+		Module: testModule(t, "graph-filter"),
+	})
+}
+
+func TestGraph_planActions(t *testing.T) {
+	tmp, cwd := testCwd(t)
+	defer testFixCwd(t, tmp, cwd)
+
+	ui := new(cli.MockUi)
+	c := &GraphCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(testProvider()),
+			Ui:               ui,
+		},
+	}
+
+	args := []string{
+		testGraphFilterPlan(t),
+	}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: \n%s", ui.ErrorWriter.String())
+	}
+
+	output := ui.OutputWriter.String()
+	want := `"[root] test_instance.foo" [color = "green", fontcolor = "green", label = "+ test_instance.foo", shape = "box"]`
+	if !strings.Contains(output, want) {
+		t.Fatalf("planned action not shown in:\n%s", output)
+	}
+}
+
+func TestGraph_filter(t *testing.T) {
+	tmp, cwd := testCwd(t)
+	defer testFixCwd(t, tmp, cwd)
+
+	planPath := testGraphFilterPlan(t)
+
+	run := func(args ...string) string {
+		ui := new(cli.MockUi)
+		c := &GraphCommand{
+			Meta: Meta{
+				testingOverrides: metaOverridesForProvider(testProvider()),
+				Ui:               ui,
+			},
+		}
+		if code := c.Run(append(args, planPath)); code != 0 {
+			t.Fatalf("%v: bad: \n%s", args, ui.ErrorWriter.String())
+		}
+		return ui.OutputWriter.String()
+	}
+
+	// The dependency of the root resource on the child resource through
+	// the module output is retained.
+	output := run("-resource-type=test_instance")
+	if !strings.Contains(output, `"[root] test_instance.foo" -> "[root] module.child.test_instance.bar"`) {
+		t.Fatalf("dependency not retained in:\n%s", output)
+	}
+	if strings.Contains(output, "provider.test") {
+		t.Fatalf("provider not filtered out of:\n%s", output)
+	}
+
+	output = run("-module=module.child")
+	if !strings.Contains(output, "module.child.test_instance.bar") {
+		t.Fatalf("child resource missing from:\n%s", output)
+	}
+	if strings.Contains(output, `"[root] test_instance.foo"`) {
+		t.Fatalf("root resource not filtered out of:\n%s", output)
+	}
+}
+
+func TestGraph_filterBadModule(t *testing.T) {
+	ui := new(cli.MockUi)
+	c := &GraphCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(testProvider()),
+			Ui:               ui,
+		},
+	}
+
+	args := []string{"-module=child", testFixturePath("graph")}
+	if code := c.Run(args); code != 1 {
+		t.Fatalf("bad: %d", code)
+	}
+}
+
+func TestGraph_json(t *testing.T) {
+	tmp, cwd := testCwd(t)
+	defer testFixCwd(t, tmp, cwd)
+
+	ui := new(cli.MockUi)
+	c := &GraphCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(testProvider()),
+			Ui:               ui,
+		},
+	}
+
+	args := []string{
+		"-json",
+		"-resource-type=test_instance",
+		testGraphFilterPlan(t),
+	}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: \n%s", ui.ErrorWriter.String())
+	}
+
+	var got jsongraph.Graph
+	if err := json.Unmarshal(ui.OutputWriter.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON: %s\n\n%s", err, ui.OutputWriter.String())
+	}
+
+	want := jsongraph.Graph{
+		FormatVersion: jsongraph.FormatVersion,
+		Type:          "apply",
+		Nodes: []*jsongraph.Node{
+			{
+				ID:           "module.child.test_instance.bar",
+				Label:        "module.child.test_instance.bar",
+				Module:       "module.child",
+				Resource:     "module.child.test_instance.bar",
+				ResourceType: "test_instance",
+				Actions:      []string{"create"},
+			},
+			{
+				ID:           "test_instance.foo",
+				Label:        "test_instance.foo",
+				Resource:     "test_instance.foo",
+				ResourceType: "test_instance",
+				Actions:      []string{"create"},
+			},
+		},
+		Edges: []*jsongraph.Edge{
+			{Source: "test_instance.foo", Target: "module.child.test_instance.bar"},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong graph\ngot:  %s\nwant: %s", spew.Sdump(got), spew.Sdump(want))
+	}
+}
@@ -0,0 +1,187 @@
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/terraform/terraform"
+)
+
+// These are the points at which an ApplyHookCommand can be run, as they're
+// given in the "when" argument of apply_hook blocks and in the "hook"
+// property of the JSON the commands are given.
+const (
+	ApplyHookPre  = "pre_apply"
+	ApplyHookPost = "post_apply"
+)
+
+// ApplyHookCommand is a command that's run before or after each resource is
+// applied, such as to send notifications or to check changes against a
+// policy.
+type ApplyHookCommand struct {
+	Name string
+
+	// Args are the program to run and its arguments.
+	Args []string
+
+	PreApply  bool
+	PostApply bool
+}
+
+// ExecHook is a hook that runs commands before and after each resource is
+// applied, giving each a JSON description of the change on stdin.
+//
+// A pre_apply command that fails stops the resource from being applied,
+// so that commands can enforce policies. The failure of a post_apply
+// command is only logged, since the change has been made by then.
+type ExecHook struct {
+	terraform.NilHook
+
+	Commands []*ApplyHookCommand
+
+	l       sync.Mutex
+	actions map[string]string
+}
+
+// execHookInput is the JSON that the commands are given on stdin.
+type execHookInput struct {
+	Hook    string `json:"hook"`
+	Address string `json:"address"`
+	Action  string `json:"action"`
+	ID      string `json:"id,omitempty"`
+
+	// Planned are the new values of the attributes that are changing, for
+	// pre_apply. The values of sensitive attributes are redacted, and those
+	// that won't be known until after the apply are marked as such.
+	Planned map[string]string `json:"planned,omitempty"`
+
+	// Error is the error the apply failed with, if it did, for post_apply.
+	Error string `json:"error,omitempty"`
+}
+
+func (h *ExecHook) PreApply(
+	n *terraform.InstanceInfo,
+	s *terraform.InstanceState,
+	d *terraform.InstanceDiff) (terraform.HookAction, error) {
+	if d.Empty() {
+		return terraform.HookActionContinue, nil
+	}
+
+	action := "update"
+	if d.Destroy {
+		action = "delete"
+	} else if s == nil || s.ID == "" {
+		action = "create"
+	}
+
+	h.l.Lock()
+	if h.actions == nil {
+		h.actions = make(map[string]string)
+	}
+	h.actions[n.HumanId()] = action
+	h.l.Unlock()
+
+	input := &execHookInput{
+		Hook:    ApplyHookPre,
+		Address: n.ResourceAddress().String(),
+		Action:  action,
+		Planned: make(map[string]string),
+	}
+	if s != nil {
+		input.ID = s.ID
+	}
+	for k, attr := range d.CopyAttributes() {
+		switch {
+		case attr.NewRemoved:
+			continue
+		case attr.Sensitive:
+			input.Planned[k] = "(sensitive)"
+		case attr.NewComputed:
+			input.Planned[k] = "(known after apply)"
+		default:
+			input.Planned[k] = attr.New
+		}
+	}
+
+	for _, cmd := range h.Commands {
+		if !cmd.PreApply {
+			continue
+		}
+		if err := cmd.run(input); err != nil {
+			return terraform.HookActionHalt, fmt.Errorf(
+				"The apply_hook %q rejected the change to %s: %s", cmd.Name, input.Address, err)
+		}
+	}
+
+	return terraform.HookActionContinue, nil
+}
+
+func (h *ExecHook) PostApply(
+	n *terraform.InstanceInfo,
+	s *terraform.InstanceState,
+	applyerr error) (terraform.HookAction, error) {
+	h.l.Lock()
+	action, ok := h.actions[n.HumanId()]
+	delete(h.actions, n.HumanId())
+	h.l.Unlock()
+	if !ok {
+		// Nothing was applied.
+		return terraform.HookActionContinue, nil
+	}
+
+	input := &execHookInput{
+		Hook:    ApplyHookPost,
+		Address: n.ResourceAddress().String(),
+		Action:  action,
+	}
+	if s != nil {
+		input.ID = s.ID
+	}
+	if applyerr != nil {
+		input.Error = applyerr.Error()
+	}
+
+	for _, cmd := range h.Commands {
+		if !cmd.PostApply {
+			continue
+		}
+		if err := cmd.run(input); err != nil {
+			log.Printf("[WARN] apply_hook %q failed after applying %s: %s", cmd.Name, input.Address, err)
+		}
+	}
+
+	return terraform.HookActionContinue, nil
+}
+
+// run runs the command with the given input on stdin. The error, if the
+// command fails, includes what it wrote to stderr.
+func (c *ApplyHookCommand) run(input *execHookInput) error {
+	js, err := json.Marshal(input)
+	if err != nil {
+		return err
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(c.Args[0], c.Args[1:]...)
+	cmd.Stdin = bytes.NewReader(js)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	log.Printf("[DEBUG] running apply_hook %q for %s of %s", c.Name, input.Hook, input.Address)
+	err = cmd.Run()
+	if out := strings.TrimSpace(stdout.String()); out != "" {
+		log.Printf("[DEBUG] apply_hook %q output: %s", c.Name, out)
+	}
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%s: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
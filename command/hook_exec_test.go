@@ -0,0 +1,138 @@
+package command
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestExecHook_impl(t *testing.T) {
+	var _ terraform.Hook = new(ExecHook)
+}
+
+func TestExecHook(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the hook commands are shell scripts")
+	}
+
+	td, err := ioutil.TempDir("", "tf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(td)
+	out := filepath.Join(td, "out")
+
+	h := &ExecHook{
+		Commands: []*ApplyHookCommand{
+			{
+				Name:      "record",
+				Args:      []string{"sh", "-c", "cat >> " + out + "; echo >> " + out},
+				PreApply:  true,
+				PostApply: true,
+			},
+		},
+	}
+
+	n := &terraform.InstanceInfo{
+		Id:         "aws_instance.foo",
+		ModulePath: []string{"root", "child"},
+		Type:       "aws_instance",
+	}
+	d := &terraform.InstanceDiff{
+		Attributes: map[string]*terraform.ResourceAttrDiff{
+			"ami":      {New: "ami-123"},
+			"password": {New: "hunter2", Sensitive: true},
+			"id":       {NewComputed: true},
+		},
+	}
+
+	if _, err := h.PreApply(n, &terraform.InstanceState{}, d); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if _, err := h.PostApply(n, &terraform.InstanceState{ID: "i-abc"}, errors.New("quota exceeded")); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	data, err := ioutil.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 runs, got:\n%s", data)
+	}
+
+	var got []*execHookInput
+	for _, l := range lines {
+		input := &execHookInput{}
+		if err := json.Unmarshal([]byte(l), input); err != nil {
+			t.Fatalf("invalid JSON %q: %s", l, err)
+		}
+		got = append(got, input)
+	}
+
+	want := []*execHookInput{
+		{
+			Hook:    "pre_apply",
+			Address: "module.child.aws_instance.foo",
+			Action:  "create",
+			Planned: map[string]string{
+				"ami":      "ami-123",
+				"password": "(sensitive)",
+				"id":       "(known after apply)",
+			},
+		},
+		{
+			Hook:    "post_apply",
+			Address: "module.child.aws_instance.foo",
+			Action:  "create",
+			ID:      "i-abc",
+			Error:   "quota exceeded",
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong input\ngot:  %#v\nwant: %#v", got, want)
+	}
+}
+
+func TestExecHook_preApplyFails(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the hook commands are shell scripts")
+	}
+
+	h := &ExecHook{
+		Commands: []*ApplyHookCommand{
+			{
+				Name:     "policy",
+				Args:     []string{"sh", "-c", "echo deletions are not allowed >&2; exit 1"},
+				PreApply: true,
+			},
+		},
+	}
+
+	n := &terraform.InstanceInfo{
+		Id:         "aws_instance.foo",
+		ModulePath: []string{"root"},
+		Type:       "aws_instance",
+	}
+	d := &terraform.InstanceDiff{Destroy: true}
+
+	action, err := h.PreApply(n, &terraform.InstanceState{ID: "i-abc"}, d)
+	if err == nil {
+		t.Fatal("should have failed")
+	}
+	if action != terraform.HookActionHalt {
+		t.Fatalf("wrong action %v", action)
+	}
+	if !strings.Contains(err.Error(), "deletions are not allowed") {
+		t.Fatalf("the error should include what the command wrote to stderr: %s", err)
+	}
+}
@@ -1,7 +1,9 @@
 package command
 
 import (
+	"bytes"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"os"
 	"strings"
@@ -9,10 +11,12 @@ import (
 	"github.com/hashicorp/hcl2/hcl"
 
 	"github.com/hashicorp/terraform/backend"
+	"github.com/hashicorp/terraform/command/jsonstate"
 	"github.com/hashicorp/terraform/config"
 	"github.com/hashicorp/terraform/config/module"
 	"github.com/hashicorp/terraform/terraform"
 	"github.com/hashicorp/terraform/tfdiags"
+	"github.com/posener/complete"
 )
 
 // ImportCommand is a cli.Command implementation that imports resources
@@ -29,7 +33,7 @@ func (c *ImportCommand) Run(args []string) int {
 		return 1
 	}
 
-	var configPath string
+	var configPath, importFile, generateConfigPath string
 	args, err = c.Meta.process(args, true)
 	if err != nil {
 		return 1
@@ -42,6 +46,8 @@ func (c *ImportCommand) Run(args []string) int {
 	cmdFlags.StringVar(&c.Meta.backupPath, "backup", "", "path")
 	cmdFlags.StringVar(&configPath, "config", pwd, "path")
 	cmdFlags.StringVar(&c.Meta.provider, "provider", "", "provider")
+	cmdFlags.StringVar(&importFile, "file", "", "file")
+	cmdFlags.StringVar(&generateConfigPath, "generate-config-out", "", "path")
 	cmdFlags.BoolVar(&c.Meta.stateLock, "lock", true, "lock state")
 	cmdFlags.DurationVar(&c.Meta.stateLockTimeout, "lock-timeout", 0, "lock timeout")
 	cmdFlags.BoolVar(&c.Meta.allowMissingConfig, "allow-missing-config", false, "allow missing config")
@@ -50,28 +56,66 @@ func (c *ImportCommand) Run(args []string) int {
 		return 1
 	}
 
+	// The resources to import are either given as arguments, or many of
+	// them in a file.
+	var targets []*terraform.ImportTarget
 	args = cmdFlags.Args()
-	if len(args) != 2 {
-		c.Ui.Error("The import command expects two arguments.")
-		cmdFlags.Usage()
-		return 1
-	}
+	if importFile != "" {
+		if len(args) != 0 {
+			c.Ui.Error("The import command expects no arguments when -file is given.")
+			cmdFlags.Usage()
+			return 1
+		}
 
-	// Validate the provided resource address for syntax
-	addr, err := terraform.ParseResourceAddress(args[0])
-	if err != nil {
-		c.Ui.Error(fmt.Sprintf(importCommandInvalidAddressFmt, err))
-		return 1
+		targets, err = loadImportFile(importFile, c.Meta.provider)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error loading import file: %s", err))
+			return 1
+		}
+	} else {
+		if len(args) != 2 {
+			c.Ui.Error("The import command expects two arguments.")
+			cmdFlags.Usage()
+			return 1
+		}
+
+		targets = []*terraform.ImportTarget{
+			&terraform.ImportTarget{
+				Addr:     args[0],
+				ID:       args[1],
+				Provider: c.Meta.provider,
+			},
+		}
 	}
-	if !addr.HasResourceSpec() {
-		// module.foo target isn't allowed for import
-		c.Ui.Error(importCommandMissingResourceSpecMsg)
-		return 1
+
+	// Generated configuration is never written over existing files, which
+	// may have configuration of their own.
+	if generateConfigPath != "" {
+		if _, err := os.Stat(generateConfigPath); err == nil {
+			c.Ui.Error(fmt.Sprintf(importCommandGenerateConfigExistsFmt, generateConfigPath))
+			return 1
+		}
 	}
-	if addr.Mode != config.ManagedResourceMode {
-		// can't import to a data resource address
-		c.Ui.Error(importCommandResourceModeMsg)
-		return 1
+
+	// Validate the provided resource addresses for syntax
+	addrs := make([]*terraform.ResourceAddress, len(targets))
+	for i, target := range targets {
+		addr, err := terraform.ParseResourceAddress(target.Addr)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf(importCommandInvalidAddressFmt, err))
+			return 1
+		}
+		if !addr.HasResourceSpec() {
+			// module.foo target isn't allowed for import
+			c.Ui.Error(importCommandMissingResourceSpecMsg)
+			return 1
+		}
+		if addr.Mode != config.ManagedResourceMode {
+			// can't import to a data resource address
+			c.Ui.Error(importCommandResourceModeMsg)
+			return 1
+		}
+		addrs[i] = addr
 	}
 
 	var diags tfdiags.Diagnostics
@@ -101,50 +145,67 @@ func (c *ImportCommand) Run(args []string) int {
 		}
 	}
 
-	// Verify that the given address points to something that exists in config.
-	// This is to reduce the risk that a typo in the resource address will
-	// import something that Terraform will want to immediately destroy on
-	// the next plan, and generally acts as a reassurance of user intent.
-	targetMod := mod.Child(addr.Path)
-	if targetMod == nil {
-		modulePath := addr.WholeModuleAddress().String()
-		diags = diags.Append(&hcl.Diagnostic{
-			Severity: hcl.DiagError,
-			Summary:  "Import to non-existent module",
-			Detail: fmt.Sprintf(
-				"%s is not defined in the configuration. Please add configuration for this module before importing into it.",
-				modulePath,
-			),
-		})
-		c.showDiagnostics(diags)
-		return 1
-	}
-	rcs := targetMod.Config().Resources
-	var rc *config.Resource
-	for _, thisRc := range rcs {
-		if addr.MatchesConfig(targetMod, thisRc) {
-			rc = thisRc
-			break
+	// Verify that the given addresses point to something that exists in
+	// config. This is to reduce the risk that a typo in a resource address
+	// will import something that Terraform will want to immediately destroy
+	// on the next plan, and generally acts as a reassurance of user intent.
+	// With -generate-config-out, the configuration of the resources that
+	// have none is generated instead.
+	missingConfig := false
+	var generate []int
+	for i, addr := range addrs {
+		targetMod := mod.Child(addr.Path)
+		if targetMod == nil {
+			modulePath := addr.WholeModuleAddress().String()
+			diags = diags.Append(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Import to non-existent module",
+				Detail: fmt.Sprintf(
+					"%s is not defined in the configuration. Please add configuration for this module before importing into it.",
+					modulePath,
+				),
+			})
+			c.showDiagnostics(diags)
+			return 1
 		}
-	}
-	if !c.Meta.allowMissingConfig && rc == nil {
-		modulePath := addr.WholeModuleAddress().String()
-		if modulePath == "" {
-			modulePath = "the root module"
+		rcs := targetMod.Config().Resources
+		var rc *config.Resource
+		for _, thisRc := range rcs {
+			if addr.MatchesConfig(targetMod, thisRc) {
+				rc = thisRc
+				break
+			}
+		}
+		if rc == nil && generateConfigPath != "" {
+			if len(addr.Path) > 0 || addr.Index != -1 {
+				c.Ui.Error(fmt.Sprintf(importCommandGenerateConfigAddrFmt, addr))
+				return 1
+			}
+			generate = append(generate, i)
+			continue
 		}
+		if rc == nil {
+			missingConfig = true
+		}
+		if !c.Meta.allowMissingConfig && rc == nil {
+			modulePath := addr.WholeModuleAddress().String()
+			if modulePath == "" {
+				modulePath = "the root module"
+			}
 
-		c.showDiagnostics(diags)
+			c.showDiagnostics(diags)
 
-		// This is not a diagnostic because currently our diagnostics printer
-		// doesn't support having a code example in the detail, and there's
-		// a code example in this message.
-		// TODO: Improve the diagnostics printer so we can use it for this
-		// message.
-		c.Ui.Error(fmt.Sprintf(
-			importCommandMissingResourceFmt,
-			addr, modulePath, addr.Type, addr.Name,
-		))
-		return 1
+			// This is not a diagnostic because currently our diagnostics printer
+			// doesn't support having a code example in the detail, and there's
+			// a code example in this message.
+			// TODO: Improve the diagnostics printer so we can use it for this
+			// message.
+			c.Ui.Error(fmt.Sprintf(
+				importCommandMissingResourceFmt,
+				addr, modulePath, addr.Type, addr.Name,
+			))
+			return 1
+		}
 	}
 
 	// Check for user-supplied plugin path
@@ -184,19 +245,20 @@ func (c *ImportCommand) Run(args []string) int {
 		return 1
 	}
 
-	// Perform the import. Note that as you can see it is possible for this
-	// API to import more than one resource at once. For now, we only allow
-	// one while we stabilize this feature.
+	// Resources that are already in the state can't be imported, so
+	// remember which those are to tell what each import did.
+	existing := make([]bool, len(addrs))
+	for i, addr := range addrs {
+		existing[i] = stateHasResource(ctx.State(), addr)
+	}
+
+	// Perform the import. The resources are imported concurrently, as many
+	// at a time as allowed by -parallelism. If some of them fail, the
+	// others still complete and the state has whatever was imported.
 	newState, err := ctx.Import(&terraform.ImportOpts{
-		Targets: []*terraform.ImportTarget{
-			&terraform.ImportTarget{
-				Addr:     args[0],
-				ID:       args[1],
-				Provider: c.Meta.provider,
-			},
-		},
+		Targets: targets,
 	})
-	if err != nil {
+	if err != nil && importFile == "" {
 		diags = diags.Append(err)
 		c.showDiagnostics(diags)
 		return 1
@@ -213,9 +275,33 @@ func (c *ImportCommand) Run(args []string) int {
 		return 1
 	}
 
-	c.Ui.Output(c.Colorize().Color("[reset][green]\n" + importCommandSuccessMsg))
+	resultMsg := "[reset][green]\n" + importCommandSuccessMsg
+	if importFile != "" {
+		imported := c.showImportSummary(targets, addrs, existing, newState)
+		if err != nil {
+			diags = diags.Append(err)
+		}
+		if imported == 0 {
+			c.showDiagnostics(diags)
+			return 1
+		}
+		if imported < len(targets) {
+			resultMsg = "[reset][yellow]\n" + importCommandPartialMsg
+		}
+	}
+
+	c.Ui.Output(c.Colorize().Color(resultMsg))
 
-	if c.Meta.allowMissingConfig && rc == nil {
+	if len(generate) > 0 {
+		if err := c.writeImportConfig(generateConfigPath, targets, addrs, existing, generate, newState); err != nil {
+			c.Ui.Error(fmt.Sprintf("Error writing generated configuration: %s", err))
+			return 1
+		}
+		c.Ui.Output(c.Colorize().Color(fmt.Sprintf(
+			"[reset][yellow]\n"+importCommandGeneratedConfigFmt, generateConfigPath)))
+	}
+
+	if c.Meta.allowMissingConfig && missingConfig {
 		c.Ui.Output(c.Colorize().Color("[reset][yellow]\n" + importCommandAllowMissingResourceMsg))
 	}
 
@@ -227,9 +313,113 @@ func (c *ImportCommand) Run(args []string) int {
 	return 0
 }
 
+// showImportSummary outputs whether each of the resources of an import
+// file was imported, and returns how many were.
+func (c *ImportCommand) showImportSummary(
+	targets []*terraform.ImportTarget,
+	addrs []*terraform.ResourceAddress,
+	existing []bool,
+	state *terraform.State) int {
+	var buf bytes.Buffer
+	imported := 0
+	for i, target := range targets {
+		status := "[red]failed"
+		switch {
+		case existing[i]:
+			status = "[red]failed, already managed by Terraform"
+		case stateHasResource(state, addrs[i]):
+			status = "[green]imported"
+			imported++
+		}
+		fmt.Fprintf(&buf, "  %s (id: %s): %s[reset]\n", target.Addr, target.ID, status)
+	}
+
+	c.Ui.Output(c.Colorize().Color(fmt.Sprintf(
+		"[reset][bold]\nImport summary:[reset] %d imported, %d failed.\n\n%s",
+		imported, len(targets)-imported, buf.String())))
+	return imported
+}
+
+// writeImportConfig writes the generated configuration of the resources
+// that were imported to the targets with the given indexes to path, unless
+// none of them were.
+func (c *ImportCommand) writeImportConfig(
+	path string,
+	targets []*terraform.ImportTarget,
+	addrs []*terraform.ResourceAddress,
+	existing []bool,
+	generate []int,
+	state *terraform.State) error {
+	providers := make(map[string]string)
+	for _, i := range generate {
+		if existing[i] || !stateHasResource(state, addrs[i]) {
+			continue
+		}
+		addr := addrs[i].String()
+		providers[addr] = ""
+		if p := targets[i].Provider; p != config.ResourceProviderFullName(addrs[i].Type, "") {
+			providers[addr] = p
+		}
+	}
+	if len(providers) == 0 {
+		return nil
+	}
+
+	var rs []*jsonstate.Resource
+	for _, r := range jsonstate.Resources(state) {
+		if _, ok := providers[r.Address]; ok && !r.Deposed {
+			rs = append(rs, r)
+		}
+	}
+
+	schemas := resourceSchemas(c.contextOpts().ProviderResolver, state, rs)
+	src, err := generateImportConfig(rs, schemas, providers)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, src, 0644)
+}
+
+// stateHasResource returns true if the state has a resource at addr.
+func stateHasResource(state *terraform.State, addr *terraform.ResourceAddress) bool {
+	if state == nil {
+		return false
+	}
+	results, err := (&terraform.StateFilter{State: state}).Filter(addr.String())
+	return err == nil && len(results) > 0
+}
+
+func (c *ImportCommand) AutocompleteArgs() complete.Predictor {
+	return completePredictSequence{
+		c.completePredictConfigResourceAddress(),
+		complete.PredictNothing, // the ID can't be predicted
+	}
+}
+
+func (c *ImportCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{
+		"-allow-missing-config": complete.PredictNothing,
+		"-backup":               complete.PredictFiles("*"),
+		"-config":               complete.PredictDirs(""),
+		"-file":                 complete.PredictFiles("*"),
+		"-generate-config-out":  complete.PredictFiles("*.tf"),
+		"-input":                completePredictBoolean,
+		"-lock":                 completePredictBoolean,
+		"-lock-timeout":         complete.PredictAnything,
+		"-no-color":             complete.PredictNothing,
+		"-parallelism":          complete.PredictAnything,
+		"-provider":             complete.PredictAnything,
+		"-state":                complete.PredictFiles("*.tfstate"),
+		"-state-out":            complete.PredictFiles("*.tfstate"),
+		"-var":                  complete.PredictAnything,
+		"-var-file":             complete.PredictFiles("*.tfvars"),
+	}
+}
+
 func (c *ImportCommand) Help() string {
 	helpText := `
 Usage: terraform import [options] ADDR ID
+       terraform import [options] -file=FILE
 
   Import existing infrastructure into your Terraform state.
 
@@ -244,13 +434,15 @@ Usage: terraform import [options] ADDR ID
   determine the ID syntax to use. It typically matches directly to the ID
   that the provider uses.
 
-  The current implementation of Terraform import can only import resources
-  into the state. It does not generate configuration. A future version of
-  Terraform will also generate configuration.
+  Many resources can be imported at once with -file, from an HCL or JSON
+  file with an import block for each, which sets the "to" address, the
+  "id", and optionally the "provider" to use. The resources are imported
+  concurrently, and a summary shows which of them were imported. If some
+  fail, the others are still imported.
 
-  Because of this, prior to running terraform import it is necessary to write
-  a resource configuration block for the resource manually, to which the
-  imported object will be attached.
+  Prior to running terraform import it is necessary to write a resource
+  configuration block for the resource, to which the imported object will
+  be attached, unless -generate-config-out is given to generate it.
 
   This command will not modify your infrastructure, but it will make
   network requests to inspect parts of your infrastructure relevant to
@@ -269,6 +461,17 @@ Options:
 
   -allow-missing-config   Allow import when no resource configuration block exists.
 
+  -file=path              Import the resources described by the import blocks
+                          of the given file, instead of a single resource given
+                          as arguments.
+
+  -generate-config-out=path
+                          Write configuration for the imported resources that
+                          have none to the given new file, setting the
+                          arguments of each resource to the values of the
+                          imported object. Only resources of the root module
+                          without a count index are supported.
+
   -input=true             Ask for input for variables if not directly set.
 
   -lock=true              Lock the state file when locking is supported.
@@ -277,9 +480,14 @@ Options:
 
   -no-color               If specified, output won't contain any color.
 
+  -parallelism=n          Limit the number of resources imported concurrently
+                          with -file. Defaults to 10.
+
   -provider=provider      Specific provider to use for import. This is used for
                           specifying aliases, such as "aws.eu". Defaults to the
                           normal provider prefix of the resource being imported.
+                          With -file, this is the provider of the import blocks
+                          that don't set one.
 
   -state=PATH             Path to the source state file. Defaults to the configured
                           backend, or "terraform.tfstate"
@@ -337,6 +545,33 @@ The resources that were imported are shown above. These resources are now in
 your Terraform state and will henceforth be managed by Terraform.
 `
 
+const importCommandPartialMsg = `Import partially successful.
+
+The resources that were imported are shown above. These resources are now in
+your Terraform state and will henceforth be managed by Terraform. The resources
+that failed to import are not, so fix the problems shown and import them again.
+`
+
+const importCommandGenerateConfigExistsFmt = `Error: %s already exists
+
+Generated configuration is only written to new files, so that it doesn't
+overwrite any configuration. Please give the path of a file that doesn't exist.
+`
+
+const importCommandGenerateConfigAddrFmt = `Error: can't generate configuration for %s
+
+Configuration can only be generated for resources of the root module without
+a count index. Please write the configuration of this resource by hand before
+importing it.
+`
+
+const importCommandGeneratedConfigFmt = `Configuration for the imported resources that had none was written to %s.
+
+Review it before running "terraform plan": it sets the arguments of the
+resources to the values of the imported objects, which may include values
+that are better left to their defaults or set from variables.
+`
+
 const importCommandAllowMissingResourceMsg = `Import does not generate resource configuration, you must create a resource
 configuration block that matches the current or desired state manually.
 
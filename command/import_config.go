@@ -0,0 +1,177 @@
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/hcl/hcl/printer"
+	"github.com/hashicorp/terraform/command/jsonstate"
+	"github.com/hashicorp/terraform/config/configschema"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+// generateImportConfig returns configuration for the imported resource
+// instances rs, with a resource block for each that sets the attributes that
+// can be set in configuration to their imported values. The schemas are the
+// provider schemas returned by resourceSchemas, and providers maps the
+// addresses of the instances to the providers they were imported with, if
+// those aren't the default providers of their types.
+func generateImportConfig(
+	rs []*jsonstate.Resource,
+	schemas map[string]*terraform.ProviderSchema,
+	providers map[string]string) ([]byte, error) {
+	var buf bytes.Buffer
+	for i, r := range rs {
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+
+		fmt.Fprintf(&buf, "resource %q %q {\n", r.Type, r.Name)
+		if p := providers[r.Address]; p != "" {
+			fmt.Fprintf(&buf, "provider = %q\n", p)
+		}
+
+		schema := resourceSchema(schemas, r)
+		if schema == nil {
+			buf.WriteString(
+				"# The schema of this resource type isn't available, so its\n" +
+					"# arguments must be written by hand.\n")
+		} else {
+			writeConfigBlock(&buf, jsonstate.AttributeValues(r.Attributes, schema), schema)
+		}
+		buf.WriteString("}\n")
+	}
+
+	return printer.Format(buf.Bytes())
+}
+
+// writeConfigBlock writes the body of a block with the given values, leaving
+// out the attributes that can't be set in configuration and those that are
+// null.
+func writeConfigBlock(buf *bytes.Buffer, values map[string]interface{}, schema *configschema.Block) {
+	for _, name := range sortedKeys(values) {
+		attr, ok := schema.Attributes[name]
+		if !ok || name == "id" || !(attr.Required || attr.Optional) || values[name] == nil {
+			continue
+		}
+		fmt.Fprintf(buf, "%s = ", name)
+		writeConfigValue(buf, values[name])
+		buf.WriteString("\n")
+	}
+
+	var blockNames []string
+	for name := range schema.BlockTypes {
+		blockNames = append(blockNames, name)
+	}
+	sort.Strings(blockNames)
+
+	for _, name := range blockNames {
+		nested := schema.BlockTypes[name]
+		switch v := values[name].(type) {
+		case map[string]interface{}:
+			if nested.Nesting != configschema.NestingMap {
+				writeConfigNestedBlock(buf, name, "", v, &nested.Block)
+				continue
+			}
+			for _, key := range sortedKeys(v) {
+				body, _ := v[key].(map[string]interface{})
+				writeConfigNestedBlock(buf, name, key, body, &nested.Block)
+			}
+		case []interface{}:
+			for _, e := range v {
+				body, _ := e.(map[string]interface{})
+				writeConfigNestedBlock(buf, name, "", body, &nested.Block)
+			}
+		}
+	}
+}
+
+// writeConfigNestedBlock writes a nested block, with a label if the label
+// isn't empty.
+func writeConfigNestedBlock(buf *bytes.Buffer, name, label string, values map[string]interface{}, schema *configschema.Block) {
+	buf.WriteString("\n" + name)
+	if label != "" {
+		fmt.Fprintf(buf, " %s", hclLiteralString(label))
+	}
+	buf.WriteString(" {\n")
+	writeConfigBlock(buf, values, schema)
+	buf.WriteString("}\n")
+}
+
+// writeConfigValue writes a value decoded by jsonstate.AttributeValues as
+// an HCL expression.
+func writeConfigValue(buf *bytes.Buffer, v interface{}) {
+	switch v := v.(type) {
+	case []interface{}:
+		buf.WriteString("[")
+		for i, e := range v {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+			writeConfigValue(buf, e)
+		}
+		buf.WriteString("]")
+	case map[string]interface{}:
+		buf.WriteString("{\n")
+		for _, k := range sortedKeys(v) {
+			if hclIdentifier.MatchString(k) {
+				buf.WriteString(k)
+			} else {
+				buf.WriteString(hclLiteralString(k))
+			}
+			buf.WriteString(" = ")
+			writeConfigValue(buf, v[k])
+			buf.WriteString("\n")
+		}
+		buf.WriteString("}")
+	case json.Number:
+		buf.WriteString(v.String())
+	case bool:
+		buf.WriteString(strconv.FormatBool(v))
+	case string:
+		buf.WriteString(hclLiteralString(v))
+	case nil:
+		// Null elements of collections can't be written in configuration,
+		// so the closest is an empty string.
+		buf.WriteString(`""`)
+	default:
+		buf.WriteString(hclLiteralString(fmt.Sprint(v)))
+	}
+}
+
+// hclIdentifier matches the keys of maps that don't need to be quoted.
+var hclIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// hclLiteralString quotes s as an HCL string whose value is exactly s,
+// escaping the sequences that would otherwise start interpolations.
+func hclLiteralString(s string) string {
+	var buf bytes.Buffer
+	buf.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '"', '\\':
+			buf.WriteByte('\\')
+			buf.WriteByte(c)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\t':
+			buf.WriteString(`\t`)
+		case '$':
+			buf.WriteByte('$')
+			if strings.HasPrefix(s[i+1:], "{") {
+				buf.WriteByte('$')
+			}
+		default:
+			buf.WriteByte(c)
+		}
+	}
+	buf.WriteByte('"')
+	return buf.String()
+}
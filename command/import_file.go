@@ -0,0 +1,79 @@
+package command
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	hcl1 "github.com/hashicorp/hcl"
+	"github.com/hashicorp/hcl/hcl/ast"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+// rawImportEntry is an import block of an import file.
+type rawImportEntry struct {
+	To       string `hcl:"to"`
+	ID       string `hcl:"id"`
+	Provider string `hcl:"provider"`
+}
+
+// loadImportFile reads the resources to import from the file at path, which
+// is HCL or JSON with an import block per resource:
+//
+//	import {
+//	  to       = "aws_instance.web"
+//	  id       = "i-abcd1234"
+//	  provider = "aws.west" # optional
+//	}
+//
+// Entries without a provider use defaultProvider, which may be empty to use
+// the default provider of the resource type.
+func loadImportFile(path, defaultProvider string) ([]*terraform.ImportTarget, error) {
+	src, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	root, err := hcl1.Parse(string(src))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %s", path, err)
+	}
+	list, ok := root.Node.(*ast.ObjectList)
+	if !ok {
+		return nil, fmt.Errorf("%s doesn't have a root object", path)
+	}
+
+	var targets []*terraform.ImportTarget
+	seen := make(map[string]bool)
+	for i, item := range list.Filter("import").Items {
+		if len(item.Keys) > 0 {
+			return nil, fmt.Errorf("%s: import blocks don't have names", path)
+		}
+
+		var raw rawImportEntry
+		if err := hcl1.DecodeObject(&raw, item.Val); err != nil {
+			return nil, fmt.Errorf("%s: import block %d: %s", path, i+1, err)
+		}
+		if raw.To == "" || raw.ID == "" {
+			return nil, fmt.Errorf("%s: import block %d must set both \"to\" and \"id\"", path, i+1)
+		}
+		if seen[raw.To] {
+			return nil, fmt.Errorf("%s: %s is imported more than once", path, raw.To)
+		}
+		seen[raw.To] = true
+
+		provider := raw.Provider
+		if provider == "" {
+			provider = defaultProvider
+		}
+		targets = append(targets, &terraform.ImportTarget{
+			Addr:     raw.To,
+			ID:       raw.ID,
+			Provider: provider,
+		})
+	}
+
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("%s has no import blocks", path)
+	}
+	return targets, nil
+}
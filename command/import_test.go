@@ -4,14 +4,18 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
+	"github.com/hashicorp/terraform/config"
+	"github.com/hashicorp/terraform/config/configschema"
 	"github.com/hashicorp/terraform/helper/copy"
 	"github.com/hashicorp/terraform/plugin"
 	"github.com/hashicorp/terraform/plugin/discovery"
 	"github.com/hashicorp/terraform/terraform"
 	"github.com/mitchellh/cli"
+	"github.com/zclconf/go-cty/cty"
 )
 
 func TestImport(t *testing.T) {
@@ -403,6 +407,305 @@ func TestImport_customProvider(t *testing.T) {
 	testStateOutput(t, statePath, testImportCustomProviderStr)
 }
 
+func TestImport_file(t *testing.T) {
+	for _, name := range []string{"imports.hcl", "imports.json"} {
+		t.Run(name, func(t *testing.T) {
+			defer testChdir(t, testFixturePath("import-file"))()
+
+			statePath := testTempFile(t)
+
+			p := testProvider()
+			ui := new(cli.MockUi)
+			c := &ImportCommand{
+				Meta: Meta{
+					testingOverrides: metaOverridesForProvider(p),
+					Ui:               ui,
+				},
+			}
+
+			p.ImportStateFn = testImportStateByID
+
+			args := []string{
+				"-state", statePath,
+				"-file", name,
+			}
+			if code := c.Run(args); code != 0 {
+				t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+			}
+
+			output := ui.OutputWriter.String()
+			if !strings.Contains(output, "2 imported, 0 failed") {
+				t.Fatalf("wrong summary:\n%s", output)
+			}
+			if !strings.Contains(output, "Import successful!") {
+				t.Fatalf("import should be reported as successful:\n%s", output)
+			}
+
+			testStateOutput(t, statePath, testImportFileStr)
+		})
+	}
+}
+
+func TestImport_filePartialFailure(t *testing.T) {
+	defer testChdir(t, testFixturePath("import-file"))()
+
+	statePath := testTempFile(t)
+
+	p := testProvider()
+	ui := new(cli.MockUi)
+	c := &ImportCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(p),
+			Ui:               ui,
+		},
+	}
+
+	p.ImportStateFn = testImportStateByID
+
+	args := []string{
+		"-state", statePath,
+		"-file", "partial.hcl",
+	}
+	if code := c.Run(args); code != 1 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.OutputWriter.String())
+	}
+
+	output := ui.OutputWriter.String()
+	if !strings.Contains(output, "1 imported, 1 failed") {
+		t.Fatalf("wrong summary:\n%s", output)
+	}
+	if !strings.Contains(output, "test_instance.bar (id: missing): failed") {
+		t.Fatalf("summary should show the failed import:\n%s", output)
+	}
+	if strings.Contains(output, "Import successful!") || !strings.Contains(output, "Import partially successful.") {
+		t.Fatalf("import should be reported as partially successful:\n%s", output)
+	}
+	if !strings.Contains(ui.ErrorWriter.String(), "no such object") {
+		t.Fatalf("wrong error:\n%s", ui.ErrorWriter.String())
+	}
+
+	// The import that succeeded is still in the state.
+	testStateOutput(t, statePath, `
+test_instance.foo:
+  ID = foo-id
+  provider = provider.test
+`)
+}
+
+func TestImport_fileInvalid(t *testing.T) {
+	defer testChdir(t, testFixturePath("import-file"))()
+
+	cases := map[string]struct {
+		Args []string
+		Err  string
+	}{
+		"duplicate": {
+			[]string{"-file", "duplicate.hcl"},
+			"test_instance.foo is imported more than once",
+		},
+		"missing file": {
+			[]string{"-file", "nonexistent.hcl"},
+			"Error loading import file",
+		},
+		"extra arguments": {
+			[]string{"-file", "imports.hcl", "test_instance.foo", "bar"},
+			"expects no arguments when -file is given",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			p := testProvider()
+			ui := new(cli.MockUi)
+			c := &ImportCommand{
+				Meta: Meta{
+					testingOverrides: metaOverridesForProvider(p),
+					Ui:               ui,
+				},
+			}
+
+			args := append([]string{"-state", testTempFile(t)}, tc.Args...)
+			if code := c.Run(args); code != 1 {
+				t.Fatalf("bad: %d\n\n%s", code, ui.OutputWriter.String())
+			}
+			if !strings.Contains(ui.ErrorWriter.String(), tc.Err) {
+				t.Fatalf("wrong error:\n%s", ui.ErrorWriter.String())
+			}
+			if p.ImportStateCalled {
+				t.Fatal("ImportState should not be called")
+			}
+		})
+	}
+}
+
+// testImportStateByID imports a test_instance with the given ID, unless
+// the ID is "missing".
+func testImportStateByID(info *terraform.InstanceInfo, id string) ([]*terraform.InstanceState, error) {
+	if id == "missing" {
+		return nil, fmt.Errorf("no such object")
+	}
+	return []*terraform.InstanceState{
+		&terraform.InstanceState{
+			ID: id,
+			Ephemeral: terraform.EphemeralState{
+				Type: "test_instance",
+			},
+		},
+	}, nil
+}
+
+func TestImport_generateConfig(t *testing.T) {
+	td := tempDir(t)
+	copy.CopyDir(testFixturePath("import-generate-config"), td)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	statePath := testTempFile(t)
+
+	p := testProvider()
+	p.GetSchemaReturn = &terraform.ProviderSchema{
+		ResourceTypes: map[string]*configschema.Block{
+			"test_instance": {
+				Attributes: map[string]*configschema.Attribute{
+					"id":       {Type: cty.String, Computed: true},
+					"ami":      {Type: cty.String, Required: true},
+					"count":    {Type: cty.Number, Optional: true},
+					"tags":     {Type: cty.Map(cty.String), Optional: true},
+					"arn":      {Type: cty.String, Computed: true},
+					"disabled": {Type: cty.Bool, Optional: true},
+				},
+				BlockTypes: map[string]*configschema.NestedBlock{
+					"network_interface": {
+						Nesting: configschema.NestingList,
+						Block: configschema.Block{
+							Attributes: map[string]*configschema.Attribute{
+								"device_index": {Type: cty.Number, Optional: true},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	p.ImportStateFn = nil
+	p.ImportStateReturn = []*terraform.InstanceState{
+		&terraform.InstanceState{
+			ID: "yay",
+			Attributes: map[string]string{
+				"id":                               "yay",
+				"ami":                              "ami-${abc}",
+				"count":                            "2",
+				"tags.%":                           "2",
+				"tags.Name":                        "web",
+				"tags.with space":                  "x",
+				"arn":                              "arn:yay",
+				"network_interface.#":              "1",
+				"network_interface.0.device_index": "0",
+			},
+			Ephemeral: terraform.EphemeralState{
+				Type: "test_instance",
+			},
+		},
+	}
+
+	ui := new(cli.MockUi)
+	c := &ImportCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(p),
+			Ui:               ui,
+		},
+	}
+
+	args := []string{
+		"-state", statePath,
+		"-generate-config-out", "generated.tf",
+		"-provider", "test.alias",
+		"test_instance.foo",
+		"yay",
+	}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+	}
+
+	got, err := ioutil.ReadFile("generated.tf")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	want := strings.TrimSpace(`
+resource "test_instance" "foo" {
+  provider = "test.alias"
+  ami      = "ami-$${abc}"
+  count    = 2
+
+  tags = {
+    Name         = "web"
+    "with space" = "x"
+  }
+
+  network_interface {
+    device_index = 0
+  }
+}
+`)
+	if strings.TrimSpace(string(got)) != want {
+		t.Fatalf("wrong generated configuration\ngot:\n%s\n\nwant:\n%s", got, want)
+	}
+
+	// The generated configuration is valid, and its values are those of
+	// the imported object.
+	cfg, err := config.LoadFile("generated.tf")
+	if err != nil {
+		t.Fatalf("generated configuration is invalid: %s", err)
+	}
+	if got := cfg.Resources[0].RawConfig.Raw["ami"]; got != "ami-$${abc}" {
+		t.Fatalf("wrong ami: %#v", got)
+	}
+
+	// Generated configuration isn't written over existing files.
+	ui = new(cli.MockUi)
+	c = &ImportCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(p),
+			Ui:               ui,
+		},
+	}
+	if code := c.Run(args); code != 1 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.OutputWriter.String())
+	}
+	if !strings.Contains(ui.ErrorWriter.String(), "already exists") {
+		t.Fatalf("wrong error:\n%s", ui.ErrorWriter.String())
+	}
+}
+
+func TestImport_generateConfigIndex(t *testing.T) {
+	defer testChdir(t, testFixturePath("import-generate-config"))()
+
+	p := testProvider()
+	ui := new(cli.MockUi)
+	c := &ImportCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(p),
+			Ui:               ui,
+		},
+	}
+
+	args := []string{
+		"-state", testTempFile(t),
+		"-generate-config-out", filepath.Join(testTempDir(t), "generated.tf"),
+		"test_instance.foo[1]",
+		"yay",
+	}
+	if code := c.Run(args); code != 1 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.OutputWriter.String())
+	}
+	if !strings.Contains(ui.ErrorWriter.String(), "can't generate configuration") {
+		t.Fatalf("wrong error:\n%s", ui.ErrorWriter.String())
+	}
+	if p.ImportStateCalled {
+		t.Fatal("ImportState should not be called")
+	}
+}
+
 func TestImport_allowMissingResourceConfig(t *testing.T) {
 	defer testChdir(t, testFixturePath("import-missing-resource-config"))()
 
@@ -696,6 +999,15 @@ test_instance.foo:
   provider = provider.test
 `
 
+const testImportFileStr = `
+test_instance.bar:
+  ID = bar-id
+  provider = provider.test.alias
+test_instance.foo:
+  ID = foo-id
+  provider = provider.test
+`
+
 const testImportCustomProviderStr = `
 test_instance.foo:
   ID = yay
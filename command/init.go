@@ -1,19 +1,21 @@
 package command
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"sort"
 	"strings"
+	"sync"
 
+	"github.com/mitchellh/cli"
 	"github.com/posener/complete"
 
 	multierror "github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/terraform/backend"
 	"github.com/hashicorp/terraform/config"
 	"github.com/hashicorp/terraform/config/module"
-	"github.com/hashicorp/terraform/helper/variables"
 	"github.com/hashicorp/terraform/plugin"
 	"github.com/hashicorp/terraform/plugin/discovery"
 	"github.com/hashicorp/terraform/terraform"
@@ -27,6 +29,10 @@ type InitCommand struct {
 	// getPlugins is for the -get-plugins flag
 	getPlugins bool
 
+	// providerLocks are the contents of the dependency lock file, which
+	// are respected and updated when installing providers.
+	providerLocks discovery.ProviderLocks
+
 	// providerInstaller is used to download and install providers that
 	// aren't found locally. This uses a discovery.ProviderInstaller instance
 	// by default, but it can be overridden here as a way to mock fetching
@@ -37,7 +43,7 @@ type InitCommand struct {
 func (c *InitCommand) Run(args []string) int {
 	var flagFromModule string
 	var flagBackend, flagGet, flagUpgrade bool
-	var flagConfigExtra map[string]interface{}
+	var flagConfigExtra FlagBackendConfig
 	var flagPluginPath FlagStringSlice
 	var flagVerifyPlugins bool
 
@@ -47,13 +53,17 @@ func (c *InitCommand) Run(args []string) int {
 	}
 	cmdFlags := c.flagSet("init")
 	cmdFlags.BoolVar(&flagBackend, "backend", true, "")
-	cmdFlags.Var((*variables.FlagAny)(&flagConfigExtra), "backend-config", "")
+	cmdFlags.Var(&flagConfigExtra, "backend-config", "")
 	cmdFlags.StringVar(&flagFromModule, "from-module", "", "copy the source of the given module into the directory before init")
 	cmdFlags.BoolVar(&flagGet, "get", true, "")
 	cmdFlags.BoolVar(&c.getPlugins, "get-plugins", true, "")
 	cmdFlags.BoolVar(&c.forceInitCopy, "force-copy", false, "suppress prompts about copying state data")
+	cmdFlags.BoolVar(&c.migrateState, "migrate-state", false, "copy state data without prompting, except to overwrite")
+	cmdFlags.BoolVar(&c.migrateDryRun, "migrate-dry-run", false, "show the state data that would be copied")
+	cmdFlags.StringVar(&c.migrateWorkspace, "migrate-workspace", "", "workspace corresponding to a backend without workspaces")
 	cmdFlags.BoolVar(&c.Meta.stateLock, "lock", true, "lock state")
 	cmdFlags.DurationVar(&c.Meta.stateLockTimeout, "lock-timeout", 0, "lock timeout")
+	cmdFlags.IntVar(&c.Meta.parallelism, "parallelism", DefaultParallelism, "parallelism")
 	cmdFlags.BoolVar(&c.reconfigure, "reconfigure", false, "reconfigure")
 	cmdFlags.BoolVar(&flagUpgrade, "upgrade", false, "")
 	cmdFlags.Var(&flagPluginPath, "plugin-dir", "plugin directory")
@@ -64,11 +74,31 @@ func (c *InitCommand) Run(args []string) int {
 		return 1
 	}
 
+	if c.migrateDryRun {
+		if !flagBackend {
+			c.Ui.Error("The -migrate-dry-run option can't be used with -backend=false.")
+			return 1
+		}
+
+		// A dry run only inspects the state migration, so nothing else
+		// is downloaded or installed.
+		flagGet = false
+	}
+
 	if len(flagPluginPath) > 0 {
 		c.pluginPath = flagPluginPath
 		c.getPlugins = false
 	}
 
+	c.providerLocks, err = c.providerDependencyLocks()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error reading the dependency lock file: %s", err))
+		return 1
+	}
+	if c.providerLocks == nil {
+		c.providerLocks = make(discovery.ProviderLocks)
+	}
+
 	// set providerInstaller if we don't have a test version already
 	if c.providerInstaller == nil {
 		c.providerInstaller = &discovery.ProviderInstaller{
@@ -76,7 +106,9 @@ func (c *InitCommand) Run(args []string) int {
 			Cache: c.pluginCache(),
 			PluginProtocolVersion: plugin.Handshake.ProtocolVersion,
 			SkipVerify:            !flagVerifyPlugins,
-			Ui:                    c.Ui,
+			Sources:               c.ProviderSources,
+			Locks:                 c.providerLocks,
+			Ui:                    &cli.ConcurrentUi{Ui: c.Ui},
 		}
 	}
 
@@ -196,14 +228,23 @@ func (c *InitCommand) Run(args []string) int {
 			}
 
 			opts := &BackendOpts{
-				Config:      conf,
-				ConfigExtra: flagConfigExtra,
-				Init:        true,
+				Config:        conf,
+				ConfigSources: flagConfigExtra,
+				Init:          true,
 			}
 			if back, err = c.Backend(opts); err != nil {
+				if err == errBackendMigrateDryRun {
+					// The planned migration has been shown
+					return 0
+				}
 				c.Ui.Error(err.Error())
 				return 1
 			}
+
+			if c.migrateDryRun {
+				c.Ui.Output(strings.TrimSpace(outputInitMigrateDryRunUnchanged))
+				return 0
+			}
 		}
 	}
 
@@ -300,30 +341,103 @@ func (c *InitCommand) getProviders(path string, state *terraform.State, upgrade
 		available = c.providerPluginSet()
 	}
 
-	requirements := terraform.ModuleTreeDependencies(mod, state).AllPluginRequirements()
+	deps := terraform.ModuleTreeDependencies(mod, state)
+	if sourceDiags := deps.ProviderSourceConflicts(); sourceDiags.HasErrors() {
+		c.showDiagnostics(sourceDiags)
+		return sourceDiags.Err()
+	}
+
+	requirements := deps.AllPluginRequirements()
 	if len(requirements) == 0 {
 		// nothing to initialize
 		return nil
 	}
 
+	// A lock of a provider from another source than the configuration
+	// requires is the lock of a different provider, which is replaced once
+	// the required one is installed.
+	for name, l := range c.providerLocks {
+		if req, required := requirements[name]; required && req.Source != l.Source {
+			delete(c.providerLocks, name)
+		}
+	}
+
 	c.Ui.Output(c.Colorize().Color(
 		"\n[reset][bold]Initializing provider plugins...",
 	))
 
-	missing := c.missingPlugins(available, requirements)
+	// Unless we're upgrading, the versions recorded in the dependency lock
+	// file are the only ones that may be selected.
+	selectable := requirements
+	if !upgrade {
+		var conflicts []*discovery.ProviderLock
+		selectable, conflicts = requirements.Locked(c.providerLocks)
+		if len(conflicts) > 0 {
+			var errs error
+			for _, l := range conflicts {
+				c.Ui.Error(fmt.Sprintf(errProviderLockConflict, l.Name, l.Version, requirements[l.Name].Versions, DefaultDependencyLockFile))
+				errs = multierror.Append(errs, fmt.Errorf("locked provider %q version %s doesn't meet the version constraints", l.Name, l.Version))
+			}
+			return errs
+		}
+	}
+
+	missing := c.missingPlugins(available, selectable)
 
 	var errs error
 	if c.getPlugins {
 		if len(missing) > 0 {
-			c.Ui.Output(fmt.Sprintf("- Checking for available provider plugins on %s...",
-				discovery.GetReleaseHost()))
+			if len(c.ProviderSources) > 0 {
+				c.Ui.Output("- Checking for available provider plugins using the " +
+					"provider installation methods in the CLI configuration...")
+			} else {
+				c.Ui.Output(fmt.Sprintf("- Checking for available provider plugins on %s...",
+					discovery.GetReleaseHost()))
+			}
 		}
 
-		for provider, reqd := range missing {
-			_, err := c.providerInstaller.Get(provider, reqd.Versions)
+		// The missing providers are installed concurrently, and any errors
+		// are reported afterwards in a consistent order.
+		names := make([]string, 0, len(missing))
+		for provider := range missing {
+			names = append(names, provider)
+		}
+		sort.Strings(names)
+
+		parallelism := c.parallelism
+		if parallelism < 1 {
+			parallelism = 1
+		}
+		sem := make(chan struct{}, parallelism)
+		getErrs := make([]error, len(names))
+		var wg sync.WaitGroup
+		for i, provider := range names {
+			// The official releases only have the providers of the
+			// default source addresses, so others can only come from the
+			// installation methods in the CLI configuration.
+			if missing[provider].Source != "" && len(c.ProviderSources) == 0 {
+				getErrs[i] = errProviderSourceNotInstallable
+				continue
+			}
+
+			wg.Add(1)
+			go func(i int, provider string) {
+				defer wg.Done()
+				sem <- struct{}{}
+				defer func() { <-sem }()
+				_, getErrs[i] = c.providerInstaller.Get(provider, missing[provider].Versions)
+			}(i, provider)
+		}
+		wg.Wait()
+
+		for i, provider := range names {
+			reqd := missing[provider]
+			err := getErrs[i]
 
 			if err != nil {
 				switch err {
+				case errProviderSourceNotInstallable:
+					c.Ui.Error(fmt.Sprintf(errProviderSourceNotInstallableMsg, provider, reqd.Source, DefaultPluginVendorDir))
 				case discovery.ErrorNoSuchProvider:
 					c.Ui.Error(fmt.Sprintf(errProviderNotFound, provider, DefaultPluginVendorDir))
 				case discovery.ErrorNoSuitableVersion:
@@ -334,7 +448,8 @@ func (c *InitCommand) getProviders(path string, state *terraform.State, upgrade
 						// versions. We'll treat it like ErrorNoSuchProvider, then.
 						c.Ui.Error(fmt.Sprintf(errProviderNotFound, provider, DefaultPluginVendorDir))
 					} else {
-						c.Ui.Error(fmt.Sprintf(errProviderVersionsUnsuitable, provider, reqd.Versions))
+						c.Ui.Error(fmt.Sprintf(errProviderVersionsUnsuitable, provider, reqd.Versions,
+							"    "+strings.Join(deps.ProviderConstraints(provider), "\n    ")))
 					}
 				case discovery.ErrorNoVersionCompatible:
 					// FIXME: This error message is sub-awesome because we don't
@@ -379,7 +494,7 @@ func (c *InitCommand) getProviders(path string, state *terraform.State, upgrade
 	available = c.providerPluginSet() // re-discover to see newly-installed plugins
 
 	// internal providers were already filtered out, since we don't need to get them.
-	chosen := choosePlugins(available, nil, requirements)
+	chosen := choosePlugins(available, nil, selectable)
 
 	digests := map[string][]byte{}
 	for name, meta := range chosen {
@@ -399,6 +514,18 @@ func (c *InitCommand) getProviders(path string, state *terraform.State, upgrade
 		return err
 	}
 
+	// The dependency lock file records the versions we chose, so that future
+	// runs, here and elsewhere, select the same ones. The installer already
+	// locked any providers it installed, with the hashes of their packages.
+	_, statErr := os.Stat(DefaultDependencyLockFile)
+	if err := c.lockProviders(requirements, chosen); err != nil {
+		c.Ui.Error(fmt.Sprintf("Error updating the dependency lock file: %s", err))
+		return err
+	}
+	if _, err := os.Stat(DefaultDependencyLockFile); err == nil && os.IsNotExist(statErr) {
+		c.Ui.Output(c.Colorize().Color(fmt.Sprintf(outputInitProvidersLockCreated, DefaultDependencyLockFile)))
+	}
+
 	{
 		// Purge any auto-installed plugins that aren't being used.
 		purged, err := c.providerInstaller.PurgeUnused(chosen)
@@ -447,26 +574,68 @@ func (c *InitCommand) getProviders(path string, state *terraform.State, upgrade
 	return nil
 }
 
+// lockProviders records the chosen provider versions in the dependency lock
+// file, removing the locks of any providers that are no longer required.
+func (c *InitCommand) lockProviders(requirements discovery.PluginRequirements, chosen map[string]discovery.PluginMeta) error {
+	if c.providerLocks == nil {
+		c.providerLocks = make(discovery.ProviderLocks)
+	}
+
+	for name, l := range c.providerLocks {
+		if req, required := requirements[name]; !required || req.Source != l.Source {
+			delete(c.providerLocks, name)
+		}
+	}
+
+	for name, meta := range chosen {
+		// meta.Version.MustParse is safe here because our "chosen" metas
+		// were already filtered for validity of versions.
+		v := meta.Version.MustParse()
+		l := c.providerLocks[name]
+		if l == nil || !l.Version.Equal(v) {
+			l = &discovery.ProviderLock{
+				Name:    name,
+				Version: v,
+			}
+			c.providerLocks[name] = l
+		}
+		l.Source = requirements[name].Source
+		l.Constraints = requirements[name].Versions.String()
+	}
+
+	if len(c.providerLocks) == 0 {
+		// Don't create a lock file that would be empty.
+		if _, err := os.Stat(DefaultDependencyLockFile); os.IsNotExist(err) {
+			return nil
+		}
+	}
+	return c.saveProviderDependencyLocks(c.providerLocks)
+}
+
 func (c *InitCommand) AutocompleteArgs() complete.Predictor {
 	return complete.PredictDirs("")
 }
 
 func (c *InitCommand) AutocompleteFlags() complete.Flags {
 	return complete.Flags{
-		"-backend":        completePredictBoolean,
-		"-backend-config": complete.PredictFiles("*.tfvars"), // can also be key=value, but we can't "predict" that
-		"-force-copy":     complete.PredictNothing,
-		"-from-module":    completePredictModuleSource,
-		"-get":            completePredictBoolean,
-		"-get-plugins":    completePredictBoolean,
-		"-input":          completePredictBoolean,
-		"-lock":           completePredictBoolean,
-		"-lock-timeout":   complete.PredictAnything,
-		"-no-color":       complete.PredictNothing,
-		"-plugin-dir":     complete.PredictDirs(""),
-		"-reconfigure":    complete.PredictNothing,
-		"-upgrade":        completePredictBoolean,
-		"-verify-plugins": completePredictBoolean,
+		"-backend":           completePredictBoolean,
+		"-backend-config":    complete.PredictFiles("*.tfvars"), // can also be key=value, but we can't "predict" that
+		"-force-copy":        complete.PredictNothing,
+		"-from-module":       completePredictModuleSource,
+		"-get":               completePredictBoolean,
+		"-get-plugins":       completePredictBoolean,
+		"-input":             completePredictBoolean,
+		"-lock":              completePredictBoolean,
+		"-lock-timeout":      complete.PredictAnything,
+		"-migrate-dry-run":   complete.PredictNothing,
+		"-migrate-state":     complete.PredictNothing,
+		"-migrate-workspace": complete.PredictAnything,
+		"-no-color":          complete.PredictNothing,
+		"-parallelism":       complete.PredictAnything,
+		"-plugin-dir":        complete.PredictDirs(""),
+		"-reconfigure":       complete.PredictNothing,
+		"-upgrade":           completePredictBoolean,
+		"-verify-plugins":    completePredictBoolean,
 	}
 }
 
@@ -519,8 +688,29 @@ Options:
 
   -lock-timeout=0s     Duration to retry a state lock.
 
+  -migrate-dry-run     Show which workspaces would be copied to a newly
+                       configured backend, without changing any state or the
+                       backend configuration.
+
+  -migrate-state       Copy existing state to a newly configured backend
+                       without asking first. Terraform still asks before
+                       overwriting a workspace that already has different
+                       state in the new backend, unless -force-copy is set.
+
+  -migrate-workspace=name
+                       When copying state between a backend with workspaces
+                       and one without, the workspace that corresponds to the
+                       state of the backend without workspaces. This is the
+                       workspace that is copied to a backend without
+                       workspaces, which defaults to the current workspace, or
+                       the workspace that the state of a backend without
+                       workspaces is copied to, which defaults to "default".
+
   -no-color            If specified, output won't contain any color.
 
+  -parallelism=10      Limit the number of modules and provider plugins that
+                       are downloaded concurrently.
+
   -plugin-dir          Directory containing plugin binaries. This overrides all
                        default search paths for plugins, and prevents the 
                        automatic installation of plugins. This flag can be used
@@ -531,7 +721,9 @@ Options:
 
   -upgrade=false       If installing modules (-get) or plugins (-get-plugins),
                        ignore previously-downloaded objects and install the
-                       latest version allowed within configured constraints.
+                       latest version allowed within configured constraints,
+                       ignoring the provider versions recorded in the
+                       dependency lock file.
 
   -verify-plugins=true Verify the authenticity and integrity of automatically
                        downloaded plugins.
@@ -565,6 +757,10 @@ The directory has no Terraform configuration files. You may begin working
 with Terraform immediately by creating Terraform configuration files.
 `
 
+const outputInitMigrateDryRunUnchanged = `
+The backend configuration hasn't changed, so no state needs to be migrated.
+`
+
 const outputInitSuccess = `
 [reset][bold][green]Terraform has been successfully initialized![reset][green]
 `
@@ -589,6 +785,13 @@ corresponding provider blocks in configuration, with the constraint strings
 suggested below.
 `
 
+const outputInitProvidersLockCreated = `
+Terraform has created a dependency lock file %s to record the provider
+versions it selected above. Include this file in your version control
+repository so that Terraform selects the same versions by default when you
+run "terraform init" in the future.
+`
+
 const errProviderNotFound = `
 [reset][bold][red]Provider %[1]q not available for installation.[reset][red]
 
@@ -610,16 +813,38 @@ To view the provider versions requested by each module, run
 const errProviderVersionsUnsuitable = `
 [reset][bold][red]No provider %[1]q plugins meet the constraint %[2]q.[reset][red]
 
-The version constraint is derived from the "version" argument within the
-provider %[1]q block in configuration. Child modules may also apply
-provider version constraints. To view the provider versions requested by each
-module in the current configuration, run "terraform providers".
+The version constraint combines the "version" arguments of the provider %[1]q
+blocks and the %[1]q entries of the required_providers blocks of all the
+modules in the configuration, which constrain the versions as follows:
+%[3]s
+
+To view the provider versions requested by each module in the current
+configuration, run "terraform providers".
 
 To proceed, the version constraints for this provider must be relaxed by
-either adjusting or removing the "version" argument in the provider blocks
+either adjusting or removing the conflicting "version" arguments
 throughout the configuration.
 `
 
+// errProviderSourceNotInstallable is the installation error of a provider
+// whose source address isn't the default, when there are no installation
+// methods in the CLI configuration to install it with.
+var errProviderSourceNotInstallable = errors.New("provider source not installable from the official releases")
+
+const errProviderSourceNotInstallableMsg = `
+[reset][bold][red]Provider %[1]q (%[2]s) can't be installed automatically.[reset][red]
+
+The configuration requires the provider %[1]q from the source address
+%[2]s, but Terraform can only install providers from the official releases,
+which only has the providers of the "hashicorp" namespace, unless the CLI
+configuration has a provider_installation block.
+
+Either configure a network or filesystem mirror that has the provider in the
+CLI configuration, or install its plugin manually by placing the plugin's
+executable file in the following directory:
+    %[3]s
+`
+
 const errProviderIncompatible = `
 [reset][bold][red]No available provider %[1]q plugins are compatible with this Terraform version.[reset][red]
 
@@ -657,6 +882,18 @@ following directory:
     %[3]s
 `
 
+const errProviderLockConflict = `
+[reset][bold][red]Locked provider %[1]q version %[2]s doesn't meet the constraint %[3]q.[reset][red]
+
+The dependency lock file %[4]s records the version of each provider that
+was previously selected for this configuration, and only that version is
+installed unless you ask Terraform to select a new one.
+
+The version constraints in the configuration have changed since the version
+was selected. To select a new version that meets them, and record it in the
+dependency lock file, run "terraform init -upgrade".
+`
+
 const errMissingProvidersNoInstall = `
 [reset][bold][red]Missing required providers.[reset][red]
 
@@ -9,6 +9,7 @@ import (
 	"runtime"
 	"sort"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/hashicorp/terraform/backend/local"
@@ -349,6 +350,41 @@ func TestInit_backendConfigFileChange(t *testing.T) {
 	}
 }
 
+func TestInit_migrateDryRun(t *testing.T) {
+	// Create a temporary working directory that is empty
+	td := tempDir(t)
+	copy.CopyDir(testFixturePath("backend-change"), td)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	ui := new(cli.MockUi)
+	c := &InitCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(testProvider()),
+			Ui:               ui,
+		},
+	}
+
+	args := []string{"-migrate-dry-run"}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: \n%s", ui.ErrorWriter.String())
+	}
+
+	output := ui.OutputWriter.String()
+	if !strings.Contains(output, `"default" -> "default": copy`) {
+		t.Fatalf("bad: \n%s", output)
+	}
+
+	// Verify the state wasn't copied and the saved backend is unchanged
+	if _, err := os.Stat("local-state-2.tfstate"); err == nil {
+		t.Fatal("state should not have been copied")
+	}
+	state := testStateRead(t, filepath.Join(DefaultDataDir, DefaultStateFilename))
+	if v := state.Backend.Config["path"]; v != "local-state.tfstate" {
+		t.Fatalf("bad: %#v", v)
+	}
+}
+
 func TestInit_backendConfigKV(t *testing.T) {
 	// Create a temporary working directory that is empty
 	td := tempDir(t)
@@ -376,6 +412,120 @@ func TestInit_backendConfigKV(t *testing.T) {
 	}
 }
 
+func TestInit_backendConfigPrecedence(t *testing.T) {
+	// Create a temporary working directory that is empty
+	td := tempDir(t)
+	copy.CopyDir(testFixturePath("init-backend-config-file"), td)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	// The environment only fills in what isn't set anywhere else, and the
+	// -backend-config options override each other in order.
+	defer os.Unsetenv(BackendConfigEnvPrefix + "path")
+	defer os.Unsetenv(BackendConfigEnvPrefix + "workspace_dir")
+	os.Setenv(BackendConfigEnvPrefix+"path", "env")
+	os.Setenv(BackendConfigEnvPrefix+"workspace_dir", "envdir")
+
+	ui := new(cli.MockUi)
+	c := &InitCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(testProvider()),
+			Ui:               ui,
+		},
+	}
+
+	args := []string{"-backend-config", "path=flag", "-backend-config", "input.config"}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: \n%s", ui.ErrorWriter.String())
+	}
+
+	state := testStateRead(t, filepath.Join(DefaultDataDir, DefaultStateFilename))
+	if v := state.Backend.Config["path"]; v != "hello" {
+		t.Fatalf("bad: %#v", v)
+	}
+	if v := state.Backend.Config["workspace_dir"]; v != "envdir" {
+		t.Fatalf("bad: %#v", v)
+	}
+}
+
+func TestInit_backendConfigEnvBlock(t *testing.T) {
+	// Create a temporary working directory that is empty
+	td := tempDir(t)
+	copy.CopyDir(testFixturePath("init-backend"), td)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	// The backend block overrides the environment.
+	defer os.Unsetenv(BackendConfigEnvPrefix + "path")
+	os.Setenv(BackendConfigEnvPrefix+"path", "env")
+
+	ui := new(cli.MockUi)
+	c := &InitCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(testProvider()),
+			Ui:               ui,
+		},
+	}
+
+	if code := c.Run(nil); code != 0 {
+		t.Fatalf("bad: \n%s", ui.ErrorWriter.String())
+	}
+
+	state := testStateRead(t, filepath.Join(DefaultDataDir, DefaultStateFilename))
+	if v := state.Backend.Config["path"]; v != "foo" {
+		t.Fatalf("bad: %#v", v)
+	}
+}
+
+func TestInit_backendConfigNoBlock(t *testing.T) {
+	// Create a temporary working directory that is empty
+	td := tempDir(t)
+	copy.CopyDir(testFixturePath("init"), td)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	ui := new(cli.MockUi)
+	c := &InitCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(testProvider()),
+			Ui:               ui,
+		},
+	}
+
+	args := []string{"-backend-config", "path=hello"}
+	if code := c.Run(args); code != 1 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.OutputWriter.String())
+	}
+	if errMsg := ui.ErrorWriter.String(); !strings.Contains(errMsg, "no backend block") {
+		t.Fatalf("bad error: %s", errMsg)
+	}
+}
+
+func TestInit_backendConfigInterpolation(t *testing.T) {
+	// Create a temporary working directory that is empty
+	td := tempDir(t)
+	copy.CopyDir(testFixturePath("init-backend-config-kv"), td)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	ui := new(cli.MockUi)
+	c := &InitCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(testProvider()),
+			Ui:               ui,
+		},
+	}
+
+	args := []string{"-backend-config", "path=${var.path}"}
+	if code := c.Run(args); code != 1 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.OutputWriter.String())
+	}
+	errMsg := ui.ErrorWriter.String()
+	if !strings.Contains(errMsg, `"path" from -backend-config "path=..." cannot contain interpolations`) {
+		t.Fatalf("bad error: %s", errMsg)
+	}
+}
+
 func TestInit_targetSubdir(t *testing.T) {
 	// Create a temporary working directory that is empty
 	td := tempDir(t)
@@ -926,6 +1076,64 @@ func TestInit_getProviderHaveLegacyVersion(t *testing.T) {
 	}
 }
 
+func TestInit_getProvidersParallel(t *testing.T) {
+	// Create a temporary working directory that is empty
+	td := tempDir(t)
+	copy.CopyDir(testFixturePath("init-get-providers"), td)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	// The installer fails for every provider, once at least two of them
+	// are being installed at the same time, so that both the bound on the
+	// concurrent installations and the order of the errors can be checked.
+	var lock sync.Mutex
+	var running, maxRunning int
+	var startedOnce sync.Once
+	started := make(chan struct{})
+	ui := new(cli.MockUi)
+	c := &InitCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(testProvider()),
+			Ui:               ui,
+		},
+		providerInstaller: callbackPluginInstaller(func(provider string, req discovery.Constraints) (discovery.PluginMeta, error) {
+			lock.Lock()
+			running++
+			if running > maxRunning {
+				maxRunning = running
+			}
+			if running == 2 {
+				startedOnce.Do(func() { close(started) })
+			}
+			lock.Unlock()
+
+			<-started
+
+			lock.Lock()
+			running--
+			lock.Unlock()
+			return discovery.PluginMeta{}, fmt.Errorf("EXPECTED PROVIDER ERROR %s", provider)
+		}),
+	}
+
+	args := []string{"-backend=false", "-parallelism=2"}
+	if code := c.Run(args); code == 0 {
+		t.Fatalf("expected error, got output: \n%s", ui.OutputWriter.String())
+	}
+
+	if maxRunning != 2 {
+		t.Fatalf("wrong number of concurrent installations %d; want 2", maxRunning)
+	}
+
+	errStr := ui.ErrorWriter.String()
+	between := strings.Index(errStr, "EXPECTED PROVIDER ERROR between")
+	exact := strings.Index(errStr, "EXPECTED PROVIDER ERROR exact")
+	greaterThan := strings.Index(errStr, "EXPECTED PROVIDER ERROR greater_than")
+	if between < 0 || exact < between || greaterThan < exact {
+		t.Fatalf("errors missing or not in order of provider name:\n%s", errStr)
+	}
+}
+
 func TestInit_getProviderCheckRequiredVersion(t *testing.T) {
 	// Create a temporary working directory that is empty
 	td := tempDir(t)
@@ -997,6 +1205,198 @@ func TestInit_providerLockFile(t *testing.T) {
 	}
 }
 
+func TestInit_dependencyLockFile(t *testing.T) {
+	// Create a temporary working directory that is empty
+	td := tempDir(t)
+	copy.CopyDir(testFixturePath("init-dependency-lock"), td)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	run := func(args ...string) {
+		ui := new(cli.MockUi)
+		m := Meta{
+			testingOverrides: metaOverridesForProvider(testProvider()),
+			Ui:               ui,
+		}
+		c := &InitCommand{
+			Meta: m,
+			providerInstaller: &mockProviderInstaller{
+				Providers: map[string][]string{
+					"test": []string{"1.3.0", "1.2.3"},
+				},
+				Dir: m.pluginDir(),
+			},
+		}
+		if code := c.Run(args); code != 0 {
+			t.Fatalf("bad: \n%s", ui.ErrorWriter.String())
+		}
+	}
+
+	// The locked version is installed even though a newer one is available,
+	// and the hashes already locked for it are retained.
+	locks := discovery.ProviderLocks{
+		"test": &discovery.ProviderLock{
+			Name:    "test",
+			Version: discovery.VersionStr("1.2.3").MustParse(),
+			Hashes:  []string{"zh:abc"},
+		},
+	}
+	if err := locks.Write(DefaultDependencyLockFile); err != nil {
+		t.Fatal(err)
+	}
+	run()
+
+	got, err := discovery.ReadProviderLocks(DefaultDependencyLockFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lock := got["test"]
+	if lock.Version.String() != "1.2.3" || lock.Constraints != "~> 1.0" || !lock.HasHash("zh:abc") {
+		t.Fatalf("wrong lock %#v", lock)
+	}
+
+	// Upgrading selects and locks the newest version.
+	run("-upgrade")
+
+	got, err = discovery.ReadProviderLocks(DefaultDependencyLockFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lock = got["test"]
+	if lock.Version.String() != "1.3.0" || len(lock.Hashes) != 0 {
+		t.Fatalf("wrong lock after upgrade %#v", lock)
+	}
+}
+
+func TestInit_requiredProviderSource(t *testing.T) {
+	// Create a temporary working directory that is empty
+	td := tempDir(t)
+	copy.CopyDir(testFixturePath("init-required-providers-source"), td)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	run := func(sources []*discovery.ProviderInstallationSource) (int, *cli.MockUi) {
+		ui := new(cli.MockUi)
+		m := Meta{
+			testingOverrides: metaOverridesForProvider(testProvider()),
+			Ui:               ui,
+			ProviderSources:  sources,
+		}
+		c := &InitCommand{
+			Meta: m,
+			providerInstaller: &mockProviderInstaller{
+				Providers: map[string][]string{
+					"test": []string{"1.3.0", "1.2.3"},
+				},
+				Dir: m.pluginDir(),
+			},
+		}
+		return c.Run(nil), ui
+	}
+
+	// The official releases don't have providers of other sources, so they
+	// can't be installed without an installation method for them.
+	code, ui := run(nil)
+	if code == 0 {
+		t.Fatalf("succeeded; want error\n%s", ui.OutputWriter.String())
+	}
+	if got := ui.ErrorWriter.String(); !strings.Contains(got, `Provider "test" (example.com/acme/test) can't be installed automatically`) {
+		t.Fatalf("wrong error:\n%s", got)
+	}
+
+	code, ui = run([]*discovery.ProviderInstallationSource{
+		{Source: &discovery.FilesystemMirrorSource{Dir: filepath.Join(td, "mirror")}},
+	})
+	if code != 0 {
+		t.Fatalf("bad: \n%s", ui.ErrorWriter.String())
+	}
+
+	locks, err := discovery.ReadProviderLocks(DefaultDependencyLockFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lock := locks["test"]
+	if lock == nil || lock.Source != "example.com/acme/test" || lock.Version.String() != "1.3.0" || lock.Constraints != "~> 1.0" {
+		t.Fatalf("wrong lock %#v", lock)
+	}
+}
+
+func TestInit_dependencyLockFileCreated(t *testing.T) {
+	// Create a temporary working directory that is empty
+	td := tempDir(t)
+	copy.CopyDir(testFixturePath("init-dependency-lock"), td)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	ui := new(cli.MockUi)
+	m := Meta{
+		testingOverrides: metaOverridesForProvider(testProvider()),
+		Ui:               ui,
+	}
+	c := &InitCommand{
+		Meta: m,
+		providerInstaller: &mockProviderInstaller{
+			Providers: map[string][]string{
+				"test": []string{"1.3.0", "1.2.3"},
+			},
+			Dir: m.pluginDir(),
+		},
+	}
+	if code := c.Run(nil); code != 0 {
+		t.Fatalf("bad: \n%s", ui.ErrorWriter.String())
+	}
+
+	locks, err := discovery.ReadProviderLocks(DefaultDependencyLockFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(locks) != 1 || locks["test"].Version.String() != "1.3.0" {
+		t.Fatalf("wrong locks %#v", locks)
+	}
+	if !strings.Contains(ui.OutputWriter.String(), "created a dependency lock file") {
+		t.Fatalf("no message about the new lock file in output:\n%s", ui.OutputWriter.String())
+	}
+}
+
+func TestInit_dependencyLockFileConflict(t *testing.T) {
+	// Create a temporary working directory that is empty
+	td := tempDir(t)
+	copy.CopyDir(testFixturePath("init-dependency-lock"), td)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	locks := discovery.ProviderLocks{
+		"test": &discovery.ProviderLock{
+			Name:    "test",
+			Version: discovery.VersionStr("0.9.0").MustParse(),
+		},
+	}
+	if err := locks.Write(DefaultDependencyLockFile); err != nil {
+		t.Fatal(err)
+	}
+
+	ui := new(cli.MockUi)
+	m := Meta{
+		testingOverrides: metaOverridesForProvider(testProvider()),
+		Ui:               ui,
+	}
+	c := &InitCommand{
+		Meta: m,
+		providerInstaller: &mockProviderInstaller{
+			Providers: map[string][]string{
+				"test": []string{"1.3.0", "0.9.0"},
+			},
+			Dir: m.pluginDir(),
+		},
+	}
+	if code := c.Run(nil); code == 0 {
+		t.Fatal("succeeded; want error for a locked version that doesn't meet the constraints")
+	}
+	if !strings.Contains(ui.ErrorWriter.String(), "terraform init -upgrade") {
+		t.Fatalf("wrong error output:\n%s", ui.ErrorWriter.String())
+	}
+}
+
 func TestInit_pluginDirReset(t *testing.T) {
 	td, err := ioutil.TempDir("", "tf")
 	if err != nil {
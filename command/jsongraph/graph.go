@@ -0,0 +1,141 @@
+// Package jsongraph produces the JSON representation of a graph, which is
+// the machine-readable output of "terraform graph -json".
+//
+// The structure of the JSON representation is part of the interface of the
+// command that produces it, so fields may be added to it but existing ones
+// must not be removed or changed without incrementing FormatVersion.
+package jsongraph
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/hashicorp/terraform/command/jsonstate"
+	"github.com/hashicorp/terraform/dag"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+// FormatVersion is the version of the JSON representation of graphs.
+const FormatVersion = "0.1"
+
+// Graph is the JSON representation of a graph.
+type Graph struct {
+	FormatVersion string `json:"format_version"`
+
+	// Type is the type of the graph, such as "plan" or "apply".
+	Type string `json:"type"`
+
+	Nodes []*Node `json:"nodes"`
+	Edges []*Edge `json:"edges"`
+}
+
+// Node is a vertex of the graph.
+type Node struct {
+	// ID is the name of the vertex, which is unique within the graph and is
+	// used by the edges to refer to it.
+	ID    string `json:"id"`
+	Label string `json:"label"`
+
+	// Module is the address of the module the vertex belongs to, such as
+	// "module.foo", and is empty for the root module.
+	Module string `json:"module,omitempty"`
+
+	// Resource and ResourceType are set for the vertices of resources.
+	Resource     string `json:"resource,omitempty"`
+	ResourceType string `json:"resource_type,omitempty"`
+
+	// Actions are the actions planned for the resource, in the form used
+	// by the JSON representation of plans, if the graph is that of a plan.
+	Actions []string `json:"actions,omitempty"`
+}
+
+// Edge is a dependency of the vertex Source on the vertex Target.
+type Edge struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+}
+
+// Marshal returns the JSON representation of a graph.
+func Marshal(g *terraform.Graph, graphType string, actions map[string][]string) ([]byte, error) {
+	return json.MarshalIndent(NewGraph(g, graphType, actions), "", "  ")
+}
+
+// NewGraph returns the JSON representation of a graph of the given type.
+// The actions planned for the resources are keyed by resource address, and
+// may be nil.
+func NewGraph(g *terraform.Graph, graphType string, actions map[string][]string) *Graph {
+	ret := &Graph{
+		FormatVersion: FormatVersion,
+		Type:          graphType,
+		Nodes:         []*Node{},
+		Edges:         []*Edge{},
+	}
+
+	for _, v := range g.Vertices() {
+		name := dag.VertexName(v)
+		n := &Node{
+			ID:    name,
+			Label: name,
+		}
+
+		if dn, ok := v.(dag.GraphNodeDotter); ok {
+			if node := dn.DotNode(name, &dag.DotOpts{}); node != nil && node.Attrs["label"] != "" {
+				n.Label = node.Attrs["label"]
+			}
+		}
+		if path := ModulePath(v); len(path) > 1 {
+			n.Module = jsonstate.ModuleAddress(path)
+		}
+		if r, ok := v.(terraform.GraphNodeResource); ok {
+			if addr := r.ResourceAddr(); addr != nil {
+				n.Resource = ResourceAddress(addr)
+				n.ResourceType = addr.Type
+				n.Actions = actions[n.Resource]
+			}
+		}
+
+		ret.Nodes = append(ret.Nodes, n)
+	}
+	sort.Slice(ret.Nodes, func(i, j int) bool {
+		return ret.Nodes[i].ID < ret.Nodes[j].ID
+	})
+
+	for _, e := range g.Edges() {
+		ret.Edges = append(ret.Edges, &Edge{
+			Source: dag.VertexName(e.Source()),
+			Target: dag.VertexName(e.Target()),
+		})
+	}
+	sort.Slice(ret.Edges, func(i, j int) bool {
+		if ret.Edges[i].Source != ret.Edges[j].Source {
+			return ret.Edges[i].Source < ret.Edges[j].Source
+		}
+		return ret.Edges[i].Target < ret.Edges[j].Target
+	})
+
+	return ret
+}
+
+// ResourceAddress returns the address of a resource without its instance
+// type, as used in the JSON representations to identify resources.
+func ResourceAddress(addr *terraform.ResourceAddress) string {
+	addr = addr.Copy()
+	addr.InstanceTypeSet = false
+	return addr.String()
+}
+
+// ModulePath returns the path of the module a vertex belongs to, starting
+// with "root", or nil if the vertex doesn't belong to a module.
+func ModulePath(v dag.Vertex) []string {
+	sp, ok := v.(terraform.GraphNodeSubPath)
+	if !ok {
+		return nil
+	}
+
+	// The paths of resources leave out the root module.
+	path := sp.Path()
+	if len(path) == 0 || path[0] != terraform.RootModuleName {
+		path = append([]string{terraform.RootModuleName}, path...)
+	}
+	return path
+}
@@ -0,0 +1,74 @@
+package jsongraph
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform/config"
+	"github.com/hashicorp/terraform/dag"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestNewGraph(t *testing.T) {
+	foo := &terraform.NodeAbstractResource{
+		Addr: &terraform.ResourceAddress{
+			Path:  []string{"child"},
+			Mode:  config.ManagedResourceMode,
+			Type:  "test_instance",
+			Name:  "foo",
+			Index: -1,
+		},
+	}
+
+	var g terraform.Graph
+	g.Add(foo)
+	g.Add("provider.test")
+	g.Connect(dag.BasicEdge(foo, "provider.test"))
+
+	got := NewGraph(&g, "plan", map[string][]string{
+		"module.child.test_instance.foo": {"create"},
+	})
+	want := &Graph{
+		FormatVersion: FormatVersion,
+		Type:          "plan",
+		Nodes: []*Node{
+			{
+				ID:           "module.child.test_instance.foo",
+				Label:        "module.child.test_instance.foo",
+				Module:       "module.child",
+				Resource:     "module.child.test_instance.foo",
+				ResourceType: "test_instance",
+				Actions:      []string{"create"},
+			},
+			{
+				ID:    "provider.test",
+				Label: "provider.test",
+			},
+		},
+		Edges: []*Edge{
+			{Source: "module.child.test_instance.foo", Target: "provider.test"},
+		},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong graph\ngot:  %#v\nwant: %#v", got, want)
+	}
+}
+
+func TestModulePath(t *testing.T) {
+	res := &terraform.NodeAbstractResource{
+		Addr: &terraform.ResourceAddress{Path: []string{"child"}},
+	}
+	if got, want := ModulePath(res), []string{"root", "child"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong path %#v; want %#v", got, want)
+	}
+
+	out := &terraform.NodeApplyableOutput{PathValue: []string{"root"}}
+	if got, want := ModulePath(out), []string{"root"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong path %#v; want %#v", got, want)
+	}
+
+	if got := ModulePath("provider.test"); got != nil {
+		t.Fatalf("wrong path %#v; want nil", got)
+	}
+}
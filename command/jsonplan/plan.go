@@ -0,0 +1,184 @@
+// Package jsonplan produces the JSON representation of a plan, which is the
+// machine-readable output of "terraform show -json" for plan files.
+//
+// The structure of the JSON representation is part of the interface of the
+// commands that produce it, so fields may be added to it but existing ones
+// must not be removed or changed without incrementing FormatVersion.
+package jsonplan
+
+import (
+	"encoding/json"
+
+	"github.com/hashicorp/terraform/command/format"
+	"github.com/hashicorp/terraform/command/jsonstate"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+// FormatVersion is the version of the JSON representation of plans.
+const FormatVersion = "0.1"
+
+// Plan is the JSON representation of a plan.
+type Plan struct {
+	FormatVersion    string                 `json:"format_version"`
+	TerraformVersion string                 `json:"terraform_version,omitempty"`
+	Variables        map[string]interface{} `json:"variables,omitempty"`
+	Targets          []string               `json:"targets,omitempty"`
+
+	// VariableSources describe where the values of Variables were set, such
+	// as "set by -var".
+	VariableSources map[string]string `json:"variable_sources,omitempty"`
+
+	// ResourceChanges are the changes the plan makes to resources, while
+	// ResourceDrift are the changes detected outside of Terraform when
+	// refreshing the state before planning.
+	ResourceChanges []*ResourceChange `json:"resource_changes"`
+	ResourceDrift   []*ResourceChange `json:"resource_drift,omitempty"`
+
+	// Checks are the results of the configuration's check blocks.
+	Checks []*terraform.CheckResult `json:"checks,omitempty"`
+
+	// PriorState is the state the plan was made from.
+	PriorState *jsonstate.State `json:"prior_state,omitempty"`
+}
+
+// ResourceChange is a change to an instance of a resource.
+type ResourceChange struct {
+	Address       string `json:"address"`
+	ModuleAddress string `json:"module_address,omitempty"`
+	Mode          string `json:"mode"`
+	Type          string `json:"type"`
+	Name          string `json:"name"`
+
+	// Index is the index of the instance if the resource has a count.
+	Index *int `json:"index,omitempty"`
+
+	// Deposed is set for the changes to instances that were replaced and
+	// are awaiting destruction.
+	Deposed bool `json:"deposed,omitempty"`
+
+	Change *Change `json:"change"`
+}
+
+// Change describes the actions taken on an instance, and the changes to its
+// attributes.
+type Change struct {
+	// Actions are "no-op", "create", "read", "update" or "delete", or
+	// "delete" followed by "create" when the instance is replaced.
+	Actions []string `json:"actions"`
+
+	// Tainted is set when the instance is replaced because it's tainted,
+	// and ReplaceRequested when its replacement was requested with
+	// -replace.
+	Tainted          bool `json:"tainted,omitempty"`
+	ReplaceRequested bool `json:"replace_requested,omitempty"`
+
+	Attributes []*AttributeChange `json:"attributes,omitempty"`
+}
+
+// AttributeChange is a change to a flattened attribute of an instance.
+type AttributeChange struct {
+	Path   string `json:"path"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+
+	// AfterUnknown is set when the value of the attribute will only be
+	// known after the plan is applied.
+	AfterUnknown bool `json:"after_unknown,omitempty"`
+
+	Removed           bool `json:"removed,omitempty"`
+	Sensitive         bool `json:"sensitive,omitempty"`
+	ForcesReplacement bool `json:"forces_replacement,omitempty"`
+}
+
+// Marshal returns the JSON representation of a plan.
+func Marshal(p *terraform.Plan) ([]byte, error) {
+	return json.MarshalIndent(NewPlan(p), "", "  ")
+}
+
+// NewPlan returns the JSON representation of a plan.
+func NewPlan(p *terraform.Plan) *Plan {
+	ret := &Plan{
+		FormatVersion:   FormatVersion,
+		ResourceChanges: []*ResourceChange{},
+	}
+	if p == nil {
+		return ret
+	}
+
+	ret.TerraformVersion = p.TerraformVersion
+	ret.Variables = p.Vars
+	ret.VariableSources = p.VarSources
+	ret.Targets = p.Targets
+
+	// The display model of the plan already leaves out the implementation
+	// details of the diff, such as the destruction of data sources.
+	dispPlan := format.NewPlan(p)
+	ret.ResourceChanges = append(ret.ResourceChanges, newResourceChanges(dispPlan.Resources)...)
+	ret.ResourceDrift = newResourceChanges(dispPlan.Drift)
+	ret.Checks = p.Checks
+
+	if p.State != nil {
+		ret.PriorState = jsonstate.NewState(p.State)
+	}
+
+	return ret
+}
+
+func newResourceChanges(diffs []*format.InstanceDiff) []*ResourceChange {
+	var ret []*ResourceChange
+	for _, d := range diffs {
+		addr := d.Addr.Copy()
+		addr.InstanceTypeSet = false
+
+		rc := &ResourceChange{
+			Address:       addr.String(),
+			ModuleAddress: jsonstate.ModuleAddress(append([]string{"root"}, addr.Path...)),
+			Mode:          jsonstate.Mode(addr.Mode),
+			Type:          addr.Type,
+			Name:          addr.Name,
+			Deposed:       d.Deposed,
+			Change: &Change{
+				Actions:          actions(d.Action),
+				Tainted:          d.Tainted,
+				ReplaceRequested: d.Replace,
+			},
+		}
+		if addr.Index >= 0 {
+			index := addr.Index
+			rc.Index = &index
+		}
+
+		for _, a := range d.Attributes {
+			rc.Change.Attributes = append(rc.Change.Attributes, &AttributeChange{
+				Path:              a.Path,
+				Before:            a.OldValue,
+				After:             a.NewValue,
+				AfterUnknown:      a.NewComputed,
+				Removed:           a.Action == terraform.DiffDestroy,
+				Sensitive:         a.Sensitive,
+				ForcesReplacement: a.ForcesNew,
+			})
+		}
+
+		ret = append(ret, rc)
+	}
+	return ret
+}
+
+// actions returns the actions of a change type.
+func actions(action terraform.DiffChangeType) []string {
+	switch action {
+	case terraform.DiffCreate:
+		return []string{"create"}
+	case terraform.DiffRefresh:
+		return []string{"read"}
+	case terraform.DiffUpdate:
+		return []string{"update"}
+	case terraform.DiffDestroy:
+		return []string{"delete"}
+	case terraform.DiffDestroyCreate:
+		return []string{"delete", "create"}
+	default:
+		return []string{"no-op"}
+	}
+}
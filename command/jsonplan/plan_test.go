@@ -0,0 +1,106 @@
+package jsonplan
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestNewPlan(t *testing.T) {
+	p := &terraform.Plan{
+		TerraformVersion: "0.11.0",
+		Vars:             map[string]interface{}{"region": "us-east-1"},
+		VarSources:       map[string]string{"region": "set by -var"},
+		Diff: &terraform.Diff{
+			Modules: []*terraform.ModuleDiff{
+				{
+					Path: []string{"root", "child"},
+					Resources: map[string]*terraform.InstanceDiff{
+						"test_instance.foo.1": {
+							DestroyTainted: true,
+							Attributes: map[string]*terraform.ResourceAttrDiff{
+								"ami": {Old: "a", New: "b", RequiresNew: true},
+								"ip":  {Old: "1.2.3.4", NewComputed: true},
+							},
+						},
+					},
+				},
+				{
+					Path: []string{"root"},
+					Resources: map[string]*terraform.InstanceDiff{
+						"test_instance.bar": {Destroy: true},
+						"data.test_data.baz": {
+							Attributes: map[string]*terraform.ResourceAttrDiff{
+								"id": {New: "baz", RequiresNew: true},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	got := NewPlan(p)
+	if got.FormatVersion != FormatVersion || got.TerraformVersion != "0.11.0" || got.Variables["region"] != "us-east-1" {
+		t.Fatalf("bad plan: %#v", got)
+	}
+	if got.VariableSources["region"] != "set by -var" {
+		t.Fatalf("wrong variable sources %#v", got.VariableSources)
+	}
+	if got.PriorState != nil || got.ResourceDrift != nil {
+		t.Fatalf("bad plan: %#v", got)
+	}
+
+	one := 1
+	want := []*ResourceChange{
+		{
+			Address: "data.test_data.baz",
+			Mode:    "data",
+			Type:    "test_data",
+			Name:    "baz",
+			Change: &Change{
+				Actions: []string{"read"},
+				Attributes: []*AttributeChange{
+					{Path: "id", After: "baz", ForcesReplacement: true},
+				},
+			},
+		},
+		{
+			Address: "test_instance.bar",
+			Mode:    "managed",
+			Type:    "test_instance",
+			Name:    "bar",
+			Change: &Change{
+				Actions: []string{"delete"},
+			},
+		},
+		{
+			Address:       "module.child.test_instance.foo[1]",
+			ModuleAddress: "module.child",
+			Mode:          "managed",
+			Type:          "test_instance",
+			Name:          "foo",
+			Index:         &one,
+			Change: &Change{
+				Actions: []string{"delete", "create"},
+				Tainted: true,
+				Attributes: []*AttributeChange{
+					{Path: "ami", Before: "a", After: "b", ForcesReplacement: true},
+					{Path: "ip", Before: "1.2.3.4", AfterUnknown: true},
+				},
+			},
+		},
+	}
+	if !reflect.DeepEqual(got.ResourceChanges, want) {
+		t.Fatalf("wrong changes\ngot: %swant: %s", spew.Sdump(got.ResourceChanges), spew.Sdump(want))
+	}
+}
+
+func TestNewPlan_nil(t *testing.T) {
+	got := NewPlan(nil)
+	if got.ResourceChanges == nil || len(got.ResourceChanges) != 0 {
+		t.Fatalf("bad plan: %#v", got)
+	}
+}
@@ -0,0 +1,186 @@
+// Package jsonprovider produces the JSON representation of the schemas of
+// providers, which is the machine-readable output of "terraform providers
+// schema -json".
+//
+// The structure of the JSON representation is part of the interface of the
+// command that produces it, so fields may be added to it but existing ones
+// must not be removed or changed without incrementing FormatVersion.
+package jsonprovider
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/hashicorp/terraform/config/configschema"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+// FormatVersion is the version of the JSON representation of provider
+// schemas.
+const FormatVersion = "0.1"
+
+// Providers is the JSON representation of the schemas of providers.
+type Providers struct {
+	FormatVersion string `json:"format_version"`
+
+	// Schemas maps the names of the providers, such as "aws", to their
+	// schemas.
+	Schemas map[string]*Provider `json:"provider_schemas,omitempty"`
+}
+
+// Provider is the schema of a provider: its own configuration, and that of
+// its resource types and data sources.
+type Provider struct {
+	Provider          *Schema            `json:"provider,omitempty"`
+	ResourceSchemas   map[string]*Schema `json:"resource_schemas,omitempty"`
+	DataSourceSchemas map[string]*Schema `json:"data_source_schemas,omitempty"`
+}
+
+// Schema is the schema of the configuration block of a provider, resource
+// type or data source. Only resource types have versions other than zero.
+type Schema struct {
+	Version int    `json:"version"`
+	Block   *Block `json:"block,omitempty"`
+}
+
+// Block is the schema of a block: its attributes and its nested blocks.
+type Block struct {
+	Attributes map[string]*Attribute `json:"attributes,omitempty"`
+	BlockTypes map[string]*BlockType `json:"block_types,omitempty"`
+}
+
+// Attribute is the schema of an attribute. Its type is in the JSON
+// representation of cty types, such as "string" or ["list","string"].
+type Attribute struct {
+	AttributeType json.RawMessage `json:"type"`
+	Required      bool            `json:"required,omitempty"`
+	Optional      bool            `json:"optional,omitempty"`
+	Computed      bool            `json:"computed,omitempty"`
+	Sensitive     bool            `json:"sensitive,omitempty"`
+}
+
+// BlockType is the schema of a nested block type. The nesting mode is
+// "single", "list", "set" or "map".
+type BlockType struct {
+	NestingMode string `json:"nesting_mode"`
+	Block       *Block `json:"block"`
+	MinItems    int    `json:"min_items,omitempty"`
+	MaxItems    int    `json:"max_items,omitempty"`
+}
+
+// Marshal returns the JSON representation of the schemas of providers,
+// keyed by the provider names.
+func Marshal(schemas map[string]*terraform.ProviderSchema) ([]byte, error) {
+	ret, err := NewProviders(schemas)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(ret, "", "  ")
+}
+
+// NewProviders returns the JSON representation of the schemas of
+// providers, keyed by the provider names. It fails only if an attribute
+// has a type that has no JSON representation.
+func NewProviders(schemas map[string]*terraform.ProviderSchema) (*Providers, error) {
+	ret := &Providers{FormatVersion: FormatVersion}
+	if len(schemas) == 0 {
+		return ret, nil
+	}
+
+	ret.Schemas = make(map[string]*Provider, len(schemas))
+	for name, schema := range schemas {
+		p, err := newProvider(schema)
+		if err != nil {
+			return nil, err
+		}
+		ret.Schemas[name] = p
+	}
+	return ret, nil
+}
+
+func newProvider(schema *terraform.ProviderSchema) (*Provider, error) {
+	ret := &Provider{}
+	if schema == nil {
+		return ret, nil
+	}
+
+	var err error
+	if schema.Provider != nil {
+		ret.Provider = &Schema{}
+		if ret.Provider.Block, err = newBlock(schema.Provider); err != nil {
+			return nil, err
+		}
+	}
+
+	if ret.ResourceSchemas, err = newSchemas(schema.ResourceTypes, schema.ResourceTypeSchemaVersions); err != nil {
+		return nil, err
+	}
+	if ret.DataSourceSchemas, err = newSchemas(schema.DataSources, nil); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+func newSchemas(blocks map[string]*configschema.Block, versions map[string]int) (map[string]*Schema, error) {
+	if len(blocks) == 0 {
+		return nil, nil
+	}
+
+	ret := make(map[string]*Schema, len(blocks))
+	for name, block := range blocks {
+		b, err := newBlock(block)
+		if err != nil {
+			return nil, err
+		}
+		ret[name] = &Schema{Version: versions[name], Block: b}
+	}
+	return ret, nil
+}
+
+func newBlock(block *configschema.Block) (*Block, error) {
+	ret := &Block{}
+	if block == nil {
+		return ret, nil
+	}
+
+	if len(block.Attributes) > 0 {
+		ret.Attributes = make(map[string]*Attribute, len(block.Attributes))
+		for name, attr := range block.Attributes {
+			ty, err := attr.Type.MarshalJSON()
+			if err != nil {
+				return nil, err
+			}
+			ret.Attributes[name] = &Attribute{
+				AttributeType: ty,
+				Required:      attr.Required,
+				Optional:      attr.Optional,
+				Computed:      attr.Computed,
+				Sensitive:     attr.Sensitive,
+			}
+		}
+	}
+
+	if len(block.BlockTypes) > 0 {
+		ret.BlockTypes = make(map[string]*BlockType, len(block.BlockTypes))
+		for name, nested := range block.BlockTypes {
+			b, err := newBlock(&nested.Block)
+			if err != nil {
+				return nil, err
+			}
+			ret.BlockTypes[name] = &BlockType{
+				NestingMode: nestingMode(nested.Nesting),
+				Block:       b,
+				MinItems:    nested.MinItems,
+				MaxItems:    nested.MaxItems,
+			}
+		}
+	}
+
+	return ret, nil
+}
+
+// nestingMode returns the name of a nesting mode, such as "list" for
+// configschema.NestingList.
+func nestingMode(mode configschema.NestingMode) string {
+	return strings.ToLower(strings.TrimPrefix(mode.String(), "Nesting"))
+}
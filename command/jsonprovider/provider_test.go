@@ -0,0 +1,77 @@
+package jsonprovider
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/hashicorp/terraform/config/configschema"
+	"github.com/hashicorp/terraform/terraform"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestNewProviders(t *testing.T) {
+	got, err := NewProviders(map[string]*terraform.ProviderSchema{
+		"empty": nil,
+		"test": {
+			ResourceTypes: map[string]*configschema.Block{
+				"test_instance": {
+					Attributes: map[string]*configschema.Attribute{
+						"tags": {Type: cty.Map(cty.String), Optional: true, Computed: true},
+					},
+					BlockTypes: map[string]*configschema.NestedBlock{
+						"network": {Nesting: configschema.NestingSingle, MinItems: 1, MaxItems: 1},
+					},
+				},
+			},
+			ResourceTypeSchemaVersions: map[string]int{"test_instance": 2},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &Providers{
+		FormatVersion: FormatVersion,
+		Schemas: map[string]*Provider{
+			"empty": {},
+			"test": {
+				ResourceSchemas: map[string]*Schema{
+					"test_instance": {
+						Version: 2,
+						Block: &Block{
+							Attributes: map[string]*Attribute{
+								"tags": {
+									AttributeType: []byte(`["map","string"]`),
+									Optional:      true,
+									Computed:      true,
+								},
+							},
+							BlockTypes: map[string]*BlockType{
+								"network": {
+									NestingMode: "single",
+									Block:       &Block{},
+									MinItems:    1,
+									MaxItems:    1,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong result\ngot: %swant: %s", spew.Sdump(got), spew.Sdump(want))
+	}
+}
+
+func TestNewProviders_none(t *testing.T) {
+	got, err := NewProviders(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (&Providers{FormatVersion: FormatVersion}); !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong result %#v", got)
+	}
+}
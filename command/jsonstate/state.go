@@ -0,0 +1,267 @@
+// Package jsonstate produces the JSON representation of a state, which is
+// the machine-readable output of "terraform show -json" for state files.
+//
+// The structure of the JSON representation is part of the interface of the
+// commands that produce it, so fields may be added to it but existing ones
+// must not be removed or changed without incrementing FormatVersion.
+package jsonstate
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform/config"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+// FormatVersion is the version of the JSON representation of states.
+const FormatVersion = "0.1"
+
+// State is the JSON representation of a state.
+type State struct {
+	FormatVersion    string `json:"format_version"`
+	TerraformVersion string `json:"terraform_version,omitempty"`
+	Serial           int64  `json:"serial,omitempty"`
+	Lineage          string `json:"lineage,omitempty"`
+
+	// Values is nil if there's no state.
+	Values *Values `json:"values,omitempty"`
+
+	// Checks are the results of the configuration's check blocks at the
+	// end of the last apply.
+	Checks []*terraform.CheckResult `json:"checks,omitempty"`
+}
+
+// Values are the outputs of the root module and the resources of every
+// module of a state.
+type Values struct {
+	Outputs    map[string]*Output `json:"outputs,omitempty"`
+	RootModule *Module            `json:"root_module"`
+}
+
+// Output is an output of the root module.
+type Output struct {
+	Sensitive bool        `json:"sensitive"`
+	Type      string      `json:"type"`
+	Value     interface{} `json:"value"`
+}
+
+// Module holds the resources of a module, and its child modules.
+type Module struct {
+	// Address is the address of the module, such as "module.foo", and is
+	// empty for the root module.
+	Address      string      `json:"address,omitempty"`
+	Resources    []*Resource `json:"resources,omitempty"`
+	ChildModules []*Module   `json:"child_modules,omitempty"`
+}
+
+// Resource is an instance of a resource.
+type Resource struct {
+	Address string `json:"address"`
+	Mode    string `json:"mode"`
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+
+	// Index is the index of the instance if the resource has a count.
+	Index *int `json:"index,omitempty"`
+
+	ProviderName string `json:"provider_name"`
+
+	// ID is the ID of the instance in the remote system.
+	ID string `json:"id,omitempty"`
+
+	// Attributes are the flattened attributes of the instance, as stored in
+	// the state.
+	Attributes          map[string]string `json:"attributes"`
+	SensitiveAttributes []string          `json:"sensitive_attributes,omitempty"`
+
+	// Values are the attributes decoded into typed values, which are only
+	// set when the schema of the resource type is known. See
+	// AttributeValues.
+	Values map[string]interface{} `json:"values,omitempty"`
+
+	Tainted bool `json:"tainted,omitempty"`
+
+	// Deposed is set for the instances that were replaced and are awaiting
+	// destruction.
+	Deposed bool `json:"deposed,omitempty"`
+
+	DependsOn []string `json:"depends_on,omitempty"`
+
+	addr *terraform.ResourceAddress
+}
+
+// ResourceList is the JSON representation of a list of resource instances,
+// as output by "terraform state list -json".
+type ResourceList struct {
+	FormatVersion string      `json:"format_version"`
+	Resources     []*Resource `json:"resources"`
+}
+
+// Marshal returns the JSON representation of a state, which may be nil.
+func Marshal(s *terraform.State) ([]byte, error) {
+	return json.MarshalIndent(NewState(s), "", "  ")
+}
+
+// NewState returns the JSON representation of a state, which may be nil.
+func NewState(s *terraform.State) *State {
+	ret := &State{FormatVersion: FormatVersion}
+	if s == nil {
+		return ret
+	}
+
+	ret.TerraformVersion = s.TFVersion
+	ret.Serial = s.Serial
+	ret.Lineage = s.Lineage
+	ret.Checks = s.Checks
+	ret.Values = &Values{RootModule: &Module{}}
+
+	if root := s.RootModule(); root != nil && len(root.Outputs) > 0 {
+		ret.Values.Outputs = make(map[string]*Output, len(root.Outputs))
+		for name, o := range root.Outputs {
+			ret.Values.Outputs[name] = &Output{
+				Sensitive: o.Sensitive,
+				Type:      o.Type,
+				Value:     o.Value,
+			}
+		}
+	}
+
+	// The modules of the state are a flat list, so the child modules are
+	// attached to their parents by path, from the shortest.
+	mods := make([]*terraform.ModuleState, len(s.Modules))
+	copy(mods, s.Modules)
+	sort.SliceStable(mods, func(i, j int) bool {
+		return len(mods[i].Path) < len(mods[j].Path)
+	})
+
+	byPath := map[string]*Module{"root": ret.Values.RootModule}
+	for _, ms := range mods {
+		m := moduleForPath(byPath, ms.Path)
+		m.Resources = append(m.Resources, newResources(ms)...)
+	}
+
+	return ret
+}
+
+// Resources returns the JSON representations of all of the resource instances
+// of a state, which may be nil, in a flat list sorted by address.
+func Resources(s *terraform.State) []*Resource {
+	if s == nil {
+		return nil
+	}
+
+	var ret []*Resource
+	for _, ms := range s.Modules {
+		ret = append(ret, newResources(ms)...)
+	}
+	sort.SliceStable(ret, func(i, j int) bool {
+		if ret[i].Address != ret[j].Address {
+			return ret[i].addr.Less(ret[j].addr)
+		}
+		return !ret[i].Deposed && ret[j].Deposed
+	})
+	return ret
+}
+
+// ResourceAddress returns the address of the resource instance.
+func (r *Resource) ResourceAddress() *terraform.ResourceAddress {
+	return r.addr.Copy()
+}
+
+// moduleForPath returns the module with the given path, adding it and any
+// missing parent to the tree.
+func moduleForPath(byPath map[string]*Module, path []string) *Module {
+	key := strings.Join(path, ".")
+	if m, ok := byPath[key]; ok {
+		return m
+	}
+
+	parent := moduleForPath(byPath, path[:len(path)-1])
+	m := &Module{Address: ModuleAddress(path)}
+	parent.ChildModules = append(parent.ChildModules, m)
+	byPath[key] = m
+	return m
+}
+
+// ModuleAddress returns the address of the module with the given path, which
+// starts with "root", such as "module.foo.module.bar".
+func ModuleAddress(path []string) string {
+	var parts []string
+	for _, name := range path[1:] {
+		parts = append(parts, "module", name)
+	}
+	return strings.Join(parts, ".")
+}
+
+// Mode returns the name of a resource mode in the JSON representations.
+func Mode(mode config.ResourceMode) string {
+	if mode == config.DataResourceMode {
+		return "data"
+	}
+	return "managed"
+}
+
+// newResources returns the instances of the resources of a module, sorted by
+// address.
+func newResources(ms *terraform.ModuleState) []*Resource {
+	var ret []*Resource
+	for k, rs := range ms.Resources {
+		key, err := terraform.ParseResourceStateKey(k)
+		if err != nil {
+			// should never happen; indicates an invalid state
+			continue
+		}
+
+		addr := &terraform.ResourceAddress{
+			Path:  ms.Path[1:],
+			Index: key.Index,
+			Name:  key.Name,
+			Type:  key.Type,
+			Mode:  key.Mode,
+		}
+
+		newResource := func(is *terraform.InstanceState) *Resource {
+			r := &Resource{
+				Address:      addr.String(),
+				Mode:         Mode(key.Mode),
+				Type:         key.Type,
+				Name:         key.Name,
+				ProviderName: config.ResourceProviderFullName(key.Type, rs.Provider),
+				DependsOn:    rs.Dependencies,
+
+				addr: addr,
+			}
+			if key.Index >= 0 {
+				index := key.Index
+				r.Index = &index
+			}
+			if is != nil {
+				r.ID = is.ID
+				r.Attributes = is.Attributes
+				r.SensitiveAttributes = is.SensitiveAttributes
+				r.Tainted = is.Tainted
+			}
+			return r
+		}
+
+		if rs.Primary != nil {
+			ret = append(ret, newResource(rs.Primary))
+		}
+		for _, is := range rs.Deposed {
+			r := newResource(is)
+			r.Deposed = true
+			ret = append(ret, r)
+		}
+	}
+
+	sort.SliceStable(ret, func(i, j int) bool {
+		if ret[i].Address != ret[j].Address {
+			return ret[i].addr.Less(ret[j].addr)
+		}
+		return !ret[i].Deposed && ret[j].Deposed
+	})
+
+	return ret
+}
@@ -0,0 +1,143 @@
+package jsonstate
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestNewState(t *testing.T) {
+	s := &terraform.State{
+		Serial:  3,
+		Lineage: "lineage",
+		Modules: []*terraform.ModuleState{
+			{
+				Path: []string{"root", "child", "grandchild"},
+				Resources: map[string]*terraform.ResourceState{
+					"data.test_data.baz": {
+						Type:    "test_data",
+						Primary: &terraform.InstanceState{ID: "baz"},
+					},
+				},
+			},
+			{
+				Path: []string{"root"},
+				Outputs: map[string]*terraform.OutputState{
+					"out": {Type: "string", Value: "hello", Sensitive: true},
+				},
+				Resources: map[string]*terraform.ResourceState{
+					"test_instance.foo.10": {
+						Type:    "test_instance",
+						Primary: &terraform.InstanceState{ID: "foo10"},
+					},
+					"test_instance.foo.2": {
+						Type:     "test_instance",
+						Provider: "provider.test.alias",
+						Primary: &terraform.InstanceState{
+							ID:         "foo2",
+							Attributes: map[string]string{"id": "foo2"},
+							Tainted:    true,
+						},
+						Deposed: []*terraform.InstanceState{
+							{ID: "old"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	got := NewState(s)
+	if got.FormatVersion != FormatVersion || got.Serial != 3 || got.Lineage != "lineage" {
+		t.Fatalf("bad state: %#v", got)
+	}
+
+	if o := got.Values.Outputs["out"]; o == nil || o.Value != "hello" || !o.Sensitive {
+		t.Fatalf("bad outputs: %#v", got.Values.Outputs)
+	}
+
+	root := got.Values.RootModule
+	var addrs []string
+	for _, r := range root.Resources {
+		addrs = append(addrs, r.Address)
+	}
+	want := []string{"test_instance.foo[2]", "test_instance.foo[2]", "test_instance.foo[10]"}
+	if len(addrs) != len(want) {
+		t.Fatalf("bad resources: %v", addrs)
+	}
+	for i := range want {
+		if addrs[i] != want[i] {
+			t.Fatalf("bad resources: %v", addrs)
+		}
+	}
+
+	foo := root.Resources[0]
+	if foo.Deposed || !foo.Tainted || *foo.Index != 2 || foo.ProviderName != "test.alias" || foo.Attributes["id"] != "foo2" {
+		t.Fatalf("bad resource: %#v", foo)
+	}
+	if !root.Resources[1].Deposed {
+		t.Fatalf("expected a deposed instance: %#v", root.Resources[1])
+	}
+
+	// the intermediate module is added to the tree
+	if len(root.ChildModules) != 1 || root.ChildModules[0].Address != "module.child" {
+		t.Fatalf("bad child modules: %#v", root.ChildModules)
+	}
+	grandchild := root.ChildModules[0].ChildModules[0]
+	if grandchild.Address != "module.child.module.grandchild" || len(grandchild.Resources) != 1 {
+		t.Fatalf("bad grandchild module: %#v", grandchild)
+	}
+	if r := grandchild.Resources[0]; r.Mode != "data" || r.Address != "module.child.module.grandchild.data.test_data.baz" {
+		t.Fatalf("bad data resource: %#v", r)
+	}
+}
+
+func TestNewState_nil(t *testing.T) {
+	got := NewState(nil)
+	if got.FormatVersion != FormatVersion || got.Values != nil {
+		t.Fatalf("bad state: %#v", got)
+	}
+}
+
+func TestResources(t *testing.T) {
+	s := &terraform.State{
+		Modules: []*terraform.ModuleState{
+			{
+				Path: []string{"root", "child"},
+				Resources: map[string]*terraform.ResourceState{
+					"test_instance.foo": {
+						Type:    "test_instance",
+						Primary: &terraform.InstanceState{ID: "child"},
+					},
+				},
+			},
+			{
+				Path: []string{"root"},
+				Resources: map[string]*terraform.ResourceState{
+					"test_instance.foo": {
+						Type:    "test_instance",
+						Primary: &terraform.InstanceState{ID: "foo"},
+						Deposed: []*terraform.InstanceState{{ID: "old"}},
+					},
+				},
+			},
+		},
+	}
+
+	got := Resources(s)
+	if len(got) != 3 {
+		t.Fatalf("wrong resources %#v", got)
+	}
+	for i, want := range []string{"foo", "old", "child"} {
+		if got[i].ID != want {
+			t.Fatalf("wrong resource %d: %#v", i, got[i])
+		}
+	}
+	if addr := got[2].ResourceAddress(); addr.String() != "module.child.test_instance.foo" {
+		t.Fatalf("wrong address %s", addr)
+	}
+
+	if got := Resources(nil); got != nil {
+		t.Fatalf("wrong resources for a nil state %#v", got)
+	}
+}
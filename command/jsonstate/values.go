@@ -0,0 +1,155 @@
+package jsonstate
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform/config"
+	"github.com/hashicorp/terraform/config/configschema"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// AttributeValues decodes the flattened attributes of a resource instance,
+// as stored in the state, into values typed according to the schema of the
+// resource type: numbers and bools are decoded from their string forms,
+// and lists, sets, maps and nested blocks are rebuilt from their elements.
+//
+// Attributes in the schema that aren't in the state are null, and those in
+// the state that aren't in the schema are left out.
+func AttributeValues(attrs map[string]string, schema *configschema.Block) map[string]interface{} {
+	return blockValue(attrs, "", schema)
+}
+
+func blockValue(attrs map[string]string, prefix string, schema *configschema.Block) map[string]interface{} {
+	ret := make(map[string]interface{})
+	for name, attr := range schema.Attributes {
+		ret[name] = attributeValue(attrs, prefix+name, attr.Type)
+	}
+
+	for name, nested := range schema.BlockTypes {
+		k := prefix + name
+		switch nested.Nesting {
+		case configschema.NestingSingle:
+			// Single blocks are stored as lists of one element
+			keys := elementKeys(attrs, k, false)
+			if len(keys) == 0 {
+				ret[name] = nil
+				continue
+			}
+			ret[name] = blockValue(attrs, k+"."+keys[0]+".", &nested.Block)
+		case configschema.NestingMap:
+			m := make(map[string]interface{})
+			for _, key := range elementKeys(attrs, k, false) {
+				m[key] = blockValue(attrs, k+"."+key+".", &nested.Block)
+			}
+			ret[name] = m
+		default:
+			l := make([]interface{}, 0)
+			for _, key := range elementKeys(attrs, k, false) {
+				l = append(l, blockValue(attrs, k+"."+key+".", &nested.Block))
+			}
+			ret[name] = l
+		}
+	}
+
+	return ret
+}
+
+func attributeValue(attrs map[string]string, k string, ty cty.Type) interface{} {
+	switch {
+	case ty.IsListType() || ty.IsSetType():
+		if _, ok := attrs[k+".#"]; !ok {
+			return nil
+		}
+		l := make([]interface{}, 0)
+		for _, key := range elementKeys(attrs, k, false) {
+			l = append(l, attributeValue(attrs, k+"."+key, ty.ElementType()))
+		}
+		return l
+
+	case ty.IsTupleType():
+		if _, ok := attrs[k+".#"]; !ok {
+			return nil
+		}
+		l := make([]interface{}, 0)
+		for i, ety := range ty.TupleElementTypes() {
+			l = append(l, attributeValue(attrs, k+"."+strconv.Itoa(i), ety))
+		}
+		return l
+
+	case ty.IsMapType():
+		if _, ok := attrs[k+".%"]; !ok {
+			return nil
+		}
+		// The keys of maps of primitive values may contain dots.
+		ety := ty.ElementType()
+		m := make(map[string]interface{})
+		for _, key := range elementKeys(attrs, k, ety.IsPrimitiveType()) {
+			m[key] = attributeValue(attrs, k+"."+key, ety)
+		}
+		return m
+
+	case ty.IsObjectType():
+		if _, ok := attrs[k+".%"]; !ok {
+			return nil
+		}
+		m := make(map[string]interface{})
+		for name, aty := range ty.AttributeTypes() {
+			m[name] = attributeValue(attrs, k+"."+name, aty)
+		}
+		return m
+	}
+
+	v, ok := attrs[k]
+	if !ok || v == config.UnknownVariableValue {
+		return nil
+	}
+	switch ty {
+	case cty.Number:
+		if _, err := strconv.ParseFloat(v, 64); err == nil {
+			return json.Number(v)
+		}
+	case cty.Bool:
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
+	}
+	return v
+}
+
+// elementKeys returns the keys of the elements of the list, set or map
+// attribute k, sorted numerically for lists and sets. If whole is true,
+// keys run to the end of the flattened attribute names.
+func elementKeys(attrs map[string]string, k string, whole bool) []string {
+	prefix := k + "."
+	seen := make(map[string]bool)
+	var keys []string
+	for name := range attrs {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		key := name[len(prefix):]
+		if !whole {
+			if i := strings.Index(key, "."); i >= 0 {
+				key = key[:i]
+			}
+		}
+		if key == "#" || key == "%" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		a, errA := strconv.Atoi(keys[i])
+		b, errB := strconv.Atoi(keys[j])
+		if errA == nil && errB == nil {
+			return a < b
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
@@ -0,0 +1,83 @@
+package jsonstate
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform/config/configschema"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestAttributeValues(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"id":      {Type: cty.String, Computed: true},
+			"count":   {Type: cty.Number, Optional: true},
+			"enabled": {Type: cty.Bool, Optional: true},
+			"names":   {Type: cty.List(cty.String), Optional: true},
+			"tags":    {Type: cty.Map(cty.String), Optional: true},
+			"missing": {Type: cty.String, Optional: true},
+		},
+		BlockTypes: map[string]*configschema.NestedBlock{
+			"disk": {
+				Nesting: configschema.NestingList,
+				Block: configschema.Block{
+					Attributes: map[string]*configschema.Attribute{
+						"size": {Type: cty.Number, Optional: true},
+					},
+				},
+			},
+			"network": {
+				Nesting: configschema.NestingSingle,
+				Block: configschema.Block{
+					Attributes: map[string]*configschema.Attribute{
+						"name": {Type: cty.String, Optional: true},
+					},
+				},
+			},
+		},
+	}
+
+	attrs := map[string]string{
+		"id":                 "foo",
+		"count":              "2",
+		"enabled":            "true",
+		"names.#":            "11",
+		"names.0":            "a",
+		"names.1":            "b",
+		"names.2":            "c",
+		"names.3":            "d",
+		"names.4":            "e",
+		"names.5":            "f",
+		"names.6":            "g",
+		"names.7":            "h",
+		"names.8":            "i",
+		"names.9":            "j",
+		"names.10":           "k",
+		"tags.%":             "1",
+		"tags.example.com/x": "y",
+		"disk.#":             "1",
+		"disk.0.size":        "10",
+		"network.#":          "1",
+		"network.0.name":     "net",
+		"unknown":            "ignored",
+	}
+
+	got := AttributeValues(attrs, schema)
+	want := map[string]interface{}{
+		"id":      "foo",
+		"count":   json.Number("2"),
+		"enabled": true,
+		"names":   []interface{}{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k"},
+		"tags":    map[string]interface{}{"example.com/x": "y"},
+		"missing": nil,
+		"disk": []interface{}{
+			map[string]interface{}{"size": json.Number("10")},
+		},
+		"network": map[string]interface{}{"name": "net"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong values\ngot:  %#v\nwant: %#v", got, want)
+	}
+}
@@ -0,0 +1,349 @@
+package command
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	cleanhttp "github.com/hashicorp/go-cleanhttp"
+	"github.com/hashicorp/terraform/svchost"
+	"github.com/hashicorp/terraform/svchost/auth"
+	"github.com/hashicorp/terraform/svchost/disco"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+// loginServiceID is the service that hosts publish to let Terraform obtain
+// API tokens for them with "terraform login".
+const loginServiceID = "login.v1"
+
+// loginTokenTimeout is how long the token request may take.
+const loginTokenTimeout = 30 * time.Second
+
+// LoginCommand is a Command implementation that obtains an API token for a
+// Terraform-native service host and stores it as the credentials for the
+// host.
+type LoginCommand struct {
+	Meta
+}
+
+func (c *LoginCommand) Run(args []string) int {
+	args, err := c.Meta.process(args, false)
+	if err != nil {
+		return 1
+	}
+
+	cmdFlags := c.Meta.flagSet("login")
+	cmdFlags.Usage = func() { c.Ui.Error(c.Help()) }
+	if err := cmdFlags.Parse(args); err != nil {
+		return 1
+	}
+
+	args = cmdFlags.Args()
+	if len(args) != 1 {
+		c.Ui.Error("The login command expects exactly one argument: the hostname to log in to.")
+		cmdFlags.Usage()
+		return 1
+	}
+
+	host, err := svchost.ForComparison(args[0])
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Invalid hostname %q: %s", args[0], err))
+		return 1
+	}
+	if c.Credentials == nil || c.Services == nil {
+		// Only in unusual embedding situations.
+		c.Ui.Error("Credentials can't be stored in this context.")
+		return 1
+	}
+
+	client, err := c.Services.Discover(host).ServiceOAuthClient(loginServiceID)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Host %s doesn't support login correctly: %s", host.ForDisplay(), err))
+		return 1
+	}
+	if client == nil {
+		c.Ui.Error(fmt.Sprintf(
+			"Host %s doesn't support automatic login.\n\n"+
+				"Obtain an API token from the host some other way and set it in a "+
+				"\"credentials\" block of the CLI configuration instead.",
+			host.ForDisplay()))
+		return 1
+	}
+	if !client.SupportsGrantType(disco.OAuthGrantTypeAuthzCode) {
+		c.Ui.Error(fmt.Sprintf(
+			"Host %s doesn't support any login method that this version of Terraform supports.",
+			host.ForDisplay()))
+		return 1
+	}
+
+	ok, err := c.confirm(&terraform.InputOpts{
+		Id:    "login",
+		Query: "Do you want to proceed?",
+		Description: fmt.Sprintf(
+			"Terraform will request an API token for %s using your browser.\n\n"+
+				"If login is successful, Terraform will store the token as the "+
+				"credentials for %s, according to the CLI configuration.\n"+
+				"Only 'yes' will be accepted to confirm.",
+			host.ForDisplay(), host.ForDisplay()),
+	})
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Login requires interactive confirmation: %s", err))
+		return 1
+	}
+	if !ok {
+		c.Ui.Output("Login cancelled.")
+		return 1
+	}
+
+	token, err := c.authzCodeLogin(host, client)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to obtain an API token for %s: %s", host.ForDisplay(), err))
+		return 1
+	}
+
+	if err := c.Credentials.StoreForHost(host, auth.HostCredentialsToken(token)); err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to save the API token for %s: %s", host.ForDisplay(), err))
+		return 1
+	}
+
+	c.Ui.Output(c.Colorize().Color(strings.TrimSpace(fmt.Sprintf(outputLoginSuccess, host.ForDisplay()))))
+	return 0
+}
+
+// authzCodeLogin obtains an API token with the OAuth authorization code
+// flow, with a proof key (PKCE) to protect the code. The user logs in in a
+// web browser, which is redirected to a server that Terraform runs on
+// localhost to receive the authorization code.
+func (c *LoginCommand) authzCodeLogin(host svchost.Hostname, client *disco.OAuthClient) (string, error) {
+	listener, err := listenInRange(client.MinPort, client.MaxPort)
+	if err != nil {
+		return "", err
+	}
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+	redirectURL := "http://localhost:" + port + "/login"
+
+	state, err := randomURLString(16)
+	if err != nil {
+		return "", err
+	}
+	verifier, err := randomURLString(32)
+	if err != nil {
+		return "", err
+	}
+	challenge := sha256.Sum256([]byte(verifier))
+
+	authzURL := *client.AuthorizationURL
+	q := authzURL.Query()
+	q.Set("response_type", "code")
+	q.Set("client_id", client.ID)
+	q.Set("redirect_uri", redirectURL)
+	q.Set("state", state)
+	q.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	q.Set("code_challenge_method", "S256")
+	authzURL.RawQuery = q.Encode()
+
+	type callback struct {
+		code string
+		err  error
+	}
+	callbackCh := make(chan callback, 1)
+	server := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/login" {
+				http.NotFound(w, r)
+				return
+			}
+
+			// Requests that aren't from the authorization server are
+			// ignored, since anything running locally can send them.
+			q := r.URL.Query()
+			if q.Get("state") != state {
+				http.Error(w, "Invalid state.", http.StatusBadRequest)
+				return
+			}
+
+			var cb callback
+			if e := q.Get("error"); e != "" {
+				msg := e
+				if desc := q.Get("error_description"); desc != "" {
+					msg = fmt.Sprintf("%s (%s)", desc, e)
+				}
+				cb.err = fmt.Errorf("the login server returned an error: %s", msg)
+			} else if cb.code = q.Get("code"); cb.code == "" {
+				cb.err = errors.New("the login server didn't return an authorization code")
+			}
+
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			if cb.err != nil {
+				fmt.Fprintf(w, loginCallbackPage, "Login failed: "+html.EscapeString(cb.err.Error()))
+			} else {
+				fmt.Fprintf(w, loginCallbackPage, "The login server has returned an authorization code to Terraform.")
+			}
+
+			select {
+			case callbackCh <- cb:
+			default:
+			}
+		}),
+	}
+	go server.Serve(listener)
+	defer server.Close()
+
+	c.Ui.Output(fmt.Sprintf(
+		"\nTerraform must now open a web browser to the login page for %s.\n\n"+
+			"If a browser doesn't open automatically, open the following URL to proceed:\n"+
+			"    %s\n\nTerraform will wait for the login to complete...\n",
+		host.ForDisplay(), authzURL.String()))
+	if c.BrowserLauncher != nil {
+		if err := c.BrowserLauncher.OpenURL(authzURL.String()); err != nil {
+			log.Printf("[WARN] Failed to open a web browser: %s", err)
+		}
+	}
+
+	var cb callback
+	select {
+	case cb = <-callbackCh:
+	case <-c.ShutdownCh:
+		return "", errors.New("interrupted")
+	}
+	if cb.err != nil {
+		return "", cb.err
+	}
+
+	return exchangeAuthzCode(client, cb.code, redirectURL, verifier)
+}
+
+// exchangeAuthzCode asks the token endpoint of the client for an API token
+// in exchange for an authorization code.
+func exchangeAuthzCode(client *disco.OAuthClient, code, redirectURL, verifier string) (string, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("client_id", client.ID)
+	form.Set("redirect_uri", redirectURL)
+	form.Set("code_verifier", verifier)
+
+	ctx, cancel := context.WithTimeout(context.Background(), loginTokenTimeout)
+	defer cancel()
+
+	req, err := http.NewRequest("POST", client.TokenURL.String(), strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := cleanhttp.DefaultClient().Do(req)
+	if err != nil {
+		return "", fmt.Errorf("token request failed: %s", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the token response: %s", err)
+	}
+
+	var result struct {
+		AccessToken      string `json:"access_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil && resp.StatusCode == http.StatusOK {
+		return "", fmt.Errorf("malformed token response: %s", err)
+	}
+	if resp.StatusCode != http.StatusOK || result.AccessToken == "" {
+		msg := result.ErrorDescription
+		if msg == "" {
+			msg = result.Error
+		}
+		if msg == "" {
+			msg = resp.Status
+		}
+		return "", fmt.Errorf("the token request was rejected: %s", msg)
+	}
+	return result.AccessToken, nil
+}
+
+// listenInRange listens on the first free port of localhost in the given
+// range.
+func listenInRange(minPort, maxPort uint16) (net.Listener, error) {
+	for port := int(minPort); port <= int(maxPort); port++ {
+		l, err := net.Listen("tcp4", net.JoinHostPort("127.0.0.1", strconv.Itoa(port)))
+		if err == nil {
+			return l, nil
+		}
+	}
+	return nil, fmt.Errorf("no free port in the range %d-%d to receive the login result on", minPort, maxPort)
+}
+
+// randomURLString returns n random bytes encoded so that they can be sent
+// in URLs without escaping.
+func randomURLString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate random data: %s", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func (c *LoginCommand) Help() string {
+	helpText := `
+Usage: terraform login [options] HOSTNAME
+
+  Obtains an API token for the given Terraform-native service host, such as
+  a module registry or a remote backend, by logging in with a web browser,
+  and saves it as the credentials for the host.
+
+  Unless a credentials helper is configured, the token is saved in the
+  credentials.tfrc.json file in the CLI configuration directory. Credentials
+  set by hand in a "credentials" block of the CLI configuration must be
+  removed first.
+
+  The host must support automatic login. For other hosts, obtain a token
+  some other way and set it in the CLI configuration by hand.
+
+Options:
+
+  -input=true         Ask for confirmation before logging in. Login can't
+                      proceed without it.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *LoginCommand) Synopsis() string {
+	return "Obtain and save credentials for a remote host"
+}
+
+const outputLoginSuccess = `
+[reset][bold][green]Success![reset][green] Terraform has obtained and saved an API token for %s.[reset]
+
+The token will be used by any future Terraform command that makes
+authenticated requests to the host.
+`
+
+// loginCallbackPage is the page the web browser shows once it's been
+// redirected back to Terraform, with a message that's already HTML.
+const loginCallbackPage = `<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Terraform Login</title></head>
+<body>
+<p>%s</p>
+<p>Now close this page and return to the terminal.</p>
+</body>
+</html>
+`
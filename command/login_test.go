@@ -0,0 +1,198 @@
+package command
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform/command/webbrowser"
+	"github.com/hashicorp/terraform/svchost"
+	"github.com/hashicorp/terraform/svchost/auth"
+	"github.com/hashicorp/terraform/svchost/disco"
+	"github.com/mitchellh/cli"
+)
+
+func TestLogin(t *testing.T) {
+	ts := httptest.NewServer(newTestOAuthServer(t))
+	defer ts.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	defer testInputMap(t, map[string]string{
+		"login": "yes",
+	})()
+
+	creds := newTestCredentialsSource()
+	ui := new(cli.MockUi)
+	c := &LoginCommand{
+		Meta: Meta{
+			Ui:              ui,
+			Services:        testLoginServices(ts),
+			Credentials:     creds,
+			BrowserLauncher: webbrowser.NewMockLauncher(ctx),
+		},
+	}
+
+	if code := c.Run([]string{"example.com"}); code != 0 {
+		t.Fatalf("bad: %d\n%s\n%s", code, ui.OutputWriter.String(), ui.ErrorWriter.String())
+	}
+
+	got, err := creds.ForHost(svchost.Hostname("example.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := auth.HostCredentialsToken("good-token"); got != want {
+		t.Fatalf("wrong credentials %#v; want %#v", got, want)
+	}
+	if !strings.Contains(ui.OutputWriter.String(), "Success!") {
+		t.Fatalf("no success message in the output:\n%s", ui.OutputWriter.String())
+	}
+}
+
+func TestLogin_cancelled(t *testing.T) {
+	ts := httptest.NewServer(newTestOAuthServer(t))
+	defer ts.Close()
+
+	defer testInputMap(t, map[string]string{
+		"login": "no",
+	})()
+
+	creds := newTestCredentialsSource()
+	ui := new(cli.MockUi)
+	c := &LoginCommand{
+		Meta: Meta{
+			Ui:          ui,
+			Services:    testLoginServices(ts),
+			Credentials: creds,
+		},
+	}
+
+	if code := c.Run([]string{"example.com"}); code != 1 {
+		t.Fatalf("bad: %d\n%s\n%s", code, ui.OutputWriter.String(), ui.ErrorWriter.String())
+	}
+	if len(creds.creds) > 0 {
+		t.Fatalf("credentials were stored: %#v", creds.creds)
+	}
+}
+
+func TestLogin_unsupported(t *testing.T) {
+	services := disco.NewDisco()
+	services.ForceHostServices(svchost.Hostname("example.com"), map[string]interface{}{
+		"modules.v1": "https://example.com/modules/",
+	})
+
+	ui := new(cli.MockUi)
+	c := &LoginCommand{
+		Meta: Meta{
+			Ui:          ui,
+			Services:    services,
+			Credentials: newTestCredentialsSource(),
+		},
+	}
+
+	if code := c.Run([]string{"example.com"}); code != 1 {
+		t.Fatalf("bad: %d\n%s\n%s", code, ui.OutputWriter.String(), ui.ErrorWriter.String())
+	}
+	if got := ui.ErrorWriter.String(); !strings.Contains(got, "doesn't support automatic login") {
+		t.Fatalf("wrong error:\n%s", got)
+	}
+}
+
+// testLoginServices returns services that give the login service of the
+// given test OAuth server for example.com.
+func testLoginServices(ts *httptest.Server) *disco.Disco {
+	services := disco.NewDisco()
+	services.ForceHostServices(svchost.Hostname("example.com"), map[string]interface{}{
+		"login.v1": map[string]interface{}{
+			"client": "terraform-cli",
+			"authz":  ts.URL + "/authz",
+			"token":  ts.URL + "/token",
+			"ports":  []interface{}{float64(20000), float64(20100)},
+		},
+	})
+	return services
+}
+
+// newTestOAuthServer returns an OAuth authorization server that grants the
+// token "good-token" to the client "terraform-cli" without asking the user
+// anything, checking the proof key of the code.
+func newTestOAuthServer(t *testing.T) http.Handler {
+	challenges := map[string]string{}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/authz", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("client_id") != "terraform-cli" || q.Get("response_type") != "code" ||
+			q.Get("code_challenge_method") != "S256" {
+			t.Errorf("invalid authorization request: %s", r.URL)
+			http.Error(w, "invalid request", http.StatusBadRequest)
+			return
+		}
+		challenges["good-code"] = q.Get("code_challenge")
+
+		redirect, err := url.Parse(q.Get("redirect_uri"))
+		if err != nil {
+			t.Errorf("invalid redirect URI: %s", err)
+			http.Error(w, "invalid request", http.StatusBadRequest)
+			return
+		}
+		rq := redirect.Query()
+		rq.Set("code", "good-code")
+		rq.Set("state", q.Get("state"))
+		redirect.RawQuery = rq.Encode()
+		http.Redirect(w, r, redirect.String(), http.StatusFound)
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("invalid token request: %s", err)
+			return
+		}
+		challenge := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+		code := r.PostForm.Get("code")
+		if r.PostForm.Get("grant_type") != "authorization_code" ||
+			challenges[code] != base64.RawURLEncoding.EncodeToString(challenge[:]) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{
+			"access_token": "good-token",
+			"token_type":   "bearer",
+		})
+	})
+	return mux
+}
+
+// testCredentialsSource is an auth.CredentialsSource that keeps
+// credentials in memory.
+type testCredentialsSource struct {
+	creds map[svchost.Hostname]map[string]interface{}
+}
+
+func newTestCredentialsSource() *testCredentialsSource {
+	return &testCredentialsSource{creds: map[svchost.Hostname]map[string]interface{}{}}
+}
+
+func (s *testCredentialsSource) ForHost(host svchost.Hostname) (auth.HostCredentials, error) {
+	return auth.HostCredentialsFromMap(s.creds[host]), nil
+}
+
+func (s *testCredentialsSource) StoreForHost(host svchost.Hostname, credentials auth.HostCredentialsWritable) error {
+	s.creds[host] = credentials.ToStore()
+	return nil
+}
+
+func (s *testCredentialsSource) ForgetForHost(host svchost.Hostname) error {
+	delete(s.creds, host)
+	return nil
+}
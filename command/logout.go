@@ -0,0 +1,87 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform/svchost"
+)
+
+// LogoutCommand is a Command implementation that discards the stored
+// credentials for a Terraform-native service host.
+type LogoutCommand struct {
+	Meta
+}
+
+func (c *LogoutCommand) Run(args []string) int {
+	args, err := c.Meta.process(args, false)
+	if err != nil {
+		return 1
+	}
+
+	cmdFlags := c.Meta.flagSet("logout")
+	cmdFlags.Usage = func() { c.Ui.Error(c.Help()) }
+	if err := cmdFlags.Parse(args); err != nil {
+		return 1
+	}
+
+	args = cmdFlags.Args()
+	if len(args) != 1 {
+		c.Ui.Error("The logout command expects exactly one argument: the hostname to log out of.")
+		cmdFlags.Usage()
+		return 1
+	}
+
+	host, err := svchost.ForComparison(args[0])
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Invalid hostname %q: %s", args[0], err))
+		return 1
+	}
+	if c.Credentials == nil {
+		// Only in unusual embedding situations.
+		c.Ui.Error("Credentials can't be discarded in this context.")
+		return 1
+	}
+
+	creds, err := c.Credentials.ForHost(host)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to look up the credentials for %s: %s", host.ForDisplay(), err))
+		return 1
+	}
+	if creds == nil {
+		c.Ui.Output(fmt.Sprintf("No credentials for %s are stored.", host.ForDisplay()))
+		return 0
+	}
+
+	if err := c.Credentials.ForgetForHost(host); err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to discard the credentials for %s: %s", host.ForDisplay(), err))
+		return 1
+	}
+
+	c.Ui.Output(c.Colorize().Color(strings.TrimSpace(fmt.Sprintf(outputLogoutSuccess, host.ForDisplay()))))
+	return 0
+}
+
+func (c *LogoutCommand) Help() string {
+	helpText := `
+Usage: terraform logout [options] HOSTNAME
+
+  Discards the stored credentials for the given Terraform-native service
+  host, such as those saved by "terraform login".
+
+  The credentials are only discarded locally. API tokens remain valid until
+  they're revoked with the host, which is done some other way.
+
+  Credentials set by hand in a "credentials" block of the CLI configuration
+  aren't discarded, and must be removed from there by hand.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *LogoutCommand) Synopsis() string {
+	return "Discard the saved credentials for a remote host"
+}
+
+const outputLogoutSuccess = `
+[reset][bold][green]Success![reset][green] Terraform has discarded the stored credentials for %s.[reset]
+`
@@ -0,0 +1,48 @@
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform/svchost"
+	"github.com/mitchellh/cli"
+)
+
+func TestLogout(t *testing.T) {
+	creds := newTestCredentialsSource()
+	creds.creds[svchost.Hostname("example.com")] = map[string]interface{}{
+		"token": "good-token",
+	}
+
+	ui := new(cli.MockUi)
+	c := &LogoutCommand{
+		Meta: Meta{
+			Ui:          ui,
+			Credentials: creds,
+		},
+	}
+
+	if code := c.Run([]string{"example.com"}); code != 0 {
+		t.Fatalf("bad: %d\n%s\n%s", code, ui.OutputWriter.String(), ui.ErrorWriter.String())
+	}
+	if _, exists := creds.creds[svchost.Hostname("example.com")]; exists {
+		t.Fatal("the credentials weren't discarded")
+	}
+}
+
+func TestLogout_noCredentials(t *testing.T) {
+	ui := new(cli.MockUi)
+	c := &LogoutCommand{
+		Meta: Meta{
+			Ui:          ui,
+			Credentials: newTestCredentialsSource(),
+		},
+	}
+
+	if code := c.Run([]string{"example.com"}); code != 0 {
+		t.Fatalf("bad: %d\n%s\n%s", code, ui.OutputWriter.String(), ui.ErrorWriter.String())
+	}
+	if got := ui.OutputWriter.String(); !strings.Contains(got, "No credentials for example.com are stored.") {
+		t.Fatalf("wrong output:\n%s", got)
+	}
+}
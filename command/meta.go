@@ -18,12 +18,14 @@ import (
 
 	"github.com/hashicorp/terraform/backend"
 	"github.com/hashicorp/terraform/backend/local"
-	"github.com/hashicorp/terraform/command/format"
+	"github.com/hashicorp/terraform/command/views"
+	"github.com/hashicorp/terraform/command/webbrowser"
+	"github.com/hashicorp/terraform/command/workdir"
 	"github.com/hashicorp/terraform/config"
 	"github.com/hashicorp/terraform/config/module"
 	"github.com/hashicorp/terraform/helper/experiment"
-	"github.com/hashicorp/terraform/helper/variables"
 	"github.com/hashicorp/terraform/helper/wrappedstreams"
+	"github.com/hashicorp/terraform/plugin/discovery"
 	"github.com/hashicorp/terraform/svchost/auth"
 	"github.com/hashicorp/terraform/svchost/disco"
 	"github.com/hashicorp/terraform/terraform"
@@ -54,6 +56,11 @@ type Meta struct {
 	// services, which are accessed by a service hostname.
 	Credentials auth.CredentialsSource
 
+	// BrowserLauncher opens web pages for the commands that need the user
+	// to visit one, such as "terraform login". If nil, the user is asked to
+	// open them by hand.
+	BrowserLauncher webbrowser.Launcher
+
 	// RunningInAutomation indicates that commands are being run by an
 	// automated system rather than directly at a command prompt.
 	//
@@ -67,14 +74,38 @@ type Meta struct {
 	// the specific commands being run.
 	RunningInAutomation bool
 
-	// PluginCacheDir, if non-empty, enables caching of downloaded plugins
-	// into the given directory.
-	PluginCacheDir string
-
-	// OverrideDataDir, if non-empty, overrides the return value of the
-	// DataDir method for situations where the local .terraform/ directory
-	// is not suitable, e.g. because of a read-only filesystem.
-	OverrideDataDir string
+	// ProviderDevOverrides maps provider names to directories containing
+	// development builds of those providers, from the dev_overrides block
+	// of the CLI configuration.
+	ProviderDevOverrides map[string]string
+
+	// ProviderSources are the sources that "terraform init" installs
+	// providers from, from the installation methods in the
+	// provider_installation block of the CLI configuration. If empty,
+	// providers are installed from the official releases service.
+	ProviderSources []*discovery.ProviderInstallationSource
+
+	// ApprovalPolicy decides how the plans of "terraform apply" are
+	// approved, from the apply_approval block of the CLI configuration.
+	// If nil, every plan must be approved with "yes".
+	ApprovalPolicy *backend.ApprovalPolicy
+
+	// DiffPager is the command that "terraform apply" shows plans with
+	// before asking for approval, from the apply_approval block of the
+	// CLI configuration. If set, plans are paged unless "-page=false" is
+	// given.
+	DiffPager string
+
+	// ApplyHooks are the commands that are run before and after each
+	// resource is applied, from the apply_hook blocks of the CLI
+	// configuration and the TF_APPLY_HOOK environment variable.
+	ApplyHooks []*ApplyHookCommand
+
+	// WorkingDir is the working directory the command runs in, which
+	// decides its data directory and plugin cache. If nil, the command
+	// runs in the current directory with the default data directory and
+	// no plugin cache.
+	WorkingDir *workdir.Dir
 
 	// When this channel is closed, the command will be cancelled.
 	ShutdownCh <-chan struct{}
@@ -105,11 +136,22 @@ type Meta struct {
 	// backendState is the currently active backend state
 	backendState *terraform.BackendState
 
-	// Variables for the context (private)
-	autoKey       string
-	autoVariables map[string]interface{}
-	input         bool
-	variables     map[string]interface{}
+	// backendConfigOrigins are the names of the sources that supplied each
+	// attribute of the backend configuration, from the lowest precedence.
+	backendConfigOrigins map[string][]string
+
+	// Variables for the context (private). The values of the variables
+	// are from the files loaded automatically, followed by those given
+	// with -var and -var-file in the order they're given, with the later
+	// values taking precedence.
+	autoKey   string
+	input     bool
+	variables terraform.InputValues
+
+	// sensitiveVariables are the names of the root module variables that
+	// the last module loaded declares as sensitive, whose values are
+	// never shown in diagnostics.
+	sensitiveVariables map[string]bool
 
 	// Targets for this context (private)
 	targets []string
@@ -118,6 +160,11 @@ type Meta struct {
 	color bool
 	oldUi cli.Ui
 
+	// jsonView, if non-nil, is the view that the command reports its
+	// operation with instead of the text output. It's set by commands
+	// with a -json flag.
+	jsonView *views.JSONView
+
 	// The fields below are expected to be set by the command via
 	// command line flags. See the Apply command for an example.
 	//
@@ -134,10 +181,14 @@ type Meta struct {
 	// version. It defaults to stateOutPath + DefaultBackupExtension
 	//
 	// parallelism is used to control the number of concurrent operations
-	// allowed when walking the graph
+	// allowed when walking the graph, or when downloading modules and
+	// providers during init
 	//
 	// shadow is used to enable/disable the shadow graph
 	//
+	// schemaCache is set to false to disable caching of provider schemas
+	// in the data directory.
+	//
 	// provider is to specify specific resource providers
 	//
 	// stateLock is set to false to disable state locking
@@ -148,20 +199,36 @@ type Meta struct {
 	// forceInitCopy suppresses confirmation for copying state data during
 	// init.
 	//
+	// migrateState suppresses confirmation for copying state data during
+	// init, except for overwriting existing state in the new backend.
+	//
+	// migrateDryRun makes init only show what state data it would copy.
+	//
+	// migrateWorkspace is the workspace that corresponds to the state of a
+	// backend that doesn't support workspaces during a migration.
+	//
 	// reconfigure forces init to ignore any stored configuration.
 	statePath        string
 	stateOutPath     string
 	backupPath       string
 	parallelism      int
 	shadow           bool
+	schemaCache      bool
 	provider         string
 	stateLock        bool
 	stateLockTimeout time.Duration
 	forceInitCopy    bool
+	migrateState     bool
+	migrateDryRun    bool
+	migrateWorkspace string
 	reconfigure      bool
 
 	// Used with the import command to allow import of state when no matching config exists.
 	allowMissingConfig bool
+
+	// devOverridesWarned is set once the warning about provider development
+	// overrides has been shown, so that it is shown only once per command.
+	devOverridesWarned bool
 }
 
 type PluginOverrides struct {
@@ -205,8 +272,8 @@ func (m *Meta) Colorize() *colorstring.Colorize {
 // DataDir returns the directory where local data will be stored.
 // Defaults to DefaultDataDir in the current working directory.
 func (m *Meta) DataDir() string {
-	if m.OverrideDataDir != "" {
-		return m.OverrideDataDir
+	if m.WorkingDir != nil {
+		return m.WorkingDir.DataDir()
 	}
 	return DefaultDataDir
 }
@@ -267,20 +334,17 @@ const (
 // context with the settings from this Meta.
 func (m *Meta) contextOpts() *terraform.ContextOpts {
 	var opts terraform.ContextOpts
-	opts.Hooks = []terraform.Hook{m.uiHook(), &terraform.DebugHook{}}
-	opts.Hooks = append(opts.Hooks, m.ExtraHooks...)
-
-	vs := make(map[string]interface{})
-	for k, v := range opts.Variables {
-		vs[k] = v
+	var uiHook terraform.Hook = m.uiHook()
+	if m.jsonView != nil {
+		uiHook = m.jsonView.Hook()
 	}
-	for k, v := range m.autoVariables {
-		vs[k] = v
+	opts.Hooks = []terraform.Hook{uiHook, &terraform.DebugHook{}}
+	if len(m.ApplyHooks) > 0 {
+		opts.Hooks = append(opts.Hooks, &ExecHook{Commands: m.ApplyHooks})
 	}
-	for k, v := range m.variables {
-		vs[k] = v
-	}
-	opts.Variables = vs
+	opts.Hooks = append(opts.Hooks, m.ExtraHooks...)
+
+	opts.InputValues = m.variables
 
 	opts.Targets = m.targets
 	opts.UIInput = m.UIInput()
@@ -314,16 +378,17 @@ func (m *Meta) contextOpts() *terraform.ContextOpts {
 func (m *Meta) flagSet(n string) *flag.FlagSet {
 	f := flag.NewFlagSet(n, flag.ContinueOnError)
 	f.BoolVar(&m.input, "input", true, "input")
-	f.Var((*variables.Flag)(&m.variables), "var", "variables")
-	f.Var((*variables.FlagFile)(&m.variables), "var-file", "variable file")
+	f.Var((*FlagVar)(&m.variables), "var", "variables")
+	f.Var(&FlagVarFile{Values: &m.variables}, "var-file", "variable file")
 	f.Var((*FlagStringSlice)(&m.targets), "target", "resource to target")
 
 	if m.autoKey != "" {
-		f.Var((*variables.FlagFile)(&m.autoVariables), m.autoKey, "variable file")
+		f.Var(&FlagVarFile{Values: &m.variables, Auto: true}, m.autoKey, "variable file")
 	}
 
 	// Advanced (don't need documentation, or unlikely to be set)
 	f.BoolVar(&m.shadow, "shadow", true, "shadow graph")
+	f.BoolVar(&m.schemaCache, "schema-cache", true, "provider schema cache")
 
 	// Experimental features
 	experiment.Flag(f)
@@ -364,6 +429,10 @@ func (m *Meta) moduleStorage(root string, mode module.GetMode) *module.Storage {
 	s := module.NewStorage(filepath.Join(root, "modules"), m.Services, m.Credentials)
 	s.Ui = m.Ui
 	s.Mode = mode
+	s.Parallelism = m.parallelism
+	if m.WorkingDir != nil {
+		s.CacheDir = m.WorkingDir.ModuleCacheDir()
+	}
 	return s
 }
 
@@ -493,21 +562,57 @@ func (m *Meta) showDiagnostics(vals ...interface{}) {
 	var diags tfdiags.Diagnostics
 	diags = diags.Append(vals...)
 
+	if m.jsonView != nil {
+		m.jsonView.Diagnostics(diags, diagnosticSources(diags))
+		return
+	}
+
+	vars := m.variables.Values()
+	view := views.NewDiagnosticsView(m.Ui, m.Colorize(), m.diagnosticsWidth(), vars, m.sensitiveVariables)
+	view.Diagnostics(diags, diagnosticSources(diags))
+}
+
+// defaultDiagnosticsWidth is the width that diagnostics are wrapped at when
+// the width of the terminal isn't known.
+const defaultDiagnosticsWidth = 78
+
+// diagnosticsWidth returns the width to wrap diagnostics at: the COLUMNS
+// environment variable if it's set, or else the width of the terminal that
+// errors are written to.
+func (m *Meta) diagnosticsWidth() int {
+	if v := os.Getenv("COLUMNS"); v != "" {
+		if width, err := strconv.Atoi(v); err == nil && width > 0 {
+			return width
+		}
+	}
+	if width, ok := terminalWidth(wrappedstreams.Stderr()); ok {
+		return width
+	}
+	return defaultDiagnosticsWidth
+}
+
+// diagnosticSources reads the source files the diagnostics refer to, for
+// the snippets of their JSON representation. The files that can't be read
+// are skipped.
+func diagnosticSources(diags tfdiags.Diagnostics) map[string][]byte {
+	sources := make(map[string][]byte)
 	for _, diag := range diags {
-		// TODO: Actually measure the terminal width and pass it here.
-		// For now, we don't have easy access to the writer that
-		// ui.Error (etc) are writing to and thus can't interrogate
-		// to see if it's a terminal and what size it is.
-		msg := format.Diagnostic(diag, m.Colorize(), 78)
-		switch diag.Severity() {
-		case tfdiags.Error:
-			m.Ui.Error(msg)
-		case tfdiags.Warning:
-			m.Ui.Warn(msg)
-		default:
-			m.Ui.Output(msg)
+		subject := diag.Source().Subject
+		if subject == nil || subject.Filename == "" {
+			continue
+		}
+		if _, ok := sources[subject.Filename]; ok {
+			continue
+		}
+
+		src, err := ioutil.ReadFile(subject.Filename)
+		if err != nil {
+			log.Printf("[WARN] Failed to read %s for diagnostics: %s", subject.Filename, err)
+			continue
 		}
+		sources[subject.Filename] = src
 	}
+	return sources
 }
 
 const (
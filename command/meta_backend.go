@@ -40,6 +40,11 @@ type BackendOpts struct {
 	// configuration after the extra file above.
 	ConfigExtra map[string]interface{}
 
+	// ConfigSources are further sources of configuration to merge into the
+	// backend configuration after ConfigExtra, in order. Each source is
+	// named in the errors about the resulting configuration.
+	ConfigSources []*BackendConfigSource
+
 	// Plan is a plan that is being used. If this is set, the backend
 	// configuration and output configuration will come from this plan.
 	Plan *terraform.Plan
@@ -104,6 +109,7 @@ func (m *Meta) Backend(opts *BackendOpts) (backend.Enhanced, error) {
 		ContextOpts:         m.contextOpts(),
 		Input:               m.Input(),
 		RunningInAutomation: m.RunningInAutomation,
+		Credentials:         m.Credentials,
 	}
 
 	// Don't validate if we have a plan.  Validation is normally harmless here,
@@ -165,7 +171,7 @@ func (m *Meta) IsLocalBackend(b backend.Backend) bool {
 // to modify fields of the operation such as Sequence to specify what will
 // be called.
 func (m *Meta) Operation() *backend.Operation {
-	return &backend.Operation{
+	op := &backend.Operation{
 		PlanOutBackend:   m.backendState,
 		Targets:          m.targets,
 		UIIn:             m.UIInput(),
@@ -174,6 +180,10 @@ func (m *Meta) Operation() *backend.Operation {
 		LockState:        m.stateLock,
 		StateLockTimeout: m.stateLockTimeout,
 	}
+	if m.jsonView != nil {
+		op.View = m.jsonView
+	}
+	return op
 }
 
 // backendConfig returns the local configuration for the backend
@@ -196,46 +206,38 @@ func (m *Meta) backendConfig(opts *BackendOpts) (*config.Backend, error) {
 
 	c := opts.Config
 
-	// If there is no Terraform configuration block, no backend config
-	if c.Terraform == nil {
-		log.Println("[INFO] command: empty terraform config, returning nil")
-		return nil, nil
+	// Get the configuration for the backend itself. If there is none, any
+	// extra configuration has nothing to complete, which is most likely a
+	// mistake, so we say so rather than silently ignoring it.
+	var backend *config.Backend
+	if c.Terraform != nil {
+		backend = c.Terraform.Backend
 	}
-
-	// Get the configuration for the backend itself.
-	backend := c.Terraform.Backend
 	if backend == nil {
+		if opts.ConfigFile != "" {
+			return nil, fmt.Errorf(strings.TrimSpace(errBackendConfigNoBlock),
+				fmt.Sprintf("the file %q", opts.ConfigFile))
+		}
+		if len(opts.ConfigExtra) > 0 {
+			return nil, fmt.Errorf(strings.TrimSpace(errBackendConfigNoBlock),
+				"the command line")
+		}
+		if len(opts.ConfigSources) > 0 {
+			return nil, fmt.Errorf(strings.TrimSpace(errBackendConfigNoBlock),
+				opts.ConfigSources[0].Name)
+		}
+
 		log.Println("[INFO] command: empty backend config, returning nil")
 		return nil, nil
 	}
 
-	// If we have a config file set, load that and merge.
-	if opts.ConfigFile != "" {
-		log.Printf(
-			"[DEBUG] command: loading extra backend config from: %s",
-			opts.ConfigFile)
-		rc, err := m.backendConfigFile(opts.ConfigFile)
-		if err != nil {
-			return nil, fmt.Errorf(
-				"Error loading extra configuration file for backend: %s", err)
-		}
-
-		// Merge in the configuration
-		backend.RawConfig = backend.RawConfig.Merge(rc)
+	// Complete the backend block with the extra configuration.
+	sources, err := m.backendConfigSources(backend, opts)
+	if err != nil {
+		return nil, err
 	}
-
-	// If we have extra config values, merge that
-	if len(opts.ConfigExtra) > 0 {
-		log.Printf(
-			"[DEBUG] command: adding extra backend config from CLI")
-		rc, err := config.NewRawConfig(opts.ConfigExtra)
-		if err != nil {
-			return nil, fmt.Errorf(
-				"Error adding extra configuration file for backend: %s", err)
-		}
-
-		// Merge in the configuration
-		backend.RawConfig = backend.RawConfig.Merge(rc)
+	if err := m.backendConfigMerge(backend, sources); err != nil {
+		return nil, err
 	}
 
 	// Validate the backend early. We have to do this before the normal
@@ -924,10 +926,19 @@ func (m *Meta) backend_C_r_s(
 		return nil, fmt.Errorf(errBackendLocalRead, err)
 	}
 
+	// A dry run of the migration has nothing to show without local state,
+	// but must still stop before the backend is configured.
+	localS := localState.State()
+	if localS.Empty() && m.migrateDryRun {
+		m.Ui.Output(fmt.Sprintf(strings.TrimSpace(outputBackendMigrateDryRunNone),
+			"local", c.Type))
+		return nil, errBackendMigrateDryRun
+	}
+
 	// If the local state is not empty, we need to potentially do a
 	// state migration to the new backend (with user permission), unless the
 	// destination is also "local"
-	if localS := localState.State(); !localS.Empty() {
+	if !localS.Empty() {
 		// Perform the migration
 		err = m.backendMigrateState(&backendMigrateOpts{
 			OneType: "local",
@@ -1265,13 +1276,13 @@ func (m *Meta) backendInitFromConfig(c *config.Backend) (backend.Backend, error)
 	}
 	if len(errs) > 0 {
 		return nil, fmt.Errorf(
-			"Error configuring the backend %q: %s",
-			c.Type, multierror.Append(nil, errs...))
+			"Error configuring the backend %q: %s%s",
+			c.Type, multierror.Append(nil, errs...), m.backendConfigSummary())
 	}
 
 	// Configure
 	if err := b.Configure(config); err != nil {
-		return nil, fmt.Errorf(errBackendNewConfig, c.Type, err)
+		return nil, fmt.Errorf(errBackendNewConfig, c.Type, err, m.backendConfigSummary())
 	}
 
 	return b, nil
@@ -1398,7 +1409,7 @@ above and try again.
 
 const errBackendNewConfig = `
 Error configuring the backend %q: %s
-
+%s
 Please update the configuration in your Terraform files to fix this error
 then run this command again.
 `
@@ -0,0 +1,238 @@
+package command
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform/config"
+	"github.com/hashicorp/terraform/helper/variables"
+)
+
+// BackendConfigEnvPrefix is the prefix of the environment variables that
+// complete the backend configuration during init, as in
+// TF_BACKEND_CONFIG_bucket=example.
+const BackendConfigEnvPrefix = "TF_BACKEND_CONFIG_"
+
+// BackendConfigSource is a set of backend configuration attributes that all
+// come from the same place, such as a single -backend-config file.
+type BackendConfigSource struct {
+	// Name describes the source to the user, and is used to report which
+	// source supplied each attribute.
+	Name string
+
+	// Config are the attributes the source sets.
+	Config map[string]interface{}
+}
+
+// FlagBackendConfig is a flag.Value for the -backend-config flag, which
+// accepts either a 'key=value' pair or the path of a file of such pairs.
+// Unlike variables.FlagAny it keeps each use of the flag separate, in the
+// order given, so that the origin of each attribute can be reported.
+type FlagBackendConfig []*BackendConfigSource
+
+func (v *FlagBackendConfig) String() string {
+	return ""
+}
+
+func (v *FlagBackendConfig) Set(raw string) error {
+	if idx := strings.Index(raw, "="); idx >= 0 {
+		var f variables.Flag
+		if err := f.Set(raw); err != nil {
+			return err
+		}
+		*v = append(*v, &BackendConfigSource{
+			Name:   fmt.Sprintf("-backend-config %q", strings.TrimSpace(raw[:idx])+"=..."),
+			Config: f,
+		})
+		return nil
+	}
+
+	var f variables.FlagFile
+	if err := f.Set(raw); err != nil {
+		return err
+	}
+	*v = append(*v, &BackendConfigSource{
+		Name:   fmt.Sprintf("-backend-config file %q", raw),
+		Config: f,
+	})
+	return nil
+}
+
+// backendConfigEnv returns a source for each of the environment variables
+// that set a backend configuration attribute, sorted by name.
+func backendConfigEnv() []*BackendConfigSource {
+	var result []*BackendConfigSource
+	for _, kv := range os.Environ() {
+		if !strings.HasPrefix(kv, BackendConfigEnvPrefix) {
+			continue
+		}
+
+		idx := strings.Index(kv, "=")
+		name, value := kv[:idx], kv[idx+1:]
+		key := strings.TrimPrefix(name, BackendConfigEnvPrefix)
+		if key == "" {
+			continue
+		}
+
+		result = append(result, &BackendConfigSource{
+			Name:   fmt.Sprintf("environment variable %s", name),
+			Config: map[string]interface{}{key: value},
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// backendConfigBlockName is the name of the backend block as a source of
+// backend configuration.
+const backendConfigBlockName = "the backend block"
+
+// backendConfigSources returns the sources of the backend configuration
+// from the lowest to the highest precedence: the environment, the backend
+// block itself, then ConfigFile, ConfigExtra and each of ConfigSources.
+//
+// The environment is only read when initializing, since it's the saved
+// backend configuration that's used afterwards.
+func (m *Meta) backendConfigSources(b *config.Backend, opts *BackendOpts) ([]*BackendConfigSource, error) {
+	var result []*BackendConfigSource
+	if opts.Init {
+		result = append(result, backendConfigEnv()...)
+	}
+
+	result = append(result, &BackendConfigSource{
+		Name:   backendConfigBlockName,
+		Config: b.RawConfig.Raw,
+	})
+
+	if opts.ConfigFile != "" {
+		log.Printf(
+			"[DEBUG] command: loading extra backend config from: %s",
+			opts.ConfigFile)
+		rc, err := m.backendConfigFile(opts.ConfigFile)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"Error loading extra configuration file for backend: %s", err)
+		}
+		result = append(result, &BackendConfigSource{
+			Name:   fmt.Sprintf("file %q", opts.ConfigFile),
+			Config: rc.Raw,
+		})
+	}
+
+	if len(opts.ConfigExtra) > 0 {
+		result = append(result, &BackendConfigSource{
+			Name:   "the command line",
+			Config: opts.ConfigExtra,
+		})
+	}
+
+	return append(result, opts.ConfigSources...), nil
+}
+
+// backendConfigMerge replaces the configuration of the backend block with
+// the sources merged in order, recording which sources supplied each
+// attribute in Meta.backendConfigOrigins.
+func (m *Meta) backendConfigMerge(b *config.Backend, sources []*BackendConfigSource) error {
+	raw := make(map[string]interface{})
+	origins := make(map[string][]string)
+	for _, s := range sources {
+		for k, v := range s.Config {
+			// The backend block is validated once merged, since the other
+			// sources may override its attributes. Those are checked one
+			// attribute at a time, so that an interpolation can be
+			// reported along with where it came from.
+			if s.Name != backendConfigBlockName {
+				rc, err := config.NewRawConfig(map[string]interface{}{k: v})
+				if err != nil {
+					return fmt.Errorf("Error in backend configuration %q from %s: %s", k, s.Name, err)
+				}
+				if len(rc.Interpolations) > 0 {
+					return fmt.Errorf(strings.TrimSpace(errBackendConfigInterpolation), k, s.Name)
+				}
+			}
+
+			log.Printf("[DEBUG] command: backend config %q from %s", k, s.Name)
+			raw[k] = v
+			origins[k] = append(origins[k], s.Name)
+		}
+	}
+
+	rc, err := config.NewRawConfig(raw)
+	if err != nil {
+		return fmt.Errorf("Error merging the backend configuration: %s", err)
+	}
+	b.RawConfig = rc
+	m.backendConfigOrigins = origins
+	return nil
+}
+
+// backendConfigSummary describes which sources supplied each attribute of
+// the last merged backend configuration, to help make sense of errors in
+// a configuration assembled from several places. It returns an empty
+// string if the backend block was used as is.
+func (m *Meta) backendConfigSummary() string {
+	extra := false
+	keys := make([]string, 0, len(m.backendConfigOrigins))
+	width := 0
+	for k, names := range m.backendConfigOrigins {
+		keys = append(keys, k)
+		if len(k) > width {
+			width = len(k)
+		}
+		if len(names) > 1 || names[0] != backendConfigBlockName {
+			extra = true
+		}
+	}
+	if !extra {
+		return ""
+	}
+	sort.Strings(keys)
+
+	var buf strings.Builder
+	buf.WriteString("\n")
+	buf.WriteString(strings.TrimSpace(backendConfigSummaryHeader))
+	buf.WriteString("\n\n")
+	for _, k := range keys {
+		names := m.backendConfigOrigins[k]
+		last := len(names) - 1
+		fmt.Fprintf(&buf, "  %-*s  %s", width+1, k+":", names[last])
+		if last > 0 {
+			fmt.Fprintf(&buf, " (overriding %s)", strings.Join(names[:last], ", "))
+		}
+		buf.WriteString("\n")
+	}
+	return buf.String()
+}
+
+const backendConfigSummaryHeader = `
+The backend configuration was assembled from the following sources. The
+backend block overrides the environment, and each -backend-config option
+overrides the backend block and the options before it.
+`
+
+const errBackendConfigInterpolation = `
+Backend configuration %q from %s cannot contain interpolations.
+
+The backend configuration is loaded before interpolations can be processed.
+Please set the literal value instead.
+`
+
+const errBackendConfigNoBlock = `
+Backend configuration was given with %s, but the configuration has
+no backend block.
+
+Extra backend configuration only completes a backend block in the root
+module, such as:
+
+    terraform {
+      backend "s3" {}
+    }
+
+Please add the backend block, or remove the extra configuration.
+`
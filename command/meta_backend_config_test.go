@@ -0,0 +1,72 @@
+package command
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFlagBackendConfig_impl(t *testing.T) {
+	var _ flag.Value = new(FlagBackendConfig)
+}
+
+func TestFlagBackendConfig(t *testing.T) {
+	td := tempDir(t)
+	os.MkdirAll(td, 0755)
+	defer os.RemoveAll(td)
+
+	path := filepath.Join(td, "backend.config")
+	if err := ioutil.WriteFile(path, []byte(`path = "file"`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var f FlagBackendConfig
+	for _, raw := range []string{"path=flag", path, "workspace_dir=dir"} {
+		if err := f.Set(raw); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+	}
+
+	expected := FlagBackendConfig{
+		{Name: `-backend-config "path=..."`, Config: map[string]interface{}{"path": "flag"}},
+		{Name: `-backend-config file "` + path + `"`, Config: map[string]interface{}{"path": "file"}},
+		{Name: `-backend-config "workspace_dir=..."`, Config: map[string]interface{}{"workspace_dir": "dir"}},
+	}
+	if !reflect.DeepEqual(f, expected) {
+		t.Fatalf("bad: %#v", f)
+	}
+
+	if err := f.Set(filepath.Join(td, "missing")); err == nil {
+		t.Fatal("expected error for a missing file")
+	}
+}
+
+func TestMetaBackend_configSummary(t *testing.T) {
+	m := testMetaBackend(t, nil)
+
+	// A backend block that's used as is needs no explanation.
+	m.backendConfigOrigins = map[string][]string{
+		"path": {backendConfigBlockName},
+	}
+	if s := m.backendConfigSummary(); s != "" {
+		t.Fatalf("expected no summary, got:\n%s", s)
+	}
+
+	m.backendConfigOrigins = map[string][]string{
+		"path":          {backendConfigBlockName, `-backend-config "path=..."`},
+		"workspace_dir": {"environment variable TF_BACKEND_CONFIG_workspace_dir"},
+	}
+	s := m.backendConfigSummary()
+	for _, line := range []string{
+		`  path:           -backend-config "path=..." (overriding the backend block)`,
+		`  workspace_dir:  environment variable TF_BACKEND_CONFIG_workspace_dir`,
+	} {
+		if !strings.Contains(s, line+"\n") {
+			t.Fatalf("expected %q in the summary:\n%s", line, s)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package command
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
@@ -26,7 +27,13 @@ import (
 // After migrating the state, the existing state in the first backend
 // remains untouched.
 //
-// This will attempt to lock both states for the migration.
+// The migration is planned before anything is changed: the workspaces to
+// copy are determined and compared with the destination first. With
+// -migrate-dry-run the plan is only shown, and errBackendMigrateDryRun is
+// returned so that the backend configuration isn't changed either.
+//
+// This will attempt to lock all the states being copied, on both sides,
+// before copying any of them.
 func (m *Meta) backendMigrateState(opts *backendMigrateOpts) error {
 	// We need to check what the named state status is. If we're converting
 	// from multi-state to single-state for example, we need to handle that.
@@ -41,7 +48,7 @@ func (m *Meta) backendMigrateState(opts *backendMigrateOpts) error {
 			errMigrateLoadStates), opts.OneType, err)
 	}
 
-	_, err = opts.Two.States()
+	twoStates, err := opts.Two.States()
 	if err == backend.ErrNamedStatesNotSupported {
 		twoSingle = true
 		err = nil
@@ -55,19 +62,27 @@ func (m *Meta) backendMigrateState(opts *backendMigrateOpts) error {
 	opts.oneEnv = backend.DefaultStateName
 	opts.twoEnv = backend.DefaultStateName
 	opts.force = m.forceInitCopy
+	opts.twoStates = twoStates
+	opts.twoSingle = twoSingle
 
 	// Determine migration behavior based on whether the source/destination
 	// supports multi-state.
+	var plan *backendMigratePlan
 	switch {
 	// Single-state to single-state. This is the easiest case: we just
 	// copy the default state directly.
 	case oneSingle && twoSingle:
-		return m.backendMigrateState_s_s(opts)
+		plan, err = m.backendMigrateState_s_s(opts)
 
 	// Single-state to multi-state. This is easy since we just copy
-	// the default state and ignore the rest in the destination.
+	// the default state and ignore the rest in the destination. The
+	// default state may be mapped to another workspace with
+	// -migrate-workspace.
 	case oneSingle && !twoSingle:
-		return m.backendMigrateState_s_s(opts)
+		if m.migrateWorkspace != "" {
+			opts.twoEnv = m.migrateWorkspace
+		}
+		plan, err = m.backendMigrateState_s_s(opts)
 
 	// Multi-state to single-state. If the source has more than the default
 	// state this is complicated since we have to ask the user what to do.
@@ -75,10 +90,11 @@ func (m *Meta) backendMigrateState(opts *backendMigrateOpts) error {
 		// If the source only has one state and it is the default,
 		// treat it as if it doesn't support multi-state.
 		if len(oneStates) == 1 && oneStates[0] == backend.DefaultStateName {
-			return m.backendMigrateState_s_s(opts)
+			plan, err = m.backendMigrateState_s_s(opts)
+			break
 		}
 
-		return m.backendMigrateState_S_s(opts)
+		plan, err = m.backendMigrateState_S_s(opts, oneStates)
 
 	// Multi-state to multi-state. We merge the states together (migrating
 	// each from the source to the destination one by one).
@@ -86,13 +102,22 @@ func (m *Meta) backendMigrateState(opts *backendMigrateOpts) error {
 		// If the source only has one state and it is the default,
 		// treat it as if it doesn't support multi-state.
 		if len(oneStates) == 1 && oneStates[0] == backend.DefaultStateName {
-			return m.backendMigrateState_s_s(opts)
+			plan, err = m.backendMigrateState_s_s(opts)
+			break
 		}
 
-		return m.backendMigrateState_S_S(opts)
+		plan, err = m.backendMigrateState_S_S(opts, oneStates)
+	}
+	if err != nil {
+		return err
+	}
+
+	if m.migrateDryRun {
+		m.backendMigrateOutputPlan(opts, plan)
+		return errBackendMigrateDryRun
 	}
 
-	return nil
+	return m.backendMigrateApply(opts, plan)
 }
 
 //-------------------------------------------------------------------
@@ -109,233 +134,428 @@ func (m *Meta) backendMigrateState(opts *backendMigrateOpts) error {
 // The suffix is used to disambiguate multiple cases with the same type of
 // states.
 //
+// Each scenario returns the plan of the workspaces to copy, which is then
+// applied by backendMigrateApply.
+//
 //-------------------------------------------------------------------
 
 // Multi-state to multi-state.
-func (m *Meta) backendMigrateState_S_S(opts *backendMigrateOpts) error {
-	// Ask the user if they want to migrate their existing remote state
-	migrate, err := m.confirm(&terraform.InputOpts{
-		Id: "backend-migrate-multistate-to-multistate",
-		Query: fmt.Sprintf(
-			"Do you want to migrate all workspaces to %q?",
-			opts.TwoType),
-		Description: fmt.Sprintf(
-			strings.TrimSpace(inputBackendMigrateMultiToMulti),
-			opts.OneType, opts.TwoType),
-	})
-	if err != nil {
-		return fmt.Errorf(
-			"Error asking for state migration action: %s", err)
-	}
-	if !migrate {
-		return fmt.Errorf("Migration aborted by user.")
-	}
-
-	// Read all the states
-	oneStates, err := opts.One.States()
-	if err != nil {
-		return fmt.Errorf(strings.TrimSpace(
-			errMigrateLoadStates), opts.OneType, err)
+func (m *Meta) backendMigrateState_S_S(opts *backendMigrateOpts, oneStates []string) (*backendMigratePlan, error) {
+	plan := &backendMigratePlan{
+		multi: true,
+		confirm: func() (bool, error) {
+			// Ask the user if they want to migrate their existing remote state
+			return m.confirm(&terraform.InputOpts{
+				Id: "backend-migrate-multistate-to-multistate",
+				Query: fmt.Sprintf(
+					"Do you want to migrate all workspaces to %q?",
+					opts.TwoType),
+				Description: fmt.Sprintf(
+					strings.TrimSpace(inputBackendMigrateMultiToMulti),
+					opts.OneType, opts.TwoType),
+			})
+		},
 	}
 
 	// Sort the states so they're always copied alphabetically
+	oneStates = append([]string(nil), oneStates...)
 	sort.Strings(oneStates)
 
-	// Go through each and migrate
+	// Copy the same names
 	for _, name := range oneStates {
-		// Copy the same names
-		opts.oneEnv = name
-		opts.twoEnv = name
-
-		// Force it, we confirmed above
-		opts.force = true
-
-		// Perform the migration
-		if err := m.backendMigrateState_s_s(opts); err != nil {
-			return fmt.Errorf(strings.TrimSpace(
-				errMigrateMulti), name, opts.OneType, opts.TwoType, err)
+		if err := m.backendMigratePlanWorkspace(opts, plan, name, name); err != nil {
+			return nil, err
 		}
 	}
 
-	return nil
+	return plan, nil
 }
 
 // Multi-state to single state.
-func (m *Meta) backendMigrateState_S_s(opts *backendMigrateOpts) error {
-	currentEnv := m.Workspace()
-
-	migrate := opts.force
-	if !migrate {
-		var err error
-		// Ask the user if they want to migrate their existing remote state
-		migrate, err = m.confirm(&terraform.InputOpts{
-			Id: "backend-migrate-multistate-to-single",
-			Query: fmt.Sprintf(
-				"Destination state %q doesn't support workspaces.\n"+
-					"Do you want to copy only your current workspace?",
-				opts.TwoType),
-			Description: fmt.Sprintf(
-				strings.TrimSpace(inputBackendMigrateMultiToSingle),
-				opts.OneType, opts.TwoType, currentEnv),
-		})
-		if err != nil {
-			return fmt.Errorf(
-				"Error asking for state migration action: %s", err)
+func (m *Meta) backendMigrateState_S_s(opts *backendMigrateOpts, oneStates []string) (*backendMigratePlan, error) {
+	// Copy the current workspace, unless another one was chosen with
+	// -migrate-workspace.
+	name := m.Workspace()
+	if m.migrateWorkspace != "" {
+		name = m.migrateWorkspace
+
+		found := false
+		for _, s := range oneStates {
+			if s == name {
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf(strings.TrimSpace(errMigrateWorkspaceNotFound),
+				name, opts.OneType)
 		}
 	}
 
-	if !migrate {
-		return fmt.Errorf("Migration aborted by user.")
+	plan := &backendMigratePlan{
+		// now switch back to the default env so we can acccess the new backend
+		workspace: backend.DefaultStateName,
+		confirm: func() (bool, error) {
+			// Ask the user if they want to migrate their existing remote state
+			return m.confirm(&terraform.InputOpts{
+				Id: "backend-migrate-multistate-to-single",
+				Query: fmt.Sprintf(
+					"Destination state %q doesn't support workspaces.\n"+
+						"Do you want to copy only your current workspace?",
+					opts.TwoType),
+				Description: fmt.Sprintf(
+					strings.TrimSpace(inputBackendMigrateMultiToSingle),
+					opts.OneType, opts.TwoType, name),
+			})
+		},
 	}
 
-	// Copy the default state
-	opts.oneEnv = currentEnv
+	if err := m.backendMigratePlanWorkspace(opts, plan, name, backend.DefaultStateName); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
 
-	// now switch back to the default env so we can acccess the new backend
-	m.SetWorkspace(backend.DefaultStateName)
+// Single state to single state, assumed default state name unless the
+// destination workspace was mapped to another name.
+func (m *Meta) backendMigrateState_s_s(opts *backendMigrateOpts) (*backendMigratePlan, error) {
+	plan := &backendMigratePlan{}
+	if opts.twoEnv != backend.DefaultStateName {
+		plan.workspace = opts.twoEnv
+	}
 
-	return m.backendMigrateState_s_s(opts)
+	if err := m.backendMigratePlanWorkspace(opts, plan, opts.oneEnv, opts.twoEnv); err != nil {
+		return nil, err
+	}
+	return plan, nil
 }
 
-// Single state to single state, assumed default state name.
-func (m *Meta) backendMigrateState_s_s(opts *backendMigrateOpts) error {
-	stateOne, err := opts.One.State(opts.oneEnv)
-	if err != nil {
+// backendMigratePlanWorkspace loads the source and destination states of a
+// workspace and adds the action for it to the plan.
+//
+// A destination workspace that doesn't exist yet isn't created during a
+// dry run, since creating a workspace writes an empty state in most
+// backends.
+func (m *Meta) backendMigratePlanWorkspace(opts *backendMigrateOpts, plan *backendMigratePlan, oneName, twoName string) error {
+	w := &backendMigrateWorkspace{One: oneName, Two: twoName}
+
+	var err error
+	if w.stateOne, err = opts.One.State(oneName); err != nil {
 		return fmt.Errorf(strings.TrimSpace(
 			errMigrateSingleLoadDefault), opts.OneType, err)
 	}
-	if err := stateOne.RefreshState(); err != nil {
+	if err := w.stateOne.RefreshState(); err != nil {
 		return fmt.Errorf(strings.TrimSpace(
 			errMigrateSingleLoadDefault), opts.OneType, err)
 	}
 
-	stateTwo, err := opts.Two.State(opts.twoEnv)
-	if err != nil {
+	if m.migrateDryRun && !opts.twoExists(twoName) {
+		w.Action = backendMigrateActionFor(w.stateOne.State(), nil)
+		plan.Workspaces = append(plan.Workspaces, w)
+		return nil
+	}
+
+	if w.stateTwo, err = opts.Two.State(twoName); err != nil {
 		return fmt.Errorf(strings.TrimSpace(
 			errMigrateSingleLoadDefault), opts.TwoType, err)
 	}
-	if err := stateTwo.RefreshState(); err != nil {
+	if err := w.stateTwo.RefreshState(); err != nil {
 		return fmt.Errorf(strings.TrimSpace(
 			errMigrateSingleLoadDefault), opts.TwoType, err)
 	}
 
-	// Check if we need migration at all.
-	// This is before taking a lock, because they may also correspond to the same lock.
-	one := stateOne.State()
-	two := stateTwo.State()
+	w.Action = backendMigrateActionFor(w.stateOne.State(), w.stateTwo.State())
+	plan.Workspaces = append(plan.Workspaces, w)
+	return nil
+}
 
-	// no reason to migrate if the state is already there
-	if one.Equal(two) {
-		// Equal isn't identical; it doesn't check lineage.
-		if one != nil && two != nil && one.Lineage == two.Lineage {
-			return nil
+// backendMigrateApply copies the workspaces of a plan, asking for
+// confirmation unless -force-copy was given.
+func (m *Meta) backendMigrateApply(opts *backendMigrateOpts, plan *backendMigratePlan) error {
+	// Nothing to copy, but the migration may still change the workspace.
+	if !plan.copies() {
+		if plan.workspace != "" {
+			m.SetWorkspace(plan.workspace)
+		}
+		return nil
+	}
+
+	// Abort if we can't ask for input.
+	if !opts.force && !m.input {
+		return errors.New("error asking for state migration action: input disabled")
+	}
+
+	if plan.confirm != nil && !opts.force && !m.migrateState {
+		migrate, err := plan.confirm()
+		if err != nil {
+			return fmt.Errorf(
+				"Error asking for state migration action: %s", err)
+		}
+		if !migrate {
+			return fmt.Errorf("Migration aborted by user.")
 		}
 	}
 
+	// Check if we need migration at all. This was done while planning,
+	// before taking any lock, because the states may also correspond to
+	// the same lock. Only the workspaces that are copied are locked.
 	if m.stateLock {
 		lockCtx, cancel := context.WithTimeout(context.Background(), m.stateLockTimeout)
 		defer cancel()
 
-		lockInfoOne := state.NewLockInfo()
-		lockInfoOne.Operation = "migration"
-		lockInfoOne.Info = "source state"
+		for _, w := range plan.Workspaces {
+			if !w.Action.copies() {
+				continue
+			}
+
+			lockInfoOne := state.NewLockInfo()
+			lockInfoOne.Operation = "migration"
+			lockInfoOne.Info = "source state"
+
+			lockIDOne, err := clistate.Lock(lockCtx, w.stateOne, lockInfoOne, m.Ui, m.Colorize())
+			if err != nil {
+				return fmt.Errorf("Error locking source state: %s", err)
+			}
+			defer clistate.Unlock(w.stateOne, lockIDOne, m.Ui, m.Colorize())
+
+			lockInfoTwo := state.NewLockInfo()
+			lockInfoTwo.Operation = "migration"
+			lockInfoTwo.Info = "destination state"
+
+			lockIDTwo, err := clistate.Lock(lockCtx, w.stateTwo, lockInfoTwo, m.Ui, m.Colorize())
+			if err != nil {
+				return fmt.Errorf("Error locking destination state: %s", err)
+			}
+			defer clistate.Unlock(w.stateTwo, lockIDTwo, m.Ui, m.Colorize())
+		}
 
-		lockIDOne, err := clistate.Lock(lockCtx, stateOne, lockInfoOne, m.Ui, m.Colorize())
-		if err != nil {
-			return fmt.Errorf("Error locking source state: %s", err)
+		// We now own all the locks, so double check that we have the
+		// versions corresponding to the locks.
+		for _, w := range plan.Workspaces {
+			if !w.Action.copies() {
+				continue
+			}
+
+			if err := w.stateOne.RefreshState(); err != nil {
+				return fmt.Errorf(strings.TrimSpace(
+					errMigrateSingleLoadDefault), opts.OneType, err)
+			}
+			if err := w.stateTwo.RefreshState(); err != nil {
+				return fmt.Errorf(strings.TrimSpace(
+					errMigrateSingleLoadDefault), opts.TwoType, err)
+			}
+			w.Action = backendMigrateActionFor(w.stateOne.State(), w.stateTwo.State())
 		}
-		defer clistate.Unlock(stateOne, lockIDOne, m.Ui, m.Colorize())
+	}
 
-		lockInfoTwo := state.NewLockInfo()
-		lockInfoTwo.Operation = "migration"
-		lockInfoTwo.Info = "destination state"
+	for _, w := range plan.Workspaces {
+		one := w.stateOne.State()
+		two := w.stateTwo.State()
 
-		lockIDTwo, err := clistate.Lock(lockCtx, stateTwo, lockInfoTwo, m.Ui, m.Colorize())
-		if err != nil {
-			return fmt.Errorf("Error locking destination state: %s", err)
+		// Clear the legacy remote state in both cases. If we're at the
+		// migration step then this won't be used anymore.
+		if one != nil {
+			one.Remote = nil
+		}
+		if two != nil {
+			two.Remote = nil
 		}
-		defer clistate.Unlock(stateTwo, lockIDTwo, m.Ui, m.Colorize())
 
-		// We now own a lock, so double check that we have the version
-		// corresponding to the lock.
-		if err := stateOne.RefreshState(); err != nil {
-			return fmt.Errorf(strings.TrimSpace(
-				errMigrateSingleLoadDefault), opts.OneType, err)
+		var confirmFunc func(*backendMigrateWorkspace, *backendMigrateOpts, bool) (bool, error)
+		switch w.Action {
+		// No migration necessary
+		case backendMigrateSkipEmpty, backendMigrateSkipCurrent:
+			continue
+
+		// We have existing state moving into no state. Ask the user if
+		// they'd like to do this, unless all the workspaces were already
+		// confirmed together.
+		case backendMigrateCopy:
+			if !plan.multi && !m.migrateState {
+				confirmFunc = m.backendMigrateEmptyConfirm
+			}
+
+		// Both states are non-empty, meaning we need to determine which
+		// state should be used and update accordingly. This is always
+		// confirmed separately, even with -migrate-state.
+		case backendMigrateOverwrite:
+			confirmFunc = m.backendMigrateNonEmptyConfirm
 		}
-		if err := stateTwo.RefreshState(); err != nil {
-			return fmt.Errorf(strings.TrimSpace(
-				errMigrateSingleLoadDefault), opts.OneType, err)
+
+		if confirmFunc != nil && !opts.force {
+			// Confirm with the user whether we want to copy state over
+			confirm, err := confirmFunc(w, opts, plan.multi)
+			if err != nil {
+				return err
+			}
+			if !confirm {
+				continue
+			}
 		}
 
-		one = stateOne.State()
-		two = stateTwo.State()
+		// Confirmed! Write.
+		err := w.stateTwo.WriteState(one)
+		if err == nil {
+			err = w.stateTwo.PersistState()
+		}
+		if err != nil {
+			if plan.multi {
+				return fmt.Errorf(strings.TrimSpace(
+					errMigrateMulti), w.One, opts.OneType, opts.TwoType, err)
+			}
+			return fmt.Errorf(strings.TrimSpace(errBackendStateCopy),
+				opts.OneType, opts.TwoType, err)
+		}
 	}
 
-	// Clear the legacy remote state in both cases. If we're at the migration
-	// step then this won't be used anymore.
-	if one != nil {
-		one.Remote = nil
+	if plan.workspace != "" {
+		m.SetWorkspace(plan.workspace)
 	}
-	if two != nil {
-		two.Remote = nil
+
+	// And we're done.
+	return nil
+}
+
+// backendMigrateOutputPlan shows what a migration would copy, for
+// -migrate-dry-run.
+func (m *Meta) backendMigrateOutputPlan(opts *backendMigrateOpts, plan *backendMigratePlan) {
+	if !plan.copies() {
+		m.Ui.Output(fmt.Sprintf(strings.TrimSpace(outputBackendMigrateDryRunNone),
+			opts.OneType, opts.TwoType))
+		return
 	}
 
-	var confirmFunc func(state.State, state.State, *backendMigrateOpts) (bool, error)
-	switch {
-	// No migration necessary
-	case one.Empty() && two.Empty():
-		return nil
+	var buf bytes.Buffer
+	buf.WriteString(fmt.Sprintf(strings.TrimSpace(outputBackendMigrateDryRun),
+		opts.OneType, opts.TwoType))
+	buf.WriteString("\n\n")
+	for _, w := range plan.Workspaces {
+		buf.WriteString(fmt.Sprintf("  %q -> %q: %s\n", w.One, w.Two, w.Action))
+	}
+	m.Ui.Output(buf.String())
+}
 
-	// No migration necessary if we're inheriting state.
-	case one.Empty() && !two.Empty():
-		return nil
+type backendMigrateOpts struct {
+	OneType, TwoType string
+	One, Two         backend.Backend
 
-	// We have existing state moving into no state. Ask the user if
-	// they'd like to do this.
-	case !one.Empty() && two.Empty():
-		confirmFunc = m.backendMigrateEmptyConfirm
+	// Fields below are set internally when migrate is called
 
-	// Both states are non-empty, meaning we need to determine which
-	// state should be used and update accordingly.
-	case !one.Empty() && !two.Empty():
-		confirmFunc = m.backendMigrateNonEmptyConfirm
-	}
+	oneEnv    string   // source env
+	twoEnv    string   // dest env
+	force     bool     // if true, won't ask for confirmation
+	twoSingle bool     // if true, the destination doesn't support workspaces
+	twoStates []string // the existing workspaces of the destination
+}
 
-	if confirmFunc == nil {
-		panic("confirmFunc must not be nil")
+// twoExists returns true if the destination workspace exists already.
+func (o *backendMigrateOpts) twoExists(name string) bool {
+	if o.twoSingle {
+		return true
 	}
-
-	if !opts.force {
-		// Abort if we can't ask for input.
-		if !m.input {
-			return errors.New("error asking for state migration action: input disabled")
+	for _, s := range o.twoStates {
+		if s == name {
+			return true
 		}
+	}
+	return false
+}
 
-		// Confirm with the user whether we want to copy state over
-		confirm, err := confirmFunc(stateOne, stateTwo, opts)
-		if err != nil {
-			return err
-		}
-		if !confirm {
-			return nil
+// backendMigratePlan is the set of workspaces that a migration copies,
+// determined before anything is changed.
+type backendMigratePlan struct {
+	Workspaces []*backendMigrateWorkspace
+
+	// confirm, if set, asks whether to go ahead with the migration as a
+	// whole.
+	confirm func() (bool, error)
+
+	// multi is true if all the workspaces of the source are copied. The
+	// workspaces copied into empty states then aren't confirmed one by
+	// one, since the whole migration was confirmed.
+	multi bool
+
+	// workspace, if set, is the workspace to select after the migration.
+	workspace string
+}
+
+// copies returns true if any of the workspaces of the plan is copied.
+func (p *backendMigratePlan) copies() bool {
+	for _, w := range p.Workspaces {
+		if w.Action.copies() {
+			return true
 		}
 	}
+	return false
+}
 
-	// Confirmed! Write.
-	if err := stateTwo.WriteState(one); err != nil {
-		return fmt.Errorf(strings.TrimSpace(errBackendStateCopy),
-			opts.OneType, opts.TwoType, err)
-	}
-	if err := stateTwo.PersistState(); err != nil {
-		return fmt.Errorf(strings.TrimSpace(errBackendStateCopy),
-			opts.OneType, opts.TwoType, err)
+// backendMigrateWorkspace is a source workspace and the destination
+// workspace that it's copied to.
+type backendMigrateWorkspace struct {
+	One, Two string
+	Action   backendMigrateAction
+
+	stateOne, stateTwo state.State
+}
+
+// backendMigrateAction is what a migration does with a workspace.
+type backendMigrateAction int
+
+const (
+	// The source state is empty, so the destination state is kept.
+	backendMigrateSkipEmpty backendMigrateAction = iota
+
+	// The destination state is the same as the source state already.
+	backendMigrateSkipCurrent
+
+	// The destination state is empty, so the source state is copied.
+	backendMigrateCopy
+
+	// The destination state is different, so it's overwritten if the
+	// user agrees.
+	backendMigrateOverwrite
+)
+
+func (a backendMigrateAction) copies() bool {
+	return a == backendMigrateCopy || a == backendMigrateOverwrite
+}
+
+func (a backendMigrateAction) String() string {
+	switch a {
+	case backendMigrateSkipEmpty:
+		return "skip, the source state is empty"
+	case backendMigrateSkipCurrent:
+		return "skip, the destination state is up to date"
+	case backendMigrateCopy:
+		return "copy, the destination state is empty"
+	case backendMigrateOverwrite:
+		return "overwrite, the destination state is different (will ask for confirmation)"
+	default:
+		return fmt.Sprintf("backendMigrateAction(%d)", int(a))
 	}
+}
 
-	// And we're done.
-	return nil
+// backendMigrateActionFor determines what to do with a source state and
+// the existing destination state, which is nil if it doesn't exist.
+func backendMigrateActionFor(one, two *terraform.State) backendMigrateAction {
+	switch {
+	// No migration necessary, including if we're inheriting state.
+	case one.Empty():
+		return backendMigrateSkipEmpty
+
+	// no reason to migrate if the state is already there. Equal isn't
+	// identical; it doesn't check lineage.
+	case one.Equal(two) && two != nil && one.Lineage == two.Lineage:
+		return backendMigrateSkipCurrent
+
+	case two.Empty():
+		return backendMigrateCopy
+
+	default:
+		return backendMigrateOverwrite
+	}
 }
 
-func (m *Meta) backendMigrateEmptyConfirm(one, two state.State, opts *backendMigrateOpts) (bool, error) {
+func (m *Meta) backendMigrateEmptyConfirm(w *backendMigrateWorkspace, opts *backendMigrateOpts, multi bool) (bool, error) {
 	inputOpts := &terraform.InputOpts{
 		Id:    "backend-migrate-copy-to-empty",
 		Query: "Do you want to copy existing state to the new backend?",
@@ -348,10 +568,10 @@ func (m *Meta) backendMigrateEmptyConfirm(one, two state.State, opts *backendMig
 }
 
 func (m *Meta) backendMigrateNonEmptyConfirm(
-	stateOne, stateTwo state.State, opts *backendMigrateOpts) (bool, error) {
+	w *backendMigrateWorkspace, opts *backendMigrateOpts, multi bool) (bool, error) {
 	// We need to grab both states so we can write them to a file
-	one := stateOne.State()
-	two := stateTwo.State()
+	one := w.stateOne.State()
+	two := w.stateTwo.State()
 
 	// Save both to a temporary
 	td, err := ioutil.TempDir("", "terraform")
@@ -389,21 +609,21 @@ func (m *Meta) backendMigrateNonEmptyConfirm(
 			strings.TrimSpace(inputBackendMigrateNonEmpty),
 			opts.OneType, opts.TwoType, onePath, twoPath),
 	}
+	if multi {
+		// Name the workspace, since each conflicting workspace is
+		// confirmed separately.
+		inputOpts.Query = fmt.Sprintf(
+			"Do you want to overwrite the workspace %q in the new backend?", w.Two)
+	}
 
 	// Confirm with the user that the copy should occur
 	return m.confirm(inputOpts)
 }
 
-type backendMigrateOpts struct {
-	OneType, TwoType string
-	One, Two         backend.Backend
-
-	// Fields below are set internally when migrate is called
-
-	oneEnv string // source env
-	twoEnv string // dest env
-	force  bool   // if true, won't ask for confirmation
-}
+// errBackendMigrateDryRun is returned by backendMigrateState after showing
+// what it would copy with -migrate-dry-run, to stop the backend from being
+// changed.
+var errBackendMigrateDryRun = errors.New("state migration dry run")
 
 const errMigrateLoadStates = `
 Error inspecting states in the %q backend:
@@ -440,6 +660,14 @@ Please resolve the error above and run the initialization command again.
 This will attempt to copy (with permission) all workspaces again.
 `
 
+const errMigrateWorkspaceNotFound = `
+The workspace %q given with -migrate-workspace doesn't exist in the
+previous %q backend.
+
+The newly configured backend doesn't support workspaces, so only one workspace
+can be copied to it. Please choose an existing workspace and try again.
+`
+
 const errBackendStateCopy = `
 Error copying state from the previous %q backend to the newly configured %q backend:
     %s
@@ -481,13 +709,25 @@ up, or cancel altogether, answer "no" and Terraform will abort.
 const inputBackendMigrateMultiToMulti = `
 Both the existing %[1]q backend and the newly configured %[2]q backend support
 workspaces. When migrating between backends, Terraform will copy all
-workspaces (with the same names). THIS WILL OVERWRITE any conflicting
-states in the destination.
+workspaces (with the same names). Terraform will ask separately before
+overwriting any workspace that already has different state in the
+destination.
 
 Terraform initialization doesn't currently migrate only select workspaces.
 If you want to migrate a select number of workspaces, you must manually
-pull and push those states.
+pull and push those states. To see which workspaces would be copied, run
+"terraform init -migrate-dry-run".
 
 If you answer "yes", Terraform will migrate all states. If you answer
 "no", Terraform will abort.
 `
+
+const outputBackendMigrateDryRun = `
+Terraform would migrate the following workspaces from the previous %q backend
+to the newly configured %q backend:
+`
+
+const outputBackendMigrateDryRunNone = `
+No state needs to be migrated from the previous %q backend to the newly
+configured %q backend.
+`
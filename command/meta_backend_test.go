@@ -285,6 +285,28 @@ func TestMetaBackend_configureInterpolation(t *testing.T) {
 	}
 }
 
+// Verify that an interpolation that the extra configuration replaces
+// doesn't result in an error, since the merged configuration is validated
+func TestMetaBackend_configureInterpolationOverridden(t *testing.T) {
+	// Create a temporary working directory that is empty
+	td := tempDir(t)
+	copy.CopyDir(testFixturePath("backend-new-interp"), td)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	// Setup the meta
+	m := testMetaBackend(t, nil)
+
+	// Get the backend
+	_, err := m.Backend(&BackendOpts{
+		Init:        true,
+		ConfigExtra: map[string]interface{}{"path": "local-state.tfstate"},
+	})
+	if err != nil {
+		t.Fatalf("bad: %s", err)
+	}
+}
+
 // Newly configured backend
 func TestMetaBackend_configureNew(t *testing.T) {
 	// Create a temporary working directory that is empty
@@ -1422,6 +1444,212 @@ func TestMetaBackend_configuredChangeCopy_multiToMulti(t *testing.T) {
 	}
 }
 
+// Changing a configured backend that supports multi-state to another,
+// where a workspace already has different state in the destination.
+func TestMetaBackend_configuredChangeCopy_multiToMultiConflict(t *testing.T) {
+	// Create a temporary working directory that is empty
+	td := tempDir(t)
+	copy.CopyDir(testFixturePath("backend-change-multi-to-multi"), td)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	// Create a conflicting workspace in the destination
+	conflict := terraform.NewState()
+	conflict.Lineage = "conflict"
+	conflict.AddModule(terraform.RootModulePath)
+	conflictPath := filepath.Join("envdir-new", "env2", backendlocal.DefaultStateFilename)
+	if err := os.MkdirAll(filepath.Dir(conflictPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Create(conflictPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = terraform.WriteState(conflict, f)
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Ask input, keeping the conflicting workspace
+	defer testInputMap(t, map[string]string{
+		"backend-migrate-multistate-to-multistate": "yes",
+		"backend-migrate-to-backend":               "no",
+	})()
+
+	// Setup the meta
+	m := testMetaBackend(t, nil)
+
+	// Get the backend
+	b, err := m.Backend(&BackendOpts{Init: true})
+	if err != nil {
+		t.Fatalf("bad: %s", err)
+	}
+
+	expected := map[string]string{
+		backend.DefaultStateName: "backend-change",
+		"env2":                   "conflict",
+	}
+	for name, lineage := range expected {
+		s, err := b.State(name)
+		if err != nil {
+			t.Fatalf("bad: %s", err)
+		}
+		if err := s.RefreshState(); err != nil {
+			t.Fatalf("bad: %s", err)
+		}
+		if state := s.State(); state == nil || state.Lineage != lineage {
+			t.Fatalf("%s: bad: %#v", name, state)
+		}
+	}
+}
+
+// Changing a configured backend that supports multi-state to a
+// backend that only supports single states, choosing the workspace to copy.
+func TestMetaBackend_configuredChangeCopy_multiToSingleMapped(t *testing.T) {
+	// Create a temporary working directory that is empty
+	td := tempDir(t)
+	copy.CopyDir(testFixturePath("backend-change-multi-to-single"), td)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	// Register the single-state backend
+	backendinit.Set("local-single", backendlocal.TestNewLocalSingle)
+	defer backendinit.Set("local-single", nil)
+
+	// Setup the meta
+	m := testMetaBackend(t, nil)
+	m.migrateState = true
+	m.migrateWorkspace = "env2"
+
+	// Get the backend
+	b, err := m.Backend(&BackendOpts{Init: true})
+	if err != nil {
+		t.Fatalf("bad: %s", err)
+	}
+
+	// Check the state
+	s, err := b.State(backend.DefaultStateName)
+	if err != nil {
+		t.Fatalf("bad: %s", err)
+	}
+	if err := s.RefreshState(); err != nil {
+		t.Fatalf("bad: %s", err)
+	}
+	state := s.State()
+	if state == nil || state.Lineage != "backend-change-env2" {
+		t.Fatalf("bad: %#v", state)
+	}
+
+	// Verify we are now in the default env
+	if env := m.Workspace(); env != backend.DefaultStateName {
+		t.Fatal("using non-default env with single-env backend")
+	}
+
+	// A workspace that doesn't exist can't be chosen
+	td2 := tempDir(t)
+	copy.CopyDir(testFixturePath("backend-change-multi-to-single"), td2)
+	defer os.RemoveAll(td2)
+	defer testChdir(t, td2)()
+
+	m = testMetaBackend(t, nil)
+	m.migrateState = true
+	m.migrateWorkspace = "nonexistent"
+	if _, err := m.Backend(&BackendOpts{Init: true}); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+// Changing a configured backend that supports only single states to a
+// backend that supports multi-state, copying to another workspace.
+func TestMetaBackend_configuredChangeCopy_singleToMultiMapped(t *testing.T) {
+	// Create a temporary working directory that is empty
+	td := tempDir(t)
+	copy.CopyDir(testFixturePath("backend-change-single-to-multi"), td)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	// Register the single-state backend
+	backendinit.Set("local-single", backendlocal.TestNewLocalSingle)
+	defer backendinit.Set("local-single", nil)
+
+	// Ask input
+	defer testInputMap(t, map[string]string{
+		"backend-migrate-copy-to-empty": "yes",
+	})()
+
+	// Setup the meta
+	m := testMetaBackend(t, nil)
+	m.migrateWorkspace = "prod"
+
+	// Get the backend
+	b, err := m.Backend(&BackendOpts{Init: true})
+	if err != nil {
+		t.Fatalf("bad: %s", err)
+	}
+
+	// Check the state
+	s, err := b.State("prod")
+	if err != nil {
+		t.Fatalf("bad: %s", err)
+	}
+	if err := s.RefreshState(); err != nil {
+		t.Fatalf("bad: %s", err)
+	}
+	state := s.State()
+	if state == nil || state.Lineage != "backend-change" {
+		t.Fatalf("bad: %#v", state)
+	}
+
+	// Verify we are now in the workspace the state was copied to
+	if env := m.Workspace(); env != "prod" {
+		t.Fatalf("bad workspace: %q", env)
+	}
+}
+
+// Changing a configured backend with -migrate-dry-run doesn't change
+// anything.
+func TestMetaBackend_configuredChangeCopy_dryRun(t *testing.T) {
+	// Create a temporary working directory that is empty
+	td := tempDir(t)
+	copy.CopyDir(testFixturePath("backend-change-multi-to-multi"), td)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	// Setup the meta
+	ui := new(cli.MockUi)
+	m := testMetaBackend(t, nil)
+	m.Ui = ui
+	m.migrateDryRun = true
+
+	// Get the backend
+	_, err := m.Backend(&BackendOpts{Init: true})
+	if err != errBackendMigrateDryRun {
+		t.Fatalf("bad: %s", err)
+	}
+
+	output := ui.OutputWriter.String()
+	for _, s := range []string{
+		`"default" -> "default": copy`,
+		`"env2" -> "env2": copy`,
+	} {
+		if !strings.Contains(output, s) {
+			t.Fatalf("expected %q in output:\n%s", s, output)
+		}
+	}
+
+	// Verify nothing was copied
+	if _, err := os.Stat("envdir-new"); err == nil {
+		t.Fatal("destination workspaces should not exist")
+	}
+
+	// Verify the saved backend is unchanged
+	actual := testStateRead(t, filepath.Join(DefaultDataDir, DefaultStateFilename))
+	if v := actual.Backend.Config["path"]; v != "local-state.tfstate" {
+		t.Fatalf("bad: %#v", actual.Backend)
+	}
+}
+
 // Unsetting a saved backend
 func TestMetaBackend_configuredUnset(t *testing.T) {
 	// Create a temporary working directory that is empty
@@ -62,6 +62,15 @@ func (m *Meta) Module(path string) (*module.Tree, tfdiags.Diagnostics) {
 
 	diags = diags.Append(mod.Validate())
 
+	for _, v := range mod.Config().Variables {
+		if v.Sensitive {
+			if m.sensitiveVariables == nil {
+				m.sensitiveVariables = make(map[string]bool)
+			}
+			m.sensitiveVariables[v.Name] = true
+		}
+	}
+
 	return mod, diags
 }
 
@@ -162,8 +171,15 @@ func (m *Meta) Plan(path string) (*terraform.Plan, error) {
 
 	// We do a validation here that seems odd but if any plan is given,
 	// we must not have set any extra variables. The plan itself contains
-	// the variables and those aren't overwritten.
-	if len(m.variables) > 0 {
+	// the variables and those aren't overwritten. The values from the
+	// files that are loaded automatically are ignored.
+	explicit := false
+	for _, v := range m.variables {
+		if v.SourceType != terraform.ValueFromAutoFile {
+			explicit = true
+		}
+	}
+	if explicit {
 		return nil, fmt.Errorf(
 			"You can't set variables with the '-var' or '-var-file' flag\n" +
 				"when you're applying a plan file. The variables used when\n" +
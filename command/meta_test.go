@@ -8,6 +8,7 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/davecgh/go-spew/spew"
 	"github.com/hashicorp/terraform/backend"
 	"github.com/hashicorp/terraform/terraform"
 )
@@ -387,3 +388,64 @@ func TestMeta_process(t *testing.T) {
 		t.Fatalf("expected %q, got %q", fileLastAlphabetical, args[5])
 	}
 }
+
+func TestMeta_variables(t *testing.T) {
+	test = false
+	defer func() { test = true }()
+
+	d := tempDir(t)
+	os.MkdirAll(d, 0755)
+	defer os.RemoveAll(d)
+	defer testChdir(t, d)()
+
+	files := map[string]string{
+		DefaultVarsFilename: "a = \"default\"\nb = \"default\"\nc = \"default\"\n",
+		"x.auto.tfvars":     "b = \"auto\"\n",
+		"prod.tfvars":       "c = \"named\"\nd = \"named\"\n",
+	}
+	for name, src := range files {
+		if err := ioutil.WriteFile(filepath.Join(d, name), []byte(src), 0644); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+	}
+
+	m := new(Meta)
+	args, err := m.process([]string{"-var", "d=arg", "-var-file", "prod.tfvars"}, true)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := m.flagSet("foo").Parse(args); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// The automatically-loaded files come first, and then the flags in the
+	// order they're given.
+	want := terraform.InputValues{
+		"a": &terraform.InputValue{Value: "default", SourceType: terraform.ValueFromAutoFile, SourceName: DefaultVarsFilename},
+		"b": &terraform.InputValue{Value: "auto", SourceType: terraform.ValueFromAutoFile, SourceName: "x.auto.tfvars"},
+		"c": &terraform.InputValue{Value: "named", SourceType: terraform.ValueFromNamedFile, SourceName: "prod.tfvars"},
+		"d": &terraform.InputValue{Value: "named", SourceType: terraform.ValueFromNamedFile, SourceName: "prod.tfvars"},
+	}
+	if !reflect.DeepEqual(m.variables, want) {
+		t.Fatalf("wrong variables\ngot:  %s\nwant: %s", spew.Sdump(m.variables), spew.Sdump(want))
+	}
+
+	if got := m.contextOpts().InputValues; !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong input values\ngot:  %s\nwant: %s", spew.Sdump(got), spew.Sdump(want))
+	}
+}
+
+func TestMeta_diagnosticsWidth(t *testing.T) {
+	defer os.Setenv("COLUMNS", os.Getenv("COLUMNS"))
+
+	m := new(Meta)
+	os.Setenv("COLUMNS", "120")
+	if got := m.diagnosticsWidth(); got != 120 {
+		t.Fatalf("wrong width %d; want 120", got)
+	}
+
+	os.Setenv("COLUMNS", "wide")
+	if got := m.diagnosticsWidth(); got <= 0 {
+		t.Fatalf("wrong width %d", got)
+	}
+}
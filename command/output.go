@@ -7,6 +7,11 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+
+	hcl2 "github.com/hashicorp/hcl2/hcl"
+	"github.com/hashicorp/hcl2/hcl/hclsyntax"
+	"github.com/hashicorp/terraform/config/hcl2shim"
+	"github.com/zclconf/go-cty/cty"
 )
 
 // OutputCommand is a Command implementation that reads an output
@@ -21,10 +26,12 @@ func (c *OutputCommand) Run(args []string) int {
 		return 1
 	}
 
-	var module string
-	var jsonOutput bool
+	var module, query string
+	var jsonOutput, rawOutput bool
 	cmdFlags := flag.NewFlagSet("output", flag.ContinueOnError)
 	cmdFlags.BoolVar(&jsonOutput, "json", false, "json")
+	cmdFlags.BoolVar(&rawOutput, "raw", false, "raw")
+	cmdFlags.StringVar(&query, "query", "", "query")
 	cmdFlags.StringVar(&c.Meta.statePath, "state", DefaultStateFilename, "path")
 	cmdFlags.StringVar(&module, "module", "", "module")
 	cmdFlags.Usage = func() { c.Ui.Error(c.Help()) }
@@ -46,6 +53,38 @@ func (c *OutputCommand) Run(args []string) int {
 		name = args[0]
 	}
 
+	// A query names the output it extracts a value from as the root of the
+	// traversal, such as "instance_ips[0]".
+	var traversal hcl2.Traversal
+	if query != "" {
+		if name != "" {
+			c.Ui.Error("The -query option can't be used with the name of an output.\n")
+			cmdFlags.Usage()
+			return 1
+		}
+
+		var diags hcl2.Diagnostics
+		traversal, diags = hclsyntax.ParseTraversalAbs([]byte(query), "<query>", hcl2.Pos{Line: 1, Column: 1})
+		if diags.HasErrors() {
+			c.Ui.Error(fmt.Sprintf("Invalid query %q: %s", query, diags.Error()))
+			return 1
+		}
+		name = traversal.RootName()
+	}
+
+	if rawOutput {
+		if jsonOutput {
+			c.Ui.Error("The -raw and -json options are mutually exclusive.\n")
+			cmdFlags.Usage()
+			return 1
+		}
+		if name == "" {
+			c.Ui.Error("The -raw option requires the name of an output or a -query.\n")
+			cmdFlags.Usage()
+			return 1
+		}
+	}
+
 	// Load the backend
 	b, err := c.Backend(nil)
 	if err != nil {
@@ -121,15 +160,48 @@ func (c *OutputCommand) Run(args []string) int {
 		return 1
 	}
 
+	// The JSON of a whole output describes its type and sensitivity, while
+	// that of a queried value is only the value.
+	var value interface{} = v.Value
+	var jsonValue interface{} = v
+	if traversal != nil {
+		ctx := &hcl2.EvalContext{
+			Variables: map[string]cty.Value{
+				name: hcl2shim.HCL2ValueFromConfigValue(v.Value),
+			},
+		}
+		result, diags := traversal.TraverseAbs(ctx)
+		if diags.HasErrors() {
+			c.Ui.Error(fmt.Sprintf("Failed to query output %q: %s", name, diags.Error()))
+			return 1
+		}
+
+		value = hcl2shim.ConfigValueFromHCL2(result)
+		jsonValue = value
+	}
+
+	if rawOutput {
+		output, ok := value.(string)
+		if !ok {
+			c.Ui.Error(fmt.Sprintf(
+				"The -raw option only supports string values, but %q is not a string.\n"+
+					"Use the -json option to read values of any type.", name))
+			return 1
+		}
+
+		c.Ui.Output(output)
+		return 0
+	}
+
 	if jsonOutput {
-		jsonOutputs, err := json.MarshalIndent(v, "", "    ")
+		jsonOutputs, err := json.MarshalIndent(jsonValue, "", "    ")
 		if err != nil {
 			return 1
 		}
 
 		c.Ui.Output(string(jsonOutputs))
 	} else {
-		switch output := v.Value.(type) {
+		switch output := value.(type) {
 		case string:
 			c.Ui.Output(output)
 			return 0
@@ -139,8 +211,11 @@ func (c *OutputCommand) Run(args []string) int {
 		case map[string]interface{}:
 			c.Ui.Output(formatMapOutput("", "", output))
 			return 0
+		case bool, int, float64:
+			c.Ui.Output(fmt.Sprint(output))
+			return 0
 		default:
-			c.Ui.Error(fmt.Sprintf("Unknown output type: %T", v.Type))
+			c.Ui.Error(fmt.Sprintf("Unknown output type: %T", output))
 			return 1
 		}
 	}
@@ -283,6 +358,14 @@ Options:
   -json            If specified, machine readable output will be
                    printed in JSON format
 
+  -raw             If specified, the value of a string output is
+                   printed as is, without any formatting. Requires
+                   NAME or -query.
+
+  -query=expr      Print the value at a path within an output, such
+                   as "instance_ips[0]" or "db.address", instead of
+                   a whole output. Can't be used with NAME.
+
 `
 	return strings.TrimSpace(helpText)
 }
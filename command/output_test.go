@@ -351,6 +351,51 @@ func TestOutput_blank(t *testing.T) {
 	}
 }
 
+func TestOutput_sensitive(t *testing.T) {
+	originalState := &terraform.State{
+		Modules: []*terraform.ModuleState{
+			{
+				Path: []string{"root"},
+				Outputs: map[string]*terraform.OutputState{
+					"foo": {
+						Value: "bar",
+						Type:  "string",
+					},
+					"password": {
+						Value:     "hunter2",
+						Type:      "string",
+						Sensitive: true,
+					},
+				},
+			},
+		},
+	}
+
+	statePath := testStateFile(t, originalState)
+
+	ui := new(cli.MockUi)
+	c := &OutputCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(testProvider()),
+			Ui:               ui,
+		},
+	}
+
+	args := []string{
+		"-state", statePath,
+	}
+
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: \n%s", ui.ErrorWriter.String())
+	}
+
+	expectedOutput := "foo = bar\npassword = <sensitive>\n"
+	output := ui.OutputWriter.String()
+	if output != expectedOutput {
+		t.Fatalf("Expected output: %#v\ngiven: %#v", expectedOutput, output)
+	}
+}
+
 func TestOutput_manyArgs(t *testing.T) {
 	ui := new(cli.MockUi)
 	c := &OutputCommand{
@@ -497,3 +542,164 @@ func TestOutput_stateDefault(t *testing.T) {
 		t.Fatalf("bad: %#v", actual)
 	}
 }
+
+func TestOutput_raw(t *testing.T) {
+	originalState := &terraform.State{
+		Modules: []*terraform.ModuleState{
+			{
+				Path: []string{"root"},
+				Outputs: map[string]*terraform.OutputState{
+					"foo": {
+						Value: "bar",
+						Type:  "string",
+					},
+					"list": {
+						Value: []interface{}{"a", "b"},
+						Type:  "list",
+					},
+				},
+			},
+		},
+	}
+
+	statePath := testStateFile(t, originalState)
+
+	ui := new(cli.MockUi)
+	c := &OutputCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(testProvider()),
+			Ui:               ui,
+		},
+	}
+
+	args := []string{
+		"-state", statePath,
+		"-raw",
+		"foo",
+	}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: \n%s", ui.ErrorWriter.String())
+	}
+
+	actual := strings.TrimSpace(ui.OutputWriter.String())
+	if actual != "bar" {
+		t.Fatalf("bad: %#v", actual)
+	}
+
+	// only strings can be printed raw
+	ui = new(cli.MockUi)
+	c = &OutputCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(testProvider()),
+			Ui:               ui,
+		},
+	}
+	args = []string{
+		"-state", statePath,
+		"-raw",
+		"list",
+	}
+	if code := c.Run(args); code != 1 {
+		t.Fatalf("expected an error for a list output, got %d", code)
+	}
+	if !strings.Contains(ui.ErrorWriter.String(), "-json") {
+		t.Fatalf("bad error: %s", ui.ErrorWriter.String())
+	}
+}
+
+func TestOutput_query(t *testing.T) {
+	originalState := &terraform.State{
+		Modules: []*terraform.ModuleState{
+			{
+				Path: []string{"root"},
+				Outputs: map[string]*terraform.OutputState{
+					"db": {
+						Value: map[string]interface{}{
+							"address": "db.example.com",
+							"ports":   []interface{}{"5432", "5433"},
+						},
+						Type: "map",
+					},
+				},
+			},
+		},
+	}
+
+	statePath := testStateFile(t, originalState)
+
+	cases := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"-query", "db.address"}, "db.example.com"},
+		{[]string{"-query", `db["address"]`, "-raw"}, "db.example.com"},
+		{[]string{"-query", "db.ports[1]"}, "5433"},
+		{[]string{"-query", "db.ports", "-json"}, "[\n    \"5432\",\n    \"5433\"\n]"},
+	}
+
+	for _, tc := range cases {
+		ui := new(cli.MockUi)
+		c := &OutputCommand{
+			Meta: Meta{
+				testingOverrides: metaOverridesForProvider(testProvider()),
+				Ui:               ui,
+			},
+		}
+
+		args := append([]string{"-state", statePath}, tc.args...)
+		if code := c.Run(args); code != 0 {
+			t.Fatalf("%v: bad: \n%s", tc.args, ui.ErrorWriter.String())
+		}
+
+		actual := strings.TrimSpace(ui.OutputWriter.String())
+		if actual != tc.expected {
+			t.Fatalf("%v: bad: %#v", tc.args, actual)
+		}
+	}
+}
+
+func TestOutput_queryBad(t *testing.T) {
+	originalState := &terraform.State{
+		Modules: []*terraform.ModuleState{
+			{
+				Path: []string{"root"},
+				Outputs: map[string]*terraform.OutputState{
+					"db": {
+						Value: map[string]interface{}{
+							"address": "db.example.com",
+						},
+						Type: "map",
+					},
+				},
+			},
+		},
+	}
+
+	statePath := testStateFile(t, originalState)
+
+	cases := [][]string{
+		// not a traversal
+		{"-query", "db +"},
+		// missing attribute
+		{"-query", "db.port"},
+		// missing output
+		{"-query", "foo.address"},
+		// both a name and a query
+		{"-query", "db.address", "db"},
+	}
+
+	for _, tc := range cases {
+		ui := new(cli.MockUi)
+		c := &OutputCommand{
+			Meta: Meta{
+				testingOverrides: metaOverridesForProvider(testProvider()),
+				Ui:               ui,
+			},
+		}
+
+		args := append([]string{"-state", statePath}, tc...)
+		if code := c.Run(args); code != 1 {
+			t.Fatalf("%v: expected an error, got %d", tc, code)
+		}
+	}
+}
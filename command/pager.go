@@ -0,0 +1,44 @@
+package command
+
+import (
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// defaultDiffPager is the pager that "terraform apply -page" uses when
+// neither the CLI configuration nor the PAGER environment variable sets one.
+const defaultDiffPager = "less -R"
+
+// diffPager returns the pager that plans are shown with before they're
+// approved.
+func (m *Meta) diffPager() *commandPager {
+	command := m.DiffPager
+	if command == "" {
+		command = os.Getenv("PAGER")
+	}
+	if command == "" {
+		command = defaultDiffPager
+	}
+	return &commandPager{command: command}
+}
+
+// commandPager is a backend.DiffPager that pipes the text into a pager
+// command, which is run by the shell so that it can have arguments.
+type commandPager struct {
+	command string
+}
+
+func (p *commandPager) Page(text string) error {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", p.command)
+	} else {
+		cmd = exec.Command("sh", "-c", p.command)
+	}
+	cmd.Stdin = strings.NewReader(text)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
@@ -0,0 +1,52 @@
+package command
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestCommandPager(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the pager command is run by sh")
+	}
+
+	td := testTempDir(t)
+	defer os.RemoveAll(td)
+	out := filepath.Join(td, "paged")
+
+	p := &commandPager{command: "cat > " + out}
+	if err := p.Page("the plan\n"); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ioutil.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "the plan\n" {
+		t.Fatalf("wrong text %q", got)
+	}
+}
+
+func TestMeta_diffPager(t *testing.T) {
+	defer os.Setenv("PAGER", os.Getenv("PAGER"))
+
+	os.Setenv("PAGER", "more")
+	m := &Meta{DiffPager: "less -RS"}
+	if got, want := m.diffPager().command, "less -RS"; got != want {
+		t.Fatalf("wrong pager %q; want %q", got, want)
+	}
+
+	m.DiffPager = ""
+	if got, want := m.diffPager().command, "more"; got != want {
+		t.Fatalf("wrong pager %q; want %q", got, want)
+	}
+
+	os.Setenv("PAGER", "")
+	if got, want := m.diffPager().command, defaultDiffPager; got != want {
+		t.Fatalf("wrong pager %q; want %q", got, want)
+	}
+}
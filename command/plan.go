@@ -3,12 +3,16 @@ package command
 import (
 	"context"
 	"fmt"
+	"os"
 	"strings"
 
 	"github.com/hashicorp/terraform/backend"
+	"github.com/hashicorp/terraform/command/views"
 	"github.com/hashicorp/terraform/config"
 	"github.com/hashicorp/terraform/config/module"
+	"github.com/hashicorp/terraform/terraform"
 	"github.com/hashicorp/terraform/tfdiags"
+	"github.com/posener/complete"
 )
 
 // PlanCommand is a Command implementation that compares a Terraform
@@ -18,9 +22,10 @@ type PlanCommand struct {
 }
 
 func (c *PlanCommand) Run(args []string) int {
-	var destroy, refresh, detailed bool
-	var outPath string
+	var destroy, refresh, refreshOnly, detailed, jsonOutput, structuralDiff, concise bool
+	var outPath, evalTracePath string
 	var moduleDepth int
+	var replace []string
 
 	args, err := c.Meta.process(args, true)
 	if err != nil {
@@ -30,12 +35,18 @@ func (c *PlanCommand) Run(args []string) int {
 	cmdFlags := c.Meta.flagSet("plan")
 	cmdFlags.BoolVar(&destroy, "destroy", false, "destroy")
 	cmdFlags.BoolVar(&refresh, "refresh", true, "refresh")
+	cmdFlags.BoolVar(&refreshOnly, "refresh-only", false, "refresh-only")
+	cmdFlags.Var((*FlagStringSlice)(&replace), "replace", "resource to replace")
 	c.addModuleDepthFlag(cmdFlags, &moduleDepth)
 	cmdFlags.StringVar(&outPath, "out", "", "path")
 	cmdFlags.IntVar(
 		&c.Meta.parallelism, "parallelism", DefaultParallelism, "parallelism")
 	cmdFlags.StringVar(&c.Meta.statePath, "state", "", "path")
 	cmdFlags.BoolVar(&detailed, "detailed-exitcode", false, "detailed-exitcode")
+	cmdFlags.BoolVar(&jsonOutput, "json", false, "json")
+	cmdFlags.StringVar(&evalTracePath, "eval-trace", "", "path")
+	cmdFlags.BoolVar(&structuralDiff, "structural-diff", true, "structural-diff")
+	cmdFlags.BoolVar(&concise, "concise", false, "concise")
 	cmdFlags.BoolVar(&c.Meta.stateLock, "lock", true, "lock state")
 	cmdFlags.DurationVar(&c.Meta.stateLockTimeout, "lock-timeout", 0, "lock timeout")
 	cmdFlags.Usage = func() { c.Ui.Error(c.Help()) }
@@ -43,6 +54,19 @@ func (c *PlanCommand) Run(args []string) int {
 		return 1
 	}
 
+	if refreshOnly {
+		if msg := refreshOnlyConflict(refresh, destroy, replace); msg != "" {
+			c.Ui.Error(msg)
+			return 1
+		}
+	}
+
+	if jsonOutput {
+		// The output is a stream of events, so there's no asking for input.
+		c.Meta.input = false
+		c.Meta.jsonView = views.NewJSONView(c.Ui)
+	}
+
 	configPath, err := ModulePath(cmdFlags.Args())
 	if err != nil {
 		c.Ui.Error(err.Error())
@@ -103,8 +127,15 @@ func (c *PlanCommand) Run(args []string) int {
 	opReq.Module = mod
 	opReq.Plan = plan
 	opReq.PlanRefresh = refresh
+	opReq.PlanRefreshOnly = refreshOnly
 	opReq.PlanOutPath = outPath
+	opReq.Replace = replace
+	opReq.NoStructuralDiff = !structuralDiff
+	opReq.ConcisePlan = concise
 	opReq.Type = backend.OperationTypePlan
+	if evalTracePath != "" {
+		opReq.EvalTrace = terraform.NewEvalTrace()
+	}
 
 	// Perform the operation
 	ctx, ctxCancel := context.WithCancel(context.Background())
@@ -122,7 +153,11 @@ func (c *PlanCommand) Run(args []string) int {
 		ctxCancel()
 
 		// Notify the user
-		c.Ui.Output(outputInterrupt)
+		if c.jsonView != nil {
+			c.jsonView.Log("Interrupt received. Gracefully shutting down...")
+		} else {
+			c.Ui.Output(outputInterrupt)
+		}
 
 		// Still get the result, since there is still one
 		select {
@@ -138,6 +173,14 @@ func (c *PlanCommand) Run(args []string) int {
 		}
 	}
 
+	// The trace is written even if the plan failed, since that's when it's
+	// most likely to be wanted.
+	if opReq.EvalTrace != nil {
+		if err := writeEvalTrace(evalTracePath, opReq.EvalTrace); err != nil {
+			diags = diags.Append(fmt.Errorf("Error writing evaluation trace: %s", err))
+		}
+	}
+
 	c.showDiagnostics(diags)
 	if diags.HasErrors() {
 		return 1
@@ -150,6 +193,23 @@ func (c *PlanCommand) Run(args []string) int {
 	return 0
 }
 
+func (c *PlanCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictDirs("")
+}
+
+func (c *PlanCommand) AutocompleteFlags() complete.Flags {
+	flags := c.completeFlagsOperation()
+	flags["-destroy"] = complete.PredictNothing
+	flags["-detailed-exitcode"] = complete.PredictNothing
+	flags["-eval-trace"] = complete.PredictFiles("*.json")
+	flags["-json"] = complete.PredictNothing
+	flags["-module-depth"] = complete.PredictAnything
+	flags["-out"] = complete.PredictFiles("*.tfplan")
+	flags["-refresh-only"] = complete.PredictNothing
+	flags["-replace"] = c.completePredictResourceAddress()
+	return flags
+}
+
 func (c *PlanCommand) Help() string {
 	helpText := `
 Usage: terraform plan [options] [DIR-OR-PLAN]
@@ -166,6 +226,10 @@ Usage: terraform plan [options] [DIR-OR-PLAN]
 
 Options:
 
+  -concise            Show only the attributes that are changing, collapsing
+                      nested blocks that aren't changing to a count of their
+                      elements.
+
   -destroy            If set, a plan will be generated to destroy all resources
                       managed by the given configuration and state.
 
@@ -175,8 +239,16 @@ Options:
                       1 - Errored
                       2 - Succeeded, there is a diff
 
+  -eval-trace=path    Write every reference resolved while planning, with
+                      the value it resolved to and where the value came
+                      from, to the given path as JSON.
+
   -input=true         Ask for input for variables if not directly set.
 
+  -json               Output a stream of JSON events, one per line, instead
+                      of the text output, for automation to consume. This
+                      disables asking for input.
+
   -lock=true          Lock the state file when locking is supported.
 
   -lock-timeout=0s    Duration to retry a state lock.
@@ -194,10 +266,24 @@ Options:
 
   -refresh=true       Update state prior to checking for differences.
 
+  -refresh-only       Plan to only update the state to match any changes made
+                      to remote objects outside of Terraform, without
+                      proposing any changes to them. This can't be combined
+                      with -destroy, -refresh=false or -replace.
+
+  -replace=resource   Plan to replace the given resource instance even if
+                      its configuration hasn't changed. This flag can be used
+                      multiple times.
+
   -state=statefile    Path to a Terraform state file to use to look
                       up Terraform-managed resources. By default it will
                       use the state "terraform.tfstate" if it exists.
 
+  -structural-diff=true
+                      Show changes to string attributes that contain JSON or
+                      YAML as the values that changed within the documents.
+                      If false, they're shown as diffs of their text.
+
   -target=resource    Resource to target. Operation will be limited to this
                       resource and its dependencies. This flag can be used
                       multiple times.
@@ -212,6 +298,34 @@ Options:
 	return strings.TrimSpace(helpText)
 }
 
+// writeEvalTrace writes the given evaluation trace to the file at path.
+func writeEvalTrace(path string, trace *terraform.EvalTrace) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := trace.WriteJSON(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// refreshOnlyConflict returns an error message if the -refresh-only flag is
+// combined with flags of the plan or apply commands that would change what
+// the plan does, or an empty string if it isn't.
+func refreshOnlyConflict(refresh, destroy bool, replace []string) string {
+	switch {
+	case !refresh:
+		return "The -refresh-only and -refresh=false options are mutually exclusive."
+	case destroy:
+		return "The -refresh-only and -destroy options are mutually exclusive."
+	case len(replace) > 0:
+		return "The -refresh-only and -replace options are mutually exclusive."
+	}
+	return ""
+}
+
 func (c *PlanCommand) Synopsis() string {
 	return "Generate and show an execution plan"
 }
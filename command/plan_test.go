@@ -2,6 +2,7 @@ package command
 
 import (
 	"bytes"
+	"encoding/json"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -159,6 +160,158 @@ func TestPlan_destroy(t *testing.T) {
 	}
 }
 
+func TestPlan_replace(t *testing.T) {
+	originalState := &terraform.State{
+		Modules: []*terraform.ModuleState{
+			&terraform.ModuleState{
+				Path: []string{"root"},
+				Resources: map[string]*terraform.ResourceState{
+					"test_instance.foo": &terraform.ResourceState{
+						Type: "test_instance",
+						Primary: &terraform.InstanceState{
+							ID: "bar",
+						},
+					},
+				},
+			},
+		},
+	}
+
+	outPath := testTempFile(t)
+	statePath := testStateFile(t, originalState)
+
+	p := testProvider()
+	ui := new(cli.MockUi)
+	c := &PlanCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(p),
+			Ui:               ui,
+		},
+	}
+
+	args := []string{
+		"-replace", "test_instance.foo",
+		"-out", outPath,
+		"-state", statePath,
+		testFixturePath("plan"),
+	}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+	}
+
+	plan := testReadPlan(t, outPath)
+	rd := plan.Diff.RootModule().Resources["test_instance.foo"]
+	if rd == nil {
+		t.Fatal("missing diff for test_instance.foo")
+	}
+	if rd.ActionReason != terraform.DiffActionReasonReplaceByRequest {
+		t.Fatalf("wrong action reason: %#v", rd)
+	}
+	if !strings.Contains(ui.OutputWriter.String(), "(replace requested)") {
+		t.Fatalf("output does not mention the requested replacement:\n%s", ui.OutputWriter.String())
+	}
+
+	// The state must not have been modified
+	if testStateRead(t, statePath).RootModule().Resources["test_instance.foo"].Primary.Tainted {
+		t.Fatal("state should not be tainted")
+	}
+}
+
+func TestPlan_refreshOnly(t *testing.T) {
+	originalState := &terraform.State{
+		Modules: []*terraform.ModuleState{
+			&terraform.ModuleState{
+				Path: []string{"root"},
+				Resources: map[string]*terraform.ResourceState{
+					"test_instance.foo": &terraform.ResourceState{
+						Type: "test_instance",
+						Primary: &terraform.InstanceState{
+							ID:         "bar",
+							Attributes: map[string]string{"ami": "bar"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	outPath := testTempFile(t)
+	statePath := testStateFile(t, originalState)
+
+	p := testProvider()
+	p.RefreshFn = nil
+	p.RefreshReturn = &terraform.InstanceState{
+		ID:         "bar",
+		Attributes: map[string]string{"ami": "baz"},
+	}
+	ui := new(cli.MockUi)
+	c := &PlanCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(p),
+			Ui:               ui,
+		},
+	}
+
+	args := []string{
+		"-refresh-only",
+		"-detailed-exitcode",
+		"-out", outPath,
+		"-state", statePath,
+		testFixturePath("plan"),
+	}
+	if code := c.Run(args); code != 2 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+	}
+
+	if p.DiffCalled {
+		t.Fatal("refresh-only plan should not diff resources")
+	}
+	if !strings.Contains(ui.OutputWriter.String(), "This is a refresh-only plan") {
+		t.Fatalf("output does not describe the refresh-only plan:\n%s", ui.OutputWriter.String())
+	}
+
+	plan := testReadPlan(t, outPath)
+	if !plan.RefreshOnly {
+		t.Fatal("saved plan should be refresh-only")
+	}
+	if !plan.Diff.Empty() {
+		t.Fatalf("refresh-only plan should have no changes:\n%s", plan.Diff)
+	}
+	if got := plan.State.RootModule().Resources["test_instance.foo"].Primary.Attributes["ami"]; got != "baz" {
+		t.Fatalf("saved plan should have the refreshed state, got ami = %q", got)
+	}
+}
+
+func TestPlan_refreshOnlyConflicts(t *testing.T) {
+	cases := map[string][]string{
+		"refresh": {"-refresh=false"},
+		"destroy": {"-destroy"},
+		"replace": {"-replace", "test_instance.foo"},
+	}
+
+	for name, flags := range cases {
+		t.Run(name, func(t *testing.T) {
+			p := testProvider()
+			ui := new(cli.MockUi)
+			c := &PlanCommand{
+				Meta: Meta{
+					testingOverrides: metaOverridesForProvider(p),
+					Ui:               ui,
+				},
+			}
+
+			args := append([]string{"-refresh-only"}, flags...)
+			args = append(args, testFixturePath("plan"))
+			if code := c.Run(args); code != 1 {
+				t.Fatalf("bad: %d\n\n%s", code, ui.OutputWriter.String())
+			}
+			if !strings.Contains(ui.ErrorWriter.String(), "mutually exclusive") {
+				t.Fatalf("wrong error:\n%s", ui.ErrorWriter.String())
+			}
+		})
+	}
+}
+
 func TestPlan_noState(t *testing.T) {
 	tmp, cwd := testCwd(t)
 	defer testFixCwd(t, tmp, cwd)
@@ -664,6 +817,53 @@ func TestPlan_vars(t *testing.T) {
 	}
 }
 
+func TestPlan_evalTrace(t *testing.T) {
+	tmp, cwd := testCwd(t)
+	defer testFixCwd(t, tmp, cwd)
+
+	p := testProvider()
+	ui := new(cli.MockUi)
+	c := &PlanCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(p),
+			Ui:               ui,
+		},
+	}
+
+	tracePath := filepath.Join(tmp, "trace.json")
+	args := []string{
+		"-var", "foo=bar",
+		"-eval-trace", tracePath,
+		testFixturePath("plan-vars"),
+	}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+	}
+
+	data, err := ioutil.ReadFile(tracePath)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	var entries []*terraform.EvalTraceEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	found := false
+	for _, e := range entries {
+		if e.Operation != "plan" || e.Reference != "var.foo" {
+			continue
+		}
+		found = true
+		if e.Value != "bar" || e.Source != terraform.EvalTraceVariable || e.Resource != "test_instance.foo" {
+			t.Fatalf("bad: %#v", e)
+		}
+	}
+	if !found {
+		t.Fatalf("var.foo isn't in the trace:\n%s", data)
+	}
+}
+
 func TestPlan_varsUnset(t *testing.T) {
 	tmp, cwd := testCwd(t)
 	defer testFixCwd(t, tmp, cwd)
@@ -900,3 +1100,62 @@ const testPlanStateDefaultStr = `
 ID = bar
 Tainted = false
 `
+
+func TestPlan_json(t *testing.T) {
+	p := testProvider()
+	ui := new(cli.MockUi)
+	c := &PlanCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(p),
+			Ui:               ui,
+		},
+	}
+
+	args := []string{
+		"-json",
+		testFixturePath("plan"),
+	}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+	}
+
+	events := testJSONEvents(t, ui.OutputWriter.String())
+	want := []string{"version", "operation_start", "planned_change", "change_summary"}
+	if got := testJSONEventTypes(events); !reflect.DeepEqual(got, want) {
+		t.Fatalf("bad event types\ngot:  %#v\nwant: %#v", got, want)
+	}
+
+	change := events[2]["change"].(map[string]interface{})
+	resource := change["resource"].(map[string]interface{})
+	if change["action"] != "create" || resource["addr"] != "test_instance.foo" {
+		t.Fatalf("bad planned change: %#v", change)
+	}
+	if events[3]["@message"] != "Plan: 1 to add, 0 to change, 0 to destroy." {
+		t.Fatalf("bad change summary: %#v", events[3])
+	}
+}
+
+// testJSONEvents decodes the line-delimited JSON events of the output of a
+// command run with -json.
+func testJSONEvents(t *testing.T, output string) []map[string]interface{} {
+	t.Helper()
+
+	var events []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		var event map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			t.Fatalf("output line %q is not a JSON event: %s", line, err)
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+// testJSONEventTypes returns the types of the events.
+func testJSONEventTypes(events []map[string]interface{}) []string {
+	var types []string
+	for _, event := range events {
+		types = append(types, event["type"].(string))
+	}
+	return types
+}
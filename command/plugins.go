@@ -10,13 +10,17 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
+	"sync"
 
 	plugin "github.com/hashicorp/go-plugin"
 	terraformProvider "github.com/hashicorp/terraform/builtin/providers/terraform"
+	"github.com/hashicorp/terraform/helper/crash"
 	tfplugin "github.com/hashicorp/terraform/plugin"
 	"github.com/hashicorp/terraform/plugin/discovery"
 	"github.com/hashicorp/terraform/terraform"
+	"github.com/hashicorp/terraform/tfdiags"
 	"github.com/kardianos/osext"
 )
 
@@ -33,6 +37,84 @@ type multiVersionProviderResolver struct {
 	// exceptional circumstances since it forces the provider's release
 	// schedule to be tied to that of Terraform Core.
 	Internal map[string]terraform.ResourceProviderFactory
+
+	// SchemaCacheDir, if non-empty, is the directory where the schemas
+	// returned by provider plugins are cached between runs.
+	SchemaCacheDir string
+
+	// ReattachProviders is the value of the TF_REATTACH_PROVIDERS
+	// environment variable, which describes any provider processes that
+	// are already running and should be used instead of discovered
+	// plugins. Like internal providers, these are not subject to version
+	// constraints.
+	ReattachProviders string
+
+	// DevOverrides maps provider names to directories containing
+	// development builds of those providers, which are used instead of
+	// discovered plugins regardless of version constraints and the plugin
+	// lock file.
+	DevOverrides map[string]string
+
+	// LockFile, if non-empty, is the path of the dependency lock file. If
+	// the file exists then each provider must be locked in it, and only the
+	// locked version is used.
+	LockFile string
+}
+
+// pluginClients is the set of plugin clients used by this process.
+var pluginClients = &pluginClientSet{
+	clients: make(map[string]*plugin.Client),
+}
+
+// pluginClientSet retains the client for each plugin executable, so that
+// all of the contexts created while running a single command share the
+// same plugin process rather than each launching and tearing down their
+// own. The processes are killed when the command exits, by
+// plugin.CleanupClients.
+type pluginClientSet struct {
+	lock    sync.Mutex
+	clients map[string]*plugin.Client
+}
+
+// Client returns the client for the plugin described by the given metadata,
+// whose executable has the given SHA256 hash. A new client is created if
+// there is none yet, or if the process of the previous one has exited.
+func (s *pluginClientSet) Client(meta discovery.PluginMeta, digest []byte) *plugin.Client {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	key := pluginClientKey(meta, digest)
+	if client, ok := s.clients[key]; ok {
+		if !client.Exited() {
+			return client
+		}
+		log.Printf("[WARN] plugin %s exited; it will be restarted", meta.Path)
+	}
+
+	client := tfplugin.Client(meta)
+	s.clients[key] = client
+	return client
+}
+
+// Discard kills the given client's plugin process, and removes the client
+// from the set if it is the current client for the plugin described by the
+// given metadata, so that the next call to Client starts a new process.
+//
+// This is used when the connection to a plugin has been lost, in which case
+// the process may not yet have been seen to exit.
+func (s *pluginClientSet) Discard(meta discovery.PluginMeta, digest []byte, client *plugin.Client) {
+	s.lock.Lock()
+	key := pluginClientKey(meta, digest)
+	if s.clients[key] == client {
+		delete(s.clients, key)
+	}
+	s.lock.Unlock()
+
+	client.Kill()
+}
+
+func pluginClientKey(meta discovery.PluginMeta, digest []byte) string {
+	return fmt.Sprintf("%s\x00%x", meta.Path, digest)
 }
 
 func choosePlugins(avail discovery.PluginMetaSet, internal map[string]terraform.ResourceProviderFactory, reqd discovery.PluginRequirements) map[string]discovery.PluginMeta {
@@ -59,17 +141,83 @@ func (r *multiVersionProviderResolver) ResolveProviders(
 	factories := make(map[string]terraform.ResourceProviderFactory, len(reqd))
 	var errs []error
 
+	reattach, err := tfplugin.ParseReattachProviders(r.ReattachProviders)
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	var locks discovery.ProviderLocks
+	conflicts := make(map[string]*discovery.ProviderLock)
+	if r.LockFile != "" {
+		locks, err = discovery.ReadProviderLocks(r.LockFile)
+		if err != nil {
+			return nil, []error{err}
+		}
+	}
+	if locks != nil {
+		var lockConflicts []*discovery.ProviderLock
+		reqd, lockConflicts = reqd.Locked(locks)
+		for _, l := range lockConflicts {
+			conflicts[l.Name] = l
+		}
+	}
+
 	chosen := choosePlugins(r.Available, r.Internal, reqd)
 	for name, req := range reqd {
+		if config, ok := reattach[name]; ok {
+			log.Printf("[INFO] provider.%s: using already-running process %d", name, config.Pid)
+			factories[name] = reattachedProviderFactory(tfplugin.ReattachClient(config))
+			crash.SetProvider(name, "reattached")
+			continue
+		}
+
+		if dir, ok := r.DevOverrides[name]; ok {
+			metas := discovery.FindPlugins("provider", []string{dir}).WithName(name)
+			metas, _ = metas.ValidateVersions()
+			if metas.Count() == 0 {
+				errs = append(errs, fmt.Errorf("provider.%s: no plugin found in development override directory %s", name, dir))
+				continue
+			}
+
+			meta := metas.Newest()
+			digest, err := meta.SHA256()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("provider.%s: failed to read plugin: %s", name, err))
+				continue
+			}
+
+			log.Printf("[WARN] provider.%s: using development build %s", name, meta.Path)
+			factories[name] = providerFactory(meta, digest, nil)
+			crash.SetProvider(name, fmt.Sprintf("%s (development build)", meta.Version))
+			continue
+		}
+
 		if factory, isInternal := r.Internal[name]; isInternal {
 			if !req.Versions.Unconstrained() {
 				errs = append(errs, fmt.Errorf("provider.%s: this provider is built in to Terraform and so it does not support version constraints", name))
 				continue
 			}
 			factories[name] = factory
+			crash.SetProvider(name, "built-in")
 			continue
 		}
 
+		if locks != nil {
+			if _, locked := locks[name]; !locked {
+				errs = append(errs, fmt.Errorf(
+					"provider.%s: not recorded in the dependency lock file %s; run \"terraform init\" to select a version",
+					name, r.LockFile))
+				continue
+			}
+			if l, conflict := conflicts[name]; conflict {
+				errs = append(errs, fmt.Errorf(
+					"provider.%s: the version locked in %s, %s, doesn't meet the version constraints %q; "+
+						"run \"terraform init -upgrade\" to select a new version",
+					name, r.LockFile, l.Version, req.Versions))
+				continue
+			}
+		}
+
 		if newest, available := chosen[name]; available {
 			digest, err := newest.SHA256()
 			if err != nil {
@@ -81,8 +229,18 @@ func (r *multiVersionProviderResolver) ResolveProviders(
 				continue
 			}
 
-			client := tfplugin.Client(newest)
-			factories[name] = providerFactory(client)
+			var cache *tfplugin.ProviderSchemaCache
+			if r.SchemaCacheDir != "" {
+				cache = &tfplugin.ProviderSchemaCache{
+					Dir:     r.SchemaCacheDir,
+					Name:    newest.Name,
+					Version: string(newest.Version),
+					SHA256:  digest,
+				}
+			}
+
+			factories[name] = providerFactory(newest, digest, cache)
+			crash.SetProvider(name, string(newest.Version))
 		} else {
 			msg := fmt.Sprintf("provider.%s: no suitable version installed", name)
 
@@ -203,10 +361,10 @@ func (m *Meta) pluginDirs(includeAutoInstalled bool) []string {
 }
 
 func (m *Meta) pluginCache() discovery.PluginCache {
-	dir := m.PluginCacheDir
-	if dir == "" {
+	if m.WorkingDir == nil || m.WorkingDir.PluginCacheDir() == "" {
 		return nil // cache disabled
 	}
+	dir := m.WorkingDir.PluginCacheDir()
 
 	dir = filepath.Join(dir, pluginMachineName)
 
@@ -272,12 +430,56 @@ func (m *Meta) providerPluginManuallyInstalledSet() discovery.PluginMetaSet {
 }
 
 func (m *Meta) providerResolver() terraform.ResourceProviderResolver {
+	m.warnProviderDevOverrides()
+
+	var schemaCacheDir string
+	if m.schemaCache {
+		schemaCacheDir = filepath.Join(m.DataDir(), "schemas")
+	}
+
 	return &multiVersionProviderResolver{
-		Available: m.providerPluginSet(),
-		Internal:  m.internalProviders(),
+		Available:      m.providerPluginSet(),
+		Internal:       m.internalProviders(),
+		SchemaCacheDir: schemaCacheDir,
+
+		ReattachProviders: os.Getenv(tfplugin.ReattachEnvVar),
+		DevOverrides:      m.ProviderDevOverrides,
+		LockFile:          DefaultDependencyLockFile,
 	}
 }
 
+// warnProviderDevOverrides shows a warning if any provider development
+// overrides are in effect, since they cause the installed plugins and the
+// plugin lock file to be ignored.
+func (m *Meta) warnProviderDevOverrides() {
+	if len(m.ProviderDevOverrides) == 0 || m.devOverridesWarned {
+		return
+	}
+	m.devOverridesWarned = true
+
+	names := make([]string, 0, len(m.ProviderDevOverrides))
+	for name := range m.ProviderDevOverrides {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var lines []string
+	for _, name := range names {
+		lines = append(lines, fmt.Sprintf(" - %s in %s", name, m.ProviderDevOverrides[name]))
+	}
+
+	var diags tfdiags.Diagnostics
+	diags = diags.Append(tfdiags.SimpleWarning(fmt.Sprintf(
+		"Provider development overrides are in effect\n\n"+
+			"The following provider development overrides are set in the CLI configuration:\n%s\n\n"+
+			"The behavior may therefore not match any released version of the provider, "+
+			"and applying changes may cause the state to become incompatible with "+
+			"published releases.",
+		strings.Join(lines, "\n"),
+	)))
+	m.showDiagnostics(diags)
+}
+
 func (m *Meta) internalProviders() map[string]terraform.ResourceProviderFactory {
 	return map[string]terraform.ResourceProviderFactory{
 		"terraform": func() (terraform.ResourceProvider, error) {
@@ -293,12 +495,25 @@ func (m *Meta) missingPlugins(avail discovery.PluginMetaSet, reqd discovery.Plug
 	candidates := avail.ConstrainVersions(reqd)
 	internal := m.internalProviders()
 
+	// Any error here is reported when the providers are resolved.
+	reattach, _ := tfplugin.ParseReattachProviders(os.Getenv(tfplugin.ReattachEnvVar))
+
 	for name, versionSet := range reqd {
 		// internal providers can't be missing
 		if _, ok := internal[name]; ok {
 			continue
 		}
 
+		// nor can providers that are already running
+		if _, ok := reattach[name]; ok {
+			continue
+		}
+
+		// nor can development builds, which are never installed
+		if _, ok := m.ProviderDevOverrides[name]; ok {
+			continue
+		}
+
 		log.Printf("[DEBUG] plugin requirements: %q=%q", name, versionSet.Versions)
 		if metas := candidates[name]; metas.Count() == 0 {
 			missing[name] = versionSet
@@ -365,8 +580,17 @@ func internalPluginClient(kind, name string) (*plugin.Client, error) {
 	return plugin.NewClient(cfg), nil
 }
 
-func providerFactory(client *plugin.Client) terraform.ResourceProviderFactory {
-	return func() (terraform.ResourceProvider, error) {
+// providerFactory returns a factory for instances of the provider plugin
+// described by the given metadata, whose executable has the given SHA256
+// hash.
+//
+// The instances are able to restart the plugin if its process crashes, so
+// that the rest of the operation can continue.
+func providerFactory(meta discovery.PluginMeta, digest []byte, cache *tfplugin.ProviderSchemaCache) terraform.ResourceProviderFactory {
+	var dispense func() (*tfplugin.ResourceProvider, error)
+	dispense = func() (*tfplugin.ResourceProvider, error) {
+		client := pluginClients.Client(meta, digest)
+
 		// Request the RPC client so we can get the provider
 		// so we can build the actual RPC-implemented provider.
 		rpcClient, err := client.Client()
@@ -379,6 +603,42 @@ func providerFactory(client *plugin.Client) terraform.ResourceProviderFactory {
 			return nil, err
 		}
 
+		p := raw.(*tfplugin.ResourceProvider)
+		p.SchemaCache = cache
+		p.Reconnect = func() (*tfplugin.ResourceProvider, error) {
+			pluginClients.Discard(meta, digest, client)
+			return dispense()
+		}
+
+		return p, nil
+	}
+
+	return func() (terraform.ResourceProvider, error) {
+		p, err := dispense()
+		if err != nil {
+			return nil, err
+		}
+		return p, nil
+	}
+}
+
+// reattachedProviderFactory returns a factory for instances of the provider
+// in the already-running process that the given client is attached to.
+//
+// Terraform doesn't control the process, so unlike plugins it launches
+// itself the process can't be restarted if it exits.
+func reattachedProviderFactory(client *plugin.Client) terraform.ResourceProviderFactory {
+	return func() (terraform.ResourceProvider, error) {
+		rpcClient, err := client.Client()
+		if err != nil {
+			return nil, err
+		}
+
+		raw, err := rpcClient.Dispense(tfplugin.ProviderPluginName)
+		if err != nil {
+			return nil, err
+		}
+
 		return raw.(terraform.ResourceProvider), nil
 	}
 }
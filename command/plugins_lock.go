@@ -7,6 +7,8 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+
+	"github.com/hashicorp/terraform/plugin/discovery"
 )
 
 func (m *Meta) providerPluginsLock() *pluginSHA256LockFile {
@@ -15,6 +17,18 @@ func (m *Meta) providerPluginsLock() *pluginSHA256LockFile {
 	}
 }
 
+// providerDependencyLocks reads the dependency lock file, returning nil if
+// there is none.
+func (m *Meta) providerDependencyLocks() (discovery.ProviderLocks, error) {
+	return discovery.ReadProviderLocks(DefaultDependencyLockFile)
+}
+
+// saveProviderDependencyLocks replaces the contents of the dependency lock
+// file with the given locks.
+func (m *Meta) saveProviderDependencyLocks(locks discovery.ProviderLocks) error {
+	return locks.Write(DefaultDependencyLockFile)
+}
+
 type pluginSHA256LockFile struct {
 	Filename string
 }
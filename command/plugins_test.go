@@ -8,6 +8,7 @@ import (
 	"reflect"
 	"testing"
 
+	plugin "github.com/hashicorp/go-plugin"
 	"github.com/hashicorp/terraform/plugin/discovery"
 	"github.com/hashicorp/terraform/terraform"
 )
@@ -94,6 +95,195 @@ func TestMultiVersionProviderResolver(t *testing.T) {
 	})
 }
 
+func TestMultiVersionProviderResolver_devOverrides(t *testing.T) {
+	dir := tempDir(t)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, "terraform-provider-dev"), nil, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	resolver := &multiVersionProviderResolver{
+		Available: make(discovery.PluginMetaSet),
+		DevOverrides: map[string]string{
+			"dev":     dir,
+			"missing": filepath.Join(dir, "missing"),
+		},
+	}
+
+	t.Run("override", func(t *testing.T) {
+		// Development builds are used whatever the version constraint.
+		reqd := discovery.PluginRequirements{
+			"dev": &discovery.PluginConstraints{
+				Versions: discovery.ConstraintStr("2.0.0").MustParse(),
+			},
+		}
+		got, err := resolver.ResolveProviders(reqd)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if _, exists := got["dev"]; !exists {
+			t.Errorf("provider \"dev\" not in result")
+		}
+	})
+	t.Run("no plugin in directory", func(t *testing.T) {
+		reqd := discovery.PluginRequirements{
+			"missing": &discovery.PluginConstraints{
+				Versions: discovery.AllVersions,
+			},
+		}
+		_, err := resolver.ResolveProviders(reqd)
+		if err == nil {
+			t.Errorf("resolved successfully, but want error")
+		}
+	})
+}
+
+func TestMultiVersionProviderResolver_reattach(t *testing.T) {
+	resolver := &multiVersionProviderResolver{
+		Available:         make(discovery.PluginMetaSet),
+		ReattachProviders: `{"debug":{"Protocol":"netrpc","Pid":1,"Addr":{"Network":"unix","String":"/tmp/plugin.sock"}}}`,
+	}
+
+	t.Run("reattached", func(t *testing.T) {
+		// The process is already running, so the version constraint is
+		// ignored and no plugin needs to be installed.
+		reqd := discovery.PluginRequirements{
+			"debug": &discovery.PluginConstraints{
+				Versions: discovery.ConstraintStr("2.0.0").MustParse(),
+			},
+		}
+		got, err := resolver.ResolveProviders(reqd)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if _, exists := got["debug"]; !exists {
+			t.Errorf("provider \"debug\" not in result")
+		}
+	})
+	t.Run("invalid", func(t *testing.T) {
+		resolver := &multiVersionProviderResolver{
+			Available:         make(discovery.PluginMetaSet),
+			ReattachProviders: `{`,
+		}
+		_, err := resolver.ResolveProviders(discovery.PluginRequirements{})
+		if err == nil {
+			t.Errorf("resolved successfully, but want error")
+		}
+	})
+}
+
+func TestMultiVersionProviderResolver_locks(t *testing.T) {
+	td := tempDir(t)
+	if err := os.MkdirAll(td, 0755); err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(td)
+
+	lockFile := filepath.Join(td, DefaultDependencyLockFile)
+	locks := discovery.ProviderLocks{
+		"plugin": &discovery.ProviderLock{
+			Name:    "plugin",
+			Version: discovery.VersionStr("1.0.0").MustParse(),
+		},
+		"uninstalled": &discovery.ProviderLock{
+			Name:    "uninstalled",
+			Version: discovery.VersionStr("2.0.0").MustParse(),
+		},
+	}
+	if err := locks.Write(lockFile); err != nil {
+		t.Fatal(err)
+	}
+
+	available := make(discovery.PluginMetaSet)
+	for _, meta := range []discovery.PluginMeta{
+		{Name: "plugin", Version: "1.0.0", Path: "test-fixtures/empty-file"},
+		{Name: "uninstalled", Version: "1.0.0", Path: "test-fixtures/empty-file"},
+		{Name: "unlocked", Version: "1.0.0", Path: "test-fixtures/empty-file"},
+	} {
+		available.Add(meta)
+	}
+
+	resolver := &multiVersionProviderResolver{
+		Internal: map[string]terraform.ResourceProviderFactory{
+			"internal": func() (terraform.ResourceProvider, error) {
+				return &terraform.MockResourceProvider{}, nil
+			},
+		},
+		Available: available,
+		LockFile:  lockFile,
+	}
+
+	t.Run("locked version installed", func(t *testing.T) {
+		reqd := discovery.PluginRequirements{
+			"plugin": &discovery.PluginConstraints{
+				Versions: discovery.ConstraintStr(">= 1.0").MustParse(),
+			},
+			"internal": &discovery.PluginConstraints{
+				Versions: discovery.AllVersions,
+			},
+		}
+		got, err := resolver.ResolveProviders(reqd)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if ct := len(got); ct != 2 {
+			t.Errorf("wrong number of results %d; want 2", ct)
+		}
+	})
+	t.Run("locked version not installed", func(t *testing.T) {
+		// Another version that meets the constraints is installed, but
+		// isn't used.
+		reqd := discovery.PluginRequirements{
+			"uninstalled": &discovery.PluginConstraints{
+				Versions: discovery.AllVersions,
+			},
+		}
+		_, err := resolver.ResolveProviders(reqd)
+		if err == nil {
+			t.Errorf("resolved successfully, but want error")
+		}
+	})
+	t.Run("locked version doesn't meet constraints", func(t *testing.T) {
+		reqd := discovery.PluginRequirements{
+			"plugin": &discovery.PluginConstraints{
+				Versions: discovery.ConstraintStr("> 1.0").MustParse(),
+			},
+		}
+		_, err := resolver.ResolveProviders(reqd)
+		if err == nil {
+			t.Errorf("resolved successfully, but want error")
+		}
+	})
+	t.Run("not locked", func(t *testing.T) {
+		reqd := discovery.PluginRequirements{
+			"unlocked": &discovery.PluginConstraints{
+				Versions: discovery.AllVersions,
+			},
+		}
+		_, err := resolver.ResolveProviders(reqd)
+		if err == nil {
+			t.Errorf("resolved successfully, but want error")
+		}
+	})
+	t.Run("no lock file", func(t *testing.T) {
+		resolver := &multiVersionProviderResolver{
+			Available: available,
+			LockFile:  filepath.Join(td, "nonexist.hcl"),
+		}
+		reqd := discovery.PluginRequirements{
+			"unlocked": &discovery.PluginConstraints{
+				Versions: discovery.AllVersions,
+			},
+		}
+		if _, err := resolver.ResolveProviders(reqd); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	})
+}
+
 func TestPluginPath(t *testing.T) {
 	td, err := ioutil.TempDir("", "tf")
 	if err != nil {
@@ -213,3 +403,22 @@ func (cb callbackPluginInstaller) PurgeUnused(map[string]discovery.PluginMeta) (
 	// does nothing
 	return make(discovery.PluginMetaSet), nil
 }
+
+func TestPluginClientSet(t *testing.T) {
+	s := &pluginClientSet{
+		clients: make(map[string]*plugin.Client),
+	}
+	meta := discovery.PluginMeta{
+		Name:    "plugin",
+		Version: "1.0.0",
+		Path:    "test-fixtures/empty-file",
+	}
+
+	a := s.Client(meta, []byte{1})
+	if b := s.Client(meta, []byte{1}); b != a {
+		t.Error("same executable should share a client")
+	}
+	if b := s.Client(meta, []byte{2}); b == a {
+		t.Error("changed executable should have a new client")
+	}
+}
@@ -2,8 +2,10 @@ package command
 
 import (
 	"fmt"
+	"log"
 	"sort"
 
+	"github.com/hashicorp/terraform/config"
 	"github.com/hashicorp/terraform/moduledeps"
 	"github.com/hashicorp/terraform/terraform"
 	"github.com/xlab/treeprint"
@@ -77,9 +79,17 @@ func (c *ProvidersCommand) Run(args []string) int {
 
 	depTree := terraform.ModuleTreeDependencies(root, s)
 	depTree.SortDescendents()
+	diags = diags.Append(depTree.ProviderSourceConflicts())
+
+	// The module calls give the sources and versions of the modules, which
+	// are shown along with their names.
+	calls, err := config.LoadModuleCalls(configPath)
+	if err != nil {
+		log.Printf("[WARN] failed to load module calls: %s", err)
+	}
 
 	printRoot := treeprint.New()
-	providersCommandPopulateTreeNode(printRoot, depTree)
+	providersCommandPopulateTreeNode(printRoot, depTree, calls)
 
 	c.Ui.Output(printRoot.String())
 
@@ -91,7 +101,7 @@ func (c *ProvidersCommand) Run(args []string) int {
 	return 0
 }
 
-func providersCommandPopulateTreeNode(node treeprint.Tree, deps *moduledeps.Module) {
+func providersCommandPopulateTreeNode(node treeprint.Tree, deps *moduledeps.Module, calls []*config.ModuleCall) {
 	names := make([]string, 0, len(deps.Providers))
 	for name := range deps.Providers {
 		names = append(names, string(name))
@@ -111,12 +121,31 @@ func providersCommandPopulateTreeNode(node treeprint.Tree, deps *moduledeps.Modu
 		case moduledeps.ProviderDependencyFromState:
 			reasonStr = " (from state)"
 		}
-		node.AddNode(fmt.Sprintf("provider.%s%s%s", name, versionsStr, reasonStr))
+		var sourceStr string
+		if dep.Source != "" {
+			sourceStr = " from " + dep.Source
+		}
+		node.AddNode(fmt.Sprintf("provider.%s%s%s%s", name, sourceStr, versionsStr, reasonStr))
 	}
 
 	for _, child := range deps.Children {
-		childNode := node.AddBranch(fmt.Sprintf("module.%s", child.Name))
-		providersCommandPopulateTreeNode(childNode, child)
+		label := fmt.Sprintf("module.%s", child.Name)
+		var childCalls []*config.ModuleCall
+		for _, call := range calls {
+			if call.Name != child.Name {
+				continue
+			}
+			if call.Version != "" {
+				label += fmt.Sprintf(" (%s %s)", call.Source, call.Version)
+			} else {
+				label += fmt.Sprintf(" (%s)", call.Source)
+			}
+			childCalls = call.Calls
+			break
+		}
+
+		childNode := node.AddBranch(label)
+		providersCommandPopulateTreeNode(childNode, child, childCalls)
 	}
 }
 
@@ -0,0 +1,240 @@
+package command
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform/plugin"
+	"github.com/hashicorp/terraform/plugin/discovery"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+// ProvidersLockCommand is a Command implementation that records the hashes
+// of the provider packages for one or more platforms in the dependency lock
+// file, so that the configuration can be initialized on those platforms.
+type ProvidersLockCommand struct {
+	Meta
+}
+
+func (c *ProvidersLockCommand) Help() string {
+	return providersLockCommandHelp
+}
+
+func (c *ProvidersLockCommand) Synopsis() string {
+	return "Records provider package hashes for platforms in the dependency lock file"
+}
+
+func (c *ProvidersLockCommand) Run(args []string) int {
+	args, err := c.Meta.process(args, false)
+	if err != nil {
+		return 1
+	}
+
+	var platforms FlagStringSlice
+	var fsMirror, netMirror string
+	cmdFlags := c.Meta.flagSet("providers lock")
+	cmdFlags.Var(&platforms, "platform", "target platform")
+	cmdFlags.StringVar(&fsMirror, "fs-mirror", "", "filesystem mirror")
+	cmdFlags.StringVar(&netMirror, "net-mirror", "", "network mirror")
+	cmdFlags.Usage = func() { c.Ui.Error(c.Help()) }
+	if err := cmdFlags.Parse(args); err != nil {
+		return 1
+	}
+
+	// A mirror given on the command line replaces the provider installation
+	// methods of the CLI configuration.
+	sources := c.ProviderSources
+	switch {
+	case fsMirror != "" && netMirror != "":
+		c.Ui.Error("The -fs-mirror and -net-mirror options are mutually exclusive.")
+		return 1
+	case fsMirror != "":
+		sources = []*discovery.ProviderInstallationSource{
+			{Source: &discovery.FilesystemMirrorSource{Dir: fsMirror}},
+		}
+	case netMirror != "":
+		if !strings.HasPrefix(netMirror, "https://") {
+			c.Ui.Error("The -net-mirror option requires an https: URL.")
+			return 1
+		}
+		sources = []*discovery.ProviderInstallationSource{
+			{Source: &discovery.NetworkMirrorSource{URL: netMirror}},
+		}
+	}
+
+	if len(platforms) == 0 {
+		platforms = FlagStringSlice{pluginMachineName}
+	}
+	for _, platform := range platforms {
+		if parts := strings.Split(platform, "_"); len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			c.Ui.Error(fmt.Sprintf(
+				"Invalid platform %q: platforms must be given as OS_ARCH, such as linux_amd64.",
+				platform))
+			return 1
+		}
+	}
+
+	configPath, err := ModulePath(cmdFlags.Args())
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
+	// Load the config
+	root, diags := c.Module(configPath)
+	if diags.HasErrors() {
+		c.showDiagnostics(diags)
+		return 1
+	}
+	if root == nil {
+		c.Ui.Error(fmt.Sprintf(
+			"No configuration files found in the directory: %s\n\n"+
+				"This command requires configuration to run.",
+			configPath))
+		return 1
+	}
+
+	// Load the backend
+	b, err := c.Backend(&BackendOpts{
+		Config: root.Config(),
+	})
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to load backend: %s", err))
+		return 1
+	}
+
+	// Get the state
+	env := c.Workspace()
+	state, err := b.State(env)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to load state: %s", err))
+		return 1
+	}
+	if err := state.RefreshState(); err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to load state: %s", err))
+		return 1
+	}
+
+	locks, err := c.providerDependencyLocks()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error reading the dependency lock file: %s", err))
+		return 1
+	}
+	if locks == nil {
+		locks = make(discovery.ProviderLocks)
+	}
+
+	requirements := terraform.ModuleTreeDependencies(root, state.State()).AllPluginRequirements()
+	internal := c.internalProviders()
+	names := make([]string, 0, len(requirements))
+	for name := range requirements {
+		// Internal providers and development builds are never installed,
+		// so they aren't locked.
+		if _, isInternal := internal[name]; isInternal {
+			continue
+		}
+		if _, isDev := c.ProviderDevOverrides[name]; isDev {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	installer := &discovery.ProviderInstaller{
+		PluginProtocolVersion: plugin.Handshake.ProtocolVersion,
+		Sources:               sources,
+		Ui:                    c.Ui,
+	}
+
+	failed := false
+	for _, name := range names {
+		req := requirements[name].Versions
+
+		// Providers that are already locked keep their locked version, and
+		// the others are locked to the newest version that init would
+		// select.
+		cons := req
+		existing := locks[name]
+		if existing != nil && existing.Source != requirements[name].Source {
+			// A lock of a provider from another source is replaced.
+			existing = nil
+		}
+		if existing != nil {
+			if !req.Allows(existing.Version) {
+				c.Ui.Error(fmt.Sprintf(errProviderLockConflict, name, existing.Version, req, DefaultDependencyLockFile))
+				failed = true
+				continue
+			}
+			cons = discovery.ConstraintStr(existing.Version.String()).MustParse()
+		}
+
+		c.Ui.Output(fmt.Sprintf("- Retrieving hashes for provider %q...", name))
+		l, err := installer.Lock(name, cons, platforms)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Failed to retrieve hashes for provider %q: %s", name, err))
+			failed = true
+			continue
+		}
+
+		if existing != nil {
+			existing.AddHashes(l.Hashes...)
+			continue
+		}
+		l.Source = requirements[name].Source
+		l.Constraints = req.String()
+		locks[name] = l
+	}
+
+	if failed {
+		return 1
+	}
+	if len(names) == 0 {
+		c.Ui.Output("The configuration doesn't require any providers that can be locked.")
+		return 0
+	}
+
+	if err := c.saveProviderDependencyLocks(locks); err != nil {
+		c.Ui.Error(fmt.Sprintf("Error updating the dependency lock file: %s", err))
+		return 1
+	}
+
+	c.Ui.Output(c.Colorize().Color(fmt.Sprintf(
+		"\n[reset][bold][green]Success![reset] The dependency lock file %s now has "+
+			"the hashes of the selected provider versions for: %s",
+		DefaultDependencyLockFile, strings.Join(platforms, ", "))))
+	return 0
+}
+
+const providersLockCommandHelp = `
+Usage: terraform providers lock [options] [DIR]
+
+  Records the hashes of the provider packages for the given platforms in the
+  dependency lock file, .terraform.lock.hcl, selecting a version for each
+  provider that isn't locked yet the same way "terraform init" would.
+
+  "terraform init" only records the hashes it can obtain while installing
+  providers for the current platform, which may not include those of other
+  platforms. Run this command to add them, so that the configuration can be
+  initialized on each platform your team uses with the packages verified
+  against the lock file.
+
+  To select newer versions of the locked providers, run
+  "terraform init -upgrade" instead.
+
+Options:
+
+  -fs-mirror=DIR     Retrieve the hashes from the filesystem mirror in DIR,
+                     such as one made by "terraform providers mirror",
+                     instead of using the provider installation methods of
+                     the CLI configuration.
+
+  -net-mirror=URL    Retrieve the hashes from the network mirror at the given
+                     https: URL instead of using the provider installation
+                     methods of the CLI configuration.
+
+  -platform=OS_ARCH  A platform to record hashes for, such as linux_amd64 or
+                     darwin_amd64. This flag can be used multiple times, and
+                     defaults to the current platform.
+
+`
@@ -0,0 +1,217 @@
+package command
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/copy"
+	"github.com/hashicorp/terraform/plugin/discovery"
+	"github.com/mitchellh/cli"
+)
+
+// testProviderMirror writes release archives of the "test" provider to a
+// filesystem mirror in the given directory, returning their hashes by
+// version and platform.
+func testProviderMirror(t *testing.T, dir string, versions, platforms []string) map[string]map[string]string {
+	hashes := make(map[string]map[string]string)
+	for _, v := range versions {
+		vdir := filepath.Join(dir, "terraform-provider-test", v)
+		if err := os.MkdirAll(vdir, 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		hashes[v] = make(map[string]string)
+		for _, platform := range platforms {
+			var buf bytes.Buffer
+			z := zip.NewWriter(&buf)
+			w, err := z.Create(fmt.Sprintf("terraform-provider-test_v%s_x4", v))
+			if err != nil {
+				t.Fatal(err)
+			}
+			fmt.Fprintf(w, "%s %s", v, platform)
+			if err := z.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			name := fmt.Sprintf("terraform-provider-test_%s_%s.zip", v, platform)
+			if err := ioutil.WriteFile(filepath.Join(vdir, name), buf.Bytes(), 0644); err != nil {
+				t.Fatal(err)
+			}
+			sum := sha256.Sum256(buf.Bytes())
+			hashes[v][platform] = discovery.ArchiveHash(hex.EncodeToString(sum[:]))
+		}
+	}
+	return hashes
+}
+
+func TestProvidersLock(t *testing.T) {
+	td := tempDir(t)
+	copy.CopyDir(testFixturePath("init-dependency-lock"), td)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	mirror := filepath.Join(td, "mirror")
+	hashes := testProviderMirror(t, mirror,
+		[]string{"1.2.3", "1.3.0", "2.0.0"},
+		[]string{"linux_amd64", "darwin_amd64"},
+	)
+
+	ui := new(cli.MockUi)
+	c := &ProvidersLockCommand{
+		Meta: Meta{
+			Ui: ui,
+			ProviderSources: []*discovery.ProviderInstallationSource{
+				{Source: &discovery.FilesystemMirrorSource{Dir: mirror}},
+			},
+		},
+	}
+
+	// The newest version that meets the constraints is locked.
+	args := []string{"-platform=linux_amd64", "-platform=darwin_amd64"}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+	}
+
+	locks, err := discovery.ReadProviderLocks(DefaultDependencyLockFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lock := locks["test"]
+	if lock == nil || lock.Version.String() != "1.3.0" || lock.Constraints != "~> 1.0" {
+		t.Fatalf("wrong lock %#v", lock)
+	}
+	want := []string{hashes["1.3.0"]["darwin_amd64"], hashes["1.3.0"]["linux_amd64"]}
+	if want[0] > want[1] {
+		want[0], want[1] = want[1], want[0]
+	}
+	if !reflect.DeepEqual(lock.Hashes, want) {
+		t.Fatalf("wrong hashes\ngot:  %#v\nwant: %#v", lock.Hashes, want)
+	}
+}
+
+func TestProvidersLock_locked(t *testing.T) {
+	td := tempDir(t)
+	copy.CopyDir(testFixturePath("init-dependency-lock"), td)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	mirror := filepath.Join(td, "mirror")
+	hashes := testProviderMirror(t, mirror,
+		[]string{"1.2.3", "1.3.0"},
+		[]string{"linux_amd64", "darwin_amd64"},
+	)
+
+	locks := discovery.ProviderLocks{
+		"test": &discovery.ProviderLock{
+			Name:    "test",
+			Version: discovery.VersionStr("1.2.3").MustParse(),
+			Hashes:  []string{hashes["1.2.3"]["linux_amd64"]},
+		},
+	}
+	if err := locks.Write(DefaultDependencyLockFile); err != nil {
+		t.Fatal(err)
+	}
+
+	ui := new(cli.MockUi)
+	c := &ProvidersLockCommand{
+		Meta: Meta{
+			Ui: ui,
+			ProviderSources: []*discovery.ProviderInstallationSource{
+				{Source: &discovery.FilesystemMirrorSource{Dir: mirror}},
+			},
+		},
+	}
+
+	// The locked version is kept, and the new platform's hash is added.
+	if code := c.Run([]string{"-platform=darwin_amd64"}); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+	}
+
+	got, err := discovery.ReadProviderLocks(DefaultDependencyLockFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lock := got["test"]
+	if lock.Version.String() != "1.2.3" {
+		t.Fatalf("wrong version %s", lock.Version)
+	}
+	for _, platform := range []string{"linux_amd64", "darwin_amd64"} {
+		if !lock.HasHash(hashes["1.2.3"][platform]) {
+			t.Errorf("no hash for %s in %#v", platform, lock.Hashes)
+		}
+	}
+
+	// There are no packages for Windows.
+	ui = new(cli.MockUi)
+	c.Meta.Ui = ui
+	if code := c.Run([]string{"-platform=windows_amd64"}); code == 0 {
+		t.Fatal("succeeded; want error for a platform without packages")
+	}
+}
+
+func TestProvidersLock_fsMirror(t *testing.T) {
+	td := tempDir(t)
+	copy.CopyDir(testFixturePath("init-dependency-lock"), td)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	mirror := filepath.Join(td, "mirror")
+	hashes := testProviderMirror(t, mirror,
+		[]string{"1.2.3"},
+		[]string{"linux_amd64", "windows_amd64"},
+	)
+
+	// The mirror on the command line is used instead of the configured
+	// sources, which don't have the provider.
+	ui := new(cli.MockUi)
+	c := &ProvidersLockCommand{
+		Meta: Meta{
+			Ui: ui,
+			ProviderSources: []*discovery.ProviderInstallationSource{
+				{Source: &discovery.FilesystemMirrorSource{Dir: filepath.Join(td, "empty")}},
+			},
+		},
+	}
+
+	args := []string{"-fs-mirror=" + mirror, "-platform=linux_amd64", "-platform=windows_amd64"}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+	}
+
+	locks, err := discovery.ReadProviderLocks(DefaultDependencyLockFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lock := locks["test"]
+	for _, platform := range []string{"linux_amd64", "windows_amd64"} {
+		if !lock.HasHash(hashes["1.2.3"][platform]) {
+			t.Errorf("no hash for %s in %#v", platform, lock.Hashes)
+		}
+	}
+}
+
+func TestProvidersLock_mirrorFlags(t *testing.T) {
+	cases := [][]string{
+		{"-fs-mirror=a", "-net-mirror=https://example.com/"},
+		{"-net-mirror=http://example.com/"},
+	}
+	for _, args := range cases {
+		ui := new(cli.MockUi)
+		c := &ProvidersLockCommand{
+			Meta: Meta{
+				Ui: ui,
+			},
+		}
+		if code := c.Run(args); code != 1 {
+			t.Errorf("%v: bad: %d", args, code)
+		}
+	}
+}
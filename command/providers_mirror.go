@@ -0,0 +1,203 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform/plugin"
+	"github.com/hashicorp/terraform/plugin/discovery"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+// ProvidersMirrorCommand is a Command implementation that downloads the
+// provider packages required by the configuration into a local mirror
+// directory, for installing providers without access to their origin.
+type ProvidersMirrorCommand struct {
+	Meta
+}
+
+func (c *ProvidersMirrorCommand) Help() string {
+	return providersMirrorCommandHelp
+}
+
+func (c *ProvidersMirrorCommand) Synopsis() string {
+	return "Mirrors the provider plugins needed for the current configuration"
+}
+
+func (c *ProvidersMirrorCommand) Run(args []string) int {
+	args, err := c.Meta.process(args, false)
+	if err != nil {
+		return 1
+	}
+
+	var platforms FlagStringSlice
+	cmdFlags := c.Meta.flagSet("providers mirror")
+	cmdFlags.Var(&platforms, "platform", "target platform")
+	cmdFlags.Usage = func() { c.Ui.Error(c.Help()) }
+	if err := cmdFlags.Parse(args); err != nil {
+		return 1
+	}
+
+	args = cmdFlags.Args()
+	if len(args) != 1 {
+		c.Ui.Error("The providers mirror command expects exactly one argument, the target directory.")
+		cmdFlags.Usage()
+		return 1
+	}
+	outputDir := args[0]
+
+	if len(platforms) == 0 {
+		platforms = FlagStringSlice{pluginMachineName}
+	}
+	for _, platform := range platforms {
+		if parts := strings.Split(platform, "_"); len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			c.Ui.Error(fmt.Sprintf(
+				"Invalid platform %q: platforms must be given as OS_ARCH, such as linux_amd64.",
+				platform))
+			return 1
+		}
+	}
+
+	configPath, err := os.Getwd()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error getting pwd: %s", err))
+		return 1
+	}
+
+	// Load the config
+	root, diags := c.Module(configPath)
+	if diags.HasErrors() {
+		c.showDiagnostics(diags)
+		return 1
+	}
+	if root == nil {
+		c.Ui.Error(fmt.Sprintf(
+			"No configuration files found in the directory: %s\n\n"+
+				"This command requires configuration to run.",
+			configPath))
+		return 1
+	}
+
+	// Load the backend
+	b, err := c.Backend(&BackendOpts{
+		Config: root.Config(),
+	})
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to load backend: %s", err))
+		return 1
+	}
+
+	// Get the state
+	env := c.Workspace()
+	state, err := b.State(env)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to load state: %s", err))
+		return 1
+	}
+	if err := state.RefreshState(); err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to load state: %s", err))
+		return 1
+	}
+
+	locks, err := c.providerDependencyLocks()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error reading the dependency lock file: %s", err))
+		return 1
+	}
+
+	requirements := terraform.ModuleTreeDependencies(root, state.State()).AllPluginRequirements()
+	internal := c.internalProviders()
+	names := make([]string, 0, len(requirements))
+	for name := range requirements {
+		// Internal providers and development builds are never installed,
+		// so they aren't mirrored.
+		if _, isInternal := internal[name]; isInternal {
+			continue
+		}
+		if _, isDev := c.ProviderDevOverrides[name]; isDev {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// The packages of locked providers are also verified against the
+	// hashes in the lock file.
+	installer := &discovery.ProviderInstaller{
+		PluginProtocolVersion: plugin.Handshake.ProtocolVersion,
+		Sources:               c.ProviderSources,
+		Locks:                 locks,
+		Ui:                    c.Ui,
+	}
+
+	failed := false
+	for _, name := range names {
+		req := requirements[name].Versions
+
+		// Providers that are locked are mirrored at their locked version,
+		// and the others at the newest version that init would select.
+		cons := req
+		if l := locks[name]; l != nil {
+			if !req.Allows(l.Version) {
+				c.Ui.Error(fmt.Sprintf(errProviderLockConflict, name, l.Version, req, DefaultDependencyLockFile))
+				failed = true
+				continue
+			}
+			cons = discovery.ConstraintStr(l.Version.String()).MustParse()
+		}
+
+		c.Ui.Output(fmt.Sprintf("- Mirroring provider %q...", name))
+		v, err := installer.Mirror(outputDir, name, cons, platforms)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Failed to mirror provider %q: %s", name, err))
+			failed = true
+			continue
+		}
+		if err := discovery.WriteMirrorIndex(outputDir, name); err != nil {
+			c.Ui.Error(fmt.Sprintf("Failed to write the mirror index of provider %q: %s", name, err))
+			failed = true
+			continue
+		}
+		c.Ui.Output(fmt.Sprintf("- Mirrored provider %q (%s)", name, v))
+	}
+
+	if failed {
+		return 1
+	}
+	if len(names) == 0 {
+		c.Ui.Output("The configuration doesn't require any providers that can be mirrored.")
+		return 0
+	}
+
+	c.Ui.Output(c.Colorize().Color(fmt.Sprintf(
+		"\n[reset][bold][green]Success![reset] The providers are mirrored in %s for: %s",
+		outputDir, strings.Join(platforms, ", "))))
+	return 0
+}
+
+const providersMirrorCommandHelp = `
+Usage: terraform providers mirror [options] TARGET_DIR
+
+  Downloads the provider packages required by the configuration in the
+  current working directory into the target directory, so that they can be
+  installed on machines without access to their usual source.
+
+  The packages are laid out as a filesystem mirror, and the directory also
+  gets the index files of a network mirror, so it can either be used
+  directly or be served over HTTPS. Mirroring again into the same directory
+  adds packages for other providers, versions or platforms.
+
+  Providers that are recorded in the dependency lock file are mirrored at
+  their locked version, and their packages must match the locked hashes.
+  Other providers are mirrored at the newest version that meets the
+  version constraints, the same way "terraform init" would select.
+
+Options:
+
+  -platform=OS_ARCH  A platform to mirror packages for, such as linux_amd64
+                     or darwin_amd64. This flag can be used multiple times,
+                     and defaults to the current platform.
+
+`
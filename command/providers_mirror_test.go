@@ -0,0 +1,138 @@
+package command
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/copy"
+	"github.com/hashicorp/terraform/plugin/discovery"
+	"github.com/mitchellh/cli"
+)
+
+func TestProvidersMirror(t *testing.T) {
+	td := tempDir(t)
+	copy.CopyDir(testFixturePath("init-dependency-lock"), td)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	mirror := filepath.Join(td, "mirror")
+	hashes := testProviderMirror(t, mirror,
+		[]string{"1.2.3", "1.3.0", "2.0.0"},
+		[]string{"linux_amd64", "darwin_amd64", "windows_amd64"},
+	)
+
+	ui := new(cli.MockUi)
+	c := &ProvidersMirrorCommand{
+		Meta: Meta{
+			Ui: ui,
+			ProviderSources: []*discovery.ProviderInstallationSource{
+				{Source: &discovery.FilesystemMirrorSource{Dir: mirror}},
+			},
+		},
+	}
+
+	// The newest version that meets the constraints is mirrored for the
+	// given platforms only.
+	out := filepath.Join(td, "out")
+	args := []string{"-platform=linux_amd64", "-platform=darwin_amd64", out}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+	}
+
+	got, err := (&discovery.FilesystemMirrorSource{Dir: out}).PackageHashes("test", discovery.VersionStr("1.3.0").MustParse())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"linux_amd64":  hashes["1.3.0"]["linux_amd64"],
+		"darwin_amd64": hashes["1.3.0"]["darwin_amd64"],
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong mirrored packages\ngot:  %#v\nwant: %#v", got, want)
+	}
+
+	src, err := ioutil.ReadFile(filepath.Join(out, "test", "index.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var index struct {
+		Versions map[string]interface{} `json:"versions"`
+	}
+	if err := json.Unmarshal(src, &index); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := index.Versions["1.3.0"]; !ok || len(index.Versions) != 1 {
+		t.Fatalf("wrong index %s", src)
+	}
+	if _, err := os.Stat(filepath.Join(out, "test", "1.3.0.json")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestProvidersMirror_locked(t *testing.T) {
+	td := tempDir(t)
+	copy.CopyDir(testFixturePath("init-dependency-lock"), td)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	mirror := filepath.Join(td, "mirror")
+	hashes := testProviderMirror(t, mirror,
+		[]string{"1.2.3", "1.3.0"},
+		[]string{"linux_amd64", "darwin_amd64"},
+	)
+
+	locks := discovery.ProviderLocks{
+		"test": &discovery.ProviderLock{
+			Name:    "test",
+			Version: discovery.VersionStr("1.2.3").MustParse(),
+			Hashes:  []string{hashes["1.2.3"]["linux_amd64"]},
+		},
+	}
+	if err := locks.Write(DefaultDependencyLockFile); err != nil {
+		t.Fatal(err)
+	}
+
+	ui := new(cli.MockUi)
+	c := &ProvidersMirrorCommand{
+		Meta: Meta{
+			Ui: ui,
+			ProviderSources: []*discovery.ProviderInstallationSource{
+				{Source: &discovery.FilesystemMirrorSource{Dir: mirror}},
+			},
+		},
+	}
+
+	// The locked version is mirrored.
+	out := filepath.Join(td, "out")
+	if code := c.Run([]string{"-platform=linux_amd64", out}); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+	}
+	archive := filepath.Join(out, "terraform-provider-test", "1.2.3", "terraform-provider-test_1.2.3_linux_amd64.zip")
+	if _, err := os.Stat(archive); err != nil {
+		t.Fatal(err)
+	}
+
+	// The darwin package isn't one of the locked hashes.
+	ui = new(cli.MockUi)
+	c.Meta.Ui = ui
+	if code := c.Run([]string{"-platform=darwin_amd64", out}); code == 0 {
+		t.Fatal("succeeded; want error for a package that doesn't match the lock")
+	}
+}
+
+func TestProvidersMirror_noTarget(t *testing.T) {
+	ui := new(cli.MockUi)
+	c := &ProvidersMirrorCommand{
+		Meta: Meta{
+			Ui: ui,
+		},
+	}
+
+	if code := c.Run(nil); code != 1 {
+		t.Fatalf("bad: %d", code)
+	}
+}
@@ -0,0 +1,176 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/hashicorp/terraform/command/jsonprovider"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+// ProvidersSchemaCommand is a Command implementation that prints the full
+// schemas of the providers required by the configuration, for tools such as
+// documentation generators and language servers.
+type ProvidersSchemaCommand struct {
+	Meta
+}
+
+func (c *ProvidersSchemaCommand) Help() string {
+	return providersSchemaCommandHelp
+}
+
+func (c *ProvidersSchemaCommand) Synopsis() string {
+	return "Prints the schemas of the providers used in the configuration"
+}
+
+func (c *ProvidersSchemaCommand) Run(args []string) int {
+	args, err := c.Meta.process(args, false)
+	if err != nil {
+		return 1
+	}
+
+	var jsonOutput bool
+	cmdFlags := c.Meta.flagSet("providers schema")
+	cmdFlags.BoolVar(&jsonOutput, "json", false, "json")
+	cmdFlags.Usage = func() { c.Ui.Error(c.Help()) }
+	if err := cmdFlags.Parse(args); err != nil {
+		return 1
+	}
+
+	if len(cmdFlags.Args()) != 0 {
+		c.Ui.Error("The providers schema command expects no arguments.")
+		cmdFlags.Usage()
+		return 1
+	}
+	if !jsonOutput {
+		c.Ui.Error("The -json flag is required, since the schemas are only available as JSON.")
+		cmdFlags.Usage()
+		return 1
+	}
+
+	configPath, err := os.Getwd()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error getting pwd: %s", err))
+		return 1
+	}
+
+	// Load the config
+	root, diags := c.Module(configPath)
+	if diags.HasErrors() {
+		c.showDiagnostics(diags)
+		return 1
+	}
+	if root == nil {
+		c.Ui.Error(fmt.Sprintf(
+			"No configuration files found in the directory: %s\n\n"+
+				"This command requires configuration to run.",
+			configPath))
+		return 1
+	}
+
+	// Load the backend
+	b, err := c.Backend(&BackendOpts{
+		Config: root.Config(),
+	})
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to load backend: %s", err))
+		return 1
+	}
+
+	// Get the state
+	env := c.Workspace()
+	state, err := b.State(env)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to load state: %s", err))
+		return 1
+	}
+	if err := state.RefreshState(); err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to load state: %s", err))
+		return 1
+	}
+
+	requirements := terraform.ModuleTreeDependencies(root, state.State()).AllPluginRequirements()
+	factories, errs := c.contextOpts().ProviderResolver.ResolveProviders(requirements)
+	if len(errs) > 0 {
+		for _, err := range errs {
+			c.Ui.Error(err.Error())
+		}
+		c.Ui.Error(errProvidersSchemaInit)
+		return 1
+	}
+
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	schemas := make(map[string]*terraform.ProviderSchema, len(names))
+	for _, name := range names {
+		schema, err := fullProviderSchema(factories[name])
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Failed to get the schema of provider %q: %s", name, err))
+			return 1
+		}
+		schemas[name] = schema
+	}
+
+	j, err := jsonprovider.Marshal(schemas)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to encode JSON: %s", err))
+		return 1
+	}
+	c.Ui.Output(string(j))
+	return 0
+}
+
+// fullProviderSchema starts a provider to get the schemas of all of its
+// resource types and data sources, along with its own.
+func fullProviderSchema(factory terraform.ResourceProviderFactory) (*terraform.ProviderSchema, error) {
+	p, err := factory()
+	if err != nil {
+		return nil, err
+	}
+	if c, ok := p.(terraform.ResourceProviderCloser); ok {
+		defer c.Close()
+	}
+
+	req := &terraform.ProviderSchemaRequest{}
+	for _, rt := range p.Resources() {
+		req.ResourceTypes = append(req.ResourceTypes, rt.Name)
+	}
+	for _, ds := range p.DataSources() {
+		req.DataSources = append(req.DataSources, ds.Name)
+	}
+
+	schema, err := p.GetSchema(req)
+	if err != nil {
+		return nil, err
+	}
+	if schema == nil {
+		schema = &terraform.ProviderSchema{}
+	}
+	return schema, nil
+}
+
+const errProvidersSchemaInit = `
+The schemas can't be read until the providers required by the configuration
+are installed. Run "terraform init" to install them.`
+
+const providersSchemaCommandHelp = `
+Usage: terraform providers schema -json
+
+  Prints the schemas of the providers required by the configuration in the
+  current working directory, as JSON: the configuration of each provider,
+  and of all of its resource types and data sources, including the types
+  of their attributes and their nested blocks.
+
+  The providers must be installed, by running "terraform init" first.
+
+Options:
+
+  -json  Prints the schemas as JSON. This flag is required, since there's
+         no other format yet.
+
+`
@@ -0,0 +1,142 @@
+package command
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform/config/configschema"
+	"github.com/hashicorp/terraform/helper/copy"
+	"github.com/hashicorp/terraform/terraform"
+	"github.com/mitchellh/cli"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestProvidersSchema(t *testing.T) {
+	td := tempDir(t)
+	copy.CopyDir(testFixturePath("providers-schema"), td)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	p := testProvider()
+	p.DataSourcesReturn = []terraform.DataSource{{Name: "test_data"}}
+	p.GetSchemaReturn = &terraform.ProviderSchema{
+		Provider: &configschema.Block{
+			Attributes: map[string]*configschema.Attribute{
+				"region": {Type: cty.String, Optional: true},
+			},
+		},
+		ResourceTypes: map[string]*configschema.Block{
+			"test_instance": {
+				Attributes: map[string]*configschema.Attribute{
+					"ami": {Type: cty.String, Required: true},
+				},
+				BlockTypes: map[string]*configschema.NestedBlock{
+					"disk": {
+						Block: configschema.Block{
+							Attributes: map[string]*configschema.Attribute{
+								"sizes": {Type: cty.List(cty.Number), Computed: true},
+							},
+						},
+						Nesting:  configschema.NestingList,
+						MaxItems: 2,
+					},
+				},
+			},
+		},
+		ResourceTypeSchemaVersions: map[string]int{"test_instance": 1},
+		DataSources: map[string]*configschema.Block{
+			"test_data": {},
+		},
+	}
+
+	ui := new(cli.MockUi)
+	c := &ProvidersSchemaCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(p),
+			Ui:               ui,
+		},
+	}
+
+	if code := c.Run([]string{"-json"}); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+	}
+
+	// All of the provider's resource types and data sources are asked for,
+	// not only those that the configuration uses.
+	wantReq := &terraform.ProviderSchemaRequest{
+		ResourceTypes: []string{"test_instance"},
+		DataSources:   []string{"test_data"},
+	}
+	if !reflect.DeepEqual(p.GetSchemaRequest, wantReq) {
+		t.Fatalf("wrong schema request\ngot:  %#v\nwant: %#v", p.GetSchemaRequest, wantReq)
+	}
+
+	var got interface{}
+	if err := json.Unmarshal(ui.OutputWriter.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON: %s\n%s", err, ui.OutputWriter.String())
+	}
+	var want interface{}
+	json.Unmarshal([]byte(`{
+		"format_version": "0.1",
+		"provider_schemas": {
+			"test": {
+				"provider": {
+					"version": 0,
+					"block": {
+						"attributes": {
+							"region": {"type": "string", "optional": true}
+						}
+					}
+				},
+				"resource_schemas": {
+					"test_instance": {
+						"version": 1,
+						"block": {
+							"attributes": {
+								"ami": {"type": "string", "required": true}
+							},
+							"block_types": {
+								"disk": {
+									"nesting_mode": "list",
+									"block": {
+										"attributes": {
+											"sizes": {"type": ["list", "number"], "computed": true}
+										}
+									},
+									"max_items": 2
+								}
+							}
+						}
+					}
+				},
+				"data_source_schemas": {
+					"test_data": {"version": 0, "block": {}}
+				}
+			}
+		}
+	}`), &want)
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong output\n%s", ui.OutputWriter.String())
+	}
+}
+
+func TestProvidersSchema_noJSON(t *testing.T) {
+	td := tempDir(t)
+	copy.CopyDir(testFixturePath("providers-schema"), td)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	ui := new(cli.MockUi)
+	c := &ProvidersSchemaCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(testProvider()),
+			Ui:               ui,
+		},
+	}
+
+	if code := c.Run(nil); code != 1 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.OutputWriter.String())
+	}
+}
@@ -5,7 +5,10 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/hashicorp/terraform/config"
+	"github.com/hashicorp/terraform/moduledeps"
 	"github.com/mitchellh/cli"
+	"github.com/xlab/treeprint"
 )
 
 func TestProviders(t *testing.T) {
@@ -71,3 +74,64 @@ func TestProviders_noConfigs(t *testing.T) {
 		t.Errorf("Expected error message: %s\nGiven output: %s", expectedErrMsg, output)
 	}
 }
+
+func TestProviders_source(t *testing.T) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := os.Chdir(testFixturePath("providers-source")); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.Chdir(cwd)
+
+	ui := new(cli.MockUi)
+	c := &ProvidersCommand{
+		Meta: Meta{
+			Ui: ui,
+		},
+	}
+
+	args := []string{}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+	}
+
+	output := ui.OutputWriter.String()
+	if !strings.Contains(output, "provider.foo from acme/foo ~> 1.0") {
+		t.Errorf("output missing provider.foo with its source\n\n%s", output)
+	}
+}
+
+func TestProvidersCommandPopulateTreeNode_moduleSources(t *testing.T) {
+	deps := &moduledeps.Module{
+		Name: "root",
+		Children: []*moduledeps.Module{
+			{
+				Name: "network",
+				Children: []*moduledeps.Module{
+					{Name: "subnets"},
+				},
+			},
+		},
+	}
+	calls := []*config.ModuleCall{
+		{
+			Name:    "network",
+			Source:  "example/network/aws",
+			Version: "~> 1.0",
+		},
+	}
+
+	tree := treeprint.New()
+	providersCommandPopulateTreeNode(tree, deps, calls)
+	output := tree.String()
+
+	if !strings.Contains(output, "module.network (example/network/aws ~> 1.0)") {
+		t.Errorf("output missing the network module's source\n\n%s", output)
+	}
+	// The calls of modules that aren't local are unknown.
+	if !strings.Contains(output, "module.subnets\n") {
+		t.Errorf("output missing the subnets module\n\n%s", output)
+	}
+}
@@ -57,7 +57,7 @@ func (c *PushCommand) Run(args []string) int {
 	// a variable we don't see in our context, but which exists in this Terraform
 	// Enterprise workspace.
 	cliVars := make(map[string]string)
-	for k, v := range c.variables {
+	for k, v := range c.variables.Values() {
 		if _, ok := overwriteMap[k]; ok {
 			if val, ok := v.(string); ok {
 				cliVars[k] = val
@@ -9,6 +9,7 @@ import (
 	"github.com/hashicorp/terraform/config"
 	"github.com/hashicorp/terraform/terraform"
 	"github.com/hashicorp/terraform/tfdiags"
+	"github.com/posener/complete"
 )
 
 // RefreshCommand is a cli.Command implementation that refreshes the state
@@ -18,12 +19,14 @@ type RefreshCommand struct {
 }
 
 func (c *RefreshCommand) Run(args []string) int {
+	var autoApprove bool
 	args, err := c.Meta.process(args, true)
 	if err != nil {
 		return 1
 	}
 
 	cmdFlags := c.Meta.flagSet("refresh")
+	cmdFlags.BoolVar(&autoApprove, "auto-approve", false, "skip interactive approval of the detected changes")
 	cmdFlags.StringVar(&c.Meta.statePath, "state", DefaultStateFilename, "path")
 	cmdFlags.IntVar(&c.Meta.parallelism, "parallelism", 0, "parallelism")
 	cmdFlags.StringVar(&c.Meta.stateOutPath, "state-out", "", "path")
@@ -70,24 +73,50 @@ func (c *RefreshCommand) Run(args []string) int {
 		return 1
 	}
 
-	// Build the operation
+	// A refresh is a refresh-only apply, so that the detected changes can
+	// be reviewed before they're recorded in the state. Without input, as
+	// in automation, they're recorded without review, as refresh always
+	// did before.
 	opReq := c.Operation()
-	opReq.Type = backend.OperationTypeRefresh
+	opReq.Type = backend.OperationTypeApply
 	opReq.Module = mod
+	opReq.PlanRefresh = true
+	opReq.PlanRefreshOnly = true
+	opReq.AutoApprove = autoApprove || !c.Input()
 
 	// Perform the operation
-	op, err := b.Operation(context.Background(), opReq)
+	ctx, ctxCancel := context.WithCancel(context.Background())
+	defer ctxCancel()
+
+	op, err := b.Operation(ctx, opReq)
 	if err != nil {
 		c.Ui.Error(fmt.Sprintf("Error starting operation: %s", err))
 		return 1
 	}
 
-	// Wait for the operation to complete
-	<-op.Done()
-	if err := op.Err; err != nil {
-		diags = diags.Append(err)
+	// Wait for the operation to complete or an interrupt to occur
+	select {
+	case <-c.ShutdownCh:
+		// Cancel our context so we can start gracefully exiting
+		ctxCancel()
+		c.Ui.Output(outputInterrupt)
+
+		// Still get the result, since there is still one
+		select {
+		case <-c.ShutdownCh:
+			c.Ui.Error(
+				"Two interrupts received. Exiting immediately. Note that data\n" +
+					"loss may have occurred.")
+			return 1
+		case <-op.Done():
+		}
+	case <-op.Done():
+		if err := op.Err; err != nil {
+			diags = diags.Append(err)
+		}
 	}
 
+	diags = diags.Append(tfdiags.SimpleWarning(strings.TrimSpace(refreshDeprecated)))
 	c.showDiagnostics(diags)
 	if diags.HasErrors() {
 		return 1
@@ -101,6 +130,19 @@ func (c *RefreshCommand) Run(args []string) int {
 	return 0
 }
 
+func (c *RefreshCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictDirs("")
+}
+
+func (c *RefreshCommand) AutocompleteFlags() complete.Flags {
+	flags := c.completeFlagsOperation()
+	delete(flags, "-refresh")
+	flags["-auto-approve"] = complete.PredictNothing
+	flags["-backup"] = complete.PredictFiles("*")
+	flags["-state-out"] = complete.PredictFiles("*.tfstate")
+	return flags
+}
+
 func (c *RefreshCommand) Help() string {
 	helpText := `
 Usage: terraform refresh [options] [dir]
@@ -112,8 +154,16 @@ Usage: terraform refresh [options] [dir]
   state file to update metadata. This metadata might cause new changes
   to occur when you generate a plan or call apply next.
 
+  This command is deprecated. It's the same as "terraform apply
+  -refresh-only", which shows the changes that the refresh detects and asks
+  for approval before recording them in the state. Without input, such as
+  with -input=false, the changes are recorded without asking.
+
 Options:
 
+  -auto-approve       Record the detected changes without asking for
+                      approval.
+
   -backup=path        Path to backup the existing state file before
                       modifying. Defaults to the "-state-out" path with
                       ".backup" extension. Set to "-" to disable backup.
@@ -150,3 +200,11 @@ Options:
 func (c *RefreshCommand) Synopsis() string {
 	return "Update local state file against real resources"
 }
+
+const refreshDeprecated = `
+The refresh command is deprecated.
+
+Use "terraform apply -refresh-only" instead, which this command now runs. To
+only see the changes that a refresh would record, use "terraform plan
+-refresh-only".
+`
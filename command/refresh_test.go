@@ -62,6 +62,64 @@ func TestRefresh(t *testing.T) {
 	}
 }
 
+func TestRefresh_approval(t *testing.T) {
+	for _, answer := range []string{"no", "yes"} {
+		t.Run(answer, func(t *testing.T) {
+			state := testState()
+			statePath := testStateFile(t, state)
+
+			p := testProvider()
+			ui := new(cli.MockUi)
+			c := &RefreshCommand{
+				Meta: Meta{
+					testingOverrides: metaOverridesForProvider(p),
+					Ui:               ui,
+				},
+			}
+
+			p.RefreshFn = nil
+			p.RefreshReturn = &terraform.InstanceState{
+				ID: "yes",
+				Attributes: map[string]string{
+					"ami": "changed",
+				},
+			}
+
+			defer testInputMap(t, map[string]string{
+				"approve": answer,
+			})()
+
+			args := []string{
+				"-state", statePath,
+				testFixturePath("refresh"),
+			}
+			code := c.Run(args)
+
+			newState := testStateRead(t, statePath)
+			actual := strings.TrimSpace(newState.String())
+			if answer == "no" {
+				if code != 1 {
+					t.Fatalf("bad: %d\n\n%s", code, ui.OutputWriter.String())
+				}
+				if expected := strings.TrimSpace(state.String()); actual != expected {
+					t.Fatalf("state changed without approval:\n\n%s", actual)
+				}
+				return
+			}
+
+			if code != 0 {
+				t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+			}
+			if !strings.Contains(actual, "ami = changed") {
+				t.Fatalf("bad:\n\n%s", actual)
+			}
+			if !strings.Contains(ui.ErrorWriter.String(), "The refresh command is deprecated") {
+				t.Fatalf("no deprecation warning:\n%s", ui.ErrorWriter.String())
+			}
+		})
+	}
+}
+
 func TestRefresh_empty(t *testing.T) {
 	// Create a temporary working directory that is empty
 	td := tempDir(t)
@@ -7,6 +7,8 @@ import (
 	"strings"
 
 	"github.com/hashicorp/terraform/command/format"
+	"github.com/hashicorp/terraform/command/jsonplan"
+	"github.com/hashicorp/terraform/command/jsonstate"
 	"github.com/hashicorp/terraform/terraform"
 )
 
@@ -18,6 +20,7 @@ type ShowCommand struct {
 
 func (c *ShowCommand) Run(args []string) int {
 	var moduleDepth int
+	var modifications, jsonOutput, structuralDiff, concise bool
 
 	args, err := c.Meta.process(args, false)
 	if err != nil {
@@ -26,6 +29,10 @@ func (c *ShowCommand) Run(args []string) int {
 
 	cmdFlags := flag.NewFlagSet("show", flag.ContinueOnError)
 	c.addModuleDepthFlag(cmdFlags, &moduleDepth)
+	cmdFlags.BoolVar(&modifications, "plan-modifications", false, "plan-modifications")
+	cmdFlags.BoolVar(&jsonOutput, "json", false, "json")
+	cmdFlags.BoolVar(&structuralDiff, "structural-diff", true, "structural-diff")
+	cmdFlags.BoolVar(&concise, "concise", false, "concise")
 	cmdFlags.Usage = func() { c.Ui.Error(c.Help()) }
 	if err := cmdFlags.Parse(args); err != nil {
 		return 1
@@ -40,6 +47,12 @@ func (c *ShowCommand) Run(args []string) int {
 		return 1
 	}
 
+	if jsonOutput && modifications {
+		c.Ui.Error("The -json and -plan-modifications options are mutually exclusive.\n")
+		cmdFlags.Usage()
+		return 1
+	}
+
 	var planErr, stateErr error
 	var path string
 	var plan *terraform.Plan
@@ -93,6 +106,9 @@ func (c *ShowCommand) Run(args []string) int {
 
 		state = stateStore.State()
 		if state == nil {
+			if jsonOutput {
+				return c.showJSON(jsonstate.Marshal(nil))
+			}
 			c.Ui.Output("No state.")
 			return 0
 		}
@@ -109,8 +125,21 @@ func (c *ShowCommand) Run(args []string) int {
 		return 1
 	}
 
+	if jsonOutput {
+		if plan != nil {
+			return c.showJSON(jsonplan.Marshal(plan))
+		}
+		return c.showJSON(jsonstate.Marshal(state))
+	}
+
 	if plan != nil {
 		dispPlan := format.NewPlan(plan)
+		dispPlan.NoStructuralDiff = !structuralDiff
+		dispPlan.Concise = concise
+		if modifications {
+			c.Ui.Output(dispPlan.FormatModifications(c.Colorize()))
+			return 0
+		}
 		c.Ui.Output(dispPlan.Format(c.Colorize()))
 		return 0
 	}
@@ -123,6 +152,17 @@ func (c *ShowCommand) Run(args []string) int {
 	return 0
 }
 
+// showJSON outputs the JSON representation of a plan or state.
+func (c *ShowCommand) showJSON(j []byte, err error) int {
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to encode JSON: %s", err))
+		return 1
+	}
+
+	c.Ui.Output(string(j))
+	return 0
+}
+
 func (c *ShowCommand) Help() string {
 	helpText := `
 Usage: terraform show [options] [path]
@@ -132,11 +172,28 @@ Usage: terraform show [options] [path]
 
 Options:
 
+  -concise            When showing a plan file, show only the attributes that
+                      are changing, collapsing nested blocks that aren't
+                      changing to a count of their elements.
+
+  -json               If specified, output the plan or state in a
+                      machine-readable JSON format.
+
   -module-depth=n     Specifies the depth of modules to show in the output.
                       By default this is -1, which will expand all.
 
   -no-color           If specified, output won't contain any color.
 
+  -plan-modifications When showing a plan file, list the attributes whose
+                      planned values the provider changed from the values
+                      set in the configuration, instead of the plan itself.
+
+  -structural-diff=true
+                      When showing a plan file, show changes to string
+                      attributes that contain JSON or YAML as the values
+                      that changed within the documents. If false, they're
+                      shown as diffs of their text.
+
 `
 	return strings.TrimSpace(helpText)
 }
@@ -1,12 +1,15 @@
 package command
 
 import (
+	"encoding/json"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 
+	"github.com/hashicorp/terraform/command/jsonplan"
+	"github.com/hashicorp/terraform/command/jsonstate"
 	"github.com/hashicorp/terraform/config/module"
 	"github.com/hashicorp/terraform/terraform"
 	"github.com/mitchellh/cli"
@@ -174,3 +177,84 @@ func TestShow_state(t *testing.T) {
 		t.Fatalf("bad: \n%s", ui.ErrorWriter.String())
 	}
 }
+
+func TestShow_planJSON(t *testing.T) {
+	planPath := testPlanFile(t, &terraform.Plan{
+		Module: new(module.Tree),
+		Diff: &terraform.Diff{
+			Modules: []*terraform.ModuleDiff{
+				{
+					Path: []string{"root"},
+					Resources: map[string]*terraform.InstanceDiff{
+						"test_instance.foo": {
+							Attributes: map[string]*terraform.ResourceAttrDiff{
+								"ami": {Old: "", New: "bar"},
+							},
+						},
+					},
+				},
+			},
+		},
+		State: testState(),
+	})
+
+	ui := new(cli.MockUi)
+	c := &ShowCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(testProvider()),
+			Ui:               ui,
+		},
+	}
+
+	args := []string{
+		"-json",
+		planPath,
+	}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: \n%s", ui.ErrorWriter.String())
+	}
+
+	var plan jsonplan.Plan
+	if err := json.Unmarshal([]byte(ui.OutputWriter.String()), &plan); err != nil {
+		t.Fatalf("bad output: %s\n\n%s", err, ui.OutputWriter.String())
+	}
+	if len(plan.ResourceChanges) != 1 {
+		t.Fatalf("bad changes: %#v", plan.ResourceChanges)
+	}
+	rc := plan.ResourceChanges[0]
+	if rc.Address != "test_instance.foo" || len(rc.Change.Attributes) != 1 || rc.Change.Attributes[0].After != "bar" {
+		t.Fatalf("bad change: %#v", rc)
+	}
+	if plan.PriorState == nil || len(plan.PriorState.Values.RootModule.Resources) != 1 {
+		t.Fatalf("bad prior state: %#v", plan.PriorState)
+	}
+}
+
+func TestShow_stateJSON(t *testing.T) {
+	statePath := testStateFile(t, testState())
+
+	ui := new(cli.MockUi)
+	c := &ShowCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(testProvider()),
+			Ui:               ui,
+		},
+	}
+
+	args := []string{
+		"-json",
+		statePath,
+	}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: \n%s", ui.ErrorWriter.String())
+	}
+
+	var state jsonstate.State
+	if err := json.Unmarshal([]byte(ui.OutputWriter.String()), &state); err != nil {
+		t.Fatalf("bad output: %s\n\n%s", err, ui.OutputWriter.String())
+	}
+	resources := state.Values.RootModule.Resources
+	if len(resources) != 1 || resources[0].Address != "test_instance.foo" || resources[0].ProviderName != "test" {
+		t.Fatalf("bad state: %s", ui.OutputWriter.String())
+	}
+}
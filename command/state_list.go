@@ -1,11 +1,14 @@
 package command
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 
+	"github.com/hashicorp/terraform/command/jsonstate"
 	"github.com/hashicorp/terraform/terraform"
 	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
 )
 
 // StateListCommand is a Command implementation that lists the resources
@@ -16,6 +19,9 @@ type StateListCommand struct {
 }
 
 func (c *StateListCommand) Run(args []string) int {
+	var filter stateFilter
+	var jsonOutput bool
+
 	args, err := c.Meta.process(args, true)
 	if err != nil {
 		return 1
@@ -23,10 +29,13 @@ func (c *StateListCommand) Run(args []string) int {
 
 	cmdFlags := c.Meta.flagSet("state list")
 	cmdFlags.StringVar(&c.Meta.statePath, "state", DefaultStateFilename, "path")
+	cmdFlags.StringVar(&filter.ID, "id", "", "id")
+	cmdFlags.StringVar(&filter.Module, "module", "", "module")
+	cmdFlags.BoolVar(&jsonOutput, "json", false, "json")
 	if err := cmdFlags.Parse(args); err != nil {
 		return cli.RunResultHelp
 	}
-	args = cmdFlags.Args()
+	filter.Patterns = cmdFlags.Args()
 
 	// Load the backend
 	b, err := c.Backend(nil)
@@ -54,22 +63,52 @@ func (c *StateListCommand) Run(args []string) int {
 		return 1
 	}
 
-	filter := &terraform.StateFilter{State: stateReal}
-	results, err := filter.Filter(args...)
+	results, err := filterResources(stateReal, &filter)
 	if err != nil {
 		c.Ui.Error(fmt.Sprintf(errStateFilter, err))
 		return cli.RunResultHelp
 	}
 
+	if jsonOutput {
+		addResourceValues(c.contextOpts().ProviderResolver, stateReal, results)
+		out, err := json.MarshalIndent(&jsonstate.ResourceList{
+			FormatVersion: jsonstate.FormatVersion,
+			Resources:     results,
+		}, "", "  ")
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Failed to marshal resources to JSON: %s", err))
+			return 1
+		}
+		c.Ui.Output(string(out))
+		return 0
+	}
+
 	for _, result := range results {
-		if _, ok := result.Value.(*terraform.InstanceState); ok {
-			c.Ui.Output(result.Address)
+		addr := result.ResourceAddress()
+		if result.Deposed {
+			addr.InstanceType = terraform.TypeDeposed
+			addr.InstanceTypeSet = true
 		}
+		c.Ui.Output(addr.String())
 	}
 
 	return 0
 }
 
+func (c *StateListCommand) AutocompleteArgs() complete.Predictor {
+	// Any number of patterns, which may be whole addresses.
+	return c.completePredictResourceAddress()
+}
+
+func (c *StateListCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{
+		"-id":     complete.PredictAnything,
+		"-json":   complete.PredictNothing,
+		"-module": complete.PredictAnything,
+		"-state":  complete.PredictFiles("*.tfstate"),
+	}
+}
+
 func (c *StateListCommand) Help() string {
 	helpText := `
 Usage: terraform state list [options] [pattern...]
@@ -86,10 +125,21 @@ Usage: terraform state list [options] [pattern...]
 
   The pattern argument accepts any resource targeting syntax. Please
   refer to the documentation on resource targeting syntax for more
-  information.
+  information. Patterns with "*" or "?" are instead matched against the
+  whole addresses of resources, where "*" matches any sequence of
+  characters and "?" any one character, such as "module.*.aws_instance.*".
 
 Options:
 
+  -id=ID              Only list the resources with the given ID.
+
+  -json               Output the resources in a machine-readable JSON
+                      form, with their attributes decoded into typed
+                      values if the provider is available.
+
+  -module=ADDR        Only list the resources of the given module, such as
+                      module.foo, and of its descendents.
+
   -state=statefile    Path to a Terraform state file to use to look
                       up Terraform-managed resources. By default it will
                       use the state "terraform.tfstate" if it exists.
@@ -1,12 +1,18 @@
 package command
 
 import (
+	"encoding/json"
 	"os"
+	"reflect"
 	"strings"
 	"testing"
 
+	"github.com/hashicorp/terraform/command/jsonstate"
+	"github.com/hashicorp/terraform/config/configschema"
 	"github.com/hashicorp/terraform/helper/copy"
+	"github.com/hashicorp/terraform/terraform"
 	"github.com/mitchellh/cli"
+	"github.com/zclconf/go-cty/cty"
 )
 
 func TestStateList(t *testing.T) {
@@ -88,3 +94,157 @@ func TestStateList_noState(t *testing.T) {
 const testStateListOutput = `
 test_instance.foo
 `
+
+func TestStateList_filters(t *testing.T) {
+	state := &terraform.State{
+		Modules: []*terraform.ModuleState{
+			{
+				Path: []string{"root"},
+				Resources: map[string]*terraform.ResourceState{
+					"test_instance.foo": {
+						Type:    "test_instance",
+						Primary: &terraform.InstanceState{ID: "foo"},
+					},
+					"test_instance.bar.0": {
+						Type:    "test_instance",
+						Primary: &terraform.InstanceState{ID: "bar0"},
+					},
+					"test_instance.bar.1": {
+						Type:    "test_instance",
+						Primary: &terraform.InstanceState{ID: "bar1"},
+					},
+				},
+			},
+			{
+				Path: []string{"root", "child"},
+				Resources: map[string]*terraform.ResourceState{
+					"test_instance.foo": {
+						Type:    "test_instance",
+						Primary: &terraform.InstanceState{ID: "foo"},
+					},
+					"data.test_data.baz": {
+						Type:    "test_data",
+						Primary: &terraform.InstanceState{ID: "baz"},
+					},
+				},
+			},
+		},
+	}
+	statePath := testStateFile(t, state)
+
+	cases := map[string]struct {
+		args []string
+		want []string
+	}{
+		"address": {
+			[]string{"test_instance.bar"},
+			[]string{"test_instance.bar[0]", "test_instance.bar[1]"},
+		},
+		"glob": {
+			[]string{"*.foo"},
+			[]string{"test_instance.foo", "module.child.test_instance.foo"},
+		},
+		"glob with index": {
+			[]string{"test_instance.bar[?]"},
+			[]string{"test_instance.bar[0]", "test_instance.bar[1]"},
+		},
+		"module": {
+			[]string{"-module=module.child"},
+			[]string{"module.child.data.test_data.baz", "module.child.test_instance.foo"},
+		},
+		"id": {
+			[]string{"-id=foo"},
+			[]string{"test_instance.foo", "module.child.test_instance.foo"},
+		},
+		"module and id": {
+			[]string{"-module=module.child", "-id=foo"},
+			[]string{"module.child.test_instance.foo"},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			ui := new(cli.MockUi)
+			c := &StateListCommand{
+				Meta: Meta{
+					testingOverrides: metaOverridesForProvider(testProvider()),
+					Ui:               ui,
+				},
+			}
+
+			args := append([]string{"-state", statePath}, tc.args...)
+			if code := c.Run(args); code != 0 {
+				t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+			}
+
+			want := strings.Join(tc.want, "\n") + "\n"
+			if got := ui.OutputWriter.String(); got != want {
+				t.Fatalf("wrong output\ngot:\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestStateList_json(t *testing.T) {
+	state := testState()
+	state.RootModule().Resources["test_instance.foo"].Primary.Attributes = map[string]string{
+		"id":      "bar",
+		"ami":     "ami-123",
+		"count":   "2",
+		"list.#":  "1",
+		"list.0":  "a",
+		"unknown": "x",
+	}
+	statePath := testStateFile(t, state)
+
+	p := testProvider()
+	p.GetSchemaReturn = &terraform.ProviderSchema{
+		ResourceTypes: map[string]*configschema.Block{
+			"test_instance": {
+				Attributes: map[string]*configschema.Attribute{
+					"id":    {Type: cty.String, Computed: true},
+					"ami":   {Type: cty.String, Optional: true},
+					"count": {Type: cty.Number, Optional: true},
+					"list":  {Type: cty.List(cty.String), Optional: true},
+				},
+			},
+		},
+	}
+	ui := new(cli.MockUi)
+	c := &StateListCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(p),
+			Ui:               ui,
+		},
+	}
+
+	args := []string{"-state", statePath, "-json"}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+	}
+
+	var got jsonstate.ResourceList
+	if err := json.Unmarshal(ui.OutputWriter.Bytes(), &got); err != nil {
+		t.Fatalf("invalid output: %s\n\n%s", err, ui.OutputWriter.String())
+	}
+	if got.FormatVersion != jsonstate.FormatVersion || len(got.Resources) != 1 {
+		t.Fatalf("wrong output\n\n%s", ui.OutputWriter.String())
+	}
+
+	r := got.Resources[0]
+	if r.Address != "test_instance.foo" || r.ID != "bar" {
+		t.Fatalf("wrong resource %#v", r)
+	}
+	want := map[string]interface{}{
+		"id":    "bar",
+		"ami":   "ami-123",
+		"count": float64(2),
+		"list":  []interface{}{"a"},
+	}
+	if !reflect.DeepEqual(r.Values, want) {
+		t.Fatalf("wrong values\ngot:  %#v\nwant: %#v", r.Values, want)
+	}
+	if req := p.GetSchemaRequest; req == nil || !reflect.DeepEqual(req.ResourceTypes, []string{"test_instance"}) {
+		t.Fatalf("wrong schema request %#v", req)
+	}
+}
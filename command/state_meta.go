@@ -1,11 +1,16 @@
 package command
 
 import (
-	"errors"
 	"fmt"
+	"log"
+	"path"
+	"strings"
 	"time"
 
 	backendlocal "github.com/hashicorp/terraform/backend/local"
+	"github.com/hashicorp/terraform/command/jsonstate"
+	"github.com/hashicorp/terraform/config"
+	"github.com/hashicorp/terraform/config/configschema"
 	"github.com/hashicorp/terraform/state"
 	"github.com/hashicorp/terraform/terraform"
 )
@@ -79,22 +84,182 @@ func (c *StateMeta) State() (state.State, error) {
 	return realState, nil
 }
 
-// filterInstance filters a single instance out of filter results.
-func (c *StateMeta) filterInstance(rs []*terraform.StateFilterResult) (*terraform.StateFilterResult, error) {
-	var result *terraform.StateFilterResult
+// stateFilter selects resource instances of a state.
+type stateFilter struct {
+	// Patterns are addresses, such as "module.foo" or "aws_instance.web",
+	// which select the instances they contain, or glob patterns of the
+	// addresses of instances, such as "aws_instance.*", where "*" matches
+	// any sequence of characters and "?" any one character. Instances
+	// matching any of the patterns are selected, and all of them are if
+	// there are no patterns.
+	Patterns []string
+
+	// Module, if set, is the address of a module, such as "module.foo",
+	// to select the instances of, including those of its descendents.
+	Module string
+
+	// ID, if set, selects the instances with the given ID.
+	ID string
+}
+
+// filterResources returns the resource instances of the state that are
+// selected by the filter, sorted by address.
+func filterResources(s *terraform.State, f *stateFilter) ([]*jsonstate.Resource, error) {
+	var module *terraform.ResourceAddress
+	if f.Module != "" {
+		addr, err := terraform.ParseResourceAddress(f.Module)
+		if err != nil || addr.HasResourceSpec() {
+			return nil, fmt.Errorf("%q is not a module address, such as module.foo", f.Module)
+		}
+		module = addr
+	}
+
+	// Addresses select the instances they contain, and the others are
+	// glob patterns matched against whole instance addresses.
+	var addrs []*terraform.ResourceAddress
+	var globs []string
+	for _, p := range f.Patterns {
+		if strings.ContainsAny(p, "*?") {
+			// Brackets are part of the addresses of indexed instances, not
+			// character classes.
+			glob := strings.NewReplacer("[", `\[`, "]", `\]`).Replace(p)
+			if _, err := path.Match(glob, ""); err != nil {
+				return nil, fmt.Errorf("Invalid pattern %q: %s", p, err)
+			}
+			globs = append(globs, glob)
+			continue
+		}
+
+		addr, err := terraform.ParseResourceAddress(p)
+		if err != nil {
+			return nil, fmt.Errorf("Error parsing address '%s': %s", p, err)
+		}
+		addrs = append(addrs, addr)
+	}
+
+	var ret []*jsonstate.Resource
+	for _, r := range jsonstate.Resources(s) {
+		addr := r.ResourceAddress()
+		if module != nil && !module.Contains(addr) {
+			continue
+		}
+		if f.ID != "" && r.ID != f.ID {
+			continue
+		}
+
+		match := len(f.Patterns) == 0
+		for _, a := range addrs {
+			if a.Contains(addr) {
+				match = true
+			}
+		}
+		for _, glob := range globs {
+			if ok, _ := path.Match(glob, r.Address); ok {
+				match = true
+			}
+		}
+		if match {
+			ret = append(ret, r)
+		}
+	}
+
+	return ret, nil
+}
+
+// addResourceValues sets the typed values of the given resource instances
+// of the state, using the schemas of their resource types from the
+// providers that created them, as resolved by the given resolver. The
+// values of instances whose providers aren't available are left unset.
+func addResourceValues(resolver terraform.ResourceProviderResolver, s *terraform.State, rs []*jsonstate.Resource) {
+	schemas := resourceSchemas(resolver, s, rs)
+	for _, r := range rs {
+		if block := resourceSchema(schemas, r); block != nil {
+			r.Values = jsonstate.AttributeValues(r.Attributes, block)
+		}
+	}
+}
+
+// resourceSchemas returns the schemas of the providers of the resource
+// instances rs of the state, as resolved by the given resolver, keyed by
+// provider name. Providers that aren't available are left out.
+func resourceSchemas(resolver terraform.ResourceProviderResolver, s *terraform.State, rs []*jsonstate.Resource) map[string]*terraform.ProviderSchema {
+	type typeNames struct {
+		resourceTypes, dataSources []string
+		seen                       map[string]bool
+	}
+
+	byProvider := make(map[string]*typeNames)
 	for _, r := range rs {
-		if _, ok := r.Value.(*terraform.InstanceState); !ok {
+		name := strings.SplitN(r.ProviderName, ".", 2)[0]
+		names := byProvider[name]
+		if names == nil {
+			names = &typeNames{seen: make(map[string]bool)}
+			byProvider[name] = names
+		}
+
+		key := r.Mode + "." + r.Type
+		if names.seen[key] {
 			continue
 		}
+		names.seen[key] = true
+		if r.Mode == jsonstate.Mode(config.DataResourceMode) {
+			names.dataSources = append(names.dataSources, r.Type)
+		} else {
+			names.resourceTypes = append(names.resourceTypes, r.Type)
+		}
+	}
+
+	reqd := terraform.ModuleTreeDependencies(nil, s).AllPluginRequirements()
+	for name := range reqd {
+		if _, ok := byProvider[name]; !ok {
+			delete(reqd, name)
+		}
+	}
+	factories, errs := resolver.ResolveProviders(reqd)
+	for _, err := range errs {
+		log.Printf("[WARN] decoding state values without provider schemas: %s", err)
+	}
 
-		if result != nil {
-			return nil, errors.New(errStateMultiple)
+	schemas := make(map[string]*terraform.ProviderSchema)
+	for name, factory := range factories {
+		names := byProvider[name]
+		schema, err := providerSchema(factory, &terraform.ProviderSchemaRequest{
+			ResourceTypes: names.resourceTypes,
+			DataSources:   names.dataSources,
+		})
+		if err != nil {
+			log.Printf("[WARN] failed to get the schema of provider %q: %s", name, err)
+			continue
 		}
+		schemas[name] = schema
+	}
+	return schemas
+}
 
-		result = r
+// resourceSchema returns the schema of the type of the resource instance r
+// from the provider schemas returned by resourceSchemas, or nil if it isn't
+// known.
+func resourceSchema(schemas map[string]*terraform.ProviderSchema, r *jsonstate.Resource) *configschema.Block {
+	schema := schemas[strings.SplitN(r.ProviderName, ".", 2)[0]]
+	if schema == nil {
+		return nil
+	}
+	if r.Mode == jsonstate.Mode(config.DataResourceMode) {
+		return schema.DataSources[r.Type]
 	}
+	return schema.ResourceTypes[r.Type]
+}
 
-	return result, nil
+// providerSchema starts a provider to get its schema.
+func providerSchema(factory terraform.ResourceProviderFactory, req *terraform.ProviderSchemaRequest) (*terraform.ProviderSchema, error) {
+	p, err := factory()
+	if err != nil {
+		return nil, err
+	}
+	if c, ok := p.(terraform.ResourceProviderCloser); ok {
+		defer c.Close()
+	}
+	return p.GetSchema(req)
 }
 
 const errStateMultiple = `Multiple instances found for the given pattern!
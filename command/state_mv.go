@@ -6,6 +6,7 @@ import (
 
 	"github.com/hashicorp/terraform/terraform"
 	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
 )
 
 // StateMvCommand is a Command implementation that shows a single resource.
@@ -181,6 +182,23 @@ func (c *StateMvCommand) addableResult(results []*terraform.StateFilterResult) i
 	}
 }
 
+func (c *StateMvCommand) AutocompleteArgs() complete.Predictor {
+	return completePredictSequence{
+		complete.PredictNothing, // the "mv" subcommand itself (already matched)
+		c.completePredictResourceAddress(),
+		complete.PredictAnything, // the destination is usually a new address
+	}
+}
+
+func (c *StateMvCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{
+		"-backup":     complete.PredictFiles("*"),
+		"-backup-out": complete.PredictFiles("*"),
+		"-state":      complete.PredictFiles("*.tfstate"),
+		"-state-out":  complete.PredictFiles("*.tfstate"),
+	}
+}
+
 func (c *StateMvCommand) Help() string {
 	helpText := `
 Usage: terraform state mv [options] SOURCE DESTINATION
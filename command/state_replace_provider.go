@@ -0,0 +1,184 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform/terraform"
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+)
+
+// StateReplaceProviderCommand is a Command implementation that changes the
+// provider of resources in the state.
+type StateReplaceProviderCommand struct {
+	StateMeta
+}
+
+func (c *StateReplaceProviderCommand) Run(args []string) int {
+	args, err := c.Meta.process(args, true)
+	if err != nil {
+		return 1
+	}
+
+	var dryRun bool
+	cmdFlags := c.Meta.flagSet("state replace-provider")
+	cmdFlags.BoolVar(&dryRun, "dry-run", false, "dry run")
+	cmdFlags.StringVar(&c.backupPath, "backup", "-", "backup")
+	cmdFlags.StringVar(&c.statePath, "state", "", "path")
+	if err := cmdFlags.Parse(args); err != nil {
+		return cli.RunResultHelp
+	}
+	args = cmdFlags.Args()
+
+	if len(args) < 2 {
+		c.Ui.Error("Both the provider to replace and its replacement are required.")
+		return cli.RunResultHelp
+	}
+	from, to, patterns := args[0], args[1], args[2:]
+
+	state, err := c.State()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf(errStateLoadingState, err))
+		return 1
+	}
+	if err := state.RefreshState(); err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to load state: %s", err))
+		return 1
+	}
+
+	stateReal := state.State()
+	if stateReal == nil {
+		c.Ui.Error(fmt.Sprintf(errStateNotFound))
+		return 1
+	}
+
+	// The resources are selected the same way as by "state list", so that
+	// the addresses may be globs.
+	var addrs []*terraform.ResourceAddress
+	if len(patterns) > 0 {
+		results, err := filterResources(stateReal, &stateFilter{Patterns: patterns})
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf(errStateFilter, err))
+			return cli.RunResultHelp
+		}
+		if len(results) == 0 {
+			c.Ui.Output("No resources match the given addresses.")
+			return 0
+		}
+		for _, r := range results {
+			addrs = append(addrs, r.ResourceAddress())
+		}
+	}
+
+	// A dry run changes a copy, so that what it reports is exactly what
+	// the real run would do.
+	if dryRun {
+		stateReal = stateReal.DeepCopy()
+	}
+
+	changes, err := stateReal.ReplaceProvider(from, to, addrs)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf(errStateReplaceProvider, err))
+		return 1
+	}
+	if len(changes) == 0 {
+		c.Ui.Output(fmt.Sprintf("No resources use the provider %q.", from))
+		return 0
+	}
+
+	for _, change := range changes {
+		oldProvider := change.From
+		if oldProvider == "" {
+			oldProvider = "(default)"
+		}
+		c.Ui.Output(fmt.Sprintf("%s: %s -> %s", change.Address, oldProvider, change.To))
+	}
+
+	if dryRun {
+		c.Ui.Output(fmt.Sprintf(
+			"\nWould replace the provider of %d resources. The state was not changed.", len(changes)))
+		return 0
+	}
+
+	if err := state.WriteState(stateReal); err != nil {
+		c.Ui.Error(fmt.Sprintf(errStateReplaceProviderPersist, err))
+		return 1
+	}
+	if err := state.PersistState(); err != nil {
+		c.Ui.Error(fmt.Sprintf(errStateReplaceProviderPersist, err))
+		return 1
+	}
+
+	c.Ui.Output(fmt.Sprintf("\nReplaced the provider of %d resources.", len(changes)))
+	return 0
+}
+
+func (c *StateReplaceProviderCommand) AutocompleteArgs() complete.Predictor {
+	// The providers, then any number of addresses.
+	return complete.PredictAnything
+}
+
+func (c *StateReplaceProviderCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{
+		"-backup":  complete.PredictFiles("*"),
+		"-dry-run": complete.PredictNothing,
+		"-state":   complete.PredictFiles("*.tfstate"),
+	}
+}
+
+func (c *StateReplaceProviderCommand) Help() string {
+	helpText := `
+Usage: terraform state replace-provider [options] FROM TO [ADDRESS...]
+
+  Replace the provider of resources in the Terraform state.
+
+  This command changes the provider recorded in the state for the
+  resources that use the provider FROM to the provider TO, such as to move
+  them to a fork of their provider after installing the fork under a new
+  name. The configuration must be changed to use the new provider too.
+
+  FROM and TO are provider names, such as "aws". A name matches all of the
+  configurations of the provider, and each resource keeps the alias of its
+  configuration. A name with an alias, such as "aws.west", matches or sets
+  just that configuration.
+
+  If addresses are given, only the resources they match are changed. The
+  addresses may be globs, as with "terraform state list".
+
+  This command creates a timestamped backup of the state on every invocation.
+  This can't be disabled. Due to the destructive nature of this command,
+  the backup is ensured by Terraform for safety reasons.
+
+Options:
+
+  -dry-run            Show the resources that would be changed, without
+                      changing the state.
+
+  -backup=PATH        Path where Terraform should write the backup
+                      state. This can't be disabled. If not set, Terraform
+                      will write it to the same path as the statefile with
+                      a backup extension.
+
+  -state=PATH         Path to the source state file. Defaults to the configured
+                      backend, or "terraform.tfstate"
+
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *StateReplaceProviderCommand) Synopsis() string {
+	return "Replace the provider of resources in the state"
+}
+
+const errStateReplaceProvider = `Error replacing the provider: %s
+
+The state was not saved. No resources were changed in the persisted
+state. No backup was created since no modification occurred. Please
+resolve the issue above and try again.`
+
+const errStateReplaceProviderPersist = `Error saving the state: %s
+
+The state was not saved. No resources were changed in the persisted
+state. No backup was created since no modification occurred. Please
+resolve the issue above and try again.`
@@ -0,0 +1,150 @@
+package command
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform/terraform"
+	"github.com/mitchellh/cli"
+)
+
+func testStateReplaceProviderState() *terraform.State {
+	return &terraform.State{
+		Modules: []*terraform.ModuleState{
+			&terraform.ModuleState{
+				Path: []string{"root"},
+				Resources: map[string]*terraform.ResourceState{
+					"test_instance.foo": &terraform.ResourceState{
+						Type:     "test_instance",
+						Provider: "provider.test",
+						Primary: &terraform.InstanceState{
+							ID: "foo",
+						},
+					},
+					"test_instance.bar": &terraform.ResourceState{
+						Type:     "test_instance",
+						Provider: "provider.test.west",
+						Primary: &terraform.InstanceState{
+							ID: "bar",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestStateReplaceProvider(t *testing.T) {
+	statePath := testStateFile(t, testStateReplaceProviderState())
+
+	p := testProvider()
+	ui := new(cli.MockUi)
+	c := &StateReplaceProviderCommand{
+		StateMeta{
+			Meta: Meta{
+				testingOverrides: metaOverridesForProvider(p),
+				Ui:               ui,
+			},
+		},
+	}
+
+	args := []string{
+		"-state", statePath,
+		"test", "testfork",
+	}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+	}
+
+	output := ui.OutputWriter.String()
+	for _, line := range []string{
+		"test_instance.bar: provider.test.west -> provider.testfork.west",
+		"test_instance.foo: provider.test -> provider.testfork",
+		"Replaced the provider of 2 resources.",
+	} {
+		if !strings.Contains(output, line) {
+			t.Fatalf("output is missing %q:\n%s", line, output)
+		}
+	}
+
+	root := testStateRead(t, statePath).RootModule()
+	if got := root.Resources["test_instance.foo"].Provider; got != "provider.testfork" {
+		t.Fatalf("wrong provider %q", got)
+	}
+	if got := root.Resources["test_instance.bar"].Provider; got != "provider.testfork.west" {
+		t.Fatalf("wrong provider %q", got)
+	}
+
+	backups := testStateBackups(t, filepath.Dir(statePath))
+	if len(backups) != 1 {
+		t.Fatalf("bad: %#v", backups)
+	}
+}
+
+func TestStateReplaceProvider_dryRun(t *testing.T) {
+	statePath := testStateFile(t, testStateReplaceProviderState())
+
+	p := testProvider()
+	ui := new(cli.MockUi)
+	c := &StateReplaceProviderCommand{
+		StateMeta{
+			Meta: Meta{
+				testingOverrides: metaOverridesForProvider(p),
+				Ui:               ui,
+			},
+		},
+	}
+
+	args := []string{
+		"-state", statePath,
+		"-dry-run",
+		"test", "testfork",
+		"test_instance.f*",
+	}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+	}
+
+	output := ui.OutputWriter.String()
+	if !strings.Contains(output, "test_instance.foo: provider.test -> provider.testfork") {
+		t.Fatalf("wrong output:\n%s", output)
+	}
+	if strings.Contains(output, "test_instance.bar") {
+		t.Fatalf("bar should not be changed:\n%s", output)
+	}
+
+	root := testStateRead(t, statePath).RootModule()
+	if got := root.Resources["test_instance.foo"].Provider; got != "provider.test" {
+		t.Fatalf("state was changed by a dry run: provider %q", got)
+	}
+	if backups := testStateBackups(t, filepath.Dir(statePath)); len(backups) != 0 {
+		t.Fatalf("dry run made backups: %#v", backups)
+	}
+}
+
+func TestStateReplaceProvider_invalid(t *testing.T) {
+	statePath := testStateFile(t, testStateReplaceProviderState())
+
+	p := testProvider()
+	ui := new(cli.MockUi)
+	c := &StateReplaceProviderCommand{
+		StateMeta{
+			Meta: Meta{
+				testingOverrides: metaOverridesForProvider(p),
+				Ui:               ui,
+			},
+		},
+	}
+
+	args := []string{
+		"-state", statePath,
+		"registry.terraform.io/-/test", "testfork",
+	}
+	if code := c.Run(args); code != 1 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.OutputWriter.String())
+	}
+	if !strings.Contains(ui.ErrorWriter.String(), "invalid provider") {
+		t.Fatalf("wrong error:\n%s", ui.ErrorWriter.String())
+	}
+}
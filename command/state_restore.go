@@ -0,0 +1,129 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform/backend"
+	"github.com/hashicorp/terraform/command/clistate"
+	"github.com/hashicorp/terraform/state"
+	"github.com/mitchellh/cli"
+)
+
+// StateRestoreCommand is a Command implementation that writes a stored
+// version of the state back as the current state.
+type StateRestoreCommand struct {
+	Meta
+	StateMeta
+}
+
+func (c *StateRestoreCommand) Run(args []string) int {
+	args, err := c.Meta.process(args, true)
+	if err != nil {
+		return 1
+	}
+
+	cmdFlags := c.Meta.flagSet("state restore")
+	cmdFlags.BoolVar(&c.Meta.stateLock, "lock", true, "lock state")
+	cmdFlags.DurationVar(&c.Meta.stateLockTimeout, "lock-timeout", 0, "lock timeout")
+	if err := cmdFlags.Parse(args); err != nil {
+		return cli.RunResultHelp
+	}
+	args = cmdFlags.Args()
+
+	if len(args) != 1 {
+		c.Ui.Error("Exactly one argument expected: the ID of the version to restore")
+		return 1
+	}
+	id := args[0]
+
+	// Load the backend
+	b, err := c.Backend(nil)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to load backend: %s", err))
+		return 1
+	}
+
+	v, ok := b.(backend.StateVersioner)
+	if !ok {
+		c.Ui.Error(strings.TrimSpace(errStateVersionsNotSupported))
+		return 1
+	}
+
+	env := c.Workspace()
+	restored, err := v.StateVersion(env, id)
+	if err == backend.ErrStateVersionsNotSupported {
+		c.Ui.Error(strings.TrimSpace(errStateVersionsNotSupported))
+		return 1
+	}
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to read state version: %s", err))
+		return 1
+	}
+
+	// Get the state
+	st, err := b.State(env)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to load state: %s", err))
+		return 1
+	}
+
+	if c.stateLock {
+		lockCtx, cancel := context.WithTimeout(context.Background(), c.stateLockTimeout)
+		defer cancel()
+
+		lockInfo := state.NewLockInfo()
+		lockInfo.Operation = "state restore"
+		lockID, err := clistate.Lock(lockCtx, st, lockInfo, c.Ui, c.Colorize())
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error locking state: %s", err))
+			return 1
+		}
+		defer clistate.Unlock(st, lockID, c.Ui, c.Colorize())
+	}
+
+	if err := st.RefreshState(); err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to load state: %s", err))
+		return 1
+	}
+
+	// The restored state is written as a new version, which takes the
+	// serial following that of the current state so it isn't mistaken for
+	// an older one.
+	if err := st.WriteState(restored); err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to write state: %s", err))
+		return 1
+	}
+	if err := st.PersistState(); err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to write state: %s", err))
+		return 1
+	}
+
+	c.Ui.Output(fmt.Sprintf("Restored version %s of the state.", id))
+	return 0
+}
+
+func (c *StateRestoreCommand) Help() string {
+	helpText := `
+Usage: terraform state restore [options] ID
+
+  Write a stored version of the state back as the current state.
+
+  The versions of the state, and their IDs, are listed by
+  "terraform state versions". The restored state is written as a new
+  version, so the current state can itself be restored later.
+
+Options:
+
+  -lock=true          Lock the state file when locking is supported.
+
+  -lock-timeout=0s    Duration to retry a state lock.
+
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *StateRestoreCommand) Synopsis() string {
+	return "Restore a stored version of the state"
+}
@@ -0,0 +1,74 @@
+package command
+
+import (
+	"os"
+	"testing"
+
+	"github.com/hashicorp/terraform/backend/remote-state/inmem"
+	"github.com/hashicorp/terraform/helper/copy"
+	"github.com/mitchellh/cli"
+)
+
+func TestStateRestore(t *testing.T) {
+	td := tempDir(t)
+	copy.CopyDir(testFixturePath("inmem-backend"), td)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+	defer inmem.Reset()
+
+	b := testStateVersionsWorkspace(t, "test")
+	sMgr, err := b.State("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sMgr.RefreshState(); err != nil {
+		t.Fatal(err)
+	}
+	current := sMgr.State()
+
+	ui := new(cli.MockUi)
+	c := &StateRestoreCommand{
+		Meta: Meta{Ui: ui},
+	}
+	if code := c.Run([]string{"1"}); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+	}
+
+	// The first version is written back with a newer serial.
+	if err := sMgr.RefreshState(); err != nil {
+		t.Fatal(err)
+	}
+	restored := sMgr.State()
+	if restored.Lineage == current.Lineage {
+		t.Fatal("the state wasn't restored")
+	}
+	if restored.Serial != current.Serial+1 {
+		t.Fatalf("expected serial %d, got %d", current.Serial+1, restored.Serial)
+	}
+
+	versions, err := b.(*inmem.Backend).StateVersions("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 3 {
+		t.Fatalf("expected 3 versions, got %d", len(versions))
+	}
+}
+
+func TestStateRestore_missingVersion(t *testing.T) {
+	td := tempDir(t)
+	copy.CopyDir(testFixturePath("inmem-backend"), td)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+	defer inmem.Reset()
+
+	testStateVersionsWorkspace(t, "test")
+
+	ui := new(cli.MockUi)
+	c := &StateRestoreCommand{
+		Meta: Meta{Ui: ui},
+	}
+	if code := c.Run([]string{"3"}); code != 1 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.OutputWriter.String())
+	}
+}
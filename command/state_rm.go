@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
 )
 
 // StateRmCommand is a Command implementation that shows a single resource.
@@ -68,6 +69,18 @@ func (c *StateRmCommand) Run(args []string) int {
 	return 0
 }
 
+func (c *StateRmCommand) AutocompleteArgs() complete.Predictor {
+	// Any number of addresses.
+	return c.completePredictResourceAddress()
+}
+
+func (c *StateRmCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{
+		"-backup": complete.PredictFiles("*"),
+		"-state":  complete.PredictFiles("*.tfstate"),
+	}
+}
+
 func (c *StateRmCommand) Help() string {
 	helpText := `
 Usage: terraform state rm [options] ADDRESS...
@@ -1,12 +1,13 @@
 package command
 
 import (
+	"encoding/json"
 	"fmt"
 	"sort"
 	"strings"
 
-	"github.com/hashicorp/terraform/terraform"
 	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
 	"github.com/ryanuber/columnize"
 )
 
@@ -17,6 +18,9 @@ type StateShowCommand struct {
 }
 
 func (c *StateShowCommand) Run(args []string) int {
+	var filter stateFilter
+	var jsonOutput bool
+
 	args, err := c.Meta.process(args, true)
 	if err != nil {
 		return 1
@@ -24,10 +28,12 @@ func (c *StateShowCommand) Run(args []string) int {
 
 	cmdFlags := c.Meta.flagSet("state show")
 	cmdFlags.StringVar(&c.Meta.statePath, "state", DefaultStateFilename, "path")
+	cmdFlags.StringVar(&filter.ID, "id", "", "id")
+	cmdFlags.BoolVar(&jsonOutput, "json", false, "json")
 	if err := cmdFlags.Parse(args); err != nil {
 		return cli.RunResultHelp
 	}
-	args = cmdFlags.Args()
+	filter.Patterns = cmdFlags.Args()
 
 	// Load the backend
 	b, err := c.Backend(nil)
@@ -54,8 +60,7 @@ func (c *StateShowCommand) Run(args []string) int {
 		return 1
 	}
 
-	filter := &terraform.StateFilter{State: stateReal}
-	results, err := filter.Filter(args...)
+	results, err := filterResources(stateReal, &filter)
 	if err != nil {
 		c.Ui.Error(fmt.Sprintf(errStateFilter, err))
 		return 1
@@ -64,32 +69,36 @@ func (c *StateShowCommand) Run(args []string) int {
 	if len(results) == 0 {
 		return 0
 	}
-
-	instance, err := c.filterInstance(results)
-	if err != nil {
-		c.Ui.Error(err.Error())
+	if len(results) > 1 {
+		c.Ui.Error(errStateMultiple)
 		return 1
 	}
-
-	if instance == nil {
+	r := results[0]
+
+	if jsonOutput {
+		addResourceValues(c.contextOpts().ProviderResolver, stateReal, results)
+		out, err := json.MarshalIndent(r, "", "  ")
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Failed to marshal resource to JSON: %s", err))
+			return 1
+		}
+		c.Ui.Output(string(out))
 		return 0
 	}
 
-	is := instance.Value.(*terraform.InstanceState)
-
 	// Sort the keys
 	var keys []string
-	for k, _ := range is.Attributes {
+	for k, _ := range r.Attributes {
 		keys = append(keys, k)
 	}
 	sort.Strings(keys)
 
 	// Build the output
 	var output []string
-	output = append(output, fmt.Sprintf("id | %s", is.ID))
+	output = append(output, fmt.Sprintf("id | %s", r.ID))
 	for _, k := range keys {
 		if k != "id" {
-			output = append(output, fmt.Sprintf("%s | %s", k, is.Attributes[k]))
+			output = append(output, fmt.Sprintf("%s | %s", k, r.Attributes[k]))
 		}
 	}
 
@@ -100,18 +109,40 @@ func (c *StateShowCommand) Run(args []string) int {
 	return 0
 }
 
+func (c *StateShowCommand) AutocompleteArgs() complete.Predictor {
+	return completePredictSequence{
+		complete.PredictNothing, // the "show" subcommand itself (already matched)
+		c.completePredictResourceAddress(),
+	}
+}
+
+func (c *StateShowCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{
+		"-id":    complete.PredictAnything,
+		"-json":  complete.PredictNothing,
+		"-state": complete.PredictFiles("*.tfstate"),
+	}
+}
+
 func (c *StateShowCommand) Help() string {
 	helpText := `
-Usage: terraform state show [options] ADDRESS
+Usage: terraform state show [options] [ADDRESS]
 
   Shows the attributes of a resource in the Terraform state.
 
   This command shows the attributes of a single resource in the Terraform
-  state. The address argument must be used to specify a single resource.
-  You can view the list of available resources with "terraform state list".
+  state. The address argument must be used to specify a single resource,
+  unless the resource is looked up by its ID with -id. You can view the
+  list of available resources with "terraform state list".
 
 Options:
 
+  -id=ID              Show the resource with the given ID.
+
+  -json               Output the resource in a machine-readable JSON form,
+                      with its attributes decoded into typed values if the
+                      provider is available.
+
   -state=statefile    Path to a Terraform state file to use to look
                       up Terraform-managed resources. By default it will
                       use the state "terraform.tfstate" if it exists.
@@ -1,11 +1,16 @@
 package command
 
 import (
+	"encoding/json"
+	"reflect"
 	"strings"
 	"testing"
 
+	"github.com/hashicorp/terraform/command/jsonstate"
+	"github.com/hashicorp/terraform/config/configschema"
 	"github.com/hashicorp/terraform/terraform"
 	"github.com/mitchellh/cli"
+	"github.com/zclconf/go-cty/cty"
 )
 
 func TestStateShow(t *testing.T) {
@@ -182,3 +187,130 @@ id  = bar
 bar = value
 foo = value
 `
+
+func TestStateShow_id(t *testing.T) {
+	state := &terraform.State{
+		Modules: []*terraform.ModuleState{
+			&terraform.ModuleState{
+				Path: []string{"root"},
+				Resources: map[string]*terraform.ResourceState{
+					"test_instance.foo": &terraform.ResourceState{
+						Type: "test_instance",
+						Primary: &terraform.InstanceState{
+							ID: "foo",
+						},
+					},
+					"test_instance.bar": &terraform.ResourceState{
+						Type: "test_instance",
+						Primary: &terraform.InstanceState{
+							ID: "bar",
+							Attributes: map[string]string{
+								"foo": "value",
+								"bar": "value",
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	statePath := testStateFile(t, state)
+
+	p := testProvider()
+	ui := new(cli.MockUi)
+	c := &StateShowCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(p),
+			Ui:               ui,
+		},
+	}
+
+	args := []string{
+		"-state", statePath,
+		"-id", "bar",
+	}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+	}
+
+	expected := strings.TrimSpace(testStateShowOutput) + "\n"
+	actual := ui.OutputWriter.String()
+	if actual != expected {
+		t.Fatalf("Expected:\n%q\n\nTo equal: %q", actual, expected)
+	}
+}
+
+func TestStateShow_json(t *testing.T) {
+	state := &terraform.State{
+		Modules: []*terraform.ModuleState{
+			&terraform.ModuleState{
+				Path: []string{"root", "child"},
+				Resources: map[string]*terraform.ResourceState{
+					"data.test_data.foo": &terraform.ResourceState{
+						Type: "test_data",
+						Primary: &terraform.InstanceState{
+							ID: "bar",
+							Attributes: map[string]string{
+								"id":      "bar",
+								"enabled": "true",
+								"tags.%":  "1",
+								"tags.a":  "b",
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	statePath := testStateFile(t, state)
+
+	p := testProvider()
+	p.GetSchemaReturn = &terraform.ProviderSchema{
+		DataSources: map[string]*configschema.Block{
+			"test_data": {
+				Attributes: map[string]*configschema.Attribute{
+					"id":      {Type: cty.String, Computed: true},
+					"enabled": {Type: cty.Bool, Optional: true},
+					"tags":    {Type: cty.Map(cty.String), Optional: true},
+				},
+			},
+		},
+	}
+	ui := new(cli.MockUi)
+	c := &StateShowCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(p),
+			Ui:               ui,
+		},
+	}
+
+	args := []string{
+		"-state", statePath,
+		"-json",
+		"module.child.data.test_data.foo",
+	}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+	}
+
+	var got jsonstate.Resource
+	if err := json.Unmarshal(ui.OutputWriter.Bytes(), &got); err != nil {
+		t.Fatalf("invalid output: %s\n\n%s", err, ui.OutputWriter.String())
+	}
+	if got.Address != "module.child.data.test_data.foo" || got.Mode != "data" || got.ID != "bar" {
+		t.Fatalf("wrong resource %#v", got)
+	}
+	want := map[string]interface{}{
+		"id":      "bar",
+		"enabled": true,
+		"tags":    map[string]interface{}{"a": "b"},
+	}
+	if !reflect.DeepEqual(got.Values, want) {
+		t.Fatalf("wrong values\ngot:  %#v\nwant: %#v", got.Values, want)
+	}
+	if req := p.GetSchemaRequest; req == nil || !reflect.DeepEqual(req.DataSources, []string{"test_data"}) {
+		t.Fatalf("wrong schema request %#v", req)
+	}
+}
@@ -0,0 +1,96 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform/backend"
+	"github.com/mitchellh/cli"
+	"github.com/ryanuber/columnize"
+)
+
+// StateVersionsCommand is a Command implementation that lists the stored
+// versions of the state.
+type StateVersionsCommand struct {
+	Meta
+	StateMeta
+}
+
+func (c *StateVersionsCommand) Run(args []string) int {
+	args, err := c.Meta.process(args, true)
+	if err != nil {
+		return 1
+	}
+
+	cmdFlags := c.Meta.flagSet("state versions")
+	if err := cmdFlags.Parse(args); err != nil {
+		return cli.RunResultHelp
+	}
+
+	// Load the backend
+	b, err := c.Backend(nil)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to load backend: %s", err))
+		return 1
+	}
+
+	v, ok := b.(backend.StateVersioner)
+	if !ok {
+		c.Ui.Error(strings.TrimSpace(errStateVersionsNotSupported))
+		return 1
+	}
+
+	versions, err := v.StateVersions(c.Workspace())
+	if err == backend.ErrStateVersionsNotSupported {
+		c.Ui.Error(strings.TrimSpace(errStateVersionsNotSupported))
+		return 1
+	}
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to list state versions: %s", err))
+		return 1
+	}
+	if len(versions) == 0 {
+		// Output on "error" so it shows up on stderr
+		c.Ui.Error("No state versions")
+		return 0
+	}
+
+	lines := []string{"  | ID | LAST MODIFIED | SIZE"}
+	for _, version := range versions {
+		mark := " "
+		if version.Current {
+			mark = "*"
+		}
+		lines = append(lines, fmt.Sprintf("%s | %s | %s | %d",
+			mark, version.ID, version.LastModified.UTC().Format(time.RFC3339), version.Size))
+	}
+
+	config := columnize.DefaultConfig()
+	config.Glue = " "
+	c.Ui.Output(columnize.Format(lines, config))
+	return 0
+}
+
+func (c *StateVersionsCommand) Help() string {
+	helpText := `
+Usage: terraform state versions
+
+  List the versions of the state kept by the backend, newest first.
+
+  The current version of the state is marked with an asterisk. Any version
+  can be written back as the current state with "terraform state restore".
+
+  Only backends that keep earlier versions of their states, such as the
+  S3 and GCS backends with versioned buckets, support this command.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *StateVersionsCommand) Synopsis() string {
+	return "List the stored versions of the state"
+}
+
+const errStateVersionsNotSupported = `
+The configured backend doesn't keep versions of its states.
+`
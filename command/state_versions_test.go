@@ -0,0 +1,100 @@
+package command
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform/backend"
+	"github.com/hashicorp/terraform/backend/remote-state/inmem"
+	"github.com/hashicorp/terraform/helper/copy"
+	"github.com/hashicorp/terraform/terraform"
+	"github.com/mitchellh/cli"
+)
+
+func TestStateVersions(t *testing.T) {
+	td := tempDir(t)
+	copy.CopyDir(testFixturePath("inmem-backend"), td)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+	defer inmem.Reset()
+
+	testStateVersionsWorkspace(t, "test")
+
+	ui := new(cli.MockUi)
+	c := &StateVersionsCommand{
+		Meta: Meta{Ui: ui},
+	}
+	if code := c.Run(nil); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+	}
+
+	lines := strings.Split(strings.TrimSpace(ui.OutputWriter.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("bad: %q", lines)
+	}
+	if fields := strings.Fields(lines[0]); strings.Join(fields, " ") != "ID LAST MODIFIED SIZE" {
+		t.Fatalf("bad header: %q", lines[0])
+	}
+	if fields := strings.Fields(lines[1]); len(fields) != 4 || fields[0] != "*" || fields[1] != "2" {
+		t.Fatalf("bad current version: %q", lines[1])
+	}
+	if fields := strings.Fields(lines[2]); len(fields) != 3 || fields[0] != "1" {
+		t.Fatalf("bad version: %q", lines[2])
+	}
+}
+
+func TestStateVersions_notSupported(t *testing.T) {
+	tmp, cwd := testCwd(t)
+	defer testFixCwd(t, tmp, cwd)
+
+	ui := new(cli.MockUi)
+	c := &StateVersionsCommand{
+		Meta: Meta{Ui: ui},
+	}
+	if code := c.Run(nil); code != 1 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.OutputWriter.String())
+	}
+	if !strings.Contains(ui.ErrorWriter.String(), "doesn't keep versions") {
+		t.Fatalf("bad error: %s", ui.ErrorWriter.String())
+	}
+}
+
+// testStateVersionsWorkspace initializes the inmem backend in the current
+// directory and selects a new workspace with two versions of its state: the
+// empty state it was created with, and a state with serial 5. It returns
+// the backend.
+func testStateVersionsWorkspace(t *testing.T, name string) backend.Backend {
+	t.Helper()
+
+	ui := new(cli.MockUi)
+	initCmd := &InitCommand{
+		Meta: Meta{Ui: ui},
+	}
+	if code := initCmd.Run([]string{}); code != 0 {
+		t.Fatalf("bad: \n%s", ui.ErrorWriter.String())
+	}
+
+	newCmd := &WorkspaceNewCommand{
+		Meta: Meta{Ui: ui},
+	}
+	if code := newCmd.Run([]string{name}); code != 0 {
+		t.Fatalf("bad: \n%s", ui.ErrorWriter.String())
+	}
+
+	b := backend.TestBackendConfig(t, inmem.New(), nil)
+	sMgr, err := b.State(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	st := terraform.NewState()
+	st.Serial = 5
+	if err := sMgr.WriteState(st); err != nil {
+		t.Fatal(err)
+	}
+	if err := sMgr.PersistState(); err != nil {
+		t.Fatal(err)
+	}
+
+	return b
+}
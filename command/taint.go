@@ -9,6 +9,7 @@ import (
 	"github.com/hashicorp/terraform/command/clistate"
 	"github.com/hashicorp/terraform/state"
 	"github.com/hashicorp/terraform/terraform"
+	"github.com/posener/complete"
 )
 
 // TaintCommand is a cli.Command implementation that manually taints
@@ -169,9 +170,29 @@ func (c *TaintCommand) Run(args []string) int {
 	c.Ui.Output(fmt.Sprintf(
 		"The resource %s in the module %s has been marked as tainted!",
 		name, module))
+	c.Ui.Warn(strings.TrimSpace(taintReplaceWarning))
 	return 0
 }
 
+func (c *TaintCommand) AutocompleteArgs() complete.Predictor {
+	return completePredictSequence{
+		c.completePredictResourceName(),
+	}
+}
+
+func (c *TaintCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{
+		"-allow-missing": complete.PredictNothing,
+		"-backup":        complete.PredictFiles("*"),
+		"-lock":          completePredictBoolean,
+		"-lock-timeout":  complete.PredictAnything,
+		"-module":        complete.PredictAnything,
+		"-no-color":      complete.PredictNothing,
+		"-state":         complete.PredictFiles("*.tfstate"),
+		"-state-out":     complete.PredictFiles("*.tfstate"),
+	}
+}
+
 func (c *TaintCommand) Help() string {
 	helpText := `
 Usage: terraform taint [options] name
@@ -185,6 +206,10 @@ Usage: terraform taint [options] name
   its own will not modify infrastructure. This command can be undone by
   reverting the state backup file that is created.
 
+  This command is deprecated. Use the -replace option of "terraform plan"
+  or "terraform apply" instead, which includes the replacement in the plan
+  so that it can be reviewed before any change is made.
+
 Options:
 
   -allow-missing      If specified, the command will succeed (exit code 0)
@@ -225,3 +250,13 @@ func (c *TaintCommand) allowMissingExit(name, module string) int {
 		name, module))
 	return 0
 }
+
+const taintReplaceWarning = `
+Warning: "terraform taint" is deprecated
+
+Tainting modifies the state immediately, before the replacement can be
+reviewed in a plan. Instead, use the -replace option to include the
+replacement in a plan without modifying the state:
+
+    terraform apply -replace=ADDRESS
+`
@@ -0,0 +1,14 @@
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd
+
+package command
+
+import (
+	"os"
+)
+
+// terminalWidth returns false on the platforms where the size of the
+// terminal isn't measured, so that diagnostics are wrapped at the default
+// width or the one that the COLUMNS environment variable sets.
+func terminalWidth(f *os.File) (int, bool) {
+	return 0, false
+}
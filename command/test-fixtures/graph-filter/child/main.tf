@@ -0,0 +1,7 @@
+resource "test_instance" "bar" {
+  ami = "bar"
+}
+
+output "id" {
+  value = "${test_instance.bar.id}"
+}
@@ -0,0 +1,7 @@
+resource "test_instance" "foo" {
+  ami = "${module.child.id}"
+}
+
+module "child" {
+  source = "./child"
+}
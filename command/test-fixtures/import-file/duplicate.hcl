@@ -0,0 +1,9 @@
+import {
+  to = "test_instance.foo"
+  id = "foo-id"
+}
+
+import {
+  to = "test_instance.foo"
+  id = "other-id"
+}
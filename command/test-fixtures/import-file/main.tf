@@ -0,0 +1,16 @@
+provider "test" {
+    foo = "bar"
+}
+
+provider "test" {
+    foo = "bar"
+
+    alias = "alias"
+}
+
+resource "test_instance" "foo" {
+}
+
+resource "test_instance" "bar" {
+    provider = "test.alias"
+}
@@ -0,0 +1,10 @@
+import {
+  to = "test_instance.foo"
+  id = "foo-id"
+}
+
+import {
+  to       = "test_instance.bar"
+  id       = "missing"
+  provider = "test.alias"
+}
@@ -0,0 +1,12 @@
+provider "test" {
+    foo = "bar"
+}
+
+provider "test" {
+    foo = "bar"
+
+    alias = "alias"
+}
+
+resource "test_instance" "existing" {
+}
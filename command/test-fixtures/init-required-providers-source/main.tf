@@ -0,0 +1,11 @@
+terraform {
+  required_providers {
+    test = {
+      source  = "example.com/acme/test"
+      version = "~> 1.0"
+    }
+  }
+}
+
+resource "test_instance" "foo" {
+}
@@ -0,0 +1,11 @@
+terraform {
+  required_providers {
+    foo = {
+      source  = "acme/foo"
+      version = "~> 1.0"
+    }
+  }
+}
+
+resource "foo_instance" "a" {
+}
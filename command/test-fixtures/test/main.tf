@@ -0,0 +1,15 @@
+variable "ami" {
+  default = "foo"
+}
+
+resource "test_instance" "foo" {
+  ami = "${var.ami}"
+}
+
+locals {
+  upper_ami = "${upper(var.ami)}"
+}
+
+output "ami" {
+  value = "${test_instance.foo.ami}"
+}
@@ -0,0 +1,7 @@
+mock_provider "test" {}
+
+run "other" {
+  assert {
+    condition = "${var.ami == "foo" && test_instance.foo.id != ""}"
+  }
+}
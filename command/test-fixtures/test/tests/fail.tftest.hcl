@@ -0,0 +1,16 @@
+mock_provider "test" {}
+
+run "wrong" {
+  assert {
+    condition     = "${test_instance.foo.ami == "bar"}"
+    error_message = "The ami is wrong."
+  }
+}
+
+run "invalid" {
+  assert {
+    condition = "${test_instance.foo.nope}"
+  }
+}
+
+run "skipped" {}
@@ -0,0 +1,29 @@
+mock_provider "test" {
+  defaults {
+    arn = "arn:mock"
+  }
+}
+
+run "plan" {
+  command = "plan"
+
+  assert {
+    condition     = "${test_instance.foo.ami == "foo"}"
+    error_message = "The planned ami is wrong."
+  }
+}
+
+run "apply" {
+  variables {
+    ami = "bar"
+  }
+
+  assert {
+    condition     = "test_instance.foo.ami == \"bar\""
+    error_message = "The ami is wrong."
+  }
+
+  assert {
+    condition = "${test_instance.foo.arn == "arn:mock" && local.upper_ami == "BAR"}"
+  }
+}
@@ -0,0 +1,328 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/terraform/config/module"
+	"github.com/hashicorp/terraform/moduletest"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+// TestCommand is a Command implementation that runs the test files of the
+// module in the current working directory.
+type TestCommand struct {
+	Meta
+}
+
+func (c *TestCommand) Help() string {
+	return testCommandHelp
+}
+
+func (c *TestCommand) Synopsis() string {
+	return "Runs the tests of the current module"
+}
+
+func (c *TestCommand) Run(args []string) int {
+	var jsonOutput bool
+	var testDir string
+	var filters FlagStringSlice
+
+	args, err := c.Meta.process(args, true)
+	if err != nil {
+		return 1
+	}
+
+	cmdFlags := c.Meta.flagSet("test")
+	cmdFlags.BoolVar(&jsonOutput, "json", false, "json")
+	cmdFlags.StringVar(&testDir, "test-directory", moduletest.DefaultTestDirectory, "test-directory")
+	cmdFlags.Var(&filters, "filter", "filter")
+	cmdFlags.Usage = func() { c.Ui.Error(c.Help()) }
+	if err := cmdFlags.Parse(args); err != nil {
+		return 1
+	}
+	if len(cmdFlags.Args()) > 0 {
+		c.Ui.Error("The test command expects no arguments.")
+		cmdFlags.Usage()
+		return 1
+	}
+
+	configPath, err := os.Getwd()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error getting pwd: %s", err))
+		return 1
+	}
+
+	mod, diags := c.Module(configPath)
+	if diags.HasErrors() {
+		c.showDiagnostics(diags)
+		return 1
+	}
+	if mod == nil {
+		c.Ui.Error(fmt.Sprintf(
+			"No configuration files found in the directory: %s\n\n"+
+				"This command requires configuration to run.",
+			configPath))
+		return 1
+	}
+
+	files, err := moduletest.LoadFiles(configPath, testDir, filters)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error loading test files: %s", err))
+		return 1
+	}
+
+	results := &moduletest.Results{Status: moduletest.Pass, Files: []*moduletest.FileResult{}}
+	for _, f := range files {
+		results.Add(c.runFile(mod, f))
+	}
+
+	if jsonOutput {
+		out, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Failed to marshal the test results: %s", err))
+			return 1
+		}
+		c.Ui.Output(string(out))
+	} else {
+		c.showResults(results, testDir)
+	}
+
+	if results.Status != moduletest.Pass {
+		return 1
+	}
+	return 0
+}
+
+// runFile runs the runs of a test file in order, sharing a state between
+// them, and destroys whatever they created at the end.
+func (c *TestCommand) runFile(mod *module.Tree, f *moduletest.File) *moduletest.FileResult {
+	result := &moduletest.FileResult{Name: f.Name}
+	resolver := f.ProviderResolver(mod, c.contextOpts().ProviderResolver)
+	state := terraform.NewState()
+
+	// The variables of the last run are also those of the destroy, since
+	// they're what the resources in the state were created with.
+	vars := f.Variables
+	failed := false
+	for _, run := range f.Runs {
+		runResult := &moduletest.RunResult{
+			Name:    run.Name,
+			Command: run.Command,
+			Status:  moduletest.Skip,
+		}
+		result.Runs = append(result.Runs, runResult)
+		if failed {
+			continue
+		}
+
+		runVars := make(map[string]interface{})
+		for k, v := range f.Variables {
+			runVars[k] = v
+		}
+		for k, v := range run.Variables {
+			runVars[k] = v
+		}
+
+		newState, err := c.run(mod, run, resolver, state, runVars, runResult)
+		if newState != nil {
+			state = newState
+			vars = runVars
+		}
+		if err != nil {
+			runResult.Status = moduletest.Error
+			runResult.Errors = append(runResult.Errors, err.Error())
+			failed = true
+		}
+	}
+
+	if !state.Empty() {
+		if err := c.destroy(mod, resolver, state, vars); err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf(
+				"Failed to destroy the resources created by the tests, which may need to be cleaned up by hand: %s", err))
+		}
+	}
+
+	return result
+}
+
+// run performs a single run of a test file with the given state, recording
+// the outcome of its assertions in result. It returns the state after an
+// apply, which is returned even if the apply fails, or nil after a plan.
+func (c *TestCommand) run(
+	mod *module.Tree,
+	run *moduletest.Run,
+	resolver terraform.ResourceProviderResolver,
+	state *terraform.State,
+	vars map[string]interface{},
+	result *moduletest.RunResult) (*terraform.State, error) {
+	ctx, err := c.testContext(mod, resolver, state, vars, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if diags := ctx.Validate(); diags.HasErrors() {
+		return nil, diags.Err()
+	}
+
+	plan, err := ctx.Plan()
+	if err != nil {
+		return nil, fmt.Errorf("Error running plan: %s", err)
+	}
+
+	// Plans are checked against the values the resources will have once
+	// the plan is applied, which are unknown for computed attributes.
+	var newState *terraform.State
+	if run.Command != moduletest.CommandPlan {
+		newState, err = ctx.Apply()
+		if err != nil {
+			return newState, fmt.Errorf("Error applying plan: %s", err)
+		}
+	}
+
+	interpolater := ctx.Interpolater()
+	defer interpolater.Close()
+	if run.Command == moduletest.CommandPlan {
+		interpolater.State = plan.PlannedState()
+	}
+
+	result.Status = moduletest.Pass
+	for _, a := range run.Asserts {
+		ok, err := a.Check(interpolater)
+		if err != nil {
+			return newState, fmt.Errorf("Error evaluating condition %q: %s", a.Condition, err)
+		}
+		if !ok {
+			msg := a.ErrorMessage
+			if msg == "" {
+				msg = fmt.Sprintf("Condition %q is false", a.Condition)
+			}
+			result.Status = moduletest.Fail
+			result.Failures = append(result.Failures, msg)
+		}
+	}
+
+	return newState, nil
+}
+
+// destroy destroys the resources in the state.
+func (c *TestCommand) destroy(
+	mod *module.Tree,
+	resolver terraform.ResourceProviderResolver,
+	state *terraform.State,
+	vars map[string]interface{}) error {
+	ctx, err := c.testContext(mod, resolver, state, vars, true)
+	if err != nil {
+		return err
+	}
+	if _, err := ctx.Plan(); err != nil {
+		return err
+	}
+	_, err = ctx.Apply()
+	return err
+}
+
+// testContext returns a context for a run of a test file. The context
+// doesn't report progress, since the output is that of the test results.
+func (c *TestCommand) testContext(
+	mod *module.Tree,
+	resolver terraform.ResourceProviderResolver,
+	state *terraform.State,
+	vars map[string]interface{},
+	destroy bool) (*terraform.Context, error) {
+	opts := c.contextOpts()
+	opts.Hooks = []terraform.Hook{&terraform.DebugHook{}}
+	opts.Module = mod
+	opts.State = state
+	opts.Destroy = destroy
+	opts.ProviderResolver = resolver
+
+	opts.InputValues = opts.InputValues.Override(
+		terraform.InputValuesFromMap(vars, terraform.ValueFromCaller, ""))
+
+	return terraform.NewContext(opts)
+}
+
+// showResults outputs the results of the tests for people to read.
+func (c *TestCommand) showResults(results *moduletest.Results, testDir string) {
+	if len(results.Files) == 0 {
+		c.Ui.Output(fmt.Sprintf("No test files were found in %s.", testDir))
+		return
+	}
+
+	colors := map[moduletest.Status]string{
+		moduletest.Pass:  "[green]",
+		moduletest.Fail:  "[red]",
+		moduletest.Error: "[red]",
+		moduletest.Skip:  "[yellow]",
+	}
+
+	var buf strings.Builder
+	for _, f := range results.Files {
+		fmt.Fprintf(&buf, "[bold]%s[reset]... %s%s[reset]\n", f.Name, colors[f.Status], f.Status)
+		for _, run := range f.Runs {
+			fmt.Fprintf(&buf, "  run %q... %s%s[reset]\n", run.Name, colors[run.Status], run.Status)
+			for _, msg := range run.Failures {
+				fmt.Fprintf(&buf, "    - %s\n", msg)
+			}
+			for _, msg := range run.Errors {
+				fmt.Fprintf(&buf, "    [red]Error:[reset] %s\n", msg)
+			}
+		}
+		for _, msg := range f.Errors {
+			fmt.Fprintf(&buf, "  [red]Error:[reset] %s\n", msg)
+		}
+	}
+
+	s := results.Summary
+	if results.Status == moduletest.Pass {
+		fmt.Fprintf(&buf, "\n[reset][bold][green]Success![reset] %d passed, %d failed.", s.Pass, s.Fail)
+	} else {
+		fmt.Fprintf(&buf, "\n[reset][bold][red]Failure![reset] %d passed, %d failed, %d errored, %d skipped.",
+			s.Pass, s.Fail, s.Error, s.Skip)
+	}
+
+	c.Ui.Output(c.Colorize().Color(buf.String()))
+}
+
+const testCommandHelp = `
+Usage: terraform test [options]
+
+  Runs the tests of the module in the current working directory.
+
+  Tests are written in test files, named like tests/*.tftest.hcl, each of
+  which is a sequence of run blocks. Each run plans or applies the module,
+  sharing a state with the earlier runs of the same file, and then checks
+  the conditions of its assert blocks. Whatever the runs of a file created
+  is destroyed once they're done.
+
+  Providers can be replaced by mocks with mock_provider blocks, which
+  accept any configuration and create nothing, so that modules can be
+  tested without credentials or real infrastructure.
+
+  The exit status is 0 if all of the assertions hold, and 1 otherwise.
+
+Options:
+
+  -filter=FILE           Only run the given test file, named relative to the
+                         module, such as tests/main.tftest.hcl. This flag can
+                         be used multiple times.
+
+  -json                  Output the results in a machine-readable JSON form.
+
+  -no-color              If specified, output won't contain any color.
+
+  -test-directory=DIR    The directory of the test files, relative to the
+                         module. Defaults to "tests".
+
+  -var 'foo=bar'         Set a variable in the Terraform configuration for all
+                         of the tests. The variables of test files take
+                         precedence. This flag can be set multiple times.
+
+  -var-file=foo          Set variables in the Terraform configuration from
+                         a file. If "terraform.tfvars" or any ".auto.tfvars"
+                         files are present, they will be automatically loaded.
+
+`
@@ -0,0 +1,151 @@
+package command
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/copy"
+	"github.com/hashicorp/terraform/moduletest"
+	"github.com/mitchellh/cli"
+)
+
+func TestTest(t *testing.T) {
+	td := tempDir(t)
+	copy.CopyDir(testFixturePath("test"), td)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	p := testProvider()
+	ui := new(cli.MockUi)
+	c := &TestCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(p),
+			Ui:               ui,
+		},
+	}
+
+	args := []string{"-no-color", "-filter=tests/main.tftest.hcl"}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: %d\n\n%s\n%s", code, ui.OutputWriter.String(), ui.ErrorWriter.String())
+	}
+
+	output := ui.OutputWriter.String()
+	for _, want := range []string{
+		"tests/main.tftest.hcl... pass",
+		`run "plan"... pass`,
+		`run "apply"... pass`,
+		"Success! 2 passed, 0 failed.",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output doesn't contain %q\n\n%s", want, output)
+		}
+	}
+	if strings.Contains(output, "fail.tftest.hcl") {
+		t.Errorf("output contains a file that was filtered out\n\n%s", output)
+	}
+
+	// The provider is mocked, so the real one isn't used.
+	if p.ApplyCalled || p.DiffCalled {
+		t.Fatal("real provider was used")
+	}
+}
+
+func TestTest_fail(t *testing.T) {
+	td := tempDir(t)
+	copy.CopyDir(testFixturePath("test"), td)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	ui := new(cli.MockUi)
+	c := &TestCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(testProvider()),
+			Ui:               ui,
+		},
+	}
+
+	args := []string{"-json"}
+	if code := c.Run(args); code != 1 {
+		t.Fatalf("bad: %d\n\n%s\n%s", code, ui.OutputWriter.String(), ui.ErrorWriter.String())
+	}
+
+	var results moduletest.Results
+	if err := json.Unmarshal(ui.OutputWriter.Bytes(), &results); err != nil {
+		t.Fatalf("invalid output: %s\n\n%s", err, ui.OutputWriter.String())
+	}
+
+	if results.Status != moduletest.Error {
+		t.Errorf("wrong status %q", results.Status)
+	}
+	want := moduletest.Summary{Pass: 2, Fail: 1, Error: 1, Skip: 1}
+	if results.Summary != want {
+		t.Errorf("wrong summary\ngot:  %#v\nwant: %#v", results.Summary, want)
+	}
+	if len(results.Files) != 2 || results.Files[0].Name != "tests/fail.tftest.hcl" {
+		t.Fatalf("wrong files %#v", results.Files)
+	}
+
+	runs := results.Files[0].Runs
+	if len(runs) != 3 {
+		t.Fatalf("wrong runs %#v", runs)
+	}
+	if runs[0].Status != moduletest.Fail || len(runs[0].Failures) != 1 || runs[0].Failures[0] != "The ami is wrong." {
+		t.Errorf("wrong result of the first run %#v", runs[0])
+	}
+	if runs[1].Status != moduletest.Error || len(runs[1].Errors) != 1 {
+		t.Errorf("wrong result of the second run %#v", runs[1])
+	}
+	if runs[2].Status != moduletest.Skip {
+		t.Errorf("wrong result of the third run %#v", runs[2])
+	}
+}
+
+func TestTest_testDirectory(t *testing.T) {
+	td := tempDir(t)
+	copy.CopyDir(testFixturePath("test"), td)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	ui := new(cli.MockUi)
+	c := &TestCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(testProvider()),
+			Ui:               ui,
+		},
+	}
+
+	args := []string{"-no-color", "-test-directory=other"}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: %d\n\n%s\n%s", code, ui.OutputWriter.String(), ui.ErrorWriter.String())
+	}
+
+	if output := ui.OutputWriter.String(); !strings.Contains(output, `run "other"... pass`) {
+		t.Fatalf("wrong output\n\n%s", output)
+	}
+}
+
+func TestTest_noFiles(t *testing.T) {
+	td := tempDir(t)
+	copy.CopyDir(testFixturePath("test"), td)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	ui := new(cli.MockUi)
+	c := &TestCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(testProvider()),
+			Ui:               ui,
+		},
+	}
+
+	args := []string{"-test-directory=missing"}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+	}
+
+	if output := ui.OutputWriter.String(); !strings.Contains(output, "No test files were found in missing.") {
+		t.Fatalf("wrong output\n\n%s", output)
+	}
+}
@@ -1,8 +1,11 @@
 package command
 
 import (
+	"encoding/json"
 	"fmt"
+	"log"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/terraform/state"
 	"github.com/hashicorp/terraform/terraform"
@@ -22,22 +25,27 @@ func (c *UnlockCommand) Run(args []string) int {
 	}
 
 	force := false
+	jsonOutput := false
 	cmdFlags := c.Meta.flagSet("force-unlock")
 	cmdFlags.BoolVar(&force, "force", false, "force")
+	cmdFlags.BoolVar(&jsonOutput, "json", false, "json")
 	cmdFlags.Usage = func() { c.Ui.Error(c.Help()) }
 	if err := cmdFlags.Parse(args); err != nil {
 		return 1
 	}
 
+	// With -json the lock is only inspected, so no lock ID is given.
 	args = cmdFlags.Args()
-	if len(args) == 0 {
-		c.Ui.Error("unlock requires a lock id argument")
-		return cli.RunResultHelp
+	var lockID string
+	if !jsonOutput {
+		if len(args) == 0 {
+			c.Ui.Error("unlock requires a lock id argument")
+			return cli.RunResultHelp
+		}
+		lockID = args[0]
+		args = args[1:]
 	}
 
-	lockID := args[0]
-	args = args[1:]
-
 	// assume everything is initialized. The user can manually init if this is
 	// required.
 	configPath, err := ModulePath(args)
@@ -78,28 +86,68 @@ func (c *UnlockCommand) Run(args []string) int {
 		isLocal = true
 	}
 
-	if !force {
-		// Forcing this doesn't do anything, but doesn't break anything either,
-		// and allows us to run the basic command test too.
-		if isLocal {
-			c.Ui.Error("Local state cannot be unlocked by another process")
+	// Local state locks are held by the processes using the state, and
+	// inspecting them would create the state file, so there's nothing to do
+	// either way. Forcing this doesn't do anything, but doesn't break
+	// anything either, and allows us to run the basic command test too.
+	if isLocal && jsonOutput {
+		c.Ui.Error("Local state locks cannot be inspected by another process")
+		return 1
+	}
+	if isLocal && !force {
+		c.Ui.Error("Local state cannot be unlocked by another process")
+		return 1
+	}
+
+	// The lock is inspected by trying to take it, which doesn't disturb the
+	// lock being inspected.
+	var held *state.LockInfo
+	var inspectErr error
+	if !isLocal {
+		probe := state.NewLockInfo()
+		probe.Operation = "force-unlock"
+		probe.Info = "inspecting the lock"
+		held, inspectErr = state.InspectLock(st, probe)
+	}
+
+	if jsonOutput {
+		return c.outputJSON(held, inspectErr)
+	}
+
+	if inspectErr != nil {
+		// The lock can still be removed without its details.
+		log.Printf("[WARN] force-unlock: failed to inspect the lock: %s", inspectErr)
+		c.Ui.Warn(fmt.Sprintf(
+			"The details of the lock aren't available: %s", inspectErr))
+	} else if held != nil {
+		c.Ui.Output(strings.TrimSpace(held.String()) + "\n")
+		if held.ID != lockID {
+			c.Ui.Error(fmt.Sprintf(
+				"The state is locked with the lock ID %q, not %q.\n\n"+
+					"Check that the lock is the one you intend to remove before trying again.",
+				held.ID, lockID))
 			return 1
 		}
+	} else if !isLocal {
+		c.Ui.Error("The state isn't locked, or its backend doesn't support locking.")
+		return 1
+	}
 
+	if !force {
 		desc := "Terraform will remove the lock on the remote state.\n" +
 			"This will allow local Terraform commands to modify this state, even though it\n" +
-			"may be still be in use. Only 'yes' will be accepted to confirm."
+			"may be still be in use. Only the lock ID will be accepted to confirm."
 
 		v, err := c.UIInput().Input(&terraform.InputOpts{
 			Id:          "force-unlock",
-			Query:       "Do you really want to force-unlock?",
+			Query:       "Enter the ID of the lock to remove:",
 			Description: desc,
 		})
 		if err != nil {
 			c.Ui.Error(fmt.Sprintf("Error asking for confirmation: %s", err))
 			return 1
 		}
-		if v != "yes" {
+		if v != lockID {
 			c.Ui.Output("force-unlock cancelled.")
 			return 1
 		}
@@ -114,9 +162,66 @@ func (c *UnlockCommand) Run(args []string) int {
 	return 0
 }
 
+// outputJSON outputs the result of inspecting the lock for automation to
+// consume.
+func (c *UnlockCommand) outputJSON(held *state.LockInfo, inspectErr error) int {
+	out := lockStatusJSON{
+		Locked: held != nil,
+	}
+	if le, ok := inspectErr.(*state.LockError); ok {
+		// Locked, but the backend doesn't tell by whom.
+		out.Locked = true
+		inspectErr = nil
+		log.Printf("[WARN] force-unlock: the lock has no details: %s", le)
+	}
+	if inspectErr != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to inspect the lock: %s", inspectErr))
+		return 1
+	}
+
+	if held != nil {
+		out.Lock = &lockInfoJSON{
+			ID:        held.ID,
+			Operation: held.Operation,
+			Info:      held.Info,
+			Who:       held.Who,
+			Version:   held.Version,
+			Created:   held.Created,
+			Path:      held.Path,
+		}
+	}
+
+	js, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to marshal the lock info: %s", err))
+		return 1
+	}
+	c.Ui.Output(string(js))
+	return 0
+}
+
+// lockStatusJSON is the output of force-unlock -json. Lock is nil if the
+// state isn't locked, or if the backend doesn't report the details of its
+// locks.
+type lockStatusJSON struct {
+	Locked bool          `json:"locked"`
+	Lock   *lockInfoJSON `json:"lock,omitempty"`
+}
+
+type lockInfoJSON struct {
+	ID        string    `json:"id"`
+	Operation string    `json:"operation"`
+	Info      string    `json:"info"`
+	Who       string    `json:"who"`
+	Version   string    `json:"version"`
+	Created   time.Time `json:"created"`
+	Path      string    `json:"path"`
+}
+
 func (c *UnlockCommand) Help() string {
 	helpText := `
-Usage: terraform force-unlock LOCK_ID [DIR]
+Usage: terraform force-unlock [options] LOCK_ID [DIR]
+       terraform force-unlock -json [DIR]
 
   Manually unlock the state for the defined configuration.
 
@@ -125,9 +230,16 @@ Usage: terraform force-unlock LOCK_ID [DIR]
   on the backend being used. Local state files cannot be unlocked by another
   process.
 
+  The details of the lock are shown first, when the backend reports them, and
+  the lock is only removed if its ID is LOCK_ID. Removing it must be confirmed
+  by entering the lock ID again.
+
 Options:
 
   -force                 Don't ask for input for unlock confirmation.
+
+  -json                  Only output the details of the lock, if any, as a
+                         JSON object, without removing it.
 `
 	return strings.TrimSpace(helpText)
 }
@@ -1,7 +1,9 @@
 package command
 
 import (
+	"encoding/json"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/hashicorp/terraform/backend/remote-state/inmem"
@@ -102,3 +104,110 @@ func TestUnlock_inmemBackend(t *testing.T) {
 	}
 
 }
+
+func TestUnlock_inmemBackendConfirm(t *testing.T) {
+	td := tempDir(t)
+	copy.CopyDir(testFixturePath("backend-inmem-locked"), td)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+	defer inmem.Reset()
+
+	ui := new(cli.MockUi)
+	ci := &InitCommand{
+		Meta: Meta{
+			Ui: ui,
+		},
+	}
+	if code := ci.Run(nil); code != 0 {
+		t.Fatalf("bad: %d\n%s", code, ui.ErrorWriter)
+	}
+
+	lockID := "2b6a6738-5dd5-50d6-c0ae-f6352977666b"
+
+	// Anything other than the lock ID cancels the unlock.
+	defer testInputMap(t, map[string]string{
+		"force-unlock": "yes",
+	})()
+	ui = new(cli.MockUi)
+	c := &UnlockCommand{
+		Meta: Meta{
+			Ui: ui,
+		},
+	}
+	if code := c.Run([]string{lockID}); code != 1 {
+		t.Fatalf("bad: %d\n%s\n%s", code, ui.OutputWriter.String(), ui.ErrorWriter.String())
+	}
+	output := ui.OutputWriter.String()
+	if !strings.Contains(output, "ID:        "+lockID) {
+		t.Fatalf("the lock info wasn't shown:\n%s", output)
+	}
+	if !strings.Contains(output, "force-unlock cancelled.") {
+		t.Fatalf("the unlock wasn't cancelled:\n%s", output)
+	}
+
+	testInputResponseMap = map[string]string{
+		"force-unlock": lockID,
+	}
+	ui = new(cli.MockUi)
+	c = &UnlockCommand{
+		Meta: Meta{
+			Ui: ui,
+		},
+	}
+	if code := c.Run([]string{lockID}); code != 0 {
+		t.Fatalf("bad: %d\n%s\n%s", code, ui.OutputWriter.String(), ui.ErrorWriter.String())
+	}
+}
+
+func TestUnlock_json(t *testing.T) {
+	td := tempDir(t)
+	copy.CopyDir(testFixturePath("backend-inmem-locked"), td)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+	defer inmem.Reset()
+
+	ui := new(cli.MockUi)
+	ci := &InitCommand{
+		Meta: Meta{
+			Ui: ui,
+		},
+	}
+	if code := ci.Run(nil); code != 0 {
+		t.Fatalf("bad: %d\n%s", code, ui.ErrorWriter)
+	}
+
+	ui = new(cli.MockUi)
+	c := &UnlockCommand{
+		Meta: Meta{
+			Ui: ui,
+		},
+	}
+	if code := c.Run([]string{"-json"}); code != 0 {
+		t.Fatalf("bad: %d\n%s\n%s", code, ui.OutputWriter.String(), ui.ErrorWriter.String())
+	}
+
+	var got lockStatusJSON
+	if err := json.Unmarshal(ui.OutputWriter.Bytes(), &got); err != nil {
+		t.Fatalf("invalid output: %s\n%s", err, ui.OutputWriter.String())
+	}
+	if !got.Locked || got.Lock == nil || got.Lock.ID != "2b6a6738-5dd5-50d6-c0ae-f6352977666b" {
+		t.Fatalf("wrong lock status: %s", ui.OutputWriter.String())
+	}
+
+	// Inspecting the lock doesn't remove it.
+	ui = new(cli.MockUi)
+	c = &UnlockCommand{
+		Meta: Meta{
+			Ui: ui,
+		},
+	}
+	if code := c.Run([]string{"-json"}); code != 0 {
+		t.Fatalf("bad: %d\n%s\n%s", code, ui.OutputWriter.String(), ui.ErrorWriter.String())
+	}
+	if err := json.Unmarshal(ui.OutputWriter.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if !got.Locked {
+		t.Fatal("the state was unlocked")
+	}
+}
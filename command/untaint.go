@@ -8,6 +8,7 @@ import (
 
 	"github.com/hashicorp/terraform/command/clistate"
 	"github.com/hashicorp/terraform/state"
+	"github.com/posener/complete"
 )
 
 // UntaintCommand is a cli.Command implementation that manually untaints
@@ -160,6 +161,25 @@ func (c *UntaintCommand) Run(args []string) int {
 	return 0
 }
 
+func (c *UntaintCommand) AutocompleteArgs() complete.Predictor {
+	return completePredictSequence{
+		c.completePredictResourceName(),
+	}
+}
+
+func (c *UntaintCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{
+		"-allow-missing": complete.PredictNothing,
+		"-backup":        complete.PredictFiles("*"),
+		"-lock":          completePredictBoolean,
+		"-lock-timeout":  complete.PredictAnything,
+		"-module":        complete.PredictAnything,
+		"-no-color":      complete.PredictNothing,
+		"-state":         complete.PredictFiles("*.tfstate"),
+		"-state-out":     complete.PredictFiles("*.tfstate"),
+	}
+}
+
 func (c *UntaintCommand) Help() string {
 	helpText := `
 Usage: terraform untaint [options] name
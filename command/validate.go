@@ -1,6 +1,7 @@
 package command
 
 import (
+	"encoding/json"
 	"fmt"
 	"path/filepath"
 	"strings"
@@ -23,10 +24,11 @@ func (c *ValidateCommand) Run(args []string) int {
 	if err != nil {
 		return 1
 	}
-	var checkVars bool
+	var checkVars, jsonOutput bool
 
 	cmdFlags := c.Meta.flagSet("validate")
 	cmdFlags.BoolVar(&checkVars, "check-variables", true, "check-variables")
+	cmdFlags.BoolVar(&jsonOutput, "json", false, "json")
 	cmdFlags.Usage = func() {
 		c.Ui.Error(c.Help())
 	}
@@ -54,9 +56,53 @@ func (c *ValidateCommand) Run(args []string) int {
 		return 1
 	}
 
-	rtnCode := c.validate(dir, checkVars)
+	diags := c.validate(dir, checkVars)
 
-	return rtnCode
+	if jsonOutput {
+		return c.showJSON(diags)
+	}
+
+	c.showDiagnostics(diags)
+	if diags.HasErrors() {
+		return 1
+	}
+
+	return 0
+}
+
+// validateOutput is the result of validate with -json.
+type validateOutput struct {
+	Valid        bool                      `json:"valid"`
+	ErrorCount   int                       `json:"error_count"`
+	WarningCount int                       `json:"warning_count"`
+	Diagnostics  []*tfdiags.JSONDiagnostic `json:"diagnostics"`
+}
+
+func (c *ValidateCommand) showJSON(diags tfdiags.Diagnostics) int {
+	output := validateOutput{
+		Valid:       !diags.HasErrors(),
+		Diagnostics: diags.JSON(diagnosticSources(diags)),
+	}
+	for _, diag := range diags {
+		switch diag.Severity() {
+		case tfdiags.Error:
+			output.ErrorCount++
+		case tfdiags.Warning:
+			output.WarningCount++
+		}
+	}
+
+	j, err := json.MarshalIndent(output, "", "  ")
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to encode the validation result: %s", err))
+		return 1
+	}
+	c.Ui.Output(string(j))
+
+	if !output.Valid {
+		return 1
+	}
+	return 0
 }
 
 func (c *ValidateCommand) Synopsis() string {
@@ -84,6 +130,10 @@ Options:
   -check-variables=true If set to true (default), the command will check
                         whether all required variables have been specified.
 
+  -json                 If specified, the result of the validation is
+                        printed in JSON format, with the details and
+                        source location of each error and warning.
+
   -no-color             If specified, output won't contain any color.
 
   -var 'foo=bar'        Set a variable in the Terraform configuration. This
@@ -96,29 +146,26 @@ Options:
 	return strings.TrimSpace(helpText)
 }
 
-func (c *ValidateCommand) validate(dir string, checkVars bool) int {
+func (c *ValidateCommand) validate(dir string, checkVars bool) tfdiags.Diagnostics {
 	var diags tfdiags.Diagnostics
 
 	cfg, err := config.LoadDir(dir)
 	if err != nil {
 		diags = diags.Append(err)
-		c.showDiagnostics(err)
-		return 1
+		return diags
 	}
 
 	diags = diags.Append(cfg.Validate())
 
 	if diags.HasErrors() {
-		c.showDiagnostics(diags)
-		return 1
+		return diags
 	}
 
 	if checkVars {
 		mod, modDiags := c.Module(dir)
 		diags = diags.Append(modDiags)
 		if modDiags.HasErrors() {
-			c.showDiagnostics(diags)
-			return 1
+			return diags
 		}
 
 		opts := c.contextOpts()
@@ -127,17 +174,11 @@ func (c *ValidateCommand) validate(dir string, checkVars bool) int {
 		tfCtx, err := terraform.NewContext(opts)
 		if err != nil {
 			diags = diags.Append(err)
-			c.showDiagnostics(diags)
-			return 1
+			return diags
 		}
 
 		diags = diags.Append(tfCtx.Validate())
 	}
 
-	c.showDiagnostics(diags)
-	if diags.HasErrors() {
-		return 1
-	}
-
-	return 0
+	return diags
 }
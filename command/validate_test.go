@@ -1,11 +1,13 @@
 package command
 
 import (
+	"encoding/json"
 	"os"
 	"strings"
 	"testing"
 
 	"github.com/hashicorp/terraform/helper/copy"
+	"github.com/hashicorp/terraform/tfdiags"
 	"github.com/mitchellh/cli"
 )
 
@@ -84,7 +86,7 @@ func TestSameProviderMutipleTimesShouldFail(t *testing.T) {
 	if code != 1 {
 		t.Fatalf("Should have failed: %d\n\n%s", code, ui.ErrorWriter.String())
 	}
-	if !strings.HasSuffix(strings.TrimSpace(ui.ErrorWriter.String()), "provider.aws: multiple configurations present; only one configuration is allowed per provider") {
+	if !strings.Contains(ui.ErrorWriter.String(), "provider.aws: multiple configurations present; only one configuration is allowed per provider") {
 		t.Fatalf("Should have failed: %d\n\n'%s'", code, ui.ErrorWriter.String())
 	}
 }
@@ -94,7 +96,7 @@ func TestSameModuleMultipleTimesShouldFail(t *testing.T) {
 	if code != 1 {
 		t.Fatalf("Should have failed: %d\n\n%s", code, ui.ErrorWriter.String())
 	}
-	if !strings.HasSuffix(strings.TrimSpace(ui.ErrorWriter.String()), "module \"multi_module\": module repeated multiple times") {
+	if !strings.Contains(ui.ErrorWriter.String(), "module \"multi_module\": module repeated multiple times") {
 		t.Fatalf("Should have failed: %d\n\n'%s'", code, ui.ErrorWriter.String())
 	}
 }
@@ -104,9 +106,12 @@ func TestSameResourceMultipleTimesShouldFail(t *testing.T) {
 	if code != 1 {
 		t.Fatalf("Should have failed: %d\n\n%s", code, ui.ErrorWriter.String())
 	}
-	if !strings.HasSuffix(strings.TrimSpace(ui.ErrorWriter.String()), "aws_instance.web: resource repeated multiple times") {
+	if !strings.Contains(ui.ErrorWriter.String(), "aws_instance.web: resource repeated multiple times") {
 		t.Fatalf("Should have failed: %d\n\n'%s'", code, ui.ErrorWriter.String())
 	}
+	if !strings.Contains(ui.ErrorWriter.String(), `resource "aws_instance" "web"`) {
+		t.Fatalf("error should show the second declaration\n\n'%s'", ui.ErrorWriter.String())
+	}
 }
 
 func TestOutputWithoutValueShouldFail(t *testing.T) {
@@ -114,7 +119,7 @@ func TestOutputWithoutValueShouldFail(t *testing.T) {
 	if code != 1 {
 		t.Fatalf("Should have failed: %d\n\n%s", code, ui.ErrorWriter.String())
 	}
-	if !strings.HasSuffix(strings.TrimSpace(ui.ErrorWriter.String()), "output \"myvalue\": missing required 'value' argument") {
+	if !strings.Contains(ui.ErrorWriter.String(), "output \"myvalue\": missing required 'value' argument") {
 		t.Fatalf("Should have failed: %d\n\n'%s'", code, ui.ErrorWriter.String())
 	}
 }
@@ -164,3 +169,33 @@ func TestMissingDefinedVarConfigOnly(t *testing.T) {
 		t.Fatalf("Should have passed: %d\n\n%s", code, ui.ErrorWriter.String())
 	}
 }
+
+func TestValidateCommand_json(t *testing.T) {
+	ui, code := setupTest("validate-invalid/missing_var", "-json")
+	if code != 1 {
+		t.Fatalf("Should have failed: %d\n\n%s", code, ui.ErrorWriter.String())
+	}
+
+	var output validateOutput
+	if err := json.Unmarshal([]byte(ui.OutputWriter.String()), &output); err != nil {
+		t.Fatalf("bad output: %s\n\n%s", err, ui.OutputWriter.String())
+	}
+	if output.Valid || output.ErrorCount != 1 || len(output.Diagnostics) != 1 {
+		t.Fatalf("bad output: %#v", output)
+	}
+	diag := output.Diagnostics[0]
+	if diag.Severity != "error" || !strings.Contains(diag.Summary, "unknown variable referenced: 'description'") {
+		t.Fatalf("bad diagnostic: %#v", diag)
+	}
+	if diag.Code != tfdiags.CodeConfigInvalid {
+		t.Fatalf("bad code: %q", diag.Code)
+	}
+
+	ui, code = setupTest("validate-valid", "-json")
+	if code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+	}
+	if !strings.Contains(ui.OutputWriter.String(), `"valid": true`) {
+		t.Fatalf("bad output: %s", ui.OutputWriter.String())
+	}
+}
@@ -2,8 +2,11 @@ package command
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
+	"runtime"
 	"sort"
+	"strings"
 )
 
 // VersionCommand is a Command implementation prints the version.
@@ -14,6 +17,10 @@ type VersionCommand struct {
 	Version           string
 	VersionPrerelease string
 	CheckFunc         VersionCheckFunc
+
+	// ReleasesCheckFunc checks for a newer version with the releases API,
+	// when the user asks for it with -check.
+	ReleasesCheckFunc VersionCheckFunc
 }
 
 // VersionCheckFunc is the callback called by the Version command to
@@ -30,7 +37,20 @@ type VersionCheckInfo struct {
 }
 
 func (c *VersionCommand) Help() string {
-	return ""
+	helpText := `
+Usage: terraform version [options]
+
+  Displays the version of Terraform and of the providers selected by
+  "terraform init" for the configuration in the current directory.
+
+Options:
+
+  -check              Check whether a newer version of Terraform has been
+                      released, using the releases API.
+
+  -json               Output the version information as a JSON object.
+`
+	return strings.TrimSpace(helpText)
 }
 
 func (c *VersionCommand) Run(args []string) int {
@@ -40,6 +60,15 @@ func (c *VersionCommand) Run(args []string) int {
 		return 1
 	}
 
+	var jsonOutput, check bool
+	cmdFlags := c.Meta.flagSet("version")
+	cmdFlags.BoolVar(&jsonOutput, "json", false, "json")
+	cmdFlags.BoolVar(&check, "check", false, "check")
+	cmdFlags.Usage = func() { c.Ui.Error(c.Help()) }
+	if err := cmdFlags.Parse(args); err != nil {
+		return 1
+	}
+
 	fmt.Fprintf(&versionString, "Terraform v%s", c.Version)
 	if c.VersionPrerelease != "" {
 		fmt.Fprintf(&versionString, "-%s", c.VersionPrerelease)
@@ -49,8 +78,6 @@ func (c *VersionCommand) Run(args []string) int {
 		}
 	}
 
-	c.Ui.Output(versionString.String())
-
 	// We'll also attempt to print out the selected plugin versions. We can
 	// do this only if "terraform init" was already run and thus we've committed
 	// to a specific set of plugins. If not, the plugins lock will be empty
@@ -59,33 +86,32 @@ func (c *VersionCommand) Run(args []string) int {
 	// Generally-speaking this is a best-effort thing that will give us a good
 	// result in the usual case where the user successfully ran "terraform init"
 	// and then hit a problem running _another_ command.
-	providerPlugins := c.providerPluginSet()
-	pluginsLockFile := c.providerPluginsLock()
-	pluginsLock := pluginsLockFile.Read()
-	var pluginVersions []string
-	for meta := range providerPlugins {
-		name := meta.Name
-		wantHash, wanted := pluginsLock[name]
-		if !wanted {
-			// Ignore providers that aren't used by the current config at all
-			continue
-		}
-		gotHash, err := meta.SHA256()
-		if err != nil {
-			// if we can't read the file to hash it, ignore it.
-			continue
-		}
-		if !bytes.Equal(gotHash, wantHash) {
-			// Not the plugin we've locked, so ignore it.
-			continue
+	selections := c.providerSelections()
+
+	// The releases API is only asked if the user opts in, since it's a
+	// network request that the default checkpoint check already covers
+	// for interactive use.
+	checkFunc := c.CheckFunc
+	if check {
+		checkFunc = c.ReleasesCheckFunc
+		if checkFunc == nil {
+			c.Ui.Error("This build of Terraform can't check for newer versions.")
+			return 1
 		}
+	}
+
+	if jsonOutput {
+		return c.outputJSON(selections, checkFunc, check)
+	}
+
+	c.Ui.Output(versionString.String())
 
-		// If we get here then we've found a selected plugin, so we'll print
-		// out its details.
-		if meta.Version == "0.0.0" {
+	var pluginVersions []string
+	for name, version := range selections {
+		if version == "0.0.0" {
 			pluginVersions = append(pluginVersions, fmt.Sprintf("+ provider.%s (unversioned)", name))
 		} else {
-			pluginVersions = append(pluginVersions, fmt.Sprintf("+ provider.%s v%s", name, meta.Version))
+			pluginVersions = append(pluginVersions, fmt.Sprintf("+ provider.%s v%s", name, version))
 		}
 	}
 	if len(pluginVersions) != 0 {
@@ -97,27 +123,110 @@ func (c *VersionCommand) Run(args []string) int {
 
 	// If we have a version check function, then let's check for
 	// the latest version as well.
-	if c.CheckFunc != nil {
+	if checkFunc != nil {
 		// Separate the prior output with a newline
 		c.Ui.Output("")
 
 		// Check the latest version
-		info, err := c.CheckFunc()
+		info, err := checkFunc()
 		if err != nil {
 			c.Ui.Error(fmt.Sprintf(
 				"Error checking latest version: %s", err))
+			if check {
+				return 1
+			}
 		}
 		if info.Outdated {
 			c.Ui.Output(fmt.Sprintf(
 				"Your version of Terraform is out of date! The latest version\n"+
 					"is %s. You can update by downloading from www.terraform.io/downloads.html",
 				info.Latest))
+		} else if check && err == nil {
+			c.Ui.Output("Your version of Terraform is up to date.")
 		}
 	}
 
 	return 0
 }
 
+// providerSelections returns the versions of the providers that
+// "terraform init" selected for the configuration, by name. Unversioned
+// providers have the version "0.0.0".
+func (c *VersionCommand) providerSelections() map[string]string {
+	providerPlugins := c.providerPluginSet()
+	pluginsLockFile := c.providerPluginsLock()
+	pluginsLock := pluginsLockFile.Read()
+	selections := make(map[string]string)
+	for meta := range providerPlugins {
+		name := meta.Name
+		wantHash, wanted := pluginsLock[name]
+		if !wanted {
+			// Ignore providers that aren't used by the current config at all
+			continue
+		}
+		gotHash, err := meta.SHA256()
+		if err != nil {
+			// if we can't read the file to hash it, ignore it.
+			continue
+		}
+		if !bytes.Equal(gotHash, wantHash) {
+			// Not the plugin we've locked, so ignore it.
+			continue
+		}
+
+		selections[name] = string(meta.Version)
+	}
+	return selections
+}
+
+// outputJSON outputs the version information as a JSON object for wrapper
+// tools to consume. The check for newer versions is only done if it's been
+// asked for, so that the output doesn't wait on the network otherwise.
+func (c *VersionCommand) outputJSON(selections map[string]string, checkFunc VersionCheckFunc, check bool) int {
+	version := c.Version
+	if c.VersionPrerelease != "" {
+		version += "-" + c.VersionPrerelease
+	}
+
+	out := versionJSON{
+		Version:            version,
+		Revision:           c.Revision,
+		Platform:           runtime.GOOS + "_" + runtime.GOARCH,
+		ProviderSelections: selections,
+	}
+
+	ret := 0
+	if check {
+		info, err := checkFunc()
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error checking latest version: %s", err))
+			ret = 1
+		} else {
+			out.Outdated = &info.Outdated
+			out.Latest = info.Latest
+		}
+	}
+
+	js, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to marshal the version information: %s", err))
+		return 1
+	}
+	c.Ui.Output(string(js))
+	return ret
+}
+
+// versionJSON is the output of "terraform version -json". Outdated and
+// Latest are only set if the latest version was checked with -check.
+type versionJSON struct {
+	Version            string            `json:"terraform_version"`
+	Revision           string            `json:"terraform_revision,omitempty"`
+	Platform           string            `json:"platform"`
+	ProviderSelections map[string]string `json:"provider_selections"`
+	Outdated           *bool             `json:"terraform_outdated,omitempty"`
+	Latest             string            `json:"terraform_latest_version,omitempty"`
+}
+
 func (c *VersionCommand) Synopsis() string {
 	return "Prints the Terraform version"
 }
@@ -1,6 +1,10 @@
 package command
 
 import (
+	"encoding/json"
+	"errors"
+	"runtime"
+	"strings"
 	"testing"
 
 	"github.com/mitchellh/cli"
@@ -9,3 +13,98 @@ import (
 func TestVersionCommand_implements(t *testing.T) {
 	var _ cli.Command = &VersionCommand{}
 }
+
+func TestVersion(t *testing.T) {
+	ui := new(cli.MockUi)
+	c := &VersionCommand{
+		Meta:              Meta{Ui: ui},
+		Version:           "4.5.6",
+		VersionPrerelease: "foo",
+		Revision:          "abc123",
+	}
+
+	if code := c.Run([]string{}); code != 0 {
+		t.Fatalf("bad: %d\n%s", code, ui.ErrorWriter.String())
+	}
+	if got, want := ui.OutputWriter.String(), "Terraform v4.5.6-foo (abc123)\n"; got != want {
+		t.Fatalf("wrong output %q; want %q", got, want)
+	}
+}
+
+func TestVersion_json(t *testing.T) {
+	ui := new(cli.MockUi)
+	c := &VersionCommand{
+		Meta:              Meta{Ui: ui},
+		Version:           "4.5.6",
+		VersionPrerelease: "foo",
+		CheckFunc: func() (VersionCheckInfo, error) {
+			t.Error("checked the latest version without -check")
+			return VersionCheckInfo{}, nil
+		},
+	}
+
+	if code := c.Run([]string{"-json"}); code != 0 {
+		t.Fatalf("bad: %d\n%s", code, ui.ErrorWriter.String())
+	}
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(ui.OutputWriter.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON: %s\n%s", err, ui.OutputWriter.String())
+	}
+	if got["terraform_version"] != "4.5.6-foo" {
+		t.Errorf("wrong version %#v", got["terraform_version"])
+	}
+	if got["platform"] != runtime.GOOS+"_"+runtime.GOARCH {
+		t.Errorf("wrong platform %#v", got["platform"])
+	}
+	if _, ok := got["provider_selections"].(map[string]interface{}); !ok {
+		t.Errorf("wrong provider selections %#v", got["provider_selections"])
+	}
+	if _, ok := got["terraform_outdated"]; ok {
+		t.Errorf("outdated without -check: %#v", got["terraform_outdated"])
+	}
+}
+
+func TestVersion_jsonCheck(t *testing.T) {
+	ui := new(cli.MockUi)
+	c := &VersionCommand{
+		Meta:    Meta{Ui: ui},
+		Version: "4.5.6",
+		ReleasesCheckFunc: func() (VersionCheckInfo, error) {
+			return VersionCheckInfo{Outdated: true, Latest: "4.6.0"}, nil
+		},
+	}
+
+	if code := c.Run([]string{"-json", "-check"}); code != 0 {
+		t.Fatalf("bad: %d\n%s", code, ui.ErrorWriter.String())
+	}
+
+	var got versionJSON
+	if err := json.Unmarshal(ui.OutputWriter.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON: %s\n%s", err, ui.OutputWriter.String())
+	}
+	if got.Outdated == nil || !*got.Outdated {
+		t.Errorf("wrong outdated %#v", got.Outdated)
+	}
+	if got.Latest != "4.6.0" {
+		t.Errorf("wrong latest version %q", got.Latest)
+	}
+}
+
+func TestVersion_checkError(t *testing.T) {
+	ui := new(cli.MockUi)
+	c := &VersionCommand{
+		Meta:    Meta{Ui: ui},
+		Version: "4.5.6",
+		ReleasesCheckFunc: func() (VersionCheckInfo, error) {
+			return VersionCheckInfo{}, errors.New("unavailable")
+		},
+	}
+
+	if code := c.Run([]string{"-check"}); code != 1 {
+		t.Fatalf("bad: %d\n%s", code, ui.OutputWriter.String())
+	}
+	if got := ui.ErrorWriter.String(); !strings.Contains(got, "Error checking latest version: unavailable") {
+		t.Fatalf("wrong error:\n%s", got)
+	}
+}
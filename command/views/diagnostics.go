@@ -0,0 +1,136 @@
+package views
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+
+	"github.com/hashicorp/terraform/command/format"
+	"github.com/hashicorp/terraform/tfdiags"
+	"github.com/mitchellh/cli"
+	"github.com/mitchellh/colorstring"
+)
+
+// DiagnosticsView renders diagnostics as text for people to read, so that
+// every command shows them the same way: colored by their severity, with
+// the configuration they're about and wrapped to the width of the terminal.
+type DiagnosticsView struct {
+	ui    cli.Ui
+	color *colorstring.Colorize
+	width int
+
+	// variables are the values of the root module variables that the
+	// command was given, which describe the variables that the expression
+	// of a diagnostic refers to.
+	variables map[string]interface{}
+
+	// sensitive are the names of the variables declared as sensitive,
+	// whose values are never shown.
+	sensitive map[string]bool
+}
+
+// NewDiagnosticsView returns a view that writes diagnostics to ui, wrapped
+// at the given width, or not at all if it's zero. The variables and the
+// names of the sensitive ones may be nil.
+func NewDiagnosticsView(ui cli.Ui, color *colorstring.Colorize, width int, variables map[string]interface{}, sensitive map[string]bool) *DiagnosticsView {
+	return &DiagnosticsView{
+		ui:        ui,
+		color:     color,
+		width:     width,
+		variables: variables,
+		sensitive: sensitive,
+	}
+}
+
+// Diagnostics writes the diagnostics, errors to the error output of the UI
+// and warnings to its warning output. The sources map the name of each
+// source file to its content, for the snippets of configuration.
+func (v *DiagnosticsView) Diagnostics(diags tfdiags.Diagnostics, sources map[string][]byte) {
+	for _, diag := range diags {
+		diag = v.withVariableValues(diag, sources)
+
+		msg := format.SourceDiagnostic(diag, sources, v.color, v.width)
+		switch diag.Severity() {
+		case tfdiags.Error:
+			v.ui.Error(msg)
+		case tfdiags.Warning:
+			v.ui.Warn(msg)
+		default:
+			v.ui.Output(msg)
+		}
+	}
+}
+
+// variableRefRe matches the references to variables in an expression.
+var variableRefRe = regexp.MustCompile(`\bvar\.([A-Za-z_][A-Za-z0-9_-]*)`)
+
+// withVariableValues adds the values of the variables that the highlighted
+// expression of diag refers to, for those the command was given.
+func (v *DiagnosticsView) withVariableValues(diag tfdiags.Diagnostic, sources map[string][]byte) tfdiags.Diagnostic {
+	if len(v.variables) == 0 {
+		return diag
+	}
+	subject := diag.Source().Subject
+	if subject == nil {
+		return diag
+	}
+	src, ok := sources[subject.Filename]
+	if !ok {
+		return diag
+	}
+	snippet := tfdiags.Snippet(diag, src)
+	if snippet == nil {
+		return diag
+	}
+
+	expr := snippet.Code[snippet.HighlightStartOffset:snippet.HighlightEndOffset]
+	var values []tfdiags.ExpressionValue
+	for _, match := range variableRefRe.FindAllStringSubmatch(expr, -1) {
+		val, ok := v.variables[match[1]]
+		if !ok {
+			continue
+		}
+		statement := describeValue(val)
+		if v.sensitive[match[1]] {
+			statement = "has a sensitive value"
+		}
+		values = append(values, tfdiags.ExpressionValue{
+			Traversal: match[0],
+			Statement: statement,
+		})
+	}
+	return tfdiags.WithExpressionValues(diag, values...)
+}
+
+// describeValue returns a statement about a variable value, short enough to
+// fit on a line: strings and other primitive values are shown, while
+// collections are only described by their size.
+func describeValue(val interface{}) string {
+	if val == nil {
+		return "is null"
+	}
+
+	// HCL decodes maps as a list of one map.
+	if ms, ok := val.([]map[string]interface{}); ok && len(ms) == 1 {
+		val = ms[0]
+	}
+
+	rv := reflect.ValueOf(val)
+	switch rv.Kind() {
+	case reflect.String:
+		return fmt.Sprintf("is %q", val)
+	case reflect.Slice, reflect.Array:
+		return fmt.Sprintf("is a list with %s", elements(rv.Len()))
+	case reflect.Map:
+		return fmt.Sprintf("is a map with %s", elements(rv.Len()))
+	default:
+		return fmt.Sprintf("is %v", val)
+	}
+}
+
+func elements(n int) string {
+	if n == 1 {
+		return "1 element"
+	}
+	return fmt.Sprintf("%d elements", n)
+}
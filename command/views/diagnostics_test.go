@@ -0,0 +1,111 @@
+package views
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/hcl2/hcl"
+	"github.com/hashicorp/terraform/tfdiags"
+	"github.com/mitchellh/cli"
+	"github.com/mitchellh/colorstring"
+)
+
+func TestDiagnosticsView(t *testing.T) {
+	src := []byte("resource \"a\" \"b\" {\n  ami   = \"${var.ami}-${var.other}\"\n  zones = \"${var.zones}\"\n}\n")
+	sources := map[string][]byte{"main.tf": src}
+
+	var diags tfdiags.Diagnostics
+	diags = diags.Append(&hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  "Invalid AMI",
+		Subject: &hcl.Range{
+			Filename: "main.tf",
+			Start:    hcl.Pos{Line: 2, Column: 11, Byte: 29},
+			End:      hcl.Pos{Line: 2, Column: 35, Byte: 53},
+		},
+	})
+	diags = diags.Append(&hcl.Diagnostic{
+		Severity: hcl.DiagWarning,
+		Summary:  "Deprecated zones",
+		Subject: &hcl.Range{
+			Filename: "main.tf",
+			Start:    hcl.Pos{Line: 3, Column: 11, Byte: 65},
+			End:      hcl.Pos{Line: 3, Column: 25, Byte: 79},
+		},
+	})
+
+	ui := new(cli.MockUi)
+	color := &colorstring.Colorize{Colors: colorstring.DefaultColors, Disable: true}
+	v := NewDiagnosticsView(ui, color, 78, map[string]interface{}{
+		"ami":   "ubuntu",
+		"zones": []interface{}{"a", "b"},
+	}, nil)
+	v.Diagnostics(diags, sources)
+
+	errOut := ui.ErrorWriter.String()
+	if !strings.Contains(errOut, "Error: Invalid AMI") || !strings.Contains(errOut, "Warning: Deprecated zones") {
+		t.Fatalf("wrong output\n%s", errOut)
+	}
+	if !strings.Contains(errOut, "var.ami is \"ubuntu\"") {
+		t.Fatalf("value of var.ami is missing\n%s", errOut)
+	}
+	if strings.Contains(errOut, "var.other is") {
+		t.Fatalf("unknown variable has a value\n%s", errOut)
+	}
+	if !strings.Contains(errOut, "var.zones is a list with 2 elements") {
+		t.Fatalf("value of var.zones is missing\n%s", errOut)
+	}
+}
+
+func TestDiagnosticsView_sensitive(t *testing.T) {
+	src := []byte("resource \"a\" \"b\" {\n  password = \"${var.password}\"\n}\n")
+	sources := map[string][]byte{"main.tf": src}
+
+	var diags tfdiags.Diagnostics
+	diags = diags.Append(&hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  "Invalid password",
+		Subject: &hcl.Range{
+			Filename: "main.tf",
+			Start:    hcl.Pos{Line: 2, Column: 14, Byte: 32},
+			End:      hcl.Pos{Line: 2, Column: 31, Byte: 49},
+		},
+	})
+
+	ui := new(cli.MockUi)
+	color := &colorstring.Colorize{Colors: colorstring.DefaultColors, Disable: true}
+	v := NewDiagnosticsView(ui, color, 78, map[string]interface{}{
+		"password": "hunter2",
+	}, map[string]bool{"password": true})
+	v.Diagnostics(diags, sources)
+
+	errOut := ui.ErrorWriter.String()
+	if strings.Contains(errOut, "hunter2") {
+		t.Fatalf("sensitive value is shown\n%s", errOut)
+	}
+	if !strings.Contains(errOut, "var.password has a sensitive value") {
+		t.Fatalf("var.password is not described\n%s", errOut)
+	}
+}
+
+func TestDescribeValue(t *testing.T) {
+	tests := map[string]struct {
+		Value interface{}
+		Want  string
+	}{
+		"null":    {nil, "is null"},
+		"string":  {"foo", `is "foo"`},
+		"number":  {5, "is 5"},
+		"list":    {[]interface{}{"a"}, "is a list with 1 element"},
+		"map":     {map[string]interface{}{"a": 1, "b": 2}, "is a map with 2 elements"},
+		"hcl map": {[]map[string]interface{}{{"a": 1}}, "is a map with 1 element"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := describeValue(test.Value); got != test.Want {
+				t.Fatalf("wrong result %q; want %q", got, test.Want)
+			}
+		})
+	}
+}
@@ -0,0 +1,222 @@
+package views
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform/command/format"
+	"github.com/hashicorp/terraform/terraform"
+	"github.com/hashicorp/terraform/tfdiags"
+	"github.com/hashicorp/terraform/version"
+	"github.com/mitchellh/cli"
+)
+
+// JSONUIVersion is the version of the format of the events of JSONView. It
+// follows semantic versioning: fields are only added to events, and new
+// event types added, within a major version.
+const JSONUIVersion = "1.0"
+
+// JSONView is an Operation view that writes a stream of JSON events, one
+// per line, for automation to consume instead of the text that people read.
+//
+// Every event is an object with the fields "@level" ("info", "warn" or
+// "error"), "@message", "@module" (always "terraform.ui"), "@timestamp" and
+// "type", plus the fields of its type.
+type JSONView struct {
+	ui   cli.Ui
+	hook *jsonHook
+
+	// now returns the time of the events, and is replaced by tests.
+	now func() time.Time
+
+	l sync.Mutex
+}
+
+var _ Operation = (*JSONView)(nil)
+
+// NewJSONView returns a view that writes its events to the output of ui, and
+// writes the version event that starts every stream.
+func NewJSONView(ui cli.Ui) *JSONView {
+	v := &JSONView{ui: ui, now: time.Now}
+	v.hook = &jsonHook{view: v, applying: make(map[string]*jsonApplyStart)}
+
+	v.emit("info", fmt.Sprintf("Terraform %s", version.String()), "version", map[string]interface{}{
+		"terraform": version.String(),
+		"ui":        JSONUIVersion,
+	})
+	return v
+}
+
+func (v *JSONView) Hook() terraform.Hook {
+	return v.hook
+}
+
+func (v *JSONView) OperationStart(operation string) {
+	v.emit("info", fmt.Sprintf("Starting %s operation", operation), "operation_start", map[string]interface{}{
+		"operation": operation,
+	})
+}
+
+func (v *JSONView) Log(msg string) {
+	v.emit("info", msg, "log", nil)
+}
+
+func (v *JSONView) Drift(plan *format.Plan) {
+	for _, r := range plan.Drift {
+		action := jsonChangeAction(r.Action)
+		v.emit("info", fmt.Sprintf("%s: Drift detected (%s)", r.Addr, action), "resource_drift", map[string]interface{}{
+			"change": map[string]interface{}{
+				"resource": newJSONResource(r.Addr),
+				"action":   action,
+			},
+		})
+	}
+}
+
+func (v *JSONView) PlannedChanges(plan *format.Plan) {
+	for _, r := range plan.Resources {
+		action := jsonChangeAction(r.Action)
+		v.emit("info", fmt.Sprintf("%s: Plan to %s", r.Addr, action), "planned_change", map[string]interface{}{
+			"change": map[string]interface{}{
+				"resource": newJSONResource(r.Addr),
+				"action":   action,
+			},
+		})
+	}
+
+	stats := plan.Stats()
+	v.ChangeSummary("plan", stats.ToAdd, stats.ToChange, stats.ToDestroy)
+}
+
+func (v *JSONView) ChangeSummary(operation string, add, change, remove int) {
+	var msg string
+	switch operation {
+	case "plan":
+		msg = fmt.Sprintf("Plan: %d to add, %d to change, %d to destroy.", add, change, remove)
+	case "destroy":
+		msg = fmt.Sprintf("Destroy complete! Resources: %d destroyed.", remove)
+	default:
+		msg = fmt.Sprintf("Apply complete! Resources: %d added, %d changed, %d destroyed.", add, change, remove)
+	}
+
+	v.emit("info", msg, "change_summary", map[string]interface{}{
+		"changes": map[string]interface{}{
+			"add":       add,
+			"change":    change,
+			"remove":    remove,
+			"operation": operation,
+		},
+	})
+}
+
+// Diagnostics writes an event for each of the diagnostics, with the
+// snippets of the source files in sources.
+func (v *JSONView) Diagnostics(diags tfdiags.Diagnostics, sources map[string][]byte) {
+	for _, diag := range diags {
+		level := "error"
+		if diag.Severity() == tfdiags.Warning {
+			level = "warn"
+		}
+
+		d := tfdiags.NewJSONDiagnostic(diag, sources)
+		msg := d.Summary
+		if level == "error" {
+			msg = "Error: " + msg
+		} else {
+			msg = "Warning: " + msg
+		}
+		v.emit(level, msg, "diagnostic", map[string]interface{}{
+			"diagnostic": d,
+		})
+	}
+}
+
+// Outputs writes an event with the outputs of the root module of the
+// state. The values of sensitive outputs are left out.
+func (v *JSONView) Outputs(state *terraform.State) {
+	outputs := make(map[string]interface{})
+	if state != nil {
+		if ms := state.RootModule(); ms != nil {
+			for name, o := range ms.Outputs {
+				output := map[string]interface{}{
+					"sensitive": o.Sensitive,
+					"type":      o.Type,
+				}
+				if !o.Sensitive {
+					output["value"] = o.Value
+				}
+				outputs[name] = output
+			}
+		}
+	}
+
+	v.emit("info", fmt.Sprintf("Outputs: %d", len(outputs)), "outputs", map[string]interface{}{
+		"outputs": outputs,
+	})
+}
+
+// emit writes an event of type typ with the given fields.
+func (v *JSONView) emit(level, msg, typ string, fields map[string]interface{}) {
+	event := map[string]interface{}{
+		"@level":     level,
+		"@message":   msg,
+		"@module":    "terraform.ui",
+		"@timestamp": v.now().Format(time.RFC3339Nano),
+		"type":       typ,
+	}
+	for k, val := range fields {
+		event[k] = val
+	}
+
+	j, err := json.Marshal(event)
+	if err != nil {
+		// The fields are all made of values that can be encoded, so this
+		// should never happen.
+		panic(fmt.Sprintf("failed to encode %s event: %s", typ, err))
+	}
+
+	v.l.Lock()
+	defer v.l.Unlock()
+	v.ui.Output(string(j))
+}
+
+// newJSONResource returns the representation of a resource address in
+// events.
+func newJSONResource(addr *terraform.ResourceAddress) map[string]interface{} {
+	var module []string
+	for _, step := range addr.Path {
+		module = append(module, "module."+step)
+	}
+
+	r := map[string]interface{}{
+		"addr":          addr.String(),
+		"module":        strings.Join(module, "."),
+		"resource_type": addr.Type,
+		"resource_name": addr.Name,
+	}
+	if addr.Index >= 0 {
+		r["resource_key"] = addr.Index
+	}
+	return r
+}
+
+// jsonChangeAction returns the name of a change action in events.
+func jsonChangeAction(action terraform.DiffChangeType) string {
+	switch action {
+	case terraform.DiffCreate:
+		return "create"
+	case terraform.DiffUpdate:
+		return "update"
+	case terraform.DiffDestroy:
+		return "delete"
+	case terraform.DiffDestroyCreate:
+		return "replace"
+	case terraform.DiffRefresh:
+		return "read"
+	default:
+		return "noop"
+	}
+}
@@ -0,0 +1,170 @@
+package views
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform/terraform"
+)
+
+// jsonHook is the hook of a JSONView, which writes events for the progress
+// of each resource: its refresh, apply and provisioning.
+type jsonHook struct {
+	terraform.NilHook
+
+	view *JSONView
+
+	l        sync.Mutex
+	applying map[string]*jsonApplyStart
+}
+
+// jsonApplyStart records the start of the apply of a resource, for the
+// event of its completion.
+type jsonApplyStart struct {
+	action string
+	start  time.Time
+}
+
+func (h *jsonHook) PreApply(
+	n *terraform.InstanceInfo,
+	s *terraform.InstanceState,
+	d *terraform.InstanceDiff) (terraform.HookAction, error) {
+	// if there's no diff, there's nothing to report
+	if d.Empty() {
+		return terraform.HookActionContinue, nil
+	}
+
+	action, verb := "update", "Modifying..."
+	if d.Destroy {
+		action, verb = "delete", "Destroying..."
+	} else if s.ID == "" {
+		action, verb = "create", "Creating..."
+	}
+
+	h.l.Lock()
+	h.applying[n.HumanId()] = &jsonApplyStart{action: action, start: h.view.now()}
+	h.l.Unlock()
+
+	addr := n.ResourceAddress()
+	hook := map[string]interface{}{
+		"resource": newJSONResource(addr),
+		"action":   action,
+	}
+	if s.ID != "" {
+		hook["id_value"] = s.ID
+	}
+	h.view.emit("info", fmt.Sprintf("%s: %s", addr, verb), "apply_start", map[string]interface{}{
+		"hook": hook,
+	})
+	return terraform.HookActionContinue, nil
+}
+
+func (h *jsonHook) PostApply(
+	n *terraform.InstanceInfo,
+	s *terraform.InstanceState,
+	applyerr error) (terraform.HookAction, error) {
+	id := n.HumanId()
+	h.l.Lock()
+	started := h.applying[id]
+	delete(h.applying, id)
+	h.l.Unlock()
+	if started == nil {
+		return terraform.HookActionContinue, nil
+	}
+
+	addr := n.ResourceAddress()
+	elapsed := h.view.now().Sub(started.start).Round(time.Second)
+	hook := map[string]interface{}{
+		"resource":        newJSONResource(addr),
+		"action":          started.action,
+		"elapsed_seconds": int(elapsed.Seconds()),
+	}
+
+	if applyerr != nil {
+		h.view.emit("error", fmt.Sprintf("%s: %s errored after %s", addr, started.action, elapsed), "apply_errored", map[string]interface{}{
+			"hook": hook,
+		})
+		return terraform.HookActionContinue, nil
+	}
+
+	if s != nil && s.ID != "" {
+		hook["id_value"] = s.ID
+	}
+	h.view.emit("info", fmt.Sprintf("%s: %s complete after %s", addr, started.action, elapsed), "apply_complete", map[string]interface{}{
+		"hook": hook,
+	})
+	return terraform.HookActionContinue, nil
+}
+
+func (h *jsonHook) PreProvision(n *terraform.InstanceInfo, provId string) (terraform.HookAction, error) {
+	addr := n.ResourceAddress()
+	h.view.emit("info", fmt.Sprintf("%s: Provisioning with '%s'...", addr, provId), "provision_start", map[string]interface{}{
+		"hook": map[string]interface{}{
+			"resource":    newJSONResource(addr),
+			"provisioner": provId,
+		},
+	})
+	return terraform.HookActionContinue, nil
+}
+
+func (h *jsonHook) ProvisionOutput(n *terraform.InstanceInfo, provId string, msg string) {
+	addr := n.ResourceAddress()
+	h.view.emit("info", fmt.Sprintf("%s (%s): %s", addr, provId, msg), "provision_progress", map[string]interface{}{
+		"hook": map[string]interface{}{
+			"resource":    newJSONResource(addr),
+			"provisioner": provId,
+			"output":      msg,
+		},
+	})
+}
+
+func (h *jsonHook) PostProvision(n *terraform.InstanceInfo, provId string, err error) (terraform.HookAction, error) {
+	addr := n.ResourceAddress()
+	hook := map[string]interface{}{
+		"resource":    newJSONResource(addr),
+		"provisioner": provId,
+	}
+	if err != nil {
+		hook["error"] = err.Error()
+		h.view.emit("error", fmt.Sprintf("%s: Provisioning with '%s' errored", addr, provId), "provision_errored", map[string]interface{}{
+			"hook": hook,
+		})
+		return terraform.HookActionContinue, nil
+	}
+
+	h.view.emit("info", fmt.Sprintf("%s: Provisioning with '%s' complete", addr, provId), "provision_complete", map[string]interface{}{
+		"hook": hook,
+	})
+	return terraform.HookActionContinue, nil
+}
+
+func (h *jsonHook) PreRefresh(n *terraform.InstanceInfo, s *terraform.InstanceState) (terraform.HookAction, error) {
+	addr := n.ResourceAddress()
+	hook := map[string]interface{}{
+		"resource": newJSONResource(addr),
+	}
+	// Data resources refresh before they have ids, whereas managed
+	// resources are only refreshed when they have ids.
+	if s != nil && s.ID != "" {
+		hook["id_value"] = s.ID
+	}
+	h.view.emit("info", fmt.Sprintf("%s: Refreshing state...", addr), "refresh_start", map[string]interface{}{
+		"hook": hook,
+	})
+	return terraform.HookActionContinue, nil
+}
+
+func (h *jsonHook) PostRefresh(n *terraform.InstanceInfo, s *terraform.InstanceState) (terraform.HookAction, error) {
+	addr := n.ResourceAddress()
+	hook := map[string]interface{}{
+		"resource": newJSONResource(addr),
+	}
+	if s != nil && s.ID != "" {
+		hook["id_value"] = s.ID
+	}
+	h.view.emit("info", fmt.Sprintf("%s: Refresh complete", addr), "refresh_complete", map[string]interface{}{
+		"hook": hook,
+	})
+	return terraform.HookActionContinue, nil
+}
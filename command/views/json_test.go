@@ -0,0 +1,263 @@
+package views
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform/command/format"
+	"github.com/hashicorp/terraform/terraform"
+	"github.com/hashicorp/terraform/tfdiags"
+	"github.com/mitchellh/cli"
+)
+
+// testTime is the time of the events of the views of testJSONView.
+var testTime = time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// testJSONView returns a view whose clock is stopped at testTime, and a func
+// returning the events it wrote since the last call, without their
+// timestamps.
+func testJSONView(t *testing.T) (*JSONView, func() []map[string]interface{}) {
+	ui := new(cli.MockUi)
+	v := NewJSONView(ui)
+	v.now = func() time.Time { return testTime }
+
+	return v, func() []map[string]interface{} {
+		var events []map[string]interface{}
+		for _, line := range strings.Split(strings.TrimSpace(ui.OutputWriter.String()), "\n") {
+			var event map[string]interface{}
+			if err := json.Unmarshal([]byte(line), &event); err != nil {
+				t.Fatalf("invalid event %q: %s", line, err)
+			}
+			if event["@module"] != "terraform.ui" || event["@timestamp"] == nil {
+				t.Fatalf("bad event: %s", line)
+			}
+			delete(event, "@module")
+			delete(event, "@timestamp")
+			events = append(events, event)
+		}
+		ui.OutputWriter.Reset()
+		return events
+	}
+}
+
+func TestJSONView_version(t *testing.T) {
+	_, events := testJSONView(t)
+
+	got := events()
+	if len(got) != 1 || got[0]["type"] != "version" || got[0]["ui"] != JSONUIVersion || got[0]["terraform"] == "" {
+		t.Fatalf("bad events: %#v", got)
+	}
+}
+
+func TestJSONView_plannedChanges(t *testing.T) {
+	v, events := testJSONView(t)
+	events()
+
+	create, err := terraform.ParseResourceAddress("module.child.test_instance.foo[1]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	replace, err := terraform.ParseResourceAddress("test_instance.bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	drift, err := terraform.ParseResourceAddress("test_instance.baz")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plan := &format.Plan{
+		Resources: []*format.InstanceDiff{
+			{Addr: create, Action: terraform.DiffCreate},
+			{Addr: replace, Action: terraform.DiffDestroyCreate},
+		},
+		Drift: []*format.InstanceDiff{
+			{Addr: drift, Action: terraform.DiffUpdate},
+		},
+	}
+	v.Drift(plan)
+	v.PlannedChanges(plan)
+
+	want := []map[string]interface{}{
+		{
+			"@level":   "info",
+			"@message": "test_instance.baz: Drift detected (update)",
+			"type":     "resource_drift",
+			"change": map[string]interface{}{
+				"action": "update",
+				"resource": map[string]interface{}{
+					"addr":          "test_instance.baz",
+					"module":        "",
+					"resource_type": "test_instance",
+					"resource_name": "baz",
+				},
+			},
+		},
+		{
+			"@level":   "info",
+			"@message": "module.child.test_instance.foo[1]: Plan to create",
+			"type":     "planned_change",
+			"change": map[string]interface{}{
+				"action": "create",
+				"resource": map[string]interface{}{
+					"addr":          "module.child.test_instance.foo[1]",
+					"module":        "module.child",
+					"resource_type": "test_instance",
+					"resource_name": "foo",
+					"resource_key":  float64(1),
+				},
+			},
+		},
+		{
+			"@level":   "info",
+			"@message": "test_instance.bar: Plan to replace",
+			"type":     "planned_change",
+			"change": map[string]interface{}{
+				"action": "replace",
+				"resource": map[string]interface{}{
+					"addr":          "test_instance.bar",
+					"module":        "",
+					"resource_type": "test_instance",
+					"resource_name": "bar",
+				},
+			},
+		},
+		{
+			"@level":   "info",
+			"@message": "Plan: 2 to add, 0 to change, 1 to destroy.",
+			"type":     "change_summary",
+			"changes": map[string]interface{}{
+				"add":       float64(2),
+				"change":    float64(0),
+				"remove":    float64(1),
+				"operation": "plan",
+			},
+		},
+	}
+	if got := events(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("\ngot:  %#v\nwant: %#v", got, want)
+	}
+}
+
+func TestJSONView_diagnostics(t *testing.T) {
+	v, events := testJSONView(t)
+	events()
+
+	var diags tfdiags.Diagnostics
+	diags = diags.Append(tfdiags.SimpleWarning("Deprecated"))
+	diags = diags.Append(errors.New("Broken"))
+	v.Diagnostics(diags, nil)
+
+	got := events()
+	if len(got) != 2 {
+		t.Fatalf("bad events: %#v", got)
+	}
+	if got[0]["@level"] != "warn" || got[0]["@message"] != "Warning: Deprecated" || got[0]["type"] != "diagnostic" {
+		t.Fatalf("bad warning: %#v", got[0])
+	}
+	if d := got[1]["diagnostic"].(map[string]interface{}); got[1]["@level"] != "error" || d["severity"] != "error" || d["summary"] != "Broken" {
+		t.Fatalf("bad error: %#v", got[1])
+	}
+}
+
+func TestJSONView_hook(t *testing.T) {
+	v, events := testJSONView(t)
+	events()
+
+	h := v.Hook()
+	info := &terraform.InstanceInfo{Id: "test_instance.foo", Type: "test_instance"}
+	resource := map[string]interface{}{
+		"addr":          "test_instance.foo",
+		"module":        "",
+		"resource_type": "test_instance",
+		"resource_name": "foo",
+	}
+
+	h.PreRefresh(info, &terraform.InstanceState{ID: "old"})
+	h.PostRefresh(info, &terraform.InstanceState{ID: "old"})
+	h.PreApply(info, &terraform.InstanceState{}, &terraform.InstanceDiff{
+		Attributes: map[string]*terraform.ResourceAttrDiff{
+			"ami": {New: "bar"},
+		},
+	})
+	h.PreProvision(info, "local-exec")
+	h.ProvisionOutput(info, "local-exec", "hello")
+	h.PostProvision(info, "local-exec", nil)
+	v.now = func() time.Time { return testTime.Add(3 * time.Second) }
+	h.PostApply(info, &terraform.InstanceState{ID: "new"}, nil)
+
+	want := []map[string]interface{}{
+		{
+			"@level":   "info",
+			"@message": "test_instance.foo: Refreshing state...",
+			"type":     "refresh_start",
+			"hook":     map[string]interface{}{"resource": resource, "id_value": "old"},
+		},
+		{
+			"@level":   "info",
+			"@message": "test_instance.foo: Refresh complete",
+			"type":     "refresh_complete",
+			"hook":     map[string]interface{}{"resource": resource, "id_value": "old"},
+		},
+		{
+			"@level":   "info",
+			"@message": "test_instance.foo: Creating...",
+			"type":     "apply_start",
+			"hook":     map[string]interface{}{"resource": resource, "action": "create"},
+		},
+		{
+			"@level":   "info",
+			"@message": "test_instance.foo: Provisioning with 'local-exec'...",
+			"type":     "provision_start",
+			"hook":     map[string]interface{}{"resource": resource, "provisioner": "local-exec"},
+		},
+		{
+			"@level":   "info",
+			"@message": "test_instance.foo (local-exec): hello",
+			"type":     "provision_progress",
+			"hook":     map[string]interface{}{"resource": resource, "provisioner": "local-exec", "output": "hello"},
+		},
+		{
+			"@level":   "info",
+			"@message": "test_instance.foo: Provisioning with 'local-exec' complete",
+			"type":     "provision_complete",
+			"hook":     map[string]interface{}{"resource": resource, "provisioner": "local-exec"},
+		},
+		{
+			"@level":   "info",
+			"@message": "test_instance.foo: create complete after 3s",
+			"type":     "apply_complete",
+			"hook": map[string]interface{}{
+				"resource":        resource,
+				"action":          "create",
+				"id_value":        "new",
+				"elapsed_seconds": float64(3),
+			},
+		},
+	}
+	if got := events(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("\ngot:  %#v\nwant: %#v", got, want)
+	}
+
+	// The apply of a resource that failed is reported as an error.
+	h.PreApply(info, &terraform.InstanceState{ID: "new"}, &terraform.InstanceDiff{Destroy: true})
+	h.PostApply(info, nil, errors.New("failed"))
+	got := events()
+	if len(got) != 2 || got[1]["type"] != "apply_errored" || got[1]["@level"] != "error" {
+		t.Fatalf("bad events: %#v", got)
+	}
+
+	// A provisioner that failed is reported as an error, with the reason.
+	h.PostProvision(info, "local-exec", errors.New("exit status 1"))
+	got = events()
+	if len(got) != 1 || got[0]["type"] != "provision_errored" || got[0]["@level"] != "error" {
+		t.Fatalf("bad events: %#v", got)
+	}
+	if hook := got[0]["hook"].(map[string]interface{}); hook["error"] != "exit status 1" {
+		t.Fatalf("bad hook: %#v", hook)
+	}
+}
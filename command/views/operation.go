@@ -0,0 +1,37 @@
+// Package views contains the views that commands present the progress and
+// results of operations with, other than the text the backends render for
+// people to read.
+package views
+
+import (
+	"github.com/hashicorp/terraform/command/format"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+// Operation is a view of a plan or apply operation. Backends that are given
+// an Operation view report the operation through it instead of rendering
+// their usual text output.
+type Operation interface {
+	// Hook returns the hook that reports the progress of each resource.
+	Hook() terraform.Hook
+
+	// OperationStart reports the start of the operation, which is "plan",
+	// "apply" or "destroy".
+	OperationStart(operation string)
+
+	// Log reports a message about the operation that isn't any of the
+	// other events.
+	Log(msg string)
+
+	// Drift reports the changes made outside of Terraform that the refresh
+	// before the plan detected.
+	Drift(plan *format.Plan)
+
+	// PlannedChanges reports the changes of the plan, followed by their
+	// summary.
+	PlannedChanges(plan *format.Plan)
+
+	// ChangeSummary reports the numbers of resources that the operation
+	// added, changed and removed once it's complete.
+	ChangeSummary(operation string, add, change, remove int)
+}
@@ -0,0 +1,81 @@
+// Package webbrowser opens URLs in a web browser, for the commands that need
+// the user to interact with a web page, such as "terraform login".
+package webbrowser
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"runtime"
+
+	cleanhttp "github.com/hashicorp/go-cleanhttp"
+)
+
+// Launcher opens URLs in a web browser.
+type Launcher interface {
+	// OpenURL opens the given URL in a web browser. An error means that no
+	// browser could be opened, in which case the caller should ask the user
+	// to open the URL by hand.
+	OpenURL(url string) error
+}
+
+// NewNativeLauncher returns a Launcher that opens URLs in the default web
+// browser of the user, with the usual program of the operating system.
+func NewNativeLauncher() Launcher {
+	return nativeLauncher{}
+}
+
+type nativeLauncher struct{}
+
+func (l nativeLauncher) OpenURL(url string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+
+	// The program only hands the URL over to the browser, so it should
+	// exit promptly.
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to run %s: %s", cmd.Path, err)
+	}
+	return nil
+}
+
+// NewMockLauncher returns a Launcher that stands in for a web browser in
+// tests. It requests the URL in the background, following redirects as a
+// browser would, but doesn't otherwise interact with the page. Requests are
+// cancelled when the given context is.
+func NewMockLauncher(ctx context.Context) Launcher {
+	return &mockLauncher{
+		ctx:    ctx,
+		client: cleanhttp.DefaultClient(),
+	}
+}
+
+type mockLauncher struct {
+	ctx    context.Context
+	client *http.Client
+}
+
+func (l *mockLauncher) OpenURL(url string) error {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(l.ctx)
+
+	go func() {
+		resp, err := l.client.Do(req)
+		if err != nil {
+			return
+		}
+		resp.Body.Close()
+	}()
+	return nil
+}
@@ -0,0 +1,77 @@
+// Package workdir models the working directory that Terraform commands run
+// in, which is the current directory of the process once the -chdir option
+// has been applied.
+package workdir
+
+import (
+	"path/filepath"
+)
+
+// DefaultDataDir is the default name of the data directory, relative to the
+// working directory.
+const DefaultDataDir = ".terraform"
+
+// Dir is the working directory of Terraform commands: the directory of the
+// root module, the data directory where "terraform init" keeps the modules,
+// plugins and backend configuration of the root module, and the plugin and
+// module caches shared by all working directories.
+//
+// The zero value isn't valid; use NewDir.
+type Dir struct {
+	// mainDir is the directory of the root module.
+	mainDir string
+
+	dataDir        string
+	pluginCacheDir string
+	moduleCacheDir string
+}
+
+// NewDir returns the working directory at mainPath, with the default data
+// directory and no plugin or module cache. mainPath is usually ".", since commands
+// change the directory of the process to the working directory.
+func NewDir(mainPath string) *Dir {
+	mainPath = filepath.Clean(mainPath)
+	return &Dir{
+		mainDir: mainPath,
+		dataDir: filepath.Join(mainPath, DefaultDataDir),
+	}
+}
+
+// OverrideDataDir changes the data directory, for when the default isn't
+// suitable, e.g. because the working directory is read-only. A relative
+// path is relative to the working directory.
+func (d *Dir) OverrideDataDir(dataDir string) {
+	if !filepath.IsAbs(dataDir) {
+		dataDir = filepath.Join(d.mainDir, dataDir)
+	}
+	d.dataDir = dataDir
+}
+
+// SetPluginCacheDir enables the plugin cache in the given directory, or
+// disables it if the path is empty.
+func (d *Dir) SetPluginCacheDir(pluginCacheDir string) {
+	d.pluginCacheDir = pluginCacheDir
+}
+
+// SetModuleCacheDir enables the module cache in the given directory, or
+// disables it if the path is empty.
+func (d *Dir) SetModuleCacheDir(moduleCacheDir string) {
+	d.moduleCacheDir = moduleCacheDir
+}
+
+// DataDir returns the data directory.
+func (d *Dir) DataDir() string {
+	return d.dataDir
+}
+
+// PluginCacheDir returns the directory of the plugin cache, or an empty
+// string if the cache is disabled.
+func (d *Dir) PluginCacheDir() string {
+	return d.pluginCacheDir
+}
+
+// ModuleCacheDir returns the directory of the module cache, or an empty
+// string if the cache is disabled.
+func (d *Dir) ModuleCacheDir() string {
+	return d.moduleCacheDir
+}
@@ -0,0 +1,50 @@
+package workdir
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestDir(t *testing.T) {
+	d := NewDir(".")
+	if got := d.DataDir(); got != DefaultDataDir {
+		t.Fatalf("wrong data dir %q", got)
+	}
+	if got := d.PluginCacheDir(); got != "" {
+		t.Fatalf("plugin cache should be disabled, got %q", got)
+	}
+	if got := d.ModuleCacheDir(); got != "" {
+		t.Fatalf("module cache should be disabled, got %q", got)
+	}
+
+	d.OverrideDataDir("data")
+	d.SetPluginCacheDir("/cache")
+	d.SetModuleCacheDir("/modules")
+	if got := d.DataDir(); got != "data" {
+		t.Fatalf("wrong data dir %q", got)
+	}
+	if got := d.PluginCacheDir(); got != "/cache" {
+		t.Fatalf("wrong plugin cache dir %q", got)
+	}
+	if got := d.ModuleCacheDir(); got != "/modules" {
+		t.Fatalf("wrong module cache dir %q", got)
+	}
+}
+
+func TestDir_subdirectory(t *testing.T) {
+	d := NewDir("infra/")
+	if got, want := d.DataDir(), filepath.Join("infra", DefaultDataDir); got != want {
+		t.Fatalf("wrong data dir %q; want %q", got, want)
+	}
+
+	// Relative data directories are relative to the working directory.
+	d.OverrideDataDir("data")
+	if got, want := d.DataDir(), filepath.Join("infra", "data"); got != want {
+		t.Fatalf("wrong data dir %q; want %q", got, want)
+	}
+
+	d.OverrideDataDir("/var/lib/terraform")
+	if got := d.DataDir(); got != "/var/lib/terraform" {
+		t.Fatalf("wrong data dir %q", got)
+	}
+}
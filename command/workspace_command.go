@@ -1,9 +1,15 @@
 package command
 
 import (
+	"context"
+	"fmt"
 	"net/url"
 	"strings"
 
+	"github.com/hashicorp/terraform/backend"
+	"github.com/hashicorp/terraform/command/clistate"
+	"github.com/hashicorp/terraform/state"
+	"github.com/hashicorp/terraform/terraform"
 	"github.com/mitchellh/cli"
 )
 
@@ -42,6 +48,7 @@ Subcommands:
     list      List workspaces.
     select    Select a workspace.
     new       Create a new workspace.
+    update    Update the description and protection of a workspace.
     delete    Delete an existing workspace.
 `
 	return strings.TrimSpace(helpText)
@@ -58,6 +65,75 @@ func validWorkspaceName(name string) bool {
 	return name == url.PathEscape(name)
 }
 
+// workspaceMetadata returns the metadata of the named workspace, which is
+// stored with its state, or nil if the workspace has none.
+func workspaceMetadata(b backend.Backend, name string) (*terraform.WorkspaceMetadata, error) {
+	sMgr, err := b.State(name)
+	if err != nil {
+		return nil, err
+	}
+	if err := sMgr.RefreshState(); err != nil {
+		return nil, err
+	}
+
+	if s := sMgr.State(); s != nil {
+		return s.Workspace, nil
+	}
+	return nil, nil
+}
+
+// updateWorkspaceMetadata changes the metadata of the named workspace with
+// fn and stores it with the state of the workspace, holding a lock on the
+// state while doing so if locking is enabled.
+func (m *Meta) updateWorkspaceMetadata(
+	b backend.Backend,
+	name, operation string,
+	fn func(*terraform.WorkspaceMetadata)) error {
+	sMgr, err := b.State(name)
+	if err != nil {
+		return err
+	}
+
+	if m.stateLock {
+		lockCtx, cancel := context.WithTimeout(context.Background(), m.stateLockTimeout)
+		defer cancel()
+
+		lockInfo := state.NewLockInfo()
+		lockInfo.Operation = operation
+		lockID, err := clistate.Lock(lockCtx, sMgr, lockInfo, m.Ui, m.Colorize())
+		if err != nil {
+			return fmt.Errorf("Error locking state: %s", err)
+		}
+		defer clistate.Unlock(sMgr, lockID, m.Ui, m.Colorize())
+	}
+
+	if err := sMgr.RefreshState(); err != nil {
+		return err
+	}
+
+	s := sMgr.State()
+	if s == nil {
+		s = terraform.NewState()
+	} else {
+		s = s.DeepCopy()
+	}
+
+	meta := &terraform.WorkspaceMetadata{}
+	if s.Workspace != nil {
+		*meta = *s.Workspace
+	}
+	fn(meta)
+	if meta.Empty() {
+		meta = nil
+	}
+	s.Workspace = meta
+
+	if err := sMgr.WriteState(s); err != nil {
+		return err
+	}
+	return sMgr.PersistState()
+}
+
 func envCommandShowWarning(ui cli.Ui, show bool) {
 	if !show {
 		return
@@ -111,6 +187,18 @@ but are no longer manageable by Terraform since the state has
 been deleted.
 `
 
+	envProtected = `
+Workspace %[1]q is protected.
+
+Protected workspaces can't be deleted, and their resources can't be
+destroyed, unless the protection is overridden with the
+'-override-protection' flag. To remove the protection, run:
+
+    terraform workspace update -protected=false %[1]s
+`
+
+	envUpdated = `[reset][green]Updated workspace %q.`
+
 	envDelCurrent = `
 Workspace %[1]q is your active workspace.
 
@@ -1,9 +1,11 @@
 package command
 
 import (
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -211,6 +213,110 @@ func TestWorkspace_createInvalid(t *testing.T) {
 	}
 }
 
+func TestWorkspace_listDetailed(t *testing.T) {
+	// Create a temporary working directory that is empty
+	td := tempDir(t)
+	os.MkdirAll(td, 0755)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	ui := new(cli.MockUi)
+	newCmd := &WorkspaceNewCommand{
+		Meta: Meta{Ui: ui},
+	}
+	if code := newCmd.Run([]string{"test_a"}); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter)
+	}
+
+	st := terraform.NewState()
+	st.Serial = 3
+	f, err := os.Create(filepath.Join(local.DefaultWorkspaceDir, "test_a", DefaultStateFilename))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := terraform.WriteState(st, f); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	listCmd := &WorkspaceListCommand{}
+	ui = new(cli.MockUi)
+	listCmd.Meta = Meta{Ui: ui}
+
+	if code := listCmd.Run([]string{"-detailed"}); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter)
+	}
+
+	lines := strings.Split(strings.TrimSpace(ui.OutputWriter.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("bad: %q", lines)
+	}
+	if fields := strings.Fields(lines[0]); strings.Join(fields, " ") != "NAME SERIAL SIZE LAST MODIFIED" {
+		t.Fatalf("bad header: %q", lines[0])
+	}
+
+	// The default workspace has no state yet.
+	if fields := strings.Fields(lines[1]); strings.Join(fields, " ") != "default 0 0 -" {
+		t.Fatalf("bad default workspace: %q", lines[1])
+	}
+
+	fields := strings.Fields(lines[2])
+	if len(fields) != 5 || fields[0] != "*" || fields[1] != "test_a" || fields[2] != "3" || fields[3] == "0" || fields[4] == "-" {
+		t.Fatalf("bad workspace: %q", lines[2])
+	}
+}
+
+func TestWorkspace_listDetailedUnknown(t *testing.T) {
+	td := tempDir(t)
+	copy.CopyDir(testFixturePath("inmem-backend"), td)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+	defer inmem.Reset()
+
+	// init the backend
+	ui := new(cli.MockUi)
+	initCmd := &InitCommand{
+		Meta: Meta{Ui: ui},
+	}
+	if code := initCmd.Run([]string{}); code != 0 {
+		t.Fatalf("bad: \n%s", ui.ErrorWriter.String())
+	}
+
+	// The inmem backend can't describe its workspaces, so only the serial
+	// read from the state is known.
+	b := backend.TestBackendConfig(t, inmem.New(), nil)
+	sMgr, err := b.State("test_a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sMgr.WriteState(terraform.NewState()); err != nil {
+		t.Fatal(err)
+	}
+	if err := sMgr.PersistState(); err != nil {
+		t.Fatal(err)
+	}
+
+	listCmd := &WorkspaceListCommand{}
+	ui = new(cli.MockUi)
+	listCmd.Meta = Meta{Ui: ui}
+
+	if code := listCmd.Run([]string{"-detailed"}); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter)
+	}
+
+	lines := strings.Split(strings.TrimSpace(ui.OutputWriter.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("bad: %q", lines)
+	}
+	if fields := strings.Fields(lines[1]); strings.Join(fields, " ") != "* default 0 0 -" {
+		t.Fatalf("bad default workspace: %q", lines[1])
+	}
+	expected := fmt.Sprintf("test_a %d - -", sMgr.State().Serial)
+	if fields := strings.Fields(lines[2]); strings.Join(fields, " ") != expected {
+		t.Fatalf("bad workspace: %q", lines[2])
+	}
+}
+
 func TestWorkspace_createWithState(t *testing.T) {
 	td := tempDir(t)
 	copy.CopyDir(testFixturePath("inmem-backend"), td)
@@ -388,3 +494,147 @@ func TestWorkspace_deleteWithState(t *testing.T) {
 		t.Fatal("env 'test' still exists!")
 	}
 }
+
+func TestWorkspace_metadata(t *testing.T) {
+	td := tempDir(t)
+	os.MkdirAll(td, 0755)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	ui := new(cli.MockUi)
+	newCmd := &WorkspaceNewCommand{
+		Meta: Meta{Ui: ui},
+	}
+	args := []string{"-description=Production environment", "-protected", "prod"}
+	if code := newCmd.Run(args); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter)
+	}
+
+	ui = new(cli.MockUi)
+	newCmd = &WorkspaceNewCommand{
+		Meta: Meta{Ui: ui},
+	}
+	if code := newCmd.Run([]string{"dev"}); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter)
+	}
+
+	ui = new(cli.MockUi)
+	listCmd := &WorkspaceListCommand{
+		Meta: Meta{Ui: ui},
+	}
+	if code := listCmd.Run(nil); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter)
+	}
+	expected := "  default\n* dev\n  prod     (protected)  Production environment"
+	if actual := strings.TrimSpace(ui.OutputWriter.String()); actual != strings.TrimSpace(expected) {
+		t.Fatalf("\nexpected: %q\nactual:   %q", expected, actual)
+	}
+
+	ui = new(cli.MockUi)
+	listCmd = &WorkspaceListCommand{
+		Meta: Meta{Ui: ui},
+	}
+	if code := listCmd.Run([]string{"-detailed"}); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter)
+	}
+	lines := strings.Split(strings.TrimSpace(ui.OutputWriter.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("bad: %q", lines)
+	}
+	if fields := strings.Fields(lines[0]); strings.Join(fields, " ") != "NAME SERIAL SIZE LAST MODIFIED PROTECTED DESCRIPTION" {
+		t.Fatalf("bad header: %q", lines[0])
+	}
+	if fields := strings.Fields(lines[2]); fields[len(fields)-2] != "no" || fields[len(fields)-1] != "-" {
+		t.Fatalf("bad dev workspace: %q", lines[2])
+	}
+	if fields := strings.Fields(lines[3]); strings.Join(fields[len(fields)-3:], " ") != "yes Production environment" {
+		t.Fatalf("bad prod workspace: %q", lines[3])
+	}
+
+	// A protected workspace can only be deleted by overriding the protection.
+	ui = new(cli.MockUi)
+	delCmd := &WorkspaceDeleteCommand{
+		Meta: Meta{Ui: ui},
+	}
+	if code := delCmd.Run([]string{"prod"}); code == 0 {
+		t.Fatalf("expected failure for a protected workspace.\noutput: %s", ui.OutputWriter)
+	}
+	if !strings.Contains(ui.ErrorWriter.String(), `Workspace "prod" is protected`) {
+		t.Fatalf("bad error: %s", ui.ErrorWriter)
+	}
+
+	ui = new(cli.MockUi)
+	delCmd = &WorkspaceDeleteCommand{
+		Meta: Meta{Ui: ui},
+	}
+	if code := delCmd.Run([]string{"-override-protection", "prod"}); code != 0 {
+		t.Fatalf("failure: %s", ui.ErrorWriter)
+	}
+	if _, err := os.Stat(filepath.Join(local.DefaultWorkspaceDir, "prod")); !os.IsNotExist(err) {
+		t.Fatal("workspace 'prod' still exists!")
+	}
+}
+
+func TestWorkspace_update(t *testing.T) {
+	td := tempDir(t)
+	os.MkdirAll(td, 0755)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	ui := new(cli.MockUi)
+	newCmd := &WorkspaceNewCommand{
+		Meta: Meta{Ui: ui},
+	}
+	if code := newCmd.Run([]string{"-description=Old", "test"}); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter)
+	}
+
+	readMetadata := func() *terraform.WorkspaceMetadata {
+		statePath := filepath.Join(local.DefaultWorkspaceDir, "test", DefaultStateFilename)
+		s := &state.LocalState{Path: statePath}
+		if err := s.RefreshState(); err != nil {
+			t.Fatal(err)
+		}
+		return s.State().Workspace
+	}
+
+	// Only the given flags are changed.
+	ui = new(cli.MockUi)
+	updateCmd := &WorkspaceUpdateCommand{
+		Meta: Meta{Ui: ui},
+	}
+	if code := updateCmd.Run([]string{"-protected", "test"}); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter)
+	}
+	expected := &terraform.WorkspaceMetadata{Description: "Old", Protected: true}
+	if actual := readMetadata(); !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("\nexpected: %#v\nactual:   %#v", expected, actual)
+	}
+
+	ui = new(cli.MockUi)
+	updateCmd = &WorkspaceUpdateCommand{
+		Meta: Meta{Ui: ui},
+	}
+	if code := updateCmd.Run([]string{"-description=", "-protected=false", "test"}); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter)
+	}
+	if actual := readMetadata(); actual != nil {
+		t.Fatalf("expected no metadata, got %#v", actual)
+	}
+
+	ui = new(cli.MockUi)
+	updateCmd = &WorkspaceUpdateCommand{
+		Meta: Meta{Ui: ui},
+	}
+	if code := updateCmd.Run([]string{"-protected", "missing"}); code == 0 {
+		t.Fatal("expected failure for a workspace that doesn't exist")
+	}
+
+	ui = new(cli.MockUi)
+	updateCmd = &WorkspaceUpdateCommand{
+		Meta: Meta{Ui: ui},
+	}
+	if code := updateCmd.Run([]string{"test"}); code == 0 {
+		t.Fatal("expected failure without any flags")
+	}
+}
@@ -25,8 +25,10 @@ func (c *WorkspaceDeleteCommand) Run(args []string) int {
 	envCommandShowWarning(c.Ui, c.LegacyName)
 
 	force := false
+	overrideProtection := false
 	cmdFlags := c.Meta.flagSet("workspace")
 	cmdFlags.BoolVar(&force, "force", false, "force removal of a non-empty workspace")
+	cmdFlags.BoolVar(&overrideProtection, "override-protection", false, "override-protection")
 	cmdFlags.Usage = func() { c.Ui.Error(c.Help()) }
 	if err := cmdFlags.Parse(args); err != nil {
 		return 1
@@ -102,6 +104,11 @@ func (c *WorkspaceDeleteCommand) Run(args []string) int {
 		return 1
 	}
 
+	if s := sMgr.State(); s != nil && s.Workspace != nil && s.Workspace.Protected && !overrideProtection {
+		c.Ui.Error(fmt.Sprintf(strings.TrimSpace(envProtected), delEnv))
+		return 1
+	}
+
 	hasResources := sMgr.State().HasResources()
 
 	if hasResources && !force {
@@ -168,7 +175,8 @@ func (c *WorkspaceDeleteCommand) AutocompleteArgs() complete.Predictor {
 
 func (c *WorkspaceDeleteCommand) AutocompleteFlags() complete.Flags {
 	return complete.Flags{
-		"-force": complete.PredictNothing,
+		"-force":               complete.PredictNothing,
+		"-override-protection": complete.PredictNothing,
 	}
 }
 
@@ -181,7 +189,9 @@ Usage: terraform workspace delete [OPTIONS] NAME [DIR]
 
 Options:
 
-    -force    remove a non-empty workspace.
+    -force                  remove a non-empty workspace.
+
+    -override-protection    remove a protected workspace.
 `
 	return strings.TrimSpace(helpText)
 }
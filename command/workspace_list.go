@@ -3,9 +3,14 @@ package command
 import (
 	"bytes"
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/hashicorp/terraform/backend"
+	"github.com/hashicorp/terraform/terraform"
 	"github.com/posener/complete"
+	"github.com/ryanuber/columnize"
 )
 
 type WorkspaceListCommand struct {
@@ -21,7 +26,9 @@ func (c *WorkspaceListCommand) Run(args []string) int {
 
 	envCommandShowWarning(c.Ui, c.LegacyName)
 
+	var detailed bool
 	cmdFlags := c.Meta.flagSet("workspace list")
+	cmdFlags.BoolVar(&detailed, "detailed", false, "detailed")
 	cmdFlags.Usage = func() { c.Ui.Error(c.Help()) }
 	if err := cmdFlags.Parse(args); err != nil {
 		return 1
@@ -50,13 +57,53 @@ func (c *WorkspaceListCommand) Run(args []string) int {
 		return 1
 	}
 
+	env, isOverridden := c.WorkspaceOverridden()
+
+	if detailed {
+		infos, err := backend.ListWorkspacesWithInfo(b)
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+
+		var names []string
+		for _, info := range infos {
+			names = append(names, info.Name)
+		}
+		metas, err := workspaceMetadatas(b, names)
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+
+		c.Ui.Output(formatWorkspaceInfos(infos, env, metas))
+		if isOverridden {
+			c.Ui.Output(envIsOverriddenNote)
+		}
+		return 0
+	}
+
 	states, err := b.States()
 	if err != nil {
 		c.Ui.Error(err.Error())
 		return 1
 	}
 
-	env, isOverridden := c.WorkspaceOverridden()
+	metas, err := workspaceMetadatas(b, states)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
+	// Names are padded to line up the metadata, if any workspace has it.
+	width := 0
+	if len(metas) > 0 {
+		for _, s := range states {
+			if len(s) > width {
+				width = len(s)
+			}
+		}
+	}
 
 	var out bytes.Buffer
 	for _, s := range states {
@@ -65,7 +112,20 @@ func (c *WorkspaceListCommand) Run(args []string) int {
 		} else {
 			out.WriteString("  ")
 		}
-		out.WriteString(s + "\n")
+
+		meta := metas[s]
+		if meta == nil {
+			out.WriteString(s + "\n")
+			continue
+		}
+		line := fmt.Sprintf("%-*s", width, s)
+		if meta.Protected {
+			line += "  (protected)"
+		}
+		if meta.Description != "" {
+			line += "  " + meta.Description
+		}
+		out.WriteString(line + "\n")
 	}
 
 	c.Ui.Output(out.String())
@@ -77,19 +137,96 @@ func (c *WorkspaceListCommand) Run(args []string) int {
 	return 0
 }
 
+// workspaceMetadatas returns the metadata of the named workspaces that
+// have any, by name.
+func workspaceMetadatas(b backend.Backend, names []string) (map[string]*terraform.WorkspaceMetadata, error) {
+	metas := make(map[string]*terraform.WorkspaceMetadata)
+	for _, name := range names {
+		meta, err := workspaceMetadata(b, name)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read the state of workspace %q: %s", name, err)
+		}
+		if !meta.Empty() {
+			metas[name] = meta
+		}
+	}
+	return metas, nil
+}
+
+// formatWorkspaceInfos formats the workspaces as a table, marking the
+// current one. Anything the backend doesn't know is shown as "-". The
+// protection and description of the workspaces are shown if any workspace
+// has metadata in metas.
+func formatWorkspaceInfos(
+	infos []*backend.WorkspaceInfo,
+	current string,
+	metas map[string]*terraform.WorkspaceMetadata) string {
+	header := "  | NAME | SERIAL | SIZE | LAST MODIFIED"
+	if len(metas) > 0 {
+		header += " | PROTECTED | DESCRIPTION"
+	}
+	lines := []string{header}
+	for _, info := range infos {
+		mark := " "
+		if info.Name == current {
+			mark = "*"
+		}
+
+		serial, size, modified := "-", "-", "-"
+		if info.Serial >= 0 {
+			serial = strconv.FormatInt(info.Serial, 10)
+		}
+		if info.Size >= 0 {
+			size = strconv.FormatInt(info.Size, 10)
+		}
+		if !info.LastModified.IsZero() {
+			modified = info.LastModified.UTC().Format(time.RFC3339)
+		}
+
+		line := fmt.Sprintf("%s | %s | %s | %s | %s",
+			mark, info.Name, serial, size, modified)
+		if len(metas) > 0 {
+			protected, description := "no", "-"
+			if meta := metas[info.Name]; meta != nil {
+				if meta.Protected {
+					protected = "yes"
+				}
+				if meta.Description != "" {
+					description = meta.Description
+				}
+			}
+			line += fmt.Sprintf(" | %s | %s", protected, description)
+		}
+		lines = append(lines, line)
+	}
+
+	config := columnize.DefaultConfig()
+	config.Glue = " "
+	return columnize.Format(lines, config)
+}
+
 func (c *WorkspaceListCommand) AutocompleteArgs() complete.Predictor {
 	return complete.PredictDirs("")
 }
 
 func (c *WorkspaceListCommand) AutocompleteFlags() complete.Flags {
-	return nil
+	return complete.Flags{
+		"-detailed": complete.PredictNothing,
+	}
 }
 
 func (c *WorkspaceListCommand) Help() string {
 	helpText := `
-Usage: terraform workspace list [DIR]
+Usage: terraform workspace list [options] [DIR]
+
+  List Terraform workspaces, with the protection and description of those
+  that have them.
+
+Options:
 
-  List Terraform workspaces.
+  -detailed    Also show the serial, size in bytes and last modification
+               time of the state of each workspace, when the backend
+               provides them.
 `
 	return strings.TrimSpace(helpText)
 }
@@ -27,9 +27,12 @@ func (c *WorkspaceNewCommand) Run(args []string) int {
 	envCommandShowWarning(c.Ui, c.LegacyName)
 
 	statePath := ""
+	meta := &terraform.WorkspaceMetadata{}
 
 	cmdFlags := c.Meta.flagSet("workspace new")
 	cmdFlags.StringVar(&statePath, "state", "", "terraform state file")
+	cmdFlags.StringVar(&meta.Description, "description", "", "description")
+	cmdFlags.BoolVar(&meta.Protected, "protected", false, "protected")
 	cmdFlags.Usage = func() { c.Ui.Error(c.Help()) }
 	if err := cmdFlags.Parse(args); err != nil {
 		return 1
@@ -103,7 +106,17 @@ func (c *WorkspaceNewCommand) Run(args []string) int {
 		strings.TrimSpace(envCreated), newEnv)))
 
 	if statePath == "" {
-		// if we're not loading a state, then we're done
+		// if we're not loading a state, then we're done once the metadata
+		// is stored
+		if !meta.Empty() {
+			err := c.updateWorkspaceMetadata(b, newEnv, "workspace new", func(m *terraform.WorkspaceMetadata) {
+				*m = *meta
+			})
+			if err != nil {
+				c.Ui.Error(fmt.Sprintf("Error storing the workspace metadata: %s", err))
+				return 1
+			}
+		}
 		return 0
 	}
 
@@ -142,6 +155,13 @@ func (c *WorkspaceNewCommand) Run(args []string) int {
 		return 1
 	}
 
+	// The metadata of the workspace the state was copied from doesn't
+	// carry over to the new one.
+	s.Workspace = nil
+	if !meta.Empty() {
+		s.Workspace = meta
+	}
+
 	// save the existing state in the new Backend.
 	err = sMgr.WriteState(s)
 	if err != nil {
@@ -167,7 +187,9 @@ func (c *WorkspaceNewCommand) AutocompleteArgs() complete.Predictor {
 
 func (c *WorkspaceNewCommand) AutocompleteFlags() complete.Flags {
 	return complete.Flags{
-		"-state": complete.PredictFiles("*.tfstate"),
+		"-description": complete.PredictAnything,
+		"-protected":   complete.PredictNothing,
+		"-state":       complete.PredictFiles("*.tfstate"),
 	}
 }
 
@@ -180,7 +202,14 @@ Usage: terraform workspace new [OPTIONS] NAME [DIR]
 
 Options:
 
-    -state=path    Copy an existing state file into the new workspace.
+    -description=text    A description of the workspace, shown when listing
+                         workspaces.
+
+    -protected           Protect the workspace from being deleted, and its
+                         resources from being destroyed, unless the
+                         protection is overridden.
+
+    -state=path          Copy an existing state file into the new workspace.
 `
 	return strings.TrimSpace(helpText)
 }
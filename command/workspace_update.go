@@ -0,0 +1,151 @@
+package command
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform/terraform"
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+)
+
+// WorkspaceUpdateCommand is a Command implementation that changes the
+// description and protection of an existing workspace.
+type WorkspaceUpdateCommand struct {
+	Meta
+}
+
+func (c *WorkspaceUpdateCommand) Run(args []string) int {
+	args, err := c.Meta.process(args, true)
+	if err != nil {
+		return 1
+	}
+
+	var description string
+	var protected bool
+	cmdFlags := c.Meta.flagSet("workspace update")
+	cmdFlags.StringVar(&description, "description", "", "description")
+	cmdFlags.BoolVar(&protected, "protected", false, "protected")
+	cmdFlags.Usage = func() { c.Ui.Error(c.Help()) }
+	if err := cmdFlags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Only the flags that are given are changed, so that the description
+	// can be changed without changing the protection and vice versa.
+	set := make(map[string]bool)
+	cmdFlags.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	if !set["description"] && !set["protected"] {
+		c.Ui.Error("At least one of -description and -protected must be given.\n")
+		return cli.RunResultHelp
+	}
+
+	args = cmdFlags.Args()
+	if len(args) == 0 {
+		c.Ui.Error("Expected a single argument: NAME.\n")
+		return cli.RunResultHelp
+	}
+
+	name := args[0]
+	if !validWorkspaceName(name) {
+		c.Ui.Error(fmt.Sprintf(envInvalidName, name))
+		return 1
+	}
+
+	configPath, err := ModulePath(args[1:])
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
+	cfg, err := c.Config(configPath)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to load root config module: %s", err))
+		return 1
+	}
+
+	// Load the backend
+	b, err := c.Backend(&BackendOpts{
+		Config: cfg,
+	})
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to load backend: %s", err))
+		return 1
+	}
+
+	states, err := b.States()
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
+	exists := false
+	for _, s := range states {
+		if name == s {
+			exists = true
+			break
+		}
+	}
+	if !exists {
+		c.Ui.Error(fmt.Sprintf(strings.TrimSpace(envDoesNotExist), name))
+		return 1
+	}
+
+	err = c.updateWorkspaceMetadata(b, name, "workspace update", func(m *terraform.WorkspaceMetadata) {
+		if set["description"] {
+			m.Description = description
+		}
+		if set["protected"] {
+			m.Protected = protected
+		}
+	})
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error storing the workspace metadata: %s", err))
+		return 1
+	}
+
+	c.Ui.Output(c.Colorize().Color(fmt.Sprintf(envUpdated, name)))
+	return 0
+}
+
+func (c *WorkspaceUpdateCommand) AutocompleteArgs() complete.Predictor {
+	return completePredictSequence{
+		complete.PredictNothing, // the "update" subcommand itself (already matched)
+		c.completePredictWorkspaceName(),
+		complete.PredictDirs(""),
+	}
+}
+
+func (c *WorkspaceUpdateCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{
+		"-description": complete.PredictAnything,
+		"-protected":   complete.PredictSet("true", "false"),
+	}
+}
+
+func (c *WorkspaceUpdateCommand) Help() string {
+	helpText := `
+Usage: terraform workspace update [OPTIONS] NAME [DIR]
+
+  Update the description and protection of a Terraform workspace. Only
+  the given options are changed.
+
+
+Options:
+
+    -description=text        A description of the workspace, shown when
+                             listing workspaces. An empty description
+                             removes it.
+
+    -protected=true|false    Whether to protect the workspace from being
+                             deleted, and its resources from being
+                             destroyed, unless the protection is
+                             overridden.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *WorkspaceUpdateCommand) Synopsis() string {
+	return "Update the description and protection of a workspace"
+}
@@ -1,14 +1,13 @@
 package main
 
 import (
-	"log"
 	"os"
 	"os/signal"
 
 	"github.com/hashicorp/terraform/command"
-	pluginDiscovery "github.com/hashicorp/terraform/plugin/discovery"
+	"github.com/hashicorp/terraform/command/webbrowser"
+	"github.com/hashicorp/terraform/command/workdir"
 	"github.com/hashicorp/terraform/svchost"
-	"github.com/hashicorp/terraform/svchost/auth"
 	"github.com/hashicorp/terraform/svchost/disco"
 	"github.com/mitchellh/cli"
 )
@@ -30,6 +29,7 @@ const (
 	OutputPrefix = "o:"
 )
 
+// initCommands initializes the commands, which run in the current directory.
 func initCommands(config *Config) {
 	var inAutomation bool
 	if v := os.Getenv(runningInAutomationEnvName); v != "" {
@@ -49,7 +49,12 @@ func initCommands(config *Config) {
 		services.ForceHostServices(host, hostConfig.Services)
 	}
 
-	dataDir := os.Getenv("TF_DATA_DIR")
+	wd := workdir.NewDir(".")
+	if dataDir := os.Getenv("TF_DATA_DIR"); dataDir != "" {
+		wd.OverrideDataDir(dataDir)
+	}
+	wd.SetPluginCacheDir(config.PluginCacheDir)
+	wd.SetModuleCacheDir(config.ModuleCacheDir)
 
 	meta := command.Meta{
 		Color:            true,
@@ -57,12 +62,17 @@ func initCommands(config *Config) {
 		PluginOverrides:  &PluginOverrides,
 		Ui:               Ui,
 
-		Services:    services,
-		Credentials: credsSrc,
+		Services:        services,
+		Credentials:     credsSrc,
+		BrowserLauncher: webbrowser.NewNativeLauncher(),
 
-		RunningInAutomation: inAutomation,
-		PluginCacheDir:      config.PluginCacheDir,
-		OverrideDataDir:     dataDir,
+		RunningInAutomation:  inAutomation,
+		ProviderDevOverrides: config.ProviderDevOverrides(),
+		ProviderSources:      config.ProviderSources(),
+		ApprovalPolicy:       config.ApprovalPolicy(),
+		DiffPager:            config.DiffPager(),
+		ApplyHooks:           config.ApplyHookCommands(),
+		WorkingDir:           wd,
 
 		ShutdownCh: makeShutdownCh(),
 	}
@@ -170,6 +180,18 @@ func initCommands(config *Config) {
 			}, nil
 		},
 
+		"login": func() (cli.Command, error) {
+			return &command.LoginCommand{
+				Meta: meta,
+			}, nil
+		},
+
+		"logout": func() (cli.Command, error) {
+			return &command.LogoutCommand{
+				Meta: meta,
+			}, nil
+		},
+
 		"output": func() (cli.Command, error) {
 			return &command.OutputCommand{
 				Meta: meta,
@@ -188,6 +210,24 @@ func initCommands(config *Config) {
 			}, nil
 		},
 
+		"providers lock": func() (cli.Command, error) {
+			return &command.ProvidersLockCommand{
+				Meta: meta,
+			}, nil
+		},
+
+		"providers mirror": func() (cli.Command, error) {
+			return &command.ProvidersMirrorCommand{
+				Meta: meta,
+			}, nil
+		},
+
+		"providers schema": func() (cli.Command, error) {
+			return &command.ProvidersSchemaCommand{
+				Meta: meta,
+			}, nil
+		},
+
 		"push": func() (cli.Command, error) {
 			return &command.PushCommand{
 				Meta: meta,
@@ -212,6 +252,12 @@ func initCommands(config *Config) {
 			}, nil
 		},
 
+		"test": func() (cli.Command, error) {
+			return &command.TestCommand{
+				Meta: meta,
+			}, nil
+		},
+
 		"validate": func() (cli.Command, error) {
 			return &command.ValidateCommand{
 				Meta: meta,
@@ -225,6 +271,7 @@ func initCommands(config *Config) {
 				Version:           Version,
 				VersionPrerelease: VersionPrerelease,
 				CheckFunc:         commandVersionCheck,
+				ReleasesCheckFunc: releasesVersionCheck,
 			}, nil
 		},
 
@@ -270,6 +317,12 @@ func initCommands(config *Config) {
 			}, nil
 		},
 
+		"workspace update": func() (cli.Command, error) {
+			return &command.WorkspaceUpdateCommand{
+				Meta: meta,
+			}, nil
+		},
+
 		//-----------------------------------------------------------
 		// Plumbing
 		//-----------------------------------------------------------
@@ -318,6 +371,14 @@ func initCommands(config *Config) {
 			}, nil
 		},
 
+		"state replace-provider": func() (cli.Command, error) {
+			return &command.StateReplaceProviderCommand{
+				StateMeta: command.StateMeta{
+					Meta: meta,
+				},
+			}, nil
+		},
+
 		"state pull": func() (cli.Command, error) {
 			return &command.StatePullCommand{
 				Meta: meta,
@@ -335,6 +396,18 @@ func initCommands(config *Config) {
 				Meta: meta,
 			}, nil
 		},
+
+		"state versions": func() (cli.Command, error) {
+			return &command.StateVersionsCommand{
+				Meta: meta,
+			}, nil
+		},
+
+		"state restore": func() (cli.Command, error) {
+			return &command.StateRestoreCommand{
+				Meta: meta,
+			}, nil
+		},
 	}
 }
 
@@ -355,45 +428,3 @@ func makeShutdownCh() <-chan struct{} {
 
 	return resultCh
 }
-
-func credentialsSource(config *Config) auth.CredentialsSource {
-	creds := auth.NoCredentials
-	if len(config.Credentials) > 0 {
-		staticTable := map[svchost.Hostname]map[string]interface{}{}
-		for userHost, creds := range config.Credentials {
-			host, err := svchost.ForComparison(userHost)
-			if err != nil {
-				// We expect the config was already validated by the time we get
-				// here, so we'll just ignore invalid hostnames.
-				continue
-			}
-			staticTable[host] = creds
-		}
-		creds = auth.StaticCredentialsSource(staticTable)
-	}
-
-	for helperType, helperConfig := range config.CredentialsHelpers {
-		log.Printf("[DEBUG] Searching for credentials helper named %q", helperType)
-		available := pluginDiscovery.FindPlugins("credentials", globalPluginDirs())
-		available = available.WithName(helperType)
-		if available.Count() == 0 {
-			log.Printf("[ERROR] Unable to find credentials helper %q; ignoring", helperType)
-			break
-		}
-
-		selected := available.Newest()
-
-		helperSource := auth.HelperProgramCredentialsSource(selected.Path, helperConfig.Args...)
-		creds = auth.Credentials{
-			creds,
-			auth.CachingCredentialsSource(helperSource), // cached because external operation may be slow/expensive
-		}
-
-		// There should only be zero or one "credentials_helper" blocks. We
-		// assume that the config was validated earlier and so we don't check
-		// for extras here.
-		break
-	}
-
-	return creds
-}
@@ -38,9 +38,8 @@ var randShared *rand.Rand
 // Communicator represents the SSH communicator
 type Communicator struct {
 	connInfo *connectionInfo
-	client   *ssh.Client
+	client   *pooledClient
 	config   *sshConfig
-	conn     net.Conn
 	address  string
 
 	lock sync.Mutex
@@ -55,12 +54,23 @@ type sshConfig struct {
 	// case an error occurs.
 	connection func() (net.Conn, error)
 
+	// poolKey identifies the SSH connections created using this
+	// configuration in clientPool, and maxSessions is the number of
+	// communicators that may share each of them. If maxSessions is zero,
+	// connections are not shared.
+	poolKey     string
+	maxSessions int
+
 	// noPty, if true, will not request a pty from the remote end.
 	noPty bool
 
 	// sshAgent is a struct surrounding the agent.Agent client and the net.Conn
 	// to the SSH Agent. It is nil if no SSH agent is configured
 	sshAgent *sshAgent
+
+	// forwardAgent, if true, forwards the SSH agent to the remote host, if
+	// one is configured.
+	forwardAgent bool
 }
 
 // New creates a new communicator implementation over SSH.
@@ -102,12 +112,14 @@ func (c *Communicator) Connect(o terraform.UIOutput) (err error) {
 	c.lock.Lock()
 	defer c.lock.Unlock()
 
-	if c.conn != nil {
-		c.conn.Close()
+	// If we already have a connection then it isn't working, so make sure
+	// it isn't given to anyone else before releasing it.
+	if c.client != nil {
+		clientPool.discard(c.client)
+		clientPool.release(c.client)
 	}
 
-	// Set the conn and client to nil since we'll recreate it
-	c.conn = nil
+	// Set the client to nil since we'll recreate it
 	c.client = nil
 
 	if o != nil {
@@ -117,11 +129,17 @@ func (c *Communicator) Connect(o terraform.UIOutput) (err error) {
 				"  User: %s\n"+
 				"  Password: %t\n"+
 				"  Private key: %t\n"+
-				"  SSH Agent: %t",
+				"  Certificate: %t\n"+
+				"  SSH Agent: %t\n"+
+				"  Agent forwarding: %t\n"+
+				"  Checking Host Key: %t",
 			c.connInfo.Host, c.connInfo.User,
 			c.connInfo.Password != "",
 			c.connInfo.PrivateKey != "",
+			c.connInfo.Certificate != "",
 			c.connInfo.Agent,
+			c.connInfo.AgentForwarding,
+			c.connInfo.KnownHosts != "",
 		))
 
 		if c.connInfo.BastionHost != "" {
@@ -131,43 +149,54 @@ func (c *Communicator) Connect(o terraform.UIOutput) (err error) {
 					"  User: %s\n"+
 					"  Password: %t\n"+
 					"  Private key: %t\n"+
+					"  Certificate: %t\n"+
 					"  SSH Agent: %t",
 				c.connInfo.BastionHost, c.connInfo.BastionUser,
 				c.connInfo.BastionPassword != "",
 				c.connInfo.BastionPrivateKey != "",
+				c.connInfo.BastionCertificate != "",
 				c.connInfo.Agent,
 			))
 		}
 	}
 
-	log.Printf("connecting to TCP connection for SSH")
-	c.conn, err = c.config.connection()
-	if err != nil {
-		// Explicitly set this to the REAL nil. Connection() can return
-		// a nil implementation of net.Conn which will make the
-		// "if c.conn == nil" check fail above. Read here for more information
-		// on this psychotic language feature:
-		//
-		// http://golang.org/doc/faq#nil_error
-		c.conn = nil
-
-		log.Printf("connection error: %s", err)
-		return err
+	host := fmt.Sprintf("%s:%d", c.connInfo.Host, c.connInfo.Port)
+
+	// A connection that forwards our SSH agent can't be shared, since it
+	// would need to outlive this communicator's connection to the agent.
+	forwardAgent := c.config.sshAgent != nil && c.config.forwardAgent
+	maxSessions := c.config.maxSessions
+	if forwardAgent {
+		maxSessions = 0
 	}
 
-	log.Printf("handshaking with SSH")
-	host := fmt.Sprintf("%s:%d", c.connInfo.Host, c.connInfo.Port)
-	sshConn, sshChan, req, err := ssh.NewClientConn(c.conn, host, c.config.config)
+	client, isNew, err := clientPool.get(c.config.poolKey, host, maxSessions, func() (*ssh.Client, error) {
+		log.Printf("connecting to TCP connection for SSH")
+		conn, err := c.config.connection()
+		if err != nil {
+			log.Printf("connection error: %s", err)
+			return nil, err
+		}
+
+		log.Printf("handshaking with SSH")
+		sshConn, sshChan, req, err := ssh.NewClientConn(conn, host, c.config.config)
+		if err != nil {
+			log.Printf("handshake error: %s", err)
+			conn.Close()
+			return nil, err
+		}
+
+		return ssh.NewClient(sshConn, sshChan, req), nil
+	})
 	if err != nil {
-		log.Printf("handshake error: %s", err)
 		return err
 	}
 
-	c.client = ssh.NewClient(sshConn, sshChan, req)
+	c.client = client
 
-	if c.config.sshAgent != nil {
+	if isNew && forwardAgent {
 		log.Printf("[DEBUG] Telling SSH config to forward to agent")
-		if err := c.config.sshAgent.ForwardToAgent(c.client); err != nil {
+		if err := c.config.sshAgent.ForwardToAgent(c.client.Client); err != nil {
 			return err
 		}
 
@@ -205,10 +234,10 @@ func (c *Communicator) Disconnect() error {
 		}
 	}
 
-	if c.conn != nil {
-		conn := c.conn
-		c.conn = nil
-		return conn.Close()
+	if c.client != nil {
+		client := c.client
+		c.client = nil
+		return clientPool.release(client)
 	}
 
 	return nil
@@ -673,9 +702,42 @@ func BastionConnectFunc(
 	bConf *ssh.ClientConfig,
 	proto string,
 	addr string) func() (net.Conn, error) {
+	return bastionConnectFunc(bProto, bAddr, bConf, "", 0, proto, addr)
+}
+
+// bastionConnectFunc is like BastionConnectFunc, but shares the connection
+// to the bastion host between up to maxSessions connections to hosts
+// behind it, using bKey to identify it in clientPool.
+func bastionConnectFunc(
+	bProto string,
+	bAddr string,
+	bConf *ssh.ClientConfig,
+	bKey string,
+	maxSessions int,
+	proto string,
+	addr string) func() (net.Conn, error) {
+	dial := func(bAddr string, bConf *ssh.ClientConfig) (*ssh.Client, error) {
+		return ssh.Dial(bProto, bAddr, bConf)
+	}
+	return viaBastionConnectFunc(bAddr, bConf, dial, bKey, maxSessions, proto, addr)
+}
+
+// viaBastionConnectFunc is like bastionConnectFunc, but connects to the
+// bastion host using dial, which may itself connect through other bastion
+// hosts.
+func viaBastionConnectFunc(
+	bAddr string,
+	bConf *ssh.ClientConfig,
+	dial func(string, *ssh.ClientConfig) (*ssh.Client, error),
+	bKey string,
+	maxSessions int,
+	proto string,
+	addr string) func() (net.Conn, error) {
 	return func() (net.Conn, error) {
-		log.Printf("[DEBUG] Connecting to bastion: %s", bAddr)
-		bastion, err := ssh.Dial(bProto, bAddr, bConf)
+		bastion, _, err := clientPool.get(bKey, bAddr, maxSessions, func() (*ssh.Client, error) {
+			log.Printf("[DEBUG] Connecting to bastion: %s", bAddr)
+			return dial(bAddr, bConf)
+		})
 		if err != nil {
 			return nil, fmt.Errorf("Error connecting to bastion: %s", err)
 		}
@@ -683,7 +745,7 @@ func BastionConnectFunc(
 		log.Printf("[DEBUG] Connecting via bastion (%s) to host: %s", bAddr, addr)
 		conn, err := bastion.Dial(proto, addr)
 		if err != nil {
-			bastion.Close()
+			clientPool.release(bastion)
 			return nil, err
 		}
 
@@ -697,10 +759,16 @@ func BastionConnectFunc(
 
 type bastionConn struct {
 	net.Conn
-	Bastion *ssh.Client
+	Bastion *pooledClient
+
+	closeOnce sync.Once
 }
 
 func (c *bastionConn) Close() error {
-	c.Conn.Close()
-	return c.Bastion.Close()
+	var err error
+	c.closeOnce.Do(func() {
+		c.Conn.Close()
+		err = clientPool.release(c.Bastion)
+	})
+	return err
 }
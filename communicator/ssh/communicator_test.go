@@ -171,6 +171,65 @@ func TestStart(t *testing.T) {
 	}
 }
 
+func TestStart_knownHosts(t *testing.T) {
+	signer, err := ssh.ParsePrivateKey([]byte(testServerPrivateKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	serverKey := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(signer.PublicKey())))
+	otherKey := generateTestKey(t).PublicKey()
+
+	cases := map[string]struct {
+		KnownHosts string
+		Err        bool
+	}{
+		"known": {
+			"127.0.0.1,[127.0.0.1]:* " + serverKey,
+			false,
+		},
+		"mismatched": {
+			"127.0.0.1,[127.0.0.1]:* " + strings.TrimSpace(string(ssh.MarshalAuthorizedKey(otherKey))),
+			true,
+		},
+		"unknown": {
+			"example.com " + serverKey,
+			true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			address := newMockLineServer(t)
+			parts := strings.Split(address, ":")
+
+			r := &terraform.InstanceState{
+				Ephemeral: terraform.EphemeralState{
+					ConnInfo: map[string]string{
+						"type":        "ssh",
+						"user":        "user",
+						"password":    "pass",
+						"host":        parts[0],
+						"port":        parts[1],
+						"timeout":     "30s",
+						"known_hosts": tc.KnownHosts,
+					},
+				},
+			}
+
+			c, err := New(r)
+			if err != nil {
+				t.Fatalf("error creating communicator: %s", err)
+			}
+
+			err = c.Connect(nil)
+			if (err != nil) != tc.Err {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			c.Disconnect()
+		})
+	}
+}
+
 func TestAccUploadFile(t *testing.T) {
 	// use the local ssh server and scp binary to check uploads
 	if ok := os.Getenv("SSH_UPLOAD_TEST"); ok == "" {
@@ -0,0 +1,196 @@
+package ssh
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net"
+	"path"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// knownHosts is the set of host keys given in the known_hosts argument of
+// a connection, in the format of OpenSSH's known_hosts file.
+type knownHosts struct {
+	keys        []*knownHostsLine
+	authorities []*knownHostsLine
+	revoked     []ssh.PublicKey
+}
+
+// knownHostsLine is a line of a known_hosts file: the patterns of the hosts
+// it's for and their key.
+type knownHostsLine struct {
+	patterns []string
+	key      ssh.PublicKey
+}
+
+// parseKnownHosts parses the contents of a known_hosts file, including the
+// @cert-authority and @revoked markers.
+func parseKnownHosts(contents string) (*knownHosts, error) {
+	k := &knownHosts{}
+	rest := []byte(contents)
+	for {
+		var marker string
+		var hosts []string
+		var key ssh.PublicKey
+		var err error
+		marker, hosts, key, _, rest, err = ssh.ParseKnownHosts(rest)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse known_hosts: %s", err)
+		}
+
+		line := &knownHostsLine{patterns: hosts, key: key}
+		switch marker {
+		case "":
+			k.keys = append(k.keys, line)
+		case "cert-authority":
+			k.authorities = append(k.authorities, line)
+		case "revoked":
+			k.revoked = append(k.revoked, key)
+		default:
+			return nil, fmt.Errorf("Failed to parse known_hosts: unknown marker @%s", marker)
+		}
+	}
+
+	return k, nil
+}
+
+// HostKeyCallback returns a callback for ssh.ClientConfig that accepts the
+// host keys that are known for the host, and host certificates signed by
+// an authority that is known for it.
+func (k *knownHosts) HostKeyCallback() ssh.HostKeyCallback {
+	checker := &ssh.CertChecker{
+		IsHostAuthority: k.isAuthority,
+		IsRevoked: func(cert *ssh.Certificate) bool {
+			return k.isRevoked(cert.SignatureKey) || k.isRevoked(cert.Key)
+		},
+		HostKeyFallback: k.checkKey,
+	}
+	return checker.CheckHostKey
+}
+
+func (k *knownHosts) checkKey(addr string, remote net.Addr, key ssh.PublicKey) error {
+	if k.isRevoked(key) {
+		return fmt.Errorf("host key for %s is revoked in known_hosts", addr)
+	}
+
+	host := knownHostsName(addr)
+	known := false
+	for _, line := range k.keys {
+		if !line.matches(host) {
+			continue
+		}
+		if keysEqual(line.key, key) {
+			return nil
+		}
+		known = true
+	}
+
+	if known {
+		return fmt.Errorf(
+			"host key for %s doesn't match the one in known_hosts: got %s %s",
+			addr, key.Type(), ssh.FingerprintSHA256(key))
+	}
+	return fmt.Errorf(
+		"no host key for %s in known_hosts: got %s %s",
+		addr, key.Type(), ssh.FingerprintSHA256(key))
+}
+
+func (k *knownHosts) isAuthority(auth ssh.PublicKey, addr string) bool {
+	if k.isRevoked(auth) {
+		return false
+	}
+
+	host := knownHostsName(addr)
+	for _, line := range k.authorities {
+		if line.matches(host) && keysEqual(line.key, auth) {
+			return true
+		}
+	}
+	return false
+}
+
+func (k *knownHosts) isRevoked(key ssh.PublicKey) bool {
+	for _, r := range k.revoked {
+		if keysEqual(r, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// matches returns whether the line is for the given host, which is written
+// as it is in known_hosts files. As in OpenSSH, a negated pattern that
+// matches excludes the host, regardless of the other patterns.
+func (l *knownHostsLine) matches(host string) bool {
+	matched := false
+	for _, p := range l.patterns {
+		negated := strings.HasPrefix(p, "!")
+		p = strings.TrimPrefix(p, "!")
+
+		var ok bool
+		if strings.HasPrefix(p, "|1|") {
+			ok = hashedHostMatches(p, host)
+		} else {
+			// The port of a bracketed pattern is literal, but path.Match
+			// would treat the brackets as a character class.
+			escaped := strings.NewReplacer("[", `\[`, "]", `\]`).Replace(p)
+			ok, _ = path.Match(escaped, host)
+		}
+
+		if ok && negated {
+			return false
+		}
+		matched = matched || ok
+	}
+	return matched
+}
+
+// hashedHostMatches returns whether the given host is the one hashed in a
+// pattern of the form |1|salt|hash, which OpenSSH writes when
+// HashKnownHosts is set.
+func hashedHostMatches(pattern, host string) bool {
+	parts := strings.Split(pattern, "|")
+	if len(parts) != 4 {
+		return false
+	}
+	salt, err := base64.StdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+	hash, err := base64.StdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(sha1.New, salt)
+	mac.Write([]byte(host))
+	return hmac.Equal(mac.Sum(nil), hash)
+}
+
+// knownHostsName returns the name of the host at the given address, as it
+// is written in known_hosts files: the host alone for the default port,
+// and [host]:port for any other.
+func knownHostsName(addr string) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	if p, err := strconv.Atoi(port); err == nil && p == DefaultPort {
+		return host
+	}
+	return fmt.Sprintf("[%s]:%s", host, port)
+}
+
+func keysEqual(a, b ssh.PublicKey) bool {
+	return bytes.Equal(a.Marshal(), b.Marshal())
+}
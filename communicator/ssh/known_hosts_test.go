@@ -0,0 +1,99 @@
+package ssh
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func TestKnownHosts(t *testing.T) {
+	hostKey := generateTestKey(t)
+	otherKey := generateTestKey(t)
+	caKey := generateTestKey(t)
+
+	cert := &ssh.Certificate{
+		Key:             hostKey.PublicKey(),
+		CertType:        ssh.HostCert,
+		ValidPrincipals: []string{"ca.example.com"},
+		ValidBefore:     ssh.CertTimeInfinity,
+	}
+	if err := cert.SignCert(rand.Reader, caKey); err != nil {
+		t.Fatal(err)
+	}
+
+	salt := []byte("0123456789abcdefghij")
+	mac := hmac.New(sha1.New, salt)
+	mac.Write([]byte("hashed.example.com"))
+	hashed := fmt.Sprintf("|1|%s|%s",
+		base64.StdEncoding.EncodeToString(salt),
+		base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+
+	line := func(prefix string, key ssh.PublicKey) string {
+		return prefix + " " + strings.TrimSpace(string(ssh.MarshalAuthorizedKey(key)))
+	}
+	known, err := parseKnownHosts(strings.Join([]string{
+		"# comment",
+		line("web.example.com,10.0.0.1", hostKey.PublicKey()),
+		line("[web.example.com]:2222", otherKey.PublicKey()),
+		line("*.internal,!db.internal", hostKey.PublicKey()),
+		line(hashed, hostKey.PublicKey()),
+		line("@cert-authority *.example.com", caKey.PublicKey()),
+		line("@revoked *", otherKey.PublicKey()),
+		"",
+	}, "\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	callback := known.HostKeyCallback()
+
+	cases := []struct {
+		Addr string
+		Key  ssh.PublicKey
+		Err  bool
+	}{
+		{"web.example.com:22", hostKey.PublicKey(), false},
+		{"10.0.0.1:22", hostKey.PublicKey(), false},
+		{"web.example.com:2222", hostKey.PublicKey(), true},
+		{"web.example.com:2200", hostKey.PublicKey(), true},
+		{"app.internal:22", hostKey.PublicKey(), false},
+		{"db.internal:22", hostKey.PublicKey(), true},
+		{"hashed.example.com:22", hostKey.PublicKey(), false},
+		{"unknown.example.com:22", hostKey.PublicKey(), true},
+		{"ca.example.com:22", cert, false},
+		{"ca.other.com:22", cert, true},
+		{"[web.example.com]:2222", otherKey.PublicKey(), true},
+	}
+
+	for _, tc := range cases {
+		err := callback(tc.Addr, nil, tc.Key)
+		if (err != nil) != tc.Err {
+			t.Fatalf("%s: unexpected error: %v", tc.Addr, err)
+		}
+	}
+}
+
+func TestKnownHosts_invalid(t *testing.T) {
+	if _, err := parseKnownHosts("web.example.com ssh-rsa notakey"); err == nil {
+		t.Fatal("should have failed")
+	}
+}
+
+func generateTestKey(t *testing.T) ssh.Signer {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer
+}
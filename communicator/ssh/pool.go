@@ -0,0 +1,184 @@
+package ssh
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// poolIdleTimeout is how long a pooled connection is kept open once nothing
+// is using it, in case another communicator needs a connection to the same
+// host shortly afterwards.
+var poolIdleTimeout = 30 * time.Second
+
+// clientPool is the pool of SSH connections shared by all of the
+// communicators in this process.
+//
+// Many resources are often provisioned via the same bastion host, and
+// without pooling each provisioner would perform its own handshake with
+// it. With pooling, each SSH connection is multiplexed between up to
+// max_sessions communicators at a time.
+var clientPool = &connPool{
+	clients: make(map[string][]*pooledClient),
+}
+
+// connPool is a set of SSH client connections keyed by the address and
+// credentials used to create them.
+type connPool struct {
+	lock    sync.Mutex
+	clients map[string][]*pooledClient
+}
+
+// pooledClient is an SSH client connection that is shared between all of
+// the holders of a reference to it, and closed once the last reference is
+// released.
+type pooledClient struct {
+	*ssh.Client
+
+	addr   string
+	key    string
+	refs   int
+	broken bool
+	idle   *time.Timer
+}
+
+// poolKey returns the key identifying connections to the given address
+// using the given credentials, which must be distinct for any connections
+// that can't be used interchangeably.
+func poolKey(addr, user string, creds ...string) string {
+	h := sha256.New()
+	for _, c := range creds {
+		fmt.Fprintf(h, "%d:%s;", len(c), c)
+	}
+	return fmt.Sprintf("%s@%s/%s", user, addr, hex.EncodeToString(h.Sum(nil)))
+}
+
+// get returns a connection for the given key that is in use by fewer than
+// maxSessions holders, calling dial to create a new one if there is none.
+// The second return value is true if the connection was newly created.
+//
+// If maxSessions is less than one then pooling is disabled: a new
+// connection is always created, and it is closed as soon as it is
+// released.
+//
+// The caller must call release once it has finished with the connection.
+func (p *connPool) get(key, addr string, maxSessions int, dial func() (*ssh.Client, error)) (*pooledClient, bool, error) {
+	if maxSessions > 0 {
+		p.lock.Lock()
+		for _, pc := range p.clients[key] {
+			if pc.broken || pc.refs >= maxSessions {
+				continue
+			}
+			if pc.idle != nil {
+				pc.idle.Stop()
+				pc.idle = nil
+			}
+			pc.refs++
+			p.lock.Unlock()
+
+			log.Printf("[DEBUG] reusing pooled SSH connection to %s", addr)
+			return pc, false, nil
+		}
+		p.lock.Unlock()
+	}
+
+	client, err := dial()
+	if err != nil {
+		return nil, false, err
+	}
+
+	pc := &pooledClient{
+		Client: client,
+		addr:   addr,
+		refs:   1,
+	}
+	if maxSessions > 0 {
+		pc.key = key
+
+		p.lock.Lock()
+		p.clients[key] = append(p.clients[key], pc)
+		p.lock.Unlock()
+	}
+
+	// Stop handing out the connection as soon as it is lost, e.g. because
+	// the remote host is rebooting.
+	go func() {
+		client.Wait()
+		p.discard(pc)
+	}()
+
+	return pc, true, nil
+}
+
+// release gives up a reference to the given connection, obtained from get.
+func (p *connPool) release(pc *pooledClient) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	pc.refs--
+	if pc.refs > 0 {
+		return nil
+	}
+
+	if pc.broken || pc.key == "" {
+		p.remove(pc)
+		return pc.Client.Close()
+	}
+
+	var timer *time.Timer
+	timer = time.AfterFunc(poolIdleTimeout, func() {
+		p.lock.Lock()
+		defer p.lock.Unlock()
+
+		// The connection may have been reused since the timer was started.
+		if pc.idle != timer {
+			return
+		}
+
+		log.Printf("[DEBUG] closing idle pooled SSH connection to %s", pc.addr)
+		pc.idle = nil
+		p.remove(pc)
+		pc.Client.Close()
+	})
+	pc.idle = timer
+
+	return nil
+}
+
+// discard marks the given connection as unusable, so that it won't be
+// handed out again. It is still closed only once all references to it are
+// released.
+func (p *connPool) discard(pc *pooledClient) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	pc.broken = true
+	p.remove(pc)
+}
+
+// remove removes the given connection from the pool. The pool's lock must
+// be held.
+func (p *connPool) remove(pc *pooledClient) {
+	if pc.key == "" {
+		return
+	}
+
+	list := p.clients[pc.key]
+	for i, c := range list {
+		if c == pc {
+			list = append(list[:i], list[i+1:]...)
+			break
+		}
+	}
+
+	if len(list) == 0 {
+		delete(p.clients, pc.key)
+	} else {
+		p.clients[pc.key] = list
+	}
+}
@@ -0,0 +1,120 @@
+// +build !race
+
+package ssh
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func TestConnPool(t *testing.T) {
+	address := newMockLineServer(t)
+
+	// The mock server only accepts a single connection, so any further
+	// dial would indicate that the connection wasn't reused.
+	dials := 0
+	dial := func() (*ssh.Client, error) {
+		dials++
+		if dials > 1 {
+			return nil, errors.New("unexpected dial")
+		}
+		return ssh.Dial("tcp", address, &ssh.ClientConfig{
+			User:            "user",
+			Auth:            []ssh.AuthMethod{ssh.Password("pass")},
+			HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		})
+	}
+
+	p := &connPool{clients: make(map[string][]*pooledClient)}
+
+	a, isNew, err := p.get("key", address, 2, dial)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !isNew {
+		t.Fatal("first connection should be new")
+	}
+
+	b, isNew, err := p.get("key", address, 2, dial)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if isNew || b != a {
+		t.Fatal("second connection should be shared")
+	}
+
+	if _, _, err := p.get("key", address, 2, dial); err == nil {
+		t.Fatal("third connection should exceed max sessions")
+	}
+
+	p.release(b)
+	p.release(a)
+	if len(p.clients["key"]) != 1 {
+		t.Fatal("idle connection should remain in the pool")
+	}
+
+	c, isNew, err := p.get("key", address, 2, dial)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if isNew || c != a {
+		t.Fatal("idle connection should be reused")
+	}
+
+	p.discard(c)
+	if _, _, err := p.get("key", address, 2, dial); err == nil {
+		t.Fatal("discarded connection should not be reused")
+	}
+
+	if err := p.release(c); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(p.clients) != 0 {
+		t.Fatalf("pool should be empty: %#v", p.clients)
+	}
+}
+
+func TestConnPool_idleTimeout(t *testing.T) {
+	address := newMockLineServer(t)
+
+	old := poolIdleTimeout
+	poolIdleTimeout = 10 * time.Millisecond
+	defer func() { poolIdleTimeout = old }()
+
+	p := &connPool{clients: make(map[string][]*pooledClient)}
+	pc, _, err := p.get("key", address, 1, func() (*ssh.Client, error) {
+		return ssh.Dial("tcp", address, &ssh.ClientConfig{
+			User:            "user",
+			Auth:            []ssh.AuthMethod{ssh.Password("pass")},
+			HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		})
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	p.release(pc)
+	time.Sleep(100 * time.Millisecond)
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if len(p.clients) != 0 {
+		t.Fatalf("idle connection should have been closed: %#v", p.clients)
+	}
+}
+
+func TestPoolKey(t *testing.T) {
+	a := poolKey("host:22", "root", "pass", "")
+	b := poolKey("host:22", "root", "", "pass")
+	c := poolKey("host:22", "root", "pass", "")
+
+	if a == b {
+		t.Fatal("keys for different credentials should differ")
+	}
+	if a != c {
+		t.Fatal("keys for the same credentials should be equal")
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"net"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -33,6 +34,9 @@ const (
 
 	// DefaultTimeout is used if there is no timeout given
 	DefaultTimeout = 5 * time.Minute
+
+	// DefaultMaxSessions is used if there is no max_sessions given
+	DefaultMaxSessions = 10
 )
 
 // connectionInfo is decoded from the ConnInfo of the resource. These are the
@@ -49,13 +53,42 @@ type connectionInfo struct {
 	ScriptPath string        `mapstructure:"script_path"`
 	TimeoutVal time.Duration `mapstructure:"-"`
 
+	MaxSessions int `mapstructure:"max_sessions"`
+
 	BastionUser       string `mapstructure:"bastion_user"`
 	BastionPassword   string `mapstructure:"bastion_password"`
 	BastionPrivateKey string `mapstructure:"bastion_private_key"`
 	BastionHost       string `mapstructure:"bastion_host"`
 	BastionPort       int    `mapstructure:"bastion_port"`
 
-	AgentIdentity string `mapstructure:"agent_identity"`
+	// BastionHops are the bastion hosts in BastionHost, which may be a
+	// comma-separated chain of [user@]host[:port] to connect through in
+	// turn, like the -J option of ssh.
+	BastionHops []*bastionHop `mapstructure:"-"`
+
+	// Certificate and BastionCertificate are OpenSSH certificates that
+	// were issued for PrivateKey and BastionPrivateKey.
+	Certificate        string `mapstructure:"certificate"`
+	BastionCertificate string `mapstructure:"bastion_certificate"`
+
+	// KnownHosts, in the format of OpenSSH's known_hosts file, are the
+	// keys and certificate authorities that the host and bastion hosts
+	// are verified against. If it's empty, host keys aren't verified.
+	KnownHosts string `mapstructure:"known_hosts"`
+
+	AgentIdentity   string `mapstructure:"agent_identity"`
+	AgentForwarding bool   `mapstructure:"agent_forwarding"`
+}
+
+// bastionHop is one of the bastion hosts to connect through.
+type bastionHop struct {
+	User string
+	Host string
+	Port int
+}
+
+func (h *bastionHop) addr() string {
+	return fmt.Sprintf("%s:%d", h.Host, h.Port)
 }
 
 // parseConnectionInfo is used to convert the ConnInfo of the InstanceState into
@@ -83,6 +116,17 @@ func parseConnectionInfo(s *terraform.InstanceState) (*connectionInfo, error) {
 		connInfo.Agent = true
 	}
 
+	// The agent is forwarded, if it's used, unless that's turned off.
+	if s.Ephemeral.ConnInfo["agent_forwarding"] == "" {
+		connInfo.AgentForwarding = connInfo.Agent
+	}
+
+	// As with Agent, an explicit zero disables connection sharing and so
+	// must be distinguished from absence.
+	if s.Ephemeral.ConnInfo["max_sessions"] == "" {
+		connInfo.MaxSessions = DefaultMaxSessions
+	}
+
 	if connInfo.User == "" {
 		connInfo.User = DefaultUser
 	}
@@ -121,11 +165,56 @@ func parseConnectionInfo(s *terraform.InstanceState) (*connectionInfo, error) {
 		if connInfo.BastionPort == 0 {
 			connInfo.BastionPort = connInfo.Port
 		}
+		if connInfo.BastionCertificate == "" {
+			connInfo.BastionCertificate = connInfo.Certificate
+		}
+
+		connInfo.BastionHops, err = parseBastionHops(
+			connInfo.BastionHost, connInfo.BastionUser, connInfo.BastionPort)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	return connInfo, nil
 }
 
+// parseBastionHops parses a comma-separated chain of bastion hosts, each of
+// the form [user@]host[:port], using the given user and port for those that
+// don't have their own.
+func parseBastionHops(chain string, user string, port int) ([]*bastionHop, error) {
+	var hops []*bastionHop
+	for _, s := range strings.Split(chain, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			return nil, fmt.Errorf("Invalid bastion_host %q: empty host", chain)
+		}
+
+		hop := &bastionHop{User: user, Host: s, Port: port}
+		if i := strings.LastIndex(s, "@"); i >= 0 {
+			hop.User, hop.Host = s[:i], s[i+1:]
+		}
+
+		// A port can only be given with an IPv6 address if it's in
+		// brackets, as for ssh.
+		if h, p, err := net.SplitHostPort(hop.Host); err == nil {
+			n, err := strconv.Atoi(p)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid bastion_host %q: bad port %q", chain, p)
+			}
+			hop.Host, hop.Port = h, n
+		}
+		hop.Host = shared.IpFormat(strings.TrimSuffix(strings.TrimPrefix(hop.Host, "["), "]"))
+
+		if hop.User == "" || hop.Host == "" {
+			return nil, fmt.Errorf("Invalid bastion_host %q: empty user or host", chain)
+		}
+		hops = append(hops, hop)
+	}
+
+	return hops, nil
+}
+
 // safeDuration returns either the parsed duration or a default value
 func safeDuration(dur string, defaultDur time.Duration) time.Duration {
 	d, err := time.ParseDuration(dur)
@@ -144,64 +233,142 @@ func prepareSSHConfig(connInfo *connectionInfo) (*sshConfig, error) {
 		return nil, err
 	}
 
+	var hostKeyCallback ssh.HostKeyCallback
+	if connInfo.KnownHosts != "" {
+		known, err := parseKnownHosts(connInfo.KnownHosts)
+		if err != nil {
+			return nil, err
+		}
+		hostKeyCallback = known.HostKeyCallback()
+	}
+
 	sshConf, err := buildSSHClientConfig(sshClientConfigOpts{
-		user:       connInfo.User,
-		privateKey: connInfo.PrivateKey,
-		password:   connInfo.Password,
-		sshAgent:   sshAgent,
+		user:            connInfo.User,
+		privateKey:      connInfo.PrivateKey,
+		certificate:     connInfo.Certificate,
+		password:        connInfo.Password,
+		sshAgent:        sshAgent,
+		hostKeyCallback: hostKeyCallback,
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	var bastionConf *ssh.ClientConfig
-	if connInfo.BastionHost != "" {
-		bastionConf, err = buildSSHClientConfig(sshClientConfigOpts{
-			user:       connInfo.BastionUser,
-			privateKey: connInfo.BastionPrivateKey,
-			password:   connInfo.BastionPassword,
-			sshAgent:   sshAgent,
+	host := fmt.Sprintf("%s:%d", connInfo.Host, connInfo.Port)
+	connectFunc := ConnectFunc("tcp", host)
+	// Connections can only be shared by resources that would have opened
+	// them the same way, including checking the same host keys and
+	// forwarding the agent or not.
+	forwarding := strconv.FormatBool(connInfo.AgentForwarding)
+	key := poolKey(host, connInfo.User,
+		connInfo.Password, connInfo.PrivateKey, connInfo.Certificate, connInfo.AgentIdentity,
+		connInfo.KnownHosts, forwarding)
+
+	// Each bastion host is connected to through the one before it, and
+	// the connection to each is shared by up to max_sessions connections
+	// through it. The same address may refer to different hosts behind
+	// different bastions, so each is identified by those before it.
+	dialBastion := func(addr string, conf *ssh.ClientConfig) (*ssh.Client, error) {
+		return ssh.Dial("tcp", addr, conf)
+	}
+	var bastionKey string
+	for i, hop := range connInfo.BastionHops {
+		bastionConf, err := buildSSHClientConfig(sshClientConfigOpts{
+			user:            hop.User,
+			privateKey:      connInfo.BastionPrivateKey,
+			certificate:     connInfo.BastionCertificate,
+			password:        connInfo.BastionPassword,
+			sshAgent:        sshAgent,
+			hostKeyCallback: hostKeyCallback,
 		})
 		if err != nil {
 			return nil, err
 		}
-	}
 
-	host := fmt.Sprintf("%s:%d", connInfo.Host, connInfo.Port)
-	connectFunc := ConnectFunc("tcp", host)
+		hopKey := poolKey(hop.addr(), hop.User, connInfo.BastionPassword,
+			connInfo.BastionPrivateKey, connInfo.BastionCertificate, connInfo.AgentIdentity,
+			connInfo.KnownHosts, forwarding)
+		if bastionKey != "" {
+			hopKey = bastionKey + "/" + hopKey
+		}
 
-	if bastionConf != nil {
-		bastionHost := fmt.Sprintf("%s:%d", connInfo.BastionHost, connInfo.BastionPort)
-		connectFunc = BastionConnectFunc("tcp", bastionHost, bastionConf, "tcp", host)
+		next := host
+		if i+1 < len(connInfo.BastionHops) {
+			next = connInfo.BastionHops[i+1].addr()
+		}
+
+		connectFunc = viaBastionConnectFunc(
+			hop.addr(), bastionConf, dialBastion, hopKey, connInfo.MaxSessions, "tcp", next)
+
+		// The next hop is connected to through this one.
+		through := connectFunc
+		dialBastion = func(addr string, conf *ssh.ClientConfig) (*ssh.Client, error) {
+			conn, err := through()
+			if err != nil {
+				return nil, err
+			}
+			sshConn, chans, reqs, err := ssh.NewClientConn(conn, addr, conf)
+			if err != nil {
+				conn.Close()
+				return nil, err
+			}
+			return ssh.NewClient(sshConn, chans, reqs), nil
+		}
+		bastionKey = hopKey
+	}
+	if bastionKey != "" {
+		key = bastionKey + "/" + key
 	}
 
 	config := &sshConfig{
-		config:     sshConf,
-		connection: connectFunc,
-		sshAgent:   sshAgent,
+		config:       sshConf,
+		connection:   connectFunc,
+		poolKey:      key,
+		maxSessions:  connInfo.MaxSessions,
+		sshAgent:     sshAgent,
+		forwardAgent: connInfo.AgentForwarding,
 	}
 	return config, nil
 }
 
 type sshClientConfigOpts struct {
-	privateKey string
-	password   string
-	sshAgent   *sshAgent
-	user       string
+	privateKey  string
+	certificate string
+	password    string
+	sshAgent    *sshAgent
+	user        string
+
+	// hostKeyCallback verifies the host key. If it's nil, any host key is
+	// accepted.
+	hostKeyCallback ssh.HostKeyCallback
 }
 
 func buildSSHClientConfig(opts sshClientConfigOpts) (*ssh.ClientConfig, error) {
 	conf := &ssh.ClientConfig{
-		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		HostKeyCallback: opts.hostKeyCallback,
 		User:            opts.user,
 	}
+	if conf.HostKeyCallback == nil {
+		conf.HostKeyCallback = ssh.InsecureIgnoreHostKey()
+	}
+
+	if opts.certificate != "" && opts.privateKey == "" {
+		return nil, fmt.Errorf(
+			"A certificate can only be used with the private_key it was issued for")
+	}
 
 	if opts.privateKey != "" {
-		pubKeyAuth, err := readPrivateKey(opts.privateKey)
+		signer, err := readPrivateKey(opts.privateKey)
 		if err != nil {
 			return nil, err
 		}
-		conf.Auth = append(conf.Auth, pubKeyAuth)
+		if opts.certificate != "" {
+			signer, err = certSigner(opts.certificate, signer)
+			if err != nil {
+				return nil, err
+			}
+		}
+		conf.Auth = append(conf.Auth, ssh.PublicKeys(signer))
 	}
 
 	if opts.password != "" {
@@ -217,7 +384,7 @@ func buildSSHClientConfig(opts sshClientConfigOpts) (*ssh.ClientConfig, error) {
 	return conf, nil
 }
 
-func readPrivateKey(pk string) (ssh.AuthMethod, error) {
+func readPrivateKey(pk string) (ssh.Signer, error) {
 	// We parse the private key on our own first so that we can
 	// show a nicer error if the private key has a password.
 	block, _ := pem.Decode([]byte(pk))
@@ -235,7 +402,30 @@ func readPrivateKey(pk string) (ssh.AuthMethod, error) {
 		return nil, fmt.Errorf("Failed to parse key file %q: %s", pk, err)
 	}
 
-	return ssh.PublicKeys(signer), nil
+	return signer, nil
+}
+
+// certSigner returns a signer that authenticates with the given OpenSSH
+// certificate, in the format of a .pub file, which must have been issued
+// for the given signer's key.
+func certSigner(certificate string, signer ssh.Signer) (ssh.Signer, error) {
+	pk, _, _, _, err := ssh.ParseAuthorizedKey([]byte(certificate))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse certificate: %s", err)
+	}
+	cert, ok := pk.(*ssh.Certificate)
+	if !ok {
+		return nil, fmt.Errorf("Failed to parse certificate: %s is a public key, not a certificate", pk.Type())
+	}
+	if cert.CertType != ssh.UserCert {
+		return nil, fmt.Errorf("Failed to parse certificate: it's not a user certificate")
+	}
+
+	certSigner, err := ssh.NewCertSigner(cert, signer)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to use certificate: %s", err)
+	}
+	return certSigner, nil
 }
 
 func connectToAgent(connInfo *connectionInfo) (*sshAgent, error) {
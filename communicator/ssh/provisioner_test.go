@@ -1,9 +1,13 @@
 package ssh
 
 import (
+	"crypto/rand"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/hashicorp/terraform/terraform"
+	"golang.org/x/crypto/ssh"
 )
 
 func TestProvisioner_connInfo(t *testing.T) {
@@ -64,6 +68,30 @@ func TestProvisioner_connInfo(t *testing.T) {
 	if conf.BastionPrivateKey != "someprivatekeycontents" {
 		t.Fatalf("bad: %v", conf)
 	}
+	if conf.MaxSessions != DefaultMaxSessions {
+		t.Fatalf("bad: %v", conf)
+	}
+}
+
+func TestProvisioner_connInfoMaxSessions(t *testing.T) {
+	r := &terraform.InstanceState{
+		Ephemeral: terraform.EphemeralState{
+			ConnInfo: map[string]string{
+				"type":         "ssh",
+				"host":         "127.0.0.1",
+				"max_sessions": "0",
+			},
+		},
+	}
+
+	conf, err := parseConnectionInfo(r)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if conf.MaxSessions != 0 {
+		t.Fatalf("bad: %v", conf)
+	}
 }
 
 func TestProvisioner_connInfoIpv6(t *testing.T) {
@@ -127,3 +155,166 @@ func TestProvisioner_connInfoHostname(t *testing.T) {
 		t.Fatalf("bad %v", conf)
 	}
 }
+
+func TestProvisioner_connInfoBastionChain(t *testing.T) {
+	r := &terraform.InstanceState{
+		Ephemeral: terraform.EphemeralState{
+			ConnInfo: map[string]string{
+				"type":         "ssh",
+				"user":         "root",
+				"host":         "10.0.0.10",
+				"bastion_host": "jump1.example.com, admin@jump2.example.com:2222,[::1]:2200",
+				"bastion_port": "2022",
+				"certificate":  "somecertificatecontents",
+			},
+		},
+	}
+
+	conf, err := parseConnectionInfo(r)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	expected := []*bastionHop{
+		{User: "root", Host: "jump1.example.com", Port: 2022},
+		{User: "admin", Host: "jump2.example.com", Port: 2222},
+		{User: "root", Host: "[::1]", Port: 2200},
+	}
+	if !reflect.DeepEqual(conf.BastionHops, expected) {
+		t.Fatalf("bad: %#v", conf.BastionHops)
+	}
+	if conf.BastionCertificate != "somecertificatecontents" {
+		t.Fatalf("bad: %v", conf)
+	}
+}
+
+func TestProvisioner_connInfoBastionChainInvalid(t *testing.T) {
+	for _, host := range []string{"jump1,,jump2", "@jump1", "jump1:ssh"} {
+		r := &terraform.InstanceState{
+			Ephemeral: terraform.EphemeralState{
+				ConnInfo: map[string]string{
+					"type":         "ssh",
+					"host":         "10.0.0.10",
+					"bastion_host": host,
+				},
+			},
+		}
+
+		if _, err := parseConnectionInfo(r); err == nil {
+			t.Fatalf("%s: should have failed", host)
+		}
+	}
+}
+
+func TestProvisioner_connInfoAgentForwarding(t *testing.T) {
+	r := &terraform.InstanceState{
+		Ephemeral: terraform.EphemeralState{
+			ConnInfo: map[string]string{
+				"type":  "ssh",
+				"host":  "127.0.0.1",
+				"agent": "true",
+			},
+		},
+	}
+
+	conf, err := parseConnectionInfo(r)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !conf.AgentForwarding {
+		t.Fatal("the agent should be forwarded by default")
+	}
+
+	r.Ephemeral.ConnInfo["agent_forwarding"] = "false"
+	conf, err = parseConnectionInfo(r)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if conf.AgentForwarding {
+		t.Fatal("the agent should not be forwarded")
+	}
+}
+
+func TestPrepareSSHConfig_poolKey(t *testing.T) {
+	hostKey := generateTestKey(t)
+	knownHosts := "10.0.0.10,jump.example.com " + string(ssh.MarshalAuthorizedKey(hostKey.PublicKey()))
+
+	connInfo := func() *connectionInfo {
+		return &connectionInfo{
+			User:            "root",
+			Password:        "pass",
+			Host:            "10.0.0.10",
+			Port:            22,
+			BastionPassword: "pass",
+			BastionHops: []*bastionHop{
+				{User: "root", Host: "jump.example.com", Port: 22},
+			},
+		}
+	}
+
+	// The keys of the bastion and of the host, which are separated by "/"
+	// as are the address and credentials in each.
+	keys := func(ci *connectionInfo) (string, string) {
+		conf, err := prepareSSHConfig(ci)
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		parts := strings.Split(conf.poolKey, "/")
+		if len(parts) != 4 {
+			t.Fatalf("unexpected pool key %q", conf.poolKey)
+		}
+		return parts[1], parts[3]
+	}
+
+	baseBastion, baseHost := keys(connInfo())
+
+	withKnownHosts := connInfo()
+	withKnownHosts.KnownHosts = knownHosts
+	withForwarding := connInfo()
+	withForwarding.AgentForwarding = true
+
+	for name, ci := range map[string]*connectionInfo{
+		"known_hosts":      withKnownHosts,
+		"agent_forwarding": withForwarding,
+	} {
+		bastion, host := keys(ci)
+		if bastion == baseBastion {
+			t.Errorf("%s: bastion connections should not be shared", name)
+		}
+		if host == baseHost {
+			t.Errorf("%s: host connections should not be shared", name)
+		}
+	}
+}
+
+func TestCertSigner(t *testing.T) {
+	userKey := generateTestKey(t)
+	otherKey := generateTestKey(t)
+	caKey := generateTestKey(t)
+
+	cert := &ssh.Certificate{
+		Key:             userKey.PublicKey(),
+		CertType:        ssh.UserCert,
+		ValidPrincipals: []string{"root"},
+		ValidBefore:     ssh.CertTimeInfinity,
+	}
+	if err := cert.SignCert(rand.Reader, caKey); err != nil {
+		t.Fatal(err)
+	}
+	certificate := string(ssh.MarshalAuthorizedKey(cert))
+
+	signer, err := certSigner(certificate, userKey)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, ok := signer.PublicKey().(*ssh.Certificate); !ok {
+		t.Fatalf("expected the certificate as the public key, got %s", signer.PublicKey().Type())
+	}
+
+	if _, err := certSigner(certificate, otherKey); err == nil {
+		t.Fatal("a certificate for another key should have failed")
+	}
+	if _, err := certSigner(string(ssh.MarshalAuthorizedKey(userKey.PublicKey())), userKey); err == nil {
+		t.Fatal("a public key should have failed")
+	}
+}
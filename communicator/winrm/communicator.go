@@ -35,10 +35,11 @@ func New(s *terraform.InstanceState) (*Communicator, error) {
 	}
 
 	endpoint := &winrm.Endpoint{
-		Host:     connInfo.Host,
-		Port:     connInfo.Port,
-		HTTPS:    connInfo.HTTPS,
-		Insecure: connInfo.Insecure,
+		Host:          connInfo.Host,
+		Port:          connInfo.Port,
+		HTTPS:         connInfo.HTTPS,
+		Insecure:      connInfo.Insecure,
+		TLSServerName: connInfo.TLSServerName,
 	}
 	if len(connInfo.CACert) > 0 {
 		endpoint.CACert = []byte(connInfo.CACert)
@@ -60,11 +61,16 @@ func (c *Communicator) Connect(o terraform.UIOutput) error {
 		return nil
 	}
 
-	params := winrm.DefaultParameters
+	params := *winrm.DefaultParameters
 	params.Timeout = formatDuration(c.Timeout())
 
+	transport := newTransport(c.connInfo)
+	params.TransportDecorator = func() winrm.Transporter {
+		return transport
+	}
+
 	client, err := winrm.NewClientWithParameters(
-		c.endpoint, c.connInfo.User, c.connInfo.Password, params)
+		c.endpoint, c.connInfo.User, c.connInfo.Password, &params)
 	if err != nil {
 		return err
 	}
@@ -78,7 +84,10 @@ func (c *Communicator) Connect(o terraform.UIOutput) error {
 				"  Password: %t\n"+
 				"  HTTPS: %t\n"+
 				"  Insecure: %t\n"+
-				"  CACert: %t",
+				"  CACert: %t\n"+
+				"  TLSServerName: %s\n"+
+				"  NTLM: %t\n"+
+				"  Proxy: %t",
 			c.connInfo.Host,
 			c.connInfo.Port,
 			c.connInfo.User,
@@ -86,10 +95,14 @@ func (c *Communicator) Connect(o terraform.UIOutput) error {
 			c.connInfo.HTTPS,
 			c.connInfo.Insecure,
 			c.connInfo.CACert != "",
+			c.connInfo.TLSServerName,
+			c.connInfo.UseNTLM,
+			c.connInfo.ProxyHost != "",
 		))
 	}
 
 	log.Printf("connecting to remote shell using WinRM")
+	start := time.Now()
 	shell, err := client.CreateShell()
 	if err != nil {
 		log.Printf("connection error: %s", err)
@@ -102,8 +115,18 @@ func (c *Communicator) Connect(o terraform.UIOutput) error {
 		return err
 	}
 
+	// The time it took and the authentication scheme the host accepted
+	// help tell apart slow hosts, proxies and authentication problems.
+	elapsed := time.Since(start)
+	log.Printf("connected to remote shell in %s using %s authentication", elapsed, transport.Auth())
 	if o != nil {
-		o.Output("Connected!")
+		o.Output(fmt.Sprintf(
+			"Connected!\n"+
+				"  Time: %s\n"+
+				"  Authentication: %s",
+			elapsed,
+			transport.Auth(),
+		))
 	}
 
 	c.client = client
@@ -206,8 +229,12 @@ func (c *Communicator) newCopyClient() (*winrmcp.Winrmcp, error) {
 		},
 		Https:                 c.connInfo.HTTPS,
 		Insecure:              c.connInfo.Insecure,
+		TLSServerName:         c.connInfo.TLSServerName,
 		OperationTimeout:      c.Timeout(),
 		MaxOperationsPerShell: 15, // lowest common denominator
+		TransportDecorator: func() winrm.Transporter {
+			return newTransport(c.connInfo)
+		},
 	}
 
 	if c.connInfo.CACert != "" {
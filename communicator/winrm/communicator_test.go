@@ -3,8 +3,13 @@ package winrm
 import (
 	"bytes"
 	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"regexp"
 	"strconv"
+	"strings"
+	"sync/atomic"
 	"testing"
 
 	"github.com/dylanmei/winrmtest"
@@ -81,6 +86,67 @@ func TestStart(t *testing.T) {
 	}
 }
 
+func TestConnect_proxy(t *testing.T) {
+	wrm := newMockWinRMServer(t)
+	defer wrm.Close()
+
+	// The proxy forwards every request it gets to the host it's for.
+	var proxied int32
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&proxied, 1)
+		req.RequestURI = ""
+		resp, err := http.DefaultTransport.RoundTrip(req)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		defer resp.Body.Close()
+		for k, v := range resp.Header {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(resp.StatusCode)
+		io.Copy(w, resp.Body)
+	}))
+	defer proxy.Close()
+
+	proxyURL, err := url.Parse(proxy.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := &terraform.InstanceState{
+		Ephemeral: terraform.EphemeralState{
+			ConnInfo: map[string]string{
+				"type":       "winrm",
+				"user":       "user",
+				"password":   "pass",
+				"host":       wrm.Host,
+				"port":       strconv.Itoa(wrm.Port),
+				"timeout":    "30s",
+				"proxy_host": proxyURL.Hostname(),
+				"proxy_port": proxyURL.Port(),
+			},
+		},
+	}
+
+	c, err := New(r)
+	if err != nil {
+		t.Fatalf("error creating communicator: %s", err)
+	}
+
+	o := new(terraform.MockUIOutput)
+	if err := c.Connect(o); err != nil {
+		t.Fatalf("error connecting: %s", err)
+	}
+
+	if atomic.LoadInt32(&proxied) == 0 {
+		t.Fatal("expected the connection to go through the proxy")
+	}
+	if !strings.Contains(o.OutputMessage, "Authentication: Basic") {
+		t.Fatalf("expected the authentication scheme in the output, got: %s", o.OutputMessage)
+	}
+}
+
 func TestUpload(t *testing.T) {
 	wrm := newMockWinRMServer(t)
 	defer wrm.Close()
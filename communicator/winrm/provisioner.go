@@ -25,6 +25,9 @@ const (
 
 	// DefaultTimeout is used if there is no timeout given
 	DefaultTimeout = 5 * time.Minute
+
+	// DefaultProxyPort is used if there is no port given for the proxy
+	DefaultProxyPort = 8080
 )
 
 // connectionInfo is decoded from the ConnInfo of the resource. These are the
@@ -41,6 +44,24 @@ type connectionInfo struct {
 	Timeout    string
 	ScriptPath string        `mapstructure:"script_path"`
 	TimeoutVal time.Duration `mapstructure:"-"`
+
+	// UseNTLM authenticates with NTLM, rather than basic authentication.
+	UseNTLM bool `mapstructure:"use_ntlm"`
+
+	// UseKerberos asks for Kerberos authentication, which isn't supported,
+	// so that it's refused rather than silently ignored.
+	UseKerberos bool `mapstructure:"use_kerberos"`
+
+	// TLSServerName is the name the host's certificate is verified against,
+	// when it's not that of Host.
+	TLSServerName string `mapstructure:"tls_server_name"`
+
+	// The HTTP proxy to connect through, if any, instead of the one given
+	// by the proxy environment variables.
+	ProxyHost         string `mapstructure:"proxy_host"`
+	ProxyPort         int    `mapstructure:"proxy_port"`
+	ProxyUserName     string `mapstructure:"proxy_user_name"`
+	ProxyUserPassword string `mapstructure:"proxy_user_password"`
 }
 
 // parseConnectionInfo is used to convert the ConnInfo of the InstanceState into
@@ -70,6 +91,12 @@ func parseConnectionInfo(s *terraform.InstanceState) (*connectionInfo, error) {
 			`Using the C:\Windows\Temp folder is not supported. Please use a different 'script_path'.`)
 	}
 
+	if connInfo.UseKerberos {
+		return nil, fmt.Errorf(
+			"Kerberos authentication is not supported by the winrm connection. " +
+				"Set 'use_ntlm' to authenticate as a domain user instead.")
+	}
+
 	if connInfo.User == "" {
 		connInfo.User = DefaultUser
 	}
@@ -81,6 +108,9 @@ func parseConnectionInfo(s *terraform.InstanceState) (*connectionInfo, error) {
 	if connInfo.Port == 0 {
 		connInfo.Port = DefaultPort
 	}
+	if connInfo.ProxyHost != "" && connInfo.ProxyPort == 0 {
+		connInfo.ProxyPort = DefaultProxyPort
+	}
 	if connInfo.ScriptPath == "" {
 		connInfo.ScriptPath = DefaultScriptPath
 	}
@@ -0,0 +1,163 @@
+package winrm
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/go-ntlmssp"
+	"github.com/masterzen/winrm"
+	"github.com/masterzen/winrm/soap"
+)
+
+// transport is the winrm.Transporter the communicator uses, rather than the
+// library's own, so that it can go through a configured HTTP proxy and
+// record which authentication scheme the host accepted.
+type transport struct {
+	connInfo *connectionInfo
+
+	url  string
+	http http.RoundTripper
+
+	lock sync.Mutex
+	auth string
+}
+
+// newTransport returns a transport for the given connection, for use as the
+// TransportDecorator of the winrm and winrmcp clients.
+func newTransport(connInfo *connectionInfo) *transport {
+	return &transport{connInfo: connInfo}
+}
+
+// Transport implements winrm.Transporter.
+func (t *transport) Transport(endpoint *winrm.Endpoint) error {
+	scheme := "http"
+	if endpoint.HTTPS {
+		scheme = "https"
+	}
+	t.url = fmt.Sprintf("%s://%s:%d/wsman", scheme, endpoint.Host, endpoint.Port)
+
+	proxy := http.ProxyFromEnvironment
+	if u := t.connInfo.proxyURL(); u != nil {
+		proxy = http.ProxyURL(u)
+	}
+
+	base := &http.Transport{
+		Proxy: proxy,
+		TLSClientConfig: &tls.Config{
+			InsecureSkipVerify: endpoint.Insecure,
+			ServerName:         endpoint.TLSServerName,
+		},
+		Dial: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).Dial,
+		ResponseHeaderTimeout: endpoint.Timeout,
+	}
+
+	if len(endpoint.CACert) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(endpoint.CACert) {
+			return fmt.Errorf("Unable to read certificates from cacert")
+		}
+		base.TLSClientConfig.RootCAs = pool
+	}
+
+	// The requests are recorded as they're sent, after the negotiator has
+	// replaced the basic credentials, so that the scheme the host accepted
+	// is known.
+	t.http = &recordingRoundTripper{RoundTripper: base, t: t}
+	if t.connInfo.UseNTLM {
+		t.http = ntlmssp.Negotiator{RoundTripper: t.http}
+	}
+
+	return nil
+}
+
+// Post implements winrm.Transporter.
+func (t *transport) Post(_ *winrm.Client, request *soap.SoapMessage) (string, error) {
+	req, err := http.NewRequest("POST", t.url, strings.NewReader(request.String()))
+	if err != nil {
+		return "", fmt.Errorf("impossible to create http request %s", err)
+	}
+	req.Header.Set("Content-Type", "application/soap+xml;charset=UTF-8")
+	req.SetBasicAuth(t.connInfo.User, t.connInfo.Password)
+
+	resp, err := t.http.RoundTrip(req)
+	if err != nil {
+		return "", fmt.Errorf("unknown error %s", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("error while reading request body %s", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("http error %d: %s", resp.StatusCode, body)
+	}
+	if !strings.Contains(resp.Header.Get("Content-Type"), "application/soap+xml") {
+		return "", fmt.Errorf("http response error: %d - invalid content type", resp.StatusCode)
+	}
+
+	return string(body), nil
+}
+
+// Auth returns the authentication scheme of the last request that the host
+// accepted, such as "Basic" or "NTLM", or "" if it hasn't accepted any.
+func (t *transport) Auth() string {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.auth
+}
+
+// recordingRoundTripper records the authentication scheme of the requests
+// that are accepted.
+type recordingRoundTripper struct {
+	http.RoundTripper
+	t *transport
+}
+
+func (r *recordingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := r.RoundTripper.RoundTrip(req)
+	if err != nil || resp.StatusCode == http.StatusUnauthorized {
+		return resp, err
+	}
+
+	scheme := req.Header.Get("Authorization")
+	if i := strings.IndexByte(scheme, ' '); i >= 0 {
+		scheme = scheme[:i]
+	}
+	if scheme != "" {
+		r.t.lock.Lock()
+		r.t.auth = scheme
+		r.t.lock.Unlock()
+	}
+
+	return resp, nil
+}
+
+// proxyURL returns the URL of the configured HTTP proxy, or nil if there
+// isn't one, in which case the proxy environment variables are used.
+func (c *connectionInfo) proxyURL() *url.URL {
+	if c.ProxyHost == "" {
+		return nil
+	}
+
+	u := &url.URL{
+		Scheme: "http",
+		Host:   net.JoinHostPort(c.ProxyHost, strconv.Itoa(c.ProxyPort)),
+	}
+	if c.ProxyUserName != "" {
+		u.User = url.UserPassword(c.ProxyUserName, c.ProxyUserPassword)
+	}
+	return u
+}
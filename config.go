@@ -5,17 +5,26 @@ import (
 	"fmt"
 	"io/ioutil"
 	"log"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/hashicorp/hcl"
+	"github.com/hashicorp/hcl/hcl/ast"
 
+	"github.com/hashicorp/terraform/backend"
 	"github.com/hashicorp/terraform/command"
+	"github.com/hashicorp/terraform/plugin/discovery"
 	"github.com/hashicorp/terraform/svchost"
 	"github.com/hashicorp/terraform/tfdiags"
 )
 
 const pluginCacheDirEnvVar = "TF_PLUGIN_CACHE_DIR"
+const moduleCacheDirEnvVar = "TF_MODULE_CACHE_DIR"
+const applyHookEnvVar = "TF_APPLY_HOOK"
 
 // Config is the structure of the configuration for the Terraform CLI.
 //
@@ -32,10 +41,20 @@ type Config struct {
 	// avoid repeatedly re-downloading over the Internet.
 	PluginCacheDir string `hcl:"plugin_cache_dir"`
 
+	// If set, enables local caching of modules in this directory, shared
+	// by all configurations.
+	ModuleCacheDir string `hcl:"module_cache_dir"`
+
 	Hosts map[string]*ConfigHost `hcl:"host"`
 
 	Credentials        map[string]map[string]interface{}   `hcl:"credentials"`
 	CredentialsHelpers map[string]*ConfigCredentialsHelper `hcl:"credentials_helper"`
+
+	ProviderInstallation *ConfigProviderInstallation `hcl:"provider_installation"`
+
+	ApplyApproval *ConfigApplyApproval `hcl:"apply_approval"`
+
+	ApplyHooks map[string]*ConfigApplyHook `hcl:"apply_hook"`
 }
 
 // ConfigHost is the structure of the "host" nested block within the CLI
@@ -51,6 +70,74 @@ type ConfigCredentialsHelper struct {
 	Args []string `hcl:"args"`
 }
 
+// ConfigProviderInstallation is the structure of the "provider_installation"
+// nested block within the CLI configuration.
+type ConfigProviderInstallation struct {
+	// DevOverrides maps provider names to directories containing
+	// development builds of those providers, which are used instead of any
+	// installed plugins. Version constraints and the plugin lock file are
+	// not checked for these providers.
+	DevOverrides map[string]string `hcl:"dev_overrides"`
+
+	// Methods are the ways that providers may be installed, in order of
+	// preference. If there are none then providers are installed directly
+	// from the official releases service.
+	Methods []*ConfigProviderInstallationMethod `hcl:"-"`
+}
+
+// ConfigProviderInstallationMethod is the structure of the "direct",
+// "filesystem_mirror" and "network_mirror" blocks within the
+// "provider_installation" block of the CLI configuration.
+type ConfigProviderInstallationMethod struct {
+	// Type is the name of the block that the method was declared with.
+	Type string `hcl:"-"`
+
+	Path string `hcl:"path"` // filesystem_mirror only
+	URL  string `hcl:"url"`  // network_mirror only
+
+	// Include and Exclude are lists of provider name patterns that decide
+	// which providers may be installed using this method.
+	Include []string `hcl:"include"`
+	Exclude []string `hcl:"exclude"`
+}
+
+// ConfigApplyApproval is the structure of the "apply_approval" nested block
+// within the CLI configuration, which decides how "terraform apply" shows
+// plans and has them approved.
+type ConfigApplyApproval struct {
+	// Pager is the command that plans are shown with before they're
+	// approved, such as "less -R".
+	Pager string `hcl:"pager"`
+
+	// AutoApproveAttributes are the attributes that plans may update
+	// in-place without approval, such as "tags".
+	AutoApproveAttributes []string `hcl:"auto_approve_attributes"`
+
+	// DestroyConfirmation is how plans that destroy anything are approved:
+	// "yes" by default, or "workspace" to require the workspace name.
+	DestroyConfirmation string `hcl:"destroy_confirmation"`
+}
+
+// ConfigApplyHook is the structure of the "apply_hook" nested block within
+// the CLI configuration, which declares a command that's run before or
+// after each resource is applied.
+type ConfigApplyHook struct {
+	// Command is the program to run and its arguments.
+	Command []string `hcl:"command"`
+
+	// When is when the command is run: "pre_apply", "post_apply" or both.
+	// If it's empty, the command is run at both.
+	When []string `hcl:"when"`
+}
+
+// providerInstallationMethodTypes are the names of the blocks within the
+// "provider_installation" block that declare installation methods.
+var providerInstallationMethodTypes = map[string]bool{
+	"direct":            true,
+	"filesystem_mirror": true,
+	"network_mirror":    true,
+}
+
 // BuiltinConfig is the built-in defaults for the configuration. These
 // can be overridden by user configurations.
 var BuiltinConfig Config
@@ -142,13 +229,77 @@ func loadConfigFile(path string) (*Config, tfdiags.Diagnostics) {
 		result.Provisioners[k] = os.ExpandEnv(v)
 	}
 
+	// The installation methods are decoded separately because their
+	// order matters, which decoding into a struct doesn't retain.
+	if list, ok := obj.Node.(*ast.ObjectList); ok {
+		methods, err := decodeProviderInstallationMethods(list)
+		if err != nil {
+			diags = diags.Append(fmt.Errorf("Error parsing %s: %s", path, err))
+			return result, diags
+		}
+		if len(methods) > 0 {
+			if result.ProviderInstallation == nil {
+				result.ProviderInstallation = &ConfigProviderInstallation{}
+			}
+			result.ProviderInstallation.Methods = methods
+		}
+	}
+
+	if pi := result.ProviderInstallation; pi != nil {
+		for k, v := range pi.DevOverrides {
+			pi.DevOverrides[k] = os.ExpandEnv(v)
+		}
+		for _, m := range pi.Methods {
+			m.Path = os.ExpandEnv(m.Path)
+		}
+	}
+
 	if result.PluginCacheDir != "" {
 		result.PluginCacheDir = os.ExpandEnv(result.PluginCacheDir)
 	}
+	if result.ModuleCacheDir != "" {
+		result.ModuleCacheDir = os.ExpandEnv(result.ModuleCacheDir)
+	}
+	for _, h := range result.ApplyHooks {
+		for i, arg := range h.Command {
+			h.Command[i] = os.ExpandEnv(arg)
+		}
+	}
 
 	return result, diags
 }
 
+// decodeProviderInstallationMethods decodes the installation methods in the
+// "provider_installation" block of the given CLI configuration, in the
+// order they are declared.
+func decodeProviderInstallationMethods(list *ast.ObjectList) ([]*ConfigProviderInstallationMethod, error) {
+	var methods []*ConfigProviderInstallationMethod
+	for _, item := range list.Filter("provider_installation").Items {
+		ot, ok := item.Val.(*ast.ObjectType)
+		if !ok {
+			return nil, fmt.Errorf("provider_installation must be a block")
+		}
+
+		for _, mi := range ot.List.Items {
+			if len(mi.Keys) == 0 {
+				continue
+			}
+			typ := mi.Keys[0].Token.Value().(string)
+			if !providerInstallationMethodTypes[typ] {
+				continue
+			}
+
+			m := &ConfigProviderInstallationMethod{Type: typ}
+			if err := hcl.DecodeObject(m, mi.Val); err != nil {
+				return nil, fmt.Errorf("provider_installation.%s: %s", typ, err)
+			}
+			methods = append(methods, m)
+		}
+	}
+
+	return methods, nil
+}
+
 func loadConfigDir(path string) (*Config, tfdiags.Diagnostics) {
 	var diags tfdiags.Diagnostics
 	result := &Config{}
@@ -192,6 +343,14 @@ func EnvConfig() *Config {
 		// standard shell features.)
 		config.PluginCacheDir = envPluginCacheDir
 	}
+	if envModuleCacheDir := os.Getenv(moduleCacheDirEnvVar); envModuleCacheDir != "" {
+		config.ModuleCacheDir = envModuleCacheDir
+	}
+	if envApplyHook := strings.Fields(os.Getenv(applyHookEnvVar)); len(envApplyHook) > 0 {
+		config.ApplyHooks = map[string]*ConfigApplyHook{
+			applyHookEnvVar: {Command: envApplyHook},
+		}
+	}
 
 	return config
 }
@@ -240,6 +399,87 @@ func (c *Config) Validate() tfdiags.Diagnostics {
 		)
 	}
 
+	// Check that all development overrides are absolute paths, since
+	// they are otherwise interpreted relative to whatever directory
+	// Terraform happens to be run in.
+	for name, dir := range c.ProviderDevOverrides() {
+		if !filepath.IsAbs(dir) {
+			diags = diags.Append(
+				fmt.Errorf("The dev_overrides entry for provider %q must be an absolute path", name),
+			)
+		}
+	}
+
+	if c.ProviderInstallation != nil {
+		for _, m := range c.ProviderInstallation.Methods {
+			diags = diags.Append(m.Validate())
+		}
+	}
+
+	if a := c.ApplyApproval; a != nil {
+		switch a.DestroyConfirmation {
+		case "", backend.DestroyConfirmationYes, backend.DestroyConfirmationWorkspace:
+		default:
+			diags = diags.Append(
+				fmt.Errorf("The apply_approval destroy_confirmation must be %q or %q", backend.DestroyConfirmationYes, backend.DestroyConfirmationWorkspace),
+			)
+		}
+		for _, name := range a.AutoApproveAttributes {
+			if name == "" || strings.HasPrefix(name, ".") || strings.HasSuffix(name, ".") {
+				diags = diags.Append(
+					fmt.Errorf("The apply_approval block has an invalid attribute name %q", name),
+				)
+			}
+		}
+	}
+
+	for name, h := range c.ApplyHooks {
+		if len(h.Command) == 0 || h.Command[0] == "" {
+			diags = diags.Append(
+				fmt.Errorf("The apply_hook %q block must have a command", name),
+			)
+		}
+		for _, when := range h.When {
+			if when != command.ApplyHookPre && when != command.ApplyHookPost {
+				diags = diags.Append(
+					fmt.Errorf("The apply_hook %q block's when must be %q or %q, not %q", name, command.ApplyHookPre, command.ApplyHookPost, when),
+				)
+			}
+		}
+	}
+
+	return diags
+}
+
+// Validate checks for errors in the installation method, returning any
+// problems as diagnostics.
+func (m *ConfigProviderInstallationMethod) Validate() tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+
+	switch m.Type {
+	case "filesystem_mirror":
+		if !filepath.IsAbs(m.Path) {
+			diags = diags.Append(
+				fmt.Errorf("The filesystem_mirror path must be an absolute path"),
+			)
+		}
+	case "network_mirror":
+		u, err := url.Parse(m.URL)
+		if err != nil || u.Scheme != "https" || u.Host == "" {
+			diags = diags.Append(
+				fmt.Errorf("The network_mirror url %q must be an https URL", m.URL),
+			)
+		}
+	}
+
+	for _, pattern := range append(m.Include, m.Exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			diags = diags.Append(
+				fmt.Errorf("The %s block has an invalid provider name pattern %q", m.Type, pattern),
+			)
+		}
+	}
+
 	return diags
 }
 
@@ -275,6 +515,11 @@ func (c1 *Config) Merge(c2 *Config) *Config {
 		result.PluginCacheDir = c2.PluginCacheDir
 	}
 
+	result.ModuleCacheDir = c1.ModuleCacheDir
+	if result.ModuleCacheDir == "" {
+		result.ModuleCacheDir = c2.ModuleCacheDir
+	}
+
 	if (len(c1.Hosts) + len(c2.Hosts)) > 0 {
 		result.Hosts = make(map[string]*ConfigHost)
 		for name, host := range c1.Hosts {
@@ -308,5 +553,122 @@ func (c1 *Config) Merge(c2 *Config) *Config {
 		}
 	}
 
+	result.ProviderInstallation = c1.ProviderInstallation
+	if result.ProviderInstallation == nil {
+		result.ProviderInstallation = c2.ProviderInstallation
+	}
+
+	result.ApplyApproval = c1.ApplyApproval
+	if result.ApplyApproval == nil {
+		result.ApplyApproval = c2.ApplyApproval
+	}
+
+	if (len(c1.ApplyHooks) + len(c2.ApplyHooks)) > 0 {
+		result.ApplyHooks = make(map[string]*ConfigApplyHook)
+		for name, hook := range c1.ApplyHooks {
+			result.ApplyHooks[name] = hook
+		}
+		for name, hook := range c2.ApplyHooks {
+			result.ApplyHooks[name] = hook
+		}
+	}
+
 	return &result
 }
+
+// ApprovalPolicy returns the policy that decides how plans are approved,
+// from the apply_approval block, or nil if there's no such block.
+func (c *Config) ApprovalPolicy() *backend.ApprovalPolicy {
+	if c.ApplyApproval == nil {
+		return nil
+	}
+	return &backend.ApprovalPolicy{
+		AutoApproveAttributes: c.ApplyApproval.AutoApproveAttributes,
+		DestroyConfirmation:   c.ApplyApproval.DestroyConfirmation,
+	}
+}
+
+// DiffPager returns the command that plans are shown with before they're
+// approved, or an empty string to print them instead.
+func (c *Config) DiffPager() string {
+	if c.ApplyApproval == nil {
+		return ""
+	}
+	return c.ApplyApproval.Pager
+}
+
+// ApplyHookCommands returns the commands that are run before and after each
+// resource is applied, from the apply_hook blocks, sorted by name.
+func (c *Config) ApplyHookCommands() []*command.ApplyHookCommand {
+	names := make([]string, 0, len(c.ApplyHooks))
+	for name := range c.ApplyHooks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var cmds []*command.ApplyHookCommand
+	for _, name := range names {
+		h := c.ApplyHooks[name]
+		if len(h.Command) == 0 || h.Command[0] == "" {
+			// Validate reports hooks without a command, which have
+			// nothing to run.
+			continue
+		}
+		cmd := &command.ApplyHookCommand{
+			Name:      name,
+			Args:      h.Command,
+			PreApply:  len(h.When) == 0,
+			PostApply: len(h.When) == 0,
+		}
+		for _, when := range h.When {
+			switch when {
+			case command.ApplyHookPre:
+				cmd.PreApply = true
+			case command.ApplyHookPost:
+				cmd.PostApply = true
+			}
+		}
+		cmds = append(cmds, cmd)
+	}
+	return cmds
+}
+
+// ProviderSources returns the sources that providers may be installed from
+// according to the installation methods, in order of preference. If no
+// methods are configured then it returns nil, and providers are installed
+// directly from the official releases service.
+func (c *Config) ProviderSources() []*discovery.ProviderInstallationSource {
+	if c.ProviderInstallation == nil {
+		return nil
+	}
+
+	var sources []*discovery.ProviderInstallationSource
+	for _, m := range c.ProviderInstallation.Methods {
+		var source discovery.ProviderSource
+		switch m.Type {
+		case "direct":
+			source = discovery.DirectSource{}
+		case "filesystem_mirror":
+			source = &discovery.FilesystemMirrorSource{Dir: m.Path}
+		case "network_mirror":
+			source = &discovery.NetworkMirrorSource{URL: m.URL}
+		}
+
+		sources = append(sources, &discovery.ProviderInstallationSource{
+			Source:  source,
+			Include: m.Include,
+			Exclude: m.Exclude,
+		})
+	}
+	return sources
+}
+
+// ProviderDevOverrides returns the directories of any development builds
+// of providers that should be used instead of installed plugins, keyed by
+// provider name.
+func (c *Config) ProviderDevOverrides() map[string]string {
+	if c.ProviderInstallation == nil {
+		return nil
+	}
+	return c.ProviderInstallation.DevOverrides
+}
@@ -88,5 +88,17 @@ func Append(c1, c2 *Config) (*Config, error) {
 		c.Locals = append(c.Locals, c2.Locals...)
 	}
 
+	if len(c1.Moved) > 0 || len(c2.Moved) > 0 {
+		c.Moved = make([]*Moved, 0, len(c1.Moved)+len(c2.Moved))
+		c.Moved = append(c.Moved, c1.Moved...)
+		c.Moved = append(c.Moved, c2.Moved...)
+	}
+
+	if len(c1.Checks) > 0 || len(c2.Checks) > 0 {
+		c.Checks = make([]*Check, 0, len(c1.Checks)+len(c2.Checks))
+		c.Checks = append(c.Checks, c1.Checks...)
+		c.Checks = append(c.Checks, c2.Checks...)
+	}
+
 	return c, nil
 }
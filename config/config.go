@@ -5,11 +5,16 @@ package config
 import (
 	"fmt"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
 	hcl2 "github.com/hashicorp/hcl2/hcl"
 	"github.com/hashicorp/hil/ast"
+	"github.com/hashicorp/terraform/addrs"
 	"github.com/hashicorp/terraform/helper/hilmapstructure"
 	"github.com/hashicorp/terraform/plugin/discovery"
 	"github.com/hashicorp/terraform/tfdiags"
@@ -36,6 +41,8 @@ type Config struct {
 	Variables       []*Variable
 	Locals          []*Local
 	Outputs         []*Output
+	Moved           []*Moved
+	Checks          []*Check
 
 	// The fields below can be filled in by loaders for validation
 	// purposes.
@@ -59,6 +66,9 @@ type Module struct {
 	Version   string
 	Providers map[string]string
 	RawConfig *RawConfig
+
+	// DeclRange is the range of the module block's header.
+	DeclRange tfdiags.SourceRange
 }
 
 // ProviderConfig is the configuration for a resource provider.
@@ -69,7 +79,106 @@ type ProviderConfig struct {
 	Name      string
 	Alias     string
 	Version   string
+	Timeouts  *ProviderTimeouts
 	RawConfig *RawConfig
+	DeclRange tfdiags.SourceRange
+
+	// Parallelism, if greater than zero, limits the number of concurrent
+	// calls Terraform makes to this provider configuration, in addition to
+	// the overall limit on parallelism.
+	Parallelism int
+}
+
+// ProviderTimeouts are the limits on how long Terraform waits for each kind
+// of call to a provider, set by the "timeouts" block of a provider
+// configuration. A zero duration means there is no limit.
+type ProviderTimeouts struct {
+	Read  time.Duration // refreshing, importing and reading data sources
+	Plan  time.Duration // diffing
+	Apply time.Duration // applying
+}
+
+// NewProviderTimeouts parses the durations given in the "timeouts" block of
+// a provider configuration, keyed by the kind of call they apply to.
+func NewProviderTimeouts(raw map[string]string) (*ProviderTimeouts, error) {
+	var result ProviderTimeouts
+	for k, v := range raw {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s timeout %q: %s", k, v, err)
+		}
+		if d < 0 {
+			return nil, fmt.Errorf("invalid %s timeout %q: must not be negative", k, v)
+		}
+
+		switch k {
+		case "read":
+			result.Read = d
+		case "plan":
+			result.Plan = d
+		case "apply":
+			result.Apply = d
+		default:
+			return nil, fmt.Errorf("unsupported timeout %q: must be read, plan or apply", k)
+		}
+	}
+
+	return &result, nil
+}
+
+// Moved is a "moved" block, which records that the managed resource with
+// the address From has been renamed to To, or replaced by a resource of
+// another type that manages the same remote objects. Terraform moves any
+// existing state for From to To rather than planning to destroy and
+// recreate the remote objects.
+//
+// Both addresses are of the form TYPE.NAME, relative to the module the
+// block appears in.
+type Moved struct {
+	From string
+	To   string
+
+	DeclRange tfdiags.SourceRange `hcl:"-"`
+}
+
+// Check is a "check" block, which declares assertions about the
+// infrastructure that are evaluated on every plan and apply. A failing
+// assertion is reported as a warning and recorded in the plan and state,
+// but doesn't stop the plan or apply, and nothing can depend on a check.
+//
+// The data sources declared within a check are in the Resources of the
+// configuration, with their Check set, and only the check can refer to
+// them.
+type Check struct {
+	Name    string
+	Asserts []*CheckAssert
+
+	DeclRange tfdiags.SourceRange
+}
+
+// CheckAssert is an "assert" block of a check.
+type CheckAssert struct {
+	// Condition is the expression, under the key "condition", that is true
+	// when the assertion holds.
+	Condition *RawConfig
+
+	// ErrorMessage explains what's wrong when the condition is false.
+	ErrorMessage string
+
+	// DeclRange is the range of the condition in the configuration.
+	DeclRange tfdiags.SourceRange
+}
+
+// ParseMovedAddr splits an address from a "moved" block into the resource
+// type and name.
+func ParseMovedAddr(addr string) (typ, name string, err error) {
+	r, err := addrs.ParseResourceStr(addr)
+	if err != nil || r.Mode != addrs.ManagedResourceMode {
+		return "", "", fmt.Errorf(
+			"%q is not a valid managed resource address; must be of the form TYPE.NAME", addr)
+	}
+
+	return r.Type, r.Name, nil
 }
 
 // A resource represents a single Terraform resource in the configuration.
@@ -86,6 +195,18 @@ type Resource struct {
 	Provider     string
 	DependsOn    []string
 	Lifecycle    ResourceLifecycle
+
+	// Check is the name of the check block that a data source is declared
+	// in, if it's scoped to one.
+	Check string
+
+	// DeclRange is where the resource is declared, for diagnostics.
+	DeclRange tfdiags.SourceRange
+
+	// setKeys are the arguments and blocks that Terraform interprets itself,
+	// such as "count" and "lifecycle", that the resource sets explicitly,
+	// so that an override can tell them from their defaults.
+	setKeys []string
 }
 
 // Copy returns a copy of this Resource. Helpful for avoiding shared
@@ -102,6 +223,9 @@ func (r *Resource) Copy() *Resource {
 		Provider:     r.Provider,
 		DependsOn:    make([]string, len(r.DependsOn)),
 		Lifecycle:    *r.Lifecycle.Copy(),
+		Check:        r.Check,
+		DeclRange:    r.DeclRange,
+		setKeys:      r.setKeys,
 	}
 	for _, p := range r.Provisioners {
 		n.Provisioners = append(n.Provisioners, p.Copy())
@@ -116,6 +240,11 @@ type ResourceLifecycle struct {
 	CreateBeforeDestroy bool     `mapstructure:"create_before_destroy"`
 	PreventDestroy      bool     `mapstructure:"prevent_destroy"`
 	IgnoreChanges       []string `mapstructure:"ignore_changes"`
+
+	// Lock, if set, requests that the provider hold an advisory lock on
+	// the remote object while it is being created, updated or destroyed,
+	// so that concurrent Terraform runs can't change it at the same time.
+	Lock bool `mapstructure:"lock"`
 }
 
 // Copy returns a copy of this ResourceLifecycle
@@ -124,6 +253,7 @@ func (r *ResourceLifecycle) Copy() *ResourceLifecycle {
 		CreateBeforeDestroy: r.CreateBeforeDestroy,
 		PreventDestroy:      r.PreventDestroy,
 		IgnoreChanges:       make([]string, len(r.IgnoreChanges)),
+		Lock:                r.Lock,
 	}
 	copy(n.IgnoreChanges, r.IgnoreChanges)
 	return n
@@ -156,12 +286,38 @@ type Variable struct {
 	DeclaredType string `mapstructure:"type"`
 	Default      interface{}
 	Description  string
+
+	// Sensitive is true if the values of the variable must not be shown,
+	// and nor must the values derived from them.
+	Sensitive bool
+
+	// Validations are the rules that the values of the variable must
+	// follow, declared by "validation" blocks.
+	Validations []*VariableValidation
+
+	// DeclRange is the range of the variable's name in the configuration.
+	DeclRange tfdiags.SourceRange
+}
+
+// VariableValidation is a rule that the values of a variable must follow.
+type VariableValidation struct {
+	// Condition is the expression, under the key "condition", that must
+	// be true for a value to be valid. It may refer only to the variable
+	// that the rule belongs to.
+	Condition *RawConfig
+
+	// ErrorMessage explains why a value is invalid when it isn't.
+	ErrorMessage string
+
+	// DeclRange is the range of the condition in the configuration.
+	DeclRange tfdiags.SourceRange
 }
 
 // Local is a local value defined within the configuration.
 type Local struct {
 	Name      string
 	RawConfig *RawConfig
+	DeclRange tfdiags.SourceRange
 }
 
 // Output is an output defined within the configuration. An output is
@@ -174,6 +330,12 @@ type Output struct {
 	Description string
 	Sensitive   bool
 	RawConfig   *RawConfig
+	DeclRange   tfdiags.SourceRange
+
+	// setKeys are the arguments that the output sets explicitly, other
+	// than in RawConfig, so that an override can tell them from their
+	// defaults.
+	setKeys []string
 }
 
 // VariableType is the type of value a variable is holding, and returned
@@ -298,16 +460,21 @@ func (c *Config) Validate() tfdiags.Diagnostics {
 		for _, err := range errs {
 			diags = diags.Append(err)
 		}
+		for _, p := range tf.RequiredProviders {
+			for _, err := range p.Validate() {
+				diags = diags.Append(errorAt(p.DeclRange, err))
+			}
+		}
 	}
 
 	vars := c.InterpolatedVariables()
 	varMap := make(map[string]*Variable)
 	for _, v := range c.Variables {
 		if _, ok := varMap[v.Name]; ok {
-			diags = diags.Append(fmt.Errorf(
+			diags = diags.Append(errorAt(v.DeclRange, fmt.Errorf(
 				"Variable '%s': duplicate found. Variable names must be unique.",
 				v.Name,
-			))
+			)))
 		}
 
 		varMap[v.Name] = v
@@ -315,19 +482,19 @@ func (c *Config) Validate() tfdiags.Diagnostics {
 
 	for k, _ := range varMap {
 		if !NameRegexp.MatchString(k) {
-			diags = diags.Append(fmt.Errorf(
+			diags = diags.Append(errorAt(varMap[k].DeclRange, fmt.Errorf(
 				"variable %q: variable name must match regular expression %s",
 				k, NameRegexp,
-			))
+			)))
 		}
 	}
 
 	for _, v := range c.Variables {
 		if v.Type() == VariableTypeUnknown {
-			diags = diags.Append(fmt.Errorf(
+			diags = diags.Append(errorAt(v.DeclRange, fmt.Errorf(
 				"Variable '%s': must be a string or a map",
 				v.Name,
-			))
+			)))
 			continue
 		}
 
@@ -348,13 +515,17 @@ func (c *Config) Validate() tfdiags.Diagnostics {
 		if v.Default != nil {
 			if err := reflectwalk.Walk(v.Default, w); err == nil {
 				if interp {
-					diags = diags.Append(fmt.Errorf(
+					diags = diags.Append(errorAt(v.DeclRange, fmt.Errorf(
 						"variable %q: default may not contain interpolations",
 						v.Name,
-					))
+					)))
 				}
 			}
 		}
+
+		for _, validation := range v.Validations {
+			diags = diags.Append(validation.validate(v.Name))
+		}
 	}
 
 	// Check for references to user variables that do not actually
@@ -406,33 +577,58 @@ func (c *Config) Validate() tfdiags.Diagnostics {
 	for _, p := range c.ProviderConfigs {
 		name := p.FullName()
 		if _, ok := providerSet[name]; ok {
-			diags = diags.Append(fmt.Errorf(
+			diags = diags.Append(errorAt(p.DeclRange, fmt.Errorf(
 				"provider.%s: multiple configurations present; only one configuration is allowed per provider",
 				name,
-			))
+			)))
 			continue
 		}
 
+		if p.Parallelism < 0 {
+			diags = diags.Append(attributeErrorAt(p.DeclRange, "provider."+name, "parallelism", fmt.Errorf(
+				"provider.%s: parallelism must not be negative", name,
+			)))
+		}
+
 		if p.Version != "" {
 			_, err := discovery.ConstraintStr(p.Version).Parse()
 			if err != nil {
-				diags = diags.Append(&hcl2.Diagnostic{
+				var versionDiags tfdiags.Diagnostics
+				versionDiags = versionDiags.Append(&hcl2.Diagnostic{
 					Severity: hcl2.DiagError,
 					Summary:  "Invalid provider version constraint",
 					Detail: fmt.Sprintf(
 						"The value %q given for provider.%s is not a valid version constraint.",
 						p.Version, name,
 					),
-					// TODO: include a "Subject" source reference in here,
-					// once the config loader is able to retain source
-					// location information.
+					Subject: p.DeclRange.ToHCL().Ptr(),
 				})
+				diags = diags.Append(versionDiags.WithExtra(tfdiags.Extra{
+					Address:       "provider." + name,
+					AttributePath: "version",
+				}))
 			}
 		}
 
 		providerSet[name] = true
 	}
 
+	// The configurations that callers pass in are also available to pass on
+	// to the module's own children.
+	if tf := c.Terraform; tf != nil {
+		for _, p := range tf.RequiredProviders {
+			for _, alias := range p.ConfigurationAliases {
+				if providerSet[alias] {
+					diags = diags.Append(errorAt(p.DeclRange, fmt.Errorf(
+						"terraform.required_providers.%s: configuration alias %q is also configured by a provider block; remove one of them",
+						p.Name, alias,
+					)))
+				}
+				providerSet[alias] = true
+			}
+		}
+	}
+
 	// Check that all references to modules are valid
 	modules := make(map[string]*Module)
 	dupped := make(map[string]struct{})
@@ -442,10 +638,10 @@ func (c *Config) Validate() tfdiags.Diagnostics {
 			if _, ok := dupped[m.Id()]; !ok {
 				dupped[m.Id()] = struct{}{}
 
-				diags = diags.Append(fmt.Errorf(
+				diags = diags.Append(errorAt(m.DeclRange, fmt.Errorf(
 					"module %q: module repeated multiple times",
 					m.Id(),
-				))
+				)))
 			}
 
 			// Already seen this module, just skip it
@@ -459,23 +655,23 @@ func (c *Config) Validate() tfdiags.Diagnostics {
 			"root": m.Source,
 		})
 		if err != nil {
-			diags = diags.Append(fmt.Errorf(
+			diags = diags.Append(attributeErrorAt(m.DeclRange, "module."+m.Name, "source", fmt.Errorf(
 				"module %q: module source error: %s",
 				m.Id(), err,
-			))
+			)))
 		} else if len(rc.Interpolations) > 0 {
-			diags = diags.Append(fmt.Errorf(
+			diags = diags.Append(attributeErrorAt(m.DeclRange, "module."+m.Name, "source", fmt.Errorf(
 				"module %q: module source cannot contain interpolations",
 				m.Id(),
-			))
+			)))
 		}
 
 		// Check that the name matches our regexp
 		if !NameRegexp.Match([]byte(m.Name)) {
-			diags = diags.Append(fmt.Errorf(
+			diags = diags.Append(errorAt(m.DeclRange, fmt.Errorf(
 				"module %q: module name must be a letter or underscore followed by only letters, numbers, dashes, and underscores",
 				m.Id(),
-			))
+			)))
 		}
 
 		// Check that the configuration can all be strings, lists or maps
@@ -499,44 +695,58 @@ func (c *Config) Validate() tfdiags.Diagnostics {
 				continue
 			}
 
-			diags = diags.Append(fmt.Errorf(
+			diags = diags.Append(attributeErrorAt(m.DeclRange, "module."+m.Name, k, fmt.Errorf(
 				"module %q: argument %s must have a string, list, or map value",
 				m.Id(), k,
-			))
+			)))
 		}
 
 		// Check for invalid count variables
 		for _, v := range m.RawConfig.Variables {
 			switch v.(type) {
 			case *CountVariable:
-				diags = diags.Append(fmt.Errorf(
+				diags = diags.Append(errorAt(m.DeclRange, fmt.Errorf(
 					"module %q: count variables are only valid within resources",
 					m.Name,
-				))
+				)))
 			case *SelfVariable:
-				diags = diags.Append(fmt.Errorf(
+				diags = diags.Append(errorAt(m.DeclRange, fmt.Errorf(
 					"module %q: self variables are only valid within resources",
 					m.Name,
-				))
+				)))
 			}
 		}
 
 		// Update the raw configuration to only contain the string values
 		m.RawConfig, err = NewRawConfig(raw)
 		if err != nil {
-			diags = diags.Append(fmt.Errorf(
+			diags = diags.Append(errorAt(m.DeclRange, fmt.Errorf(
 				"%s: can't initialize configuration: %s",
 				m.Id(), err,
-			))
+			)))
+		}
+
+		// check that all named providers actually exist, and that they're
+		// passed as providers the child can know them by
+		names := make([]string, 0, len(m.Providers))
+		for name := range m.Providers {
+			names = append(names, name)
 		}
+		sort.Strings(names)
+		for _, name := range names {
+			if !validProviderName(name) {
+				diags = diags.Append(errorAt(m.DeclRange, fmt.Errorf(
+					"module %q: invalid provider name %q in providers; must be NAME or NAME.ALIAS",
+					m.Name, name,
+				)))
+			}
 
-		// check that all named providers actually exist
-		for _, p := range m.Providers {
+			p := m.Providers[name]
 			if !providerSet[p] {
-				diags = diags.Append(fmt.Errorf(
-					"module %q: cannot pass non-existent provider %q",
+				diags = diags.Append(errorAt(m.DeclRange, fmt.Errorf(
+					"module %q: cannot pass non-existent provider %q; it must be configured by a provider block or declared in configuration_aliases",
 					m.Name, p,
-				))
+				)))
 			}
 		}
 
@@ -569,10 +779,10 @@ func (c *Config) Validate() tfdiags.Diagnostics {
 			if _, ok := dupped[r.Id()]; !ok {
 				dupped[r.Id()] = struct{}{}
 
-				diags = diags.Append(fmt.Errorf(
+				diags = diags.Append(errorAt(r.DeclRange, fmt.Errorf(
 					"%s: resource repeated multiple times",
 					r.Id(),
-				))
+				)))
 			}
 		}
 
@@ -586,15 +796,15 @@ func (c *Config) Validate() tfdiags.Diagnostics {
 		for _, v := range r.RawCount.Variables {
 			switch v.(type) {
 			case *CountVariable:
-				diags = diags.Append(fmt.Errorf(
+				diags = diags.Append(attributeErrorAt(r.DeclRange, n, "count", fmt.Errorf(
 					"%s: resource count can't reference count variable: %s",
 					n, v.FullKey(),
-				))
+				)))
 			case *SimpleVariable:
-				diags = diags.Append(fmt.Errorf(
+				diags = diags.Append(attributeErrorAt(r.DeclRange, n, "count", fmt.Errorf(
 					"%s: resource count can't reference variable: %s",
 					n, v.FullKey(),
-				))
+				)))
 
 			// Good
 			case *ModuleVariable:
@@ -612,9 +822,9 @@ func (c *Config) Validate() tfdiags.Diagnostics {
 		}
 
 		if !r.RawCount.couldBeInteger() {
-			diags = diags.Append(fmt.Errorf(
+			diags = diags.Append(attributeErrorAt(r.DeclRange, n, "count", fmt.Errorf(
 				"%s: resource count must be an integer", n,
-			))
+			)))
 		}
 		r.RawCount.init()
 
@@ -635,10 +845,10 @@ func (c *Config) Validate() tfdiags.Diagnostics {
 				}
 
 				if rv.Multi && rv.Index == -1 && rv.Type == r.Type && rv.Name == r.Name {
-					diags = diags.Append(fmt.Errorf(
+					diags = diags.Append(errorAt(r.DeclRange, fmt.Errorf(
 						"%s: connection info cannot contain splat variable referencing itself",
 						n,
-					))
+					)))
 					break
 				}
 			}
@@ -650,10 +860,10 @@ func (c *Config) Validate() tfdiags.Diagnostics {
 				}
 
 				if rv.Multi && rv.Index == -1 && rv.Type == r.Type && rv.Name == r.Name {
-					diags = diags.Append(fmt.Errorf(
+					diags = diags.Append(errorAt(r.DeclRange, fmt.Errorf(
 						"%s: connection info cannot contain splat variable referencing itself",
 						n,
-					))
+					)))
 					break
 				}
 			}
@@ -661,24 +871,24 @@ func (c *Config) Validate() tfdiags.Diagnostics {
 			// Check for invalid when/onFailure values, though this should be
 			// picked up by the loader we check here just in case.
 			if p.When == ProvisionerWhenInvalid {
-				diags = diags.Append(fmt.Errorf(
+				diags = diags.Append(errorAt(r.DeclRange, fmt.Errorf(
 					"%s: provisioner 'when' value is invalid", n,
-				))
+				)))
 			}
 			if p.OnFailure == ProvisionerOnFailureInvalid {
-				diags = diags.Append(fmt.Errorf(
+				diags = diags.Append(errorAt(r.DeclRange, fmt.Errorf(
 					"%s: provisioner 'on_failure' value is invalid", n,
-				))
+				)))
 			}
 		}
 
 		// Verify ignore_changes contains valid entries
 		for _, v := range r.Lifecycle.IgnoreChanges {
 			if strings.Contains(v, "*") && v != "*" {
-				diags = diags.Append(fmt.Errorf(
+				diags = diags.Append(errorAt(r.DeclRange, fmt.Errorf(
 					"%s: ignore_changes does not support using a partial string together with a wildcard: %s",
 					n, v,
-				))
+				)))
 			}
 		}
 
@@ -687,24 +897,24 @@ func (c *Config) Validate() tfdiags.Diagnostics {
 			"root": r.Lifecycle.IgnoreChanges,
 		})
 		if err != nil {
-			diags = diags.Append(fmt.Errorf(
+			diags = diags.Append(errorAt(r.DeclRange, fmt.Errorf(
 				"%s: lifecycle ignore_changes error: %s",
 				n, err,
-			))
+			)))
 		} else if len(rc.Interpolations) > 0 {
-			diags = diags.Append(fmt.Errorf(
+			diags = diags.Append(errorAt(r.DeclRange, fmt.Errorf(
 				"%s: lifecycle ignore_changes cannot contain interpolations",
 				n,
-			))
+			)))
 		}
 
 		// If it is a data source then it can't have provisioners
 		if r.Mode == DataResourceMode {
 			if _, ok := r.RawConfig.Raw["provisioner"]; ok {
-				diags = diags.Append(fmt.Errorf(
+				diags = diags.Append(errorAt(r.DeclRange, fmt.Errorf(
 					"%s: data sources cannot have provisioners",
 					n,
-				))
+				)))
 			}
 		}
 	}
@@ -734,19 +944,19 @@ func (c *Config) Validate() tfdiags.Diagnostics {
 		found := make(map[string]struct{})
 		for _, l := range c.Locals {
 			if _, ok := found[l.Name]; ok {
-				diags = diags.Append(fmt.Errorf(
+				diags = diags.Append(errorAt(l.DeclRange, fmt.Errorf(
 					"%s: duplicate local. local value names must be unique",
 					l.Name,
-				))
+				)))
 				continue
 			}
 			found[l.Name] = struct{}{}
 
 			for _, v := range l.RawConfig.Variables {
 				if _, ok := v.(*CountVariable); ok {
-					diags = diags.Append(fmt.Errorf(
+					diags = diags.Append(errorAt(l.DeclRange, fmt.Errorf(
 						"local %s: count variables are only valid within resources", l.Name,
-					))
+					)))
 				}
 			}
 		}
@@ -758,10 +968,10 @@ func (c *Config) Validate() tfdiags.Diagnostics {
 		for _, o := range c.Outputs {
 			// Verify the output is new
 			if _, ok := found[o.Name]; ok {
-				diags = diags.Append(fmt.Errorf(
+				diags = diags.Append(errorAt(o.DeclRange, fmt.Errorf(
 					"output %q: an output of this name was already defined",
 					o.Name,
-				))
+				)))
 				continue
 			}
 			found[o.Name] = struct{}{}
@@ -781,10 +991,10 @@ func (c *Config) Validate() tfdiags.Diagnostics {
 						continue
 					}
 
-					diags = diags.Append(fmt.Errorf(
+					diags = diags.Append(errorAt(o.DeclRange, fmt.Errorf(
 						"output %q: value for 'sensitive' must be boolean",
 						o.Name,
-					))
+					)))
 					continue
 				}
 				if k == "description" {
@@ -793,32 +1003,32 @@ func (c *Config) Validate() tfdiags.Diagnostics {
 						continue
 					}
 
-					diags = diags.Append(fmt.Errorf(
+					diags = diags.Append(errorAt(o.DeclRange, fmt.Errorf(
 						"output %q: value for 'description' must be string",
 						o.Name,
-					))
+					)))
 					continue
 				}
 				invalidKeys = append(invalidKeys, k)
 			}
 			if len(invalidKeys) > 0 {
-				diags = diags.Append(fmt.Errorf(
+				diags = diags.Append(errorAt(o.DeclRange, fmt.Errorf(
 					"output %q: invalid keys: %s",
 					o.Name, strings.Join(invalidKeys, ", "),
-				))
+				)))
 			}
 			if !valueKeyFound {
-				diags = diags.Append(fmt.Errorf(
+				diags = diags.Append(errorAt(o.DeclRange, fmt.Errorf(
 					"output %q: missing required 'value' argument", o.Name,
-				))
+				)))
 			}
 
 			for _, v := range o.RawConfig.Variables {
 				if _, ok := v.(*CountVariable); ok {
-					diags = diags.Append(fmt.Errorf(
+					diags = diags.Append(errorAt(o.DeclRange, fmt.Errorf(
 						"output %q: count variables are only valid within resources",
 						o.Name,
-					))
+					)))
 				}
 			}
 
@@ -870,6 +1080,79 @@ func (c *Config) Validate() tfdiags.Diagnostics {
 		}
 	}
 
+	// Check that all moved blocks move a removed resource to a declared one
+	managed := make(map[string]bool)
+	for _, r := range c.Resources {
+		if r.Mode == ManagedResourceMode {
+			managed[r.Id()] = true
+		}
+	}
+	movedFrom := make(map[string]bool)
+	for _, m := range c.Moved {
+		_, _, fromErr := ParseMovedAddr(m.From)
+		_, _, toErr := ParseMovedAddr(m.To)
+		switch {
+		case fromErr != nil:
+			diags = diags.Append(errorAt(m.DeclRange, fmt.Errorf("moved block: from: %s", fromErr)))
+		case toErr != nil:
+			diags = diags.Append(errorAt(m.DeclRange, fmt.Errorf("moved block: to: %s", toErr)))
+		case m.From == m.To:
+			diags = diags.Append(errorAt(m.DeclRange, fmt.Errorf(
+				"moved block: cannot move %s to itself", m.From)))
+		case movedFrom[m.From]:
+			diags = diags.Append(errorAt(m.DeclRange, fmt.Errorf(
+				"moved block: %s is moved more than once", m.From)))
+		case managed[m.From]:
+			diags = diags.Append(errorAt(m.DeclRange, fmt.Errorf(
+				"moved block: cannot move %s, because it is still declared; "+
+					"remove its resource block, or the moved block", m.From)))
+		case !managed[m.To]:
+			diags = diags.Append(errorAt(m.DeclRange, fmt.Errorf(
+				"moved block: cannot move %s to %s, because %s is not declared",
+				m.From, m.To, m.To)))
+		}
+		movedFrom[m.From] = true
+	}
+
+	// Check that the checks are valid, and that the data sources scoped to
+	// a check are only referred to from within it.
+	checks := make(map[string]bool)
+	for _, ch := range c.Checks {
+		if checks[ch.Name] {
+			diags = diags.Append(errorAt(ch.DeclRange, fmt.Errorf(
+				"check %s: duplicate check. check names must be unique", ch.Name)))
+		}
+		checks[ch.Name] = true
+		diags = diags.Append(ch.validate())
+	}
+	scoped := make(map[string]string)
+	for _, r := range c.Resources {
+		if r.Check != "" {
+			scoped[r.Id()] = r.Check
+		}
+	}
+	if len(scoped) > 0 {
+		for source, vs := range vars {
+			for _, v := range vs {
+				rv, ok := v.(*ResourceVariable)
+				if !ok {
+					continue
+				}
+				check, ok := scoped[rv.ResourceId()]
+				if !ok || checkOfSource(source, check, scoped) {
+					continue
+				}
+				diags = diags.Append(fmt.Errorf(
+					"%s: cannot refer to %s, since it's declared in check %q and only that check can refer to it",
+					source, rv.ResourceId(), check))
+			}
+		}
+	}
+
+	// Validate that self, count and each are referred to only where
+	// they're in scope, where the checks above don't already.
+	diags = diags.Append(c.validateReferenceScopes())
+
 	// Validate the self variable
 	for source, rc := range c.rawConfigs() {
 		// Ignore provisioners. This is a pretty brittle way to do this,
@@ -888,7 +1171,34 @@ func (c *Config) Validate() tfdiags.Diagnostics {
 		}
 	}
 
-	return diags
+	return diags.WithCode(tfdiags.CodeConfigInvalid)
+}
+
+// errorAt returns err as an error diagnostic about the part of the
+// configuration at rng. The diagnostic's summary is the whole message, so
+// that it reads as before where the source isn't shown. Configurations that
+// weren't loaded from files have no ranges, so their errors are returned as
+// they are.
+func errorAt(rng tfdiags.SourceRange, err error) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+	if rng.Filename == "" {
+		return diags.Append(err)
+	}
+
+	return diags.Append(&hcl2.Diagnostic{
+		Severity: hcl2.DiagError,
+		Summary:  err.Error(),
+		Subject:  rng.ToHCL().Ptr(),
+	})
+}
+
+// attributeErrorAt returns err as an error diagnostic as errorAt does, about
+// the given attribute of the object at addr.
+func attributeErrorAt(rng tfdiags.SourceRange, addr, attr string, err error) tfdiags.Diagnostics {
+	return errorAt(rng, err).WithExtra(tfdiags.Extra{
+		Address:       addr,
+		AttributePath: attr,
+	})
 }
 
 // InterpolatedVariables is a helper that returns a mapping of all the interpolated
@@ -928,6 +1238,9 @@ func (c *Config) rawConfigs() map[string]*RawConfig {
 				"%s provisioner %s (#%d)",
 				source, p.Type, i+1)
 			result[subsource] = p.RawConfig
+			if p.ConnInfo != nil {
+				result[subsource+" connection"] = p.ConnInfo
+			}
 		}
 	}
 
@@ -936,6 +1249,13 @@ func (c *Config) rawConfigs() map[string]*RawConfig {
 		result[source] = o.RawConfig
 	}
 
+	for _, ch := range c.Checks {
+		for i, a := range ch.Asserts {
+			source := fmt.Sprintf("check '%s' assert (#%d)", ch.Name, i+1)
+			result[source] = a.Condition
+		}
+	}
+
 	return result
 }
 
@@ -1042,6 +1362,12 @@ func (c *ProviderConfig) mergerMerge(m merger) merger {
 	if c2.Alias != "" {
 		result.Alias = c2.Alias
 	}
+	if c2.Timeouts != nil {
+		result.Timeouts = c2.Timeouts
+	}
+	if c2.Parallelism != 0 {
+		result.Parallelism = c2.Parallelism
+	}
 
 	return &result
 }
@@ -1087,10 +1413,120 @@ func (v *Variable) Merge(v2 *Variable) *Variable {
 	if v2.Description != "" {
 		result.Description = v2.Description
 	}
+	if v2.Sensitive {
+		result.Sensitive = true
+	}
+	if len(v2.Validations) > 0 {
+		result.Validations = v2.Validations
+	}
 
 	return &result
 }
 
+// validate checks that the condition of the rule refers only to the
+// variable with the given name, and that its error message is made of
+// sentences, since it's shown as part of a longer message.
+func (v *VariableValidation) validate(name string) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+	subject := v.DeclRange.ToHCL()
+
+	for _, rawV := range v.Condition.Variables {
+		if uv, ok := rawV.(*UserVariable); ok && uv.Name == name {
+			continue
+		}
+		diags = diags.Append(&hcl2.Diagnostic{
+			Severity: hcl2.DiagError,
+			Summary:  "Invalid reference in variable validation",
+			Detail: fmt.Sprintf(
+				"The condition for variable %q can only refer to the variable itself, using var.%s, not to %s.",
+				name, name, rawV.FullKey(),
+			),
+			Subject: &subject,
+		})
+	}
+
+	msg := strings.TrimSpace(v.ErrorMessage)
+	if msg == "" {
+		diags = diags.Append(&hcl2.Diagnostic{
+			Severity: hcl2.DiagError,
+			Summary:  "Missing error message in variable validation",
+			Detail:   fmt.Sprintf("The validation rule for variable %q must have an error_message explaining why a value is invalid.", name),
+			Subject:  &subject,
+		})
+	} else if !looksLikeSentences(msg) {
+		diags = diags.Append(&hcl2.Diagnostic{
+			Severity: hcl2.DiagError,
+			Summary:  "Invalid error message in variable validation",
+			Detail: fmt.Sprintf(
+				"The error_message for variable %q must be at least one full sentence, starting with an uppercase letter and ending with a period or question mark.",
+				name,
+			),
+			Subject: &subject,
+		})
+	}
+
+	return diags
+}
+
+// validate checks that the check has assertions, and that their error
+// messages are made of sentences, since they're shown as part of a longer
+// message.
+func (ch *Check) validate() tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+	if len(ch.Asserts) == 0 {
+		diags = diags.Append(errorAt(ch.DeclRange, fmt.Errorf(
+			"check %s: must have at least one assert block", ch.Name)))
+	}
+
+	for _, a := range ch.Asserts {
+		subject := a.DeclRange.ToHCL()
+		msg := strings.TrimSpace(a.ErrorMessage)
+		if msg == "" {
+			diags = diags.Append(&hcl2.Diagnostic{
+				Severity: hcl2.DiagError,
+				Summary:  "Missing error message in check assertion",
+				Detail:   fmt.Sprintf("The assertions of check %q must have an error_message explaining what's wrong when they fail.", ch.Name),
+				Subject:  &subject,
+			})
+		} else if !looksLikeSentences(msg) {
+			diags = diags.Append(&hcl2.Diagnostic{
+				Severity: hcl2.DiagError,
+				Summary:  "Invalid error message in check assertion",
+				Detail: fmt.Sprintf(
+					"The error_message of an assertion of check %q must be at least one full sentence, starting with an uppercase letter and ending with a period or question mark.",
+					ch.Name,
+				),
+				Subject: &subject,
+			})
+		}
+	}
+
+	return diags
+}
+
+// checkOfSource returns true if the given source, as named by rawConfigs,
+// is within the check with the given name: one of its assertions, or one
+// of the data sources scoped to it.
+func checkOfSource(source, check string, scoped map[string]string) bool {
+	if strings.HasPrefix(source, fmt.Sprintf("check '%s' ", check)) {
+		return true
+	}
+	for id, c := range scoped {
+		if c == check && strings.HasPrefix(source, fmt.Sprintf("resource '%s' ", id)) {
+			return true
+		}
+	}
+	return false
+}
+
+// looksLikeSentences returns whether s starts with an uppercase letter and
+// ends with a period or question mark, like English sentences do.
+func looksLikeSentences(s string) bool {
+	first, _ := utf8.DecodeRuneInString(s)
+	last, _ := utf8.DecodeLastRuneInString(s)
+	return unicode.IsUpper(first) && (last == '.' || last == '?')
+}
+
 var typeStringMap = map[string]VariableType{
 	"string": VariableTypeString,
 	"map":    VariableTypeMap,
@@ -1101,34 +1537,73 @@ var typeStringMap = map[string]VariableType{
 func (v *Variable) Type() VariableType {
 	if v.DeclaredType != "" {
 		declaredType, ok := typeStringMap[v.DeclaredType]
-		if !ok {
-			return VariableTypeUnknown
+		if ok {
+			return declaredType
 		}
 
-		return declaredType
+		t, err := v.TypeConstraint()
+		if err != nil || t == nil {
+			return VariableTypeUnknown
+		}
+		return t.VariableType()
 	}
 
 	return v.inferTypeFromDefault()
 }
 
+// TypeConstraint returns the type constraint that the variable declares,
+// such as "list(string)" or "object({name = string})", which values are
+// converted to.
+//
+// It returns nil for variables that declare no type, or just "string",
+// "list" or "map", whose values are checked but never converted.
+func (v *Variable) TypeConstraint() (*TypeConstraint, error) {
+	if _, ok := typeStringMap[v.DeclaredType]; ok || v.DeclaredType == "" {
+		return nil, nil
+	}
+	return ParseTypeConstraint(v.DeclaredType)
+}
+
 // ValidateTypeAndDefault ensures that default variable value is compatible
 // with the declared type (if one exists), and that the type is one which is
 // known to Terraform
 func (v *Variable) ValidateTypeAndDefault() error {
 	// If an explicit type is declared, ensure it is valid
-	if v.DeclaredType != "" {
-		if _, ok := typeStringMap[v.DeclaredType]; !ok {
-			validTypes := []string{}
-			for k := range typeStringMap {
+	t, err := v.TypeConstraint()
+	if err != nil {
+		if strings.ContainsAny(v.DeclaredType, "({") {
+			return fmt.Errorf("Variable '%s' has an %s", v.Name, err)
+		}
+		validTypes := []string{}
+		for k := range typeKindNames {
+			if k != "any" {
 				validTypes = append(validTypes, k)
 			}
+		}
+		sort.Strings(validTypes)
+		return fmt.Errorf(
+			"Variable '%s' type must be one of [%s] - '%s' is not a valid type",
+			v.Name,
+			strings.Join(validTypes, ", "),
+			v.DeclaredType,
+		)
+	}
+	if t != nil {
+		if t.Kind == TypeKindAny {
 			return fmt.Errorf(
-				"Variable '%s' type must be one of [%s] - '%s' is not a valid type",
-				v.Name,
-				strings.Join(validTypes, ", "),
-				v.DeclaredType,
-			)
+				"Variable '%s' type must not be 'any', which is only allowed for "+
+					"the elements of lists, sets and maps", v.Name)
+		}
+		if v.Default == nil {
+			return nil
 		}
+		def, err := t.Convert(v.Default)
+		if err != nil {
+			return fmt.Errorf("'%s' has a default value which is not of type '%s': %s",
+				v.Name, v.DeclaredType, err)
+		}
+		v.Default = def
+		return nil
 	}
 
 	if v.DeclaredType == "" || v.Default == nil {
@@ -0,0 +1,183 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	hcl2 "github.com/hashicorp/hcl2/hcl"
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+// referenceScope is the kind of block that an expression is in, which
+// decides which references to self, count and each it may make.
+type referenceScope int
+
+const (
+	// scopeOther is any block outside of a resource, such as a provider,
+	// module, output, local or check.
+	scopeOther referenceScope = iota
+
+	// scopeCount is the count argument of a resource, which is evaluated
+	// before the resource has any instances.
+	scopeCount
+
+	// scopeResource is the other arguments of a resource, which are
+	// evaluated for each of its instances.
+	scopeResource
+
+	// scopeProvisioner is a provisioner or connection block of a resource,
+	// which is evaluated for each instance after it's created, so that it
+	// can refer to the instance's attributes with self.
+	scopeProvisioner
+)
+
+// referenceError returns why the reference with the given name, which has
+// the given root, can't be made from the scope, or "" if it can.
+func (s referenceScope) referenceError(root, name string) string {
+	switch root {
+	case "self":
+		if s != scopeProvisioner {
+			return "self references are only valid in provisioner and connection blocks"
+		}
+	case "count":
+		switch {
+		case name != "count.index":
+			return "the only count attribute is count.index"
+		case s == scopeOther:
+			return "count variables are only valid within resources"
+		case s == scopeCount:
+			return "a resource's count can't refer to count.index"
+		}
+	case "each":
+		return errEachUnsupported
+	}
+	return ""
+}
+
+// errEachUnsupported is why references to each can't be made.
+const errEachUnsupported = "each.key and each.value are only valid in resources with for_each, which this version of Terraform doesn't support"
+
+// validateReferenceScopes checks that the references to self, count and
+// each in the configuration are made from blocks where they're valid.
+//
+// The configurations of the HCL2 experiment are checked for all of them,
+// since none of the other checks of references see their expressions.
+// The other configurations are checked only for the roots that the other
+// checks don't cover for the block, which are given as legacy roots; they
+// can't refer to each at all, since NewInterpolatedVariable rejects it.
+func (c *Config) validateReferenceScopes() tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+	check := func(source string, rc *RawConfig, scope referenceScope, legacy ...string) {
+		if rc == nil {
+			return
+		}
+		for _, ref := range rawConfigReferences(rc) {
+			root := strings.SplitN(ref.Name, ".", 2)[0]
+			if rc.Body == nil && containsString(legacy, root) {
+				continue
+			}
+			msg := scope.referenceError(root, ref.Name)
+			if msg == "" {
+				continue
+			}
+
+			err := fmt.Errorf("%s: invalid reference %s: %s", source, ref.Name, msg)
+			if ref.Range == nil {
+				diags = diags.Append(err)
+				continue
+			}
+			diags = diags.Append(&hcl2.Diagnostic{
+				Severity: hcl2.DiagError,
+				Summary:  "Invalid reference",
+				Detail:   err.Error(),
+				Subject:  ref.Range,
+			})
+		}
+	}
+
+	for _, m := range c.Modules {
+		check(fmt.Sprintf("module %q", m.Name), m.RawConfig, scopeOther, "count", "self")
+	}
+	for _, pc := range c.ProviderConfigs {
+		check(fmt.Sprintf("provider config '%s'", pc.Name), pc.RawConfig, scopeOther, "self")
+	}
+	for _, l := range c.Locals {
+		check(fmt.Sprintf("local %s", l.Name), l.RawConfig, scopeOther, "count")
+	}
+	for _, o := range c.Outputs {
+		check(fmt.Sprintf("output %q", o.Name), o.RawConfig, scopeOther, "count", "self")
+	}
+	for _, ch := range c.Checks {
+		for i, a := range ch.Asserts {
+			check(fmt.Sprintf("check '%s' assert (#%d)", ch.Name, i+1), a.Condition, scopeOther, "self")
+		}
+	}
+	for _, r := range c.Resources {
+		source := fmt.Sprintf("resource '%s'", r.Id())
+		check(source+" count", r.RawCount, scopeCount, "count", "self")
+		check(source+" config", r.RawConfig, scopeResource, "count", "self")
+		for i, p := range r.Provisioners {
+			subsource := fmt.Sprintf("%s provisioner %s (#%d)", source, p.Type, i+1)
+			check(subsource, p.RawConfig, scopeProvisioner, "count", "self")
+			check(subsource+" connection", p.ConnInfo, scopeProvisioner, "count", "self")
+		}
+	}
+
+	return diags
+}
+
+// rawConfigReference is a reference made by an expression of a RawConfig.
+type rawConfigReference struct {
+	// Name is the reference's root and first attribute, such as
+	// "self.private_ip" or "count.index".
+	Name string
+
+	// Range is where the reference is, if it's known.
+	Range *hcl2.Range
+}
+
+// rawConfigReferences returns the references that are made by the given
+// configuration. For the HCL2 experiment these are found in the block's
+// own attributes, but not in its nested blocks, whose structure isn't
+// known without a schema.
+func rawConfigReferences(rc *RawConfig) []rawConfigReference {
+	var result []rawConfigReference
+	if rc.Body == nil {
+		for _, v := range rc.Variables {
+			result = append(result, rawConfigReference{Name: v.FullKey()})
+		}
+		sort.Slice(result, func(i, j int) bool {
+			return result[i].Name < result[j].Name
+		})
+		return result
+	}
+
+	// The diagnostics are about nested blocks, which are skipped, and the
+	// attributes are returned regardless.
+	attrs, _ := rc.Body.JustAttributes()
+	names := make([]string, 0, len(attrs))
+	for name := range attrs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		for _, tr := range attrs[name].Expr.Variables() {
+			root, ok := tr[0].(hcl2.TraverseRoot)
+			if !ok {
+				continue
+			}
+			ref := rawConfigReference{Name: root.Name, Range: root.SrcRange.Ptr()}
+			if len(tr) > 1 {
+				if attr, ok := tr[1].(hcl2.TraverseAttr); ok {
+					ref.Name += "." + attr.Name
+					rng := hcl2.RangeBetween(root.SrcRange, attr.SrcRange)
+					ref.Range = &rng
+				}
+			}
+			result = append(result, ref)
+		}
+	}
+	return result
+}
@@ -357,6 +357,9 @@ func variablesStr(vs []*Variable) string {
 		if v.DeclaredType != "" {
 			declaredType = fmt.Sprintf(" (%s)", v.DeclaredType)
 		}
+		if v.Sensitive {
+			declaredType += " (sensitive)"
+		}
 
 		if v.Default == nil || v.Default == "" {
 			v.Default = "<>"
@@ -5,6 +5,8 @@ import (
 	"strings"
 
 	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/terraform/registry/regsrc"
+	"github.com/hashicorp/terraform/tfdiags"
 	"github.com/mitchellh/hashstructure"
 )
 
@@ -13,6 +15,15 @@ import (
 type Terraform struct {
 	RequiredVersion string   `hcl:"required_version"` // Required Terraform version (constraint)
 	Backend         *Backend // See Backend struct docs
+
+	// ProviderMetas is the metadata this module passes to the providers
+	// that manage its resources. See ProviderMeta struct docs.
+	ProviderMetas []*ProviderMeta `hcl:"-"`
+
+	// RequiredProviders are the providers this module requires, with
+	// their source addresses and version constraints. See RequiredProvider
+	// struct docs.
+	RequiredProviders []*RequiredProvider `hcl:"-"`
 }
 
 // Validate performs the validation for just the Terraform configuration.
@@ -44,6 +55,26 @@ func (t *Terraform) Validate() []error {
 		errs = append(errs, t.Backend.Validate()...)
 	}
 
+	names := make(map[string]struct{})
+	for _, m := range t.ProviderMetas {
+		if _, ok := names[m.Name]; ok {
+			errs = append(errs, fmt.Errorf(
+				"terraform.provider_meta.%s: declared multiple times", m.Name))
+		}
+		names[m.Name] = struct{}{}
+
+		errs = append(errs, m.Validate()...)
+	}
+
+	names = make(map[string]struct{})
+	for _, p := range t.RequiredProviders {
+		if _, ok := names[p.Name]; ok {
+			errs = append(errs, fmt.Errorf(
+				"terraform.required_providers.%s: declared multiple times", p.Name))
+		}
+		names[p.Name] = struct{}{}
+	}
+
 	return errs
 }
 
@@ -57,6 +88,140 @@ func (t *Terraform) Merge(t2 *Terraform) {
 	if t2.Backend != nil {
 		t.Backend = t2.Backend
 	}
+
+	for _, m2 := range t2.ProviderMetas {
+		replaced := false
+		for i, m := range t.ProviderMetas {
+			if m.Name == m2.Name {
+				t.ProviderMetas[i] = m2
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			t.ProviderMetas = append(t.ProviderMetas, m2)
+		}
+	}
+
+	for _, p2 := range t2.RequiredProviders {
+		replaced := false
+		for i, p := range t.RequiredProviders {
+			if p.Name == p2.Name {
+				t.RequiredProviders[i] = p2
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			t.RequiredProviders = append(t.RequiredProviders, p2)
+		}
+	}
+}
+
+// RequiredProvider is an entry in the required_providers block, which
+// declares a provider the module uses by the name the module knows it by,
+// along with where to install it from and which of its versions the
+// module works with.
+//
+// An entry can be just a version constraint, as in aws = "~> 1.0", in which
+// case the provider is the one of that name in the "hashicorp" namespace of
+// the public registry.
+type RequiredProvider struct {
+	Name string // Local name of the provider, such as "aws"
+
+	// Source is the source address of the provider, in the form
+	// [HOSTNAME/]NAMESPACE/TYPE, or empty for the default.
+	Source string
+
+	// Version is the version constraint, or empty if any version will do.
+	Version string
+
+	// ConfigurationAliases are the full names of the aliased configurations
+	// of the provider that the module uses without configuring them itself,
+	// such as "aws.west". Every call of the module must pass each of them
+	// in its providers argument.
+	ConfigurationAliases []string
+
+	DeclRange tfdiags.SourceRange
+}
+
+// DefaultProviderNamespace is the registry namespace of providers whose
+// source address isn't given.
+const DefaultProviderNamespace = "hashicorp"
+
+// SourceAddr returns the parsed source address of the provider, defaulting
+// to the provider of the same name in the "hashicorp" namespace of the
+// public registry.
+func (p *RequiredProvider) SourceAddr() (*regsrc.Provider, error) {
+	if p.Source == "" {
+		return &regsrc.Provider{
+			RawNamespace: DefaultProviderNamespace,
+			RawType:      p.Name,
+		}, nil
+	}
+	return regsrc.ParseProviderSource(p.Source)
+}
+
+func (p *RequiredProvider) Validate() []error {
+	var errs []error
+
+	addr, err := p.SourceAddr()
+	if err != nil {
+		errs = append(errs, fmt.Errorf(
+			"terraform.required_providers.%s: invalid source address %q: must be in the form [HOSTNAME/]NAMESPACE/TYPE",
+			p.Name, p.Source))
+	} else if typ := strings.ToLower(addr.RawType); typ != p.Name {
+		// Terraform finds and configures providers by their type, so
+		// a provider can't be known by any other name.
+		errs = append(errs, fmt.Errorf(
+			"terraform.required_providers.%s: the provider's local name must be its type %q",
+			p.Name, typ))
+	}
+
+	if p.Version != "" {
+		if _, err := version.NewConstraint(p.Version); err != nil {
+			errs = append(errs, fmt.Errorf(
+				"terraform.required_providers.%s: invalid version constraint: %s",
+				p.Name, err))
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, alias := range p.ConfigurationAliases {
+		parts := strings.SplitN(alias, ".", 2)
+		if len(parts) != 2 || parts[0] != p.Name || !NameRegexp.MatchString(parts[1]) {
+			errs = append(errs, fmt.Errorf(
+				"terraform.required_providers.%s: invalid configuration alias %q: must be in the form %s.ALIAS",
+				p.Name, alias, p.Name))
+			continue
+		}
+		if seen[alias] {
+			errs = append(errs, fmt.Errorf(
+				"terraform.required_providers.%s: configuration alias %q is declared more than once",
+				p.Name, alias))
+		}
+		seen[alias] = true
+	}
+
+	return errs
+}
+
+// ProviderMeta is opaque metadata that a module passes to a provider when
+// it is configured, such as a key that lets the provider attribute its use
+// to the module. Terraform only sends it to providers that report that
+// they accept it, and only for the modules whose resources use them.
+type ProviderMeta struct {
+	Name      string // Provider type name, such as "aws"
+	RawConfig *RawConfig
+}
+
+func (m *ProviderMeta) Validate() []error {
+	if len(m.RawConfig.Interpolations) > 0 {
+		return []error{fmt.Errorf(
+			"terraform.provider_meta.%s: cannot contain interpolations", m.Name)}
+	}
+
+	return nil
 }
 
 // Backend is the configuration for the "backend" to use with Terraform.
@@ -8,11 +8,14 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"sort"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/hil/ast"
 	"github.com/hashicorp/terraform/helper/logging"
+	"github.com/hashicorp/terraform/tfdiags"
 )
 
 // This is the directory where our test fixtures are.
@@ -201,6 +204,42 @@ func TestConfigValidate_table(t *testing.T) {
 			true,
 			"cannot contain interp",
 		},
+		{
+			"provider_meta with interpolations",
+			"validate-provider-meta-interpolate",
+			true,
+			"cannot contain interp",
+		},
+		{
+			"provider_meta declared twice",
+			"validate-provider-meta-dup",
+			true,
+			"declared multiple times",
+		},
+		{
+			"required provider with invalid source",
+			"validate-required-providers-source",
+			true,
+			"invalid source address",
+		},
+		{
+			"required provider named differently from its type",
+			"validate-required-providers-type",
+			true,
+			`local name must be its type "gadgets"`,
+		},
+		{
+			"required provider with invalid version constraint",
+			"validate-required-providers-version",
+			true,
+			"invalid version constraint",
+		},
+		{
+			"required provider declared twice",
+			"validate-required-providers-dup",
+			true,
+			"declared multiple times",
+		},
 		{
 			"nested types in variable default",
 			"validate-var-nested",
@@ -225,6 +264,60 @@ func TestConfigValidate_table(t *testing.T) {
 			true,
 			"cannot pass non-existent provider",
 		},
+		{
+			"invalid provider name in module providers",
+			"validate-module-provider-name",
+			true,
+			`invalid provider name "aws.west.east"`,
+		},
+		{
+			"configuration alias of another provider",
+			"validate-required-providers-alias",
+			true,
+			`invalid configuration alias "google.west"`,
+		},
+		{
+			"configuration alias also configured",
+			"validate-configuration-alias-configured",
+			true,
+			"also configured by a provider block",
+		},
+		{
+			"moved resource",
+			"moved",
+			false,
+			"",
+		},
+		{
+			"moved to undeclared resource",
+			"validate-moved-undeclared",
+			true,
+			"because aws_instance.baz is not declared",
+		},
+		{
+			"moved resource still declared",
+			"validate-moved-still-declared",
+			true,
+			"because it is still declared",
+		},
+		{
+			"check",
+			"checks",
+			false,
+			"",
+		},
+		{
+			"check scoped data source referred to outside the check",
+			"validate-check-scoped-ref",
+			true,
+			"only that check can refer to it",
+		},
+		{
+			"check without assertions",
+			"validate-check-no-assert",
+			true,
+			"must have at least one assert block",
+		},
 	}
 
 	for i, tc := range cases {
@@ -397,9 +490,16 @@ func TestConfigValidate_dupModule(t *testing.T) {
 
 func TestConfigValidate_dupResource(t *testing.T) {
 	c := testConfig(t, "validate-dup-resource")
-	if err := c.Validate(); err == nil {
+	diags := c.Validate()
+	if !diags.HasErrors() {
 		t.Fatal("should not be valid")
 	}
+	if got, want := diags[0].Source().Subject.Start.Line, 5; got != want {
+		t.Fatalf("wrong line %d; want %d", got, want)
+	}
+	if got, want := diags.Err().Error(), "aws_instance.web: resource repeated multiple times"; got != want {
+		t.Fatalf("wrong error %q; want %q", got, want)
+	}
 }
 
 func TestConfigValidate_ignoreChanges(t *testing.T) {
@@ -534,6 +634,13 @@ func TestConfigValidate_providerMultiRefGood(t *testing.T) {
 	}
 }
 
+func TestConfigValidate_providerParallelismNegative(t *testing.T) {
+	c := testConfig(t, "validate-provider-parallelism-negative")
+	if err := c.Validate(); err == nil {
+		t.Fatal("should not be valid")
+	}
+}
+
 func TestConfigValidate_provConnSplatOther(t *testing.T) {
 	c := testConfig(t, "validate-prov-conn-splat-other")
 	if err := c.Validate(); err != nil {
@@ -576,6 +683,70 @@ func TestConfigValidate_resourceVarSelf(t *testing.T) {
 	}
 }
 
+func TestConfigValidate_providerCountVar(t *testing.T) {
+	c := testConfig(t, "validate-provider-count-var")
+	if err := c.Validate(); err == nil {
+		t.Fatal("should not be valid")
+	}
+}
+
+func TestConfigValidate_connVarInvalid(t *testing.T) {
+	c := testConfig(t, "validate-conn-var-invalid")
+	diags := c.Validate()
+	if !diags.HasErrors() {
+		t.Fatal("should not be valid")
+	}
+	if err := diags.Err(); !strings.Contains(err.Error(), "connection: invalid count variable: count.foo") {
+		t.Fatalf("wrong error: %s", err)
+	}
+}
+
+func TestConfigValidate_eachVar(t *testing.T) {
+	_, err := LoadFile(filepath.Join(fixtureDir, "validate-each-var", "main.tf"))
+	if err == nil {
+		t.Fatal("should not load")
+	}
+	if !strings.Contains(err.Error(), "each.key and each.value are only valid in resources with for_each") {
+		t.Fatalf("wrong error: %s", err)
+	}
+}
+
+func TestConfigValidate_scopeHCL2(t *testing.T) {
+	c := testConfigHCL2(t, "validate-scope-hcl2")
+	if diags := c.validateReferenceScopes(); diags.HasErrors() {
+		t.Fatalf("should be valid: %s", diags.Err())
+	}
+}
+
+func TestConfigValidate_scopeHCL2Invalid(t *testing.T) {
+	c := testConfigHCL2(t, "validate-scope-hcl2-invalid")
+	diags := c.validateReferenceScopes()
+
+	var got []string
+	for _, diag := range diags {
+		got = append(got, diag.Description().Detail)
+	}
+	expected := []string{
+		"provider config 'aws': invalid reference self.region: self references are only valid in provisioner and connection blocks",
+		"local index: invalid reference count.index: count variables are only valid within resources",
+		"output \"ip\": invalid reference self.public_ip: self references are only valid in provisioner and connection blocks",
+		"resource 'aws_instance.web' count: invalid reference count.index: a resource's count can't refer to count.index",
+		"resource 'aws_instance.web' config: invalid reference self.ami: self references are only valid in provisioner and connection blocks",
+		"resource 'aws_instance.web' config: invalid reference each.key: " + errEachUnsupported,
+		"resource 'aws_instance.web' provisioner local-exec (#1): invalid reference count.foo: the only count attribute is count.index",
+	}
+	sort.Strings(got)
+	sort.Strings(expected)
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("wrong errors\ngot:  %s\nwant: %s", strings.Join(got, "\n      "), strings.Join(expected, "\n      "))
+	}
+	for _, diag := range diags {
+		if diag.Source().Subject == nil {
+			t.Fatalf("error has no source: %s", diag.Description().Detail)
+		}
+	}
+}
+
 func TestConfigValidate_unknownThing(t *testing.T) {
 	c := testConfig(t, "validate-unknownthing")
 	if err := c.Validate(); err == nil {
@@ -639,6 +810,35 @@ func TestConfigValidate_varDefaultInterpolateEscaped(t *testing.T) {
 	}
 }
 
+func TestConfigValidate_varValidation(t *testing.T) {
+	c := testConfig(t, "validate-var-validation")
+	if diags := c.Validate(); len(diags) != 0 {
+		t.Fatalf("should be valid: %s", diags.Err())
+	}
+}
+
+func TestConfigValidate_varValidationRef(t *testing.T) {
+	c := testConfig(t, "validate-var-validation-ref")
+	diags := c.Validate()
+	if !diags.HasErrors() {
+		t.Fatal("should not be valid")
+	}
+	if got, want := diags.Err().Error(), "can only refer to the variable itself"; !strings.Contains(got, want) {
+		t.Fatalf("wrong error %q; want it to contain %q", got, want)
+	}
+}
+
+func TestConfigValidate_varValidationMessage(t *testing.T) {
+	c := testConfig(t, "validate-var-validation-msg")
+	diags := c.Validate()
+	if !diags.HasErrors() {
+		t.Fatal("should not be valid")
+	}
+	if got, want := diags[0].Source().Subject.Start.Line, 3; got != want {
+		t.Fatalf("wrong line %d; want %d", got, want)
+	}
+}
+
 func TestConfigValidate_varDup(t *testing.T) {
 	c := testConfig(t, "validate-var-dup")
 	if err := c.Validate(); err == nil {
@@ -676,9 +876,13 @@ func TestConfigValidate_varModuleInvalid(t *testing.T) {
 
 func TestConfigValidate_varProviderVersionInvalid(t *testing.T) {
 	c := testConfig(t, "validate-provider-version-invalid")
-	if err := c.Validate(); err == nil {
+	diags := c.Validate()
+	if !diags.HasErrors() {
 		t.Fatal("should not be valid")
 	}
+	if diags[0].Source().Subject == nil {
+		t.Fatal("diagnostic has no subject")
+	}
 }
 
 func TestNameRegexp(t *testing.T) {
@@ -757,6 +961,52 @@ func testConfigHCL2(t *testing.T, name string) *Config {
 	return cfg
 }
 
+func TestConfigDeclRanges(t *testing.T) {
+	loaders := map[string]func(*testing.T, string) *Config{
+		"HCL":  testConfig,
+		"HCL2": testConfigHCL2,
+	}
+
+	for name, load := range loaders {
+		t.Run(name, func(t *testing.T) {
+			c := load(t, "decl-ranges")
+
+			got := map[string]tfdiags.SourceRange{
+				"variable": c.Variables[0].DeclRange,
+				"provider": c.ProviderConfigs[0].DeclRange,
+				"module":   c.Modules[0].DeclRange,
+				"local":    c.Locals[0].DeclRange,
+				"output":   c.Outputs[0].DeclRange,
+			}
+			for _, r := range c.Resources {
+				got[r.Id()] = r.DeclRange
+			}
+			want := map[string]int{
+				"variable":            1,
+				"provider":            3,
+				"module":              7,
+				"aws_instance.web":    11,
+				"data.aws_ami.ubuntu": 13,
+				"local":               16,
+				"output":              19,
+			}
+			for k, line := range want {
+				rng, ok := got[k]
+				if !ok {
+					t.Errorf("no %s", k)
+					continue
+				}
+				if rng.Filename != filepath.Join(fixtureDir, "decl-ranges", "main.tf") {
+					t.Errorf("wrong filename for %s: %s", k, rng.Filename)
+				}
+				if rng.Start.Line != line {
+					t.Errorf("wrong line for %s: %d; want %d", k, rng.Start.Line, line)
+				}
+			}
+		})
+	}
+}
+
 func TestConfigDataCount(t *testing.T) {
 	c := testConfig(t, "data-count")
 	actual, err := c.Resources[0].Count()
@@ -795,6 +1045,129 @@ func TestConfigProviderVersion(t *testing.T) {
 	}
 }
 
+func TestConfigProviderTimeouts(t *testing.T) {
+	loaders := map[string]func(*testing.T, string) *Config{
+		"HCL":  testConfig,
+		"HCL2": testConfigHCL2,
+	}
+
+	for name, load := range loaders {
+		t.Run(name, func(t *testing.T) {
+			c := load(t, "provider-timeouts")
+
+			if len(c.ProviderConfigs) != 1 {
+				t.Fatal("expected 1 provider")
+			}
+
+			p := c.ProviderConfigs[0]
+			want := &ProviderTimeouts{
+				Read:  2 * time.Minute,
+				Apply: time.Hour,
+			}
+			if !reflect.DeepEqual(p.Timeouts, want) {
+				t.Fatalf("wrong timeouts\ngot:  %#v\nwant: %#v", p.Timeouts, want)
+			}
+
+			if _, ok := p.RawConfig.Raw["timeouts"]; ok {
+				t.Fatal("'timeouts' should not exist in raw config")
+			}
+		})
+	}
+}
+
+func TestConfigProviderParallelism(t *testing.T) {
+	loaders := map[string]func(*testing.T, string) *Config{
+		"HCL":  testConfig,
+		"HCL2": testConfigHCL2,
+	}
+
+	for name, load := range loaders {
+		t.Run(name, func(t *testing.T) {
+			c := load(t, "provider-parallelism")
+
+			got := make(map[string]int)
+			for _, p := range c.ProviderConfigs {
+				got[p.FullName()] = p.Parallelism
+
+				if _, ok := p.RawConfig.Raw["parallelism"]; ok {
+					t.Fatal("'parallelism' should not exist in raw config")
+				}
+			}
+			want := map[string]int{
+				"aws":      0,
+				"aws.west": 2,
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("wrong parallelism\ngot:  %#v\nwant: %#v", got, want)
+			}
+		})
+	}
+}
+
+func TestConfigProviderTimeouts_invalid(t *testing.T) {
+	_, err := LoadFile(filepath.Join(fixtureDir, "provider-timeouts-invalid", "main.tf"))
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if !strings.Contains(err.Error(), "invalid plan timeout") {
+		t.Fatalf("wrong error: %s", err)
+	}
+}
+
+func TestConfigMoved(t *testing.T) {
+	loaders := map[string]func(*testing.T, string) *Config{
+		"HCL":  testConfig,
+		"HCL2": testConfigHCL2,
+	}
+
+	for name, load := range loaders {
+		t.Run(name, func(t *testing.T) {
+			c := load(t, "moved")
+
+			if len(c.Moved) != 1 {
+				t.Fatalf("wrong number of moved blocks %d; want 1", len(c.Moved))
+			}
+			if got, want := c.Moved[0].DeclRange.Start.Line, 5; got != want {
+				t.Fatalf("wrong line %d; want %d", got, want)
+			}
+			c.Moved[0].DeclRange = tfdiags.SourceRange{}
+
+			want := []*Moved{
+				{From: "aws_instance.foo", To: "aws_instance.bar"},
+			}
+			if !reflect.DeepEqual(c.Moved, want) {
+				t.Fatalf("wrong moved blocks\ngot:  %#v\nwant: %#v", c.Moved, want)
+			}
+		})
+	}
+}
+
+func TestParseMovedAddr(t *testing.T) {
+	cases := map[string]struct {
+		Type, Name string
+		Err        bool
+	}{
+		"aws_instance.foo":      {"aws_instance", "foo", false},
+		"aws_instance":          {"", "", true},
+		"aws_instance.foo.bar":  {"", "", true},
+		"data.aws_ami.foo":      {"", "", true},
+		"module.foo":            {"", "", true},
+		"aws_instance.${var.a}": {"", "", true},
+	}
+
+	for addr, tc := range cases {
+		t.Run(addr, func(t *testing.T) {
+			typ, name, err := ParseMovedAddr(addr)
+			if (err != nil) != tc.Err {
+				t.Fatalf("err: %s", err)
+			}
+			if typ != tc.Type || name != tc.Name {
+				t.Fatalf("bad: %q %q", typ, name)
+			}
+		})
+	}
+}
+
 func TestResourceProviderFullName(t *testing.T) {
 	type testCase struct {
 		ResourceName string
@@ -148,6 +148,8 @@ func NewInterpolatedVariable(v string) (InterpolatedVariable, error) {
 		return NewLocalVariable(v)
 	} else if strings.HasPrefix(v, "module.") {
 		return NewModuleVariable(v)
+	} else if strings.HasPrefix(v, "each.") {
+		return nil, fmt.Errorf("%s: %s", v, errEachUnsupported)
 	} else if !strings.ContainsRune(v, '.') {
 		return NewSimpleVariable(v)
 	} else {
@@ -14,10 +14,15 @@ import (
 	"encoding/json"
 	"encoding/pem"
 	"fmt"
+	"hash"
+	"io"
 	"io/ioutil"
 	"math"
+	"math/big"
 	"net"
 	"net/url"
+	"os"
+	"path"
 	"path/filepath"
 	"regexp"
 	"sort"
@@ -25,12 +30,12 @@ import (
 	"strings"
 	"time"
 
-	"github.com/apparentlymart/go-cidr/cidr"
 	"github.com/hashicorp/go-uuid"
 	"github.com/hashicorp/hil"
 	"github.com/hashicorp/hil/ast"
 	"github.com/mitchellh/go-homedir"
 	"golang.org/x/crypto/bcrypt"
+	yaml "gopkg.in/yaml.v2"
 )
 
 // stringSliceToVariableValue converts a string slice into the value
@@ -61,68 +66,87 @@ func listVariableValueToStringSlice(values []ast.Variable) ([]string, error) {
 // Funcs is the mapping of built-in functions for configuration.
 func Funcs() map[string]ast.Function {
 	return map[string]ast.Function{
-		"abs":          interpolationFuncAbs(),
-		"basename":     interpolationFuncBasename(),
-		"base64decode": interpolationFuncBase64Decode(),
-		"base64encode": interpolationFuncBase64Encode(),
-		"base64gzip":   interpolationFuncBase64Gzip(),
-		"base64sha256": interpolationFuncBase64Sha256(),
-		"base64sha512": interpolationFuncBase64Sha512(),
-		"bcrypt":       interpolationFuncBcrypt(),
-		"ceil":         interpolationFuncCeil(),
-		"chomp":        interpolationFuncChomp(),
-		"cidrhost":     interpolationFuncCidrHost(),
-		"cidrnetmask":  interpolationFuncCidrNetmask(),
-		"cidrsubnet":   interpolationFuncCidrSubnet(),
-		"coalesce":     interpolationFuncCoalesce(),
-		"coalescelist": interpolationFuncCoalesceList(),
-		"compact":      interpolationFuncCompact(),
-		"concat":       interpolationFuncConcat(),
-		"contains":     interpolationFuncContains(),
-		"dirname":      interpolationFuncDirname(),
-		"distinct":     interpolationFuncDistinct(),
-		"element":      interpolationFuncElement(),
-		"chunklist":    interpolationFuncChunklist(),
-		"file":         interpolationFuncFile(),
-		"matchkeys":    interpolationFuncMatchKeys(),
-		"flatten":      interpolationFuncFlatten(),
-		"floor":        interpolationFuncFloor(),
-		"format":       interpolationFuncFormat(),
-		"formatlist":   interpolationFuncFormatList(),
-		"indent":       interpolationFuncIndent(),
-		"index":        interpolationFuncIndex(),
-		"join":         interpolationFuncJoin(),
-		"jsonencode":   interpolationFuncJSONEncode(),
-		"length":       interpolationFuncLength(),
-		"list":         interpolationFuncList(),
-		"log":          interpolationFuncLog(),
-		"lower":        interpolationFuncLower(),
-		"map":          interpolationFuncMap(),
-		"max":          interpolationFuncMax(),
-		"md5":          interpolationFuncMd5(),
-		"merge":        interpolationFuncMerge(),
-		"min":          interpolationFuncMin(),
-		"pathexpand":   interpolationFuncPathExpand(),
-		"pow":          interpolationFuncPow(),
-		"uuid":         interpolationFuncUUID(),
-		"replace":      interpolationFuncReplace(),
-		"rsadecrypt":   interpolationFuncRsaDecrypt(),
-		"sha1":         interpolationFuncSha1(),
-		"sha256":       interpolationFuncSha256(),
-		"sha512":       interpolationFuncSha512(),
-		"signum":       interpolationFuncSignum(),
-		"slice":        interpolationFuncSlice(),
-		"sort":         interpolationFuncSort(),
-		"split":        interpolationFuncSplit(),
-		"substr":       interpolationFuncSubstr(),
-		"timestamp":    interpolationFuncTimestamp(),
-		"timeadd":      interpolationFuncTimeAdd(),
-		"title":        interpolationFuncTitle(),
-		"transpose":    interpolationFuncTranspose(),
-		"trimspace":    interpolationFuncTrimSpace(),
-		"upper":        interpolationFuncUpper(),
-		"urlencode":    interpolationFuncURLEncode(),
-		"zipmap":       interpolationFuncZipMap(),
+		"abs":              interpolationFuncAbs(),
+		"basename":         interpolationFuncBasename(),
+		"base64decode":     interpolationFuncBase64Decode(),
+		"base64encode":     interpolationFuncBase64Encode(),
+		"base64gzip":       interpolationFuncBase64Gzip(),
+		"base64sha256":     interpolationFuncBase64Sha256(),
+		"base64sha512":     interpolationFuncBase64Sha512(),
+		"bcrypt":           interpolationFuncBcrypt(),
+		"ceil":             interpolationFuncCeil(),
+		"chomp":            interpolationFuncChomp(),
+		"cidrhost":         interpolationFuncCidrHost(),
+		"cidrnetmask":      interpolationFuncCidrNetmask(),
+		"cidrcontains":     interpolationFuncCidrContains(),
+		"cidrsubnet":       interpolationFuncCidrSubnet(),
+		"cidrsubnets":      interpolationFuncCidrSubnets(),
+		"coalesce":         interpolationFuncCoalesce(),
+		"coalescelist":     interpolationFuncCoalesceList(),
+		"compact":          interpolationFuncCompact(),
+		"concat":           interpolationFuncConcat(),
+		"contains":         interpolationFuncContains(),
+		"dirname":          interpolationFuncDirname(),
+		"distinct":         interpolationFuncDistinct(),
+		"element":          interpolationFuncElement(),
+		"chunklist":        interpolationFuncChunklist(),
+		"file":             interpolationFuncFile(),
+		"filebase64":       interpolationFuncFileBase64(),
+		"filebase64sha256": interpolationFuncFileHash(sha256.New, base64.StdEncoding.EncodeToString),
+		"filebase64sha512": interpolationFuncFileHash(sha512.New, base64.StdEncoding.EncodeToString),
+		"filemd5":          interpolationFuncFileHash(md5.New, hex.EncodeToString),
+		"fileset":          interpolationFuncFileSet(),
+		"filesha1":         interpolationFuncFileHash(sha1.New, hex.EncodeToString),
+		"filesha256":       interpolationFuncFileHash(sha256.New, hex.EncodeToString),
+		"filesha512":       interpolationFuncFileHash(sha512.New, hex.EncodeToString),
+		"matchkeys":        interpolationFuncMatchKeys(),
+		"flatten":          interpolationFuncFlatten(),
+		"floor":            interpolationFuncFloor(),
+		"format":           interpolationFuncFormat(),
+		"formatdate":       interpolationFuncFormatDate(),
+		"formatlist":       interpolationFuncFormatList(),
+		"indent":           interpolationFuncIndent(),
+		"index":            interpolationFuncIndex(),
+		"join":             interpolationFuncJoin(),
+		"jsondecode":       interpolationFuncJSONDecode(),
+		"jsonencode":       interpolationFuncJSONEncode(),
+		"length":           interpolationFuncLength(),
+		"list":             interpolationFuncList(),
+		"log":              interpolationFuncLog(),
+		"lower":            interpolationFuncLower(),
+		"map":              interpolationFuncMap(),
+		"max":              interpolationFuncMax(),
+		"md5":              interpolationFuncMd5(),
+		"merge":            interpolationFuncMerge(),
+		"min":              interpolationFuncMin(),
+		"pathexpand":       interpolationFuncPathExpand(),
+		"pow":              interpolationFuncPow(),
+		"uuid":             interpolationFuncUUID(),
+		"replace":          interpolationFuncReplace(),
+		"rsadecrypt":       interpolationFuncRsaDecrypt(),
+		"setproduct":       interpolationFuncSetProduct(),
+		"sha1":             interpolationFuncSha1(),
+		"sha256":           interpolationFuncSha256(),
+		"sha512":           interpolationFuncSha512(),
+		"signum":           interpolationFuncSignum(),
+		"slice":            interpolationFuncSlice(),
+		"sort":             interpolationFuncSort(),
+		"split":            interpolationFuncSplit(),
+		"substr":           interpolationFuncSubstr(),
+		"sum":              interpolationFuncSum(),
+		"templatefile":     interpolationFuncTemplateFile(),
+		"timestamp":        interpolationFuncTimestamp(),
+		"timeadd":          interpolationFuncTimeAdd(),
+		"timecmp":          interpolationFuncTimeCmp(),
+		"plantimestamp":    PlanTimestampFunc(time.Time{}),
+		"title":            interpolationFuncTitle(),
+		"transpose":        interpolationFuncTranspose(),
+		"trimspace":        interpolationFuncTrimSpace(),
+		"upper":            interpolationFuncUpper(),
+		"urlencode":        interpolationFuncURLEncode(),
+		"yamldecode":       interpolationFuncYAMLDecode(),
+		"yamlencode":       interpolationFuncYAMLEncode(),
+		"zipmap":           interpolationFuncZipMap(),
 	}
 }
 
@@ -256,7 +280,7 @@ func interpolationFuncCidrHost() ast.Function {
 				return nil, fmt.Errorf("invalid CIDR expression: %s", err)
 			}
 
-			ip, err := cidr.Host(network, hostNum)
+			ip, err := cidrHost(network, hostNum)
 			if err != nil {
 				return nil, err
 			}
@@ -306,15 +330,7 @@ func interpolationFuncCidrSubnet() ast.Function {
 				return nil, fmt.Errorf("invalid CIDR expression: %s", err)
 			}
 
-			// For portability with 32-bit systems where the subnet number
-			// will be a 32-bit int, we only allow extension of 32 bits in
-			// one call even if we're running on a 64-bit machine.
-			// (Of course, this is significant only for IPv6.)
-			if extraBits > 32 {
-				return nil, fmt.Errorf("may not extend prefix by more than 32 bits")
-			}
-
-			newNetwork, err := cidr.Subnet(network, extraBits, subnetNum)
+			newNetwork, err := cidrSubnet(network, extraBits, subnetNum)
 			if err != nil {
 				return nil, err
 			}
@@ -324,6 +340,179 @@ func interpolationFuncCidrSubnet() ast.Function {
 	}
 }
 
+// interpolationFuncCidrSubnets implements the "cidrsubnets" function that
+// allocates consecutive subnets of an IP block expressed in CIDR notation,
+// each extending its prefix by the corresponding number of bits. Each
+// subnet starts at the first address after the previous one that is
+// aligned to the subnet's size, so some addresses may be skipped when a
+// smaller subnet is followed by a larger one.
+func interpolationFuncCidrSubnets() ast.Function {
+	return ast.Function{
+		ArgTypes: []ast.Type{
+			ast.TypeString, // starting CIDR mask
+		},
+		ReturnType:   ast.TypeList,
+		Variadic:     true,
+		VariadicType: ast.TypeInt, // numbers of bits to extend the prefix
+		Callback: func(args []interface{}) (interface{}, error) {
+			_, network, err := net.ParseCIDR(args[0].(string))
+			if err != nil {
+				return nil, fmt.Errorf("invalid CIDR expression: %s", err)
+			}
+			if len(args) < 2 {
+				return nil, fmt.Errorf("cidrsubnets: at least one number of bits to extend the prefix is required")
+			}
+
+			prefixLen, bits := network.Mask.Size()
+			size := len(network.IP)
+			start := new(big.Int).SetBytes(network.IP)
+			end := new(big.Int).Lsh(big.NewInt(1), uint(bits-prefixLen))
+			end.Add(end, start)
+
+			next := new(big.Int).Set(start)
+			var prev *net.IPNet
+			result := make([]ast.Variable, 0, len(args)-1)
+			for _, arg := range args[1:] {
+				newBits := arg.(int)
+				newPrefixLen := prefixLen + newBits
+				if newBits < 0 {
+					return nil, fmt.Errorf("cidrsubnets: invalid number of bits %d to extend the prefix", newBits)
+				}
+				if newPrefixLen > bits {
+					return nil, fmt.Errorf("cidrsubnets: insufficient address space to extend prefix of %d by %d", prefixLen, newBits)
+				}
+
+				// Round up to the next subnet boundary.
+				subnetSize := new(big.Int).Lsh(big.NewInt(1), uint(bits-newPrefixLen))
+				mod := new(big.Int).Mod(new(big.Int).Sub(next, start), subnetSize)
+				if mod.Sign() != 0 {
+					next.Add(next, subnetSize)
+					next.Sub(next, mod)
+				}
+				if new(big.Int).Add(next, subnetSize).Cmp(end) > 0 {
+					if prev == nil {
+						return nil, fmt.Errorf("cidrsubnets: not enough address space in %s for a subnet with a prefix of %d bits", network, newPrefixLen)
+					}
+					return nil, fmt.Errorf("cidrsubnets: not enough address space in %s after %s for a subnet with a prefix of %d bits", network, prev, newPrefixLen)
+				}
+
+				prev = &net.IPNet{
+					IP:   bigToIP(next, size),
+					Mask: net.CIDRMask(newPrefixLen, bits),
+				}
+				result = append(result, ast.Variable{Type: ast.TypeString, Value: prev.String()})
+				next.Add(next, subnetSize)
+			}
+
+			return result, nil
+		},
+	}
+}
+
+// interpolationFuncCidrContains implements the "cidrcontains" function that
+// returns whether an IP block expressed in CIDR notation contains an IP
+// address, or all of another IP block.
+func interpolationFuncCidrContains() ast.Function {
+	return ast.Function{
+		ArgTypes: []ast.Type{
+			ast.TypeString, // containing CIDR mask
+			ast.TypeString, // contained IP address or CIDR mask
+		},
+		ReturnType: ast.TypeBool,
+		Callback: func(args []interface{}) (interface{}, error) {
+			_, network, err := net.ParseCIDR(args[0].(string))
+			if err != nil {
+				return nil, fmt.Errorf("invalid CIDR expression: %s", err)
+			}
+
+			contained := args[1].(string)
+			var first, last net.IP
+			if strings.Contains(contained, "/") {
+				_, sub, err := net.ParseCIDR(contained)
+				if err != nil {
+					return nil, fmt.Errorf("invalid CIDR expression: %s", err)
+				}
+				first = sub.IP
+				last = lastAddress(sub)
+			} else {
+				first = net.ParseIP(contained)
+				if first == nil {
+					return nil, fmt.Errorf("invalid IP address: %q", contained)
+				}
+				last = first
+			}
+
+			if (network.IP.To4() == nil) != (first.To4() == nil) {
+				return nil, fmt.Errorf("cidrcontains: %s and %s are in different address families", args[0], contained)
+			}
+
+			return network.Contains(first) && network.Contains(last), nil
+		},
+	}
+}
+
+// cidrHost returns the address with the given number within network,
+// counting back from the end of it for a negative number.
+func cidrHost(network *net.IPNet, num int) (net.IP, error) {
+	prefixLen, bits := network.Mask.Size()
+	count := new(big.Int).Lsh(big.NewInt(1), uint(bits-prefixLen))
+
+	n := big.NewInt(int64(num))
+	if n.Sign() < 0 {
+		n.Add(n, count)
+	}
+	if n.Sign() < 0 || n.Cmp(count) >= 0 {
+		return nil, fmt.Errorf("prefix of %d does not accommodate a host numbered %d", prefixLen, num)
+	}
+
+	n.Or(n, new(big.Int).SetBytes(network.IP))
+	return bigToIP(n, len(network.IP)), nil
+}
+
+// cidrSubnet returns the subnet with the given number within network,
+// whose prefix is longer by newBits.
+func cidrSubnet(network *net.IPNet, newBits, num int) (*net.IPNet, error) {
+	prefixLen, bits := network.Mask.Size()
+	newPrefixLen := prefixLen + newBits
+	if newBits < 0 {
+		return nil, fmt.Errorf("invalid number of bits %d to extend the prefix", newBits)
+	}
+	if newPrefixLen > bits {
+		return nil, fmt.Errorf("insufficient address space to extend prefix of %d by %d", prefixLen, newBits)
+	}
+
+	count := new(big.Int).Lsh(big.NewInt(1), uint(newBits))
+	n := big.NewInt(int64(num))
+	if n.Sign() < 0 || n.Cmp(count) >= 0 {
+		return nil, fmt.Errorf("prefix extension of %d does not accommodate a subnet numbered %d", newBits, num)
+	}
+
+	n.Lsh(n, uint(bits-newPrefixLen))
+	n.Or(n, new(big.Int).SetBytes(network.IP))
+	return &net.IPNet{
+		IP:   bigToIP(n, len(network.IP)),
+		Mask: net.CIDRMask(newPrefixLen, bits),
+	}, nil
+}
+
+// lastAddress returns the last address within network.
+func lastAddress(network *net.IPNet) net.IP {
+	last := make(net.IP, len(network.IP))
+	for i := range network.IP {
+		last[i] = network.IP[i] | ^network.Mask[i]
+	}
+	return last
+}
+
+// bigToIP returns the address of the given size in bytes whose value is n,
+// which must fit in it.
+func bigToIP(n *big.Int, size int) net.IP {
+	b := n.Bytes()
+	ip := make(net.IP, size)
+	copy(ip[size-len(b):], b)
+	return ip
+}
+
 // interpolationFuncCoalesce implements the "coalesce" function that
 // returns the first non null / empty string from the provided input
 func interpolationFuncCoalesce() ast.Function {
@@ -443,6 +632,51 @@ func interpolationFuncPow() ast.Function {
 // interpolationFuncFile implements the "file" function that allows
 // loading contents from a file.
 func interpolationFuncFile() ast.Function {
+	return ast.Function{
+		ArgTypes:   []ast.Type{ast.TypeString},
+		ReturnType: ast.TypeString,
+		Callback: func(args []interface{}) (interface{}, error) {
+			data, err := readFileArg(args[0].(string))
+			if err != nil {
+				return "", err
+			}
+
+			return string(data), nil
+		},
+	}
+}
+
+// readFileArg reads the file at a path given to a function, which is
+// relative to the working directory and may start with "~".
+func readFileArg(path string) ([]byte, error) {
+	path, err := homedir.Expand(path)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadFile(path)
+}
+
+// interpolationFuncFileBase64 implements the "filebase64" function that
+// returns the contents of a file encoded as base64, which unlike file works
+// for files that aren't valid UTF-8.
+func interpolationFuncFileBase64() ast.Function {
+	return ast.Function{
+		ArgTypes:   []ast.Type{ast.TypeString},
+		ReturnType: ast.TypeString,
+		Callback: func(args []interface{}) (interface{}, error) {
+			data, err := readFileArg(args[0].(string))
+			if err != nil {
+				return "", err
+			}
+			return base64.StdEncoding.EncodeToString(data), nil
+		},
+	}
+}
+
+// interpolationFuncFileHash returns a function that hashes the contents of
+// a file, such as "filesha256", which is the same as the hash function
+// applied to file(path) without reading the file into a string first.
+func interpolationFuncFileHash(newHash func() hash.Hash, encode func([]byte) string) ast.Function {
 	return ast.Function{
 		ArgTypes:   []ast.Type{ast.TypeString},
 		ReturnType: ast.TypeString,
@@ -451,12 +685,146 @@ func interpolationFuncFile() ast.Function {
 			if err != nil {
 				return "", err
 			}
-			data, err := ioutil.ReadFile(path)
+			f, err := os.Open(path)
 			if err != nil {
 				return "", err
 			}
+			defer f.Close()
 
-			return string(data), nil
+			h := newHash()
+			if _, err := io.Copy(h, f); err != nil {
+				return "", err
+			}
+			return encode(h.Sum(nil)), nil
+		},
+	}
+}
+
+// interpolationFuncFileSet implements the "fileset" function that returns
+// the paths of the files under a directory that match a pattern, relative
+// to the directory, with forward slashes and in lexical order.
+//
+// Patterns use the syntax of filepath.Match within each path segment, plus
+// "**" as a whole segment for any number of directories. They can't be
+// absolute or contain ".." segments, so the files are always within the
+// directory. Symbolic links aren't followed.
+func interpolationFuncFileSet() ast.Function {
+	return ast.Function{
+		ArgTypes:   []ast.Type{ast.TypeString, ast.TypeString},
+		ReturnType: ast.TypeList,
+		Callback: func(args []interface{}) (interface{}, error) {
+			dir, err := homedir.Expand(args[0].(string))
+			if err != nil {
+				return nil, err
+			}
+			pattern := args[1].(string)
+
+			if path.IsAbs(pattern) || filepath.IsAbs(pattern) {
+				return nil, fmt.Errorf("fileset: the pattern must be relative to the directory, not %q", pattern)
+			}
+			patternSegs := strings.Split(filepath.ToSlash(pattern), "/")
+			for _, seg := range patternSegs {
+				if seg == ".." {
+					return nil, fmt.Errorf("fileset: the pattern %q can't refer to a parent directory", pattern)
+				}
+				if _, err := filepath.Match(seg, ""); err != nil {
+					return nil, fmt.Errorf("fileset: invalid pattern %q: %s", pattern, err)
+				}
+			}
+
+			if info, err := os.Stat(dir); err != nil {
+				return nil, err
+			} else if !info.IsDir() {
+				return nil, fmt.Errorf("fileset: %s is not a directory", dir)
+			}
+
+			var matches []string
+			err = filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+				if err != nil {
+					return err
+				}
+				if !info.Mode().IsRegular() {
+					return nil
+				}
+				rel, err := filepath.Rel(dir, p)
+				if err != nil {
+					return err
+				}
+				rel = filepath.ToSlash(rel)
+				if matchPathSegments(patternSegs, strings.Split(rel, "/")) {
+					matches = append(matches, rel)
+				}
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			sort.Strings(matches)
+			return stringSliceToVariableValue(matches), nil
+		},
+	}
+}
+
+// matchPathSegments reports whether the segments of a path match those of a
+// fileset pattern, where "**" matches any number of segments.
+func matchPathSegments(pattern, segs []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(segs); i++ {
+				if matchPathSegments(pattern[1:], segs[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(segs) == 0 {
+			return false
+		}
+		if ok, _ := filepath.Match(pattern[0], segs[0]); !ok {
+			return false
+		}
+		pattern, segs = pattern[1:], segs[1:]
+	}
+	return len(segs) == 0
+}
+
+// interpolationFuncTemplateFile implements the "templatefile" function that
+// renders the file at a path as a template, with the given map providing
+// its variables. Templates have the same syntax and functions as the
+// configuration, except for templatefile itself, and refer to a variable
+// by its name alone, such as ${name}.
+func interpolationFuncTemplateFile() ast.Function {
+	return ast.Function{
+		ArgTypes:   []ast.Type{ast.TypeString, ast.TypeMap},
+		ReturnType: ast.TypeString,
+		Callback: func(args []interface{}) (interface{}, error) {
+			filename := args[0].(string)
+			data, err := readFileArg(filename)
+			if err != nil {
+				return "", err
+			}
+			vars := args[1].(map[string]ast.Variable)
+
+			root, err := hil.Parse(string(data))
+			if err != nil {
+				return "", fmt.Errorf("templatefile: failed to parse %s: %s", filename, err)
+			}
+
+			config := langEvalConfig(vars)
+			delete(config.GlobalScope.FuncMap, "templatefile")
+			root, err = evalTryCalls(root, config)
+			if err != nil {
+				return "", fmt.Errorf("templatefile: failed to render %s: %s", filename, err)
+			}
+			result, err := hil.Eval(root, config)
+			if err != nil {
+				return "", fmt.Errorf("templatefile: failed to render %s: %s", filename, err)
+			}
+			if result.Type != hil.TypeString {
+				return "", fmt.Errorf("templatefile: %s must render to a string, not a %s", filename, result.Type)
+			}
+			return result.Value.(string), nil
 		},
 	}
 }
@@ -914,6 +1282,182 @@ func interpolationFuncJSONEncode() ast.Function {
 	}
 }
 
+// interpolationFuncJSONDecode implements the "jsondecode" function that
+// decodes a JSON object into a map. See decodedVariable for how the values
+// in the object are represented.
+func interpolationFuncJSONDecode() ast.Function {
+	return ast.Function{
+		ArgTypes:   []ast.Type{ast.TypeString},
+		ReturnType: ast.TypeMap,
+		Callback: func(args []interface{}) (interface{}, error) {
+			dec := json.NewDecoder(strings.NewReader(args[0].(string)))
+			dec.UseNumber()
+
+			var v interface{}
+			if err := dec.Decode(&v); err != nil {
+				return nil, fmt.Errorf("failed to decode JSON: %s", err)
+			}
+			if _, err := dec.Token(); err != io.EOF {
+				return nil, fmt.Errorf("failed to decode JSON: unexpected data after the object")
+			}
+
+			return decodedObject("JSON", v)
+		},
+	}
+}
+
+// interpolationFuncYAMLDecode implements the "yamldecode" function that
+// decodes a YAML mapping into a map, from the first document of the given
+// string. See decodedVariable for how the values in the mapping are
+// represented.
+func interpolationFuncYAMLDecode() ast.Function {
+	return ast.Function{
+		ArgTypes:   []ast.Type{ast.TypeString},
+		ReturnType: ast.TypeMap,
+		Callback: func(args []interface{}) (interface{}, error) {
+			var v interface{}
+			if err := yaml.Unmarshal([]byte(args[0].(string)), &v); err != nil {
+				return nil, fmt.Errorf("failed to decode YAML: %s", err)
+			}
+
+			return decodedObject("YAML", v)
+		},
+	}
+}
+
+// interpolationFuncYAMLEncode implements the "yamlencode" function that
+// encodes a string, list or map as YAML, in the same way as jsonencode
+// encodes it as JSON.
+func interpolationFuncYAMLEncode() ast.Function {
+	return ast.Function{
+		ArgTypes:   []ast.Type{ast.TypeAny},
+		ReturnType: ast.TypeString,
+		Callback: func(args []interface{}) (interface{}, error) {
+			variable, err := hil.InterfaceToVariable(args[0])
+			if err != nil {
+				return "", fmt.Errorf("unknown type for YAML encoding: %T", args[0])
+			}
+			toEncode, err := hil.VariableToInterface(variable)
+			if err != nil {
+				return "", err
+			}
+
+			yEnc, err := yaml.Marshal(toEncode)
+			if err != nil {
+				return "", fmt.Errorf("failed to encode YAML data '%s'", toEncode)
+			}
+			return string(yEnc), nil
+		},
+	}
+}
+
+// decodedObject returns the value of a decoded document for jsondecode and
+// yamldecode, which must be an object.
+func decodedObject(format string, v interface{}) (interface{}, error) {
+	switch v.(type) {
+	case map[string]interface{}, map[interface{}]interface{}:
+	default:
+		return nil, fmt.Errorf("the %s must be an object, not %s", format, decodedKind(v))
+	}
+
+	variable, err := decodedVariable(v)
+	if err != nil {
+		return nil, err
+	}
+	return variable.Value, nil
+}
+
+// decodedVariable converts a value decoded from JSON or YAML to a variable.
+// Objects become maps and arrays become lists. Since the elements of maps
+// and lists are strings, the scalars are kept as strings: numbers as they
+// were written for JSON, so that large numbers don't lose precision, booleans
+// as "true" or "false", and null as an empty string.
+func decodedVariable(v interface{}) (ast.Variable, error) {
+	switch v := v.(type) {
+	case []interface{}:
+		elems := make([]ast.Variable, len(v))
+		for i, e := range v {
+			elem, err := decodedVariable(e)
+			if err != nil {
+				return ast.Variable{}, err
+			}
+			elems[i] = elem
+		}
+		return ast.Variable{Type: ast.TypeList, Value: elems}, nil
+	case map[string]interface{}:
+		elems := make(map[string]ast.Variable, len(v))
+		for k, e := range v {
+			elem, err := decodedVariable(e)
+			if err != nil {
+				return ast.Variable{}, err
+			}
+			elems[k] = elem
+		}
+		return ast.Variable{Type: ast.TypeMap, Value: elems}, nil
+	case map[interface{}]interface{}:
+		// YAML mappings may have keys of any type.
+		elems := make(map[string]ast.Variable, len(v))
+		for k, e := range v {
+			key, err := decodedVariable(k)
+			if err != nil {
+				return ast.Variable{}, err
+			}
+			if key.Type != ast.TypeString {
+				return ast.Variable{}, fmt.Errorf("map keys must be strings, not %s", decodedKind(k))
+			}
+			elem, err := decodedVariable(e)
+			if err != nil {
+				return ast.Variable{}, err
+			}
+			elems[key.Value.(string)] = elem
+		}
+		return ast.Variable{Type: ast.TypeMap, Value: elems}, nil
+	}
+
+	var s string
+	switch v := v.(type) {
+	case nil:
+		s = ""
+	case string:
+		s = v
+	case json.Number:
+		s = v.String()
+	case bool:
+		s = strconv.FormatBool(v)
+	case int:
+		s = strconv.Itoa(v)
+	case int64:
+		s = strconv.FormatInt(v, 10)
+	case uint64:
+		s = strconv.FormatUint(v, 10)
+	case float64:
+		s = strconv.FormatFloat(v, 'g', -1, 64)
+	case time.Time:
+		s = v.Format(time.RFC3339Nano)
+	default:
+		return ast.Variable{}, fmt.Errorf("unsupported value %#v", v)
+	}
+	return ast.Variable{Type: ast.TypeString, Value: s}, nil
+}
+
+// decodedKind describes the kind of a decoded value in error messages.
+func decodedKind(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case []interface{}:
+		return "an array"
+	case map[string]interface{}, map[interface{}]interface{}:
+		return "an object"
+	case string:
+		return "a string"
+	case bool:
+		return "a boolean"
+	default:
+		return "a number"
+	}
+}
+
 // interpolationFuncReplace implements the "replace" function that does
 // string replacement.
 func interpolationFuncReplace() ast.Function {
@@ -1532,6 +2076,195 @@ func interpolationFuncTimeAdd() ast.Function {
 	}
 }
 
+// interpolationFuncTimeCmp implements the "timecmp" function that compares
+// two RFC 3339 timestamps, returning -1 if the first is before the second, 1
+// if it's after it and 0 if they're the same instant, even if they're
+// written with different time zones.
+func interpolationFuncTimeCmp() ast.Function {
+	return ast.Function{
+		ArgTypes:   []ast.Type{ast.TypeString, ast.TypeString},
+		ReturnType: ast.TypeInt,
+		Callback: func(args []interface{}) (interface{}, error) {
+			a, err := time.Parse(time.RFC3339, args[0].(string))
+			if err != nil {
+				return nil, fmt.Errorf("timecmp: invalid timestamp: %s", err)
+			}
+			b, err := time.Parse(time.RFC3339, args[1].(string))
+			if err != nil {
+				return nil, fmt.Errorf("timecmp: invalid timestamp: %s", err)
+			}
+
+			switch {
+			case a.Before(b):
+				return -1, nil
+			case a.After(b):
+				return 1, nil
+			default:
+				return 0, nil
+			}
+		},
+	}
+}
+
+// PlanTimestampFunc returns the "plantimestamp" function, which returns the
+// given time as an RFC 3339 timestamp in UTC. Terraform gives it the time at
+// which the plan was created, during both plan and apply, so unlike
+// timestamp its result doesn't change between them.
+//
+// If the time is zero, as it is outside of planning and applying, the
+// result is unknown.
+func PlanTimestampFunc(t time.Time) ast.Function {
+	return ast.Function{
+		ArgTypes:   []ast.Type{},
+		ReturnType: ast.TypeString,
+		Callback: func(args []interface{}) (interface{}, error) {
+			if t.IsZero() {
+				return UnknownVariableValue, nil
+			}
+			return t.UTC().Format(time.RFC3339), nil
+		},
+	}
+}
+
+// interpolationFuncFormatDate implements the "formatdate" function that
+// formats an RFC 3339 timestamp according to a specification made of the
+// verbs that formatDate supports.
+func interpolationFuncFormatDate() ast.Function {
+	return ast.Function{
+		ArgTypes:   []ast.Type{ast.TypeString, ast.TypeString},
+		ReturnType: ast.TypeString,
+		Callback: func(args []interface{}) (interface{}, error) {
+			t, err := time.Parse(time.RFC3339, args[1].(string))
+			if err != nil {
+				return nil, fmt.Errorf("formatdate: invalid timestamp: %s", err)
+			}
+			result, err := formatDate(args[0].(string), t)
+			if err != nil {
+				return nil, fmt.Errorf("formatdate: %s", err)
+			}
+			return result, nil
+		},
+	}
+}
+
+// formatDate formats t according to spec, in which each run of the same
+// letter is a verb, such as "YYYY" for the year, text between single quotes
+// is copied literally, with two quotes in a row for a quote, and any other
+// character is copied as-is. Letters that aren't verbs are an error, so
+// that letters that are meant literally must be quoted.
+func formatDate(spec string, t time.Time) (string, error) {
+	var buf bytes.Buffer
+	for i := 0; i < len(spec); {
+		c := spec[i]
+		switch {
+		case c == '\'':
+			// A quoted literal, which ends at the next quote that isn't
+			// doubled.
+			i++
+			if i < len(spec) && spec[i] == '\'' {
+				buf.WriteByte('\'')
+				i++
+				continue
+			}
+			for {
+				if i >= len(spec) {
+					return "", fmt.Errorf("unterminated literal in format %q", spec)
+				}
+				if spec[i] == '\'' {
+					if i+1 < len(spec) && spec[i+1] == '\'' {
+						buf.WriteByte('\'')
+						i += 2
+						continue
+					}
+					i++
+					break
+				}
+				buf.WriteByte(spec[i])
+				i++
+			}
+		case (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z'):
+			j := i
+			for j < len(spec) && spec[j] == c {
+				j++
+			}
+			verb := spec[i:j]
+			i = j
+
+			s, ok := formatDateVerb(verb, t)
+			if !ok {
+				return "", fmt.Errorf("invalid verb %q in format %q; letters that aren't verbs must be quoted, as in 'T'", verb, spec)
+			}
+			buf.WriteString(s)
+		default:
+			buf.WriteByte(c)
+			i++
+		}
+	}
+	return buf.String(), nil
+}
+
+// formatDateVerb returns the part of t that a formatdate verb stands for,
+// and false if it's not a verb.
+func formatDateVerb(verb string, t time.Time) (string, bool) {
+	hour12 := t.Hour() % 12
+	if hour12 == 0 {
+		hour12 = 12
+	}
+
+	switch verb {
+	case "YYYY":
+		return fmt.Sprintf("%04d", t.Year()), true
+	case "YY":
+		return fmt.Sprintf("%02d", t.Year()%100), true
+	case "MMMM":
+		return t.Month().String(), true
+	case "MMM":
+		return t.Month().String()[:3], true
+	case "MM":
+		return fmt.Sprintf("%02d", int(t.Month())), true
+	case "M":
+		return strconv.Itoa(int(t.Month())), true
+	case "DD":
+		return fmt.Sprintf("%02d", t.Day()), true
+	case "D":
+		return strconv.Itoa(t.Day()), true
+	case "EEEE":
+		return t.Weekday().String(), true
+	case "EEE":
+		return t.Weekday().String()[:3], true
+	case "hh":
+		return fmt.Sprintf("%02d", t.Hour()), true
+	case "h":
+		return strconv.Itoa(t.Hour()), true
+	case "HH":
+		return fmt.Sprintf("%02d", hour12), true
+	case "H":
+		return strconv.Itoa(hour12), true
+	case "AA":
+		return t.Format("PM"), true
+	case "aa":
+		return t.Format("pm"), true
+	case "mm":
+		return fmt.Sprintf("%02d", t.Minute()), true
+	case "m":
+		return strconv.Itoa(t.Minute()), true
+	case "ss":
+		return fmt.Sprintf("%02d", t.Second()), true
+	case "s":
+		return strconv.Itoa(t.Second()), true
+	case "ZZZZZ":
+		return t.Format("-07:00"), true
+	case "ZZZZ":
+		return t.Format("-0700"), true
+	case "ZZZ":
+		return t.Format("MST"), true
+	case "Z":
+		return t.Format("Z07:00"), true
+	default:
+		return "", false
+	}
+}
+
 // interpolationFuncTitle implements the "title" function that returns a copy of the
 // string in which first characters of all the words are capitalized.
 func interpolationFuncTitle() ast.Function {
@@ -1675,6 +2408,79 @@ func interpolationFuncTranspose() ast.Function {
 	}
 }
 
+// interpolationFuncSetProduct implements the "setproduct" function that
+// returns every combination of one element from each of the given lists,
+// as a list of lists, varying the elements of the last list fastest.
+func interpolationFuncSetProduct() ast.Function {
+	return ast.Function{
+		ArgTypes:     []ast.Type{ast.TypeList},
+		ReturnType:   ast.TypeList,
+		Variadic:     true,
+		VariadicType: ast.TypeList,
+		Callback: func(args []interface{}) (interface{}, error) {
+			if len(args) < 2 {
+				return nil, fmt.Errorf("setproduct requires at least two lists")
+			}
+
+			products := [][]ast.Variable{{}}
+			for _, arg := range args {
+				list := arg.([]ast.Variable)
+				next := make([][]ast.Variable, 0, len(products)*len(list))
+				for _, product := range products {
+					for _, v := range list {
+						combination := make([]ast.Variable, len(product), len(product)+1)
+						copy(combination, product)
+						next = append(next, append(combination, v))
+					}
+				}
+				products = next
+			}
+
+			output := make([]ast.Variable, 0, len(products))
+			for _, product := range products {
+				output = append(output, ast.Variable{Type: ast.TypeList, Value: product})
+			}
+			return output, nil
+		},
+	}
+}
+
+// interpolationFuncSum implements the "sum" function that returns the sum
+// of a list of numbers.
+func interpolationFuncSum() ast.Function {
+	return ast.Function{
+		ArgTypes:   []ast.Type{ast.TypeList},
+		ReturnType: ast.TypeFloat,
+		Callback: func(args []interface{}) (interface{}, error) {
+			list := args[0].([]ast.Variable)
+			if len(list) == 0 {
+				return nil, fmt.Errorf("sum cannot be used with an empty list")
+			}
+
+			var sum float64
+			for i, v := range list {
+				switch v.Type {
+				case ast.TypeInt:
+					sum += float64(v.Value.(int))
+				case ast.TypeFloat:
+					sum += v.Value.(float64)
+				case ast.TypeString:
+					// Numbers from lists of strings, such as a
+					// split() result, are summed too.
+					f, err := strconv.ParseFloat(v.Value.(string), 64)
+					if err != nil {
+						return nil, fmt.Errorf("sum: element %d is not a number: %q", i, v.Value)
+					}
+					sum += f
+				default:
+					return nil, fmt.Errorf("sum: element %d is a %s, not a number", i, v.Type.Printable())
+				}
+			}
+			return sum, nil
+		},
+	}
+}
+
 // interpolationFuncAbs returns the absolute value of a given float.
 func interpolationFuncAbs() ast.Function {
 	return ast.Function{
@@ -643,6 +643,26 @@ func TestInterpolateFuncCidrHost(t *testing.T) {
 				"192.168.1.0",
 				false,
 			},
+			{
+				`${cidrhost("fe80::/64", 5)}`,
+				"fe80::5",
+				false,
+			},
+			{
+				`${cidrhost("fe80::/64", -1)}`,
+				"fe80::ffff:ffff:ffff:ffff",
+				false,
+			},
+			{
+				`${cidrhost("fe80::/32", -1)}`,
+				"fe80:0:ffff:ffff:ffff:ffff:ffff:ffff",
+				false, // more than 64 host bits
+			},
+			{
+				`${cidrhost("fe80::/126", 4)}`,
+				nil,
+				true, // 4 doesn't fit in two bits
+			},
 			{
 				`${cidrhost("192.168.1.0/30", 255)}`,
 				nil,
@@ -745,6 +765,140 @@ func TestInterpolateFuncCidrSubnet(t *testing.T) {
 				nil,
 				true, // can't have an octet >255
 			},
+			{
+				`${cidrsubnet("fe80::/48", 64, 6)}`,
+				"fe80::6:0/112",
+				false, // IPv6 prefixes can be extended by more than 32 bits
+			},
+			{
+				`${cidrsubnet("fe80::/120", 16, 6)}`,
+				nil,
+				true, // not enough bits left
+			},
+			{
+				`${cidrsubnet("192.168.0.0/16", -1, 0)}`,
+				nil,
+				true, // can't shorten the prefix
+			},
+			{
+				`${cidrsubnet("192.168.0.0/16", 2, -1)}`,
+				nil,
+				true, // negative network number
+			},
+		},
+	})
+}
+
+func TestInterpolateFuncCidrSubnets(t *testing.T) {
+	testFunction(t, testFunctionConfig{
+		Cases: []testFunctionCase{
+			{
+				`${cidrsubnets("10.1.0.0/16", 4, 4, 8, 4)}`,
+				[]interface{}{"10.1.0.0/20", "10.1.16.0/20", "10.1.32.0/24", "10.1.48.0/20"},
+				false, // the last subnet is aligned to its size
+			},
+			{
+				`${cidrsubnets("10.1.0.0/16", 0)}`,
+				[]interface{}{"10.1.0.0/16"},
+				false,
+			},
+			{
+				`${cidrsubnets("10.1.0.0/24", 2, 2, 2, 2)}`,
+				[]interface{}{"10.1.0.0/26", "10.1.0.64/26", "10.1.0.128/26", "10.1.0.192/26"},
+				false,
+			},
+			{
+				`${cidrsubnets("fd00:fd12:3456:7890::/56", 16, 16, 16, 32)}`,
+				[]interface{}{
+					"fd00:fd12:3456:7800::/72",
+					"fd00:fd12:3456:7800:100::/72",
+					"fd00:fd12:3456:7800:200::/72",
+					"fd00:fd12:3456:7800:300::/88",
+				},
+				false,
+			},
+			{
+				`${cidrsubnets("10.1.0.0/24", 2, 2, 2, 2, 2)}`,
+				nil,
+				true, // address space exhausted
+			},
+			{
+				`${cidrsubnets("10.1.0.0/24", 1, 2, 1)}`,
+				nil,
+				true, // the last subnet would be aligned past the end
+			},
+			{
+				`${cidrsubnets("10.1.0.0/24", 9)}`,
+				nil,
+				true, // prefix too long
+			},
+			{
+				`${cidrsubnets("10.1.0.0/24", -1)}`,
+				nil,
+				true,
+			},
+			{
+				`${cidrsubnets("10.1.0.0/24")}`,
+				nil,
+				true,
+			},
+			{
+				`${cidrsubnets("not-a-cidr", 4)}`,
+				nil,
+				true, // not a valid CIDR mask
+			},
+		},
+	})
+}
+
+func TestInterpolateFuncCidrContains(t *testing.T) {
+	testFunction(t, testFunctionConfig{
+		Cases: []testFunctionCase{
+			{
+				`${cidrcontains("192.168.2.0/24", "192.168.2.1")}`,
+				"true",
+				false,
+			},
+			{
+				`${cidrcontains("192.168.2.0/24", "192.168.3.1")}`,
+				"false",
+				false,
+			},
+			{
+				`${cidrcontains("192.168.2.0/24", "192.168.2.128/25")}`,
+				"true",
+				false,
+			},
+			{
+				`${cidrcontains("192.168.2.0/24", "192.168.2.0/23")}`,
+				"false",
+				false, // only partly contained
+			},
+			{
+				`${cidrcontains("fd00:fd12:3456:7890::/56", "fd00:fd12:3456:7890:00a2::1")}`,
+				"true",
+				false,
+			},
+			{
+				`${cidrcontains("fd00:fd12:3456:7890::/56", "fd00:fd12:3456:7a00::/64")}`,
+				"false",
+				false,
+			},
+			{
+				`${cidrcontains("192.168.2.0/24", "fd00::1")}`,
+				nil,
+				true, // different address families
+			},
+			{
+				`${cidrcontains("192.168.2.0/24", "not-an-ip")}`,
+				nil,
+				true,
+			},
+			{
+				`${cidrcontains("not-a-cidr", "192.168.2.1")}`,
+				nil,
+				true,
+			},
 		},
 	})
 }
@@ -1206,16 +1360,268 @@ func TestInterpolateFuncFile(t *testing.T) {
 				false,
 			},
 
-			// Invalid path
+			// Invalid path
+			{
+				`${file("/i/dont/exist")}`,
+				nil,
+				true,
+			},
+
+			// Too many args
+			{
+				`${file("foo", "bar")}`,
+				nil,
+				true,
+			},
+		},
+	})
+}
+
+func TestInterpolateFuncFileHashes(t *testing.T) {
+	tf, err := ioutil.TempFile("", "tf")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	path := tf.Name()
+	tf.Write([]byte("foo"))
+	tf.Close()
+	defer os.Remove(path)
+
+	testFunction(t, testFunctionConfig{
+		Cases: []testFunctionCase{
+			{
+				fmt.Sprintf(`${filebase64("%s")}`, path),
+				"Zm9v",
+				false,
+			},
+			{
+				fmt.Sprintf(`${filemd5("%s")}`, path),
+				"acbd18db4cc2f85cedef654fccc4a4d8",
+				false,
+			},
+			{
+				fmt.Sprintf(`${filesha1("%s")}`, path),
+				"0beec7b5ea3f0fdbc95d0dd47f3c5bc275da8a33",
+				false,
+			},
+			{
+				fmt.Sprintf(`${filesha256("%s")}`, path),
+				"2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae",
+				false,
+			},
+			{
+				fmt.Sprintf(`${filesha512("%s")}`, path),
+				"f7fbba6e0636f890e56fbbf3283e524c6fa3204ae298382d624741d0dc6638326e282c41be5e4254d8820772c5518a2c5a8c0c7f7eda19594a7eb539453e1ed7",
+				false,
+			},
+			{
+				fmt.Sprintf(`${filebase64sha256("%s")}`, path),
+				"LCa0a2j/xo/5m0U8HTBBNBNCLXBkg7+g+YpeiGJm564=",
+				false,
+			},
+			{
+				fmt.Sprintf(`${filebase64sha512("%s")}`, path),
+				"9/u6bgY2+JDlb7vzKD5STG+jIErimDgtYkdB0NxmODJuKCxBvl5CVNiCB3LFUYosWowMf37aGVlKfrU5RT4e1w==",
+				false,
+			},
+
+			// The same as hashing the contents
+			{
+				fmt.Sprintf(`${filesha256("%s") == sha256(file("%s")) ? "same" : "different"}`, path, path),
+				"same",
+				false,
+			},
+
+			// Invalid path
+			{
+				`${filesha256("/i/dont/exist")}`,
+				nil,
+				true,
+			},
+		},
+	})
+}
+
+func TestInterpolateFuncFileSet(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tf")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, name := range []string{
+		"main.tf",
+		"README.md",
+		"templates/a.tpl",
+		"templates/b.txt",
+		"templates/nested/c.tpl",
+	} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		if err := ioutil.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+	}
+
+	testFunction(t, testFunctionConfig{
+		Cases: []testFunctionCase{
+			{
+				fmt.Sprintf(`${fileset("%s", "*.tf")}`, dir),
+				[]interface{}{"main.tf"},
+				false,
+			},
+			{
+				fmt.Sprintf(`${fileset("%s", "templates/*.tpl")}`, dir),
+				[]interface{}{"templates/a.tpl"},
+				false,
+			},
+			{
+				fmt.Sprintf(`${fileset("%s", "**/*.tpl")}`, dir),
+				[]interface{}{"templates/a.tpl", "templates/nested/c.tpl"},
+				false,
+			},
+			{
+				fmt.Sprintf(`${fileset("%s", "templates/**")}`, dir),
+				[]interface{}{"templates/a.tpl", "templates/b.txt", "templates/nested/c.tpl"},
+				false,
+			},
+			{
+				fmt.Sprintf(`${fileset("%s/templates", "[ab].*")}`, dir),
+				[]interface{}{"a.tpl", "b.txt"},
+				false,
+			},
+
+			// Directories aren't matched
+			{
+				fmt.Sprintf(`${fileset("%s", "*")}`, dir),
+				[]interface{}{"README.md", "main.tf"},
+				false,
+			},
+
+			// No matches
+			{
+				fmt.Sprintf(`${fileset("%s", "*.json")}`, dir),
+				[]interface{}{},
+				false,
+			},
+
+			// Patterns can't leave the directory
+			{
+				fmt.Sprintf(`${fileset("%s/templates", "../*.tf")}`, dir),
+				nil,
+				true,
+			},
+			{
+				fmt.Sprintf(`${fileset("%s", "/etc/*")}`, dir),
+				nil,
+				true,
+			},
+
+			// Invalid pattern
+			{
+				fmt.Sprintf(`${fileset("%s", "[")}`, dir),
+				nil,
+				true,
+			},
+
+			// Not a directory
+			{
+				fmt.Sprintf(`${fileset("%s/main.tf", "*")}`, dir),
+				nil,
+				true,
+			},
+			{
+				`${fileset("/i/dont/exist", "*")}`,
+				nil,
+				true,
+			},
+		},
+	})
+}
+
+func TestInterpolateFuncTemplateFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tf")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	templates := map[string]string{
+		"hello.tpl":     `Hello, ${name}!`,
+		"funcs.tpl":     `${length(items)} items: ${upper(join(",", items))}`,
+		"list.tpl":      `${items}`,
+		"invalid.tpl":   `${`,
+		"recurse.tpl":   `${templatefile("recurse.tpl", map())}`,
+		"unknown.tpl":   `${nope}`,
+		"lookup.tpl":    `${lookup(tags, "env")}`,
+		"noninterp.tpl": "plain text\n",
+	}
+	for name, content := range templates {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+	}
+
+	testFunction(t, testFunctionConfig{
+		Vars: map[string]ast.Variable{
+			"var.items": interfaceToVariableSwallowError([]string{"a", "b"}),
+			"var.tags":  interfaceToVariableSwallowError(map[string]string{"env": "prod"}),
+		},
+		Cases: []testFunctionCase{
+			{
+				fmt.Sprintf(`${templatefile("%s/hello.tpl", map("name", "world"))}`, dir),
+				"Hello, world!",
+				false,
+			},
+			{
+				fmt.Sprintf(`${templatefile("%s/funcs.tpl", map("items", var.items))}`, dir),
+				"2 items: A,B",
+				false,
+			},
+			{
+				fmt.Sprintf(`${templatefile("%s/lookup.tpl", map("tags", var.tags))}`, dir),
+				"prod",
+				false,
+			},
+			{
+				fmt.Sprintf(`${templatefile("%s/noninterp.tpl", map())}`, dir),
+				"plain text\n",
+				false,
+			},
+
+			// Must render to a string
+			{
+				fmt.Sprintf(`${templatefile("%s/list.tpl", map("items", var.items))}`, dir),
+				nil,
+				true,
+			},
+
+			// Invalid template
+			{
+				fmt.Sprintf(`${templatefile("%s/invalid.tpl", map())}`, dir),
+				nil,
+				true,
+			},
+
+			// Undefined variable
+			{
+				fmt.Sprintf(`${templatefile("%s/unknown.tpl", map())}`, dir),
+				nil,
+				true,
+			},
+
+			// templatefile isn't available within templates
 			{
-				`${file("/i/dont/exist")}`,
+				fmt.Sprintf(`${templatefile("%s/recurse.tpl", map())}`, dir),
 				nil,
 				true,
 			},
 
-			// Too many args
+			// Invalid path
 			{
-				`${file("foo", "bar")}`,
+				`${templatefile("/i/dont/exist", map())}`,
 				nil,
 				true,
 			},
@@ -1422,6 +1828,161 @@ func TestInterpolateFuncJoin(t *testing.T) {
 	})
 }
 
+func TestInterpolateFuncJSONDecode(t *testing.T) {
+	testFunction(t, testFunctionConfig{
+		Vars: map[string]ast.Variable{
+			"var.object": {
+				Type:  ast.TypeString,
+				Value: `{"name": "web", "size": 12345678901234567890.5, "enabled": true, "missing": null, "ports": [80, 443], "tags": {"env": "prod"}}`,
+			},
+			"var.list":     {Type: ast.TypeString, Value: `[1, 2]`},
+			"var.trailing": {Type: ast.TypeString, Value: `{} {}`},
+			"var.unknown":  {Type: ast.TypeUnknown, Value: UnknownVariableValue},
+		},
+		Cases: []testFunctionCase{
+			{
+				`${jsondecode(var.object)}`,
+				map[string]interface{}{
+					"name":    "web",
+					"size":    "12345678901234567890.5",
+					"enabled": "true",
+					"missing": "",
+					"ports":   []interface{}{"80", "443"},
+					"tags":    map[string]interface{}{"env": "prod"},
+				},
+				false,
+			},
+			{
+				`${lookup(jsondecode(var.object), "name")}`,
+				"web",
+				false,
+			},
+			{
+				`${jsondecode("{}")}`,
+				map[string]interface{}{},
+				false,
+			},
+			{
+				`${jsondecode(var.list)}`,
+				nil,
+				true,
+			},
+			{
+				`${jsondecode(var.trailing)}`,
+				nil,
+				true,
+			},
+			{
+				`${jsondecode("{")}`,
+				nil,
+				true,
+			},
+			{
+				`${jsondecode(var.unknown)}`,
+				UnknownVariableValue,
+				false,
+			},
+		},
+	})
+}
+
+func TestInterpolateFuncYAMLDecode(t *testing.T) {
+	testFunction(t, testFunctionConfig{
+		Vars: map[string]ast.Variable{
+			"var.object": {
+				Type: ast.TypeString,
+				Value: "name: web\nreplicas: 3\nratio: 0.1\nenabled: yes\ncreated: null\n" +
+					"ports:\n- 80\n- 443\nlabels:\n  app: web\n  1: one\n",
+			},
+			"var.list":    {Type: ast.TypeString, Value: "- a\n- b\n"},
+			"var.badkey":  {Type: ast.TypeString, Value: "? [a, b]\n: c\n"},
+			"var.unknown": {Type: ast.TypeUnknown, Value: UnknownVariableValue},
+		},
+		Cases: []testFunctionCase{
+			{
+				`${yamldecode(var.object)}`,
+				map[string]interface{}{
+					"name":     "web",
+					"replicas": "3",
+					"ratio":    "0.1",
+					"enabled":  "true",
+					"created":  "",
+					"ports":    []interface{}{"80", "443"},
+					"labels":   map[string]interface{}{"app": "web", "1": "one"},
+				},
+				false,
+			},
+			{
+				`${yamldecode("")}`,
+				nil,
+				true,
+			},
+			{
+				`${yamldecode(var.list)}`,
+				nil,
+				true,
+			},
+			{
+				`${yamldecode(var.badkey)}`,
+				nil,
+				true,
+			},
+			{
+				`${yamldecode(var.unknown)}`,
+				UnknownVariableValue,
+				false,
+			},
+		},
+	})
+}
+
+func TestInterpolateFuncYAMLEncode(t *testing.T) {
+	testFunction(t, testFunctionConfig{
+		Vars: map[string]ast.Variable{
+			"var.map": {
+				Type: ast.TypeMap,
+				Value: map[string]ast.Variable{
+					"name": {Type: ast.TypeString, Value: "web"},
+					"ports": {
+						Type: ast.TypeList,
+						Value: []ast.Variable{
+							{Type: ast.TypeString, Value: "80"},
+						},
+					},
+				},
+			},
+			"var.unknown": {Type: ast.TypeUnknown, Value: UnknownVariableValue},
+		},
+		Cases: []testFunctionCase{
+			{
+				`${yamlencode("foo")}`,
+				"foo\n",
+				false,
+			},
+			{
+				`${yamlencode(list("a", "b"))}`,
+				"- a\n- b\n",
+				false,
+			},
+			{
+				`${yamlencode(var.map)}`,
+				"name: web\nports:\n- \"80\"\n",
+				false,
+			},
+			{
+				`${yamlencode(yamldecode(yamlencode(var.map)))}`,
+				"name: web\nports:\n- \"80\"\n",
+				false,
+			},
+			{
+				`${yamlencode(var.unknown)}`,
+				UnknownVariableValue,
+				false,
+			},
+		},
+	})
+}
+
 func TestInterpolateFuncJSONEncode(t *testing.T) {
 	testFunction(t, testFunctionConfig{
 		Vars: map[string]ast.Variable{
@@ -2458,6 +3019,141 @@ func TestInterpolateFuncTimeAdd(t *testing.T) {
 	})
 }
 
+func TestInterpolateFuncTimeCmp(t *testing.T) {
+	testFunction(t, testFunctionConfig{
+		Cases: []testFunctionCase{
+			{
+				`${timecmp("2017-11-22T00:00:00Z", "2017-11-22T00:00:01Z")}`,
+				"-1",
+				false,
+			},
+			{
+				`${timecmp("2017-11-22T00:00:01Z", "2017-11-22T00:00:00Z")}`,
+				"1",
+				false,
+			},
+			{
+				`${timecmp("2017-11-22T00:00:00Z", "2017-11-22T00:00:00Z")}`,
+				"0",
+				false,
+			},
+			{ // the same instant in different time zones
+				`${timecmp("2017-11-22T01:00:00+01:00", "2017-11-22T00:00:00Z")}`,
+				"0",
+				false,
+			},
+			{ // Invalid format timestamp
+				`${timecmp("2017-11-22", "2017-11-22T00:00:00Z")}`,
+				nil,
+				true,
+			},
+			{
+				`${timecmp("2017-11-22T00:00:00Z", "yesterday")}`,
+				nil,
+				true,
+			},
+		},
+	})
+}
+
+func TestInterpolateFuncFormatDate(t *testing.T) {
+	testFunction(t, testFunctionConfig{
+		Cases: []testFunctionCase{
+			{
+				`${formatdate("YYYY-MM-DD hh:mm:ss", "2017-11-02T09:05:03Z")}`,
+				"2017-11-02 09:05:03",
+				false,
+			},
+			{
+				`${formatdate("D M YY h m s", "2017-11-02T09:05:03Z")}`,
+				"2 11 17 9 5 3",
+				false,
+			},
+			{
+				`${formatdate("EEEE, DD MMMM YYYY", "2017-11-02T09:05:03Z")}`,
+				"Thursday, 02 November 2017",
+				false,
+			},
+			{
+				`${formatdate("EEE, DD MMM YYYY hh:mm:ss ZZZ", "2017-11-02T09:05:03Z")}`,
+				"Thu, 02 Nov 2017 09:05:03 UTC",
+				false,
+			},
+			{
+				`${formatdate("HH:mm AA, H:mm aa", "2017-11-02T21:05:03Z")}`,
+				"09:05 PM, 9:05 pm",
+				false,
+			},
+			{ // midnight is 12 AM
+				`${formatdate("H AA", "2017-11-02T00:05:03Z")}`,
+				"12 AM",
+				false,
+			},
+			{
+				`${formatdate("Z ZZZZ ZZZZZ", "2017-11-02T09:05:03Z")}`,
+				"Z +0000 +00:00",
+				false,
+			},
+			{
+				`${formatdate("Z ZZZZ ZZZZZ", "2017-11-02T09:05:03-08:00")}`,
+				"-08:00 -0800 -08:00",
+				false,
+			},
+			{ // quoted literals
+				`${formatdate("YYYY-MM-DD'T'hh:mm:ss'Z'", "2017-11-02T09:05:03Z")}`,
+				"2017-11-02T09:05:03Z",
+				false,
+			},
+			{
+				`${formatdate("h'h'mm 'o''clock' ''", "2017-11-02T09:05:03Z")}`,
+				"9h05 o'clock '",
+				false,
+			},
+			{ // letters that aren't verbs
+				`${formatdate("YYYY-MM-DDThh:mm:ss", "2017-11-02T09:05:03Z")}`,
+				nil,
+				true,
+			},
+			{
+				`${formatdate("YYY", "2017-11-02T09:05:03Z")}`,
+				nil,
+				true,
+			},
+			{ // unterminated literal
+				`${formatdate("'hello", "2017-11-02T09:05:03Z")}`,
+				nil,
+				true,
+			},
+			{ // Invalid format timestamp
+				`${formatdate("YYYY", "2017-11-02")}`,
+				nil,
+				true,
+			},
+		},
+	})
+}
+
+func TestInterpolateFuncPlanTimestamp(t *testing.T) {
+	testFunction(t, testFunctionConfig{
+		Cases: []testFunctionCase{
+			{
+				`${plantimestamp()}`,
+				UnknownVariableValue,
+				false,
+			},
+		},
+	})
+
+	ts := time.Date(2017, 11, 22, 1, 0, 0, 0, time.FixedZone("CET", 3600))
+	result, err := PlanTimestampFunc(ts).Callback(nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if got, want := result, "2017-11-22T00:00:00Z"; got != want {
+		t.Fatalf("wrong result %q; want %q", got, want)
+	}
+}
+
 type testFunctionConfig struct {
 	Cases []testFunctionCase
 	Vars  map[string]ast.Variable
@@ -2766,6 +3462,89 @@ func TestInterpolateFuncTranspose(t *testing.T) {
 	})
 }
 
+func TestInterpolateFuncSetProduct(t *testing.T) {
+	testFunction(t, testFunctionConfig{
+		Cases: []testFunctionCase{
+			{
+				`${setproduct(list("a", "b"), list("1", "2", "3"))}`,
+				[]interface{}{
+					[]interface{}{"a", "1"},
+					[]interface{}{"a", "2"},
+					[]interface{}{"a", "3"},
+					[]interface{}{"b", "1"},
+					[]interface{}{"b", "2"},
+					[]interface{}{"b", "3"},
+				},
+				false,
+			},
+			{
+				`${setproduct(list("a"), list("b"), list("c", "d"))}`,
+				[]interface{}{
+					[]interface{}{"a", "b", "c"},
+					[]interface{}{"a", "b", "d"},
+				},
+				false,
+			},
+			{
+				`${setproduct(list("a", "b"), list())}`,
+				[]interface{}{},
+				false,
+			},
+			{
+				`${setproduct(list("a", "b"))}`,
+				nil,
+				true,
+			},
+			{
+				`${setproduct(list("a"), "b")}`,
+				nil,
+				true,
+			},
+		},
+	})
+}
+
+func TestInterpolateFuncSum(t *testing.T) {
+	testFunction(t, testFunctionConfig{
+		Vars: map[string]ast.Variable{
+			"var.numbers": {
+				Type: ast.TypeList,
+				Value: []ast.Variable{
+					{Type: ast.TypeInt, Value: 1},
+					{Type: ast.TypeFloat, Value: 2.5},
+				},
+			},
+		},
+		Cases: []testFunctionCase{
+			{
+				`${sum(list("1", "2", "3"))}`,
+				"6",
+				false,
+			},
+			{
+				`${sum(var.numbers)}`,
+				"3.5",
+				false,
+			},
+			{
+				`${sum(split(",", "1,-2,10"))}`,
+				"9",
+				false,
+			},
+			{
+				`${sum(list())}`,
+				nil,
+				true,
+			},
+			{
+				`${sum(list("a"))}`,
+				nil,
+				true,
+			},
+		},
+	})
+}
+
 func TestInterpolateFuncAbs(t *testing.T) {
 	testFunction(t, testFunctionConfig{
 		Cases: []testFunctionCase{
@@ -0,0 +1,145 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hil"
+	"github.com/hashicorp/hil/ast"
+)
+
+// tryFuncs are the functions whose arguments are evaluated one by one by
+// evalTryCalls, so that their errors can be handled, rather than all at
+// once by HIL, for which any error fails the whole interpolation.
+var tryFuncs = map[string]bool{
+	"try": true,
+	"can": true,
+}
+
+// tryOnlyVariables adds to inTry the keys of the variables that root refers
+// to within the arguments of try and can, and to outside the keys of those
+// it refers to anywhere else.
+func tryOnlyVariables(root ast.Node, inTry, outside map[string]bool) {
+	var walk func(n ast.Node, withinTry bool)
+	walk = func(n ast.Node, withinTry bool) {
+		switch n := n.(type) {
+		case *ast.Output:
+			for _, e := range n.Exprs {
+				walk(e, withinTry)
+			}
+		case *ast.Call:
+			withinTry = withinTry || tryFuncs[n.Func]
+			for _, a := range n.Args {
+				walk(a, withinTry)
+			}
+		case *ast.Arithmetic:
+			for _, e := range n.Exprs {
+				walk(e, withinTry)
+			}
+		case *ast.Conditional:
+			walk(n.CondExpr, withinTry)
+			walk(n.TrueExpr, withinTry)
+			walk(n.FalseExpr, withinTry)
+		case *ast.Index:
+			walk(n.Target, withinTry)
+			walk(n.Key, withinTry)
+		case *ast.VariableAccess:
+			// Invalid names are reported by DetectVariables.
+			v, err := NewInterpolatedVariable(n.Name)
+			if err != nil {
+				return
+			}
+			if withinTry {
+				inTry[v.FullKey()] = true
+			} else {
+				outside[v.FullKey()] = true
+			}
+		}
+	}
+	walk(root, false)
+}
+
+// evalTryCalls replaces the calls to try and can in root, innermost first,
+// with their results, by evaluating their arguments one by one with the
+// given configuration.
+//
+// try returns the value of its first argument that evaluates without error,
+// and fails if none do. can returns whether its only argument evaluates
+// without error. Either returns an unknown value if it reaches an argument
+// whose value is unknown, since it can't tell whether it will fail.
+func evalTryCalls(root ast.Node, config *hil.EvalConfig) (ast.Node, error) {
+	var resultErr error
+	fn := func(n ast.Node) ast.Node {
+		call, ok := n.(*ast.Call)
+		if !ok || !tryFuncs[call.Func] || resultErr != nil {
+			return n
+		}
+
+		var result *ast.LiteralNode
+		switch call.Func {
+		case "try":
+			result, resultErr = evalTry(call, config)
+		case "can":
+			result, resultErr = evalCan(call, config)
+		}
+		if resultErr != nil {
+			return n
+		}
+		result.Posx = call.Posx
+		return result
+	}
+
+	root = root.Accept(fn)
+	return root, resultErr
+}
+
+func evalTry(call *ast.Call, config *hil.EvalConfig) (*ast.LiteralNode, error) {
+	if len(call.Args) == 0 {
+		return nil, fmt.Errorf("try: at least one argument is required")
+	}
+
+	var lastErr error
+	for _, arg := range call.Args {
+		result, err := evalTryArg(arg, config)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		return result, nil
+	}
+	return nil, fmt.Errorf("try: no argument could be evaluated; the last error was: %s", lastErr)
+}
+
+func evalCan(call *ast.Call, config *hil.EvalConfig) (*ast.LiteralNode, error) {
+	if len(call.Args) != 1 {
+		return nil, fmt.Errorf("can: exactly one argument is required, got %d", len(call.Args))
+	}
+
+	result, err := evalTryArg(call.Args[0], config)
+	if err != nil {
+		return &ast.LiteralNode{Value: false, Typex: ast.TypeBool}, nil
+	}
+	if result.IsUnknown() {
+		return result, nil
+	}
+	return &ast.LiteralNode{Value: true, Typex: ast.TypeBool}, nil
+}
+
+// evalTryArg evaluates an argument of try or can on its own, returning its
+// value as a literal, which is unknown if any part of the value is.
+func evalTryArg(arg ast.Node, config *hil.EvalConfig) (*ast.LiteralNode, error) {
+	// Evaluating the argument as an interpolation of its own gives it the
+	// same conversions as the argument of a function call would get.
+	result, err := hil.Eval(&ast.Output{Exprs: []ast.Node{arg}, Posx: arg.Pos()}, config)
+	if err != nil {
+		return nil, err
+	}
+	if result.Type == hil.TypeUnknown {
+		return &ast.LiteralNode{Value: UnknownVariableValue, Typex: ast.TypeUnknown}, nil
+	}
+
+	v, err := hil.InterfaceToVariable(result.Value)
+	if err != nil {
+		return nil, err
+	}
+	return &ast.LiteralNode{Value: v.Value, Typex: v.Type}, nil
+}
@@ -0,0 +1,135 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/hil/ast"
+)
+
+func TestRawConfigInterpolate_try(t *testing.T) {
+	vars := map[string]ast.Variable{
+		"var.name": {Type: ast.TypeString, Value: "web"},
+		"var.list": {
+			Type: ast.TypeList,
+			Value: []ast.Variable{
+				{Type: ast.TypeString, Value: "a"},
+			},
+		},
+		"var.unknown": {Type: ast.TypeUnknown, Value: UnknownVariableValue},
+	}
+
+	tests := map[string]struct {
+		Input string
+		Want  interface{}
+		Err   bool
+	}{
+		"first succeeds": {
+			`${try(var.name, "default")}`,
+			"web",
+			false,
+		},
+		"missing variable": {
+			`${try(var.missing, "default")}`,
+			"default",
+			false,
+		},
+		"failing function": {
+			`${try(element(list(), 0), var.name)}`,
+			"web",
+			false,
+		},
+		"list": {
+			`${try(var.missing, var.list)}`,
+			[]interface{}{"a"},
+			false,
+		},
+		"nested": {
+			`${upper(try(var.missing, try(var.other, var.name)))}`,
+			"WEB",
+			false,
+		},
+		"all fail": {
+			`${try(var.missing, var.other)}`,
+			nil,
+			true,
+		},
+		"no arguments": {
+			`${try()}`,
+			nil,
+			true,
+		},
+		"unknown": {
+			`${try(var.unknown, "default")}`,
+			UnknownVariableValue,
+			false,
+		},
+		"can succeed": {
+			`${can(var.name)}`,
+			"true",
+			false,
+		},
+		"can fail": {
+			`${can(var.missing)}`,
+			"false",
+			false,
+		},
+		"conditional evaluates both results": {
+			`${can(var.missing) ? var.missing : var.name}`,
+			nil,
+			true,
+		},
+		"can unknown": {
+			`${can(var.unknown)}`,
+			UnknownVariableValue,
+			false,
+		},
+		"can with two arguments": {
+			`${can(var.name, var.name)}`,
+			nil,
+			true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			rc, err := NewRawConfig(map[string]interface{}{"foo": test.Input})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = rc.Interpolate(vars)
+			if err != nil != test.Err {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if test.Err {
+				return
+			}
+			if got := rc.Config()["foo"]; !reflect.DeepEqual(got, test.Want) {
+				t.Fatalf("wrong result %#v; want %#v", got, test.Want)
+			}
+		})
+	}
+}
+
+func TestRawConfig_tryVariables(t *testing.T) {
+	rc, err := NewRawConfig(map[string]interface{}{
+		"a": `${try(var.optional, var.both)}`,
+		"b": `${var.both}`,
+		"c": `${can(aws_instance.web.missing) ? "yes" : var.outside}`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]bool{
+		"var.optional":             true,
+		"aws_instance.web.missing": true,
+	}
+	if !reflect.DeepEqual(rc.TryVariables, want) {
+		t.Fatalf("wrong try variables %#v; want %#v", rc.TryVariables, want)
+	}
+	if len(rc.Variables) != 4 {
+		t.Fatalf("wrong variables %#v", rc.Variables)
+	}
+}
@@ -120,7 +120,7 @@ func (w *interpolationWalker) Primitive(v reflect.Value) error {
 		return nil
 	}
 
-	astRoot, err := hil.Parse(v.String())
+	astRoot, err := hil.Parse(rewriteProviderFuncCalls(v.String()))
 	if err != nil {
 		return err
 	}
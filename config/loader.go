@@ -10,6 +10,7 @@ import (
 	"strings"
 
 	"github.com/hashicorp/hcl"
+	"github.com/hashicorp/terraform/tfdiags"
 )
 
 // ErrNoConfigsFound is the error returned by LoadDir if no
@@ -72,7 +73,8 @@ func LoadFile(path string) (*Config, error) {
 // Special files known as "override files" can also be present, which
 // are merged into the loaded configuration. That is, the non-override
 // files are loaded first to create the configuration. Then, the overrides
-// are merged into the configuration to create the final configuration.
+// are merged into the configuration to create the final configuration,
+// as described for Override.
 //
 // Files are loaded in lexical order.
 func LoadDir(root string) (*Config, error) {
@@ -123,9 +125,10 @@ func LoadDir(root string) (*Config, error) {
 			return nil, err
 		}
 
-		result, err = Merge(result, c)
-		if err != nil {
-			return nil, err
+		var diags tfdiags.Diagnostics
+		result, diags = Override(result, c)
+		if diags.HasErrors() {
+			return nil, diags.Err()
 		}
 	}
 
@@ -3,10 +3,12 @@ package config
 import (
 	"fmt"
 	"io/ioutil"
+	"strings"
 
 	"github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/hcl"
 	"github.com/hashicorp/hcl/hcl/ast"
+	"github.com/hashicorp/terraform/tfdiags"
 	"github.com/mitchellh/mapstructure"
 )
 
@@ -44,9 +46,11 @@ var ReservedProviderFields = []string{
 func (t *hclConfigurable) Config() (*Config, error) {
 	validKeys := map[string]struct{}{
 		"atlas":     struct{}{},
+		"check":     struct{}{},
 		"data":      struct{}{},
 		"locals":    struct{}{},
 		"module":    struct{}{},
+		"moved":     struct{}{},
 		"output":    struct{}{},
 		"provider":  struct{}{},
 		"resource":  struct{}{},
@@ -59,6 +63,7 @@ func (t *hclConfigurable) Config() (*Config, error) {
 	if !ok {
 		return nil, fmt.Errorf("error parsing: file doesn't contain a root object")
 	}
+	removeJSONComments(list)
 
 	// Start building up the actual configuration.
 	config := new(Config)
@@ -66,7 +71,7 @@ func (t *hclConfigurable) Config() (*Config, error) {
 	// Terraform config
 	if o := list.Filter("terraform"); len(o.Items) > 0 {
 		var err error
-		config.Terraform, err = loadTerraformHcl(o)
+		config.Terraform, err = loadTerraformHcl(t.File, o)
 		if err != nil {
 			return nil, err
 		}
@@ -75,7 +80,7 @@ func (t *hclConfigurable) Config() (*Config, error) {
 	// Build the variables
 	if vars := list.Filter("variable"); len(vars.Items) > 0 {
 		var err error
-		config.Variables, err = loadVariablesHcl(vars)
+		config.Variables, err = loadVariablesHcl(t.File, vars)
 		if err != nil {
 			return nil, err
 		}
@@ -84,7 +89,7 @@ func (t *hclConfigurable) Config() (*Config, error) {
 	// Build local values
 	if locals := list.Filter("locals"); len(locals.Items) > 0 {
 		var err error
-		config.Locals, err = loadLocalsHcl(locals)
+		config.Locals, err = loadLocalsHcl(t.File, locals)
 		if err != nil {
 			return nil, err
 		}
@@ -102,7 +107,7 @@ func (t *hclConfigurable) Config() (*Config, error) {
 	// Build the modules
 	if modules := list.Filter("module"); len(modules.Items) > 0 {
 		var err error
-		config.Modules, err = loadModulesHcl(modules)
+		config.Modules, err = loadModulesHcl(t.File, modules)
 		if err != nil {
 			return nil, err
 		}
@@ -111,7 +116,7 @@ func (t *hclConfigurable) Config() (*Config, error) {
 	// Build the provider configs
 	if providers := list.Filter("provider"); len(providers.Items) > 0 {
 		var err error
-		config.ProviderConfigs, err = loadProvidersHcl(providers)
+		config.ProviderConfigs, err = loadProvidersHcl(t.File, providers)
 		if err != nil {
 			return nil, err
 		}
@@ -128,11 +133,11 @@ func (t *hclConfigurable) Config() (*Config, error) {
 			len(managedResourceConfigs.Items)+len(dataResourceConfigs.Items),
 		)
 
-		managedResources, err := loadManagedResourcesHcl(managedResourceConfigs)
+		managedResources, err := loadManagedResourcesHcl(t.File, managedResourceConfigs)
 		if err != nil {
 			return nil, err
 		}
-		dataResources, err := loadDataResourcesHcl(dataResourceConfigs)
+		dataResources, err := loadDataResourcesHcl(t.File, dataResourceConfigs)
 		if err != nil {
 			return nil, err
 		}
@@ -144,12 +149,32 @@ func (t *hclConfigurable) Config() (*Config, error) {
 	// Build the outputs
 	if outputs := list.Filter("output"); len(outputs.Items) > 0 {
 		var err error
-		config.Outputs, err = loadOutputsHcl(outputs)
+		config.Outputs, err = loadOutputsHcl(t.File, outputs)
 		if err != nil {
 			return nil, err
 		}
 	}
 
+	// Build the moved blocks
+	if moved := list.Filter("moved"); len(moved.Items) > 0 {
+		var err error
+		config.Moved, err = loadMovedHcl(t.File, moved)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Build the checks, along with the data sources scoped to them
+	if checks := list.Filter("check"); len(checks.Items) > 0 {
+		var resources []*Resource
+		var err error
+		config.Checks, resources, err = loadChecksHcl(t.File, checks)
+		if err != nil {
+			return nil, err
+		}
+		config.Resources = append(config.Resources, resources...)
+	}
+
 	// Check for invalid keys
 	for _, item := range list.Items {
 		if len(item.Keys) == 0 {
@@ -235,7 +260,7 @@ func loadFileHcl(root string) (configurable, []string, error) {
 }
 
 // Given a handle to a HCL object, this transforms it into the Terraform config
-func loadTerraformHcl(list *ast.ObjectList) (*Terraform, error) {
+func loadTerraformHcl(filename string, list *ast.ObjectList) (*Terraform, error) {
 	if len(list.Items) > 1 {
 		return nil, fmt.Errorf("only one 'terraform' block allowed per module")
 	}
@@ -289,9 +314,140 @@ func loadTerraformHcl(list *ast.ObjectList) (*Terraform, error) {
 		}
 	}
 
+	if os := listVal.Filter("provider_meta"); len(os.Items) > 0 {
+		var err error
+		config.ProviderMetas, err = loadTerraformProviderMetasHcl(os)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"Error reading provider_meta config for terraform block: %s",
+				err)
+		}
+	}
+
+	if os := listVal.Filter("required_providers"); len(os.Items) > 0 {
+		var err error
+		config.RequiredProviders, err = loadTerraformRequiredProvidersHcl(filename, os)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"Error reading required_providers for terraform block: %s",
+				err)
+		}
+	}
+
 	return &config, nil
 }
 
+// Loads the required providers from an object list. Each entry is either a
+// version constraint string or an object with "source", "version" and
+// "configuration_aliases".
+func loadTerraformRequiredProvidersHcl(filename string, list *ast.ObjectList) ([]*RequiredProvider, error) {
+	// Each required_providers block is an object of entries, but the JSON
+	// parser flattens it into entries keyed by provider name.
+	var entries []*ast.ObjectItem
+	for _, item := range list.Items {
+		if len(item.Keys) > 0 {
+			entries = append(entries, item)
+			continue
+		}
+		ot, ok := item.Val.(*ast.ObjectType)
+		if !ok {
+			return nil, fmt.Errorf("position %s: required_providers must be an object", item.Pos())
+		}
+		entries = append(entries, ot.List.Items...)
+	}
+
+	result := make([]*RequiredProvider, 0, len(entries))
+	for _, item := range entries {
+		if len(item.Keys) != 1 {
+			return nil, fmt.Errorf(
+				"position %s: each required provider must be a name and either a version constraint or an object",
+				item.Pos())
+		}
+
+		p := &RequiredProvider{
+			Name:      item.Keys[0].Token.Value().(string),
+			DeclRange: hclItemRange(filename, item),
+		}
+		switch v := item.Val.(type) {
+		case *ast.LiteralType:
+			if err := hcl.DecodeObject(&p.Version, v); err != nil {
+				return nil, fmt.Errorf("Error reading %s: %s", p.Name, err)
+			}
+		case *ast.ObjectType:
+			var raw struct {
+				Source               string   `hcl:"source"`
+				Version              string   `hcl:"version"`
+				ConfigurationAliases []string `hcl:"configuration_aliases"`
+			}
+			if err := hcl.DecodeObject(&raw, v); err != nil {
+				return nil, fmt.Errorf("Error reading %s: %s", p.Name, err)
+			}
+			var unknown []string
+			for _, attr := range v.List.Items {
+				switch k := attr.Keys[0].Token.Value().(string); k {
+				case "source", "version", "configuration_aliases":
+				default:
+					unknown = append(unknown, k)
+				}
+			}
+			if len(unknown) > 0 {
+				return nil, fmt.Errorf(
+					"%s: unsupported arguments %s; only source, version and configuration_aliases are allowed",
+					p.Name, strings.Join(unknown, ", "))
+			}
+			p.Source = raw.Source
+			p.Version = raw.Version
+			p.ConfigurationAliases = raw.ConfigurationAliases
+		default:
+			return nil, fmt.Errorf(
+				"position %s: %s must be a version constraint or an object with source and version",
+				item.Pos(), p.Name)
+		}
+
+		result = append(result, p)
+	}
+
+	return result, nil
+}
+
+// Loads the provider metadata from an object list.
+func loadTerraformProviderMetasHcl(list *ast.ObjectList) ([]*ProviderMeta, error) {
+	list = list.Children()
+	if len(list.Items) == 0 {
+		return nil, nil
+	}
+
+	result := make([]*ProviderMeta, 0, len(list.Items))
+	for _, item := range list.Items {
+		if len(item.Keys) != 1 {
+			return nil, fmt.Errorf(
+				"position %s: 'provider_meta' must be followed by exactly one string: a provider name",
+				item.Pos())
+		}
+
+		n := item.Keys[0].Token.Value().(string)
+
+		var config map[string]interface{}
+		if err := hcl.DecodeObject(&config, item.Val); err != nil {
+			return nil, fmt.Errorf(
+				"Error reading provider_meta config for %s: %s", n, err)
+		}
+
+		rawConfig, err := NewRawConfig(config)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"Error reading provider_meta config for %s: %s", n, err)
+		}
+
+		result = append(result, &ProviderMeta{
+			Name:      n,
+			RawConfig: rawConfig,
+		})
+	}
+
+	return result, nil
+}
+
 // Loads the Backend configuration from an object list.
 func loadTerraformBackendHcl(list *ast.ObjectList) (*Backend, error) {
 	if len(list.Items) > 1 {
@@ -360,7 +516,8 @@ func loadAtlasHcl(list *ast.ObjectList) (*AtlasConfig, error) {
 // The resulting modules may not be unique, but each module
 // represents exactly one module definition in the HCL configuration.
 // We leave it up to another pass to merge them together.
-func loadModulesHcl(list *ast.ObjectList) ([]*Module, error) {
+func loadModulesHcl(filename string, list *ast.ObjectList) ([]*Module, error) {
+	list = expandHCLObjectKeysFromJSON(list, 1)
 	if err := assertAllBlocksHaveNames("module", list); err != nil {
 		return nil, err
 	}
@@ -376,6 +533,7 @@ func loadModulesHcl(list *ast.ObjectList) ([]*Module, error) {
 	// Now go over all the types and their children in order to get
 	// all of the actual resources.
 	for _, item := range list.Items {
+		unwrapHCLObjectKeysFromJSON(item, 1)
 		k := item.Keys[0].Token.Value().(string)
 
 		var listVal *ast.ObjectList
@@ -445,6 +603,7 @@ func loadModulesHcl(list *ast.ObjectList) ([]*Module, error) {
 			Version:   version,
 			Providers: providers,
 			RawConfig: rawConfig,
+			DeclRange: hclBlockRange(filename, item),
 		})
 	}
 
@@ -453,11 +612,12 @@ func loadModulesHcl(list *ast.ObjectList) ([]*Module, error) {
 
 // loadLocalsHcl recurses into the given HCL object turns it into
 // a list of locals.
-func loadLocalsHcl(list *ast.ObjectList) ([]*Local, error) {
+func loadLocalsHcl(filename string, list *ast.ObjectList) ([]*Local, error) {
 
 	result := make([]*Local, 0, len(list.Items))
 
 	for _, block := range list.Items {
+		unwrapHCLObjectKeysFromJSON(block, 0)
 		if len(block.Keys) > 0 {
 			return nil, fmt.Errorf(
 				"locals block at %s should not have label %q",
@@ -496,6 +656,7 @@ func loadLocalsHcl(list *ast.ObjectList) ([]*Local, error) {
 				result = append(result, &Local{
 					Name:      k,
 					RawConfig: rawConfig,
+					DeclRange: hclItemRange(filename, item),
 				})
 			}
 		}
@@ -504,9 +665,38 @@ func loadLocalsHcl(list *ast.ObjectList) ([]*Local, error) {
 	return result, nil
 }
 
+// loadMovedHcl turns the given "moved" blocks into Moved structures.
+func loadMovedHcl(filename string, list *ast.ObjectList) ([]*Moved, error) {
+	result := make([]*Moved, 0, len(list.Items))
+	for _, item := range list.Items {
+		if len(item.Keys) > 0 {
+			return nil, fmt.Errorf(
+				"moved block at %s should not have label %q",
+				item.Pos(), item.Keys[0].Token.Value(),
+			)
+		}
+
+		var m Moved
+		if err := hcl.DecodeObject(&m, item.Val); err != nil {
+			return nil, fmt.Errorf(
+				"Error reading moved block at %s: %s", item.Pos(), err)
+		}
+		if m.From == "" || m.To == "" {
+			return nil, fmt.Errorf(
+				"moved block at %s must set both \"from\" and \"to\"", item.Pos())
+		}
+		m.DeclRange = hclBlockRange(filename, item)
+
+		result = append(result, &m)
+	}
+
+	return result, nil
+}
+
 // LoadOutputsHcl recurses into the given HCL object and turns
 // it into a mapping of outputs.
-func loadOutputsHcl(list *ast.ObjectList) ([]*Output, error) {
+func loadOutputsHcl(filename string, list *ast.ObjectList) ([]*Output, error) {
+	list = expandHCLObjectKeysFromJSON(list, 1)
 	if err := assertAllBlocksHaveNames("output", list); err != nil {
 		return nil, err
 	}
@@ -516,6 +706,7 @@ func loadOutputsHcl(list *ast.ObjectList) ([]*Output, error) {
 	// Go through each object and turn it into an actual result.
 	result := make([]*Output, 0, len(list.Items))
 	for _, item := range list.Items {
+		unwrapHCLObjectKeysFromJSON(item, 1)
 		n := item.Keys[0].Token.Value().(string)
 
 		var listVal *ast.ObjectList
@@ -571,6 +762,7 @@ func loadOutputsHcl(list *ast.ObjectList) ([]*Output, error) {
 			RawConfig:   rawConfig,
 			DependsOn:   dependsOn,
 			Description: description,
+			DeclRange:   hclBlockRange(filename, item),
 		})
 	}
 
@@ -579,7 +771,8 @@ func loadOutputsHcl(list *ast.ObjectList) ([]*Output, error) {
 
 // LoadVariablesHcl recurses into the given HCL object and turns
 // it into a list of variables.
-func loadVariablesHcl(list *ast.ObjectList) ([]*Variable, error) {
+func loadVariablesHcl(filename string, list *ast.ObjectList) ([]*Variable, error) {
+	list = expandHCLObjectKeysFromJSON(list, 1)
 	if err := assertAllBlocksHaveNames("variable", list); err != nil {
 		return nil, err
 	}
@@ -591,6 +784,7 @@ func loadVariablesHcl(list *ast.ObjectList) ([]*Variable, error) {
 		DeclaredType string `hcl:"type"`
 		Default      interface{}
 		Description  string
+		Sensitive    bool
 		Fields       []string `hcl:",decodedFields"`
 	}
 
@@ -615,12 +809,24 @@ func loadVariablesHcl(list *ast.ObjectList) ([]*Variable, error) {
 		}
 
 		// Check for invalid keys
-		valid := []string{"type", "default", "description"}
+		valid := []string{"type", "default", "description", "sensitive", "validation"}
 		if err := checkHCLKeys(item.Val, valid); err != nil {
 			return nil, multierror.Prefix(err, fmt.Sprintf(
 				"variable[%s]:", n))
 		}
 
+		var validations []*VariableValidation
+		if ot, ok := item.Val.(*ast.ObjectType); ok {
+			if o := ot.List.Filter("validation"); len(o.Items) > 0 {
+				var err error
+				validations, err = loadVariableValidationsHcl(filename, o)
+				if err != nil {
+					return nil, multierror.Prefix(err, fmt.Sprintf(
+						"variable[%s]:", n))
+				}
+			}
+		}
+
 		// Decode into hclVariable to get typed values
 		var hclVar hclVariable
 		if err := hcl.DecodeObject(&hclVar, item.Val); err != nil {
@@ -629,8 +835,10 @@ func loadVariablesHcl(list *ast.ObjectList) ([]*Variable, error) {
 
 		// Defaults turn into a slice of map[string]interface{} and
 		// we need to make sure to convert that down into the
-		// proper type for Config.
-		if ms, ok := hclVar.Default.([]map[string]interface{}); ok {
+		// proper type for Config, unless it's a list of maps.
+		t, _ := (&Variable{DeclaredType: hclVar.DeclaredType}).TypeConstraint()
+		isList := t != nil && t.VariableType() == VariableTypeList
+		if ms, ok := hclVar.Default.([]map[string]interface{}); ok && !isList {
 			def := make(map[string]interface{})
 			for _, m := range ms {
 				for k, v := range m {
@@ -647,6 +855,9 @@ func loadVariablesHcl(list *ast.ObjectList) ([]*Variable, error) {
 			DeclaredType: hclVar.DeclaredType,
 			Default:      hclVar.Default,
 			Description:  hclVar.Description,
+			Sensitive:    hclVar.Sensitive,
+			Validations:  validations,
+			DeclRange:    hclBlockRange(filename, item),
 		}
 		if err := newVar.ValidateTypeAndDefault(); err != nil {
 			return nil, err
@@ -658,9 +869,198 @@ func loadVariablesHcl(list *ast.ObjectList) ([]*Variable, error) {
 	return result, nil
 }
 
+// loadVariableValidationsHcl turns the "validation" blocks of a variable in
+// the given file into VariableValidation structures.
+func loadVariableValidationsHcl(filename string, list *ast.ObjectList) ([]*VariableValidation, error) {
+	result := make([]*VariableValidation, 0, len(list.Items))
+	for _, item := range list.Items {
+		rc, msg, rng, err := loadConditionHcl(filename, "validation", item)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, &VariableValidation{
+			Condition:    rc,
+			ErrorMessage: msg,
+			DeclRange:    rng,
+		})
+	}
+
+	return result, nil
+}
+
+// loadConditionHcl reads a block of the given type in the given file that
+// has a condition and an error_message, returning the condition, the
+// message and the range of the condition.
+func loadConditionHcl(filename, blockType string, item *ast.ObjectItem) (*RawConfig, string, tfdiags.SourceRange, error) {
+	var rng tfdiags.SourceRange
+	if len(item.Keys) > 0 {
+		return nil, "", rng, fmt.Errorf(
+			"%s block at %s should not have label %q",
+			blockType, item.Pos(), item.Keys[0].Token.Value(),
+		)
+	}
+	ot, ok := item.Val.(*ast.ObjectType)
+	if !ok {
+		return nil, "", rng, fmt.Errorf("%s at %s should be a block", blockType, item.Pos())
+	}
+
+	valid := []string{"condition", "error_message"}
+	if err := checkHCLKeys(item.Val, valid); err != nil {
+		return nil, "", rng, err
+	}
+
+	var v struct {
+		Condition    string `hcl:"condition"`
+		ErrorMessage string `hcl:"error_message"`
+	}
+	if err := hcl.DecodeObject(&v, item.Val); err != nil {
+		return nil, "", rng, fmt.Errorf(
+			"Error reading %s block at %s: %s", blockType, item.Pos(), err)
+	}
+
+	// Filter would strip the key that the range starts at.
+	var cond *ast.ObjectItem
+	for _, attr := range ot.List.Items {
+		if len(attr.Keys) == 1 && attr.Keys[0].Token.Value() == "condition" {
+			cond = attr
+		}
+	}
+	if cond == nil {
+		return nil, "", rng, fmt.Errorf(
+			"%s block at %s must set \"condition\"", blockType, item.Pos())
+	}
+
+	rc, err := NewRawConfig(map[string]interface{}{
+		"condition": v.Condition,
+	})
+	if err != nil {
+		return nil, "", rng, fmt.Errorf(
+			"Error reading condition at %s: %s", cond.Pos(), err)
+	}
+
+	return rc, v.ErrorMessage, hclItemRange(filename, cond), nil
+}
+
+// loadChecksHcl turns the given "check" blocks into Check structures,
+// along with the data sources that are declared within them.
+func loadChecksHcl(filename string, list *ast.ObjectList) ([]*Check, []*Resource, error) {
+	list = expandHCLObjectKeysFromJSON(list, 1)
+	if err := assertAllBlocksHaveNames("check", list); err != nil {
+		return nil, nil, err
+	}
+
+	list = list.Children()
+
+	var checks []*Check
+	var resources []*Resource
+	for _, item := range list.Items {
+		n := item.Keys[0].Token.Value().(string)
+		if len(item.Keys) > 1 {
+			return nil, nil, fmt.Errorf(
+				"check %q at %s should have exactly one label", n, item.Pos())
+		}
+		ot, ok := item.Val.(*ast.ObjectType)
+		if !ok {
+			return nil, nil, fmt.Errorf("check %q: should be a block", n)
+		}
+
+		valid := []string{"assert", "data"}
+		if err := checkHCLKeys(item.Val, valid); err != nil {
+			return nil, nil, multierror.Prefix(err, fmt.Sprintf("check %q:", n))
+		}
+
+		ch := &Check{
+			Name:      n,
+			DeclRange: hclBlockRange(filename, item),
+		}
+		for _, a := range ot.List.Filter("assert").Items {
+			rc, msg, rng, err := loadConditionHcl(filename, "assert", a)
+			if err != nil {
+				return nil, nil, fmt.Errorf("check %q: %s", n, err)
+			}
+			ch.Asserts = append(ch.Asserts, &CheckAssert{
+				Condition:    rc,
+				ErrorMessage: msg,
+				DeclRange:    rng,
+			})
+		}
+
+		datas, err := loadDataResourcesHcl(filename, ot.List.Filter("data"))
+		if err != nil {
+			return nil, nil, fmt.Errorf("check %q: %s", n, err)
+		}
+		for _, r := range datas {
+			r.Check = n
+		}
+
+		checks = append(checks, ch)
+		resources = append(resources, datas...)
+	}
+
+	return checks, resources, nil
+}
+
+// hclItemRange returns the range of an attribute in the given file, from
+// the start of its key to the end of its value if that's a literal, or to
+// the end of its key otherwise.
+func hclItemRange(filename string, item *ast.ObjectItem) tfdiags.SourceRange {
+	start := item.Keys[0].Token.Pos
+	if !start.IsValid() {
+		// The JSON parser doesn't record where keys are, so their ranges
+		// are unknown.
+		return tfdiags.SourceRange{}
+	}
+	end := start
+	text := item.Keys[0].Token.Text
+	if lit, ok := item.Val.(*ast.LiteralType); ok {
+		end = lit.Token.Pos
+		text = lit.Token.Text
+	}
+
+	endPos := tfdiags.SourcePos{
+		Line:   end.Line,
+		Column: end.Column + len(text),
+		Byte:   end.Offset + len(text),
+	}
+	if i := strings.LastIndex(text, "\n"); i >= 0 {
+		endPos.Line += strings.Count(text, "\n")
+		endPos.Column = len(text) - i
+	}
+
+	return tfdiags.SourceRange{
+		Filename: filename,
+		Start: tfdiags.SourcePos{
+			Line:   start.Line,
+			Column: start.Column,
+			Byte:   start.Offset,
+		},
+		End: endPos,
+	}
+}
+
+// hclBlockRange returns the range of the header of a block in the given
+// file, spanning its labels, or its opening brace if it has none.
+func hclBlockRange(filename string, item *ast.ObjectItem) tfdiags.SourceRange {
+	if len(item.Keys) == 0 {
+		start := item.Val.Pos()
+		return tfdiags.SourceRange{
+			Filename: filename,
+			Start:    tfdiags.SourcePos{Line: start.Line, Column: start.Column, Byte: start.Offset},
+			End:      tfdiags.SourcePos{Line: start.Line, Column: start.Column + 1, Byte: start.Offset + 1},
+		}
+	}
+
+	rng := hclItemRange(filename, &ast.ObjectItem{Keys: item.Keys[len(item.Keys)-1:]})
+	first := item.Keys[0].Token.Pos
+	rng.Start = tfdiags.SourcePos{Line: first.Line, Column: first.Column, Byte: first.Offset}
+	return rng
+}
+
 // LoadProvidersHcl recurses into the given HCL object and turns
 // it into a mapping of provider configs.
-func loadProvidersHcl(list *ast.ObjectList) ([]*ProviderConfig, error) {
+func loadProvidersHcl(filename string, list *ast.ObjectList) ([]*ProviderConfig, error) {
+	list = expandHCLObjectKeysFromJSON(list, 1)
 	if err := assertAllBlocksHaveNames("provider", list); err != nil {
 		return nil, err
 	}
@@ -673,6 +1073,7 @@ func loadProvidersHcl(list *ast.ObjectList) ([]*ProviderConfig, error) {
 	// Go through each object and turn it into an actual result.
 	result := make([]*ProviderConfig, 0, len(list.Items))
 	for _, item := range list.Items {
+		unwrapHCLObjectKeysFromJSON(item, 1)
 		n := item.Keys[0].Token.Value().(string)
 
 		var listVal *ast.ObjectList
@@ -689,6 +1090,8 @@ func loadProvidersHcl(list *ast.ObjectList) ([]*ProviderConfig, error) {
 
 		delete(config, "alias")
 		delete(config, "version")
+		delete(config, "timeouts")
+		delete(config, "parallelism")
 
 		rawConfig, err := NewRawConfig(config)
 		if err != nil {
@@ -722,11 +1125,43 @@ func loadProvidersHcl(list *ast.ObjectList) ([]*ProviderConfig, error) {
 			}
 		}
 
+		// If we have a parallelism field then extract it
+		var parallelism int
+		if a := listVal.Filter("parallelism"); len(a.Items) > 0 {
+			err := hcl.DecodeObject(&parallelism, a.Items[0].Val)
+			if err != nil {
+				return nil, fmt.Errorf(
+					"Error reading parallelism for provider[%s]: %s",
+					n,
+					err)
+			}
+		}
+
+		// If we have a timeouts block then parse it
+		var timeouts *ProviderTimeouts
+		if a := listVal.Filter("timeouts"); len(a.Items) > 0 {
+			var raw map[string]string
+			err := hcl.DecodeObject(&raw, a.Items[0].Val)
+			if err == nil {
+				timeouts, err = NewProviderTimeouts(raw)
+			}
+			if err != nil {
+				return nil, fmt.Errorf(
+					"Error reading timeouts for provider[%s]: %s",
+					n,
+					err)
+			}
+		}
+
 		result = append(result, &ProviderConfig{
 			Name:      n,
 			Alias:     alias,
 			Version:   version,
+			Timeouts:  timeouts,
 			RawConfig: rawConfig,
+			DeclRange: hclBlockRange(filename, item),
+
+			Parallelism: parallelism,
 		})
 	}
 
@@ -739,7 +1174,8 @@ func loadProvidersHcl(list *ast.ObjectList) ([]*ProviderConfig, error) {
 // The resulting data sources may not be unique, but each one
 // represents exactly one data definition in the HCL configuration.
 // We leave it up to another pass to merge them together.
-func loadDataResourcesHcl(list *ast.ObjectList) ([]*Resource, error) {
+func loadDataResourcesHcl(filename string, list *ast.ObjectList) ([]*Resource, error) {
+	list = expandHCLObjectKeysFromJSON(list, 2)
 	if err := assertAllBlocksHaveNames("data", list); err != nil {
 		return nil, err
 	}
@@ -755,6 +1191,7 @@ func loadDataResourcesHcl(list *ast.ObjectList) ([]*Resource, error) {
 	// Now go over all the types and their children in order to get
 	// all of the actual resources.
 	for _, item := range list.Items {
+		unwrapHCLObjectKeysFromJSON(item, 2)
 		if len(item.Keys) != 2 {
 			return nil, fmt.Errorf(
 				"position %s: 'data' must be followed by exactly two strings: a type and a name",
@@ -796,7 +1233,9 @@ func loadDataResourcesHcl(list *ast.ObjectList) ([]*Resource, error) {
 
 		// If we have a count, then figure it out
 		var count string = "1"
+		var setKeys []string
 		if o := listVal.Filter("count"); len(o.Items) > 0 {
+			setKeys = append(setKeys, "count")
 			err = hcl.DecodeObject(&count, o.Items[0].Val)
 			if err != nil {
 				return nil, fmt.Errorf(
@@ -850,6 +1289,8 @@ func loadDataResourcesHcl(list *ast.ObjectList) ([]*Resource, error) {
 			Provisioners: []*Provisioner{},
 			DependsOn:    dependsOn,
 			Lifecycle:    ResourceLifecycle{},
+			DeclRange:    hclBlockRange(filename, item),
+			setKeys:      setKeys,
 		})
 	}
 
@@ -862,7 +1303,8 @@ func loadDataResourcesHcl(list *ast.ObjectList) ([]*Resource, error) {
 // The resulting resources may not be unique, but each resource
 // represents exactly one "resource" block in the HCL configuration.
 // We leave it up to another pass to merge them together.
-func loadManagedResourcesHcl(list *ast.ObjectList) ([]*Resource, error) {
+func loadManagedResourcesHcl(filename string, list *ast.ObjectList) ([]*Resource, error) {
+	list = expandHCLObjectKeysFromJSON(list, 2)
 	list = list.Children()
 	if len(list.Items) == 0 {
 		return nil, nil
@@ -930,7 +1372,9 @@ func loadManagedResourcesHcl(list *ast.ObjectList) ([]*Resource, error) {
 
 		// If we have a count, then figure it out
 		var count string = "1"
+		var setKeys []string
 		if o := listVal.Filter("count"); len(o.Items) > 0 {
+			setKeys = append(setKeys, "count")
 			err = hcl.DecodeObject(&count, o.Items[0].Val)
 			if err != nil {
 				return nil, fmt.Errorf(
@@ -1005,6 +1449,7 @@ func loadManagedResourcesHcl(list *ast.ObjectList) ([]*Resource, error) {
 		// destroying the existing instance
 		var lifecycle ResourceLifecycle
 		if o := listVal.Filter("lifecycle"); len(o.Items) > 0 {
+			setKeys = append(setKeys, "lifecycle")
 			if len(o.Items) > 1 {
 				return nil, fmt.Errorf(
 					"%s[%s]: Multiple lifecycle blocks found, expected one",
@@ -1012,7 +1457,7 @@ func loadManagedResourcesHcl(list *ast.ObjectList) ([]*Resource, error) {
 			}
 
 			// Check for invalid keys
-			valid := []string{"create_before_destroy", "ignore_changes", "prevent_destroy"}
+			valid := []string{"create_before_destroy", "ignore_changes", "prevent_destroy", "lock"}
 			if err := checkHCLKeys(o.Items[0].Val, valid); err != nil {
 				return nil, multierror.Prefix(err, fmt.Sprintf(
 					"%s[%s]:", t, k))
@@ -1046,6 +1491,8 @@ func loadManagedResourcesHcl(list *ast.ObjectList) ([]*Resource, error) {
 			Provider:     provider,
 			DependsOn:    dependsOn,
 			Lifecycle:    lifecycle,
+			DeclRange:    hclBlockRange(filename, item),
+			setKeys:      setKeys,
 		})
 	}
 
@@ -1053,6 +1500,7 @@ func loadManagedResourcesHcl(list *ast.ObjectList) ([]*Resource, error) {
 }
 
 func loadProvisionersHcl(list *ast.ObjectList, connInfo map[string]interface{}) ([]*Provisioner, error) {
+	list = expandHCLObjectKeysFromJSON(list, 1)
 	if err := assertAllBlocksHaveNames("provisioner", list); err != nil {
 		return nil, err
 	}
@@ -1065,6 +1513,7 @@ func loadProvisionersHcl(list *ast.ObjectList, connInfo map[string]interface{})
 	// Go through each object and turn it into an actual result.
 	result := make([]*Provisioner, 0, len(list.Items))
 	for _, item := range list.Items {
+		unwrapHCLObjectKeysFromJSON(item, 1)
 		n := item.Keys[0].Token.Value().(string)
 
 		var listVal *ast.ObjectList
@@ -1230,6 +1679,79 @@ func checkHCLKeys(node ast.Node, valid []string) error {
 	return result
 }
 
+// expandHCLObjectKeysFromJSON is the counterpart of
+// unwrapHCLObjectKeysFromJSON for blocks in JSON that have fewer keys than
+// the labels we expect, because the objects for the labels have properties
+// that aren't objects, such as comments, or because they're in arrays:
+//
+//	{ "provider": [{ "aws": { "region": "us-east-1" } }] }
+//
+// Each object within the block's object becomes an item of its own with the
+// keys leading to it, until there are depth keys.
+func expandHCLObjectKeysFromJSON(list *ast.ObjectList, depth int) *ast.ObjectList {
+	result := &ast.ObjectList{Items: make([]*ast.ObjectItem, 0, len(list.Items))}
+	for _, item := range list.Items {
+		result.Items = append(result.Items, expandHCLObjectItemFromJSON(item, depth)...)
+	}
+	return result
+}
+
+func expandHCLObjectItemFromJSON(item *ast.ObjectItem, depth int) []*ast.ObjectItem {
+	ot, ok := item.Val.(*ast.ObjectType)
+	if len(item.Keys) >= depth || !ok || len(ot.List.Items) == 0 {
+		return []*ast.ObjectItem{item}
+	}
+	for _, sub := range ot.List.Items {
+		if _, ok := sub.Val.(*ast.ObjectType); !ok || len(sub.Keys) == 0 || !sub.Keys[0].Token.JSON {
+			return []*ast.ObjectItem{item}
+		}
+	}
+
+	var result []*ast.ObjectItem
+	for _, sub := range ot.List.Items {
+		keys := make([]*ast.ObjectKey, 0, len(item.Keys)+len(sub.Keys))
+		keys = append(keys, item.Keys...)
+		keys = append(keys, sub.Keys...)
+		result = append(result, expandHCLObjectItemFromJSON(&ast.ObjectItem{
+			Keys: keys,
+			Val:  sub.Val,
+		}, depth)...)
+	}
+	return result
+}
+
+// removeJSONComments removes the properties named "//" from the objects in
+// a configuration in JSON, where they're comments.
+func removeJSONComments(list *ast.ObjectList) {
+	ast.Walk(list, func(n ast.Node) (ast.Node, bool) {
+		list, ok := n.(*ast.ObjectList)
+		if !ok {
+			return n, true
+		}
+
+		items := list.Items[:0]
+		for _, item := range list.Items {
+			if !isJSONComment(item) {
+				items = append(items, item)
+			}
+		}
+		list.Items = items
+		return n, true
+	})
+}
+
+// isJSONComment returns true if any of the keys of item is a JSON "//"
+// property. In JSON, nested objects show up as additional keys, so a comment
+// at the start of an object of blocks is one of several.
+func isJSONComment(item *ast.ObjectItem) bool {
+	for _, k := range item.Keys {
+		if k.Token.JSON && k.Token.Value() == "//" {
+			return true
+		}
+	}
+	return false
+}
+
 // unwrapHCLObjectKeysFromJSON cleans up an edge case that can occur when
 // parsing JSON as input: if we're parsing JSON then directly nested
 // items will show up as additional "keys".
@@ -1248,11 +1770,12 @@ func checkHCLKeys(node ast.Node, valid []string) error {
 func unwrapHCLObjectKeysFromJSON(item *ast.ObjectItem, depth int) {
 	if len(item.Keys) > depth && item.Keys[0].Token.JSON {
 		for len(item.Keys) > depth {
-			// Pop off the last key
+			// Pop off the last key. The keys may be shared with other
+			// items that came from the same JSON array, so they're not
+			// modified in place.
 			n := len(item.Keys)
 			key := item.Keys[n-1]
-			item.Keys[n-1] = nil
-			item.Keys = item.Keys[:n-1]
+			item.Keys = item.Keys[: n-1 : n-1]
 
 			// Wrap our value in a list
 			item.Val = &ast.ObjectType{
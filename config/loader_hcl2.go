@@ -9,6 +9,7 @@ import (
 	hcl2 "github.com/hashicorp/hcl2/hcl"
 	hcl2parse "github.com/hashicorp/hcl2/hclparse"
 	"github.com/hashicorp/terraform/config/hcl2shim"
+	"github.com/hashicorp/terraform/tfdiags"
 	"github.com/zclconf/go-cty/cty"
 )
 
@@ -75,11 +76,18 @@ func (t *hcl2Configurable) Config() (*Config, error) {
 		Include *[]string `hcl:"include"`
 		Exclude *[]string `hcl:"exclude"`
 	}
+	type providerTimeouts struct {
+		Read  *string `hcl:"read,attr"`
+		Plan  *string `hcl:"plan,attr"`
+		Apply *string `hcl:"apply,attr"`
+	}
 	type provider struct {
-		Name    string    `hcl:"name,label"`
-		Alias   *string   `hcl:"alias,attr"`
-		Version *string   `hcl:"version,attr"`
-		Config  hcl2.Body `hcl:",remain"`
+		Name        string            `hcl:"name,label"`
+		Alias       *string           `hcl:"alias,attr"`
+		Version     *string           `hcl:"version,attr"`
+		Parallelism *int              `hcl:"parallelism,attr"`
+		Timeouts    *providerTimeouts `hcl:"timeouts,block"`
+		Config      hcl2.Body         `hcl:",remain"`
 	}
 	type module struct {
 		Name      string             `hcl:"name,label"`
@@ -92,6 +100,7 @@ func (t *hcl2Configurable) Config() (*Config, error) {
 		CreateBeforeDestroy *bool     `hcl:"create_before_destroy,attr"`
 		PreventDestroy      *bool     `hcl:"prevent_destroy,attr"`
 		IgnoreChanges       *[]string `hcl:"ignore_changes,attr"`
+		Lock                *bool     `hcl:"lock,attr"`
 	}
 	type connection struct {
 		Config hcl2.Body `hcl:",remain"`
@@ -148,13 +157,26 @@ func (t *hcl2Configurable) Config() (*Config, error) {
 	type locals struct {
 		Definitions hcl2.Attributes `hcl:",remain"`
 	}
+	type moved struct {
+		From string `hcl:"from,attr"`
+		To   string `hcl:"to,attr"`
+	}
 	type backend struct {
 		Type   string    `hcl:"type,label"`
 		Config hcl2.Body `hcl:",remain"`
 	}
+	type providerMeta struct {
+		Name   string    `hcl:"name,label"`
+		Config hcl2.Body `hcl:",remain"`
+	}
+	type requiredProviders struct {
+		Config hcl2.Body `hcl:",remain"`
+	}
 	type terraform struct {
-		RequiredVersion *string  `hcl:"required_version,attr"`
-		Backend         *backend `hcl:"backend,block"`
+		RequiredVersion   *string             `hcl:"required_version,attr"`
+		Backend           *backend            `hcl:"backend,block"`
+		ProviderMetas     []providerMeta      `hcl:"provider_meta,block"`
+		RequiredProviders []requiredProviders `hcl:"required_providers,block"`
 	}
 	type topLevel struct {
 		Atlas     *atlas            `hcl:"atlas,block"`
@@ -166,6 +188,7 @@ func (t *hcl2Configurable) Config() (*Config, error) {
 		Terraform *terraform        `hcl:"terraform,block"`
 		Variables []variable        `hcl:"variable,block"`
 		Locals    []*locals         `hcl:"locals,block"`
+		Moved     []moved           `hcl:"moved,block"`
 	}
 
 	var raw topLevel
@@ -187,6 +210,7 @@ func (t *hcl2Configurable) Config() (*Config, error) {
 		// absense of any errors.
 		return config, diags
 	}
+	declRanges := hcl2BlockRanges(t.Body)
 
 	if raw.Terraform != nil {
 		var reqdVersion string
@@ -223,9 +247,46 @@ func (t *hcl2Configurable) Config() (*Config, error) {
 			}
 		}
 
+		var metas []*ProviderMeta
+		for _, rawMeta := range raw.Terraform.ProviderMetas {
+			// Like the backend config, provider metadata can't contain
+			// interpolations or nested blocks.
+			var config map[string]string
+			configDiags := gohcl2.DecodeBody(rawMeta.Config, nil, &config)
+			diags = append(diags, configDiags...)
+
+			raw := make(map[string]interface{}, len(config))
+			for k, v := range config {
+				raw[k] = v
+			}
+
+			rawConfig, err := NewRawConfig(raw)
+			if err != nil {
+				diags = append(diags, &hcl2.Diagnostic{
+					Severity: hcl2.DiagError,
+					Summary:  "Invalid provider_meta configuration",
+					Detail:   fmt.Sprintf("Error in provider_meta configuration for %s: %s", rawMeta.Name, err),
+				})
+				continue
+			}
+			metas = append(metas, &ProviderMeta{
+				Name:      rawMeta.Name,
+				RawConfig: rawConfig,
+			})
+		}
+
+		var required []*RequiredProvider
+		for _, rawRequired := range raw.Terraform.RequiredProviders {
+			reqs, reqDiags := decodeRequiredProvidersHCL2(rawRequired.Config)
+			diags = append(diags, reqDiags...)
+			required = append(required, reqs...)
+		}
+
 		config.Terraform = &Terraform{
-			RequiredVersion: reqdVersion,
-			Backend:         backend,
+			RequiredVersion:   reqdVersion,
+			Backend:           backend,
+			ProviderMetas:     metas,
+			RequiredProviders: required,
 		}
 	}
 
@@ -244,11 +305,12 @@ func (t *hcl2Configurable) Config() (*Config, error) {
 		}
 	}
 
-	for _, rawM := range raw.Modules {
+	for i, rawM := range raw.Modules {
 		m := &Module{
 			Name:      rawM.Name,
 			Source:    rawM.Source,
 			RawConfig: NewRawConfigHCL2(rawM.Config),
+			DeclRange: declRanges.get("module", i),
 		}
 
 		if rawM.Version != nil {
@@ -262,9 +324,10 @@ func (t *hcl2Configurable) Config() (*Config, error) {
 		config.Modules = append(config.Modules, m)
 	}
 
-	for _, rawV := range raw.Variables {
+	for i, rawV := range raw.Variables {
 		v := &Variable{
-			Name: rawV.Name,
+			Name:      rawV.Name,
+			DeclRange: declRanges.get("variable", i),
 		}
 		if rawV.DeclaredType != nil {
 			v.DeclaredType = *rawV.DeclaredType
@@ -275,13 +338,17 @@ func (t *hcl2Configurable) Config() (*Config, error) {
 		if rawV.Description != nil {
 			v.Description = *rawV.Description
 		}
+		if rawV.Sensitive != nil {
+			v.Sensitive = *rawV.Sensitive
+		}
 
 		config.Variables = append(config.Variables, v)
 	}
 
-	for _, rawO := range raw.Outputs {
+	for i, rawO := range raw.Outputs {
 		o := &Output{
-			Name: rawO.Name,
+			Name:      rawO.Name,
+			DeclRange: declRanges.get("output", i),
 		}
 
 		if rawO.Description != nil {
@@ -292,6 +359,7 @@ func (t *hcl2Configurable) Config() (*Config, error) {
 		}
 		if rawO.Sensitive != nil {
 			o.Sensitive = *rawO.Sensitive
+			o.setKeys = append(o.setKeys, "sensitive")
 		}
 
 		// The result is expected to be a map like map[string]interface{}{"value": something},
@@ -304,11 +372,12 @@ func (t *hcl2Configurable) Config() (*Config, error) {
 		config.Outputs = append(config.Outputs, o)
 	}
 
-	for _, rawR := range raw.Resources {
+	for i, rawR := range raw.Resources {
 		r := &Resource{
-			Mode: ManagedResourceMode,
-			Type: rawR.Type,
-			Name: rawR.Name,
+			Mode:      ManagedResourceMode,
+			Type:      rawR.Type,
+			Name:      rawR.Name,
+			DeclRange: declRanges.get("resource", i),
 		}
 		if rawR.Lifecycle != nil {
 			var l ResourceLifecycle
@@ -321,7 +390,11 @@ func (t *hcl2Configurable) Config() (*Config, error) {
 			if rawR.Lifecycle.IgnoreChanges != nil {
 				l.IgnoreChanges = *rawR.Lifecycle.IgnoreChanges
 			}
+			if rawR.Lifecycle.Lock != nil {
+				l.Lock = *rawR.Lifecycle.Lock
+			}
 			r.Lifecycle = l
+			r.setKeys = append(r.setKeys, "lifecycle")
 		}
 		if rawR.Provider != nil {
 			r.Provider = *rawR.Provider
@@ -384,6 +457,9 @@ func (t *hcl2Configurable) Config() (*Config, error) {
 
 			r.RawCount = NewRawConfigHCL2(countBody)
 			r.RawCount.Key = "count"
+			if hcl2ExprSet(rawR.CountExpr) {
+				r.setKeys = append(r.setKeys, "count")
+			}
 		}
 
 		r.RawConfig = NewRawConfigHCL2(rawR.Config)
@@ -392,11 +468,12 @@ func (t *hcl2Configurable) Config() (*Config, error) {
 
 	}
 
-	for _, rawR := range raw.Datas {
+	for i, rawR := range raw.Datas {
 		r := &Resource{
-			Mode: DataResourceMode,
-			Type: rawR.Type,
-			Name: rawR.Name,
+			Mode:      DataResourceMode,
+			Type:      rawR.Type,
+			Name:      rawR.Name,
+			DeclRange: declRanges.get("data", i),
 		}
 
 		if rawR.Provider != nil {
@@ -417,6 +494,9 @@ func (t *hcl2Configurable) Config() (*Config, error) {
 
 			r.RawCount = NewRawConfigHCL2(countBody)
 			r.RawCount.Key = "count"
+			if hcl2ExprSet(rawR.CountExpr) {
+				r.setKeys = append(r.setKeys, "count")
+			}
 		}
 
 		r.RawConfig = NewRawConfigHCL2(rawR.Config)
@@ -424,9 +504,10 @@ func (t *hcl2Configurable) Config() (*Config, error) {
 		config.Resources = append(config.Resources, r)
 	}
 
-	for _, rawP := range raw.Providers {
+	for i, rawP := range raw.Providers {
 		p := &ProviderConfig{
-			Name: rawP.Name,
+			Name:      rawP.Name,
+			DeclRange: declRanges.get("provider", i),
 		}
 
 		if rawP.Alias != nil {
@@ -435,6 +516,31 @@ func (t *hcl2Configurable) Config() (*Config, error) {
 		if rawP.Version != nil {
 			p.Version = *rawP.Version
 		}
+		if rawP.Parallelism != nil {
+			p.Parallelism = *rawP.Parallelism
+		}
+		if rawP.Timeouts != nil {
+			raw := make(map[string]string)
+			if rawP.Timeouts.Read != nil {
+				raw["read"] = *rawP.Timeouts.Read
+			}
+			if rawP.Timeouts.Plan != nil {
+				raw["plan"] = *rawP.Timeouts.Plan
+			}
+			if rawP.Timeouts.Apply != nil {
+				raw["apply"] = *rawP.Timeouts.Apply
+			}
+
+			var err error
+			p.Timeouts, err = NewProviderTimeouts(raw)
+			if err != nil {
+				diags = append(diags, &hcl2.Diagnostic{
+					Severity: hcl2.DiagError,
+					Summary:  "Invalid provider timeouts",
+					Detail:   fmt.Sprintf("Error in timeouts for provider %q: %s", rawP.Name, err),
+				})
+			}
+		}
 
 		// The result is expected to be a map like map[string]interface{}{"value": something},
 		// so we'll fake that with our hcl2shim.SingleAttrBody shim.
@@ -457,11 +563,20 @@ func (t *hcl2Configurable) Config() (*Config, error) {
 					Name: "value",
 					Expr: attr.Expr,
 				}),
+				DeclRange: tfdiags.SourceRangeFromHCL(attr.Range),
 			}
 			config.Locals = append(config.Locals, l)
 		}
 	}
 
+	for i, rawM := range raw.Moved {
+		config.Moved = append(config.Moved, &Moved{
+			From:      rawM.From,
+			To:        rawM.To,
+			DeclRange: declRanges.get("moved", i),
+		})
+	}
+
 	// FIXME: The current API gives us no way to return warnings in the
 	// absense of any errors.
 	var err error
@@ -471,3 +586,149 @@ func (t *hcl2Configurable) Config() (*Config, error) {
 
 	return config, err
 }
+
+// decodeRequiredProvidersHCL2 decodes the entries of a required_providers
+// block, each of which is either a version constraint string or an object
+// with "source" and "version" attributes. Like the backend config, the
+// entries can't refer to anything.
+func decodeRequiredProvidersHCL2(body hcl2.Body) ([]*RequiredProvider, hcl2.Diagnostics) {
+	attrs, diags := body.JustAttributes()
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	names := make([]string, 0, len(attrs))
+	for name := range attrs {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return attrs[names[i]].Range.Start.Byte < attrs[names[j]].Range.Start.Byte
+	})
+
+	var result []*RequiredProvider
+	for _, name := range names {
+		attr := attrs[name]
+		val, valDiags := attr.Expr.Value(nil)
+		diags = append(diags, valDiags...)
+		if valDiags.HasErrors() {
+			continue
+		}
+
+		p := &RequiredProvider{
+			Name:      name,
+			DeclRange: tfdiags.SourceRangeFromHCL(attr.Range),
+		}
+		ty := val.Type()
+		switch {
+		case ty == cty.String && val.IsKnown() && !val.IsNull():
+			p.Version = val.AsString()
+		case ty.IsObjectType() && val.IsKnown() && !val.IsNull():
+			invalid := false
+			for k := range ty.AttributeTypes() {
+				v := val.GetAttr(k)
+				if k == "configuration_aliases" {
+					aliases, ok := decodeStringListHCL2(v)
+					if !ok {
+						invalid = true
+					}
+					p.ConfigurationAliases = aliases
+					continue
+				}
+				if (k != "source" && k != "version") || v.Type() != cty.String || v.IsNull() {
+					invalid = true
+					continue
+				}
+				if k == "source" {
+					p.Source = v.AsString()
+				} else {
+					p.Version = v.AsString()
+				}
+			}
+			if invalid {
+				diags = append(diags, &hcl2.Diagnostic{
+					Severity: hcl2.DiagError,
+					Summary:  "Invalid required provider",
+					Detail:   fmt.Sprintf("The requirement for %s can only set source and version, which must be strings, and configuration_aliases, which must be a list of strings.", name),
+					Subject:  attr.Expr.Range().Ptr(),
+				})
+				continue
+			}
+		default:
+			diags = append(diags, &hcl2.Diagnostic{
+				Severity: hcl2.DiagError,
+				Summary:  "Invalid required provider",
+				Detail:   fmt.Sprintf("The requirement for %s must be a version constraint string or an object with source and version.", name),
+				Subject:  attr.Expr.Range().Ptr(),
+			})
+			continue
+		}
+
+		result = append(result, p)
+	}
+
+	return result, diags
+}
+
+// decodeStringListHCL2 returns the elements of a list or tuple of strings,
+// or false if v is anything else.
+func decodeStringListHCL2(v cty.Value) ([]string, bool) {
+	ty := v.Type()
+	if v.IsNull() || !v.IsKnown() || !(ty.IsListType() || ty.IsTupleType()) {
+		return nil, false
+	}
+
+	var result []string
+	for it := v.ElementIterator(); it.Next(); {
+		_, elem := it.Element()
+		if elem.Type() != cty.String || elem.IsNull() || !elem.IsKnown() {
+			return nil, false
+		}
+		result = append(result, elem.AsString())
+	}
+	return result, true
+}
+
+// hcl2ExprSet returns true if expr is an argument set in the config, rather
+// than the null expression gohcl stands in for an argument that's missing.
+func hcl2ExprSet(expr hcl2.Expression) bool {
+	if len(expr.Variables()) > 0 {
+		return true
+	}
+	v, diags := expr.Value(nil)
+	return diags.HasErrors() || !v.IsNull()
+}
+
+// hcl2BlockRanges finds the ranges of the headers of the top-level blocks in
+// body, which gohcl can't decode along with them. They're in the order the
+// blocks appear in, as are the blocks gohcl decodes.
+func hcl2BlockRanges(body hcl2.Body) blockRanges {
+	content, _, _ := body.PartialContent(&hcl2.BodySchema{
+		Blocks: []hcl2.BlockHeaderSchema{
+			{Type: "data", LabelNames: []string{"type", "name"}},
+			{Type: "module", LabelNames: []string{"name"}},
+			{Type: "moved"},
+			{Type: "output", LabelNames: []string{"name"}},
+			{Type: "provider", LabelNames: []string{"name"}},
+			{Type: "resource", LabelNames: []string{"type", "name"}},
+			{Type: "variable", LabelNames: []string{"name"}},
+		},
+	})
+
+	ranges := make(blockRanges)
+	for _, block := range content.Blocks {
+		ranges[block.Type] = append(ranges[block.Type], tfdiags.SourceRangeFromHCL(block.DefRange))
+	}
+	return ranges
+}
+
+// blockRanges are the ranges of blocks, by block type.
+type blockRanges map[string][]tfdiags.SourceRange
+
+// get returns the range of the i'th block of the given type, or the zero
+// range if there's no such block.
+func (r blockRanges) get(typeName string, i int) tfdiags.SourceRange {
+	if i < len(r[typeName]) {
+		return r[typeName][i]
+	}
+	return tfdiags.SourceRange{}
+}
@@ -1,11 +1,14 @@
 package config
 
 import (
+	"fmt"
 	"io/ioutil"
 	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
+
+	"github.com/hashicorp/terraform/tfdiags"
 )
 
 func TestErrNoConfigsFound_impl(t *testing.T) {
@@ -67,6 +70,23 @@ func TestLoadFile_lifecycleKeyCheck(t *testing.T) {
 	t.Logf("err: %s", err)
 }
 
+func TestLoadFile_lifecycleLock(t *testing.T) {
+	c, err := LoadFile(filepath.Join(fixtureDir, "lifecycle-lock.tf"))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if len(c.Resources) != 2 {
+		t.Fatalf("bad: %#v", c.Resources)
+	}
+	for _, r := range c.Resources {
+		expected := r.Name == "locked"
+		if r.Lifecycle.Lock != expected {
+			t.Fatalf("%s: lock should be %t", r.Id(), expected)
+		}
+	}
+}
+
 func TestLoadFile_varInvalidKey(t *testing.T) {
 	_, err := LoadFile(filepath.Join(fixtureDir, "var-invalid-key.tf"))
 	if err == nil {
@@ -442,6 +462,84 @@ func TestLoadFile_terraformBackendMulti(t *testing.T) {
 	}
 }
 
+func TestLoadFile_terraformProviderMeta(t *testing.T) {
+	c, err := LoadFile(filepath.Join(fixtureDir, "terraform-provider-meta.tf"))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	metas := c.Terraform.ProviderMetas
+	if len(metas) != 2 {
+		t.Fatalf("bad: %#v", metas)
+	}
+	if metas[0].Name != "aws" || metas[1].Name != "google" {
+		t.Fatalf("bad: %s, %s", metas[0].Name, metas[1].Name)
+	}
+	if v := metas[0].RawConfig.Raw["module_name"]; v != "foo" {
+		t.Fatalf("bad: %#v", metas[0].RawConfig.Raw)
+	}
+}
+
+func TestLoadFile_terraformRequiredProviders(t *testing.T) {
+	loaders := map[string]func(*testing.T) *Config{
+		"HCL": func(t *testing.T) *Config {
+			return testConfig(t, "required-providers")
+		},
+		"HCL2": func(t *testing.T) *Config {
+			return testConfigHCL2(t, "required-providers")
+		},
+		"JSON": func(t *testing.T) *Config {
+			c, err := LoadFile(filepath.Join(fixtureDir, "required-providers", "main.tf.json"))
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			return c
+		},
+	}
+
+	for name, load := range loaders {
+		t.Run(name, func(t *testing.T) {
+			c := load(t)
+			if diags := c.Validate(); diags.HasErrors() {
+				t.Fatalf("err: %s", diags.Err())
+			}
+
+			reqs := c.RequiredProvidersByName()
+			if len(reqs) != 2 {
+				t.Fatalf("wrong required providers %#v", reqs)
+			}
+			if aws := reqs["aws"]; aws.Source != "" || aws.Version != "~> 1.0" {
+				t.Errorf("wrong aws requirement %#v", aws)
+			}
+			if w := reqs["widgets"]; w.Source != "example.com/acme/widgets" || w.Version != ">= 2.0" {
+				t.Errorf("wrong widgets requirement %#v", w)
+			}
+			if got, want := reqs["widgets"].ConfigurationAliases, []string{"widgets.east", "widgets.west"}; !reflect.DeepEqual(got, want) {
+				t.Errorf("wrong widgets configuration aliases %#v; want %#v", got, want)
+			}
+
+			addr, err := reqs["aws"].SourceAddr()
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			if got, want := addr.Normalized(), "hashicorp/aws"; got != want {
+				t.Errorf("wrong aws source %q; want %q", got, want)
+			}
+			addr, err = reqs["widgets"].SourceAddr()
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			if got, want := addr.Normalized(), "example.com/acme/widgets"; got != want {
+				t.Errorf("wrong widgets source %q; want %q", got, want)
+			}
+
+			if name != "JSON" && reqs["widgets"].DeclRange.Start.Line != 5 {
+				t.Errorf("wrong widgets range %#v", reqs["widgets"].DeclRange)
+			}
+		})
+	}
+}
+
 func TestLoadJSONBasic(t *testing.T) {
 	raw, err := ioutil.ReadFile(filepath.Join(fixtureDir, "basic.tf.json"))
 	if err != nil {
@@ -514,6 +612,9 @@ func TestLoadJSONAmbiguous(t *testing.T) {
 	if len(c.Variables) != 2 {
 		t.Fatal("config should have 2 variables, found", len(c.Variables))
 	}
+	for _, v := range c.Variables {
+		v.DeclRange = tfdiags.SourceRange{}
+	}
 
 	first := &Variable{
 		Name:    "first",
@@ -709,6 +810,59 @@ func TestLoadFile_badVariableTypes(t *testing.T) {
 	}
 }
 
+func TestLoadFile_variableObjectType(t *testing.T) {
+	c, err := LoadFile(filepath.Join(fixtureDir, "variable-object-type.tf"))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	want := []interface{}{
+		map[string]interface{}{"name": "web", "port": "80"},
+		[]interface{}{
+			map[string]interface{}{"name": "a", "primary": "true"},
+			map[string]interface{}{"name": "b", "primary": "false"},
+		},
+	}
+	wantTypes := []VariableType{VariableTypeMap, VariableTypeList}
+	for i, v := range c.Variables {
+		if !reflect.DeepEqual(v.Default, want[i]) {
+			t.Errorf("wrong default for %s\ngot:  %#v\nwant: %#v", v.Name, v.Default, want[i])
+		}
+		if v.Type() != wantTypes[i] {
+			t.Errorf("wrong type for %s: %s", v.Name, v.Type().Printable())
+		}
+	}
+}
+
+func TestLoadFile_variableObjectTypeBadDefault(t *testing.T) {
+	_, err := LoadFile(filepath.Join(fixtureDir, "variable-object-type-bad-default.tf"))
+	if err == nil {
+		t.Fatalf("bad: expected error")
+	}
+
+	errorStr := err.Error()
+	if !strings.Contains(errorStr, `'service' has a default value which is not of type`) ||
+		!strings.Contains(errorStr, `attribute "name" is required`) {
+		t.Fatalf("bad: expected error has wrong text: %s", errorStr)
+	}
+}
+
+func TestLoadFile_variableSensitive(t *testing.T) {
+	c, err := LoadFile(filepath.Join(fixtureDir, "variable-sensitive.tf"))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	got := map[string]bool{}
+	for _, v := range c.Variables {
+		got[v.Name] = v.Sensitive
+	}
+	want := map[string]bool{"password": true, "name": false}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong sensitive variables %#v; want %#v", got, want)
+	}
+}
+
 func TestLoadFile_variableNoName(t *testing.T) {
 	_, err := LoadFile(filepath.Join(fixtureDir, "variable-no-name.tf"))
 	if err == nil {
@@ -887,6 +1041,79 @@ func TestLoadFile_ignoreChanges(t *testing.T) {
 	}
 }
 
+func TestLoadFile_variableValidation(t *testing.T) {
+	c, err := LoadFile(filepath.Join(fixtureDir, "variable-validation.tf"))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if len(c.Variables) != 1 {
+		t.Fatalf("wrong number of variables %d; want 1", len(c.Variables))
+	}
+	validations := c.Variables[0].Validations
+	if len(validations) != 2 {
+		t.Fatalf("wrong number of validations %d; want 2", len(validations))
+	}
+
+	v := validations[0]
+	if got, want := v.ErrorMessage, `The image_id value must be a valid AMI id, starting with "ami-".`; got != want {
+		t.Fatalf("wrong error message %q; want %q", got, want)
+	}
+	if got := v.Condition.Variables; len(got) != 1 || got["var.image_id"] == nil {
+		t.Fatalf("wrong condition variables %#v", got)
+	}
+
+	wantRange := tfdiags.SourceRange{
+		Filename: filepath.Join(fixtureDir, "variable-validation.tf"),
+		Start:    tfdiags.SourcePos{Line: 5, Column: 5, Byte: 60},
+		End:      tfdiags.SourcePos{Line: 5, Column: 90, Byte: 145},
+	}
+	if !reflect.DeepEqual(v.DeclRange, wantRange) {
+		t.Fatalf("wrong range\ngot:  %#v\nwant: %#v", v.DeclRange, wantRange)
+	}
+
+	if got, want := validations[1].ErrorMessage, "The image ami-bad is deprecated."; got != want {
+		t.Fatalf("wrong error message %q; want %q", got, want)
+	}
+}
+
+func TestLoadFile_checks(t *testing.T) {
+	c, err := LoadFile(filepath.Join(fixtureDir, "checks.tf"))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if len(c.Checks) != 1 {
+		t.Fatalf("wrong number of checks %d; want 1", len(c.Checks))
+	}
+	ch := c.Checks[0]
+	if ch.Name != "health" {
+		t.Fatalf("wrong name %q", ch.Name)
+	}
+	if len(ch.Asserts) != 2 {
+		t.Fatalf("wrong number of assertions %d; want 2", len(ch.Asserts))
+	}
+	if got, want := ch.Asserts[0].ErrorMessage, "The web server is not healthy."; got != want {
+		t.Fatalf("wrong error message %q; want %q", got, want)
+	}
+	if got := ch.Asserts[0].Condition.Variables; len(got) != 1 || got["data.http.web.status_code"] == nil {
+		t.Fatalf("wrong condition variables %#v", got)
+	}
+	if got, want := ch.Asserts[0].DeclRange.Start.Line, 11; got != want {
+		t.Fatalf("wrong line %d; want %d", got, want)
+	}
+
+	var scoped []string
+	for _, r := range c.Resources {
+		if r.Check != "" {
+			scoped = append(scoped, r.Id()+" in "+r.Check)
+		}
+	}
+	if want := []string{"data.http.web in health"}; !reflect.DeepEqual(scoped, want) {
+		t.Fatalf("wrong scoped data sources %#v; want %#v", scoped, want)
+	}
+}
+
 func TestLoad_preventDestroyString(t *testing.T) {
 	c, err := LoadFile(filepath.Join(fixtureDir, "prevent-destroy-string.tf"))
 	if err != nil {
@@ -1019,19 +1246,84 @@ func TestLoad_jsonAttributes(t *testing.T) {
 	}
 }
 
-func TestLoad_onlyOverride(t *testing.T) {
-	c, err := LoadDir(filepath.Join(fixtureDir, "dir-only-override"))
-	if err != nil {
-		t.Fatalf("err: %s", err)
+// TestLoad_jsonParity checks that each configuration in json-parity loads
+// the same from native syntax as from JSON.
+func TestLoad_jsonParity(t *testing.T) {
+	for _, name := range []string{"full", "arrays", "nested"} {
+		t.Run(name, func(t *testing.T) {
+			native, err := LoadFile(filepath.Join(fixtureDir, "json-parity", name+".tf"))
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			json, err := LoadFile(filepath.Join(fixtureDir, "json-parity", name+".tf.json"))
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+
+			if got, want := jsonParityStr(json), jsonParityStr(native); got != want {
+				t.Fatalf("JSON differs from native syntax\ngot:\n%s\n\nwant:\n%s", got, want)
+			}
+			if got, want := json.Validate().Err(), native.Validate().Err(); !reflect.DeepEqual(got, want) {
+				t.Fatalf("wrong validation errors\ngot:  %s\nwant: %s", got, want)
+			}
+		})
 	}
+}
 
-	if c == nil {
-		t.Fatal("config should not be nil")
+func TestLoad_jsonParityInvalid(t *testing.T) {
+	_, nativeErr := LoadFile(filepath.Join(fixtureDir, "json-parity", "invalid.tf"))
+	_, jsonErr := LoadFile(filepath.Join(fixtureDir, "json-parity", "invalid.tf.json"))
+	if nativeErr == nil || jsonErr == nil {
+		t.Fatalf("both should be invalid\nnative: %v\nJSON:   %v", nativeErr, jsonErr)
 	}
 
-	actual := variablesStr(c.Variables)
-	if actual != strings.TrimSpace(dirOnlyOverrideVariablesStr) {
-		t.Fatalf("bad:\n%s", actual)
+	// The errors start with the name of the file.
+	want := strings.SplitN(nativeErr.Error(), ": ", 2)[1]
+	if got := strings.SplitN(jsonErr.Error(), ": ", 2)[1]; got != want {
+		t.Fatalf("wrong error\ngot:  %s\nwant: %s", got, want)
+	}
+}
+
+// jsonParityStr describes what's loaded from a configuration in more detail
+// than TestString, but without where it was loaded from.
+func jsonParityStr(c *Config) string {
+	var buf strings.Builder
+	buf.WriteString(c.TestString())
+	for _, v := range c.Variables {
+		fmt.Fprintf(&buf, "\nvariable %s: %q %#v %q", v.Name, v.DeclaredType, v.Default, v.Description)
+	}
+	for _, p := range c.ProviderConfigs {
+		fmt.Fprintf(&buf, "\nprovider %s: %#v", p.FullName(), p.RawConfig.Raw)
+	}
+	for _, m := range c.Modules {
+		fmt.Fprintf(&buf, "\nmodule %s: %q %#v", m.Name, m.Source, m.RawConfig.Raw)
+	}
+	for _, l := range c.Locals {
+		fmt.Fprintf(&buf, "\nlocal %s: %#v", l.Name, l.RawConfig.Raw)
+	}
+	for _, r := range c.Resources {
+		fmt.Fprintf(&buf, "\nresource %s: %#v %#v", r.Id(), r.RawConfig.Raw, r.Lifecycle)
+		for _, p := range r.Provisioners {
+			fmt.Fprintf(&buf, "\n  provisioner %s: %#v %#v %s %s", p.Type, p.RawConfig.Raw, p.ConnInfo.Raw, p.When, p.OnFailure)
+		}
+	}
+	for _, o := range c.Outputs {
+		fmt.Fprintf(&buf, "\noutput %s: %#v %t %q", o.Name, o.RawConfig.Raw, o.Sensitive, o.Description)
+	}
+	for _, m := range c.Moved {
+		fmt.Fprintf(&buf, "\nmoved %s to %s", m.From, m.To)
+	}
+	return buf.String()
+}
+
+func TestLoad_onlyOverride(t *testing.T) {
+	// There's nothing for the override to change.
+	_, err := LoadDir(filepath.Join(fixtureDir, "dir-only-override"))
+	if err == nil {
+		t.Fatal("should error")
+	}
+	if got, want := err.Error(), "Missing base variable to override"; !strings.Contains(got, want) {
+		t.Fatalf("wrong error %q; want it to contain %q", got, want)
 	}
 }
 
@@ -1242,12 +1534,6 @@ foo
   bar
 `
 
-const dirOnlyOverrideVariablesStr = `
-foo
-  bar
-  bar
-`
-
 const importProvidersStr = `
 aws
   bar
@@ -148,6 +148,21 @@ func Merge(c1, c2 *Config) (*Config, error) {
 		c.Locals = append(c.Locals, c2.Locals...)
 	}
 
+	// Moved blocks are also flat, and have no names to merge by.
+	if len(c1.Moved)+len(c2.Moved) != 0 {
+		c.Moved = make([]*Moved, 0, len(c1.Moved)+len(c2.Moved))
+		c.Moved = append(c.Moved, c1.Moved...)
+		c.Moved = append(c.Moved, c2.Moved...)
+	}
+
+	// Checks are merged by appending, and duplicate names are caught by
+	// validation.
+	if len(c1.Checks)+len(c2.Checks) != 0 {
+		c.Checks = make([]*Check, 0, len(c1.Checks)+len(c2.Checks))
+		c.Checks = append(c.Checks, c1.Checks...)
+		c.Checks = append(c.Checks, c2.Checks...)
+	}
+
 	return c, nil
 }
 
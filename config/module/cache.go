@@ -0,0 +1,138 @@
+package module
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/terraform/registry/regsrc"
+)
+
+// moduleCacheKey returns the key of a module in the module cache, or an
+// empty string if the module can't be cached because its source doesn't
+// identify a fixed version of it.
+//
+// Registry modules are keyed by their address and version, rather than by
+// the location the registry returned, which may be a signed URL that
+// changes with each request. Other modules are cacheable when their source
+// pins a ref, as with git and Mercurial, or names an OCI artifact.
+func moduleCacheKey(rec moduleRecord, source string) string {
+	if rec.registry {
+		mod, err := regsrc.ParseModuleSource(rec.Source)
+		if err != nil || rec.Version == "" {
+			return ""
+		}
+		// Submodules are subdirectories of the same package, so the key is
+		// of the package.
+		return strings.ToLower(mod.Host().Normalized()+"/"+mod.Module()) + "@" + rec.Version
+	}
+
+	getterName, rest := splitForcedGetter(source)
+	u, err := url.Parse(rest)
+	if err != nil {
+		return ""
+	}
+	if getterName == "" {
+		getterName = u.Scheme
+	}
+	switch getterName {
+	case "git":
+		if u.Query().Get("ref") == "" {
+			return ""
+		}
+	case "hg":
+		if u.Query().Get("rev") == "" {
+			return ""
+		}
+	case "oci":
+	default:
+		return ""
+	}
+	return source
+}
+
+// fetchCached gets the module at src into dir through the module cache in
+// s.CacheDir, downloading it into the cache only if it's not there yet, or
+// update is true. dir is then a symlink to the cached module, or a copy of
+// it where symlinks can't be made.
+func (s Storage) fetchCached(dir, src, cacheKey string, update bool) error {
+	cacheDir, err := filepath.Abs(s.CacheDir)
+	if err != nil {
+		return fmt.Errorf("Error reading module cache directory: %s", err)
+	}
+	sum := sha256.Sum256([]byte(cacheKey))
+	cached := filepath.Join(cacheDir, hex.EncodeToString(sum[:]))
+
+	_, err = os.Stat(cached)
+	switch {
+	case err == nil && !update:
+		log.Printf("[DEBUG] found %q in the module cache at %s", cacheKey, cached)
+	case err == nil || os.IsNotExist(err):
+		log.Printf("[DEBUG] downloading %q into the module cache at %s", cacheKey, cached)
+		if err := cacheModule(cached, src); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("Error reading module cache directory: %s", err)
+	}
+
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return err
+	}
+	if err := os.Symlink(cached, dir); err == nil {
+		return nil
+	} else {
+		log.Printf("[DEBUG] can't link %s to the module cache, so copying it: %s", dir, err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	return copyDir(dir, cached)
+}
+
+// cacheModule downloads the module at src into the cache directory cached,
+// replacing what's there. The module is downloaded alongside it first, so
+// that other Terraform processes sharing the cache never see a partial
+// download.
+func cacheModule(cached, src string) error {
+	if err := os.MkdirAll(filepath.Dir(cached), 0755); err != nil {
+		return fmt.Errorf("Error creating module cache directory: %s", err)
+	}
+	tmpDir, err := ioutil.TempDir(filepath.Dir(cached), ".download-")
+	if err != nil {
+		return fmt.Errorf("Error creating module cache directory: %s", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// The getters update a directory that exists, rather than downloading
+	// into it, so the module goes in a new directory within tmpDir.
+	tmp := filepath.Join(tmpDir, "module")
+	if err := getModule(tmp, src); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp, cached); err == nil {
+		return nil
+	}
+	// Either the module is being updated, or another process cached it
+	// first. Both downloads are of the same version, so either will do.
+	old := filepath.Join(tmpDir, "old")
+	if err := os.Rename(cached, old); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("Error replacing cached module: %s", err)
+	}
+	if err := os.Rename(tmp, cached); err != nil {
+		if _, statErr := os.Stat(cached); statErr != nil {
+			return fmt.Errorf("Error replacing cached module: %s", err)
+		}
+	}
+	return nil
+}
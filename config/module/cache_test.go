@@ -0,0 +1,151 @@
+package module
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestModuleCacheKey(t *testing.T) {
+	tests := map[string]struct {
+		Rec    moduleRecord
+		Source string
+		Want   string
+	}{
+		"registry": {
+			moduleRecord{Source: "hashicorp/consul/aws//modules/consul-cluster", Version: "0.1.0", registry: true},
+			"https://example.com/consul.tar.gz?signature=abc",
+			"registry.terraform.io/hashicorp/consul/aws@0.1.0",
+		},
+		"registry without version": {
+			moduleRecord{Source: "hashicorp/consul/aws", registry: true},
+			"https://example.com/consul.tar.gz",
+			"",
+		},
+		"git ref": {
+			moduleRecord{},
+			"git::https://example.com/vpc.git?ref=v1.2.0",
+			"git::https://example.com/vpc.git?ref=v1.2.0",
+		},
+		"git without ref": {
+			moduleRecord{},
+			"git::https://example.com/vpc.git",
+			"",
+		},
+		"hg rev": {
+			moduleRecord{},
+			"hg::https://example.com/vpc?rev=default",
+			"hg::https://example.com/vpc?rev=default",
+		},
+		"oci": {
+			moduleRecord{},
+			"oci://registry.example.com/vpc:1.0.0",
+			"oci://registry.example.com/vpc:1.0.0",
+		},
+		"local": {
+			moduleRecord{},
+			"file:///home/user/modules/vpc",
+			"",
+		},
+		"http": {
+			moduleRecord{},
+			"https://example.com/vpc.zip",
+			"",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := moduleCacheKey(tc.Rec, tc.Source); got != tc.Want {
+				t.Fatalf("wrong key %q; want %q", got, tc.Want)
+			}
+		})
+	}
+}
+
+func TestStorage_cacheDir(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+
+	td, err := ioutil.TempDir("", "tf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(td)
+
+	repo := filepath.Join(td, "repo")
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repo
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+		)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %s\n%s", args[0], err, out)
+		}
+	}
+	if err := os.MkdirAll(repo, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(repo, "main.tf"), []byte("# v1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	git("init", "-q")
+	git("add", ".")
+	git("commit", "-q", "-m", "v1")
+	git("tag", "v1")
+
+	source := fmt.Sprintf("git::file://%s?ref=v1", filepath.ToSlash(repo))
+	cacheKey := moduleCacheKey(moduleRecord{}, source)
+	cacheDir := filepath.Join(td, "cache")
+
+	// Each configuration has its own storage, sharing the cache.
+	get := func(storageDir string) string {
+		t.Helper()
+		s := &Storage{StorageDir: storageDir, CacheDir: cacheDir, Mode: GetModeGet}
+		dir, ok, err := s.getStorage("root.vpc", source, cacheKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok {
+			t.Fatal("module not found")
+		}
+		return dir
+	}
+
+	dir := get(filepath.Join(td, "a"))
+	got, err := ioutil.ReadFile(filepath.Join(dir, "main.tf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "# v1\n" {
+		t.Fatalf("wrong content %q", got)
+	}
+
+	// Without the repository, the module can only come from the cache.
+	if err := os.RemoveAll(repo); err != nil {
+		t.Fatal(err)
+	}
+	dir = get(filepath.Join(td, "b"))
+	got, err = ioutil.ReadFile(filepath.Join(dir, "main.tf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "# v1\n" {
+		t.Fatalf("wrong content %q", got)
+	}
+
+	entries, err := ioutil.ReadDir(cacheDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("the cache should have 1 module, has %d", len(entries))
+	}
+}
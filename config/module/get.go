@@ -1,8 +1,11 @@
 package module
 
 import (
+	"fmt"
 	"io/ioutil"
+	"net/url"
 	"os"
+	"regexp"
 
 	"github.com/hashicorp/go-getter"
 )
@@ -45,7 +48,7 @@ func GetCopy(dst, src string) error {
 	defer os.RemoveAll(tmpDir)
 
 	// Get to that temporary dir
-	if err := getter.Get(tmpDir, src); err != nil {
+	if err := getModule(tmpDir, src); err != nil {
 		return err
 	}
 
@@ -57,3 +60,72 @@ func GetCopy(dst, src string) error {
 	// Copy to the final location
 	return copyDir(dst, tmpDir)
 }
+
+// moduleGetters are the getters used to fetch modules: those of go-getter,
+// with git replaced by one that can also make shallow and sparse clones,
+// and oci for modules stored as artifacts in OCI registries.
+var moduleGetters map[string]getter.Getter
+
+func init() {
+	moduleGetters = make(map[string]getter.Getter, len(getter.Getters)+1)
+	for k, g := range getter.Getters {
+		moduleGetters[k] = g
+	}
+	moduleGetters["git"] = &gitGetter{fallback: getter.Getters["git"]}
+	moduleGetters["oci"] = new(ociGetter)
+}
+
+// getModule downloads the module directory at src into dst, updating dst
+// if it already exists.
+func getModule(dst, src string) error {
+	return (&getter.Client{
+		Src:     src,
+		Dst:     dst,
+		Mode:    getter.ClientModeDir,
+		Getters: moduleGetters,
+	}).Get()
+}
+
+// withSparsePath returns the source of a module with the sparse=true
+// argument of a git source replaced by the subdirectory to check out, which
+// the getter doesn't otherwise know about.
+func withSparsePath(source, subDir string) (string, error) {
+	forced, rawURL := splitForcedGetter(source)
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return source, nil
+	}
+	q := u.Query()
+	if q.Get("sparse") == "" {
+		return source, nil
+	}
+	if forced != "git" && u.Scheme != "git" {
+		return "", fmt.Errorf("the sparse argument is only valid for git sources")
+	}
+	if q.Get("sparse") != "true" {
+		return "", fmt.Errorf("the sparse argument must be true, got %q", q.Get("sparse"))
+	}
+	if subDir == "" {
+		return "", fmt.Errorf("a sparse checkout requires a subdirectory, as in \"git::https://example.com/repo.git//modules/vpc?sparse=true\"")
+	}
+
+	q.Set("sparse", subDir)
+	u.RawQuery = q.Encode()
+	if forced != "" {
+		return forced + "::" + u.String(), nil
+	}
+	return u.String(), nil
+}
+
+// forcedGetterRegexp matches the sources that force a getter, such as
+// "git::https://example.com/repo.git".
+var forcedGetterRegexp = regexp.MustCompile(`^([A-Za-z0-9]+)::(.+)$`)
+
+// splitForcedGetter splits the forced getter, such as "git" in
+// "git::https://example.com/repo.git", from a source.
+func splitForcedGetter(source string) (string, string) {
+	if m := forcedGetterRegexp.FindStringSubmatch(source); m != nil {
+		return m[1], m[2]
+	}
+	return "", source
+}
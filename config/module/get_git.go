@@ -0,0 +1,161 @@
+package module
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	getter "github.com/hashicorp/go-getter"
+)
+
+// gitGetter is the getter for git sources. Given a depth argument, it makes
+// a shallow clone with only that many commits of history, and given a
+// sparse argument, a sparse checkout of the subdirectory of the module
+// alone, which is much faster for large repositories holding many modules:
+//
+//	git::https://example.com/infra.git//modules/vpc?ref=v1.2.0&depth=1&sparse=true
+//
+// The sparse argument is replaced by the subdirectory by withSparsePath.
+// Other sources are fetched by the getter of go-getter.
+type gitGetter struct {
+	fallback getter.Getter
+}
+
+func (g *gitGetter) ClientMode(u *url.URL) (getter.ClientMode, error) {
+	return getter.ClientModeDir, nil
+}
+
+func (g *gitGetter) GetFile(dst string, u *url.URL) error {
+	return g.fallback.GetFile(dst, u)
+}
+
+func (g *gitGetter) Get(dst string, u *url.URL) error {
+	q := u.Query()
+	if q.Get("depth") == "" && q.Get("sparse") == "" {
+		return g.fallback.Get(dst, u)
+	}
+	if _, err := exec.LookPath("git"); err != nil {
+		return fmt.Errorf("git must be available and on the PATH")
+	}
+
+	ref := q.Get("ref")
+	sparse := q.Get("sparse")
+	sshKey := q.Get("sshkey")
+	depth := 0
+	if v := q.Get("depth"); v != "" {
+		var err error
+		depth, err = strconv.Atoi(v)
+		if err != nil || depth < 1 {
+			return fmt.Errorf("the depth argument must be a positive number, got %q", v)
+		}
+	}
+	for _, k := range []string{"ref", "sparse", "sshkey", "depth"} {
+		q.Del(k)
+	}
+	remote := *u
+	remote.RawQuery = q.Encode()
+
+	var sshKeyFile string
+	if sshKey != "" {
+		raw, err := base64.StdEncoding.DecodeString(sshKey)
+		if err != nil {
+			return err
+		}
+		f, err := ioutil.TempFile("", "terraform-git")
+		if err != nil {
+			return err
+		}
+		sshKeyFile = f.Name()
+		defer os.Remove(sshKeyFile)
+		if err := os.Chmod(sshKeyFile, 0600); err != nil {
+			f.Close()
+			return err
+		}
+		_, err = f.Write(raw)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	// A new repository and an existing one are updated the same way, by
+	// fetching just the ref and checking it out, since a shallow
+	// repository can't be pulled like a full one.
+	if _, err := os.Stat(filepath.Join(dst, ".git")); os.IsNotExist(err) {
+		if err := os.MkdirAll(dst, 0755); err != nil {
+			return err
+		}
+		if err := runGit(dst, sshKeyFile, "init", "-q"); err != nil {
+			return err
+		}
+		if err := runGit(dst, sshKeyFile, "remote", "add", "origin", remote.String()); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	} else if err := runGit(dst, sshKeyFile, "remote", "set-url", "origin", remote.String()); err != nil {
+		return err
+	}
+
+	fetch := []string{"fetch", "-q"}
+	if depth > 0 {
+		fetch = append(fetch, "--depth", strconv.Itoa(depth))
+	}
+	if sparse != "" {
+		if err := runGit(dst, sshKeyFile, "config", "core.sparseCheckout", "true"); err != nil {
+			return err
+		}
+		pattern := "/" + strings.Trim(filepath.ToSlash(sparse), "/") + "/\n"
+		if err := ioutil.WriteFile(filepath.Join(dst, ".git", "info", "sparse-checkout"), []byte(pattern), 0644); err != nil {
+			return err
+		}
+		// Servers that can't filter send the blobs anyway.
+		fetch = append(fetch, "--filter=blob:none")
+	}
+	if ref == "" {
+		ref = "HEAD"
+	}
+	fetch = append(fetch, "origin", ref)
+	if err := runGit(dst, sshKeyFile, fetch...); err != nil {
+		return err
+	}
+	if err := runGit(dst, sshKeyFile, "checkout", "-q", "--force", "FETCH_HEAD"); err != nil {
+		return err
+	}
+
+	submodules := []string{"submodule", "update", "--init", "--recursive"}
+	if depth > 0 {
+		submodules = append(submodules, "--depth", strconv.Itoa(depth))
+	}
+	return runGit(dst, sshKeyFile, submodules...)
+}
+
+// runGit runs git with the given arguments in dir, using the given SSH key
+// file if it isn't empty.
+func runGit(dir, sshKeyFile string, args ...string) error {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = os.Environ()
+	if sshKeyFile != "" {
+		sshCmd := os.Getenv("GIT_SSH_COMMAND")
+		if sshCmd == "" {
+			sshCmd = "ssh"
+		}
+		cmd.Env = append(cmd.Env, fmt.Sprintf("GIT_SSH_COMMAND=%s -i %s", sshCmd, sshKeyFile))
+	}
+
+	var buf bytes.Buffer
+	cmd.Stdout = &buf
+	cmd.Stderr = &buf
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("git %s failed: %s\n%s", args[0], err, buf.String())
+	}
+	return nil
+}
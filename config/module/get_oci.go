@@ -0,0 +1,302 @@
+package module
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+
+	cleanhttp "github.com/hashicorp/go-cleanhttp"
+	getter "github.com/hashicorp/go-getter"
+)
+
+// ociGetter is the getter for modules stored as artifacts in OCI
+// registries, such as container registries, with sources like:
+//
+//	oci://registry.example.com/org/modules/vpc:1.2.0
+//	oci://registry.example.com/org/modules/vpc@sha256:0123...
+//
+// The tag defaults to "latest". The artifact's manifest must have a single
+// layer, which is a tar.gz or zip archive of the module directory. Registries
+// are reached over HTTPS, unless the source has the argument insecure=true.
+//
+// Only registries that allow anonymous access, or hand out anonymous
+// tokens, are supported.
+type ociGetter struct{}
+
+// ociManifestMediaTypes are the media types of manifests that the getter
+// accepts, in order of preference.
+var ociManifestMediaTypes = []string{
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+// ociRepositoryRegexp matches the names of repositories.
+var ociRepositoryRegexp = regexp.MustCompile(`^[a-z0-9]+(?:[._-][a-z0-9]+)*(?:/[a-z0-9]+(?:[._-][a-z0-9]+)*)*$`)
+
+func (g *ociGetter) ClientMode(u *url.URL) (getter.ClientMode, error) {
+	return getter.ClientModeDir, nil
+}
+
+func (g *ociGetter) GetFile(dst string, u *url.URL) error {
+	return fmt.Errorf("oci sources must be module directories, not single files")
+}
+
+func (g *ociGetter) Get(dst string, u *url.URL) error {
+	ref, err := parseOCIReference(u)
+	if err != nil {
+		return err
+	}
+	c := &ociClient{http: cleanhttp.DefaultClient(), ref: ref}
+
+	var manifest struct {
+		Layers []struct {
+			MediaType string `json:"mediaType"`
+			Digest    string `json:"digest"`
+			Size      int64  `json:"size"`
+		} `json:"layers"`
+	}
+	if err := c.getJSON("manifests/"+ref.Reference, ociManifestMediaTypes, &manifest); err != nil {
+		return err
+	}
+	if len(manifest.Layers) != 1 {
+		return fmt.Errorf("%s: the artifact must have exactly one layer, with the module, but it has %d", ref, len(manifest.Layers))
+	}
+	layer := manifest.Layers[0]
+
+	var decompressor getter.Decompressor
+	switch {
+	case strings.HasSuffix(layer.MediaType, "tar+gzip"):
+		decompressor = getter.Decompressors["tar.gz"]
+	case strings.HasSuffix(layer.MediaType, "zip"):
+		decompressor = getter.Decompressors["zip"]
+	default:
+		return fmt.Errorf("%s: the layer has media type %q, but only tar.gz and zip archives are supported", ref, layer.MediaType)
+	}
+
+	f, err := ioutil.TempFile("", "terraform-oci")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	err = c.getBlob(layer.Digest, layer.Size, f)
+	f.Close()
+	if err != nil {
+		return err
+	}
+
+	// An artifact can't be updated in place, so the previous version is
+	// replaced.
+	if err := os.RemoveAll(dst); err != nil {
+		return err
+	}
+	return decompressor.Decompress(dst, f.Name(), true)
+}
+
+// ociReference is a reference to an artifact in an OCI registry.
+type ociReference struct {
+	// Scheme is "https", or "http" for insecure registries.
+	Scheme     string
+	Host       string
+	Repository string
+
+	// Reference is a tag, or a digest such as "sha256:0123...".
+	Reference string
+}
+
+func (r ociReference) String() string {
+	sep := ":"
+	if strings.Contains(r.Reference, ":") {
+		sep = "@"
+	}
+	return fmt.Sprintf("oci://%s/%s%s%s", r.Host, r.Repository, sep, r.Reference)
+}
+
+// parseOCIReference parses the URL of an oci source.
+func parseOCIReference(u *url.URL) (ociReference, error) {
+	ref := ociReference{Scheme: "https", Host: u.Host}
+	if u.Query().Get("insecure") == "true" {
+		ref.Scheme = "http"
+	}
+	if ref.Host == "" {
+		return ref, fmt.Errorf("invalid oci source %q: the registry host is missing", u)
+	}
+
+	path := strings.TrimPrefix(u.Path, "/")
+	switch {
+	case strings.Contains(path, "@"):
+		i := strings.Index(path, "@")
+		ref.Repository, ref.Reference = path[:i], path[i+1:]
+		if !strings.HasPrefix(ref.Reference, "sha256:") {
+			return ref, fmt.Errorf("invalid oci source %q: only sha256 digests are supported", u)
+		}
+	case strings.LastIndex(path, ":") > strings.LastIndex(path, "/"):
+		i := strings.LastIndex(path, ":")
+		ref.Repository, ref.Reference = path[:i], path[i+1:]
+	default:
+		ref.Repository, ref.Reference = path, "latest"
+	}
+	if !ociRepositoryRegexp.MatchString(ref.Repository) {
+		return ref, fmt.Errorf("invalid oci source %q: %q is not a valid repository name", u, ref.Repository)
+	}
+	if ref.Reference == "" {
+		return ref, fmt.Errorf("invalid oci source %q: the tag is empty", u)
+	}
+	return ref, nil
+}
+
+// ociClient makes requests to the registry API for a repository, getting
+// an anonymous token when the registry asks for one.
+type ociClient struct {
+	http  *http.Client
+	ref   ociReference
+	token string
+}
+
+func (c *ociClient) get(path string, accept []string) (*http.Response, error) {
+	u := fmt.Sprintf("%s://%s/v2/%s/%s", c.ref.Scheme, c.ref.Host, c.ref.Repository, path)
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequest("GET", u, nil)
+		if err != nil {
+			return nil, err
+		}
+		if len(accept) > 0 {
+			req.Header.Set("Accept", strings.Join(accept, ", "))
+		}
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+
+		resp, err := c.http.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", c.ref, err)
+		}
+		if resp.StatusCode == http.StatusUnauthorized && attempt == 0 {
+			challenge := resp.Header.Get("WWW-Authenticate")
+			resp.Body.Close()
+			if err := c.authenticate(challenge); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusNotFound {
+				return nil, fmt.Errorf("%s: not found", c.ref)
+			}
+			return nil, fmt.Errorf("%s: the registry responded with %s", c.ref, resp.Status)
+		}
+		return resp, nil
+	}
+}
+
+func (c *ociClient) getJSON(path string, accept []string, v interface{}) error {
+	resp, err := c.get(path, accept)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("%s: invalid response from the registry: %s", c.ref, err)
+	}
+	return nil
+}
+
+// getBlob writes the blob with the given digest to w, checking that it has
+// that digest and size.
+func (c *ociClient) getBlob(digest string, size int64, w io.Writer) error {
+	if !strings.HasPrefix(digest, "sha256:") {
+		return fmt.Errorf("%s: the layer has digest %q, but only sha256 digests are supported", c.ref, digest)
+	}
+	resp, err := c.get("blobs/"+digest, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(w, h), resp.Body)
+	if err != nil {
+		return fmt.Errorf("%s: %s", c.ref, err)
+	}
+	if size > 0 && n != size {
+		return fmt.Errorf("%s: the layer should have %d bytes, but has %d", c.ref, size, n)
+	}
+	if got := "sha256:" + hex.EncodeToString(h.Sum(nil)); got != digest {
+		return fmt.Errorf("%s: the layer should have digest %s, but has %s", c.ref, digest, got)
+	}
+	return nil
+}
+
+// authenticate gets an anonymous token from the server named by a Bearer
+// challenge of the registry.
+func (c *ociClient) authenticate(challenge string) error {
+	params := parseOCIChallenge(challenge)
+	realm := params["realm"]
+	if realm == "" {
+		return fmt.Errorf("%s: the registry requires authentication, which isn't supported", c.ref)
+	}
+
+	u, err := url.Parse(realm)
+	if err != nil {
+		return fmt.Errorf("%s: invalid authentication realm %q: %s", c.ref, realm, err)
+	}
+	q := u.Query()
+	if params["service"] != "" {
+		q.Set("service", params["service"])
+	}
+	scope := params["scope"]
+	if scope == "" {
+		scope = fmt.Sprintf("repository:%s:pull", c.ref.Repository)
+	}
+	q.Set("scope", scope)
+	u.RawQuery = q.Encode()
+
+	resp, err := c.http.Get(u.String())
+	if err != nil {
+		return fmt.Errorf("%s: %s", c.ref, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: the registry refused an anonymous token: %s", c.ref, resp.Status)
+	}
+
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return fmt.Errorf("%s: invalid token response: %s", c.ref, err)
+	}
+	c.token = token.Token
+	if c.token == "" {
+		c.token = token.AccessToken
+	}
+	if c.token == "" {
+		return fmt.Errorf("%s: the token response has no token", c.ref)
+	}
+	return nil
+}
+
+// ociChallengeRegexp matches the parameters of an authentication challenge.
+var ociChallengeRegexp = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// parseOCIChallenge returns the parameters of a Bearer challenge from a
+// WWW-Authenticate header, or nil if it's not one.
+func parseOCIChallenge(challenge string) map[string]string {
+	if !strings.HasPrefix(strings.ToLower(challenge), "bearer ") {
+		return nil
+	}
+	params := make(map[string]string)
+	for _, m := range ociChallengeRegexp.FindAllStringSubmatch(challenge, -1) {
+		params[m[1]] = m[2]
+	}
+	return params
+}
@@ -0,0 +1,296 @@
+package module
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWithSparsePath(t *testing.T) {
+	tests := map[string]struct {
+		Source string
+		SubDir string
+		Want   string
+		Err    string
+	}{
+		"not sparse": {
+			"git::https://example.com/repo.git?ref=v1",
+			"modules/vpc",
+			"git::https://example.com/repo.git?ref=v1",
+			"",
+		},
+		"sparse": {
+			"git::https://example.com/repo.git?ref=v1&sparse=true",
+			"modules/vpc",
+			"git::https://example.com/repo.git?ref=v1&sparse=modules%2Fvpc",
+			"",
+		},
+		"no subdirectory": {
+			"git::https://example.com/repo.git?sparse=true",
+			"",
+			"",
+			"requires a subdirectory",
+		},
+		"not git": {
+			"https://example.com/module.zip?sparse=true",
+			"vpc",
+			"",
+			"only valid for git sources",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := withSparsePath(tc.Source, tc.SubDir)
+			if tc.Err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.Err) {
+					t.Fatalf("wrong error %v; want %q", err, tc.Err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if got != tc.Want {
+				t.Fatalf("wrong source\ngot:  %s\nwant: %s", got, tc.Want)
+			}
+		})
+	}
+}
+
+func TestGetModule_gitShallowSparse(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+
+	repo, err := ioutil.TempDir("", "tf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(repo)
+
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repo
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+		)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %s\n%s", args[0], err, out)
+		}
+	}
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(repo, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	git("init", "-q")
+	write("modules/a/main.tf", "# a v1\n")
+	write("modules/b/main.tf", "# b v1\n")
+	git("add", ".")
+	git("commit", "-q", "-m", "v1")
+	write("modules/a/main.tf", "# a v2\n")
+	git("commit", "-q", "-a", "-m", "v2")
+	git("tag", "v2")
+	write("modules/a/main.tf", "# a v3\n")
+	git("commit", "-q", "-a", "-m", "v3")
+
+	td, err := ioutil.TempDir("", "tf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(td)
+	dst := filepath.Join(td, "module")
+
+	source, err := withSparsePath(fmt.Sprintf("git::file://%s?ref=v2&depth=1&sparse=true", filepath.ToSlash(repo)), "modules/a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := getModule(dst, source); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ioutil.ReadFile(filepath.Join(dst, "modules", "a", "main.tf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "# a v2\n" {
+		t.Fatalf("wrong content %q; want the pinned ref", got)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "modules", "b")); !os.IsNotExist(err) {
+		t.Fatalf("modules/b should not be checked out: %v", err)
+	}
+	out, err := exec.Command("git", "-C", dst, "rev-list", "--count", "HEAD").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(string(out)) != "1" {
+		t.Fatalf("clone should have 1 commit, has %s", out)
+	}
+
+	// Updating fetches the new ref into the same directory.
+	source = strings.Replace(source, "ref=v2", "ref=master", 1)
+	git("branch", "-M", "master")
+	if err := getModule(dst, source); err != nil {
+		t.Fatal(err)
+	}
+	got, err = ioutil.ReadFile(filepath.Join(dst, "modules", "a", "main.tf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "# a v3\n" {
+		t.Fatalf("wrong content %q after update", got)
+	}
+}
+
+func TestParseOCIReference(t *testing.T) {
+	tests := map[string]struct {
+		Source string
+		Want   ociReference
+		Err    string
+	}{
+		"tag": {
+			"oci://registry.example.com/org/vpc:1.2.0",
+			ociReference{"https", "registry.example.com", "org/vpc", "1.2.0"},
+			"",
+		},
+		"default tag": {
+			"oci://localhost:5000/vpc?insecure=true",
+			ociReference{"http", "localhost:5000", "vpc", "latest"},
+			"",
+		},
+		"digest": {
+			"oci://registry.example.com/org/vpc@sha256:abcd",
+			ociReference{"https", "registry.example.com", "org/vpc", "sha256:abcd"},
+			"",
+		},
+		"bad repository": {
+			"oci://registry.example.com/Org/VPC:1",
+			ociReference{},
+			"is not a valid repository name",
+		},
+		"bad digest": {
+			"oci://registry.example.com/vpc@md5:abcd",
+			ociReference{},
+			"only sha256 digests",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			u, err := url.Parse(tc.Source)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := parseOCIReference(u)
+			if tc.Err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.Err) {
+					t.Fatalf("wrong error %v; want %q", err, tc.Err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if got != tc.Want {
+				t.Fatalf("wrong reference\ngot:  %#v\nwant: %#v", got, tc.Want)
+			}
+		})
+	}
+}
+
+func TestGetModule_oci(t *testing.T) {
+	var archive bytes.Buffer
+	gz := gzip.NewWriter(&archive)
+	tw := tar.NewWriter(gz)
+	content := []byte("variable \"cidr\" {}\n")
+	if err := tw.WriteHeader(&tar.Header{Name: "main.tf", Mode: 0644, Size: int64(len(content))}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tw.Write(content); err != nil {
+		t.Fatal(err)
+	}
+	tw.Close()
+	gz.Close()
+
+	sum := sha256.Sum256(archive.Bytes())
+	digest := "sha256:" + hex.EncodeToString(sum[:])
+	manifest := fmt.Sprintf(`{
+  "schemaVersion": 2,
+  "mediaType": "application/vnd.oci.image.manifest.v1+json",
+  "layers": [
+    {"mediaType": "application/vnd.oci.image.layer.v1.tar+gzip", "digest": %q, "size": %d}
+  ]
+}`, digest, archive.Len())
+
+	// The registry hands out anonymous tokens, like public registries do.
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			if got := r.URL.Query().Get("scope"); got != "repository:org/vpc:pull" {
+				t.Errorf("wrong scope %q", got)
+			}
+			w.Write([]byte(`{"token": "anonymous"}`))
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer anonymous" {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="test"`, server.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/v2/org/vpc/manifests/1.0.0":
+			w.Header().Set("Content-Type", "application/vnd.oci.image.manifest.v1+json")
+			w.Write([]byte(manifest))
+		case "/v2/org/vpc/blobs/" + digest:
+			w.Write(archive.Bytes())
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	td, err := ioutil.TempDir("", "tf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(td)
+	dst := filepath.Join(td, "module")
+
+	if err := getModule(dst, fmt.Sprintf("oci://%s/org/vpc:1.0.0?insecure=true", host)); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ioutil.ReadFile(filepath.Join(dst, "main.tf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, content) {
+		t.Fatalf("wrong content %q", got)
+	}
+
+	err = getModule(filepath.Join(td, "missing"), fmt.Sprintf("oci://%s/org/vpc:2.0.0?insecure=true", host))
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("wrong error for a missing tag: %v", err)
+	}
+}
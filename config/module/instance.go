@@ -0,0 +1,53 @@
+package module
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// A module path is a sequence of steps, one for each module call traversed
+// from the root module. Each step is the name of the module call, optionally
+// followed by an instance key in brackets, like "consul[1]", which
+// identifies one of several instances of the same module call. Each module
+// instance has its own state and its own evaluation scope, but all instances
+// of a call share the same configuration.
+
+// InstanceStep returns the path step for the instance of the module call
+// with the given name that has the given index. An index of -1 represents
+// the sole instance of a module call that isn't repeated, in which case the
+// step is just the name.
+func InstanceStep(name string, index int) string {
+	if index < 0 {
+		return name
+	}
+
+	return fmt.Sprintf("%s[%d]", name, index)
+}
+
+// ParseInstanceStep is the inverse of InstanceStep, returning the name of
+// the module call and the instance index given in a single path step. The
+// index is -1 if the step has no instance key.
+func ParseInstanceStep(step string) (string, int, error) {
+	open := strings.IndexByte(step, '[')
+	if open == -1 {
+		if step == "" || strings.ContainsRune(step, ']') {
+			return "", -1, fmt.Errorf("invalid module path step %q", step)
+		}
+		return step, -1, nil
+	}
+
+	name := step[:open]
+	key := step[open+1:]
+	if name == "" || !strings.HasSuffix(key, "]") {
+		return "", -1, fmt.Errorf("invalid module path step %q", step)
+	}
+
+	index, err := strconv.Atoi(key[:len(key)-1])
+	if err != nil || index < 0 {
+		return "", -1, fmt.Errorf(
+			"invalid module path step %q: instance key must be a non-negative integer", step)
+	}
+
+	return name, index, nil
+}
@@ -0,0 +1,50 @@
+package module
+
+import (
+	"testing"
+)
+
+func TestInstanceStep(t *testing.T) {
+	cases := []struct {
+		Name  string
+		Index int
+		Step  string
+	}{
+		{"foo", -1, "foo"},
+		{"foo", 0, "foo[0]"},
+		{"foo-bar", 12, "foo-bar[12]"},
+	}
+
+	for _, tc := range cases {
+		step := InstanceStep(tc.Name, tc.Index)
+		if step != tc.Step {
+			t.Fatalf("%s %d: got %q; want %q", tc.Name, tc.Index, step, tc.Step)
+		}
+
+		name, index, err := ParseInstanceStep(step)
+		if err != nil {
+			t.Fatalf("%s: %s", step, err)
+		}
+		if name != tc.Name || index != tc.Index {
+			t.Fatalf("%s: got %q, %d", step, name, index)
+		}
+	}
+}
+
+func TestParseInstanceStep_invalid(t *testing.T) {
+	cases := []string{
+		"",
+		"[0]",
+		"foo[",
+		"foo[]",
+		"foo[-1]",
+		"foo[bar]",
+		"foo]",
+	}
+
+	for _, step := range cases {
+		if _, _, err := ParseInstanceStep(step); err == nil {
+			t.Fatalf("%q: should error", step)
+		}
+	}
+}
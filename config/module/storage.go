@@ -1,12 +1,15 @@
 package module
 
 import (
+	"crypto/md5"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"os"
 	"path/filepath"
+	"sync"
 
 	getter "github.com/hashicorp/go-getter"
 	"github.com/hashicorp/terraform/registry"
@@ -64,6 +67,11 @@ type Storage struct {
 	// StorageDir is the full path to the directory where all modules will be
 	// stored.
 	StorageDir string
+	// CacheDir is an optional directory of modules shared by all
+	// configurations, so that each version of a module is only downloaded
+	// once. The modules in StorageDir are then links to, or copies of, the
+	// modules in the cache.
+	CacheDir string
 	// Services is a required *disco.Disco, which may have services and
 	// credentials pre-loaded.
 	Services *disco.Disco
@@ -74,8 +82,24 @@ type Storage struct {
 	Ui cli.Ui
 	// Mode is the GetMode that will be used for various operations.
 	Mode GetMode
+	// Parallelism is the maximum number of modules that Tree.Load will
+	// download at once. Modules are loaded one at a time if it is less than
+	// two, or if the Storage wasn't created by NewStorage.
+	Parallelism int
 
 	registry *registry.Client
+	state    *storageState
+}
+
+// storageState is the state shared by all copies of a Storage, which
+// coordinates the loading of modules concurrently.
+type storageState struct {
+	// lock serializes everything but the downloads themselves: the
+	// manifest, registry lookups, and the output for each module.
+	lock sync.Mutex
+
+	semOnce sync.Once
+	sem     chan struct{}
 }
 
 func NewStorage(dir string, services *disco.Disco, creds auth.CredentialsSource) *Storage {
@@ -84,9 +108,77 @@ func NewStorage(dir string, services *disco.Disco, creds auth.CredentialsSource)
 	return &Storage{
 		StorageDir: dir,
 		registry:   regClient,
+		state:      &storageState{},
+	}
+}
+
+func (s Storage) concurrent() bool {
+	return s.state != nil && s.Parallelism > 1
+}
+
+// lock and unlock guard the non-download parts of loading a module when
+// modules are loaded concurrently.
+func (s Storage) lock() {
+	if s.concurrent() {
+		s.state.lock.Lock()
+	}
+}
+
+func (s Storage) unlock() {
+	if s.concurrent() {
+		s.state.lock.Unlock()
+	}
+}
+
+// acquire and release bound the number of concurrent downloads to
+// Parallelism.
+func (s Storage) acquire() {
+	if !s.concurrent() {
+		return
+	}
+	s.state.semOnce.Do(func() {
+		s.state.sem = make(chan struct{}, s.Parallelism)
+	})
+	s.state.sem <- struct{}{}
+}
+
+func (s Storage) release() {
+	if s.concurrent() {
+		<-s.state.sem
 	}
 }
 
+// each calls fn with each index from 0 to n-1, concurrently if modules are
+// loaded concurrently, and returns the error for the lowest index, if any.
+func (s Storage) each(n int, fn func(int) error) error {
+	if !s.concurrent() {
+		for i := 0; i < n; i++ {
+			if err := fn(i); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = fn(i)
+		}(i)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // loadManifest returns the moduleManifest file from the parent directory.
 func (s Storage) loadManifest() (moduleManifest, error) {
 	manifest := moduleManifest{}
@@ -213,7 +305,10 @@ func (s Storage) output(msg string) {
 	s.Ui.Output(msg)
 }
 
-func (s Storage) getStorage(key string, src string) (string, bool, error) {
+// getStorage gets the module at src into the directory of the storage for
+// key, depending on the Mode, and returns that directory. cacheKey is the
+// key of the module in the module cache, or empty if it can't be cached.
+func (s Storage) getStorage(key, src, cacheKey string) (string, bool, error) {
 	storage := &getter.FolderStorage{
 		StorageDir: s.StorageDir,
 	}
@@ -223,7 +318,7 @@ func (s Storage) getStorage(key string, src string) (string, bool, error) {
 	// Get the module with the level specified if we were told to.
 	if s.Mode > GetModeNone {
 		log.Printf("[DEBUG] fetching %q with key %q", src, key)
-		if err := storage.Get(key, src, s.Mode == GetModeUpdate); err != nil {
+		if err := s.fetch(key, src, cacheKey, s.Mode == GetModeUpdate); err != nil {
 			return "", false, err
 		}
 	}
@@ -234,6 +329,27 @@ func (s Storage) getStorage(key string, src string) (string, bool, error) {
 	return dir, found, err
 }
 
+// fetch gets the module at src into the directory of the storage for key,
+// like getter.FolderStorage does, but with the getters for modules and
+// through the module cache, if there is one. Unless update is true, a
+// module that's already stored is left as it is.
+func (s Storage) fetch(key, src, cacheKey string, update bool) error {
+	sum := md5.Sum([]byte(key))
+	dir := filepath.Join(s.StorageDir, hex.EncodeToString(sum[:]))
+	if !update {
+		if _, err := os.Stat(dir); err == nil {
+			return nil
+		} else if !os.IsNotExist(err) {
+			return fmt.Errorf("Error reading module directory: %s", err)
+		}
+	}
+
+	if s.CacheDir != "" && cacheKey != "" {
+		return s.fetchCached(dir, src, cacheKey, update)
+	}
+	return getModule(dir, src)
+}
+
 // find a stored module that's not from a registry
 func (s Storage) findModule(key string) (string, error) {
 	if s.Mode == GetModeUpdate {
@@ -0,0 +1,8 @@
+provider "aws" {
+  alias  = "west"
+  region = "us-west-2"
+}
+
+resource "aws_instance" "foo" {
+  provider = "aws.west"
+}
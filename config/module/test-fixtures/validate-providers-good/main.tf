@@ -0,0 +1,11 @@
+provider "aws" {
+  alias  = "east"
+  region = "us-east-1"
+}
+
+module "child" {
+  source = "./child"
+  providers = {
+    "aws.west" = "aws.east"
+  }
+}
@@ -0,0 +1,7 @@
+provider "aws" {
+  alias = "east"
+}
+
+module "child" {
+  source = "./child"
+}
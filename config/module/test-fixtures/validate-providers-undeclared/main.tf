@@ -0,0 +1,10 @@
+provider "aws" {
+  alias = "east"
+}
+
+module "child" {
+  source = "./child"
+  providers = {
+    "aws.west" = "aws.east"
+  }
+}
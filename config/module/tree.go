@@ -78,7 +78,9 @@ func (t *Tree) Config() *config.Config {
 	return t.config
 }
 
-// Child returns the child with the given path (by name).
+// Child returns the child with the given path (by name). The path may be
+// a module instance path, in which case the tree for the configuration
+// shared by all instances is returned.
 func (t *Tree) Child(path []string) *Tree {
 	if t == nil {
 		return nil
@@ -88,7 +90,14 @@ func (t *Tree) Child(path []string) *Tree {
 		return t
 	}
 
-	c := t.Children()[path[0]]
+	// All instances of a module call share the same configuration, so we
+	// ignore any instance key here.
+	name, _, err := ParseInstanceStep(path[0])
+	if err != nil {
+		return nil
+	}
+
+	c := t.Children()[name]
 	if c == nil {
 		return nil
 	}
@@ -180,11 +189,18 @@ func (t *Tree) Load(s *Storage) error {
 		return err
 	}
 
-	// Go through all the children and load them.
-	for _, c := range children {
-		if err := c.Load(s); err != nil {
-			return err
-		}
+	// Go through all the children and load them. Sibling modules are
+	// loaded concurrently if the storage allows it, so that the downloads
+	// of the whole tree can overlap.
+	names := make([]string, 0, len(children))
+	for _, m := range t.Modules() {
+		names = append(names, m.Name)
+	}
+	err = s.each(len(names), func(i int) error {
+		return children[names[i]].Load(s)
+	})
+	if err != nil {
+		return err
 	}
 
 	// Set our tree up
@@ -193,156 +209,226 @@ func (t *Tree) Load(s *Storage) error {
 	return nil
 }
 
+// moduleGet is a module that must be fetched into the storage before its
+// child tree can be created.
+type moduleGet struct {
+	module *Module
+	path   []string
+	key    string
+	source string
+	subDir string
+	rec    moduleRecord
+}
+
 func (t *Tree) getChildren(s *Storage) (map[string]*Tree, error) {
 	children := make(map[string]*Tree)
+	seen := make(map[string]bool)
+	var gets []*moduleGet
 
 	// Go through all the modules and get the directory for them.
 	for _, m := range t.Modules() {
-		if _, ok := children[m.Name]; ok {
+		if seen[m.Name] {
 			return nil, fmt.Errorf(
 				"module %s: duplicated. module names must be unique", m.Name)
 		}
+		seen[m.Name] = true
 
-		// Determine the path to this child
-		modPath := make([]string, len(t.path), len(t.path)+1)
-		copy(modPath, t.path)
-		modPath = append(modPath, m.Name)
-
-		log.Printf("[TRACE] module source: %q", m.Source)
-
-		// add the module path to help indicate where modules with relative
-		// paths are being loaded from
-		s.output(fmt.Sprintf("- module.%s", strings.Join(modPath, ".")))
-
-		// Lookup the local location of the module.
-		// dir is the local directory where the module is stored
-		mod, err := s.findRegistryModule(m.Source, m.Version)
+		child, get, err := t.findChild(s, m)
 		if err != nil {
 			return nil, err
 		}
-
-		// The key is the string that will be used to uniquely id the Source in
-		// the local storage.  The prefix digit can be incremented to
-		// invalidate the local module storage.
-		key := "1." + t.versionedPathKey(m)
-		if mod.Version != "" {
-			key += "." + mod.Version
+		if child != nil {
+			children[m.Name] = child
+		} else {
+			gets = append(gets, get)
 		}
+	}
 
-		// Check for the exact key if it's not a registry module
-		if !mod.registry {
-			mod.Dir, err = s.findModule(key)
-			if err != nil {
-				return nil, err
-			}
-		}
+	// Fetch the modules that weren't found locally.
+	fetched := make([]*Tree, len(gets))
+	err := s.each(len(gets), func(i int) error {
+		child, err := t.fetchChild(s, gets[i])
+		fetched[i] = child
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i, get := range gets {
+		children[get.module.Name] = fetched[i]
+	}
 
-		if mod.Dir != "" && s.Mode != GetModeUpdate {
-			// We found it locally, but in order to load the Tree we need to
-			// find out if there was another subDir stored from detection.
-			subDir, err := s.getModuleRoot(mod.Dir)
-			if err != nil {
-				// If there's a problem with the subdir record, we'll let the
-				// recordSubdir method fix it up.  Any other filesystem errors
-				// will turn up again below.
-				log.Println("[WARN] error reading subdir record:", err)
-			}
+	return children, nil
+}
 
-			fullDir := filepath.Join(mod.Dir, subDir)
+// findChild returns the child tree for the given module if it is already
+// in the storage, or otherwise what must be fetched to create it.
+//
+// The output for the module is all written here, while holding the storage
+// lock, so that it isn't interleaved with that of modules being loaded
+// concurrently.
+func (t *Tree) findChild(s *Storage, m *Module) (*Tree, *moduleGet, error) {
+	s.lock()
+	defer s.unlock()
+
+	// Determine the path to this child
+	modPath := make([]string, len(t.path), len(t.path)+1)
+	copy(modPath, t.path)
+	modPath = append(modPath, m.Name)
+
+	log.Printf("[TRACE] module source: %q", m.Source)
+
+	// add the module path to help indicate where modules with relative
+	// paths are being loaded from
+	s.output(fmt.Sprintf("- module.%s", strings.Join(modPath, ".")))
+
+	// Lookup the local location of the module.
+	// dir is the local directory where the module is stored
+	mod, err := s.findRegistryModule(m.Source, m.Version)
+	if err != nil {
+		return nil, nil, err
+	}
 
-			child, err := NewTreeModule(m.Name, fullDir)
-			if err != nil {
-				return nil, fmt.Errorf("module %s: %s", m.Name, err)
-			}
-			child.path = modPath
-			child.parent = t
-			child.version = mod.Version
-			child.source = m.Source
-			children[m.Name] = child
-			continue
+	// The key is the string that will be used to uniquely id the Source in
+	// the local storage.  The prefix digit can be incremented to
+	// invalidate the local module storage.
+	key := "1." + t.versionedPathKey(m)
+	if mod.Version != "" {
+		key += "." + mod.Version
+	}
+
+	// Check for the exact key if it's not a registry module
+	if !mod.registry {
+		mod.Dir, err = s.findModule(key)
+		if err != nil {
+			return nil, nil, err
 		}
+	}
 
-		// Split out the subdir if we have one.
-		// Terraform keeps the entire requested tree, so that modules can
-		// reference sibling modules from the same archive or repo.
-		rawSource, subDir := getter.SourceDirSubdir(m.Source)
-
-		// we haven't found a source, so fallback to the go-getter detectors
-		source := mod.url
-		if source == "" {
-			source, err = getter.Detect(rawSource, t.config.Dir, getter.Detectors)
-			if err != nil {
-				return nil, fmt.Errorf("module %s: %s", m.Name, err)
-			}
+	if mod.Dir != "" && s.Mode != GetModeUpdate {
+		// We found it locally, but in order to load the Tree we need to
+		// find out if there was another subDir stored from detection.
+		subDir, err := s.getModuleRoot(mod.Dir)
+		if err != nil {
+			// If there's a problem with the subdir record, we'll let the
+			// recordSubdir method fix it up.  Any other filesystem errors
+			// will turn up again below.
+			log.Println("[WARN] error reading subdir record:", err)
 		}
 
-		log.Printf("[TRACE] detected module source %q", source)
+		fullDir := filepath.Join(mod.Dir, subDir)
 
-		// Check if the detector introduced something new.
-		// For example, the registry always adds a subdir of `//*`,
-		// indicating that we need to strip off the first component from the
-		// tar archive, though we may not yet know what it is called.
-		source, detectedSubDir := getter.SourceDirSubdir(source)
-		if detectedSubDir != "" {
-			subDir = filepath.Join(detectedSubDir, subDir)
-		}
+		child, err := t.newChild(m, modPath, mod, fullDir)
+		return child, nil, err
+	}
 
-		output := ""
-		switch s.Mode {
-		case GetModeUpdate:
-			output = fmt.Sprintf("  Updating source %q", m.Source)
-		default:
-			output = fmt.Sprintf("  Getting source %q", m.Source)
-		}
-		s.output(output)
+	// Split out the subdir if we have one.
+	// Terraform keeps the entire requested tree, so that modules can
+	// reference sibling modules from the same archive or repo.
+	rawSource, subDir := getter.SourceDirSubdir(m.Source)
 
-		dir, ok, err := s.getStorage(key, source)
+	// we haven't found a source, so fallback to the go-getter detectors
+	source := mod.url
+	if source == "" {
+		source, err = getter.Detect(rawSource, t.config.Dir, getter.Detectors)
 		if err != nil {
-			return nil, err
-		}
-		if !ok {
-			return nil, fmt.Errorf("module %s: not found, may need to run 'terraform init'", m.Name)
+			return nil, nil, fmt.Errorf("module %s: %s", m.Name, err)
 		}
+	}
 
-		log.Printf("[TRACE] %q stored in %q", source, dir)
+	log.Printf("[TRACE] detected module source %q", source)
 
-		// expand and record the subDir for later
-		fullDir := dir
-		if subDir != "" {
-			fullDir, err = getter.SubdirGlob(dir, subDir)
-			if err != nil {
-				return nil, err
-			}
+	// Check if the detector introduced something new.
+	// For example, the registry always adds a subdir of `//*`,
+	// indicating that we need to strip off the first component from the
+	// tar archive, though we may not yet know what it is called.
+	source, detectedSubDir := getter.SourceDirSubdir(source)
+	if detectedSubDir != "" {
+		subDir = filepath.Join(detectedSubDir, subDir)
+	}
 
-			// +1 to account for the pathsep
-			if len(dir)+1 > len(fullDir) {
-				return nil, fmt.Errorf("invalid module storage path %q", fullDir)
-			}
-			subDir = fullDir[len(dir)+1:]
-		}
+	source, err = withSparsePath(source, subDir)
+	if err != nil {
+		return nil, nil, fmt.Errorf("module %s: %s", m.Name, err)
+	}
+
+	output := ""
+	switch s.Mode {
+	case GetModeUpdate:
+		output = fmt.Sprintf("  Updating source %q", m.Source)
+	default:
+		output = fmt.Sprintf("  Getting source %q", m.Source)
+	}
+	s.output(output)
+
+	return nil, &moduleGet{
+		module: m,
+		path:   modPath,
+		key:    key,
+		source: source,
+		subDir: subDir,
+		rec:    mod,
+	}, nil
+}
+
+// fetchChild fetches a module found by findChild into the storage, and
+// returns its child tree.
+func (t *Tree) fetchChild(s *Storage, get *moduleGet) (*Tree, error) {
+	s.acquire()
+	dir, ok, err := s.getStorage(get.key, get.source, moduleCacheKey(get.rec, get.source))
+	s.release()
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("module %s: not found, may need to run 'terraform init'", get.module.Name)
+	}
 
-		// add new info to the module record
-		mod.Key = key
-		mod.Dir = dir
-		mod.Root = subDir
+	log.Printf("[TRACE] %q stored in %q", get.source, dir)
 
-		// record the module in our manifest
-		if err := s.recordModule(mod); err != nil {
+	// expand and record the subDir for later
+	subDir := get.subDir
+	fullDir := dir
+	if subDir != "" {
+		fullDir, err = getter.SubdirGlob(dir, subDir)
+		if err != nil {
 			return nil, err
 		}
 
-		child, err := NewTreeModule(m.Name, fullDir)
-		if err != nil {
-			return nil, fmt.Errorf("module %s: %s", m.Name, err)
+		// +1 to account for the pathsep
+		if len(dir)+1 > len(fullDir) {
+			return nil, fmt.Errorf("invalid module storage path %q", fullDir)
 		}
-		child.path = modPath
-		child.parent = t
-		child.version = mod.Version
-		child.source = m.Source
-		children[m.Name] = child
+		subDir = fullDir[len(dir)+1:]
 	}
 
-	return children, nil
+	// add new info to the module record
+	mod := get.rec
+	mod.Key = get.key
+	mod.Dir = dir
+	mod.Root = subDir
+
+	// record the module in our manifest
+	s.lock()
+	err = s.recordModule(mod)
+	s.unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	return t.newChild(get.module, get.path, mod, fullDir)
+}
+
+func (t *Tree) newChild(m *Module, modPath []string, mod moduleRecord, dir string) (*Tree, error) {
+	child, err := NewTreeModule(m.Name, dir)
+	if err != nil {
+		return nil, fmt.Errorf("module %s: %s", m.Name, err)
+	}
+	child.path = modPath
+	child.parent = t
+	child.version = mod.Version
+	child.source = m.Source
+	return child, nil
 }
 
 // Path is the full path to this tree.
@@ -406,8 +492,9 @@ func (t *Tree) Validate() tfdiags.Diagnostics {
 		return diags
 	}
 
-	// Validate our configuration first.
-	diags = diags.Append(t.config.Validate())
+	// Validate our configuration first. Its addresses are relative to the
+	// module, so they're made absolute.
+	diags = diags.Append(t.absoluteAddresses(t.config.Validate()))
 
 	// If we're the root, we do extra validation. This validation usually
 	// requires the entire tree (since children don't have parent pointers).
@@ -438,6 +525,9 @@ func (t *Tree) Validate() tfdiags.Diagnostics {
 			panic("module not found in children: " + m.Name)
 		}
 
+		// Check the provider configurations passed to the module
+		diags = diags.Append(t.validateModuleProviders(m, tree))
+
 		// Build the variables that the module defines
 		requiredMap := make(map[string]struct{})
 		varMap := make(map[string]struct{})
@@ -452,10 +542,10 @@ func (t *Tree) Validate() tfdiags.Diagnostics {
 		// Compare to the keys in our raw config for the module
 		for k, _ := range m.RawConfig.Raw {
 			if _, ok := varMap[k]; !ok {
-				diags = diags.Append(fmt.Errorf(
+				diags = diags.Append(t.argumentError(m.Name, k, fmt.Errorf(
 					"module %q: %q is not a valid argument",
 					m.Name, k,
-				))
+				)))
 			}
 
 			// Remove the required
@@ -464,10 +554,10 @@ func (t *Tree) Validate() tfdiags.Diagnostics {
 
 		// If we have any required left over, they aren't set.
 		for k, _ := range requiredMap {
-			diags = diags.Append(fmt.Errorf(
+			diags = diags.Append(t.argumentError(m.Name, k, fmt.Errorf(
 				"module %q: missing required argument %q",
 				m.Name, k,
-			))
+			)))
 		}
 	}
 
@@ -505,9 +595,46 @@ func (t *Tree) Validate() tfdiags.Diagnostics {
 		}
 	}
 
+	return diags.WithCode(tfdiags.CodeConfigInvalid)
+}
+
+// addressPrefix returns the prefix of the addresses of the objects in the
+// module, such as "module.network.", or "" for the root module.
+func (t *Tree) addressPrefix() string {
+	var prefix string
+	for _, name := range t.path {
+		prefix += "module." + name + "."
+	}
+	return prefix
+}
+
+// absoluteAddresses returns the diagnostics of the module's configuration
+// with the addresses of the objects they're about, which are relative to
+// the module, made absolute.
+func (t *Tree) absoluteAddresses(diags tfdiags.Diagnostics) tfdiags.Diagnostics {
+	prefix := t.addressPrefix()
+	if prefix == "" {
+		return diags
+	}
+	for i, diag := range diags {
+		if addr := tfdiags.GetExtra(diag).Address; addr != "" {
+			diags[i] = tfdiags.WithExtra(diag, tfdiags.Extra{Address: prefix + addr})
+		}
+	}
 	return diags
 }
 
+// argumentError returns err as a diagnostic about the given argument of the
+// call of the named child module.
+func (t *Tree) argumentError(name, arg string, err error) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+	diags = diags.Append(err)
+	return diags.WithExtra(tfdiags.Extra{
+		Address:       t.addressPrefix() + "module." + name,
+		AttributePath: arg,
+	})
+}
+
 // versionedPathKey returns a path string with every levels full name, version
 // and source encoded. This is to provide a unique key for our module storage,
 // since submodules need to know which versions of their ancestor modules they
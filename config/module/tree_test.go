@@ -6,11 +6,14 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"sort"
 	"strings"
 	"testing"
 
 	"github.com/hashicorp/terraform/config"
 	"github.com/hashicorp/terraform/helper/copy"
+	"github.com/hashicorp/terraform/tfdiags"
+	"github.com/mitchellh/cli"
 )
 
 func TestTreeChild(t *testing.T) {
@@ -61,6 +64,18 @@ func TestTreeChild(t *testing.T) {
 	} else if !reflect.DeepEqual(c.Path(), []string{"foo", "bar"}) {
 		t.Fatalf("bad: %#v", c.Path())
 	}
+
+	// Should be able to get the nested child by instance path
+	if c := tree.Child([]string{"foo[1]", "bar[0]"}); c == nil {
+		t.Fatal("should not be nil")
+	} else if c.Name() != "bar" {
+		t.Fatalf("bad: %#v", c.Name())
+	}
+
+	// Invalid instance keys match nothing
+	if c := tree.Child([]string{"foo[x]"}); c != nil {
+		t.Fatalf("should be nil: %#v", c.Name())
+	}
 }
 
 func TestTreeLoad(t *testing.T) {
@@ -274,6 +289,58 @@ func TestTreeLoad_subdir(t *testing.T) {
 	}
 }
 
+func TestTreeLoad_parallel(t *testing.T) {
+	ui := new(cli.MockUi)
+	storage := testStorage(t, nil)
+	storage.Ui = ui
+	storage.Mode = GetModeGet
+	storage.Parallelism = 2
+	tree := NewTree("", testConfig(t, "parallel"))
+
+	if err := tree.Load(storage); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	var paths []string
+	tree.DeepEach(func(c *Tree) {
+		paths = append(paths, strings.Join(c.Path(), "."))
+	})
+	sort.Strings(paths)
+	expected := []string{"", "a", "a.d", "b", "c"}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Fatalf("wrong paths\ngot:  %#v\nwant: %#v", paths, expected)
+	}
+
+	// The output for each module must not be interleaved with that of the
+	// others, even though they were loaded concurrently.
+	lines := strings.Split(strings.TrimSpace(ui.OutputWriter.String()), "\n")
+	if len(lines) != 8 {
+		t.Fatalf("wrong output:\n%s", ui.OutputWriter.String())
+	}
+	for i := 0; i < len(lines); i += 2 {
+		name := strings.TrimPrefix(lines[i], "- module.")
+		parts := strings.Split(name, ".")
+		want := fmt.Sprintf("  Getting source %q", "./"+parts[len(parts)-1])
+		if lines[i+1] != want {
+			t.Fatalf("wrong output for %s\ngot:  %q\nwant: %q", name, lines[i+1], want)
+		}
+	}
+
+	// All of the modules must have been recorded in the manifest.
+	manifest, err := storage.loadManifest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(manifest.Modules) != 4 {
+		t.Fatalf("wrong number of modules in manifest: %#v", manifest.Modules)
+	}
+
+	storage.Mode = GetModeNone
+	if err := tree.Load(storage); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+}
+
 func TestTree_recordManifest(t *testing.T) {
 	td, err := ioutil.TempDir("", "tf-module")
 	if err != nil {
@@ -382,6 +449,30 @@ func TestTreeValidate_table(t *testing.T) {
 			"alias must be defined",
 		},
 
+		{
+			"configuration alias passed to child",
+			"validate-providers-good",
+			"",
+		},
+
+		{
+			"configuration alias not passed to child",
+			"validate-providers-missing",
+			`requires the provider configuration "aws.west"`,
+		},
+
+		{
+			"aliased provider passed to child that doesn't declare it",
+			"validate-providers-undeclared",
+			`provider "aws.west" is passed, but the module doesn't declare it`,
+		},
+
+		{
+			"provider passed to child that configures it",
+			"validate-providers-configured",
+			`cannot pass provider "aws.west", since the module configures it itself`,
+		},
+
 		{
 			"root module named root",
 			"validate-module-root",
@@ -531,6 +622,16 @@ func TestTreeValidate_requiredChildVar(t *testing.T) {
 			t.Fatalf("no mention of missing variable %q", v)
 		}
 	}
+
+	for _, diag := range diags {
+		extra := tfdiags.GetExtra(diag)
+		if extra.Code != tfdiags.CodeConfigInvalid || extra.Address != "module.child" {
+			t.Fatalf("wrong extra %#v", extra)
+		}
+		if extra.AttributePath != "feature" && extra.AttributePath != "memory" {
+			t.Fatalf("wrong attribute path %q", extra.AttributePath)
+		}
+	}
 }
 
 func TestTreeValidate_unknownModule(t *testing.T) {
@@ -2,10 +2,13 @@ package module
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/terraform/config"
 	"github.com/hashicorp/terraform/dag"
+	"github.com/hashicorp/terraform/tfdiags"
 )
 
 // validateProviderAlias validates that all provider alias references are
@@ -75,12 +78,69 @@ func (t *Tree) validateProviderAlias() error {
 	return err
 }
 
+// validateModuleProviders validates the providers argument of the call m of
+// the child module against the provider configurations the child declares.
+// The call must pass each of the child's configuration aliases, and each
+// aliased configuration it passes must be declared by the child, either as
+// a configuration alias or by an empty provider block. A configuration
+// can't be passed to a child that configures it itself.
+func (t *Tree) validateModuleProviders(m *config.Module, child *Tree) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+
+	declared := make(map[string]bool)
+	configured := make(map[string]bool)
+	for _, alias := range child.config.ConfigurationAliases() {
+		declared[alias] = true
+	}
+	for _, p := range child.config.ProviderConfigs {
+		if len(p.RawConfig.RawMap()) > 0 {
+			configured[p.FullName()] = true
+		} else if p.Alias != "" {
+			declared[p.FullName()] = true
+		}
+	}
+
+	for _, alias := range child.config.ConfigurationAliases() {
+		if _, ok := m.Providers[alias]; !ok {
+			diags = diags.Append(t.argumentError(m.Name, "providers", fmt.Errorf(
+				"module %q: the module requires the provider configuration %q, which must be passed in the module block's providers argument",
+				m.Name, alias,
+			)))
+		}
+	}
+
+	names := make([]string, 0, len(m.Providers))
+	for name := range m.Providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		switch {
+		case configured[name]:
+			diags = diags.Append(t.argumentError(m.Name, "providers", fmt.Errorf(
+				"module %q: cannot pass provider %q, since the module configures it itself",
+				m.Name, name,
+			)))
+		case strings.Contains(name, ".") && !declared[name]:
+			diags = diags.Append(t.argumentError(m.Name, "providers", fmt.Errorf(
+				"module %q: provider %q is passed, but the module doesn't declare it; it must be in configuration_aliases of the module's required_providers",
+				m.Name, name,
+			)))
+		}
+	}
+
+	return diags
+}
+
 func (t *Tree) buildProviderAliasGraph(g *dag.AcyclicGraph, parent dag.Vertex) {
 	// Add all our defined aliases
 	defined := make(map[string]struct{})
 	for _, p := range t.config.ProviderConfigs {
 		defined[p.FullName()] = struct{}{}
 	}
+	for _, alias := range t.config.ConfigurationAliases() {
+		defined[alias] = struct{}{}
+	}
 
 	// Add all our used aliases
 	used := make(map[string]struct{})
@@ -0,0 +1,136 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/terraform/config/hcl2shim"
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+// ModuleCall is the static description of a module block, as it's written
+// in the configuration, for tools that document or analyze configurations
+// without installing their modules.
+type ModuleCall struct {
+	Name      string
+	Source    string
+	Version   string            // Version constraint, if any
+	Providers map[string]string // As for Module.Providers
+
+	// Arguments are the values the call passes to the module's input
+	// variables, by name. Values that refer to anything else, such as
+	// "${var.region}", are as they're written in the native syntax, and
+	// are hcl2shim.UnknownVariableValue in the experimental HCL2 syntax.
+	Arguments map[string]interface{}
+
+	DeclRange tfdiags.SourceRange
+
+	// Dir is the directory of the called module if its source is a local
+	// path, in which case Calls are the module calls it makes in turn.
+	// Other modules can't be read before they're installed, so their Dir
+	// is empty and their Calls are unknown.
+	Dir   string
+	Calls []*ModuleCall
+}
+
+// Local returns true if the module's source is a local path, which is the
+// only kind of source that's read without installing the module.
+func (c *ModuleCall) Local() bool {
+	return isLocalModuleSource(c.Source)
+}
+
+// LoadModuleCalls returns the module calls of the module in the given
+// directory, in the order the module declares them, following local paths
+// to the calls of the modules they call.
+//
+// Unlike module.Tree, this doesn't need the modules of the configuration to
+// have been installed, so it works on any configuration as it's checked
+// out. That also means that only the calls of modules with local sources
+// are known.
+func LoadModuleCalls(dir string) ([]*ModuleCall, error) {
+	return loadModuleCalls(dir, nil)
+}
+
+// loadModuleCalls loads the calls of the module in dir, which is called by
+// the modules in the given directories, so that a module that calls itself
+// is an error rather than an endless tree.
+func loadModuleCalls(dir string, callers []string) ([]*ModuleCall, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, caller := range callers {
+		if caller == abs {
+			return nil, fmt.Errorf("module %s calls itself through local paths", dir)
+		}
+	}
+	callers = append(callers, abs)
+
+	cfg, err := LoadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	calls := make([]*ModuleCall, 0, len(cfg.Modules))
+	for _, m := range cfg.Modules {
+		call := &ModuleCall{
+			Name:      m.Name,
+			Source:    m.Source,
+			Version:   m.Version,
+			Providers: m.Providers,
+			Arguments: moduleCallArguments(m.RawConfig),
+			DeclRange: m.DeclRange,
+		}
+
+		if call.Local() {
+			call.Dir = filepath.Join(dir, filepath.FromSlash(m.Source))
+			call.Calls, err = loadModuleCalls(call.Dir, callers)
+			if err != nil {
+				return nil, fmt.Errorf("module %s: %s", m.Name, err)
+			}
+		}
+
+		calls = append(calls, call)
+	}
+
+	return calls, nil
+}
+
+// moduleCallArguments returns the arguments of a module block's config.
+func moduleCallArguments(rc *RawConfig) map[string]interface{} {
+	if rc == nil {
+		return nil
+	}
+	if rc.Body == nil {
+		return rc.RawMap()
+	}
+
+	attrs, diags := rc.Body.JustAttributes()
+	if diags.HasErrors() {
+		return nil
+	}
+
+	ret := make(map[string]interface{}, len(attrs))
+	for name, attr := range attrs {
+		switch name {
+		case "source", "version", "providers":
+			continue
+		}
+
+		val, diags := attr.Expr.Value(nil)
+		if diags.HasErrors() {
+			ret[name] = hcl2shim.UnknownVariableValue
+			continue
+		}
+		ret[name] = hcl2shim.ConfigValueFromHCL2(val)
+	}
+	return ret
+}
+
+// isLocalModuleSource returns true if the given module source is a local
+// path, which must start with "./" or "../" to distinguish it from a
+// registry address.
+func isLocalModuleSource(source string) bool {
+	return strings.HasPrefix(source, "./") || strings.HasPrefix(source, "../")
+}
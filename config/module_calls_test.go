@@ -0,0 +1,66 @@
+package config
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadModuleCalls(t *testing.T) {
+	dir := filepath.Join(fixtureDir, "module-calls")
+	calls, err := LoadModuleCalls(dir)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(calls) != 2 {
+		t.Fatalf("wrong number of calls %d; want 2", len(calls))
+	}
+
+	child := calls[0]
+	if child.Name != "child" || child.Source != "./child" || !child.Local() {
+		t.Errorf("wrong child call %#v", child)
+	}
+	if child.Dir != filepath.Join(dir, "child") {
+		t.Errorf("wrong child dir %q", child.Dir)
+	}
+	if want := map[string]interface{}{"region": "us-east-1", "size": 2}; !reflect.DeepEqual(child.Arguments, want) {
+		t.Errorf("wrong child arguments %#v; want %#v", child.Arguments, want)
+	}
+	if child.DeclRange.Start.Line != 1 {
+		t.Errorf("wrong child range %#v", child.DeclRange)
+	}
+
+	if len(child.Calls) != 1 {
+		t.Fatalf("wrong number of child calls %d; want 1", len(child.Calls))
+	}
+	grandchild := child.Calls[0]
+	if grandchild.Source != "../grandchild" || grandchild.Dir != filepath.Join(dir, "child", "..", "grandchild") {
+		t.Errorf("wrong grandchild call %#v", grandchild)
+	}
+	if got := grandchild.Arguments["name"]; got != "${var.region}" {
+		t.Errorf("wrong grandchild name %#v", got)
+	}
+
+	// Modules from other sources aren't read, since they'd need installing.
+	consul := calls[1]
+	if consul.Local() || consul.Dir != "" || consul.Calls != nil {
+		t.Errorf("the consul module shouldn't be read: %#v", consul)
+	}
+	if consul.Version != "~> 0.1" || consul.Providers["aws"] != "aws.west" {
+		t.Errorf("wrong consul call %#v", consul)
+	}
+	if len(consul.Arguments) != 0 {
+		t.Errorf("wrong consul arguments %#v", consul.Arguments)
+	}
+}
+
+func TestLoadModuleCalls_cycle(t *testing.T) {
+	_, err := LoadModuleCalls(filepath.Join(fixtureDir, "module-calls-cycle"))
+	if err == nil {
+		t.Fatal("succeeded; want error")
+	}
+	if !strings.Contains(err.Error(), "calls itself") {
+		t.Fatalf("wrong error %q", err)
+	}
+}
@@ -0,0 +1,309 @@
+package config
+
+import (
+	"fmt"
+
+	hcl2 "github.com/hashicorp/hcl2/hcl"
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+// Override merges the configuration from an override file into base, the
+// configuration of the other files of a module, returning the result.
+//
+// Each block in an override file changes the block in base with the same
+// identity, which is its name for variables, modules, outputs and locals,
+// its name and alias for providers, and its address for resources. A block
+// with no counterpart in base is an error, since it's most likely a
+// misspelling that would otherwise silently add to the configuration.
+//
+// Within a block, each argument the override sets replaces the argument of
+// the same name, and each type of nested block it has, such as a resource's
+// "network_interface" blocks, replaces all of the nested blocks of that
+// type. The rules for the arguments and blocks that Terraform itself
+// interprets are documented with the override functions for each type.
+//
+// Neither configuration is modified.
+func Override(base, override *Config) (*Config, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+
+	c := *base
+	c.unknownKeys = append([]string(nil), base.unknownKeys...)
+	for _, k := range override.unknownKeys {
+		if !containsString(c.unknownKeys, k) {
+			c.unknownKeys = append(c.unknownKeys, k)
+		}
+	}
+
+	if override.Atlas != nil {
+		c.Atlas = override.Atlas
+	}
+	if override.Terraform != nil {
+		c.Terraform = overrideTerraform(base.Terraform, override.Terraform)
+	}
+
+	c.Variables = append([]*Variable(nil), base.Variables...)
+	for _, o := range override.Variables {
+		i := -1
+		for j, v := range c.Variables {
+			if v.Name == o.Name {
+				i = j
+				break
+			}
+		}
+		if i < 0 {
+			diags = diags.Append(missingBaseError(o.DeclRange, "variable", o.Name))
+			continue
+		}
+
+		v := c.Variables[i].Merge(o)
+		if err := v.ValidateTypeAndDefault(); err != nil {
+			diags = diags.Append(errorAt(o.DeclRange, err))
+			continue
+		}
+		c.Variables[i] = v
+	}
+
+	c.Locals = append([]*Local(nil), base.Locals...)
+	for _, o := range override.Locals {
+		i := -1
+		for j, l := range c.Locals {
+			if l.Name == o.Name {
+				i = j
+				break
+			}
+		}
+		if i < 0 {
+			diags = diags.Append(missingBaseError(o.DeclRange, "local value", o.Name))
+			continue
+		}
+		c.Locals[i] = o
+	}
+
+	c.ProviderConfigs = append([]*ProviderConfig(nil), base.ProviderConfigs...)
+	for _, o := range override.ProviderConfigs {
+		i := -1
+		for j, p := range c.ProviderConfigs {
+			if p.FullName() == o.FullName() {
+				i = j
+				break
+			}
+		}
+		if i < 0 {
+			diags = diags.Append(missingBaseError(o.DeclRange, "provider", o.FullName()))
+			continue
+		}
+		c.ProviderConfigs[i] = c.ProviderConfigs[i].override(o)
+	}
+
+	c.Modules = append([]*Module(nil), base.Modules...)
+	for _, o := range override.Modules {
+		i := -1
+		for j, m := range c.Modules {
+			if m.Id() == o.Id() {
+				i = j
+				break
+			}
+		}
+		if i < 0 {
+			diags = diags.Append(missingBaseError(o.DeclRange, "module", o.Name))
+			continue
+		}
+		c.Modules[i] = c.Modules[i].override(o)
+	}
+
+	c.Resources = append([]*Resource(nil), base.Resources...)
+	for _, o := range override.Resources {
+		if o.Check != "" {
+			// Reported along with the check it's declared in, below.
+			continue
+		}
+		i := -1
+		for j, r := range c.Resources {
+			if r.Id() == o.Id() {
+				i = j
+				break
+			}
+		}
+		if i < 0 {
+			kind := "resource"
+			if o.Mode == DataResourceMode {
+				kind = "data source"
+			}
+			diags = diags.Append(missingBaseError(o.DeclRange, kind, o.Id()))
+			continue
+		}
+		c.Resources[i] = c.Resources[i].override(o)
+	}
+
+	c.Outputs = append([]*Output(nil), base.Outputs...)
+	for _, o := range override.Outputs {
+		i := -1
+		for j, out := range c.Outputs {
+			if out.Name == o.Name {
+				i = j
+				break
+			}
+		}
+		if i < 0 {
+			diags = diags.Append(missingBaseError(o.DeclRange, "output", o.Name))
+			continue
+		}
+		c.Outputs[i] = c.Outputs[i].override(o)
+	}
+
+	for _, m := range override.Moved {
+		diags = diags.Append(&hcl2.Diagnostic{
+			Severity: hcl2.DiagError,
+			Summary:  "Cannot override moved blocks",
+			Detail:   fmt.Sprintf("The moved block for %s must be in a file that isn't an override file, since moved blocks record what happened to resources rather than configuring them.", m.From),
+			Subject:  diagSubject(m.DeclRange),
+		})
+	}
+
+	for _, ch := range override.Checks {
+		diags = diags.Append(&hcl2.Diagnostic{
+			Severity: hcl2.DiagError,
+			Summary:  "Cannot override check blocks",
+			Detail:   fmt.Sprintf("The check block %q must be in a file that isn't an override file.", ch.Name),
+			Subject:  diagSubject(ch.DeclRange),
+		})
+	}
+
+	return &c, diags
+}
+
+// overrideTerraform returns the terraform block t with the settings of the
+// override o: a required_version replaces the base's, a backend block
+// replaces the base's backend block and all its settings, and each
+// provider_meta block and required_providers entry replaces the base's for
+// the same provider, if any.
+func overrideTerraform(t, o *Terraform) *Terraform {
+	var result Terraform
+	if t != nil {
+		result = *t
+		result.ProviderMetas = append([]*ProviderMeta(nil), t.ProviderMetas...)
+		result.RequiredProviders = append([]*RequiredProvider(nil), t.RequiredProviders...)
+	}
+	result.Merge(o)
+	return &result
+}
+
+// override returns the provider configuration p with the settings of the
+// override o. The alias identifies the configuration, and the version,
+// parallelism and timeouts replace the base's if they're set.
+func (p *ProviderConfig) override(o *ProviderConfig) *ProviderConfig {
+	result := *p
+	result.RawConfig = p.RawConfig.merge(o.RawConfig)
+
+	if o.Version != "" {
+		result.Version = o.Version
+	}
+	if o.Timeouts != nil {
+		result.Timeouts = o.Timeouts
+	}
+	if o.Parallelism != 0 {
+		result.Parallelism = o.Parallelism
+	}
+
+	return &result
+}
+
+// override returns the module call m with the settings of the override o.
+// The source, version and providers replace the base's if they're set.
+func (m *Module) override(o *Module) *Module {
+	result := *m
+	result.RawConfig = m.RawConfig.merge(o.RawConfig)
+
+	if o.Source != "" {
+		result.Source = o.Source
+	}
+	if o.Version != "" {
+		result.Version = o.Version
+	}
+	if o.Providers != nil {
+		result.Providers = o.Providers
+	}
+
+	return &result
+}
+
+// override returns the resource r with the settings of the override o.
+//
+// The count, provider and depends_on replace the base's if they're set. A
+// lifecycle block replaces the base's lifecycle block, even if all of its
+// settings are false, and provisioner blocks replace all of the base's
+// provisioners, since their order matters.
+func (r *Resource) override(o *Resource) *Resource {
+	result := *r
+	result.RawConfig = r.RawConfig.merge(o.RawConfig)
+
+	// The loader sets a count of 1 where there's none, so only one that's
+	// set explicitly, even to 1, replaces the base's.
+	if containsString(o.setKeys, "count") {
+		result.RawCount = o.RawCount
+	}
+	if o.Provider != "" {
+		result.Provider = o.Provider
+	}
+	if o.DependsOn != nil {
+		result.DependsOn = o.DependsOn
+	}
+	if containsString(o.setKeys, "lifecycle") {
+		result.Lifecycle = *o.Lifecycle.Copy()
+	}
+	if len(o.Provisioners) > 0 {
+		result.Provisioners = o.Provisioners
+	}
+
+	return &result
+}
+
+// override returns the output out with the settings of the override o. The
+// value and sensitive arguments are merged as for any other argument, and
+// the description and depends_on replace the base's if they're set. A
+// sensitive argument replaces the base's even if it's false.
+func (out *Output) override(o *Output) *Output {
+	result := *out
+	result.RawConfig = out.RawConfig.merge(o.RawConfig)
+
+	if o.Description != "" {
+		result.Description = o.Description
+	}
+	if o.DependsOn != nil {
+		result.DependsOn = o.DependsOn
+	}
+	if containsString(o.setKeys, "sensitive") {
+		result.Sensitive = o.Sensitive
+	}
+
+	return &result
+}
+
+// missingBaseError returns the error for an override of the given kind of
+// block that has nothing to override.
+func missingBaseError(rng tfdiags.SourceRange, kind, name string) *hcl2.Diagnostic {
+	return &hcl2.Diagnostic{
+		Severity: hcl2.DiagError,
+		Summary:  fmt.Sprintf("Missing base %s to override", kind),
+		Detail:   fmt.Sprintf("There is no %s %q in the configuration for the override to change. Override files can only change what's declared in the other files of the module.", kind, name),
+		Subject:  diagSubject(rng),
+	}
+}
+
+// diagSubject returns rng as the subject of a diagnostic, or nil if the
+// range is unknown.
+func diagSubject(rng tfdiags.SourceRange) *hcl2.Range {
+	if rng.Filename == "" {
+		return nil
+	}
+	return rng.ToHCL().Ptr()
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,176 @@
+package config
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestOverride(t *testing.T) {
+	c, err := LoadDir(filepath.Join(fixtureDir, "override-semantics"))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if got, want := c.Terraform.RequiredVersion, ">= 0.10.0"; got != want {
+		t.Errorf("wrong required_version %q; want %q", got, want)
+	}
+	if got, want := c.Terraform.Backend.Type, "local"; got != want {
+		t.Errorf("wrong backend %q; want %q", got, want)
+	}
+	if _, ok := c.Terraform.Backend.RawConfig.Raw["bucket"]; ok {
+		t.Error("the backend's settings should be replaced")
+	}
+
+	reqs := c.RequiredProvidersByName()
+	if aws := reqs["aws"]; aws == nil || aws.Version != "~> 1.0" {
+		t.Errorf("wrong aws requirement %#v", aws)
+	}
+	if w := reqs["widgets"]; w == nil || w.Source != "example.com/acme/widgets" || w.Version != ">= 2.0" {
+		t.Errorf("the widgets requirement should be replaced: %#v", w)
+	}
+
+	v := c.Variables[0]
+	if v.Default != "eu-west-1" || v.Description != "The region" {
+		t.Errorf("wrong variable %q %q", v.Default, v.Description)
+	}
+
+	locals := map[string]interface{}{}
+	for _, l := range c.Locals {
+		locals[l.Name] = l.RawConfig.Raw["value"]
+	}
+	if want := map[string]interface{}{"name": "override", "env": "prod"}; !reflect.DeepEqual(locals, want) {
+		t.Errorf("wrong locals %#v; want %#v", locals, want)
+	}
+
+	regions := map[string]interface{}{}
+	for _, p := range c.ProviderConfigs {
+		regions[p.FullName()] = p.RawConfig.Raw["region"]
+	}
+	if want := map[string]interface{}{"aws": "us-east-1", "aws.west": "us-west-2"}; !reflect.DeepEqual(regions, want) {
+		t.Errorf("wrong provider regions %#v; want %#v", regions, want)
+	}
+
+	m := c.Modules[0]
+	if m.Source != "./network" || m.Version != "1.2.0" || m.RawConfig.Raw["cidr"] != "10.0.0.0/16" {
+		t.Errorf("wrong module %q %q %#v", m.Source, m.Version, m.RawConfig.Raw)
+	}
+
+	r := c.Resources[0]
+	if got := r.RawConfig.Raw["ami"]; got != "ami-override" {
+		t.Errorf("wrong ami %q", got)
+	}
+	if got := r.RawConfig.Raw["instance_type"]; got != "t2.micro" {
+		t.Errorf("wrong instance_type %q", got)
+	}
+	nics := r.RawConfig.Raw["network_interface"].([]map[string]interface{})
+	if len(nics) != 1 || nics[0]["device_index"] != 2 {
+		t.Errorf("the network_interface blocks should be replaced: %#v", nics)
+	}
+	if !r.Lifecycle.PreventDestroy || r.Lifecycle.CreateBeforeDestroy {
+		t.Errorf("the lifecycle block should be replaced: %#v", r.Lifecycle)
+	}
+	if want := []string{"module.network"}; !reflect.DeepEqual(r.DependsOn, want) {
+		t.Errorf("wrong depends_on %#v; want %#v", r.DependsOn, want)
+	}
+
+	if diags := c.Validate(); diags.HasErrors() {
+		t.Fatalf("err: %s", diags.Err())
+	}
+	o := c.Outputs[0]
+	if !o.Sensitive || o.Description != "The IP" || o.RawConfig.Raw["value"] == nil {
+		t.Errorf("wrong output %#v", o)
+	}
+}
+
+// Test that the arguments an override sets replace the base's even when
+// they're set to their defaults.
+func TestOverride_explicitDefaults(t *testing.T) {
+	loadHCL2 := func(path string) (*Config, error) {
+		cbl, _, err := globalHCL2Loader.loadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return cbl.Config()
+	}
+
+	cases := map[string]func(string) (*Config, error){
+		"override-explicit":      LoadFile,
+		"override-explicit-hcl2": loadHCL2,
+	}
+
+	for name, load := range cases {
+		t.Run(name, func(t *testing.T) {
+			dir := filepath.Join(fixtureDir, name)
+			base, err := load(filepath.Join(dir, "main.tf"))
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			override, err := load(filepath.Join(dir, "main_override.tf"))
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+
+			c, diags := Override(base, override)
+			if diags.HasErrors() {
+				t.Fatalf("err: %s", diags.Err())
+			}
+
+			r := c.Resources[0]
+			if r.RawCount != override.Resources[0].RawCount {
+				t.Errorf("the count should be replaced")
+			}
+			if r.Lifecycle.CreateBeforeDestroy || r.Lifecycle.PreventDestroy {
+				t.Errorf("the lifecycle block should be replaced: %#v", r.Lifecycle)
+			}
+
+			// The old loader only records sensitive in the raw config,
+			// which validation reads.
+			c.Validate()
+			if o := c.Outputs[0]; o.Sensitive {
+				t.Errorf("the output should not be sensitive")
+			}
+		})
+	}
+}
+
+func TestOverride_invalid(t *testing.T) {
+	cases := map[string]struct {
+		Error string
+		Line  int
+	}{
+		"override-missing":  {`There is no resource "aws_instance.wbe"`, 5},
+		"override-moved":    {"Cannot override moved blocks", 1},
+		"override-var-type": {"not of type 'list'", 1},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			dir := filepath.Join(fixtureDir, name)
+			base, err := LoadFile(filepath.Join(dir, "main.tf"))
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			override, err := LoadFile(filepath.Join(dir, "main_override.tf"))
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+
+			_, diags := Override(base, override)
+			if len(diags) != 1 {
+				t.Fatalf("wrong number of diagnostics %d; want 1: %s", len(diags), diags.Err())
+			}
+			if got := diags.Err().Error(); !strings.Contains(got, tc.Error) {
+				t.Fatalf("wrong error %q; want it to contain %q", got, tc.Error)
+			}
+			subject := diags[0].Source().Subject
+			if subject == nil {
+				t.Fatal("diagnostic has no subject")
+			}
+			if subject.Filename != filepath.Join(dir, "main_override.tf") || subject.Start.Line != tc.Line {
+				t.Fatalf("wrong subject %s line %d; want line %d of the override", subject.Filename, subject.Start.Line, tc.Line)
+			}
+		})
+	}
+}
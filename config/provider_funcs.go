@@ -0,0 +1,185 @@
+package config
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hil/ast"
+)
+
+// providerFuncPrefix is the prefix of the internal function names used for
+// functions exported by providers.
+//
+// In configuration a provider function is called using the syntax
+// provider::NAME::FUNC(...), but HIL identifiers cannot contain colons and
+// so the configuration loader rewrites such calls to the equivalent
+// provider.NAME.FUNC(...) before parsing. The dotted form is what appears
+// in the AST and thus what must be present in the function table at
+// evaluation time.
+const providerFuncPrefix = "provider."
+
+// ProviderFunctionName returns the name under which the function with the
+// given name, exported by the provider with the given type name, is
+// registered in the interpolation function table.
+func ProviderFunctionName(provider, name string) string {
+	return providerFuncPrefix + provider + "." + name
+}
+
+// ParseProviderFunctionName is the inverse of ProviderFunctionName. If the
+// given function name does not refer to a provider function then ok is
+// false.
+func ParseProviderFunctionName(fn string) (provider, name string, ok bool) {
+	if !strings.HasPrefix(fn, providerFuncPrefix) {
+		return "", "", false
+	}
+
+	parts := strings.Split(fn[len(providerFuncPrefix):], ".")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
+
+	return parts[0], parts[1], true
+}
+
+// ProviderFunctionCalls returns the names of all of the provider functions
+// called by any of the given interpolation ASTs, in the form returned by
+// ProviderFunctionName. The result is sorted and has no duplicates.
+func ProviderFunctionCalls(nodes []ast.Node) []string {
+	seen := make(map[string]struct{})
+	fn := func(n ast.Node) ast.Node {
+		if call, ok := n.(*ast.Call); ok {
+			if _, _, ok := ParseProviderFunctionName(call.Func); ok {
+				seen[call.Func] = struct{}{}
+			}
+		}
+		return n
+	}
+
+	for _, n := range nodes {
+		n.Accept(fn)
+	}
+
+	result := make([]string, 0, len(seen))
+	for name := range seen {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// ProviderFunctionProviders returns the type names of all of the providers
+// whose functions are called from anywhere in this configuration. Calling a
+// provider function creates a dependency on the provider, even if no
+// resources belonging to it are present.
+func (c *Config) ProviderFunctionProviders() []string {
+	seen := make(map[string]struct{})
+	for _, rc := range c.rawConfigs() {
+		if rc == nil {
+			continue
+		}
+		for _, fn := range ProviderFunctionCalls(rc.Interpolations) {
+			provider, _, _ := ParseProviderFunctionName(fn)
+			seen[provider] = struct{}{}
+		}
+	}
+	for _, l := range c.Locals {
+		for _, fn := range ProviderFunctionCalls(l.RawConfig.Interpolations) {
+			provider, _, _ := ParseProviderFunctionName(fn)
+			seen[provider] = struct{}{}
+		}
+	}
+
+	result := make([]string, 0, len(seen))
+	for provider := range seen {
+		result = append(result, provider)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// rewriteProviderFuncCalls rewrites any provider::NAME::FUNC references
+// appearing inside interpolation sequences in the given string to the
+// dotted form that HIL is able to parse. Literal text outside of
+// interpolation sequences and inside quoted strings is left untouched.
+func rewriteProviderFuncCalls(s string) string {
+	if !strings.Contains(s, "::") {
+		// Fast path for the common case
+		return s
+	}
+
+	const (
+		modeTemplate = iota
+		modeInterp
+		modeString
+	)
+
+	var buf bytes.Buffer
+	modes := []int{modeTemplate}
+	for i := 0; i < len(s); i++ {
+		mode := modes[len(modes)-1]
+		c := s[i]
+
+		switch mode {
+		case modeTemplate, modeString:
+			switch {
+			case mode == modeString && c == '\\' && i+1 < len(s):
+				buf.WriteByte(c)
+				buf.WriteByte(s[i+1])
+				i++
+				continue
+			case mode == modeString && c == '"':
+				modes = modes[:len(modes)-1]
+			case c == '$' && strings.HasPrefix(s[i:], "$${"):
+				// Escaped interpolation sequence, which is literal text
+				buf.WriteString("$${")
+				i += 2
+				continue
+			case c == '$' && strings.HasPrefix(s[i:], "${"):
+				modes = append(modes, modeInterp)
+				buf.WriteString("${")
+				i++
+				continue
+			}
+		case modeInterp:
+			switch {
+			case c == '"':
+				modes = append(modes, modeString)
+			case c == '}':
+				if len(modes) > 1 {
+					modes = modes[:len(modes)-1]
+				}
+			case strings.HasPrefix(s[i:], "provider::") && (i == 0 || !isIdentByte(s[i-1])):
+				rest := s[i+len("provider::"):]
+				end := strings.Index(rest, "::")
+				if end > 0 && isIdent(rest[:end]) {
+					buf.WriteString(providerFuncPrefix)
+					buf.WriteString(rest[:end])
+					buf.WriteByte('.')
+					i += len("provider::") + end + len("::") - 1
+					continue
+				}
+			}
+		}
+
+		buf.WriteByte(c)
+	}
+
+	return buf.String()
+}
+
+func isIdent(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if !isIdentByte(s[i]) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c == '-' ||
+		(c >= 'a' && c <= 'z') ||
+		(c >= 'A' && c <= 'Z') ||
+		(c >= '0' && c <= '9')
+}
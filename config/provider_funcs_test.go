@@ -0,0 +1,143 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/hil/ast"
+)
+
+func TestRewriteProviderFuncCalls(t *testing.T) {
+	cases := []struct {
+		Input, Output string
+	}{
+		{
+			"foo",
+			"foo",
+		},
+		{
+			"provider::aws::arn_parse(x)",
+			"provider::aws::arn_parse(x)",
+		},
+		{
+			"${provider::aws::arn_parse(var.arn)}",
+			"${provider.aws.arn_parse(var.arn)}",
+		},
+		{
+			"a ${provider::aws::foo(provider::aws::bar())} b",
+			"a ${provider.aws.foo(provider.aws.bar())} b",
+		},
+		{
+			`${provider::aws::foo("provider::aws::bar()")}`,
+			`${provider.aws.foo("provider::aws::bar()")}`,
+		},
+		{
+			`${provider::aws::foo("${provider::aws::bar()}")}`,
+			`${provider.aws.foo("${provider.aws.bar()}")}`,
+		},
+		{
+			`${upper("\"")} provider::aws::foo()`,
+			`${upper("\"")} provider::aws::foo()`,
+		},
+		{
+			"$${provider::aws::foo()}",
+			"$${provider::aws::foo()}",
+		},
+		{
+			"${true ? a :: b}",
+			"${true ? a :: b}",
+		},
+		{
+			"${myprovider::aws::foo()}",
+			"${myprovider::aws::foo()}",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Input, func(t *testing.T) {
+			got := rewriteProviderFuncCalls(tc.Input)
+			if got != tc.Output {
+				t.Fatalf("wrong result\ngot:  %s\nwant: %s", got, tc.Output)
+			}
+		})
+	}
+}
+
+func TestParseProviderFunctionName(t *testing.T) {
+	cases := []struct {
+		Input    string
+		Provider string
+		Name     string
+		OK       bool
+	}{
+		{"provider.aws.arn_parse", "aws", "arn_parse", true},
+		{"upper", "", "", false},
+		{"provider.aws", "", "", false},
+		{"provider.aws.foo.bar", "", "", false},
+		{"provider..foo", "", "", false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Input, func(t *testing.T) {
+			provider, name, ok := ParseProviderFunctionName(tc.Input)
+			if provider != tc.Provider || name != tc.Name || ok != tc.OK {
+				t.Fatalf(
+					"wrong result %q, %q, %#v; want %q, %q, %#v",
+					provider, name, ok, tc.Provider, tc.Name, tc.OK)
+			}
+		})
+	}
+
+	if got, want := ProviderFunctionName("aws", "arn_parse"), "provider.aws.arn_parse"; got != want {
+		t.Fatalf("wrong name %q; want %q", got, want)
+	}
+}
+
+func TestRawConfigInterpolateWithFuncs(t *testing.T) {
+	rc, err := NewRawConfig(map[string]interface{}{
+		"foo": `${provider::test::greet("world", upper("x"))}`,
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	calls := ProviderFunctionCalls(rc.Interpolations)
+	if want := []string{"provider.test.greet"}; !reflect.DeepEqual(calls, want) {
+		t.Fatalf("wrong calls %#v; want %#v", calls, want)
+	}
+
+	funcs := map[string]ast.Function{
+		"provider.test.greet": {
+			ArgTypes:   []ast.Type{ast.TypeString, ast.TypeString},
+			ReturnType: ast.TypeString,
+			Callback: func(args []interface{}) (interface{}, error) {
+				return "hello " + args[0].(string) + " " + args[1].(string), nil
+			},
+		},
+	}
+	if err := rc.InterpolateWithFuncs(nil, funcs); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	expected := map[string]interface{}{
+		"foo": "hello world X",
+	}
+	if !reflect.DeepEqual(rc.Config(), expected) {
+		t.Fatalf("bad: %#v", rc.Config())
+	}
+
+	// Without the functions the call must fail
+	if err := rc.Interpolate(nil); err == nil {
+		t.Fatal("should error")
+	}
+}
+
+func TestConfigProviderFunctionProviders(t *testing.T) {
+	c := testConfig(t, "provider-funcs")
+
+	got := c.ProviderFunctionProviders()
+	want := []string{"aws", "test"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong result %#v; want %#v", got, want)
+	}
+}
@@ -1,6 +1,11 @@
 package config
 
-import "github.com/blang/semver"
+import (
+	"sort"
+	"strings"
+
+	"github.com/blang/semver"
+)
 
 // ProviderVersionConstraint presents a constraint for a particular
 // provider, identified by its full name.
@@ -101,3 +106,49 @@ func (c *Config) ProviderConfigsByFullName() map[string]*ProviderConfig {
 
 	return ret
 }
+
+// RequiredProvidersByName returns a map from the local names of the
+// providers in the module's required_providers block to their requirements.
+//
+// If the same name is declared more than once then the result is undefined,
+// but that is guaranteed not to happen for any config that has passed
+// validation.
+func (c *Config) RequiredProvidersByName() map[string]*RequiredProvider {
+	if c.Terraform == nil {
+		return nil
+	}
+
+	ret := make(map[string]*RequiredProvider, len(c.Terraform.RequiredProviders))
+	for _, p := range c.Terraform.RequiredProviders {
+		ret[p.Name] = p
+	}
+
+	return ret
+}
+
+// ConfigurationAliases returns the full names of the aliased provider
+// configurations, such as "aws.west", that the module's required_providers
+// block declares it's passed by its callers, sorted.
+func (c *Config) ConfigurationAliases() []string {
+	if c.Terraform == nil {
+		return nil
+	}
+
+	var ret []string
+	for _, p := range c.Terraform.RequiredProviders {
+		ret = append(ret, p.ConfigurationAliases...)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// validProviderName returns true if name is the name of a provider
+// configuration, which is NAME or NAME.ALIAS.
+func validProviderName(name string) bool {
+	for _, part := range strings.SplitN(name, ".", 2) {
+		if !NameRegexp.MatchString(part) {
+			return false
+		}
+	}
+	return true
+}
@@ -54,6 +54,12 @@ type RawConfig struct {
 	Interpolations []ast.Node
 	Variables      map[string]InterpolatedVariable
 
+	// TryVariables are the keys of the Variables that are only referenced
+	// within the arguments of the try and can functions, which handle the
+	// errors of their arguments. Their values may therefore be left out of
+	// the variables given to Interpolate if they can't be determined.
+	TryVariables map[string]bool
+
 	lock        sync.Mutex
 	config      map[string]interface{}
 	unknownKeys []string
@@ -168,11 +174,27 @@ func (r *RawConfig) Config() map[string]interface{} {
 //
 // If a variable key is missing, this will panic.
 func (r *RawConfig) Interpolate(vs map[string]ast.Variable) error {
+	return r.InterpolateWithFuncs(vs, nil)
+}
+
+// InterpolateWithFuncs is like Interpolate but additionally makes the given
+// functions available to the expressions, alongside the built-in functions.
+// This is used to expose functions exported by providers, which are not
+// known to this package.
+func (r *RawConfig) InterpolateWithFuncs(vs map[string]ast.Variable, funcs map[string]ast.Function) error {
 	r.lock.Lock()
 	defer r.lock.Unlock()
 
 	config := langEvalConfig(vs)
+	for k, v := range funcs {
+		config.GlobalScope.FuncMap[k] = v
+	}
 	return r.interpolate(func(root ast.Node) (interface{}, error) {
+		root, err := evalTryCalls(root, config)
+		if err != nil {
+			return "", err
+		}
+
 		// None of the variables we need are computed, meaning we should
 		// be able to properly evaluate.
 		result, err := hil.Eval(root, config)
@@ -241,13 +263,17 @@ func (r *RawConfig) init() error {
 	r.config = r.Raw
 	r.Interpolations = nil
 	r.Variables = nil
+	r.TryVariables = nil
 
+	inTry := make(map[string]bool)
+	outside := make(map[string]bool)
 	fn := func(node ast.Node) (interface{}, error) {
 		r.Interpolations = append(r.Interpolations, node)
 		vars, err := DetectVariables(node)
 		if err != nil {
 			return "", err
 		}
+		tryOnlyVariables(node, inTry, outside)
 
 		for _, v := range vars {
 			if r.Variables == nil {
@@ -265,6 +291,16 @@ func (r *RawConfig) init() error {
 		return err
 	}
 
+	for k := range inTry {
+		if outside[k] {
+			continue
+		}
+		if r.TryVariables == nil {
+			r.TryVariables = make(map[string]bool)
+		}
+		r.TryVariables[k] = true
+	}
+
 	return nil
 }
 
@@ -0,0 +1,19 @@
+resource "aws_instance" "web" {
+  ami = "ami-123"
+}
+
+check "health" {
+  data "http" "web" {
+    url = "https://${aws_instance.web.public_ip}/health"
+  }
+
+  assert {
+    condition     = "${data.http.web.status_code == 200}"
+    error_message = "The web server is not healthy."
+  }
+
+  assert {
+    condition     = "${aws_instance.web.ami != ""}"
+    error_message = "The web server has no image."
+  }
+}
@@ -0,0 +1,21 @@
+variable "region" {}
+
+provider "aws" {
+  region = "${var.region}"
+}
+
+module "network" {
+  source = "./network"
+}
+
+resource "aws_instance" "web" {}
+
+data "aws_ami" "ubuntu" {}
+
+locals {
+  name = "web"
+}
+
+output "ip" {
+  value = "${aws_instance.web.private_ip}"
+}
@@ -0,0 +1,45 @@
+variable "a" {}
+
+variable "b" {
+  default = "b"
+}
+
+provider "aws" {
+  alias  = "east"
+  region = "us-east-1"
+}
+
+provider "aws" {
+  alias  = "west"
+  region = "us-west-2"
+}
+
+resource "aws_instance" "web" {
+  ami = "ami-123"
+
+  lifecycle {
+    prevent_destroy = true
+  }
+
+  provisioner "local-exec" {
+    command = "echo one"
+  }
+
+  provisioner "local-exec" {
+    command = "echo two"
+  }
+}
+
+resource "aws_instance" "db" {
+  ami = "ami-456"
+}
+
+moved {
+  from = "aws_instance.old"
+  to   = "aws_instance.web"
+}
+
+moved {
+  from = "aws_instance.older"
+  to   = "aws_instance.db"
+}
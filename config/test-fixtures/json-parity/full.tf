@@ -0,0 +1,75 @@
+variable "region" {
+  default     = "us-east-1"
+  description = "The region"
+}
+
+variable "zones" {
+  type    = "list"
+  default = ["a", "b"]
+}
+
+variable "tags" {
+  type = "map"
+  default = {
+    env = "prod"
+  }
+}
+
+provider "aws" {
+  region = "${var.region}"
+  alias  = "east"
+}
+
+module "network" {
+  source = "./network"
+  cidr   = "10.0.0.0/16"
+}
+
+locals {
+  name = "web-${var.region}"
+}
+
+resource "aws_instance" "web" {
+  count    = 2
+  ami      = "ami-123"
+  provider = "aws.east"
+
+  tags {
+    Name = "${local.name}"
+  }
+
+  lifecycle {
+    create_before_destroy = true
+    ignore_changes        = ["tags"]
+  }
+
+  connection {
+    user = "root"
+  }
+
+  provisioner "local-exec" {
+    command = "echo ${self.id}"
+  }
+
+  provisioner "remote-exec" {
+    inline = ["true"]
+    when   = "destroy"
+  }
+
+  depends_on = ["module.network"]
+}
+
+data "aws_ami" "ubuntu" {
+  most_recent = true
+}
+
+output "ip" {
+  value       = "${aws_instance.web.*.private_ip}"
+  description = "The IPs"
+  sensitive   = true
+}
+
+moved {
+  from = "aws_instance.old"
+  to   = "aws_instance.web"
+}
@@ -0,0 +1,3 @@
+variable "region" {
+  defualt = "us-east-1"
+}
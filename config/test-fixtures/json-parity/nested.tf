@@ -0,0 +1,24 @@
+locals {
+  tags = {
+    env = "prod"
+  }
+}
+
+data "aws_ami" "ubuntu" {
+  filter {
+    name   = "name"
+    values = ["ubuntu-*"]
+  }
+}
+
+resource "aws_instance" "web" {
+  tags {
+    Name = "web"
+  }
+}
+
+output "tags" {
+  value = {
+    env = "prod"
+  }
+}
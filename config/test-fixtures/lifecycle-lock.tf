@@ -0,0 +1,7 @@
+resource "aws_instance" "locked" {
+  lifecycle {
+    lock = true
+  }
+}
+
+resource "aws_instance" "unlocked" {}
@@ -0,0 +1,7 @@
+variable "region" {}
+variable "size" {}
+
+module "grandchild" {
+  source = "../grandchild"
+  name   = "${var.region}"
+}
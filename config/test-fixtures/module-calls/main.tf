@@ -0,0 +1,18 @@
+module "child" {
+  source = "./child"
+  region = "us-east-1"
+  size   = 2
+}
+
+module "consul" {
+  source  = "hashicorp/consul/aws"
+  version = "~> 0.1"
+
+  providers = {
+    aws = "aws.west"
+  }
+}
+
+provider "aws" {
+  alias = "west"
+}
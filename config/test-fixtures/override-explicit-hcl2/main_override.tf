@@ -0,0 +1,13 @@
+#terraform:hcl2
+
+resource "aws_instance" "web" {
+  count = 1
+
+  lifecycle {
+    create_before_destroy = false
+  }
+}
+
+output "ip" {
+  sensitive = false
+}
@@ -0,0 +1,13 @@
+resource "aws_instance" "web" {
+  count = 3
+
+  lifecycle {
+    create_before_destroy = true
+    prevent_destroy       = true
+  }
+}
+
+output "ip" {
+  value     = "${aws_instance.web.0.private_ip}"
+  sensitive = true
+}
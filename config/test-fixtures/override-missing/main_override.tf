@@ -0,0 +1,7 @@
+resource "aws_instance" "web" {
+  ami = "ami-override"
+}
+
+resource "aws_instance" "wbe" {
+  ami = "ami-override"
+}
@@ -0,0 +1,4 @@
+moved {
+  from = "aws_instance.old"
+  to   = "aws_instance.web"
+}
@@ -0,0 +1,59 @@
+terraform {
+  required_version = ">= 0.10.0"
+
+  required_providers {
+    aws     = "~> 1.0"
+    widgets = ">= 1.0"
+  }
+
+  backend "s3" {
+    bucket = "base"
+    key    = "base.tfstate"
+  }
+}
+
+variable "region" {
+  default     = "us-east-1"
+  description = "The region"
+}
+
+locals {
+  name = "base"
+  env  = "prod"
+}
+
+provider "aws" {
+  region = "us-east-1"
+}
+
+provider "aws" {
+  alias  = "west"
+  region = "us-west-1"
+}
+
+module "network" {
+  source = "./network"
+  cidr   = "10.0.0.0/16"
+}
+
+resource "aws_instance" "web" {
+  ami           = "ami-base"
+  instance_type = "t2.micro"
+
+  network_interface {
+    device_index = 0
+  }
+
+  network_interface {
+    device_index = 1
+  }
+
+  lifecycle {
+    create_before_destroy = true
+  }
+}
+
+output "ip" {
+  value       = "${aws_instance.web.private_ip}"
+  description = "The IP"
+}
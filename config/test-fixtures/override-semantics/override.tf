@@ -0,0 +1,46 @@
+terraform {
+  required_providers {
+    widgets = {
+      source  = "example.com/acme/widgets"
+      version = ">= 2.0"
+    }
+  }
+
+  backend "local" {
+    path = "override.tfstate"
+  }
+}
+
+variable "region" {
+  default = "eu-west-1"
+}
+
+locals {
+  name = "override"
+}
+
+provider "aws" {
+  alias  = "west"
+  region = "us-west-2"
+}
+
+module "network" {
+  version = "1.2.0"
+}
+
+resource "aws_instance" "web" {
+  ami        = "ami-override"
+  depends_on = ["module.network"]
+
+  network_interface {
+    device_index = 2
+  }
+
+  lifecycle {
+    prevent_destroy = true
+  }
+}
+
+output "ip" {
+  sensitive = true
+}
@@ -0,0 +1,13 @@
+locals {
+  account = "${provider::aws::arn_account(var.arn)}"
+}
+
+resource "null_resource" "foo" {
+  triggers = {
+    greeting = "${provider::test::greet("world")}"
+  }
+}
+
+output "region" {
+  value = "${provider::aws::arn_region(var.arn)}"
+}
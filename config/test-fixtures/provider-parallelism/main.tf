@@ -0,0 +1,9 @@
+provider "aws" {
+  a = "a"
+}
+
+provider "aws" {
+  alias       = "west"
+  parallelism = 2
+  a           = "b"
+}
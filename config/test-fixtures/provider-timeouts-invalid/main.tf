@@ -0,0 +1,5 @@
+provider "aws" {
+	timeouts {
+		plan = "soon"
+	}
+}
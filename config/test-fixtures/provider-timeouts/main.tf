@@ -0,0 +1,8 @@
+provider "aws" {
+  a = "a"
+
+  timeouts {
+    read  = "2m"
+    apply = "1h"
+  }
+}
@@ -0,0 +1,12 @@
+terraform {
+  required_providers {
+    aws = "~> 1.0"
+
+    widgets = {
+      source  = "example.com/acme/widgets"
+      version = ">= 2.0"
+
+      configuration_aliases = ["widgets.east", "widgets.west"]
+    }
+  }
+}
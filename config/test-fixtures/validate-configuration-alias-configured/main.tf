@@ -0,0 +1,11 @@
+terraform {
+  required_providers {
+    aws = {
+      configuration_aliases = ["aws.west"]
+    }
+  }
+}
+
+provider "aws" {
+  alias = "west"
+}
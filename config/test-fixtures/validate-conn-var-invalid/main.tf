@@ -0,0 +1,7 @@
+resource "aws_instance" "web" {
+  provisioner "shell" {
+    connection {
+      port = "${count.foo}"
+    }
+  }
+}
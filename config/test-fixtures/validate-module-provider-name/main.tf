@@ -0,0 +1,8 @@
+provider "aws" {}
+
+module "child" {
+  source = "./child"
+  providers = {
+    "aws.west.east" = "aws"
+  }
+}
@@ -0,0 +1,7 @@
+resource "aws_instance" "foo" {}
+resource "aws_instance" "bar" {}
+
+moved {
+  from = "aws_instance.foo"
+  to   = "aws_instance.bar"
+}
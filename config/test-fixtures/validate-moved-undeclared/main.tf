@@ -0,0 +1,6 @@
+resource "aws_instance" "bar" {}
+
+moved {
+  from = "aws_instance.foo"
+  to   = "aws_instance.baz"
+}
@@ -0,0 +1,9 @@
+terraform {
+  provider_meta "aws" {
+    module_name = "foo"
+  }
+
+  provider_meta "aws" {
+    module_name = "bar"
+  }
+}
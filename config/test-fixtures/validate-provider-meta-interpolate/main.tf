@@ -0,0 +1,7 @@
+variable "var" {}
+
+terraform {
+  provider_meta "aws" {
+    module_name = "${var.var}"
+  }
+}
@@ -0,0 +1,4 @@
+provider "aws" {
+  alias       = "west"
+  parallelism = -1
+}
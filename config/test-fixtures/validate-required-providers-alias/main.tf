@@ -0,0 +1,7 @@
+terraform {
+  required_providers {
+    aws = {
+      configuration_aliases = ["google.west"]
+    }
+  }
+}
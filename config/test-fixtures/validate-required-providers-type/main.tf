@@ -0,0 +1,7 @@
+terraform {
+  required_providers {
+    widgets = {
+      source = "acme/gadgets"
+    }
+  }
+}
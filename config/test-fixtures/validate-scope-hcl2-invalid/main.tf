@@ -0,0 +1,21 @@
+provider "aws" {
+  region = self.region
+}
+
+resource "aws_instance" "web" {
+  count = count.index
+  ami   = self.ami
+  name  = each.key
+
+  provisioner "local-exec" {
+    command = "echo ${count.foo}"
+  }
+}
+
+locals {
+  index = count.index
+}
+
+output "ip" {
+  value = self.public_ip
+}
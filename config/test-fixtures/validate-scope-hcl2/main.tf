@@ -0,0 +1,17 @@
+resource "aws_instance" "web" {
+  count = 2
+  ami   = "ami-${count.index}"
+
+  connection {
+    host = self.public_ip
+  }
+
+  provisioner "local-exec" {
+    command = "echo ${self.private_ip} ${count.index}"
+
+    connection {
+      host = self.private_ip
+      port = 2200 + count.index
+    }
+  }
+}
@@ -0,0 +1,6 @@
+variable "zone" {
+  validation {
+    condition     = "${var.zone != ""}"
+    error_message = "zone must be set"
+  }
+}
@@ -0,0 +1,8 @@
+variable "zone" {
+  validation {
+    condition     = "${var.zone != var.region}"
+    error_message = "The zone must not be the region."
+  }
+}
+
+variable "region" {}
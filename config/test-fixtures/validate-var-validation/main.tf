@@ -0,0 +1,6 @@
+variable "zone" {
+  validation {
+    condition     = "${contains(list("a", "b"), var.zone)}"
+    error_message = "The zone must be a or b."
+  }
+}
@@ -0,0 +1,7 @@
+variable "service" {
+  type = "object({name = string, port = optional(number, 80)})"
+
+  default = {
+    port = 8080
+  }
+}
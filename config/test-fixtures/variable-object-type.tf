@@ -0,0 +1,21 @@
+variable "service" {
+  type = <<EOT
+object({
+  name = string
+  port = optional(number, 80)
+})
+EOT
+
+  default = {
+    name = "web"
+  }
+}
+
+variable "zones" {
+  type = "list(object({name = string, primary = optional(bool, false)}))"
+
+  default = [
+    { name = "a", primary = true },
+    { name = "b" },
+  ]
+}
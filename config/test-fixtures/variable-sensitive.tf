@@ -0,0 +1,5 @@
+variable "password" {
+  sensitive = true
+}
+
+variable "name" {}
@@ -0,0 +1,13 @@
+variable "image_id" {
+  type = "string"
+
+  validation {
+    condition     = "${length(var.image_id) > 4 && substr(var.image_id, 0, 4) == "ami-"}"
+    error_message = "The image_id value must be a valid AMI id, starting with \"ami-\"."
+  }
+
+  validation {
+    condition     = "${var.image_id != "ami-bad"}"
+    error_message = "The image ami-bad is deprecated."
+  }
+}
@@ -0,0 +1,655 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// TypeKind is the kind of a type in a type constraint.
+type TypeKind byte
+
+const (
+	TypeKindAny TypeKind = iota
+	TypeKindString
+	TypeKindNumber
+	TypeKindBool
+	TypeKindList
+	TypeKindSet
+	TypeKindMap
+	TypeKindObject
+)
+
+// TypeConstraint is the type that the values of a variable must have,
+// parsed from the "type" argument of its declaration, such as
+// "list(string)" or "object({name = string, port = optional(number, 80)})".
+//
+// Values are primitives, lists and maps as they are everywhere else in
+// Terraform: numbers and bools are kept as strings, sets as lists without
+// duplicates and objects as maps.
+type TypeConstraint struct {
+	Kind TypeKind
+
+	// ElementType is the type of the elements of a list, set or map.
+	ElementType *TypeConstraint
+
+	// Attributes are the attributes of an object, keyed by their names.
+	Attributes map[string]*ObjectAttribute
+}
+
+// ObjectAttribute is an attribute of an object type constraint.
+type ObjectAttribute struct {
+	Type *TypeConstraint
+
+	// Optional is true for attributes declared with optional(type) or
+	// optional(type, default), which values don't need to set. Default is
+	// then the value that they get instead, or nil if they get none and
+	// are left out.
+	Optional bool
+	Default  interface{}
+}
+
+// ParseTypeConstraint parses a type constraint.
+//
+// The primitive types are string, number and bool, and any allows values
+// of any type. The collection types are list(T), set(T) and map(T), with
+// list, set and map alone meaning list(any), set(any) and map(any). An
+// object type lists the types of its attributes, as in
+// object({name = string, port = optional(number, 80)}), where the default
+// value of an optional attribute may be a string, number, bool, list or
+// map literal.
+func ParseTypeConstraint(s string) (*TypeConstraint, error) {
+	p := &typeParser{src: s}
+	t, err := p.parseType()
+	if err != nil {
+		return nil, err
+	}
+	if p.skipSpace(); p.pos < len(p.src) {
+		return nil, p.errorf("unexpected %q after the type", p.src[p.pos:])
+	}
+	return t, nil
+}
+
+var typeKindNames = map[string]TypeKind{
+	"any":    TypeKindAny,
+	"string": TypeKindString,
+	"number": TypeKindNumber,
+	"bool":   TypeKindBool,
+	"list":   TypeKindList,
+	"set":    TypeKindSet,
+	"map":    TypeKindMap,
+	"object": TypeKindObject,
+}
+
+// anyType is the type of values of any type.
+var anyType = &TypeConstraint{Kind: TypeKindAny}
+
+// VariableType returns the kind of value that a value of type t is in
+// the interpolation language.
+func (t *TypeConstraint) VariableType() VariableType {
+	switch t.Kind {
+	case TypeKindString, TypeKindNumber, TypeKindBool:
+		return VariableTypeString
+	case TypeKindList, TypeKindSet:
+		return VariableTypeList
+	case TypeKindMap, TypeKindObject:
+		return VariableTypeMap
+	default:
+		return VariableTypeUnknown
+	}
+}
+
+// String returns t in the syntax that ParseTypeConstraint parses.
+func (t *TypeConstraint) String() string {
+	switch t.Kind {
+	case TypeKindString:
+		return "string"
+	case TypeKindNumber:
+		return "number"
+	case TypeKindBool:
+		return "bool"
+	case TypeKindList:
+		return fmt.Sprintf("list(%s)", t.ElementType)
+	case TypeKindSet:
+		return fmt.Sprintf("set(%s)", t.ElementType)
+	case TypeKindMap:
+		return fmt.Sprintf("map(%s)", t.ElementType)
+	case TypeKindObject:
+		attrs := make([]string, 0, len(t.Attributes))
+		for _, name := range t.attributeNames() {
+			attr := t.Attributes[name]
+			switch {
+			case !attr.Optional:
+				attrs = append(attrs, fmt.Sprintf("%s = %s", name, attr.Type))
+			case attr.Default == nil:
+				attrs = append(attrs, fmt.Sprintf("%s = optional(%s)", name, attr.Type))
+			default:
+				attrs = append(attrs, fmt.Sprintf("%s = optional(%s, %s)", name, attr.Type, attr.Type.literalString(attr.Default)))
+			}
+		}
+		return fmt.Sprintf("object({%s})", strings.Join(attrs, ", "))
+	default:
+		return "any"
+	}
+}
+
+func (t *TypeConstraint) attributeNames() []string {
+	names := make([]string, 0, len(t.Attributes))
+	for name := range t.Attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Convert returns v converted to type t, or an error saying where in v a
+// value of the wrong type is. Primitive values are converted to the
+// strings that represent them, and the optional attributes that objects
+// don't set get their default values.
+//
+// Unknown values are of any type, so they're returned as they are.
+func (t *TypeConstraint) Convert(v interface{}) (interface{}, error) {
+	return t.convert(v, "")
+}
+
+func (t *TypeConstraint) convert(v interface{}, path string) (interface{}, error) {
+	if v == UnknownVariableValue {
+		return v, nil
+	}
+	if v == nil {
+		return nil, typeError(path, "a value is required")
+	}
+
+	switch t.Kind {
+	case TypeKindAny:
+		return v, nil
+
+	case TypeKindString:
+		switch v := v.(type) {
+		case string:
+			return v, nil
+		case bool:
+			return strconv.FormatBool(v), nil
+		}
+		if s, ok := numberString(v); ok {
+			return s, nil
+		}
+		return nil, typeError(path, "a string is required, got %s", typeName(v))
+
+	case TypeKindNumber:
+		if s, ok := v.(string); ok {
+			f, err := strconv.ParseFloat(s, 64)
+			if err != nil {
+				return nil, typeError(path, "a number is required, got %q", s)
+			}
+			return strconv.FormatFloat(f, 'f', -1, 64), nil
+		}
+		if s, ok := numberString(v); ok {
+			return s, nil
+		}
+		return nil, typeError(path, "a number is required, got %s", typeName(v))
+
+	case TypeKindBool:
+		switch v := v.(type) {
+		case bool:
+			return strconv.FormatBool(v), nil
+		case string:
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return nil, typeError(path, "a bool is required, got %q", v)
+			}
+			return strconv.FormatBool(b), nil
+		}
+		return nil, typeError(path, "a bool is required, got %s", typeName(v))
+
+	case TypeKindList, TypeKindSet:
+		rv := reflect.ValueOf(v)
+		if rv.Kind() != reflect.Slice {
+			return nil, typeError(path, "a %s is required, got %s", typeKindName(t.Kind), typeName(v))
+		}
+		result := make([]interface{}, 0, rv.Len())
+	Elements:
+		for i := 0; i < rv.Len(); i++ {
+			elem, err := t.ElementType.convert(rv.Index(i).Interface(), fmt.Sprintf("%s[%d]", path, i))
+			if err != nil {
+				return nil, err
+			}
+			if t.Kind == TypeKindSet {
+				for _, existing := range result {
+					if reflect.DeepEqual(existing, elem) {
+						continue Elements
+					}
+				}
+			}
+			result = append(result, elem)
+		}
+		return result, nil
+
+	case TypeKindMap:
+		m, ok := mapValue(v)
+		if !ok {
+			return nil, typeError(path, "a map is required, got %s", typeName(v))
+		}
+		result := make(map[string]interface{}, len(m))
+		for k, elem := range m {
+			elem, err := t.ElementType.convert(elem, fmt.Sprintf("%s[%q]", path, k))
+			if err != nil {
+				return nil, err
+			}
+			result[k] = elem
+		}
+		return result, nil
+
+	case TypeKindObject:
+		m, ok := mapValue(v)
+		if !ok {
+			return nil, typeError(path, "an object is required, got %s", typeName(v))
+		}
+		// Attributes that the type doesn't declare are dropped, like the
+		// keys of a map that a module doesn't look up.
+		result := make(map[string]interface{}, len(t.Attributes))
+		for _, name := range t.attributeNames() {
+			attr := t.Attributes[name]
+			attrPath := fmt.Sprintf("%s.%s", path, name)
+			value, ok := m[name]
+			if !ok || value == nil {
+				if !attr.Optional {
+					return nil, typeError(path, "attribute %q is required", name)
+				}
+				if attr.Default == nil {
+					continue
+				}
+				value = attr.Default
+			}
+			value, err := attr.Type.convert(value, attrPath)
+			if err != nil {
+				return nil, err
+			}
+			result[name] = value
+		}
+		return result, nil
+	}
+
+	return nil, fmt.Errorf("unknown type kind %d", t.Kind)
+}
+
+// mapValue returns v as a map, which HCL may have decoded as a list of one
+// map.
+func mapValue(v interface{}) (map[string]interface{}, bool) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return v, true
+	case []map[string]interface{}:
+		if len(v) == 1 {
+			return v[0], true
+		}
+	case []interface{}:
+		if len(v) == 1 {
+			m, ok := v[0].(map[string]interface{})
+			return m, ok
+		}
+	}
+	return nil, false
+}
+
+// numberString returns the string that represents v if it's a number.
+func numberString(v interface{}) (string, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(rv.Uint(), 10), true
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(rv.Float(), 'f', -1, 64), true
+	}
+	return "", false
+}
+
+// typeName returns the name of the type of a value, for error messages.
+func typeName(v interface{}) string {
+	if _, ok := mapValue(v); ok {
+		return "a map"
+	}
+	switch reflect.ValueOf(v).Kind() {
+	case reflect.Slice, reflect.Array:
+		return "a list"
+	case reflect.Map:
+		return "a map"
+	case reflect.Bool:
+		return "a bool"
+	case reflect.String:
+		return "a string"
+	}
+	if _, ok := numberString(v); ok {
+		return "a number"
+	}
+	return fmt.Sprintf("a value of type %T", v)
+}
+
+func typeKindName(kind TypeKind) string {
+	for name, k := range typeKindNames {
+		if k == kind {
+			return name
+		}
+	}
+	return "value"
+}
+
+// typeError returns an error about the value at path, which is empty for
+// the value being converted itself.
+func typeError(path string, format string, args ...interface{}) error {
+	msg := fmt.Sprintf(format, args...)
+	if path == "" {
+		return fmt.Errorf("%s", msg)
+	}
+	return fmt.Errorf("%s: %s", strings.TrimPrefix(path, "."), msg)
+}
+
+// literalString returns a value of type t in the syntax of the literals
+// of type constraints, where numbers and bools aren't quoted.
+func (t *TypeConstraint) literalString(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		if t.Kind == TypeKindNumber || t.Kind == TypeKindBool {
+			return v
+		}
+		return strconv.Quote(v)
+	case []interface{}:
+		elems := make([]string, len(v))
+		for i, elem := range v {
+			elems[i] = t.elementType("").literalString(elem)
+		}
+		return fmt.Sprintf("[%s]", strings.Join(elems, ", "))
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		elems := make([]string, len(keys))
+		for i, k := range keys {
+			elems[i] = fmt.Sprintf("%s = %s", strconv.Quote(k), t.elementType(k).literalString(v[k]))
+		}
+		return fmt.Sprintf("{%s}", strings.Join(elems, ", "))
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// elementType returns the type of the elements of a collection of type
+// t, or of its attribute with the given name if it's an object.
+func (t *TypeConstraint) elementType(name string) *TypeConstraint {
+	if t.ElementType != nil {
+		return t.ElementType
+	}
+	if attr, ok := t.Attributes[name]; ok {
+		return attr.Type
+	}
+	return anyType
+}
+
+// typeParser is a recursive descent parser of type constraints.
+type typeParser struct {
+	src string
+	pos int
+}
+
+func (p *typeParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("invalid type %q: %s", p.src, fmt.Sprintf(format, args...))
+}
+
+func (p *typeParser) skipSpace() {
+	for p.pos < len(p.src) && unicode.IsSpace(rune(p.src[p.pos])) {
+		p.pos++
+	}
+}
+
+// peek returns the next character after any whitespace, or 0 at the end.
+func (p *typeParser) peek() byte {
+	p.skipSpace()
+	if p.pos >= len(p.src) {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *typeParser) expect(c byte) error {
+	if p.peek() != c {
+		if p.pos >= len(p.src) {
+			return p.errorf("expected %q, got the end of the type", c)
+		}
+		return p.errorf("expected %q at %q", c, p.src[p.pos:])
+	}
+	p.pos++
+	return nil
+}
+
+func (p *typeParser) ident() string {
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.src) {
+		c := rune(p.src[p.pos])
+		if !unicode.IsLetter(c) && !unicode.IsDigit(c) && c != '_' && c != '-' {
+			break
+		}
+		p.pos++
+	}
+	return p.src[start:p.pos]
+}
+
+func (p *typeParser) parseType() (*TypeConstraint, error) {
+	name := p.ident()
+	if name == "" {
+		if p.pos >= len(p.src) {
+			return nil, p.errorf("expected a type, got the end of the type")
+		}
+		return nil, p.errorf("expected a type at %q", p.src[p.pos:])
+	}
+	kind, ok := typeKindNames[name]
+	if !ok {
+		return nil, p.errorf("unknown type %q", name)
+	}
+
+	t := &TypeConstraint{Kind: kind}
+	switch kind {
+	case TypeKindList, TypeKindSet, TypeKindMap:
+		t.ElementType = &TypeConstraint{Kind: TypeKindAny}
+		if p.peek() != '(' {
+			return t, nil
+		}
+		p.pos++
+		elem, err := p.parseType()
+		if err != nil {
+			return nil, err
+		}
+		t.ElementType = elem
+		if err := p.expect(')'); err != nil {
+			return nil, err
+		}
+	case TypeKindObject:
+		if err := p.expect('('); err != nil {
+			return nil, err
+		}
+		attrs, err := p.parseAttributes()
+		if err != nil {
+			return nil, err
+		}
+		t.Attributes = attrs
+		if err := p.expect(')'); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// parseAttributes parses the attributes of an object type, which are
+// separated by commas or new lines.
+func (p *typeParser) parseAttributes() (map[string]*ObjectAttribute, error) {
+	if err := p.expect('{'); err != nil {
+		return nil, err
+	}
+	attrs := make(map[string]*ObjectAttribute)
+	for {
+		if p.peek() == ',' {
+			p.pos++
+			continue
+		}
+		if p.peek() == '}' {
+			p.pos++
+			return attrs, nil
+		}
+
+		name := p.ident()
+		if name == "" {
+			return nil, p.errorf("expected an attribute name or '}'")
+		}
+		if _, ok := attrs[name]; ok {
+			return nil, p.errorf("attribute %q is declared more than once", name)
+		}
+		if err := p.expect('='); err != nil {
+			return nil, err
+		}
+		attr, err := p.parseAttribute()
+		if err != nil {
+			return nil, err
+		}
+		attrs[name] = attr
+	}
+}
+
+func (p *typeParser) parseAttribute() (*ObjectAttribute, error) {
+	start := p.pos
+	if p.ident() != "optional" || p.peek() != '(' {
+		p.pos = start
+		t, err := p.parseType()
+		if err != nil {
+			return nil, err
+		}
+		return &ObjectAttribute{Type: t}, nil
+	}
+	p.pos++
+
+	t, err := p.parseType()
+	if err != nil {
+		return nil, err
+	}
+	attr := &ObjectAttribute{Type: t, Optional: true}
+	if p.peek() == ',' {
+		p.pos++
+		def, err := p.parseLiteral()
+		if err != nil {
+			return nil, err
+		}
+		if attr.Default, err = t.Convert(def); err != nil {
+			return nil, p.errorf("invalid default value %s: %s", anyType.literalString(def), err)
+		}
+	}
+	if err := p.expect(')'); err != nil {
+		return nil, err
+	}
+	return attr, nil
+}
+
+// parseLiteral parses the default value of an optional attribute.
+func (p *typeParser) parseLiteral() (interface{}, error) {
+	switch c := p.peek(); {
+	case c == '"':
+		return p.parseString()
+	case c == '[':
+		p.pos++
+		list := []interface{}{}
+		for {
+			if p.peek() == ',' {
+				p.pos++
+				continue
+			}
+			if p.peek() == ']' {
+				p.pos++
+				return list, nil
+			}
+			elem, err := p.parseLiteral()
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, elem)
+		}
+	case c == '{':
+		p.pos++
+		m := map[string]interface{}{}
+		for {
+			if p.peek() == ',' {
+				p.pos++
+				continue
+			}
+			if p.peek() == '}' {
+				p.pos++
+				return m, nil
+			}
+			var key string
+			var err error
+			if p.peek() == '"' {
+				key, err = p.parseString()
+				if err != nil {
+					return nil, err
+				}
+			} else if key = p.ident(); key == "" {
+				return nil, p.errorf("expected a key or '}'")
+			}
+			if c := p.peek(); c != '=' && c != ':' {
+				return nil, p.errorf("expected '=' after key %q", key)
+			}
+			p.pos++
+			value, err := p.parseLiteral()
+			if err != nil {
+				return nil, err
+			}
+			m[key] = value
+		}
+	case c == '-' || (c >= '0' && c <= '9'):
+		start := p.pos
+		p.pos++
+		for p.pos < len(p.src) && strings.IndexByte("0123456789.eE+-", p.src[p.pos]) >= 0 {
+			p.pos++
+		}
+		if _, err := strconv.ParseFloat(p.src[start:p.pos], 64); err != nil {
+			return nil, p.errorf("invalid number %q", p.src[start:p.pos])
+		}
+		return p.src[start:p.pos], nil
+	}
+
+	switch word := p.ident(); word {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "":
+		return nil, p.errorf("expected a default value")
+	default:
+		return nil, p.errorf("invalid default value %q; strings must be quoted", word)
+	}
+}
+
+func (p *typeParser) parseString() (string, error) {
+	start := p.pos
+	p.pos++
+	for p.pos < len(p.src) {
+		switch p.src[p.pos] {
+		case '\\':
+			p.pos += 2
+			continue
+		case '"':
+			p.pos++
+			s, err := strconv.Unquote(p.src[start:p.pos])
+			if err != nil {
+				return "", p.errorf("invalid string %s", p.src[start:p.pos])
+			}
+			return s, nil
+		}
+		p.pos++
+	}
+	return "", p.errorf("unterminated string %s", p.src[start:])
+}
@@ -0,0 +1,230 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseTypeConstraint(t *testing.T) {
+	tests := map[string]struct {
+		Input string
+		Want  string
+		Err   string
+	}{
+		"primitive": {
+			"number",
+			"number",
+			"",
+		},
+		"bare collection": {
+			"list",
+			"list(any)",
+			"",
+		},
+		"nested collections": {
+			"map( set(bool) )",
+			"map(set(bool))",
+			"",
+		},
+		"object": {
+			`object({name = string, port = optional(number, 80), tags = optional(map(string))})`,
+			`object({name = string, port = optional(number, 80), tags = optional(map(string))})`,
+			"",
+		},
+		"object on many lines": {
+			"object({\n  name = string\n  tier = optional(string, \"web\")\n})",
+			`object({name = string, tier = optional(string, "web")})`,
+			"",
+		},
+		"default collections": {
+			`object({zones = optional(list(string), ["a", "b"]), labels = optional(map(bool), {public = true})})`,
+			`object({labels = optional(map(bool), {"public" = true}), zones = optional(list(string), ["a", "b"])})`,
+			"",
+		},
+		"nested default": {
+			`object({db = optional(object({size = optional(number, 10)}), {})})`,
+			`object({db = optional(object({size = optional(number, 10)}), {"size" = 10})})`,
+			"",
+		},
+		"unknown type": {
+			"list(strings)",
+			"",
+			`unknown type "strings"`,
+		},
+		"unclosed": {
+			"list(string",
+			"",
+			"expected ')', got the end of the type",
+		},
+		"trailing": {
+			"string string",
+			"",
+			`unexpected "string" after the type`,
+		},
+		"object without attributes": {
+			"object",
+			"",
+			"expected '('",
+		},
+		"duplicate attribute": {
+			"object({a = string, a = number})",
+			"",
+			`attribute "a" is declared more than once`,
+		},
+		"unquoted default": {
+			"object({a = optional(string, web)})",
+			"",
+			"strings must be quoted",
+		},
+		"wrong default": {
+			`object({a = optional(number, "many")})`,
+			"",
+			`invalid default value "many": a number is required`,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := ParseTypeConstraint(tc.Input)
+			if tc.Err != "" {
+				if err == nil {
+					t.Fatalf("expected error, got %s", got)
+				}
+				if !strings.Contains(err.Error(), tc.Err) {
+					t.Fatalf("wrong error\ngot:  %s\nwant: %s", err, tc.Err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if got.String() != tc.Want {
+				t.Fatalf("wrong result\ngot:  %s\nwant: %s", got, tc.Want)
+			}
+		})
+	}
+}
+
+func TestTypeConstraintConvert(t *testing.T) {
+	tests := map[string]struct {
+		Type  string
+		Input interface{}
+		Want  interface{}
+		Err   string
+	}{
+		"number from int": {
+			"number",
+			80,
+			"80",
+			"",
+		},
+		"number from string": {
+			"number",
+			"1.500000",
+			"1.5",
+			"",
+		},
+		"bool": {
+			"bool",
+			"1",
+			"true",
+			"",
+		},
+		"not a number": {
+			"number",
+			"many",
+			nil,
+			`a number is required, got "many"`,
+		},
+		"set": {
+			"set(string)",
+			[]interface{}{"a", "b", "a"},
+			[]interface{}{"a", "b"},
+			"",
+		},
+		"map from HCL": {
+			"map(number)",
+			[]map[string]interface{}{{"a": 1}},
+			map[string]interface{}{"a": "1"},
+			"",
+		},
+		"unknown": {
+			"list(string)",
+			[]interface{}{UnknownVariableValue},
+			[]interface{}{UnknownVariableValue},
+			"",
+		},
+		"optional attributes": {
+			`object({name = string, port = optional(number, 80), tags = optional(map(string))})`,
+			map[string]interface{}{"name": "web", "extra": "dropped"},
+			map[string]interface{}{"name": "web", "port": "80"},
+			"",
+		},
+		"optional attribute set": {
+			`object({name = string, port = optional(number, 80)})`,
+			map[string]interface{}{"name": "web", "port": 8080},
+			map[string]interface{}{"name": "web", "port": "8080"},
+			"",
+		},
+		"nested defaults": {
+			`list(object({name = string, db = optional(object({size = optional(number, 10)}), {})}))`,
+			[]map[string]interface{}{
+				{"name": "a"},
+				{"name": "b", "db": []map[string]interface{}{{"size": 20}}},
+			},
+			[]interface{}{
+				map[string]interface{}{"name": "a", "db": map[string]interface{}{"size": "10"}},
+				map[string]interface{}{"name": "b", "db": map[string]interface{}{"size": "20"}},
+			},
+			"",
+		},
+		"missing attribute": {
+			`list(object({name = string}))`,
+			[]interface{}{map[string]interface{}{"name": "a"}, map[string]interface{}{}},
+			nil,
+			`[1]: attribute "name" is required`,
+		},
+		"wrong attribute type": {
+			`object({servers = map(object({port = number}))})`,
+			map[string]interface{}{
+				"servers": map[string]interface{}{
+					"web": map[string]interface{}{"port": []interface{}{}},
+				},
+			},
+			nil,
+			`servers["web"].port: a number is required, got a list`,
+		},
+		"not an object": {
+			`object({name = string})`,
+			"web",
+			nil,
+			"an object is required, got a string",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			ty, err := ParseTypeConstraint(tc.Type)
+			if err != nil {
+				t.Fatalf("unexpected error parsing type: %s", err)
+			}
+			got, err := ty.Convert(tc.Input)
+			if tc.Err != "" {
+				if err == nil {
+					t.Fatalf("expected error, got %#v", got)
+				}
+				if err.Error() != tc.Err {
+					t.Fatalf("wrong error\ngot:  %s\nwant: %s", err, tc.Err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !reflect.DeepEqual(got, tc.Want) {
+				t.Fatalf("wrong result\ngot:  %#v\nwant: %#v", got, tc.Want)
+			}
+		})
+	}
+}
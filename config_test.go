@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/davecgh/go-spew/spew"
+	"github.com/hashicorp/terraform/command"
 )
 
 // This is the directory where our test fixtures are.
@@ -47,6 +48,7 @@ func TestLoadConfig_env(t *testing.T) {
 		Provisioners: map[string]string{
 			"local": "hello",
 		},
+		ModuleCacheDir: "hello/modules",
 	}
 
 	if !reflect.DeepEqual(c, expected) {
@@ -103,6 +105,157 @@ func TestLoadConfig_credentials(t *testing.T) {
 	}
 }
 
+func TestLoadConfig_providerInstallation(t *testing.T) {
+	defer os.Setenv("GOPATH", os.Getenv("GOPATH"))
+	os.Setenv("GOPATH", "/home/dev/go")
+
+	got, err := loadConfigFile(filepath.Join(fixtureDir, "provider-installation"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &Config{
+		ProviderInstallation: &ConfigProviderInstallation{
+			DevOverrides: map[string]string{
+				"aws":  "/tmp/terraform-provider-aws",
+				"null": "/home/dev/go/bin",
+			},
+		},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("wrong result\ngot:  %swant: %s", spew.Sdump(got), spew.Sdump(want))
+	}
+}
+
+func TestLoadConfig_providerInstallationMethods(t *testing.T) {
+	defer os.Setenv("GOPATH", os.Getenv("GOPATH"))
+	os.Setenv("GOPATH", "/home/dev/go")
+
+	got, err := loadConfigFile(filepath.Join(fixtureDir, "provider-installation-methods"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &Config{
+		ProviderInstallation: &ConfigProviderInstallation{
+			Methods: []*ConfigProviderInstallationMethod{
+				{
+					Type:    "filesystem_mirror",
+					Path:    "/home/dev/go/providers",
+					Include: []string{"aws", "google*"},
+				},
+				{
+					Type:    "network_mirror",
+					URL:     "https://mirror.example.com/providers/",
+					Exclude: []string{"internal-*"},
+				},
+				{
+					Type:    "direct",
+					Exclude: []string{"aws", "google*"},
+				},
+			},
+		},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("wrong result\ngot:  %swant: %s", spew.Sdump(got), spew.Sdump(want))
+	}
+}
+
+func TestLoadConfig_applyApproval(t *testing.T) {
+	got, err := loadConfigFile(filepath.Join(fixtureDir, "apply-approval"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &Config{
+		ApplyApproval: &ConfigApplyApproval{
+			Pager:                 "less -R",
+			AutoApproveAttributes: []string{"tags", "tags_all"},
+			DestroyConfirmation:   "workspace",
+		},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("wrong result\ngot:  %swant: %s", spew.Sdump(got), spew.Sdump(want))
+	}
+}
+
+func TestLoadConfig_applyHooks(t *testing.T) {
+	got, err := loadConfigFile(filepath.Join(fixtureDir, "apply-hooks"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &Config{
+		ApplyHooks: map[string]*ConfigApplyHook{
+			"notify": {
+				Command: []string{"notify-send", "--channel", "ops"},
+				When:    []string{"post_apply"},
+			},
+			"policy": {
+				Command: []string{"/usr/local/bin/check-policy"},
+			},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong result\ngot:  %swant: %s", spew.Sdump(got), spew.Sdump(want))
+	}
+
+	wantCmds := []*command.ApplyHookCommand{
+		{
+			Name:      "notify",
+			Args:      []string{"notify-send", "--channel", "ops"},
+			PostApply: true,
+		},
+		{
+			Name:      "policy",
+			Args:      []string{"/usr/local/bin/check-policy"},
+			PreApply:  true,
+			PostApply: true,
+		},
+	}
+	if cmds := got.ApplyHookCommands(); !reflect.DeepEqual(cmds, wantCmds) {
+		t.Fatalf("wrong commands\ngot:  %swant: %s", spew.Sdump(cmds), spew.Sdump(wantCmds))
+	}
+}
+
+func TestConfig_ApplyHookCommandsEmpty(t *testing.T) {
+	c := &Config{
+		ApplyHooks: map[string]*ConfigApplyHook{
+			"audit": {Command: []string{"/usr/local/bin/audit"}},
+			"empty": {Command: []string{}},
+			"blank": {Command: []string{""}},
+		},
+	}
+
+	want := []*command.ApplyHookCommand{
+		{
+			Name:      "audit",
+			Args:      []string{"/usr/local/bin/audit"},
+			PreApply:  true,
+			PostApply: true,
+		},
+	}
+	if got := c.ApplyHookCommands(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong commands\ngot:  %swant: %s", spew.Sdump(got), spew.Sdump(want))
+	}
+}
+
+func TestEnvConfig_applyHook(t *testing.T) {
+	defer os.Unsetenv(applyHookEnvVar)
+	os.Setenv(applyHookEnvVar, "/usr/local/bin/audit --json")
+
+	got := EnvConfig().ApplyHooks
+	want := map[string]*ConfigApplyHook{
+		"TF_APPLY_HOOK": {Command: []string{"/usr/local/bin/audit", "--json"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong result\ngot:  %swant: %s", spew.Sdump(got), spew.Sdump(want))
+	}
+}
+
 func TestConfigValidate(t *testing.T) {
 	tests := map[string]struct {
 		Config    *Config
@@ -169,6 +322,79 @@ func TestConfigValidate(t *testing.T) {
 			},
 			1, // no more than one credentials_helper block allowed
 		},
+		"dev override relative path": {
+			&Config{
+				ProviderInstallation: &ConfigProviderInstallation{
+					DevOverrides: map[string]string{
+						"aws": "bin",
+					},
+				},
+			},
+			1, // dev_overrides paths must be absolute
+		},
+		"installation methods": {
+			&Config{
+				ProviderInstallation: &ConfigProviderInstallation{
+					Methods: []*ConfigProviderInstallationMethod{
+						{Type: "filesystem_mirror", Path: "/usr/share/terraform/providers"},
+						{Type: "network_mirror", URL: "https://mirror.example.com/"},
+						{Type: "direct", Exclude: []string{"internal-*"}},
+					},
+				},
+			},
+			0,
+		},
+		"installation methods invalid": {
+			&Config{
+				ProviderInstallation: &ConfigProviderInstallation{
+					Methods: []*ConfigProviderInstallationMethod{
+						{Type: "filesystem_mirror", Path: "providers"},
+						{Type: "network_mirror", URL: "http://mirror.example.com/"},
+						{Type: "direct", Include: []string{"[aws"}},
+					},
+				},
+			},
+			3, // relative path, non-https URL and invalid pattern
+		},
+		"apply approval good": {
+			&Config{
+				ApplyApproval: &ConfigApplyApproval{
+					AutoApproveAttributes: []string{"tags"},
+					DestroyConfirmation:   "workspace",
+				},
+			},
+			0,
+		},
+		"apply approval invalid": {
+			&Config{
+				ApplyApproval: &ConfigApplyApproval{
+					AutoApproveAttributes: []string{"tags."},
+					DestroyConfirmation:   "always",
+				},
+			},
+			2, // invalid attribute name and destroy confirmation
+		},
+		"apply hook good": {
+			&Config{
+				ApplyHooks: map[string]*ConfigApplyHook{
+					"notify": {
+						Command: []string{"notify-send"},
+						When:    []string{"pre_apply", "post_apply"},
+					},
+				},
+			},
+			0,
+		},
+		"apply hook invalid": {
+			&Config{
+				ApplyHooks: map[string]*ConfigApplyHook{
+					"notify": {
+						When: []string{"apply"},
+					},
+				},
+			},
+			2, // no command and invalid when
+		},
 	}
 
 	for name, test := range tests {
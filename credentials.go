@@ -0,0 +1,268 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+
+	pluginDiscovery "github.com/hashicorp/terraform/plugin/discovery"
+	"github.com/hashicorp/terraform/svchost"
+	"github.com/hashicorp/terraform/svchost/auth"
+)
+
+// credentialsFilename is the name of the file in the CLI configuration
+// directory where new credentials are stored when no credentials helper is
+// configured. It's an ordinary CLI configuration file, and so it's loaded
+// along with the others.
+const credentialsFilename = "credentials.tfrc.json"
+
+// credentialsSource returns the source of the credentials for
+// Terraform-native services given by the CLI configuration.
+func credentialsSource(config *Config) auth.CredentialsSource {
+	src := &cliCredentialsSource{
+		configured: map[svchost.Hostname]map[string]interface{}{},
+	}
+	for userHost, creds := range config.Credentials {
+		host, err := svchost.ForComparison(userHost)
+		if err != nil {
+			// We expect the config was already validated by the time we get
+			// here, so we'll just ignore invalid hostnames.
+			continue
+		}
+		src.configured[host] = creds
+	}
+
+	if dir, err := ConfigDir(); err == nil {
+		src.filePath = filepath.Join(dir, credentialsFilename)
+	} else {
+		log.Printf("[WARN] Can't find the CLI configuration directory, so credentials can't be stored: %s", err)
+	}
+
+	for helperType, helperConfig := range config.CredentialsHelpers {
+		log.Printf("[DEBUG] Searching for credentials helper named %q", helperType)
+		available := pluginDiscovery.FindPlugins("credentials", globalPluginDirs())
+		available = available.WithName(helperType)
+		if available.Count() == 0 {
+			log.Printf("[ERROR] Unable to find credentials helper %q; ignoring", helperType)
+			break
+		}
+
+		selected := available.Newest()
+
+		helperSource := auth.HelperProgramCredentialsSource(selected.Path, helperConfig.Args...)
+		src.helper = auth.CachingCredentialsSource(helperSource) // cached because external operation may be slow/expensive
+
+		// There should only be zero or one "credentials_helper" blocks. We
+		// assume that the config was validated earlier and so we don't check
+		// for extras here.
+		break
+	}
+
+	return src
+}
+
+// cliCredentialsSource is an auth.CredentialsSource that looks credentials
+// up in the "credentials" blocks of the CLI configuration first, and then
+// with the credentials helper, if there is one.
+//
+// New credentials are stored with the credentials helper if there is one,
+// and in the credentials file in the CLI configuration directory otherwise.
+// Credentials in other CLI configuration files were written by hand, so
+// they're never replaced or discarded.
+type cliCredentialsSource struct {
+	// configured are the credentials of the "credentials" blocks of all of
+	// the CLI configuration files, including the credentials file.
+	configured map[svchost.Hostname]map[string]interface{}
+
+	// filePath is the path of the credentials file, which is empty if it
+	// isn't known.
+	filePath string
+
+	// helper is the credentials helper, which is nil if there isn't one.
+	helper auth.CredentialsSource
+}
+
+var _ auth.CredentialsSource = (*cliCredentialsSource)(nil)
+
+func (s *cliCredentialsSource) ForHost(host svchost.Hostname) (auth.HostCredentials, error) {
+	if m, exists := s.configured[host]; exists {
+		return auth.HostCredentialsFromMap(m), nil
+	}
+	if s.helper != nil {
+		return s.helper.ForHost(host)
+	}
+	return nil, nil
+}
+
+func (s *cliCredentialsSource) StoreForHost(host svchost.Hostname, credentials auth.HostCredentialsWritable) error {
+	inFile, err := s.checkWritable(host)
+	if err != nil {
+		return err
+	}
+
+	if s.helper != nil {
+		if err := s.helper.StoreForHost(host, credentials); err != nil {
+			return err
+		}
+		// Credentials in the file would take precedence over the new ones.
+		if inFile {
+			if err := s.updateFile(host, nil); err != nil {
+				return err
+			}
+		}
+		delete(s.configured, host)
+		return nil
+	}
+
+	m := credentials.ToStore()
+	if err := s.updateFile(host, m); err != nil {
+		return err
+	}
+	s.configured[host] = m
+	return nil
+}
+
+func (s *cliCredentialsSource) ForgetForHost(host svchost.Hostname) error {
+	inFile, err := s.checkWritable(host)
+	if err != nil {
+		return err
+	}
+
+	if inFile {
+		if err := s.updateFile(host, nil); err != nil {
+			return err
+		}
+	}
+	delete(s.configured, host)
+
+	if s.helper != nil {
+		return s.helper.ForgetForHost(host)
+	}
+	return nil
+}
+
+// checkWritable returns an error if the credentials for the given host were
+// written by hand in a CLI configuration file, and otherwise whether they're
+// in the credentials file.
+func (s *cliCredentialsSource) checkWritable(host svchost.Hostname) (bool, error) {
+	if s.filePath == "" {
+		return false, fmt.Errorf("can't find the CLI configuration directory to store credentials in")
+	}
+
+	doc, err := s.readFile()
+	if err != nil {
+		return false, err
+	}
+	inFile := fileCredentialsKey(doc, host) != ""
+
+	if _, exists := s.configured[host]; exists && !inFile {
+		return false, fmt.Errorf(
+			"the credentials for %s are set in a \"credentials\" block of the CLI configuration, "+
+				"which must be removed by hand before they can be changed",
+			host.ForDisplay())
+	}
+	return inFile, nil
+}
+
+// readFile reads the credentials file, returning an empty document if it
+// doesn't exist.
+func (s *cliCredentialsSource) readFile() (map[string]interface{}, error) {
+	src, err := ioutil.ReadFile(s.filePath)
+	if os.IsNotExist(err) {
+		return map[string]interface{}{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %s", s.filePath, err)
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(src, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %s", s.filePath, err)
+	}
+	if doc == nil {
+		doc = map[string]interface{}{}
+	}
+	return doc, nil
+}
+
+// updateFile sets the credentials for the given host in the credentials
+// file, or removes them if m is nil. Anything else in the file is kept, and
+// the file is removed once it's empty.
+func (s *cliCredentialsSource) updateFile(host svchost.Hostname, m map[string]interface{}) error {
+	doc, err := s.readFile()
+	if err != nil {
+		return err
+	}
+
+	creds, _ := doc["credentials"].(map[string]interface{})
+	if creds == nil {
+		creds = map[string]interface{}{}
+	}
+	if k := fileCredentialsKey(doc, host); k != "" {
+		delete(creds, k)
+	}
+	if m != nil {
+		creds[string(host)] = m
+	}
+
+	if len(creds) > 0 {
+		doc["credentials"] = creds
+	} else {
+		delete(doc, "credentials")
+	}
+
+	if len(doc) == 0 {
+		if err := os.Remove(s.filePath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s: %s", s.filePath, err)
+		}
+		return nil
+	}
+
+	src, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize the credentials: %s", err)
+	}
+
+	// The file is replaced atomically, so that it's never left half
+	// written, and is only readable by the current user.
+	dir := filepath.Dir(s.filePath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %s", dir, err)
+	}
+	f, err := ioutil.TempFile(dir, "."+credentialsFilename)
+	if err != nil {
+		return fmt.Errorf("failed to create a temporary file in %s: %s", dir, err)
+	}
+	defer os.Remove(f.Name())
+
+	_, err = f.Write(append(src, '\n'))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(f.Name(), 0600)
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), s.filePath)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %s", s.filePath, err)
+	}
+	return nil
+}
+
+// fileCredentialsKey returns the key of the credentials for the given host
+// in the credentials document, which may be written in any form of the
+// hostname, or an empty string if there are none.
+func fileCredentialsKey(doc map[string]interface{}, host svchost.Hostname) string {
+	creds, _ := doc["credentials"].(map[string]interface{})
+	for k := range creds {
+		if h, err := svchost.ForComparison(k); err == nil && h == host {
+			return k
+		}
+	}
+	return ""
+}
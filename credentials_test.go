@@ -0,0 +1,155 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform/svchost"
+	"github.com/hashicorp/terraform/svchost/auth"
+)
+
+func TestCliCredentialsSource_file(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "config.d", credentialsFilename)
+	src := &cliCredentialsSource{
+		configured: map[svchost.Hostname]map[string]interface{}{
+			"manual.example.com": {"token": "manual-token"},
+		},
+		filePath: path,
+	}
+
+	host := svchost.Hostname("example.com")
+	if err := src.StoreForHost(host, auth.HostCredentialsToken("new-token")); err != nil {
+		t.Fatal(err)
+	}
+
+	creds, err := src.ForHost(host)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := creds, auth.HostCredentialsToken("new-token"); got != want {
+		t.Fatalf("wrong credentials %#v; want %#v", got, want)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := info.Mode().Perm(), os.FileMode(0600); got != want {
+		t.Fatalf("wrong file mode %s; want %s", got, want)
+	}
+
+	// The file is an ordinary CLI configuration file.
+	config, diags := loadConfigFile(path)
+	if diags.HasErrors() {
+		t.Fatal(diags.Err())
+	}
+	want := map[string]map[string]interface{}{
+		"example.com": {"token": "new-token"},
+	}
+	if !reflect.DeepEqual(config.Credentials, want) {
+		t.Fatalf("wrong credentials in the file\ngot:  %#v\nwant: %#v", config.Credentials, want)
+	}
+
+	// Credentials written by hand can't be replaced or discarded.
+	manual := svchost.Hostname("manual.example.com")
+	if err := src.StoreForHost(manual, auth.HostCredentialsToken("new-token")); err == nil {
+		t.Fatal("storing manual credentials succeeded; want error")
+	}
+	if err := src.ForgetForHost(manual); err == nil {
+		t.Fatal("forgetting manual credentials succeeded; want error")
+	}
+
+	if err := src.ForgetForHost(host); err != nil {
+		t.Fatal(err)
+	}
+	creds, err = src.ForHost(host)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if creds != nil {
+		t.Fatalf("got credentials %#v after forgetting them; want nil", creds)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("the empty credentials file wasn't removed: %v", err)
+	}
+}
+
+func TestCliCredentialsSource_helper(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Credentials that were stored in the file before the helper was
+	// configured are moved to the helper.
+	path := filepath.Join(dir, credentialsFilename)
+	err = ioutil.WriteFile(path, []byte(`{"credentials":{"example.com":{"token":"old-token"}}}`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	helper := &testCredentialsHelper{creds: map[svchost.Hostname]map[string]interface{}{}}
+	src := &cliCredentialsSource{
+		configured: map[svchost.Hostname]map[string]interface{}{
+			"example.com": {"token": "old-token"},
+		},
+		filePath: path,
+		helper:   helper,
+	}
+
+	host := svchost.Hostname("example.com")
+	if err := src.StoreForHost(host, auth.HostCredentialsToken("new-token")); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := helper.creds[host]["token"], "new-token"; got != want {
+		t.Fatalf("wrong token in the helper %#v; want %#v", got, want)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("the credentials file wasn't removed: %v", err)
+	}
+
+	creds, err := src.ForHost(host)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := creds, auth.HostCredentialsToken("new-token"); got != want {
+		t.Fatalf("wrong credentials %#v; want %#v", got, want)
+	}
+
+	if err := src.ForgetForHost(host); err != nil {
+		t.Fatal(err)
+	}
+	if _, exists := helper.creds[host]; exists {
+		t.Fatal("the credentials weren't removed from the helper")
+	}
+}
+
+// testCredentialsHelper is an auth.CredentialsSource that stands in for a
+// credentials helper program.
+type testCredentialsHelper struct {
+	creds map[svchost.Hostname]map[string]interface{}
+}
+
+func (h *testCredentialsHelper) ForHost(host svchost.Hostname) (auth.HostCredentials, error) {
+	return auth.HostCredentialsFromMap(h.creds[host]), nil
+}
+
+func (h *testCredentialsHelper) StoreForHost(host svchost.Hostname, credentials auth.HostCredentialsWritable) error {
+	h.creds[host] = credentials.ToStore()
+	return nil
+}
+
+func (h *testCredentialsHelper) ForgetForHost(host svchost.Hostname) error {
+	delete(h.creds, host)
+	return nil
+}
@@ -19,6 +19,11 @@ type DotOpts struct {
 	// How many levels to expand modules as we draw
 	MaxDepth int
 
+	// DecorateNode, if set, is called with each vertex that's drawn along
+	// with its dot node, and may change the node's attributes, such as to
+	// highlight some of the vertices.
+	DecorateNode func(Vertex, *DotNode)
+
 	// use this to keep the cluster_ naming convention from the previous dot writer
 	cluster bool
 }
@@ -93,6 +98,12 @@ func (v *marshalVertex) dot(g *marshalGraph, opts *DotOpts) []byte {
 		if node == nil {
 			return []byte{}
 		}
+		if opts.DecorateNode != nil {
+			if node.Attrs == nil {
+				node.Attrs = make(map[string]string)
+			}
+			opts.DecorateNode(v.graphNodeDotter, node)
+		}
 
 		newAttrs := make(map[string]string)
 		for k, v := range attrs {
@@ -2,6 +2,7 @@ package dag
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -24,6 +25,30 @@ func TestGraphDot_opts(t *testing.T) {
 	}
 }
 
+func TestGraphDot_decorateNode(t *testing.T) {
+	v := &testDotVertex{
+		DotNodeReturn: &DotNode{Name: "foo", Attrs: map[string]string{"label": "foo"}},
+	}
+	var g Graph
+	g.Add(v)
+
+	var decorated Vertex
+	actual := string(g.Dot(&DotOpts{
+		DecorateNode: func(dv Vertex, node *DotNode) {
+			decorated = dv
+			node.Attrs["color"] = "red"
+		},
+	}))
+
+	if decorated != v {
+		t.Fatalf("wrong vertex decorated: %#v", decorated)
+	}
+	want := `"[root] foo" [color = "red", label = "foo"]`
+	if !strings.Contains(actual, want) {
+		t.Fatalf("decorated node not found in:\n%s", actual)
+	}
+}
+
 type testDotVertex struct {
 	DotNodeCalled bool
 	DotNodeTitle  string
@@ -32,7 +32,7 @@ func helpFunc(commands map[string]cli.CommandFactory) string {
 	// website/source/docs/commands/index.html.markdown; if you
 	// change this then consider updating that to match.
 	helpText := fmt.Sprintf(`
-Usage: terraform [--version] [--help] <command> [args]
+Usage: terraform [-chdir=DIR] [--version] [--help] <command> [args]
 
 The available commands for execution are listed below.
 The most common, useful commands are shown first, followed by
@@ -44,6 +44,9 @@ Common commands:
 %s
 All other commands:
 %s
+Global options (use these before the subcommand, if any):
+    -chdir=DIR         Switch to a different working directory before
+                       executing the given subcommand.
 `, listCommands(porcelain, maxKeyLen), listCommands(plumbing, maxKeyLen))
 
 	return strings.TrimSpace(helpText)
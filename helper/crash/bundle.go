@@ -0,0 +1,162 @@
+package crash
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// LogLines is how many of the most recent lines of the log a bundle keeps.
+const LogLines = 1000
+
+// Bundle is what's written when Terraform crashes.
+type Bundle struct {
+	Version   string    `json:"terraform_version"`
+	GoVersion string    `json:"go_version"`
+	Platform  string    `json:"platform"`
+	Time      time.Time `json:"time"`
+
+	// Args are the arguments Terraform was run with, with the values of
+	// variables and backend configuration redacted.
+	Args []string `json:"args"`
+
+	// Crash is the crash output of the child process: the panic message and
+	// the stack traces.
+	Crash string `json:"crash"`
+
+	Providers   map[string]string `json:"providers,omitempty"`
+	PlanSummary string            `json:"plan_summary,omitempty"`
+
+	// Log are the most recent lines of the log.
+	Log []string `json:"log"`
+}
+
+// NewBundle returns the bundle for a crash of Terraform of the given
+// version, which was run with the given arguments, from its crash output,
+// its log and the context it recorded.
+func NewBundle(version string, args []string, output string, logR io.Reader, ctx *Context) (*Bundle, error) {
+	lines, err := tailLines(logR, LogLines)
+	if err != nil {
+		return nil, err
+	}
+	for i, line := range lines {
+		lines[i] = redactLogLine(line)
+	}
+
+	b := &Bundle{
+		Version:   version,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "_" + runtime.GOARCH,
+		Time:      time.Now().UTC(),
+		Args:      redactArgs(args),
+		Crash:     output,
+		Log:       lines,
+	}
+	if ctx != nil {
+		b.Providers = ctx.Providers
+		b.PlanSummary = ctx.PlanSummary
+	}
+	return b, nil
+}
+
+// Write writes the bundle as JSON.
+func (b *Bundle) Write(w io.Writer) error {
+	js, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(js, '\n'))
+	return err
+}
+
+// tailLines returns the last n lines that are read from r, keeping no more
+// than that many at a time.
+func tailLines(r io.Reader, n int) ([]string, error) {
+	ring := make([]string, n)
+	count := 0
+
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadString('\n')
+		if line != "" {
+			ring[count%n] = strings.TrimRight(line, "\r\n")
+			count++
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if count <= n {
+		return ring[:count], nil
+	}
+	start := count % n
+	return append(ring[start:], ring[:start]...), nil
+}
+
+// redactedFlags are the flags whose values may be secret.
+var redactedFlags = map[string]bool{
+	"var":            true,
+	"backend-config": true,
+}
+
+// redactArgs returns the given command line arguments with the values of
+// the flags in redactedFlags redacted.
+func redactArgs(args []string) []string {
+	result := make([]string, len(args))
+	redactNext := false
+	for i, arg := range args {
+		if redactNext {
+			result[i] = redactFlagValue(arg)
+			redactNext = false
+			continue
+		}
+		result[i] = arg
+
+		if !strings.HasPrefix(arg, "-") {
+			continue
+		}
+		name := strings.TrimLeft(arg, "-")
+		value := ""
+		hasValue := false
+		if idx := strings.Index(name, "="); idx >= 0 {
+			name, value, hasValue = name[:idx], name[idx+1:], true
+		}
+		if !redactedFlags[name] {
+			continue
+		}
+		if !hasValue {
+			redactNext = true
+			continue
+		}
+		result[i] = arg[:strings.Index(arg, "=")+1] + redactFlagValue(value)
+	}
+	return result
+}
+
+// redactFlagValue redacts the value of a name=value pair, keeping the name
+// so that it's known which variable was set. A -backend-config value that
+// isn't a pair is the path of a file, which is kept.
+func redactFlagValue(v string) string {
+	if idx := strings.Index(v, "="); idx >= 0 {
+		return v[:idx+1] + "<redacted>"
+	}
+	return v
+}
+
+// redactLogLine redacts the lines of the log that contain the command line
+// arguments, which may include secrets.
+func redactLogLine(line string) string {
+	for _, marker := range []string{"CLI args: ", "CLI command args: "} {
+		if idx := strings.Index(line, marker); idx >= 0 {
+			return line[:idx+len(marker)] + "<redacted>"
+		}
+	}
+	return line
+}
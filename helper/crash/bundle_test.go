@@ -0,0 +1,114 @@
+package crash
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNewBundle(t *testing.T) {
+	log := strings.Join([]string{
+		`2017/01/01 00:00:00 [INFO] Terraform version: 0.11.0`,
+		`2017/01/01 00:00:00 [INFO] CLI args: []string{"terraform", "apply", "-var", "password=hunter2"}`,
+		`2017/01/01 00:00:00 [INFO] CLI command args: []string{"apply", "-var", "password=hunter2"}`,
+		`panic: boom`,
+	}, "\n")
+
+	ctx := &Context{
+		Providers:   map[string]string{"aws": "1.2.3"},
+		PlanSummary: "CREATE: aws_instance.foo",
+	}
+	b, err := NewBundle("0.11.0", []string{"apply", "-var", "password=hunter2"}, "panic: boom", strings.NewReader(log), ctx)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	var buf bytes.Buffer
+	if err := b.Write(&buf); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if strings.Contains(buf.String(), "hunter2") {
+		t.Fatalf("bundle has a secret:\n%s", buf.String())
+	}
+
+	var actual Bundle
+	if err := json.Unmarshal(buf.Bytes(), &actual); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if actual.Version != "0.11.0" || actual.Crash != "panic: boom" {
+		t.Fatalf("bad: %#v", actual)
+	}
+	if !reflect.DeepEqual(actual.Providers, ctx.Providers) || actual.PlanSummary != ctx.PlanSummary {
+		t.Fatalf("bad: %#v", actual)
+	}
+
+	expectedLog := []string{
+		`2017/01/01 00:00:00 [INFO] Terraform version: 0.11.0`,
+		`2017/01/01 00:00:00 [INFO] CLI args: <redacted>`,
+		`2017/01/01 00:00:00 [INFO] CLI command args: <redacted>`,
+		`panic: boom`,
+	}
+	if !reflect.DeepEqual(actual.Log, expectedLog) {
+		t.Fatalf("bad log:\n%#v", actual.Log)
+	}
+}
+
+func TestTailLines(t *testing.T) {
+	var lines []string
+	for i := 0; i < 10; i++ {
+		lines = append(lines, fmt.Sprintf("line %d", i))
+	}
+	input := strings.Join(lines, "\n") + "\n"
+
+	cases := []struct {
+		n        int
+		expected []string
+	}{
+		{20, lines},
+		{10, lines},
+		{3, lines[7:]},
+		{1, lines[9:]},
+	}
+
+	for _, tc := range cases {
+		t.Run(fmt.Sprint(tc.n), func(t *testing.T) {
+			actual, err := tailLines(strings.NewReader(input), tc.n)
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			if !reflect.DeepEqual(actual, tc.expected) {
+				t.Fatalf("bad: %#v", actual)
+			}
+		})
+	}
+}
+
+func TestRedactArgs(t *testing.T) {
+	cases := []struct {
+		args     []string
+		expected []string
+	}{
+		{
+			[]string{"plan", "-out=plan.tfplan"},
+			[]string{"plan", "-out=plan.tfplan"},
+		},
+		{
+			[]string{"apply", "-var", "password=hunter2", "-var=token=abc"},
+			[]string{"apply", "-var", "password=<redacted>", "-var=token=<redacted>"},
+		},
+		{
+			[]string{"init", "--backend-config", "key=secret", "-backend-config=backend.hcl"},
+			[]string{"init", "--backend-config", "key=<redacted>", "-backend-config=backend.hcl"},
+		},
+	}
+
+	for _, tc := range cases {
+		actual := redactArgs(tc.args)
+		if !reflect.DeepEqual(actual, tc.expected) {
+			t.Fatalf("%#v: bad: %#v", tc.args, actual)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+// Package crash builds the crash bundle that Terraform writes when it
+// crashes, which gives those who triage the crash what they need to know
+// without the secrets of the run that crashed.
+//
+// Terraform runs in a child process, and it's the parent that writes the
+// bundle after the child has crashed. As the child runs, it records what
+// it knows that the parent doesn't, such as the versions of the providers
+// and a summary of the plan, to the context file whose path the parent
+// gives it in EnvContextPath.
+package crash
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"os"
+	"sync"
+)
+
+// EnvContextPath is the environment variable that gives the child process
+// the path of the context file.
+const EnvContextPath = "TF_CRASH_CONTEXT_PATH"
+
+// Context is what the child process has recorded about its run.
+type Context struct {
+	// Providers are the versions of the providers that were used, by
+	// name.
+	Providers map[string]string `json:"providers,omitempty"`
+
+	// PlanSummary is the most recent plan, with sensitive values
+	// redacted.
+	PlanSummary string `json:"plan_summary,omitempty"`
+}
+
+var (
+	contextLock sync.Mutex
+	current     Context
+)
+
+// SetProvider records the version of the provider with the given name.
+func SetProvider(name, version string) {
+	contextLock.Lock()
+	defer contextLock.Unlock()
+
+	if current.Providers == nil {
+		current.Providers = make(map[string]string)
+	}
+	current.Providers[name] = version
+	saveContext()
+}
+
+// SetPlanSummary records the summary of a plan, which must have had its
+// sensitive values redacted.
+func SetPlanSummary(summary string) {
+	contextLock.Lock()
+	defer contextLock.Unlock()
+
+	current.PlanSummary = summary
+	saveContext()
+}
+
+// saveContext writes the context to the context file, if there is one. It
+// must be called with contextLock held.
+func saveContext() {
+	path := os.Getenv(EnvContextPath)
+	if path == "" {
+		return
+	}
+
+	js, err := json.Marshal(&current)
+	if err != nil {
+		log.Printf("[WARN] failed to encode crash context: %s", err)
+		return
+	}
+
+	// The file is replaced rather than written in place, so that the
+	// parent never reads half of it if the child crashes while writing.
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, js, 0600); err != nil {
+		log.Printf("[WARN] failed to write crash context: %s", err)
+		return
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		log.Printf("[WARN] failed to write crash context: %s", err)
+	}
+}
+
+// ReadContext reads the context file at the given path. A file that's empty
+// or missing, because the child didn't record anything, is an empty
+// context.
+func ReadContext(path string) (*Context, error) {
+	ctx := &Context{}
+	js, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) || (err == nil && len(js) == 0) {
+		return ctx, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(js, ctx); err != nil {
+		return nil, err
+	}
+	return ctx, nil
+}
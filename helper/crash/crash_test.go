@@ -0,0 +1,68 @@
+package crash
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestContext(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tf")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "context")
+
+	old := os.Getenv(EnvContextPath)
+	os.Setenv(EnvContextPath, path)
+	defer os.Setenv(EnvContextPath, old)
+
+	// Nothing has been recorded yet.
+	ctx, err := ReadContext(path)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !reflect.DeepEqual(ctx, &Context{}) {
+		t.Fatalf("bad: %#v", ctx)
+	}
+
+	SetProvider("aws", "1.2.3")
+	SetProvider("null", "built-in")
+	SetPlanSummary("CREATE: aws_instance.foo")
+
+	ctx, err = ReadContext(path)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	expected := &Context{
+		Providers: map[string]string{
+			"aws":  "1.2.3",
+			"null": "built-in",
+		},
+		PlanSummary: "CREATE: aws_instance.foo",
+	}
+	if !reflect.DeepEqual(ctx, expected) {
+		t.Fatalf("bad: %#v", ctx)
+	}
+
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Fatalf("temporary file should have been renamed: %v", err)
+	}
+}
+
+func TestReadContext_invalid(t *testing.T) {
+	f, err := ioutil.TempFile("", "tf")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.Remove(f.Name())
+	f.WriteString("{")
+	f.Close()
+
+	if _, err := ReadContext(f.Name()); err == nil {
+		t.Fatal("should error")
+	}
+}
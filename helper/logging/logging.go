@@ -1,6 +1,7 @@
 package logging
 
 import (
+	"bytes"
 	"io"
 	"io/ioutil"
 	"log"
@@ -14,8 +15,9 @@ import (
 // These are the environmental variables that determine if we log, and if
 // we log whether or not the log should go to a file.
 const (
-	EnvLog     = "TF_LOG"      // Set to True
-	EnvLogFile = "TF_LOG_PATH" // Set to a file
+	EnvLog         = "TF_LOG"          // Set to True
+	EnvLogFile     = "TF_LOG_PATH"     // Set to a file
+	EnvLogProvider = "TF_LOG_PROVIDER" // Set to the log level for provider plugins
 )
 
 var ValidLevels = []logutils.LogLevel{"TRACE", "DEBUG", "INFO", "WARN", "ERROR"}
@@ -25,7 +27,8 @@ func LogOutput() (logOutput io.Writer, err error) {
 	logOutput = ioutil.Discard
 
 	logLevel := LogLevel()
-	if logLevel == "" {
+	providerLevel := ProviderLogLevel()
+	if logLevel == "" && providerLevel == "" {
 		return
 	}
 
@@ -39,15 +42,64 @@ func LogOutput() (logOutput io.Writer, err error) {
 	}
 
 	// This was the default since the beginning
-	logOutput = &logutils.LevelFilter{
-		Levels:   ValidLevels,
-		MinLevel: logutils.LogLevel(logLevel),
-		Writer:   logOutput,
+	if providerLevel == logLevel {
+		logOutput = &logutils.LevelFilter{
+			Levels:   ValidLevels,
+			MinLevel: logutils.LogLevel(logLevel),
+			Writer:   logOutput,
+		}
+		return
+	}
+
+	// Provider plugins have their own log level, so their entries must be
+	// filtered separately.
+	var core io.Writer = ioutil.Discard
+	if logLevel != "" {
+		core = &logutils.LevelFilter{
+			Levels:   ValidLevels,
+			MinLevel: logutils.LogLevel(logLevel),
+			Writer:   logOutput,
+		}
+	}
+	var provider io.Writer = ioutil.Discard
+	if providerLevel != "" {
+		provider = &logutils.LevelFilter{
+			Levels:   ValidLevels,
+			MinLevel: logutils.LogLevel(providerLevel),
+			Writer:   logOutput,
+		}
 	}
+	logOutput = &providerSplitter{Core: core, Provider: provider}
 
 	return
 }
 
+// providerSplitter is a writer that sends log lines from provider plugins
+// to one writer and all other log lines to another.
+//
+// Provider plugin log lines are identified by the "provider." prefix of
+// the logger name that follows the level, as in
+// "2018-01-02T15:04:05.000Z [DEBUG] provider.aws: ...".
+type providerSplitter struct {
+	Core     io.Writer
+	Provider io.Writer
+}
+
+func (s *providerSplitter) Write(p []byte) (int, error) {
+	if isProviderLine(p) {
+		return s.Provider.Write(p)
+	}
+	return s.Core.Write(p)
+}
+
+func isProviderLine(line []byte) bool {
+	end := bytes.IndexByte(line, ']')
+	if end == -1 || bytes.IndexByte(line[:end], '[') == -1 {
+		return false
+	}
+	return bytes.HasPrefix(bytes.TrimLeft(line[end+1:], " "), []byte("provider."))
+}
+
 // SetOutput checks for a log destination with LogOutput, and calls
 // log.SetOutput with the result. If LogOutput returns nil, SetOutput uses
 // ioutil.Discard. Any error from LogOutout is fatal.
@@ -83,6 +135,25 @@ func LogLevel() string {
 	return logLevel
 }
 
+// ProviderLogLevel returns the log level for provider plugins, which is
+// set by TF_LOG_PROVIDER or otherwise is the same as the LogLevel.
+func ProviderLogLevel() string {
+	envLevel := os.Getenv(EnvLogProvider)
+	if envLevel == "" {
+		return LogLevel()
+	}
+
+	logLevel := "TRACE"
+	if isValidLogLevel(envLevel) {
+		logLevel = strings.ToUpper(envLevel)
+	} else {
+		log.Printf("[WARN] Invalid provider log level: %q. Defaulting to level: TRACE. Valid levels are: %+v",
+			envLevel, ValidLevels)
+	}
+
+	return logLevel
+}
+
 // IsDebugOrHigher returns whether or not the current log level is debug or trace
 func IsDebugOrHigher() bool {
 	level := string(LogLevel())
@@ -0,0 +1,112 @@
+package schema
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hil/ast"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+// Function represents a function exported by a provider, which can then be
+// called from expressions in the configuration using the syntax
+// provider::NAME::FUNC(...).
+//
+// Functions must be pure: they must produce the same result whenever they
+// are called with the same arguments and must not have any side-effects.
+// They are called on an unconfigured provider, so they cannot make use of
+// the provider's meta value.
+type Function struct {
+	// Description is a human-readable description of what the function
+	// does.
+	Description string
+
+	// Params are the types of the required arguments, in order, and
+	// VariadicParam is the type of any further arguments. If VariadicParam
+	// is TypeInvalid then the function is not variadic.
+	//
+	// Only TypeString, TypeList and TypeMap are supported for arguments and
+	// the return value. Lists and maps can contain only strings, lists
+	// and maps.
+	Params        []ValueType
+	VariadicParam ValueType
+
+	// Return is the type of the value returned by Call.
+	Return ValueType
+
+	// Call implements the function. The arguments are guaranteed to conform
+	// to the types given in Params and VariadicParam: strings are given as
+	// string, lists as []interface{} and maps as map[string]interface{}.
+	Call FunctionCallFunc
+}
+
+// FunctionCallFunc is the type of the function that implements a Function.
+type FunctionCallFunc func(args []interface{}) (interface{}, error)
+
+// InternalValidate should be called to validate the structure of the
+// function.
+func (f *Function) InternalValidate() error {
+	if f.Call == nil {
+		return fmt.Errorf("Call must be set")
+	}
+
+	for i, t := range f.Params {
+		if _, err := functionValueType(t); err != nil {
+			return fmt.Errorf("parameter %d: %s", i+1, err)
+		}
+	}
+	if f.VariadicParam != TypeInvalid {
+		if _, err := functionValueType(f.VariadicParam); err != nil {
+			return fmt.Errorf("variadic parameter: %s", err)
+		}
+	}
+	if _, err := functionValueType(f.Return); err != nil {
+		return fmt.Errorf("return value: %s", err)
+	}
+
+	return nil
+}
+
+// coreFunction returns the signature of the function for Terraform core.
+func (f *Function) coreFunction(name string) (terraform.ProviderFunction, error) {
+	ret := terraform.ProviderFunction{
+		Name:        name,
+		Description: f.Description,
+		ParamTypes:  make([]ast.Type, len(f.Params)),
+	}
+
+	for i, t := range f.Params {
+		at, err := functionValueType(t)
+		if err != nil {
+			return ret, err
+		}
+		ret.ParamTypes[i] = at
+	}
+	if f.VariadicParam != TypeInvalid {
+		at, err := functionValueType(f.VariadicParam)
+		if err != nil {
+			return ret, err
+		}
+		ret.VariadicType = at
+	}
+
+	at, err := functionValueType(f.Return)
+	if err != nil {
+		return ret, err
+	}
+	ret.ReturnType = at
+
+	return ret, nil
+}
+
+func functionValueType(t ValueType) (ast.Type, error) {
+	switch t {
+	case TypeString:
+		return ast.TypeString, nil
+	case TypeList:
+		return ast.TypeList, nil
+	case TypeMap:
+		return ast.TypeMap, nil
+	default:
+		return ast.TypeInvalid, fmt.Errorf("type %s is not supported for functions", t)
+	}
+}
@@ -46,6 +46,10 @@ type Provider struct {
 	// and must *not* implement Create, Update or Delete.
 	DataSourcesMap map[string]*Resource
 
+	// FunctionsMap is the collection of functions that this provider
+	// exports for use in expressions, keyed by function name.
+	FunctionsMap map[string]*Function
+
 	// ConfigureFunc is a function for configuring the provider. If the
 	// provider doesn't need to be configured, this can be omitted.
 	//
@@ -110,6 +114,12 @@ func (p *Provider) InternalValidate() error {
 		}
 	}
 
+	for k, f := range p.FunctionsMap {
+		if err := f.InternalValidate(); err != nil {
+			validationErrors = multierror.Append(validationErrors, fmt.Errorf("function %s: %s", k, err))
+		}
+	}
+
 	return validationErrors
 }
 
@@ -202,10 +212,18 @@ func (p *Provider) GetSchema(req *terraform.ProviderSchemaRequest) (*terraform.P
 		}
 	}
 
+	var caps terraform.ProviderCapabilities
+	for _, r := range p.ResourcesMap {
+		if len(r.MoveState) > 0 {
+			caps.MoveResourceState = true
+		}
+	}
+
 	return &terraform.ProviderSchema{
 		Provider:      schemaMap(p.Schema).CoreConfigSchema(),
 		ResourceTypes: resourceTypes,
 		DataSources:   dataSources,
+		Capabilities:  caps,
 	}, nil
 }
 
@@ -283,6 +301,66 @@ func (p *Provider) Apply(
 	return r.Apply(s, d, p.meta)
 }
 
+// LockResource implementation of terraform.ResourceProviderLocker interface.
+func (p *Provider) LockResource(
+	info *terraform.InstanceInfo,
+	s *terraform.InstanceState,
+	d *terraform.InstanceDiff) (string, error) {
+	r, ok := p.ResourcesMap[info.Type]
+	if !ok {
+		return "", fmt.Errorf("unknown resource type: %s", info.Type)
+	}
+	if r.Lock == nil {
+		return "", fmt.Errorf("resource type %s does not support locking", info.Type)
+	}
+
+	data, err := schemaMap(r.Schema).Data(s, d)
+	if err != nil {
+		return "", err
+	}
+
+	return r.Lock(data, p.meta)
+}
+
+// UnlockResource implementation of terraform.ResourceProviderLocker interface.
+func (p *Provider) UnlockResource(info *terraform.InstanceInfo, id string) error {
+	r, ok := p.ResourcesMap[info.Type]
+	if !ok {
+		return fmt.Errorf("unknown resource type: %s", info.Type)
+	}
+	if r.Unlock == nil {
+		return fmt.Errorf("resource type %s does not support locking", info.Type)
+	}
+
+	return r.Unlock(id, p.meta)
+}
+
+// MoveResourceState implementation of terraform.ResourceProviderMover
+// interface.
+func (p *Provider) MoveResourceState(req *terraform.MoveResourceStateRequest) (*terraform.InstanceState, error) {
+	r, ok := p.ResourcesMap[req.Info.Type]
+	if !ok {
+		return nil, fmt.Errorf("unknown resource type: %s", req.Info.Type)
+	}
+
+	move, ok := r.MoveState[req.SourceType]
+	if !ok {
+		return nil, fmt.Errorf(
+			"resource type %s does not support moving state from %s",
+			req.Info.Type, req.SourceType)
+	}
+
+	state, err := move(req.SourceState.DeepCopy())
+	if err != nil || state == nil {
+		return state, err
+	}
+
+	// The converted state is for the current version of this resource's
+	// schema, whatever the version of the source's schema was.
+	delete(state.Meta, "schema_version")
+	return r.recordCurrentSchemaVersion(state), nil
+}
+
 // Diff implementation of terraform.ResourceProvider interface.
 func (p *Provider) Diff(
 	info *terraform.InstanceInfo,
@@ -447,3 +525,34 @@ func (p *Provider) DataSources() []terraform.DataSource {
 
 	return result
 }
+
+// Functions implementation of terraform.ResourceProviderFunctions interface.
+func (p *Provider) Functions() ([]terraform.ProviderFunction, error) {
+	keys := make([]string, 0, len(p.FunctionsMap))
+	for k := range p.FunctionsMap {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	result := make([]terraform.ProviderFunction, 0, len(keys))
+	for _, k := range keys {
+		fn, err := p.FunctionsMap[k].coreFunction(k)
+		if err != nil {
+			return nil, fmt.Errorf("function %s: %s", k, err)
+		}
+		result = append(result, fn)
+	}
+
+	return result, nil
+}
+
+// CallFunction implementation of terraform.ResourceProviderFunctions
+// interface.
+func (p *Provider) CallFunction(name string, args []interface{}) (interface{}, error) {
+	f, ok := p.FunctionsMap[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown function: %s", name)
+	}
+
+	return f.Call(args)
+}
@@ -3,10 +3,12 @@ package schema
 import (
 	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/davecgh/go-spew/spew"
+	"github.com/hashicorp/hil/ast"
 	"github.com/zclconf/go-cty/cty"
 
 	"github.com/hashicorp/terraform/config"
@@ -16,6 +18,8 @@ import (
 
 func TestProvider_impl(t *testing.T) {
 	var _ terraform.ResourceProvider = new(Provider)
+	var _ terraform.ResourceProviderFunctions = new(Provider)
+	var _ terraform.ResourceProviderLocker = new(Provider)
 }
 
 func TestProviderGetSchema(t *testing.T) {
@@ -412,6 +416,125 @@ func TestProviderMeta(t *testing.T) {
 	}
 }
 
+func TestProviderLockResource(t *testing.T) {
+	var unlocked string
+	p := &Provider{
+		ResourcesMap: map[string]*Resource{
+			"foo": &Resource{
+				Schema: map[string]*Schema{
+					"name": &Schema{
+						Type:     TypeString,
+						Required: true,
+					},
+				},
+				Lock: func(d *ResourceData, meta interface{}) (string, error) {
+					return fmt.Sprintf("%s/%s", d.Id(), d.Get("name")), nil
+				},
+				Unlock: func(id string, meta interface{}) error {
+					unlocked = id
+					return nil
+				},
+			},
+			"bar": &Resource{},
+		},
+	}
+
+	info := &terraform.InstanceInfo{Type: "foo"}
+	state := &terraform.InstanceState{
+		ID:         "abc",
+		Attributes: map[string]string{"name": "old"},
+	}
+	diff := &terraform.InstanceDiff{
+		Attributes: map[string]*terraform.ResourceAttrDiff{
+			"name": &terraform.ResourceAttrDiff{Old: "old", New: "new"},
+		},
+	}
+
+	id, err := p.LockResource(info, state, diff)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if id != "abc/new" {
+		t.Fatalf("bad: %q", id)
+	}
+
+	if err := p.UnlockResource(info, id); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if unlocked != id {
+		t.Fatalf("bad: %q", unlocked)
+	}
+
+	if _, err := p.LockResource(&terraform.InstanceInfo{Type: "bar"}, state, nil); err == nil {
+		t.Fatal("should error for resource without Lock")
+	}
+}
+
+func TestProviderMoveResourceState(t *testing.T) {
+	p := &Provider{
+		ResourcesMap: map[string]*Resource{
+			"foo": &Resource{
+				SchemaVersion: 2,
+				Schema: map[string]*Schema{
+					"name": &Schema{
+						Type:     TypeString,
+						Required: true,
+					},
+				},
+				MoveState: map[string]StateMoveFunc{
+					"old_foo": func(s *terraform.InstanceState) (*terraform.InstanceState, error) {
+						s.Attributes = map[string]string{
+							"name": s.Attributes["old_name"],
+						}
+						return s, nil
+					},
+				},
+			},
+			"bar": &Resource{},
+		},
+	}
+
+	schema, err := p.GetSchema(&terraform.ProviderSchemaRequest{})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !schema.Capabilities.MoveResourceState {
+		t.Fatal("provider should report the MoveResourceState capability")
+	}
+
+	source := &terraform.InstanceState{
+		ID:         "abc",
+		Attributes: map[string]string{"old_name": "n"},
+		Meta:       map[string]interface{}{"schema_version": "5"},
+	}
+	state, err := p.MoveResourceState(&terraform.MoveResourceStateRequest{
+		Info:        &terraform.InstanceInfo{Type: "foo"},
+		SourceType:  "old_foo",
+		SourceState: source,
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if state.ID != "abc" || state.Attributes["name"] != "n" {
+		t.Fatalf("bad: %#v", state)
+	}
+	if v := state.Meta["schema_version"]; v != "2" {
+		t.Fatalf("bad schema version: %#v", v)
+	}
+	if source.Attributes["old_name"] != "n" {
+		t.Fatal("source state was modified")
+	}
+
+	_, err = p.MoveResourceState(&terraform.MoveResourceStateRequest{
+		Info:        &terraform.InstanceInfo{Type: "bar"},
+		SourceType:  "old_foo",
+		SourceState: source,
+	})
+	if err == nil {
+		t.Fatal("should error for resource without MoveState")
+	}
+}
+
 func TestProviderStop(t *testing.T) {
 	var p Provider
 
@@ -550,3 +673,84 @@ func TestProvider_InternalValidate(t *testing.T) {
 		}
 	}
 }
+
+func TestProviderFunctions(t *testing.T) {
+	p := &Provider{
+		FunctionsMap: map[string]*Function{
+			"upper": &Function{
+				Params: []ValueType{TypeString},
+				Return: TypeString,
+				Call: func(args []interface{}) (interface{}, error) {
+					return strings.ToUpper(args[0].(string)), nil
+				},
+			},
+			"concat": &Function{
+				VariadicParam: TypeList,
+				Return:        TypeList,
+				Call: func(args []interface{}) (interface{}, error) {
+					var result []interface{}
+					for _, arg := range args {
+						result = append(result, arg.([]interface{})...)
+					}
+					return result, nil
+				},
+			},
+		},
+	}
+
+	if err := p.InternalValidate(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	fns, err := p.Functions()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	expected := []terraform.ProviderFunction{
+		{
+			Name:         "concat",
+			ParamTypes:   []ast.Type{},
+			VariadicType: ast.TypeList,
+			ReturnType:   ast.TypeList,
+		},
+		{
+			Name:       "upper",
+			ParamTypes: []ast.Type{ast.TypeString},
+			ReturnType: ast.TypeString,
+		},
+	}
+	if !reflect.DeepEqual(fns, expected) {
+		t.Fatalf("bad: %#v", fns)
+	}
+
+	result, err := p.CallFunction("upper", []interface{}{"foo"})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if result != "FOO" {
+		t.Fatalf("bad: %#v", result)
+	}
+
+	if _, err := p.CallFunction("nope", nil); err == nil {
+		t.Fatal("should error")
+	}
+}
+
+func TestProviderFunctions_invalid(t *testing.T) {
+	p := &Provider{
+		FunctionsMap: map[string]*Function{
+			"bad": &Function{
+				Params: []ValueType{TypeInt},
+				Return: TypeString,
+				Call: func(args []interface{}) (interface{}, error) {
+					return "", nil
+				},
+			},
+		},
+	}
+
+	if err := p.InternalValidate(); err == nil {
+		t.Fatal("should error")
+	}
+}
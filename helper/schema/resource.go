@@ -136,6 +136,27 @@ type Resource struct {
 	// actions (Create, Read, Update, Delete, Default) to the Resource struct, and
 	// accessing them in the matching methods.
 	Timeouts *ResourceTimeout
+
+	// Lock and Unlock, if set, allow users to request an advisory lock on
+	// the remote object while it is being changed, using the "lock"
+	// lifecycle option. Either both or neither must be set.
+	//
+	// Lock is given the data for the object that is about to be created,
+	// updated or destroyed and must acquire a lock on it that is visible
+	// to other Terraform runs, typically in the remote system itself,
+	// returning an ID for the lock. Unlock is given that ID to release it.
+	Lock   LockFunc
+	Unlock UnlockFunc
+
+	// MoveState, if set, allows "moved" blocks to move instances of other
+	// resource types to this one, such as when a resource type is renamed.
+	// It maps the names of the source resource types, which may belong to
+	// other providers, to functions that convert the state of an instance
+	// of that type to the state of an instance of this one.
+	//
+	// The functions are called without the provider being configured, so
+	// they must not call any remote APIs.
+	MoveState map[string]StateMoveFunc
 }
 
 // See Resource documentation.
@@ -160,6 +181,15 @@ type StateMigrateFunc func(
 // See Resource documentation.
 type CustomizeDiffFunc func(*ResourceDiff, interface{}) error
 
+// See Resource documentation.
+type LockFunc func(*ResourceData, interface{}) (string, error)
+
+// See Resource documentation.
+type UnlockFunc func(string, interface{}) error
+
+// See Resource documentation.
+type StateMoveFunc func(*terraform.InstanceState) (*terraform.InstanceState, error)
+
 // Apply creates, updates, and/or deletes a resource.
 func (r *Resource) Apply(
 	s *terraform.InstanceState,
@@ -375,11 +405,23 @@ func (r *Resource) InternalValidate(topSchemaMap schemaMap, writable bool) error
 		return errors.New("resource is nil")
 	}
 
+	if (r.Lock == nil) != (r.Unlock == nil) {
+		return fmt.Errorf("must implement both Lock and Unlock, or neither")
+	}
+
 	if !writable {
 		if r.Create != nil || r.Update != nil || r.Delete != nil {
 			return fmt.Errorf("must not implement Create, Update or Delete")
 		}
 
+		if r.Lock != nil {
+			return fmt.Errorf("must not implement Lock or Unlock")
+		}
+
+		if r.MoveState != nil {
+			return fmt.Errorf("must not implement MoveState")
+		}
+
 		// CustomizeDiff cannot be defined for read-only resources
 		if r.CustomizeDiff != nil {
 			return fmt.Errorf("cannot implement CustomizeDiff")
@@ -850,6 +850,40 @@ func TestResourceInternalValidate(t *testing.T) {
 			false,
 			true,
 		},
+
+		14: { // Lock without Unlock
+			&Resource{
+				Create: func(d *ResourceData, meta interface{}) error { return nil },
+				Read:   func(d *ResourceData, meta interface{}) error { return nil },
+				Delete: func(d *ResourceData, meta interface{}) error { return nil },
+				Schema: map[string]*Schema{
+					"goo": &Schema{
+						Type:     TypeInt,
+						Optional: true,
+						ForceNew: true,
+					},
+				},
+				Lock: func(*ResourceData, interface{}) (string, error) { return "", nil },
+			},
+			true,
+			true,
+		},
+
+		15: { // non-writable must not define Lock
+			&Resource{
+				Read: func(d *ResourceData, meta interface{}) error { return nil },
+				Schema: map[string]*Schema{
+					"goo": &Schema{
+						Type:     TypeInt,
+						Optional: true,
+					},
+				},
+				Lock:   func(*ResourceData, interface{}) (string, error) { return "", nil },
+				Unlock: func(string, interface{}) error { return nil },
+			},
+			false,
+			true,
+		},
 	}
 
 	for i, tc := range cases {
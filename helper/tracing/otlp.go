@@ -0,0 +1,239 @@
+package tracing
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultEndpoint is where spans are sent if no endpoint is configured,
+// which is where a collector listens for OTLP over HTTP by default.
+const defaultEndpoint = "http://localhost:4318"
+
+// otlpExporter exports spans using OTLP over HTTP, encoded as JSON. It's
+// the only protocol that's supported, since it doesn't need the
+// OpenTelemetry SDK or gRPC.
+type otlpExporter struct {
+	endpoint string
+	headers  map[string]string
+	version  string
+	client   *http.Client
+}
+
+func newOTLPExporter(serviceVersion string) (*otlpExporter, error) {
+	protocol := os.Getenv(EnvTracesProtocol)
+	if protocol == "" {
+		protocol = os.Getenv(EnvProtocol)
+	}
+	if protocol != "" && protocol != "http/json" {
+		return nil, fmt.Errorf("the OTLP protocol %q isn't supported; only \"http/json\" is", protocol)
+	}
+
+	endpoint := os.Getenv(EnvTracesEndpoint)
+	if endpoint == "" {
+		base := os.Getenv(EnvEndpoint)
+		if base == "" {
+			base = defaultEndpoint
+		}
+		endpoint = strings.TrimSuffix(base, "/") + "/v1/traces"
+	}
+	if u, err := url.Parse(endpoint); err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid OTLP endpoint %q", endpoint)
+	}
+
+	headers := make(map[string]string)
+	for _, env := range []string{EnvHeaders, EnvTracesHeaders} {
+		h, err := parseHeaders(os.Getenv(env))
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %s", env, err)
+		}
+		for k, v := range h {
+			headers[k] = v
+		}
+	}
+
+	return &otlpExporter{
+		endpoint: endpoint,
+		headers:  headers,
+		version:  serviceVersion,
+		client:   &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// parseHeaders parses headers in the form that OTEL_EXPORTER_OTLP_HEADERS
+// has, which is a comma-separated list of key=value, where the values are
+// URL-encoded.
+func parseHeaders(s string) (map[string]string, error) {
+	headers := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, fmt.Errorf("%q isn't of the form key=value", pair)
+		}
+		v, err := url.QueryUnescape(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return nil, fmt.Errorf("%q has an invalid value: %s", pair, err)
+		}
+		headers[strings.TrimSpace(parts[0])] = v
+	}
+	return headers, nil
+}
+
+func (e *otlpExporter) Export(spans []*Span) error {
+	body, err := json.Marshal(e.request(spans))
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", e.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+// The types below are the JSON encoding of an OTLP
+// ExportTraceServiceRequest.
+
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              SpanKind        `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            *otlpStatus     `json:"status,omitempty"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue *string `json:"stringValue,omitempty"`
+	BoolValue   *bool   `json:"boolValue,omitempty"`
+
+	// Integers are encoded as strings, since they're 64-bit.
+	IntValue *string `json:"intValue,omitempty"`
+}
+
+// otlpStatusError is the code of the status of a span whose work failed.
+const otlpStatusError = 2
+
+func (e *otlpExporter) request(spans []*Span) *otlpRequest {
+	encoded := make([]otlpSpan, 0, len(spans))
+	for _, s := range spans {
+		s.lock.Lock()
+		span := otlpSpan{
+			TraceID:           s.traceID,
+			SpanID:            s.spanID,
+			ParentSpanID:      s.parent,
+			Name:              s.name,
+			Kind:              s.kind,
+			StartTimeUnixNano: strconv.FormatInt(s.start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.end.UnixNano(), 10),
+			Attributes:        otlpAttributes(s.attrs),
+		}
+		if s.err != nil {
+			span.Status = &otlpStatus{Code: otlpStatusError, Message: s.err.Error()}
+		}
+		s.lock.Unlock()
+		encoded = append(encoded, span)
+	}
+
+	return &otlpRequest{
+		ResourceSpans: []otlpResourceSpans{
+			{
+				Resource: otlpResource{
+					Attributes: otlpAttributes([]Attribute{
+						String("service.name", "terraform"),
+						String("service.version", e.version),
+					}),
+				},
+				ScopeSpans: []otlpScopeSpans{
+					{
+						Scope: otlpScope{Name: "github.com/hashicorp/terraform", Version: e.version},
+						Spans: encoded,
+					},
+				},
+			},
+		},
+	}
+}
+
+func otlpAttributes(attrs []Attribute) []otlpAttribute {
+	result := make([]otlpAttribute, 0, len(attrs))
+	for _, a := range attrs {
+		var v otlpValue
+		switch val := a.Value.(type) {
+		case string:
+			v.StringValue = &val
+		case bool:
+			v.BoolValue = &val
+		case int64:
+			s := strconv.FormatInt(val, 10)
+			v.IntValue = &s
+		default:
+			s := fmt.Sprintf("%v", val)
+			v.StringValue = &s
+		}
+		result = append(result, otlpAttribute{Key: a.Key, Value: v})
+	}
+	return result
+}
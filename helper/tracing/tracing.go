@@ -0,0 +1,293 @@
+// Package tracing records the spans of work that Terraform does, such as
+// commands, backend operations, graph walks and provider calls, and exports
+// them to an OpenTelemetry collector using OTLP, if that's configured with
+// the standard OpenTelemetry environment variables.
+//
+// Tracing is off unless OTEL_TRACES_EXPORTER is "otlp". When it's off, Start
+// returns nil and the methods of a nil *Span do nothing, so that code can be
+// instrumented without checking whether tracing is on.
+package tracing
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// These are the environment variables that configure tracing, which are
+// those that OpenTelemetry SDKs use.
+const (
+	EnvTracesExporter = "OTEL_TRACES_EXPORTER"
+	EnvEndpoint       = "OTEL_EXPORTER_OTLP_ENDPOINT"
+	EnvTracesEndpoint = "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"
+	EnvHeaders        = "OTEL_EXPORTER_OTLP_HEADERS"
+	EnvTracesHeaders  = "OTEL_EXPORTER_OTLP_TRACES_HEADERS"
+	EnvProtocol       = "OTEL_EXPORTER_OTLP_PROTOCOL"
+	EnvTracesProtocol = "OTEL_EXPORTER_OTLP_TRACES_PROTOCOL"
+)
+
+// Attribute is an attribute of a span, such as the address of the resource
+// that the span is for.
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+// String returns a string attribute.
+func String(key, value string) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Int returns an integer attribute.
+func Int(key string, value int) Attribute {
+	return Attribute{Key: key, Value: int64(value)}
+}
+
+// Bool returns a boolean attribute.
+func Bool(key string, value bool) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// SpanKind is the kind of a span, as OpenTelemetry defines them.
+type SpanKind int
+
+const (
+	SpanKindInternal SpanKind = 1
+	SpanKindClient   SpanKind = 3
+)
+
+// Span is a span of work, such as the walk of a graph or a call to a
+// provider.
+type Span struct {
+	tracer *tracer
+
+	traceID string
+	spanID  string
+	parent  string
+
+	name  string
+	kind  SpanKind
+	start time.Time
+
+	lock  sync.Mutex
+	end   time.Time
+	attrs []Attribute
+	err   error
+	ended bool
+}
+
+// tracer is the tracer of the process, which is nil if tracing is off.
+var (
+	tracerLock sync.Mutex
+	current    *tracer
+)
+
+type tracer struct {
+	exporter exporter
+
+	lock sync.Mutex
+
+	// stack are the spans that were entered with Enter and haven't ended,
+	// the last of which is the parent of the spans that are started
+	// without one.
+	stack []*Span
+	spans []*Span
+}
+
+// exporter sends ended spans to where they're collected.
+type exporter interface {
+	Export(spans []*Span) error
+}
+
+// Init turns tracing on, if it's configured by the environment, and returns
+// a function that exports the spans that haven't been exported yet, which
+// must be called before the process exits.
+func Init(serviceVersion string) (func(), error) {
+	switch exp := os.Getenv(EnvTracesExporter); exp {
+	case "", "none":
+		return func() {}, nil
+	case "otlp":
+	default:
+		return func() {}, fmt.Errorf("%s must be \"otlp\" or \"none\", not %q", EnvTracesExporter, exp)
+	}
+
+	exp, err := newOTLPExporter(serviceVersion)
+	if err != nil {
+		return func() {}, err
+	}
+
+	t := &tracer{exporter: exp}
+	tracerLock.Lock()
+	current = t
+	tracerLock.Unlock()
+
+	log.Printf("[INFO] exporting traces to %s", exp.endpoint)
+	return func() {
+		tracerLock.Lock()
+		current = nil
+		tracerLock.Unlock()
+		t.flush()
+	}, nil
+}
+
+// Enabled returns whether tracing is on.
+func Enabled() bool {
+	return getTracer() != nil
+}
+
+func getTracer() *tracer {
+	tracerLock.Lock()
+	defer tracerLock.Unlock()
+	return current
+}
+
+// Start starts a span that's a child of the given one. If parent is nil, the
+// span is a child of the most recently entered span that hasn't ended, or
+// the root of a new trace if there is none.
+func Start(parent *Span, name string, attrs ...Attribute) *Span {
+	return StartKind(parent, SpanKindInternal, name, attrs...)
+}
+
+// StartKind is like Start, but starts a span of the given kind, such as a
+// client span for a call to another process.
+func StartKind(parent *Span, kind SpanKind, name string, attrs ...Attribute) *Span {
+	t := getTracer()
+	if t == nil {
+		return nil
+	}
+
+	if parent == nil {
+		t.lock.Lock()
+		if len(t.stack) > 0 {
+			parent = t.stack[len(t.stack)-1]
+		}
+		t.lock.Unlock()
+	}
+
+	s := &Span{
+		tracer: t,
+		spanID: newID(8),
+		name:   name,
+		kind:   kind,
+		start:  time.Now(),
+		attrs:  attrs,
+	}
+	if parent != nil {
+		s.traceID = parent.traceID
+		s.parent = parent.spanID
+	} else {
+		s.traceID = newID(16)
+	}
+	return s
+}
+
+// Enter is like Start, but the span is also the parent of the spans that
+// are started without one, until it ends. It's for the spans of work that's
+// done one at a time, such as commands and graph walks, so that the work
+// they do doesn't need to be given them.
+func Enter(name string, attrs ...Attribute) *Span {
+	s := Start(nil, name, attrs...)
+	if s == nil {
+		return nil
+	}
+
+	s.tracer.lock.Lock()
+	s.tracer.stack = append(s.tracer.stack, s)
+	s.tracer.lock.Unlock()
+	return s
+}
+
+// SetAttributes adds attributes to the span.
+func (s *Span) SetAttributes(attrs ...Attribute) {
+	if s == nil {
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.attrs = append(s.attrs, attrs...)
+}
+
+// SetError records that the work of the span failed with the given error,
+// if it isn't nil.
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.err = err
+}
+
+// End ends the span. Only the first call has any effect.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+
+	s.lock.Lock()
+	if s.ended {
+		s.lock.Unlock()
+		return
+	}
+	s.ended = true
+	s.end = time.Now()
+	s.lock.Unlock()
+
+	s.tracer.ended(s)
+}
+
+// batchSize is how many ended spans are kept before they're exported.
+const batchSize = 512
+
+func (t *tracer) ended(s *Span) {
+	t.lock.Lock()
+	for i := len(t.stack) - 1; i >= 0; i-- {
+		if t.stack[i] == s {
+			t.stack = append(t.stack[:i], t.stack[i+1:]...)
+			break
+		}
+	}
+
+	t.spans = append(t.spans, s)
+	var batch []*Span
+	if len(t.spans) >= batchSize {
+		batch, t.spans = t.spans, nil
+	}
+	t.lock.Unlock()
+
+	if batch != nil {
+		t.export(batch)
+	}
+}
+
+func (t *tracer) flush() {
+	t.lock.Lock()
+	batch := t.spans
+	t.spans = nil
+	t.lock.Unlock()
+
+	if len(batch) > 0 {
+		t.export(batch)
+	}
+}
+
+func (t *tracer) export(spans []*Span) {
+	if err := t.exporter.Export(spans); err != nil {
+		// Tracing must never make Terraform fail.
+		log.Printf("[WARN] failed to export %d trace spans: %s", len(spans), err)
+	}
+}
+
+func newID(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,231 @@
+package tracing
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestInit_off(t *testing.T) {
+	defer setenv(t, EnvTracesExporter, "")()
+
+	shutdown, err := Init("0.0.0")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer shutdown()
+
+	if Enabled() {
+		t.Fatal("tracing should be off")
+	}
+
+	// A nil span must be safe to use.
+	span := Enter("test")
+	if span != nil {
+		t.Fatalf("expected no span, got %#v", span)
+	}
+	span.SetAttributes(String("foo", "bar"))
+	span.SetError(errors.New("failed"))
+	span.End()
+}
+
+func TestInit_invalid(t *testing.T) {
+	cases := map[string]map[string]string{
+		"exporter": {
+			EnvTracesExporter: "zipkin",
+		},
+		"protocol": {
+			EnvTracesExporter: "otlp",
+			EnvProtocol:       "grpc",
+		},
+		"endpoint": {
+			EnvTracesExporter: "otlp",
+			EnvTracesEndpoint: "localhost",
+		},
+		"headers": {
+			EnvTracesExporter: "otlp",
+			EnvHeaders:        "foo",
+		},
+	}
+
+	for name, env := range cases {
+		t.Run(name, func(t *testing.T) {
+			for k, v := range env {
+				defer setenv(t, k, v)()
+			}
+
+			shutdown, err := Init("0.0.0")
+			shutdown()
+			if err == nil {
+				t.Fatal("should error")
+			}
+			if Enabled() {
+				t.Fatal("tracing should be off")
+			}
+		})
+	}
+}
+
+func TestExport(t *testing.T) {
+	var body []byte
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/traces" {
+			t.Errorf("wrong path %q", r.URL.Path)
+		}
+		body, _ = ioutil.ReadAll(r.Body)
+		header = r.Header
+	}))
+	defer server.Close()
+
+	defer setenv(t, EnvTracesExporter, "otlp")()
+	defer setenv(t, EnvEndpoint, server.URL+"/")()
+	defer setenv(t, EnvHeaders, "authorization=Bearer%20abc,x-tenant=foo")()
+
+	shutdown, err := Init("1.2.3")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	root := Enter("terraform apply", String("terraform.command", "apply"))
+	child := StartKind(nil, SpanKindClient, "Plugin.Apply",
+		String("terraform.resource.address", "aws_instance.foo"))
+	child.SetError(errors.New("failed"))
+	child.End()
+	root.SetAttributes(Int("terraform.exit_code", 1), Bool("ok", false))
+	root.End()
+
+	// Once the root has ended, spans without a parent start new traces.
+	other := Start(nil, "other")
+	other.End()
+
+	shutdown()
+	if Enabled() {
+		t.Fatal("tracing should be off after shutdown")
+	}
+
+	if got := header.Get("Authorization"); got != "Bearer abc" {
+		t.Fatalf("wrong authorization header %q", got)
+	}
+	if got := header.Get("X-Tenant"); got != "foo" {
+		t.Fatalf("wrong tenant header %q", got)
+	}
+	if got := header.Get("Content-Type"); got != "application/json" {
+		t.Fatalf("wrong content type %q", got)
+	}
+
+	var req otlpRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		t.Fatalf("invalid request body: %s\n%s", err, body)
+	}
+	if len(req.ResourceSpans) != 1 || len(req.ResourceSpans[0].ScopeSpans) != 1 {
+		t.Fatalf("wrong request: %s", body)
+	}
+
+	resource := attributeMap(req.ResourceSpans[0].Resource.Attributes)
+	if resource["service.name"] != "terraform" || resource["service.version"] != "1.2.3" {
+		t.Fatalf("wrong resource attributes: %#v", resource)
+	}
+
+	spans := req.ResourceSpans[0].ScopeSpans[0].Spans
+	if len(spans) != 3 {
+		t.Fatalf("expected 3 spans, got %d: %s", len(spans), body)
+	}
+	gotChild, gotRoot, gotOther := spans[0], spans[1], spans[2]
+
+	if gotRoot.ParentSpanID != "" {
+		t.Fatalf("root has parent %q", gotRoot.ParentSpanID)
+	}
+	if gotChild.ParentSpanID != gotRoot.SpanID || gotChild.TraceID != gotRoot.TraceID {
+		t.Fatalf("child isn't a child of the root:\n%#v\n%#v", gotChild, gotRoot)
+	}
+	if gotOther.ParentSpanID != "" || gotOther.TraceID == gotRoot.TraceID {
+		t.Fatalf("other span should start a new trace: %#v", gotOther)
+	}
+	if len(gotRoot.TraceID) != 32 || len(gotRoot.SpanID) != 16 {
+		t.Fatalf("wrong ID lengths: %#v", gotRoot)
+	}
+
+	if gotChild.Kind != SpanKindClient || gotRoot.Kind != SpanKindInternal {
+		t.Fatalf("wrong kinds %d and %d", gotChild.Kind, gotRoot.Kind)
+	}
+	if gotChild.Status == nil || gotChild.Status.Code != otlpStatusError || gotChild.Status.Message != "failed" {
+		t.Fatalf("wrong child status: %#v", gotChild.Status)
+	}
+	if gotRoot.Status != nil {
+		t.Fatalf("root should have no status: %#v", gotRoot.Status)
+	}
+
+	expected := map[string]interface{}{
+		"terraform.command":   "apply",
+		"terraform.exit_code": "1",
+		"ok":                  false,
+	}
+	if got := attributeMap(gotRoot.Attributes); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("wrong root attributes\ngot:  %#v\nwant: %#v", got, expected)
+	}
+}
+
+func TestExport_failure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "no", http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	exp := &otlpExporter{endpoint: server.URL, client: http.DefaultClient}
+	err := exp.Export([]*Span{{name: "foo"}})
+	if err == nil {
+		t.Fatal("should error")
+	}
+}
+
+func TestParseHeaders(t *testing.T) {
+	got, err := parseHeaders(" a = 1 ,b=x%3Dy,,")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	expected := map[string]string{"a": "1", "b": "x=y"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("got %#v, want %#v", got, expected)
+	}
+
+	if _, err := parseHeaders("=1"); err == nil {
+		t.Fatal("should error")
+	}
+}
+
+func attributeMap(attrs []otlpAttribute) map[string]interface{} {
+	result := make(map[string]interface{})
+	for _, a := range attrs {
+		switch {
+		case a.Value.StringValue != nil:
+			result[a.Key] = *a.Value.StringValue
+		case a.Value.IntValue != nil:
+			result[a.Key] = *a.Value.IntValue
+		case a.Value.BoolValue != nil:
+			result[a.Key] = *a.Value.BoolValue
+		}
+	}
+	return result
+}
+
+// setenv sets an environment variable and returns a function that restores
+// its old value.
+func setenv(t *testing.T, key, value string) func() {
+	old, ok := os.LookupEnv(key)
+	if err := os.Setenv(key, value); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	return func() {
+		if ok {
+			os.Setenv(key, old)
+		} else {
+			os.Unsetenv(key)
+		}
+	}
+}
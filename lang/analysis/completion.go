@@ -0,0 +1,344 @@
+package analysis
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/hcl/ast"
+	"github.com/hashicorp/terraform/config"
+	"github.com/hashicorp/terraform/config/configschema"
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+// CandidateKind is the kind of thing a completion candidate is.
+type CandidateKind int
+
+const (
+	// CandidateBlockType is a type of block, such as "resource" at the top
+	// level of a file or "lifecycle" within a resource.
+	CandidateBlockType CandidateKind = iota
+
+	// CandidateAttribute is the name of an argument.
+	CandidateAttribute
+
+	// CandidateReference is a reference to something in scope, for use in
+	// an interpolation.
+	CandidateReference
+)
+
+// Candidate is something that can be written at a position in a file.
+type Candidate struct {
+	Label  string
+	Kind   CandidateKind
+	Detail string
+
+	// Range is the range of the text that the candidate replaces, which is
+	// the part of it that's already been written.
+	Range tfdiags.SourceRange
+}
+
+// Completions returns what can be written at the given position of the
+// file with the given name and source, sorted by label: the arguments and
+// blocks of the block the position is in, at the start of a line, or the
+// references in scope, within an interpolation.
+//
+// The line of the position is expected to be incomplete, and is ignored
+// from the position on, but the rest of the file must be valid.
+func (m *Module) Completions(filename string, src []byte, pos tfdiags.SourcePos) ([]*Candidate, error) {
+	lines := strings.Split(string(src), "\n")
+	if pos.Line < 1 || pos.Line > len(lines) {
+		return nil, nil
+	}
+	line := lines[pos.Line-1]
+	col := pos.Column - 1
+	if col < 0 || col > len(line) {
+		return nil, nil
+	}
+	before := line[:col]
+
+	// The incomplete line is replaced by one that parses, with no more
+	// than what's before the position.
+	var prefix string
+	state := cursorState(before)
+	switch state {
+	case inInterpolation:
+		prefix = trailing(before, isReferenceChar)
+		lines[pos.Line-1] = before[:len(before)-len(prefix)] + `}"`
+	case inBody:
+		prefix = trailing(before, isNameChar)
+		if strings.TrimSpace(before[:len(before)-len(prefix)]) != "" {
+			// Only names can be completed, at the start of a line.
+			return nil, nil
+		}
+		lines[pos.Line-1] = ""
+	default:
+		return nil, nil
+	}
+
+	file, err := parseFile([]byte(strings.Join(lines, "\n")))
+	if err != nil {
+		return nil, err
+	}
+	ctx := findBlock(file, pos)
+
+	var all []*Candidate
+	if state == inInterpolation {
+		all = m.referenceCandidates(ctx, prefix)
+	} else {
+		all = m.bodyCandidates(ctx)
+	}
+
+	offset := col
+	for _, l := range lines[:pos.Line-1] {
+		offset += len(l) + 1
+	}
+	rng := tfdiags.SourceRange{
+		Filename: filename,
+		Start: tfdiags.SourcePos{
+			Line:   pos.Line,
+			Column: pos.Column - len(prefix),
+			Byte:   offset - len(prefix),
+		},
+		End: tfdiags.SourcePos{
+			Line:   pos.Line,
+			Column: pos.Column,
+			Byte:   offset,
+		},
+	}
+
+	seen := make(map[string]bool)
+	var ret []*Candidate
+	for _, c := range all {
+		if !strings.HasPrefix(c.Label, prefix) || seen[c.Label] {
+			continue
+		}
+		seen[c.Label] = true
+		c.Range = rng
+		ret = append(ret, c)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].Label < ret[j].Label
+	})
+	return ret, nil
+}
+
+// topLevelBlockTypes are the types of blocks at the top level of a file.
+var topLevelBlockTypes = []string{
+	"check", "data", "locals", "module", "moved", "output", "provider",
+	"resource", "terraform", "variable",
+}
+
+// metaArguments are the arguments and blocks that Terraform interprets
+// itself in each type of block, rather than its provider, by block type.
+// Those of nested blocks are keyed by the path to them, such as
+// "resource.lifecycle".
+var metaArguments = map[string]struct {
+	Attributes []string
+	Blocks     []string
+}{
+	"check":              {Blocks: []string{"assert", "data"}},
+	"check.assert":       {Attributes: []string{"condition", "error_message"}},
+	"data":               {Attributes: []string{"count", "depends_on", "provider"}},
+	"module":             {Attributes: []string{"providers", "source", "version"}},
+	"moved":              {Attributes: []string{"from", "to"}},
+	"output":             {Attributes: []string{"depends_on", "description", "sensitive", "value"}},
+	"provider":           {Attributes: []string{"alias", "version"}},
+	"resource":           {Attributes: []string{"count", "depends_on", "provider"}, Blocks: []string{"lifecycle", "provisioner"}},
+	"resource.lifecycle": {Attributes: []string{"create_before_destroy", "ignore_changes", "prevent_destroy"}},
+	"terraform":          {Attributes: []string{"required_version"}, Blocks: []string{"backend", "provider_meta", "required_providers"}},
+	"variable":           {Attributes: []string{"default", "description", "type"}},
+}
+
+// bodyCandidates returns the arguments and blocks that can be written in
+// the innermost block of the given context, except for the arguments that
+// are already set.
+func (m *Module) bodyCandidates(ctx *blockContext) []*Candidate {
+	var ret []*Candidate
+	if ctx == nil {
+		for _, name := range topLevelBlockTypes {
+			ret = append(ret, &Candidate{Label: name, Kind: CandidateBlockType, Detail: "block"})
+		}
+		return ret
+	}
+
+	set := make(map[string]bool)
+	for _, item := range ctx.Body.Items {
+		if _, ok := item.Val.(*ast.ObjectType); !ok && len(item.Keys) > 0 {
+			set[keyName(item.Keys[0])] = true
+		}
+	}
+
+	meta := metaArguments[strings.Join(append([]string{ctx.kind()}, ctx.Nested...), ".")]
+	for _, name := range meta.Attributes {
+		if !set[name] {
+			ret = append(ret, &Candidate{Label: name, Kind: CandidateAttribute, Detail: "meta-argument"})
+		}
+	}
+	for _, name := range meta.Blocks {
+		ret = append(ret, &Candidate{Label: name, Kind: CandidateBlockType, Detail: "block"})
+	}
+
+	if schema := m.blockSchema(ctx); schema != nil {
+		for name, attr := range schema.Attributes {
+			if set[name] || !(attr.Optional || attr.Required) {
+				continue
+			}
+			ret = append(ret, &Candidate{Label: name, Kind: CandidateAttribute, Detail: attributeDetail(attr)})
+		}
+		for name := range schema.BlockTypes {
+			ret = append(ret, &Candidate{Label: name, Kind: CandidateBlockType, Detail: "block"})
+		}
+	}
+
+	return ret
+}
+
+// referenceCandidates returns the references that are in scope in the
+// given context. The attributes of resources are only included once the
+// prefix has the resource's address, to keep the list short.
+func (m *Module) referenceCandidates(ctx *blockContext, prefix string) []*Candidate {
+	var ret []*Candidate
+	add := func(label, detail string) {
+		ret = append(ret, &Candidate{Label: label, Kind: CandidateReference, Detail: detail})
+	}
+
+	for _, v := range m.Config.Variables {
+		detail := "input variable"
+		if v.Description != "" {
+			detail = v.Description
+		}
+		add("var."+v.Name, detail)
+	}
+	for _, l := range m.Config.Locals {
+		add("local."+l.Name, "local value")
+	}
+	for _, mod := range m.Config.Modules {
+		add("module."+mod.Name, fmt.Sprintf("module %q", mod.Source))
+	}
+
+	self := m.resource(ctx)
+	for _, r := range m.Config.Resources {
+		if r == self {
+			// A resource can't refer to itself, except as "self".
+			continue
+		}
+		id := r.Id()
+		detail := "resource"
+		if r.Mode == config.DataResourceMode {
+			detail = "data source"
+		}
+		add(id, detail)
+
+		if strings.HasPrefix(prefix, id+".") {
+			if schema := m.resourceSchema(r.Mode, r.Type, r.ProviderFullName()); schema != nil {
+				for name, attr := range schema.Attributes {
+					add(id+"."+name, attributeDetail(attr))
+				}
+			}
+		}
+	}
+
+	add("path.cwd", "path")
+	add("path.module", "path")
+	add("path.root", "path")
+	add("terraform.workspace", "workspace name")
+
+	if self != nil {
+		add("count.index", "index of the instance")
+
+		// Provisioners and their connections can refer to the attributes
+		// of the instance they're provisioning.
+		for _, name := range ctx.Nested {
+			if name != "provisioner" && name != "connection" {
+				continue
+			}
+			if schema := m.resourceSchema(self.Mode, self.Type, self.ProviderFullName()); schema != nil {
+				for name, attr := range schema.Attributes {
+					add("self."+name, attributeDetail(attr))
+				}
+			}
+			break
+		}
+	}
+
+	return ret
+}
+
+// attributeDetail returns the description of an attribute for a
+// completion candidate, such as "required string".
+func attributeDetail(attr *configschema.Attribute) string {
+	switch {
+	case attr.Required:
+		return "required " + attr.Type.FriendlyName()
+	case attr.Optional:
+		return "optional " + attr.Type.FriendlyName()
+	default:
+		return "computed " + attr.Type.FriendlyName()
+	}
+}
+
+// cursorStateKind is where a position in a line is.
+type cursorStateKind int
+
+const (
+	inBody cursorStateKind = iota
+	inString
+	inInterpolation
+	inComment
+)
+
+// cursorState returns where the end of the given text, which is the start
+// of a line, is.
+func cursorState(text string) cursorStateKind {
+	// Interpolations can contain strings, which can contain
+	// interpolations, so the state is a stack.
+	stack := []cursorStateKind{inBody}
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch stack[len(stack)-1] {
+		case inBody:
+			switch {
+			case c == '"':
+				stack = append(stack, inString)
+			case c == '#' || strings.HasPrefix(text[i:], "//"):
+				return inComment
+			}
+		case inString:
+			switch {
+			case c == '\\':
+				i++
+			case c == '"':
+				stack = stack[:len(stack)-1]
+			case strings.HasPrefix(text[i:], "${"):
+				stack = append(stack, inInterpolation)
+				i++
+			}
+		case inInterpolation:
+			switch c {
+			case '"':
+				stack = append(stack, inString)
+			case '}':
+				stack = stack[:len(stack)-1]
+			}
+		}
+	}
+	return stack[len(stack)-1]
+}
+
+// trailing returns the longest suffix of s whose bytes all satisfy f.
+func trailing(s string, f func(byte) bool) string {
+	i := len(s)
+	for i > 0 && f(s[i-1]) {
+		i--
+	}
+	return s[i:]
+}
+
+func isNameChar(c byte) bool {
+	return c == '_' || c == '-' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+func isReferenceChar(c byte) bool {
+	return isNameChar(c) || c == '.' || c == '*'
+}
@@ -0,0 +1,206 @@
+package analysis
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+func TestModuleCompletions(t *testing.T) {
+	m := testModule(t, "basic")
+
+	cases := map[string]struct {
+		// Src is the source of the file, in which "|" is the position.
+		Src    string
+		Labels []string
+	}{
+		"top level": {
+			`
+variable "region" {}
+
+res|`,
+			[]string{"resource"},
+		},
+
+		"resource body": {
+			`
+resource "aws_instance" "web" {
+  ami   = "ami-123"
+  count = 2
+
+  |
+}`,
+			[]string{
+				"depends_on", "ebs_block_device", "instance_type", "lifecycle",
+				"provider", "provisioner",
+			},
+		},
+
+		"resource body prefix": {
+			`
+resource "aws_instance" "web" {
+  inst|
+}`,
+			[]string{"instance_type"},
+		},
+
+		"check body": {
+			`
+check "health" {
+  |
+}`,
+			[]string{"assert", "data"},
+		},
+
+		"nested block": {
+			`
+resource "aws_instance" "web" {
+  ebs_block_device {
+    |
+  }
+}`,
+			[]string{"device_name"},
+		},
+
+		"lifecycle": {
+			`
+resource "aws_instance" "web" {
+  lifecycle {
+    pre|
+  }
+}`,
+			[]string{"prevent_destroy"},
+		},
+
+		"provider": {
+			`
+provider "aws" {
+  |
+}`,
+			[]string{"alias", "region", "version"},
+		},
+
+		"after a name": {
+			`
+resource "aws_instance" "web" {
+  ami = |
+}`,
+			nil,
+		},
+
+		"in a string": {
+			`
+resource "aws_instance" "web" {
+  ami = "var.|
+}`,
+			nil,
+		},
+
+		"variables": {
+			`
+resource "aws_instance" "web" {
+  ami = "${var.|
+}`,
+			[]string{"var.region"},
+		},
+
+		"references": {
+			`
+resource "aws_instance" "web" {
+  ami = "${|
+}`,
+			[]string{
+				"count.index", "data.aws_ami.ubuntu", "local.name",
+				"module.network", "path.cwd", "path.module", "path.root",
+				"terraform.workspace", "var.region",
+			},
+		},
+
+		"resource attributes": {
+			`
+resource "aws_instance" "web" {
+  ami = "ami-${data.aws_ami.ubuntu.|
+}`,
+			[]string{
+				"data.aws_ami.ubuntu.id", "data.aws_ami.ubuntu.most_recent",
+			},
+		},
+
+		"outside of a resource": {
+			`
+output "ami" {
+  value = "${aws_|
+}`,
+			[]string{"aws_instance.web"},
+		},
+
+		"self": {
+			`
+resource "aws_instance" "web" {
+  provisioner "local-exec" {
+    command = "echo ${self.|
+  }
+}`,
+			[]string{"self.ami", "self.id", "self.instance_type"},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			src, pos := testCursor(tc.Src)
+			cs, err := m.Completions("main.tf", src, pos)
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+
+			var labels []string
+			for _, c := range cs {
+				labels = append(labels, c.Label)
+			}
+			if !reflect.DeepEqual(labels, tc.Labels) {
+				t.Fatalf("bad: %#v", labels)
+			}
+		})
+	}
+}
+
+func TestModuleCompletions_range(t *testing.T) {
+	m := testModule(t, "basic")
+
+	src, pos := testCursor("\nresource \"aws_instance\" \"web\" {\n  ami = \"${var.re|\n}")
+	cs, err := m.Completions("main.tf", src, pos)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(cs) != 1 {
+		t.Fatalf("bad: %#v", cs)
+	}
+
+	c := cs[0]
+	if c.Kind != CandidateReference || c.Detail != "The region to deploy in" {
+		t.Fatalf("bad: %#v", c)
+	}
+	expected := tfdiags.SourceRange{
+		Filename: "main.tf",
+		Start:    tfdiags.SourcePos{Line: 3, Column: 12, Byte: 44},
+		End:      tfdiags.SourcePos{Line: 3, Column: 18, Byte: 50},
+	}
+	if !reflect.DeepEqual(c.Range, expected) {
+		t.Fatalf("bad: %#v", c.Range)
+	}
+	if got := string(src[c.Range.Start.Byte:c.Range.End.Byte]); got != "var.re" {
+		t.Fatalf("bad: %q", got)
+	}
+}
+
+// testCursor returns the given source without the "|" in it, and the
+// position of the "|".
+func testCursor(s string) ([]byte, tfdiags.SourcePos) {
+	i := strings.Index(s, "|")
+	before := s[:i]
+	line := strings.Count(before, "\n") + 1
+	col := i - strings.LastIndex(before, "\n")
+	return []byte(before + s[i+1:]), tfdiags.SourcePos{Line: line, Column: col, Byte: i}
+}
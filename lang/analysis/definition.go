@@ -0,0 +1,141 @@
+package analysis
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/hcl/ast"
+	"github.com/hashicorp/hcl/hcl/token"
+	"github.com/hashicorp/hil"
+	hilast "github.com/hashicorp/hil/ast"
+	"github.com/hashicorp/terraform/config"
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+// Reference is a reference in an interpolation, such as "var.region" or
+// "aws_instance.web.id".
+type Reference struct {
+	Name  string
+	Range tfdiags.SourceRange
+
+	// DeclRange is the range of the declaration the reference refers to,
+	// if it refers to one the module has.
+	DeclRange tfdiags.SourceRange
+}
+
+// Definition returns the reference at the given position of the file with
+// the given name and source, along with the range of the declaration it
+// refers to. It returns nil if there's no reference at the position, or if
+// the module declares nothing by the referenced name, such as for
+// references to paths.
+func (m *Module) Definition(filename string, src []byte, pos tfdiags.SourcePos) (*Reference, error) {
+	file, err := parseFile(src)
+	if err != nil {
+		return nil, err
+	}
+
+	var ref *Reference
+	ast.Walk(file.Node, func(n ast.Node) (ast.Node, bool) {
+		lit, ok := n.(*ast.LiteralType)
+		if !ok || ref != nil {
+			return n, ref == nil
+		}
+		for _, r := range stringReferences(filename, lit.Token) {
+			if posIn(pos, r.Range) {
+				ref = r
+				break
+			}
+		}
+		return n, true
+	})
+	if ref == nil {
+		return nil, nil
+	}
+
+	rng, ok := m.declRange(ref.Name)
+	if !ok {
+		return nil, nil
+	}
+	ref.DeclRange = rng
+	return ref, nil
+}
+
+// declRange returns the range of the declaration that the reference with
+// the given name refers to, if the module has it.
+func (m *Module) declRange(name string) (tfdiags.SourceRange, bool) {
+	v, err := config.NewInterpolatedVariable(name)
+	if err != nil {
+		return tfdiags.SourceRange{}, false
+	}
+
+	switch v := v.(type) {
+	case *config.UserVariable:
+		for _, d := range m.Config.Variables {
+			if d.Name == v.Name {
+				return d.DeclRange, true
+			}
+		}
+	case *config.LocalVariable:
+		for _, d := range m.Config.Locals {
+			if d.Name == v.Name {
+				return d.DeclRange, true
+			}
+		}
+	case *config.ModuleVariable:
+		for _, d := range m.Config.Modules {
+			if d.Name == v.Name {
+				return d.DeclRange, true
+			}
+		}
+	case *config.ResourceVariable:
+		for _, d := range m.Config.Resources {
+			if d.Mode == v.Mode && d.Type == v.Type && d.Name == v.Name {
+				return d.DeclRange, true
+			}
+		}
+	}
+
+	return tfdiags.SourceRange{}, false
+}
+
+// stringReferences returns the references in the interpolations of the
+// given string token, in the order they appear. It returns nil for strings
+// that can't be parsed, and for heredocs, whose references aren't located.
+func stringReferences(filename string, tok token.Token) []*Reference {
+	if tok.Type != token.STRING || len(tok.Text) < 2 || !strings.Contains(tok.Text, "${") {
+		return nil
+	}
+
+	// The references are located in the text as it's written, between the
+	// quotes, so that their positions match the file.
+	text := tok.Text[1 : len(tok.Text)-1]
+	root, err := hil.ParseWithPosition(text, hilast.Pos{
+		Line:   tok.Pos.Line,
+		Column: tok.Pos.Column + 1,
+	})
+	if err != nil {
+		return nil
+	}
+
+	var refs []*Reference
+	root.Accept(func(n hilast.Node) hilast.Node {
+		if va, ok := n.(*hilast.VariableAccess); ok {
+			p := va.Pos()
+			start := token.Pos{
+				Line:   p.Line,
+				Column: p.Column,
+				Offset: tok.Pos.Offset + p.Column - tok.Pos.Column,
+			}
+			refs = append(refs, &Reference{
+				Name:  va.Name,
+				Range: textRange(filename, start, va.Name),
+			})
+		}
+		return n
+	})
+
+	sort.Slice(refs, func(i, j int) bool {
+		return refs[i].Range.Start.Column < refs[j].Range.Start.Column
+	})
+	return refs
+}
@@ -0,0 +1,70 @@
+package analysis
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+func TestModuleDefinition(t *testing.T) {
+	m := testModule(t, "basic")
+	src := testSource(t, "basic", "main.tf")
+
+	cases := []struct {
+		Pos      tfdiags.SourcePos
+		Name     string
+		Start    int // column of the reference
+		DeclLine int
+	}{
+		{tfdiags.SourcePos{Line: 10, Column: 16}, "var.region", 15, 1},
+		{tfdiags.SourcePos{Line: 10, Column: 24}, "var.region", 15, 1},
+		{tfdiags.SourcePos{Line: 22, Column: 20}, "data.aws_ami.ubuntu.id", 14, 17},
+		{tfdiags.SourcePos{Line: 26, Column: 15}, "local.name", 15, 6},
+	}
+
+	for _, tc := range cases {
+		ref, err := m.Definition("main.tf", src, tc.Pos)
+		if err != nil {
+			t.Fatalf("%d:%d: err: %s", tc.Pos.Line, tc.Pos.Column, err)
+		}
+		if ref == nil {
+			t.Errorf("%d:%d: expected a reference", tc.Pos.Line, tc.Pos.Column)
+			continue
+		}
+		if ref.Name != tc.Name {
+			t.Errorf("%d:%d: bad name: %s", tc.Pos.Line, tc.Pos.Column, ref.Name)
+		}
+		if ref.Range.Start.Line != tc.Pos.Line || ref.Range.Start.Column != tc.Start {
+			t.Errorf("%d:%d: bad range: %#v", tc.Pos.Line, tc.Pos.Column, ref.Range)
+		}
+		if ref.Range.End.Column != tc.Start+len(tc.Name) {
+			t.Errorf("%d:%d: bad range end: %#v", tc.Pos.Line, tc.Pos.Column, ref.Range)
+		}
+		if ref.Range.Start.Byte >= len(src) || string(src[ref.Range.Start.Byte:ref.Range.End.Byte]) != tc.Name {
+			t.Errorf("%d:%d: bad range bytes: %#v", tc.Pos.Line, tc.Pos.Column, ref.Range)
+		}
+		if ref.DeclRange.Start.Line != tc.DeclLine {
+			t.Errorf("%d:%d: bad declaration: %#v", tc.Pos.Line, tc.Pos.Column, ref.DeclRange)
+		}
+	}
+}
+
+func TestModuleDefinition_none(t *testing.T) {
+	m := testModule(t, "basic")
+	src := testSource(t, "basic", "main.tf")
+
+	for _, pos := range []tfdiags.SourcePos{
+		// Outside of any interpolation.
+		{Line: 2, Column: 20},
+		// A reference to something that isn't declared.
+		{Line: 26, Column: 32},
+	} {
+		ref, err := m.Definition("main.tf", src, pos)
+		if err != nil {
+			t.Fatalf("%d:%d: err: %s", pos.Line, pos.Column, err)
+		}
+		if ref != nil {
+			t.Errorf("%d:%d: expected nothing, got %#v", pos.Line, pos.Column, ref)
+		}
+	}
+}
@@ -0,0 +1,11 @@
+// Package analysis answers the questions that editors ask about a module's
+// configuration: what can be written at a position in a file, what a
+// reference refers to, and what each part of a file is. It follows the same
+// rules as the rest of Terraform, so that language servers can depend on it
+// rather than reimplementing them.
+//
+// It works on the native syntax only, and on the source of one file at a
+// time, which may differ from the file on disk while it's being edited. The
+// declarations that are in scope are those of the module's configuration as
+// it was last loaded.
+package analysis
@@ -0,0 +1,256 @@
+package analysis
+
+import (
+	"strings"
+
+	"github.com/hashicorp/hcl/hcl/ast"
+	hclparser "github.com/hashicorp/hcl/hcl/parser"
+	"github.com/hashicorp/hcl/hcl/token"
+	"github.com/hashicorp/terraform/config"
+	"github.com/hashicorp/terraform/config/configschema"
+	"github.com/hashicorp/terraform/terraform"
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+// Module is a module's configuration, along with the schemas of the
+// providers it uses, which the analysis of its files is based on.
+type Module struct {
+	Config *config.Config
+
+	// Schemas are the schemas of the module's providers, if they're
+	// available. Without them, nothing is known about the arguments of
+	// providers, resources and data sources, or about their attributes.
+	Schemas *terraform.Schemas
+}
+
+// NewModule returns the analysis of the given module configuration, with
+// the given provider schemas, which may be nil.
+func NewModule(cfg *config.Config, schemas *terraform.Schemas) *Module {
+	return &Module{
+		Config:  cfg,
+		Schemas: schemas,
+	}
+}
+
+// parseFile parses the source of a file in the native syntax.
+func parseFile(src []byte) (*ast.File, error) {
+	return hclparser.Parse(src)
+}
+
+// blockContext describes the block that contains a position in a file.
+type blockContext struct {
+	// Labels are the type and labels of the top-level block, such as
+	// "resource", "aws_instance" and "web".
+	Labels []string
+
+	// Nested are the types of the nested blocks that contain the position,
+	// outermost first.
+	Nested []string
+
+	// Body is the body of the innermost block.
+	Body *ast.ObjectList
+}
+
+// findBlock returns the context of the block that contains the given
+// position, or nil if it's at the top level of the file.
+func findBlock(file *ast.File, pos tfdiags.SourcePos) *blockContext {
+	list, ok := file.Node.(*ast.ObjectList)
+	if !ok {
+		return nil
+	}
+
+	for _, item := range list.Items {
+		ot, ok := item.Val.(*ast.ObjectType)
+		if !ok || !inBraces(ot, pos) {
+			continue
+		}
+
+		ctx := &blockContext{Body: ot.List}
+		for _, k := range item.Keys {
+			ctx.Labels = append(ctx.Labels, keyName(k))
+		}
+
+	nested:
+		for {
+			for _, item := range ctx.Body.Items {
+				ot, ok := item.Val.(*ast.ObjectType)
+				if !ok || len(item.Keys) == 0 || !inBraces(ot, pos) {
+					continue
+				}
+				ctx.Nested = append(ctx.Nested, keyName(item.Keys[0]))
+				ctx.Body = ot.List
+				continue nested
+			}
+			break
+		}
+
+		return ctx
+	}
+
+	return nil
+}
+
+// kind returns the type of the top-level block, such as "resource".
+func (c *blockContext) kind() string {
+	if c == nil || len(c.Labels) == 0 {
+		return ""
+	}
+	return c.Labels[0]
+}
+
+// label returns the i'th label of the top-level block, or "" if it has no
+// such label.
+func (c *blockContext) label(i int) string {
+	if c == nil || i+1 >= len(c.Labels) {
+		return ""
+	}
+	return c.Labels[i+1]
+}
+
+// resource returns the resource declared by the top-level block, if it's
+// a resource or data block that's in the configuration.
+func (m *Module) resource(ctx *blockContext) *config.Resource {
+	mode := config.ManagedResourceMode
+	switch ctx.kind() {
+	case "resource":
+	case "data":
+		mode = config.DataResourceMode
+	default:
+		return nil
+	}
+
+	for _, r := range m.Config.Resources {
+		if r.Mode == mode && r.Type == ctx.label(0) && r.Name == ctx.label(1) {
+			return r
+		}
+	}
+	return nil
+}
+
+// providerSchema returns the schema of the provider of the given type, or
+// nil if it's unknown.
+func (m *Module) providerSchema(name string) *terraform.ProviderSchema {
+	if m.Schemas == nil {
+		return nil
+	}
+	return m.Schemas.Providers[name]
+}
+
+// resourceSchema returns the schema of the given type of resource, or nil
+// if it's unknown. The provider is the full name of the provider that
+// manages the resource, or "" for the default provider of the type.
+func (m *Module) resourceSchema(mode config.ResourceMode, typeName, provider string) *configschema.Block {
+	if provider == "" {
+		provider = config.ResourceProviderFullName(typeName, "")
+	}
+	schema := m.providerSchema(strings.SplitN(provider, ".", 2)[0])
+	if schema == nil {
+		return nil
+	}
+	if mode == config.DataResourceMode {
+		return schema.DataSources[typeName]
+	}
+	return schema.ResourceTypes[typeName]
+}
+
+// blockSchema returns the schema of the innermost block of the given
+// context, or nil if it's unknown.
+func (m *Module) blockSchema(ctx *blockContext) *configschema.Block {
+	var schema *configschema.Block
+	switch ctx.kind() {
+	case "resource", "data":
+		mode := config.ManagedResourceMode
+		if ctx.kind() == "data" {
+			mode = config.DataResourceMode
+		}
+		provider := ""
+		if r := m.resource(ctx); r != nil {
+			provider = r.ProviderFullName()
+		}
+		schema = m.resourceSchema(mode, ctx.label(0), provider)
+	case "provider":
+		if p := m.providerSchema(ctx.label(0)); p != nil {
+			schema = p.Provider
+		}
+	}
+
+	for _, name := range ctx.Nested {
+		if schema == nil {
+			return nil
+		}
+		nested := schema.BlockTypes[name]
+		if nested == nil {
+			return nil
+		}
+		schema = &nested.Block
+	}
+
+	return schema
+}
+
+// inBraces returns true if the position is between the braces of the given
+// object.
+func inBraces(ot *ast.ObjectType, pos tfdiags.SourcePos) bool {
+	return posAfter(pos, ot.Lbrace) && !posAfter(pos, ot.Rbrace)
+}
+
+// posAfter returns true if the position is after the start of the given
+// token position.
+func posAfter(pos tfdiags.SourcePos, tp token.Pos) bool {
+	if pos.Line != tp.Line {
+		return pos.Line > tp.Line
+	}
+	return pos.Column > tp.Column
+}
+
+// posIn returns true if the position is within the given range.
+func posIn(pos tfdiags.SourcePos, rng tfdiags.SourceRange) bool {
+	if pos.Line < rng.Start.Line || pos.Line > rng.End.Line {
+		return false
+	}
+	if pos.Line == rng.Start.Line && pos.Column < rng.Start.Column {
+		return false
+	}
+	if pos.Line == rng.End.Line && pos.Column > rng.End.Column {
+		return false
+	}
+	return true
+}
+
+// keyName returns the name of an object key, without the quotes of a
+// quoted key.
+func keyName(k *ast.ObjectKey) string {
+	if s, ok := k.Token.Value().(string); ok {
+		return s
+	}
+	return k.Token.Text
+}
+
+// tokenRange returns the range of the given token in the named file.
+func tokenRange(filename string, tok token.Token) tfdiags.SourceRange {
+	return textRange(filename, tok.Pos, tok.Text)
+}
+
+// textRange returns the range of the given text, which starts at the given
+// position in the named file.
+func textRange(filename string, start token.Pos, text string) tfdiags.SourceRange {
+	end := tfdiags.SourcePos{
+		Line:   start.Line,
+		Column: start.Column + len(text),
+		Byte:   start.Offset + len(text),
+	}
+	if i := strings.LastIndex(text, "\n"); i >= 0 {
+		end.Line += strings.Count(text, "\n")
+		end.Column = len(text) - i
+	}
+
+	return tfdiags.SourceRange{
+		Filename: filename,
+		Start: tfdiags.SourcePos{
+			Line:   start.Line,
+			Column: start.Column,
+			Byte:   start.Offset,
+		},
+		End: end,
+	}
+}
@@ -0,0 +1,117 @@
+package analysis
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform/config"
+	"github.com/hashicorp/terraform/config/configschema"
+	"github.com/hashicorp/terraform/terraform"
+	"github.com/hashicorp/terraform/tfdiags"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// fixtureDir is the directory of the test fixtures.
+const fixtureDir = "./test-fixtures"
+
+// testModule returns the analysis of the module in the named fixture, with
+// the schemas of testSchemas.
+func testModule(t *testing.T, name string) *Module {
+	t.Helper()
+
+	cfg, err := config.LoadDir(filepath.Join(fixtureDir, name))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	return NewModule(cfg, testSchemas())
+}
+
+// testSource returns the source of the named file of a fixture.
+func testSource(t *testing.T, name, file string) []byte {
+	t.Helper()
+
+	src, err := ioutil.ReadFile(filepath.Join(fixtureDir, name, file))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	return src
+}
+
+func testSchemas() *terraform.Schemas {
+	return &terraform.Schemas{
+		Providers: terraform.ProviderSchemas{
+			"aws": {
+				Provider: &configschema.Block{
+					Attributes: map[string]*configschema.Attribute{
+						"region": {Type: cty.String, Required: true},
+					},
+				},
+				ResourceTypes: map[string]*configschema.Block{
+					"aws_instance": {
+						Attributes: map[string]*configschema.Attribute{
+							"ami":           {Type: cty.String, Required: true},
+							"instance_type": {Type: cty.String, Optional: true},
+							"id":            {Type: cty.String, Computed: true},
+						},
+						BlockTypes: map[string]*configschema.NestedBlock{
+							"ebs_block_device": {
+								Nesting: configschema.NestingList,
+								Block: configschema.Block{
+									Attributes: map[string]*configschema.Attribute{
+										"device_name": {Type: cty.String, Required: true},
+									},
+								},
+							},
+						},
+					},
+				},
+				DataSources: map[string]*configschema.Block{
+					"aws_ami": {
+						Attributes: map[string]*configschema.Attribute{
+							"most_recent": {Type: cty.Bool, Optional: true},
+							"id":          {Type: cty.String, Computed: true},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestFindBlock(t *testing.T) {
+	src := testSource(t, "basic", "main.tf")
+	file, err := parseFile(src)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	cases := []struct {
+		Pos    tfdiags.SourcePos
+		Labels []string
+		Nested []string
+	}{
+		{tfdiags.SourcePos{Line: 4, Column: 1}, nil, nil},
+		{tfdiags.SourcePos{Line: 2, Column: 3}, []string{"variable", "region"}, nil},
+		{tfdiags.SourcePos{Line: 23, Column: 3}, []string{"resource", "aws_instance", "web"}, nil},
+		{tfdiags.SourcePos{Line: 26, Column: 5}, []string{"resource", "aws_instance", "web"}, []string{"tags"}},
+	}
+
+	for _, tc := range cases {
+		ctx := findBlock(file, tc.Pos)
+		if tc.Labels == nil {
+			if ctx != nil {
+				t.Errorf("%d:%d: expected no block, got %#v", tc.Pos.Line, tc.Pos.Column, ctx)
+			}
+			continue
+		}
+		if ctx == nil {
+			t.Errorf("%d:%d: expected a block", tc.Pos.Line, tc.Pos.Column)
+			continue
+		}
+		if !reflect.DeepEqual(ctx.Labels, tc.Labels) || !reflect.DeepEqual(ctx.Nested, tc.Nested) {
+			t.Errorf("%d:%d: bad: %#v %#v", tc.Pos.Line, tc.Pos.Column, ctx.Labels, ctx.Nested)
+		}
+	}
+}
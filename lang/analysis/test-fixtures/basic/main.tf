@@ -0,0 +1,28 @@
+variable "region" {
+  description = "The region to deploy in"
+}
+
+locals {
+  name = "web"
+}
+
+provider "aws" {
+  region = "${var.region}"
+}
+
+module "network" {
+  source = "./network"
+}
+
+data "aws_ami" "ubuntu" {
+  most_recent = true
+}
+
+resource "aws_instance" "web" {
+  ami   = "${data.aws_ami.ubuntu.id}"
+  count = 2
+
+  tags {
+    Name = "${local.name}-${count.index}"
+  }
+}
@@ -0,0 +1,152 @@
+package analysis
+
+import (
+	"sort"
+
+	"github.com/hashicorp/hcl/hcl/ast"
+	"github.com/hashicorp/hcl/hcl/token"
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+// TokenType is the classification of a semantic token.
+type TokenType int
+
+const (
+	TokenComment TokenType = iota
+
+	// TokenBlockType is the type of a block, such as "resource" or
+	// "lifecycle".
+	TokenBlockType
+
+	// TokenBlockLabel is a label of a block, such as the type and name of
+	// a resource.
+	TokenBlockLabel
+
+	// TokenAttribute is the name of an argument.
+	TokenAttribute
+
+	// TokenString is a string, or the part of a string that's between its
+	// references.
+	TokenString
+
+	TokenNumber
+	TokenBool
+
+	// TokenReference is a reference in an interpolation, such as
+	// "var.region".
+	TokenReference
+)
+
+// Token is a semantic token: a range of a file and what it is.
+type Token struct {
+	Type  TokenType
+	Range tfdiags.SourceRange
+}
+
+// SemanticTokens returns the semantic tokens of the file with the given
+// name and source, in the order they appear. The tokens don't overlap, so
+// the references in a string split it into several string tokens.
+func SemanticTokens(filename string, src []byte) ([]Token, error) {
+	file, err := parseFile(src)
+	if err != nil {
+		return nil, err
+	}
+
+	var tokens []Token
+	for _, group := range file.Comments {
+		for _, c := range group.List {
+			tokens = append(tokens, Token{
+				Type:  TokenComment,
+				Range: textRange(filename, c.Start, c.Text),
+			})
+		}
+	}
+
+	if list, ok := file.Node.(*ast.ObjectList); ok {
+		tokens = append(tokens, bodyTokens(filename, list)...)
+	}
+
+	sort.SliceStable(tokens, func(i, j int) bool {
+		a, b := tokens[i].Range.Start, tokens[j].Range.Start
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return tokens, nil
+}
+
+// bodyTokens returns the tokens of the items of a body, in which those
+// that are objects are blocks and the others are arguments.
+func bodyTokens(filename string, list *ast.ObjectList) []Token {
+	var tokens []Token
+	for _, item := range list.Items {
+		if ot, ok := item.Val.(*ast.ObjectType); ok {
+			for i, k := range item.Keys {
+				typ := TokenBlockLabel
+				if i == 0 {
+					typ = TokenBlockType
+				}
+				tokens = append(tokens, Token{Type: typ, Range: tokenRange(filename, k.Token)})
+			}
+			tokens = append(tokens, bodyTokens(filename, ot.List)...)
+			continue
+		}
+
+		for _, k := range item.Keys {
+			tokens = append(tokens, Token{Type: TokenAttribute, Range: tokenRange(filename, k.Token)})
+		}
+		tokens = append(tokens, valueTokens(filename, item.Val)...)
+	}
+	return tokens
+}
+
+// valueTokens returns the tokens of an argument's value.
+func valueTokens(filename string, n ast.Node) []Token {
+	switch n := n.(type) {
+	case *ast.LiteralType:
+		return literalTokens(filename, n.Token)
+	case *ast.ListType:
+		var tokens []Token
+		for _, elem := range n.List {
+			tokens = append(tokens, valueTokens(filename, elem)...)
+		}
+		return tokens
+	case *ast.ObjectType:
+		// An object value, as in tags = { Name = "web" }, has the same
+		// syntax as a block.
+		return bodyTokens(filename, n.List)
+	}
+	return nil
+}
+
+// literalTokens returns the tokens of a literal value, splitting strings
+// around their references.
+func literalTokens(filename string, tok token.Token) []Token {
+	rng := tokenRange(filename, tok)
+	switch tok.Type {
+	case token.NUMBER, token.FLOAT:
+		return []Token{{Type: TokenNumber, Range: rng}}
+	case token.BOOL:
+		return []Token{{Type: TokenBool, Range: rng}}
+	case token.STRING, token.HEREDOC:
+	default:
+		return nil
+	}
+
+	var tokens []Token
+	start := rng.Start
+	for _, ref := range stringReferences(filename, tok) {
+		tokens = append(tokens, Token{
+			Type:  TokenString,
+			Range: tfdiags.SourceRange{Filename: filename, Start: start, End: ref.Range.Start},
+		})
+		tokens = append(tokens, Token{Type: TokenReference, Range: ref.Range})
+		start = ref.Range.End
+	}
+	tokens = append(tokens, Token{
+		Type:  TokenString,
+		Range: tfdiags.SourceRange{Filename: filename, Start: start, End: rng.End},
+	})
+	return tokens
+}
@@ -0,0 +1,54 @@
+package analysis
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSemanticTokens(t *testing.T) {
+	src := []byte(`# web server
+resource "aws_instance" "web" {
+  ami           = "ami-${var.version}"
+  count         = 2
+  ebs_optimized = true
+}
+`)
+
+	tokens, err := SemanticTokens("main.tf", src)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	type tok struct {
+		Type TokenType
+		Text string
+	}
+	var got []tok
+	for _, t := range tokens {
+		got = append(got, tok{t.Type, string(src[t.Range.Start.Byte:t.Range.End.Byte])})
+	}
+
+	expected := []tok{
+		{TokenComment, "# web server"},
+		{TokenBlockType, "resource"},
+		{TokenBlockLabel, `"aws_instance"`},
+		{TokenBlockLabel, `"web"`},
+		{TokenAttribute, "ami"},
+		{TokenString, `"ami-${`},
+		{TokenReference, "var.version"},
+		{TokenString, `}"`},
+		{TokenAttribute, "count"},
+		{TokenNumber, "2"},
+		{TokenAttribute, "ebs_optimized"},
+		{TokenBool, "true"},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("bad: %#v", got)
+	}
+}
+
+func TestSemanticTokens_invalid(t *testing.T) {
+	if _, err := SemanticTokens("main.tf", []byte(`resource "aws_instance" {`)); err == nil {
+		t.Fatal("expected an error")
+	}
+}
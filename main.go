@@ -15,7 +15,9 @@ import (
 
 	"github.com/hashicorp/go-plugin"
 	"github.com/hashicorp/terraform/command/format"
+	"github.com/hashicorp/terraform/helper/crash"
 	"github.com/hashicorp/terraform/helper/logging"
+	"github.com/hashicorp/terraform/helper/tracing"
 	"github.com/hashicorp/terraform/terraform"
 	"github.com/mattn/go-colorable"
 	"github.com/mattn/go-shellwords"
@@ -61,6 +63,17 @@ func realMain() int {
 		defer os.Remove(logTempFile.Name())
 		defer logTempFile.Close()
 
+		// The child records what it knows about its run to the crash
+		// context file, for the crash bundle if it crashes.
+		crashContextFile, err := ioutil.TempFile("", "terraform-crash-context")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Couldn't setup crash context file: %s", err)
+			return 1
+		}
+		crashContextFile.Close()
+		defer os.Remove(crashContextFile.Name())
+		os.Setenv(crash.EnvContextPath, crashContextFile.Name())
+
 		// Setup the prefixed readers that send data properly to
 		// stdout/stderr.
 		doneCh := make(chan struct{})
@@ -68,7 +81,7 @@ func realMain() int {
 		go copyOutput(outR, doneCh)
 
 		// Create the configuration for panicwrap and wrap our executable
-		wrapConfig.Handler = panicHandler(logTempFile)
+		wrapConfig.Handler = panicHandler(logTempFile, crashContextFile.Name())
 		wrapConfig.Writer = io.MultiWriter(logTempFile, logWriter)
 		wrapConfig.Stdout = outW
 		wrapConfig.IgnoreSignals = ignoreSignals
@@ -122,6 +135,14 @@ func wrappedMain() int {
 	log.Printf("[INFO] Go runtime version: %s", runtime.Version())
 	log.Printf("[INFO] CLI args: %#v", os.Args)
 
+	// Start tracing, if it's configured, and export the spans that are left
+	// when we exit.
+	shutdownTracing, err := tracing.Init(terraform.VersionString())
+	if err != nil {
+		Ui.Error(fmt.Sprintf("Tracing is misconfigured and will be disabled: %s", err))
+	}
+	defer shutdownTracing()
+
 	config, diags := LoadConfig()
 	if len(diags) > 0 {
 		// Since we haven't instantiated a command.Meta yet, we need to do
@@ -142,6 +163,21 @@ func wrappedMain() int {
 		}
 	}
 
+	// The args can start with a -chdir option, which switches to another
+	// working directory for the rest of the work, so that wrappers don't
+	// need to change directories themselves.
+	overrideWd, args, err := extractChdirOption(os.Args[1:])
+	if err != nil {
+		Ui.Error(fmt.Sprintf("Invalid -chdir option: %s", err))
+		return 1
+	}
+	if overrideWd != "" {
+		if err := os.Chdir(overrideWd); err != nil {
+			Ui.Error(fmt.Sprintf("Error handling -chdir option: %s", err))
+			return 1
+		}
+	}
+
 	// In tests, Commands may already be set to provide mock commands
 	if Commands == nil {
 		initCommands(config)
@@ -153,9 +189,7 @@ func wrappedMain() int {
 	// Make sure we clean up any managed plugins at the end of this
 	defer plugin.CleanupClients()
 
-	// Get the command line args.
 	binName := filepath.Base(os.Args[0])
-	args := os.Args[1:]
 
 	// Build the CLI so far, we do this so we can query the subcommand.
 	cliRunner := &cli.CLI{
@@ -211,12 +245,18 @@ func wrappedMain() int {
 	PluginOverrides.Providers = config.Providers
 	PluginOverrides.Provisioners = config.Provisioners
 
+	span := tracing.Enter("terraform "+cliRunner.Subcommand(),
+		tracing.String("terraform.command", cliRunner.Subcommand()))
+	defer span.End()
+
 	exitCode, err := cliRunner.Run()
 	if err != nil {
+		span.SetError(err)
 		Ui.Error(fmt.Sprintf("Error executing CLI: %s", err.Error()))
 		return 1
 	}
 
+	span.SetAttributes(tracing.Int("terraform.exit_code", exitCode))
 	return exitCode
 }
 
@@ -309,6 +349,38 @@ func copyOutput(r io.Reader, doneCh chan<- struct{}) {
 	wg.Wait()
 }
 
+// extractChdirOption returns the directory of the -chdir option at the start
+// of args, and args without it. The option has to come before the command,
+// and its value has to follow an equals sign, since the first argument that
+// isn't an option is the command.
+func extractChdirOption(args []string) (string, []string, error) {
+	for i, arg := range args {
+		if !strings.HasPrefix(arg, "-") {
+			// The command, which ends the global options
+			break
+		}
+
+		name := strings.TrimPrefix(strings.TrimPrefix(arg, "-"), "-")
+		if name == "chdir" {
+			return "", args, fmt.Errorf("must include an equals sign followed by a directory path, like -chdir=example")
+		}
+		if !strings.HasPrefix(name, "chdir=") {
+			continue
+		}
+
+		dir := strings.TrimPrefix(name, "chdir=")
+		if dir == "" {
+			return "", args, fmt.Errorf("must include a directory path after the equals sign, like -chdir=example")
+		}
+
+		newArgs := make([]string, 0, len(args)-1)
+		newArgs = append(newArgs, args[:i]...)
+		newArgs = append(newArgs, args[i+1:]...)
+		return dir, newArgs, nil
+	}
+	return "", args, nil
+}
+
 func mergeEnvArgs(envName string, cmd string, args []string) ([]string, error) {
 	v := os.Getenv(envName)
 	if v == "" {
@@ -2,7 +2,9 @@ package main
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 
@@ -264,3 +266,115 @@ func (c *testCommandCLI) Run(args []string) int {
 
 func (c *testCommandCLI) Synopsis() string { return "" }
 func (c *testCommandCLI) Help() string     { return "" }
+
+func TestMain_extractChdirOption(t *testing.T) {
+	cases := []struct {
+		Name     string
+		Args     []string
+		Dir      string
+		Expected []string
+		Err      bool
+	}{
+		{
+			"no option",
+			[]string{"plan", "-chdir=foo"},
+			"",
+			[]string{"plan", "-chdir=foo"},
+			false,
+		},
+		{
+			"option",
+			[]string{"-chdir=infra", "plan", "-out=tfplan"},
+			"infra",
+			[]string{"plan", "-out=tfplan"},
+			false,
+		},
+		{
+			"double dash, after other options",
+			[]string{"-v", "--chdir=infra"},
+			"infra",
+			[]string{"-v"},
+			false,
+		},
+		{
+			"no equals sign",
+			[]string{"-chdir", "infra", "plan"},
+			"",
+			nil,
+			true,
+		},
+		{
+			"empty",
+			[]string{"-chdir=", "plan"},
+			"",
+			nil,
+			true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			dir, args, err := extractChdirOption(tc.Args)
+			if (err != nil) != tc.Err {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if tc.Err {
+				return
+			}
+			if dir != tc.Dir {
+				t.Fatalf("wrong dir %q; want %q", dir, tc.Dir)
+			}
+			if !reflect.DeepEqual(args, tc.Expected) {
+				t.Fatalf("wrong args %#v; want %#v", args, tc.Expected)
+			}
+		})
+	}
+}
+
+func TestMain_chdir(t *testing.T) {
+	oldArgs := os.Args
+	defer func() { os.Args = oldArgs }()
+
+	oldWd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(oldWd)
+
+	td, err := ioutil.TempDir("", "tf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(td)
+
+	Commands = make(map[string]cli.CommandFactory)
+	defer func() {
+		Commands = nil
+	}()
+	testCommandName := "unit-test-chdir"
+	testCommand := &testCommandCLI{}
+	Commands[testCommandName] = func() (cli.Command, error) {
+		return testCommand, nil
+	}
+
+	os.Args = []string{oldArgs[0], "-chdir=" + td, testCommandName, "foo"}
+	if code := wrappedMain(); code != 0 {
+		t.Fatalf("bad: %d", code)
+	}
+
+	if !reflect.DeepEqual(testCommand.Args, []string{"foo"}) {
+		t.Fatalf("bad args: %#v", testCommand.Args)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := filepath.EvalSymlinks(td)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := filepath.EvalSymlinks(wd); got != want {
+		t.Fatalf("wrong working directory %q; want %q", got, want)
+	}
+}
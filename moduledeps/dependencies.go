@@ -15,6 +15,11 @@ type Providers map[ProviderInstance]ProviderDependency
 type ProviderDependency struct {
 	Constraints discovery.Constraints
 	Reason      ProviderDependencyReason
+
+	// Source is the normalized source address of the provider, such as
+	// "example.com/acme/widgets", or empty for the default provider of its
+	// type in the "hashicorp" namespace of the public registry.
+	Source string
 }
 
 // ProviderDependencyReason is an enumeration of reasons why a dependency might be
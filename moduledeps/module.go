@@ -1,10 +1,13 @@
 package moduledeps
 
 import (
+	"fmt"
 	"sort"
 	"strings"
 
+	hcl2 "github.com/hashicorp/hcl2/hcl"
 	"github.com/hashicorp/terraform/plugin/discovery"
+	"github.com/hashicorp/terraform/tfdiags"
 )
 
 // Module represents the dependencies of a single module, as well being
@@ -105,8 +108,8 @@ func (s sortModules) Swap(i, j int) {
 // Use AllPluginRequirements to flatten the dependencies for the
 // entire tree of modules.
 //
-// Requirements returned by this method include only version constraints,
-// and apply no particular SHA256 hash constraint.
+// Requirements returned by this method include only version constraints
+// and source addresses, and apply no particular SHA256 hash constraint.
 func (m *Module) PluginRequirements() discovery.PluginRequirements {
 	ret := make(discovery.PluginRequirements)
 	for inst, dep := range m.Providers {
@@ -118,15 +121,124 @@ func (m *Module) PluginRequirements() discovery.PluginRequirements {
 		pty := inst.Type()
 		if existing, exists := ret[pty]; exists {
 			ret[pty].Versions = existing.Versions.Append(dep.Constraints)
+			if existing.Source == "" {
+				ret[pty].Source = dep.Source
+			}
 		} else {
 			ret[pty] = &discovery.PluginConstraints{
 				Versions: dep.Constraints,
+				Source:   dep.Source,
 			}
 		}
 	}
 	return ret
 }
 
+// ProviderSourceConflicts returns an error for each provider type that the
+// modules in the tree require from more than one source address. Terraform
+// identifies providers by their type, so it can't use two different
+// providers of the same type in one configuration.
+//
+// Dependencies that only come from the state are ignored, since the state
+// doesn't record where their providers came from.
+func (m *Module) ProviderSourceConflicts() tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+
+	// sources maps each provider type to its source addresses, and each of
+	// those to the modules that require it.
+	sources := make(map[string]map[string][]string)
+	m.WalkTree(func(path []string, parent *Module, current *Module) error {
+		for inst, dep := range current.Providers {
+			if dep.Reason == ProviderDependencyFromState {
+				continue
+			}
+			pty := inst.Type()
+			src := dep.Source
+			if src == "" {
+				src = DefaultProviderSource(pty)
+			}
+			if sources[pty] == nil {
+				sources[pty] = make(map[string][]string)
+			}
+			addr := modulePathString(path)
+			if mods := sources[pty][src]; len(mods) == 0 || mods[len(mods)-1] != addr {
+				sources[pty][src] = append(mods, addr)
+			}
+		}
+		return nil
+	})
+
+	types := make([]string, 0, len(sources))
+	for pty := range sources {
+		types = append(types, pty)
+	}
+	sort.Strings(types)
+
+	for _, pty := range types {
+		if len(sources[pty]) < 2 {
+			continue
+		}
+
+		srcs := make([]string, 0, len(sources[pty]))
+		for src := range sources[pty] {
+			srcs = append(srcs, src)
+		}
+		sort.Strings(srcs)
+
+		var lines []string
+		for _, src := range srcs {
+			lines = append(lines, fmt.Sprintf("- %s, required by %s", src, strings.Join(sources[pty][src], ", ")))
+		}
+		diags = diags.Append(&hcl2.Diagnostic{
+			Severity: hcl2.DiagError,
+			Summary:  fmt.Sprintf("Conflicting sources for provider %q", pty),
+			Detail: fmt.Sprintf(
+				"The modules of this configuration require different providers named %q:\n%s\n\nA configuration can only use one provider of each type. A module that uses a provider without declaring it in its required_providers block uses the source its parent module declares, or %s if no ancestor declares one, so declaring the same source in every module that uses the provider resolves this.",
+				pty, strings.Join(lines, "\n"), DefaultProviderSource(pty),
+			),
+		})
+	}
+
+	return diags
+}
+
+// ProviderConstraints returns a description of the version constraints
+// that each module in the tree places on the provider of the given type,
+// one line per module that constrains it, for explaining why no version of
+// the provider is suitable.
+func (m *Module) ProviderConstraints(pty string) []string {
+	var lines []string
+	m.WalkTree(func(path []string, parent *Module, current *Module) error {
+		var cons discovery.Constraints
+		for inst, dep := range current.Providers {
+			if inst.Type() == pty {
+				cons = cons.Append(dep.Constraints)
+			}
+		}
+		if !cons.Unconstrained() {
+			lines = append(lines, fmt.Sprintf("%s: %s", modulePathString(path), cons))
+		}
+		return nil
+	})
+	return lines
+}
+
+// DefaultProviderSource returns the source address of the provider of the
+// given type that a module uses if it doesn't declare a source.
+func DefaultProviderSource(pty string) string {
+	return "hashicorp/" + pty
+}
+
+// modulePathString returns the path of a module as it's written in
+// addresses, such as "module.network.module.subnets", or "root" for the
+// root module.
+func modulePathString(path []string) string {
+	if len(path) <= 1 {
+		return "root"
+	}
+	return "module." + strings.Join(path[1:], ".module.")
+}
+
 // AllPluginRequirements calls PluginRequirements for the receiver and all
 // of its descendents, and merges the result into a single PluginRequirements
 // structure that would satisfy all of the modules together.
@@ -188,6 +300,10 @@ func (m *Module) Equal(other *Module) bool {
 		if dep.Constraints.String() != other.Providers[inst].Constraints.String() {
 			return false
 		}
+
+		if dep.Source != other.Providers[inst].Source {
+			return false
+		}
 	}
 
 	// Above we already checked that we have the same number of children
@@ -3,6 +3,7 @@ package moduledeps
 import (
 	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/hashicorp/terraform/plugin/discovery"
@@ -200,12 +201,19 @@ func TestModulePluginRequirements(t *testing.T) {
 			"baz": ProviderDependency{
 				Constraints: discovery.ConstraintStr(">=3.0.0").MustParse(),
 			},
+			"qux": ProviderDependency{
+				Constraints: discovery.AllVersions,
+				Source:      "example.com/acme/qux",
+			},
 		},
 	}
 
 	reqd := m.PluginRequirements()
-	if len(reqd) != 2 {
-		t.Errorf("wrong number of elements in %#v; want 2", reqd)
+	if len(reqd) != 3 {
+		t.Errorf("wrong number of elements in %#v; want 3", reqd)
+	}
+	if got, want := reqd["qux"].Source, "example.com/acme/qux"; got != want {
+		t.Errorf("wrong source for 'qux' %q; want %q", got, want)
 	}
 	if got, want := reqd["foo"].Versions.String(), ">=1.0.0,>=2.0.0"; got != want {
 		t.Errorf("wrong combination of versions for 'foo' %q; want %q", got, want)
@@ -214,3 +222,94 @@ func TestModulePluginRequirements(t *testing.T) {
 		t.Errorf("wrong combination of versions for 'baz' %q; want %q", got, want)
 	}
 }
+
+func TestModuleProviderSourceConflicts(t *testing.T) {
+	m := &Module{
+		Name: "root",
+		Providers: Providers{
+			"foo": ProviderDependency{
+				Reason: ProviderDependencyExplicit,
+				Source: "acme/foo",
+			},
+			"bar": ProviderDependency{
+				Reason: ProviderDependencyExplicit,
+				Source: "acme/bar",
+			},
+		},
+		Children: []*Module{
+			{
+				Name: "child",
+				Providers: Providers{
+					"foo": ProviderDependency{
+						Reason: ProviderDependencyImplicit,
+					},
+					"bar": ProviderDependency{
+						Reason: ProviderDependencyInherited,
+						Source: "acme/bar",
+					},
+				},
+			},
+			{
+				Name: "old",
+				Providers: Providers{
+					// The state doesn't record sources, so this isn't
+					// a conflict.
+					"bar": ProviderDependency{
+						Reason: ProviderDependencyFromState,
+					},
+				},
+			},
+		},
+	}
+
+	diags := m.ProviderSourceConflicts()
+	if len(diags) != 1 {
+		t.Fatalf("wrong number of diagnostics %d; want 1: %s", len(diags), diags.Err())
+	}
+	desc := diags[0].Description()
+	if got, want := desc.Summary, `Conflicting sources for provider "foo"`; got != want {
+		t.Errorf("wrong summary %q; want %q", got, want)
+	}
+	for _, want := range []string{"- acme/foo, required by root", "- hashicorp/foo, required by module.child"} {
+		if !strings.Contains(desc.Detail, want) {
+			t.Errorf("detail doesn't contain %q:\n%s", want, desc.Detail)
+		}
+	}
+}
+
+func TestModuleProviderConstraints(t *testing.T) {
+	m := &Module{
+		Name: "root",
+		Providers: Providers{
+			"foo": ProviderDependency{
+				Constraints: discovery.ConstraintStr("~> 1.0").MustParse(),
+			},
+		},
+		Children: []*Module{
+			{
+				Name: "child",
+				Providers: Providers{
+					"foo": ProviderDependency{
+						Constraints: discovery.ConstraintStr(">= 2.0").MustParse(),
+					},
+				},
+				Children: []*Module{
+					{
+						Name: "grandchild",
+						Providers: Providers{
+							"foo": ProviderDependency{
+								Constraints: discovery.AllVersions,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	got := m.ProviderConstraints("foo")
+	want := []string{"root: ~> 1.0", "module.child: >= 2.0"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("wrong constraints %#v; want %#v", got, want)
+	}
+}
@@ -0,0 +1,36 @@
+package moduletest
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform/repl"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+// Check evaluates the condition of the assertion with the given
+// interpolater, returning whether it holds. It's an error for the condition
+// to evaluate to anything but true or false.
+func (a *Assert) Check(i *terraform.Interpolater) (bool, error) {
+	expr := strings.TrimSpace(a.Condition)
+	if strings.HasPrefix(expr, "${") && strings.HasSuffix(expr, "}") && strings.Count(expr, "${") == 1 {
+		expr = strings.TrimSpace(expr[2 : len(expr)-1])
+	}
+
+	// The REPL evaluates expressions the same way as the console, which
+	// includes local values.
+	session := &repl.Session{Interpolater: i}
+	result, err := session.Handle(expr)
+	if err != nil {
+		return false, err
+	}
+
+	switch result {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	default:
+		return false, fmt.Errorf("condition must be true or false, got %s", result)
+	}
+}
@@ -0,0 +1,34 @@
+package moduletest
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestAssertCheck(t *testing.T) {
+	i := &terraform.Interpolater{}
+
+	cases := []struct {
+		Condition string
+		Want      bool
+		Err       bool
+	}{
+		{`${1 == 1}`, true, false},
+		{`1 == 2`, false, false},
+		{`${"a" == "a"}`, true, false},
+		{`"foo"`, false, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Condition, func(t *testing.T) {
+			got, err := (&Assert{Condition: tc.Condition}).Check(i)
+			if (err != nil) != tc.Err {
+				t.Fatalf("wrong error: %v", err)
+			}
+			if got != tc.Want {
+				t.Fatalf("got %t, want %t", got, tc.Want)
+			}
+		})
+	}
+}
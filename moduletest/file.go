@@ -0,0 +1,266 @@
+// Package moduletest reads the test files of modules and represents the
+// results of running them, for the "terraform test" command.
+package moduletest
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl"
+	"github.com/hashicorp/hcl/hcl/ast"
+)
+
+// FileExtension is the extension of test files.
+const FileExtension = ".tftest.hcl"
+
+// DefaultTestDirectory is the directory of a module, relative to the module,
+// that test files are discovered in by default.
+const DefaultTestDirectory = "tests"
+
+// File is a test file, which runs a sequence of plans and applies of the
+// module under test, sharing a state, and checks assertions after each.
+type File struct {
+	// Name is the path of the file, relative to the module.
+	Name string
+
+	// Variables are values of the variables of the module for all of the
+	// runs in the file.
+	Variables map[string]interface{}
+
+	Runs []*Run
+
+	// MockProviders are the providers that are replaced by mocks for the
+	// runs in the file, keyed by provider name.
+	MockProviders map[string]*MockProviderConfig
+}
+
+// Run is a single plan or apply of the module under test.
+type Run struct {
+	Name string
+
+	// Command is "apply", the default, or "plan".
+	Command string
+
+	// Variables override the variables of the file for this run.
+	Variables map[string]interface{}
+
+	Asserts []*Assert
+}
+
+// Assert is an assertion checked after a run.
+type Assert struct {
+	// Condition is an interpolation that must evaluate to true, such as
+	// "${aws_instance.web.instance_type == "t2.micro"}". The surrounding
+	// "${" and "}" may be left out.
+	Condition string
+
+	// ErrorMessage is reported when the condition is false.
+	ErrorMessage string
+}
+
+// MockProviderConfig configures a provider that is replaced by a mock.
+type MockProviderConfig struct {
+	Name string
+
+	// Defaults are values for the attributes of resources and data
+	// sources that aren't set in the configuration, which real providers
+	// would compute, keyed by attribute name.
+	Defaults map[string]string
+}
+
+// Commands that a run can perform.
+const (
+	CommandApply = "apply"
+	CommandPlan  = "plan"
+)
+
+type rawAssert struct {
+	Condition    string `hcl:"condition"`
+	ErrorMessage string `hcl:"error_message"`
+}
+
+// LoadFile reads the test file with the given filename, giving it the
+// given name.
+func LoadFile(filename, name string) (*File, error) {
+	src, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	root, err := hcl.Parse(string(src))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse test file %s: %s", name, err)
+	}
+	list, ok := root.Node.(*ast.ObjectList)
+	if !ok {
+		return nil, fmt.Errorf("test file %s doesn't have a root object", name)
+	}
+
+	f := &File{
+		Name:          name,
+		MockProviders: make(map[string]*MockProviderConfig),
+	}
+	if f.Variables, err = decodeVariables(list); err != nil {
+		return nil, fmt.Errorf("test file %s: %s", name, err)
+	}
+
+	seen := make(map[string]bool)
+	for _, item := range list.Filter("run").Items {
+		body, runName, err := namedBlock("run", item)
+		if err != nil {
+			return nil, fmt.Errorf("test file %s: %s", name, err)
+		}
+		if seen[runName] {
+			return nil, fmt.Errorf("test file %s has more than one run named %q", name, runName)
+		}
+		seen[runName] = true
+
+		run := &Run{Name: runName}
+		if o := body.Filter("command"); len(o.Items) > 0 {
+			if err := hcl.DecodeObject(&run.Command, o.Items[0].Val); err != nil {
+				return nil, fmt.Errorf("run %q in test file %s: invalid command: %s", runName, name, err)
+			}
+		}
+		switch run.Command {
+		case "":
+			run.Command = CommandApply
+		case CommandApply, CommandPlan:
+		default:
+			return nil, fmt.Errorf(
+				"run %q in test file %s has invalid command %q; must be %q or %q",
+				runName, name, run.Command, CommandApply, CommandPlan)
+		}
+
+		if run.Variables, err = decodeVariables(body); err != nil {
+			return nil, fmt.Errorf("run %q in test file %s: %s", runName, name, err)
+		}
+
+		for _, a := range body.Filter("assert").Items {
+			var raw rawAssert
+			if err := hcl.DecodeObject(&raw, a.Val); err != nil {
+				return nil, fmt.Errorf("run %q in test file %s: invalid assert: %s", runName, name, err)
+			}
+			if strings.TrimSpace(raw.Condition) == "" {
+				return nil, fmt.Errorf("run %q in test file %s has an assert without a condition", runName, name)
+			}
+			run.Asserts = append(run.Asserts, &Assert{
+				Condition:    raw.Condition,
+				ErrorMessage: raw.ErrorMessage,
+			})
+		}
+
+		f.Runs = append(f.Runs, run)
+	}
+
+	for _, item := range list.Filter("mock_provider").Items {
+		body, providerName, err := namedBlock("mock_provider", item)
+		if err != nil {
+			return nil, fmt.Errorf("test file %s: %s", name, err)
+		}
+		if _, exists := f.MockProviders[providerName]; exists {
+			return nil, fmt.Errorf("test file %s mocks provider %q more than once", name, providerName)
+		}
+
+		mp := &MockProviderConfig{
+			Name:     providerName,
+			Defaults: make(map[string]string),
+		}
+		for _, d := range body.Filter("defaults").Items {
+			var defaults map[string]string
+			if err := hcl.DecodeObject(&defaults, d.Val); err != nil {
+				return nil, fmt.Errorf("mock_provider %q in test file %s: invalid defaults: %s", providerName, name, err)
+			}
+			for k, v := range defaults {
+				mp.Defaults[k] = v
+			}
+		}
+		f.MockProviders[providerName] = mp
+	}
+
+	return f, nil
+}
+
+// LoadFiles reads the test files in the given test directory of the module
+// in dir, sorted by name. If filters are given then only the files whose
+// names are among them are read. A missing test directory has no tests.
+func LoadFiles(dir, testDir string, filters []string) ([]*File, error) {
+	entries, err := ioutil.ReadDir(filepath.Join(dir, testDir))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), FileExtension) {
+			continue
+		}
+		names = append(names, filepath.ToSlash(filepath.Join(testDir, entry.Name())))
+	}
+	sort.Strings(names)
+
+	if len(filters) > 0 {
+		keep := make(map[string]bool, len(filters))
+		for _, f := range filters {
+			keep[filepath.ToSlash(filepath.Clean(f))] = true
+		}
+
+		var filtered []string
+		for _, name := range names {
+			if keep[name] {
+				filtered = append(filtered, name)
+			}
+		}
+		names = filtered
+	}
+
+	var files []*File
+	for _, name := range names {
+		f, err := LoadFile(filepath.Join(dir, filepath.FromSlash(name)), name)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+	return files, nil
+}
+
+// namedBlock returns the body and the name of a block of the given type,
+// such as run "name" { ... }.
+func namedBlock(blockType string, item *ast.ObjectItem) (*ast.ObjectList, string, error) {
+	if len(item.Keys) != 1 {
+		return nil, "", fmt.Errorf("%s blocks must have exactly one name", blockType)
+	}
+	name := item.Keys[0].Token.Value().(string)
+
+	ot, ok := item.Val.(*ast.ObjectType)
+	if !ok {
+		return nil, "", fmt.Errorf("%s %q: should be a block", blockType, name)
+	}
+	return ot.List, name, nil
+}
+
+// decodeVariables merges the values of the variables blocks in list.
+func decodeVariables(list *ast.ObjectList) (map[string]interface{}, error) {
+	ret := make(map[string]interface{})
+	for _, item := range list.Filter("variables").Items {
+		var vars map[string]interface{}
+		if err := hcl.DecodeObject(&vars, item.Val); err != nil {
+			return nil, fmt.Errorf("invalid variables: %s", err)
+		}
+		for k, v := range vars {
+			// HCL decodes maps as lists of maps, as for .tfvars files.
+			if ms, ok := v.([]map[string]interface{}); ok && len(ms) == 1 {
+				v = ms[0]
+			}
+			ret[k] = v
+		}
+	}
+	return ret, nil
+}
@@ -0,0 +1,105 @@
+package moduletest
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadFiles(t *testing.T) {
+	files, err := LoadFiles("test-fixtures/load", DefaultTestDirectory, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(files) != 2 {
+		t.Fatalf("wrong number of files %d", len(files))
+	}
+	f := files[0]
+	if f.Name != "tests/main.tftest.hcl" || files[1].Name != "tests/other.tftest.hcl" {
+		t.Fatalf("wrong files %q, %q", f.Name, files[1].Name)
+	}
+
+	wantVars := map[string]interface{}{
+		"name": "foo",
+		"tags": map[string]interface{}{"env": "prod"},
+		"list": []interface{}{"a", "b"},
+	}
+	if !reflect.DeepEqual(f.Variables, wantVars) {
+		t.Fatalf("wrong variables\ngot:  %#v\nwant: %#v", f.Variables, wantVars)
+	}
+
+	wantRuns := []*Run{
+		{
+			Name:      "first",
+			Command:   CommandPlan,
+			Variables: map[string]interface{}{"name": "bar"},
+			Asserts: []*Assert{
+				{
+					Condition:    `${test_instance.foo.ami == "bar"}`,
+					ErrorMessage: "wrong ami",
+				},
+				{
+					Condition: "true",
+				},
+			},
+		},
+		{
+			Name:      "second",
+			Command:   CommandApply,
+			Variables: map[string]interface{}{},
+		},
+	}
+	if !reflect.DeepEqual(f.Runs, wantRuns) {
+		t.Fatalf("wrong runs\ngot:  %#v\nwant: %#v", f.Runs, wantRuns)
+	}
+
+	wantMocks := map[string]*MockProviderConfig{
+		"test": {
+			Name:     "test",
+			Defaults: map[string]string{"arn": "arn:mock"},
+		},
+	}
+	if !reflect.DeepEqual(f.MockProviders, wantMocks) {
+		t.Fatalf("wrong mock providers\ngot:  %#v\nwant: %#v", f.MockProviders, wantMocks)
+	}
+}
+
+func TestLoadFiles_filter(t *testing.T) {
+	files, err := LoadFiles("test-fixtures/load", DefaultTestDirectory, []string{"./tests/other.tftest.hcl"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || files[0].Name != "tests/other.tftest.hcl" {
+		t.Fatalf("wrong files %#v", files)
+	}
+}
+
+func TestLoadFiles_missing(t *testing.T) {
+	files, err := LoadFiles("test-fixtures/load", "missing", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 0 {
+		t.Fatalf("wrong files %#v", files)
+	}
+}
+
+func TestLoadFiles_invalid(t *testing.T) {
+	cases := map[string]string{
+		"invalid-command": `invalid command "destroy"`,
+		"duplicate-run":   `more than one run named "a"`,
+	}
+
+	for dir, want := range cases {
+		t.Run(dir, func(t *testing.T) {
+			_, err := LoadFiles("test-fixtures/"+dir, DefaultTestDirectory, nil)
+			if err == nil {
+				t.Fatal("want error")
+			}
+			if !strings.Contains(err.Error(), want) {
+				t.Fatalf("wrong error %q; want %q", err, want)
+			}
+		})
+	}
+}
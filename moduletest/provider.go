@@ -0,0 +1,136 @@
+package moduletest
+
+import (
+	"strings"
+
+	"github.com/hashicorp/terraform/builtin/providers/mock"
+	"github.com/hashicorp/terraform/config"
+	"github.com/hashicorp/terraform/config/configschema"
+	"github.com/hashicorp/terraform/config/module"
+	"github.com/hashicorp/terraform/plugin/discovery"
+	"github.com/hashicorp/terraform/terraform"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// ProviderResolver returns a terraform.ResourceProviderResolver that
+// resolves the providers that are mocked in the test file f to mocks of
+// the providers as used by the module mod, and the others with the given
+// resolver.
+//
+// Each mocked provider is resolved to the same mock every time, so that the
+// mocks are shared by all of the runs of the file.
+func (f *File) ProviderResolver(mod *module.Tree, resolver terraform.ResourceProviderResolver) terraform.ResourceProviderResolver {
+	mocks := make(map[string]terraform.ResourceProviderFactory, len(f.MockProviders))
+	for name, c := range f.MockProviders {
+		mocks[name] = terraform.ResourceProviderFactoryFixed(NewMockProvider(c, mod))
+	}
+
+	return terraform.ResourceProviderResolverFunc(func(reqd discovery.PluginRequirements) (map[string]terraform.ResourceProviderFactory, []error) {
+		ret := make(map[string]terraform.ResourceProviderFactory, len(reqd))
+		others := make(discovery.PluginRequirements)
+		for name, req := range reqd {
+			if factory, ok := mocks[name]; ok {
+				ret[name] = factory
+				continue
+			}
+			others[name] = req
+		}
+		if len(others) == 0 {
+			return ret, nil
+		}
+
+		factories, errs := resolver.ResolveProviders(others)
+		for name, factory := range factories {
+			ret[name] = factory
+		}
+		return ret, errs
+	})
+}
+
+// NewMockProvider returns a mock of the provider configured by c, which
+// stands in for the real provider so that the module mod can be tested
+// without credentials or creating real infrastructure.
+//
+// The mock has a schema that accepts whatever the module sets in the
+// configuration of the provider and of each of its resources and data
+// sources, so that the attributes of resources and data sources come from
+// their configuration. The defaults of c are the values of the attributes
+// the real provider would compute, for every resource type and data source.
+func NewMockProvider(c *MockProviderConfig, mod *module.Tree) *mock.Provider {
+	p := &mock.Provider{
+		Schema: &terraform.ProviderSchema{
+			Provider:      &configschema.Block{Attributes: make(map[string]*configschema.Attribute)},
+			ResourceTypes: make(map[string]*configschema.Block),
+			DataSources:   make(map[string]*configschema.Block),
+		},
+		ResourceTypes:   make(map[string]*mock.Resource),
+		DataSourceTypes: make(map[string]*mock.DataSource),
+	}
+
+	mod.DeepEach(func(t *module.Tree) {
+		cfg := t.Config()
+		for _, pc := range cfg.ProviderConfigs {
+			if pc.Name == c.Name {
+				addMockArguments(p.Schema.Provider, pc.RawConfig)
+			}
+		}
+
+		for _, r := range cfg.Resources {
+			if providerName(r.ProviderFullName()) != c.Name {
+				continue
+			}
+
+			blocks := p.Schema.ResourceTypes
+			if r.Mode == config.DataResourceMode {
+				blocks = p.Schema.DataSources
+				p.DataSourceTypes[r.Type] = &mock.DataSource{Computed: c.Defaults}
+			} else {
+				p.ResourceTypes[r.Type] = &mock.Resource{Computed: c.Defaults}
+			}
+
+			block, ok := blocks[r.Type]
+			if !ok {
+				block = &configschema.Block{Attributes: map[string]*configschema.Attribute{
+					"id": {Type: cty.String, Computed: true},
+				}}
+				blocks[r.Type] = block
+			}
+			addMockArguments(block, r.RawConfig)
+			for k := range c.Defaults {
+				if attr, ok := block.Attributes[k]; ok {
+					attr.Computed = true
+					continue
+				}
+				block.Attributes[k] = &configschema.Attribute{
+					Type:     cty.DynamicPseudoType,
+					Optional: true,
+					Computed: true,
+				}
+			}
+		}
+	})
+
+	return p
+}
+
+// addMockArguments adds the arguments set in the given configuration to
+// the schema of a mocked block, as optional attributes of any type.
+func addMockArguments(block *configschema.Block, rc *config.RawConfig) {
+	if rc == nil {
+		return
+	}
+	for k := range rc.Raw {
+		if _, ok := block.Attributes[k]; !ok {
+			block.Attributes[k] = &configschema.Attribute{Type: cty.DynamicPseudoType, Optional: true}
+		}
+	}
+}
+
+// providerName returns the name of the provider with the given full name,
+// which is "NAME" or "NAME.ALIAS".
+func providerName(fullName string) string {
+	if i := strings.Index(fullName, "."); i != -1 {
+		return fullName[:i]
+	}
+	return fullName
+}
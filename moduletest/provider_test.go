@@ -0,0 +1,179 @@
+package moduletest
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform/builtin/providers/mock"
+	"github.com/hashicorp/terraform/config"
+	"github.com/hashicorp/terraform/config/module"
+	"github.com/hashicorp/terraform/plugin/discovery"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestNewMockProvider(t *testing.T) {
+	p := NewMockProvider(&MockProviderConfig{
+		Name:     "test",
+		Defaults: map[string]string{"arn": "arn:mock"},
+	}, testModule(t, "mock"))
+
+	// The schema accepts what the module configures, and nothing else.
+	if _, errs := p.Validate(testConfig(t, map[string]interface{}{"region": "us-west-2"})); len(errs) > 0 {
+		t.Fatalf("unexpected provider errors %v", errs)
+	}
+	if _, errs := p.ValidateResource("test_instance", testConfig(t, map[string]interface{}{"ami": "bar"})); len(errs) > 0 {
+		t.Fatalf("unexpected resource errors %v", errs)
+	}
+	if _, errs := p.ValidateResource("test_instance", testConfig(t, map[string]interface{}{"nope": "bar"})); len(errs) == 0 {
+		t.Fatal("expected an error for an argument the module doesn't set")
+	}
+	if _, errs := p.ValidateResource("other_instance", testConfig(t, nil)); len(errs) == 0 {
+		t.Fatal("expected an error for a resource of another provider")
+	}
+
+	info := &terraform.InstanceInfo{Id: "foo", Type: "test_instance"}
+
+	// Creating
+	d, err := p.Diff(info, nil, testConfig(t, map[string]interface{}{
+		"ami":  "foo",
+		"tags": map[string]interface{}{"env": "prod"},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Empty() {
+		t.Fatal("expected a diff to create the instance")
+	}
+
+	s, err := p.Apply(info, nil, d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.ID == "" {
+		t.Fatal("no id")
+	}
+	want := map[string]string{
+		"id":       s.ID,
+		"ami":      "foo",
+		"arn":      "arn:mock",
+		"tags.%":   "1",
+		"tags.env": "prod",
+	}
+	if !reflect.DeepEqual(s.Attributes, want) {
+		t.Fatalf("wrong attributes\ngot:  %#v\nwant: %#v", s.Attributes, want)
+	}
+
+	// Updating, where the attribute with a default is kept.
+	d, err = p.Diff(info, s, testConfig(t, map[string]interface{}{
+		"ami": "bar",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.ChangeType() != terraform.DiffUpdate {
+		t.Fatalf("wrong change type %#v", d.ChangeType())
+	}
+
+	id := s.ID
+	s, err = p.Apply(info, s, d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = map[string]string{
+		"id":  id,
+		"ami": "bar",
+		"arn": "arn:mock",
+	}
+	if !reflect.DeepEqual(s.Attributes, want) {
+		t.Fatalf("wrong attributes\ngot:  %#v\nwant: %#v", s.Attributes, want)
+	}
+
+	// Reading a data source
+	dsInfo := &terraform.InstanceInfo{Id: "data.test_image.foo", Type: "test_image"}
+	d, err = p.ReadDataDiff(dsInfo, testConfig(t, map[string]interface{}{"name": "foo"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ds, err := p.ReadDataApply(dsInfo, d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ds.Attributes["arn"]; got != "arn:mock" {
+		t.Fatalf("wrong data source arn %q", got)
+	}
+}
+
+func TestFileProviderResolver(t *testing.T) {
+	f := &File{
+		MockProviders: map[string]*MockProviderConfig{
+			"test": {Name: "test"},
+		},
+	}
+	real := new(terraform.MockResourceProvider)
+	resolver := f.ProviderResolver(testModule(t, "mock"), terraform.ResourceProviderResolverFixed(
+		map[string]terraform.ResourceProviderFactory{
+			"other": terraform.ResourceProviderFactoryFixed(real),
+		},
+	))
+
+	factories, errs := resolver.ResolveProviders(discovery.PluginRequirements{
+		"test":  &discovery.PluginConstraints{Versions: discovery.AllVersions},
+		"other": &discovery.PluginConstraints{Versions: discovery.AllVersions},
+	})
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors %v", errs)
+	}
+
+	p, err := factories["test"]()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := p.(*mock.Provider); !ok {
+		t.Fatalf("mocked provider resolved to %T", p)
+	}
+
+	p, err = factories["other"]()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p != real {
+		t.Fatalf("real provider resolved to %#v", p)
+	}
+}
+
+func testModule(t *testing.T, name string) *module.Tree {
+	t.Helper()
+
+	mod, err := module.NewTreeModule("", filepath.Join("test-fixtures", name))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	dir, err := ioutil.TempDir("", "tf-moduletest")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	s := &module.Storage{
+		StorageDir: dir,
+		Mode:       module.GetModeGet,
+	}
+	if err := mod.Load(s); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	return mod
+}
+
+func testConfig(t *testing.T, raw map[string]interface{}) *terraform.ResourceConfig {
+	rc, err := config.NewRawConfig(raw)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	return terraform.NewResourceConfig(rc)
+}
@@ -0,0 +1,98 @@
+package moduletest
+
+// Status is the outcome of a run, or of a test file.
+type Status string
+
+const (
+	// Pass means that all of the assertions held.
+	Pass Status = "pass"
+
+	// Fail means that one or more assertions didn't hold.
+	Fail Status = "fail"
+
+	// Error means that the run couldn't be completed, because planning or
+	// applying the module, or evaluating an assertion, failed.
+	Error Status = "error"
+
+	// Skip means that the run wasn't performed because an earlier run of
+	// the same file had an error.
+	Skip Status = "skip"
+)
+
+// worse returns the more severe of two statuses, where errors are worse
+// than failures, which are worse than skips and passes.
+func (s Status) worse(other Status) Status {
+	rank := map[Status]int{Pass: 0, Skip: 1, Fail: 2, Error: 3}
+	if rank[other] > rank[s] {
+		return other
+	}
+	return s
+}
+
+// Results are the results of the test files of a module, which are also the
+// JSON representation of the results reported by "terraform test -json".
+type Results struct {
+	Status Status        `json:"status"`
+	Files  []*FileResult `json:"files"`
+
+	Summary Summary `json:"summary"`
+}
+
+// Summary counts the runs of all of the test files by their status.
+type Summary struct {
+	Pass  int `json:"pass"`
+	Fail  int `json:"fail"`
+	Error int `json:"error"`
+	Skip  int `json:"skip"`
+}
+
+// FileResult is the result of a test file.
+type FileResult struct {
+	Name   string       `json:"name"`
+	Status Status       `json:"status"`
+	Runs   []*RunResult `json:"runs"`
+
+	// Errors are problems with the file that aren't specific to a run, such
+	// as failing to destroy the resources created by the runs.
+	Errors []string `json:"errors,omitempty"`
+}
+
+// RunResult is the result of a run of a test file.
+type RunResult struct {
+	Name    string `json:"name"`
+	Command string `json:"command"`
+	Status  Status `json:"status"`
+
+	// Failures are the error messages of the assertions that didn't hold.
+	Failures []string `json:"failures,omitempty"`
+
+	// Errors are the errors that kept the run from being completed.
+	Errors []string `json:"errors,omitempty"`
+}
+
+// Add adds the result of a test file, updating the status and summary.
+func (r *Results) Add(f *FileResult) {
+	f.Status = Pass
+	if len(f.Errors) > 0 {
+		f.Status = Error
+	}
+	for _, run := range f.Runs {
+		f.Status = f.Status.worse(run.Status)
+		switch run.Status {
+		case Pass:
+			r.Summary.Pass++
+		case Fail:
+			r.Summary.Fail++
+		case Error:
+			r.Summary.Error++
+		case Skip:
+			r.Summary.Skip++
+		}
+	}
+
+	if r.Status == "" {
+		r.Status = Pass
+	}
+	r.Status = r.Status.worse(f.Status)
+	r.Files = append(r.Files, f)
+}
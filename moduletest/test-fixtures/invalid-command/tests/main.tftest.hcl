@@ -0,0 +1,3 @@
+run "destroy" {
+  command = "destroy"
+}
@@ -0,0 +1,32 @@
+variables {
+  name = "foo"
+  tags = {
+    env = "prod"
+  }
+  list = ["a", "b"]
+}
+
+mock_provider "test" {
+  defaults {
+    arn = "arn:mock"
+  }
+}
+
+run "first" {
+  command = "plan"
+
+  variables {
+    name = "bar"
+  }
+
+  assert {
+    condition     = "${test_instance.foo.ami == "bar"}"
+    error_message = "wrong ami"
+  }
+
+  assert {
+    condition = "true"
+  }
+}
+
+run "second" {}
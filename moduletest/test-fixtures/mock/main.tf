@@ -0,0 +1,19 @@
+provider "test" {
+  region = "us-east-1"
+}
+
+resource "test_instance" "foo" {
+  ami = "foo"
+
+  tags = {
+    env = "prod"
+  }
+}
+
+data "test_image" "foo" {
+  name = "foo"
+}
+
+resource "other_instance" "foo" {
+  name = "foo"
+}
@@ -6,6 +6,8 @@ import (
 	"os"
 	"strings"
 
+	"github.com/hashicorp/terraform/helper/crash"
+	"github.com/hashicorp/terraform/terraform"
 	"github.com/mitchellh/panicwrap"
 )
 
@@ -19,6 +21,12 @@ A crash log has been placed at "crash.log" relative to your current
 working directory. It would be immensely helpful if you could please
 report the crash with Terraform[1] so that we can fix this.
 
+A crash bundle has also been placed at "crash-bundle.json". It has the
+versions of Terraform and the providers, the crash, the end of the log
+and a summary of the plan, with sensitive values redacted. Please
+attach it to the report, after checking that it has no secrets that
+Terraform didn't know were sensitive.
+
 When reporting bugs, please include your terraform version. That
 information is available on the first line of crash.log. You can also
 get it by running 'terraform --version' on the command line.
@@ -31,8 +39,8 @@ get it by running 'terraform --version' on the command line.
 // panicHandler is what is called by panicwrap when a panic is encountered
 // within Terraform. It is guaranteed to run after the resulting process has
 // exited so we can take the log file, add in the panic, and store it
-// somewhere locally.
-func panicHandler(logF *os.File) panicwrap.HandlerFunc {
+// somewhere locally, along with the crash bundle.
+func panicHandler(logF *os.File, crashContextPath string) panicwrap.HandlerFunc {
 	return func(m string) {
 		// Right away just output this thing on stderr so that it gets
 		// shown in case anything below fails.
@@ -59,9 +67,41 @@ func panicHandler(logF *os.File) panicwrap.HandlerFunc {
 			return
 		}
 
+		// The bundle is written after the log, so that the log is kept even
+		// if the bundle can't be written.
+		if err := writeCrashBundle(m, logF, crashContextPath); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to write crash bundle: %s", err)
+		}
+
 		// Tell the user a crash occurred in some helpful way that
 		// they'll hopefully notice.
 		fmt.Printf("\n\n")
 		fmt.Println(strings.TrimSpace(panicOutput))
 	}
 }
+
+// writeCrashBundle writes the crash bundle for the crash with the given
+// output to crash-bundle.json.
+func writeCrashBundle(output string, logF *os.File, crashContextPath string) error {
+	ctx, err := crash.ReadContext(crashContextPath)
+	if err != nil {
+		// The bundle is still useful without what the child recorded.
+		fmt.Fprintf(os.Stderr, "Failed to read crash context: %s\n", err)
+		ctx = nil
+	}
+
+	if _, err := logF.Seek(0, 0); err != nil {
+		return err
+	}
+	bundle, err := crash.NewBundle(terraform.VersionString(), os.Args[1:], output, logF, ctx)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile("crash-bundle.json", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return bundle.Write(f)
+}
@@ -3,18 +3,32 @@ package plugin
 import (
 	"os"
 	"os/exec"
+	"path/filepath"
+	"strings"
 
 	hclog "github.com/hashicorp/go-hclog"
 	plugin "github.com/hashicorp/go-plugin"
+	"github.com/hashicorp/terraform/helper/logging"
 	"github.com/hashicorp/terraform/plugin/discovery"
 )
 
 // ClientConfig returns a configuration object that can be used to instantiate
 // a client for the plugin described by the given metadata.
+//
+// Log entries from a provider plugin are named after the provider, and are
+// filtered according to the provider log level, which can be set
+// separately from Terraform's own log level.
 func ClientConfig(m discovery.PluginMeta) *plugin.ClientConfig {
+	name := "plugin"
+	level := hclog.Trace
+	if strings.HasPrefix(filepath.Base(m.Path), "terraform-provider-") {
+		name = "provider." + m.Name
+		level = hclog.LevelFromString(logging.ProviderLogLevel())
+	}
+
 	logger := hclog.New(&hclog.LoggerOptions{
-		Name:   "plugin",
-		Level:  hclog.Trace,
+		Name:   name,
+		Level:  level,
 		Output: os.Stderr,
 	})
 
@@ -12,6 +12,7 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"sync"
 
 	"golang.org/x/net/html"
 
@@ -43,8 +44,8 @@ type Installer interface {
 }
 
 // ProviderInstaller is an Installer implementation that knows how to
-// download Terraform providers from the official HashiCorp releases service
-// into a local directory. The files downloaded are compliant with the
+// download Terraform providers from the official HashiCorp releases service,
+// or from the mirrors given in Sources, into a local directory. The files downloaded are compliant with the
 // naming scheme expected by FindPlugins, so the target directory of a
 // provider installer can be used as one of several plugin discovery sources.
 type ProviderInstaller struct {
@@ -66,12 +67,34 @@ type ProviderInstaller struct {
 	// Skip checksum and signature verification
 	SkipVerify bool
 
+	// Sources are the sources that providers may be installed from, in
+	// order of preference. Each provider is installed from the sources
+	// whose rules allow it, choosing the newest version that any of them
+	// has and preferring earlier sources for the same version. If Sources
+	// is empty, providers are installed directly from the official
+	// releases service.
+	Sources []*ProviderInstallationSource
+
+	// Locks, if non-nil, are the dependency locks of the configuration that
+	// providers are being installed for. A locked version of a provider is
+	// only installed if its release archive matches one of the locked
+	// hashes, and each provider that is installed is locked to the version
+	// installed, with the hashes of its archives for all platforms.
+	Locks ProviderLocks
+
 	Ui cli.Ui // Ui for output
+
+	// locksLock guards Locks, since Get may be called concurrently to
+	// install different providers.
+	locksLock sync.Mutex
 }
 
 // Get is part of an implementation of type Installer, and attempts to download
 // and install a Terraform provider matching the given constraints.
 //
+// Get may be called concurrently for different providers, as long as Ui is
+// safe for concurrent use.
+//
 // This method may return one of a number of sentinel errors from this
 // package to indicate issues that are likely to be resolvable via user action:
 //
@@ -89,24 +112,11 @@ type ProviderInstaller struct {
 // be presented alongside context about what is being installed, and thus the
 // error messages do not redundantly include such information.
 func (i *ProviderInstaller) Get(provider string, req Constraints) (PluginMeta, error) {
-	versions, err := i.listProviderVersions(provider)
-	// TODO: return multiple errors
+	versions, versionSources, err := i.findVersions(provider, req)
 	if err != nil {
 		return PluginMeta{}, err
 	}
 
-	if len(versions) == 0 {
-		return PluginMeta{}, ErrorNoSuitableVersion
-	}
-
-	versions = allowedVersions(versions, req)
-	if len(versions) == 0 {
-		return PluginMeta{}, ErrorNoSuitableVersion
-	}
-
-	// sort them newest to oldest
-	Versions(versions).Sort()
-
 	// Ensure that our installation directory exists
 	err = os.MkdirAll(i.Dir, os.ModePerm)
 	if err != nil {
@@ -115,25 +125,31 @@ func (i *ProviderInstaller) Get(provider string, req Constraints) (PluginMeta, e
 
 	// take the first matching plugin we find
 	for _, v := range versions {
-		url := i.providerURL(provider, v.String())
-
-		if !i.SkipVerify {
-			sha256, err := i.getProviderChecksum(provider, v.String())
+		for _, source := range versionSources[v.String()] {
+			log.Printf("[DEBUG] fetching provider info for %s version %s from %s", provider, v, source)
+			pkg, err := source.Package(&ProviderPackageRequest{
+				Name:                  provider,
+				Version:               v,
+				OS:                    i.os(),
+				Arch:                  i.arch(),
+				PluginProtocolVersion: i.PluginProtocolVersion,
+				SkipVerify:            i.SkipVerify,
+			})
 			if err != nil {
 				return PluginMeta{}, err
 			}
+			if pkg == nil {
+				log.Printf("[INFO] no compatible package for %s version %s from %s", provider, v, source)
+				continue
+			}
 
-			// add the checksum parameter for go-getter to verify the download for us.
-			if sha256 != "" {
-				url = url + "?checksum=sha256:" + sha256
+			if err := i.checkLock(provider, v, pkg.SHA256); err != nil {
+				return PluginMeta{}, err
 			}
-		}
 
-		log.Printf("[DEBUG] fetching provider info for %s version %s", provider, v)
-		if checkPlugin(url, i.PluginProtocolVersion) {
 			i.Ui.Info(fmt.Sprintf("- Downloading plugin for provider %q (%s)...", provider, v.String()))
 			log.Printf("[DEBUG] getting provider %q version %q", provider, v)
-			err := i.install(provider, v, url)
+			err = i.install(provider, v, pkg.URL)
 			if err != nil {
 				return PluginMeta{}, err
 			}
@@ -169,98 +185,222 @@ func (i *ProviderInstaller) Get(provider string, req Constraints) (PluginMeta, e
 				)
 			}
 
+			if i.Locks != nil {
+				i.lockInstalled(provider, v, source, pkg.SHA256)
+			}
+
+			i.Ui.Info(fmt.Sprintf("- Installed provider %q (%s): %s", provider, v.String(), pkg.Authentication))
+
 			// By now we know we have exactly one meta, and so "Newest" will
 			// return that one.
 			return metas.Newest(), nil
 		}
-
-		log.Printf("[INFO] incompatible ProtocolVersion for %s version %s", provider, v)
 	}
 
 	return PluginMeta{}, ErrorNoVersionCompatible
 }
 
-func (i *ProviderInstaller) install(provider string, version Version, url string) error {
-	if i.Cache != nil {
-		log.Printf("[DEBUG] looking for provider %s %s in plugin cache", provider, version)
-		cached := i.Cache.CachedPluginPath("provider", provider, version)
-		if cached == "" {
-			log.Printf("[DEBUG] %s %s not yet in cache, so downloading %s", provider, version, url)
-			err := getter.Get(i.Cache.InstallDir(), url)
-			if err != nil {
-				return err
-			}
-			// should now be in cache
-			cached = i.Cache.CachedPluginPath("provider", provider, version)
-			if cached == "" {
-				// should never happen if the getter is behaving properly
-				// and the plugins are packaged properly.
-				return fmt.Errorf("failed to find downloaded plugin in cache %s", i.Cache.InstallDir())
+// Lock returns a lock for the newest version of the named provider that
+// meets the given constraints, with the hashes of the version's release
+// archives for the given platforms, without installing it. The platforms
+// are named like "linux_amd64", and the hashes are taken from the most
+// preferred source that has archives for all of them.
+func (i *ProviderInstaller) Lock(provider string, req Constraints, platforms []string) (*ProviderLock, error) {
+	versions, versionSources, err := i.findVersions(provider, req)
+	if err != nil {
+		return nil, err
+	}
+
+	v := versions[0]
+	var missing []string
+	for _, source := range versionSources[v.String()] {
+		log.Printf("[DEBUG] fetching package hashes for %s version %s from %s", provider, v, source)
+		hashes, err := source.PackageHashes(provider, v)
+		if err != nil {
+			return nil, err
+		}
+
+		lock := &ProviderLock{
+			Name:    provider,
+			Version: v,
+		}
+		missing = nil
+		for _, platform := range platforms {
+			if h, ok := hashes[platform]; ok {
+				lock.AddHashes(h)
+			} else {
+				missing = append(missing, platform)
 			}
 		}
+		if len(missing) == 0 {
+			return lock, nil
+		}
+		log.Printf("[INFO] no packages of %s version %s for %s from %s", provider, v, strings.Join(missing, ", "), source)
+	}
 
-		// Link or copy the cached binary into our install dir so the
-		// normal resolution machinery can find it.
-		filename := filepath.Base(cached)
-		targetPath := filepath.Join(i.Dir, filename)
+	return nil, fmt.Errorf(
+		"no source has packages of version %s for all of the platforms; missing %s",
+		v, strings.Join(missing, ", "))
+}
 
-		log.Printf("[DEBUG] installing %s %s to %s from local cache %s", provider, version, targetPath, cached)
+// findVersions returns the versions of the named provider that meet the
+// given constraints, newest first, along with the sources that have each of
+// them in order of preference.
+func (i *ProviderInstaller) findVersions(provider string, req Constraints) ([]Version, map[string][]ProviderSource, error) {
+	sources := i.sourcesFor(provider)
+	if len(sources) == 0 {
+		return nil, nil, fmt.Errorf(
+			"the provider installation methods in the CLI configuration don't allow installing provider %q",
+			provider)
+	}
 
-		// Delete if we can. If there's nothing there already then no harm done.
-		// This is important because we can't create a link if there's
-		// already a file of the same name present.
-		// (any other error here we'll catch below when we try to write here)
-		os.Remove(targetPath)
-
-		// We don't attempt linking on Windows because links are not
-		// comprehensively supported by all tools/apps in Windows and
-		// so we choose to be conservative to avoid creating any
-		// weird issues for Windows users.
-		linkErr := errors.New("link not supported for Windows") // placeholder error, never actually returned
-		if runtime.GOOS != "windows" {
-			// Try hard linking first. Hard links are preferable because this
-			// creates a self-contained directory that doesn't depend on the
-			// cache after install.
-			linkErr = os.Link(cached, targetPath)
-
-			// If that failed, try a symlink. This _does_ depend on the cache
-			// after install, so the user must manage the cache more carefully
-			// in this case, but avoids creating redundant copies of the
-			// plugins on disk.
-			if linkErr != nil {
-				linkErr = os.Symlink(cached, targetPath)
-			}
+	found := false
+	var versions []Version
+	versionSources := make(map[string][]ProviderSource)
+	for _, source := range sources {
+		available, err := source.AvailableVersions(provider)
+		if err == ErrorNoSuchProvider {
+			continue
+		}
+		// TODO: return multiple errors
+		if err != nil {
+			return nil, nil, err
 		}
+		found = true
 
-		// If we still have an error then we'll try a copy as a fallback.
-		// In this case either the OS is Windows or the target filesystem
-		// can't support symlinks.
-		if linkErr != nil {
-			srcFile, err := os.Open(cached)
-			if err != nil {
-				return fmt.Errorf("failed to open cached plugin %s: %s", cached, err)
+		for _, v := range available {
+			k := v.String()
+			if _, ok := versionSources[k]; !ok {
+				versions = append(versions, v)
 			}
-			defer srcFile.Close()
+			versionSources[k] = append(versionSources[k], source)
+		}
+	}
 
-			destFile, err := os.OpenFile(targetPath, os.O_TRUNC|os.O_CREATE|os.O_WRONLY, os.ModePerm)
-			if err != nil {
-				return fmt.Errorf("failed to create %s: %s", targetPath, err)
-			}
+	if !found {
+		return nil, nil, ErrorNoSuchProvider
+	}
 
-			_, err = io.Copy(destFile, srcFile)
-			if err != nil {
-				destFile.Close()
-				return fmt.Errorf("failed to copy cached plugin from %s to %s: %s", cached, targetPath, err)
-			}
+	versions = allowedVersions(versions, req)
+	if len(versions) == 0 {
+		return nil, nil, ErrorNoSuitableVersion
+	}
 
-			err = destFile.Close()
-			if err != nil {
-				return fmt.Errorf("error creating %s: %s", targetPath, err)
-			}
+	// sort them newest to oldest
+	Versions(versions).Sort()
+
+	return versions, versionSources, nil
+}
+
+// checkLock returns an error if the release archive with the given SHA256
+// checksum can't be shown to match the hashes locked for the given version
+// of the named provider. Archives of other versions are allowed, since the
+// caller chooses which versions may be installed.
+func (i *ProviderInstaller) checkLock(provider string, v Version, sum string) error {
+	i.locksLock.Lock()
+	defer i.locksLock.Unlock()
+
+	lock := i.Locks[provider]
+	if lock == nil || !lock.Version.Equal(v) || len(lock.Hashes) == 0 {
+		return nil
+	}
+
+	switch {
+	case i.SkipVerify:
+		log.Printf("[WARN] not verifying %s version %s against the dependency lock file", provider, v)
+		return nil
+	case sum == "":
+		return fmt.Errorf(
+			"the package of version %s has no checksum to verify against the dependency lock file",
+			v)
+	case !lock.HasHash(ArchiveHash(sum)):
+		return fmt.Errorf(
+			"the checksum of the package of version %s doesn't match any of the hashes "+
+				"recorded in the dependency lock file; the package may have been tampered with",
+			v)
+	}
+	return nil
+}
+
+// lockInstalled records the given version of the named provider in Locks,
+// with the hashes of its release archives for all of the platforms the
+// given source has archives for. Hashes already locked for the version are
+// retained.
+func (i *ProviderInstaller) lockInstalled(provider string, v Version, source ProviderSource, sum string) {
+	hashes, err := source.PackageHashes(provider, v)
+	if err != nil {
+		log.Printf("[WARN] failed to get package hashes of %s version %s from %s: %s", provider, v, source, err)
+	}
+
+	i.locksLock.Lock()
+	defer i.locksLock.Unlock()
+
+	lock := i.Locks[provider]
+	if lock == nil || !lock.Version.Equal(v) {
+		lock = &ProviderLock{
+			Name:    provider,
+			Version: v,
+		}
+	}
+
+	if sum != "" {
+		lock.AddHashes(ArchiveHash(sum))
+	}
+	for _, h := range hashes {
+		lock.AddHashes(h)
+	}
+
+	i.Locks[provider] = lock
+}
+
+// sourcesFor returns the sources that the named provider may be installed
+// from, in order of preference.
+func (i *ProviderInstaller) sourcesFor(provider string) []ProviderSource {
+	if len(i.Sources) == 0 {
+		return []ProviderSource{DirectSource{}}
+	}
+
+	var ret []ProviderSource
+	for _, s := range i.Sources {
+		if s.Allows(provider) {
+			ret = append(ret, s.Source)
+		}
+	}
+	return ret
+}
+
+func (i *ProviderInstaller) os() string {
+	if i.OS == "" {
+		return runtime.GOOS
+	}
+	return i.OS
+}
+
+func (i *ProviderInstaller) arch() string {
+	if i.Arch == "" {
+		return runtime.GOARCH
+	}
+	return i.Arch
+}
+
+func (i *ProviderInstaller) install(provider string, version Version, url string) error {
+	if i.Cache != nil {
+		log.Printf("[DEBUG] looking for provider %s %s in plugin cache", provider, version)
+		cached, err := i.Cache.Populate("provider", provider, version, func(dir string) error {
+			log.Printf("[DEBUG] %s %s not yet in cache, so downloading %s", provider, version, url)
+			return getter.Get(dir, url)
+		})
+		if err != nil {
+			return err
 		}
 
-		// One way or another, by the time we get here we should have either
-		// a link or a copy of the cached plugin within i.Dir, as expected.
+		// Link or copy the cached binary into our install dir so the
+		// normal resolution machinery can find it.
+		targetPath := filepath.Join(i.Dir, filepath.Base(cached))
+		log.Printf("[DEBUG] installing %s %s to %s from local cache %s", provider, version, targetPath, cached)
+		if err := linkOrCopy(cached, targetPath); err != nil {
+			return err
+		}
 	} else {
 		log.Printf("[DEBUG] plugin cache is disabled, so downloading %s %s from %s", provider, version, url)
 		err := getter.Get(i.Dir, url)
@@ -272,6 +412,69 @@ func (i *ProviderInstaller) install(provider string, version Version, url string
 	return nil
 }
 
+// linkOrCopy installs the cached plugin at src to dst, by linking to it if
+// possible, and otherwise by copying it. The link or copy is made with a
+// temporary name and then renamed to dst, so that another process never
+// finds a partially-written plugin at dst, even if it's installing the same
+// plugin at the same time.
+func linkOrCopy(src, dst string) error {
+	tmp := filepath.Join(filepath.Dir(dst), fmt.Sprintf(".%s.%d.tmp", filepath.Base(dst), os.Getpid()))
+	os.Remove(tmp)
+	defer os.Remove(tmp)
+
+	// We don't attempt linking on Windows because links are not
+	// comprehensively supported by all tools/apps in Windows and
+	// so we choose to be conservative to avoid creating any
+	// weird issues for Windows users.
+	linkErr := errors.New("link not supported for Windows") // placeholder error, never actually returned
+	if runtime.GOOS != "windows" {
+		// Try hard linking first. Hard links are preferable because this
+		// creates a self-contained directory that doesn't depend on the
+		// cache after install.
+		linkErr = os.Link(src, tmp)
+
+		// If that failed, try a symlink. This _does_ depend on the cache
+		// after install, so the user must manage the cache more carefully
+		// in this case, but avoids creating redundant copies of the
+		// plugins on disk.
+		if linkErr != nil {
+			linkErr = os.Symlink(src, tmp)
+		}
+	}
+
+	// If we still have an error then we'll try a copy as a fallback.
+	// In this case either the OS is Windows or the target filesystem
+	// can't support symlinks.
+	if linkErr != nil {
+		srcFile, err := os.Open(src)
+		if err != nil {
+			return fmt.Errorf("failed to open cached plugin %s: %s", src, err)
+		}
+		defer srcFile.Close()
+
+		destFile, err := os.OpenFile(tmp, os.O_TRUNC|os.O_CREATE|os.O_WRONLY, os.ModePerm)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %s", dst, err)
+		}
+
+		_, err = io.Copy(destFile, srcFile)
+		if err != nil {
+			destFile.Close()
+			return fmt.Errorf("failed to copy cached plugin from %s to %s: %s", src, dst, err)
+		}
+
+		err = destFile.Close()
+		if err != nil {
+			return fmt.Errorf("error creating %s: %s", dst, err)
+		}
+	}
+
+	if err := os.Rename(tmp, dst); err != nil {
+		return fmt.Errorf("error creating %s: %s", dst, err)
+	}
+	return nil
+}
+
 func (i *ProviderInstaller) PurgeUnused(used map[string]PluginMeta) (PluginMetaSet, error) {
 	purge := make(PluginMetaSet)
 
@@ -307,19 +510,19 @@ func (i *ProviderInstaller) PurgeUnused(used map[string]PluginMeta) (PluginMetaS
 // Plugins are referred to by the short name, but all URLs and files will use
 // the full name prefixed with terraform-<plugin_type>-
 func (i *ProviderInstaller) providerName(name string) string {
-	return "terraform-provider-" + name
+	return providerName(name)
 }
 
 func (i *ProviderInstaller) providerFileName(name, version string) string {
-	os := i.OS
-	arch := i.Arch
-	if os == "" {
-		os = runtime.GOOS
-	}
-	if arch == "" {
-		arch = runtime.GOARCH
-	}
-	return fmt.Sprintf("%s_%s_%s_%s.zip", i.providerName(name), version, os, arch)
+	return providerFileName(name, version, i.os(), i.arch())
+}
+
+func providerName(name string) string {
+	return "terraform-provider-" + name
+}
+
+func providerFileName(name, version, os, arch string) string {
+	return fmt.Sprintf("%s_%s_%s_%s.zip", providerName(name), version, os, arch)
 }
 
 // providerVersionsURL returns the path to the released versions directory for the provider:
@@ -1,5 +1,14 @@
 package discovery
 
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
 // PluginCache is an interface implemented by objects that are able to maintain
 // a cache of plugins.
 type PluginCache interface {
@@ -13,7 +22,19 @@ type PluginCache interface {
 	//
 	// After installing into this directory, use CachedPluginPath to obtain the
 	// path where the plugin was installed.
+	//
+	// Populate should be used instead where other processes may be
+	// populating the same cache.
 	InstallDir() string
+
+	// Populate returns the path where the requested plugin is cached,
+	// first calling fetch to download it into the given empty directory if
+	// it isn't cached yet. The downloaded files are then moved into the
+	// cache, so that the cache never has a partially-written plugin.
+	//
+	// It's safe for several processes to populate the same cache at once:
+	// only one downloads a given plugin, and the others wait for it.
+	Populate(kind string, name string, version Version, fetch func(dir string) error) (string, error)
 }
 
 // NewLocalPluginCache returns a PluginCache that caches plugins in a
@@ -46,3 +67,110 @@ func (c *pluginCache) CachedPluginPath(kind string, name string, version Version
 func (c *pluginCache) InstallDir() string {
 	return c.Dir
 }
+
+func (c *pluginCache) Populate(kind string, name string, version Version, fetch func(dir string) error) (string, error) {
+	if cached := c.CachedPluginPath(kind, name, version); cached != "" {
+		return cached, nil
+	}
+
+	if err := os.MkdirAll(c.Dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create plugin cache directory %s: %s", c.Dir, err)
+	}
+
+	unlock, err := c.lock(kind, name, version)
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+
+	// Another process may have populated the cache while we were waiting
+	// for the lock.
+	if cached := c.CachedPluginPath(kind, name, version); cached != "" {
+		log.Printf("[DEBUG] %s %s %s was added to the plugin cache by another process", kind, name, version)
+		return cached, nil
+	}
+
+	// The plugin is downloaded into a directory in the cache directory, so
+	// that its files can be renamed into place, which is atomic because
+	// they're on the same filesystem. Its name doesn't have the prefix of
+	// plugins, so that it's never mistaken for one.
+	tmpDir, err := ioutil.TempDir(c.Dir, ".download-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary directory in plugin cache: %s", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if err := fetch(tmpDir); err != nil {
+		return "", err
+	}
+
+	items, err := ioutil.ReadDir(tmpDir)
+	if err != nil {
+		return "", err
+	}
+	for _, item := range items {
+		if item.IsDir() {
+			continue
+		}
+		src := filepath.Join(tmpDir, item.Name())
+		dst := filepath.Join(c.Dir, item.Name())
+		if err := os.Rename(src, dst); err != nil {
+			return "", fmt.Errorf("failed to move %s into plugin cache: %s", item.Name(), err)
+		}
+	}
+
+	cached := c.CachedPluginPath(kind, name, version)
+	if cached == "" {
+		// should never happen if the plugins are packaged properly
+		return "", fmt.Errorf("failed to find downloaded plugin in cache %s", c.Dir)
+	}
+	return cached, nil
+}
+
+// cacheLocks are the locks of the plugins that this process is populating
+// the cache with. The file locks only exclude other processes, so these
+// exclude the other goroutines of this one.
+var (
+	cacheLocks     = make(map[string]*sync.Mutex)
+	cacheLocksLock sync.Mutex
+)
+
+// lock takes the lock on populating the cache with the given plugin, waiting
+// for any other process or goroutine that holds it, and returns the function
+// that releases it.
+func (c *pluginCache) lock(kind string, name string, version Version) (func(), error) {
+	path := filepath.Join(c.Dir, fmt.Sprintf(".terraform-%s-%s_v%s.lock", kind, name, version))
+
+	cacheLocksLock.Lock()
+	mu, ok := cacheLocks[path]
+	if !ok {
+		mu = new(sync.Mutex)
+		cacheLocks[path] = mu
+	}
+	cacheLocksLock.Unlock()
+	mu.Lock()
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		mu.Unlock()
+		return nil, fmt.Errorf("failed to create plugin cache lock file %s: %s", path, err)
+	}
+
+	log.Printf("[DEBUG] waiting for plugin cache lock %s", path)
+	if err := lockCacheFile(f); err != nil {
+		f.Close()
+		mu.Unlock()
+		return nil, fmt.Errorf("failed to lock plugin cache lock file %s: %s", path, err)
+	}
+
+	// The lock file is left in place, since removing it could let another
+	// process lock a new file of the same name while one still holds the
+	// lock on the old one.
+	return func() {
+		if err := unlockCacheFile(f); err != nil {
+			log.Printf("[WARN] failed to unlock %s: %s", path, err)
+		}
+		f.Close()
+		mu.Unlock()
+	}, nil
+}
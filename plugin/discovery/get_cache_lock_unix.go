@@ -0,0 +1,38 @@
+// +build !windows
+
+package discovery
+
+import (
+	"os"
+	"syscall"
+)
+
+// lockCacheFile takes an exclusive lock on the given file, waiting until any
+// other process that holds it releases it. It uses fcntl POSIX locks, as
+// the local state does.
+func lockCacheFile(f *os.File) error {
+	flock := &syscall.Flock_t{
+		Type:   syscall.F_WRLCK,
+		Whence: int16(os.SEEK_SET),
+		Start:  0,
+		Len:    0,
+	}
+
+	for {
+		err := syscall.FcntlFlock(f.Fd(), syscall.F_SETLKW, flock)
+		if err != syscall.EINTR {
+			return err
+		}
+	}
+}
+
+func unlockCacheFile(f *os.File) error {
+	flock := &syscall.Flock_t{
+		Type:   syscall.F_UNLCK,
+		Whence: int16(os.SEEK_SET),
+		Start:  0,
+		Len:    0,
+	}
+
+	return syscall.FcntlFlock(f.Fd(), syscall.F_SETLK, flock)
+}
@@ -0,0 +1,63 @@
+// +build windows
+
+package discovery
+
+import (
+	"math"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	modkernel32      = syscall.NewLazyDLL("kernel32.dll")
+	procLockFileEx   = modkernel32.NewProc("LockFileEx")
+	procUnlockFileEx = modkernel32.NewProc("UnlockFileEx")
+)
+
+// dwFlags defined for LockFileEx
+// https://msdn.microsoft.com/en-us/library/windows/desktop/aa365203(v=vs.85).aspx
+const _LOCKFILE_EXCLUSIVE_LOCK = 2
+
+// lockCacheFile takes an exclusive lock on the given file, waiting until any
+// other process that holds it releases it. The file is opened for
+// synchronous I/O, so LockFileEx doesn't return until it has the lock.
+func lockCacheFile(f *os.File) error {
+	ol := new(syscall.Overlapped)
+	r1, _, e1 := syscall.Syscall6(
+		procLockFileEx.Addr(),
+		6,
+		f.Fd(),
+		uintptr(_LOCKFILE_EXCLUSIVE_LOCK),
+		0,              // reserved
+		0,              // bytes low
+		math.MaxUint32, // bytes high
+		uintptr(unsafe.Pointer(ol)),
+	)
+	return lockFileErr(r1, e1)
+}
+
+func unlockCacheFile(f *os.File) error {
+	ol := new(syscall.Overlapped)
+	r1, _, e1 := syscall.Syscall6(
+		procUnlockFileEx.Addr(),
+		5,
+		f.Fd(),
+		0,              // reserved
+		0,              // bytes low
+		math.MaxUint32, // bytes high
+		uintptr(unsafe.Pointer(ol)),
+		0,
+	)
+	return lockFileErr(r1, e1)
+}
+
+func lockFileErr(r1 uintptr, e1 syscall.Errno) error {
+	if r1 != 0 {
+		return nil
+	}
+	if e1 != 0 {
+		return e1
+	}
+	return syscall.EINVAL
+}
@@ -1,7 +1,15 @@
 package discovery
 
 import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestLocalPluginCache(t *testing.T) {
@@ -27,3 +35,84 @@ func TestLocalPluginCache(t *testing.T) {
 		t.Errorf("baz v0.0.2 found at %s; should not have been found", baz2Path)
 	}
 }
+
+func TestLocalPluginCache_Populate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tf-plugin-cache")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	cache := NewLocalPluginCache(filepath.Join(dir, "linux_amd64"))
+	version := VersionStr("v0.0.1").MustParse()
+
+	// Several installs race to populate the cache, but only one of them
+	// downloads the plugin.
+	var fetches int32
+	fetch := func(dir string) error {
+		atomic.AddInt32(&fetches, 1)
+		time.Sleep(10 * time.Millisecond)
+		return ioutil.WriteFile(filepath.Join(dir, "terraform-provider-foo_v0.0.1_x4"), []byte("plugin"), 0755)
+	}
+
+	var wg sync.WaitGroup
+	paths := make([]string, 5)
+	errs := make([]error, 5)
+	for i := range paths {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			paths[i], errs[i] = cache.Populate("provider", "foo", version, fetch)
+		}(i)
+	}
+	wg.Wait()
+
+	if fetches != 1 {
+		t.Fatalf("plugin was downloaded %d times; want 1", fetches)
+	}
+	for i := range paths {
+		if errs[i] != nil {
+			t.Fatalf("err: %s", errs[i])
+		}
+		if filepath.Base(paths[i]) != "terraform-provider-foo_v0.0.1_x4" {
+			t.Fatalf("wrong path %q", paths[i])
+		}
+	}
+
+	// Only the plugin and its lock file are left in the cache.
+	items, err := ioutil.ReadDir(filepath.Join(dir, "linux_amd64"))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	var names []string
+	for _, item := range items {
+		names = append(names, item.Name())
+	}
+	expected := []string{".terraform-provider-foo_v0.0.1.lock", "terraform-provider-foo_v0.0.1_x4"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Fatalf("wrong cache contents %#v", names)
+	}
+}
+
+func TestLocalPluginCache_PopulateFailed(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tf-plugin-cache")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	cache := NewLocalPluginCache(dir)
+	version := VersionStr("v0.0.1").MustParse()
+
+	// A download that fails part way leaves nothing in the cache.
+	_, err = cache.Populate("provider", "foo", version, func(dir string) error {
+		ioutil.WriteFile(filepath.Join(dir, "terraform-provider-foo_v0.0.1_x4"), []byte("plu"), 0755)
+		return errors.New("connection reset")
+	})
+	if err == nil {
+		t.Fatal("should error")
+	}
+	if path := cache.CachedPluginPath("provider", "foo", version); path != "" {
+		t.Fatalf("partial plugin was cached at %s", path)
+	}
+}
@@ -335,3 +335,46 @@ const versionList = `<!DOCTYPE html>
 </body>
 </html>
 `
+
+func TestLinkOrCopy(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tf-plugin")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	src := filepath.Join(dir, "cached")
+	if err := ioutil.WriteFile(src, []byte("new"), 0755); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// An existing plugin is replaced.
+	dst := filepath.Join(dir, "terraform-provider-foo_v0.0.1_x4")
+	if err := ioutil.WriteFile(dst, []byte("old"), 0755); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if err := linkOrCopy(src, dst); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	content, err := ioutil.ReadFile(dst)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if string(content) != "new" {
+		t.Fatalf("wrong content %q", content)
+	}
+
+	// Installing the same plugin again is harmless, and leaves no
+	// temporary files.
+	if err := linkOrCopy(src, dst); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	items, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(items) != 2 {
+		t.Fatalf("expected 2 files, got %d", len(items))
+	}
+}
@@ -0,0 +1,187 @@
+package discovery
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl"
+)
+
+// ProviderLocks are the contents of a dependency lock file, which records
+// the provider versions selected by "terraform init" along with the hashes
+// of their release archives, so that every run of a configuration uses the
+// same providers on every machine. The map is keyed by provider name.
+type ProviderLocks map[string]*ProviderLock
+
+// ProviderLock is the lock of a single provider.
+type ProviderLock struct {
+	Name    string
+	Version Version
+
+	// Source is the normalized source address of the provider, or empty
+	// for the default provider of its name. A lock only applies to the
+	// provider with the same source address.
+	Source string
+
+	// Constraints are the version constraints that were in effect when the
+	// version was selected. They are recorded for information only.
+	Constraints string
+
+	// Hashes are the hashes of the version's release archives that are
+	// known to be trustworthy, usually for several platforms, in the form
+	// returned by ArchiveHash.
+	Hashes []string
+}
+
+// ArchiveHash returns the lock file hash of a provider release archive with
+// the given hex-encoded SHA256 checksum.
+func ArchiveHash(sha256 string) string {
+	return "zh:" + strings.ToLower(sha256)
+}
+
+// HasHash returns true if the given hash is one of the lock's hashes.
+func (l *ProviderLock) HasHash(hash string) bool {
+	for _, h := range l.Hashes {
+		if h == hash {
+			return true
+		}
+	}
+	return false
+}
+
+// AddHashes adds the given hashes to the lock's hashes, keeping them sorted
+// and without duplicates.
+func (l *ProviderLock) AddHashes(hashes ...string) {
+	for _, h := range hashes {
+		if !l.HasHash(h) {
+			l.Hashes = append(l.Hashes, h)
+		}
+	}
+	sort.Strings(l.Hashes)
+}
+
+type lockFile struct {
+	Providers []*lockFileProvider `hcl:"provider"`
+}
+
+type lockFileProvider struct {
+	Name        string   `hcl:",key"`
+	Source      string   `hcl:"source"`
+	Version     string   `hcl:"version"`
+	Constraints string   `hcl:"constraints"`
+	Hashes      []string `hcl:"hashes"`
+}
+
+// ReadProviderLocks reads the dependency lock file with the given filename.
+// If the file doesn't exist then it returns nil without an error, which
+// callers take to mean that provider versions aren't locked at all.
+func ReadProviderLocks(filename string) (ProviderLocks, error) {
+	src, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var raw lockFile
+	if err := hcl.Decode(&raw, string(src)); err != nil {
+		return nil, fmt.Errorf("failed to parse dependency lock file %s: %s", filename, err)
+	}
+
+	locks := make(ProviderLocks)
+	for _, p := range raw.Providers {
+		if _, exists := locks[p.Name]; exists {
+			return nil, fmt.Errorf("dependency lock file %s has more than one lock for provider %q", filename, p.Name)
+		}
+		v, err := VersionStr(p.Version).Parse()
+		if err != nil {
+			return nil, fmt.Errorf("dependency lock file %s has invalid version %q for provider %q: %s", filename, p.Version, p.Name, err)
+		}
+
+		l := &ProviderLock{
+			Name:        p.Name,
+			Source:      p.Source,
+			Version:     v,
+			Constraints: p.Constraints,
+		}
+		l.AddHashes(p.Hashes...)
+		locks[p.Name] = l
+	}
+
+	return locks, nil
+}
+
+// Write writes the locks to the dependency lock file with the given
+// filename, replacing its previous contents.
+func (ls ProviderLocks) Write(filename string) error {
+	names := make([]string, 0, len(ls))
+	for name := range ls {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	buf.WriteString(lockFileHeader)
+	for _, name := range names {
+		l := ls[name]
+		fmt.Fprintf(&buf, "\nprovider %q {\n", name)
+		if l.Source != "" {
+			fmt.Fprintf(&buf, "  source      = %q\n", l.Source)
+		}
+		fmt.Fprintf(&buf, "  version     = %q\n", l.Version.String())
+		if l.Constraints != "" {
+			fmt.Fprintf(&buf, "  constraints = %q\n", l.Constraints)
+		}
+		if len(l.Hashes) > 0 {
+			buf.WriteString("  hashes = [\n")
+			for _, h := range l.Hashes {
+				fmt.Fprintf(&buf, "    %q,\n", h)
+			}
+			buf.WriteString("  ]\n")
+		}
+		buf.WriteString("}\n")
+	}
+
+	return ioutil.WriteFile(filename, buf.Bytes(), 0644)
+}
+
+const lockFileHeader = `# This file is maintained automatically by "terraform init".
+# Manual edits may be lost in future updates.
+`
+
+// Locked returns a copy of the receiving requirements in which the version
+// constraints of each provider that has a lock in the given locks are
+// narrowed to exactly the locked version.
+//
+// Any locks whose versions are not allowed by the requirements are returned
+// as conflicts, and the requirements of those providers are left as-is.
+// Locks of providers from other sources than the requirements' don't
+// apply, since they're locks of different providers.
+func (r PluginRequirements) Locked(locks ProviderLocks) (PluginRequirements, []*ProviderLock) {
+	ret := make(PluginRequirements, len(r))
+	var conflicts []*ProviderLock
+	for name, cons := range r {
+		c := *cons
+		ret[name] = &c
+
+		l, ok := locks[name]
+		if !ok || l.Source != cons.Source {
+			continue
+		}
+		if !cons.Versions.Allows(l.Version) {
+			conflicts = append(conflicts, l)
+			continue
+		}
+		c.Versions = cons.Versions.Append(ConstraintStr(l.Version.String()).MustParse())
+	}
+
+	sort.Slice(conflicts, func(i, j int) bool {
+		return conflicts[i].Name < conflicts[j].Name
+	})
+	return ret, conflicts
+}
@@ -0,0 +1,181 @@
+package discovery
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestProviderLocks_roundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tf-lock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, ".terraform.lock.hcl")
+
+	locks, err := ReadProviderLocks(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if locks != nil {
+		t.Fatalf("want nil locks for a missing file; got %#v", locks)
+	}
+
+	locks = ProviderLocks{
+		"aws": &ProviderLock{
+			Name:        "aws",
+			Version:     VersionStr("1.2.0").MustParse(),
+			Constraints: "~> 1.0",
+			Hashes:      []string{ArchiveHash("AB12"), ArchiveHash("cd34")},
+		},
+		"null": &ProviderLock{
+			Name:    "null",
+			Version: VersionStr("0.1.0").MustParse(),
+		},
+		"widgets": &ProviderLock{
+			Name:    "widgets",
+			Source:  "example.com/acme/widgets",
+			Version: VersionStr("2.0.0").MustParse(),
+		},
+	}
+	if err := locks.Write(filename); err != nil {
+		t.Fatal(err)
+	}
+
+	src, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := lockFileHeader + `
+provider "aws" {
+  version     = "1.2.0"
+  constraints = "~> 1.0"
+  hashes = [
+    "zh:ab12",
+    "zh:cd34",
+  ]
+}
+
+provider "null" {
+  version     = "0.1.0"
+}
+
+provider "widgets" {
+  source      = "example.com/acme/widgets"
+  version     = "2.0.0"
+}
+`
+	if string(src) != want {
+		t.Fatalf("wrong file contents\ngot:\n%s\nwant:\n%s", src, want)
+	}
+
+	got, err := ReadProviderLocks(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 {
+		t.Fatalf("wrong locks %#v", got)
+	}
+	aws := got["aws"]
+	if aws.Name != "aws" || aws.Version.String() != "1.2.0" || aws.Constraints != "~> 1.0" {
+		t.Fatalf("wrong aws lock %#v", aws)
+	}
+	if !reflect.DeepEqual(aws.Hashes, []string{"zh:ab12", "zh:cd34"}) {
+		t.Fatalf("wrong aws hashes %#v", aws.Hashes)
+	}
+	if got["null"].Version.String() != "0.1.0" || len(got["null"].Hashes) != 0 {
+		t.Fatalf("wrong null lock %#v", got["null"])
+	}
+	if got["widgets"].Source != "example.com/acme/widgets" || got["aws"].Source != "" {
+		t.Fatalf("wrong sources %q and %q", got["widgets"].Source, got["aws"].Source)
+	}
+}
+
+func TestReadProviderLocks_invalid(t *testing.T) {
+	tests := map[string]string{
+		"duplicate": `
+provider "aws" { version = "1.0.0" }
+provider "aws" { version = "1.1.0" }
+`,
+		"bad version": `provider "aws" { version = "nope" }`,
+		"bad syntax":  `provider "aws" {`,
+	}
+
+	dir, err := ioutil.TempDir("", "tf-lock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for name, src := range tests {
+		t.Run(name, func(t *testing.T) {
+			filename := filepath.Join(dir, ".terraform.lock.hcl")
+			if err := ioutil.WriteFile(filename, []byte(src), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := ReadProviderLocks(filename); err == nil {
+				t.Fatal("succeeded; want error")
+			}
+		})
+	}
+}
+
+func TestPluginRequirementsLocked(t *testing.T) {
+	reqd := PluginRequirements{
+		"aws": &PluginConstraints{
+			Versions: ConstraintStr(">= 1.0").MustParse(),
+		},
+		"google": &PluginConstraints{
+			Versions: ConstraintStr("~> 2.0").MustParse(),
+		},
+		"null": &PluginConstraints{
+			Versions: AllVersions,
+		},
+		"widgets": &PluginConstraints{
+			Versions: AllVersions,
+			Source:   "example.com/acme/widgets",
+		},
+	}
+	locks := ProviderLocks{
+		"aws": &ProviderLock{
+			Name:    "aws",
+			Version: VersionStr("1.2.0").MustParse(),
+		},
+		"google": &ProviderLock{
+			Name:    "google",
+			Version: VersionStr("1.0.0").MustParse(),
+		},
+		// A lock of the default provider of the name doesn't apply.
+		"widgets": &ProviderLock{
+			Name:    "widgets",
+			Version: VersionStr("0.5.0").MustParse(),
+		},
+	}
+
+	got, conflicts := reqd.Locked(locks)
+	if len(conflicts) != 1 || conflicts[0].Name != "google" {
+		t.Fatalf("wrong conflicts %#v", conflicts)
+	}
+
+	aws := got["aws"].Versions
+	if !aws.Allows(VersionStr("1.2.0").MustParse()) || aws.Allows(VersionStr("1.3.0").MustParse()) {
+		t.Fatalf("wrong aws constraints %s", aws)
+	}
+	if got["google"].Versions.String() != "~> 2.0" {
+		t.Fatalf("wrong google constraints %s", got["google"].Versions)
+	}
+	if !got["null"].Versions.Unconstrained() {
+		t.Fatalf("wrong null constraints %s", got["null"].Versions)
+	}
+	if !got["widgets"].Versions.Unconstrained() {
+		t.Fatalf("wrong widgets constraints %s", got["widgets"].Versions)
+	}
+
+	// The receiver is unchanged
+	if reqd["aws"].Versions.String() != ">= 1.0" {
+		t.Fatalf("receiver was modified: %s", reqd["aws"].Versions)
+	}
+}
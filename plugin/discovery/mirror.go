@@ -0,0 +1,243 @@
+package discovery
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	getter "github.com/hashicorp/go-getter"
+)
+
+// Mirror downloads the release archives of the newest version of the named
+// provider that meets the given constraints for the given platforms, named
+// like "linux_amd64", into dir in the layout read by FilesystemMirrorSource,
+// and returns the version mirrored.
+//
+// The archives are taken from the most preferred source that has them for
+// all of the platforms. Each archive is verified against its checksum from
+// the source, and against Locks if the version is locked, before it's added
+// to the mirror, and the SHA256SUMS file of the version directory is updated
+// with its checksum.
+func (i *ProviderInstaller) Mirror(dir, provider string, req Constraints, platforms []string) (Version, error) {
+	versions, versionSources, err := i.findVersions(provider, req)
+	if err != nil {
+		return Version{}, err
+	}
+
+	v := versions[0]
+	var missing []string
+	for _, source := range versionSources[v.String()] {
+		pkgs := make(map[string]*ProviderPackage, len(platforms))
+		fileNames := make(map[string]string, len(platforms))
+		missing = nil
+		for _, platform := range platforms {
+			parts := strings.SplitN(platform, "_", 2)
+			if len(parts) != 2 {
+				return Version{}, fmt.Errorf("invalid platform %q", platform)
+			}
+
+			log.Printf("[DEBUG] fetching provider info for %s version %s for %s from %s", provider, v, platform, source)
+			pkg, err := source.Package(&ProviderPackageRequest{
+				Name:                  provider,
+				Version:               v,
+				OS:                    parts[0],
+				Arch:                  parts[1],
+				PluginProtocolVersion: i.PluginProtocolVersion,
+				SkipVerify:            i.SkipVerify,
+			})
+			if err != nil {
+				return Version{}, err
+			}
+			if pkg == nil {
+				missing = append(missing, platform)
+				continue
+			}
+			pkgs[platform] = pkg
+			fileNames[platform] = providerFileName(provider, v.String(), parts[0], parts[1])
+		}
+		if len(missing) > 0 {
+			log.Printf("[INFO] no packages of %s version %s for %s from %s", provider, v, strings.Join(missing, ", "), source)
+			continue
+		}
+
+		vdir := filepath.Join(dir, providerName(provider), v.String())
+		if err := os.MkdirAll(vdir, os.ModePerm); err != nil {
+			return Version{}, fmt.Errorf("failed to create mirror dir %s: %s", vdir, err)
+		}
+
+		sums := make(map[string]string)
+		for _, platform := range platforms {
+			pkg, fileName := pkgs[platform], fileNames[platform]
+			i.Ui.Info(fmt.Sprintf("- Downloading package for %s...", platform))
+			sum, err := i.mirrorPackage(filepath.Join(vdir, fileName), provider, v, pkg)
+			if err != nil {
+				return Version{}, fmt.Errorf("failed to mirror the package for %s: %s", platform, err)
+			}
+			sums[fileName] = sum
+			i.Ui.Info(fmt.Sprintf("- Package for %s verified: %s", platform, pkg.Authentication))
+		}
+
+		if err := updateSHA256SUMS(vdir, provider, v, sums); err != nil {
+			return Version{}, err
+		}
+		return v, nil
+	}
+
+	return Version{}, fmt.Errorf(
+		"no source has packages of version %s for all of the platforms; missing %s",
+		v, strings.Join(missing, ", "))
+}
+
+// mirrorPackage downloads the release archive of the given package to the
+// given filename, returning its SHA256 checksum. The archive is only moved
+// into place once it's verified.
+func (i *ProviderInstaller) mirrorPackage(filename, provider string, v Version, pkg *ProviderPackage) (string, error) {
+	u, err := url.Parse(pkg.URL)
+	if err != nil {
+		return "", err
+	}
+	// Keep go-getter from extracting the archive
+	q := u.Query()
+	q.Set("archive", "false")
+	u.RawQuery = q.Encode()
+
+	// The file getter symlinks local files by default, which would leave
+	// the mirror depending on the source.
+	getters := make(map[string]getter.Getter, len(getter.Getters))
+	for k, g := range getter.Getters {
+		getters[k] = g
+	}
+	getters["file"] = &getter.FileGetter{Copy: true}
+
+	tmp := filepath.Join(filepath.Dir(filename), "."+filepath.Base(filename)+".tmp")
+	defer os.Remove(tmp)
+
+	log.Printf("[DEBUG] downloading %s to %s", u, tmp)
+	client := &getter.Client{
+		Src:     u.String(),
+		Dst:     tmp,
+		Mode:    getter.ClientModeFile,
+		Getters: getters,
+	}
+	if err := client.Get(); err != nil {
+		return "", err
+	}
+
+	sum, err := fileSHA256(tmp)
+	if err != nil {
+		return "", err
+	}
+	if pkg.SHA256 != "" && !strings.EqualFold(pkg.SHA256, sum) {
+		return "", fmt.Errorf("the checksum of the package doesn't match %s", pkg.SHA256)
+	}
+	if err := i.checkLock(provider, v, sum); err != nil {
+		return "", err
+	}
+
+	if err := os.Rename(tmp, filename); err != nil {
+		return "", err
+	}
+	return sum, nil
+}
+
+// updateSHA256SUMS adds the given checksums, keyed by archive filename, to
+// the SHA256SUMS file of a version directory of a filesystem mirror.
+func updateSHA256SUMS(vdir, provider string, v Version, sums map[string]string) error {
+	sumsPath := filepath.Join(vdir, fmt.Sprintf("%s_%s_SHA256SUMS", providerName(provider), v))
+
+	existing, err := ioutil.ReadFile(sumsPath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	all := make(map[string]string)
+	for _, line := range strings.Split(string(existing), "\n") {
+		parts := strings.Fields(line)
+		if len(parts) > 1 {
+			all[parts[1]] = parts[0]
+		}
+	}
+	for name, sum := range sums {
+		all[name] = sum
+	}
+
+	names := make([]string, 0, len(all))
+	for name := range all {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	for _, name := range names {
+		fmt.Fprintf(&buf, "%s  %s\n", all[name], name)
+	}
+	if err := ioutil.WriteFile(sumsPath, buf.Bytes(), 0644); err != nil {
+		return err
+	}
+
+	// A signature of the previous checksums no longer applies.
+	if err := os.Remove(sumsPath + ".sig"); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// WriteMirrorIndex writes the JSON documents of the provider network mirror
+// protocol for the named provider into dir, describing the versions and
+// release archives that dir has in the layout read by
+// FilesystemMirrorSource. The same directory can then be served over HTTPS
+// for a NetworkMirrorSource, as well as read from disk.
+func WriteMirrorIndex(dir, provider string) error {
+	fs := &FilesystemMirrorSource{Dir: dir}
+	versions, err := fs.AvailableVersions(provider)
+	if err != nil {
+		return err
+	}
+
+	pdir := filepath.Join(dir, provider)
+	if err := os.MkdirAll(pdir, os.ModePerm); err != nil {
+		return err
+	}
+
+	index := networkMirrorIndex{Versions: make(map[string]struct{})}
+	for _, v := range versions {
+		hashes, err := fs.PackageHashes(provider, v)
+		if err != nil {
+			return err
+		}
+		if len(hashes) == 0 {
+			continue
+		}
+
+		doc := networkMirrorVersion{Archives: make(map[string]*networkMirrorArchive)}
+		for platform, h := range hashes {
+			parts := strings.SplitN(platform, "_", 2)
+			doc.Archives[platform] = &networkMirrorArchive{
+				// Relative to the version document, in DIR/NAME
+				URL:    path.Join("..", providerName(provider), v.String(), providerFileName(provider, v.String(), parts[0], parts[1])),
+				Hashes: []string{h},
+			}
+		}
+		if err := writeJSON(filepath.Join(pdir, v.String()+".json"), &doc); err != nil {
+			return err
+		}
+		index.Versions[v.String()] = struct{}{}
+	}
+
+	return writeJSON(filepath.Join(pdir, "index.json"), &index)
+}
+
+func writeJSON(filename string, v interface{}) error {
+	src, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, append(src, '\n'), 0644)
+}
@@ -0,0 +1,137 @@
+package discovery
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/mitchellh/cli"
+)
+
+func TestProviderInstallerMirror(t *testing.T) {
+	source := testFilesystemMirror(t, "1.0.0", "1.1.0")
+	defer os.RemoveAll(source)
+
+	dir, err := ioutil.TempDir("", "tf-mirror")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	i := &ProviderInstaller{
+		PluginProtocolVersion: 4,
+		Sources: []*ProviderInstallationSource{
+			{Source: &FilesystemMirrorSource{Dir: source}},
+		},
+		Ui: cli.NewMockUi(),
+	}
+
+	v, err := i.Mirror(dir, "mirrored", ConstraintStr("~> 1.0.0").MustParse(), []string{"linux_amd64"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.String() != "1.0.0" {
+		t.Fatalf("wrong version %s", v)
+	}
+
+	// The mirror is a copy of the source, which can be read as a mirror in
+	// its turn.
+	archive := filepath.Join(dir, "terraform-provider-mirrored", "1.0.0", "terraform-provider-mirrored_1.0.0_linux_amd64.zip")
+	if fi, err := os.Lstat(archive); err != nil || !fi.Mode().IsRegular() {
+		t.Fatalf("archive not mirrored: %v", err)
+	}
+	_, sum := testProviderArchive(t, "1.0.0")
+	hashes, err := (&FilesystemMirrorSource{Dir: dir}).PackageHashes("mirrored", v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]string{"linux_amd64": ArchiveHash(sum)}; !reflect.DeepEqual(hashes, want) {
+		t.Fatalf("wrong hashes\ngot:  %#v\nwant: %#v", hashes, want)
+	}
+
+	// A platform that the source has no package for fails.
+	if _, err := i.Mirror(dir, "mirrored", AllVersions, []string{"linux_amd64", "darwin_amd64"}); err == nil {
+		t.Fatal("want error for a missing platform")
+	}
+
+	// A package that doesn't match the locked hashes isn't mirrored.
+	i.Locks = ProviderLocks{
+		"mirrored": &ProviderLock{
+			Name:    "mirrored",
+			Version: VersionStr("1.1.0").MustParse(),
+			Hashes:  []string{"zh:other"},
+		},
+	}
+	if _, err := i.Mirror(dir, "mirrored", AllVersions, []string{"linux_amd64"}); err == nil {
+		t.Fatal("want error for a package that doesn't match the lock")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "terraform-provider-mirrored", "1.1.0", "terraform-provider-mirrored_1.1.0_linux_amd64.zip")); !os.IsNotExist(err) {
+		t.Fatalf("package that doesn't match the lock was mirrored: %v", err)
+	}
+}
+
+func TestWriteMirrorIndex(t *testing.T) {
+	dir := testFilesystemMirror(t, "1.0.0", "1.1.0")
+	defer os.RemoveAll(dir)
+
+	if err := WriteMirrorIndex(dir, "mirrored"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The directory can be served as a network mirror.
+	server := httptest.NewServer(http.FileServer(http.Dir(dir)))
+	defer server.Close()
+
+	s := &NetworkMirrorSource{URL: server.URL}
+	versions, err := s.AvailableVersions("mirrored")
+	if err != nil {
+		t.Fatal(err)
+	}
+	Versions(versions).Sort()
+	if got := fmt.Sprint(versions); got != "[1.1.0 1.0.0]" {
+		t.Fatalf("wrong versions %s", got)
+	}
+
+	_, sum := testProviderArchive(t, "1.1.0")
+	pkg, err := s.Package(&ProviderPackageRequest{
+		Name:    "mirrored",
+		Version: VersionStr("1.1.0").MustParse(),
+		OS:      "linux",
+		Arch:    "amd64",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := server.URL + "/terraform-provider-mirrored/1.1.0/terraform-provider-mirrored_1.1.0_linux_amd64.zip?checksum=sha256%3A" + sum
+	if pkg == nil || pkg.URL != want {
+		t.Fatalf("wrong package %#v\nwant URL: %s", pkg, want)
+	}
+
+	// The hashes are written as they're recorded in the lock file.
+	var doc networkMirrorVersion
+	src, err := ioutil.ReadFile(filepath.Join(dir, "mirrored", "1.1.0.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(src, &doc); err != nil {
+		t.Fatal(err)
+	}
+	archive := doc.Archives["linux_amd64"]
+	if archive == nil || !reflect.DeepEqual(archive.Hashes, []string{ArchiveHash(sum)}) {
+		t.Fatalf("wrong archive %#v", archive)
+	}
+
+	hashes, err := s.PackageHashes("mirrored", VersionStr("1.1.0").MustParse())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := hashes["linux_amd64"], ArchiveHash(sum); got != want {
+		t.Fatalf("wrong hash %q; want %q", got, want)
+	}
+}
@@ -19,6 +19,11 @@ type PluginConstraints struct {
 	// If non-nil, the hash of the on-disk plugin executable must exactly
 	// match the SHA256 hash given here.
 	SHA256 []byte
+
+	// Source is the normalized source address of the plugin, such as
+	// "example.com/acme/widgets", or empty for the default plugin of its
+	// name in the "hashicorp" namespace of the public registry.
+	Source string
 }
 
 // Allows returns true if the given version is within the receiver's version
@@ -50,11 +55,17 @@ func (r PluginRequirements) Merge(other PluginRequirements) PluginRequirements {
 		ret[n] = &PluginConstraints{
 			Versions: Constraints{}.Append(c.Versions),
 			SHA256:   c.SHA256,
+			Source:   c.Source,
 		}
 	}
 	for n, c := range other {
 		if existing, exists := ret[n]; exists {
 			ret[n].Versions = ret[n].Versions.Append(c.Versions)
+			if existing.Source == "" {
+				// Requirements that disagree about the source can't be
+				// merged meaningfully, so callers check for that first.
+				ret[n].Source = c.Source
+			}
 
 			if existing.SHA256 != nil {
 				if c.SHA256 != nil && !bytes.Equal(c.SHA256, existing.SHA256) {
@@ -71,6 +82,7 @@ func (r PluginRequirements) Merge(other PluginRequirements) PluginRequirements {
 			ret[n] = &PluginConstraints{
 				Versions: Constraints{}.Append(c.Versions),
 				SHA256:   c.SHA256,
+				Source:   c.Source,
 			}
 		}
 	}
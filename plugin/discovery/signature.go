@@ -2,22 +2,129 @@ package discovery
 
 import (
 	"bytes"
+	"fmt"
 	"log"
 	"strings"
 
 	"golang.org/x/crypto/openpgp"
 )
 
+// PackageTrust is the level of trust in a provider package that was
+// established by verifying it when it was installed, from least to most
+// trusted.
+type PackageTrust int
+
+const (
+	// PackageUnverified packages weren't verified at all.
+	PackageUnverified PackageTrust = iota
+
+	// PackageChecksumVerified packages matched the checksum given by their
+	// source, but the checksum wasn't signed, so they are only as
+	// trustworthy as the source itself.
+	PackageChecksumVerified
+
+	// PackageCommunity packages matched a checksum signed by a key that
+	// their source provided, but that HashiCorp hasn't vouched for. These
+	// are usually signed by the provider's own developers.
+	PackageCommunity
+
+	// PackagePartner packages matched a checksum signed by a key that
+	// their source provided along with a trust signature from HashiCorp.
+	PackagePartner
+
+	// PackageOfficial packages matched a checksum signed by HashiCorp.
+	PackageOfficial
+)
+
+// PackageAuthentication describes how a provider package was verified.
+type PackageAuthentication struct {
+	Trust PackageTrust
+
+	// KeyID is the ID of the key that signed the package's checksum, for
+	// community and partner packages.
+	KeyID string
+}
+
+func (a PackageAuthentication) String() string {
+	switch a.Trust {
+	case PackageOfficial:
+		return "signed by HashiCorp"
+	case PackagePartner:
+		return fmt.Sprintf("signed by a HashiCorp partner, key ID %s", a.KeyID)
+	case PackageCommunity:
+		return fmt.Sprintf("self-signed, key ID %s", a.KeyID)
+	case PackageChecksumVerified:
+		return "checksum verified, but not signed"
+	default:
+		return "not verified"
+	}
+}
+
+// SigningKey is a public key that a package source says the checksums of
+// its packages may be signed with.
+type SigningKey struct {
+	// ASCIIArmor is the armored public key.
+	ASCIIArmor string `json:"ascii_armor"`
+
+	// TrustSignature, if set, is an armored detached signature of
+	// ASCIIArmor made by HashiCorp, showing that the key belongs to a
+	// HashiCorp partner.
+	TrustSignature string `json:"trust_signature"`
+}
+
+// officialKey is the armored public key that official releases are signed
+// with. It's only a variable so that tests can replace it.
+var officialKey = hashiPublicKey
+
 // Verify the data using the provided openpgp detached signature and the
 // embedded hashicorp public key.
 func verifySig(data, sig []byte) error {
-	el, err := openpgp.ReadArmoredKeyRing(strings.NewReader(hashiPublicKey))
+	_, err := openpgp.CheckDetachedSignature(officialKeyRing(), bytes.NewReader(data), bytes.NewReader(sig))
+	return err
+}
+
+// verifySignedChecksums verifies the detached signature of a SHA256SUMS
+// file, accepting signatures made either by HashiCorp or with one of the
+// given keys, and returns how the checksums are authenticated as a result.
+func verifySignedChecksums(sums, sig []byte, keys []SigningKey) (PackageAuthentication, error) {
+	if err := verifySig(sums, sig); err == nil {
+		return PackageAuthentication{Trust: PackageOfficial}, nil
+	}
+
+	for _, key := range keys {
+		el, err := openpgp.ReadArmoredKeyRing(strings.NewReader(key.ASCIIArmor))
+		if err != nil {
+			return PackageAuthentication{}, fmt.Errorf("invalid signing key: %s", err)
+		}
+		signer, err := openpgp.CheckDetachedSignature(el, bytes.NewReader(sums), bytes.NewReader(sig))
+		if err != nil {
+			continue
+		}
+		keyID := signer.PrimaryKey.KeyIdString()
+
+		if key.TrustSignature == "" {
+			return PackageAuthentication{Trust: PackageCommunity, KeyID: keyID}, nil
+		}
+		_, err = openpgp.CheckArmoredDetachedSignature(
+			officialKeyRing(),
+			strings.NewReader(key.ASCIIArmor),
+			strings.NewReader(key.TrustSignature),
+		)
+		if err != nil {
+			return PackageAuthentication{}, fmt.Errorf("invalid trust signature for signing key %s: %s", keyID, err)
+		}
+		return PackageAuthentication{Trust: PackagePartner, KeyID: keyID}, nil
+	}
+
+	return PackageAuthentication{}, fmt.Errorf("checksums are not signed by HashiCorp or with any of the source's signing keys")
+}
+
+func officialKeyRing() openpgp.EntityList {
+	el, err := openpgp.ReadArmoredKeyRing(strings.NewReader(officialKey))
 	if err != nil {
 		log.Fatal(err)
 	}
-
-	_, err = openpgp.CheckDetachedSignature(el, bytes.NewReader(data), bytes.NewReader(sig))
-	return err
+	return el
 }
 
 // this is the public key that signs the checksums file for releases.
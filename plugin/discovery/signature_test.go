@@ -0,0 +1,236 @@
+package discovery
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+)
+
+// testSigningKey returns a new signing key along with its armored public
+// key.
+func testSigningKey(t *testing.T, name string) (*openpgp.Entity, string) {
+	e, err := openpgp.NewEntity(name, "", name+"@example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Serializing the private key makes the self-signatures that the
+	// public key needs.
+	if err := e.SerializePrivate(ioutil.Discard, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	w, err := armor.Encode(&buf, openpgp.PublicKeyType, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Serialize(w); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return e, buf.String()
+}
+
+// testOfficialKey replaces the official key with a new key for the
+// duration of a test, returning the key and a function to restore the
+// original.
+func testOfficialKey(t *testing.T) (*openpgp.Entity, func()) {
+	e, pub := testSigningKey(t, "official")
+	orig := officialKey
+	officialKey = pub
+	return e, func() { officialKey = orig }
+}
+
+func testDetachSign(t *testing.T, e *openpgp.Entity, data string) []byte {
+	var buf bytes.Buffer
+	if err := openpgp.DetachSign(&buf, e, strings.NewReader(data), nil); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func testTrustSignature(t *testing.T, e *openpgp.Entity, pub string) string {
+	var buf bytes.Buffer
+	if err := openpgp.ArmoredDetachSign(&buf, e, strings.NewReader(pub), nil); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestVerifySignedChecksums(t *testing.T) {
+	official, restore := testOfficialKey(t)
+	defer restore()
+	developer, developerPub := testSigningKey(t, "developer")
+	_, otherPub := testSigningKey(t, "other")
+
+	sums := "abc123  terraform-provider-test_1.0.0_linux_amd64.zip\n"
+	developerID := developer.PrimaryKey.KeyIdString()
+
+	tests := map[string]struct {
+		Signer *openpgp.Entity
+		Keys   []SigningKey
+		Want   PackageAuthentication
+		Err    bool
+	}{
+		"official": {
+			Signer: official,
+			Want:   PackageAuthentication{Trust: PackageOfficial},
+		},
+		"partner": {
+			Signer: developer,
+			Keys: []SigningKey{
+				{ASCIIArmor: otherPub},
+				{
+					ASCIIArmor:     developerPub,
+					TrustSignature: testTrustSignature(t, official, developerPub),
+				},
+			},
+			Want: PackageAuthentication{Trust: PackagePartner, KeyID: developerID},
+		},
+		"community": {
+			Signer: developer,
+			Keys:   []SigningKey{{ASCIIArmor: developerPub}},
+			Want:   PackageAuthentication{Trust: PackageCommunity, KeyID: developerID},
+		},
+		"invalid trust signature": {
+			Signer: developer,
+			Keys: []SigningKey{
+				{
+					ASCIIArmor:     developerPub,
+					TrustSignature: testTrustSignature(t, developer, developerPub),
+				},
+			},
+			Err: true,
+		},
+		"unknown key": {
+			Signer: developer,
+			Keys:   []SigningKey{{ASCIIArmor: otherPub}},
+			Err:    true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			sig := testDetachSign(t, test.Signer, sums)
+			got, err := verifySignedChecksums([]byte(sums), sig, test.Keys)
+			if test.Err {
+				if err == nil {
+					t.Fatalf("succeeded with %s; want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.Want {
+				t.Fatalf("wrong result\ngot:  %#v\nwant: %#v", got, test.Want)
+			}
+		})
+	}
+}
+
+func TestFilesystemMirrorSource_signed(t *testing.T) {
+	official, restore := testOfficialKey(t)
+	defer restore()
+
+	dir := testFilesystemMirror(t, "1.0.0")
+	defer os.RemoveAll(dir)
+
+	vdir := filepath.Join(dir, "terraform-provider-mirrored", "1.0.0")
+	sumsPath := filepath.Join(vdir, "terraform-provider-mirrored_1.0.0_SHA256SUMS")
+	sums, err := ioutil.ReadFile(sumsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(sumsPath+".sig", testDetachSign(t, official, string(sums)), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	s := &FilesystemMirrorSource{Dir: dir}
+	req := &ProviderPackageRequest{
+		Name:    "mirrored",
+		Version: VersionStr("1.0.0").MustParse(),
+		OS:      "linux",
+		Arch:    "amd64",
+	}
+	pkg, err := s.Package(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pkg.Authentication.Trust != PackageOfficial {
+		t.Fatalf("wrong authentication %s", pkg.Authentication)
+	}
+
+	// A signature made with any other key is rejected
+	other, _ := testSigningKey(t, "other")
+	if err := ioutil.WriteFile(sumsPath+".sig", testDetachSign(t, other, string(sums)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Package(req); err == nil {
+		t.Fatal("want error for an invalid signature")
+	}
+}
+
+func TestNetworkMirrorSource_signed(t *testing.T) {
+	_, restore := testOfficialKey(t)
+	defer restore()
+	developer, developerPub := testSigningKey(t, "developer")
+
+	_, sum := testProviderArchive(t, "1.0.0")
+	archiveHash := sum
+	sums := fmt.Sprintf("%s  mirrored_1.0.0_linux_amd64.zip\n", sum)
+	sig := testDetachSign(t, developer, sums)
+
+	handler := http.NewServeMux()
+	handler.HandleFunc("/providers/mirrored/1.0.0.json", func(w http.ResponseWriter, r *http.Request) {
+		keys := fmt.Sprintf(`[{"ascii_armor": %q}]`, developerPub)
+		fmt.Fprintf(w, `{
+			"archives": {"linux_amd64": {"url": "archives/mirrored_1.0.0_linux_amd64.zip", "hashes": ["sha256:%s"]}},
+			"shasums_url": "archives/SHA256SUMS",
+			"shasums_signature_url": "archives/SHA256SUMS.sig",
+			"signing_keys": %s
+		}`, archiveHash, keys)
+	})
+	handler.HandleFunc("/providers/mirrored/archives/SHA256SUMS", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(sums))
+	})
+	handler.HandleFunc("/providers/mirrored/archives/SHA256SUMS.sig", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(sig)
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	s := &NetworkMirrorSource{URL: server.URL + "/providers"}
+	req := &ProviderPackageRequest{
+		Name:    "mirrored",
+		Version: VersionStr("1.0.0").MustParse(),
+		OS:      "linux",
+		Arch:    "amd64",
+	}
+	pkg, err := s.Package(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := PackageAuthentication{Trust: PackageCommunity, KeyID: developer.PrimaryKey.KeyIdString()}
+	if pkg.Authentication != want || pkg.SHA256 != sum {
+		t.Fatalf("wrong package %#v", pkg)
+	}
+
+	// The archive's hash must match its signed checksum
+	archiveHash = strings.Repeat("0", 64)
+	if _, err := s.Package(req); err == nil {
+		t.Fatal("want error for a hash that doesn't match the signed checksum")
+	}
+}
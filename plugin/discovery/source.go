@@ -0,0 +1,543 @@
+package discovery
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// A ProviderSource is a location that provider plugins can be installed
+// from, such as the official releases service or a mirror of it.
+type ProviderSource interface {
+	// AvailableVersions returns the versions of the named provider that the
+	// source has, or ErrorNoSuchProvider if it doesn't have the provider
+	// at all.
+	AvailableVersions(name string) ([]Version, error)
+
+	// Package returns the location of the release archive for the given
+	// request, and how it is verified unless verification is skipped. It
+	// returns nil if the source has no archive for the requested platform,
+	// or if the archive is known to be incompatible with the requested
+	// plugin protocol version.
+	Package(req *ProviderPackageRequest) (*ProviderPackage, error)
+
+	// PackageHashes returns the hashes of the release archives of the given
+	// version of the named provider for all of the platforms the source has
+	// archives for, in the form returned by ArchiveHash, keyed by platform
+	// name such as "linux_amd64".
+	PackageHashes(name string, version Version) (map[string]string, error)
+
+	// String returns a description of the source for messages.
+	String() string
+}
+
+// ProviderPackageRequest describes the release archive of a provider that
+// is to be installed.
+type ProviderPackageRequest struct {
+	Name    string
+	Version Version
+
+	// OS and Arch are the platform to install for, using the same labels
+	// as the runtime.GOOS and runtime.GOARCH variables.
+	OS   string
+	Arch string
+
+	PluginProtocolVersion uint
+	SkipVerify            bool
+}
+
+// ProviderPackage is the location of a provider release archive.
+type ProviderPackage struct {
+	// URL is the go-getter URL of the archive, which includes the SHA256
+	// checksum for go-getter to verify the download against, if any.
+	URL string
+
+	// SHA256 is the hex-encoded SHA256 checksum of the archive, or an
+	// empty string if the archive isn't verified.
+	SHA256 string
+
+	// Authentication describes how SHA256 itself was verified.
+	Authentication PackageAuthentication
+}
+
+// ProviderInstallationSource is a provider source along with the rules
+// that decide which providers may be installed from it.
+type ProviderInstallationSource struct {
+	Source ProviderSource
+
+	// Include and Exclude are lists of provider name patterns, which may
+	// use the wildcards supported by path.Match. If Include is non-empty
+	// then only the providers matching it may be installed from the
+	// source, and the providers matching Exclude never are.
+	Include []string
+	Exclude []string
+}
+
+// Allows returns true if the named provider may be installed from the
+// source.
+func (s *ProviderInstallationSource) Allows(name string) bool {
+	if len(s.Include) > 0 && !matchProviderName(s.Include, name) {
+		return false
+	}
+	return !matchProviderName(s.Exclude, name)
+}
+
+func matchProviderName(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		// The patterns are validated when the CLI configuration is
+		// loaded, so an invalid pattern just never matches.
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// DirectSource is a ProviderSource that installs providers directly from
+// the official HashiCorp releases service.
+type DirectSource struct{}
+
+// AvailableVersions implements ProviderSource.
+func (s DirectSource) AvailableVersions(name string) ([]Version, error) {
+	i := &ProviderInstaller{}
+	return i.listProviderVersions(name)
+}
+
+// Package implements ProviderSource. The checksums of official releases are
+// signed by HashiCorp.
+func (s DirectSource) Package(req *ProviderPackageRequest) (*ProviderPackage, error) {
+	i := &ProviderInstaller{OS: req.OS, Arch: req.Arch}
+	v := req.Version.String()
+	pkg := &ProviderPackage{
+		URL: i.providerURL(req.Name, v),
+	}
+
+	if !req.SkipVerify {
+		sha256, err := i.getProviderChecksum(req.Name, v)
+		if err != nil {
+			return nil, err
+		}
+
+		// add the checksum parameter for go-getter to verify the download for us.
+		if sha256 != "" {
+			pkg.URL = pkg.URL + "?checksum=sha256:" + sha256
+			pkg.SHA256 = sha256
+			pkg.Authentication.Trust = PackageOfficial
+		}
+	}
+
+	if !checkPlugin(pkg.URL, req.PluginProtocolVersion) {
+		return nil, nil
+	}
+	return pkg, nil
+}
+
+// PackageHashes implements ProviderSource.
+func (s DirectSource) PackageHashes(name string, version Version) (map[string]string, error) {
+	i := &ProviderInstaller{}
+	sums, err := getPluginSHA256SUMs(i.providerChecksumURL(name, version.String()))
+	if err != nil {
+		return nil, err
+	}
+	return hashesFromSHA256SUMS(sums, name, version.String()), nil
+}
+
+func (s DirectSource) String() string {
+	return releaseHost
+}
+
+// FilesystemMirrorSource is a ProviderSource that installs providers from
+// a local directory laid out like the official releases service:
+//
+//     DIR/terraform-provider-NAME/VERSION/terraform-provider-NAME_VERSION_OS_ARCH.zip
+//
+// Each version directory may also contain the release's
+// terraform-provider-NAME_VERSION_SHA256SUMS file, in which case the
+// archives are verified against it.
+type FilesystemMirrorSource struct {
+	Dir string
+}
+
+// AvailableVersions implements ProviderSource.
+func (s *FilesystemMirrorSource) AvailableVersions(name string) ([]Version, error) {
+	entries, err := ioutil.ReadDir(filepath.Join(s.Dir, providerName(name)))
+	if os.IsNotExist(err) {
+		return nil, ErrorNoSuchProvider
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var versions []Version
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		v, err := VersionStr(entry.Name()).Parse()
+		if err != nil {
+			log.Printf("[WARN] ignoring invalid version directory %s in %s", entry.Name(), s.Dir)
+			continue
+		}
+		versions = append(versions, v)
+	}
+
+	return versions, nil
+}
+
+// Package implements ProviderSource. The checksums are trusted as official
+// if the version directory also contains the release's SHA256SUMS.sig file,
+// since the mirror only has HashiCorp's key to check it with.
+func (s *FilesystemMirrorSource) Package(req *ProviderPackageRequest) (*ProviderPackage, error) {
+	v := req.Version.String()
+	dir := filepath.Join(s.Dir, providerName(req.Name), v)
+	fileName := providerFileName(req.Name, v, req.OS, req.Arch)
+
+	archive := filepath.Join(dir, fileName)
+	if _, err := os.Stat(archive); err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	pkg := &ProviderPackage{
+		URL: "file://" + filepath.ToSlash(archive),
+	}
+	if req.SkipVerify {
+		return pkg, nil
+	}
+
+	sumsPath := filepath.Join(dir, fmt.Sprintf("%s_%s_SHA256SUMS", providerName(req.Name), v))
+	sums, err := ioutil.ReadFile(sumsPath)
+	switch {
+	case os.IsNotExist(err):
+		log.Printf("[WARN] no checksums for %s in %s", fileName, s.Dir)
+		return pkg, nil
+	case err != nil:
+		return nil, err
+	}
+
+	sha256 := checksumForFile(sums, fileName)
+	if sha256 == "" {
+		return nil, fmt.Errorf("no checksum for %s in %s", fileName, dir)
+	}
+	pkg.URL = pkg.URL + "?checksum=sha256:" + sha256
+	pkg.SHA256 = sha256
+	pkg.Authentication.Trust = PackageChecksumVerified
+
+	sig, err := ioutil.ReadFile(sumsPath + ".sig")
+	switch {
+	case os.IsNotExist(err):
+		log.Printf("[WARN] no signature for the checksums of %s in %s", fileName, s.Dir)
+	case err != nil:
+		return nil, err
+	default:
+		if err := verifySig(sums, sig); err != nil {
+			return nil, fmt.Errorf("error verifying the signature of %s: %s", sumsPath, err)
+		}
+		pkg.Authentication.Trust = PackageOfficial
+	}
+
+	return pkg, nil
+}
+
+// PackageHashes implements ProviderSource. If the version directory has no
+// SHA256SUMS file then the hashes are computed from the archives in it.
+func (s *FilesystemMirrorSource) PackageHashes(name string, version Version) (map[string]string, error) {
+	v := version.String()
+	dir := filepath.Join(s.Dir, providerName(name), v)
+
+	sums, err := ioutil.ReadFile(filepath.Join(dir, fmt.Sprintf("%s_%s_SHA256SUMS", providerName(name), v)))
+	if err == nil {
+		return hashesFromSHA256SUMS(sums, name, v), nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	archives, err := filepath.Glob(filepath.Join(dir, providerFileName(name, v, "*", "*")))
+	if err != nil {
+		return nil, err
+	}
+	hashes := make(map[string]string)
+	for _, archive := range archives {
+		platform, ok := archivePlatform(filepath.Base(archive), name, v)
+		if !ok {
+			continue
+		}
+		sum, err := fileSHA256(archive)
+		if err != nil {
+			return nil, err
+		}
+		hashes[platform] = ArchiveHash(sum)
+	}
+	return hashes, nil
+}
+
+func (s *FilesystemMirrorSource) String() string {
+	return s.Dir
+}
+
+// NetworkMirrorSource is a ProviderSource that installs providers from an
+// HTTPS server implementing the provider network mirror protocol, which
+// serves the following JSON documents for each provider:
+//
+//     URL/NAME/index.json lists the available versions:
+//         {"versions": {"1.0.0": {}}}
+//
+//     URL/NAME/VERSION.json lists the release archives of the version by
+//     platform, with URLs relative to the document:
+//         {"archives": {"linux_amd64": {"url": "...", "hashes": ["zh:..."]}}}
+//
+// The "zh:" hash of an archive is its SHA256 checksum, as recorded in the
+// lock file. Hashes prefixed with "sha256:" are accepted too.
+//
+// Like a registry, the version document may also give the URLs of the
+// release's SHA256SUMS file and its detached signature, along with the
+// public keys that the signature may have been made with:
+//         {"shasums_url": "...", "shasums_signature_url": "...",
+//          "signing_keys": [{"ascii_armor": "...", "trust_signature": "..."}]}
+type NetworkMirrorSource struct {
+	URL string
+}
+
+type networkMirrorIndex struct {
+	Versions map[string]struct{} `json:"versions"`
+}
+
+type networkMirrorVersion struct {
+	Archives map[string]*networkMirrorArchive `json:"archives"`
+
+	SHASumsURL          string       `json:"shasums_url,omitempty"`
+	SHASumsSignatureURL string       `json:"shasums_signature_url,omitempty"`
+	SigningKeys         []SigningKey `json:"signing_keys,omitempty"`
+}
+
+type networkMirrorArchive struct {
+	URL    string   `json:"url"`
+	Hashes []string `json:"hashes"`
+}
+
+// AvailableVersions implements ProviderSource.
+func (s *NetworkMirrorSource) AvailableVersions(name string) ([]Version, error) {
+	var index networkMirrorIndex
+	if _, err := s.get(name+"/index.json", &index); err != nil {
+		return nil, err
+	}
+
+	var versions []Version
+	for raw := range index.Versions {
+		v, err := VersionStr(raw).Parse()
+		if err != nil {
+			log.Printf("[WARN] ignoring invalid version %q of %s from %s", raw, name, s.URL)
+			continue
+		}
+		versions = append(versions, v)
+	}
+
+	return versions, nil
+}
+
+// Package implements ProviderSource.
+func (s *NetworkMirrorSource) Package(req *ProviderPackageRequest) (*ProviderPackage, error) {
+	var doc networkMirrorVersion
+	base, err := s.get(fmt.Sprintf("%s/%s.json", req.Name, req.Version), &doc)
+	if err == ErrorNoSuchProvider {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	archive, ok := doc.Archives[req.OS+"_"+req.Arch]
+	if !ok {
+		return nil, nil
+	}
+	u, err := base.Parse(archive.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid archive URL %q from %s: %s", archive.URL, base, err)
+	}
+
+	pkg := &ProviderPackage{}
+	if !req.SkipVerify {
+		pkg.SHA256 = archiveSHA256(archive.Hashes)
+		pkg.Authentication.Trust = PackageChecksumVerified
+
+		if doc.SHASumsURL != "" {
+			if err := s.checkSignedChecksums(pkg, base, &doc, path.Base(u.Path)); err != nil {
+				return nil, err
+			}
+		}
+		if pkg.SHA256 == "" {
+			return nil, fmt.Errorf("no SHA256 hash for %s from %s", u, base)
+		}
+
+		q := u.Query()
+		q.Set("checksum", "sha256:"+pkg.SHA256)
+		u.RawQuery = q.Encode()
+	}
+
+	pkg.URL = u.String()
+	return pkg, nil
+}
+
+// checkSignedChecksums verifies the signature of the SHA256SUMS file given
+// by the version document at the given URL, and that it lists the same
+// checksum for the given archive as pkg, updating pkg with the checksum and
+// how it's authenticated.
+func (s *NetworkMirrorSource) checkSignedChecksums(pkg *ProviderPackage, base *url.URL, doc *networkMirrorVersion, fileName string) error {
+	if doc.SHASumsSignatureURL == "" {
+		return fmt.Errorf("no signature for the checksums from %s", base)
+	}
+
+	var files [2][]byte
+	for i, ref := range []string{doc.SHASumsURL, doc.SHASumsSignatureURL} {
+		u, err := base.Parse(ref)
+		if err != nil {
+			return fmt.Errorf("invalid URL %q from %s: %s", ref, base, err)
+		}
+		files[i], err = getFile(u.String())
+		if err != nil {
+			return fmt.Errorf("error fetching %s: %s", u, err)
+		}
+	}
+	sums, sig := files[0], files[1]
+
+	auth, err := verifySignedChecksums(sums, sig, doc.SigningKeys)
+	if err != nil {
+		return fmt.Errorf("error verifying the checksums from %s: %s", base, err)
+	}
+
+	listed := checksumForFile(sums, fileName)
+	switch {
+	case listed == "":
+		return fmt.Errorf("no checksum for %s in the checksums from %s", fileName, base)
+	case pkg.SHA256 != "" && !strings.EqualFold(pkg.SHA256, listed):
+		return fmt.Errorf("the SHA256 hash of %s from %s doesn't match its signed checksum", fileName, base)
+	}
+
+	pkg.SHA256 = listed
+	pkg.Authentication = auth
+	return nil
+}
+
+// PackageHashes implements ProviderSource.
+func (s *NetworkMirrorSource) PackageHashes(name string, version Version) (map[string]string, error) {
+	var doc networkMirrorVersion
+	if _, err := s.get(fmt.Sprintf("%s/%s.json", name, version), &doc); err != nil {
+		return nil, err
+	}
+
+	hashes := make(map[string]string)
+	for platform, archive := range doc.Archives {
+		if sum := archiveSHA256(archive.Hashes); sum != "" {
+			hashes[platform] = ArchiveHash(sum)
+		}
+	}
+	return hashes, nil
+}
+
+// archiveSHA256 returns the SHA256 checksum of an archive from its hashes
+// given by a network mirror, or an empty string if there's none.
+func archiveSHA256(hashes []string) string {
+	for _, h := range hashes {
+		for _, prefix := range []string{"zh:", "sha256:"} {
+			if strings.HasPrefix(h, prefix) {
+				return strings.ToLower(strings.TrimPrefix(h, prefix))
+			}
+		}
+	}
+	return ""
+}
+
+func (s *NetworkMirrorSource) String() string {
+	return s.URL
+}
+
+// get decodes the JSON document at the given path relative to the mirror's
+// URL into v, returning the document's URL. It returns ErrorNoSuchProvider
+// if the document doesn't exist.
+func (s *NetworkMirrorSource) get(p string, v interface{}) (*url.URL, error) {
+	base, err := url.Parse(strings.TrimSuffix(s.URL, "/") + "/")
+	if err != nil {
+		return nil, fmt.Errorf("invalid network mirror URL %q: %s", s.URL, err)
+	}
+	u, err := base.Parse(p)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := httpClient.Get(u.String())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, ErrorNoSuchProvider
+	default:
+		return nil, fmt.Errorf("error accessing %s: %s", u, resp.Status)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return nil, fmt.Errorf("invalid response from %s: %s", u, err)
+	}
+	return u, nil
+}
+
+// hashesFromSHA256SUMS returns the hashes of the release archives of the
+// given provider version listed in a SHA256SUMS file, keyed by platform.
+func hashesFromSHA256SUMS(sums []byte, name, version string) map[string]string {
+	hashes := make(map[string]string)
+	for _, line := range strings.Split(string(sums), "\n") {
+		parts := strings.Fields(line)
+		if len(parts) < 2 {
+			continue
+		}
+		if platform, ok := archivePlatform(parts[1], name, version); ok {
+			hashes[platform] = ArchiveHash(parts[0])
+		}
+	}
+	return hashes
+}
+
+// archivePlatform returns the platform of the release archive of the given
+// provider version with the given filename, or false if the filename isn't
+// that of such an archive.
+func archivePlatform(fileName, name, version string) (string, bool) {
+	prefix := fmt.Sprintf("%s_%s_", providerName(name), version)
+	if !strings.HasPrefix(fileName, prefix) || !strings.HasSuffix(fileName, ".zip") {
+		return "", false
+	}
+	platform := strings.TrimSuffix(strings.TrimPrefix(fileName, prefix), ".zip")
+	if strings.Count(platform, "_") != 1 {
+		return "", false
+	}
+	return platform, true
+}
+
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,351 @@
+package discovery
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/mitchellh/cli"
+)
+
+// testProviderArchive returns a release archive of the "mirrored" provider
+// at the given version, and its SHA256 checksum.
+func testProviderArchive(t *testing.T, version string) ([]byte, string) {
+	var buf bytes.Buffer
+	z := zip.NewWriter(&buf)
+	f, err := z.Create(fmt.Sprintf("terraform-provider-mirrored_v%s_x4", version))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte(testProviderFile))
+	if err := z.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	sum := sha256.Sum256(buf.Bytes())
+	return buf.Bytes(), hex.EncodeToString(sum[:])
+}
+
+// testFilesystemMirror returns a filesystem mirror containing the given
+// versions of the "mirrored" provider for linux_amd64.
+func testFilesystemMirror(t *testing.T, versions ...string) string {
+	dir, err := ioutil.TempDir("", "tf-mirror")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, v := range versions {
+		vdir := filepath.Join(dir, "terraform-provider-mirrored", v)
+		if err := os.MkdirAll(vdir, 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		archive, sum := testProviderArchive(t, v)
+		name := fmt.Sprintf("terraform-provider-mirrored_%s_linux_amd64.zip", v)
+		if err := ioutil.WriteFile(filepath.Join(vdir, name), archive, 0644); err != nil {
+			t.Fatal(err)
+		}
+		sums := fmt.Sprintf("%s  %s\n", sum, name)
+		sumsName := fmt.Sprintf("terraform-provider-mirrored_%s_SHA256SUMS", v)
+		if err := ioutil.WriteFile(filepath.Join(vdir, sumsName), []byte(sums), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
+
+func TestProviderInstallationSourceAllows(t *testing.T) {
+	tests := []struct {
+		Include []string
+		Exclude []string
+		Name    string
+		Want    bool
+	}{
+		{nil, nil, "aws", true},
+		{[]string{"aws", "google*"}, nil, "google-beta", true},
+		{[]string{"aws", "google*"}, nil, "azurerm", false},
+		{nil, []string{"internal-*"}, "internal-dns", false},
+		{[]string{"*"}, []string{"aws"}, "aws", false},
+	}
+
+	for _, test := range tests {
+		s := &ProviderInstallationSource{
+			Include: test.Include,
+			Exclude: test.Exclude,
+		}
+		if got := s.Allows(test.Name); got != test.Want {
+			t.Errorf("%#v allows %s = %t; want %t", s, test.Name, got, test.Want)
+		}
+	}
+}
+
+func TestFilesystemMirrorSource(t *testing.T) {
+	dir := testFilesystemMirror(t, "1.0.0", "1.1.0")
+	defer os.RemoveAll(dir)
+
+	s := &FilesystemMirrorSource{Dir: dir}
+	versions, err := s.AvailableVersions("mirrored")
+	if err != nil {
+		t.Fatal(err)
+	}
+	Versions(versions).Sort()
+	if len(versions) != 2 || versions[0].String() != "1.1.0" || versions[1].String() != "1.0.0" {
+		t.Fatalf("wrong versions %s", versions)
+	}
+
+	if _, err := s.AvailableVersions("nonexist"); err != ErrorNoSuchProvider {
+		t.Fatalf("want ErrorNoSuchProvider; got %v", err)
+	}
+
+	req := &ProviderPackageRequest{
+		Name:    "mirrored",
+		Version: VersionStr("1.1.0").MustParse(),
+		OS:      "linux",
+		Arch:    "amd64",
+	}
+	pkg, err := s.Package(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, sum := testProviderArchive(t, "1.1.0")
+	want := &ProviderPackage{
+		URL: "file://" + filepath.ToSlash(filepath.Join(dir, "terraform-provider-mirrored", "1.1.0",
+			"terraform-provider-mirrored_1.1.0_linux_amd64.zip")) + "?checksum=sha256:" + sum,
+		SHA256:         sum,
+		Authentication: PackageAuthentication{Trust: PackageChecksumVerified},
+	}
+	if !reflect.DeepEqual(pkg, want) {
+		t.Fatalf("wrong package\ngot:  %#v\nwant: %#v", pkg, want)
+	}
+
+	// There's no archive for other platforms
+	req.OS = "windows"
+	if pkg, err := s.Package(req); err != nil || pkg != nil {
+		t.Fatalf("want no package; got %#v, %v", pkg, err)
+	}
+}
+
+func TestNetworkMirrorSource(t *testing.T) {
+	archive, sum := testProviderArchive(t, "1.0.0")
+	handler := http.NewServeMux()
+	handler.HandleFunc("/providers/mirrored/index.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"versions": {"1.0.0": {}, "bogus": {}}}`))
+	})
+	handler.HandleFunc("/providers/mirrored/1.0.0.json", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"archives": {"linux_amd64": {"url": "archives/mirrored_1.0.0_linux_amd64.zip", "hashes": ["sha256:%s"]}}}`, sum)
+	})
+	handler.HandleFunc("/providers/mirrored/archives/mirrored_1.0.0_linux_amd64.zip", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(archive)
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	s := &NetworkMirrorSource{URL: server.URL + "/providers"}
+	versions, err := s.AvailableVersions("mirrored")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 1 || versions[0].String() != "1.0.0" {
+		t.Fatalf("wrong versions %s", versions)
+	}
+
+	if _, err := s.AvailableVersions("nonexist"); err != ErrorNoSuchProvider {
+		t.Fatalf("want ErrorNoSuchProvider; got %v", err)
+	}
+
+	req := &ProviderPackageRequest{
+		Name:    "mirrored",
+		Version: VersionStr("1.0.0").MustParse(),
+		OS:      "linux",
+		Arch:    "amd64",
+	}
+	pkg, err := s.Package(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := server.URL + "/providers/mirrored/archives/mirrored_1.0.0_linux_amd64.zip?checksum=sha256%3A" + sum
+	if pkg.URL != want {
+		t.Fatalf("wrong URL\ngot:  %s\nwant: %s", pkg.URL, want)
+	}
+	if pkg.Authentication.Trust != PackageChecksumVerified {
+		t.Fatalf("wrong authentication %s", pkg.Authentication)
+	}
+
+	tmpDir, err := ioutil.TempDir("", "tf-plugin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	i := &ProviderInstaller{
+		Dir:                   tmpDir,
+		PluginProtocolVersion: 4,
+		OS:                    "linux",
+		Arch:                  "amd64",
+		Sources: []*ProviderInstallationSource{
+			{Source: s},
+		},
+		Ui: cli.NewMockUi(),
+	}
+	meta, err := i.Get("mirrored", AllVersions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.Version != "1.0.0" {
+		t.Fatalf("wrong version %s", meta.Version)
+	}
+}
+
+func TestProviderInstallerGet_sources(t *testing.T) {
+	first := testFilesystemMirror(t, "1.0.0")
+	defer os.RemoveAll(first)
+	second := testFilesystemMirror(t, "1.0.0", "1.1.0")
+	defer os.RemoveAll(second)
+
+	tmpDir, err := ioutil.TempDir("", "tf-plugin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	i := &ProviderInstaller{
+		Dir:                   tmpDir,
+		PluginProtocolVersion: 4,
+		OS:                    "linux",
+		Arch:                  "amd64",
+		Sources: []*ProviderInstallationSource{
+			{
+				Source:  &FilesystemMirrorSource{Dir: first},
+				Include: []string{"mirrored"},
+			},
+			{
+				Source:  &FilesystemMirrorSource{Dir: second},
+				Exclude: []string{"test"},
+			},
+		},
+		Ui: cli.NewMockUi(),
+	}
+
+	// The newest version is chosen, whichever source has it
+	meta, err := i.Get("mirrored", AllVersions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := PluginMeta{
+		Name:    "mirrored",
+		Version: VersionStr("1.1.0"),
+		Path:    filepath.Join(tmpDir, "terraform-provider-mirrored_v1.1.0_x4"),
+	}
+	if !reflect.DeepEqual(meta, want) {
+		t.Fatalf("wrong result meta\ngot:  %#v\nwant: %#v", meta, want)
+	}
+
+	// A corrupt archive in the first source shows that it's preferred for
+	// the versions both sources have.
+	archive := filepath.Join(first, "terraform-provider-mirrored", "1.0.0", "terraform-provider-mirrored_1.0.0_linux_amd64.zip")
+	if err := ioutil.WriteFile(archive, []byte("corrupt"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := i.Get("mirrored", ConstraintStr("1.0.0").MustParse()); err == nil {
+		t.Fatal("want checksum error from the first source")
+	}
+
+	// The official releases aren't used unless configured, and the rules
+	// decide which sources are consulted.
+	if _, err := i.Get("test", AllVersions); err == nil {
+		t.Fatal("want error for a provider no source allows")
+	}
+	if _, err := i.Get("other", AllVersions); err != ErrorNoSuchProvider {
+		t.Fatalf("want ErrorNoSuchProvider; got %v", err)
+	}
+}
+
+func TestProviderInstallerGet_locks(t *testing.T) {
+	mirror := testFilesystemMirror(t, "1.0.0", "1.1.0")
+	defer os.RemoveAll(mirror)
+
+	tmpDir, err := ioutil.TempDir("", "tf-plugin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	_, sum := testProviderArchive(t, "1.0.0")
+	i := &ProviderInstaller{
+		Dir:                   tmpDir,
+		PluginProtocolVersion: 4,
+		OS:                    "linux",
+		Arch:                  "amd64",
+		Sources: []*ProviderInstallationSource{
+			{Source: &FilesystemMirrorSource{Dir: mirror}},
+		},
+		Locks: ProviderLocks{
+			"mirrored": &ProviderLock{
+				Name:    "mirrored",
+				Version: VersionStr("1.0.0").MustParse(),
+				Hashes:  []string{ArchiveHash(sum), "zh:other"},
+			},
+		},
+		Ui: cli.NewMockUi(),
+	}
+
+	// Installing the locked version whose hash matches retains the lock's
+	// other hashes.
+	if _, err := i.Get("mirrored", ConstraintStr("1.0.0").MustParse()); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := i.Locks["mirrored"].Hashes, []string{ArchiveHash(sum), "zh:other"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong hashes\ngot:  %#v\nwant: %#v", got, want)
+	}
+
+	// Installing another version replaces the lock.
+	if _, err := i.Get("mirrored", AllVersions); err != nil {
+		t.Fatal(err)
+	}
+	_, newSum := testProviderArchive(t, "1.1.0")
+	lock := i.Locks["mirrored"]
+	if lock.Version.String() != "1.1.0" || !reflect.DeepEqual(lock.Hashes, []string{ArchiveHash(newSum)}) {
+		t.Fatalf("wrong lock %#v", lock)
+	}
+
+	// A package that doesn't match the locked hashes isn't installed.
+	lock.Hashes = []string{"zh:other"}
+	if _, err := i.Get("mirrored", AllVersions); err == nil {
+		t.Fatal("want error for a package that doesn't match the lock")
+	}
+}
+
+func TestProviderInstallerLock(t *testing.T) {
+	mirror := testFilesystemMirror(t, "1.0.0", "1.1.0")
+	defer os.RemoveAll(mirror)
+
+	i := &ProviderInstaller{
+		Sources: []*ProviderInstallationSource{
+			{Source: &FilesystemMirrorSource{Dir: mirror}},
+		},
+	}
+
+	lock, err := i.Lock("mirrored", ConstraintStr("< 1.1").MustParse(), []string{"linux_amd64"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, sum := testProviderArchive(t, "1.0.0")
+	if lock.Version.String() != "1.0.0" || !reflect.DeepEqual(lock.Hashes, []string{ArchiveHash(sum)}) {
+		t.Fatalf("wrong lock %#v", lock)
+	}
+
+	// The mirror has no packages for other platforms
+	if _, err := i.Lock("mirrored", AllVersions, []string{"linux_amd64", "darwin_amd64"}); err == nil {
+		t.Fatal("want error for a missing platform")
+	}
+}
@@ -0,0 +1,170 @@
+package plugin
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform/terraform"
+)
+
+// activeCalls tracks the RPC calls currently being handled by this plugin
+// process, so that log entries can be associated with them.
+var activeCalls = &callTracker{
+	calls: make(map[int]callInfo),
+}
+
+// callTracker is the set of RPC calls in progress.
+type callTracker struct {
+	lock  sync.Mutex
+	next  int
+	calls map[int]callInfo
+}
+
+// callInfo describes an RPC call in progress.
+type callInfo struct {
+	RPC      string
+	Resource string
+}
+
+// begin records that the named RPC has started, optionally on behalf of
+// the resource with the given address. The returned function must be
+// called once the RPC completes.
+func (t *callTracker) begin(rpc, resource string) func() {
+	t.lock.Lock()
+	id := t.next
+	t.next++
+	t.calls[id] = callInfo{RPC: rpc, Resource: resource}
+	t.lock.Unlock()
+
+	return func() {
+		t.lock.Lock()
+		delete(t.calls, id)
+		t.lock.Unlock()
+	}
+}
+
+// current returns the only RPC call in progress. The second return value
+// is false if there are no calls in progress or more than one, in which case
+// a log entry can't be attributed to any particular call.
+func (t *callTracker) current() (callInfo, bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if len(t.calls) != 1 {
+		return callInfo{}, false
+	}
+	for _, c := range t.calls {
+		return c, true
+	}
+	return callInfo{}, false
+}
+
+// logLevels maps the level prefixes used with the standard logger to the
+// level names used in structured log entries.
+var logLevels = map[string]string{
+	"TRACE":   "trace",
+	"DEBUG":   "debug",
+	"INFO":    "info",
+	"WARN":    "warn",
+	"WARNING": "warn",
+	"ERR":     "error",
+	"ERROR":   "error",
+}
+
+// logEntry returns the structured (JSON) log entry for the given line
+// written to the standard logger, which is expected to have a level prefix
+// like "[DEBUG]". Lines without a recognized prefix are logged at the debug
+// level. The given call, if non-nil, is attached to the entry.
+func logEntry(line string, now time.Time, call *callInfo) []byte {
+	level := "debug"
+	msg := line
+	if strings.HasPrefix(line, "[") {
+		if end := strings.IndexByte(line, ']'); end != -1 {
+			if l, ok := logLevels[strings.ToUpper(line[1:end])]; ok {
+				level = l
+				msg = strings.TrimSpace(line[end+1:])
+			}
+		}
+	}
+
+	entry := map[string]interface{}{
+		"@level":     level,
+		"@message":   msg,
+		"@timestamp": now.Format("2006-01-02T15:04:05.000000Z07:00"),
+	}
+	if call != nil {
+		entry["tf_rpc"] = call.RPC
+		if call.Resource != "" {
+			entry["tf_resource"] = call.Resource
+		}
+	}
+
+	// The values are all strings, so this can't fail.
+	src, _ := json.Marshal(entry)
+	return append(src, '\n')
+}
+
+// copyLog copies the lines logged to r to w, converting them to
+// structured log entries. Lines that are already structured, which are
+// those logged by the plugin framework itself, are copied unchanged.
+func copyLog(w io.Writer, r io.Reader) {
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadString('\n')
+		if line = strings.TrimRight(line, "\r\n"); line != "" {
+			if strings.HasPrefix(line, "{") {
+				io.WriteString(w, line+"\n")
+			} else {
+				var call *callInfo
+				if c, ok := activeCalls.current(); ok {
+					call = &c
+				}
+				w.Write(logEntry(line, time.Now(), call))
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// setupLogging arranges for everything logged with the standard logger in
+// a plugin process to be sent to Terraform as structured log entries, so
+// that Terraform can filter them by level and annotate them with the
+// plugin's name.
+//
+// This must be called before the plugin server is started, since the
+// server sets the standard logger's output to whatever os.Stderr is at the
+// time. Logging is left alone if the process wasn't launched by Terraform,
+// so that any messages remain readable when a plugin is run directly.
+func setupLogging() {
+	if os.Getenv(Handshake.MagicCookieKey) != Handshake.MagicCookieValue {
+		return
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		log.Printf("[WARN] failed to set up structured logging: %s", err)
+		return
+	}
+
+	go copyLog(os.Stderr, r)
+	os.Stderr = w
+
+	// Each entry has its own timestamp.
+	log.SetFlags(0)
+}
+
+// instanceAddr returns the address of the given instance for log entries.
+func instanceAddr(info *terraform.InstanceInfo) string {
+	if info == nil {
+		return ""
+	}
+	return info.HumanId()
+}
@@ -0,0 +1,117 @@
+package plugin
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogEntry(t *testing.T) {
+	now := time.Date(2018, 1, 2, 15, 4, 5, 0, time.UTC)
+	cases := map[string]struct {
+		Line string
+		Call *callInfo
+		Want map[string]interface{}
+	}{
+		"level": {
+			"[WARN] foo bar",
+			nil,
+			map[string]interface{}{
+				"@level":     "warn",
+				"@message":   "foo bar",
+				"@timestamp": "2018-01-02T15:04:05.000000Z",
+			},
+		},
+		"lowercase level": {
+			"[err] foo",
+			nil,
+			map[string]interface{}{
+				"@level":     "error",
+				"@message":   "foo",
+				"@timestamp": "2018-01-02T15:04:05.000000Z",
+			},
+		},
+		"no level": {
+			"[foo] bar",
+			nil,
+			map[string]interface{}{
+				"@level":     "debug",
+				"@message":   "[foo] bar",
+				"@timestamp": "2018-01-02T15:04:05.000000Z",
+			},
+		},
+		"call": {
+			"[TRACE] foo",
+			&callInfo{RPC: "Apply", Resource: "aws_instance.foo"},
+			map[string]interface{}{
+				"@level":      "trace",
+				"@message":    "foo",
+				"@timestamp":  "2018-01-02T15:04:05.000000Z",
+				"tf_rpc":      "Apply",
+				"tf_resource": "aws_instance.foo",
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			var got map[string]interface{}
+			if err := json.Unmarshal(logEntry(tc.Line, now, tc.Call), &got); err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			if !reflect.DeepEqual(got, tc.Want) {
+				t.Fatalf("wrong result\ngot:  %#v\nwant: %#v", got, tc.Want)
+			}
+		})
+	}
+}
+
+func TestCopyLog(t *testing.T) {
+	defer activeCalls.begin("Refresh", "aws_instance.foo")()
+
+	var buf bytes.Buffer
+	copyLog(&buf, strings.NewReader("{\"@message\":\"framework\"}\n[INFO] provider\n"))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("bad: %q", lines)
+	}
+	if lines[0] != `{"@message":"framework"}` {
+		t.Fatalf("structured line should be unchanged: %q", lines[0])
+	}
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[1]), &entry); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if entry["tf_rpc"] != "Refresh" || entry["tf_resource"] != "aws_instance.foo" {
+		t.Fatalf("bad: %#v", entry)
+	}
+}
+
+func TestCallTracker(t *testing.T) {
+	tracker := &callTracker{calls: make(map[int]callInfo)}
+	if _, ok := tracker.current(); ok {
+		t.Fatal("should have no current call")
+	}
+
+	done1 := tracker.begin("Diff", "a")
+	if c, ok := tracker.current(); !ok || c.Resource != "a" {
+		t.Fatalf("bad: %#v", c)
+	}
+
+	// Entries can't be attributed while calls are concurrent
+	done2 := tracker.begin("Diff", "b")
+	if _, ok := tracker.current(); ok {
+		t.Fatal("should have no current call")
+	}
+
+	done1()
+	if c, ok := tracker.current(); !ok || c.Resource != "b" {
+		t.Fatalf("bad: %#v", c)
+	}
+	done2()
+}
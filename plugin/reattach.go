@@ -0,0 +1,210 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	hclog "github.com/hashicorp/go-hclog"
+	plugin "github.com/hashicorp/go-plugin"
+)
+
+// ReattachEnvVar is the environment variable that describes provider
+// processes that were launched outside of Terraform, e.g. under a debugger,
+// and that Terraform should connect to instead of launching the provider
+// plugins itself.
+//
+// Its value is a JSON object whose keys are provider names and whose values
+// are ReattachConfig objects.
+const ReattachEnvVar = "TF_REATTACH_PROVIDERS"
+
+// ReattachConfig is the JSON representation of the information needed to
+// connect to a running provider process.
+type ReattachConfig struct {
+	Protocol string
+	Pid      int
+	Addr     ReattachConfigAddr
+}
+
+// ReattachConfigAddr is the JSON representation of a network address.
+type ReattachConfigAddr struct {
+	Network string
+	String  string
+}
+
+// ParseReattachProviders parses the value of ReattachEnvVar, returning the
+// configuration for connecting to each of the providers it describes.
+func ParseReattachProviders(s string) (map[string]*plugin.ReattachConfig, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+
+	var raw map[string]ReattachConfig
+	if err := json.Unmarshal([]byte(s), &raw); err != nil {
+		return nil, fmt.Errorf("invalid value for %s: %s", ReattachEnvVar, err)
+	}
+
+	result := make(map[string]*plugin.ReattachConfig, len(raw))
+	for name, c := range raw {
+		protocol := plugin.Protocol(c.Protocol)
+		if protocol == "" {
+			protocol = plugin.ProtocolNetRPC
+		}
+		if protocol != plugin.ProtocolNetRPC {
+			return nil, fmt.Errorf(
+				"invalid value for %s: provider %q uses unsupported protocol %q",
+				ReattachEnvVar, name, c.Protocol)
+		}
+
+		var addr net.Addr
+		var err error
+		switch c.Addr.Network {
+		case "unix":
+			addr, err = net.ResolveUnixAddr("unix", c.Addr.String)
+		case "tcp":
+			addr, err = net.ResolveTCPAddr("tcp", c.Addr.String)
+		default:
+			err = fmt.Errorf("unsupported network %q", c.Addr.Network)
+		}
+		if err != nil {
+			return nil, fmt.Errorf(
+				"invalid value for %s: invalid address for provider %q: %s",
+				ReattachEnvVar, name, err)
+		}
+
+		result[name] = &plugin.ReattachConfig{
+			Protocol: protocol,
+			Pid:      c.Pid,
+			Addr:     addr,
+		}
+	}
+
+	return result, nil
+}
+
+// ReattachClient returns a plugin client for the already-running provider
+// process described by the given configuration.
+//
+// The client is not managed, so the process is left running when Terraform
+// exits.
+func ReattachClient(c *plugin.ReattachConfig) *plugin.Client {
+	logger := hclog.New(&hclog.LoggerOptions{
+		Name:   "plugin",
+		Level:  hclog.Trace,
+		Output: os.Stderr,
+	})
+
+	return plugin.NewClient(&plugin.ClientConfig{
+		Reattach:        c,
+		HandshakeConfig: Handshake,
+		Plugins:         PluginMap,
+		Logger:          logger,
+	})
+}
+
+// Debug serves a provider plugin in the foreground, for use when running
+// the plugin under a debugger, until the given context is cancelled.
+//
+// Rather than waiting to be launched by Terraform, the plugin prints the
+// value of ReattachEnvVar that Terraform must be run with in order to use
+// it. Unlike Serve, Debug doesn't exit when Terraform disconnects, so the
+// same process can be used for any number of Terraform commands.
+func Debug(ctx context.Context, name string, opts *ServeOpts) error {
+	config, err := DebugServe(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	reattach, err := json.Marshal(map[string]ReattachConfig{name: config})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf(
+		"Provider started. To attach Terraform, set the %s environment\n"+
+			"variable to the following when running Terraform commands:\n\n"+
+			"\t%s='%s'\n\n",
+		ReattachEnvVar, ReattachEnvVar, reattach)
+
+	<-ctx.Done()
+	return nil
+}
+
+// DebugServe starts serving a provider plugin in the background until the
+// given context is cancelled, returning the configuration Terraform needs
+// in order to connect to it.
+func DebugServe(ctx context.Context, opts *ServeOpts) (ReattachConfig, error) {
+	listener, err := debugListener()
+	if err != nil {
+		return ReattachConfig{}, err
+	}
+
+	// The std streams are not forwarded to Terraform, so that the plugin's
+	// output remains visible in the debugger. The server copies them for
+	// each connection, so they must be safe to read concurrently.
+	server := &plugin.RPCServer{
+		Plugins: pluginMap(opts),
+		Stdout:  eofReader{},
+		Stderr:  eofReader{},
+	}
+	go server.Serve(listener)
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+
+	return ReattachConfig{
+		Protocol: string(plugin.ProtocolNetRPC),
+		Pid:      os.Getpid(),
+		Addr: ReattachConfigAddr{
+			Network: listener.Addr().Network(),
+			String:  listener.Addr().String(),
+		},
+	}, nil
+}
+
+func debugListener() (net.Listener, error) {
+	if runtime.GOOS == "windows" {
+		return net.Listen("tcp", "127.0.0.1:0")
+	}
+
+	dir, err := ioutil.TempDir("", "plugin")
+	if err != nil {
+		return nil, err
+	}
+
+	listener, err := net.Listen("unix", filepath.Join(dir, "plugin.sock"))
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+
+	// Closing a unix listener removes the socket, but not its directory.
+	return &debugUnixListener{Listener: listener, dir: dir}, nil
+}
+
+type debugUnixListener struct {
+	net.Listener
+	dir string
+}
+
+func (l *debugUnixListener) Close() error {
+	err := l.Listener.Close()
+	os.RemoveAll(l.dir)
+	return err
+}
+
+// eofReader is an io.Reader that has nothing to read, and so can be shared
+// by any number of readers.
+type eofReader struct{}
+
+func (eofReader) Read([]byte) (int, error) {
+	return 0, io.EOF
+}
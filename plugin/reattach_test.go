@@ -0,0 +1,112 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestParseReattachProviders(t *testing.T) {
+	cases := map[string]struct {
+		Input string
+		Names []string
+		Err   bool
+	}{
+		"empty": {
+			"",
+			nil,
+			false,
+		},
+		"unix": {
+			`{"test":{"Protocol":"netrpc","Pid":1,"Addr":{"Network":"unix","String":"/tmp/plugin.sock"}}}`,
+			[]string{"test"},
+			false,
+		},
+		"tcp without protocol": {
+			`{"test":{"Pid":1,"Addr":{"Network":"tcp","String":"127.0.0.1:1234"}}}`,
+			[]string{"test"},
+			false,
+		},
+		"grpc": {
+			`{"test":{"Protocol":"grpc","Pid":1,"Addr":{"Network":"unix","String":"/tmp/plugin.sock"}}}`,
+			nil,
+			true,
+		},
+		"bad network": {
+			`{"test":{"Pid":1,"Addr":{"Network":"udp","String":"127.0.0.1:1234"}}}`,
+			nil,
+			true,
+		},
+		"invalid json": {
+			`{`,
+			nil,
+			true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			result, err := ParseReattachProviders(tc.Input)
+			if (err != nil) != tc.Err {
+				t.Fatalf("err: %s", err)
+			}
+			if len(result) != len(tc.Names) {
+				t.Fatalf("bad: %#v", result)
+			}
+			for _, n := range tc.Names {
+				if result[n] == nil || result[n].Addr == nil {
+					t.Fatalf("bad %q: %#v", n, result[n])
+				}
+			}
+		})
+	}
+}
+
+func TestDebugServe(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	p := new(terraform.MockResourceProvider)
+	config, err := DebugServe(ctx, &ServeOpts{
+		ProviderFunc: testProviderFixed(p),
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	raw, err := json.Marshal(map[string]ReattachConfig{"test": config})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	reattach, err := ParseReattachProviders(string(raw))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// Connect twice, as two Terraform commands would, to check that the
+	// first disconnecting doesn't stop the server.
+	for i := 0; i < 2; i++ {
+		client := ReattachClient(reattach["test"])
+		rpcClient, err := client.Client()
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		raw, err := rpcClient.Dispense(ProviderPluginName)
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		provider := raw.(terraform.ResourceProvider)
+
+		p.ConfigureCalled = false
+		if err := provider.Configure(&terraform.ResourceConfig{}); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		if !p.ConfigureCalled {
+			t.Fatal("configure should be called")
+		}
+
+		rpcClient.Close()
+	}
+}
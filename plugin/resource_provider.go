@@ -1,10 +1,17 @@
 package plugin
 
 import (
+	"fmt"
+	"io"
+	"log"
 	"net/rpc"
+	"strings"
+	"sync"
 
 	"github.com/hashicorp/go-plugin"
+	"github.com/hashicorp/terraform/helper/tracing"
 	"github.com/hashicorp/terraform/terraform"
+	"github.com/hashicorp/yamux"
 )
 
 // ResourceProviderPlugin is the plugin.Plugin implementation.
@@ -26,11 +33,196 @@ func (p *ResourceProviderPlugin) Client(
 type ResourceProvider struct {
 	Broker *plugin.MuxBroker
 	Client *rpc.Client
+
+	// SchemaCache, if non-nil, is consulted by GetSchema before calling
+	// the plugin, and updated with the plugin's response.
+	SchemaCache *ProviderSchemaCache
+
+	// Reconnect, if non-nil, is called when the connection to the plugin
+	// is lost, usually because the plugin process crashed. It must start
+	// a new plugin process and return a provider connected to it, after
+	// which the configuration given to Configure is replayed and the
+	// failed call is retried where that is safe.
+	Reconnect func() (*ResourceProvider, error)
+
+	lock   sync.Mutex
+	config *terraform.ResourceConfig
+	metas  []*terraform.ProviderModuleMeta
+}
+
+// retryableMethods are the plugin methods that are safe to call again if
+// the plugin crashes while handling them, because they don't change any
+// remote objects.
+var retryableMethods = map[string]bool{
+	"Plugin.GetSchema":             true,
+	"Plugin.Validate":              true,
+	"Plugin.ValidateResource":      true,
+	"Plugin.ValidateDataSource":    true,
+	"Plugin.Configure":             true,
+	"Plugin.ConfigureProviderMeta": true,
+	"Plugin.Diff":                  true,
+	"Plugin.Refresh":               true,
+	"Plugin.ImportState":           true,
+	"Plugin.Resources":             true,
+	"Plugin.DataSources":           true,
+	"Plugin.ReadDataDiff":          true,
+	"Plugin.ReadDataApply":         true,
+	"Plugin.Functions":             true,
+	"Plugin.CallFunction":          true,
+	"Plugin.MoveResourceState":     true,
+	"Plugin.UpgradeResourceState":  true,
+}
+
+// call calls the given method of the plugin, restarting the plugin and
+// retrying the call if the connection to it has been lost.
+//
+// A call that failed because the connection was already closed was never
+// sent, so it is retried whatever the method. A call that was in flight
+// when the connection was lost may already have been partially handled by
+// the plugin, so it is retried only if the method is in retryableMethods.
+func (p *ResourceProvider) call(method string, args interface{}, reply interface{}) (err error) {
+	var span *tracing.Span
+	if tracing.Enabled() {
+		span = tracing.StartKind(nil, tracing.SpanKindClient, method, callAttributes(method, args)...)
+	}
+	defer func() {
+		span.SetError(err)
+		span.End()
+	}()
+
+	p.lock.Lock()
+	client, canRestart := p.Client, p.Reconnect != nil
+	p.lock.Unlock()
+
+	err = client.Call(method, args, reply)
+	if !canRestart || !isConnectionError(err) {
+		return err
+	}
+
+	if err != rpc.ErrShutdown && !retryableMethods[method] {
+		return fmt.Errorf(
+			"the provider plugin exited unexpectedly while handling %s, so its "+
+				"outcome is unknown. The plugin will be restarted for subsequent "+
+				"operations. The plugin's log output may contain more details.",
+			strings.TrimPrefix(method, "Plugin."))
+	}
+
+	log.Printf("[WARN] lost connection to provider plugin during %s (%s); restarting it", method, err)
+	client, err = p.restart(client)
+	if err != nil {
+		return fmt.Errorf("the provider plugin exited unexpectedly and could not be restarted: %s", err)
+	}
+
+	span.SetAttributes(tracing.Bool("terraform.provider.restarted", true))
+	return client.Call(method, args, reply)
+}
+
+// callAttributes returns the attributes of the span of a call to the
+// plugin: the method, and the resource the call is for, if there is one.
+func callAttributes(method string, args interface{}) []tracing.Attribute {
+	attrs := []tracing.Attribute{
+		tracing.String("rpc.system", "netrpc"),
+		tracing.String("rpc.method", strings.TrimPrefix(method, "Plugin.")),
+	}
+
+	var info *terraform.InstanceInfo
+	switch args := args.(type) {
+	case *ResourceProviderApplyArgs:
+		info = args.Info
+	case *ResourceProviderDiffArgs:
+		info = args.Info
+	case *ResourceProviderRefreshArgs:
+		info = args.Info
+	case *ResourceProviderImportStateArgs:
+		info = args.Info
+	case *ResourceProviderReadDataApplyArgs:
+		info = args.Info
+	case *ResourceProviderReadDataDiffArgs:
+		info = args.Info
+	case *ResourceProviderLockResourceArgs:
+		info = args.Info
+	case *ResourceProviderUnlockResourceArgs:
+		info = args.Info
+	case *ResourceProviderValidateResourceArgs:
+		attrs = append(attrs, tracing.String("terraform.resource.type", args.Type))
+	case *ResourceProviderCallFunctionArgs:
+		attrs = append(attrs, tracing.String("terraform.function", args.Name))
+	}
+	if info != nil {
+		attrs = append(attrs,
+			tracing.String("terraform.resource.address", info.HumanId()),
+			tracing.String("terraform.resource.type", info.Type))
+	}
+
+	return attrs
+}
+
+// restart replaces the given broken connection to the plugin with a new
+// one, replaying the provider configuration. If the connection has already
+// been replaced by a concurrent call, the replacement is returned instead.
+func (p *ResourceProvider) restart(broken *rpc.Client) (*rpc.Client, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.Client != broken {
+		return p.Client, nil
+	}
+
+	np, err := p.Reconnect()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.config != nil {
+		var resp ResourceProviderConfigureResponse
+		if err := np.Client.Call("Plugin.Configure", p.config, &resp); err != nil {
+			np.Client.Close()
+			return nil, err
+		}
+		if resp.Error != nil {
+			np.Client.Close()
+			return nil, resp.Error
+		}
+	}
+	if p.metas != nil {
+		var resp ResourceProviderConfigureProviderMetaResponse
+		args := &ResourceProviderConfigureProviderMetaArgs{Metas: p.metas}
+		if err := np.Client.Call("Plugin.ConfigureProviderMeta", args, &resp); err != nil {
+			np.Client.Close()
+			return nil, err
+		}
+		if resp.Error != nil {
+			np.Client.Close()
+			return nil, resp.Error
+		}
+	}
+
+	broken.Close()
+	p.Broker, p.Client, p.Reconnect = np.Broker, np.Client, np.Reconnect
+	return p.Client, nil
+}
+
+func (p *ResourceProvider) rpcClient() *rpc.Client {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.Client
+}
+
+// isConnectionError returns true if the given error from an RPC call means
+// that the connection to the plugin has been lost.
+func isConnectionError(err error) bool {
+	switch err {
+	case rpc.ErrShutdown, io.EOF, io.ErrUnexpectedEOF,
+		yamux.ErrSessionShutdown, yamux.ErrConnectionReset:
+		return true
+	default:
+		return false
+	}
 }
 
 func (p *ResourceProvider) Stop() error {
 	var resp ResourceProviderStopResponse
-	err := p.Client.Call("Plugin.Stop", new(interface{}), &resp)
+	err := p.call("Plugin.Stop", new(interface{}), &resp)
 	if err != nil {
 		return err
 	}
@@ -42,28 +234,46 @@ func (p *ResourceProvider) Stop() error {
 }
 
 func (p *ResourceProvider) GetSchema(req *terraform.ProviderSchemaRequest) (*terraform.ProviderSchema, error) {
+	if p.SchemaCache != nil {
+		if schema := p.SchemaCache.Get(req); schema != nil {
+			return schema, nil
+		}
+	}
+
 	var result ResourceProviderGetSchemaResponse
 	args := &ResourceProviderGetSchemaArgs{
 		Req: req,
 	}
 
-	err := p.Client.Call("Plugin.GetSchema", args, &result)
+	err := p.call("Plugin.GetSchema", args, &result)
 	if err != nil {
 		return nil, err
 	}
 
 	if result.Error != nil {
-		err = result.Error
+		return result.Schema, result.Error
+	}
+
+	if p.SchemaCache != nil && result.Schema != nil {
+		if err := p.SchemaCache.Put(req, result.Schema); err != nil {
+			log.Printf("[WARN] failed to cache schema: %s", err)
+		}
 	}
 
-	return result.Schema, err
+	return result.Schema, nil
 }
 
 func (p *ResourceProvider) Input(
 	input terraform.UIInput,
 	c *terraform.ResourceConfig) (*terraform.ResourceConfig, error) {
-	id := p.Broker.NextId()
-	go p.Broker.AcceptAndServe(id, &UIInputServer{
+	// The input server is registered with the broker of the current
+	// connection, so this call can't be retried on a new one.
+	p.lock.Lock()
+	broker, client := p.Broker, p.Client
+	p.lock.Unlock()
+
+	id := broker.NextId()
+	go broker.AcceptAndServe(id, &UIInputServer{
 		UIInput: input,
 	})
 
@@ -73,7 +283,7 @@ func (p *ResourceProvider) Input(
 		Config:  c,
 	}
 
-	err := p.Client.Call("Plugin.Input", &args, &resp)
+	err := client.Call("Plugin.Input", &args, &resp)
 	if err != nil {
 		return nil, err
 	}
@@ -91,7 +301,7 @@ func (p *ResourceProvider) Validate(c *terraform.ResourceConfig) ([]string, []er
 		Config: c,
 	}
 
-	err := p.Client.Call("Plugin.Validate", &args, &resp)
+	err := p.call("Plugin.Validate", &args, &resp)
 	if err != nil {
 		return nil, []error{err}
 	}
@@ -115,7 +325,7 @@ func (p *ResourceProvider) ValidateResource(
 		Type:   t,
 	}
 
-	err := p.Client.Call("Plugin.ValidateResource", &args, &resp)
+	err := p.call("Plugin.ValidateResource", &args, &resp)
 	if err != nil {
 		return nil, []error{err}
 	}
@@ -133,15 +343,49 @@ func (p *ResourceProvider) ValidateResource(
 
 func (p *ResourceProvider) Configure(c *terraform.ResourceConfig) error {
 	var resp ResourceProviderConfigureResponse
-	err := p.Client.Call("Plugin.Configure", c, &resp)
+	err := p.call("Plugin.Configure", c, &resp)
 	if err != nil {
 		return err
 	}
 	if resp.Error != nil {
-		err = resp.Error
+		return resp.Error
 	}
 
-	return err
+	// Remember the configuration so that it can be replayed if the plugin
+	// needs to be restarted.
+	p.lock.Lock()
+	p.config = c
+	p.lock.Unlock()
+
+	return nil
+}
+
+func (p *ResourceProvider) ConfigureProviderMeta(metas []*terraform.ProviderModuleMeta) error {
+	var resp ResourceProviderConfigureProviderMetaResponse
+	args := &ResourceProviderConfigureProviderMetaArgs{
+		Metas: metas,
+	}
+
+	err := p.call("Plugin.ConfigureProviderMeta", args, &resp)
+	if err != nil {
+		// Plugins built against older versions of Terraform don't have
+		// this method at all.
+		if strings.Contains(err.Error(), "can't find method") {
+			return fmt.Errorf("provider does not support provider_meta")
+		}
+		return err
+	}
+	if resp.Error != nil {
+		return resp.Error
+	}
+
+	// Remember the metadata so that it can be replayed along with the
+	// configuration if the plugin needs to be restarted.
+	p.lock.Lock()
+	p.metas = metas
+	p.lock.Unlock()
+
+	return nil
 }
 
 func (p *ResourceProvider) Apply(
@@ -155,7 +399,7 @@ func (p *ResourceProvider) Apply(
 		Diff:  d,
 	}
 
-	err := p.Client.Call("Plugin.Apply", args, &resp)
+	err := p.call("Plugin.Apply", args, &resp)
 	if err != nil {
 		return nil, err
 	}
@@ -176,7 +420,7 @@ func (p *ResourceProvider) Diff(
 		State:  s,
 		Config: c,
 	}
-	err := p.Client.Call("Plugin.Diff", args, &resp)
+	err := p.call("Plugin.Diff", args, &resp)
 	if err != nil {
 		return nil, err
 	}
@@ -195,7 +439,7 @@ func (p *ResourceProvider) ValidateDataSource(
 		Type:   t,
 	}
 
-	err := p.Client.Call("Plugin.ValidateDataSource", &args, &resp)
+	err := p.call("Plugin.ValidateDataSource", &args, &resp)
 	if err != nil {
 		return nil, []error{err}
 	}
@@ -220,7 +464,7 @@ func (p *ResourceProvider) Refresh(
 		State: s,
 	}
 
-	err := p.Client.Call("Plugin.Refresh", args, &resp)
+	err := p.call("Plugin.Refresh", args, &resp)
 	if err != nil {
 		return nil, err
 	}
@@ -240,7 +484,7 @@ func (p *ResourceProvider) ImportState(
 		Id:   id,
 	}
 
-	err := p.Client.Call("Plugin.ImportState", args, &resp)
+	err := p.call("Plugin.ImportState", args, &resp)
 	if err != nil {
 		return nil, err
 	}
@@ -254,7 +498,7 @@ func (p *ResourceProvider) ImportState(
 func (p *ResourceProvider) Resources() []terraform.ResourceType {
 	var result []terraform.ResourceType
 
-	err := p.Client.Call("Plugin.Resources", new(interface{}), &result)
+	err := p.call("Plugin.Resources", new(interface{}), &result)
 	if err != nil {
 		// TODO: panic, log, what?
 		return nil
@@ -272,7 +516,7 @@ func (p *ResourceProvider) ReadDataDiff(
 		Config: c,
 	}
 
-	err := p.Client.Call("Plugin.ReadDataDiff", args, &resp)
+	err := p.call("Plugin.ReadDataDiff", args, &resp)
 	if err != nil {
 		return nil, err
 	}
@@ -292,7 +536,7 @@ func (p *ResourceProvider) ReadDataApply(
 		Diff: d,
 	}
 
-	err := p.Client.Call("Plugin.ReadDataApply", args, &resp)
+	err := p.call("Plugin.ReadDataApply", args, &resp)
 	if err != nil {
 		return nil, err
 	}
@@ -306,7 +550,7 @@ func (p *ResourceProvider) ReadDataApply(
 func (p *ResourceProvider) DataSources() []terraform.DataSource {
 	var result []terraform.DataSource
 
-	err := p.Client.Call("Plugin.DataSources", new(interface{}), &result)
+	err := p.call("Plugin.DataSources", new(interface{}), &result)
 	if err != nil {
 		// TODO: panic, log, what?
 		return nil
@@ -315,8 +559,133 @@ func (p *ResourceProvider) DataSources() []terraform.DataSource {
 	return result
 }
 
+func (p *ResourceProvider) Functions() ([]terraform.ProviderFunction, error) {
+	var resp ResourceProviderFunctionsResponse
+	err := p.call("Plugin.Functions", new(interface{}), &resp)
+	if err != nil {
+		// Plugins built against older versions of Terraform don't have
+		// this method at all, which just means they export no functions.
+		if strings.Contains(err.Error(), "can't find method") {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if resp.Error != nil {
+		err = resp.Error
+	}
+
+	return resp.Functions, err
+}
+
+func (p *ResourceProvider) CallFunction(name string, args []interface{}) (interface{}, error) {
+	var resp ResourceProviderCallFunctionResponse
+	callArgs := &ResourceProviderCallFunctionArgs{
+		Name: name,
+		Args: args,
+	}
+
+	err := p.call("Plugin.CallFunction", callArgs, &resp)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Error != nil {
+		err = resp.Error
+	}
+
+	return resp.Result, err
+}
+
+func (p *ResourceProvider) LockResource(
+	info *terraform.InstanceInfo,
+	s *terraform.InstanceState,
+	d *terraform.InstanceDiff) (string, error) {
+	var resp ResourceProviderLockResourceResponse
+	args := &ResourceProviderLockResourceArgs{
+		Info:  info,
+		State: s,
+		Diff:  d,
+	}
+
+	err := p.call("Plugin.LockResource", args, &resp)
+	if err != nil {
+		// Plugins built against older versions of Terraform don't have
+		// this method at all.
+		if strings.Contains(err.Error(), "can't find method") {
+			return "", fmt.Errorf("provider does not support resource locking")
+		}
+		return "", err
+	}
+	if resp.Error != nil {
+		err = resp.Error
+	}
+
+	return resp.LockID, err
+}
+
+func (p *ResourceProvider) UnlockResource(info *terraform.InstanceInfo, id string) error {
+	var resp ResourceProviderUnlockResourceResponse
+	args := &ResourceProviderUnlockResourceArgs{
+		Info:   info,
+		LockID: id,
+	}
+
+	err := p.call("Plugin.UnlockResource", args, &resp)
+	if err != nil {
+		return err
+	}
+	if resp.Error != nil {
+		err = resp.Error
+	}
+
+	return err
+}
+
+func (p *ResourceProvider) MoveResourceState(req *terraform.MoveResourceStateRequest) (*terraform.InstanceState, error) {
+	var resp ResourceProviderMoveResourceStateResponse
+	args := &ResourceProviderMoveResourceStateArgs{
+		Req: req,
+	}
+
+	err := p.call("Plugin.MoveResourceState", args, &resp)
+	if err != nil {
+		// Plugins built against older versions of Terraform don't have
+		// this method at all.
+		if strings.Contains(err.Error(), "can't find method") {
+			return nil, fmt.Errorf("provider does not support moving resource state")
+		}
+		return nil, err
+	}
+	if resp.Error != nil {
+		err = resp.Error
+	}
+
+	return resp.State, err
+}
+
+func (p *ResourceProvider) UpgradeResourceState(req *terraform.UpgradeResourceStateRequest) (*terraform.InstanceState, error) {
+	var resp ResourceProviderUpgradeResourceStateResponse
+	args := &ResourceProviderUpgradeResourceStateArgs{
+		Req: req,
+	}
+
+	err := p.call("Plugin.UpgradeResourceState", args, &resp)
+	if err != nil {
+		// Plugins built against older versions of Terraform don't have
+		// this method at all.
+		if strings.Contains(err.Error(), "can't find method") {
+			return nil, fmt.Errorf("provider does not support upgrading resource state")
+		}
+		return nil, err
+	}
+	if resp.Error != nil {
+		err = resp.Error
+	}
+
+	return resp.State, err
+}
+
 func (p *ResourceProvider) Close() error {
-	return p.Client.Close()
+	return p.rpcClient().Close()
 }
 
 // ResourceProviderServer is a net/rpc compatible structure for serving
@@ -415,6 +784,67 @@ type ResourceProviderReadDataDiffResponse struct {
 	Error *plugin.BasicError
 }
 
+type ResourceProviderFunctionsResponse struct {
+	Functions []terraform.ProviderFunction
+	Error     *plugin.BasicError
+}
+
+type ResourceProviderCallFunctionArgs struct {
+	Name string
+	Args []interface{}
+}
+
+type ResourceProviderCallFunctionResponse struct {
+	Result interface{}
+	Error  *plugin.BasicError
+}
+
+type ResourceProviderLockResourceArgs struct {
+	Info  *terraform.InstanceInfo
+	State *terraform.InstanceState
+	Diff  *terraform.InstanceDiff
+}
+
+type ResourceProviderLockResourceResponse struct {
+	LockID string
+	Error  *plugin.BasicError
+}
+
+type ResourceProviderUnlockResourceArgs struct {
+	Info   *terraform.InstanceInfo
+	LockID string
+}
+
+type ResourceProviderUnlockResourceResponse struct {
+	Error *plugin.BasicError
+}
+
+type ResourceProviderMoveResourceStateArgs struct {
+	Req *terraform.MoveResourceStateRequest
+}
+
+type ResourceProviderMoveResourceStateResponse struct {
+	State *terraform.InstanceState
+	Error *plugin.BasicError
+}
+
+type ResourceProviderConfigureProviderMetaArgs struct {
+	Metas []*terraform.ProviderModuleMeta
+}
+
+type ResourceProviderConfigureProviderMetaResponse struct {
+	Error *plugin.BasicError
+}
+
+type ResourceProviderUpgradeResourceStateArgs struct {
+	Req *terraform.UpgradeResourceStateRequest
+}
+
+type ResourceProviderUpgradeResourceStateResponse struct {
+	State *terraform.InstanceState
+	Error *plugin.BasicError
+}
+
 type ResourceProviderValidateArgs struct {
 	Config *terraform.ResourceConfig
 }
@@ -449,6 +879,8 @@ func (s *ResourceProviderServer) GetSchema(
 	args *ResourceProviderGetSchemaArgs,
 	result *ResourceProviderGetSchemaResponse,
 ) error {
+	defer activeCalls.begin("GetSchema", "")()
+
 	schema, err := s.Provider.GetSchema(args.Req)
 	result.Schema = schema
 	if err != nil {
@@ -460,6 +892,8 @@ func (s *ResourceProviderServer) GetSchema(
 func (s *ResourceProviderServer) Input(
 	args *ResourceProviderInputArgs,
 	reply *ResourceProviderInputResponse) error {
+	defer activeCalls.begin("Input", "")()
+
 	conn, err := s.Broker.Dial(args.InputId)
 	if err != nil {
 		*reply = ResourceProviderInputResponse{
@@ -484,6 +918,8 @@ func (s *ResourceProviderServer) Input(
 func (s *ResourceProviderServer) Validate(
 	args *ResourceProviderValidateArgs,
 	reply *ResourceProviderValidateResponse) error {
+	defer activeCalls.begin("Validate", "")()
+
 	warns, errs := s.Provider.Validate(args.Config)
 	berrs := make([]*plugin.BasicError, len(errs))
 	for i, err := range errs {
@@ -499,6 +935,8 @@ func (s *ResourceProviderServer) Validate(
 func (s *ResourceProviderServer) ValidateResource(
 	args *ResourceProviderValidateResourceArgs,
 	reply *ResourceProviderValidateResourceResponse) error {
+	defer activeCalls.begin("ValidateResource", args.Type)()
+
 	warns, errs := s.Provider.ValidateResource(args.Type, args.Config)
 	berrs := make([]*plugin.BasicError, len(errs))
 	for i, err := range errs {
@@ -514,6 +952,8 @@ func (s *ResourceProviderServer) ValidateResource(
 func (s *ResourceProviderServer) Configure(
 	config *terraform.ResourceConfig,
 	reply *ResourceProviderConfigureResponse) error {
+	defer activeCalls.begin("Configure", "")()
+
 	err := s.Provider.Configure(config)
 	*reply = ResourceProviderConfigureResponse{
 		Error: plugin.NewBasicError(err),
@@ -524,6 +964,8 @@ func (s *ResourceProviderServer) Configure(
 func (s *ResourceProviderServer) Apply(
 	args *ResourceProviderApplyArgs,
 	result *ResourceProviderApplyResponse) error {
+	defer activeCalls.begin("Apply", instanceAddr(args.Info))()
+
 	state, err := s.Provider.Apply(args.Info, args.State, args.Diff)
 	*result = ResourceProviderApplyResponse{
 		State: state,
@@ -535,6 +977,8 @@ func (s *ResourceProviderServer) Apply(
 func (s *ResourceProviderServer) Diff(
 	args *ResourceProviderDiffArgs,
 	result *ResourceProviderDiffResponse) error {
+	defer activeCalls.begin("Diff", instanceAddr(args.Info))()
+
 	diff, err := s.Provider.Diff(args.Info, args.State, args.Config)
 	*result = ResourceProviderDiffResponse{
 		Diff:  diff,
@@ -546,6 +990,8 @@ func (s *ResourceProviderServer) Diff(
 func (s *ResourceProviderServer) Refresh(
 	args *ResourceProviderRefreshArgs,
 	result *ResourceProviderRefreshResponse) error {
+	defer activeCalls.begin("Refresh", instanceAddr(args.Info))()
+
 	newState, err := s.Provider.Refresh(args.Info, args.State)
 	*result = ResourceProviderRefreshResponse{
 		State: newState,
@@ -557,6 +1003,8 @@ func (s *ResourceProviderServer) Refresh(
 func (s *ResourceProviderServer) ImportState(
 	args *ResourceProviderImportStateArgs,
 	result *ResourceProviderImportStateResponse) error {
+	defer activeCalls.begin("ImportState", instanceAddr(args.Info))()
+
 	states, err := s.Provider.ImportState(args.Info, args.Id)
 	*result = ResourceProviderImportStateResponse{
 		State: states,
@@ -568,6 +1016,8 @@ func (s *ResourceProviderServer) ImportState(
 func (s *ResourceProviderServer) Resources(
 	nothing interface{},
 	result *[]terraform.ResourceType) error {
+	defer activeCalls.begin("Resources", "")()
+
 	*result = s.Provider.Resources()
 	return nil
 }
@@ -575,6 +1025,8 @@ func (s *ResourceProviderServer) Resources(
 func (s *ResourceProviderServer) ValidateDataSource(
 	args *ResourceProviderValidateResourceArgs,
 	reply *ResourceProviderValidateResourceResponse) error {
+	defer activeCalls.begin("ValidateDataSource", args.Type)()
+
 	warns, errs := s.Provider.ValidateDataSource(args.Type, args.Config)
 	berrs := make([]*plugin.BasicError, len(errs))
 	for i, err := range errs {
@@ -590,6 +1042,8 @@ func (s *ResourceProviderServer) ValidateDataSource(
 func (s *ResourceProviderServer) ReadDataDiff(
 	args *ResourceProviderReadDataDiffArgs,
 	result *ResourceProviderReadDataDiffResponse) error {
+	defer activeCalls.begin("ReadDataDiff", instanceAddr(args.Info))()
+
 	diff, err := s.Provider.ReadDataDiff(args.Info, args.Config)
 	*result = ResourceProviderReadDataDiffResponse{
 		Diff:  diff,
@@ -601,6 +1055,8 @@ func (s *ResourceProviderServer) ReadDataDiff(
 func (s *ResourceProviderServer) ReadDataApply(
 	args *ResourceProviderReadDataApplyArgs,
 	result *ResourceProviderReadDataApplyResponse) error {
+	defer activeCalls.begin("ReadDataApply", instanceAddr(args.Info))()
+
 	newState, err := s.Provider.ReadDataApply(args.Info, args.Diff)
 	*result = ResourceProviderReadDataApplyResponse{
 		State: newState,
@@ -612,6 +1068,151 @@ func (s *ResourceProviderServer) ReadDataApply(
 func (s *ResourceProviderServer) DataSources(
 	nothing interface{},
 	result *[]terraform.DataSource) error {
+	defer activeCalls.begin("DataSources", "")()
+
 	*result = s.Provider.DataSources()
 	return nil
 }
+
+func (s *ResourceProviderServer) Functions(
+	nothing interface{},
+	result *ResourceProviderFunctionsResponse) error {
+	defer activeCalls.begin("Functions", "")()
+
+	pf, ok := s.Provider.(terraform.ResourceProviderFunctions)
+	if !ok {
+		*result = ResourceProviderFunctionsResponse{}
+		return nil
+	}
+
+	fns, err := pf.Functions()
+	*result = ResourceProviderFunctionsResponse{
+		Functions: fns,
+		Error:     plugin.NewBasicError(err),
+	}
+	return nil
+}
+
+func (s *ResourceProviderServer) CallFunction(
+	args *ResourceProviderCallFunctionArgs,
+	result *ResourceProviderCallFunctionResponse) error {
+	defer activeCalls.begin("CallFunction", "")()
+
+	pf, ok := s.Provider.(terraform.ResourceProviderFunctions)
+	if !ok {
+		*result = ResourceProviderCallFunctionResponse{
+			Error: plugin.NewBasicError(fmt.Errorf("provider does not export any functions")),
+		}
+		return nil
+	}
+
+	ret, err := pf.CallFunction(args.Name, args.Args)
+	*result = ResourceProviderCallFunctionResponse{
+		Result: ret,
+		Error:  plugin.NewBasicError(err),
+	}
+	return nil
+}
+
+func (s *ResourceProviderServer) LockResource(
+	args *ResourceProviderLockResourceArgs,
+	result *ResourceProviderLockResourceResponse) error {
+	defer activeCalls.begin("LockResource", instanceAddr(args.Info))()
+
+	l, ok := s.Provider.(terraform.ResourceProviderLocker)
+	if !ok {
+		*result = ResourceProviderLockResourceResponse{
+			Error: plugin.NewBasicError(fmt.Errorf("provider does not support resource locking")),
+		}
+		return nil
+	}
+
+	id, err := l.LockResource(args.Info, args.State, args.Diff)
+	*result = ResourceProviderLockResourceResponse{
+		LockID: id,
+		Error:  plugin.NewBasicError(err),
+	}
+	return nil
+}
+
+func (s *ResourceProviderServer) UnlockResource(
+	args *ResourceProviderUnlockResourceArgs,
+	result *ResourceProviderUnlockResourceResponse) error {
+	defer activeCalls.begin("UnlockResource", instanceAddr(args.Info))()
+
+	l, ok := s.Provider.(terraform.ResourceProviderLocker)
+	if !ok {
+		*result = ResourceProviderUnlockResourceResponse{
+			Error: plugin.NewBasicError(fmt.Errorf("provider does not support resource locking")),
+		}
+		return nil
+	}
+
+	err := l.UnlockResource(args.Info, args.LockID)
+	*result = ResourceProviderUnlockResourceResponse{
+		Error: plugin.NewBasicError(err),
+	}
+	return nil
+}
+
+func (s *ResourceProviderServer) MoveResourceState(
+	args *ResourceProviderMoveResourceStateArgs,
+	result *ResourceProviderMoveResourceStateResponse) error {
+	defer activeCalls.begin("MoveResourceState", instanceAddr(args.Req.Info))()
+
+	m, ok := s.Provider.(terraform.ResourceProviderMover)
+	if !ok {
+		*result = ResourceProviderMoveResourceStateResponse{
+			Error: plugin.NewBasicError(fmt.Errorf("provider does not support moving resource state")),
+		}
+		return nil
+	}
+
+	state, err := m.MoveResourceState(args.Req)
+	*result = ResourceProviderMoveResourceStateResponse{
+		State: state,
+		Error: plugin.NewBasicError(err),
+	}
+	return nil
+}
+
+func (s *ResourceProviderServer) ConfigureProviderMeta(
+	args *ResourceProviderConfigureProviderMetaArgs,
+	reply *ResourceProviderConfigureProviderMetaResponse) error {
+	defer activeCalls.begin("ConfigureProviderMeta", "")()
+
+	m, ok := s.Provider.(terraform.ResourceProviderMetaConfigurer)
+	if !ok {
+		*reply = ResourceProviderConfigureProviderMetaResponse{
+			Error: plugin.NewBasicError(fmt.Errorf("provider does not support provider_meta")),
+		}
+		return nil
+	}
+
+	err := m.ConfigureProviderMeta(args.Metas)
+	*reply = ResourceProviderConfigureProviderMetaResponse{
+		Error: plugin.NewBasicError(err),
+	}
+	return nil
+}
+
+func (s *ResourceProviderServer) UpgradeResourceState(
+	args *ResourceProviderUpgradeResourceStateArgs,
+	result *ResourceProviderUpgradeResourceStateResponse) error {
+	defer activeCalls.begin("UpgradeResourceState", instanceAddr(args.Req.Info))()
+
+	u, ok := s.Provider.(terraform.ResourceProviderUpgrader)
+	if !ok {
+		*result = ResourceProviderUpgradeResourceStateResponse{
+			Error: plugin.NewBasicError(fmt.Errorf("provider does not support upgrading resource state")),
+		}
+		return nil
+	}
+
+	state, err := u.UpgradeResourceState(args.Req)
+	*result = ResourceProviderUpgradeResourceStateResponse{
+		State: state,
+		Error: plugin.NewBasicError(err),
+	}
+	return nil
+}
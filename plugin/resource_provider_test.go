@@ -2,16 +2,21 @@ package plugin
 
 import (
 	"errors"
+	"net/rpc"
 	"reflect"
 	"testing"
 
 	"github.com/hashicorp/go-plugin"
+	"github.com/hashicorp/hil/ast"
+	"github.com/hashicorp/terraform/helper/tracing"
 	"github.com/hashicorp/terraform/terraform"
 )
 
 func TestResourceProvider_impl(t *testing.T) {
 	var _ plugin.Plugin = new(ResourceProviderPlugin)
 	var _ terraform.ResourceProvider = new(ResourceProvider)
+	var _ terraform.ResourceProviderFunctions = new(ResourceProvider)
+	var _ terraform.ResourceProviderLocker = new(ResourceProvider)
 }
 
 func TestResourceProvider_stop(t *testing.T) {
@@ -69,6 +74,117 @@ func TestResourceProvider_stopErrors(t *testing.T) {
 	}
 }
 
+func TestResourceProvider_getSchemaCapabilities(t *testing.T) {
+	p := new(terraform.MockResourceProvider)
+	p.GetSchemaReturn = &terraform.ProviderSchema{
+		Capabilities: terraform.ProviderCapabilities{
+			PlanDestroy: true,
+		},
+	}
+
+	// Create a mock provider
+	client, _ := plugin.TestPluginRPCConn(t, pluginMap(&ServeOpts{
+		ProviderFunc: testProviderFixed(p),
+	}))
+	defer client.Close()
+
+	// Request the provider
+	raw, err := client.Dispense(ProviderPluginName)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	provider := raw.(terraform.ResourceProvider)
+
+	schema, err := provider.GetSchema(&terraform.ProviderSchemaRequest{})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !schema.Capabilities.PlanDestroy {
+		t.Fatalf("capabilities not returned: %#v", schema.Capabilities)
+	}
+}
+
+func TestResourceProvider_moveResourceState(t *testing.T) {
+	p := new(terraform.MockResourceProvider)
+	p.MoveResourceStateReturn = &terraform.InstanceState{ID: "bar"}
+
+	// Create a mock provider
+	client, _ := plugin.TestPluginRPCConn(t, pluginMap(&ServeOpts{
+		ProviderFunc: testProviderFixed(p),
+	}))
+	defer client.Close()
+
+	// Request the provider
+	raw, err := client.Dispense(ProviderPluginName)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	provider := raw.(terraform.ResourceProviderMover)
+
+	req := &terraform.MoveResourceStateRequest{
+		Info:           &terraform.InstanceInfo{Type: "test_instance"},
+		SourceProvider: "old",
+		SourceType:     "old_instance",
+		SourceState:    &terraform.InstanceState{ID: "foo"},
+	}
+	state, err := provider.MoveResourceState(req)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !p.MoveResourceStateCalled {
+		t.Fatal("MoveResourceState should be called")
+	}
+	if !reflect.DeepEqual(p.MoveResourceStateRequest, req) {
+		t.Fatalf("bad: %#v", p.MoveResourceStateRequest)
+	}
+	if !reflect.DeepEqual(state, p.MoveResourceStateReturn) {
+		t.Fatalf("bad: %#v", state)
+	}
+}
+
+func TestResourceProvider_upgradeResourceState(t *testing.T) {
+	p := new(terraform.MockResourceProvider)
+	p.UpgradeResourceStateReturn = &terraform.InstanceState{
+		ID:         "foo",
+		Attributes: map[string]string{"id": "foo", "new": "bar"},
+	}
+
+	// Create a mock provider
+	client, _ := plugin.TestPluginRPCConn(t, pluginMap(&ServeOpts{
+		ProviderFunc: testProviderFixed(p),
+	}))
+	defer client.Close()
+
+	// Request the provider
+	raw, err := client.Dispense(ProviderPluginName)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	provider := raw.(terraform.ResourceProviderUpgrader)
+
+	req := &terraform.UpgradeResourceStateRequest{
+		Info:    &terraform.InstanceInfo{Type: "test_instance"},
+		Version: 1,
+		State: &terraform.InstanceState{
+			ID:         "foo",
+			Attributes: map[string]string{"id": "foo", "old": "bar"},
+		},
+	}
+	state, err := provider.UpgradeResourceState(req)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !p.UpgradeResourceStateCalled {
+		t.Fatal("UpgradeResourceState should be called")
+	}
+	if !reflect.DeepEqual(p.UpgradeResourceStateRequest, req) {
+		t.Fatalf("bad: %#v", p.UpgradeResourceStateRequest)
+	}
+	if !reflect.DeepEqual(state, p.UpgradeResourceStateReturn) {
+		t.Fatalf("bad: %#v", state)
+	}
+}
+
 func TestResourceProvider_input(t *testing.T) {
 	// Create a mock provider
 	p := new(terraform.MockResourceProvider)
@@ -142,6 +258,40 @@ func TestResourceProvider_configure(t *testing.T) {
 	}
 }
 
+func TestResourceProvider_configureProviderMeta(t *testing.T) {
+	// Create a mock provider
+	p := new(terraform.MockResourceProvider)
+	client, _ := plugin.TestPluginRPCConn(t, pluginMap(&ServeOpts{
+		ProviderFunc: testProviderFixed(p),
+	}))
+	defer client.Close()
+
+	// Request the provider
+	raw, err := client.Dispense(ProviderPluginName)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	provider := raw.(terraform.ResourceProviderMetaConfigurer)
+
+	metas := []*terraform.ProviderModuleMeta{
+		{
+			ModulePath: []string{"root", "child"},
+			Config: &terraform.ResourceConfig{
+				Raw: map[string]interface{}{"module_name": "child"},
+			},
+		},
+	}
+	if err := provider.ConfigureProviderMeta(metas); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !p.ConfigureProviderMetaCalled {
+		t.Fatal("ConfigureProviderMeta should be called")
+	}
+	if !reflect.DeepEqual(p.ConfigureProviderMetaMetas, metas) {
+		t.Fatalf("bad: %#v", p.ConfigureProviderMetaMetas)
+	}
+}
+
 func TestResourceProvider_configure_errors(t *testing.T) {
 	p := new(terraform.MockResourceProvider)
 	p.ConfigureReturnError = errors.New("foo")
@@ -825,3 +975,237 @@ func TestResourceProvider_close(t *testing.T) {
 		t.Fatal("should have error")
 	}
 }
+
+func TestResourceProvider_functions(t *testing.T) {
+	p := new(terraform.MockResourceProvider)
+	p.FunctionsReturn = []terraform.ProviderFunction{
+		{
+			Name:       "join",
+			ParamTypes: []ast.Type{ast.TypeString, ast.TypeList},
+			ReturnType: ast.TypeString,
+		},
+	}
+	p.CallFunctionReturn = "a,b"
+
+	// Create a mock provider
+	client, _ := plugin.TestPluginRPCConn(t, pluginMap(&ServeOpts{
+		ProviderFunc: testProviderFixed(p),
+	}))
+	defer client.Close()
+
+	// Request the provider
+	raw, err := client.Dispense(ProviderPluginName)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	provider := raw.(terraform.ResourceProviderFunctions)
+
+	fns, err := provider.Functions()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !reflect.DeepEqual(fns, p.FunctionsReturn) {
+		t.Fatalf("bad: %#v", fns)
+	}
+
+	args := []interface{}{",", []interface{}{"a", "b"}}
+	result, err := provider.CallFunction("join", args)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !p.CallFunctionCalled {
+		t.Fatal("CallFunction should be called")
+	}
+	if p.CallFunctionName != "join" {
+		t.Fatalf("bad: %#v", p.CallFunctionName)
+	}
+	if !reflect.DeepEqual(p.CallFunctionArgs, args) {
+		t.Fatalf("bad: %#v", p.CallFunctionArgs)
+	}
+	if result != "a,b" {
+		t.Fatalf("bad: %#v", result)
+	}
+}
+
+func TestResourceProvider_callFunctionError(t *testing.T) {
+	p := new(terraform.MockResourceProvider)
+	p.CallFunctionReturnError = errors.New("foo")
+
+	// Create a mock provider
+	client, _ := plugin.TestPluginRPCConn(t, pluginMap(&ServeOpts{
+		ProviderFunc: testProviderFixed(p),
+	}))
+	defer client.Close()
+
+	// Request the provider
+	raw, err := client.Dispense(ProviderPluginName)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	provider := raw.(terraform.ResourceProviderFunctions)
+
+	_, err = provider.CallFunction("foo", nil)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+	if err.Error() != "foo" {
+		t.Fatalf("bad: %s", err)
+	}
+}
+
+func TestResourceProvider_lockResource(t *testing.T) {
+	p := new(terraform.MockResourceProvider)
+	p.LockResourceReturn = "lock-id"
+
+	// Create a mock provider
+	client, _ := plugin.TestPluginRPCConn(t, pluginMap(&ServeOpts{
+		ProviderFunc: testProviderFixed(p),
+	}))
+	defer client.Close()
+
+	// Request the provider
+	raw, err := client.Dispense(ProviderPluginName)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	provider := raw.(terraform.ResourceProviderLocker)
+
+	info := &terraform.InstanceInfo{Type: "test_instance"}
+	state := &terraform.InstanceState{ID: "foo"}
+	id, err := provider.LockResource(info, state, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !p.LockResourceCalled {
+		t.Fatal("LockResource should be called")
+	}
+	if !reflect.DeepEqual(p.LockResourceState, state) {
+		t.Fatalf("bad: %#v", p.LockResourceState)
+	}
+	if id != "lock-id" {
+		t.Fatalf("bad: %#v", id)
+	}
+
+	p.UnlockResourceReturnError = errors.New("foo")
+	err = provider.UnlockResource(info, id)
+	if !p.UnlockResourceCalled {
+		t.Fatal("UnlockResource should be called")
+	}
+	if p.UnlockResourceLockID != "lock-id" {
+		t.Fatalf("bad: %#v", p.UnlockResourceLockID)
+	}
+	if err == nil || err.Error() != "foo" {
+		t.Fatalf("bad: %v", err)
+	}
+}
+
+func TestResourceProvider_restart(t *testing.T) {
+	dispense := func(p *terraform.MockResourceProvider) *ResourceProvider {
+		client, _ := plugin.TestPluginRPCConn(t, pluginMap(&ServeOpts{
+			ProviderFunc: testProviderFixed(p),
+		}))
+		raw, err := client.Dispense(ProviderPluginName)
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		return raw.(*ResourceProvider)
+	}
+
+	p1 := new(terraform.MockResourceProvider)
+	p2 := new(terraform.MockResourceProvider)
+	p2.RefreshReturn = &terraform.InstanceState{ID: "bob"}
+
+	provider := dispense(p1)
+	provider.Reconnect = func() (*ResourceProvider, error) {
+		return dispense(p2), nil
+	}
+
+	config := &terraform.ResourceConfig{
+		Raw: map[string]interface{}{"foo": "bar"},
+	}
+	if err := provider.Configure(config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	metas := []*terraform.ProviderModuleMeta{
+		{
+			ModulePath: []string{"root"},
+			Config: &terraform.ResourceConfig{
+				Raw: map[string]interface{}{"module_name": "root"},
+			},
+		},
+	}
+	if err := provider.ConfigureProviderMeta(metas); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// Simulate the plugin process exiting
+	provider.Client.Close()
+
+	info := &terraform.InstanceInfo{Id: "foo"}
+	state, err := provider.Refresh(info, &terraform.InstanceState{ID: "foo"})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if state == nil || state.ID != "bob" {
+		t.Fatalf("bad: %#v", state)
+	}
+
+	if !p2.ConfigureCalled {
+		t.Fatal("configure should be replayed")
+	}
+	if !reflect.DeepEqual(p2.ConfigureConfig.Raw, config.Raw) {
+		t.Fatalf("bad: %#v", p2.ConfigureConfig)
+	}
+	if !p2.ConfigureProviderMetaCalled {
+		t.Fatal("provider_meta should be replayed")
+	}
+	if !reflect.DeepEqual(p2.ConfigureProviderMetaMetas[0].Config.Raw, metas[0].Config.Raw) {
+		t.Fatalf("bad: %#v", p2.ConfigureProviderMetaMetas)
+	}
+	if p1.RefreshCalled {
+		t.Fatal("refresh should not be called on the old plugin")
+	}
+	if !p2.RefreshCalled {
+		t.Fatal("refresh should be retried")
+	}
+}
+
+func TestResourceProvider_restartNoReconnect(t *testing.T) {
+	p := new(terraform.MockResourceProvider)
+	client, _ := plugin.TestPluginRPCConn(t, pluginMap(&ServeOpts{
+		ProviderFunc: testProviderFixed(p),
+	}))
+	raw, err := client.Dispense(ProviderPluginName)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	provider := raw.(*ResourceProvider)
+
+	provider.Client.Close()
+
+	_, err = provider.Refresh(&terraform.InstanceInfo{Id: "foo"}, nil)
+	if err != rpc.ErrShutdown {
+		t.Fatalf("bad: %#v", err)
+	}
+}
+
+func TestResourceProvider_callAttributes(t *testing.T) {
+	args := &ResourceProviderApplyArgs{
+		Info: &terraform.InstanceInfo{
+			Id:         "aws_instance.foo",
+			ModulePath: []string{"root", "child"},
+			Type:       "aws_instance",
+		},
+	}
+
+	actual := callAttributes("Plugin.Apply", args)
+	expected := []tracing.Attribute{
+		tracing.String("rpc.system", "netrpc"),
+		tracing.String("rpc.method", "Apply"),
+		tracing.String("terraform.resource.address", "module.child.aws_instance.foo"),
+		tracing.String("terraform.resource.type", "aws_instance"),
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("bad: %#v", actual)
+	}
+}
@@ -0,0 +1,119 @@
+package plugin
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform/terraform"
+)
+
+// ProviderSchemaCache is an on-disk cache of the schemas returned by a
+// particular provider plugin executable, allowing repeated operations to
+// skip the GetSchema call to the plugin altogether.
+//
+// Cache entries are keyed by the provider name and version, the SHA256
+// hash of the plugin executable and the set of resource types and data
+// sources requested. Replacing the executable therefore invalidates any
+// entries cached for it, even if the version is unchanged.
+type ProviderSchemaCache struct {
+	// Dir is the root directory of the cache, which is shared by all
+	// providers.
+	Dir string
+
+	// Name and Version identify the provider plugin.
+	Name    string
+	Version string
+
+	// SHA256 is the hash of the plugin executable.
+	SHA256 []byte
+}
+
+// Get returns the cached schema for the given request, or nil if there is
+// no usable cache entry.
+func (c *ProviderSchemaCache) Get(req *terraform.ProviderSchemaRequest) *terraform.ProviderSchema {
+	path := c.entryPath(req)
+	src, err := ioutil.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("[WARN] failed to read cached schema for provider %q: %s", c.Name, err)
+		}
+		return nil
+	}
+
+	var schema terraform.ProviderSchema
+	if err := json.Unmarshal(src, &schema); err != nil {
+		log.Printf("[WARN] ignoring invalid cached schema %s: %s", path, err)
+		return nil
+	}
+
+	log.Printf("[TRACE] using cached schema for provider %q from %s", c.Name, path)
+	return &schema
+}
+
+// Put saves the given schema as the response to the given request.
+//
+// Any entries cached for other executables with the same provider name and
+// version are removed, since they can never be used again.
+func (c *ProviderSchemaCache) Put(req *terraform.ProviderSchemaRequest, schema *terraform.ProviderSchema) error {
+	src, err := json.Marshal(schema)
+	if err != nil {
+		return err
+	}
+
+	dir := c.versionDir()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	prefix := hex.EncodeToString(c.SHA256) + "-"
+	for _, fi := range entries {
+		if !strings.HasPrefix(fi.Name(), prefix) {
+			os.Remove(filepath.Join(dir, fi.Name()))
+		}
+	}
+
+	// Write to a temporary file first so that a concurrent reader never
+	// sees a partial entry.
+	path := c.entryPath(req)
+	tmp := fmt.Sprintf("%s.%d.tmp", path, os.Getpid())
+	if err := ioutil.WriteFile(tmp, src, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func (c *ProviderSchemaCache) versionDir() string {
+	return filepath.Join(c.Dir, c.Name, c.Version)
+}
+
+func (c *ProviderSchemaCache) entryPath(req *terraform.ProviderSchemaRequest) string {
+	// The hash must not depend on the order in which types are requested.
+	var resourceTypes, dataSources []string
+	if req != nil {
+		resourceTypes = append(resourceTypes, req.ResourceTypes...)
+		dataSources = append(dataSources, req.DataSources...)
+	}
+	sort.Strings(resourceTypes)
+	sort.Strings(dataSources)
+
+	h := sha256.New()
+	fmt.Fprintf(h, "resources:%s\n", strings.Join(resourceTypes, ","))
+	fmt.Fprintf(h, "data:%s\n", strings.Join(dataSources, ","))
+
+	name := fmt.Sprintf(
+		"%s-%s.json",
+		hex.EncodeToString(c.SHA256), hex.EncodeToString(h.Sum(nil))[:16])
+	return filepath.Join(c.versionDir(), name)
+}
@@ -0,0 +1,154 @@
+package plugin
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/go-plugin"
+	"github.com/hashicorp/terraform/config/configschema"
+	"github.com/hashicorp/terraform/terraform"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func testSchemaCache(t *testing.T) (*ProviderSchemaCache, func()) {
+	dir, err := ioutil.TempDir("", "tf-schema-cache")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	cache := &ProviderSchemaCache{
+		Dir:     dir,
+		Name:    "test",
+		Version: "1.0.0",
+		SHA256:  []byte{1, 2, 3},
+	}
+	return cache, func() { os.RemoveAll(dir) }
+}
+
+func testSchema() *terraform.ProviderSchema {
+	return &terraform.ProviderSchema{
+		Provider: &configschema.Block{},
+		ResourceTypes: map[string]*configschema.Block{
+			"test_instance": &configschema.Block{
+				Attributes: map[string]*configschema.Attribute{
+					"ami": &configschema.Attribute{
+						Type:     cty.String,
+						Required: true,
+					},
+				},
+				BlockTypes: map[string]*configschema.NestedBlock{
+					"disk": &configschema.NestedBlock{
+						Nesting: configschema.NestingList,
+					},
+				},
+			},
+		},
+		DataSources: map[string]*configschema.Block{},
+	}
+}
+
+func TestProviderSchemaCache(t *testing.T) {
+	cache, cleanup := testSchemaCache(t)
+	defer cleanup()
+
+	req := &terraform.ProviderSchemaRequest{
+		ResourceTypes: []string{"test_instance", "test_other"},
+	}
+	if got := cache.Get(req); got != nil {
+		t.Fatalf("empty cache returned %#v", got)
+	}
+
+	want := testSchema()
+	if err := cache.Put(req, want); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// The order of the requested types doesn't matter
+	got := cache.Get(&terraform.ProviderSchemaRequest{
+		ResourceTypes: []string{"test_other", "test_instance"},
+	})
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong schema\ngot:  %#v\nwant: %#v", got, want)
+	}
+
+	// A different request is a different entry
+	other := &terraform.ProviderSchemaRequest{
+		ResourceTypes: []string{"test_instance"},
+	}
+	if got := cache.Get(other); got != nil {
+		t.Fatalf("wrong entry returned for a different request: %#v", got)
+	}
+}
+
+func TestProviderSchemaCache_executableChanged(t *testing.T) {
+	cache, cleanup := testSchemaCache(t)
+	defer cleanup()
+
+	req := &terraform.ProviderSchemaRequest{}
+	if err := cache.Put(req, testSchema()); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	changed := *cache
+	changed.SHA256 = []byte{4, 5, 6}
+	if got := changed.Get(req); got != nil {
+		t.Fatalf("entry for a different executable was returned: %#v", got)
+	}
+
+	// Saving an entry for the new executable removes the stale one
+	if err := changed.Put(req, testSchema()); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if got := cache.Get(req); got != nil {
+		t.Fatalf("stale entry was not removed: %#v", got)
+	}
+	if got := changed.Get(req); got == nil {
+		t.Fatal("new entry was not saved")
+	}
+}
+
+func TestResourceProvider_getSchemaCached(t *testing.T) {
+	cache, cleanup := testSchemaCache(t)
+	defer cleanup()
+
+	p := new(terraform.MockResourceProvider)
+	p.GetSchemaReturn = testSchema()
+
+	// Create a mock provider
+	client, _ := plugin.TestPluginRPCConn(t, pluginMap(&ServeOpts{
+		ProviderFunc: testProviderFixed(p),
+	}))
+	defer client.Close()
+
+	// Request the provider
+	raw, err := client.Dispense(ProviderPluginName)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	provider := raw.(*ResourceProvider)
+	provider.SchemaCache = cache
+
+	req := &terraform.ProviderSchemaRequest{
+		ResourceTypes: []string{"test_instance"},
+	}
+	if _, err := provider.GetSchema(req); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !p.GetSchemaCalled {
+		t.Fatal("GetSchema should be called")
+	}
+
+	p.GetSchemaCalled = false
+	got, err := provider.GetSchema(req)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if p.GetSchemaCalled {
+		t.Fatal("GetSchema should not be called when the schema is cached")
+	}
+	if !reflect.DeepEqual(got, p.GetSchemaReturn) {
+		t.Fatalf("wrong schema\ngot:  %#v\nwant: %#v", got, p.GetSchemaReturn)
+	}
+}
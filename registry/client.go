@@ -3,12 +3,16 @@ package registry
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
 	"net/http"
 	"net/url"
+	"os"
 	"path"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	cleanhttp "github.com/hashicorp/go-cleanhttp"
@@ -21,15 +25,39 @@ import (
 )
 
 const (
-	xTerraformGet     = "X-Terraform-Get"
-	xTerraformVersion = "X-Terraform-Version"
-	requestTimeout    = 10 * time.Second
-	serviceID         = "modules.v1"
+	xTerraformGet      = "X-Terraform-Get"
+	xTerraformVersion  = "X-Terraform-Version"
+	requestTimeout     = 10 * time.Second
+	modulesServiceID   = "modules.v1"
+	providersServiceID = "providers.v1"
+
+	// registryClientRetryEnvName and registryClientTimeoutEnvName are the
+	// names of the environment variables that override the number of
+	// retries and the timeout, in seconds, of requests to registries.
+	registryClientRetryEnvName   = "TF_REGISTRY_CLIENT_RETRY"
+	registryClientTimeoutEnvName = "TF_REGISTRY_CLIENT_TIMEOUT"
+
+	defaultRetry     = 3
+	defaultRetryWait = time.Second
+
+	// maxRetryWait is the longest the client waits to retry a request. If
+	// a registry asks for a longer wait with Retry-After, the request
+	// fails instead.
+	maxRetryWait = time.Minute
 )
 
 var tfVersion = version.String()
 
-// Client provides methods to query Terraform Registries.
+// Client provides methods to query Terraform Registries, using the module
+// and provider registry protocols.
+//
+// Requests that fail in a way that may be temporary, with a network error,
+// a server error, or the registry limiting the rate of requests, are
+// retried, waiting as long as the registry asks with Retry-After. The
+// responses listing versions are cached for the life of the client, so a
+// module or provider used many times is only looked up once.
+//
+// A Client is safe for concurrent use.
 type Client struct {
 	// this is the client to be used for all requests.
 	client *http.Client
@@ -41,6 +69,22 @@ type Client struct {
 	// Creds optionally provides credentials for communicating with service
 	// providers.
 	creds auth.CredentialsSource
+
+	// retry is the number of times a request is retried, and retryWait the
+	// wait before the first retry when the registry doesn't say how long
+	// to wait, which doubles for each retry after it.
+	retry     int
+	retryWait time.Duration
+
+	// lock guards the caches below.
+	lock sync.Mutex
+
+	// hostCreds are the credentials for each host, so that credentials
+	// helpers are run once per host rather than for every request.
+	hostCreds map[svchost.Hostname]auth.HostCredentials
+
+	// responses are the bodies of the successful JSON responses, by URL.
+	responses map[string][]byte
 }
 
 func NewClient(services *disco.Disco, creds auth.CredentialsSource, client *http.Client) *Client {
@@ -53,24 +97,59 @@ func NewClient(services *disco.Disco, creds auth.CredentialsSource, client *http
 	if client == nil {
 		client = cleanhttp.DefaultPooledClient()
 		client.Timeout = requestTimeout
+		if v := os.Getenv(registryClientTimeoutEnvName); v != "" {
+			if timeout, err := strconv.Atoi(v); err == nil && timeout > 0 {
+				client.Timeout = time.Duration(timeout) * time.Second
+			} else {
+				log.Printf("[WARN] ignoring invalid %s %q", registryClientTimeoutEnvName, v)
+			}
+		}
 	}
 
 	services.Transport = client.Transport.(*http.Transport)
 
+	retry := defaultRetry
+	if v := os.Getenv(registryClientRetryEnvName); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			retry = n
+		} else {
+			log.Printf("[WARN] ignoring invalid %s %q", registryClientRetryEnvName, v)
+		}
+	}
+
 	return &Client{
-		client:   client,
-		services: services,
-		creds:    creds,
+		client:    client,
+		services:  services,
+		creds:     creds,
+		retry:     retry,
+		retryWait: defaultRetryWait,
+		hostCreds: make(map[svchost.Hostname]auth.HostCredentials),
+		responses: make(map[string][]byte),
 	}
 }
 
-// Discover qeuries the host, and returns the url for the registry.
+// Discover qeuries the host, and returns the url for the registry, or nil
+// if the host doesn't provide Terraform modules.
 func (c *Client) Discover(host svchost.Hostname) *url.URL {
+	service, _ := c.discover(host, modulesServiceID)
+	return service
+}
+
+// discover returns the URL of a service of the host, with a trailing slash
+// so that paths can be resolved relative to it.
+func (c *Client) discover(host svchost.Hostname, serviceID string) (*url.URL, error) {
 	service := c.services.DiscoverServiceURL(host, serviceID)
+	if service == nil {
+		what := "modules"
+		if serviceID == providersServiceID {
+			what = "providers"
+		}
+		return nil, fmt.Errorf("host %s does not provide Terraform %s", host.ForDisplay(), what)
+	}
 	if !strings.HasSuffix(service.Path, "/") {
 		service.Path += "/"
 	}
-	return service
+	return service, nil
 }
 
 // Versions queries the registry for a module, and returns the available versions.
@@ -80,9 +159,9 @@ func (c *Client) Versions(module *regsrc.Module) (*response.ModuleVersions, erro
 		return nil, err
 	}
 
-	service := c.Discover(host)
-	if service == nil {
-		return nil, fmt.Errorf("host %s does not provide Terraform modules", host)
+	service, err := c.discover(host, modulesServiceID)
+	if err != nil {
+		return nil, err
 	}
 
 	p, err := url.Parse(path.Join(module.Module(), "versions"))
@@ -94,33 +173,9 @@ func (c *Client) Versions(module *regsrc.Module) (*response.ModuleVersions, erro
 
 	log.Printf("[DEBUG] fetching module versions from %q", service)
 
-	req, err := http.NewRequest("GET", service.String(), nil)
-	if err != nil {
-		return nil, err
-	}
-
-	c.addRequestCreds(host, req)
-	req.Header.Set(xTerraformVersion, tfVersion)
-
-	resp, err := c.client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	switch resp.StatusCode {
-	case http.StatusOK:
-		// OK
-	case http.StatusNotFound:
-		return nil, fmt.Errorf("module %q not found", module.String())
-	default:
-		return nil, fmt.Errorf("error looking up module versions: %s", resp.Status)
-	}
-
 	var versions response.ModuleVersions
-
-	dec := json.NewDecoder(resp.Body)
-	if err := dec.Decode(&versions); err != nil {
+	err = c.getJSON(host, service, &versions, "module versions", fmt.Errorf("module %q not found", module.String()))
+	if err != nil {
 		return nil, err
 	}
 
@@ -138,11 +193,17 @@ func (c *Client) addRequestCreds(host svchost.Hostname, req *http.Request) {
 		return
 	}
 
-	creds, err := c.creds.ForHost(host)
-	if err != nil {
-		log.Printf("[WARNING] Failed to get credentials for %s: %s (ignoring)", host, err)
-		return
+	c.lock.Lock()
+	creds, ok := c.hostCreds[host]
+	if !ok {
+		var err error
+		creds, err = c.creds.ForHost(host)
+		if err != nil {
+			log.Printf("[WARNING] Failed to get credentials for %s: %s (ignoring)", host, err)
+		}
+		c.hostCreds[host] = creds
 	}
+	c.lock.Unlock()
 
 	if creds != nil {
 		creds.PrepareRequest(req)
@@ -157,9 +218,9 @@ func (c *Client) Location(module *regsrc.Module, version string) (string, error)
 		return "", err
 	}
 
-	service := c.Discover(host)
-	if service == nil {
-		return "", fmt.Errorf("host %s does not provide Terraform modules", host.ForDisplay())
+	service, err := c.discover(host, modulesServiceID)
+	if err != nil {
+		return "", err
 	}
 
 	var p *url.URL
@@ -180,10 +241,7 @@ func (c *Client) Location(module *regsrc.Module, version string) (string, error)
 		return "", err
 	}
 
-	c.addRequestCreds(host, req)
-	req.Header.Set(xTerraformVersion, tfVersion)
-
-	resp, err := c.client.Do(req)
+	resp, err := c.do(host, req)
 	if err != nil {
 		return "", err
 	}
@@ -233,3 +291,205 @@ func (c *Client) Location(module *regsrc.Module, version string) (string, error)
 
 	return location, nil
 }
+
+// ProviderVersions queries the registry for a provider, and returns the
+// available versions with the protocols and platforms each supports.
+func (c *Client) ProviderVersions(provider *regsrc.Provider) (*response.ProviderVersions, error) {
+	host, err := provider.SvcHost()
+	if err != nil {
+		return nil, err
+	}
+
+	service, err := c.discover(host, providersServiceID)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := url.Parse(path.Join(provider.Provider(), "versions"))
+	if err != nil {
+		return nil, err
+	}
+	service = service.ResolveReference(p)
+
+	log.Printf("[DEBUG] fetching provider versions from %q", service)
+
+	var versions response.ProviderVersions
+	err = c.getJSON(host, service, &versions, "provider versions", fmt.Errorf("provider %q not found", provider.String()))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, w := range versions.Warnings {
+		log.Printf("[WARN] registry warning for provider %s: %s", provider.Display(), w)
+	}
+
+	return &versions, nil
+}
+
+// ProviderLocation finds the package of a provider version for a platform,
+// with the location of its checksums and the keys that may have signed
+// them. Relative URLs in the response are resolved, so the URLs returned
+// are absolute.
+func (c *Client) ProviderLocation(provider *regsrc.Provider, version, os, arch string) (*response.ProviderPackage, error) {
+	host, err := provider.SvcHost()
+	if err != nil {
+		return nil, err
+	}
+
+	service, err := c.discover(host, providersServiceID)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := url.Parse(path.Join(provider.Provider(), version, "download", os, arch))
+	if err != nil {
+		return nil, err
+	}
+	download := service.ResolveReference(p)
+
+	log.Printf("[DEBUG] looking up provider package from %q", download)
+
+	var pkg response.ProviderPackage
+	notFound := fmt.Errorf("provider %q version %q is not available for %s_%s", provider.String(), version, os, arch)
+	if err := c.getJSON(host, download, &pkg, "provider package", notFound); err != nil {
+		return nil, err
+	}
+	if pkg.DownloadURL == "" {
+		return nil, fmt.Errorf("failed to get download URL for provider %q version %q", provider.String(), version)
+	}
+
+	for _, u := range []*string{&pkg.DownloadURL, &pkg.SHASumsURL, &pkg.SHASumsSignatureURL} {
+		if *u == "" {
+			continue
+		}
+		ref, err := url.Parse(*u)
+		if err != nil {
+			return nil, fmt.Errorf("invalid URL %q for provider %q: %s", *u, provider.String(), err)
+		}
+		*u = download.ResolveReference(ref).String()
+	}
+
+	return &pkg, nil
+}
+
+// getJSON decodes into v the JSON body of a GET request to u, which is on
+// host, from the cache if it was requested before. what describes what is
+// requested, for errors, and notFound is the error for a 404 response.
+func (c *Client) getJSON(host svchost.Hostname, u *url.URL, v interface{}, what string, notFound error) error {
+	key := u.String()
+	c.lock.Lock()
+	body, ok := c.responses[key]
+	c.lock.Unlock()
+
+	if ok {
+		log.Printf("[TRACE] using the cached response for %q", key)
+	} else {
+		req, err := http.NewRequest("GET", key, nil)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Accept", "application/json")
+
+		resp, err := c.do(host, req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		switch resp.StatusCode {
+		case http.StatusOK:
+			// OK
+		case http.StatusNotFound:
+			return notFound
+		default:
+			return fmt.Errorf("error looking up %s: %s", what, resp.Status)
+		}
+
+		body, err = ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return fmt.Errorf("error reading response body from registry: %s", err)
+		}
+	}
+
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("error looking up %s: invalid response from registry: %s", what, err)
+	}
+
+	c.lock.Lock()
+	c.responses[key] = body
+	c.lock.Unlock()
+	return nil
+}
+
+// do sends req to host with the credentials for it, retrying as described
+// for Client.
+func (c *Client) do(host svchost.Hostname, req *http.Request) (*http.Response, error) {
+	c.addRequestCreds(host, req)
+	req.Header.Set(xTerraformVersion, tfVersion)
+
+	for attempt := 0; ; attempt++ {
+		resp, err := c.client.Do(req)
+		if !shouldRetry(resp, err) {
+			return resp, err
+		}
+
+		wait := c.retryWait << uint(attempt)
+		if resp != nil {
+			if after, ok := retryAfter(resp); ok {
+				wait = after
+			}
+		}
+		if attempt >= c.retry || wait > maxRetryWait {
+			if resp != nil && resp.StatusCode == http.StatusTooManyRequests {
+				resp.Body.Close()
+				return nil, fmt.Errorf("registry %s is limiting the rate of requests; try again in %s", host.ForDisplay(), wait.Round(time.Second))
+			}
+			return resp, err
+		}
+
+		if err != nil {
+			log.Printf("[DEBUG] retrying request to %s in %s: %s", req.URL, wait, err)
+		} else {
+			log.Printf("[DEBUG] retrying request to %s in %s: %s", req.URL, wait, resp.Status)
+			io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		time.Sleep(wait)
+	}
+}
+
+// shouldRetry returns whether a request that got resp or err may succeed if
+// it's retried.
+func shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return true
+	case resp.StatusCode == http.StatusNotImplemented:
+		return false
+	default:
+		return resp.StatusCode >= 500
+	}
+}
+
+// retryAfter returns the wait a response asks for with Retry-After, which
+// is either a number of seconds or a date.
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	v := resp.Header.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(v); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		wait := time.Until(t)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+	return 0, false
+}
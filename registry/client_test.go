@@ -1,13 +1,19 @@
 package registry
 
 import (
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	version "github.com/hashicorp/go-version"
 	"github.com/hashicorp/terraform/registry/regsrc"
 	"github.com/hashicorp/terraform/registry/test"
+	"github.com/hashicorp/terraform/svchost"
+	"github.com/hashicorp/terraform/svchost/auth"
 	"github.com/hashicorp/terraform/svchost/disco"
 )
 
@@ -181,3 +187,241 @@ func TestLookupLookupModuleError(t *testing.T) {
 		t.Fatal("error should not include the hostname. got:", err)
 	}
 }
+
+func TestLookupProviderVersions(t *testing.T) {
+	server := test.Registry()
+	defer server.Close()
+
+	client := NewClient(test.Disco(server), nil, nil)
+
+	provider, err := regsrc.ParseProviderSource("hashicorp/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := client.ProviderVersions(provider)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(resp.Versions) != 2 {
+		t.Fatal("expected 2 versions, got", len(resp.Versions))
+	}
+	v := resp.Versions[0]
+	if v.Version != "1.0.0" || len(v.Platforms) != 2 || v.Platforms[0].OS != "linux" {
+		t.Fatalf("wrong version %#v", v)
+	}
+
+	provider, err = regsrc.ParseProviderSource("hashicorp/missing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.ProviderVersions(provider)
+	if err == nil || !strings.Contains(err.Error(), `provider "hashicorp/missing" not found`) {
+		t.Fatalf("wrong error %v", err)
+	}
+}
+
+func TestLookupProviderLocation(t *testing.T) {
+	server := test.Registry()
+	defer server.Close()
+
+	client := NewClient(test.Disco(server), nil, nil)
+
+	provider, err := regsrc.ParseProviderSource("hashicorp/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pkg, err := client.ProviderLocation(provider, "1.0.0", "linux", "amd64")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := server.URL + "/download/terraform-provider-test_1.0.0_linux_amd64.zip"; pkg.DownloadURL != want {
+		t.Errorf("wrong download URL %s; want %s", pkg.DownloadURL, want)
+	}
+	if want := server.URL + "/v1/providers/hashicorp/test/1.0.0/download/linux/SHA256SUMS"; pkg.SHASumsURL != want {
+		t.Errorf("wrong checksums URL %s; want %s", pkg.SHASumsURL, want)
+	}
+
+	_, err = client.ProviderLocation(provider, "1.1.0", "darwin", "amd64")
+	if err == nil || !strings.Contains(err.Error(), "not available for darwin_amd64") {
+		t.Fatalf("wrong error %v", err)
+	}
+}
+
+func TestProviderRegistryAuth(t *testing.T) {
+	server := test.Registry()
+	defer server.Close()
+
+	provider, err := regsrc.ParseProviderSource("private/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := NewClient(test.Disco(server), nil, nil)
+	if _, err := client.ProviderVersions(provider); err == nil {
+		t.Fatal("expected error")
+	}
+
+	client = NewClient(test.Disco(server), test.Credentials, nil)
+	if _, err := client.ProviderVersions(provider); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.ProviderLocation(provider, "2.0.0", "linux", "amd64"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClient_noService(t *testing.T) {
+	server := test.Registry()
+	defer server.Close()
+
+	d := disco.NewDisco()
+	d.ForceHostServices(svchost.Hostname("example.com"), map[string]interface{}{})
+	client := NewClient(d, nil, nil)
+
+	mod, err := regsrc.ParseModuleSource("example.com/foo/bar/baz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.Versions(mod)
+	if err == nil || err.Error() != "host example.com does not provide Terraform modules" {
+		t.Fatalf("wrong error %v", err)
+	}
+}
+
+func TestClient_retry(t *testing.T) {
+	var requests int
+	var status []int
+	var retryAfter string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if len(status) > 0 {
+			s := status[0]
+			status = status[1:]
+			if retryAfter != "" {
+				w.Header().Set("Retry-After", retryAfter)
+			}
+			w.WriteHeader(s)
+			return
+		}
+		io.WriteString(w, `{"modules": [{"source": "foo/bar/baz", "versions": [{"version": "1.0.0"}]}]}`)
+	}))
+	defer server.Close()
+
+	d := disco.NewDisco()
+	d.ForceHostServices(svchost.Hostname("example.com"), map[string]interface{}{
+		"modules.v1": server.URL + "/v1/modules/",
+	})
+	mod, err := regsrc.ParseModuleSource("example.com/foo/bar/baz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	newClient := func() *Client {
+		client := NewClient(d, nil, nil)
+		client.retry = 2
+		client.retryWait = time.Millisecond
+		return client
+	}
+
+	t.Run("server errors", func(t *testing.T) {
+		requests, status, retryAfter = 0, []int{502, 503}, ""
+		if _, err := newClient().Versions(mod); err != nil {
+			t.Fatal(err)
+		}
+		if requests != 3 {
+			t.Fatalf("expected 3 requests, got %d", requests)
+		}
+	})
+
+	t.Run("rate limited", func(t *testing.T) {
+		requests, status, retryAfter = 0, []int{429}, "0"
+		if _, err := newClient().Versions(mod); err != nil {
+			t.Fatal(err)
+		}
+		if requests != 2 {
+			t.Fatalf("expected 2 requests, got %d", requests)
+		}
+	})
+
+	t.Run("retry after too long", func(t *testing.T) {
+		requests, status, retryAfter = 0, []int{429}, "3600"
+		_, err := newClient().Versions(mod)
+		if err == nil || !strings.Contains(err.Error(), "is limiting the rate of requests; try again in 1h0m0s") {
+			t.Fatalf("wrong error %v", err)
+		}
+		if requests != 1 {
+			t.Fatalf("expected 1 request, got %d", requests)
+		}
+	})
+
+	t.Run("retries exhausted", func(t *testing.T) {
+		requests, status, retryAfter = 0, []int{500, 500, 500}, ""
+		_, err := newClient().Versions(mod)
+		if err == nil || !strings.Contains(err.Error(), "500 Internal Server Error") {
+			t.Fatalf("wrong error %v", err)
+		}
+		if requests != 3 {
+			t.Fatalf("expected 3 requests, got %d", requests)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		requests, status, retryAfter = 0, []int{404}, ""
+		if _, err := newClient().Versions(mod); err == nil {
+			t.Fatal("expected error")
+		}
+		if requests != 1 {
+			t.Fatalf("expected 1 request, got %d", requests)
+		}
+	})
+
+	t.Run("cached", func(t *testing.T) {
+		requests, status, retryAfter = 0, nil, ""
+		client := newClient()
+		for i := 0; i < 3; i++ {
+			if _, err := client.Versions(mod); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if requests != 1 {
+			t.Fatalf("expected 1 request, got %d", requests)
+		}
+	})
+}
+
+// countingCredentials is a CredentialsSource that counts its lookups, like a
+// credentials helper program would be run.
+type countingCredentials struct {
+	auth.CredentialsSource
+	lookups int
+}
+
+func (c *countingCredentials) ForHost(host svchost.Hostname) (auth.HostCredentials, error) {
+	c.lookups++
+	return c.CredentialsSource.ForHost(host)
+}
+
+func TestClient_credentialsCached(t *testing.T) {
+	server := test.Registry()
+	defer server.Close()
+
+	creds := &countingCredentials{CredentialsSource: test.Credentials}
+	client := NewClient(test.Disco(server), creds, nil)
+
+	mod, err := regsrc.ParseModuleSource("private/name/provider")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Versions(mod); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Location(mod, "1.0.0"); err != nil {
+		t.Fatal(err)
+	}
+	if creds.lookups != 1 {
+		t.Fatalf("expected 1 credentials lookup, got %d", creds.lookups)
+	}
+}
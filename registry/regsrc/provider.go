@@ -0,0 +1,116 @@
+package regsrc
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/terraform/svchost"
+)
+
+var (
+	ErrInvalidProviderSource = errors.New("not a valid registry provider source")
+
+	// providerTypeSubRe is the sub-expression that matches a valid provider
+	// type in a provider source, such as "aws" or "google-beta". It does not
+	// anchor the start or end so it can be composed into more complex
+	// RegExps below.
+	providerTypeSubRe = "[0-9a-z](?:[0-9a-z-]{0,62}[0-9a-z])?"
+
+	// providerSourceRe is a regular expression that matches the
+	// namespace/type format for registry provider sources. It assumes any
+	// FriendlyHost prefix has already been removed if present.
+	providerSourceRe = regexp.MustCompile(
+		fmt.Sprintf("^(%s)\\/(%s)$", nameSubRe, providerTypeSubRe))
+)
+
+// Provider describes a Terraform Registry Provider source, such as
+// "hashicorp/aws" or "registry.example.com/example/widgets".
+type Provider struct {
+	// RawHost is the friendly host prefix if one was present, as for Module.
+	// Most callers should access it with Host().
+	RawHost      *FriendlyHost
+	RawNamespace string
+	RawType      string
+}
+
+// ParseProviderSource attempts to parse source as a Terraform registry
+// provider source. If the string is not found to be in a valid format,
+// ErrInvalidProviderSource is returned. As with ParseModuleSource, it can
+// only be used on "input" strings.
+func ParseProviderSource(source string) (*Provider, error) {
+	host, rest := ParseFriendlyHost(source)
+	if host != nil && !host.Valid() {
+		return nil, ErrInvalidProviderSource
+	}
+
+	matches := providerSourceRe.FindStringSubmatch(rest)
+	if len(matches) != 3 {
+		return nil, ErrInvalidProviderSource
+	}
+
+	return &Provider{
+		RawHost:      host,
+		RawNamespace: matches[1],
+		RawType:      matches[2],
+	}, nil
+}
+
+// Display returns the source formatted for display to the user in CLI or web
+// output.
+func (p *Provider) Display() string {
+	return p.normalizedHostPrefix(p.Host().Display()) + p.Provider()
+}
+
+// Normalized returns the source formatted for internal reference or comparison.
+func (p *Provider) Normalized() string {
+	return p.normalizedHostPrefix(p.Host().Normalized()) + p.Provider()
+}
+
+// String returns the source formatted as the user originally typed it assuming
+// it was parsed from user input.
+func (p *Provider) String() string {
+	hostPrefix := ""
+	if p.RawHost != nil {
+		hostPrefix = p.RawHost.String() + "/"
+	}
+	return fmt.Sprintf("%s%s/%s", hostPrefix, p.RawNamespace, p.RawType)
+}
+
+// Equal compares the provider source against another instance taking
+// normalization into account.
+func (p *Provider) Equal(other *Provider) bool {
+	return p.Normalized() == other.Normalized()
+}
+
+// Provider returns just the registry ID of the provider, without a hostname.
+func (p *Provider) Provider() string {
+	return strings.ToLower(fmt.Sprintf("%s/%s", p.RawNamespace, p.RawType))
+}
+
+// Host returns the FriendlyHost object describing which registry this
+// provider is in. If the original source string had no host component this
+// will return the PublicRegistryHost.
+func (p *Provider) Host() *FriendlyHost {
+	if p.RawHost == nil {
+		return PublicRegistryHost
+	}
+	return p.RawHost
+}
+
+func (p *Provider) normalizedHostPrefix(host string) string {
+	if p.Host().Equal(PublicRegistryHost) {
+		return ""
+	}
+	return host + "/"
+}
+
+// SvcHost returns the svchost.Hostname for this provider. If no host is
+// specified, the default PublicRegistryHost is returned.
+func (p *Provider) SvcHost() (svchost.Hostname, error) {
+	if p.RawHost == nil {
+		return svchost.ForComparison(PublicRegistryHost.Raw)
+	}
+	return svchost.ForComparison(p.RawHost.Raw)
+}
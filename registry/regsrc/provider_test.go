@@ -0,0 +1,89 @@
+package regsrc
+
+import (
+	"testing"
+)
+
+func TestProvider(t *testing.T) {
+	tests := []struct {
+		name        string
+		source      string
+		wantString  string
+		wantDisplay string
+		wantNorm    string
+		wantErr     bool
+	}{
+		{
+			name:        "public registry",
+			source:      "hashicorp/aws",
+			wantString:  "hashicorp/aws",
+			wantDisplay: "hashicorp/aws",
+			wantNorm:    "hashicorp/aws",
+		},
+		{
+			name:        "public registry, explicit host",
+			source:      "registry.terraform.io/hashicorp/google-beta",
+			wantString:  "registry.terraform.io/hashicorp/google-beta",
+			wantDisplay: "hashicorp/google-beta",
+			wantNorm:    "hashicorp/google-beta",
+		},
+		{
+			name:        "private registry, mixed case",
+			source:      "Example.com:1234/HashiCorp/widgets",
+			wantString:  "Example.com:1234/HashiCorp/widgets",
+			wantDisplay: "example.com:1234/hashicorp/widgets",
+			wantNorm:    "example.com:1234/hashicorp/widgets",
+		},
+		{
+			name:    "module source",
+			source:  "hashicorp/consul/aws",
+			wantErr: true,
+		},
+		{
+			name:    "uppercase type",
+			source:  "hashicorp/AWS",
+			wantErr: true,
+		},
+		{
+			name:    "invalid host",
+			source:  "---.com/hashicorp/aws",
+			wantErr: true,
+		},
+		{
+			name:    "legacy name",
+			source:  "aws",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseProviderSource(tt.source)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseProviderSource() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if v := got.String(); v != tt.wantString {
+				t.Fatalf("String() = %v, want %v", v, tt.wantString)
+			}
+			if v := got.Display(); v != tt.wantDisplay {
+				t.Fatalf("Display() = %v, want %v", v, tt.wantDisplay)
+			}
+			if v := got.Normalized(); v != tt.wantNorm {
+				t.Fatalf("Normalized() = %v, want %v", v, tt.wantNorm)
+			}
+
+			gotDisplay, err := ParseProviderSource(tt.wantDisplay)
+			if err != nil {
+				t.Fatalf("ParseProviderSource(wantDisplay) error = %v", err)
+			}
+			if !got.Equal(gotDisplay) {
+				t.Fatalf("Equal() failed for %s and %s", tt.source, tt.wantDisplay)
+			}
+		})
+	}
+}
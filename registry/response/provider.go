@@ -0,0 +1,59 @@
+package response
+
+// ProviderVersions is the response format of the provider registry protocol
+// for the versions of a provider, which the CLI needs to resolve version
+// constraints and check platform and protocol compatibility.
+type ProviderVersions struct {
+	Versions []*ProviderVersion `json:"versions"`
+
+	// Warnings are messages the registry wants shown to users of the
+	// provider, e.g. that it's deprecated.
+	Warnings []string `json:"warnings"`
+}
+
+// ProviderVersion is the metadata for a single version of a provider.
+type ProviderVersion struct {
+	Version   string              `json:"version"`
+	Protocols []string            `json:"protocols"`
+	Platforms []*ProviderPlatform `json:"platforms"`
+}
+
+// ProviderPlatform is a platform a provider version has a package for.
+type ProviderPlatform struct {
+	OS   string `json:"os"`
+	Arch string `json:"arch"`
+}
+
+// ProviderPackage is the response format of the provider registry protocol
+// for the package of a provider version for a platform, with what's needed
+// to download and verify it.
+type ProviderPackage struct {
+	Protocols []string `json:"protocols"`
+	OS        string   `json:"os"`
+	Arch      string   `json:"arch"`
+	Filename  string   `json:"filename"`
+
+	DownloadURL         string `json:"download_url"`
+	SHASumsURL          string `json:"shasums_url"`
+	SHASumsSignatureURL string `json:"shasums_signature_url"`
+
+	// SHASum is the hex SHA256 checksum of the package.
+	SHASum string `json:"shasum"`
+
+	SigningKeys SigningKeyList `json:"signing_keys"`
+}
+
+// SigningKeyList is the list of keys that may have signed the checksums of a
+// provider package.
+type SigningKeyList struct {
+	GPGPublicKeys []*GPGPublicKey `json:"gpg_public_keys"`
+}
+
+// GPGPublicKey is an ASCII-armored GPG public key.
+type GPGPublicKey struct {
+	KeyID          string `json:"key_id"`
+	ASCIIArmor     string `json:"ascii_armor"`
+	TrustSignature string `json:"trust_signature"`
+	Source         string `json:"source"`
+	SourceURL      string `json:"source_url"`
+}
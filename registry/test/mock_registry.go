@@ -25,7 +25,8 @@ func Disco(s *httptest.Server) *disco.Disco {
 	services := map[string]interface{}{
 		// Note that both with and without trailing slashes are supported behaviours
 		// TODO: add specific tests to enumerate both possibilities.
-		"modules.v1": fmt.Sprintf("%s/v1/modules", s.URL),
+		"modules.v1":   fmt.Sprintf("%s/v1/modules", s.URL),
+		"providers.v1": fmt.Sprintf("%s/v1/providers/", s.URL),
 	}
 	d := disco.NewDisco()
 
@@ -89,6 +90,18 @@ var testMods = map[string][]testMod{
 	},
 }
 
+// testProviders are the providers in the mock registry, with the versions
+// of each and the platforms each version has packages for.
+var testProviders = map[string]map[string][]string{
+	"hashicorp/test": {
+		"1.0.0": {"linux_amd64", "darwin_amd64"},
+		"1.1.0": {"linux_amd64"},
+	},
+	"private/test": {
+		"2.0.0": {"linux_amd64"},
+	},
+}
+
 func latestVersion(versions []string) string {
 	var col version.Collection
 	for _, v := range versions {
@@ -210,6 +223,10 @@ func mockRegHandler() http.Handler {
 		})),
 	)
 
+	mux.Handle("/v1/providers/",
+		http.StripPrefix("/v1/providers/", http.HandlerFunc(providerHandler)),
+	)
+
 	mux.HandleFunc("/.well-known/terraform.json", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		io.WriteString(w, `{"modules.v1":"http://localhost/v1/modules/"}`)
@@ -221,3 +238,84 @@ func mockRegHandler() http.Handler {
 func Registry() *httptest.Server {
 	return httptest.NewServer(mockRegHandler())
 }
+
+// providerHandler serves the provider registry protocol for testProviders.
+func providerHandler(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(r.URL.Path, "/")
+	if len(parts) < 3 {
+		http.NotFound(w, r)
+		return
+	}
+	name := parts[0] + "/" + parts[1]
+
+	// check for auth
+	if strings.HasPrefix(name, "private/") {
+		if !strings.Contains(r.Header.Get("Authorization"), testCred) {
+			http.Error(w, "", http.StatusForbidden)
+			return
+		}
+	}
+
+	versions, ok := testProviders[name]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	var resp interface{}
+	switch {
+	case len(parts) == 3 && parts[2] == "versions":
+		var list response.ProviderVersions
+		for v, platforms := range versions {
+			pv := &response.ProviderVersion{
+				Version:   v,
+				Protocols: []string{"4.0"},
+			}
+			for _, platform := range platforms {
+				osArch := strings.SplitN(platform, "_", 2)
+				pv.Platforms = append(pv.Platforms, &response.ProviderPlatform{OS: osArch[0], Arch: osArch[1]})
+			}
+			list.Versions = append(list.Versions, pv)
+		}
+		sort.Slice(list.Versions, func(i, j int) bool {
+			return list.Versions[i].Version < list.Versions[j].Version
+		})
+		resp = list
+
+	case len(parts) == 6 && parts[3] == "download":
+		v, goos, goarch := parts[2], parts[4], parts[5]
+		found := false
+		for _, platform := range versions[v] {
+			if platform == goos+"_"+goarch {
+				found = true
+			}
+		}
+		if !found {
+			http.NotFound(w, r)
+			return
+		}
+		filename := fmt.Sprintf("terraform-provider-%s_%s_%s_%s.zip", parts[1], v, goos, goarch)
+		resp = &response.ProviderPackage{
+			Protocols:           []string{"4.0"},
+			OS:                  goos,
+			Arch:                goarch,
+			Filename:            filename,
+			DownloadURL:         "/download/" + filename,
+			SHASumsURL:          "SHA256SUMS",
+			SHASumsSignatureURL: "SHA256SUMS.sig",
+			SHASum:              "0123456789abcdef",
+		}
+
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	js, err := json.Marshal(resp)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(js)
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	cleanhttp "github.com/hashicorp/go-cleanhttp"
+	version "github.com/hashicorp/go-version"
+	"github.com/hashicorp/terraform/command"
+)
+
+// releasesLatestURL is the releases API endpoint that describes the latest
+// release of Terraform.
+var releasesLatestURL = "https://api.releases.hashicorp.com/v1/releases/terraform/latest"
+
+// releasesTimeout is how long the releases API may take to answer.
+const releasesTimeout = 10 * time.Second
+
+// releasesVersionCheck implements command.VersionCheckFunc by asking the
+// releases API for the latest release of Terraform, for "terraform version
+// -check". Unlike the checkpoint check, it's only done when asked for, so
+// disable_checkpoint doesn't apply to it.
+func releasesVersionCheck() (command.VersionCheckInfo, error) {
+	var info command.VersionCheckInfo
+
+	current := Version
+	if VersionPrerelease != "" {
+		current += "-" + VersionPrerelease
+	}
+	currentVersion, err := version.NewVersion(current)
+	if err != nil {
+		return info, fmt.Errorf("invalid version %q: %s", current, err)
+	}
+
+	latest, err := releasesLatestVersion()
+	if err != nil {
+		return info, err
+	}
+
+	info.Latest = latest.String()
+	info.Outdated = currentVersion.LessThan(latest)
+	return info, nil
+}
+
+// releasesLatestVersion returns the version of the latest release of
+// Terraform, according to the releases API.
+func releasesLatestVersion() (*version.Version, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), releasesTimeout)
+	defer cancel()
+
+	req, err := http.NewRequest("GET", releasesLatestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := cleanhttp.DefaultClient().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach the releases API: %s", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("the releases API returned %s", resp.Status)
+	}
+
+	var release struct {
+		Version string `json:"version"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return nil, fmt.Errorf("malformed response from the releases API: %s", err)
+	}
+
+	v, err := version.NewVersion(release.Version)
+	if err != nil {
+		return nil, fmt.Errorf("the releases API returned an invalid version %q: %s", release.Version, err)
+	}
+	return v, nil
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestReleasesVersionCheck(t *testing.T) {
+	tests := map[string]struct {
+		Latest   string
+		Outdated bool
+	}{
+		"newer":   {"99.0.0", true},
+		"older":   {"0.1.0", false},
+		"current": {Version, VersionPrerelease != ""},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(`{"name": "terraform", "version": "` + test.Latest + `"}`))
+			}))
+			defer ts.Close()
+
+			defer func(url string) { releasesLatestURL = url }(releasesLatestURL)
+			releasesLatestURL = ts.URL
+
+			info, err := releasesVersionCheck()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Latest != test.Latest {
+				t.Errorf("wrong latest version %q; want %q", info.Latest, test.Latest)
+			}
+			if info.Outdated != test.Outdated {
+				t.Errorf("wrong outdated %t; want %t", info.Outdated, test.Outdated)
+			}
+		})
+	}
+}
+
+func TestReleasesVersionCheck_error(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	defer func(url string) { releasesLatestURL = url }(releasesLatestURL)
+	releasesLatestURL = ts.URL
+
+	if _, err := releasesVersionCheck(); err == nil {
+		t.Fatal("succeeded; want error")
+	}
+}
@@ -0,0 +1,105 @@
+package repl
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform/config"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+// Completions returns the names starting with prefix that can be used in
+// the interpolations of the session: the functions, the variables, local
+// values, modules and resources of the configuration, the resources and
+// module outputs of the state, and the attributes of the resources in the
+// state once prefix names a resource. The names are sorted, and those of
+// functions are followed by an opening parenthesis.
+func (s *Session) Completions(prefix string) []string {
+	names := make(map[string]struct{})
+	add := func(name string) {
+		if strings.HasPrefix(name, prefix) {
+			names[name] = struct{}{}
+		}
+	}
+
+	for name := range config.Funcs() {
+		add(name + "(")
+	}
+	for _, name := range []string{"path.cwd", "path.module", "path.root", "terraform.workspace"} {
+		add(name)
+	}
+
+	i := s.Interpolater
+	if i.Module != nil {
+		conf := i.Module.Config()
+		for _, v := range conf.Variables {
+			add("var." + v.Name)
+		}
+		for _, l := range conf.Locals {
+			add("local." + l.Name)
+		}
+		for _, r := range conf.Resources {
+			add(r.Id())
+		}
+		for _, m := range conf.Modules {
+			add("module." + m.Name)
+
+			if child := i.Module.Child([]string{m.Name}); child != nil {
+				for _, o := range child.Config().Outputs {
+					add("module." + m.Name + "." + o.Name)
+				}
+			}
+		}
+	}
+
+	if i.State != nil {
+		i.StateLock.RLock()
+		defer i.StateLock.RUnlock()
+
+		for _, mod := range i.State.Modules {
+			switch {
+			case mod.IsRoot():
+				for k, rs := range mod.Resources {
+					s.addResourceCompletions(k, rs, prefix, add)
+				}
+			case len(mod.Path) == 2:
+				for name := range mod.Outputs {
+					add("module." + mod.Path[1] + "." + name)
+				}
+			}
+		}
+	}
+
+	ret := make([]string, 0, len(names))
+	for name := range names {
+		ret = append(ret, name)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// addResourceCompletions adds the name of the resource with the given state
+// key, and the names of its attributes if the prefix names the resource.
+func (s *Session) addResourceCompletions(key string, rs *terraform.ResourceState, prefix string, add func(string)) {
+	k, err := terraform.ParseResourceStateKey(key)
+	if err != nil {
+		return
+	}
+
+	id := k.Type + "." + k.Name
+	if k.Mode == config.DataResourceMode {
+		id = "data." + id
+	}
+	add(id)
+
+	if !strings.HasPrefix(prefix, id+".") || rs.Primary == nil {
+		return
+	}
+
+	add(id + ".id")
+	for attr := range rs.Primary.Attributes {
+		// Only complete the top-level attributes, and not each element
+		// of the flattened lists and maps.
+		add(id + "." + strings.SplitN(attr, ".", 2)[0])
+	}
+}
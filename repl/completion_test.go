@@ -0,0 +1,72 @@
+package repl
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestSession_Completions(t *testing.T) {
+	state := &terraform.State{
+		Modules: []*terraform.ModuleState{
+			&terraform.ModuleState{
+				Path: []string{"root"},
+				Resources: map[string]*terraform.ResourceState{
+					"test_instance.foo": &terraform.ResourceState{
+						Type: "test_instance",
+						Primary: &terraform.InstanceState{
+							ID: "bar",
+							Attributes: map[string]string{
+								"id":       "bar",
+								"tags.%":   "1",
+								"tags.env": "prod",
+							},
+						},
+					},
+				},
+			},
+			&terraform.ModuleState{
+				Path: []string{"root", "child"},
+				Outputs: map[string]*terraform.OutputState{
+					"address": &terraform.OutputState{
+						Type:  "string",
+						Value: "10.0.0.1",
+					},
+				},
+			},
+		},
+	}
+
+	ctx, err := terraform.NewContext(&terraform.ContextOpts{
+		State:  state,
+		Module: testModule(t, "locals"),
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	s := &Session{Interpolater: ctx.Interpolater()}
+
+	cases := []struct {
+		Prefix string
+		Want   []string
+	}{
+		{"local.", []string{"local.full_name", "local.prefix"}},
+		{"var.", []string{"var.name"}},
+		{"module.", []string{"module.child.address"}},
+		{"test_", []string{"test_instance.foo"}},
+		{"test_instance.foo.", []string{"test_instance.foo.id", "test_instance.foo.tags"}},
+		{"uppe", []string{"upper("}},
+		{"path.r", []string{"path.root"}},
+		{"nothing", []string{}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Prefix, func(t *testing.T) {
+			got := s.Completions(tc.Prefix)
+			if !reflect.DeepEqual(got, tc.Want) {
+				t.Fatalf("wrong completions for %q\ngot:  %#v\nwant: %#v", tc.Prefix, got, tc.Want)
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package repl
+
+// Incomplete returns true if input is the beginning of an interpolation
+// that continues on the next line, because it has unclosed brackets,
+// parentheses or braces, or an unterminated string.
+func Incomplete(input string) bool {
+	depth := 0
+	inString := false
+	escaped := false
+
+	for _, r := range input {
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case r == '\\':
+				escaped = true
+			case r == '"':
+				inString = false
+			}
+			continue
+		}
+
+		switch r {
+		case '"':
+			inString = true
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+		}
+	}
+
+	return inString || depth > 0
+}
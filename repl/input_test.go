@@ -0,0 +1,32 @@
+package repl
+
+import (
+	"testing"
+)
+
+func TestIncomplete(t *testing.T) {
+	cases := []struct {
+		Input string
+		Want  bool
+	}{
+		{"", false},
+		{"1 + 5", false},
+		{"list(", true},
+		{"list(\n\"a\",", true},
+		{"list(\n\"a\"\n)", false},
+		{"map(\"a\", \"b\")", false},
+		{"var.list[", true},
+		{"\"abc", true},
+		{"\"(\"", false},
+		{"\"a\\\"(\"", false},
+		{"\"${var.foo\"", false},
+		{"{", true},
+		{")", false},
+	}
+
+	for _, tc := range cases {
+		if got := Incomplete(tc.Input); got != tc.Want {
+			t.Errorf("Incomplete(%q) = %t, want %t", tc.Input, got, tc.Want)
+		}
+	}
+}
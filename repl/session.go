@@ -17,6 +17,9 @@ var ErrSessionExit = errors.New("session exit")
 type Session struct {
 	// Interpolater is used for calculating interpolations
 	Interpolater *terraform.Interpolater
+
+	// localsBuilt is set once the local values have been evaluated.
+	localsBuilt bool
 }
 
 // Handle handles a single line of input from the REPL.
@@ -37,6 +40,11 @@ func (s *Session) Handle(line string) (string, error) {
 }
 
 func (s *Session) handleEval(line string) (string, error) {
+	if !s.localsBuilt {
+		s.buildLocals()
+		s.localsBuilt = true
+	}
+
 	// Wrap the line to make it an interpolation.
 	line = fmt.Sprintf("${%s}", line)
 
@@ -51,16 +59,7 @@ func (s *Session) handleEval(line string) (string, error) {
 	// Set the value
 	raw.Key = "value"
 
-	// Get the values
-	vars, err := s.Interpolater.Values(&terraform.InterpolationScope{
-		Path: []string{"root"},
-	}, raw.Variables)
-	if err != nil {
-		return "", err
-	}
-
-	// Interpolate
-	if err := raw.Interpolate(vars); err != nil {
+	if err := s.interpolate(raw); err != nil {
 		return "", err
 	}
 
@@ -78,6 +77,68 @@ func (s *Session) handleEval(line string) (string, error) {
 	return result, nil
 }
 
+// interpolate interpolates raw in the scope of the root module.
+func (s *Session) interpolate(raw *config.RawConfig) error {
+	// Get the values
+	vars, err := s.Interpolater.Values(&terraform.InterpolationScope{
+		Path: []string{"root"},
+	}, raw.Variables)
+	if err != nil {
+		return err
+	}
+
+	funcs, err := s.Interpolater.Funcs(raw)
+	if err != nil {
+		return err
+	}
+
+	// Interpolate
+	return raw.InterpolateWithFuncs(vars, funcs)
+}
+
+// buildLocals evaluates the local values of the root module into the state
+// of the interpolater, as walking the graph would, since they aren't stored
+// in the state. Local values can refer to each other, so they're evaluated
+// over several passes until no more can be. Those that can't be evaluated,
+// such as those depending on resources that don't exist yet, remain unknown.
+func (s *Session) buildLocals() {
+	i := s.Interpolater
+	if i.Module == nil || i.State == nil || len(i.Module.Config().Locals) == 0 {
+		return
+	}
+
+	i.StateLock.Lock()
+	mod := i.State.ModuleByPath(terraform.RootModulePath)
+	if mod == nil {
+		mod = i.State.AddModule(terraform.RootModulePath)
+	}
+	if mod.Locals == nil {
+		mod.Locals = map[string]interface{}{}
+	}
+	i.StateLock.Unlock()
+
+	pending := i.Module.Config().Locals
+	for len(pending) > 0 {
+		var next []*config.Local
+		for _, l := range pending {
+			raw := l.RawConfig.Copy()
+			if err := s.interpolate(raw); err != nil || len(raw.UnknownKeys()) > 0 {
+				next = append(next, l)
+				continue
+			}
+
+			i.StateLock.Lock()
+			mod.Locals[l.Name] = raw.Config()["value"]
+			i.StateLock.Unlock()
+		}
+
+		if len(next) == len(pending) {
+			break
+		}
+		pending = next
+	}
+}
+
 func (s *Session) handleHelp() (string, error) {
 	text := `
 The Terraform console allows you to experiment with Terraform interpolations.
@@ -85,7 +146,9 @@ You may access resources in the state (if you have one) just as you would
 from a configuration. For example: "aws_instance.foo.id" would evaluate
 to the ID of "aws_instance.foo" if it exists in your state.
 
-Type in the interpolation to test and hit <enter> to see the result.
+Type in the interpolation to test and hit <enter> to see the result. An
+interpolation can span several lines while its brackets or quotes are open,
+and <tab> completes the names of resources, variables and functions.
 
 To exit the console, type "exit" and hit <enter>, or use Control-C or
 Control-D.
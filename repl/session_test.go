@@ -1,6 +1,9 @@
 package repl
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -166,11 +169,48 @@ func TestSession_stateless(t *testing.T) {
 	})
 }
 
+func TestSession_locals(t *testing.T) {
+	testSession(t, testSessionTest{
+		Module: "locals",
+		Inputs: []testSessionInput{
+			{
+				Input:  "local.prefix",
+				Output: "prod",
+			},
+			{
+				Input:  "local.full_name",
+				Output: "prod-web",
+			},
+			{
+				Input:         "local.missing",
+				Error:         true,
+				ErrorContains: "local.missing",
+			},
+		},
+	})
+}
+
+func TestSession_multiLine(t *testing.T) {
+	testSession(t, testSessionTest{
+		Inputs: []testSessionInput{
+			{
+				Input:  "list(\n  \"a\",\n  \"b\"\n)",
+				Output: "[\n  a,\n  b\n]",
+			},
+		},
+	})
+}
+
 func testSession(t *testing.T, test testSessionTest) {
+	mod := module.NewEmptyTree()
+	if test.Module != "" {
+		mod = testModule(t, test.Module)
+	}
+
 	// Build the TF context
 	ctx, err := terraform.NewContext(&terraform.ContextOpts{
 		State:  test.State,
-		Module: module.NewEmptyTree(),
+		Module: mod,
 	})
 	if err != nil {
 		t.Fatalf("err: %s", err)
@@ -232,3 +272,28 @@ type testSessionInput struct {
 	Error          bool // Error is true if error is expected
 	ErrorContains  string
 }
+
+func testModule(t *testing.T, name string) *module.Tree {
+	t.Helper()
+
+	mod, err := module.NewTreeModule("", filepath.Join("test-fixtures", name))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	dir, err := ioutil.TempDir("", "tf-repl")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	s := &module.Storage{
+		StorageDir: dir,
+		Mode:       module.GetModeGet,
+	}
+	if err := mod.Load(s); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	return mod
+}
@@ -0,0 +1,12 @@
+variable "name" {
+  default = "web"
+}
+
+locals {
+  full_name = "${local.prefix}-${var.name}"
+  prefix    = "prod"
+}
+
+resource "test_instance" "foo" {
+  name = "${local.full_name}"
+}
@@ -0,0 +1,190 @@
+package remote
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/terraform/state"
+)
+
+// EnvStateCacheDir is the environment variable naming the directory that
+// remote states are cached in. Caching is disabled if it isn't set.
+const EnvStateCacheDir = "TF_STATE_CACHE_DIR"
+
+// NewCachedClient returns a client that caches the states read by c in the
+// directory named by EnvStateCacheDir, with key identifying the state
+// among all those cached, such as its URL. If caching is disabled, or c
+// can't read states conditionally, c is returned as-is.
+func NewCachedClient(c Client, key string) Client {
+	dir := os.Getenv(EnvStateCacheDir)
+	if dir == "" {
+		return c
+	}
+
+	cg, ok := c.(ClientConditionalGetter)
+	if !ok {
+		return c
+	}
+
+	sum := sha256.Sum256([]byte(key))
+	return &CachedClient{
+		ClientConditionalGetter: cg,
+		Path:                    filepath.Join(dir, hex.EncodeToString(sum[:])+".tfstate"),
+	}
+}
+
+// CachedClient is a Client that keeps the last state it read in a local
+// file, and downloads the state again only if it has changed since.
+//
+// Any write or delete through the client discards the cached state, since
+// the version of what was written isn't known until it's read again.
+type CachedClient struct {
+	ClientConditionalGetter
+
+	// Path is the file the cached state is kept in. It holds the version of
+	// the state on its first line, followed by the state itself.
+	Path string
+}
+
+func (c *CachedClient) Get() (*Payload, error) {
+	cached, err := c.readCache()
+	if err != nil {
+		log.Printf("[WARN] ignoring unreadable state cache %s: %s", c.Path, err)
+	}
+	if cached == nil {
+		return c.refresh(c.ClientConditionalGetter.Get())
+	}
+
+	payload, err := c.GetIfChanged(cached.Version)
+	if err == ErrNotModified {
+		log.Printf("[DEBUG] remote state not modified, using cache %s", c.Path)
+		return cached, nil
+	}
+	return c.refresh(payload, err)
+}
+
+func (c *CachedClient) Put(data []byte) error {
+	c.discard()
+	return c.ClientConditionalGetter.Put(data)
+}
+
+func (c *CachedClient) Delete() error {
+	c.discard()
+	return c.ClientConditionalGetter.Delete()
+}
+
+// Lock calls the wrapped client's Lock method if it's implemented.
+func (c *CachedClient) Lock(info *state.LockInfo) (string, error) {
+	if l, ok := c.ClientConditionalGetter.(ClientLocker); ok {
+		return l.Lock(info)
+	}
+	return "", nil
+}
+
+// Unlock calls the wrapped client's Unlock method if it's implemented.
+func (c *CachedClient) Unlock(id string) error {
+	if l, ok := c.ClientConditionalGetter.(ClientLocker); ok {
+		return l.Unlock(id)
+	}
+	return nil
+}
+
+// refresh caches a payload freshly read from the client.
+func (c *CachedClient) refresh(payload *Payload, err error) (*Payload, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	if payload == nil || payload.Version == "" {
+		c.discard()
+		return payload, nil
+	}
+
+	if err := c.writeCache(payload); err != nil {
+		log.Printf("[WARN] failed to cache remote state in %s: %s", c.Path, err)
+		c.discard()
+	}
+	return payload, nil
+}
+
+// readCache returns the cached payload, or nil if there is none.
+func (c *CachedClient) readCache() (*Payload, error) {
+	f, err := os.Open(c.Path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	version, err := r.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("missing state version")
+	}
+	version = strings.TrimSuffix(version, "\n")
+	if version == "" {
+		return nil, fmt.Errorf("missing state version")
+	}
+
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, r); err != nil {
+		return nil, err
+	}
+
+	sum := md5.Sum(buf.Bytes())
+	return &Payload{
+		Data:    buf.Bytes(),
+		MD5:     sum[:],
+		Version: version,
+	}, nil
+}
+
+// writeCache replaces the cached payload. The state is written to a
+// temporary file first, so that an interrupted write can't leave a
+// truncated state behind.
+func (c *CachedClient) writeCache(payload *Payload) error {
+	if strings.ContainsAny(payload.Version, "\r\n") {
+		return fmt.Errorf("invalid state version %q", payload.Version)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.Path), 0700); err != nil {
+		return err
+	}
+
+	f, err := ioutil.TempFile(filepath.Dir(c.Path), filepath.Base(c.Path))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	_, err = fmt.Fprintf(f, "%s\n", payload.Version)
+	if err == nil {
+		_, err = f.Write(payload.Data)
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), c.Path)
+}
+
+func (c *CachedClient) discard() {
+	if err := os.Remove(c.Path); err != nil && !os.IsNotExist(err) {
+		log.Printf("[WARN] failed to remove state cache %s: %s", c.Path, err)
+	}
+}
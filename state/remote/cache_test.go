@@ -0,0 +1,132 @@
+package remote
+
+import (
+	"crypto/md5"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCachedClient(t *testing.T) {
+	td, err := ioutil.TempDir("", "tf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(td)
+
+	inner := &versionedClient{}
+	c := &CachedClient{
+		ClientConditionalGetter: inner,
+		Path:                    filepath.Join(td, "cache", "state"),
+	}
+	testClient(t, c)
+	inner.downloads = 0
+
+	if err := inner.Put([]byte("first")); err != nil {
+		t.Fatal(err)
+	}
+
+	// The first read downloads the state, the next ones use the cache.
+	for i := 0; i < 3; i++ {
+		p, err := c.Get()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(p.Data) != "first" || p.Version != "2" {
+			t.Fatalf("bad: %#v", p)
+		}
+		if sum := md5.Sum(p.Data); string(p.MD5) != string(sum[:]) {
+			t.Fatalf("bad md5: %x", p.MD5)
+		}
+	}
+	if inner.downloads != 1 {
+		t.Fatalf("expected 1 download, got %d", inner.downloads)
+	}
+
+	// A changed state is downloaded again.
+	if err := inner.Put([]byte("second")); err != nil {
+		t.Fatal(err)
+	}
+	p, err := c.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(p.Data) != "second" || inner.downloads != 2 {
+		t.Fatalf("bad: %#v after %d downloads", p, inner.downloads)
+	}
+
+	// Writing through the client discards the cache.
+	if err := c.Put([]byte("third")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(c.Path); !os.IsNotExist(err) {
+		t.Fatalf("expected the cache to be removed, got %v", err)
+	}
+	p, err = c.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(p.Data) != "third" || inner.downloads != 3 {
+		t.Fatalf("bad: %#v after %d downloads", p, inner.downloads)
+	}
+}
+
+func TestNewCachedClient(t *testing.T) {
+	defer os.Setenv(EnvStateCacheDir, os.Getenv(EnvStateCacheDir))
+
+	os.Setenv(EnvStateCacheDir, "")
+	if _, ok := NewCachedClient(&versionedClient{}, "key").(*versionedClient); !ok {
+		t.Fatal("expected the client to be returned")
+	}
+
+	os.Setenv(EnvStateCacheDir, "cache")
+	if _, ok := NewCachedClient(nilClient{}, "key").(nilClient); !ok {
+		t.Fatal("expected the client to be returned")
+	}
+
+	a := NewCachedClient(&versionedClient{}, "a").(*CachedClient)
+	b := NewCachedClient(&versionedClient{}, "b").(*CachedClient)
+	if filepath.Dir(a.Path) != "cache" || a.Path == b.Path {
+		t.Fatalf("bad cache paths %q and %q", a.Path, b.Path)
+	}
+}
+
+// versionedClient is an in-memory client that numbers each write as its
+// version and counts the downloads of the state.
+type versionedClient struct {
+	data      []byte
+	version   int
+	downloads int
+}
+
+func (c *versionedClient) Get() (*Payload, error) {
+	return c.GetIfChanged("")
+}
+
+func (c *versionedClient) GetIfChanged(version string) (*Payload, error) {
+	if c.data == nil {
+		return nil, nil
+	}
+
+	v := fmt.Sprint(c.version)
+	if v == version {
+		return nil, ErrNotModified
+	}
+
+	c.downloads++
+	sum := md5.Sum(c.data)
+	return &Payload{Data: c.data, MD5: sum[:], Version: v}, nil
+}
+
+func (c *versionedClient) Put(data []byte) error {
+	c.data = data
+	c.version++
+	return nil
+}
+
+func (c *versionedClient) Delete() error {
+	c.data = nil
+	return nil
+}
@@ -1,6 +1,7 @@
 package remote
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/hashicorp/terraform/state"
@@ -22,10 +23,30 @@ type ClientLocker interface {
 	state.Locker
 }
 
+// ClientConditionalGetter is an optional interface that allows a remote
+// state client to skip downloading a state that hasn't changed since it was
+// last read.
+type ClientConditionalGetter interface {
+	Client
+
+	// GetIfChanged is like Get, but returns ErrNotModified if the stored
+	// state still has the given version, as reported in an earlier Payload.
+	GetIfChanged(version string) (*Payload, error)
+}
+
+// ErrNotModified is returned by GetIfChanged when the stored state hasn't
+// changed.
+var ErrNotModified = errors.New("remote state not modified")
+
 // Payload is the return value from the remote state storage.
 type Payload struct {
 	MD5  []byte
 	Data []byte
+
+	// Version identifies this revision of the stored state for
+	// ClientConditionalGetter, such as an HTTP ETag. It's empty if the
+	// client can't read the state conditionally.
+	Version string
 }
 
 // Factory is the factory function to create a remote client.
@@ -47,6 +68,5 @@ func NewClient(t string, conf map[string]string) (Client, error) {
 var BuiltinClients = map[string]Factory{
 	"artifactory": artifactoryFactory,
 	"etcd":        etcdFactory,
-	"http":        httpFactory,
 	"local":       fileFactory,
 }
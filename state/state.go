@@ -135,6 +135,28 @@ func LockWithContext(ctx context.Context, s State, info *LockInfo) (string, erro
 	}
 }
 
+// InspectLock returns the info of the lock currently held on the given
+// state, or nil if it isn't locked.
+//
+// Lockers can only report the lock they conflict with, so this tries to take
+// the lock with the given info, and releases it straight away if that
+// succeeds. If the state is locked but the locker doesn't report the info of
+// the lock, the LockError is returned.
+func InspectLock(s Locker, info *LockInfo) (*LockInfo, error) {
+	id, err := s.Lock(info)
+	if err == nil {
+		if err := s.Unlock(id); err != nil {
+			return nil, fmt.Errorf("failed to release the lock taken to inspect the state: %s", err)
+		}
+		return nil, nil
+	}
+
+	if le, ok := err.(*LockError); ok && le.Info != nil && le.Info.ID != "" {
+		return le.Info, nil
+	}
+	return nil, err
+}
+
 // Generate a LockInfo structure, populating the required fields.
 func NewLockInfo() *LockInfo {
 	// this doesn't need to be cryptographically secure, just unique.
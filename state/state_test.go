@@ -108,3 +108,33 @@ func TestLockWithContext(t *testing.T) {
 		t.Fatalf("lock only called %d times", s.lockCounter)
 	}
 }
+
+func TestInspectLock(t *testing.T) {
+	s := &inmemLocker{InmemState: &InmemState{state: TestStateInitial()}}
+
+	// An unlocked state is left unlocked.
+	info, err := InspectLock(s, NewLockInfo())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info != nil {
+		t.Fatalf("got lock info %s for an unlocked state", info)
+	}
+	if s.lockInfo != nil {
+		t.Fatal("the state was left locked")
+	}
+
+	held := NewLockInfo()
+	held.Operation = "test"
+	if _, err := s.Lock(held); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err = InspectLock(s, NewLockInfo())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info == nil || info.ID != held.ID || info.Operation != "test" {
+		t.Fatalf("wrong lock info %v; want %v", info, held)
+	}
+}
@@ -43,3 +43,18 @@ func (s *cachingCredentialsSource) ForHost(host svchost.Hostname) (HostCredentia
 	s.cache[host] = result
 	return result, nil
 }
+
+// StoreForHost passes the given arguments on to the wrapped credentials
+// source, discarding any cached result for the host so that the new
+// credentials are used for future requests.
+func (s *cachingCredentialsSource) StoreForHost(host svchost.Hostname, credentials HostCredentialsWritable) error {
+	delete(s.cache, host)
+	return s.source.StoreForHost(host, credentials)
+}
+
+// ForgetForHost passes the given hostname on to the wrapped credentials
+// source, discarding any cached result for the host.
+func (s *cachingCredentialsSource) ForgetForHost(host svchost.Hostname) error {
+	delete(s.cache, host)
+	return s.source.ForgetForHost(host)
+}
@@ -3,6 +3,7 @@
 package auth
 
 import (
+	"fmt"
 	"net/http"
 
 	"github.com/hashicorp/terraform/svchost"
@@ -33,6 +34,16 @@ type CredentialsSource interface {
 	// If an error is returned, progress through a list of CredentialsSources
 	// is halted and the error is returned to the user.
 	ForHost(host svchost.Hostname) (HostCredentials, error)
+
+	// StoreForHost saves the given credentials as the credentials for the
+	// given host, replacing any that were saved before, or returns an error
+	// if the source can't save credentials.
+	StoreForHost(host svchost.Hostname, credentials HostCredentialsWritable) error
+
+	// ForgetForHost discards any credentials saved for the given host, or
+	// returns an error if the source can't discard credentials. It isn't an
+	// error if there are no credentials for the host.
+	ForgetForHost(host svchost.Hostname) error
 }
 
 // HostCredentials represents a single set of credentials for a particular
@@ -44,6 +55,16 @@ type HostCredentials interface {
 	PrepareRequest(req *http.Request)
 }
 
+// HostCredentialsWritable is a HostCredentials that can be saved by a
+// CredentialsSource.
+type HostCredentialsWritable interface {
+	HostCredentials
+
+	// ToStore returns the map form of the credentials, as understood by
+	// HostCredentialsFromMap, for saving them.
+	ToStore() map[string]interface{}
+}
+
 // ForHost iterates over the contained CredentialsSource objects and
 // tries to obtain credentials for the given host from each one in turn.
 //
@@ -58,3 +79,25 @@ func (c Credentials) ForHost(host svchost.Hostname) (HostCredentials, error) {
 	}
 	return nil, nil
 }
+
+// StoreForHost passes the given arguments to the same operation on the
+// first CredentialsSource in the receiver, which is the one that takes
+// precedence when credentials are looked up.
+func (c Credentials) StoreForHost(host svchost.Hostname, credentials HostCredentialsWritable) error {
+	if len(c) == 0 {
+		return fmt.Errorf("no credentials store is available")
+	}
+	return c[0].StoreForHost(host, credentials)
+}
+
+// ForgetForHost passes the given arguments to the same operation on each of
+// the CredentialsSource objects in the receiver in turn, so that none of
+// them has credentials for the host afterwards, stopping at the first error.
+func (c Credentials) ForgetForHost(host svchost.Hostname) error {
+	for _, source := range c {
+		if err := source.ForgetForHost(host); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -26,7 +26,12 @@ type helperProgramCredentialsSource struct {
 // When credentials are requested, the program will be run in a child process
 // with the given arguments along with two additional arguments added to the
 // end of the list: the literal string "get", followed by the requested
-// hostname in ASCII compatibility form (punycode form).
+// hostname in ASCII compatibility form (punycode form). The program writes
+// the credentials to stdout as a JSON object.
+//
+// Credentials are stored by running the program with the arguments "store"
+// and the hostname, writing the credentials to its stdin as a JSON object,
+// and discarded by running it with the arguments "forget" and the hostname.
 func HelperProgramCredentialsSource(executable string, args ...string) CredentialsSource {
 	if !filepath.IsAbs(executable) {
 		panic("NewCredentialsSourceHelperProgram requires absolute path to executable")
@@ -43,10 +48,46 @@ func HelperProgramCredentialsSource(executable string, args ...string) Credentia
 }
 
 func (s *helperProgramCredentialsSource) ForHost(host svchost.Hostname) (HostCredentials, error) {
-	args := make([]string, len(s.args), len(s.args)+2)
+	out, err := s.run(nil, "get", string(host))
+	if err != nil {
+		return nil, err
+	}
+
+	var m map[string]interface{}
+	err = json.Unmarshal(out, &m)
+	if err != nil {
+		return nil, fmt.Errorf("malformed output from %s: %s", s.executable, err)
+	}
+
+	return HostCredentialsFromMap(m), nil
+}
+
+// StoreForHost runs the program with the arguments "store" and the hostname,
+// writing the JSON form of the credentials to its stdin.
+func (s *helperProgramCredentialsSource) StoreForHost(host svchost.Hostname, credentials HostCredentialsWritable) error {
+	src, err := json.Marshal(credentials.ToStore())
+	if err != nil {
+		return fmt.Errorf("failed to serialize the credentials: %s", err)
+	}
+
+	_, err = s.run(src, "store", string(host))
+	return err
+}
+
+// ForgetForHost runs the program with the arguments "forget" and the
+// hostname.
+func (s *helperProgramCredentialsSource) ForgetForHost(host svchost.Hostname) error {
+	_, err := s.run(nil, "forget", string(host))
+	return err
+}
+
+// run runs the program with the configured arguments followed by the given
+// ones, and returns what it wrote to stdout. If stdin isn't nil, it's
+// written to the program's stdin.
+func (s *helperProgramCredentialsSource) run(stdin []byte, extraArgs ...string) ([]byte, error) {
+	args := make([]string, len(s.args), len(s.args)+len(extraArgs))
 	copy(args, s.args)
-	args = append(args, "get")
-	args = append(args, string(host))
+	args = append(args, extraArgs...)
 
 	outBuf := bytes.Buffer{}
 	errBuf := bytes.Buffer{}
@@ -54,10 +95,12 @@ func (s *helperProgramCredentialsSource) ForHost(host svchost.Hostname) (HostCre
 	cmd := exec.Cmd{
 		Path:   s.executable,
 		Args:   args,
-		Stdin:  nil,
 		Stdout: &outBuf,
 		Stderr: &errBuf,
 	}
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
 	err := cmd.Run()
 	if _, isExitErr := err.(*exec.ExitError); isExitErr {
 		errText := errBuf.String()
@@ -70,11 +113,5 @@ func (s *helperProgramCredentialsSource) ForHost(host svchost.Hostname) (HostCre
 		return nil, fmt.Errorf("failed to run %s: %s", s.executable, err)
 	}
 
-	var m map[string]interface{}
-	err = json.Unmarshal(outBuf.Bytes(), &m)
-	if err != nil {
-		return nil, fmt.Errorf("malformed output from %s: %s", s.executable, err)
-	}
-
-	return HostCredentialsFromMap(m), nil
+	return outBuf.Bytes(), nil
 }
@@ -1,6 +1,8 @@
 package auth
 
 import (
+	"fmt"
+
 	"github.com/hashicorp/terraform/svchost"
 )
 
@@ -26,3 +28,16 @@ func (s staticCredentialsSource) ForHost(host svchost.Hostname) (HostCredentials
 
 	return nil, nil
 }
+
+func (s staticCredentialsSource) StoreForHost(host svchost.Hostname, credentials HostCredentialsWritable) error {
+	return fmt.Errorf("can't store new credentials in a static credentials source")
+}
+
+// ForgetForHost returns an error if the source has credentials for the
+// given host, since they can't be discarded, and nil otherwise.
+func (s staticCredentialsSource) ForgetForHost(host svchost.Hostname) error {
+	if _, exists := s[host]; exists {
+		return fmt.Errorf("can't discard credentials from a static credentials source")
+	}
+	return nil
+}
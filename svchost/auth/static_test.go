@@ -35,4 +35,22 @@ func TestStaticCredentialsSource(t *testing.T) {
 			t.Errorf("creds is %#v; want nil", creds)
 		}
 	})
+	t.Run("store", func(t *testing.T) {
+		err := src.StoreForHost(svchost.Hostname("example.com"), HostCredentialsToken("def456"))
+		if err == nil {
+			t.Error("completed successfully; want error")
+		}
+	})
+	t.Run("forget existing", func(t *testing.T) {
+		err := src.ForgetForHost(svchost.Hostname("example.com"))
+		if err == nil {
+			t.Error("completed successfully; want error")
+		}
+	})
+	t.Run("forget missing", func(t *testing.T) {
+		err := src.ForgetForHost(svchost.Hostname("example.net"))
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
 }
@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
 )
 
@@ -15,21 +16,39 @@ func main() {
 		die("not enough arguments\n")
 	}
 
-	if args[1] != "get" {
-		die("unknown subcommand %q\n", args[1])
-	}
-
 	host := args[2]
 
-	switch host {
-	case "example.com":
-		fmt.Print(`{"token":"example-token"}`)
-	case "other-cred-type.example.com":
-		fmt.Print(`{"username":"alfred"}`) // unrecognized by main program
-	case "fail.example.com":
-		die("failing because you told me to fail\n")
+	switch args[1] {
+	case "get":
+		switch host {
+		case "example.com":
+			fmt.Print(`{"token":"example-token"}`)
+		case "other-cred-type.example.com":
+			fmt.Print(`{"username":"alfred"}`) // unrecognized by main program
+		case "fail.example.com":
+			die("failing because you told me to fail\n")
+		default:
+			fmt.Print("{}") // no credentials available
+		}
+	case "store":
+		src, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			die("failed to read stdin: %s\n", err)
+		}
+		switch host {
+		case "example.com":
+			if got, want := string(src), `{"token":"example-token"}`; got != want {
+				die("wrong credentials %s; want %s\n", got, want)
+			}
+		case "fail.example.com":
+			die("failing because you told me to fail\n")
+		}
+	case "forget":
+		if host == "fail.example.com" {
+			die("failing because you told me to fail\n")
+		}
 	default:
-		fmt.Print("{}") // no credentials available
+		die("unknown subcommand %q\n", args[1])
 	}
 }
 
@@ -9,6 +9,8 @@ import (
 // with the auth type set to "Bearer"
 type HostCredentialsToken string
 
+var _ HostCredentialsWritable = HostCredentialsToken("")
+
 // PrepareRequest alters the given HTTP request by setting its Authorization
 // header to the string "Bearer " followed by the encapsulated authentication
 // token.
@@ -18,3 +20,11 @@ func (tc HostCredentialsToken) PrepareRequest(req *http.Request) {
 	}
 	req.Header.Set("Authorization", "Bearer "+string(tc))
 }
+
+// ToStore returns the token in the map form understood by
+// HostCredentialsFromMap.
+func (tc HostCredentialsToken) ToStore() map[string]interface{} {
+	return map[string]interface{}{
+		"token": string(tc),
+	}
+}
@@ -30,6 +30,13 @@ func (h Host) ServiceURL(id string) *url.URL {
 		return nil
 	}
 
+	return h.resolveURL(urlStr)
+}
+
+// resolveURL parses the given URL from the discovery document, making it
+// absolute using the discovery document URL. It returns nil if the URL is
+// invalid, isn't an http or https URL, or has embedded credentials.
+func (h Host) resolveURL(urlStr string) *url.URL {
 	ret, err := url.Parse(urlStr)
 	if err != nil {
 		return nil
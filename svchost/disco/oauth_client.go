@@ -0,0 +1,181 @@
+package disco
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// OAuthGrantTypeAuthzCode is the grant type of the OAuth authorization code
+// flow, which is the only one that Terraform currently supports.
+const OAuthGrantTypeAuthzCode = "authz_code"
+
+// OAuthClient describes an OAuth client that a host allows Terraform to act
+// as in order to obtain credentials for the host, as published by services
+// such as "login.v1".
+type OAuthClient struct {
+	// ID is the client identifier to send to the authorization server.
+	ID string
+
+	// AuthorizationURL is the URL of the authorization endpoint, which the
+	// user visits in a web browser, and TokenURL the URL of the token
+	// endpoint, which exchanges an authorization code for a token.
+	AuthorizationURL *url.URL
+	TokenURL         *url.URL
+
+	// MinPort and MaxPort are the range of the ports that the host allows
+	// the redirection endpoint on localhost to listen on.
+	MinPort, MaxPort uint16
+
+	// GrantTypes are the OAuth grant types that the host supports.
+	GrantTypes []string
+}
+
+// SupportsGrantType returns true if the host supports the given grant type.
+func (c *OAuthClient) SupportsGrantType(t string) bool {
+	for _, gt := range c.GrantTypes {
+		if gt == t {
+			return true
+		}
+	}
+	return false
+}
+
+// ServiceOAuthClient returns the OAuth client associated with the given
+// service identifier, which should be of the form "servicename.vN".
+//
+// The discovery document entry of such a service is an object such as:
+//
+//	{
+//	  "client": "terraform-cli",
+//	  "grant_types": ["authz_code"],
+//	  "authz": "/oauth/authorization",
+//	  "token": "/oauth/token",
+//	  "ports": [10000, 10010]
+//	}
+//
+// Relative URLs are resolved against the discovery document URL, the grant
+// types default to the authorization code flow only, and the ports default
+// to any port from 1024 upwards.
+//
+// If the requested service is not supported by the host, this method returns
+// nil and no error. If its entry is invalid, it returns an error.
+func (h Host) ServiceOAuthClient(id string) (*OAuthClient, error) {
+	if h.services == nil {
+		return nil, nil
+	}
+
+	raw, ok := h.services[id]
+	if !ok {
+		return nil, nil
+	}
+
+	// Services forced in the CLI configuration are decoded from HCL, which
+	// gives a list of one map for a nested object.
+	if l, ok := raw.([]map[string]interface{}); ok && len(l) == 1 {
+		raw = l[0]
+	}
+	m, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("service %s must be an object", id)
+	}
+
+	ret := &OAuthClient{
+		MinPort:    1024,
+		MaxPort:    65535,
+		GrantTypes: []string{OAuthGrantTypeAuthzCode},
+	}
+
+	if ret.ID, ok = m["client"].(string); !ok || ret.ID == "" {
+		return nil, fmt.Errorf("service %s must have a client ID in \"client\"", id)
+	}
+
+	for _, k := range []string{"authz", "token"} {
+		s, ok := m[k].(string)
+		if !ok {
+			return nil, fmt.Errorf("service %s must have a URL in %q", id, k)
+		}
+		u := h.resolveURL(s)
+		if u == nil {
+			return nil, fmt.Errorf("service %s has an invalid URL in %q", id, k)
+		}
+		if k == "authz" {
+			ret.AuthorizationURL = u
+		} else {
+			ret.TokenURL = u
+		}
+	}
+
+	if rawPorts, exists := m["ports"]; exists {
+		ports, ok := toList(rawPorts)
+		if !ok || len(ports) != 2 {
+			return nil, fmt.Errorf("service %s must have a list of two ports in \"ports\"", id)
+		}
+		var bounds [2]uint16
+		for i, p := range ports {
+			n, ok := toPort(p)
+			if !ok {
+				return nil, fmt.Errorf("service %s has an invalid port %v in \"ports\"", id, p)
+			}
+			bounds[i] = n
+		}
+		if bounds[0] > bounds[1] {
+			return nil, fmt.Errorf("service %s has an empty range of ports in \"ports\"", id)
+		}
+		ret.MinPort, ret.MaxPort = bounds[0], bounds[1]
+	}
+
+	if rawTypes, exists := m["grant_types"]; exists {
+		types, ok := toList(rawTypes)
+		if !ok || len(types) == 0 {
+			return nil, fmt.Errorf("service %s must have a list of grant types in \"grant_types\"", id)
+		}
+		ret.GrantTypes = nil
+		for _, t := range types {
+			s, ok := t.(string)
+			if !ok {
+				return nil, fmt.Errorf("service %s has an invalid grant type %v in \"grant_types\"", id, t)
+			}
+			ret.GrantTypes = append(ret.GrantTypes, s)
+		}
+	}
+
+	return ret, nil
+}
+
+// toList returns the elements of a list decoded from either JSON or HCL.
+func toList(v interface{}) ([]interface{}, bool) {
+	switch v := v.(type) {
+	case []interface{}:
+		return v, true
+	case []string:
+		l := make([]interface{}, len(v))
+		for i, s := range v {
+			l[i] = s
+		}
+		return l, true
+	case []int:
+		l := make([]interface{}, len(v))
+		for i, n := range v {
+			l[i] = n
+		}
+		return l, true
+	}
+	return nil, false
+}
+
+// toPort returns the port number decoded from either JSON or HCL.
+func toPort(v interface{}) (uint16, bool) {
+	var n float64
+	switch v := v.(type) {
+	case float64:
+		n = v
+	case int:
+		n = float64(v)
+	default:
+		return 0, false
+	}
+	if n != float64(int(n)) || n < 1024 || n > 65535 {
+		return 0, false
+	}
+	return uint16(n), true
+}
@@ -0,0 +1,112 @@
+package disco
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestHostServiceOAuthClient(t *testing.T) {
+	baseURL, _ := url.Parse("https://example.com/disco/foo.json")
+	host := Host{
+		discoURL: baseURL,
+		services: map[string]interface{}{
+			"explicit.v1": map[string]interface{}{
+				"client":      "explicitclient",
+				"authz":       "https://example.com/authz",
+				"token":       "https://example.com/token",
+				"ports":       []interface{}{float64(1025), float64(1026)},
+				"grant_types": []interface{}{"authz_code", "password"},
+			},
+			"relative.v1": map[string]interface{}{
+				"client": "relativeclient",
+				"authz":  "/authz",
+				"token":  "../token",
+			},
+			"hcl.v1": []map[string]interface{}{
+				{
+					"client": "hclclient",
+					"authz":  "/authz",
+					"token":  "/token",
+					"ports":  []interface{}{2000, 3000},
+				},
+			},
+			"url.v1":        "https://example.com/",
+			"noclient.v1":   map[string]interface{}{"authz": "/authz", "token": "/token"},
+			"notoken.v1":    map[string]interface{}{"client": "c", "authz": "/authz"},
+			"badurl.v1":     map[string]interface{}{"client": "c", "authz": "ftp://example.com/", "token": "/token"},
+			"badports.v1":   map[string]interface{}{"client": "c", "authz": "/authz", "token": "/token", "ports": []interface{}{float64(80), float64(90)}},
+			"emptyports.v1": map[string]interface{}{"client": "c", "authz": "/authz", "token": "/token", "ports": []interface{}{float64(3000), float64(2000)}},
+		},
+	}
+
+	mustURL := func(s string) *url.URL {
+		u, err := url.Parse(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return u
+	}
+
+	tests := []struct {
+		ID      string
+		Want    *OAuthClient
+		WantErr bool
+	}{
+		{
+			"explicit.v1",
+			&OAuthClient{
+				ID:               "explicitclient",
+				AuthorizationURL: mustURL("https://example.com/authz"),
+				TokenURL:         mustURL("https://example.com/token"),
+				MinPort:          1025,
+				MaxPort:          1026,
+				GrantTypes:       []string{"authz_code", "password"},
+			},
+			false,
+		},
+		{
+			"relative.v1",
+			&OAuthClient{
+				ID:               "relativeclient",
+				AuthorizationURL: mustURL("https://example.com/authz"),
+				TokenURL:         mustURL("https://example.com/token"),
+				MinPort:          1024,
+				MaxPort:          65535,
+				GrantTypes:       []string{"authz_code"},
+			},
+			false,
+		},
+		{
+			"hcl.v1",
+			&OAuthClient{
+				ID:               "hclclient",
+				AuthorizationURL: mustURL("https://example.com/authz"),
+				TokenURL:         mustURL("https://example.com/token"),
+				MinPort:          2000,
+				MaxPort:          3000,
+				GrantTypes:       []string{"authz_code"},
+			},
+			false,
+		},
+		{"missing.v1", nil, false},
+		{"url.v1", nil, true},
+		{"noclient.v1", nil, true},
+		{"notoken.v1", nil, true},
+		{"badurl.v1", nil, true},
+		{"badports.v1", nil, true},
+		{"emptyports.v1", nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.ID, func(t *testing.T) {
+			got, err := host.ServiceOAuthClient(test.ID)
+			if (err != nil) != test.WantErr {
+				t.Fatalf("wrong error: %v", err)
+			}
+			if !reflect.DeepEqual(got, test.Want) {
+				t.Errorf("wrong result\ngot:  %#v\nwant: %#v", got, test.Want)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package terraform
+
+import (
+	"sort"
+	"sync"
+)
+
+// CheckStatus is the outcome of evaluating the assertions of a check.
+type CheckStatus string
+
+const (
+	// CheckPass means that all of the check's assertions hold.
+	CheckPass CheckStatus = "pass"
+
+	// CheckFail means that at least one of the check's assertions doesn't
+	// hold.
+	CheckFail CheckStatus = "fail"
+
+	// CheckUnknown means that the check's assertions couldn't be decided
+	// yet, because they depend on values that are only known after apply.
+	CheckUnknown CheckStatus = "unknown"
+
+	// CheckError means that at least one of the check's assertions
+	// couldn't be evaluated at all.
+	CheckError CheckStatus = "error"
+)
+
+// checkStatusSeverity orders the statuses of assertions, so that a check
+// has the status of its worst assertion.
+var checkStatusSeverity = map[CheckStatus]int{
+	CheckPass:    0,
+	CheckUnknown: 1,
+	CheckFail:    2,
+	CheckError:   3,
+}
+
+// CheckResult is the result of evaluating a check block, which is recorded
+// in the plan, and in the state after an apply.
+type CheckResult struct {
+	// Address is the address of the check, such as "check.health" or
+	// "module.app.check.health".
+	Address string `json:"address"`
+
+	Status CheckStatus `json:"status"`
+
+	// FailureMessages are the error messages of the assertions that
+	// failed, and describe the assertions that couldn't be evaluated.
+	FailureMessages []string `json:"failure_messages,omitempty"`
+}
+
+// CheckResults collects the results of the checks that are evaluated
+// during a walk.
+type CheckResults struct {
+	lock    sync.Mutex
+	results map[string]*CheckResult
+}
+
+// NewCheckResults returns an empty set of check results.
+func NewCheckResults() *CheckResults {
+	return &CheckResults{results: make(map[string]*CheckResult)}
+}
+
+// Record adds the result of a check. A result for a check that already has
+// one is combined with it, so that an error reading a data source scoped to
+// the check is reported along with the results of its assertions.
+func (r *CheckResults) Record(result *CheckResult) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	prev, ok := r.results[result.Address]
+	if !ok {
+		r.results[result.Address] = result
+		return
+	}
+
+	combined := &CheckResult{
+		Address:         result.Address,
+		Status:          prev.Status,
+		FailureMessages: append(append([]string(nil), prev.FailureMessages...), result.FailureMessages...),
+	}
+	if checkStatusSeverity[result.Status] > checkStatusSeverity[combined.Status] {
+		combined.Status = result.Status
+	}
+	r.results[result.Address] = combined
+}
+
+// List returns the results that have been recorded, sorted by address, or
+// nil if there are none.
+func (r *CheckResults) List() []*CheckResult {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if len(r.results) == 0 {
+		return nil
+	}
+
+	result := make([]*CheckResult, 0, len(r.results))
+	for _, v := range r.results {
+		result = append(result, v)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Address < result[j].Address
+	})
+	return result
+}
@@ -7,6 +7,7 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/hashicorp/terraform/tfdiags"
 
@@ -14,6 +15,7 @@ import (
 	"github.com/hashicorp/hcl"
 	"github.com/hashicorp/terraform/config"
 	"github.com/hashicorp/terraform/config/module"
+	"github.com/hashicorp/terraform/helper/tracing"
 	"github.com/hashicorp/terraform/version"
 )
 
@@ -65,6 +67,28 @@ type ContextOpts struct {
 	Targets            []string
 	Variables          map[string]interface{}
 
+	// InputValues are values for the root module variables along with
+	// where they were set, which are used in messages about the values.
+	// They override Variables, whose sources aren't known.
+	InputValues InputValues
+
+	// Replace are the addresses of resource instances that should be
+	// replaced in the plan even if their configuration hasn't changed.
+	Replace []string
+
+	// RefreshOnly makes Plan propose no changes, so that applying the plan
+	// only persists the state as it was refreshed before planning.
+	RefreshOnly bool
+
+	// PlanTimestamp is the time at which the plan being applied was
+	// created, which plantimestamp returns. Plan sets it to the current
+	// time.
+	PlanTimestamp time.Time
+
+	// EvalTrace, if non-nil, records every reference that's resolved
+	// during the context's walks, and the value it resolved to.
+	EvalTrace *EvalTrace
+
 	// If non-nil, will apply as additional constraints on the provider
 	// plugins that will be requested from the provider resolver.
 	ProviderSHA256s    map[string][]byte
@@ -91,20 +115,30 @@ type Context struct {
 	// that newShadowContext still does the right thing. Tests should
 	// fail regardless but putting this note here as well.
 
-	components contextComponentFactory
-	destroy    bool
-	diff       *Diff
-	diffLock   sync.RWMutex
-	hooks      []Hook
-	meta       *ContextMeta
-	module     *module.Tree
-	sh         *stopHook
-	shadow     bool
-	state      *State
-	stateLock  sync.RWMutex
-	targets    []string
-	uiInput    UIInput
-	variables  map[string]interface{}
+	checks      *CheckResults
+	components  contextComponentFactory
+	destroy     bool
+	diff        *Diff
+	diffLock    sync.RWMutex
+	evalTrace   *EvalTrace
+	hooks       []Hook
+	meta        *ContextMeta
+	module      *module.Tree
+	planTime    time.Time
+	priorState  *State
+	refreshOnly bool
+	sh          *stopHook
+	shadow      bool
+	state       *State
+	stateLock   sync.RWMutex
+	targets     []string
+	replace     []string
+	uiInput     UIInput
+	variables   map[string]interface{}
+
+	// variableSources describe where the values of the root module
+	// variables came from, for messages about invalid values.
+	variableSources map[string]string
 
 	l                   sync.Mutex // Lock acquired during any task
 	parallelSem         Semaphore
@@ -162,19 +196,19 @@ func NewContext(opts *ContextOpts) (*Context, error) {
 		par = 10
 	}
 
-	// Set up the variables in the following sequence:
-	//    0 - Take default values from the configuration
-	//    1 - Take values from TF_VAR_x environment variables
-	//    2 - Take values specified in -var flags, overriding values
-	//        set by environment variables if necessary. This includes
-	//        values taken from -var-file in addition.
+	// Set up the variables from the defaults in the configuration, the
+	// TF_VAR_x environment variables and then the given values, in order
+	// of precedence. See VariableValues.
 	variables := make(map[string]interface{})
+	sources := make(map[string]string)
 	if opts.Module != nil {
-		var err error
-		variables, err = Variables(opts.Module, opts.Variables)
+		given := InputValuesFromMap(opts.Variables, ValueFromUnknown, "").Override(opts.InputValues)
+		values, err := VariableValues(opts.Module, given)
 		if err != nil {
 			return nil, err
 		}
+		variables = values.Values()
+		sources = values.Sources()
 	}
 
 	// Bind available provider plugins to the constraints in config
@@ -204,16 +238,22 @@ func NewContext(opts *ContextOpts) (*Context, error) {
 			providers:    providers,
 			provisioners: opts.Provisioners,
 		},
-		destroy:   opts.Destroy,
-		diff:      diff,
-		hooks:     hooks,
-		meta:      opts.Meta,
-		module:    opts.Module,
-		shadow:    opts.Shadow,
-		state:     state,
-		targets:   opts.Targets,
-		uiInput:   opts.UIInput,
-		variables: variables,
+		destroy:     opts.Destroy,
+		diff:        diff,
+		evalTrace:   opts.EvalTrace,
+		hooks:       hooks,
+		meta:        opts.Meta,
+		module:      opts.Module,
+		planTime:    opts.PlanTimestamp,
+		refreshOnly: opts.RefreshOnly,
+		shadow:      opts.Shadow,
+		state:       state,
+		targets:     opts.Targets,
+		replace:     opts.Replace,
+		uiInput:     opts.UIInput,
+		variables:   variables,
+
+		variableSources: sources,
 
 		parallelSem:         NewSemaphore(par),
 		providerInputConfig: make(map[string]map[string]interface{}),
@@ -265,6 +305,7 @@ func (c *Context) Graph(typ GraphType, opts *ContextGraphOpts) (*Graph, error) {
 			State:     c.state,
 			Providers: c.components.ResourceProviders(),
 			Targets:   c.targets,
+			Replace:   c.replace,
 			Validate:  opts.Validate,
 		}
 
@@ -346,10 +387,12 @@ func (c *Context) Interpolater() *Interpolater {
 		Operation:          walkApply,
 		Meta:               c.meta,
 		Module:             c.module,
+		PlanTimestamp:      c.planTime,
 		State:              c.state.DeepCopy(),
 		StateLock:          &stateLock,
 		VariableValues:     c.variables,
 		VariableValuesLock: &varLock,
+		ProviderFunctions:  newProviderFunctions(c.components),
 	}
 }
 
@@ -400,6 +443,7 @@ func (c *Context) Input(mode InputMode) error {
 			if _, ok := c.variables[n]; !ok {
 				if v.Default != nil {
 					c.variables[n] = v.Default.(string)
+					c.variableSources[n] = variableSourceDefault
 					continue
 				}
 			}
@@ -449,7 +493,8 @@ func (c *Context) Input(mode InputMode) error {
 			}
 
 			if decoded != nil {
-				c.variables[n] = decoded
+				c.variables[n] = convertVariable(v, decoded)
+				c.variableSources[n] = variableSourceInput
 			}
 		}
 	}
@@ -490,6 +535,18 @@ func (c *Context) Apply() (*State, error) {
 	// Copy our own state
 	c.state = c.state.DeepCopy()
 
+	// Without a plan, such as when applying a diff directly, the apply is
+	// the closest thing to the time of planning.
+	if c.planTime.IsZero() {
+		c.planTime = time.Now().UTC()
+	}
+
+	// A refresh-only plan has no changes to apply: applying it only keeps
+	// the state that was refreshed when it was created.
+	if c.refreshOnly {
+		return c.state, nil
+	}
+
 	// Build the graph.
 	graph, err := c.Graph(GraphTypeApply, nil)
 	if err != nil {
@@ -503,11 +560,18 @@ func (c *Context) Apply() (*State, error) {
 	}
 
 	// Walk the graph
+	c.checks = NewCheckResults()
 	walker, err := c.walk(graph, operation)
 	if len(walker.ValidationErrors) > 0 {
 		err = multierror.Append(err, walker.ValidationErrors...)
 	}
 
+	// The checks are of the infrastructure as it is after the apply, and
+	// so replace those of the previous apply.
+	if operation == walkApply {
+		c.state.Checks = c.checks.List()
+	}
+
 	// Clean out any unused things
 	c.state.prune()
 
@@ -524,14 +588,64 @@ func (c *Context) Apply() (*State, error) {
 func (c *Context) Plan() (*Plan, error) {
 	defer c.acquireRun("plan")()
 
+	c.planTime = time.Now().UTC()
+
+	if diags := c.validateRootVariables(); diags.HasErrors() {
+		return nil, diags.Err()
+	}
+
+	if err := c.moveResources(); err != nil {
+		return nil, err
+	}
+	if err := c.upgradeResourceStates(); err != nil {
+		return nil, err
+	}
+	if len(c.replace) > 0 {
+		addrs, err := parseReplaceAddrs(c.replace)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkReplaceState(c.state, addrs); err != nil {
+			return nil, err
+		}
+	}
+
 	p := &Plan{
-		Module:  c.module,
-		Vars:    c.variables,
-		State:   c.state,
-		Targets: c.targets,
+		Module:     c.module,
+		Vars:       c.variables,
+		VarSources: c.variableSources,
+		State:      c.state,
+		Targets:    c.targets,
+		Replace:    c.replace,
 
 		TerraformVersion: version.String(),
 		ProviderSHA256s:  c.providerSHA256s,
+
+		SkipRefresh: c.priorState == nil,
+		RefreshOnly: c.refreshOnly,
+		Timestamp:   c.planTime,
+	}
+
+	// If the state was refreshed before planning, report any changes that
+	// the refresh detected alongside those proposed by the plan.
+	if c.priorState != nil && !c.destroy {
+		p.Drift = StateDrift(c.priorState, c.state)
+	}
+
+	// A refresh-only plan proposes no changes, whatever the configuration
+	// says, so there is nothing to walk.
+	if c.refreshOnly {
+		if c.destroy {
+			return nil, fmt.Errorf("a refresh-only plan can't also be a destroy plan")
+		}
+
+		c.diffLock.Lock()
+		c.diff = new(Diff)
+		c.diff.init()
+		c.diffLock.Unlock()
+
+		p.Diff = c.diff
+		return p, nil
 	}
 
 	var operation walkOperation
@@ -573,11 +687,13 @@ func (c *Context) Plan() (*Plan, error) {
 	}
 
 	// Do the walk
+	c.checks = NewCheckResults()
 	walker, err := c.walk(graph, operation)
 	if err != nil {
 		return nil, err
 	}
 	p.Diff = c.diff
+	p.Checks = c.checks.List()
 
 	// If this is true, it means we're running unit tests. In this case,
 	// we perform a deep copy just to ensure that all context tests also
@@ -618,6 +734,19 @@ func (c *Context) Plan() (*Plan, error) {
 func (c *Context) Refresh() (*State, error) {
 	defer c.acquireRun("refresh")()
 
+	if err := c.moveResources(); err != nil {
+		return nil, err
+	}
+	if err := c.upgradeResourceStates(); err != nil {
+		return nil, err
+	}
+
+	// Retain the state as it was before the first refresh so that a
+	// subsequent plan can report what changed outside of Terraform.
+	if c.priorState == nil {
+		c.priorState = c.state.DeepCopy()
+	}
+
 	// Copy our own state
 	c.state = c.state.DeepCopy()
 
@@ -674,15 +803,20 @@ func (c *Context) Validate() tfdiags.Diagnostics {
 	var diags tfdiags.Diagnostics
 
 	// Validate the configuration itself
-	diags = diags.Append(c.module.Validate())
+	diags = diags.Append(c.module.Validate().WithCode(tfdiags.CodeConfigInvalid))
 
 	// This only needs to be done for the root module, since inter-module
 	// variables are validated in the module tree.
 	if config := c.module.Config(); config != nil {
 		// Validate the user variables
+		var varDiags tfdiags.Diagnostics
 		for _, err := range smcUserVariables(config, c.variables) {
-			diags = diags.Append(err)
+			varDiags = varDiags.Append(err)
 		}
+		if !diags.HasErrors() && !varDiags.HasErrors() {
+			varDiags = varDiags.Append(c.validateRootVariables())
+		}
+		diags = diags.Append(varDiags.WithCode(tfdiags.CodeVariableInvalid))
 	}
 
 	// If we have errors at this point, the graphing has no chance,
@@ -716,6 +850,10 @@ func (c *Context) Validate() tfdiags.Diagnostics {
 		diags = diags.Append(tfdiags.SimpleWarning(warn))
 	}
 	for _, err := range walker.ValidationErrors {
+		if verr, ok := err.(*vertexValidationError); ok {
+			diags = diags.Append(verr.diagnostics())
+			continue
+		}
 		diags = diags.Append(err)
 	}
 
@@ -737,6 +875,26 @@ func (c *Context) Variables() map[string]interface{} {
 // SetVariable sets a variable after a context has already been built.
 func (c *Context) SetVariable(k string, v interface{}) {
 	c.variables[k] = v
+	c.variableSources[k] = variableSourceSet
+}
+
+// validateRootVariables checks the values of the root module variables
+// against the validation rules of their declarations.
+func (c *Context) validateRootVariables() tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+	if c.module == nil || c.module.Config() == nil {
+		return diags
+	}
+	config := c.module.Config()
+
+	for _, v := range config.Variables {
+		value, ok := c.variables[v.Name]
+		if !ok {
+			continue
+		}
+		diags = diags.Append(validateVariable(v, value, rootModulePath, c.variableSources[v.Name]))
+	}
+	return diags
 }
 
 func (c *Context) acquireRun(phase string) func() {
@@ -800,10 +958,16 @@ func (c *Context) walk(graph *Graph, operation walkOperation) (*ContextGraphWalk
 
 	log.Printf("[DEBUG] Starting graph walk: %s", operation.String())
 
+	opName := strings.ToLower(strings.TrimPrefix(operation.String(), "walk"))
+	span := tracing.Enter("walk "+opName,
+		tracing.String("terraform.walk", opName))
+	defer span.End()
+
 	walker := &ContextGraphWalker{
 		Context:     realCtx,
 		Operation:   operation,
 		StopContext: c.runContext,
+		span:        span,
 	}
 
 	// Watch for a stop so we can call the provider Stop() API.
@@ -812,10 +976,17 @@ func (c *Context) walk(graph *Graph, operation walkOperation) (*ContextGraphWalk
 	// Walk the real graph, this will block until it completes
 	realErr := graph.Walk(walker)
 
+	// Shut down any provider instances that were started only to call
+	// provider functions.
+	if walker.providerFunctions != nil {
+		walker.providerFunctions.Close()
+	}
+
 	// Close the channel so the watcher stops, and wait for it to return.
 	close(watchStop)
 	<-watchWait
 
+	span.SetError(realErr)
 	return walker, realErr
 }
 
@@ -852,19 +1023,40 @@ func (c *Context) watchStop(walker *ContextGraphWalker) (chan struct{}, <-chan s
 
 		{
 			// Copy the providers so that a misbehaved blocking Stop doesn't
-			// completely hang Terraform.
+			// completely hang Terraform. The lock must be released before
+			// calling Stop, since the graph nodes still running need it in
+			// order to finish.
 			walker.providerLock.Lock()
 			ps := make([]ResourceProvider, 0, len(walker.providerCache))
 			for _, p := range walker.providerCache {
 				ps = append(ps, p)
 			}
-			defer walker.providerLock.Unlock()
+			walker.providerLock.Unlock()
 
+			// Stop the providers concurrently, so that one slow provider
+			// doesn't delay the others receiving their stop request.
+			var wg sync.WaitGroup
 			for _, p := range ps {
-				// We ignore the error for now since there isn't any reasonable
-				// action to take if there is an error here, since the stop is still
-				// advisory: Terraform will exit once the graph node completes.
-				p.Stop()
+				wg.Add(1)
+				go func(p ResourceProvider) {
+					defer wg.Done()
+
+					// We ignore the error for now since there isn't any reasonable
+					// action to take if there is an error here, since the stop is still
+					// advisory: Terraform will exit once the graph node completes.
+					p.Stop()
+				}(p)
+			}
+
+			stopped := make(chan struct{})
+			go func() {
+				wg.Wait()
+				close(stopped)
+			}()
+			select {
+			case <-stopped:
+			case <-time.After(providerStopGracePeriod):
+				log.Printf("[WARN] terraform: not all providers responded to Stop")
 			}
 		}
 
@@ -103,6 +103,72 @@ func TestContext2Apply_unstable(t *testing.T) {
 	}
 }
 
+func TestContext2Apply_planTimestamp(t *testing.T) {
+	// Unlike timestamp(), plantimestamp() must return the time of the plan
+	// during apply too, even when the plan was saved to a file, so that
+	// the applied value matches the planned one.
+	m := testModule(t, "apply-plantimestamp")
+	p := testProvider("test")
+	p.ApplyFn = testApplyFn
+	p.DiffFn = testDiffFn
+	providerResolver := ResourceProviderResolverFixed(
+		map[string]ResourceProviderFactory{
+			"test": testProviderFuncFixed(p),
+		},
+	)
+	ctx := testContext2(t, &ContextOpts{
+		Module:           m,
+		ProviderResolver: providerResolver,
+	})
+
+	plan, err := ctx.Plan()
+	if err != nil {
+		t.Fatalf("unexpected error during Plan: %s", err)
+	}
+	if plan.Timestamp.IsZero() {
+		t.Fatal("plan has no timestamp")
+	}
+
+	want := plan.Timestamp.Format(time.RFC3339)
+	rd := plan.Diff.RootModule().Resources["test_resource.foo"]
+	if got := rd.Attributes["created"].New; got != want {
+		t.Fatalf("wrong planned value %q; want %q", got, want)
+	}
+
+	// Write / Read plan to simulate running it through a Plan file
+	var buf bytes.Buffer
+	if err := WritePlan(plan, &buf); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	planFromFile, err := ReadPlan(&buf)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !planFromFile.Timestamp.Equal(plan.Timestamp) {
+		t.Fatalf("wrong timestamp %s in plan file; want %s", planFromFile.Timestamp, plan.Timestamp)
+	}
+
+	ctx, err = planFromFile.Context(&ContextOpts{
+		ProviderResolver: providerResolver,
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if got := ctx.Interpolater().PlanTimestamp; !got.Equal(plan.Timestamp) {
+		t.Fatalf("wrong timestamp %s for apply; want %s", got, plan.Timestamp)
+	}
+
+	state, err := ctx.Apply()
+	if err != nil {
+		t.Fatalf("unexpected error during Apply: %s", err)
+	}
+
+	rs := state.RootModule().Resources["test_resource.foo"].Primary
+	if got := rs.Attributes["created"]; got != want {
+		t.Fatalf("wrong applied value %q; want %q", got, want)
+	}
+}
+
 func TestContext2Apply_escape(t *testing.T) {
 	m := testModule(t, "apply-escape")
 	p := testProvider("aws")
@@ -656,6 +722,81 @@ func TestContext2Apply_providerAlias(t *testing.T) {
 	}
 }
 
+// An aliased provider's parallelism limits the concurrent calls made to it
+func TestContext2Apply_providerAliasParallelism(t *testing.T) {
+	m := testModule(t, "apply-provider-alias-parallelism")
+	p := testProvider("aws")
+	p.DiffFn = testDiffFn
+
+	var lock sync.Mutex
+	var running, maxRunning int
+	p.ApplyFn = func(info *InstanceInfo, s *InstanceState, d *InstanceDiff) (*InstanceState, error) {
+		lock.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		lock.Unlock()
+
+		time.Sleep(10 * time.Millisecond)
+
+		lock.Lock()
+		running--
+		lock.Unlock()
+
+		return testApplyFn(info, s, d)
+	}
+
+	ctx := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(p),
+			},
+		),
+		Parallelism: 10,
+	})
+
+	if _, err := ctx.Plan(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if _, err := ctx.Apply(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if maxRunning != 1 {
+		t.Fatalf("expected at most 1 concurrent apply; got %d", maxRunning)
+	}
+}
+
+// Errors configuring an aliased provider must say which configuration failed
+func TestContext2Apply_providerAliasConfigureError(t *testing.T) {
+	m := testModule(t, "apply-provider-alias-parallelism")
+	p := testProvider("aws")
+	p.ApplyFn = testApplyFn
+	p.DiffFn = testDiffFn
+	p.ConfigureFn = func(c *ResourceConfig) error {
+		return fmt.Errorf("invalid credentials")
+	}
+
+	ctx := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(p),
+			},
+		),
+	})
+
+	_, err := ctx.Plan()
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if !strings.Contains(err.Error(), "provider.aws.west: invalid credentials") {
+		t.Fatalf("wrong error: %s", err)
+	}
+}
+
 // Two providers that are configured should both be configured prior to apply
 func TestContext2Apply_providerAliasConfigure(t *testing.T) {
 	m := testModule(t, "apply-provider-alias-configure")
@@ -4761,6 +4902,12 @@ func TestContext2Apply_provisionerFailContinueHook(t *testing.T) {
 	if h.PostProvisionErrorArg == nil {
 		t.Fatal("should have error")
 	}
+
+	// The error is ignored, so it's reported with the provisioner's output
+	// rather than by the apply.
+	if !strings.Contains(h.ProvisionOutputMessage, "provisioner error") {
+		t.Fatalf("error not in output: %q", h.ProvisionOutputMessage)
+	}
 }
 
 func TestContext2Apply_provisionerDestroy(t *testing.T) {
@@ -4825,6 +4972,70 @@ func TestContext2Apply_provisionerDestroy(t *testing.T) {
 	}
 }
 
+// Verify that destroy provisioners see the prior state of the instance,
+// whether it's destroyed or replaced
+func TestContext2Apply_provisionerDestroySelfRef(t *testing.T) {
+	m := testModule(t, "apply-provisioner-destroy-self")
+	p := testProvider("aws")
+	pr := testProvisioner()
+	p.ApplyFn = testApplyFn
+	p.DiffFn = testDiffFn
+
+	state := &State{
+		Modules: []*ModuleState{
+			&ModuleState{
+				Path: rootModulePath,
+				Resources: map[string]*ResourceState{
+					"aws_instance.foo": &ResourceState{
+						Type: "aws_instance",
+						Primary: &InstanceState{
+							ID: "i-abc123",
+							Attributes: map[string]string{
+								"id":          "i-abc123",
+								"foo":         "old",
+								"require_new": "old",
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for _, destroy := range []bool{true, false} {
+		var command interface{}
+		pr.ApplyFn = func(rs *InstanceState, c *ResourceConfig) error {
+			command = c.Config["command"]
+			return nil
+		}
+
+		ctx := testContext2(t, &ContextOpts{
+			Module:  m,
+			State:   state.DeepCopy(),
+			Destroy: destroy,
+			ProviderResolver: ResourceProviderResolverFixed(
+				map[string]ResourceProviderFactory{
+					"aws": testProviderFuncFixed(p),
+				},
+			),
+			Provisioners: map[string]ResourceProvisionerFactory{
+				"shell": testProvisionerFuncFixed(pr),
+			},
+		})
+
+		if _, err := ctx.Plan(); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		if _, err := ctx.Apply(); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+
+		if got, want := command, "echo old i-abc123"; got != want {
+			t.Fatalf("wrong command with destroy %t: %#v; want %q", destroy, got, want)
+		}
+	}
+}
+
 // Verify that on destroy provisioner failure, nothing happens to the instance
 func TestContext2Apply_provisionerDestroyFail(t *testing.T) {
 	m := testModule(t, "apply-provisioner-destroy")
@@ -7008,6 +7219,328 @@ func TestContext2Apply_outputBasic(t *testing.T) {
 	}
 }
 
+func TestContext2Apply_replace(t *testing.T) {
+	m := testModule(t, "plan-taint")
+	p := testProvider("aws")
+	p.ApplyFn = testApplyFn
+	p.DiffFn = testDiffFn
+	ctx := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(p),
+			},
+		),
+		State: &State{
+			Modules: []*ModuleState{
+				&ModuleState{
+					Path: rootModulePath,
+					Resources: map[string]*ResourceState{
+						"aws_instance.foo": &ResourceState{
+							Type: "aws_instance",
+							Primary: &InstanceState{
+								ID: "bar",
+								Attributes: map[string]string{
+									"num":  "2",
+									"type": "aws_instance",
+								},
+							},
+						},
+						"aws_instance.bar": &ResourceState{
+							Type: "aws_instance",
+							Primary: &InstanceState{
+								ID: "baz",
+								Attributes: map[string]string{
+									"foo":  "2",
+									"type": "aws_instance",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		Replace: []string{"aws_instance.foo"},
+	})
+
+	if _, err := ctx.Plan(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	state, err := ctx.Apply()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	mod := state.RootModule()
+	if got := mod.Resources["aws_instance.foo"].Primary.ID; got == "bar" {
+		t.Fatal("aws_instance.foo should have been replaced")
+	}
+	if got, want := mod.Resources["aws_instance.bar"].Primary.ID, "baz"; got != want {
+		t.Fatalf("aws_instance.bar should not have been replaced; got ID %q", got)
+	}
+}
+
+func TestContext2Apply_lifecycleLock(t *testing.T) {
+	m := testModule(t, "apply-lifecycle-lock")
+	p := testProvider("aws")
+	p.ApplyFn = testApplyFn
+	p.DiffFn = testDiffFn
+	p.LockResourceReturn = "lock-id"
+	ctx := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(p),
+			},
+		),
+	})
+
+	if _, err := ctx.Plan(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if _, err := ctx.Apply(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if !p.LockResourceCalled {
+		t.Fatal("LockResource should be called")
+	}
+	if got, want := p.LockResourceInfo.Id, "aws_instance.locked"; got != want {
+		t.Fatalf("wrong resource locked %q; want %q", got, want)
+	}
+	if !p.UnlockResourceCalled {
+		t.Fatal("UnlockResource should be called")
+	}
+	if got, want := p.UnlockResourceLockID, "lock-id"; got != want {
+		t.Fatalf("wrong lock ID %q; want %q", got, want)
+	}
+}
+
+func TestContext2Apply_lifecycleLockError(t *testing.T) {
+	m := testModule(t, "apply-lifecycle-lock")
+	p := testProvider("aws")
+	p.ApplyFn = testApplyFn
+	p.DiffFn = testDiffFn
+	p.LockResourceReturnError = fmt.Errorf("already locked")
+	ctx := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(p),
+			},
+		),
+	})
+
+	if _, err := ctx.Plan(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	state, err := ctx.Apply()
+	if err == nil {
+		t.Fatal("should error")
+	}
+	if !strings.Contains(err.Error(), "already locked") {
+		t.Fatalf("wrong error: %s", err)
+	}
+	if p.UnlockResourceCalled {
+		t.Fatal("UnlockResource should not be called")
+	}
+
+	mod := state.RootModule()
+	if _, ok := mod.Resources["aws_instance.locked"]; ok {
+		t.Fatal("aws_instance.locked should not have been created")
+	}
+	if _, ok := mod.Resources["aws_instance.unlocked"]; !ok {
+		t.Fatal("aws_instance.unlocked should have been created")
+	}
+}
+
+// Test that the lock of a resource is released when its provisioning is
+// interrupted or fails before the apply completes.
+func TestContext2Apply_lifecycleLockProvisionerInterrupted(t *testing.T) {
+	cases := map[string]func(h *MockHook){
+		"halted": func(h *MockHook) {
+			h.PreProvisionResourceReturn = HookActionHalt
+		},
+		"hook error": func(h *MockHook) {
+			h.PreProvisionResourceError = fmt.Errorf("hook failed")
+		},
+	}
+
+	for name, setup := range cases {
+		t.Run(name, func(t *testing.T) {
+			m := testModule(t, "apply-lifecycle-lock-provisioner")
+			p := testProvider("aws")
+			p.ApplyFn = testApplyFn
+			p.DiffFn = testDiffFn
+			p.LockResourceReturn = "lock-id"
+			pr := testProvisioner()
+			h := new(MockHook)
+			setup(h)
+			ctx := testContext2(t, &ContextOpts{
+				Module: m,
+				Hooks:  []Hook{h},
+				ProviderResolver: ResourceProviderResolverFixed(
+					map[string]ResourceProviderFactory{
+						"aws": testProviderFuncFixed(p),
+					},
+				),
+				Provisioners: map[string]ResourceProvisionerFactory{
+					"shell": testProvisionerFuncFixed(pr),
+				},
+			})
+
+			if _, err := ctx.Plan(); err != nil {
+				t.Fatalf("err: %s", err)
+			}
+
+			// Whether the apply reports an error depends on how it was
+			// stopped, but either way the lock must be released.
+			ctx.Apply()
+
+			if !p.LockResourceCalled {
+				t.Fatal("LockResource should be called")
+			}
+			if pr.ApplyCalled {
+				t.Fatal("provisioner should not be applied")
+			}
+			if !p.UnlockResourceCalled {
+				t.Fatal("UnlockResource should be called")
+			}
+			if got, want := p.UnlockResourceLockID, "lock-id"; got != want {
+				t.Fatalf("wrong lock ID %q; want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestContext2Apply_sensitivePropagate(t *testing.T) {
+	m := testModule(t, "apply-sensitive-propagate")
+	p := testProvider("aws")
+	p.ApplyFn = testApplyFn
+	p.DiffFn = testDiffFn
+	ctx := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(p),
+			},
+		),
+		State: &State{
+			Modules: []*ModuleState{
+				&ModuleState{
+					Path: rootModulePath,
+					Resources: map[string]*ResourceState{
+						"aws_instance.foo": &ResourceState{
+							Type: "aws_instance",
+							Primary: &InstanceState{
+								ID: "foo",
+								Attributes: map[string]string{
+									"id":     "foo",
+									"secret": "hunter2",
+								},
+								SensitiveAttributes: []string{"secret"},
+							},
+						},
+					},
+					Outputs: map[string]*OutputState{},
+				},
+			},
+		},
+	})
+
+	plan, err := ctx.Plan()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	rd := plan.Diff.RootModule().Resources["aws_instance.bar"]
+	if rd == nil {
+		t.Fatalf("missing diff for aws_instance.bar")
+	}
+	if !rd.Attributes["foo"].Sensitive {
+		t.Fatalf("foo should be sensitive in the diff")
+	}
+	if rd.Attributes["type"].Sensitive {
+		t.Fatalf("type should not be sensitive in the diff")
+	}
+
+	state, err := ctx.Apply()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	mod := state.RootModule()
+	bar := mod.Resources["aws_instance.bar"].Primary
+	if got, want := bar.SensitiveAttributes, []string{"foo"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong sensitive attributes %#v; want %#v", got, want)
+	}
+	if !mod.Outputs["secret"].Sensitive {
+		t.Fatalf("output should be sensitive")
+	}
+}
+
+func TestContext2Apply_sensitiveVariable(t *testing.T) {
+	m := testModule(t, "apply-sensitive-variable")
+	p := testProvider("aws")
+	p.ApplyFn = testApplyFn
+	p.DiffFn = testDiffFn
+	ctx := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(p),
+			},
+		),
+		Variables: map[string]interface{}{
+			"password": "hunter2",
+			"name":     "web",
+		},
+	})
+
+	plan, err := ctx.Plan()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// The sensitive variable taints the local value derived from it in the
+	// root module, and the variable it sets in the child module.
+	for _, path := range [][]string{rootModulePath, {"root", "child"}} {
+		rd := plan.Diff.ModuleByPath(path).Resources["aws_instance.foo"]
+		if rd == nil {
+			t.Fatalf("missing diff for aws_instance.foo in %v", path)
+		}
+		if !rd.Attributes["foo"].Sensitive {
+			t.Fatalf("foo should be sensitive in the diff in %v", path)
+		}
+		if rd.Attributes["type"].Sensitive {
+			t.Fatalf("type should not be sensitive in the diff in %v", path)
+		}
+	}
+	if strings.Contains(plan.Diff.String(), "hunter2") {
+		t.Fatalf("sensitive value in the diff:\n%s", plan.Diff)
+	}
+
+	state, err := ctx.Apply()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	mod := state.RootModule()
+	foo := mod.Resources["aws_instance.foo"].Primary
+	if got, want := foo.SensitiveAttributes, []string{"foo"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong sensitive attributes %#v; want %#v", got, want)
+	}
+	if !mod.Outputs["credentials"].Sensitive {
+		t.Fatalf("credentials output should be sensitive")
+	}
+	if mod.Outputs["name"].Sensitive {
+		t.Fatalf("name output should not be sensitive")
+	}
+}
+
 func TestContext2Apply_outputInvalid(t *testing.T) {
 	m := testModule(t, "apply-output-invalid")
 	p := testProvider("aws")
@@ -9206,3 +9739,40 @@ func TestContext2Apply_providersFromState(t *testing.T) {
 	}
 
 }
+
+func TestContext2Apply_checks(t *testing.T) {
+	m := testModule(t, "plan-checks")
+	p := testProvider("aws")
+	p.ApplyFn = testApplyFn
+	p.DiffFn = testDiffFn
+	ctx := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(p),
+			},
+		),
+	})
+
+	if _, err := ctx.Plan(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// A failed check doesn't fail the apply.
+	state, err := ctx.Apply()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	want := []*CheckResult{
+		{
+			Address:         "check.id",
+			Status:          CheckFail,
+			FailureMessages: []string{"The instance has the wrong ID."},
+		},
+		{Address: "check.num", Status: CheckPass},
+	}
+	if !reflect.DeepEqual(state.Checks, want) {
+		t.Fatalf("wrong checks\ngot:  %s\nwant: %s", spew.Sdump(state.Checks), spew.Sdump(want))
+	}
+}
@@ -0,0 +1,258 @@
+package terraform
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/hashicorp/terraform/config"
+	"github.com/hashicorp/terraform/config/module"
+)
+
+// moveResources updates the state to reflect the "moved" blocks in the
+// configuration, so that renamed resources are planned as updates of the
+// existing remote objects rather than being destroyed and recreated.
+//
+// Moves whose source isn't in the state, either because they were already
+// done or because the resource was never created, are ignored. If anything
+// is moved then c.state is replaced with an updated copy, leaving the
+// caller's state untouched.
+func (c *Context) moveResources() error {
+	if c.state == nil || c.module == nil {
+		return nil
+	}
+
+	m := &resourceMover{
+		components: c.components,
+		state:      c.state.DeepCopy(),
+		providers:  make(map[string]ResourceProvider),
+	}
+	defer m.close()
+
+	if err := m.moveTree(c.module); err != nil {
+		return err
+	}
+
+	if m.moved {
+		c.state = m.state
+	}
+	return nil
+}
+
+// resourceMover applies the "moved" blocks of a module tree to a state.
+type resourceMover struct {
+	components contextComponentFactory
+	state      *State
+	moved      bool
+
+	// providers are the unconfigured provider instances used to convert
+	// state between resource types, by provider type name.
+	providers map[string]ResourceProvider
+}
+
+func (m *resourceMover) moveTree(tree *module.Tree) error {
+	if cfg := tree.Config(); cfg != nil && len(cfg.Moved) > 0 {
+		path := normalizeModulePath(tree.Path())
+		if mod := m.state.ModuleByPath(path); mod != nil {
+			for _, mv := range cfg.Moved {
+				if err := m.move(mod, cfg, mv); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	for _, child := range tree.Children() {
+		if err := m.moveTree(child); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *resourceMover) move(mod *ModuleState, cfg *config.Config, mv *config.Moved) error {
+	fromType, fromName, err := config.ParseMovedAddr(mv.From)
+	if err != nil {
+		return err
+	}
+	toType, toName, err := config.ParseMovedAddr(mv.To)
+	if err != nil {
+		return err
+	}
+
+	for k, rs := range mod.Resources {
+		key, err := ParseResourceStateKey(k)
+		if err != nil {
+			return err
+		}
+		if key.Mode != config.ManagedResourceMode || key.Type != fromType || key.Name != fromName {
+			continue
+		}
+
+		newKey := &ResourceStateKey{
+			Mode:  config.ManagedResourceMode,
+			Type:  toType,
+			Name:  toName,
+			Index: key.Index,
+		}
+		from, to := movedAddr(mod, k), movedAddr(mod, newKey.String())
+		if _, exists := mod.Resources[newKey.String()]; exists {
+			return fmt.Errorf(
+				"cannot move %s to %s, because %s is already in the state; "+
+					"if the moved block is no longer needed then remove it, or else "+
+					"use \"terraform state rm\" to forget one of the two objects",
+				from, to, to)
+		}
+
+		if toType != fromType {
+			if err := m.convert(mod, cfg, rs, newKey); err != nil {
+				return err
+			}
+		}
+
+		log.Printf("[INFO] moving %s to %s", from, to)
+		delete(mod.Resources, k)
+		mod.Resources[newKey.String()] = rs
+		m.moved = true
+	}
+
+	// Anything that depended on the old address now depends on the new one.
+	for _, rs := range mod.Resources {
+		for i, d := range rs.Dependencies {
+			if d == mv.From || strings.HasPrefix(d, mv.From+".") {
+				rs.Dependencies[i] = mv.To + strings.TrimPrefix(d, mv.From)
+			}
+		}
+	}
+
+	return nil
+}
+
+// convert asks the provider of the resource type being moved to to convert
+// the state of each object in the given resource state.
+func (m *resourceMover) convert(mod *ModuleState, cfg *config.Config, rs *ResourceState, key *ResourceStateKey) error {
+	var target *config.Resource
+	for _, r := range cfg.Resources {
+		if r.Mode == config.ManagedResourceMode && r.Type == key.Type && r.Name == key.Name {
+			target = r
+			break
+		}
+	}
+	if target == nil {
+		return fmt.Errorf("cannot move state to %s, because it is not declared", movedAddr(mod, key.String()))
+	}
+
+	providerType := strings.SplitN(resourceProvider(target.Type, target.Provider), ".", 2)[0]
+	p, err := m.provider(providerType)
+	if err != nil {
+		return err
+	}
+
+	sourceProvider := strings.SplitN(resourceProvider(rs.Type, ""), ".", 2)[0]
+	if rs.Provider != "" {
+		sourceProvider = providerTypeOf(rs.Provider)
+	}
+
+	addr := movedAddr(mod, key.String())
+	mover, ok := p.(ResourceProviderMover)
+	if ok {
+		schema, err := p.GetSchema(&ProviderSchemaRequest{})
+		ok = err == nil && schema != nil && schema.Capabilities.MoveResourceState
+	}
+	if !ok {
+		return fmt.Errorf(
+			"cannot move resource of type %s to %s, because provider %q does not "+
+				"support moving resources from other resource types",
+			rs.Type, addr, providerType)
+	}
+
+	info := &InstanceInfo{
+		Id:         key.String(),
+		Type:       key.Type,
+		ModulePath: normalizeModulePath(mod.Path),
+	}
+	convert := func(is *InstanceState) (*InstanceState, error) {
+		if is == nil {
+			return nil, nil
+		}
+		result, err := mover.MoveResourceState(&MoveResourceStateRequest{
+			Info:           info,
+			SourceProvider: sourceProvider,
+			SourceType:     rs.Type,
+			SourceState:    is,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%s: moving state from %s: %s", addr, rs.Type, err)
+		}
+		if result == nil || result.ID == "" {
+			return nil, fmt.Errorf(
+				"%s: provider %q returned no state when moving state from %s; "+
+					"this is a bug in the provider", addr, providerType, rs.Type)
+		}
+		return result, nil
+	}
+
+	primary, err := convert(rs.Primary)
+	if err != nil {
+		return err
+	}
+	deposed := make([]*InstanceState, len(rs.Deposed))
+	for i, is := range rs.Deposed {
+		if deposed[i], err = convert(is); err != nil {
+			return err
+		}
+	}
+
+	rs.Type = key.Type
+	rs.Primary = primary
+	rs.Deposed = deposed
+	if providerType != sourceProvider {
+		// The resource's provider will be taken from the configuration.
+		rs.Provider = ""
+	}
+
+	return nil
+}
+
+func (m *resourceMover) provider(typeName string) (ResourceProvider, error) {
+	if p, ok := m.providers[typeName]; ok {
+		return p, nil
+	}
+
+	p, err := m.components.ResourceProvider(typeName, "moved."+typeName)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"failed to instantiate provider %q to move resource state: %s",
+			typeName, err)
+	}
+
+	m.providers[typeName] = p
+	return p, nil
+}
+
+func (m *resourceMover) close() {
+	for _, p := range m.providers {
+		if c, ok := p.(ResourceProviderCloser); ok {
+			c.Close()
+		}
+	}
+}
+
+// movedAddr returns the absolute address of the resource with the given
+// state key in the given module, for messages.
+func movedAddr(mod *ModuleState, key string) string {
+	if prefix := modulePrefixStr(mod.Path); prefix != "" {
+		return prefix + "." + key
+	}
+	return key
+}
+
+// providerTypeOf returns the provider type name from a resolved provider
+// name as recorded in the state, such as "module.foo.provider.aws.west".
+func providerTypeOf(resolved string) string {
+	if i := strings.LastIndex(resolved, "provider."); i != -1 {
+		resolved = resolved[i+len("provider."):]
+	}
+	return strings.SplitN(resolved, ".", 2)[0]
+}
@@ -2,6 +2,7 @@ package terraform
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"os"
 	"reflect"
@@ -9,6 +10,11 @@ import (
 	"strings"
 	"sync"
 	"testing"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/hashicorp/hil/ast"
+	"github.com/hashicorp/terraform/config/configschema"
+	"github.com/zclconf/go-cty/cty"
 )
 
 func TestContext2Plan_basic(t *testing.T) {
@@ -248,6 +254,50 @@ func TestContext2Plan_modules(t *testing.T) {
 	}
 }
 
+func TestContext2Plan_moduleInstanceState(t *testing.T) {
+	m := testModule(t, "plan-modules")
+	p := testProvider("aws")
+	p.DiffFn = testDiffFn
+	ctx := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(p),
+			},
+		),
+		State: &State{
+			Modules: []*ModuleState{
+				&ModuleState{
+					Path: []string{"root", "child[0]"},
+					Resources: map[string]*ResourceState{
+						"aws_instance.foo": &ResourceState{
+							Type: "aws_instance",
+							Primary: &InstanceState{
+								ID: "bar",
+								Attributes: map[string]string{
+									"num":  "2",
+									"type": "aws_instance",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	})
+
+	plan, err := ctx.Plan()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// The state for an instance of a module call that is still in the
+	// configuration must not be treated as orphaned.
+	if md := plan.Diff.ModuleByPath([]string{"root", "child[0]"}); md != nil && !md.Empty() {
+		t.Fatalf("module instance should not be destroyed:\n%s", md)
+	}
+}
+
 // GH-1475
 func TestContext2Plan_moduleCycle(t *testing.T) {
 	m := testModule(t, "plan-module-cycle")
@@ -313,6 +363,114 @@ STATE:
 	})
 }
 
+func TestContext2Plan_moduleVarValidation(t *testing.T) {
+	m := testModule(t, "plan-module-var-validation")
+	p := testProvider("aws")
+	p.DiffFn = testDiffFn
+	providerResolver := ResourceProviderResolverFixed(
+		map[string]ResourceProviderFactory{
+			"aws": testProviderFuncFixed(p),
+		},
+	)
+
+	// The value given to module.computed isn't known until apply, so it
+	// can't be checked during plan.
+	ctx := testContext2(t, &ContextOpts{
+		Module:           m,
+		ProviderResolver: providerResolver,
+		Variables:        map[string]interface{}{"zone": "a"},
+	})
+	if _, err := ctx.Plan(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	ctx = testContext2(t, &ContextOpts{
+		Module:           m,
+		ProviderResolver: providerResolver,
+		Variables:        map[string]interface{}{"zone": "z"},
+	})
+	_, err := ctx.Plan()
+	if err == nil {
+		t.Fatal("succeeded; want error")
+	}
+	for _, want := range []string{
+		"The zone must be a or b.",
+		`The value of module.child.var.zone was set by the module "child" block`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("error %q should contain %q", err, want)
+		}
+	}
+}
+
+func TestContext2Plan_moduleVarObject(t *testing.T) {
+	m := testModule(t, "plan-module-var-object")
+	p := testProvider("aws")
+	p.DiffFn = testDiffFn
+	ctx := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(p),
+			},
+		),
+		Variables: map[string]interface{}{
+			"service": map[string]interface{}{"name": "web"},
+		},
+	})
+
+	plan, err := ctx.Plan()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// The optional port attributes get their defaults, in the root module
+	// and in the child module.
+	for path, want := range map[string]string{
+		"root":       "web:80",
+		"root.child": "db:5432",
+	} {
+		var got string
+		for _, md := range plan.Diff.Modules {
+			if strings.Join(md.Path, ".") != path {
+				continue
+			}
+			if rd, ok := md.Resources["aws_instance.foo"]; ok {
+				got = rd.Attributes["foo"].New
+			}
+		}
+		if got != want {
+			t.Errorf("wrong foo in %s: got %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestContext2Plan_moduleVarObjectInvalid(t *testing.T) {
+	m := testModule(t, "plan-module-var-object")
+	p := testProvider("aws")
+	p.DiffFn = testDiffFn
+	ctx := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(p),
+			},
+		),
+		Variables: map[string]interface{}{
+			"service": map[string]interface{}{"port": "8080"},
+		},
+	})
+
+	diags := ctx.Validate()
+	if !diags.HasErrors() {
+		t.Fatal("succeeded; want error")
+	}
+	want := `variable service should be type object({name = string, port = optional(number, 80)}): attribute "name" is required`
+	if got := diags.Err().Error(); !strings.Contains(got, want) {
+		t.Fatalf("error %q should contain %q", got, want)
+	}
+}
+
 func TestContext2Plan_moduleInput(t *testing.T) {
 	m := testModule(t, "plan-module-input")
 	p := testProvider("aws")
@@ -2468,29 +2626,8 @@ func TestContext2Plan_orphan(t *testing.T) {
 	}
 }
 
-// This tests that configurations with UUIDs don't produce errors.
-// For shadows, this would produce errors since a UUID changes every time.
-func TestContext2Plan_shadowUuid(t *testing.T) {
-	m := testModule(t, "plan-shadow-uuid")
-	p := testProvider("aws")
-	p.DiffFn = testDiffFn
-	ctx := testContext2(t, &ContextOpts{
-		Module: m,
-		ProviderResolver: ResourceProviderResolverFixed(
-			map[string]ResourceProviderFactory{
-				"aws": testProviderFuncFixed(p),
-			},
-		),
-	})
-
-	_, err := ctx.Plan()
-	if err != nil {
-		t.Fatalf("err: %s", err)
-	}
-}
-
-func TestContext2Plan_state(t *testing.T) {
-	m := testModule(t, "plan-good")
+func TestContext2Plan_moved(t *testing.T) {
+	m := testModule(t, "plan-moved")
 	p := testProvider("aws")
 	p.DiffFn = testDiffFn
 	s := &State{
@@ -2502,6 +2639,9 @@ func TestContext2Plan_state(t *testing.T) {
 						Type: "aws_instance",
 						Primary: &InstanceState{
 							ID: "bar",
+							Attributes: map[string]string{
+								"foo": "bar",
+							},
 						},
 					},
 				},
@@ -2523,38 +2663,58 @@ func TestContext2Plan_state(t *testing.T) {
 		t.Fatalf("err: %s", err)
 	}
 
-	if len(plan.Diff.RootModule().Resources) < 2 {
-		t.Fatalf("bad: %#v", plan.Diff.RootModule().Resources)
+	if !plan.Diff.Empty() {
+		t.Fatalf("expected empty plan, got:\n%s", plan)
 	}
 
-	actual := strings.TrimSpace(plan.String())
-	expected := strings.TrimSpace(testTerraformPlanStateStr)
-	if actual != expected {
-		t.Fatalf("bad:\n%s\n\nexpected:\n\n%s", actual, expected)
+	mod := plan.State.RootModule()
+	if _, ok := mod.Resources["aws_instance.foo"]; ok {
+		t.Fatal("aws_instance.foo should have been moved")
+	}
+	if rs, ok := mod.Resources["aws_instance.bar"]; !ok || rs.Primary.ID != "bar" {
+		t.Fatalf("bad: %#v", mod.Resources)
+	}
+
+	// The caller's state must not be modified.
+	if _, ok := s.RootModule().Resources["aws_instance.foo"]; !ok {
+		t.Fatal("original state was modified")
 	}
 }
 
-func TestContext2Plan_taint(t *testing.T) {
-	m := testModule(t, "plan-taint")
+func TestContext2Plan_movedType(t *testing.T) {
+	m := testModule(t, "plan-moved-type")
 	p := testProvider("aws")
 	p.DiffFn = testDiffFn
+	p.GetSchemaReturn = &ProviderSchema{
+		Capabilities: ProviderCapabilities{
+			MoveResourceState: true,
+		},
+	}
+	p.MoveResourceStateFn = func(req *MoveResourceStateRequest) (*InstanceState, error) {
+		if req.SourceProvider != "old" || req.SourceType != "old_instance" {
+			return nil, fmt.Errorf("bad source %s %s", req.SourceProvider, req.SourceType)
+		}
+		return &InstanceState{
+			ID: req.SourceState.ID,
+			Attributes: map[string]string{
+				"foo": req.SourceState.Attributes["old_foo"],
+			},
+		}, nil
+	}
+	old := testProvider("old")
 	s := &State{
 		Modules: []*ModuleState{
 			&ModuleState{
 				Path: rootModulePath,
 				Resources: map[string]*ResourceState{
-					"aws_instance.foo": &ResourceState{
-						Type: "aws_instance",
-						Primary: &InstanceState{
-							ID:         "bar",
-							Attributes: map[string]string{"num": "2"},
-						},
-					},
-					"aws_instance.bar": &ResourceState{
-						Type: "aws_instance",
+					"old_instance.foo": &ResourceState{
+						Type:     "old_instance",
+						Provider: "provider.old",
 						Primary: &InstanceState{
-							ID:      "baz",
-							Tainted: true,
+							ID: "bar",
+							Attributes: map[string]string{
+								"old_foo": "bar",
+							},
 						},
 					},
 				},
@@ -2566,6 +2726,7 @@ func TestContext2Plan_taint(t *testing.T) {
 		ProviderResolver: ResourceProviderResolverFixed(
 			map[string]ResourceProviderFactory{
 				"aws": testProviderFuncFixed(p),
+				"old": testProviderFuncFixed(old),
 			},
 		),
 		State: s,
@@ -2576,32 +2737,36 @@ func TestContext2Plan_taint(t *testing.T) {
 		t.Fatalf("err: %s", err)
 	}
 
-	actual := strings.TrimSpace(plan.String())
-	expected := strings.TrimSpace(testTerraformPlanTaintStr)
-	if actual != expected {
-		t.Fatalf("bad:\n%s", actual)
+	if !p.MoveResourceStateCalled {
+		t.Fatal("MoveResourceState should be called")
+	}
+	if !plan.Diff.Empty() {
+		t.Fatalf("expected empty plan, got:\n%s", plan)
+	}
+
+	rs, ok := plan.State.RootModule().Resources["aws_instance.foo"]
+	if !ok {
+		t.Fatalf("bad: %#v", plan.State.RootModule().Resources)
+	}
+	if rs.Type != "aws_instance" || rs.Provider != "" || rs.Primary.Attributes["foo"] != "bar" {
+		t.Fatalf("bad: %#v", rs)
 	}
 }
 
-func TestContext2Apply_taintIgnoreChanges(t *testing.T) {
-	m := testModule(t, "plan-taint-ignore-changes")
+func TestContext2Plan_movedTypeUnsupported(t *testing.T) {
+	m := testModule(t, "plan-moved-type")
 	p := testProvider("aws")
-	p.ApplyFn = testApplyFn
 	p.DiffFn = testDiffFn
+	old := testProvider("old")
 	s := &State{
 		Modules: []*ModuleState{
 			&ModuleState{
 				Path: rootModulePath,
 				Resources: map[string]*ResourceState{
-					"aws_instance.foo": &ResourceState{
-						Type: "aws_instance",
+					"old_instance.foo": &ResourceState{
+						Type: "old_instance",
 						Primary: &InstanceState{
-							ID: "foo",
-							Attributes: map[string]string{
-								"vars": "foo",
-								"type": "aws_instance",
-							},
-							Tainted: true,
+							ID: "bar",
 						},
 					},
 				},
@@ -2613,50 +2778,31 @@ func TestContext2Apply_taintIgnoreChanges(t *testing.T) {
 		ProviderResolver: ResourceProviderResolverFixed(
 			map[string]ResourceProviderFactory{
 				"aws": testProviderFuncFixed(p),
+				"old": testProviderFuncFixed(old),
 			},
 		),
 		State: s,
 	})
 
-	plan, err := ctx.Plan()
-	if err != nil {
-		t.Fatalf("err: %s", err)
+	_, err := ctx.Plan()
+	if err == nil {
+		t.Fatal("should error")
 	}
-
-	actual := strings.TrimSpace(plan.String())
-	expected := strings.TrimSpace(testTerraformPlanTaintIgnoreChangesStr)
-	if actual != expected {
-		t.Fatalf("bad:\n%s", actual)
+	if !strings.Contains(err.Error(), "does not support moving resources") {
+		t.Fatalf("bad: %s", err)
+	}
+	if p.MoveResourceStateCalled {
+		t.Fatal("MoveResourceState should not be called")
 	}
 }
 
-// Fails about 50% of the time before the fix for GH-4982, covers the fix.
-func TestContext2Plan_taintDestroyInterpolatedCountRace(t *testing.T) {
-	m := testModule(t, "plan-taint-interpolated-count")
+func TestContext2Plan_providerMeta(t *testing.T) {
+	m := testModule(t, "plan-provider-meta")
 	p := testProvider("aws")
 	p.DiffFn = testDiffFn
-	s := &State{
-		Modules: []*ModuleState{
-			&ModuleState{
-				Path: rootModulePath,
-				Resources: map[string]*ResourceState{
-					"aws_instance.foo.0": &ResourceState{
-						Type: "aws_instance",
-						Primary: &InstanceState{
-							ID:      "bar",
-							Tainted: true,
-						},
-					},
-					"aws_instance.foo.1": &ResourceState{
-						Type:    "aws_instance",
-						Primary: &InstanceState{ID: "bar"},
-					},
-					"aws_instance.foo.2": &ResourceState{
-						Type:    "aws_instance",
-						Primary: &InstanceState{ID: "bar"},
-					},
-				},
-			},
+	p.GetSchemaReturn = &ProviderSchema{
+		Capabilities: ProviderCapabilities{
+			ProviderMeta: true,
 		},
 	}
 	ctx := testContext2(t, &ContextOpts{
@@ -2666,39 +2812,28 @@ func TestContext2Plan_taintDestroyInterpolatedCountRace(t *testing.T) {
 				"aws": testProviderFuncFixed(p),
 			},
 		),
-		State: s,
 	})
 
-	for i := 0; i < 100; i++ {
-		plan, err := ctx.Plan()
-		if err != nil {
-			t.Fatalf("err: %s", err)
-		}
-
-		actual := strings.TrimSpace(plan.String())
-		expected := strings.TrimSpace(`
-DIFF:
-
-DESTROY/CREATE: aws_instance.foo.0
-  type: "" => "aws_instance"
-
-STATE:
+	if _, err := ctx.Plan(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
 
-aws_instance.foo.0: (tainted)
-  ID = bar
-aws_instance.foo.1:
-  ID = bar
-aws_instance.foo.2:
-  ID = bar
-		`)
-		if actual != expected {
-			t.Fatalf("[%d] bad:\n%s\nexpected:\n%s\n", i, actual, expected)
-		}
+	if !p.ConfigureProviderMetaCalled {
+		t.Fatal("ConfigureProviderMeta should be called")
+	}
+	var got []string
+	for _, meta := range p.ConfigureProviderMetaMetas {
+		got = append(got, fmt.Sprintf(
+			"%s=%s", strings.Join(meta.ModulePath, "."), meta.Config.Raw["module_name"]))
+	}
+	want := []string{"root=root", "root.child=child"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("bad: %#v", got)
 	}
 }
 
-func TestContext2Plan_targeted(t *testing.T) {
-	m := testModule(t, "plan-targeted")
+func TestContext2Plan_providerMetaUnsupported(t *testing.T) {
+	m := testModule(t, "plan-provider-meta")
 	p := testProvider("aws")
 	p.DiffFn = testDiffFn
 	ctx := testContext2(t, &ContextOpts{
@@ -2708,17 +2843,838 @@ func TestContext2Plan_targeted(t *testing.T) {
 				"aws": testProviderFuncFixed(p),
 			},
 		),
-		Targets: []string{"aws_instance.foo"},
 	})
 
-	plan, err := ctx.Plan()
-	if err != nil {
-		t.Fatalf("err: %s", err)
+	_, err := ctx.Plan()
+	if err == nil {
+		t.Fatal("should error")
+	}
+	if !strings.Contains(err.Error(), "provider_meta is declared by the root module, module.child") {
+		t.Fatalf("bad: %s", err)
+	}
+	if p.ConfigureProviderMetaCalled {
+		t.Fatal("ConfigureProviderMeta should not be called")
 	}
+}
 
-	actual := strings.TrimSpace(plan.String())
-	expected := strings.TrimSpace(`
-DIFF:
+func TestContext2Plan_upgradeResourceState(t *testing.T) {
+	m := testModule(t, "plan-upgrade-state")
+	p := testProvider("aws")
+	p.DiffFn = testDiffFn
+	p.GetSchemaReturn = testUpgradeProviderSchema(2)
+	p.UpgradeResourceStateFn = func(req *UpgradeResourceStateRequest) (*InstanceState, error) {
+		if req.Version != 0 {
+			return nil, fmt.Errorf("bad version %d", req.Version)
+		}
+		return &InstanceState{
+			ID: req.State.ID,
+			Attributes: map[string]string{
+				"id":  req.State.ID,
+				"foo": req.State.Attributes["old_foo"],
+			},
+		}, nil
+	}
+	s := testUpgradeState(nil)
+	ctx := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(p),
+			},
+		),
+		State: s,
+	})
+
+	plan, err := ctx.Plan()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if !p.UpgradeResourceStateCalled {
+		t.Fatal("UpgradeResourceState should be called")
+	}
+	if !plan.Diff.Empty() {
+		t.Fatalf("expected empty plan, got:\n%s", plan)
+	}
+
+	is := plan.State.RootModule().Resources["aws_instance.foo"].Primary
+	if is.Attributes["foo"] != "bar" || is.Meta["schema_version"] != "2" {
+		t.Fatalf("bad: %#v", is)
+	}
+	if _, ok := s.RootModule().Resources["aws_instance.foo"].Primary.Attributes["old_foo"]; !ok {
+		t.Fatal("original state was modified")
+	}
+}
+
+func TestContext2Plan_upgradeResourceStateCurrent(t *testing.T) {
+	m := testModule(t, "plan-upgrade-state")
+	p := testProvider("aws")
+	p.DiffFn = testDiffFn
+	p.GetSchemaReturn = testUpgradeProviderSchema(2)
+	ctx := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(p),
+			},
+		),
+		State: testUpgradeState(map[string]interface{}{"schema_version": "2"}),
+	})
+
+	if _, err := ctx.Plan(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if p.UpgradeResourceStateCalled {
+		t.Fatal("UpgradeResourceState should not be called")
+	}
+}
+
+func TestContext2Plan_upgradeResourceStateNewer(t *testing.T) {
+	m := testModule(t, "plan-upgrade-state")
+	p := testProvider("aws")
+	p.DiffFn = testDiffFn
+	p.GetSchemaReturn = testUpgradeProviderSchema(2)
+	ctx := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(p),
+			},
+		),
+		State: testUpgradeState(map[string]interface{}{"schema_version": "3"}),
+	})
+
+	_, err := ctx.Plan()
+	if err == nil {
+		t.Fatal("should error")
+	}
+	if !strings.Contains(err.Error(), "only supports up to version 2") {
+		t.Fatalf("bad: %s", err)
+	}
+	if p.UpgradeResourceStateCalled {
+		t.Fatal("UpgradeResourceState should not be called")
+	}
+}
+
+func TestContext2Plan_upgradeResourceStateInvalid(t *testing.T) {
+	m := testModule(t, "plan-upgrade-state")
+	p := testProvider("aws")
+	p.DiffFn = testDiffFn
+	p.GetSchemaReturn = testUpgradeProviderSchema(2)
+	p.UpgradeResourceStateFn = func(req *UpgradeResourceStateRequest) (*InstanceState, error) {
+		// The old attribute isn't part of the current schema
+		return req.State, nil
+	}
+	ctx := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(p),
+			},
+		),
+		State: testUpgradeState(nil),
+	})
+
+	_, err := ctx.Plan()
+	if err == nil {
+		t.Fatal("should error")
+	}
+	if !strings.Contains(err.Error(), `unsupported attribute "old_foo"`) {
+		t.Fatalf("bad: %s", err)
+	}
+}
+
+func testUpgradeProviderSchema(version int) *ProviderSchema {
+	return &ProviderSchema{
+		ResourceTypes: map[string]*configschema.Block{
+			"aws_instance": {
+				Attributes: map[string]*configschema.Attribute{
+					"foo": {Type: cty.String, Optional: true},
+				},
+			},
+		},
+		ResourceTypeSchemaVersions: map[string]int{
+			"aws_instance": version,
+		},
+		Capabilities: ProviderCapabilities{
+			UpgradeResourceState: true,
+		},
+	}
+}
+
+func testUpgradeState(meta map[string]interface{}) *State {
+	return &State{
+		Modules: []*ModuleState{
+			&ModuleState{
+				Path: rootModulePath,
+				Resources: map[string]*ResourceState{
+					"aws_instance.foo": &ResourceState{
+						Type: "aws_instance",
+						Primary: &InstanceState{
+							ID: "bar",
+							Attributes: map[string]string{
+								"id":      "bar",
+								"old_foo": "bar",
+							},
+							Meta: meta,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// This tests that configurations with UUIDs don't produce errors.
+// For shadows, this would produce errors since a UUID changes every time.
+func TestContext2Plan_shadowUuid(t *testing.T) {
+	m := testModule(t, "plan-shadow-uuid")
+	p := testProvider("aws")
+	p.DiffFn = testDiffFn
+	ctx := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(p),
+			},
+		),
+	})
+
+	_, err := ctx.Plan()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+}
+
+func TestContext2Plan_state(t *testing.T) {
+	m := testModule(t, "plan-good")
+	p := testProvider("aws")
+	p.DiffFn = testDiffFn
+	s := &State{
+		Modules: []*ModuleState{
+			&ModuleState{
+				Path: rootModulePath,
+				Resources: map[string]*ResourceState{
+					"aws_instance.foo": &ResourceState{
+						Type: "aws_instance",
+						Primary: &InstanceState{
+							ID: "bar",
+						},
+					},
+				},
+			},
+		},
+	}
+	ctx := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(p),
+			},
+		),
+		State: s,
+	})
+
+	plan, err := ctx.Plan()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if len(plan.Diff.RootModule().Resources) < 2 {
+		t.Fatalf("bad: %#v", plan.Diff.RootModule().Resources)
+	}
+
+	actual := strings.TrimSpace(plan.String())
+	expected := strings.TrimSpace(testTerraformPlanStateStr)
+	if actual != expected {
+		t.Fatalf("bad:\n%s\n\nexpected:\n\n%s", actual, expected)
+	}
+}
+
+func TestContext2Plan_taint(t *testing.T) {
+	m := testModule(t, "plan-taint")
+	p := testProvider("aws")
+	p.DiffFn = testDiffFn
+	s := &State{
+		Modules: []*ModuleState{
+			&ModuleState{
+				Path: rootModulePath,
+				Resources: map[string]*ResourceState{
+					"aws_instance.foo": &ResourceState{
+						Type: "aws_instance",
+						Primary: &InstanceState{
+							ID:         "bar",
+							Attributes: map[string]string{"num": "2"},
+						},
+					},
+					"aws_instance.bar": &ResourceState{
+						Type: "aws_instance",
+						Primary: &InstanceState{
+							ID:      "baz",
+							Tainted: true,
+						},
+					},
+				},
+			},
+		},
+	}
+	ctx := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(p),
+			},
+		),
+		State: s,
+	})
+
+	plan, err := ctx.Plan()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	actual := strings.TrimSpace(plan.String())
+	expected := strings.TrimSpace(testTerraformPlanTaintStr)
+	if actual != expected {
+		t.Fatalf("bad:\n%s", actual)
+	}
+}
+
+func TestContext2Plan_refreshDrift(t *testing.T) {
+	m := testModule(t, "plan-taint")
+	p := testProvider("aws")
+	p.DiffFn = testDiffFn
+	p.RefreshFn = func(info *InstanceInfo, s *InstanceState) (*InstanceState, error) {
+		if info.Id != "aws_instance.foo" {
+			return s, nil
+		}
+		s = s.DeepCopy()
+		s.Attributes["num"] = "3"
+		return s, nil
+	}
+	s := &State{
+		Modules: []*ModuleState{
+			&ModuleState{
+				Path: rootModulePath,
+				Resources: map[string]*ResourceState{
+					"aws_instance.foo": &ResourceState{
+						Type: "aws_instance",
+						Primary: &InstanceState{
+							ID:         "bar",
+							Attributes: map[string]string{"num": "2"},
+						},
+					},
+					"aws_instance.bar": &ResourceState{
+						Type: "aws_instance",
+						Primary: &InstanceState{
+							ID:         "baz",
+							Attributes: map[string]string{"foo": "2"},
+						},
+					},
+				},
+			},
+		},
+	}
+	ctx := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(p),
+			},
+		),
+		State: s,
+	})
+
+	if _, err := ctx.Refresh(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	plan, err := ctx.Plan()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if plan.Drift == nil {
+		t.Fatal("plan should record drift")
+	}
+	md := plan.Drift.RootModule()
+	if md == nil || len(md.Resources) != 1 {
+		t.Fatalf("wrong drift:\n%s", plan.Drift)
+	}
+	expected := &InstanceDiff{
+		Attributes: map[string]*ResourceAttrDiff{
+			"num": &ResourceAttrDiff{Old: "2", New: "3"},
+		},
+	}
+	if got := md.Resources["aws_instance.foo"]; !reflect.DeepEqual(got, expected) {
+		t.Fatalf("wrong drift for aws_instance.foo\ngot:  %#v\nwant: %#v", got, expected)
+	}
+
+	// The refreshed value is the basis for the plan itself.
+	id := plan.Diff.RootModule().Resources["aws_instance.foo"]
+	if id == nil || id.Attributes["num"] == nil || id.Attributes["num"].Old != "3" {
+		t.Fatalf("plan should be based on the refreshed state:\n%s", plan.Diff)
+	}
+}
+
+func TestContext2Plan_noRefreshNoDrift(t *testing.T) {
+	m := testModule(t, "plan-good")
+	p := testProvider("aws")
+	p.DiffFn = testDiffFn
+	ctx := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(p),
+			},
+		),
+	})
+
+	plan, err := ctx.Plan()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if plan.Drift != nil {
+		t.Fatalf("plan without refresh should have no drift:\n%s", plan.Drift)
+	}
+	if !plan.SkipRefresh {
+		t.Fatal("plan without refresh should record that refresh was skipped")
+	}
+}
+
+func TestContext2Plan_refreshOnly(t *testing.T) {
+	m := testModule(t, "plan-taint")
+	p := testProvider("aws")
+	p.DiffFn = testDiffFn
+	p.ApplyFn = testApplyFn
+	p.RefreshFn = func(info *InstanceInfo, s *InstanceState) (*InstanceState, error) {
+		s = s.DeepCopy()
+		s.Attributes["num"] = "3"
+		return s, nil
+	}
+	s := &State{
+		Modules: []*ModuleState{
+			&ModuleState{
+				Path: rootModulePath,
+				Resources: map[string]*ResourceState{
+					"aws_instance.foo": &ResourceState{
+						Type: "aws_instance",
+						Primary: &InstanceState{
+							ID:         "bar",
+							Attributes: map[string]string{"num": "2"},
+						},
+					},
+				},
+			},
+		},
+	}
+	ctx := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(p),
+			},
+		),
+		State:       s,
+		RefreshOnly: true,
+	})
+
+	if _, err := ctx.Refresh(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	plan, err := ctx.Plan()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !plan.RefreshOnly || plan.SkipRefresh {
+		t.Fatalf("wrong plan mode: refresh-only %t, skip refresh %t", plan.RefreshOnly, plan.SkipRefresh)
+	}
+	if !plan.Diff.Empty() {
+		t.Fatalf("refresh-only plan should have no changes:\n%s", plan.Diff)
+	}
+	if plan.Drift == nil || plan.Drift.RootModule() == nil {
+		t.Fatal("refresh-only plan should record drift")
+	}
+
+	// Applying the saved plan only keeps the refreshed state.
+	var buf bytes.Buffer
+	if err := WritePlan(plan, &buf); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	plan, err = ReadPlan(&buf)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	ctx, err = plan.Context(&ContextOpts{
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(p),
+			},
+		),
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	state, err := ctx.Apply()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if p.ApplyCalled {
+		t.Fatal("refresh-only apply should not apply any changes")
+	}
+
+	rs := state.RootModule().Resources
+	if len(rs) != 1 {
+		t.Fatalf("wrong resources in state:\n%s", state)
+	}
+	if got := rs["aws_instance.foo"].Primary.Attributes["num"]; got != "3" {
+		t.Fatalf("state should be refreshed, got num = %q", got)
+	}
+}
+
+func TestContext2Plan_refreshOnlyDestroy(t *testing.T) {
+	m := testModule(t, "plan-good")
+	p := testProvider("aws")
+	p.DiffFn = testDiffFn
+	ctx := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(p),
+			},
+		),
+		Destroy:     true,
+		RefreshOnly: true,
+	})
+
+	if _, err := ctx.Plan(); err == nil {
+		t.Fatal("should error")
+	}
+}
+
+func TestContext2Plan_replace(t *testing.T) {
+	m := testModule(t, "plan-taint")
+	p := testProvider("aws")
+	p.DiffFn = testDiffFn
+	s := &State{
+		Modules: []*ModuleState{
+			&ModuleState{
+				Path: rootModulePath,
+				Resources: map[string]*ResourceState{
+					"aws_instance.foo": &ResourceState{
+						Type: "aws_instance",
+						Primary: &InstanceState{
+							ID: "bar",
+							Attributes: map[string]string{
+								"num":  "2",
+								"type": "aws_instance",
+							},
+						},
+					},
+					"aws_instance.bar": &ResourceState{
+						Type: "aws_instance",
+						Primary: &InstanceState{
+							ID: "baz",
+							Attributes: map[string]string{
+								"foo":  "2",
+								"type": "aws_instance",
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	ctx := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(p),
+			},
+		),
+		State:   s,
+		Replace: []string{"aws_instance.foo"},
+	})
+
+	plan, err := ctx.Plan()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	actual := strings.TrimSpace(plan.String())
+	expected := strings.TrimSpace(testTerraformPlanReplaceStr)
+	if actual != expected {
+		t.Fatalf("bad:\n%s", actual)
+	}
+
+	rd := plan.Diff.RootModule().Resources["aws_instance.foo"]
+	if got, want := rd.ActionReason, DiffActionReasonReplaceByRequest; got != want {
+		t.Fatalf("wrong action reason %d; want %d", got, want)
+	}
+	if rd.DestroyTainted {
+		t.Fatal("replaced instance should not be marked as tainted")
+	}
+
+	// The state must not have been modified
+	if s.RootModule().Resources["aws_instance.foo"].Primary.Tainted {
+		t.Fatal("state should not be tainted")
+	}
+}
+
+func TestContext2Plan_replaceInvalid(t *testing.T) {
+	m := testModule(t, "plan-taint")
+	p := testProvider("aws")
+	p.DiffFn = testDiffFn
+	ctx := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(p),
+			},
+		),
+		Replace: []string{"data.aws_instance.foo"},
+	})
+
+	_, err := ctx.Plan()
+	if err == nil {
+		t.Fatal("should error")
+	}
+	if !strings.Contains(err.Error(), "only managed resources") {
+		t.Fatalf("wrong error: %s", err)
+	}
+}
+
+func TestContext2Plan_replaceCount(t *testing.T) {
+	m := testModule(t, "apply-targeted-count")
+	p := testProvider("aws")
+	p.DiffFn = testDiffFn
+	resources := make(map[string]*ResourceState)
+	for _, name := range []string{"foo", "bar"} {
+		for i := 0; i < 3; i++ {
+			resources[fmt.Sprintf("aws_instance.%s.%d", name, i)] = &ResourceState{
+				Type: "aws_instance",
+				Primary: &InstanceState{
+					ID: fmt.Sprintf("i-%s%d", name, i),
+				},
+			}
+		}
+	}
+	s := &State{
+		Modules: []*ModuleState{
+			&ModuleState{
+				Path:      rootModulePath,
+				Resources: resources,
+			},
+		},
+	}
+
+	ctx := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(p),
+			},
+		),
+		State:   s,
+		Replace: []string{"aws_instance.foo[1]"},
+	})
+
+	plan, err := ctx.Plan()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	var replaced []string
+	for k, rd := range plan.Diff.RootModule().Resources {
+		if rd.ActionReason == DiffActionReasonReplaceByRequest {
+			replaced = append(replaced, k)
+		}
+	}
+	if want := []string{"aws_instance.foo.1"}; !reflect.DeepEqual(replaced, want) {
+		t.Fatalf("wrong instances replaced %#v; want %#v", replaced, want)
+	}
+
+	// Without an index, the address doesn't match any of the instances.
+	ctx = testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(p),
+			},
+		),
+		State:   s,
+		Replace: []string{"aws_instance.foo"},
+	})
+
+	_, err = ctx.Plan()
+	if err == nil {
+		t.Fatal("should error")
+	}
+	if !strings.Contains(err.Error(), "no resource instance") {
+		t.Fatalf("wrong error: %s", err)
+	}
+}
+
+func TestContext2Plan_replaceNotInState(t *testing.T) {
+	m := testModule(t, "plan-taint")
+	p := testProvider("aws")
+	p.DiffFn = testDiffFn
+	ctx := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(p),
+			},
+		),
+		Replace: []string{"aws_instance.foo"},
+	})
+
+	_, err := ctx.Plan()
+	if err == nil {
+		t.Fatal("should error")
+	}
+	if !strings.Contains(err.Error(), "no resource instance") {
+		t.Fatalf("wrong error: %s", err)
+	}
+}
+
+func TestContext2Apply_taintIgnoreChanges(t *testing.T) {
+	m := testModule(t, "plan-taint-ignore-changes")
+	p := testProvider("aws")
+	p.ApplyFn = testApplyFn
+	p.DiffFn = testDiffFn
+	s := &State{
+		Modules: []*ModuleState{
+			&ModuleState{
+				Path: rootModulePath,
+				Resources: map[string]*ResourceState{
+					"aws_instance.foo": &ResourceState{
+						Type: "aws_instance",
+						Primary: &InstanceState{
+							ID: "foo",
+							Attributes: map[string]string{
+								"vars": "foo",
+								"type": "aws_instance",
+							},
+							Tainted: true,
+						},
+					},
+				},
+			},
+		},
+	}
+	ctx := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(p),
+			},
+		),
+		State: s,
+	})
+
+	plan, err := ctx.Plan()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	actual := strings.TrimSpace(plan.String())
+	expected := strings.TrimSpace(testTerraformPlanTaintIgnoreChangesStr)
+	if actual != expected {
+		t.Fatalf("bad:\n%s", actual)
+	}
+}
+
+// Fails about 50% of the time before the fix for GH-4982, covers the fix.
+func TestContext2Plan_taintDestroyInterpolatedCountRace(t *testing.T) {
+	m := testModule(t, "plan-taint-interpolated-count")
+	p := testProvider("aws")
+	p.DiffFn = testDiffFn
+	s := &State{
+		Modules: []*ModuleState{
+			&ModuleState{
+				Path: rootModulePath,
+				Resources: map[string]*ResourceState{
+					"aws_instance.foo.0": &ResourceState{
+						Type: "aws_instance",
+						Primary: &InstanceState{
+							ID:      "bar",
+							Tainted: true,
+						},
+					},
+					"aws_instance.foo.1": &ResourceState{
+						Type:    "aws_instance",
+						Primary: &InstanceState{ID: "bar"},
+					},
+					"aws_instance.foo.2": &ResourceState{
+						Type:    "aws_instance",
+						Primary: &InstanceState{ID: "bar"},
+					},
+				},
+			},
+		},
+	}
+	ctx := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(p),
+			},
+		),
+		State: s,
+	})
+
+	for i := 0; i < 100; i++ {
+		plan, err := ctx.Plan()
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+
+		actual := strings.TrimSpace(plan.String())
+		expected := strings.TrimSpace(`
+DIFF:
+
+DESTROY/CREATE: aws_instance.foo.0
+  type: "" => "aws_instance"
+
+STATE:
+
+aws_instance.foo.0: (tainted)
+  ID = bar
+aws_instance.foo.1:
+  ID = bar
+aws_instance.foo.2:
+  ID = bar
+		`)
+		if actual != expected {
+			t.Fatalf("[%d] bad:\n%s\nexpected:\n%s\n", i, actual, expected)
+		}
+	}
+}
+
+func TestContext2Plan_targeted(t *testing.T) {
+	m := testModule(t, "plan-targeted")
+	p := testProvider("aws")
+	p.DiffFn = testDiffFn
+	ctx := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(p),
+			},
+		),
+		Targets: []string{"aws_instance.foo"},
+	})
+
+	plan, err := ctx.Plan()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	actual := strings.TrimSpace(plan.String())
+	expected := strings.TrimSpace(`
+DIFF:
 
 CREATE: aws_instance.foo
   num:  "" => "2"
@@ -3665,3 +4621,154 @@ resource "aws_instance" "foo" {
 		t.Fatal("expected error")
 	}
 }
+
+func TestContext2Plan_providerFunction(t *testing.T) {
+	m := testModule(t, "plan-provider-function")
+	p := testProvider("aws")
+	p.DiffFn = testDiffFn
+	p.FunctionsReturn = []ProviderFunction{
+		{
+			Name:       "upper",
+			ParamTypes: []ast.Type{ast.TypeString},
+			ReturnType: ast.TypeString,
+		},
+	}
+	p.CallFunctionFn = func(name string, args []interface{}) (interface{}, error) {
+		if name != "upper" {
+			return nil, fmt.Errorf("unexpected function %q", name)
+		}
+		return strings.ToUpper(args[0].(string)), nil
+	}
+	ctx := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(p),
+			},
+		),
+	})
+
+	plan, err := ctx.Plan()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if !p.CallFunctionCalled {
+		t.Fatal("CallFunction should be called")
+	}
+
+	rd := plan.Diff.RootModule().Resources["aws_instance.foo"]
+	if rd == nil {
+		t.Fatalf("missing diff for aws_instance.foo")
+	}
+	if got, want := rd.Attributes["foo"].New, "BAR"; got != want {
+		t.Fatalf("wrong value for foo %q; want %q", got, want)
+	}
+}
+
+func TestContext2Plan_providerFunctionUnknown(t *testing.T) {
+	m := testModule(t, "plan-provider-function")
+	p := testProvider("aws")
+	p.DiffFn = testDiffFn
+	ctx := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(p),
+			},
+		),
+	})
+
+	_, err := ctx.Plan()
+	if err == nil {
+		t.Fatal("should error")
+	}
+	if !strings.Contains(err.Error(), `does not have a function named "upper"`) {
+		t.Fatalf("wrong error: %s", err)
+	}
+}
+
+func TestContext2Plan_checks(t *testing.T) {
+	m := testModule(t, "plan-checks")
+	p := testProvider("aws")
+	p.DiffFn = testDiffFn
+	ctx := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(p),
+			},
+		),
+	})
+
+	plan, err := ctx.Plan()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// The ID of the instance isn't known until it's created.
+	want := []*CheckResult{
+		{Address: "check.id", Status: CheckUnknown},
+		{Address: "check.num", Status: CheckPass},
+	}
+	if !reflect.DeepEqual(plan.Checks, want) {
+		t.Fatalf("wrong checks\ngot:  %s\nwant: %s", spew.Sdump(plan.Checks), spew.Sdump(want))
+	}
+}
+
+func TestContext2Plan_checksDataSourceError(t *testing.T) {
+	m := testModule(t, "plan-checks-data-error")
+	p := testProvider("aws")
+	p.ApplyFn = testApplyFn
+	p.DiffFn = testDiffFn
+	p.ReadDataDiffReturnError = errors.New("connection refused")
+	ctx := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(p),
+			},
+		),
+	})
+
+	// A data source scoped to a check that can't be read is an error of
+	// the check, and doesn't stop the refresh, plan or apply.
+	if _, err := ctx.Refresh(); err != nil {
+		t.Fatalf("refresh: %s", err)
+	}
+	plan, err := ctx.Plan()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if plan.Diff.RootModule().Resources["aws_instance.foo"] == nil {
+		t.Fatalf("instance should be planned:\n%s", plan.Diff)
+	}
+	checkResults := func(results []*CheckResult, msg string) {
+		t.Helper()
+		if len(results) != 2 {
+			t.Fatalf("wrong checks: %s", spew.Sdump(results))
+		}
+		health, num := results[0], results[1]
+		if health.Address != "check.health" || health.Status != CheckError {
+			t.Fatalf("wrong health check: %s", spew.Sdump(health))
+		}
+		if len(health.FailureMessages) == 0 || !strings.Contains(health.FailureMessages[0], msg) {
+			t.Fatalf("wrong health check messages: %#v", health.FailureMessages)
+		}
+		if num.Address != "check.num" || num.Status != CheckPass {
+			t.Fatalf("wrong num check: %s", spew.Sdump(num))
+		}
+	}
+	checkResults(plan.Checks, "The data source data.aws_data_source.status could not be read: connection refused.")
+
+	state, err := ctx.Apply()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if state.RootModule().Resources["aws_instance.foo"] == nil {
+		t.Fatalf("instance should be created:\n%s", state)
+	}
+	// The data source isn't read again during apply, since it has no diff,
+	// so the assertion that refers to it can't be evaluated.
+	checkResults(state.Checks, "data.aws_data_source.status")
+}
@@ -63,6 +63,98 @@ func TestContext2Refresh(t *testing.T) {
 	}
 }
 
+// Test that refreshing keeps the record of which attributes are sensitive,
+// so that values derived from them are still redacted in the plan.
+func TestContext2Refresh_sensitive(t *testing.T) {
+	p := testProvider("aws")
+	p.DiffFn = testDiffFn
+	m := testModule(t, "refresh-sensitive")
+	ctx := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(p),
+			},
+		),
+		State: &State{
+			Modules: []*ModuleState{
+				&ModuleState{
+					Path: rootModulePath,
+					Resources: map[string]*ResourceState{
+						"aws_instance.foo": &ResourceState{
+							Type: "aws_instance",
+							Primary: &InstanceState{
+								ID: "foo",
+								Attributes: map[string]string{
+									"id":     "foo",
+									"secret": "hunter2",
+								},
+								SensitiveAttributes: []string{"secret"},
+							},
+						},
+					},
+				},
+			},
+		},
+	})
+
+	// Like helper/schema, the provider knows nothing about sensitivity and
+	// returns states without it.
+	p.RefreshFn = func(info *InstanceInfo, s *InstanceState) (*InstanceState, error) {
+		return &InstanceState{
+			ID: s.ID,
+			Attributes: map[string]string{
+				"id":     s.ID,
+				"secret": s.Attributes["secret"],
+			},
+		}, nil
+	}
+	p.ReadDataDiffFn = func(info *InstanceInfo, c *ResourceConfig) (*InstanceDiff, error) {
+		return &InstanceDiff{
+			Attributes: map[string]*ResourceAttrDiff{
+				"foo": &ResourceAttrDiff{New: c.Config["foo"].(string)},
+			},
+		}, nil
+	}
+	p.ReadDataApplyFn = func(info *InstanceInfo, d *InstanceDiff) (*InstanceState, error) {
+		return &InstanceState{
+			ID: "baz",
+			Attributes: map[string]string{
+				"id":  "baz",
+				"foo": d.Attributes["foo"].New,
+			},
+		}, nil
+	}
+
+	s, err := ctx.Refresh()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	mod := s.RootModule()
+	foo := mod.Resources["aws_instance.foo"].Primary
+	if got, want := foo.SensitiveAttributes, []string{"secret"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong sensitive attributes for aws_instance.foo %#v; want %#v", got, want)
+	}
+	baz := mod.Resources["data.aws_data_source.baz"].Primary
+	if got, want := baz.SensitiveAttributes, []string{"foo"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong sensitive attributes for data.aws_data_source.baz %#v; want %#v", got, want)
+	}
+
+	plan, err := ctx.Plan()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	rd := plan.Diff.RootModule().Resources["aws_instance.bar"]
+	if rd == nil {
+		t.Fatal("missing diff for aws_instance.bar")
+	}
+	if !rd.Attributes["foo"].Sensitive {
+		t.Fatal("foo should be sensitive in the diff")
+	}
+}
+
 func TestContext2Refresh_dataComputedModuleVar(t *testing.T) {
 	p := testProvider("aws")
 	m := testModule(t, "refresh-data-module-var")
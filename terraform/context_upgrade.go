@@ -0,0 +1,311 @@
+package terraform
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform/config"
+	"github.com/hashicorp/terraform/config/configschema"
+)
+
+// upgradeResourceStates asks the providers that support it to upgrade the
+// state of any managed resource instance that was recorded with an earlier
+// version of its resource type's schema, so that the rest of Terraform only
+// ever sees states that conform to the current schemas.
+//
+// Instances already at the current version are skipped, as are instances
+// of resource types whose providers don't report the UpgradeResourceState
+// capability; those providers upgrade states themselves when refreshing. If
+// anything is upgraded then c.state is replaced with an updated copy,
+// leaving the caller's state untouched.
+func (c *Context) upgradeResourceStates() error {
+	if c.state == nil {
+		return nil
+	}
+
+	u := &resourceUpgrader{
+		components: c.components,
+		state:      c.state.DeepCopy(),
+		providers:  make(map[string]*upgradeProvider),
+	}
+	defer u.close()
+
+	if err := u.upgrade(); err != nil {
+		return err
+	}
+
+	if u.upgraded {
+		c.state = u.state
+	}
+	return nil
+}
+
+// resourceUpgrader upgrades the resource instance states in a state.
+type resourceUpgrader struct {
+	components contextComponentFactory
+	state      *State
+	upgraded   bool
+
+	// providers are the unconfigured provider instances used to upgrade
+	// states, along with their schemas, by provider type name.
+	providers map[string]*upgradeProvider
+}
+
+type upgradeProvider struct {
+	provider ResourceProvider
+	schema   *ProviderSchema
+}
+
+func (u *resourceUpgrader) upgrade() error {
+	// Each provider's schema is requested just once, for all of the
+	// resource types of that provider that are in the state.
+	types := make(map[string][]string)
+	for _, mod := range u.state.Modules {
+		for _, rs := range mod.Resources {
+			if rs.Primary == nil && len(rs.Deposed) == 0 {
+				continue
+			}
+			if pt := upgradeProviderType(rs); pt != "" && !strSliceContains(types[pt], rs.Type) {
+				types[pt] = append(types[pt], rs.Type)
+			}
+		}
+	}
+
+	for _, mod := range u.state.Modules {
+		keys := make([]string, 0, len(mod.Resources))
+		for k := range mod.Resources {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			key, err := ParseResourceStateKey(k)
+			if err != nil {
+				return err
+			}
+			if key.Mode != config.ManagedResourceMode {
+				continue
+			}
+
+			rs := mod.Resources[k]
+			pt := upgradeProviderType(rs)
+			if pt == "" {
+				continue
+			}
+			p, err := u.provider(pt, types[pt])
+			if err != nil {
+				return err
+			}
+			if !p.schema.Capabilities.UpgradeResourceState {
+				continue
+			}
+
+			if err := u.upgradeResource(mod, k, rs, pt, p); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (u *resourceUpgrader) upgradeResource(mod *ModuleState, k string, rs *ResourceState, providerType string, p *upgradeProvider) error {
+	addr := movedAddr(mod, k)
+	upgrader, ok := p.provider.(ResourceProviderUpgrader)
+	if !ok {
+		return fmt.Errorf(
+			"%s: provider %q reports that it can upgrade resource state, but "+
+				"doesn't implement it; this is a bug in the provider",
+			addr, providerType)
+	}
+
+	block := p.schema.ResourceTypes[rs.Type]
+	current := p.schema.ResourceTypeSchemaVersions[rs.Type]
+	info := &InstanceInfo{
+		Id:         k,
+		Type:       rs.Type,
+		ModulePath: normalizeModulePath(mod.Path),
+	}
+
+	upgrade := func(is *InstanceState) (*InstanceState, error) {
+		if is == nil {
+			return nil, nil
+		}
+
+		version, err := instanceSchemaVersion(is)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", addr, err)
+		}
+		switch {
+		case version == current:
+			return is, nil
+		case version > current:
+			return nil, fmt.Errorf(
+				"%s: state has schema version %d, but provider %q only supports "+
+					"up to version %d; the state was probably created by a newer "+
+					"version of the provider",
+				addr, version, providerType, current)
+		}
+
+		log.Printf("[INFO] upgrading state of %s from schema version %d to %d", addr, version, current)
+		result, err := upgrader.UpgradeResourceState(&UpgradeResourceStateRequest{
+			Info:    info,
+			Version: version,
+			State:   is.DeepCopy(),
+		})
+		if err != nil {
+			return nil, fmt.Errorf(
+				"%s: upgrading state from schema version %d: %s", addr, version, err)
+		}
+		if result == nil || result.ID == "" {
+			return nil, fmt.Errorf(
+				"%s: provider %q returned no state when upgrading state from "+
+					"schema version %d; this is a bug in the provider",
+				addr, providerType, version)
+		}
+		if block != nil {
+			if err := checkStateConformance(block, result.Attributes); err != nil {
+				return nil, fmt.Errorf(
+					"%s: provider %q returned invalid state when upgrading state "+
+						"from schema version %d: %s; this is a bug in the provider",
+					addr, providerType, version, err)
+			}
+		}
+
+		if result.Meta == nil {
+			result.Meta = make(map[string]interface{})
+		}
+		result.Meta["schema_version"] = strconv.Itoa(current)
+		result.Tainted = is.Tainted
+		u.upgraded = true
+		return result, nil
+	}
+
+	primary, err := upgrade(rs.Primary)
+	if err != nil {
+		return err
+	}
+	deposed := make([]*InstanceState, len(rs.Deposed))
+	for i, is := range rs.Deposed {
+		if deposed[i], err = upgrade(is); err != nil {
+			return err
+		}
+	}
+
+	rs.Primary = primary
+	rs.Deposed = deposed
+	return nil
+}
+
+func (u *resourceUpgrader) provider(typeName string, resourceTypes []string) (*upgradeProvider, error) {
+	if p, ok := u.providers[typeName]; ok {
+		return p, nil
+	}
+
+	p, err := u.components.ResourceProvider(typeName, "upgrade."+typeName)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"failed to instantiate provider %q to upgrade resource state: %s",
+			typeName, err)
+	}
+
+	// Providers that don't support GetSchema have no capabilities, so they
+	// upgrade states themselves.
+	schema, err := p.GetSchema(&ProviderSchemaRequest{ResourceTypes: resourceTypes})
+	if err != nil || schema == nil {
+		if err != nil {
+			log.Printf("[WARN] failed to get schema of provider %q: %s", typeName, err)
+		}
+		schema = &ProviderSchema{}
+	}
+
+	up := &upgradeProvider{
+		provider: p,
+		schema:   schema,
+	}
+	u.providers[typeName] = up
+	return up, nil
+}
+
+func (u *resourceUpgrader) close() {
+	for _, p := range u.providers {
+		if c, ok := p.provider.(ResourceProviderCloser); ok {
+			c.Close()
+		}
+	}
+}
+
+// upgradeProviderType returns the type name of the provider of the given
+// resource, or an empty string if it can't be determined.
+func upgradeProviderType(rs *ResourceState) string {
+	if rs.Provider != "" {
+		return providerTypeOf(rs.Provider)
+	}
+	if rs.Type == "" {
+		return ""
+	}
+	return strings.SplitN(resourceProvider(rs.Type, ""), ".", 2)[0]
+}
+
+// instanceSchemaVersion returns the version of the schema that the given
+// instance state was recorded with, which is zero if it isn't recorded.
+func instanceSchemaVersion(is *InstanceState) (int, error) {
+	raw, ok := is.Meta["schema_version"]
+	if !ok || raw == nil {
+		return 0, nil
+	}
+
+	v, err := strconv.Atoi(fmt.Sprint(raw))
+	if err != nil || v < 0 {
+		return 0, fmt.Errorf("invalid schema version %q in state", raw)
+	}
+	return v, nil
+}
+
+// checkStateConformance returns an error if any of the given flatmapped
+// attributes doesn't belong to an attribute or nested block in the given
+// schema. Values aren't checked, since the flatmap form doesn't retain their
+// types.
+func checkStateConformance(block *configschema.Block, attrs map[string]string) error {
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if k == "id" {
+			continue
+		}
+		if !flatmapKeyConforms(block, strings.Split(k, ".")) {
+			return fmt.Errorf("unsupported attribute %q", k)
+		}
+	}
+
+	return nil
+}
+
+func flatmapKeyConforms(block *configschema.Block, parts []string) bool {
+	if _, ok := block.Attributes[parts[0]]; ok {
+		// Anything below an attribute is part of its value.
+		return true
+	}
+
+	nested, ok := block.BlockTypes[parts[0]]
+	if !ok {
+		return false
+	}
+
+	// Nested blocks are flattened as a count key or an element key,
+	// which is a list index, set hash or map key, followed by the
+	// keys of the block's own attributes.
+	switch {
+	case len(parts) <= 2:
+		return true
+	default:
+		return flatmapKeyConforms(&nested.Block, parts[2:])
+	}
+}
@@ -2,8 +2,11 @@ package terraform
 
 import (
 	"fmt"
+	"os"
 	"strings"
 	"testing"
+
+	"github.com/hashicorp/terraform/tfdiags"
 )
 
 func TestContext2Validate_badCount(t *testing.T) {
@@ -143,6 +146,25 @@ func TestContext2Validate_countNegative(t *testing.T) {
 	if !diags.HasErrors() {
 		t.Fatalf("bad: %#v", diags)
 	}
+
+	want := tfdiags.Extra{
+		Code:          tfdiags.CodeObjectInvalid,
+		Address:       "aws_instance.test",
+		AttributePath: "count",
+	}
+	found := false
+	for _, diag := range diags {
+		if !strings.Contains(diag.Description().Summary, "Count is less than zero") {
+			continue
+		}
+		found = true
+		if got := tfdiags.GetExtra(diag); got != want {
+			t.Fatalf("wrong extra %#v; want %#v", got, want)
+		}
+	}
+	if !found {
+		t.Fatalf("no validation error for the count: %s", diags.Err())
+	}
 }
 
 func TestContext2Validate_countVariable(t *testing.T) {
@@ -428,6 +450,39 @@ func TestContext2Validate_providerConfig_bad(t *testing.T) {
 	if !strings.Contains(diags.Err().Error(), "bad") {
 		t.Fatalf("bad: %s", diags.Err().Error())
 	}
+	want := tfdiags.Extra{Code: tfdiags.CodeObjectInvalid, Address: "provider.aws"}
+	if got := tfdiags.GetExtra(diags[0]); got != want {
+		t.Fatalf("wrong extra %#v; want %#v", got, want)
+	}
+}
+
+// Diagnostics of an aliased provider in a module must say which
+// configuration they're about
+func TestContext2Validate_providerConfig_badAlias(t *testing.T) {
+	m := testModule(t, "apply-module-provider-alias")
+	p := testProvider("aws")
+	c := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(p),
+			},
+		),
+	})
+
+	p.ValidateReturnErrors = []error{fmt.Errorf("bad")}
+
+	diags := c.Validate()
+	if len(diags) != 1 {
+		t.Fatalf("wrong number of diagnostics %d; want %d", len(diags), 1)
+	}
+	if got, want := diags.Err().Error(), "module.child.provider.aws.eu: bad"; !strings.Contains(got, want) {
+		t.Fatalf("wrong error %q; want it to contain %q", got, want)
+	}
+	want := tfdiags.Extra{Code: tfdiags.CodeObjectInvalid, Address: "module.child.provider.aws.eu"}
+	if got := tfdiags.GetExtra(diags[0]); got != want {
+		t.Fatalf("wrong extra %#v; want %#v", got, want)
+	}
 }
 
 func TestContext2Validate_providerConfig_badEmpty(t *testing.T) {
@@ -538,6 +593,90 @@ func TestContext2Validate_requiredVar(t *testing.T) {
 	}
 }
 
+func TestContext2Validate_varValidation(t *testing.T) {
+	m := testModule(t, "validate-var-validation")
+	p := testProvider("aws")
+	providerResolver := ResourceProviderResolverFixed(
+		map[string]ResourceProviderFactory{
+			"aws": testProviderFuncFixed(p),
+		},
+	)
+
+	cases := map[string]struct {
+		Variables   map[string]interface{}
+		InputValues InputValues
+		Env         string
+		Source      string
+	}{
+		"valid": {
+			Variables: map[string]interface{}{"zone": "a"},
+		},
+		"invalid default": {
+			Source: "was the default value",
+		},
+		"invalid override": {
+			Variables: map[string]interface{}{"zone": "d"},
+			Source:    "was set by -var",
+		},
+		"invalid environment variable": {
+			Env:    "e",
+			Source: "was set by the environment variable TF_VAR_zone",
+		},
+		"invalid in a file": {
+			InputValues: InputValues{
+				"zone": &InputValue{Value: "f", SourceType: ValueFromNamedFile, SourceName: "prod.tfvars"},
+			},
+			Source: "was set in the file prod.tfvars, given by -var-file",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if tc.Env != "" {
+				os.Setenv("TF_VAR_zone", tc.Env)
+				defer os.Unsetenv("TF_VAR_zone")
+			}
+
+			c := testContext2(t, &ContextOpts{
+				Module:           m,
+				ProviderResolver: providerResolver,
+				Variables:        tc.Variables,
+				InputValues:      tc.InputValues,
+			})
+
+			diags := c.Validate()
+			if tc.Source == "" {
+				if diags.HasErrors() {
+					t.Fatalf("unexpected errors: %s", diags.Err())
+				}
+				return
+			}
+
+			if len(diags) != 1 {
+				t.Fatalf("wrong number of diagnostics %d; want 1", len(diags))
+			}
+			desc := diags[0].Description()
+			if got, want := desc.Summary, "Invalid value for variable"; got != want {
+				t.Fatalf("wrong summary %q; want %q", got, want)
+			}
+			if !strings.HasPrefix(desc.Detail, "The zone must be a or b.") {
+				t.Fatalf("detail %q should start with the error message", desc.Detail)
+			}
+			if !strings.Contains(desc.Detail, "The value of var.zone "+tc.Source) {
+				t.Fatalf("detail %q should give the source %q", desc.Detail, tc.Source)
+			}
+			subject := diags[0].Source().Subject
+			if subject == nil || !strings.HasSuffix(subject.Filename, "main.tf") || subject.Start.Line != 5 {
+				t.Fatalf("wrong subject %#v; want the condition", subject)
+			}
+			want := tfdiags.Extra{Code: tfdiags.CodeVariableInvalid, Address: "var.zone"}
+			if got := tfdiags.GetExtra(diags[0]); got != want {
+				t.Fatalf("wrong extra %#v; want %#v", got, want)
+			}
+		})
+	}
+}
+
 func TestContext2Validate_resourceConfig_bad(t *testing.T) {
 	m := testModule(t, "validate-bad-rc")
 	p := testProvider("aws")
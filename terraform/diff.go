@@ -160,6 +160,23 @@ func (d *Diff) DeepCopy() *Diff {
 	return copy.(*Diff)
 }
 
+// Redacted returns a copy of the diff in which the values of sensitive
+// attributes are replaced with "<sensitive>", so that it can be recorded
+// where secrets mustn't be, such as in a crash report.
+func (d *Diff) Redacted() *Diff {
+	if d == nil {
+		return nil
+	}
+
+	copy := d.DeepCopy()
+	for _, m := range copy.Modules {
+		for _, rd := range m.Resources {
+			rd.redact()
+		}
+	}
+	return copy
+}
+
 func (d *Diff) String() string {
 	var buf bytes.Buffer
 
@@ -374,6 +391,16 @@ type InstanceDiff struct {
 	DestroyDeposed bool
 	DestroyTainted bool
 
+	// ActionReason records why the action for this instance was chosen,
+	// when that isn't apparent from the attribute diffs alone.
+	ActionReason DiffActionReason
+
+	// PlanModifications records the attributes whose values the provider
+	// planned differently than they were set in the configuration, to help
+	// explain the diff. It is informational only and is not used when
+	// applying the diff.
+	PlanModifications []*PlanModification
+
 	// Meta is a simple K/V map that is stored in a diff and persisted to
 	// plans but otherwise is completely ignored by Terraform core. It is
 	// meant to be used for additional data a resource may want to pass through.
@@ -384,6 +411,21 @@ type InstanceDiff struct {
 func (d *InstanceDiff) Lock()   { d.mu.Lock() }
 func (d *InstanceDiff) Unlock() { d.mu.Unlock() }
 
+// DiffActionReason is an explanation of why a particular action was planned
+// for an instance.
+type DiffActionReason byte
+
+const (
+	// DiffActionReasonNone means that the action is explained entirely by
+	// the attribute diffs and the other flags in the instance diff.
+	DiffActionReasonNone DiffActionReason = iota
+
+	// DiffActionReasonReplaceByRequest means that the instance is being
+	// replaced because the user explicitly requested it with the -replace
+	// option, even though its configuration may not have changed.
+	DiffActionReasonReplaceByRequest
+)
+
 // ResourceAttrDiff is the diff of a single attribute of a resource.
 type ResourceAttrDiff struct {
 	Old         string      // Old Value
@@ -407,7 +449,12 @@ func (d *ResourceAttrDiff) Empty() bool {
 }
 
 func (d *ResourceAttrDiff) GoString() string {
-	return fmt.Sprintf("*%#v", *d)
+	v := *d
+	if v.Sensitive {
+		v.Old = "<sensitive>"
+		v.New = "<sensitive>"
+	}
+	return fmt.Sprintf("*%#v", v)
 }
 
 // DiffAttrType is an enum type that says whether a resource attribute
@@ -479,6 +526,7 @@ func (d *InstanceDiff) Empty() bool {
 	return !d.Destroy &&
 		!d.DestroyTainted &&
 		!d.DestroyDeposed &&
+		d.ActionReason != DiffActionReasonReplaceByRequest &&
 		len(d.Attributes) == 0
 }
 
@@ -507,12 +555,38 @@ func (d *InstanceDiff) DeepCopy() *InstanceDiff {
 	return copy.(*InstanceDiff)
 }
 
+// Redacted returns a copy of the diff in which the values of sensitive
+// attributes are replaced with "<sensitive>".
+func (d *InstanceDiff) Redacted() *InstanceDiff {
+	if d == nil {
+		return nil
+	}
+
+	copy := d.DeepCopy()
+	copy.redact()
+	return copy
+}
+
+func (d *InstanceDiff) redact() {
+	if d == nil {
+		return
+	}
+	for _, attr := range d.Attributes {
+		if attr != nil && attr.Sensitive {
+			attr.Old = "<sensitive>"
+			attr.New = "<sensitive>"
+		}
+	}
+}
+
 func (d *InstanceDiff) GoString() string {
 	return fmt.Sprintf("*%#v", InstanceDiff{
-		Attributes:     d.Attributes,
-		Destroy:        d.Destroy,
-		DestroyTainted: d.DestroyTainted,
-		DestroyDeposed: d.DestroyDeposed,
+		Attributes:        d.Attributes,
+		Destroy:           d.Destroy,
+		DestroyTainted:    d.DestroyTainted,
+		DestroyDeposed:    d.DestroyDeposed,
+		ActionReason:      d.ActionReason,
+		PlanModifications: d.PlanModifications,
 	})
 }
 
@@ -534,7 +608,7 @@ func (d *InstanceDiff) requiresNew() bool {
 		return false
 	}
 
-	if d.DestroyTainted {
+	if d.DestroyTainted || d.ActionReason == DiffActionReasonReplaceByRequest {
 		return true
 	}
 
@@ -578,6 +652,20 @@ func (d *InstanceDiff) GetDestroyTainted() bool {
 	return d.DestroyTainted
 }
 
+func (d *InstanceDiff) SetActionReason(r DiffActionReason) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.ActionReason = r
+}
+
+func (d *InstanceDiff) GetActionReason() DiffActionReason {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.ActionReason
+}
+
 func (d *InstanceDiff) SetDestroy(b bool) {
 	d.mu.Lock()
 	defer d.mu.Unlock()
@@ -226,6 +226,50 @@ func TestModuleDiff_ChangeType(t *testing.T) {
 	}
 }
 
+func TestDiff_Redacted(t *testing.T) {
+	diff := &Diff{
+		Modules: []*ModuleDiff{
+			&ModuleDiff{
+				Path: []string{"root"},
+				Resources: map[string]*InstanceDiff{
+					"aws_instance.foo": &InstanceDiff{
+						Attributes: map[string]*ResourceAttrDiff{
+							"num": &ResourceAttrDiff{
+								Old: "0",
+								New: "2",
+							},
+							"password": &ResourceAttrDiff{
+								Old:       "hunter2",
+								New:       "hunter3",
+								Sensitive: true,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	redacted := diff.Redacted()
+	attrs := redacted.RootModule().Resources["aws_instance.foo"].Attributes
+	if attrs["num"].Old != "0" || attrs["num"].New != "2" {
+		t.Fatalf("bad: %#v", attrs["num"])
+	}
+	if attrs["password"].Old != "<sensitive>" || attrs["password"].New != "<sensitive>" {
+		t.Fatalf("bad: %#v", attrs["password"])
+	}
+
+	// The original must not be modified.
+	orig := diff.RootModule().Resources["aws_instance.foo"].Attributes["password"]
+	if orig.Old != "hunter2" || orig.New != "hunter3" {
+		t.Fatalf("original was modified: %#v", orig)
+	}
+
+	if (*Diff)(nil).Redacted() != nil {
+		t.Fatal("nil diff should redact to nil")
+	}
+}
+
 func TestDiff_DeepCopy(t *testing.T) {
 	cases := map[string]*Diff{
 		"empty": &Diff{},
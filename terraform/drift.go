@@ -0,0 +1,103 @@
+package terraform
+
+import (
+	"github.com/hashicorp/terraform/config"
+)
+
+// StateDrift returns a diff describing the changes to managed resource
+// instances that were detected by refreshing the prior state, producing
+// the refreshed state. These are changes made outside of Terraform, as
+// opposed to the changes Terraform proposes to make to match the
+// configuration.
+//
+// The result uses the same representation as a plan diff, but it is for
+// display only and must never be applied: an attribute diff's Old value is
+// from the prior state and its New value is from the refreshed state, and
+// instances that no longer exist are represented as destroy diffs.
+func StateDrift(prior, refreshed *State) *Diff {
+	result := new(Diff)
+	result.init()
+
+	if prior == nil {
+		return result
+	}
+
+	for _, pm := range prior.Modules {
+		var rm *ModuleState
+		if refreshed != nil {
+			rm = refreshed.ModuleByPath(pm.Path)
+		}
+
+		var md *ModuleDiff
+		for k, prs := range pm.Resources {
+			if prs == nil || prs.Primary == nil {
+				continue
+			}
+
+			// Data resources are re-read on every refresh, so changes to
+			// them are expected and are not drift.
+			key, err := ParseResourceStateKey(k)
+			if err != nil || key.Mode != config.ManagedResourceMode {
+				continue
+			}
+
+			var rrs *ResourceState
+			if rm != nil {
+				rrs = rm.Resources[k]
+			}
+
+			var id *InstanceDiff
+			if rrs == nil || rrs.Primary == nil || rrs.Primary.ID == "" {
+				id = &InstanceDiff{Destroy: true}
+			} else {
+				id = instanceStateDrift(prs.Primary, rrs.Primary)
+			}
+			if id == nil {
+				continue
+			}
+
+			if md == nil {
+				md = result.ModuleByPath(pm.Path)
+			}
+			if md == nil {
+				md = result.AddModule(pm.Path)
+			}
+			md.Resources[k] = id
+		}
+	}
+
+	return result
+}
+
+// instanceStateDrift compares the attributes of the given prior and
+// refreshed states of the same instance, returning nil if they are equal.
+func instanceStateDrift(prior, refreshed *InstanceState) *InstanceDiff {
+	attrs := make(map[string]*ResourceAttrDiff)
+	for k, old := range prior.Attributes {
+		new, ok := refreshed.Attributes[k]
+		switch {
+		case !ok:
+			attrs[k] = &ResourceAttrDiff{Old: old, NewRemoved: true}
+		case new != old:
+			attrs[k] = &ResourceAttrDiff{Old: old, New: new}
+		default:
+			continue
+		}
+		attrs[k].Sensitive = prior.IsSensitive(k) || refreshed.IsSensitive(k)
+	}
+	for k, new := range refreshed.Attributes {
+		if _, ok := prior.Attributes[k]; ok {
+			continue
+		}
+		attrs[k] = &ResourceAttrDiff{
+			New:       new,
+			Sensitive: refreshed.IsSensitive(k),
+		}
+	}
+
+	if len(attrs) == 0 {
+		return nil
+	}
+
+	return &InstanceDiff{Attributes: attrs}
+}
@@ -0,0 +1,109 @@
+package terraform
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStateDrift(t *testing.T) {
+	prior := &State{
+		Modules: []*ModuleState{
+			&ModuleState{
+				Path: rootModulePath,
+				Resources: map[string]*ResourceState{
+					"aws_instance.changed": &ResourceState{
+						Type: "aws_instance",
+						Primary: &InstanceState{
+							ID: "a",
+							Attributes: map[string]string{
+								"id":     "a",
+								"foo":    "bar",
+								"secret": "old",
+								"gone":   "yes",
+							},
+							SensitiveAttributes: []string{"secret"},
+						},
+					},
+					"aws_instance.deleted": &ResourceState{
+						Type: "aws_instance",
+						Primary: &InstanceState{
+							ID:         "b",
+							Attributes: map[string]string{"id": "b"},
+						},
+					},
+					"aws_instance.unchanged": &ResourceState{
+						Type: "aws_instance",
+						Primary: &InstanceState{
+							ID:         "c",
+							Attributes: map[string]string{"id": "c"},
+						},
+					},
+					"data.aws_ami.foo": &ResourceState{
+						Type: "aws_ami",
+						Primary: &InstanceState{
+							ID:         "d",
+							Attributes: map[string]string{"id": "d"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	refreshed := prior.DeepCopy()
+	mod := refreshed.RootModule()
+	changed := mod.Resources["aws_instance.changed"].Primary
+	changed.Attributes["foo"] = "baz"
+	changed.Attributes["secret"] = "new"
+	changed.Attributes["added"] = "1"
+	delete(changed.Attributes, "gone")
+	delete(mod.Resources, "aws_instance.deleted")
+	mod.Resources["data.aws_ami.foo"].Primary.Attributes["id"] = "e"
+
+	drift := StateDrift(prior, refreshed)
+	md := drift.RootModule()
+	if md == nil {
+		t.Fatal("expected drift in the root module")
+	}
+
+	expected := map[string]*InstanceDiff{
+		"aws_instance.changed": &InstanceDiff{
+			Attributes: map[string]*ResourceAttrDiff{
+				"foo":    &ResourceAttrDiff{Old: "bar", New: "baz"},
+				"secret": &ResourceAttrDiff{Old: "old", New: "new", Sensitive: true},
+				"added":  &ResourceAttrDiff{New: "1"},
+				"gone":   &ResourceAttrDiff{Old: "yes", NewRemoved: true},
+			},
+		},
+		"aws_instance.deleted": &InstanceDiff{Destroy: true},
+	}
+	if !reflect.DeepEqual(md.Resources, expected) {
+		t.Fatalf("wrong drift\ngot:  %#v\nwant: %#v", md.Resources, expected)
+	}
+}
+
+func TestStateDrift_none(t *testing.T) {
+	prior := &State{
+		Modules: []*ModuleState{
+			&ModuleState{
+				Path: rootModulePath,
+				Resources: map[string]*ResourceState{
+					"aws_instance.foo": &ResourceState{
+						Type: "aws_instance",
+						Primary: &InstanceState{
+							ID:         "a",
+							Attributes: map[string]string{"id": "a"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	if drift := StateDrift(prior, prior.DeepCopy()); !drift.Empty() {
+		t.Fatalf("expected no drift, got:\n%s", drift)
+	}
+	if drift := StateDrift(nil, prior); !drift.Empty() {
+		t.Fatalf("expected no drift, got:\n%s", drift)
+	}
+}
@@ -12,13 +12,14 @@ import (
 // EvalApply is an EvalNode implementation that writes the diff to
 // the full diff.
 type EvalApply struct {
-	Info      *InstanceInfo
-	State     **InstanceState
-	Diff      **InstanceDiff
-	Provider  *ResourceProvider
-	Output    **InstanceState
-	CreateNew *bool
-	Error     *error
+	Info         *InstanceInfo
+	State        **InstanceState
+	Diff         **InstanceDiff
+	Provider     *ResourceProvider
+	ProviderName string
+	Output       **InstanceState
+	CreateNew    *bool
+	Error        *error
 }
 
 // TODO: test
@@ -54,12 +55,30 @@ func (n *EvalApply) Eval(ctx EvalContext) (interface{}, error) {
 
 	// With the completed diff, apply!
 	log.Printf("[DEBUG] apply: %s: executing Apply", n.Info.Id)
-	state, err := provider.Apply(n.Info, state, diff)
+	prior := state
+	var applied *InstanceState
+	var applyErr error
+	err := callProvider(ctx, n.ProviderName, providerCallApply, func() {
+		applied, applyErr = provider.Apply(n.Info, prior, diff)
+	})
+	if err == nil {
+		state, err = applied, applyErr
+	} else {
+		// We don't know whether the provider made any changes, so we keep
+		// the state we had rather than losing track of the instance.
+		log.Printf("[WARN] apply: %s: keeping prior state after abandoned Apply", n.Info.Id)
+		state = prior.DeepCopy()
+		err = fmt.Errorf("%s; the outcome of the apply is unknown, so the remote object may need to be checked manually", err)
+	}
 	if state == nil {
 		state = new(InstanceState)
 	}
 	state.init()
 
+	// Providers know nothing about where values came from, so we carry
+	// forward the record of which attributes are sensitive ourselves.
+	state.SensitiveAttributes = mergeSensitiveAttributes(prior, diff, state.Attributes)
+
 	// Force the "id" attribute to be our ID
 	if state.ID != "" {
 		state.Attributes["id"] = state.ID
@@ -345,23 +364,25 @@ func (n *EvalApplyProvisioners) apply(ctx EvalContext, provs []*config.Provision
 		output := CallbackUIOutput{OutputFn: outputFn}
 		applyErr := provisioner.Apply(&output, state, provConfig)
 
+		// An error that is ignored would otherwise go unnoticed, so it's
+		// reported along with the rest of the provisioner's output.
+		if applyErr != nil && prov.OnFailure == config.ProvisionerOnFailureContinue {
+			log.Printf(
+				"[INFO] apply: %s [%s]: error during provision, continue requested",
+				n.Info.Id, prov.Type)
+			outputFn(fmt.Sprintf(
+				"Error: %s (ignored, since on_failure is %q)",
+				applyErr, config.ProvisionerOnFailureContinue))
+		}
+
 		// Call post hook
 		hookErr := ctx.Hook(func(h Hook) (HookAction, error) {
 			return h.PostProvision(n.Info, prov.Type, applyErr)
 		})
 
 		// Handle the error before we deal with the hook
-		if applyErr != nil {
-			// Determine failure behavior
-			switch prov.OnFailure {
-			case config.ProvisionerOnFailureContinue:
-				log.Printf(
-					"[INFO] apply: %s [%s]: error during provision, continue requested",
-					n.Info.Id, prov.Type)
-
-			case config.ProvisionerOnFailureFail:
-				return applyErr
-			}
+		if applyErr != nil && prov.OnFailure == config.ProvisionerOnFailureFail {
+			return applyErr
 		}
 
 		// Deal with the hook
@@ -0,0 +1,98 @@
+package terraform
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/terraform/config"
+	"github.com/hashicorp/terraform/helper/hilmapstructure"
+)
+
+// EvalCheck is an EvalNode implementation that evaluates the assertions of
+// a check block and records the result. A failed assertion isn't an error
+// of the walk, since checks never block a plan or apply.
+type EvalCheck struct {
+	Addr   string
+	Config *config.Check
+}
+
+func (n *EvalCheck) Eval(ctx EvalContext) (interface{}, error) {
+	results := ctx.Checks()
+	if results == nil {
+		return nil, nil
+	}
+
+	result := &CheckResult{
+		Address: n.Addr,
+		Status:  CheckPass,
+	}
+	for _, a := range n.Config.Asserts {
+		status, msg := n.evalAssert(ctx, a)
+		if checkStatusSeverity[status] > checkStatusSeverity[result.Status] {
+			result.Status = status
+		}
+		if msg != "" {
+			result.FailureMessages = append(result.FailureMessages, msg)
+		}
+	}
+
+	log.Printf("[DEBUG] %s: check %s", n.Addr, result.Status)
+	results.Record(result)
+	return nil, nil
+}
+
+// evalAssert returns the status of an assertion, along with its error
+// message if it failed, or a description of why it couldn't be evaluated.
+func (n *EvalCheck) evalAssert(ctx EvalContext, a *config.CheckAssert) (CheckStatus, string) {
+	cfg, err := ctx.Interpolate(a.Condition.Copy(), nil)
+	if err != nil {
+		return CheckError, fmt.Sprintf(
+			"The condition at %s could not be evaluated: %s.", a.DeclRange.StartString(), err)
+	}
+	if cfg.IsComputed("condition") {
+		return CheckUnknown, ""
+	}
+
+	raw, _ := cfg.Get("condition")
+	var ok bool
+	if err := hilmapstructure.WeakDecode(raw, &ok); err != nil {
+		return CheckError, fmt.Sprintf(
+			"The condition at %s must be either true or false: %s.", a.DeclRange.StartString(), err)
+	}
+	if !ok {
+		return CheckFail, a.ErrorMessage
+	}
+	return CheckPass, ""
+}
+
+// EvalCheckScoped is an EvalNode implementation that evaluates Node, the
+// eval tree of a data source scoped to a check block. If the data source
+// can't be read then the error is recorded as the check's, and reported
+// with the check's other results, rather than stopping the plan or apply.
+type EvalCheckScoped struct {
+	Addr     string
+	Resource string
+	Node     EvalNode
+}
+
+func (n *EvalCheckScoped) Eval(ctx EvalContext) (interface{}, error) {
+	result, err := EvalRaw(n.Node, ctx)
+	if err == nil {
+		return result, nil
+	}
+	if _, ok := err.(EvalEarlyExitError); ok {
+		return nil, err
+	}
+
+	log.Printf("[WARN] %s: failed to read %s: %s", n.Addr, n.Resource, err)
+	if results := ctx.Checks(); results != nil {
+		results.Record(&CheckResult{
+			Address: n.Addr,
+			Status:  CheckError,
+			FailureMessages: []string{fmt.Sprintf(
+				"The data source %s could not be read: %s.", n.Resource, err)},
+		})
+	}
+
+	return nil, EvalEarlyExitError{}
+}
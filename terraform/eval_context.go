@@ -46,6 +46,23 @@ type EvalContext interface {
 	ProviderInput(string) map[string]interface{}
 	SetProviderInput(string, map[string]interface{})
 
+	// ProviderTimeouts and SetProviderTimeouts are used to record the
+	// timeouts configured for the provider with the given name, for use
+	// when calling it. ProviderTimeouts returns nil if no timeouts are set.
+	ProviderTimeouts(string) *config.ProviderTimeouts
+	SetProviderTimeouts(string, *config.ProviderTimeouts)
+
+	// ProviderSemaphore and SetProviderParallelism are used to limit the
+	// number of concurrent calls to the provider with the given name.
+	// ProviderSemaphore returns nil if there is no limit.
+	ProviderSemaphore(string) Semaphore
+	SetProviderParallelism(string, int)
+
+	// ProviderCapabilities returns the capabilities reported by the
+	// initialized provider with the given name, or the zero value if the
+	// provider isn't initialized or doesn't report any.
+	ProviderCapabilities(string) ProviderCapabilities
+
 	// InitProvisioner initializes the provisioner with the given name and
 	// returns the implementation of the resource provisioner or an error.
 	//
@@ -86,4 +103,8 @@ type EvalContext interface {
 	// State returns the global state as well as the lock that should
 	// be used to modify that state.
 	State() (*State, *sync.RWMutex)
+
+	// Checks returns where the results of check blocks are recorded, which
+	// is nil for walks that don't evaluate checks.
+	Checks() *CheckResults
 }
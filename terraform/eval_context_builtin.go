@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"sort"
 	"sync"
 
 	"github.com/hashicorp/terraform/config"
@@ -34,6 +35,9 @@ type BuiltinEvalContext struct {
 	InputValue          UIInput
 	ProviderCache       map[string]ResourceProvider
 	ProviderInputConfig map[string]map[string]interface{}
+	ProviderTimeoutsMap map[string]*config.ProviderTimeouts
+	ProviderSemMap      map[string]Semaphore
+	ProviderCapsMap     map[string]ProviderCapabilities
 	ProviderLock        *sync.Mutex
 	ProvisionerCache    map[string]ResourceProvisioner
 	ProvisionerLock     *sync.Mutex
@@ -41,6 +45,7 @@ type BuiltinEvalContext struct {
 	DiffLock            *sync.RWMutex
 	StateValue          *State
 	StateLock           *sync.RWMutex
+	ChecksValue         *CheckResults
 
 	once sync.Once
 }
@@ -168,6 +173,76 @@ func (ctx *BuiltinEvalContext) SetProviderInput(n string, c map[string]interface
 	ctx.ProviderLock.Unlock()
 }
 
+func (ctx *BuiltinEvalContext) ProviderTimeouts(n string) *config.ProviderTimeouts {
+	ctx.ProviderLock.Lock()
+	defer ctx.ProviderLock.Unlock()
+
+	return ctx.ProviderTimeoutsMap[n]
+}
+
+func (ctx *BuiltinEvalContext) SetProviderTimeouts(n string, t *config.ProviderTimeouts) {
+	ctx.ProviderLock.Lock()
+	defer ctx.ProviderLock.Unlock()
+
+	// This can happen during tests, where the map isn't provided.
+	if ctx.ProviderTimeoutsMap == nil {
+		ctx.ProviderTimeoutsMap = make(map[string]*config.ProviderTimeouts)
+	}
+	ctx.ProviderTimeoutsMap[n] = t
+}
+
+func (ctx *BuiltinEvalContext) ProviderSemaphore(n string) Semaphore {
+	ctx.ProviderLock.Lock()
+	defer ctx.ProviderLock.Unlock()
+
+	return ctx.ProviderSemMap[n]
+}
+
+func (ctx *BuiltinEvalContext) SetProviderParallelism(n string, limit int) {
+	ctx.ProviderLock.Lock()
+	defer ctx.ProviderLock.Unlock()
+
+	// This can happen during tests, where the map isn't provided.
+	if ctx.ProviderSemMap == nil {
+		ctx.ProviderSemMap = make(map[string]Semaphore)
+	}
+	if limit > 0 {
+		ctx.ProviderSemMap[n] = NewSemaphore(limit)
+	} else {
+		delete(ctx.ProviderSemMap, n)
+	}
+}
+
+func (ctx *BuiltinEvalContext) ProviderCapabilities(n string) ProviderCapabilities {
+	ctx.ProviderLock.Lock()
+	caps, ok := ctx.ProviderCapsMap[n]
+	p := ctx.ProviderCache[n]
+	ctx.ProviderLock.Unlock()
+
+	if ok || p == nil {
+		return caps
+	}
+
+	// The capabilities are reported along with the schema. Providers that
+	// don't support GetSchema have no capabilities.
+	schema, err := p.GetSchema(&ProviderSchemaRequest{})
+	if err != nil {
+		log.Printf("[WARN] failed to get capabilities of %s: %s", n, err)
+	} else if schema != nil {
+		caps = schema.Capabilities
+	}
+
+	ctx.ProviderLock.Lock()
+	defer ctx.ProviderLock.Unlock()
+
+	// This can happen during tests, where the map isn't provided.
+	if ctx.ProviderCapsMap == nil {
+		ctx.ProviderCapsMap = make(map[string]ProviderCapabilities)
+	}
+	ctx.ProviderCapsMap[n] = caps
+	return caps
+}
+
 func (ctx *BuiltinEvalContext) InitProvisioner(
 	n string) (ResourceProvisioner, error) {
 	ctx.once.Do(ctx.init)
@@ -234,25 +309,34 @@ func (ctx *BuiltinEvalContext) CloseProvisioner(n string) error {
 func (ctx *BuiltinEvalContext) Interpolate(
 	cfg *config.RawConfig, r *Resource) (*ResourceConfig, error) {
 
+	scope := &InterpolationScope{
+		Path:     ctx.Path(),
+		Resource: r,
+	}
+
 	if cfg != nil {
-		scope := &InterpolationScope{
-			Path:     ctx.Path(),
-			Resource: r,
+		vs, err := ctx.Interpolater.ConfigValues(scope, cfg)
+		if err != nil {
+			return nil, err
 		}
 
-		vs, err := ctx.Interpolater.Values(scope, cfg.Variables)
+		funcs, err := ctx.Interpolater.Funcs(cfg)
 		if err != nil {
 			return nil, err
 		}
 
 		// Do the interpolation
-		if err := cfg.Interpolate(vs); err != nil {
+		if err := cfg.InterpolateWithFuncs(vs, funcs); err != nil {
 			return nil, err
 		}
 	}
 
 	result := NewResourceConfig(cfg)
 	result.interpolateForce()
+	if cfg != nil {
+		sensitive := ctx.Interpolater.sensitiveVariables(scope, cfg.Variables)
+		result.SensitiveKeys = sensitiveConfigKeys(cfg, sensitive)
+	}
 	return result, nil
 }
 
@@ -269,13 +353,18 @@ func (ctx *BuiltinEvalContext) InterpolateProvider(
 
 		cfg = pc.RawConfig
 
-		vs, err := ctx.Interpolater.Values(scope, cfg.Variables)
+		vs, err := ctx.Interpolater.ConfigValues(scope, cfg)
+		if err != nil {
+			return nil, err
+		}
+
+		funcs, err := ctx.Interpolater.Funcs(cfg)
 		if err != nil {
 			return nil, err
 		}
 
 		// Do the interpolation
-		if err := cfg.Interpolate(vs); err != nil {
+		if err := cfg.InterpolateWithFuncs(vs, funcs); err != nil {
 			return nil, err
 		}
 	}
@@ -285,6 +374,33 @@ func (ctx *BuiltinEvalContext) InterpolateProvider(
 	return result, nil
 }
 
+// sensitiveConfigKeys returns the top-level keys of the given configuration
+// whose values refer to any of the given sensitive variables.
+func sensitiveConfigKeys(cfg *config.RawConfig, sensitive map[string]bool) []string {
+	if len(sensitive) == 0 {
+		return nil
+	}
+
+	var result []string
+	for k, v := range cfg.RawMap() {
+		keyCfg, err := config.NewRawConfig(map[string]interface{}{k: v})
+		if err != nil {
+			// Can't happen, since the whole configuration already parsed
+			continue
+		}
+
+		for name := range keyCfg.Variables {
+			if sensitive[name] {
+				result = append(result, k)
+				break
+			}
+		}
+	}
+	sort.Strings(result)
+
+	return result
+}
+
 func (ctx *BuiltinEvalContext) Path() []string {
 	return ctx.PathValue
 }
@@ -317,5 +433,9 @@ func (ctx *BuiltinEvalContext) State() (*State, *sync.RWMutex) {
 	return ctx.StateValue, ctx.StateLock
 }
 
+func (ctx *BuiltinEvalContext) Checks() *CheckResults {
+	return ctx.ChecksValue
+}
+
 func (ctx *BuiltinEvalContext) init() {
 }
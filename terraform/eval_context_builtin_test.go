@@ -37,6 +37,39 @@ func TestBuiltinEvalContextProviderInput(t *testing.T) {
 	}
 }
 
+func TestBuiltinEvalContextProviderCapabilities(t *testing.T) {
+	p := &MockResourceProvider{
+		GetSchemaReturn: &ProviderSchema{
+			Capabilities: ProviderCapabilities{PlanDestroy: true},
+		},
+	}
+
+	ctx := testBuiltinEvalContext(t)
+	ctx.ProviderLock = new(sync.Mutex)
+	ctx.ProviderCache = map[string]ResourceProvider{"provider.aws": p}
+
+	caps := ctx.ProviderCapabilities("provider.aws")
+	if !caps.PlanDestroy {
+		t.Fatalf("bad: %#v", caps)
+	}
+	if !p.GetSchemaCalled {
+		t.Fatal("GetSchema should be called")
+	}
+
+	// The result is cached
+	p.GetSchemaCalled = false
+	ctx.ProviderCapabilities("provider.aws")
+	if p.GetSchemaCalled {
+		t.Fatal("GetSchema should not be called again")
+	}
+
+	// Uninitialized providers have no capabilities
+	caps = ctx.ProviderCapabilities("provider.null")
+	if caps != (ProviderCapabilities{}) {
+		t.Fatalf("bad: %#v", caps)
+	}
+}
+
 func testBuiltinEvalContext(t *testing.T) *BuiltinEvalContext {
 	return &BuiltinEvalContext{}
 }
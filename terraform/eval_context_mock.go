@@ -40,6 +40,26 @@ type MockEvalContext struct {
 	SetProviderInputName   string
 	SetProviderInputConfig map[string]interface{}
 
+	ProviderTimeoutsCalled bool
+	ProviderTimeoutsName   string
+	ProviderTimeoutsValue  *config.ProviderTimeouts
+
+	SetProviderTimeoutsCalled bool
+	SetProviderTimeoutsName   string
+	SetProviderTimeoutsValue  *config.ProviderTimeouts
+
+	ProviderSemaphoreCalled bool
+	ProviderSemaphoreName   string
+	ProviderSemaphoreValue  Semaphore
+
+	SetProviderParallelismCalled bool
+	SetProviderParallelismName   string
+	SetProviderParallelismValue  int
+
+	ProviderCapabilitiesCalled bool
+	ProviderCapabilitiesName   string
+	ProviderCapabilitiesValue  ProviderCapabilities
+
 	ConfigureProviderCalled bool
 	ConfigureProviderName   string
 	ConfigureProviderConfig *ResourceConfig
@@ -84,6 +104,9 @@ type MockEvalContext struct {
 	StateCalled bool
 	StateState  *State
 	StateLock   *sync.RWMutex
+
+	ChecksCalled  bool
+	ChecksResults *CheckResults
 }
 
 func (c *MockEvalContext) Stopped() <-chan struct{} {
@@ -144,6 +167,36 @@ func (c *MockEvalContext) SetProviderInput(n string, cfg map[string]interface{})
 	c.SetProviderInputConfig = cfg
 }
 
+func (c *MockEvalContext) ProviderTimeouts(n string) *config.ProviderTimeouts {
+	c.ProviderTimeoutsCalled = true
+	c.ProviderTimeoutsName = n
+	return c.ProviderTimeoutsValue
+}
+
+func (c *MockEvalContext) SetProviderTimeouts(n string, t *config.ProviderTimeouts) {
+	c.SetProviderTimeoutsCalled = true
+	c.SetProviderTimeoutsName = n
+	c.SetProviderTimeoutsValue = t
+}
+
+func (c *MockEvalContext) ProviderSemaphore(n string) Semaphore {
+	c.ProviderSemaphoreCalled = true
+	c.ProviderSemaphoreName = n
+	return c.ProviderSemaphoreValue
+}
+
+func (c *MockEvalContext) SetProviderParallelism(n string, limit int) {
+	c.SetProviderParallelismCalled = true
+	c.SetProviderParallelismName = n
+	c.SetProviderParallelismValue = limit
+}
+
+func (c *MockEvalContext) ProviderCapabilities(n string) ProviderCapabilities {
+	c.ProviderCapabilitiesCalled = true
+	c.ProviderCapabilitiesName = n
+	return c.ProviderCapabilitiesValue
+}
+
 func (c *MockEvalContext) InitProvisioner(n string) (ResourceProvisioner, error) {
 	c.InitProvisionerCalled = true
 	c.InitProvisionerName = n
@@ -198,3 +251,8 @@ func (c *MockEvalContext) State() (*State, *sync.RWMutex) {
 	c.StateCalled = true
 	return c.StateState, c.StateLock
 }
+
+func (c *MockEvalContext) Checks() *CheckResults {
+	c.ChecksCalled = true
+	return c.ChecksResults
+}
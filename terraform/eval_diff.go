@@ -79,6 +79,10 @@ type EvalDiff struct {
 	OutputDiff  **InstanceDiff
 	OutputState **InstanceState
 
+	// ProviderName is the name of the provider, used to find the timeout
+	// configured for it.
+	ProviderName string
+
 	// Resource is needed to fetch the ignore_changes list so we can
 	// filter user-requested ignored attributes from the diff.
 	Resource *config.Resource
@@ -88,6 +92,10 @@ type EvalDiff struct {
 	// computed paths off of, but not as an actual diff where resouces should be
 	// counted, and not as a diff that should be acted on.
 	Stub bool
+
+	// Replace is set if the user requested that this instance be replaced
+	// even if its configuration hasn't changed.
+	Replace bool
 }
 
 // TODO: test
@@ -107,20 +115,50 @@ func (n *EvalDiff) Eval(ctx EvalContext) (interface{}, error) {
 	}
 
 	// The state for the diff must never be nil
+	// A requested replacement is planned just like the replacement of a
+	// tainted instance, and is carried forward from the planned diff
+	// during apply.
+	replace := n.Replace
+	if n.Diff != nil && *n.Diff != nil {
+		replace = replace || (*n.Diff).GetActionReason() == DiffActionReasonReplaceByRequest
+	}
+	replace = replace && state != nil && state.ID != ""
+
 	diffState := state
 	if diffState == nil {
 		diffState = new(InstanceState)
 	}
 	diffState.init()
+	if replace && !diffState.Tainted {
+		// Ask the provider for the diff it would produce for a tainted
+		// instance, without touching the real state.
+		diffState = diffState.DeepCopy()
+		diffState.Tainted = true
+	}
 
 	// Diff!
-	diff, err := provider.Diff(n.Info, diffState, config)
+	var diff *InstanceDiff
+	var diffErr error
+	err := callProvider(ctx, n.ProviderName, providerCallPlan, func() {
+		diff, diffErr = provider.Diff(n.Info, diffState, config)
+	})
+	if err == nil {
+		err = diffErr
+	}
 	if err != nil {
 		return nil, err
 	}
 	if diff == nil {
 		diff = new(InstanceDiff)
 	}
+	diff.PlanModifications = planModifications(config, state, diff)
+	for _, m := range diff.PlanModifications {
+		log.Printf("[DEBUG] %s: provider modified planned value for %s", n.Info.Id, m)
+	}
+
+	// Mark any attributes derived from sensitive values so that they
+	// are redacted when the diff is shown.
+	markSensitiveAttributes(diff, config)
 
 	// Set DestroyDeposed if we have deposed instances
 	_, err = readInstanceFromState(ctx, n.Name, nil, func(rs *ResourceState) (*InstanceState, error) {
@@ -139,6 +177,13 @@ func (n *EvalDiff) Eval(ctx EvalContext) (interface{}, error) {
 		diff.SetTainted((*n.Diff).GetDestroyTainted())
 	}
 
+	if replace {
+		if !state.Tainted {
+			diff.SetTainted(false)
+		}
+		diff.SetActionReason(DiffActionReasonReplaceByRequest)
+	}
+
 	// Require a destroy if there is an ID and it requires new.
 	if diff.RequiresNew() && state != nil && state.ID != "" {
 		diff.SetDestroy(true)
@@ -210,9 +255,10 @@ func (n *EvalDiff) processIgnoreChanges(diff *InstanceDiff) error {
 		return nil
 	}
 
-	// If the resource has been tainted then we don't process ignore changes
-	// since we MUST recreate the entire resource.
-	if diff.GetDestroyTainted() {
+	// If the resource has been tainted or its replacement was requested
+	// then we don't process ignore changes since we MUST recreate the
+	// entire resource.
+	if diff.GetDestroyTainted() || diff.GetActionReason() == DiffActionReasonReplaceByRequest {
 		return nil
 	}
 
@@ -337,6 +383,11 @@ type EvalDiffDestroy struct {
 	Info   *InstanceInfo
 	State  **InstanceState
 	Output **InstanceDiff
+
+	// Provider and ProviderName are optional. If set, and the provider
+	// has the PlanDestroy capability, the provider plans the destroy.
+	Provider     *ResourceProvider
+	ProviderName string
 }
 
 // TODO: test
@@ -359,6 +410,34 @@ func (n *EvalDiffDestroy) Eval(ctx EvalContext) (interface{}, error) {
 	// The diff
 	diff := &InstanceDiff{Destroy: true}
 
+	// Providers that plan their own destroys may reject them, for example
+	// for objects that the remote system protects against deletion.
+	if n.Provider != nil && ctx.ProviderCapabilities(n.ProviderName).PlanDestroy {
+		provider := *n.Provider
+
+		var planned *InstanceDiff
+		var planErr error
+		err := callProvider(ctx, n.ProviderName, providerCallPlan, func() {
+			planned, planErr = provider.Diff(n.Info, state, nil)
+		})
+		if err == nil {
+			err = planErr
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", n.Info.Id, err)
+		}
+
+		if planned != nil {
+			if !planned.GetDestroy() {
+				return nil, fmt.Errorf(
+					"%s: %s produced an invalid plan for destroying this instance: "+
+						"the plan doesn't destroy it; this is a bug in the provider",
+					n.Info.Id, n.ProviderName)
+			}
+			diff = planned
+		}
+	}
+
 	// Call post-diff hook
 	err = ctx.Hook(func(h Hook) (HookAction, error) {
 		return h.PostDiff(n.Info, diff)
@@ -491,3 +570,18 @@ func (n *EvalWriteDiff) Eval(ctx EvalContext) (interface{}, error) {
 
 	return nil, nil
 }
+
+// markSensitiveAttributes marks the attributes of the given diff whose
+// values were derived from sensitive values in the given configuration.
+func markSensitiveAttributes(diff *InstanceDiff, config *ResourceConfig) {
+	if config == nil || len(config.SensitiveKeys) == 0 {
+		return
+	}
+
+	for k, attr := range diff.CopyAttributes() {
+		if config.IsSensitive(k) && !attr.Sensitive {
+			attr.Sensitive = true
+			diff.SetAttribute(k, attr)
+		}
+	}
+}
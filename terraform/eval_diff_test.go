@@ -1,12 +1,83 @@
 package terraform
 
 import (
+	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/hashicorp/terraform/config"
 )
 
+func TestEvalDiffDestroy(t *testing.T) {
+	var provider ResourceProvider = &MockResourceProvider{}
+	state := &InstanceState{ID: "foo"}
+	var diff *InstanceDiff
+	n := &EvalDiffDestroy{
+		Info:         &InstanceInfo{Id: "aws_instance.foo"},
+		State:        &state,
+		Output:       &diff,
+		Provider:     &provider,
+		ProviderName: "provider.aws",
+	}
+
+	ctx := new(MockEvalContext)
+	if _, err := n.Eval(ctx); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !diff.GetDestroy() {
+		t.Fatalf("bad: %#v", diff)
+	}
+	if provider.(*MockResourceProvider).DiffCalled {
+		t.Fatal("Diff should not be called")
+	}
+}
+
+func TestEvalDiffDestroy_planDestroy(t *testing.T) {
+	p := &MockResourceProvider{
+		DiffFn: func(info *InstanceInfo, s *InstanceState, c *ResourceConfig) (*InstanceDiff, error) {
+			if c != nil {
+				t.Fatalf("config should be nil, got %#v", c)
+			}
+			return nil, fmt.Errorf("deletion protection is enabled")
+		},
+	}
+	var provider ResourceProvider = p
+	state := &InstanceState{ID: "foo"}
+	var diff *InstanceDiff
+	n := &EvalDiffDestroy{
+		Info:         &InstanceInfo{Id: "aws_instance.foo"},
+		State:        &state,
+		Output:       &diff,
+		Provider:     &provider,
+		ProviderName: "provider.aws",
+	}
+
+	ctx := &MockEvalContext{
+		ProviderCapabilitiesValue: ProviderCapabilities{PlanDestroy: true},
+	}
+	_, err := n.Eval(ctx)
+	if err == nil || !strings.Contains(err.Error(), "deletion protection") {
+		t.Fatalf("wrong error: %v", err)
+	}
+
+	// A provider that doesn't plan a destroy is buggy
+	p.DiffFn = nil
+	p.DiffReturn = &InstanceDiff{}
+	_, err = n.Eval(ctx)
+	if err == nil || !strings.Contains(err.Error(), "bug in the provider") {
+		t.Fatalf("wrong error: %v", err)
+	}
+
+	p.DiffReturn = &InstanceDiff{Destroy: true}
+	if _, err := n.Eval(ctx); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !diff.GetDestroy() {
+		t.Fatalf("bad: %#v", diff)
+	}
+}
+
 func TestEvalFilterDiff(t *testing.T) {
 	ctx := new(MockEvalContext)
 
@@ -8,10 +8,11 @@ import (
 // ImportState operation on a provider. This will return the imported
 // states but won't modify any actual state.
 type EvalImportState struct {
-	Provider *ResourceProvider
-	Info     *InstanceInfo
-	Id       string
-	Output   *[]*InstanceState
+	Provider     *ResourceProvider
+	ProviderName string
+	Info         *InstanceInfo
+	Id           string
+	Output       *[]*InstanceState
 }
 
 // TODO: test
@@ -29,7 +30,14 @@ func (n *EvalImportState) Eval(ctx EvalContext) (interface{}, error) {
 	}
 
 	// Import!
-	state, err := provider.ImportState(n.Info, n.Id)
+	var state []*InstanceState
+	var importErr error
+	err := callProvider(ctx, n.ProviderName, providerCallRead, func() {
+		state, importErr = provider.ImportState(n.Info, n.Id)
+	})
+	if err == nil {
+		err = importErr
+	}
 	if err != nil {
 		return nil, fmt.Errorf(
 			"import %s (id: %s): %s", n.Info.HumanId(), n.Id, err)
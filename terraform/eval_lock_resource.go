@@ -0,0 +1,141 @@
+package terraform
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/terraform/config"
+)
+
+// EvalLockResource is an EvalNode implementation that acquires an advisory
+// lock on the remote object for a resource instance from its provider, if
+// the resource has the "lock" lifecycle option set.
+type EvalLockResource struct {
+	Info     *InstanceInfo
+	Resource *config.Resource
+	Provider *ResourceProvider
+	State    **InstanceState
+	Diff     **InstanceDiff
+	LockID   *string
+}
+
+// TODO: test
+func (n *EvalLockResource) Eval(ctx EvalContext) (interface{}, error) {
+	*n.LockID = ""
+	if n.Resource == nil || !n.Resource.Lifecycle.Lock {
+		return nil, nil
+	}
+
+	locker, ok := (*n.Provider).(ResourceProviderLocker)
+	if !ok {
+		return nil, fmt.Errorf(
+			"%s: the provider for this resource does not support the lock lifecycle option",
+			n.Info.HumanId())
+	}
+
+	var state *InstanceState
+	if n.State != nil {
+		state = *n.State
+	}
+	var diff *InstanceDiff
+	if n.Diff != nil {
+		diff = *n.Diff
+	}
+
+	log.Printf("[DEBUG] apply: %s: acquiring resource lock", n.Info.Id)
+	id, err := locker.LockResource(n.Info, state, diff)
+	if err != nil {
+		return nil, fmt.Errorf("%s: error acquiring resource lock: %s", n.Info.HumanId(), err)
+	}
+
+	*n.LockID = id
+	return nil, nil
+}
+
+// EvalUnlockResource is an EvalNode implementation that releases a lock
+// acquired by EvalLockResource, if any.
+//
+// A failure to release the lock is recorded in Error rather than returned,
+// so that the result of the operation the lock was protecting is still
+// saved.
+type EvalUnlockResource struct {
+	Info     *InstanceInfo
+	Provider *ResourceProvider
+	LockID   *string
+	Error    *error
+}
+
+// TODO: test
+func (n *EvalUnlockResource) Eval(ctx EvalContext) (interface{}, error) {
+	if *n.LockID == "" {
+		return nil, nil
+	}
+
+	id := *n.LockID
+	*n.LockID = ""
+
+	log.Printf("[DEBUG] apply: %s: releasing resource lock", n.Info.Id)
+	locker := (*n.Provider).(ResourceProviderLocker)
+	if err := locker.UnlockResource(n.Info, id); err != nil {
+		err = fmt.Errorf(
+			"%s: error releasing resource lock %q: %s", n.Info.HumanId(), id, err)
+		if n.Error == nil {
+			return nil, err
+		}
+		*n.Error = multierror.Append(*n.Error, err)
+	}
+
+	return nil, nil
+}
+
+// EvalWithResourceLock is an EvalNode implementation that runs Node while
+// holding the lock acquired by EvalLockResource, and then releases it with
+// EvalUnlockResource.
+//
+// The lock is released however Node returns, including when it returns an
+// error or when the operation is interrupted, so that a failed apply doesn't
+// leave the remote object locked.
+type EvalWithResourceLock struct {
+	Info     *InstanceInfo
+	Resource *config.Resource
+	Provider *ResourceProvider
+	State    **InstanceState
+	Diff     **InstanceDiff
+	Error    *error
+
+	Node EvalNode
+}
+
+func (n *EvalWithResourceLock) Eval(ctx EvalContext) (result interface{}, err error) {
+	var lockID string
+	lock := &EvalLockResource{
+		Info:     n.Info,
+		Resource: n.Resource,
+		Provider: n.Provider,
+		State:    n.State,
+		Diff:     n.Diff,
+		LockID:   &lockID,
+	}
+	if _, err := EvalRaw(lock, ctx); err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		unlock := &EvalUnlockResource{
+			Info:     n.Info,
+			Provider: n.Provider,
+			LockID:   &lockID,
+			Error:    n.Error,
+		}
+		if _, unlockErr := EvalRaw(unlock, ctx); unlockErr != nil {
+			if err == nil {
+				err = unlockErr
+			} else {
+				err = multierror.Append(err, unlockErr)
+			}
+		}
+	}()
+
+	return EvalRaw(n.Node, ctx)
+}
@@ -94,23 +94,27 @@ func (n *EvalWriteOutput) Eval(ctx EvalContext) (interface{}, error) {
 		}
 	}
 
+	// An output derived from a sensitive value is itself sensitive, even
+	// if it isn't explicitly marked as such.
+	sensitive := n.Sensitive || cfg.IsSensitive("value")
+
 	switch valueTyped := valueRaw.(type) {
 	case string:
 		mod.Outputs[n.Name] = &OutputState{
 			Type:      "string",
-			Sensitive: n.Sensitive,
+			Sensitive: sensitive,
 			Value:     valueTyped,
 		}
 	case []interface{}:
 		mod.Outputs[n.Name] = &OutputState{
 			Type:      "list",
-			Sensitive: n.Sensitive,
+			Sensitive: sensitive,
 			Value:     valueTyped,
 		}
 	case map[string]interface{}:
 		mod.Outputs[n.Name] = &OutputState{
 			Type:      "map",
-			Sensitive: n.Sensitive,
+			Sensitive: sensitive,
 			Value:     valueTyped,
 		}
 	case []map[string]interface{}:
@@ -119,7 +123,7 @@ func (n *EvalWriteOutput) Eval(ctx EvalContext) (interface{}, error) {
 		if len(valueTyped) == 1 {
 			mod.Outputs[n.Name] = &OutputState{
 				Type:      "map",
-				Sensitive: n.Sensitive,
+				Sensitive: sensitive,
 				Value:     valueTyped[0],
 			}
 			break
@@ -2,7 +2,11 @@ package terraform
 
 import (
 	"fmt"
+	"log"
+	"strings"
+	"time"
 
+	"github.com/hashicorp/errwrap"
 	"github.com/hashicorp/terraform/config"
 )
 
@@ -40,12 +44,63 @@ func (n *EvalBuildProviderConfig) Eval(ctx EvalContext) (interface{}, error) {
 // EvalConfigProvider is an EvalNode implementation that configures
 // a provider that is already initialized and retrieved.
 type EvalConfigProvider struct {
-	Provider string
-	Config   **ResourceConfig
+	Provider    string
+	Config      **ResourceConfig
+	Timeouts    *config.ProviderTimeouts
+	Parallelism int
+
+	// Metas is the provider_meta metadata of the modules whose resources
+	// the provider manages, which is passed on to providers that accept it.
+	Metas []*ProviderModuleMeta
 }
 
 func (n *EvalConfigProvider) Eval(ctx EvalContext) (interface{}, error) {
-	return nil, ctx.ConfigureProvider(n.Provider, *n.Config)
+	ctx.SetProviderTimeouts(n.Provider, n.Timeouts)
+	ctx.SetProviderParallelism(n.Provider, n.Parallelism)
+
+	// The provider's name includes its alias and module path, which is
+	// needed to tell apart errors from different configurations of the
+	// same provider.
+	var configErr error
+	err := callProvider(ctx, n.Provider, providerCallConfigure, func() {
+		configErr = ctx.ConfigureProvider(n.Provider, *n.Config)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if configErr != nil {
+		return nil, errwrap.Wrapf(fmt.Sprintf("%s: {{err}}", n.Provider), configErr)
+	}
+
+	if len(n.Metas) == 0 {
+		return nil, nil
+	}
+
+	p := ctx.Provider(n.Provider)
+	mc, ok := p.(ResourceProviderMetaConfigurer)
+	if !ok || !ctx.ProviderCapabilities(n.Provider).ProviderMeta {
+		return nil, fmt.Errorf(
+			"%s: provider_meta is declared by %s, but the provider does not accept it",
+			n.Provider, modulePathsStr(n.Metas))
+	}
+	if err := mc.ConfigureProviderMeta(n.Metas); err != nil {
+		return nil, errwrap.Wrapf(fmt.Sprintf("%s: provider_meta: {{err}}", n.Provider), err)
+	}
+
+	return nil, nil
+}
+
+// modulePathsStr returns the addresses of the modules of the given
+// metadata, for messages.
+func modulePathsStr(metas []*ProviderModuleMeta) string {
+	addrs := make([]string, len(metas))
+	for i, m := range metas {
+		addrs[i] = "the root module"
+		if prefix := modulePrefixStr(m.ModulePath); prefix != "" {
+			addrs[i] = prefix
+		}
+	}
+	return strings.Join(addrs, ", ")
 }
 
 // EvalInitProvider is an EvalNode implementation that initializes a provider
@@ -97,6 +152,10 @@ type EvalInputProvider struct {
 	Name     string
 	Provider *ResourceProvider
 	Config   **ResourceConfig
+
+	// Addr is the address of the provider configuration, including its
+	// module path, which qualifies the errors of the provider.
+	Addr string
 }
 
 func (n *EvalInputProvider) Eval(ctx EvalContext) (interface{}, error) {
@@ -115,7 +174,7 @@ func (n *EvalInputProvider) Eval(ctx EvalContext) (interface{}, error) {
 	config, err := (*n.Provider).Input(input, rc)
 	if err != nil {
 		return nil, fmt.Errorf(
-			"Error configuring %s: %s", n.Name, err)
+			"Error configuring %s: %s", n.Addr, err)
 	}
 
 	// We only store values that have changed through Input.
@@ -135,3 +194,122 @@ func (n *EvalInputProvider) Eval(ctx EvalContext) (interface{}, error) {
 
 	return nil, nil
 }
+
+// providerStopGracePeriod is how long a call to a provider may continue once
+// Terraform has been interrupted, giving the provider a chance to respond to
+// its Stop request, before it is abandoned.
+var providerStopGracePeriod = 30 * time.Second
+
+// providerCallKind is the kind of provider call, which determines which of
+// the provider's configured timeouts applies to it.
+type providerCallKind int
+
+const (
+	providerCallRead providerCallKind = iota
+	providerCallPlan
+	providerCallApply
+
+	// Validating and configuring the provider are limited by its read
+	// timeout.
+	providerCallValidate
+	providerCallConfigure
+)
+
+func (k providerCallKind) String() string {
+	switch k {
+	case providerCallPlan:
+		return "plan"
+	case providerCallApply:
+		return "apply"
+	case providerCallValidate:
+		return "validate"
+	case providerCallConfigure:
+		return "configure"
+	default:
+		return "read"
+	}
+}
+
+// callProvider calls fn, which must make a single call to the provider with
+// the given name, and waits for it to return. If the provider's parallelism
+// is limited, callProvider first waits for one of the other calls to it to
+// return.
+//
+// If the call takes longer than the timeout configured for its kind, or
+// Terraform is interrupted and the provider doesn't respond to its Stop
+// request within providerStopGracePeriod, callProvider gives up waiting
+// and returns an error instead. In that case fn may still be running, so
+// the caller must not use any of its results, and the outcome of the call
+// is unknown.
+func callProvider(ctx EvalContext, name string, kind providerCallKind, fn func()) error {
+	var timeout time.Duration
+	timeoutName := "read"
+	if t := ctx.ProviderTimeouts(name); t != nil {
+		switch kind {
+		case providerCallRead, providerCallValidate, providerCallConfigure:
+			timeout = t.Read
+		case providerCallPlan:
+			timeout, timeoutName = t.Plan, "plan"
+		case providerCallApply:
+			timeout, timeoutName = t.Apply, "apply"
+		}
+	}
+
+	sem := ctx.ProviderSemaphore(name)
+	if sem != nil {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Stopped():
+			return fmt.Errorf("%s: interrupted while waiting to make %s call", name, kind)
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if sem != nil {
+			defer sem.Release()
+		}
+		fn()
+	}()
+
+	var timedOut <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		timedOut = timer.C
+	}
+
+	timeoutErr := func() error {
+		log.Printf("[ERROR] %s: %s call exceeded timeout of %s", name, kind, timeout)
+		return fmt.Errorf(
+			"%s did not respond to its %s call within the %s timeout of %s configured for it",
+			name, kind, timeoutName, timeout)
+	}
+
+	select {
+	case <-done:
+		return nil
+	case <-timedOut:
+		return timeoutErr()
+	case <-ctx.Stopped():
+	}
+
+	// Terraform has been interrupted, which asks the provider to stop, but
+	// a hung provider mustn't prevent Terraform from exiting cleanly and
+	// releasing its state lock.
+	grace := time.NewTimer(providerStopGracePeriod)
+	defer grace.Stop()
+
+	select {
+	case <-done:
+		return nil
+	case <-timedOut:
+		return timeoutErr()
+	case <-grace.C:
+		log.Printf("[ERROR] %s: abandoning %s call after interrupt", name, kind)
+		return fmt.Errorf(
+			"%s did not stop within %s of Terraform being interrupted",
+			name, providerStopGracePeriod)
+	}
+}
@@ -1,8 +1,11 @@
 package terraform
 
 import (
+	"errors"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/terraform/config"
 )
@@ -69,6 +72,58 @@ func TestEvalConfigProvider(t *testing.T) {
 	}
 }
 
+func TestEvalConfigProvider_timeouts(t *testing.T) {
+	timeouts := &config.ProviderTimeouts{Read: time.Minute}
+	cfg := testResourceConfig(t, map[string]interface{}{})
+	n := &EvalConfigProvider{Provider: "aws", Config: &cfg, Timeouts: timeouts}
+
+	ctx := &MockEvalContext{ProviderProvider: &MockResourceProvider{}}
+	if _, err := n.Eval(ctx); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if ctx.SetProviderTimeoutsName != "aws" {
+		t.Fatalf("bad: %s", ctx.SetProviderTimeoutsName)
+	}
+	if ctx.SetProviderTimeoutsValue != timeouts {
+		t.Fatalf("bad: %#v", ctx.SetProviderTimeoutsValue)
+	}
+}
+
+func TestEvalConfigProvider_parallelism(t *testing.T) {
+	cfg := testResourceConfig(t, map[string]interface{}{})
+	n := &EvalConfigProvider{Provider: "provider.aws.west", Config: &cfg, Parallelism: 2}
+
+	ctx := &MockEvalContext{ProviderProvider: &MockResourceProvider{}}
+	if _, err := n.Eval(ctx); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if ctx.SetProviderParallelismName != "provider.aws.west" {
+		t.Fatalf("bad: %s", ctx.SetProviderParallelismName)
+	}
+	if ctx.SetProviderParallelismValue != 2 {
+		t.Fatalf("bad: %d", ctx.SetProviderParallelismValue)
+	}
+}
+
+func TestEvalConfigProvider_error(t *testing.T) {
+	cfg := testResourceConfig(t, map[string]interface{}{})
+	n := &EvalConfigProvider{Provider: "module.child.provider.aws.west", Config: &cfg}
+
+	ctx := &MockEvalContext{
+		ProviderProvider:       &MockResourceProvider{},
+		ConfigureProviderError: errors.New("invalid credentials"),
+	}
+	_, err := n.Eval(ctx)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if got, want := err.Error(), "module.child.provider.aws.west: invalid credentials"; got != want {
+		t.Fatalf("wrong error %q; want %q", got, want)
+	}
+}
+
 func TestEvalInitProvider_impl(t *testing.T) {
 	var _ EvalNode = new(EvalInitProvider)
 }
@@ -191,3 +246,190 @@ func TestEvalInputProvider(t *testing.T) {
 		t.Errorf("got incorrect input config:\n%#v\nwant:\n%#v", inputCfg, want)
 	}
 }
+
+func TestEvalInputProvider_error(t *testing.T) {
+	var provider ResourceProvider = &MockResourceProvider{
+		InputFn: func(ui UIInput, c *ResourceConfig) (*ResourceConfig, error) {
+			return nil, errors.New("no credentials")
+		},
+	}
+	ctx := &MockEvalContext{ProviderProvider: provider}
+	config := testResourceConfig(t, map[string]interface{}{})
+
+	n := &EvalInputProvider{
+		Name:     "aws.eu",
+		Provider: &provider,
+		Config:   &config,
+		Addr:     "module.child.provider.aws.eu",
+	}
+
+	_, err := n.Eval(ctx)
+	if err == nil {
+		t.Fatal("should error")
+	}
+	if got, want := err.Error(), "Error configuring module.child.provider.aws.eu: no credentials"; !strings.Contains(got, want) {
+		t.Fatalf("wrong error %q; want it to contain %q", got, want)
+	}
+}
+
+func TestCallProvider(t *testing.T) {
+	ctx := &MockEvalContext{}
+
+	called := false
+	err := callProvider(ctx, "aws", providerCallRead, func() {
+		called = true
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !called {
+		t.Fatal("should be called")
+	}
+}
+
+func TestCallProvider_timeout(t *testing.T) {
+	ctx := &MockEvalContext{
+		ProviderTimeoutsValue: &config.ProviderTimeouts{
+			Apply: 10 * time.Millisecond,
+		},
+	}
+
+	release := make(chan struct{})
+	defer close(release)
+
+	err := callProvider(ctx, "aws", providerCallApply, func() {
+		<-release
+	})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if !strings.Contains(err.Error(), "apply timeout of 10ms") {
+		t.Fatalf("wrong error: %s", err)
+	}
+	if ctx.ProviderTimeoutsName != "aws" {
+		t.Fatalf("bad: %s", ctx.ProviderTimeoutsName)
+	}
+}
+
+func TestCallProvider_timeoutConfigure(t *testing.T) {
+	ctx := &MockEvalContext{
+		ProviderTimeoutsValue: &config.ProviderTimeouts{
+			Read: 10 * time.Millisecond,
+		},
+	}
+
+	release := make(chan struct{})
+	defer close(release)
+
+	err := callProvider(ctx, "aws", providerCallConfigure, func() {
+		<-release
+	})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if !strings.Contains(err.Error(), "configure call within the read timeout of 10ms") {
+		t.Fatalf("wrong error: %s", err)
+	}
+}
+
+func TestCallProvider_stopped(t *testing.T) {
+	defer func(d time.Duration) {
+		providerStopGracePeriod = d
+	}(providerStopGracePeriod)
+	providerStopGracePeriod = 10 * time.Millisecond
+
+	stopped := make(chan struct{})
+	close(stopped)
+	ctx := &MockEvalContext{StoppedValue: stopped}
+
+	release := make(chan struct{})
+	defer close(release)
+
+	err := callProvider(ctx, "aws", providerCallRead, func() {
+		<-release
+	})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if !strings.Contains(err.Error(), "did not stop") {
+		t.Fatalf("wrong error: %s", err)
+	}
+}
+
+func TestCallProvider_parallelism(t *testing.T) {
+	sem := NewSemaphore(1)
+	ctx := &MockEvalContext{ProviderSemaphoreValue: sem}
+
+	called := false
+	err := callProvider(ctx, "provider.aws.west", providerCallRead, func() {
+		called = true
+		if sem.TryAcquire() {
+			t.Error("semaphore should be held during the call")
+		}
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !called {
+		t.Fatal("should be called")
+	}
+	if ctx.ProviderSemaphoreName != "provider.aws.west" {
+		t.Fatalf("bad: %s", ctx.ProviderSemaphoreName)
+	}
+	if !sem.TryAcquire() {
+		t.Fatal("semaphore should be released after the call")
+	}
+
+	// With the semaphore held by another call, an interrupt stops the wait
+	stopped := make(chan struct{})
+	close(stopped)
+	ctx.StoppedValue = stopped
+
+	err = callProvider(ctx, "provider.aws.west", providerCallRead, func() {
+		t.Error("should not be called")
+	})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if !strings.Contains(err.Error(), "interrupted while waiting") {
+		t.Fatalf("wrong error: %s", err)
+	}
+}
+
+func TestEvalApply_abandoned(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	provider := &MockResourceProvider{
+		ApplyFn: func(*InstanceInfo, *InstanceState, *InstanceDiff) (*InstanceState, error) {
+			<-release
+			return nil, nil
+		},
+	}
+	ctx := &MockEvalContext{
+		ProviderTimeoutsValue: &config.ProviderTimeouts{
+			Apply: 10 * time.Millisecond,
+		},
+	}
+
+	var rp ResourceProvider = provider
+	state := &InstanceState{ID: "foo"}
+	diff := &InstanceDiff{Destroy: true}
+	var output *InstanceState
+	n := &EvalApply{
+		Info:         &InstanceInfo{Id: "aws_instance.foo"},
+		State:        &state,
+		Diff:         &diff,
+		Provider:     &rp,
+		ProviderName: "provider.aws",
+		Output:       &output,
+	}
+
+	_, err := n.Eval(ctx)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if output == nil || output.ID != "foo" {
+		t.Fatalf("prior state should be kept, got %#v", output)
+	}
+}
@@ -7,11 +7,12 @@ import (
 // EvalReadDataDiff is an EvalNode implementation that executes a data
 // resource's ReadDataDiff method to discover what attributes it exports.
 type EvalReadDataDiff struct {
-	Provider    *ResourceProvider
-	Output      **InstanceDiff
-	OutputState **InstanceState
-	Config      **ResourceConfig
-	Info        *InstanceInfo
+	Provider     *ResourceProvider
+	ProviderName string
+	Output       **InstanceDiff
+	OutputState  **InstanceState
+	Config       **ResourceConfig
+	Info         *InstanceInfo
 
 	// Set Previous when re-evaluating diff during apply, to ensure that
 	// the "Destroy" flag is preserved.
@@ -38,8 +39,14 @@ func (n *EvalReadDataDiff) Eval(ctx EvalContext) (interface{}, error) {
 		provider := *n.Provider
 		config := *n.Config
 
-		var err error
-		diff, err = provider.ReadDataDiff(n.Info, config)
+		var readDiff *InstanceDiff
+		var readErr error
+		err := callProvider(ctx, n.ProviderName, providerCallPlan, func() {
+			readDiff, readErr = provider.ReadDataDiff(n.Info, config)
+		})
+		if err == nil {
+			diff, err = readDiff, readErr
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -58,6 +65,8 @@ func (n *EvalReadDataDiff) Eval(ctx EvalContext) (interface{}, error) {
 				Type:        DiffAttrOutput,
 			})
 		}
+
+		markSensitiveAttributes(diff, config)
 	}
 
 	err = ctx.Hook(func(h Hook) (HookAction, error) {
@@ -86,10 +95,11 @@ func (n *EvalReadDataDiff) Eval(ctx EvalContext) (interface{}, error) {
 // EvalReadDataApply is an EvalNode implementation that executes a data
 // resource's ReadDataApply method to read data from the data source.
 type EvalReadDataApply struct {
-	Provider *ResourceProvider
-	Output   **InstanceState
-	Diff     **InstanceDiff
-	Info     *InstanceInfo
+	Provider     *ResourceProvider
+	ProviderName string
+	Output       **InstanceState
+	Diff         **InstanceDiff
+	Info         *InstanceInfo
 }
 
 func (n *EvalReadDataApply) Eval(ctx EvalContext) (interface{}, error) {
@@ -119,11 +129,24 @@ func (n *EvalReadDataApply) Eval(ctx EvalContext) (interface{}, error) {
 		return nil, err
 	}
 
-	state, err := provider.ReadDataApply(n.Info, diff)
+	var state *InstanceState
+	var readErr error
+	err = callProvider(ctx, n.ProviderName, providerCallRead, func() {
+		state, readErr = provider.ReadDataApply(n.Info, diff)
+	})
+	if err == nil {
+		err = readErr
+	}
 	if err != nil {
 		return nil, fmt.Errorf("%s: %s", n.Info.Id, err)
 	}
 
+	// Data sources are read afresh each time, so the attributes that are
+	// sensitive are those derived from sensitive values in the config.
+	if state != nil {
+		state.SensitiveAttributes = mergeSensitiveAttributes(state, diff, state.Attributes)
+	}
+
 	err = ctx.Hook(func(h Hook) (HookAction, error) {
 		return h.PostRefresh(n.Info, state)
 	})
@@ -8,10 +8,11 @@ import (
 // EvalRefresh is an EvalNode implementation that does a refresh for
 // a resource.
 type EvalRefresh struct {
-	Provider *ResourceProvider
-	State    **InstanceState
-	Info     *InstanceInfo
-	Output   **InstanceState
+	Provider     *ResourceProvider
+	ProviderName string
+	State        **InstanceState
+	Info         *InstanceInfo
+	Output       **InstanceState
 }
 
 // TODO: test
@@ -34,11 +35,25 @@ func (n *EvalRefresh) Eval(ctx EvalContext) (interface{}, error) {
 	}
 
 	// Refresh!
-	state, err = provider.Refresh(n.Info, state)
+	prior := state
+	var refreshed *InstanceState
+	var refreshErr error
+	err = callProvider(ctx, n.ProviderName, providerCallRead, func() {
+		refreshed, refreshErr = provider.Refresh(n.Info, state)
+	})
+	if err == nil {
+		state, err = refreshed, refreshErr
+	}
 	if err != nil {
 		return nil, fmt.Errorf("%s: %s", n.Info.Id, err.Error())
 	}
 
+	// Providers know nothing about where values came from, so we carry
+	// forward the record of which attributes are sensitive ourselves.
+	if state != nil {
+		state.SensitiveAttributes = mergeSensitiveAttributes(prior, nil, state.Attributes)
+	}
+
 	// Call post-refresh hook
 	err = ctx.Hook(func(h Hook) (HookAction, error) {
 		return h.PostRefresh(n.Info, state)
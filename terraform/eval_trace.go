@@ -0,0 +1,215 @@
+package terraform
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/hil"
+	"github.com/hashicorp/hil/ast"
+	"github.com/hashicorp/terraform/config"
+)
+
+// EvalTraceSource is where the value of a reference came from.
+type EvalTraceSource string
+
+const (
+	// EvalTraceState is a value read from the state as it was during the
+	// walk, as for resource attributes, module outputs and local values.
+	EvalTraceState EvalTraceSource = "state"
+
+	// EvalTraceVariable is the value that was given for an input variable.
+	EvalTraceVariable EvalTraceSource = "variable"
+
+	// EvalTraceDefault is the default value of an input variable that was
+	// given no value.
+	EvalTraceDefault EvalTraceSource = "default"
+
+	// EvalTraceCount is the index of the resource instance being evaluated.
+	EvalTraceCount EvalTraceSource = "count"
+
+	// EvalTracePath is a filesystem path, such as that of the module.
+	EvalTracePath EvalTraceSource = "path"
+
+	// EvalTraceWorkspace is the name of the current workspace.
+	EvalTraceWorkspace EvalTraceSource = "workspace"
+)
+
+// EvalTraceEntry is a reference that was resolved during a walk, and the
+// value it resolved to.
+type EvalTraceEntry struct {
+	// Operation is the walk the reference was resolved in, such as "plan".
+	Operation string `json:"operation"`
+
+	// Module is the address of the module the reference is in, which is ""
+	// for the root module, and Resource is that of the resource whose
+	// configuration has the reference, if any, with the index of the
+	// instance being evaluated.
+	Module   string `json:"module,omitempty"`
+	Resource string `json:"resource,omitempty"`
+	Index    int    `json:"index,omitempty"`
+
+	// Reference is the reference as written, such as "var.region".
+	Reference string `json:"reference"`
+
+	// Value is the value the reference resolved to, which is nil if it's
+	// unknown.
+	Value   interface{}     `json:"value"`
+	Unknown bool            `json:"unknown,omitempty"`
+	Source  EvalTraceSource `json:"source"`
+
+	// Detail is more about the source, when it's known, such as how the
+	// value of a root module variable was set.
+	Detail string `json:"detail,omitempty"`
+}
+
+// key returns the string that the entries are sorted and deduplicated by.
+func (e *EvalTraceEntry) key() string {
+	value, _ := json.Marshal(e.Value)
+	return fmt.Sprintf(
+		"%s\x00%s\x00%s\x00%09d\x00%s\x00%t\x00%s",
+		e.Operation, e.Module, e.Resource, e.Index, e.Reference, e.Unknown, value)
+}
+
+// EvalTrace records the references that are resolved while walking the
+// graph, so that it can be found out why an expression evaluated to what it
+// did. It's opt-in, by setting ContextOpts.EvalTrace, since it keeps every
+// value that's resolved.
+//
+// The same reference is usually resolved many times, in parallel, and so
+// the trace only keeps each distinct resolution once and sorts them, so that
+// it's the same for every walk of the same configuration and state.
+type EvalTrace struct {
+	lock    sync.Mutex
+	entries map[string]*EvalTraceEntry
+}
+
+// NewEvalTrace returns an empty trace.
+func NewEvalTrace() *EvalTrace {
+	return &EvalTrace{entries: make(map[string]*EvalTraceEntry)}
+}
+
+// Record adds a resolved reference to the trace.
+func (t *EvalTrace) Record(e *EvalTraceEntry) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.entries[e.key()] = e
+}
+
+// Entries returns the references that have been resolved, sorted by walk,
+// module, resource and reference.
+func (t *EvalTrace) Entries() []*EvalTraceEntry {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	keys := make([]string, 0, len(t.entries))
+	for k := range t.entries {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	result := make([]*EvalTraceEntry, len(keys))
+	for i, k := range keys {
+		result[i] = t.entries[k]
+	}
+	return result
+}
+
+// Lookup returns the entries for the given reference, such as "var.region",
+// in the module at the given address, which is "" for the root module. An
+// empty operation matches every walk.
+func (t *EvalTrace) Lookup(operation, module, reference string) []*EvalTraceEntry {
+	var result []*EvalTraceEntry
+	for _, e := range t.Entries() {
+		if operation != "" && e.Operation != operation {
+			continue
+		}
+		if e.Module == module && e.Reference == reference {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+// WriteJSON writes the entries of the trace to w as a JSON array, in the
+// order Entries returns them.
+func (t *EvalTrace) WriteJSON(w io.Writer) error {
+	entries := t.Entries()
+	if entries == nil {
+		entries = []*EvalTraceEntry{}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(entries)
+}
+
+// traceValue records the value that the reference n resolved to in the
+// given scope, if the interpolater has a trace.
+func (i *Interpolater) traceValue(
+	scope *InterpolationScope,
+	n string,
+	v config.InterpolatedVariable,
+	val ast.Variable) {
+	if i.Trace == nil {
+		return
+	}
+
+	e := &EvalTraceEntry{
+		Operation: strings.ToLower(strings.TrimPrefix(i.Operation.String(), "walk")),
+		Module:    modulePrefixStr(scope.Path),
+		Reference: n,
+	}
+	e.Source, e.Detail = i.traceSource(v)
+	if r := scope.Resource; r != nil {
+		e.Resource = fmt.Sprintf("%s.%s", r.Type, r.Name)
+		e.Index = r.CountIndex
+	}
+
+	switch val.Type {
+	case ast.TypeUnknown:
+		e.Unknown = true
+	case ast.TypeInt, ast.TypeFloat, ast.TypeBool:
+		e.Value = val.Value
+	default:
+		value, err := hil.VariableToInterface(val)
+		if err != nil {
+			value = fmt.Sprintf("%v", val.Value)
+		}
+		e.Value = value
+	}
+
+	i.Trace.Record(e)
+}
+
+// traceSource returns where the value of the given variable comes from, and
+// the detail of the source, if any.
+func (i *Interpolater) traceSource(v config.InterpolatedVariable) (EvalTraceSource, string) {
+	switch v := v.(type) {
+	case *config.CountVariable:
+		return EvalTraceCount, ""
+	case *config.PathVariable:
+		return EvalTracePath, ""
+	case *config.TerraformVariable:
+		return EvalTraceWorkspace, ""
+	case *config.UserVariable:
+		i.VariableValuesLock.Lock()
+		defer i.VariableValuesLock.Unlock()
+		if _, ok := i.VariableValues[v.Name]; !ok {
+			return EvalTraceDefault, ""
+		}
+
+		// The defaults of the root module's variables are among its
+		// values.
+		detail := i.VariableSources[v.Name]
+		if detail == variableSourceDefault {
+			return EvalTraceDefault, ""
+		}
+		return EvalTraceVariable, detail
+	}
+	return EvalTraceState, ""
+}
@@ -0,0 +1,88 @@
+package terraform
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestContext2Plan_evalTrace(t *testing.T) {
+	plan := func() *EvalTrace {
+		m := testModule(t, "plan-eval-trace")
+		p := testProvider("aws")
+		p.DiffFn = testDiffFn
+		trace := NewEvalTrace()
+		ctx := testContext2(t, &ContextOpts{
+			Module: m,
+			ProviderResolver: ResourceProviderResolverFixed(
+				map[string]ResourceProviderFactory{
+					"aws": testProviderFuncFixed(p),
+				},
+			),
+			Variables: map[string]interface{}{
+				"ami": "ami-123",
+			},
+			EvalTrace: trace,
+		})
+
+		if _, err := ctx.Plan(); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		return trace
+	}
+
+	trace := plan()
+
+	cases := []struct {
+		Reference string
+		Value     interface{}
+		Source    EvalTraceSource
+	}{
+		{"var.ami", "ami-123", EvalTraceVariable},
+		{"var.region", "us-east-1", EvalTraceDefault},
+		{"local.name", "web-us-east-1", EvalTraceState},
+	}
+	for _, tc := range cases {
+		entries := trace.Lookup("plan", "", tc.Reference)
+		if len(entries) == 0 {
+			t.Fatalf("%s: no entries", tc.Reference)
+		}
+		for _, e := range entries {
+			if e.Value != tc.Value || e.Source != tc.Source || e.Unknown {
+				t.Fatalf("%s: bad: %#v", tc.Reference, e)
+			}
+		}
+	}
+
+	entries := trace.Lookup("plan", "", "count.index")
+	if len(entries) != 2 {
+		t.Fatalf("expected an entry per instance, got %#v", entries)
+	}
+	for i, e := range entries {
+		if e.Resource != "aws_instance.foo" || e.Index != i || e.Value != i || e.Source != EvalTraceCount {
+			t.Fatalf("bad: %#v", e)
+		}
+	}
+
+	// The trace of another plan of the same configuration is the same,
+	// although its references were resolved in another order.
+	var a, b bytes.Buffer
+	if err := trace.WriteJSON(&a); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := plan().WriteJSON(&b); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if a.String() != b.String() {
+		t.Fatalf("traces differ:\n%s\n\n%s", a.String(), b.String())
+	}
+}
+
+func TestEvalTrace_empty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewEvalTrace().WriteJSON(&buf); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if buf.String() != "[]\n" {
+		t.Fatalf("bad: %q", buf.String())
+	}
+}
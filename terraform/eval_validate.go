@@ -12,6 +12,14 @@ import (
 type EvalValidateError struct {
 	Warnings []string
 	Errors   []error
+
+	// AttributePath is the path of the attribute of the object that the
+	// errors are about, if they're all about one.
+	AttributePath string
+
+	// Address is the address of the object that the errors are about. If
+	// it's empty, they're about the graph vertex that returned them.
+	Address string
 }
 
 func (e *EvalValidateError) Error() string {
@@ -53,7 +61,8 @@ func (n *EvalValidateCount) Eval(ctx EvalContext) (interface{}, error) {
 RETURN:
 	if len(errs) != 0 {
 		err = &EvalValidateError{
-			Errors: errs,
+			Errors:        errs,
+			AttributePath: "count",
 		}
 	}
 	return nil, err
@@ -64,13 +73,30 @@ RETURN:
 type EvalValidateProvider struct {
 	Provider *ResourceProvider
 	Config   **ResourceConfig
+
+	// Addr is the address of the provider configuration, including its
+	// alias and module path, which the diagnostics are reported against.
+	Addr string
+
+	// Timeouts are the timeouts configured for the provider, of which the
+	// read timeout limits how long validating its configuration may take.
+	Timeouts *config.ProviderTimeouts
 }
 
 func (n *EvalValidateProvider) Eval(ctx EvalContext) (interface{}, error) {
 	provider := *n.Provider
-	config := *n.Config
+	cfg := *n.Config
 
-	warns, errs := provider.Validate(config)
+	ctx.SetProviderTimeouts(n.Addr, n.Timeouts)
+
+	var warns []string
+	var errs []error
+	err := callProvider(ctx, n.Addr, providerCallValidate, func() {
+		warns, errs = provider.Validate(cfg)
+	})
+	if err != nil {
+		return nil, err
+	}
 	if len(warns) == 0 && len(errs) == 0 {
 		return nil, nil
 	}
@@ -78,6 +104,7 @@ func (n *EvalValidateProvider) Eval(ctx EvalContext) (interface{}, error) {
 	return nil, &EvalValidateError{
 		Warnings: warns,
 		Errors:   errs,
+		Address:  n.Addr,
 	}
 }
 
@@ -143,19 +170,30 @@ func (n *EvalValidateProvisioner) validateConnConfig(connConfig *ResourceConfig)
 		ScriptPath interface{} `mapstructure:"script_path"`
 
 		// For type=ssh only (enforced in ssh communicator)
-		PrivateKey        interface{} `mapstructure:"private_key"`
-		Agent             interface{} `mapstructure:"agent"`
-		BastionHost       interface{} `mapstructure:"bastion_host"`
-		BastionPort       interface{} `mapstructure:"bastion_port"`
-		BastionUser       interface{} `mapstructure:"bastion_user"`
-		BastionPassword   interface{} `mapstructure:"bastion_password"`
-		BastionPrivateKey interface{} `mapstructure:"bastion_private_key"`
-		AgentIdentity     interface{} `mapstructure:"agent_identity"`
+		PrivateKey         interface{} `mapstructure:"private_key"`
+		Agent              interface{} `mapstructure:"agent"`
+		BastionHost        interface{} `mapstructure:"bastion_host"`
+		BastionPort        interface{} `mapstructure:"bastion_port"`
+		BastionUser        interface{} `mapstructure:"bastion_user"`
+		BastionPassword    interface{} `mapstructure:"bastion_password"`
+		BastionPrivateKey  interface{} `mapstructure:"bastion_private_key"`
+		AgentIdentity      interface{} `mapstructure:"agent_identity"`
+		AgentForwarding    interface{} `mapstructure:"agent_forwarding"`
+		MaxSessions        interface{} `mapstructure:"max_sessions"`
+		Certificate        interface{} `mapstructure:"certificate"`
+		BastionCertificate interface{} `mapstructure:"bastion_certificate"`
+		KnownHosts         interface{} `mapstructure:"known_hosts"`
 
 		// For type=winrm only (enforced in winrm communicator)
-		HTTPS    interface{} `mapstructure:"https"`
-		Insecure interface{} `mapstructure:"insecure"`
-		CACert   interface{} `mapstructure:"cacert"`
+		HTTPS             interface{} `mapstructure:"https"`
+		Insecure          interface{} `mapstructure:"insecure"`
+		CACert            interface{} `mapstructure:"cacert"`
+		UseNTLM           interface{} `mapstructure:"use_ntlm"`
+		TLSServerName     interface{} `mapstructure:"tls_server_name"`
+		ProxyHost         interface{} `mapstructure:"proxy_host"`
+		ProxyPort         interface{} `mapstructure:"proxy_port"`
+		ProxyUserName     interface{} `mapstructure:"proxy_user_name"`
+		ProxyUserPassword interface{} `mapstructure:"proxy_user_password"`
 	}
 
 	var metadata mapstructure.Metadata
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/terraform/config"
 )
@@ -179,6 +180,39 @@ func TestEvalValidateResource_ignoreWarnings(t *testing.T) {
 	}
 }
 
+func TestEvalValidateProvider_timeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	mp := testProvider("aws")
+	mp.ValidateFn = func(c *ResourceConfig) ([]string, []error) {
+		<-release
+		return nil, nil
+	}
+
+	p := ResourceProvider(mp)
+	rc := testResourceConfig(t, map[string]interface{}{})
+	timeouts := &config.ProviderTimeouts{Read: 10 * time.Millisecond}
+	node := &EvalValidateProvider{
+		Provider: &p,
+		Config:   &rc,
+		Addr:     "provider.aws",
+		Timeouts: timeouts,
+	}
+	ctx := &MockEvalContext{ProviderTimeoutsValue: timeouts}
+
+	_, err := node.Eval(ctx)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if !strings.Contains(err.Error(), "validate call within the read timeout of 10ms") {
+		t.Fatalf("wrong error: %s", err)
+	}
+	if ctx.SetProviderTimeoutsValue != timeouts {
+		t.Fatalf("timeouts not set: %#v", ctx.SetProviderTimeoutsValue)
+	}
+}
+
 func TestEvalValidateProvisioner_valid(t *testing.T) {
 	mp := &MockResourceProvisioner{}
 	var p ResourceProvisioner = mp
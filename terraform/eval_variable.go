@@ -7,15 +7,22 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/hashicorp/hcl2/hcl"
+	"github.com/hashicorp/hil"
+	"github.com/hashicorp/hil/ast"
 	"github.com/hashicorp/terraform/config"
 	"github.com/hashicorp/terraform/config/module"
 	"github.com/hashicorp/terraform/helper/hilmapstructure"
+	"github.com/hashicorp/terraform/tfdiags"
+	"github.com/mitchellh/reflectwalk"
 )
 
 // EvalTypeCheckVariable is an EvalNode which ensures that the variable
 // values which are assigned as inputs to a module (including the root)
 // match the types which are either declared for the variables explicitly
-// or inferred from the default values.
+// or inferred from the default values. The values of variables with type
+// constraints are also converted to them, which sets the defaults of the
+// optional attributes of objects.
 //
 // In order to achieve this three things are required:
 //     - a map of the proposed variable values
@@ -36,17 +43,32 @@ func (n *EvalTypeCheckVariable) Eval(ctx EvalContext) (interface{}, error) {
 	}
 	targetConfig := currentTree.Config()
 
-	prototypes := make(map[string]config.VariableType)
-	for _, variable := range targetConfig.Variables {
-		prototypes[variable.Name] = variable.Type()
-	}
-
 	// Only display a module in an error message if we are not in the root module
 	modulePathDescription := fmt.Sprintf(" in module %s", strings.Join(n.ModulePath[1:], "."))
 	if len(n.ModulePath) == 1 {
 		modulePathDescription = ""
 	}
 
+	prototypes := make(map[string]config.VariableType)
+	for _, variable := range targetConfig.Variables {
+		t, err := variable.TypeConstraint()
+		if err != nil || t == nil {
+			prototypes[variable.Name] = variable.Type()
+			continue
+		}
+
+		proposedValue, ok := n.Variables[variable.Name]
+		if !ok {
+			continue
+		}
+		converted, err := t.Convert(proposedValue)
+		if err != nil {
+			return nil, fmt.Errorf("variable %s%s should be type %s: %s",
+				variable.Name, modulePathDescription, variable.DeclaredType, convertErrorString(variable, err))
+		}
+		n.Variables[variable.Name] = converted
+	}
+
 	for name, declaredType := range prototypes {
 		proposedValue, ok := n.Variables[name]
 		if !ok {
@@ -93,6 +115,113 @@ func (n *EvalTypeCheckVariable) Eval(ctx EvalContext) (interface{}, error) {
 	return nil, nil
 }
 
+// EvalValidateVariables is an EvalNode which checks the variable values
+// which are assigned as inputs to a child module against the validation
+// rules of the variables' declarations.
+type EvalValidateVariables struct {
+	Variables  map[string]interface{}
+	ModulePath []string
+	ModuleTree *module.Tree
+}
+
+func (n *EvalValidateVariables) Eval(ctx EvalContext) (interface{}, error) {
+	currentTree := n.ModuleTree
+	for _, pathComponent := range n.ModulePath[1:] {
+		currentTree = currentTree.Children()[pathComponent]
+	}
+	targetConfig := currentTree.Config()
+
+	source := fmt.Sprintf("set by the module %q block", n.ModulePath[len(n.ModulePath)-1])
+	var diags tfdiags.Diagnostics
+	for _, v := range targetConfig.Variables {
+		value, ok := n.Variables[v.Name]
+		if !ok {
+			continue
+		}
+		diags = diags.Append(validateVariable(v, value, n.ModulePath, source))
+	}
+
+	return nil, diags.Err()
+}
+
+// validateVariable checks a value of a variable against the validation
+// rules of its declaration, where source describes where the value came
+// from. Values that aren't known yet can't be checked, so they pass.
+func validateVariable(v *config.Variable, value interface{}, modulePath []string, source string) tfdiags.Diagnostics {
+	if len(v.Validations) == 0 {
+		return nil
+	}
+
+	w := &unknownCheckWalker{}
+	if err := reflectwalk.Walk(value, w); err == nil && w.Unknown {
+		return nil
+	}
+
+	addr := "var." + v.Name
+	if len(modulePath) > 1 {
+		addr = fmt.Sprintf("module.%s.%s", strings.Join(modulePath[1:], ".module."), addr)
+	}
+
+	var diags tfdiags.Diagnostics
+
+	// HCL decodes maps as a list of one map.
+	if ms, ok := value.([]map[string]interface{}); ok && len(ms) == 1 {
+		value = ms[0]
+	}
+	hilValue, err := hil.InterfaceToVariable(value)
+	if err != nil {
+		return diags.Append(fmt.Errorf("%s: can't check the value: %s", addr, err))
+	}
+	vars := map[string]ast.Variable{"var." + v.Name: hilValue}
+
+	for _, validation := range v.Validations {
+		subject := validation.DeclRange.ToHCL()
+
+		rc := validation.Condition.Copy()
+		if err := rc.Interpolate(vars); err != nil {
+			diags = diags.Append(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid variable validation condition",
+				Detail:   fmt.Sprintf("The condition for %s could not be evaluated: %s.", addr, err),
+				Subject:  &subject,
+			})
+			continue
+		}
+		if len(rc.UnknownKeys()) > 0 {
+			continue
+		}
+
+		var valid bool
+		if err := hilmapstructure.WeakDecode(rc.Config()["condition"], &valid); err != nil {
+			diags = diags.Append(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid variable validation condition",
+				Detail:   fmt.Sprintf("The condition for %s must be either true or false: %s.", addr, err),
+				Subject:  &subject,
+			})
+			continue
+		}
+		if valid {
+			continue
+		}
+
+		diags = diags.Append(&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid value for variable",
+			Detail: fmt.Sprintf(
+				"%s\n\nThe value of %s was %s, and was checked by the validation rule at %s.",
+				validation.ErrorMessage, addr, source, validation.DeclRange.StartString(),
+			),
+			Subject: &subject,
+		})
+	}
+
+	return diags.WithExtra(tfdiags.Extra{
+		Code:    tfdiags.CodeVariableInvalid,
+		Address: addr,
+	})
+}
+
 // EvalSetVariables is an EvalNode implementation that sets the variables
 // explicitly for interpolation later.
 type EvalSetVariables struct {
@@ -246,7 +375,7 @@ func (n *EvalCoerceMapVariable) Eval(ctx EvalContext) (interface{}, error) {
 		if list, ok := proposedValue.([]interface{}); ok && len(list) == 1 {
 			if m, ok := list[0].(map[string]interface{}); ok {
 				log.Printf("[DEBUG] EvalCoerceMapVariable: "+
-					"Coercing single element list into map: %#v", m)
+					"Coercing single element list into map for variable %s", name)
 				n.Variables[name] = m
 			}
 		}
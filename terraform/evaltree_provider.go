@@ -42,11 +42,20 @@ func ProviderEvalTree(n *NodeApplyableProvider, config *config.ProviderConfig) E
 					Name:     n.NameValue,
 					Provider: &provider,
 					Config:   &resourceConfig,
+					Addr:     n.Name(),
 				},
 			},
 		},
 	})
 
+	validate := &EvalValidateProvider{
+		Provider: &provider,
+		Config:   &resourceConfig,
+		Addr:     n.Name(),
+	}
+	if config != nil {
+		validate.Timeouts = config.Timeouts
+	}
 	seq = append(seq, &EvalOpFilter{
 		Ops: []walkOperation{walkValidate},
 		Node: &EvalSequence{
@@ -64,10 +73,7 @@ func ProviderEvalTree(n *NodeApplyableProvider, config *config.ProviderConfig) E
 					Config:   &resourceConfig,
 					Output:   &resourceConfig,
 				},
-				&EvalValidateProvider{
-					Provider: &provider,
-					Config:   &resourceConfig,
-				},
+				validate,
 			},
 		},
 	})
@@ -96,15 +102,19 @@ func ProviderEvalTree(n *NodeApplyableProvider, config *config.ProviderConfig) E
 
 	// We configure on everything but validate, since validate may
 	// not have access to all the variables.
+	configure := &EvalConfigProvider{
+		Provider: n.Name(),
+		Config:   &resourceConfig,
+		Metas:    n.Metas,
+	}
+	if config != nil {
+		configure.Timeouts = config.Timeouts
+		configure.Parallelism = config.Parallelism
+	}
 	seq = append(seq, &EvalOpFilter{
 		Ops: []walkOperation{walkRefresh, walkPlan, walkApply, walkDestroy, walkImport},
 		Node: &EvalSequence{
-			Nodes: []EvalNode{
-				&EvalConfigProvider{
-					Provider: n.Name(),
-					Config:   &resourceConfig,
-				},
-			},
+			Nodes: []EvalNode{configure},
 		},
 	})
 
@@ -97,7 +97,7 @@ func (g *Graph) walk(walker GraphWalker) error {
 		}()
 
 		walker.EnterVertex(v)
-		defer walker.ExitVertex(v, rerr)
+		defer func() { walker.ExitVertex(v, rerr) }()
 
 		// vertexCtx is the context that we use when evaluating. This
 		// is normally the context of our graph but can be overridden
@@ -110,6 +110,12 @@ func (b *ApplyGraphBuilder) Steps() []GraphTransformer {
 		// Add the outputs
 		&OutputTransformer{Module: b.Module},
 
+		// Add the checks
+		GraphTransformIf(
+			func() bool { return !b.Destroy },
+			&CheckTransformer{Module: b.Module},
+		),
+
 		// Add module variables
 		&ModuleVariableTransformer{Module: b.Module},
 
@@ -34,6 +34,10 @@ type PlanGraphBuilder struct {
 	// Targets are resources to target
 	Targets []string
 
+	// Replace are resource instances to replace even if their
+	// configuration hasn't changed
+	Replace []string
+
 	// DisableReduce, if true, will not reduce the graph. Great for testing.
 	DisableReduce bool
 
@@ -77,6 +81,9 @@ func (b *PlanGraphBuilder) Steps() []GraphTransformer {
 		// Add the outputs
 		&OutputTransformer{Module: b.Module},
 
+		// Add the checks
+		&CheckTransformer{Module: b.Module},
+
 		// Add orphan resources
 		&OrphanResourceTransformer{
 			Concrete: b.ConcreteResourceOrphan,
@@ -125,6 +132,9 @@ func (b *PlanGraphBuilder) Steps() []GraphTransformer {
 		// Add the node to fix the state count boundaries
 		&CountBoundaryTransformer{},
 
+		// Tell resources which instances should be replaced
+		&ReplaceTransformer{Replace: b.Replace},
+
 		// Target
 		&TargetsTransformer{
 			Targets: b.Targets,
@@ -6,8 +6,10 @@ import (
 	"log"
 	"sync"
 
-	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/terraform/config"
 	"github.com/hashicorp/terraform/dag"
+	"github.com/hashicorp/terraform/helper/tracing"
+	"github.com/hashicorp/terraform/tfdiags"
 )
 
 // ContextGraphWalker is the GraphWalker implementation used with the
@@ -32,9 +34,19 @@ type ContextGraphWalker struct {
 	interpolaterVars    map[string]map[string]interface{}
 	interpolaterVarLock sync.Mutex
 	providerCache       map[string]ResourceProvider
+	providerTimeouts    map[string]*config.ProviderTimeouts
+	providerSems        map[string]Semaphore
+	providerCaps        map[string]ProviderCapabilities
 	providerLock        sync.Mutex
 	provisionerCache    map[string]ResourceProvisioner
 	provisionerLock     sync.Mutex
+	providerFunctions   *providerFunctions
+
+	// span is the span of the walk, and spans are those of the vertices
+	// that are being walked, which are its children.
+	span      *tracing.Span
+	spans     map[dag.Vertex]*tracing.Span
+	spansLock sync.Mutex
 }
 
 func (w *ContextGraphWalker) EnterPath(path []string) EvalContext {
@@ -51,10 +63,12 @@ func (w *ContextGraphWalker) EnterPath(path []string) EvalContext {
 
 	// Setup the variables for this interpolater
 	variables := make(map[string]interface{})
+	var variableSources map[string]string
 	if len(path) <= 1 {
 		for k, v := range w.Context.variables {
 			variables[k] = v
 		}
+		variableSources = w.Context.variableSources
 	}
 	w.interpolaterVarLock.Lock()
 	if m, ok := w.interpolaterVars[key]; ok {
@@ -73,6 +87,9 @@ func (w *ContextGraphWalker) EnterPath(path []string) EvalContext {
 		Components:          w.Context.components,
 		ProviderCache:       w.providerCache,
 		ProviderInputConfig: w.Context.providerInputConfig,
+		ProviderTimeoutsMap: w.providerTimeouts,
+		ProviderSemMap:      w.providerSems,
+		ProviderCapsMap:     w.providerCaps,
 		ProviderLock:        &w.providerLock,
 		ProvisionerCache:    w.provisionerCache,
 		ProvisionerLock:     &w.provisionerLock,
@@ -80,14 +97,19 @@ func (w *ContextGraphWalker) EnterPath(path []string) EvalContext {
 		DiffLock:            &w.Context.diffLock,
 		StateValue:          w.Context.state,
 		StateLock:           &w.Context.stateLock,
+		ChecksValue:         w.Context.checks,
 		Interpolater: &Interpolater{
 			Operation:          w.Operation,
 			Meta:               w.Context.meta,
 			Module:             w.Context.module,
+			PlanTimestamp:      w.Context.planTime,
 			State:              w.Context.state,
 			StateLock:          &w.Context.stateLock,
 			VariableValues:     variables,
 			VariableValuesLock: &w.interpolaterVarLock,
+			ProviderFunctions:  w.providerFunctions,
+			VariableSources:    variableSources,
+			Trace:              w.Context.evalTrace,
 		},
 		InterpolaterVars:    w.interpolaterVars,
 		InterpolaterVarLock: &w.interpolaterVarLock,
@@ -97,6 +119,41 @@ func (w *ContextGraphWalker) EnterPath(path []string) EvalContext {
 	return ctx
 }
 
+func (w *ContextGraphWalker) EnterVertex(v dag.Vertex) {
+	if w.span == nil {
+		return
+	}
+
+	attrs := []tracing.Attribute{
+		tracing.String("terraform.node", dag.VertexName(v)),
+	}
+	if rn, ok := v.(GraphNodeResource); ok {
+		if addr := rn.ResourceAddr(); addr != nil {
+			attrs = append(attrs,
+				tracing.String("terraform.resource.address", addr.String()),
+				tracing.String("terraform.resource.type", addr.Type))
+		}
+	}
+
+	span := tracing.Start(w.span, dag.VertexName(v), attrs...)
+	w.spansLock.Lock()
+	if w.spans == nil {
+		w.spans = make(map[dag.Vertex]*tracing.Span)
+	}
+	w.spans[v] = span
+	w.spansLock.Unlock()
+}
+
+func (w *ContextGraphWalker) ExitVertex(v dag.Vertex, err error) {
+	w.spansLock.Lock()
+	span := w.spans[v]
+	delete(w.spans, v)
+	w.spansLock.Unlock()
+
+	span.SetError(err)
+	span.End()
+}
+
 func (w *ContextGraphWalker) EnterEvalTree(v dag.Vertex, n EvalNode) EvalNode {
 	log.Printf("[TRACE] [%s] Entering eval tree: %s",
 		w.Operation, dag.VertexName(v))
@@ -132,15 +189,21 @@ func (w *ContextGraphWalker) ExitEvalTree(
 		return err
 	}
 
+	addr := verr.Address
+	if addr == "" {
+		addr = dag.VertexName(v)
+	}
 	for _, msg := range verr.Warnings {
 		w.ValidationWarnings = append(
 			w.ValidationWarnings,
-			fmt.Sprintf("%s: %s", dag.VertexName(v), msg))
+			fmt.Sprintf("%s: %s", addr, msg))
 	}
 	for _, e := range verr.Errors {
-		w.ValidationErrors = append(
-			w.ValidationErrors,
-			errwrap.Wrapf(fmt.Sprintf("%s: {{err}}", dag.VertexName(v)), e))
+		w.ValidationErrors = append(w.ValidationErrors, &vertexValidationError{
+			Address:       addr,
+			AttributePath: verr.AttributePath,
+			Err:           e,
+		})
 	}
 
 	return nil
@@ -149,6 +212,38 @@ func (w *ContextGraphWalker) ExitEvalTree(
 func (w *ContextGraphWalker) init() {
 	w.contexts = make(map[string]*BuiltinEvalContext, 5)
 	w.providerCache = make(map[string]ResourceProvider, 5)
+	w.providerTimeouts = make(map[string]*config.ProviderTimeouts, 5)
+	w.providerSems = make(map[string]Semaphore, 5)
+	w.providerCaps = make(map[string]ProviderCapabilities, 5)
 	w.provisionerCache = make(map[string]ResourceProvisioner, 5)
 	w.interpolaterVars = make(map[string]map[string]interface{}, 5)
+	w.providerFunctions = newProviderFunctions(w.Context.components)
+}
+
+// vertexValidationError is an error of the validation of a vertex, which
+// keeps the address of the vertex's object for the diagnostic of the error.
+type vertexValidationError struct {
+	Address       string
+	AttributePath string
+	Err           error
+}
+
+func (e *vertexValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Address, e.Err)
+}
+
+// WrappedErrors makes the error of the validation available to errwrap, so
+// that the diagnostics it may hold are found.
+func (e *vertexValidationError) WrappedErrors() []error {
+	return []error{e.Err}
+}
+
+// diagnostics returns the diagnostics of the error.
+func (e *vertexValidationError) diagnostics() tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+	diags = diags.Append(e)
+	return diags.WithCode(tfdiags.CodeObjectInvalid).WithExtra(tfdiags.Extra{
+		Address:       e.Address,
+		AttributePath: e.AttributePath,
+	})
 }
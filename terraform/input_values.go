@@ -0,0 +1,177 @@
+package terraform
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/terraform/helper/variables"
+	"github.com/mitchellh/copystructure"
+)
+
+// ValueSourceType describes where the value of a root module variable was
+// set.
+type ValueSourceType rune
+
+const (
+	// ValueFromUnknown is a value whose source isn't known, such as those
+	// given in ContextOpts.Variables, which may have come from any of the
+	// sources the CLI reads.
+	ValueFromUnknown ValueSourceType = 0
+
+	// ValueFromConfig is the default value of the variable's declaration.
+	ValueFromConfig ValueSourceType = 'C'
+
+	// ValueFromEnvVar is a value from a TF_VAR_ environment variable, which
+	// is the SourceName.
+	ValueFromEnvVar ValueSourceType = 'E'
+
+	// ValueFromAutoFile is a value from a variable definitions file that was
+	// loaded automatically, such as terraform.tfvars, which is the
+	// SourceName.
+	ValueFromAutoFile ValueSourceType = 'F'
+
+	// ValueFromNamedFile is a value from a variable definitions file given
+	// with -var-file, which is the SourceName.
+	ValueFromNamedFile ValueSourceType = 'N'
+
+	// ValueFromCLIArg is a value given with -var.
+	ValueFromCLIArg ValueSourceType = 'A'
+
+	// ValueFromInput is a value that was entered at the prompt.
+	ValueFromInput ValueSourceType = 'I'
+
+	// ValueFromCaller is a value set with Context.SetVariable.
+	ValueFromCaller ValueSourceType = 'S'
+
+	// ValueFromPlan is a value from a saved plan. The SourceName is where
+	// the value came from when the plan was created, if it's known.
+	ValueFromPlan ValueSourceType = 'P'
+)
+
+// These describe where the values of root module variables came from, in
+// messages about the values, such as "The value of var.foo was set by the
+// environment variable TF_VAR_foo".
+const (
+	variableSourceDefault  = "the default value"
+	variableSourceOverride = "set by -var, a variable definitions file or a saved plan"
+	variableSourceInput    = "entered at the prompt"
+	variableSourceSet      = "set by the caller of Terraform"
+)
+
+// InputValue is the value of a root module variable, along with where it
+// was set.
+type InputValue struct {
+	Value interface{}
+
+	SourceType ValueSourceType
+
+	// SourceName is the name of the source, for the types of source that
+	// have one, such as the path of a variable definitions file.
+	SourceName string
+}
+
+// Description returns where the value was set, in a form that completes
+// "The value of var.foo was ...".
+func (v *InputValue) Description() string {
+	switch v.SourceType {
+	case ValueFromConfig:
+		return variableSourceDefault
+	case ValueFromEnvVar:
+		return fmt.Sprintf("set by the environment variable %s", v.SourceName)
+	case ValueFromAutoFile:
+		return fmt.Sprintf("set in the file %s, which is loaded automatically", v.SourceName)
+	case ValueFromNamedFile:
+		return fmt.Sprintf("set in the file %s, given by -var-file", v.SourceName)
+	case ValueFromCLIArg:
+		return "set by -var"
+	case ValueFromInput:
+		return variableSourceInput
+	case ValueFromCaller:
+		return variableSourceSet
+	case ValueFromPlan:
+		if v.SourceName != "" {
+			return fmt.Sprintf("saved in the plan, having been %s", v.SourceName)
+		}
+		return "saved in the plan"
+	default:
+		return variableSourceOverride
+	}
+}
+
+// InputValues are the values of root module variables, keyed by the names
+// of the variables.
+type InputValues map[string]*InputValue
+
+// InputValuesFromMap returns the given values as InputValues that were all
+// set by the same source.
+func InputValuesFromMap(m map[string]interface{}, sourceType ValueSourceType, sourceName string) InputValues {
+	if m == nil {
+		return nil
+	}
+
+	result := make(InputValues, len(m))
+	for k, v := range m {
+		result[k] = &InputValue{
+			Value:      v,
+			SourceType: sourceType,
+			SourceName: sourceName,
+		}
+	}
+	return result
+}
+
+// Override returns the values of vs overridden by those of each of others
+// in turn, so that the last source to set a variable takes precedence. The
+// values of variables that are maps are merged rather than replaced, as
+// they are when -var and -var-file are given more than once. Neither vs nor
+// others are modified.
+func (vs InputValues) Override(others ...InputValues) InputValues {
+	result := make(InputValues, len(vs))
+	for k, v := range vs {
+		result[k] = v
+	}
+
+	for _, other := range others {
+		for k, v := range other {
+			existing, ok := result[k]
+			if !ok {
+				result[k] = v
+				continue
+			}
+
+			// Merge changes the maps it merges into, which may be shared.
+			value, err := copystructure.Copy(existing.Value)
+			if err != nil {
+				value = existing.Value
+			}
+			merged := variables.Merge(
+				map[string]interface{}{k: value},
+				map[string]interface{}{k: v.Value})
+			result[k] = &InputValue{
+				Value:      merged[k],
+				SourceType: v.SourceType,
+				SourceName: v.SourceName,
+			}
+		}
+	}
+
+	return result
+}
+
+// Values returns the values, without their sources.
+func (vs InputValues) Values() map[string]interface{} {
+	result := make(map[string]interface{}, len(vs))
+	for k, v := range vs {
+		result[k] = v.Value
+	}
+	return result
+}
+
+// Sources returns the descriptions of where the values were set, keyed by
+// the names of the variables.
+func (vs InputValues) Sources() map[string]string {
+	result := make(map[string]string, len(vs))
+	for k, v := range vs {
+		result[k] = v.Description()
+	}
+	return result
+}
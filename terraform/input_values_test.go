@@ -0,0 +1,69 @@
+package terraform
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestInputValuesOverride(t *testing.T) {
+	auto := InputValues{
+		"region": &InputValue{Value: "us-east-1", SourceType: ValueFromAutoFile, SourceName: "terraform.tfvars"},
+		"tags": &InputValue{
+			Value:      map[string]interface{}{"Name": "web", "Env": "dev"},
+			SourceType: ValueFromAutoFile,
+			SourceName: "terraform.tfvars",
+		},
+	}
+	named := InputValues{
+		"tags": &InputValue{
+			Value:      map[string]interface{}{"Env": "prod"},
+			SourceType: ValueFromNamedFile,
+			SourceName: "prod.tfvars",
+		},
+	}
+	args := InputValues{
+		"region": &InputValue{Value: "us-west-2", SourceType: ValueFromCLIArg},
+	}
+
+	got := auto.Override(named, args)
+	want := InputValues{
+		"region": &InputValue{Value: "us-west-2", SourceType: ValueFromCLIArg},
+		"tags": &InputValue{
+			Value:      map[string]interface{}{"Name": "web", "Env": "prod"},
+			SourceType: ValueFromNamedFile,
+			SourceName: "prod.tfvars",
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong result\ngot:  %#v\nwant: %#v", got.Values(), want.Values())
+	}
+
+	// The values that were overridden are unchanged.
+	if got, want := auto["tags"].Value, map[string]interface{}{"Name": "web", "Env": "dev"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("overridden value changed to %#v", got)
+	}
+}
+
+func TestInputValueDescription(t *testing.T) {
+	cases := []struct {
+		Value *InputValue
+		Want  string
+	}{
+		{&InputValue{SourceType: ValueFromConfig}, "the default value"},
+		{&InputValue{SourceType: ValueFromEnvVar, SourceName: "TF_VAR_a"}, "set by the environment variable TF_VAR_a"},
+		{&InputValue{SourceType: ValueFromAutoFile, SourceName: "terraform.tfvars"}, "set in the file terraform.tfvars, which is loaded automatically"},
+		{&InputValue{SourceType: ValueFromNamedFile, SourceName: "prod.tfvars"}, "set in the file prod.tfvars, given by -var-file"},
+		{&InputValue{SourceType: ValueFromCLIArg}, "set by -var"},
+		{&InputValue{SourceType: ValueFromInput}, "entered at the prompt"},
+		{&InputValue{SourceType: ValueFromCaller}, "set by the caller of Terraform"},
+		{&InputValue{SourceType: ValueFromPlan, SourceName: "set by -var"}, "saved in the plan, having been set by -var"},
+		{&InputValue{SourceType: ValueFromPlan}, "saved in the plan"},
+		{&InputValue{}, "set by -var, a variable definitions file or a saved plan"},
+	}
+
+	for _, tc := range cases {
+		if got := tc.Value.Description(); got != tc.Want {
+			t.Errorf("wrong description %q; want %q", got, tc.Want)
+		}
+	}
+}
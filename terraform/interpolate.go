@@ -7,6 +7,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/hashicorp/hil"
 	"github.com/hashicorp/hil/ast"
@@ -31,6 +32,22 @@ type Interpolater struct {
 	StateLock          *sync.RWMutex
 	VariableValues     map[string]interface{}
 	VariableValuesLock *sync.Mutex
+
+	// ProviderFunctions is used to call functions exported by providers.
+	// If it is nil, any call to a provider function will fail.
+	ProviderFunctions *providerFunctions
+
+	// PlanTimestamp is the time at which the plan was created, which
+	// plantimestamp returns. If it's zero, plantimestamp is unknown.
+	PlanTimestamp time.Time
+
+	// VariableSources describe where the values of the root module
+	// variables came from, if the interpolater is for the root module.
+	VariableSources map[string]string
+
+	// Trace, if non-nil, records the value of each variable that's
+	// resolved.
+	Trace *EvalTrace
 }
 
 // InterpolationScope is the current scope of execution. This is required
@@ -41,6 +58,198 @@ type InterpolationScope struct {
 	Resource *Resource
 }
 
+// Funcs returns the functions, in addition to the built-in functions, that
+// are required to interpolate the given configuration.
+func (i *Interpolater) Funcs(cfg *config.RawConfig) (map[string]ast.Function, error) {
+	funcs := map[string]ast.Function{
+		"plantimestamp": config.PlanTimestampFunc(i.PlanTimestamp),
+	}
+
+	names := config.ProviderFunctionCalls(cfg.Interpolations)
+	if len(names) == 0 {
+		return funcs, nil
+	}
+
+	if i.ProviderFunctions == nil {
+		return nil, fmt.Errorf("provider functions cannot be called in this context")
+	}
+
+	providerFuncs, err := i.ProviderFunctions.Funcs(names)
+	if err != nil {
+		return nil, err
+	}
+	for name, f := range providerFuncs {
+		funcs[name] = f
+	}
+	return funcs, nil
+}
+
+// Close stops the provider instances that were started to call provider
+// functions. The Interpolater can still be used afterwards, starting
+// them again if necessary.
+func (i *Interpolater) Close() error {
+	if i.ProviderFunctions == nil {
+		return nil
+	}
+	return i.ProviderFunctions.Close()
+}
+
+// sensitiveVariables returns the names of those of the given variables whose
+// values are sensitive.
+//
+// A resource attribute is sensitive if it is recorded as such in the state,
+// and a module output is sensitive if it was declared as sensitive or was
+// itself derived from a sensitive value. A variable is sensitive if it was
+// declared as sensitive or, in a child module, was given a sensitive value
+// by the module block, and a local value is if it's derived from one.
+func (i *Interpolater) sensitiveVariables(
+	scope *InterpolationScope,
+	vars map[string]config.InterpolatedVariable) map[string]bool {
+	if scope == nil {
+		return nil
+	}
+
+	var result map[string]bool
+	for n, v := range vars {
+		if i.isSensitive(scope.Path, v) {
+			if result == nil {
+				result = make(map[string]bool)
+			}
+			result[n] = true
+		}
+	}
+
+	return result
+}
+
+// isSensitive returns whether the value of rawV, referred to from the module
+// at the given path, is sensitive.
+func (i *Interpolater) isSensitive(path []string, rawV config.InterpolatedVariable) bool {
+	switch v := rawV.(type) {
+	case *config.ResourceVariable:
+		if i.State == nil {
+			return false
+		}
+		i.StateLock.RLock()
+		defer i.StateLock.RUnlock()
+
+		mod := i.State.ModuleByPath(path)
+		if mod == nil {
+			return false
+		}
+
+		id := v.ResourceId()
+		for k, rs := range mod.Resources {
+			if k != id && !strings.HasPrefix(k, id+".") {
+				continue
+			}
+			// A specific index only looks at that instance, while
+			// any other reference is conservatively sensitive if
+			// any instance is.
+			if v.Multi && v.Index >= 0 && k != fmt.Sprintf("%s.%d", id, v.Index) {
+				if !(v.Index == 0 && k == id) {
+					continue
+				}
+			}
+			if rs.Primary.IsSensitive(v.Field) {
+				return true
+			}
+		}
+	case *config.ModuleVariable:
+		if i.State == nil {
+			return false
+		}
+		i.StateLock.RLock()
+		defer i.StateLock.RUnlock()
+
+		childPath := make([]string, len(path), len(path)+1)
+		copy(childPath, path)
+		childPath = append(childPath, v.Name)
+
+		mod := i.State.ModuleByPath(childPath)
+		if mod == nil {
+			return false
+		}
+		if os, ok := mod.Outputs[v.Field]; ok && os.Sensitive {
+			return true
+		}
+	case *config.UserVariable:
+		cfg := i.moduleConfig(path)
+		if cfg == nil {
+			return false
+		}
+		for _, cv := range cfg.Variables {
+			if cv.Name == v.Name && cv.Sensitive {
+				return true
+			}
+		}
+		if len(path) <= 1 {
+			return false
+		}
+
+		// The value of a child module variable is sensitive if the
+		// argument that sets it in the module block refers to a
+		// sensitive value.
+		parentPath := path[:len(path)-1]
+		parent := i.moduleConfig(parentPath)
+		if parent == nil {
+			return false
+		}
+		for _, m := range parent.Modules {
+			if m.Name != path[len(path)-1] {
+				continue
+			}
+			raw, ok := m.RawConfig.Raw[v.Name]
+			if !ok {
+				return false
+			}
+			argCfg, err := config.NewRawConfig(map[string]interface{}{v.Name: raw})
+			if err != nil {
+				return false
+			}
+			return i.anySensitive(parentPath, argCfg.Variables)
+		}
+	case *config.LocalVariable:
+		cfg := i.moduleConfig(path)
+		if cfg == nil {
+			return false
+		}
+		for _, l := range cfg.Locals {
+			if l.Name == v.Name {
+				return i.anySensitive(path, l.RawConfig.Variables)
+			}
+		}
+	}
+	return false
+}
+
+// anySensitive returns whether the value of any of the given variables,
+// referred to from the module at the given path, is sensitive.
+func (i *Interpolater) anySensitive(path []string, vars map[string]config.InterpolatedVariable) bool {
+	for _, v := range vars {
+		if i.isSensitive(path, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// moduleConfig returns the configuration of the module at the given path,
+// or nil if there's none.
+func (i *Interpolater) moduleConfig(path []string) *config.Config {
+	if i.Module == nil {
+		return nil
+	}
+	modTree := i.Module
+	if len(path) > 1 {
+		modTree = i.Module.Child(path[1:])
+	}
+	if modTree == nil {
+		return nil
+	}
+	return modTree.Config()
+}
+
 // Values returns the values for all the variables in the given map.
 func (i *Interpolater) Values(
 	scope *InterpolationScope,
@@ -101,6 +310,48 @@ func (i *Interpolater) Values(
 		if err != nil {
 			return nil, err
 		}
+		if val, ok := result[n]; ok {
+			i.traceValue(scope, n, rawV, val)
+		}
+	}
+
+	return result, nil
+}
+
+// ConfigValues returns the values of the variables of a configuration, as
+// Values does, except that the variables that are only referenced within
+// the arguments of try and can are left out when their values can't be
+// determined, so that those arguments fail instead when they're evaluated.
+func (i *Interpolater) ConfigValues(
+	scope *InterpolationScope,
+	cfg *config.RawConfig) (map[string]ast.Variable, error) {
+	required := make(map[string]config.InterpolatedVariable, len(cfg.Variables))
+	for n, v := range cfg.Variables {
+		if !cfg.TryVariables[n] {
+			required[n] = v
+		}
+	}
+
+	result, err := i.Values(scope, required)
+	if err != nil {
+		return nil, err
+	}
+
+	for n := range cfg.TryVariables {
+		v, ok := cfg.Variables[n]
+		if !ok {
+			continue
+		}
+		vs, err := i.Values(scope, map[string]config.InterpolatedVariable{n: v})
+		if err != nil {
+			log.Printf("[DEBUG] %s is only used by try or can, so its error is left to them: %s", n, err)
+			continue
+		}
+		for k, val := range vs {
+			if _, ok := result[k]; !ok {
+				result[k] = val
+			}
+		}
 	}
 
 	return result, nil
@@ -160,6 +160,22 @@ func TestInterpolater_pathModule(t *testing.T) {
 	})
 }
 
+func TestInterpolater_pathModuleInstance(t *testing.T) {
+	mod := testModule(t, "interpolate-path-module")
+	i := &Interpolater{
+		Module: mod,
+	}
+	scope := &InterpolationScope{
+		Path: []string{RootModuleName, "child[1]"},
+	}
+
+	path := mod.Child([]string{"child"}).Config().Dir
+	testInterpolate(t, i, scope, "path.module", ast.Variable{
+		Value: path,
+		Type:  ast.TypeString,
+	})
+}
+
 func TestInterpolater_pathRoot(t *testing.T) {
 	mod := testModule(t, "interpolate-path-module")
 	i := &Interpolater{
@@ -356,6 +372,74 @@ func TestInterpolater_resourceVariableMissingDuringInput(t *testing.T) {
 	}
 }
 
+func TestInterpolater_configValuesTry(t *testing.T) {
+	lock := new(sync.RWMutex)
+	state := &State{
+		Modules: []*ModuleState{
+			&ModuleState{
+				Path: rootModulePath,
+				Resources: map[string]*ResourceState{
+					"aws_instance.web": &ResourceState{
+						Type: "aws_instance",
+						Primary: &InstanceState{
+							ID: "bar",
+							Attributes: map[string]string{
+								"foo": "bar",
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	i := &Interpolater{
+		Operation: walkApply,
+		Module:    testModule(t, "interpolate-resource-variable"),
+		State:     state,
+		StateLock: lock,
+	}
+	scope := &InterpolationScope{
+		Path: rootModulePath,
+	}
+
+	// The missing attribute is only used by try, so it's left out rather
+	// than failing the interpolation.
+	rc, err := config.NewRawConfig(map[string]interface{}{
+		"value": `${try(aws_instance.web.missing, aws_instance.web.foo)}`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	vs, err := i.ConfigValues(scope, rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]ast.Variable{
+		"aws_instance.web.foo": {Type: ast.TypeString, Value: "bar"},
+	}
+	if !reflect.DeepEqual(vs, want) {
+		t.Fatalf("wrong values\ngot:  %#v\nwant: %#v", vs, want)
+	}
+	if err := rc.Interpolate(vs); err != nil {
+		t.Fatal(err)
+	}
+	if got := rc.Config()["value"]; got != "bar" {
+		t.Fatalf("wrong result %#v", got)
+	}
+
+	// Used outside of try, it fails as usual.
+	rc, err = config.NewRawConfig(map[string]interface{}{
+		"value": `${aws_instance.web.missing}`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := i.ConfigValues(scope, rc); err == nil {
+		t.Fatal("expected an error for the missing attribute")
+	}
+}
+
 func TestInterpolater_resourceVariableMulti(t *testing.T) {
 	lock := new(sync.RWMutex)
 	state := &State{
@@ -1097,3 +1181,44 @@ func testInterpolateErr(
 		t.Fatalf("%q: succeeded, but wanted error", n)
 	}
 }
+
+func TestInterpolater_closeProviderFunctions(t *testing.T) {
+	m := testModule(t, "plan-provider-function")
+	p := testProvider("aws")
+	p.FunctionsReturn = []ProviderFunction{
+		{
+			Name:       "upper",
+			ParamTypes: []ast.Type{ast.TypeString},
+			ReturnType: ast.TypeString,
+		},
+	}
+	ctx := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(p),
+			},
+		),
+	})
+
+	i := ctx.Interpolater()
+	cfg, err := config.NewRawConfig(map[string]interface{}{
+		"foo": `${provider::aws::upper("bar")}`,
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if _, err := i.Funcs(cfg); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if p.CloseCalled {
+		t.Fatal("provider should not be closed yet")
+	}
+
+	if err := i.Close(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !p.CloseCalled {
+		t.Fatal("provider should be closed")
+	}
+}
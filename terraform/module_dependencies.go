@@ -19,7 +19,7 @@ import (
 func ModuleTreeDependencies(root *module.Tree, state *State) *moduledeps.Module {
 	// First we walk the configuration tree to build the overall structure
 	// and capture the explicit/implicit/inherited provider dependencies.
-	deps := moduleTreeConfigDependencies(root, nil)
+	deps := moduleTreeConfigDependencies(root, nil, nil)
 
 	// Next we walk over the resources in the state to catch any additional
 	// dependencies created by existing resources that are no longer in config.
@@ -30,7 +30,12 @@ func ModuleTreeDependencies(root *module.Tree, state *State) *moduledeps.Module
 	return deps
 }
 
-func moduleTreeConfigDependencies(root *module.Tree, inheritProviders map[string]*config.ProviderConfig) *moduledeps.Module {
+// moduleTreeConfigDependencies returns the dependencies of the configuration
+// of the given tree. inheritProviders are the provider configurations its
+// resources may inherit, and inheritSources are the source addresses of
+// the providers its ancestors require, by provider type, which apply to
+// the providers the module uses without requiring them itself.
+func moduleTreeConfigDependencies(root *module.Tree, inheritProviders map[string]*config.ProviderConfig, inheritSources map[string]string) *moduledeps.Module {
 	if root == nil {
 		// If no config is provided, we'll make a synthetic root.
 		// This isn't necessarily correct if we're called with a nil that
@@ -46,6 +51,24 @@ func moduleTreeConfigDependencies(root *module.Tree, inheritProviders map[string
 
 	cfg := root.Config()
 	providerConfigs := cfg.ProviderConfigsByFullName()
+	requiredProviders := cfg.RequiredProvidersByName()
+
+	sources := make(map[string]string, len(inheritSources)+len(requiredProviders))
+	for k, v := range inheritSources {
+		sources[k] = v
+	}
+	for name, req := range requiredProviders {
+		addr, err := req.SourceAddr()
+		if err != nil {
+			// Can't happen for a configuration that passed validation.
+			continue
+		}
+		source := addr.Normalized()
+		if source == moduledeps.DefaultProviderSource(name) {
+			source = ""
+		}
+		sources[name] = source
+	}
 
 	// Provider dependencies
 	{
@@ -88,6 +111,44 @@ func moduleTreeConfigDependencies(root *module.Tree, inheritProviders map[string
 			}
 		}
 
+		// Calling a function exported by a provider also creates an
+		// implicit dependency on that provider.
+		for _, name := range cfg.ProviderFunctionProviders() {
+			inst := moduledeps.ProviderInstance(name)
+			if _, exists := providers[inst]; exists {
+				continue
+			}
+
+			providers[inst] = moduledeps.ProviderDependency{
+				Constraints: discovery.AllVersions,
+				Reason:      moduledeps.ProviderDependencyImplicit,
+			}
+		}
+
+		// The required_providers block constrains the versions of each
+		// provider it declares, in addition to any provider blocks, and is
+		// an explicit dependency in itself.
+		for name, req := range requiredProviders {
+			versionSet := discovery.AllVersions
+			if req.Version != "" {
+				versionSet = discovery.ConstraintStr(req.Version).MustParse()
+			}
+
+			inst := moduledeps.ProviderInstance(name)
+			dep, exists := providers[inst]
+			if !exists {
+				dep.Constraints = discovery.AllVersions
+				dep.Reason = moduledeps.ProviderDependencyExplicit
+			}
+			dep.Constraints = dep.Constraints.Append(versionSet)
+			providers[inst] = dep
+		}
+
+		for inst, dep := range providers {
+			dep.Source = sources[inst.Type()]
+			providers[inst] = dep
+		}
+
 		ret.Providers = providers
 	}
 
@@ -99,7 +160,7 @@ func moduleTreeConfigDependencies(root *module.Tree, inheritProviders map[string
 		childInherit[k] = v
 	}
 	for _, c := range root.Children() {
-		ret.Children = append(ret.Children, moduleTreeConfigDependencies(c, childInherit))
+		ret.Children = append(ret.Children, moduleTreeConfigDependencies(c, childInherit, sources))
 	}
 
 	return ret
@@ -239,6 +239,40 @@ func TestModuleTreeDependencies(t *testing.T) {
 				},
 			},
 		},
+		"required providers": {
+			"module-deps-required-providers",
+			nil,
+			&moduledeps.Module{
+				Name: "root",
+				Providers: moduledeps.Providers{
+					"foo": moduledeps.ProviderDependency{
+						Constraints: discovery.ConstraintStr(">=2.1.0,~> 2.0").MustParse(),
+						Reason:      moduledeps.ProviderDependencyExplicit,
+						Source:      "example.com/acme/foo",
+					},
+					"baz": moduledeps.ProviderDependency{
+						Constraints: discovery.ConstraintStr(">=1.0.0").MustParse(),
+						Reason:      moduledeps.ProviderDependencyExplicit,
+					},
+				},
+				Children: []*moduledeps.Module{
+					{
+						Name: "child",
+						Providers: moduledeps.Providers{
+							"foo": moduledeps.ProviderDependency{
+								Constraints: discovery.AllVersions,
+								Reason:      moduledeps.ProviderDependencyInherited,
+								Source:      "example.com/acme/foo",
+							},
+							"bar": moduledeps.ProviderDependency{
+								Constraints: discovery.AllVersions,
+								Reason:      moduledeps.ProviderDependencyImplicit,
+							},
+						},
+					},
+				},
+			},
+		},
 	}
 
 	for name, test := range tests {
@@ -0,0 +1,62 @@
+package terraform
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/terraform/config"
+)
+
+// NodeCheck represents a check block. Nothing can refer to a check, so it
+// depends on what its assertions refer to, but nothing depends on it.
+type NodeCheck struct {
+	PathValue []string
+	Config    *config.Check
+}
+
+func (n *NodeCheck) Name() string {
+	return checkAddr(n.PathValue, n.Config.Name)
+}
+
+// GraphNodeSubPath
+func (n *NodeCheck) Path() []string {
+	return n.PathValue
+}
+
+// RemovableIfNotTargeted
+func (n *NodeCheck) RemoveIfNotTargeted() bool {
+	// Checks are only evaluated when the whole configuration is, since
+	// they'd otherwise report on objects that weren't planned.
+	return true
+}
+
+// GraphNodeReferencer
+func (n *NodeCheck) References() []string {
+	var result []string
+	for _, a := range n.Config.Asserts {
+		result = append(result, ReferencesFromConfig(a.Condition)...)
+	}
+
+	return result
+}
+
+// GraphNodeEvalable
+func (n *NodeCheck) EvalTree() EvalNode {
+	return &EvalOpFilter{
+		Ops: []walkOperation{walkPlan, walkApply},
+		Node: &EvalCheck{
+			Addr:   n.Name(),
+			Config: n.Config,
+		},
+	}
+}
+
+// checkAddr returns the address of the check with the given name in the
+// module with the given path, such as "module.app.check.health".
+func checkAddr(path []string, name string) string {
+	result := fmt.Sprintf("check.%s", name)
+	if prefix := modulePrefixStr(path); prefix != "" {
+		result = fmt.Sprintf("%s.%s", prefix, result)
+	}
+
+	return result
+}
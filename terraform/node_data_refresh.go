@@ -139,7 +139,7 @@ func (n *NodeRefreshableDataResourceInstance) EvalTree() EvalNode {
 	var provider ResourceProvider
 	var state *InstanceState
 
-	return &EvalSequence{
+	return n.evalScopedToCheck(&EvalSequence{
 		Nodes: []EvalNode{
 			// Always destroy the existing state first, since we must
 			// make sure that values from a previous read will not
@@ -193,18 +193,20 @@ func (n *NodeRefreshableDataResourceInstance) EvalTree() EvalNode {
 			},
 
 			&EvalReadDataDiff{
-				Info:        info,
-				Config:      &config,
-				Provider:    &provider,
-				Output:      &diff,
-				OutputState: &state,
+				ProviderName: n.ResolvedProvider,
+				Info:         info,
+				Config:       &config,
+				Provider:     &provider,
+				Output:       &diff,
+				OutputState:  &state,
 			},
 
 			&EvalReadDataApply{
-				Info:     info,
-				Diff:     &diff,
-				Provider: &provider,
-				Output:   &state,
+				ProviderName: n.ResolvedProvider,
+				Info:         info,
+				Diff:         &diff,
+				Provider:     &provider,
+				Output:       &state,
 			},
 
 			&EvalWriteState{
@@ -217,5 +219,5 @@ func (n *NodeRefreshableDataResourceInstance) EvalTree() EvalNode {
 
 			&EvalUpdateStateHook{},
 		},
-	}
+	})
 }
@@ -129,6 +129,15 @@ func (n *NodeApplyableModuleVariable) EvalTree() EvalNode {
 				ModuleTree: n.Module,
 			},
 
+			&EvalOpFilter{
+				Ops: []walkOperation{walkPlan, walkApply, walkValidate},
+				Node: &EvalValidateVariables{
+					Variables:  variables,
+					ModulePath: n.PathValue,
+					ModuleTree: n.Module,
+				},
+			},
+
 			&EvalSetVariables{
 				Module:    &n.PathValue[len(n.PathValue)-1],
 				Variables: variables,
@@ -23,6 +23,7 @@ type NodeAbstractProvider struct {
 	// set if you already have that information.
 
 	Config *config.ProviderConfig
+	Metas  []*ProviderModuleMeta
 }
 
 func ResolveProviderName(name string, path []string) string {
@@ -83,6 +84,11 @@ func (n *NodeAbstractProvider) AttachProvider(c *config.ProviderConfig) {
 	n.Config = c
 }
 
+// GraphNodeAttachProviderMeta
+func (n *NodeAbstractProvider) AttachProviderMeta(metas []*ProviderModuleMeta) {
+	n.Metas = metas
+}
+
 // GraphNodeDotter impl.
 func (n *NodeAbstractProvider) DotNode(name string, opts *dag.DotOpts) *dag.DotNode {
 	return &dag.DotNode{
@@ -33,6 +33,7 @@ type NodeAbstractResource struct {
 	ResourceState *ResourceState   // ResourceState is the ResourceState for this
 
 	Targets []ResourceAddress // Set from GraphNodeTargetable
+	Replace []ResourceAddress // Set from GraphNodeReplaceable
 
 	// The address of the provider this resource will use
 	ResolvedProvider string
@@ -225,6 +226,43 @@ func (n *NodeAbstractResource) SetTargets(targets []ResourceAddress) {
 	n.Targets = targets
 }
 
+// GraphNodeReplaceable
+func (n *NodeAbstractResource) SetReplace(addrs []ResourceAddress) {
+	n.Replace = addrs
+}
+
+// evalScopedToCheck returns the given eval tree of this resource, wrapped
+// so that an error reading it is reported by its check if it's a data
+// source scoped to a check block.
+func (n *NodeAbstractResource) evalScopedToCheck(node EvalNode) EvalNode {
+	if n.Config == nil || n.Config.Check == "" {
+		return node
+	}
+
+	return &EvalCheckScoped{
+		Addr:     checkAddr(n.Addr.Path, n.Config.Check),
+		Resource: n.Addr.String(),
+		Node:     node,
+	}
+}
+
+// replaceRequested returns true if the user requested that this resource
+// instance be replaced.
+func (n *NodeAbstractResource) replaceRequested() bool {
+	addr := n.ResourceAddr()
+	if addr == nil {
+		return false
+	}
+
+	for i := range n.Replace {
+		if replaceAddrMatches(&n.Replace[i], addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
 // GraphNodeAttachResourceState
 func (n *NodeAbstractResource) AttachResourceState(s *ResourceState) {
 	n.ResourceState = s
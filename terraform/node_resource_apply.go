@@ -80,8 +80,8 @@ func (n *NodeApplyableResource) EvalTree() EvalNode {
 			stateId, info, resource, stateDeps,
 		)
 	case config.DataResourceMode:
-		return n.evalTreeDataResource(
-			stateId, info, resource, stateDeps)
+		return n.evalScopedToCheck(n.evalTreeDataResource(
+			stateId, info, resource, stateDeps))
 	default:
 		panic(fmt.Errorf("unsupported resource mode %s", n.Config.Mode))
 	}
@@ -141,18 +141,20 @@ func (n *NodeApplyableResource) evalTreeDataResource(
 
 			// Make a new diff with our newly-interpolated config.
 			&EvalReadDataDiff{
-				Info:     info,
-				Config:   &config,
-				Previous: &diff,
-				Provider: &provider,
-				Output:   &diff,
+				ProviderName: n.ResolvedProvider,
+				Info:         info,
+				Config:       &config,
+				Previous:     &diff,
+				Provider:     &provider,
+				Output:       &diff,
 			},
 
 			&EvalReadDataApply{
-				Info:     info,
-				Diff:     &diff,
-				Provider: &provider,
-				Output:   &state,
+				ProviderName: n.ResolvedProvider,
+				Info:         info,
+				Diff:         &diff,
+				Provider:     &provider,
+				Output:       &state,
 			},
 
 			&EvalWriteState{
@@ -260,13 +262,14 @@ func (n *NodeApplyableResource) evalTreeManagedResource(
 				IgnoreWarnings: true,
 			},
 			&EvalDiff{
-				Info:       info,
-				Config:     &resourceConfig,
-				Resource:   n.Config,
-				Provider:   &provider,
-				Diff:       &diffApply,
-				State:      &state,
-				OutputDiff: &diffApply,
+				ProviderName: n.ResolvedProvider,
+				Info:         info,
+				Config:       &resourceConfig,
+				Resource:     n.Config,
+				Provider:     &provider,
+				Diff:         &diffApply,
+				State:        &state,
+				OutputDiff:   &diffApply,
 			},
 
 			// Get the saved diff
@@ -296,45 +299,58 @@ func (n *NodeApplyableResource) evalTreeManagedResource(
 				State: &state,
 				Diff:  &diffApply,
 			},
-			&EvalApply{
-				Info:      info,
-				State:     &state,
-				Diff:      &diffApply,
-				Provider:  &provider,
-				Output:    &state,
-				Error:     &err,
-				CreateNew: &createNew,
-			},
-			&EvalWriteState{
-				Name:         stateId,
-				ResourceType: n.Config.Type,
-				Provider:     n.ResolvedProvider,
-				Dependencies: stateDeps,
-				State:        &state,
-			},
-			&EvalApplyProvisioners{
-				Info:           info,
-				State:          &state,
-				Resource:       n.Config,
-				InterpResource: resource,
-				CreateNew:      &createNew,
-				Error:          &err,
-				When:           config.ProvisionerWhenCreate,
-			},
-			&EvalIf{
-				If: func(ctx EvalContext) (bool, error) {
-					return createBeforeDestroyEnabled && err != nil, nil
-				},
-				Then: &EvalUndeposeState{
-					Name:  stateId,
-					State: &state,
-				},
-				Else: &EvalWriteState{
-					Name:         stateId,
-					ResourceType: n.Config.Type,
-					Provider:     n.ResolvedProvider,
-					Dependencies: stateDeps,
-					State:        &state,
+			&EvalWithResourceLock{
+				Info:     info,
+				Resource: n.Config,
+				Provider: &provider,
+				State:    &state,
+				Diff:     &diffApply,
+				Error:    &err,
+				Node: &EvalSequence{
+					Nodes: []EvalNode{
+						&EvalApply{
+							ProviderName: n.ResolvedProvider,
+							Info:         info,
+							State:        &state,
+							Diff:         &diffApply,
+							Provider:     &provider,
+							Output:       &state,
+							Error:        &err,
+							CreateNew:    &createNew,
+						},
+						&EvalWriteState{
+							Name:         stateId,
+							ResourceType: n.Config.Type,
+							Provider:     n.ResolvedProvider,
+							Dependencies: stateDeps,
+							State:        &state,
+						},
+						&EvalApplyProvisioners{
+							Info:           info,
+							State:          &state,
+							Resource:       n.Config,
+							InterpResource: resource,
+							CreateNew:      &createNew,
+							Error:          &err,
+							When:           config.ProvisionerWhenCreate,
+						},
+						&EvalIf{
+							If: func(ctx EvalContext) (bool, error) {
+								return createBeforeDestroyEnabled && err != nil, nil
+							},
+							Then: &EvalUndeposeState{
+								Name:  stateId,
+								State: &state,
+							},
+							Else: &EvalWriteState{
+								Name:         stateId,
+								ResourceType: n.Config.Type,
+								Provider:     n.ResolvedProvider,
+								Dependencies: stateDeps,
+								State:        &state,
+							},
+						},
+					},
 				},
 			},
 
@@ -258,18 +258,28 @@ func (n *NodeDestroyResource) EvalTree() EvalNode {
 					},
 
 					Then: &EvalReadDataApply{
-						Info:     info,
-						Diff:     &diffApply,
-						Provider: &provider,
-						Output:   &state,
+						ProviderName: n.ResolvedProvider,
+						Info:         info,
+						Diff:         &diffApply,
+						Provider:     &provider,
+						Output:       &state,
 					},
-					Else: &EvalApply{
+					Else: &EvalWithResourceLock{
 						Info:     info,
+						Resource: n.Config,
+						Provider: &provider,
 						State:    &state,
 						Diff:     &diffApply,
-						Provider: &provider,
-						Output:   &state,
 						Error:    &err,
+						Node: &EvalApply{
+							ProviderName: n.ResolvedProvider,
+							Info:         info,
+							State:        &state,
+							Diff:         &diffApply,
+							Provider:     &provider,
+							Output:       &state,
+							Error:        &err,
+						},
 					},
 				},
 				&EvalWriteState{
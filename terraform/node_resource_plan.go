@@ -28,6 +28,7 @@ func (n *NodePlannableResource) DynamicExpand(ctx EvalContext) (*Graph, error) {
 		// Add the config and state since we don't do that via transforms
 		a.Config = n.Config
 		a.ResolvedProvider = n.ResolvedProvider
+		a.Replace = n.Replace
 
 		return &NodePlannableResourceInstance{
 			NodeAbstractResource: a,
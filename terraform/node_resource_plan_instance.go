@@ -47,8 +47,8 @@ func (n *NodePlannableResourceInstance) EvalTree() EvalNode {
 			stateId, info, resource, stateDeps,
 		)
 	case config.DataResourceMode:
-		return n.evalTreeDataResource(
-			stateId, info, resource, stateDeps)
+		return n.evalScopedToCheck(n.evalTreeDataResource(
+			stateId, info, resource, stateDeps))
 	default:
 		panic(fmt.Errorf("unsupported resource mode %s", n.Config.Mode))
 	}
@@ -102,11 +102,12 @@ func (n *NodePlannableResourceInstance) evalTreeDataResource(
 			},
 
 			&EvalReadDataDiff{
-				Info:        info,
-				Config:      &config,
-				Provider:    &provider,
-				Output:      &diff,
-				OutputState: &state,
+				ProviderName: n.ResolvedProvider,
+				Info:         info,
+				Config:       &config,
+				Provider:     &provider,
+				Output:       &diff,
+				OutputState:  &state,
 			},
 
 			&EvalWriteState{
@@ -161,14 +162,16 @@ func (n *NodePlannableResourceInstance) evalTreeManagedResource(
 				Output: &state,
 			},
 			&EvalDiff{
-				Name:        stateId,
-				Info:        info,
-				Config:      &resourceConfig,
-				Resource:    n.Config,
-				Provider:    &provider,
-				State:       &state,
-				OutputDiff:  &diff,
-				OutputState: &state,
+				ProviderName: n.ResolvedProvider,
+				Name:         stateId,
+				Info:         info,
+				Config:       &resourceConfig,
+				Resource:     n.Config,
+				Provider:     &provider,
+				State:        &state,
+				OutputDiff:   &diff,
+				OutputState:  &state,
+				Replace:      n.replaceRequested(),
 			},
 			&EvalCheckPreventDestroy{
 				Resource: n.Config,
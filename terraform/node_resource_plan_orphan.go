@@ -28,17 +28,24 @@ func (n *NodePlannableResourceOrphan) EvalTree() EvalNode {
 	// evaluation. Most of this are written to by-address below.
 	var diff *InstanceDiff
 	var state *InstanceState
+	var provider ResourceProvider
 
 	return &EvalSequence{
 		Nodes: []EvalNode{
+			&EvalGetProvider{
+				Name:   n.ResolvedProvider,
+				Output: &provider,
+			},
 			&EvalReadState{
 				Name:   stateId,
 				Output: &state,
 			},
 			&EvalDiffDestroy{
-				Info:   info,
-				State:  &state,
-				Output: &diff,
+				Info:         info,
+				State:        &state,
+				Output:       &diff,
+				Provider:     &provider,
+				ProviderName: n.ResolvedProvider,
 			},
 			&EvalCheckPreventDestroy{
 				Resource:   n.Config,
@@ -158,10 +158,11 @@ func (n *NodeRefreshableManagedResourceInstance) evalTreeManagedResource() EvalN
 				Output: &state,
 			},
 			&EvalRefresh{
-				Info:     info,
-				Provider: &provider,
-				State:    &state,
-				Output:   &state,
+				ProviderName: n.ResolvedProvider,
+				Info:         info,
+				Provider:     &provider,
+				State:        &state,
+				Output:       &state,
 			},
 			&EvalWriteState{
 				Name:         stateId,
@@ -239,14 +240,15 @@ func (n *NodeRefreshableManagedResourceInstance) evalTreeManagedResourceNoState(
 				Output: &state,
 			},
 			&EvalDiff{
-				Name:        stateID,
-				Info:        info,
-				Config:      &resourceConfig,
-				Resource:    n.Config,
-				Provider:    &provider,
-				State:       &state,
-				OutputState: &state,
-				Stub:        true,
+				ProviderName: n.ResolvedProvider,
+				Name:         stateID,
+				Info:         info,
+				Config:       &resourceConfig,
+				Resource:     n.Config,
+				Provider:     &provider,
+				State:        &state,
+				OutputState:  &state,
+				Stub:         true,
 			},
 			&EvalWriteState{
 				Name:         stateID,
@@ -8,6 +8,7 @@ import (
 	"io"
 	"log"
 	"sync"
+	"time"
 
 	"github.com/hashicorp/terraform/config/module"
 	"github.com/hashicorp/terraform/version"
@@ -46,6 +47,11 @@ type Plan struct {
 	// that the same variables can be applied during apply.
 	Vars map[string]interface{}
 
+	// VarSources describe where the values of Vars were set when the plan
+	// was created, keyed by the names of the variables, such as "set by
+	// -var".
+	VarSources map[string]string
+
 	// Targets, if non-empty, contains a set of resource address strings that
 	// identify graph nodes that were selected as targets for plan.
 	//
@@ -53,6 +59,14 @@ type Plan struct {
 	// indirectly targeted via dependencies is excluded from the graph.
 	Targets []string
 
+	// Checks are the results of the check blocks of the configuration, as
+	// they were evaluated against the planned state.
+	Checks []*CheckResult
+
+	// Replace, if non-empty, contains the addresses of resource instances
+	// that were replaced by request in the plan, as given to ContextOpts.
+	Replace []string
+
 	// TerraformVersion is the version of Terraform that was used to create
 	// this plan.
 	//
@@ -75,6 +89,24 @@ type Plan struct {
 	// Destroy indicates that this plan was created for a full destroy operation
 	Destroy bool
 
+	// Drift, if non-nil, describes the changes to managed resources that
+	// were detected by refreshing the state before this plan was created,
+	// as returned by StateDrift. It is for display only and is not used
+	// when applying the plan.
+	Drift *Diff
+
+	// SkipRefresh indicates that the state was not refreshed before this
+	// plan was created, so it may not reflect the real infrastructure.
+	SkipRefresh bool
+
+	// RefreshOnly indicates that this plan was created to only refresh the
+	// state. Its diff is empty, and applying it persists its state.
+	RefreshOnly bool
+
+	// Timestamp is the time at which this plan was created, which
+	// plantimestamp returns during both plan and apply.
+	Timestamp time.Time
+
 	once sync.Once
 }
 
@@ -105,6 +137,9 @@ func (p *Plan) contextOpts(base *ContextOpts) (*ContextOpts, error) {
 	opts.Targets = p.Targets
 	opts.ProviderSHA256s = p.ProviderSHA256s
 	opts.Destroy = p.Destroy
+	opts.Replace = p.Replace
+	opts.RefreshOnly = p.RefreshOnly
+	opts.PlanTimestamp = p.Timestamp
 
 	if opts.State == nil {
 		opts.State = p.State
@@ -128,9 +163,15 @@ func (p *Plan) contextOpts(base *ContextOpts) (*ContextOpts, error) {
 		)
 	}
 
-	opts.Variables = make(map[string]interface{})
+	// The values saved in the plan take the place of any that are given.
+	opts.Variables = nil
+	opts.InputValues = make(InputValues)
 	for k, v := range p.Vars {
-		opts.Variables[k] = v
+		opts.InputValues[k] = &InputValue{
+			Value:      v,
+			SourceType: ValueFromPlan,
+			SourceName: p.VarSources[k],
+		}
 	}
 
 	return opts, nil
@@ -145,6 +186,64 @@ func (p *Plan) String() string {
 	return buf.String()
 }
 
+// PlannedState returns the state that applying the plan is expected to
+// produce. The attributes that are only known after apply are set to
+// config.UnknownVariableValue, and the resources that are replaced only
+// keep the attributes set by the diff.
+func (p *Plan) PlannedState() *State {
+	state := p.State.DeepCopy()
+	if state == nil {
+		state = NewState()
+	}
+	if p.Diff == nil {
+		return state
+	}
+
+	for _, md := range p.Diff.Modules {
+		ms := state.ModuleByPath(md.Path)
+		if ms == nil {
+			ms = state.AddModule(md.Path)
+		}
+		if ms.Resources == nil {
+			ms.Resources = make(map[string]*ResourceState)
+		}
+
+		for k, d := range md.Resources {
+			switch d.ChangeType() {
+			case DiffNone:
+				continue
+			case DiffDestroy:
+				if !d.GetDestroyDeposed() {
+					delete(ms.Resources, k)
+				}
+				continue
+			}
+
+			rs := ms.Resources[k]
+			if rs == nil {
+				key, err := ParseResourceStateKey(k)
+				if err != nil {
+					// should never happen; indicates an invalid diff
+					continue
+				}
+				rs = &ResourceState{Type: key.Type}
+				ms.Resources[k] = rs
+			}
+
+			prior := rs.Primary
+			if d.RequiresNew() {
+				prior = nil
+			}
+			rs.Primary = prior.MergeDiff(d)
+			if id, ok := rs.Primary.Attributes["id"]; ok {
+				rs.Primary.ID = id
+			}
+		}
+	}
+
+	return state
+}
+
 func (p *Plan) init() {
 	p.once.Do(func() {
 		if p.Diff == nil {
@@ -0,0 +1,151 @@
+package terraform
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PlanModification records an attribute whose value in the diff planned by
+// a provider differs from the value core proposed for it, which is the
+// value set in the configuration. This happens when a provider normalizes
+// a value, ignores a change to it or decides it can't be known until apply.
+type PlanModification struct {
+	// Path is the flatmap key of the attribute, such as "tags.Name".
+	Path string
+
+	// Proposed is the value set for the attribute in the configuration.
+	Proposed string
+
+	// Planned is the value planned by the provider. It is meaningful only
+	// if neither PlannedComputed nor PlannedRemoved is set.
+	Planned         string
+	PlannedComputed bool
+	PlannedRemoved  bool
+
+	// Sensitive is set if the attribute is sensitive, in which case its
+	// values must not be displayed.
+	Sensitive bool
+}
+
+// String returns a one-line description of the modification, with
+// sensitive values redacted.
+func (m *PlanModification) String() string {
+	proposed := strconv.Quote(m.Proposed)
+	planned := strconv.Quote(m.Planned)
+	if m.Sensitive {
+		proposed, planned = "<sensitive>", "<sensitive>"
+	}
+	switch {
+	case m.PlannedComputed:
+		planned = "<computed>"
+	case m.PlannedRemoved:
+		planned = "<removed>"
+	}
+
+	return fmt.Sprintf("%s: %s => %s", m.Path, proposed, planned)
+}
+
+// planModifications compares the given diff planned by a provider with the
+// given configuration, returning the attributes whose planned values differ
+// from their configured values, sorted by path.
+//
+// Core has no schema to compare against, so only attributes that are set
+// to known values in the configuration are considered. An attribute that
+// the diff doesn't mention is planned to keep its prior value, if it has
+// one; otherwise it is assumed to be in a set, whose element keys in the
+// diff can't be matched to the configuration.
+func planModifications(cfg *ResourceConfig, prior *InstanceState, diff *InstanceDiff) []*PlanModification {
+	if cfg == nil || diff == nil {
+		return nil
+	}
+
+	proposed := make(map[string]string)
+	for k, v := range cfg.Config {
+		flattenPlanProposed(proposed, k, reflect.ValueOf(v))
+	}
+
+	var result []*PlanModification
+	for k, v := range proposed {
+		if v == unknownValue() || planKeyComputed(cfg, k) {
+			continue
+		}
+
+		m := &PlanModification{
+			Path:      k,
+			Proposed:  v,
+			Sensitive: cfg.IsSensitive(k) || prior.IsSensitive(k),
+		}
+		if attr, ok := diff.Attributes[k]; ok && attr != nil {
+			m.Planned = attr.New
+			m.PlannedComputed = attr.NewComputed
+			m.PlannedRemoved = attr.NewRemoved
+			m.Sensitive = m.Sensitive || attr.Sensitive
+		} else if prior != nil {
+			old, ok := prior.Attributes[k]
+			if !ok {
+				continue
+			}
+			m.Planned = old
+		} else {
+			continue
+		}
+
+		if m.PlannedComputed || m.PlannedRemoved || m.Planned != m.Proposed {
+			result = append(result, m)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Path < result[j].Path
+	})
+	return result
+}
+
+// flattenPlanProposed flattens the given configuration value into result
+// using flatmap keys. Unlike flatmap.Flatten it doesn't produce count keys,
+// whose form depends on the attribute's schema, and it ignores values of
+// unexpected types rather than panicking.
+func flattenPlanProposed(result map[string]string, prefix string, v reflect.Value) {
+	if v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		result[prefix] = strconv.FormatBool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		result[prefix] = strconv.FormatInt(v.Int(), 10)
+	case reflect.Float32, reflect.Float64:
+		result[prefix] = strconv.FormatFloat(v.Float(), 'f', -1, 64)
+	case reflect.String:
+		result[prefix] = v.String()
+	case reflect.Map:
+		for _, k := range v.MapKeys() {
+			if k.Kind() == reflect.Interface {
+				k = k.Elem()
+			}
+			if k.Kind() != reflect.String {
+				continue
+			}
+			flattenPlanProposed(result, prefix+"."+k.String(), v.MapIndex(k))
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			flattenPlanProposed(result, fmt.Sprintf("%s.%d", prefix, i), v.Index(i))
+		}
+	}
+}
+
+// planKeyComputed returns true if the given flatmap key is within a value
+// that is computed in the given configuration.
+func planKeyComputed(cfg *ResourceConfig, k string) bool {
+	for _, ck := range cfg.ComputedKeys {
+		if k == ck || strings.HasPrefix(k, ck+".") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,105 @@
+package terraform
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/davecgh/go-spew/spew"
+)
+
+func TestPlanModifications(t *testing.T) {
+	cfg := &ResourceConfig{
+		Config: map[string]interface{}{
+			"ami":      "baz",
+			"count":    2,
+			"computed": unknownValue(),
+			"kept":     "yes",
+			"password": "secret",
+			"tags": map[string]interface{}{
+				"Name": "foo",
+			},
+			"ports": []interface{}{80, 443},
+			"block": []interface{}{
+				map[string]interface{}{"id": "late"},
+			},
+		},
+		ComputedKeys:  []string{"block.0"},
+		SensitiveKeys: []string{"password"},
+	}
+	prior := &InstanceState{
+		ID: "a",
+		Attributes: map[string]string{
+			"id":      "a",
+			"count":   "2",
+			"kept":    "no",
+			"ports.0": "80",
+			"ports.1": "443",
+		},
+	}
+	diff := &InstanceDiff{
+		Attributes: map[string]*ResourceAttrDiff{
+			"ami": &ResourceAttrDiff{
+				Old: "bar",
+				New: "BAZ",
+			},
+			"password": &ResourceAttrDiff{
+				NewComputed: true,
+			},
+			"tags.Name": &ResourceAttrDiff{
+				New: "foo",
+			},
+		},
+	}
+
+	got := planModifications(cfg, prior, diff)
+	want := []*PlanModification{
+		{
+			Path:     "ami",
+			Proposed: "baz",
+			Planned:  "BAZ",
+		},
+		{
+			Path:     "kept",
+			Proposed: "yes",
+			Planned:  "no",
+		},
+		{
+			Path:            "password",
+			Proposed:        "secret",
+			PlannedComputed: true,
+			Sensitive:       true,
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong result\ngot:  %s\nwant: %s", spew.Sdump(got), spew.Sdump(want))
+	}
+
+	if got, want := got[2].String(), "password: <sensitive> => <computed>"; got != want {
+		t.Fatalf("wrong string %q; want %q", got, want)
+	}
+}
+
+func TestPlanModifications_create(t *testing.T) {
+	cfg := &ResourceConfig{
+		Config: map[string]interface{}{
+			"ami":  "baz",
+			"tags": []interface{}{"a"},
+		},
+	}
+	diff := &InstanceDiff{
+		Attributes: map[string]*ResourceAttrDiff{
+			"ami": &ResourceAttrDiff{
+				New: "baz",
+			},
+			"tags.1234": &ResourceAttrDiff{
+				New: "a",
+			},
+		},
+	}
+
+	// Without a prior state, attributes the diff doesn't mention can't be
+	// compared, such as the elements of sets.
+	if got := planModifications(cfg, nil, diff); len(got) != 0 {
+		t.Fatalf("unexpected modifications: %s", spew.Sdump(got))
+	}
+}
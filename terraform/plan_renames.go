@@ -0,0 +1,177 @@
+package terraform
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/terraform/config"
+)
+
+// RenameThreshold is the least similarity, as returned by
+// renameSimilarity, that a resource to be created must have to a resource
+// to be destroyed for the pair to be reported as a likely rename.
+const RenameThreshold = 0.8
+
+// RenameSuggestion is a resource instance that a plan destroys and another
+// that it creates in its place with a largely identical configuration,
+// which is most likely the same remote object under a new address.
+type RenameSuggestion struct {
+	From *ResourceAddress
+	To   *ResourceAddress
+
+	// Similarity is the fraction of the configured attributes of To whose
+	// values are the same as those of From, between RenameThreshold and 1.
+	Similarity float64
+}
+
+// MovedBlock returns the "moved" block that records the rename in the
+// configuration of the module that has the resources, or "" if the
+// instances have different indexes, since moved blocks move whole
+// resources and keep the index of each instance.
+func (s *RenameSuggestion) MovedBlock() string {
+	if s.From.Index != s.To.Index {
+		return ""
+	}
+
+	return fmt.Sprintf(
+		"moved {\n  from = %q\n  to   = %q\n}",
+		s.From.Type+"."+s.From.Name,
+		s.To.Type+"."+s.To.Name,
+	)
+}
+
+// StateMvCommand returns the "terraform state mv" command that moves the
+// existing object to its new address in the state.
+func (s *RenameSuggestion) StateMvCommand() string {
+	return fmt.Sprintf("terraform state mv '%s' '%s'", s.From, s.To)
+}
+
+// RenameSuggestions returns the likely renames in the plan: the pairs of a
+// managed resource instance that it destroys and another of the same type
+// in the same module that it creates, whose configured attributes are
+// mostly the same as those of the destroyed one. Each instance is in at
+// most one pair, that with the greatest similarity, and the pairs are
+// sorted by the address they're from.
+func (p *Plan) RenameSuggestions() []*RenameSuggestion {
+	if p.Destroy || p.Diff == nil || p.State == nil {
+		return nil
+	}
+
+	var result []*RenameSuggestion
+	for _, md := range p.Diff.Modules {
+		ms := p.State.ModuleByPath(md.Path)
+		if ms == nil {
+			continue
+		}
+		result = append(result, moduleRenameSuggestions(md, ms)...)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].From.Less(result[j].From)
+	})
+	return result
+}
+
+// moduleRenameSuggestions returns the likely renames within one module.
+func moduleRenameSuggestions(md *ModuleDiff, ms *ModuleState) []*RenameSuggestion {
+	type instance struct {
+		addr  *ResourceAddress
+		attrs map[string]string
+	}
+
+	var destroyed, created []*instance
+	for k, d := range md.Resources {
+		addr, err := ParseResourceAddressForInstanceDiff(md.Path[1:], k)
+		if err != nil || addr.Mode != config.ManagedResourceMode {
+			continue
+		}
+
+		switch d.ChangeType() {
+		case DiffDestroy:
+			if d.DestroyDeposed {
+				continue
+			}
+			rs, ok := ms.Resources[k]
+			if !ok || rs.Primary == nil {
+				continue
+			}
+			destroyed = append(destroyed, &instance{addr, rs.Primary.Attributes})
+		case DiffCreate:
+			attrs := make(map[string]string)
+			for name, attr := range d.CopyAttributes() {
+				// Only the values that are known when planning are of the
+				// configuration; the rest are up to the provider.
+				if attr.NewComputed || attr.NewRemoved || name == "id" {
+					continue
+				}
+				attrs[name] = attr.New
+			}
+			created = append(created, &instance{addr, attrs})
+		}
+	}
+	if len(destroyed) == 0 || len(created) == 0 {
+		return nil
+	}
+
+	var candidates []*RenameSuggestion
+	for _, from := range destroyed {
+		for _, to := range created {
+			if from.addr.Type != to.addr.Type {
+				continue
+			}
+			similarity := renameSimilarity(from.attrs, to.attrs)
+			if similarity < RenameThreshold {
+				continue
+			}
+			candidates = append(candidates, &RenameSuggestion{
+				From:       from.addr,
+				To:         to.addr,
+				Similarity: similarity,
+			})
+		}
+	}
+
+	// The most similar pairs are taken first, so that an instance that's
+	// similar to several is paired with the one it's most like. Ties are
+	// broken by address so that the result doesn't depend on map order.
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.Similarity != b.Similarity {
+			return a.Similarity > b.Similarity
+		}
+		if !a.From.Equals(b.From) {
+			return a.From.Less(b.From)
+		}
+		return a.To.Less(b.To)
+	})
+
+	var result []*RenameSuggestion
+	used := make(map[string]bool)
+	for _, c := range candidates {
+		from, to := "from:"+c.From.String(), "to:"+c.To.String()
+		if used[from] || used[to] {
+			continue
+		}
+		used[from], used[to] = true, true
+		result = append(result, c)
+	}
+	return result
+}
+
+// renameSimilarity returns the fraction of the attributes that are
+// planned for a new resource whose values are the same in the state of
+// an old one. Resources with no such attributes aren't similar at all,
+// since there's nothing to tell them apart by.
+func renameSimilarity(old, new map[string]string) float64 {
+	if len(new) == 0 {
+		return 0
+	}
+
+	same := 0
+	for k, v := range new {
+		if ov, ok := old[k]; ok && ov == v {
+			same++
+		}
+	}
+	return float64(same) / float64(len(new))
+}
@@ -0,0 +1,137 @@
+package terraform
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPlanRenameSuggestions(t *testing.T) {
+	createDiff := func(attrs map[string]string) *InstanceDiff {
+		d := &InstanceDiff{
+			Attributes: map[string]*ResourceAttrDiff{
+				"id":  &ResourceAttrDiff{NewComputed: true, RequiresNew: true},
+				"arn": &ResourceAttrDiff{NewComputed: true},
+			},
+		}
+		for k, v := range attrs {
+			d.Attributes[k] = &ResourceAttrDiff{New: v}
+		}
+		return d
+	}
+	instance := func(attrs map[string]string) *ResourceState {
+		return &ResourceState{
+			Type:    "aws_instance",
+			Primary: &InstanceState{ID: "i-" + attrs["ami"], Attributes: attrs},
+		}
+	}
+	web := map[string]string{"ami": "web", "instance_type": "t2.micro", "tags.%": "1", "tags.Name": "web"}
+	db := map[string]string{"ami": "db", "instance_type": "t2.large", "tags.%": "1", "tags.Name": "db"}
+
+	plan := &Plan{
+		State: &State{
+			Modules: []*ModuleState{
+				&ModuleState{
+					Path: rootModulePath,
+					Resources: map[string]*ResourceState{
+						"aws_instance.web":   instance(web),
+						"aws_instance.db":    instance(db),
+						"aws_instance.other": instance(map[string]string{"ami": "other"}),
+						"aws_eip.ip": &ResourceState{
+							Type:    "aws_eip",
+							Primary: &InstanceState{ID: "ip", Attributes: map[string]string{"vpc": "true"}},
+						},
+					},
+				},
+				&ModuleState{
+					Path: []string{"root", "child"},
+					Resources: map[string]*ResourceState{
+						"aws_instance.foo.0": instance(web),
+					},
+				},
+			},
+		},
+		Diff: &Diff{
+			Modules: []*ModuleDiff{
+				&ModuleDiff{
+					Path: rootModulePath,
+					Resources: map[string]*InstanceDiff{
+						"aws_instance.web":   &InstanceDiff{Destroy: true},
+						"aws_instance.db":    &InstanceDiff{Destroy: true},
+						"aws_instance.other": &InstanceDiff{Destroy: true},
+						"aws_eip.ip":         &InstanceDiff{Destroy: true},
+
+						// The database was renamed without any other change,
+						// and the web server was renamed and resized.
+						"aws_instance.database": createDiff(db),
+						"aws_instance.frontend": createDiff(map[string]string{
+							"ami": "web", "instance_type": "t2.small", "tags.%": "1", "tags.Name": "web",
+						}),
+
+						// Nothing of this one is the same as of "other".
+						"aws_instance.new": createDiff(map[string]string{"ami": "new"}),
+
+						// The same as the elastic IP, but of another type.
+						"aws_eip_association.ip": createDiff(map[string]string{"vpc": "true"}),
+					},
+				},
+				&ModuleDiff{
+					Path: []string{"root", "child"},
+					Resources: map[string]*InstanceDiff{
+						"aws_instance.foo.0": &InstanceDiff{Destroy: true},
+						"aws_instance.bar.0": createDiff(web),
+					},
+				},
+			},
+		},
+	}
+
+	var actual []string
+	for _, s := range plan.RenameSuggestions() {
+		actual = append(actual, s.From.String()+" -> "+s.To.String())
+	}
+	expected := []string{
+		"aws_instance.db -> aws_instance.database",
+		"module.child.aws_instance.foo[0] -> module.child.aws_instance.bar[0]",
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("wrong suggestions\ngot:  %#v\nwant: %#v", actual, expected)
+	}
+
+	// The web server is still a likely rename with a lower threshold.
+	if sim := renameSimilarity(web, map[string]string{
+		"ami": "web", "instance_type": "t2.small", "tags.%": "1", "tags.Name": "web",
+	}); sim != 0.75 {
+		t.Fatalf("wrong similarity %v", sim)
+	}
+
+	plan.Destroy = true
+	if got := plan.RenameSuggestions(); got != nil {
+		t.Fatalf("destroy plan should have no suggestions: %#v", got)
+	}
+}
+
+func TestRenameSuggestion_commands(t *testing.T) {
+	from, _ := ParseResourceAddress("module.child.aws_instance.foo")
+	to, _ := ParseResourceAddress("module.child.aws_instance.bar")
+	s := &RenameSuggestion{From: from, To: to}
+
+	block := "moved {\n  from = \"aws_instance.foo\"\n  to   = \"aws_instance.bar\"\n}"
+	if got := s.MovedBlock(); got != block {
+		t.Fatalf("wrong moved block\ngot:\n%s\nwant:\n%s", got, block)
+	}
+	expected := "terraform state mv 'module.child.aws_instance.foo' 'module.child.aws_instance.bar'"
+	if got := s.StateMvCommand(); got != expected {
+		t.Fatalf("wrong command\ngot:  %s\nwant: %s", got, expected)
+	}
+
+	// Counted resources are moved whole, keeping their indexes.
+	s.From, _ = ParseResourceAddress("module.child.aws_instance.foo[1]")
+	s.To, _ = ParseResourceAddress("module.child.aws_instance.bar[1]")
+	if got := s.MovedBlock(); got != block {
+		t.Fatalf("wrong moved block for counted resources\ngot:\n%s\nwant:\n%s", got, block)
+	}
+	s.To, _ = ParseResourceAddress("module.child.aws_instance.bar[0]")
+	if got := s.MovedBlock(); got != "" {
+		t.Fatalf("instances with other indexes should have no moved block, got:\n%s", got)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/hashicorp/terraform/config"
 	"github.com/hashicorp/terraform/config/module"
 )
 
@@ -22,13 +23,16 @@ func TestPlanContextOpts(t *testing.T) {
 		State: &State{
 			TFVersion: "sigil",
 		},
-		Vars:    map[string]interface{}{"foo": "bar"},
-		Targets: []string{"baz"},
+		Vars:       map[string]interface{}{"foo": "bar"},
+		VarSources: map[string]string{"foo": "set by -var"},
+		Targets:    []string{"baz"},
+		Replace:    []string{"aws_instance.foo"},
 
 		TerraformVersion: VersionString(),
 		ProviderSHA256s: map[string][]byte{
 			"test": []byte("placeholder"),
 		},
+		RefreshOnly: true,
 	}
 
 	got, err := plan.contextOpts(&ContextOpts{})
@@ -37,12 +41,20 @@ func TestPlanContextOpts(t *testing.T) {
 	}
 
 	want := &ContextOpts{
-		Diff:            plan.Diff,
-		Module:          plan.Module,
-		State:           plan.State,
-		Variables:       plan.Vars,
-		Targets:         plan.Targets,
+		Diff:    plan.Diff,
+		Module:  plan.Module,
+		State:   plan.State,
+		Targets: plan.Targets,
+		Replace: plan.Replace,
+		InputValues: InputValues{
+			"foo": &InputValue{
+				Value:      "bar",
+				SourceType: ValueFromPlan,
+				SourceName: "set by -var",
+			},
+		},
 		ProviderSHA256s: plan.ProviderSHA256s,
+		RefreshOnly:     true,
 	}
 
 	if !reflect.DeepEqual(got, want) {
@@ -155,11 +167,13 @@ func TestPlanContextOptsOverrideStateGood(t *testing.T) {
 	}
 
 	want := &ContextOpts{
-		Diff:            plan.Diff,
-		Module:          plan.Module,
-		State:           base.State,
-		Variables:       plan.Vars,
-		Targets:         plan.Targets,
+		Diff:    plan.Diff,
+		Module:  plan.Module,
+		State:   base.State,
+		Targets: plan.Targets,
+		InputValues: InputValues{
+			"foo": &InputValue{Value: "bar", SourceType: ValueFromPlan},
+		},
 		ProviderSHA256s: plan.ProviderSHA256s,
 	}
 
@@ -167,3 +181,116 @@ func TestPlanContextOptsOverrideStateGood(t *testing.T) {
 		t.Errorf("wrong result\ngot:  %#v\nwant %#v", got, want)
 	}
 }
+
+func TestPlanPlannedState(t *testing.T) {
+	plan := &Plan{
+		State: &State{
+			Modules: []*ModuleState{
+				&ModuleState{
+					Path: rootModulePath,
+					Resources: map[string]*ResourceState{
+						"test_instance.update": &ResourceState{
+							Type: "test_instance",
+							Primary: &InstanceState{
+								ID: "a",
+								Attributes: map[string]string{
+									"id":  "a",
+									"foo": "old",
+									"bar": "kept",
+								},
+							},
+						},
+						"test_instance.replace": &ResourceState{
+							Type: "test_instance",
+							Primary: &InstanceState{
+								ID: "b",
+								Attributes: map[string]string{
+									"id":  "b",
+									"foo": "old",
+									"bar": "dropped",
+								},
+							},
+						},
+						"test_instance.destroy": &ResourceState{
+							Type: "test_instance",
+							Primary: &InstanceState{
+								ID:         "c",
+								Attributes: map[string]string{"id": "c"},
+							},
+						},
+					},
+				},
+			},
+		},
+		Diff: &Diff{
+			Modules: []*ModuleDiff{
+				&ModuleDiff{
+					Path: rootModulePath,
+					Resources: map[string]*InstanceDiff{
+						"test_instance.update": &InstanceDiff{
+							Attributes: map[string]*ResourceAttrDiff{
+								"foo": &ResourceAttrDiff{Old: "old", New: "new"},
+							},
+						},
+						"test_instance.replace": &InstanceDiff{
+							Attributes: map[string]*ResourceAttrDiff{
+								"id":  &ResourceAttrDiff{Old: "b", NewComputed: true, RequiresNew: true},
+								"foo": &ResourceAttrDiff{Old: "old", New: "new", RequiresNew: true},
+							},
+						},
+						"test_instance.destroy": &InstanceDiff{
+							Destroy: true,
+						},
+						"test_instance.create": &InstanceDiff{
+							Attributes: map[string]*ResourceAttrDiff{
+								"id":  &ResourceAttrDiff{NewComputed: true},
+								"foo": &ResourceAttrDiff{New: "created"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	state := plan.PlannedState()
+	root := state.RootModule()
+
+	if _, ok := root.Resources["test_instance.destroy"]; ok {
+		t.Errorf("destroyed resource is still in the planned state")
+	}
+
+	want := map[string]map[string]string{
+		"test_instance.update": {
+			"id":  "a",
+			"foo": "new",
+			"bar": "kept",
+		},
+		"test_instance.replace": {
+			"id":  config.UnknownVariableValue,
+			"foo": "new",
+		},
+		"test_instance.create": {
+			"id":  config.UnknownVariableValue,
+			"foo": "created",
+		},
+	}
+	for k, attrs := range want {
+		rs, ok := root.Resources[k]
+		if !ok {
+			t.Errorf("%s is missing from the planned state", k)
+			continue
+		}
+		if !reflect.DeepEqual(rs.Primary.Attributes, attrs) {
+			t.Errorf("wrong attributes for %s\ngot:  %#v\nwant: %#v", k, rs.Primary.Attributes, attrs)
+		}
+		if rs.Primary.ID != attrs["id"] {
+			t.Errorf("wrong id for %s: %q", k, rs.Primary.ID)
+		}
+	}
+
+	// The state of the plan itself must be left unchanged.
+	if got := plan.State.RootModule().Resources["test_instance.update"].Primary.Attributes["foo"]; got != "old" {
+		t.Errorf("state of the plan was modified: foo = %q", got)
+	}
+}
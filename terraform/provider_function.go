@@ -0,0 +1,252 @@
+package terraform
+
+import (
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/hashicorp/hil"
+	"github.com/hashicorp/hil/ast"
+	"github.com/hashicorp/terraform/config"
+)
+
+// ProviderFunction describes a function exported by a provider, which can
+// be called from expressions in the configuration using the syntax
+// provider::NAME::FUNC(...).
+//
+// Provider functions must be pure: given the same arguments they must always
+// return the same result and they must not have any side-effects. Terraform
+// calls them on an unconfigured provider instance, which may be called
+// several times during a single operation.
+type ProviderFunction struct {
+	// Name is the name of the function, without any provider prefix.
+	Name string
+
+	// Description is a human-oriented description of the function, for
+	// use in documentation and diagnostic messages.
+	Description string
+
+	// ParamTypes are the types of the required arguments, in order.
+	//
+	// As with variables, primitive values are always represented as
+	// strings, so the only valid types here and in VariadicType and
+	// ReturnType are ast.TypeString, ast.TypeList and ast.TypeMap.
+	ParamTypes []ast.Type
+
+	// VariadicType, if set to something other than ast.TypeInvalid, allows
+	// any number of additional arguments of the given type after those
+	// described by ParamTypes.
+	VariadicType ast.Type
+
+	// ReturnType is the type of the value the function returns.
+	ReturnType ast.Type
+}
+
+func (f *ProviderFunction) validate() error {
+	validType := func(t ast.Type) bool {
+		switch t {
+		case ast.TypeString, ast.TypeList, ast.TypeMap:
+			return true
+		default:
+			return false
+		}
+	}
+
+	for i, t := range f.ParamTypes {
+		if !validType(t) {
+			return fmt.Errorf("parameter %d has unsupported type %s", i+1, t.Printable())
+		}
+	}
+	if f.VariadicType != ast.TypeInvalid && !validType(f.VariadicType) {
+		return fmt.Errorf("variadic parameter has unsupported type %s", f.VariadicType.Printable())
+	}
+	if !validType(f.ReturnType) {
+		return fmt.Errorf("unsupported return type %s", f.ReturnType.Printable())
+	}
+
+	return nil
+}
+
+// ResourceProviderFunctions is an interface that providers can optionally
+// implement to export functions that can be called from expressions.
+//
+// This is a separate interface from ResourceProvider because most providers
+// export no functions at all. Providers that don't implement it are treated
+// as exporting no functions.
+type ResourceProviderFunctions interface {
+	// Functions returns the signatures of all of the functions that the
+	// provider exports.
+	Functions() ([]ProviderFunction, error)
+
+	// CallFunction calls the function with the given name with the given
+	// arguments. The arguments are guaranteed to conform to the signature
+	// returned from Functions, and are given in the form produced by
+	// hil.VariableToInterface. The result must be of a type accepted by
+	// hil.InterfaceToVariable.
+	CallFunction(name string, args []interface{}) (interface{}, error)
+}
+
+// providerFunctions manages the provider instances used to call provider
+// functions during a single graph walk.
+//
+// Provider functions are called on their own unconfigured provider
+// instances, separate from the instances used for resources, so that
+// functions can be called from anywhere in the configuration without
+// creating dependencies on provider configuration.
+type providerFunctions struct {
+	Components contextComponentFactory
+
+	lock      sync.Mutex
+	providers map[string]ResourceProvider
+	sigs      map[string]map[string]ProviderFunction
+}
+
+func newProviderFunctions(components contextComponentFactory) *providerFunctions {
+	return &providerFunctions{
+		Components: components,
+		providers:  make(map[string]ResourceProvider),
+		sigs:       make(map[string]map[string]ProviderFunction),
+	}
+}
+
+// Funcs returns HIL function definitions for each of the given provider
+// function names, which must be in the form produced by
+// config.ProviderFunctionName.
+func (f *providerFunctions) Funcs(names []string) (map[string]ast.Function, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	result := make(map[string]ast.Function, len(names))
+	for _, fullName := range names {
+		providerName, funcName, ok := config.ParseProviderFunctionName(fullName)
+		if !ok {
+			return nil, fmt.Errorf("invalid provider function name %q", fullName)
+		}
+
+		p, sigs, err := f.provider(providerName)
+		if err != nil {
+			return nil, err
+		}
+
+		sig, ok := sigs[funcName]
+		if !ok {
+			return nil, fmt.Errorf(
+				"provider %q does not have a function named %q",
+				providerName, funcName)
+		}
+
+		result[fullName] = providerFunctionHIL(p.(ResourceProviderFunctions), providerName, sig)
+	}
+
+	return result, nil
+}
+
+// provider returns the function-calling instance of the provider with the
+// given type name, along with its function signatures, starting it if
+// necessary.
+func (f *providerFunctions) provider(typeName string) (ResourceProvider, map[string]ProviderFunction, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if p, ok := f.providers[typeName]; ok {
+		return p, f.sigs[typeName], nil
+	}
+
+	p, err := f.Components.ResourceProvider(typeName, "provider-functions."+typeName)
+	if err != nil {
+		return nil, nil, fmt.Errorf(
+			"failed to instantiate provider %q to call its functions: %s",
+			typeName, err)
+	}
+
+	sigs := make(map[string]ProviderFunction)
+	if pf, ok := p.(ResourceProviderFunctions); ok {
+		fns, err := pf.Functions()
+		if err != nil {
+			return nil, nil, fmt.Errorf(
+				"failed to retrieve functions from provider %q: %s",
+				typeName, err)
+		}
+		for _, fn := range fns {
+			if err := fn.validate(); err != nil {
+				return nil, nil, fmt.Errorf(
+					"provider %q has invalid function %q: %s",
+					typeName, fn.Name, err)
+			}
+			sigs[fn.Name] = fn
+		}
+	}
+
+	f.providers[typeName] = p
+	f.sigs[typeName] = sigs
+	return p, sigs, nil
+}
+
+// Close closes all of the provider instances that were started to call
+// functions.
+func (f *providerFunctions) Close() error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	for name, p := range f.providers {
+		if c, ok := p.(ResourceProviderCloser); ok {
+			if err := c.Close(); err != nil {
+				log.Printf("[WARN] error closing provider %q used for functions: %s", name, err)
+			}
+		}
+		delete(f.providers, name)
+		delete(f.sigs, name)
+	}
+
+	return nil
+}
+
+// providerFunctionHIL returns a HIL function definition that calls the given
+// provider function.
+func providerFunctionHIL(p ResourceProviderFunctions, providerName string, sig ProviderFunction) ast.Function {
+	return ast.Function{
+		ArgTypes:     sig.ParamTypes,
+		ReturnType:   sig.ReturnType,
+		Variadic:     sig.VariadicType != ast.TypeInvalid,
+		VariadicType: sig.VariadicType,
+		Callback: func(args []interface{}) (interface{}, error) {
+			rawArgs := make([]interface{}, len(args))
+			for i, arg := range args {
+				typ := sig.VariadicType
+				if i < len(sig.ParamTypes) {
+					typ = sig.ParamTypes[i]
+				}
+
+				raw, err := hil.VariableToInterface(ast.Variable{
+					Type:  typ,
+					Value: arg,
+				})
+				if err != nil {
+					return nil, fmt.Errorf("argument %d: %s", i+1, err)
+				}
+				rawArgs[i] = raw
+			}
+
+			log.Printf("[TRACE] calling function %q from provider %q", sig.Name, providerName)
+			raw, err := p.CallFunction(sig.Name, rawArgs)
+			if err != nil {
+				return nil, fmt.Errorf("provider::%s::%s: %s", providerName, sig.Name, err)
+			}
+
+			result, err := hil.InterfaceToVariable(raw)
+			if err != nil {
+				return nil, fmt.Errorf(
+					"provider::%s::%s returned an invalid result: %s",
+					providerName, sig.Name, err)
+			}
+			if result.Type != sig.ReturnType {
+				return nil, fmt.Errorf(
+					"provider::%s::%s returned %s, but was declared as returning %s",
+					providerName, sig.Name, result.Type.Printable(), sig.ReturnType.Printable())
+			}
+
+			return result.Value, nil
+		},
+	}
+}
@@ -145,6 +145,11 @@ type ResourceConfig struct {
 	Raw          map[string]interface{}
 	Config       map[string]interface{}
 
+	// SensitiveKeys are the top-level keys of Config whose values were
+	// derived from sensitive values, such as sensitive resource attributes
+	// or sensitive module outputs.
+	SensitiveKeys []string
+
 	raw *config.RawConfig
 }
 
@@ -198,6 +203,7 @@ func (c *ResourceConfig) Equal(c2 *ResourceConfig) bool {
 		{c.ComputedKeys, c2.ComputedKeys},
 		{c.Raw, c2.Raw},
 		{c.Config, c2.Config},
+		{c.SensitiveKeys, c2.SensitiveKeys},
 	}
 	for _, pair := range check {
 		if !reflect.DeepEqual(pair[0], pair[1]) {
@@ -272,6 +278,22 @@ func (c *ResourceConfig) IsComputed(k string) bool {
 	return w.Unknown
 }
 
+// IsSensitive returns whether the value of the given key was derived from
+// a sensitive value. Keys nested below a sensitive key are also sensitive.
+func (c *ResourceConfig) IsSensitive(k string) bool {
+	if c == nil {
+		return false
+	}
+
+	for _, sk := range c.SensitiveKeys {
+		if k == sk || strings.HasPrefix(k, sk+".") {
+			return true
+		}
+	}
+
+	return false
+}
+
 // IsSet checks if the key in the configuration is set. A key is set if
 // it has a value or the value is being computed (is unknown currently).
 //
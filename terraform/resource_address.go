@@ -16,6 +16,9 @@ import (
 type ResourceAddress struct {
 	// Addresses a resource falling somewhere in the module path
 	// When specified alone, addresses all resources within a module path
+	//
+	// Each step may include an instance key, as produced by
+	// module.InstanceStep, to address a particular instance of a module.
 	Path []string
 
 	// Addresses a specific resource that occurs in a list
@@ -228,6 +231,11 @@ func ParseResourceAddress(s string) (*ResourceAddress, error) {
 		return nil, err
 	}
 	path := ParseResourcePath(matches["path"])
+	for _, step := range path {
+		if _, _, err := module.ParseInstanceStep(step); err != nil {
+			return nil, fmt.Errorf("invalid resource address %q: %s", s, err)
+		}
+	}
 
 	// not allowed to say "data." without a type following
 	if mode == config.DataResourceMode && matches["type"] == "" {
@@ -283,7 +291,7 @@ func (addr *ResourceAddress) Contains(other *ResourceAddress) bool {
 		return false
 	}
 	for i := range ourPath {
-		if ourPath[i] != givenPath[i] {
+		if !moduleStepContains(ourPath[i], givenPath[i]) {
 			return false
 		}
 	}
@@ -309,6 +317,26 @@ func (addr *ResourceAddress) Contains(other *ResourceAddress) bool {
 	return true
 }
 
+// moduleStepContains returns true if the module path step given as ours
+// contains the step given as other. A step without an instance key
+// contains every instance of the same module call.
+func moduleStepContains(ours, other string) bool {
+	if ours == other {
+		return true
+	}
+
+	ourName, ourIndex, err := module.ParseInstanceStep(ours)
+	if err != nil || ourIndex != -1 {
+		return false
+	}
+	otherName, _, err := module.ParseInstanceStep(other)
+	if err != nil {
+		return false
+	}
+
+	return ourName == otherName
+}
+
 // Equals returns true if the receiver matches the given address.
 //
 // The name of this method is a misnomer, since it doesn't test for exact
@@ -325,6 +353,17 @@ func (addr *ResourceAddress) Equals(raw interface{}) bool {
 
 	pathMatch := len(addr.Path) == 0 && len(other.Path) == 0 ||
 		reflect.DeepEqual(addr.Path, other.Path)
+	if !pathMatch && len(addr.Path) == len(other.Path) {
+		// A path step without an instance key matches any instance
+		pathMatch = true
+		for i := range addr.Path {
+			if !moduleStepContains(addr.Path[i], other.Path[i]) &&
+				!moduleStepContains(other.Path[i], addr.Path[i]) {
+				pathMatch = false
+				break
+			}
+		}
+	}
 
 	indexMatch := addr.Index == -1 ||
 		other.Index == -1 ||
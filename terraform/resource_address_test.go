@@ -271,6 +271,37 @@ func TestParseResourceAddress(t *testing.T) {
 			"",
 			false,
 		},
+		"managed in a module instance": {
+			"module.child[1].aws_instance.foo",
+			&ResourceAddress{
+				Path:         []string{"child[1]"},
+				Mode:         config.ManagedResourceMode,
+				Type:         "aws_instance",
+				Name:         "foo",
+				InstanceType: TypePrimary,
+				Index:        -1,
+			},
+			"",
+			false,
+		},
+		"just a nested module instance": {
+			"module.a.module.b[0]",
+			&ResourceAddress{
+				Path:         []string{"a", "b[0]"},
+				Type:         "",
+				Name:         "",
+				InstanceType: TypePrimary,
+				Index:        -1,
+			},
+			"",
+			false,
+		},
+		"module instance with invalid key": {
+			"module.child[foo].aws_instance.foo",
+			nil,
+			"",
+			true,
+		},
 		"module missing resource type": {
 			"module.name.foo",
 			nil,
@@ -588,6 +619,54 @@ func TestResourceAddressContains(t *testing.T) {
 			},
 			false,
 		},
+		{
+			&ResourceAddress{
+				Path:            []string{"bar"},
+				InstanceTypeSet: false,
+				Index:           -1,
+			},
+			&ResourceAddress{
+				Path:         []string{"bar[2]", "baz"},
+				Type:         "aws_instance",
+				Name:         "foo",
+				Index:        -1,
+				InstanceType: TypePrimary,
+				Mode:         config.ManagedResourceMode,
+			},
+			true,
+		},
+		{
+			&ResourceAddress{
+				Path:            []string{"bar[1]"},
+				InstanceTypeSet: false,
+				Index:           -1,
+			},
+			&ResourceAddress{
+				Path:         []string{"bar[2]"},
+				Type:         "aws_instance",
+				Name:         "foo",
+				Index:        -1,
+				InstanceType: TypePrimary,
+				Mode:         config.ManagedResourceMode,
+			},
+			false,
+		},
+		{
+			&ResourceAddress{
+				Path:            []string{"bar[1]"},
+				InstanceTypeSet: false,
+				Index:           -1,
+			},
+			&ResourceAddress{
+				Path:         []string{"bar"},
+				Type:         "aws_instance",
+				Name:         "foo",
+				Index:        -1,
+				InstanceType: TypePrimary,
+				Mode:         config.ManagedResourceMode,
+			},
+			false,
+		},
 	}
 
 	for _, test := range tests {
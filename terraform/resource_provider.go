@@ -188,6 +188,98 @@ type ResourceProviderCloser interface {
 	Close() error
 }
 
+// ResourceProviderLocker is an interface that providers can optionally
+// implement to support the "lock" lifecycle option, which holds an advisory
+// lock on a remote object while it is being changed.
+//
+// The lock is keyed by an identity of the provider's choosing, derived from
+// the instance state and diff, and must be held somewhere visible to all
+// Terraform runs that might manage the same object, such as in the remote
+// system itself.
+type ResourceProviderLocker interface {
+	// LockResource acquires the lock for the object described by the given
+	// state and diff, which may be waiting to be created. It returns an
+	// opaque ID that is later passed to UnlockResource to release it.
+	LockResource(*InstanceInfo, *InstanceState, *InstanceDiff) (string, error)
+
+	// UnlockResource releases a lock that was acquired by LockResource.
+	UnlockResource(*InstanceInfo, string) error
+}
+
+// ResourceProviderMover is an interface that providers can optionally
+// implement to take over the state of resource instances of another type,
+// possibly belonging to another provider, when a "moved" block moves them
+// to one of their own resource types. Providers implementing it must also
+// report the MoveResourceState capability.
+//
+// MoveResourceState is called on an unconfigured provider instance, so it
+// must convert the state without calling any remote APIs.
+type ResourceProviderMover interface {
+	MoveResourceState(*MoveResourceStateRequest) (*InstanceState, error)
+}
+
+// MoveResourceStateRequest describes a resource instance whose state is
+// being moved to another resource type.
+type MoveResourceStateRequest struct {
+	// Info describes the instance the state is being moved to.
+	Info *InstanceInfo
+
+	// SourceProvider is the name of the provider that managed the instance
+	// being moved, such as "aws", and SourceType is its resource type.
+	SourceProvider string
+	SourceType     string
+
+	// SourceState is the state of the instance being moved.
+	SourceState *InstanceState
+}
+
+// ResourceProviderUpgrader is an interface that providers can optionally
+// implement to upgrade the state of resource instances that was recorded
+// with an earlier version of their resource type's schema. Providers
+// implementing it must also report the UpgradeResourceState capability and
+// the current version of each resource type's schema.
+//
+// UpgradeResourceState is called on an unconfigured provider instance, so
+// it must upgrade the state without calling any remote APIs.
+type ResourceProviderUpgrader interface {
+	UpgradeResourceState(*UpgradeResourceStateRequest) (*InstanceState, error)
+}
+
+// UpgradeResourceStateRequest describes a resource instance whose state
+// must be upgraded to the current version of its resource type's schema.
+type UpgradeResourceStateRequest struct {
+	// Info describes the instance being upgraded.
+	Info *InstanceInfo
+
+	// Version is the version of the schema the state was recorded with.
+	Version int
+
+	// State is the state being upgraded, whose attributes are in the
+	// flatmap form of the schema at Version.
+	State *InstanceState
+}
+
+// ResourceProviderMetaConfigurer is an interface that providers can
+// optionally implement to receive the metadata that modules declare for
+// them in provider_meta blocks. Providers implementing it must also report
+// the ProviderMeta capability.
+//
+// ConfigureProviderMeta is called just after Configure, with the metadata
+// of each module whose resources are managed by the configured provider.
+// It isn't called if none of those modules declare any metadata.
+type ResourceProviderMetaConfigurer interface {
+	ConfigureProviderMeta([]*ProviderModuleMeta) error
+}
+
+// ProviderModuleMeta is the metadata a module declares for a provider.
+type ProviderModuleMeta struct {
+	// ModulePath is the path of the module, such as ["root", "foo"].
+	ModulePath []string
+
+	// Config is the metadata, which never contains interpolations.
+	Config *ResourceConfig
+}
+
 // ResourceType is a type of resource that a resource provider can manage.
 type ResourceType struct {
 	Name       string // Name of the resource, example "instance" (no provider prefix)
@@ -91,6 +91,46 @@ type MockResourceProvider struct {
 	ImportStateReturn      []*InstanceState
 	ImportStateReturnError error
 	ImportStateFn          func(*InstanceInfo, string) ([]*InstanceState, error)
+
+	FunctionsCalled         bool
+	FunctionsReturn         []ProviderFunction
+	FunctionsReturnError    error
+	CallFunctionCalled      bool
+	CallFunctionName        string
+	CallFunctionArgs        []interface{}
+	CallFunctionFn          func(string, []interface{}) (interface{}, error)
+	CallFunctionReturn      interface{}
+	CallFunctionReturnError error
+
+	LockResourceCalled        bool
+	LockResourceInfo          *InstanceInfo
+	LockResourceState         *InstanceState
+	LockResourceDiff          *InstanceDiff
+	LockResourceFn            func(*InstanceInfo, *InstanceState, *InstanceDiff) (string, error)
+	LockResourceReturn        string
+	LockResourceReturnError   error
+	UnlockResourceCalled      bool
+	UnlockResourceInfo        *InstanceInfo
+	UnlockResourceLockID      string
+	UnlockResourceFn          func(*InstanceInfo, string) error
+	UnlockResourceReturnError error
+
+	MoveResourceStateCalled      bool
+	MoveResourceStateRequest     *MoveResourceStateRequest
+	MoveResourceStateFn          func(*MoveResourceStateRequest) (*InstanceState, error)
+	MoveResourceStateReturn      *InstanceState
+	MoveResourceStateReturnError error
+
+	UpgradeResourceStateCalled      bool
+	UpgradeResourceStateRequest     *UpgradeResourceStateRequest
+	UpgradeResourceStateFn          func(*UpgradeResourceStateRequest) (*InstanceState, error)
+	UpgradeResourceStateReturn      *InstanceState
+	UpgradeResourceStateReturnError error
+
+	ConfigureProviderMetaCalled      bool
+	ConfigureProviderMetaMetas       []*ProviderModuleMeta
+	ConfigureProviderMetaFn          func([]*ProviderModuleMeta) error
+	ConfigureProviderMetaReturnError error
 }
 
 func (p *MockResourceProvider) Close() error {
@@ -161,6 +201,20 @@ func (p *MockResourceProvider) Configure(c *ResourceConfig) error {
 	return p.ConfigureReturnError
 }
 
+func (p *MockResourceProvider) ConfigureProviderMeta(metas []*ProviderModuleMeta) error {
+	p.Lock()
+	defer p.Unlock()
+
+	p.ConfigureProviderMetaCalled = true
+	p.ConfigureProviderMetaMetas = metas
+
+	if p.ConfigureProviderMetaFn != nil {
+		return p.ConfigureProviderMetaFn(metas)
+	}
+
+	return p.ConfigureProviderMetaReturnError
+}
+
 func (p *MockResourceProvider) Stop() error {
 	p.Lock()
 	defer p.Unlock()
@@ -313,3 +367,88 @@ func (p *MockResourceProvider) DataSources() []DataSource {
 	p.DataSourcesCalled = true
 	return p.DataSourcesReturn
 }
+
+func (p *MockResourceProvider) Functions() ([]ProviderFunction, error) {
+	p.Lock()
+	defer p.Unlock()
+
+	p.FunctionsCalled = true
+	return p.FunctionsReturn, p.FunctionsReturnError
+}
+
+func (p *MockResourceProvider) CallFunction(name string, args []interface{}) (interface{}, error) {
+	p.Lock()
+	defer p.Unlock()
+
+	p.CallFunctionCalled = true
+	p.CallFunctionName = name
+	p.CallFunctionArgs = args
+
+	if p.CallFunctionFn != nil {
+		return p.CallFunctionFn(name, args)
+	}
+
+	return p.CallFunctionReturn, p.CallFunctionReturnError
+}
+
+func (p *MockResourceProvider) LockResource(
+	info *InstanceInfo,
+	state *InstanceState,
+	diff *InstanceDiff) (string, error) {
+	p.Lock()
+	defer p.Unlock()
+
+	p.LockResourceCalled = true
+	p.LockResourceInfo = info
+	p.LockResourceState = state
+	p.LockResourceDiff = diff
+
+	if p.LockResourceFn != nil {
+		return p.LockResourceFn(info, state, diff)
+	}
+
+	return p.LockResourceReturn, p.LockResourceReturnError
+}
+
+func (p *MockResourceProvider) UnlockResource(info *InstanceInfo, id string) error {
+	p.Lock()
+	defer p.Unlock()
+
+	p.UnlockResourceCalled = true
+	p.UnlockResourceInfo = info
+	p.UnlockResourceLockID = id
+
+	if p.UnlockResourceFn != nil {
+		return p.UnlockResourceFn(info, id)
+	}
+
+	return p.UnlockResourceReturnError
+}
+
+func (p *MockResourceProvider) MoveResourceState(req *MoveResourceStateRequest) (*InstanceState, error) {
+	p.Lock()
+	defer p.Unlock()
+
+	p.MoveResourceStateCalled = true
+	p.MoveResourceStateRequest = req
+
+	if p.MoveResourceStateFn != nil {
+		return p.MoveResourceStateFn(req)
+	}
+
+	return p.MoveResourceStateReturn, p.MoveResourceStateReturnError
+}
+
+func (p *MockResourceProvider) UpgradeResourceState(req *UpgradeResourceStateRequest) (*InstanceState, error) {
+	p.Lock()
+	defer p.Unlock()
+
+	p.UpgradeResourceStateCalled = true
+	p.UpgradeResourceStateRequest = req
+
+	if p.UpgradeResourceStateFn != nil {
+		return p.UpgradeResourceStateFn(req)
+	}
+
+	return p.UpgradeResourceStateReturn, p.UpgradeResourceStateReturnError
+}
@@ -7,4 +7,5 @@ import (
 func TestMockResourceProvider_impl(t *testing.T) {
 	var _ ResourceProvider = new(MockResourceProvider)
 	var _ ResourceProviderCloser = new(MockResourceProvider)
+	var _ ResourceProviderLocker = new(MockResourceProvider)
 }
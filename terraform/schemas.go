@@ -24,6 +24,43 @@ type ProviderSchema struct {
 	Provider      *configschema.Block
 	ResourceTypes map[string]*configschema.Block
 	DataSources   map[string]*configschema.Block
+
+	// ResourceTypeSchemaVersions are the current versions of the schemas
+	// of the requested resource types. A resource type without an entry
+	// is at version zero.
+	ResourceTypeSchemaVersions map[string]int
+
+	// Capabilities is returned regardless of which resource types and data
+	// sources are requested.
+	Capabilities ProviderCapabilities
+}
+
+// ProviderCapabilities describes the optional protocol features that a
+// provider supports, so that Terraform can adapt its behavior to each
+// provider rather than guessing from the provider's version.
+//
+// Providers that predate a capability report its zero value, so each is
+// defined such that false preserves the behavior those providers expect.
+type ProviderCapabilities struct {
+	// PlanDestroy is true if the provider wants to plan the destruction of
+	// its resource instances itself. Diff is then called with a nil
+	// configuration for each instance to be destroyed, and must return a
+	// destroy diff or an error explaining why it can't be destroyed.
+	PlanDestroy bool
+
+	// MoveResourceState is true if the provider can convert the state of
+	// a resource instance from another resource type to one of its own.
+	MoveResourceState bool
+
+	// UpgradeResourceState is true if the provider wants Terraform to ask
+	// it to upgrade the state of resource instances that were recorded
+	// with an earlier version of their resource type's schema, before
+	// they are refreshed or planned.
+	UpgradeResourceState bool
+
+	// ProviderMeta is true if the provider accepts the metadata that
+	// modules declare for it in provider_meta blocks.
+	ProviderMeta bool
 }
 
 // ProviderSchemaRequest is used to describe to a ResourceProvider which
@@ -98,6 +98,14 @@ func smcUserVariables(c *config.Config, vs map[string]interface{}) []error {
 			continue
 		}
 
+		if t, err := schema.TypeConstraint(); err == nil && t != nil {
+			if _, err := t.Convert(proposedValue); err != nil {
+				errs = append(errs, fmt.Errorf("variable %s should be type %s: %s",
+					name, schema.DeclaredType, convertErrorString(schema, err)))
+			}
+			continue
+		}
+
 		declaredType := schema.Type()
 
 		switch declaredType {
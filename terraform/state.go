@@ -86,9 +86,17 @@ type State struct {
 	// configuration.
 	Backend *BackendState `json:"backend,omitempty"`
 
+	// Workspace is the metadata of the workspace that this is the state of,
+	// as set by the "terraform workspace" commands.
+	Workspace *WorkspaceMetadata `json:"workspace,omitempty"`
+
 	// Modules contains all the modules in a breadth-first order
 	Modules []*ModuleState `json:"modules"`
 
+	// Checks are the results of the check blocks of the configuration, as
+	// they were evaluated at the end of the last apply.
+	Checks []*CheckResult `json:"checks,omitempty"`
+
 	mu sync.Mutex
 }
 
@@ -532,6 +540,11 @@ func (s *State) equal(other *State) bool {
 		}
 	}
 
+	// The results of checks can change without anything else changing.
+	if !reflect.DeepEqual(s.Checks, other.Checks) {
+		return false
+	}
+
 	return true
 }
 
@@ -654,6 +667,34 @@ func (s *State) DeepCopy() *State {
 	return copy.(*State)
 }
 
+// Redacted returns a copy of the state in which the values of sensitive
+// outputs and resource attributes are replaced with "<sensitive>", so that
+// it can be recorded where secrets mustn't be, such as in a crash report.
+func (s *State) Redacted() *State {
+	copy := s.DeepCopy()
+	if copy == nil {
+		return nil
+	}
+
+	for _, m := range copy.Modules {
+		for _, o := range m.Outputs {
+			if o != nil && o.Sensitive {
+				o.Value = "<sensitive>"
+			}
+		}
+		for _, r := range m.Resources {
+			if r == nil {
+				continue
+			}
+			r.Primary.redact()
+			for _, is := range r.Deposed {
+				is.redact()
+			}
+		}
+	}
+	return copy
+}
+
 // FromFutureTerraform checks if this state was written by a Terraform
 // version from the future.
 func (s *State) FromFutureTerraform() bool {
@@ -804,6 +845,22 @@ func (s *State) String() string {
 	return strings.TrimSpace(buf.String())
 }
 
+// WorkspaceMetadata is metadata about a workspace. It's stored with the
+// state of the workspace, so that any backend that stores states stores it.
+type WorkspaceMetadata struct {
+	// Description describes the workspace to people listing workspaces.
+	Description string `json:"description,omitempty"`
+
+	// Protected blocks deleting the workspace and destroying its resources,
+	// unless the protection is explicitly overridden.
+	Protected bool `json:"protected,omitempty"`
+}
+
+// Empty returns true if the metadata sets nothing.
+func (m *WorkspaceMetadata) Empty() bool {
+	return m == nil || (m.Description == "" && !m.Protected)
+}
+
 // BackendState stores the configuration to connect to a remote backend.
 type BackendState struct {
 	Type   string                 `json:"type"`   // Backend type
@@ -1627,6 +1684,13 @@ type InstanceState struct {
 	// Tainted is used to mark a resource for recreation.
 	Tainted bool `json:"tainted"`
 
+	// SensitiveAttributes are the keys within Attributes whose values are
+	// sensitive, either because the provider declared them as such or
+	// because they were derived from other sensitive values. Sensitive
+	// values are redacted in UI output. A key also marks any nested keys
+	// beneath it as sensitive.
+	SensitiveAttributes []string `json:"sensitive_attributes,omitempty"`
+
 	mu sync.Mutex
 }
 
@@ -1659,6 +1723,7 @@ func (s *InstanceState) Set(from *InstanceState) {
 	s.Ephemeral = from.Ephemeral
 	s.Meta = from.Meta
 	s.Tainted = from.Tainted
+	s.SensitiveAttributes = from.SensitiveAttributes
 }
 
 func (s *InstanceState) DeepCopy() *InstanceState {
@@ -1670,6 +1735,29 @@ func (s *InstanceState) DeepCopy() *InstanceState {
 	return copy.(*InstanceState)
 }
 
+// Redacted returns a copy of the instance state in which the values of
+// sensitive attributes are replaced with "<sensitive>".
+func (s *InstanceState) Redacted() *InstanceState {
+	if s == nil {
+		return nil
+	}
+
+	copy := s.DeepCopy()
+	copy.redact()
+	return copy
+}
+
+func (s *InstanceState) redact() {
+	if s == nil {
+		return
+	}
+	for k := range s.Attributes {
+		if s.isSensitive(k) {
+			s.Attributes[k] = "<sensitive>"
+		}
+	}
+}
+
 func (s *InstanceState) Empty() bool {
 	if s == nil {
 		return true
@@ -1738,9 +1826,39 @@ func (s *InstanceState) Equal(other *InstanceState) bool {
 		return false
 	}
 
+	if len(s.SensitiveAttributes) != len(other.SensitiveAttributes) {
+		return false
+	}
+	for _, k := range s.SensitiveAttributes {
+		if !other.isSensitive(k) {
+			return false
+		}
+	}
+
 	return true
 }
 
+// IsSensitive returns true if the attribute with the given key is sensitive,
+// or if the key refers to a list or map that contains sensitive values.
+func (s *InstanceState) IsSensitive(key string) bool {
+	if s == nil {
+		return false
+	}
+	s.Lock()
+	defer s.Unlock()
+
+	return s.isSensitive(key)
+}
+
+func (s *InstanceState) isSensitive(key string) bool {
+	for _, k := range s.SensitiveAttributes {
+		if k == key || strings.HasPrefix(k, key+".") || strings.HasPrefix(key, k+".") {
+			return true
+		}
+	}
+	return false
+}
+
 // MergeDiff takes a ResourceDiff and merges the attributes into
 // this resource state in order to generate a new state. This new
 // state can be used to provide updated attribute lookups for
@@ -1778,6 +1896,44 @@ func (s *InstanceState) MergeDiff(d *InstanceDiff) *InstanceState {
 		}
 	}
 
+	result.SensitiveAttributes = mergeSensitiveAttributes(s, d, result.Attributes)
+
+	return result
+}
+
+// mergeSensitiveAttributes returns the sensitive attribute keys that result
+// from applying the given diff to the given prior state, limited to the keys
+// that are still present in the given attributes.
+func mergeSensitiveAttributes(prior *InstanceState, d *InstanceDiff, attrs map[string]string) []string {
+	seen := make(map[string]struct{})
+	if prior != nil {
+		for _, k := range prior.SensitiveAttributes {
+			seen[k] = struct{}{}
+		}
+	}
+	if d != nil {
+		for k, diff := range d.CopyAttributes() {
+			if diff.Sensitive && !diff.NewRemoved {
+				seen[k] = struct{}{}
+			}
+		}
+	}
+
+	var result []string
+	for k := range seen {
+		if _, ok := attrs[k]; ok {
+			result = append(result, k)
+			continue
+		}
+		for ak := range attrs {
+			if strings.HasPrefix(ak, k+".") {
+				result = append(result, k)
+				break
+			}
+		}
+	}
+	sort.Strings(result)
+
 	return result
 }
 
@@ -0,0 +1,131 @@
+package terraform
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform/config"
+)
+
+// ProviderReplacement is a change of the provider recorded in the state for
+// a resource, as made by State.ReplaceProvider.
+type ProviderReplacement struct {
+	Address *ResourceAddress
+
+	// From and To are the provider before and after the change, as the
+	// state records them, such as "provider.aws" or
+	// "module.network.provider.aws.west". From is empty if the state didn't
+	// record the provider, because the resource used the default one.
+	From string
+	To   string
+}
+
+// ReplaceProvider changes the provider recorded for the resources of the
+// state that use the provider from, to the provider to, such as to move
+// resources to a fork of their provider. It returns the changes it made,
+// sorted by address.
+//
+// The providers are given by name, as in "aws", which matches all of the
+// configurations of the provider, or with an alias, as in "aws.west", which
+// matches only that configuration. If to has no alias, each resource keeps
+// the alias of its configuration.
+//
+// Only the resources that are contained by one of addrs are changed, or all
+// of them if there are none.
+func (s *State) ReplaceProvider(from, to string, addrs []*ResourceAddress) ([]*ProviderReplacement, error) {
+	fromName, fromAlias, err := parseProviderName(from)
+	if err != nil {
+		return nil, err
+	}
+	toName, toAlias, err := parseProviderName(to)
+	if err != nil {
+		return nil, err
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	var result []*ProviderReplacement
+	for _, ms := range s.Modules {
+		for k, rs := range ms.Resources {
+			key, err := ParseResourceStateKey(k)
+			if err != nil {
+				// should never happen; indicates an invalid state
+				continue
+			}
+			addr := &ResourceAddress{
+				Path:  ms.Path[1:],
+				Index: key.Index,
+				Name:  key.Name,
+				Type:  key.Type,
+				Mode:  key.Mode,
+			}
+			if !containedByAny(addrs, addr) {
+				continue
+			}
+
+			// The recorded provider is the address of its configuration,
+			// whose last part is the name and alias of the provider.
+			prefix := "provider."
+			fullName := config.ResourceProviderFullName(key.Type, rs.Provider)
+			if rs.Provider != "" {
+				prefix = rs.Provider[:len(rs.Provider)-len(fullName)]
+			}
+			name, alias, _ := parseProviderName(fullName)
+			if name != fromName || (fromAlias != "" && alias != fromAlias) {
+				continue
+			}
+
+			if toAlias != "" {
+				alias = toAlias
+			}
+			newProvider := prefix + toName
+			if alias != "" {
+				newProvider += "." + alias
+			}
+			if newProvider == rs.Provider {
+				continue
+			}
+
+			result = append(result, &ProviderReplacement{
+				Address: addr,
+				From:    rs.Provider,
+				To:      newProvider,
+			})
+			rs.Provider = newProvider
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Address.Less(result[j].Address)
+	})
+	return result, nil
+}
+
+// parseProviderName parses a provider name with an optional alias, as in
+// "aws" or "aws.west".
+func parseProviderName(s string) (name, alias string, err error) {
+	parts := strings.SplitN(s, ".", 2)
+	name = parts[0]
+	if len(parts) == 2 {
+		alias = parts[1]
+	}
+
+	if !config.NameRegexp.MatchString(name) || (len(parts) == 2 && !config.NameRegexp.MatchString(alias)) {
+		return "", "", fmt.Errorf("invalid provider %q: must be a provider name, such as \"aws\", optionally with an alias, such as \"aws.west\"", s)
+	}
+	return name, alias, nil
+}
+
+func containedByAny(addrs []*ResourceAddress, addr *ResourceAddress) bool {
+	if len(addrs) == 0 {
+		return true
+	}
+	for _, a := range addrs {
+		if a.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,148 @@
+package terraform
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStateReplaceProvider(t *testing.T) {
+	newState := func() *State {
+		return &State{
+			Modules: []*ModuleState{
+				&ModuleState{
+					Path: rootModulePath,
+					Resources: map[string]*ResourceState{
+						"aws_instance.foo": &ResourceState{
+							Type:     "aws_instance",
+							Provider: "provider.aws",
+						},
+						"aws_instance.bar.0": &ResourceState{
+							Type: "aws_instance",
+						},
+						"aws_instance.west": &ResourceState{
+							Type:     "aws_instance",
+							Provider: "provider.aws.west",
+						},
+						"google_instance.foo": &ResourceState{
+							Type:     "google_instance",
+							Provider: "provider.google",
+						},
+					},
+				},
+				&ModuleState{
+					Path: []string{"root", "child"},
+					Resources: map[string]*ResourceState{
+						"aws_instance.foo": &ResourceState{
+							Type:     "aws_instance",
+							Provider: "module.child.provider.aws",
+						},
+					},
+				},
+			},
+		}
+	}
+
+	cases := map[string]struct {
+		From, To  string
+		Addrs     []string
+		Expected  []string
+		Providers map[string]string
+	}{
+		"all configurations": {
+			"aws", "awsfork",
+			nil,
+			[]string{
+				"aws_instance.bar[0]:  -> provider.awsfork",
+				"aws_instance.foo: provider.aws -> provider.awsfork",
+				"aws_instance.west: provider.aws.west -> provider.awsfork.west",
+				"module.child.aws_instance.foo: module.child.provider.aws -> module.child.provider.awsfork",
+			},
+			map[string]string{
+				"aws_instance.west":   "provider.awsfork.west",
+				"google_instance.foo": "provider.google",
+			},
+		},
+
+		"alias": {
+			"aws.west", "aws.east",
+			nil,
+			[]string{
+				"aws_instance.west: provider.aws.west -> provider.aws.east",
+			},
+			map[string]string{
+				"aws_instance.foo":  "provider.aws",
+				"aws_instance.west": "provider.aws.east",
+			},
+		},
+
+		"addresses": {
+			"aws", "awsfork",
+			[]string{"module.child", "aws_instance.foo"},
+			[]string{
+				"aws_instance.foo: provider.aws -> provider.awsfork",
+				"module.child.aws_instance.foo: module.child.provider.aws -> module.child.provider.awsfork",
+			},
+			map[string]string{
+				"aws_instance.bar.0": "",
+				"aws_instance.west":  "provider.aws.west",
+			},
+		},
+
+		"no match": {
+			"azurerm", "azurermfork",
+			nil,
+			nil,
+			nil,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			var addrs []*ResourceAddress
+			for _, a := range tc.Addrs {
+				addr, err := ParseResourceAddress(a)
+				if err != nil {
+					t.Fatalf("err: %s", err)
+				}
+				addrs = append(addrs, addr)
+			}
+
+			state := newState()
+			result, err := state.ReplaceProvider(tc.From, tc.To, addrs)
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+
+			var actual []string
+			for _, r := range result {
+				actual = append(actual, r.Address.String()+": "+r.From+" -> "+r.To)
+			}
+			if !reflect.DeepEqual(actual, tc.Expected) {
+				t.Fatalf("wrong result\ngot:  %#v\nwant: %#v", actual, tc.Expected)
+			}
+
+			root := state.RootModule()
+			for k, v := range tc.Providers {
+				if got := root.Resources[k].Provider; got != v {
+					t.Fatalf("%s: wrong provider %q; want %q", k, got, v)
+				}
+			}
+		})
+	}
+}
+
+func TestStateReplaceProvider_invalid(t *testing.T) {
+	cases := [][2]string{
+		{"", "aws"},
+		{"aws", "aws fork"},
+		{"provider.aws.west.east", "aws"},
+		{"registry.terraform.io/-/aws", "aws"},
+	}
+
+	for _, tc := range cases {
+		state := &State{}
+		if _, err := state.ReplaceProvider(tc[0], tc[1], nil); err == nil {
+			t.Fatalf("%q -> %q: should error", tc[0], tc[1])
+		}
+	}
+}
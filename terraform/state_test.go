@@ -1590,6 +1590,141 @@ func TestInstanceState_MergeDiff_nil(t *testing.T) {
 	}
 }
 
+func TestInstanceState_MergeDiff_sensitive(t *testing.T) {
+	is := InstanceState{
+		ID: "foo",
+		Attributes: map[string]string{
+			"foo":    "bar",
+			"secret": "hunter2",
+			"gone":   "baz",
+		},
+		SensitiveAttributes: []string{"gone", "secret"},
+	}
+
+	diff := &InstanceDiff{
+		Attributes: map[string]*ResourceAttrDiff{
+			"gone": &ResourceAttrDiff{
+				Old:        "baz",
+				NewRemoved: true,
+			},
+			"tags.#": &ResourceAttrDiff{
+				Old:       "",
+				New:       "1",
+				Sensitive: true,
+			},
+			"tags.name": &ResourceAttrDiff{
+				Old:       "",
+				New:       "secret",
+				Sensitive: true,
+			},
+		},
+	}
+
+	is2 := is.MergeDiff(diff)
+
+	expected := []string{"secret", "tags.#", "tags.name"}
+	if !reflect.DeepEqual(expected, is2.SensitiveAttributes) {
+		t.Fatalf("bad: %#v", is2.SensitiveAttributes)
+	}
+}
+
+func TestInstanceState_IsSensitive(t *testing.T) {
+	is := &InstanceState{
+		SensitiveAttributes: []string{"password", "tags.secret"},
+	}
+
+	cases := map[string]bool{
+		"password":    true,
+		"password.0":  true,
+		"tags":        true,
+		"tags.secret": true,
+		"tags.other":  false,
+		"passwords":   false,
+		"name":        false,
+	}
+
+	for k, expected := range cases {
+		if actual := is.IsSensitive(k); actual != expected {
+			t.Errorf("%s: got %t; want %t", k, actual, expected)
+		}
+	}
+}
+
+func TestState_Redacted(t *testing.T) {
+	state := &State{
+		Modules: []*ModuleState{
+			&ModuleState{
+				Path: rootModulePath,
+				Outputs: map[string]*OutputState{
+					"name": &OutputState{
+						Type:  "string",
+						Value: "foo",
+					},
+					"secret": &OutputState{
+						Type:      "string",
+						Value:     "hunter2",
+						Sensitive: true,
+					},
+				},
+				Resources: map[string]*ResourceState{
+					"aws_instance.foo": &ResourceState{
+						Type: "aws_instance",
+						Primary: &InstanceState{
+							ID: "foo",
+							Attributes: map[string]string{
+								"id":          "foo",
+								"password":    "hunter2",
+								"tags.%":      "2",
+								"tags.name":   "foo",
+								"tags.secret": "hunter2",
+							},
+							SensitiveAttributes: []string{"password", "tags.secret"},
+						},
+						Deposed: []*InstanceState{
+							&InstanceState{
+								ID: "bar",
+								Attributes: map[string]string{
+									"password": "hunter1",
+								},
+								SensitiveAttributes: []string{"password"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	redacted := state.Redacted()
+	mod := redacted.RootModule()
+	if mod.Outputs["name"].Value != "foo" || mod.Outputs["secret"].Value != "<sensitive>" {
+		t.Fatalf("bad outputs: %#v", mod.Outputs)
+	}
+
+	rs := mod.Resources["aws_instance.foo"]
+	expected := map[string]string{
+		"id":          "foo",
+		"password":    "<sensitive>",
+		"tags.%":      "2",
+		"tags.name":   "foo",
+		"tags.secret": "<sensitive>",
+	}
+	if !reflect.DeepEqual(rs.Primary.Attributes, expected) {
+		t.Fatalf("bad: %#v", rs.Primary.Attributes)
+	}
+	if rs.Deposed[0].Attributes["password"] != "<sensitive>" {
+		t.Fatalf("bad deposed: %#v", rs.Deposed[0].Attributes)
+	}
+
+	// The original must not be modified.
+	if state.RootModule().Resources["aws_instance.foo"].Primary.Attributes["password"] != "hunter2" {
+		t.Fatal("original was modified")
+	}
+	if state.RootModule().Outputs["secret"].Value != "hunter2" {
+		t.Fatal("original was modified")
+	}
+}
+
 func TestInstanceState_MergeDiff_nilDiff(t *testing.T) {
 	is := InstanceState{
 		ID: "foo",
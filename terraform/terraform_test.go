@@ -1597,6 +1597,25 @@ aws_instance.foo:
   num = 2
 `
 
+const testTerraformPlanReplaceStr = `
+DIFF:
+
+DESTROY/CREATE: aws_instance.foo
+  num:  "2" => "2"
+  type: "" => "aws_instance"
+
+STATE:
+
+aws_instance.bar:
+  ID = baz
+  foo = 2
+  type = aws_instance
+aws_instance.foo:
+  ID = bar
+  num = 2
+  type = aws_instance
+`
+
 const testTerraformPlanTaintIgnoreChangesStr = `
 DIFF:
 
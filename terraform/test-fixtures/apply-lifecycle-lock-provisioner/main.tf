@@ -0,0 +1,9 @@
+resource "aws_instance" "locked" {
+  foo = "bar"
+
+  provisioner "shell" {}
+
+  lifecycle {
+    lock = true
+  }
+}
@@ -0,0 +1,11 @@
+resource "aws_instance" "locked" {
+  foo = "bar"
+
+  lifecycle {
+    lock = true
+  }
+}
+
+resource "aws_instance" "unlocked" {
+  foo = "baz"
+}
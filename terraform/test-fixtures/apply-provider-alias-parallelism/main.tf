@@ -0,0 +1,9 @@
+provider "aws" {
+  alias       = "west"
+  parallelism = 1
+}
+
+resource "aws_instance" "foo" {
+  count    = 4
+  provider = "aws.west"
+}
@@ -0,0 +1,9 @@
+resource "aws_instance" "foo" {
+  foo         = "bar"
+  require_new = "new"
+
+  provisioner "shell" {
+    command = "echo ${self.foo} ${self.id}"
+    when    = "destroy"
+  }
+}
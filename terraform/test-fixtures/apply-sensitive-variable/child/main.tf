@@ -0,0 +1,7 @@
+variable "secret" {}
+variable "name" {}
+
+resource "aws_instance" "foo" {
+  foo  = "${var.secret}"
+  type = "${var.name}"
+}
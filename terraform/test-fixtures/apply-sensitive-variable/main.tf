@@ -0,0 +1,29 @@
+variable "password" {
+  sensitive = true
+}
+
+variable "name" {}
+
+locals {
+  credentials = "admin:${var.password}"
+}
+
+resource "aws_instance" "foo" {
+  foo  = "${local.credentials}"
+  type = "${var.name}"
+}
+
+module "child" {
+  source = "./child"
+
+  secret = "${var.password}"
+  name   = "${var.name}"
+}
+
+output "credentials" {
+  value = "${local.credentials}"
+}
+
+output "name" {
+  value = "${var.name}"
+}
@@ -0,0 +1,5 @@
+resource "foo_bar" "a" {
+}
+
+resource "bar_baz" "b" {
+}
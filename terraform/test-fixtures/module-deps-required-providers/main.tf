@@ -0,0 +1,17 @@
+terraform {
+  required_providers {
+    foo = {
+      source  = "example.com/acme/foo"
+      version = "~> 2.0"
+    }
+    baz = ">=1.0.0"
+  }
+}
+
+provider "foo" {
+  version = ">=2.1.0"
+}
+
+module "child" {
+  source = "./child"
+}
@@ -0,0 +1,21 @@
+resource "aws_instance" "foo" {
+  num = "2"
+}
+
+check "health" {
+  data "aws_data_source" "status" {
+    foo = "bar"
+  }
+
+  assert {
+    condition     = "${data.aws_data_source.status.foo == "bar"}"
+    error_message = "The status is wrong."
+  }
+}
+
+check "num" {
+  assert {
+    condition     = "${aws_instance.foo.num == 2}"
+    error_message = "The instance has the wrong number."
+  }
+}
@@ -0,0 +1,17 @@
+resource "aws_instance" "foo" {
+  num = "2"
+}
+
+check "num" {
+  assert {
+    condition     = "${aws_instance.foo.num == 2}"
+    error_message = "The instance has the wrong number."
+  }
+}
+
+check "id" {
+  assert {
+    condition     = "${aws_instance.foo.id == "bar"}"
+    error_message = "The instance has the wrong ID."
+  }
+}
@@ -0,0 +1,15 @@
+variable "region" {
+  default = "us-east-1"
+}
+
+variable "ami" {}
+
+locals {
+  name = "web-${var.region}"
+}
+
+resource "aws_instance" "foo" {
+  count = 2
+  ami   = "${var.ami}"
+  foo   = "${local.name}-${count.index}"
+}
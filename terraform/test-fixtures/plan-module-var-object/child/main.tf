@@ -0,0 +1,7 @@
+variable "settings" {
+  type = "object({name = string, port = optional(number, 5432)})"
+}
+
+resource "aws_instance" "foo" {
+  foo = "${var.settings["name"]}:${var.settings["port"]}"
+}
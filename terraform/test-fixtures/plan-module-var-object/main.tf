@@ -0,0 +1,15 @@
+variable "service" {
+  type = "object({name = string, port = optional(number, 80)})"
+}
+
+module "child" {
+  source = "./child"
+
+  settings = {
+    name = "db"
+  }
+}
+
+resource "aws_instance" "foo" {
+  foo = "${var.service["name"]}:${var.service["port"]}"
+}
@@ -0,0 +1,13 @@
+variable "zone" {}
+
+resource "aws_instance" "foo" {}
+
+module "child" {
+  source = "./child"
+  zone   = "${var.zone}"
+}
+
+module "computed" {
+  source = "./child"
+  zone   = "${aws_instance.foo.id}"
+}
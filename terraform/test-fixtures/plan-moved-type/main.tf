@@ -0,0 +1,8 @@
+resource "aws_instance" "foo" {
+  foo = "bar"
+}
+
+moved {
+  from = "old_instance.foo"
+  to   = "aws_instance.foo"
+}
@@ -0,0 +1,8 @@
+resource "aws_instance" "bar" {
+  foo = "bar"
+}
+
+moved {
+  from = "aws_instance.foo"
+  to   = "aws_instance.bar"
+}
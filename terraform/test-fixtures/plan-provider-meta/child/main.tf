@@ -0,0 +1,7 @@
+terraform {
+  provider_meta "aws" {
+    module_name = "child"
+  }
+}
+
+resource "aws_instance" "bar" {}
@@ -0,0 +1,15 @@
+terraform {
+  provider_meta "aws" {
+    module_name = "root"
+  }
+
+  provider_meta "google" {
+    module_name = "unused"
+  }
+}
+
+resource "aws_instance" "foo" {}
+
+module "child" {
+  source = "./child"
+}
@@ -0,0 +1,3 @@
+resource "aws_instance" "foo" {
+  foo = "bar"
+}
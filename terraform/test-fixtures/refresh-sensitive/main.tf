@@ -0,0 +1,9 @@
+resource "aws_instance" "foo" {}
+
+resource "aws_instance" "bar" {
+  foo = "${aws_instance.foo.secret}"
+}
+
+data "aws_data_source" "baz" {
+  foo = "${aws_instance.foo.secret}"
+}
@@ -0,0 +1,11 @@
+terraform {
+  required_providers {
+    aws = {
+      configuration_aliases = ["aws.baz"]
+    }
+  }
+}
+
+resource "aws_instance" "baz" {
+  provider = "aws.baz"
+}
@@ -0,0 +1,18 @@
+terraform {
+  required_providers {
+    aws = {
+      configuration_aliases = ["aws.bar"]
+    }
+  }
+}
+
+resource "aws_instance" "thing" {
+  provider = "aws.bar"
+}
+
+module "grandchild" {
+  source = "./grandchild"
+  providers = {
+    "aws.baz" = "aws.bar"
+  }
+}
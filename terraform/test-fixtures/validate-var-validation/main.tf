@@ -0,0 +1,8 @@
+variable "zone" {
+  default = "c"
+
+  validation {
+    condition     = "${contains(list("a", "b"), var.zone)}"
+    error_message = "The zone must be a or b."
+  }
+}
@@ -0,0 +1,36 @@
+package terraform
+
+import (
+	"github.com/hashicorp/terraform/config/module"
+)
+
+// CheckTransformer is a GraphTransformer that adds all the check blocks in
+// the configuration to the graph.
+type CheckTransformer struct {
+	Module *module.Tree
+}
+
+func (t *CheckTransformer) Transform(g *Graph) error {
+	return t.transform(g, t.Module)
+}
+
+func (t *CheckTransformer) transform(g *Graph, m *module.Tree) error {
+	if m == nil {
+		return nil
+	}
+
+	for _, c := range m.Children() {
+		if err := t.transform(g, c); err != nil {
+			return err
+		}
+	}
+
+	for _, c := range m.Config().Checks {
+		g.Add(&NodeCheck{
+			PathValue: normalizeModulePath(m.Path()),
+			Config:    c,
+		})
+	}
+
+	return nil
+}
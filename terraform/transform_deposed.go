@@ -100,10 +100,11 @@ func (n *graphNodeDeposedResource) EvalTree() EvalNode {
 					Index:  n.Index,
 				},
 				&EvalRefresh{
-					Info:     info,
-					Provider: &provider,
-					State:    &state,
-					Output:   &state,
+					ProviderName: n.ResolvedProvider,
+					Info:         info,
+					Provider:     &provider,
+					State:        &state,
+					Output:       &state,
 				},
 				&EvalWriteStateDeposed{
 					Name:         n.ResourceName,
@@ -144,12 +145,13 @@ func (n *graphNodeDeposedResource) EvalTree() EvalNode {
 					Diff:  &diff,
 				},
 				&EvalApply{
-					Info:     info,
-					State:    &state,
-					Diff:     &diff,
-					Provider: &provider,
-					Output:   &state,
-					Error:    &err,
+					ProviderName: n.ResolvedProvider,
+					Info:         info,
+					State:        &state,
+					Diff:         &diff,
+					Provider:     &provider,
+					Output:       &state,
+					Error:        &err,
 				},
 				// Always write the resource back to the state deposed... if it
 				// was successfully destroyed it will be pruned. If it was not, it will
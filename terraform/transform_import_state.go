@@ -81,10 +81,11 @@ func (n *graphNodeImportState) EvalTree() EvalNode {
 				Output: &provider,
 			},
 			&EvalImportState{
-				Provider: &provider,
-				Info:     info,
-				Id:       n.ID,
-				Output:   &n.states,
+				ProviderName: n.ResolvedProvider,
+				Provider:     &provider,
+				Info:         info,
+				Id:           n.ID,
+				Output:       &n.states,
 			},
 		},
 	}
@@ -227,10 +228,11 @@ func (n *graphNodeImportStateSub) EvalTree() EvalNode {
 				Output: &provider,
 			},
 			&EvalRefresh{
-				Provider: &provider,
-				State:    &state,
-				Info:     info,
-				Output:   &state,
+				ProviderName: n.ResolvedProvider,
+				Provider:     &provider,
+				State:        &state,
+				Info:         info,
+				Output:       &state,
 			},
 			&EvalImportStateVerify{
 				Info:  info,
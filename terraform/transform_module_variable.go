@@ -101,7 +101,9 @@ func (t *ModuleVariableTransformer) transformSingle(g *Graph, parent, m *module.
 			Module:    t.Module,
 		}
 
-		if !t.DisablePrune {
+		// Variables with validation rules are always included, since their
+		// values must be checked even if nothing uses them.
+		if !t.DisablePrune && len(v.Validations) == 0 {
 			// If the node is not referenced by anything, then we don't need
 			// to include it since it won't be used.
 			if matches := refMap.ReferencedBy(node); len(matches) == 0 {
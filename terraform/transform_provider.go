@@ -29,6 +29,8 @@ func TransformProviders(providers []string, concrete ConcreteProviderNodeFunc, m
 		&ProviderTransformer{},
 		// Remove unused providers and proxies
 		&PruneProviderTransformer{},
+		// Attach the metadata modules declare for their providers
+		&ProviderMetaTransformer{Module: mod},
 		// Connect provider to their parent provider nodes
 		&ParentProviderTransformer{},
 	)
@@ -547,19 +549,19 @@ func (t *ProviderConfigTransformer) addProxyProviders(g *Graph, m *module.Tree)
 		concreteProvider := t.providers[fullName]
 
 		// replace the concrete node with the provider passed in
-		if concreteProvider != nil && t.proxiable[fullName] {
-			g.Replace(concreteProvider, proxy)
-			t.providers[fullName] = proxy
-			continue
-		}
-
-		// aliased providers can't be implicitly passed in
-		if strings.Contains(name, ".") {
+		if concreteProvider != nil {
+			if t.proxiable[fullName] {
+				g.Replace(concreteProvider, proxy)
+				t.providers[fullName] = proxy
+			}
+			// Otherwise the module configures the provider itself, which
+			// is reported when the module tree is validated.
 			continue
 		}
 
-		// There was no concrete provider, so add this as an implicit provider.
-		// The extra proxy will be pruned later if it's unused.
+		// There was no concrete provider, so add this as an implicit provider,
+		// or as one of the module's configuration aliases. The extra proxy
+		// will be pruned later if it's unused.
 		g.Add(proxy)
 		t.providers[fullName] = proxy
 	}
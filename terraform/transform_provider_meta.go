@@ -0,0 +1,86 @@
+package terraform
+
+import (
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform/config/module"
+)
+
+// GraphNodeAttachProviderMeta is an interface that must be implemented by
+// provider nodes that want the provider_meta metadata of the modules whose
+// resources they manage attached.
+type GraphNodeAttachProviderMeta interface {
+	GraphNodeProvider
+
+	// Sets the metadata, ordered by module path
+	AttachProviderMeta([]*ProviderModuleMeta)
+}
+
+// ProviderMetaTransformer attaches the provider_meta metadata declared by
+// modules to the providers that manage their resources. It must run after
+// ProviderTransformer, which connects resources to their providers.
+type ProviderMetaTransformer struct {
+	Module *module.Tree
+}
+
+func (t *ProviderMetaTransformer) Transform(g *Graph) error {
+	if t.Module == nil {
+		return nil
+	}
+
+	for _, v := range g.Vertices() {
+		pn, ok := v.(GraphNodeAttachProviderMeta)
+		if !ok {
+			continue
+		}
+
+		typeName := strings.SplitN(pn.ProviderName(), ".", 2)[0]
+
+		// Find the modules whose resources use this provider
+		paths := make(map[string][]string)
+		for _, s := range g.UpEdges(v).List() {
+			if _, ok := s.(GraphNodeProviderConsumer); !ok {
+				continue
+			}
+			sp, ok := s.(GraphNodeSubPath)
+			if !ok {
+				continue
+			}
+			path := normalizeModulePath(sp.Path())
+			paths[strings.Join(path, ".")] = path
+		}
+
+		keys := make([]string, 0, len(paths))
+		for k := range paths {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		var metas []*ProviderModuleMeta
+		for _, k := range keys {
+			path := paths[k]
+			tree := t.Module.Child(path[1:])
+			if tree == nil || tree.Config().Terraform == nil {
+				continue
+			}
+
+			for _, m := range tree.Config().Terraform.ProviderMetas {
+				if m.Name != typeName {
+					continue
+				}
+
+				log.Printf("[TRACE] attaching provider_meta of %v to %s", path, pn.Name())
+				metas = append(metas, &ProviderModuleMeta{
+					ModulePath: path,
+					Config:     NewResourceConfig(m.RawConfig.Copy()),
+				})
+			}
+		}
+
+		pn.AttachProviderMeta(metas)
+	}
+
+	return nil
+}
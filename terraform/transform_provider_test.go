@@ -511,6 +511,43 @@ func TestProviderConfigTransformer_grandparentProviders(t *testing.T) {
 	}
 }
 
+// the configuration aliases of the child and grandchild modules are attached
+// to the provider passed down from the root module
+func TestProviderConfigTransformer_configurationAliases(t *testing.T) {
+	mod := testModule(t, "transform-provider-configuration-aliases")
+	concrete := func(a *NodeAbstractProvider) dag.Vertex { return a }
+
+	g := Graph{Path: RootModulePath}
+	{
+		tf := &ConfigTransformer{Module: mod}
+		if err := tf.Transform(&g); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+	}
+	{
+		tf := &AttachResourceConfigTransformer{Module: mod}
+		if err := tf.Transform(&g); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+	}
+	{
+		tf := TransformProviders([]string{"aws"}, concrete, mod)
+		if err := tf.Transform(&g); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+	}
+
+	actual := strings.TrimSpace(g.String())
+	expected := strings.TrimSpace(`module.child.aws_instance.thing
+  provider.aws.foo
+module.child.module.grandchild.aws_instance.baz
+  provider.aws.foo
+provider.aws.foo`)
+	if actual != expected {
+		t.Fatalf("expected:\n%s\n\ngot:\n%s", expected, actual)
+	}
+}
+
 // pass a specific provider into a module using it implicitly
 func TestProviderConfigTransformer_implicitModule(t *testing.T) {
 	mod := testModule(t, "transform-provider-implicit-module")
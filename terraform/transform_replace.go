@@ -0,0 +1,122 @@
+package terraform
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/hashicorp/terraform/config"
+)
+
+// GraphNodeReplaceable is an interface for graph nodes to implement when they
+// need to be told which resource instances the user requested be replaced.
+// As with GraphNodeTargetable, the list given contains every address
+// requested and each implementing node must filter it to those relevant.
+type GraphNodeReplaceable interface {
+	SetReplace([]ResourceAddress)
+}
+
+// ReplaceTransformer is a GraphTransformer that informs resource nodes of
+// the resource instances that the user requested be replaced, so that the
+// replacement can be planned even if the configuration hasn't changed.
+type ReplaceTransformer struct {
+	// List of resource addresses to replace, as specified by the user
+	Replace []string
+}
+
+func (t *ReplaceTransformer) Transform(g *Graph) error {
+	if len(t.Replace) == 0 {
+		return nil
+	}
+
+	addrs, err := parseReplaceAddrs(t.Replace)
+	if err != nil {
+		return err
+	}
+
+	for _, v := range g.Vertices() {
+		if rn, ok := v.(GraphNodeReplaceable); ok {
+			rn.SetReplace(addrs)
+		}
+	}
+
+	return nil
+}
+
+// parseReplaceAddrs parses the addresses of the resource instances the user
+// requested be replaced, each of which must be a managed resource.
+func parseReplaceAddrs(raw []string) ([]ResourceAddress, error) {
+	addrs := make([]ResourceAddress, len(raw))
+	for i, r := range raw {
+		addr, err := ParseResourceAddress(r)
+		if err != nil {
+			return nil, fmt.Errorf("invalid replace address %q: %s", r, err)
+		}
+		if addr.Type == "" || addr.Name == "" {
+			return nil, fmt.Errorf(
+				"invalid replace address %q: must refer to a resource", r)
+		}
+		if addr.Mode != config.ManagedResourceMode {
+			return nil, fmt.Errorf(
+				"invalid replace address %q: only managed resources can be replaced", r)
+		}
+		addrs[i] = *addr
+	}
+	return addrs, nil
+}
+
+// replaceAddrMatches returns true if the replace address r names exactly
+// the resource instance addr, rather than any instance it contains as
+// ResourceAddress.Equals would, so that replacing aws_instance.foo doesn't
+// replace every instance of a counted resource.
+//
+// The only instance of a resource with a count of 1 has no index, but can
+// also be named with an index of 0.
+func replaceAddrMatches(r, addr *ResourceAddress) bool {
+	pathMatch := len(r.Path) == 0 && len(addr.Path) == 0 ||
+		reflect.DeepEqual(r.Path, addr.Path)
+	indexMatch := r.Index == addr.Index ||
+		r.Index == 0 && addr.Index == -1
+
+	return pathMatch &&
+		indexMatch &&
+		r.Mode == addr.Mode &&
+		r.Type == addr.Type &&
+		r.Name == addr.Name
+}
+
+// checkReplaceState returns an error if any of the given replace addresses
+// doesn't match a resource instance in the state, since there is nothing
+// to replace and the address is most likely a mistake, such as leaving out
+// the index of an instance of a counted resource.
+func checkReplaceState(state *State, addrs []ResourceAddress) error {
+	var stateAddrs []*ResourceAddress
+	if state != nil {
+		for _, ms := range state.Modules {
+			for k := range ms.Resources {
+				addr, err := parseResourceAddressInternal(k)
+				if err != nil {
+					return err
+				}
+				addr.Path = ms.Path[1:]
+				stateAddrs = append(stateAddrs, addr)
+			}
+		}
+	}
+
+	for i := range addrs {
+		r := &addrs[i]
+		found := false
+		for _, addr := range stateAddrs {
+			if replaceAddrMatches(r, addr) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf(
+				"invalid replace address %q: no resource instance with this address is in the state", r)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,40 @@
+package terraform
+
+import (
+	"testing"
+)
+
+func TestReplaceAddrMatches(t *testing.T) {
+	cases := []struct {
+		Replace, Addr string
+		Match         bool
+	}{
+		{"aws_instance.foo", "aws_instance.foo", true},
+		{"aws_instance.foo[1]", "aws_instance.foo[1]", true},
+		{"aws_instance.foo[0]", "aws_instance.foo", true},
+		{"module.child.aws_instance.foo", "module.child.aws_instance.foo", true},
+
+		{"aws_instance.foo", "aws_instance.foo[0]", false},
+		{"aws_instance.foo", "aws_instance.foo[1]", false},
+		{"aws_instance.foo[1]", "aws_instance.foo[2]", false},
+		{"aws_instance.foo[1]", "aws_instance.foo", false},
+		{"aws_instance.foo", "aws_instance.bar", false},
+		{"aws_instance.foo", "module.child.aws_instance.foo", false},
+		{"module.child.aws_instance.foo", "aws_instance.foo", false},
+	}
+
+	for _, tc := range cases {
+		r, err := ParseResourceAddress(tc.Replace)
+		if err != nil {
+			t.Fatalf("%s: %s", tc.Replace, err)
+		}
+		addr, err := ParseResourceAddress(tc.Addr)
+		if err != nil {
+			t.Fatalf("%s: %s", tc.Addr, err)
+		}
+
+		if got := replaceAddrMatches(r, addr); got != tc.Match {
+			t.Errorf("%s matching %s: got %t, want %t", tc.Replace, tc.Addr, got, tc.Match)
+		}
+	}
+}
@@ -18,16 +18,36 @@ import (
 func Variables(
 	m *module.Tree,
 	override map[string]interface{}) (map[string]interface{}, error) {
-	result := make(map[string]interface{})
+	values, err := VariableValues(m, InputValuesFromMap(override, ValueFromUnknown, ""))
+	if err != nil {
+		return nil, err
+	}
+	return values.Values(), nil
+}
 
-	// Variables are loaded in the following sequence. Each additional step
-	// will override conflicting variable keys from prior steps:
-	//
-	//   * Take default values from config
-	//   * Take values from TF_VAR_x env vars
-	//   * Take values specified in the "override" param which is usually
-	//     from -var, -var-file, etc.
-	//
+// VariableValues returns the values of the root module variables of the
+// given module tree, along with where each was set.
+//
+// The values are taken from the following sources, each overriding the
+// values of the ones before it:
+//
+//   - The default values from the configuration
+//   - TF_VAR_x environment variables
+//   - The given values, which are usually from variable definitions files
+//     and -var flags, already in their order of precedence
+//
+// The values of variables that are maps are merged rather than replaced,
+// except for those declared as objects. The values of variables that the
+// configuration doesn't declare are ignored.
+//
+// The given module tree doesn't need to be loaded.
+func VariableValues(m *module.Tree, given InputValues) (InputValues, error) {
+	result := make(InputValues)
+	values := make(map[string]interface{})
+	set := func(k string, v interface{}, sourceType ValueSourceType, sourceName string) {
+		values[k] = v
+		result[k] = &InputValue{SourceType: sourceType, SourceName: sourceName}
+	}
 
 	// First load from the config
 	for _, v := range m.Config().Variables {
@@ -38,7 +58,7 @@ func Variables(
 
 		// If the type isn't a string, we use it as-is since it is a rich type
 		if v.Type() != config.VariableTypeString {
-			result[v.Name] = v.Default
+			set(v.Name, v.Default, ValueFromConfig, "")
 			continue
 		}
 
@@ -48,13 +68,13 @@ func Variables(
 			if typedDefault == "" {
 				continue
 			}
-			result[v.Name] = typedDefault
+			set(v.Name, typedDefault, ValueFromConfig, "")
 		case int, int64:
-			result[v.Name] = fmt.Sprintf("%d", typedDefault)
+			set(v.Name, fmt.Sprintf("%d", typedDefault), ValueFromConfig, "")
 		case float32, float64:
-			result[v.Name] = fmt.Sprintf("%f", typedDefault)
+			set(v.Name, fmt.Sprintf("%f", typedDefault), ValueFromConfig, "")
 		case bool:
-			result[v.Name] = fmt.Sprintf("%t", typedDefault)
+			set(v.Name, fmt.Sprintf("%t", typedDefault), ValueFromConfig, "")
 		default:
 			panic(fmt.Sprintf(
 				"Unknown default var type: %T\n\n"+
@@ -71,6 +91,7 @@ func Variables(
 
 		// Strip off the prefix and get the value after the first "="
 		idx := strings.Index(v, "=")
+		name := v[:idx]
 		k := v[len(VarEnvPrefix):idx]
 		v = v[idx+1:]
 
@@ -90,17 +111,23 @@ func Variables(
 
 			switch varType {
 			case config.VariableTypeMap:
-				if err := varSetMap(result, k, varVal); err != nil {
+				if isObjectVariable(schema) {
+					set(k, varVal, ValueFromEnvVar, name)
+					break
+				}
+				if err := varSetMap(values, k, varVal); err != nil {
 					return nil, err
 				}
+				result[k] = &InputValue{SourceType: ValueFromEnvVar, SourceName: name}
 			default:
-				result[k] = varVal
+				set(k, varVal, ValueFromEnvVar, name)
 			}
 		}
 	}
 
-	// Load from overrides
-	for k, v := range override {
+	// Load the given values
+	for k, iv := range given {
+		v := iv.Value
 		for _, schema := range m.Config().Variables {
 			if schema.Name != k {
 				continue
@@ -108,9 +135,13 @@ func Variables(
 
 			switch schema.Type() {
 			case config.VariableTypeList:
-				result[k] = v
+				values[k] = v
 			case config.VariableTypeMap:
-				if err := varSetMap(result, k, v); err != nil {
+				if isObjectVariable(schema) {
+					values[k] = v
+					break
+				}
+				if err := varSetMap(values, k, v); err != nil {
 					return nil, err
 				}
 			case config.VariableTypeString:
@@ -119,9 +150,9 @@ func Variables(
 				// any type errors.
 				var strVal string
 				if err := hilmapstructure.WeakDecode(v, &strVal); err == nil {
-					result[k] = strVal
+					values[k] = strVal
 				} else {
-					result[k] = v
+					values[k] = v
 				}
 			default:
 				panic(fmt.Sprintf(
@@ -129,15 +160,60 @@ func Variables(
 						"THIS IS A BUG. Please report it.",
 					schema.Type()))
 			}
+			result[k] = &InputValue{SourceType: iv.SourceType, SourceName: iv.SourceName}
+		}
+	}
+
+	// Convert the values to the type constraints of their variables, which
+	// sets the defaults of the optional attributes of objects.
+	for _, v := range m.Config().Variables {
+		if value, ok := values[v.Name]; ok {
+			values[v.Name] = convertVariable(v, value)
 		}
 	}
 
+	for k, v := range result {
+		v.Value = values[k]
+	}
 	return result, nil
 }
 
+// convertVariable returns a value of the variable v converted to the type
+// constraint of v. Values of variables without type constraints, and those
+// that don't convert, are returned as they are, for the type checks to
+// report.
+func convertVariable(v *config.Variable, value interface{}) interface{} {
+	t, err := v.TypeConstraint()
+	if err != nil || t == nil {
+		return value
+	}
+	converted, err := t.Convert(value)
+	if err != nil {
+		return value
+	}
+	return converted
+}
+
+// convertErrorString returns the message of an error converting a value of
+// the variable v, unless v is sensitive, since the message may quote the
+// value.
+func convertErrorString(v *config.Variable, err error) string {
+	if v.Sensitive {
+		return "the value is sensitive, so the reason isn't shown"
+	}
+	return err.Error()
+}
+
+// isObjectVariable returns true if v is declared as an object, whose
+// values replace each other rather than being merged like maps.
+func isObjectVariable(v *config.Variable) bool {
+	t, err := v.TypeConstraint()
+	return err == nil && t != nil && t.Kind == config.TypeKindObject
+}
+
 // varSetMap sets or merges the map in "v" with the key "k" in the
 // "current" set of variables. This is just a private function to remove
-// duplicate logic in Variables
+// duplicate logic in VariableValues
 func varSetMap(current map[string]interface{}, k string, v interface{}) error {
 	existing, ok := current[k]
 	if !ok {
@@ -3,6 +3,8 @@ package terraform
 import (
 	"reflect"
 	"testing"
+
+	"github.com/davecgh/go-spew/spew"
 )
 
 func TestVariables(t *testing.T) {
@@ -173,3 +175,51 @@ func TestVariables(t *testing.T) {
 		})
 	}
 }
+
+func TestVariableValues_sources(t *testing.T) {
+	defer tempEnv(t, "TF_VAR_a", "env")()
+	defer tempEnv(t, "TF_VAR_c", `{"foo" = "env", "bar" = "env"}`)()
+
+	m := testModule(t, "vars-basic")
+	given := InputValues{
+		"c": &InputValue{
+			Value:      map[string]interface{}{"foo": "file"},
+			SourceType: ValueFromNamedFile,
+			SourceName: "prod.tfvars",
+		},
+		"undeclared": &InputValue{Value: "foo", SourceType: ValueFromCLIArg},
+	}
+	got, err := VariableValues(m, given)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	want := InputValues{
+		"a": &InputValue{
+			Value:      "env",
+			SourceType: ValueFromEnvVar,
+			SourceName: "TF_VAR_a",
+		},
+		"b": &InputValue{
+			Value:      []interface{}{},
+			SourceType: ValueFromConfig,
+		},
+		"c": &InputValue{
+			Value:      map[string]interface{}{"foo": "file", "bar": "env"},
+			SourceType: ValueFromNamedFile,
+			SourceName: "prod.tfvars",
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong result\ngot:  %s\nwant: %s", spew.Sdump(got), spew.Sdump(want))
+	}
+
+	wantSources := map[string]string{
+		"a": "set by the environment variable TF_VAR_a",
+		"b": "the default value",
+		"c": "set in the file prod.tfvars, given by -var-file",
+	}
+	if got := got.Sources(); !reflect.DeepEqual(got, wantSources) {
+		t.Fatalf("wrong sources\ngot:  %#v\nwant: %#v", got, wantSources)
+	}
+}
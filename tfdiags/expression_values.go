@@ -0,0 +1,75 @@
+package tfdiags
+
+// ExpressionValue describes the value of something that the expression a
+// diagnostic is about refers to, such as a variable, so that the reader can
+// see why the expression failed without looking it up themselves.
+type ExpressionValue struct {
+	// Traversal is the reference as it's written in the configuration,
+	// such as "var.ami", and Statement what's known about its value, such
+	// as "is null".
+	Traversal string `json:"traversal"`
+	Statement string `json:"statement"`
+}
+
+// ExpressionValuesDiagnostic is implemented by diagnostics that know the
+// values of what their expression refers to.
+type ExpressionValuesDiagnostic interface {
+	Diagnostic
+
+	ExpressionValues() []ExpressionValue
+}
+
+// WithExpressionValues returns a diagnostic like diag that also describes
+// the given values, after the ones that diag already describes. Values for
+// a traversal that diag already describes are ignored.
+func WithExpressionValues(diag Diagnostic, values ...ExpressionValue) Diagnostic {
+	existing := ExpressionValues(diag)
+	seen := make(map[string]bool, len(existing))
+	for _, v := range existing {
+		seen[v.Traversal] = true
+	}
+
+	all := append([]ExpressionValue(nil), existing...)
+	for _, v := range values {
+		if seen[v.Traversal] {
+			continue
+		}
+		seen[v.Traversal] = true
+		all = append(all, v)
+	}
+
+	if len(all) == len(existing) {
+		return diag
+	}
+	if wrapped, ok := diag.(expressionValuesDiagnostic); ok {
+		diag = wrapped.Diagnostic
+	}
+	return expressionValuesDiagnostic{Diagnostic: diag, values: all}
+}
+
+// ExpressionValues returns the values that diag describes, or nil if it's
+// not an ExpressionValuesDiagnostic.
+func ExpressionValues(diag Diagnostic) []ExpressionValue {
+	if d, ok := diag.(ExpressionValuesDiagnostic); ok {
+		return d.ExpressionValues()
+	}
+	return nil
+}
+
+type expressionValuesDiagnostic struct {
+	Diagnostic
+	values []ExpressionValue
+}
+
+var _ ExpressionValuesDiagnostic = expressionValuesDiagnostic{}
+var _ ExtraDiagnostic = expressionValuesDiagnostic{}
+
+func (d expressionValuesDiagnostic) ExpressionValues() []ExpressionValue {
+	return d.values
+}
+
+// Extra returns the structured information of the wrapped diagnostic, so
+// that wrapping a diagnostic doesn't lose it.
+func (d expressionValuesDiagnostic) Extra() Extra {
+	return GetExtra(d.Diagnostic)
+}
@@ -0,0 +1,32 @@
+package tfdiags
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWithExpressionValues(t *testing.T) {
+	diag := SimpleWarning("careful")
+	if got := ExpressionValues(diag); got != nil {
+		t.Fatalf("unexpected values %#v", got)
+	}
+	if got := WithExpressionValues(diag); got != diag {
+		t.Fatalf("diagnostic without new values was wrapped: %#v", got)
+	}
+
+	a := ExpressionValue{Traversal: "var.a", Statement: "is \"a\""}
+	b := ExpressionValue{Traversal: "var.b", Statement: "is \"b\""}
+	other := ExpressionValue{Traversal: "var.a", Statement: "is \"other\""}
+
+	diag = WithExpressionValues(diag, a)
+	diag = WithExpressionValues(diag, other, b)
+	if got, want := ExpressionValues(diag), []ExpressionValue{a, b}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong values\ngot:  %#v\nwant: %#v", got, want)
+	}
+	if got, want := diag.Description().Summary, "careful"; got != want {
+		t.Fatalf("wrong summary %q; want %q", got, want)
+	}
+	if _, ok := diag.(expressionValuesDiagnostic).Diagnostic.(expressionValuesDiagnostic); ok {
+		t.Fatal("diagnostic was wrapped twice")
+	}
+}
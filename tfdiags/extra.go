@@ -0,0 +1,133 @@
+package tfdiags
+
+// Code is a stable, machine-readable identifier of a class of diagnostic,
+// for automation to branch on rather than on the wording of the summary.
+// Codes are part of the interface of Terraform's JSON output, so they may
+// be added but must not be changed or reused.
+type Code string
+
+const (
+	// CodeConfigInvalid is a configuration that Terraform itself finds
+	// invalid, such as a duplicate declaration or an argument with a value
+	// of the wrong kind.
+	CodeConfigInvalid Code = "config_invalid"
+
+	// CodeVariableInvalid is a value of an input variable that isn't
+	// valid, because it's missing, of the wrong type, or fails one of the
+	// variable's validation rules.
+	CodeVariableInvalid Code = "variable_invalid"
+
+	// CodeObjectInvalid is a configuration of a resource, data source,
+	// provider or provisioner that was found invalid when validating the
+	// object, mostly by its plugin, such as a resource missing a required
+	// argument. Its address is that of the object.
+	CodeObjectInvalid Code = "object_invalid"
+)
+
+// Extra is the structured information about a diagnostic, beyond its
+// description, that isn't meant to be read by people but by automation.
+// Any of its fields may be empty.
+type Extra struct {
+	Code Code
+
+	// Address is the address of the object the diagnostic is about, such
+	// as "aws_instance.web", "module.network" or "var.region".
+	Address string
+
+	// AttributePath is the path of the attribute of that object that the
+	// diagnostic is about, in the flattened form of the state, such as
+	// "count" or "ebs_block_device.0.volume_size".
+	AttributePath string
+}
+
+// ExtraDiagnostic is implemented by diagnostics that have structured
+// information beyond their description.
+type ExtraDiagnostic interface {
+	Diagnostic
+
+	Extra() Extra
+}
+
+// WithExtra returns a diagnostic like diag whose structured information is
+// that of diag, with the fields that are set in extra replacing those of
+// diag.
+func WithExtra(diag Diagnostic, extra Extra) Diagnostic {
+	merged := GetExtra(diag)
+	if extra.Code != "" {
+		merged.Code = extra.Code
+	}
+	if extra.Address != "" {
+		merged.Address = extra.Address
+	}
+	if extra.AttributePath != "" {
+		merged.AttributePath = extra.AttributePath
+	}
+
+	if wrapped, ok := diag.(extraDiagnostic); ok {
+		diag = wrapped.Diagnostic
+	}
+	return extraDiagnostic{Diagnostic: diag, extra: merged}
+}
+
+// WithCode returns a diagnostic like diag with the given code, unless diag
+// already has a code, since the most specific code is given to a diagnostic
+// where it's created.
+func WithCode(diag Diagnostic, code Code) Diagnostic {
+	if GetExtra(diag).Code != "" {
+		return diag
+	}
+	return WithExtra(diag, Extra{Code: code})
+}
+
+// WithCode returns the diagnostics with the given code given to each of
+// them that doesn't have one, as WithCode does.
+func (diags Diagnostics) WithCode(code Code) Diagnostics {
+	if len(diags) == 0 {
+		return diags
+	}
+	ret := make(Diagnostics, len(diags))
+	for i, diag := range diags {
+		ret[i] = WithCode(diag, code)
+	}
+	return ret
+}
+
+// WithExtra returns the diagnostics with the fields that are set in extra
+// given to each of them, as WithExtra does.
+func (diags Diagnostics) WithExtra(extra Extra) Diagnostics {
+	if len(diags) == 0 {
+		return diags
+	}
+	ret := make(Diagnostics, len(diags))
+	for i, diag := range diags {
+		ret[i] = WithExtra(diag, extra)
+	}
+	return ret
+}
+
+// GetExtra returns the structured information of diag, which is empty if
+// it's not an ExtraDiagnostic.
+func GetExtra(diag Diagnostic) Extra {
+	if d, ok := diag.(ExtraDiagnostic); ok {
+		return d.Extra()
+	}
+	return Extra{}
+}
+
+type extraDiagnostic struct {
+	Diagnostic
+	extra Extra
+}
+
+var _ ExtraDiagnostic = extraDiagnostic{}
+var _ ExpressionValuesDiagnostic = extraDiagnostic{}
+
+func (d extraDiagnostic) Extra() Extra {
+	return d.extra
+}
+
+// ExpressionValues returns the values of the wrapped diagnostic, so that
+// wrapping a diagnostic doesn't lose them.
+func (d extraDiagnostic) ExpressionValues() []ExpressionValue {
+	return ExpressionValues(d.Diagnostic)
+}
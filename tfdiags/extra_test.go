@@ -0,0 +1,82 @@
+package tfdiags
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestWithExtra(t *testing.T) {
+	diag := SimpleWarning("careful")
+	if got := GetExtra(diag); got != (Extra{}) {
+		t.Fatalf("unexpected extra %#v", got)
+	}
+
+	diag = WithExtra(diag, Extra{Code: CodeConfigInvalid, Address: "aws_instance.foo"})
+	diag = WithExtra(diag, Extra{AttributePath: "count"})
+	want := Extra{Code: CodeConfigInvalid, Address: "aws_instance.foo", AttributePath: "count"}
+	if got := GetExtra(diag); got != want {
+		t.Fatalf("wrong extra\ngot:  %#v\nwant: %#v", got, want)
+	}
+	if got, want := diag.Description().Summary, "careful"; got != want {
+		t.Fatalf("wrong summary %q; want %q", got, want)
+	}
+	if _, ok := diag.(extraDiagnostic).Diagnostic.(extraDiagnostic); ok {
+		t.Fatal("diagnostic was wrapped twice")
+	}
+}
+
+func TestWithCode(t *testing.T) {
+	var diags Diagnostics
+	diags = diags.Append(errors.New("a"), WithCode(SimpleWarning("b"), CodeVariableInvalid))
+	diags = diags.WithCode(CodeConfigInvalid)
+
+	if got := GetExtra(diags[0]).Code; got != CodeConfigInvalid {
+		t.Fatalf("wrong code %q", got)
+	}
+	// The code given where the diagnostic was created is kept.
+	if got := GetExtra(diags[1]).Code; got != CodeVariableInvalid {
+		t.Fatalf("wrong code %q", got)
+	}
+}
+
+func TestWithExtra_expressionValues(t *testing.T) {
+	v := ExpressionValue{Traversal: "var.a", Statement: "is \"a\""}
+	extra := Extra{Code: CodeObjectInvalid, Address: "aws_instance.foo"}
+
+	// Neither wrapper hides what the other adds, in either order.
+	for _, diag := range []Diagnostic{
+		WithExtra(WithExpressionValues(SimpleWarning("careful"), v), extra),
+		WithExpressionValues(WithExtra(SimpleWarning("careful"), extra), v),
+	} {
+		if got := GetExtra(diag); got != extra {
+			t.Fatalf("wrong extra %#v", got)
+		}
+		if got := ExpressionValues(diag); !reflect.DeepEqual(got, []ExpressionValue{v}) {
+			t.Fatalf("wrong values %#v", got)
+		}
+	}
+}
+
+func TestWithExtra_rpcFriendly(t *testing.T) {
+	var diags Diagnostics
+	diags = diags.Append(WithExtra(SimpleWarning("careful"), Extra{
+		Code:    CodeObjectInvalid,
+		Address: "aws_instance.foo",
+	}))
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(diags.ForRPC()); err != nil {
+		t.Fatalf("error encoding: %s", err)
+	}
+	var got Diagnostics
+	if err := gob.NewDecoder(&buf).Decode(&got); err != nil {
+		t.Fatalf("error decoding: %s", err)
+	}
+
+	if got, want := GetExtra(got[0]), GetExtra(diags[0]); got != want {
+		t.Fatalf("wrong extra\ngot:  %#v\nwant: %#v", got, want)
+	}
+}
@@ -0,0 +1,165 @@
+package tfdiags
+
+import (
+	"bytes"
+)
+
+// JSONDiagnostic is the representation of a Diagnostic in the
+// machine-readable output of commands, such as "terraform validate -json".
+// Its structure is part of the interface of those commands, so fields may
+// be added to it but must not be removed or changed.
+type JSONDiagnostic struct {
+	// Severity is either "error" or "warning".
+	Severity string `json:"severity"`
+
+	Summary string `json:"summary"`
+	Detail  string `json:"detail"`
+
+	// Code is the class of the diagnostic, if it has one, and Address and
+	// AttributePath are what it's about, if they're known. See Extra.
+	Code          Code   `json:"code,omitempty"`
+	Address       string `json:"address,omitempty"`
+	AttributePath string `json:"attribute_path,omitempty"`
+
+	// Range is the part of the configuration the diagnostic is about, if
+	// any, and Snippet the source code of that part when it's available.
+	Range   *JSONRange   `json:"range,omitempty"`
+	Snippet *JSONSnippet `json:"snippet,omitempty"`
+}
+
+// JSONRange is a range of a source file, from its start to its end.
+type JSONRange struct {
+	Filename string  `json:"filename"`
+	Start    JSONPos `json:"start"`
+	End      JSONPos `json:"end"`
+}
+
+// JSONPos is a position in a source file. Lines and columns start at 1,
+// while the byte offset starts at 0.
+type JSONPos struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+	Byte   int `json:"byte"`
+}
+
+// JSONSnippet is the source code of the lines covered by the range of a
+// diagnostic.
+type JSONSnippet struct {
+	// Code holds the lines, without their final newline, and StartLine is
+	// the number of the first one.
+	Code      string `json:"code"`
+	StartLine int    `json:"start_line"`
+
+	// HighlightStartOffset and HighlightEndOffset are the offsets in bytes
+	// within Code of the range itself.
+	HighlightStartOffset int `json:"highlight_start_offset"`
+	HighlightEndOffset   int `json:"highlight_end_offset"`
+
+	// Values are the values of what the highlighted expression refers to,
+	// as far as they're known.
+	Values []ExpressionValue `json:"values,omitempty"`
+}
+
+// NewJSONDiagnostic returns the JSON representation of a diagnostic. The
+// sources map the name of each source file to its content, to include a
+// snippet of the source code the diagnostic is about. It may be nil, or
+// lack some files, in which case no snippet is included.
+func NewJSONDiagnostic(diag Diagnostic, sources map[string][]byte) *JSONDiagnostic {
+	desc := diag.Description()
+	extra := GetExtra(diag)
+	ret := &JSONDiagnostic{
+		Summary:       desc.Summary,
+		Detail:        desc.Detail,
+		Code:          extra.Code,
+		Address:       extra.Address,
+		AttributePath: extra.AttributePath,
+	}
+
+	switch diag.Severity() {
+	case Warning:
+		ret.Severity = "warning"
+	default:
+		ret.Severity = "error"
+	}
+
+	subject := diag.Source().Subject
+	if subject == nil {
+		return ret
+	}
+
+	ret.Range = &JSONRange{
+		Filename: subject.Filename,
+		Start:    JSONPos(subject.Start),
+		End:      JSONPos(subject.End),
+	}
+
+	if src, ok := sources[subject.Filename]; ok {
+		ret.Snippet = Snippet(diag, src)
+	}
+
+	return ret
+}
+
+// JSON returns the JSON representation of every diagnostic, as returned by
+// NewJSONDiagnostic. The result is never nil, so that it's encoded as an
+// empty list when there are no diagnostics.
+func (diags Diagnostics) JSON(sources map[string][]byte) []*JSONDiagnostic {
+	ret := make([]*JSONDiagnostic, 0, len(diags))
+	for _, diag := range diags {
+		ret = append(ret, NewJSONDiagnostic(diag, sources))
+	}
+	return ret
+}
+
+// Snippet returns the snippet of source code that diag is about, as in its
+// JSON representation, or nil if it has no source range or src doesn't
+// cover it.
+func Snippet(diag Diagnostic, src []byte) *JSONSnippet {
+	subject := diag.Source().Subject
+	if subject == nil {
+		return nil
+	}
+	ret := newJSONSnippet(src, *subject)
+	if ret != nil {
+		ret.Values = ExpressionValues(diag)
+	}
+	return ret
+}
+
+// newJSONSnippet extracts the lines of src covered by rng, or returns nil if
+// the range doesn't fit in the source.
+func newJSONSnippet(src []byte, rng SourceRange) *JSONSnippet {
+	if rng.Start.Line < 1 || rng.End.Line < rng.Start.Line {
+		return nil
+	}
+
+	lines := bytes.Split(src, []byte{'\n'})
+	if rng.End.Line > len(lines) {
+		return nil
+	}
+
+	// the offset in src of the first line of the snippet
+	offset := 0
+	for _, line := range lines[:rng.Start.Line-1] {
+		offset += len(line) + 1
+	}
+
+	code := string(bytes.Join(lines[rng.Start.Line-1:rng.End.Line], []byte{'\n'}))
+	ret := &JSONSnippet{
+		Code:                 code,
+		StartLine:            rng.Start.Line,
+		HighlightStartOffset: rng.Start.Byte - offset,
+		HighlightEndOffset:   rng.End.Byte - offset,
+	}
+
+	// Not every range has byte offsets, so fall back on the columns.
+	if rng.Start.Byte < offset || rng.End.Byte < rng.Start.Byte || rng.End.Byte-offset > len(code) {
+		ret.HighlightStartOffset = rng.Start.Column - 1
+		ret.HighlightEndOffset = len(code) - len(lines[rng.End.Line-1]) + rng.End.Column - 1
+		if ret.HighlightStartOffset < 0 || ret.HighlightEndOffset < ret.HighlightStartOffset || ret.HighlightEndOffset > len(code) {
+			ret.HighlightStartOffset, ret.HighlightEndOffset = 0, len(code)
+		}
+	}
+
+	return ret
+}
@@ -0,0 +1,172 @@
+package tfdiags
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/hashicorp/hcl2/hcl"
+)
+
+func TestNewJSONDiagnostic(t *testing.T) {
+	src := []byte("resource \"a\" \"b\" {\n  foo = \"bar\"\n  baz = 1\n}\n")
+	sources := map[string][]byte{"main.tf": src}
+
+	tests := map[string]struct {
+		Diag interface{}
+		Want *JSONDiagnostic
+	}{
+		"error": {
+			errors.New("oh no"),
+			&JSONDiagnostic{
+				Severity: "error",
+				Summary:  "oh no",
+			},
+		},
+		"warning": {
+			SimpleWarning("careful"),
+			&JSONDiagnostic{
+				Severity: "warning",
+				Summary:  "careful",
+			},
+		},
+		"with extra": {
+			WithExtra(nativeError{errors.New("oh no")}, Extra{
+				Code:          CodeObjectInvalid,
+				Address:       "aws_instance.foo",
+				AttributePath: "count",
+			}),
+			&JSONDiagnostic{
+				Severity:      "error",
+				Summary:       "oh no",
+				Code:          CodeObjectInvalid,
+				Address:       "aws_instance.foo",
+				AttributePath: "count",
+			},
+		},
+		"with snippet": {
+			&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Unsupported argument",
+				Detail:   "An argument named \"foo\" is not expected here.",
+				Subject: &hcl.Range{
+					Filename: "main.tf",
+					Start:    hcl.Pos{Line: 2, Column: 3, Byte: 21},
+					End:      hcl.Pos{Line: 2, Column: 6, Byte: 24},
+				},
+			},
+			&JSONDiagnostic{
+				Severity: "error",
+				Summary:  "Unsupported argument",
+				Detail:   "An argument named \"foo\" is not expected here.",
+				Range: &JSONRange{
+					Filename: "main.tf",
+					Start:    JSONPos{Line: 2, Column: 3, Byte: 21},
+					End:      JSONPos{Line: 2, Column: 6, Byte: 24},
+				},
+				Snippet: &JSONSnippet{
+					Code:                 "  foo = \"bar\"",
+					StartLine:            2,
+					HighlightStartOffset: 2,
+					HighlightEndOffset:   5,
+				},
+			},
+		},
+		"multiline without byte offsets": {
+			&hcl.Diagnostic{
+				Severity: hcl.DiagWarning,
+				Summary:  "Deprecated",
+				Subject: &hcl.Range{
+					Filename: "main.tf",
+					Start:    hcl.Pos{Line: 2, Column: 3},
+					End:      hcl.Pos{Line: 3, Column: 6},
+				},
+			},
+			&JSONDiagnostic{
+				Severity: "warning",
+				Summary:  "Deprecated",
+				Range: &JSONRange{
+					Filename: "main.tf",
+					Start:    JSONPos{Line: 2, Column: 3},
+					End:      JSONPos{Line: 3, Column: 6},
+				},
+				Snippet: &JSONSnippet{
+					Code:                 "  foo = \"bar\"\n  baz = 1",
+					StartLine:            2,
+					HighlightStartOffset: 2,
+					HighlightEndOffset:   19,
+				},
+			},
+		},
+		"with values": {
+			WithExpressionValues(hclDiagnostic{&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid value",
+				Subject: &hcl.Range{
+					Filename: "main.tf",
+					Start:    hcl.Pos{Line: 3, Column: 9, Byte: 42},
+					End:      hcl.Pos{Line: 3, Column: 10, Byte: 43},
+				},
+			}}, ExpressionValue{Traversal: "var.baz", Statement: "is \"1\""}),
+			&JSONDiagnostic{
+				Severity: "error",
+				Summary:  "Invalid value",
+				Range: &JSONRange{
+					Filename: "main.tf",
+					Start:    JSONPos{Line: 3, Column: 9, Byte: 42},
+					End:      JSONPos{Line: 3, Column: 10, Byte: 43},
+				},
+				Snippet: &JSONSnippet{
+					Code:                 "  baz = 1",
+					StartLine:            3,
+					HighlightStartOffset: 8,
+					HighlightEndOffset:   9,
+					Values: []ExpressionValue{
+						{Traversal: "var.baz", Statement: "is \"1\""},
+					},
+				},
+			},
+		},
+		"missing source": {
+			&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Bad",
+				Subject: &hcl.Range{
+					Filename: "other.tf",
+					Start:    hcl.Pos{Line: 1, Column: 1},
+					End:      hcl.Pos{Line: 1, Column: 2, Byte: 1},
+				},
+			},
+			&JSONDiagnostic{
+				Severity: "error",
+				Summary:  "Bad",
+				Range: &JSONRange{
+					Filename: "other.tf",
+					Start:    JSONPos{Line: 1, Column: 1},
+					End:      JSONPos{Line: 1, Column: 2, Byte: 1},
+				},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var diags Diagnostics
+			diags = diags.Append(test.Diag)
+
+			got := NewJSONDiagnostic(diags[0], sources)
+			if !reflect.DeepEqual(got, test.Want) {
+				t.Errorf("wrong result\ngot: %swant: %s", spew.Sdump(got), spew.Sdump(test.Want))
+			}
+		})
+	}
+}
+
+func TestDiagnosticsJSON_empty(t *testing.T) {
+	var diags Diagnostics
+	got := diags.JSON(nil)
+	if got == nil || len(got) != 0 {
+		t.Fatalf("expected an empty list, got %#v", got)
+	}
+}